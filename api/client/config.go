@@ -0,0 +1,61 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package client
+
+import (
+	"time"
+)
+
+// DefaultConfig is the default configuration for a set of DPS API replicas.
+var DefaultConfig = Config{
+	HedgeDelay: 0,
+	Hedges:     1,
+	Cooldown:   10 * time.Second,
+}
+
+// Config is the configuration for a set of DPS API replicas.
+type Config struct {
+	HedgeDelay time.Duration
+	Hedges     uint
+	Cooldown   time.Duration
+}
+
+// Option is a function that can be applied to a Config.
+type Option func(*Config)
+
+// WithHedgeDelay sets the delay after which a request that did not complete yet
+// is also sent to the next replica, so that a single slow replica does not
+// delay the request. Zero disables hedging.
+func WithHedgeDelay(delay time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.HedgeDelay = delay
+	}
+}
+
+// WithHedges sets the maximum number of hedged requests that are sent for each
+// request, in addition to the original one.
+func WithHedges(hedges uint) Option {
+	return func(cfg *Config) {
+		cfg.Hedges = hedges
+	}
+}
+
+// WithCooldown sets the duration for which a replica that was unavailable is
+// only used after all other replicas.
+func WithCooldown(cooldown time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.Cooldown = cooldown
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Replicas is a client connection to a set of DPS API servers that serve the
+// same index. It routes each request to the replica with the lowest observed
+// latency, fails over to the next one when a replica is unavailable, and can
+// hedge requests that take longer than a given delay by also sending them to
+// the next replica, using the response that arrives first. It can be given to
+// `dps.NewAPIClient` in place of a single connection. Streaming requests are
+// routed in the same way, but they are neither retried nor hedged.
+type Replicas struct {
+	cfg      Config
+	mutex    *sync.Mutex
+	replicas []*replica
+}
+
+// replica keeps track of the connection to a single replica, of its average
+// latency and of when it was last unavailable.
+type replica struct {
+	conn    grpc.ClientConnInterface
+	latency time.Duration
+	failed  time.Time
+}
+
+// NewReplicas returns a new client connection to the replicas that the given
+// connections lead to.
+func NewReplicas(conns []grpc.ClientConnInterface, options ...Option) (*Replicas, error) {
+
+	if len(conns) == 0 {
+		return nil, fmt.Errorf("no connections to replicas given")
+	}
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	replicas := make([]*replica, 0, len(conns))
+	for _, conn := range conns {
+		replicas = append(replicas, &replica{conn: conn})
+	}
+
+	r := Replicas{
+		cfg:      cfg,
+		mutex:    &sync.Mutex{},
+		replicas: replicas,
+	}
+
+	return &r, nil
+}
+
+// result is the outcome of a request that was sent to a replica.
+type result struct {
+	reply interface{}
+	err   error
+}
+
+// Invoke sends a unary request to the replicas, and writes the first successful
+// response into the given reply. Requests that fail because a replica is
+// unavailable are sent to the next one right away.
+func (r *Replicas) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+
+	// Hedged requests need a reply of their own each, so they are only possible
+	// for protobuf messages, which all requests of the DPS API use.
+	message, ok := reply.(proto.Message)
+	if !ok {
+		return r.invoke(ctx, r.rank()[0], method, args, reply, opts...)
+	}
+
+	// Once we have a response, the requests that are still in flight are
+	// canceled.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	order := r.rank()
+	results := make(chan result, len(order))
+	sent := 0
+	send := func() {
+		replica := order[sent]
+		sent++
+		out := message.ProtoReflect().New().Interface()
+		go func() {
+			err := r.invoke(ctx, replica, method, args, out, opts...)
+			results <- result{reply: out, err: err}
+		}()
+	}
+
+	send()
+	pending := 1
+	hedges := uint(0)
+	var err error
+	for {
+
+		var timer *time.Timer
+		var hedge <-chan time.Time
+		if r.cfg.HedgeDelay > 0 && hedges < r.cfg.Hedges && sent < len(order) {
+			timer = time.NewTimer(r.cfg.HedgeDelay)
+			hedge = timer.C
+		}
+
+		select {
+
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				proto.Reset(message)
+				proto.Merge(message, res.reply.(proto.Message))
+				return nil
+			}
+			if !unavailable(res.err) {
+				return res.err
+			}
+			err = res.err
+			if sent < len(order) {
+				send()
+				pending++
+			}
+
+		case <-hedge:
+			send()
+			pending++
+			hedges++
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+		if pending == 0 {
+			return err
+		}
+	}
+}
+
+// NewStream opens a stream on the replica with the lowest observed latency.
+func (r *Replicas) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	replica := r.rank()[0]
+	stream, err := replica.conn.NewStream(ctx, desc, method, opts...)
+	if unavailable(err) {
+		r.fail(replica)
+	}
+	return stream, err
+}
+
+// rank returns the replicas in the order in which requests are sent to them.
+// Replicas that were recently unavailable come last, and the others are sorted
+// by their average latency. Replicas that did not serve any request yet have
+// no latency, so they are tried first.
+func (r *Replicas) rank() []*replica {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	cooling := func(replica *replica) bool {
+		return now.Sub(replica.failed) < r.cfg.Cooldown
+	}
+
+	order := make([]*replica, len(r.replicas))
+	copy(order, r.replicas)
+	sort.SliceStable(order, func(i int, j int) bool {
+		ci, cj := cooling(order[i]), cooling(order[j])
+		if ci != cj {
+			return cj
+		}
+		return order[i].latency < order[j].latency
+	})
+
+	return order
+}
+
+// observe updates the average latency of the given replica with the latency
+// of a request that it served.
+func (r *Replicas) observe(replica *replica, latency time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if replica.latency == 0 {
+		replica.latency = latency
+		return
+	}
+	replica.latency += (latency - replica.latency) / 4
+}
+
+// fail records that the given replica was unavailable.
+func (r *Replicas) fail(replica *replica) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	replica.failed = time.Now()
+}
+
+// invoke sends a unary request to the given replica, and records its latency
+// or its unavailability. Requests that fail otherwise, for example because
+// they were canceled once another replica responded, are not recorded.
+func (r *Replicas) invoke(ctx context.Context, replica *replica, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+
+	start := time.Now()
+	err := replica.conn.Invoke(ctx, method, args, reply, opts...)
+	switch {
+	case err == nil:
+		r.observe(replica, time.Since(start))
+	case unavailable(err):
+		r.fail(replica)
+	}
+
+	return err
+}
+
+// unavailable returns whether the given error means that the replica could not
+// serve the request, in which case another replica might be able to.
+func unavailable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
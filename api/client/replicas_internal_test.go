@@ -0,0 +1,224 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package client
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestReplicas_Invoke(t *testing.T) {
+
+	// serve returns a connection to a replica that responds with the given
+	// value after the given delay, and counts the requests it receives.
+	serve := func(t *testing.T, value uint64, delay time.Duration, calls *int32) *mocks.ClientConn {
+		t.Helper()
+
+		conn := mocks.BaselineClientConn(t)
+		conn.InvokeFunc = func(ctx context.Context, _ string, _ interface{}, reply interface{}, _ ...grpc.CallOption) error {
+			atomic.AddInt32(calls, 1)
+			select {
+			case <-ctx.Done():
+				return status.FromContextError(ctx.Err()).Err()
+			case <-time.After(delay):
+			}
+			reply.(*wrapperspb.UInt64Value).Value = value
+			return nil
+		}
+
+		return conn
+	}
+
+	// fail returns a connection to a replica that fails with the given code,
+	// and counts the requests it receives.
+	fail := func(t *testing.T, code codes.Code, calls *int32) *mocks.ClientConn {
+		t.Helper()
+
+		conn := mocks.BaselineClientConn(t)
+		conn.InvokeFunc = func(context.Context, string, interface{}, interface{}, ...grpc.CallOption) error {
+			atomic.AddInt32(calls, 1)
+			return status.Error(code, "dummy error")
+		}
+
+		return conn
+	}
+
+	t.Run("routes to replica with lowest latency", func(t *testing.T) {
+		t.Parallel()
+
+		var slow, fast int32
+		r, err := NewReplicas([]grpc.ClientConnInterface{
+			serve(t, 1, 0, &slow),
+			serve(t, 2, 0, &fast),
+		})
+		require.NoError(t, err)
+		r.replicas[0].latency = time.Second
+		r.replicas[1].latency = time.Millisecond
+
+		var reply wrapperspb.UInt64Value
+		err = r.Invoke(context.Background(), "method", nil, &reply)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), reply.Value)
+		assert.Zero(t, atomic.LoadInt32(&slow))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&fast))
+	})
+
+	t.Run("fails over when replica is unavailable", func(t *testing.T) {
+		t.Parallel()
+
+		var down, up int32
+		r, err := NewReplicas([]grpc.ClientConnInterface{
+			fail(t, codes.Unavailable, &down),
+			serve(t, 2, 0, &up),
+		})
+		require.NoError(t, err)
+
+		var reply wrapperspb.UInt64Value
+		err = r.Invoke(context.Background(), "method", nil, &reply)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), reply.Value)
+		assert.False(t, r.replicas[0].failed.IsZero())
+
+		// The unavailable replica is avoided for the next requests.
+		err = r.Invoke(context.Background(), "method", nil, &reply)
+
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&down))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&up))
+	})
+
+	t.Run("handles all replicas unavailable", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int32
+		r, err := NewReplicas([]grpc.ClientConnInterface{
+			fail(t, codes.Unavailable, &calls),
+			fail(t, codes.ResourceExhausted, &calls),
+		})
+		require.NoError(t, err)
+
+		var reply wrapperspb.UInt64Value
+		err = r.Invoke(context.Background(), "method", nil, &reply)
+
+		assert.Error(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("does not fail over on other errors", func(t *testing.T) {
+		t.Parallel()
+
+		var missing, other int32
+		r, err := NewReplicas([]grpc.ClientConnInterface{
+			fail(t, codes.NotFound, &missing),
+			serve(t, 2, 0, &other),
+		})
+		require.NoError(t, err)
+
+		var reply wrapperspb.UInt64Value
+		err = r.Invoke(context.Background(), "method", nil, &reply)
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Zero(t, atomic.LoadInt32(&other))
+	})
+
+	t.Run("hedges slow requests", func(t *testing.T) {
+		t.Parallel()
+
+		var slow, fast int32
+		r, err := NewReplicas([]grpc.ClientConnInterface{
+			serve(t, 1, time.Minute, &slow),
+			serve(t, 2, 0, &fast),
+		}, WithHedgeDelay(10*time.Millisecond))
+		require.NoError(t, err)
+
+		var reply wrapperspb.UInt64Value
+		err = r.Invoke(context.Background(), "method", nil, &reply)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), reply.Value)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&slow))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&fast))
+	})
+
+	t.Run("does not hedge when disabled", func(t *testing.T) {
+		t.Parallel()
+
+		var slow, fast int32
+		r, err := NewReplicas([]grpc.ClientConnInterface{
+			serve(t, 1, 50*time.Millisecond, &slow),
+			serve(t, 2, 0, &fast),
+		})
+		require.NoError(t, err)
+
+		var reply wrapperspb.UInt64Value
+		err = r.Invoke(context.Background(), "method", nil, &reply)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), reply.Value)
+		assert.Zero(t, atomic.LoadInt32(&fast))
+	})
+
+	t.Run("handles canceled context", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int32
+		r, err := NewReplicas([]grpc.ClientConnInterface{
+			serve(t, 1, time.Minute, &calls),
+		})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var reply wrapperspb.UInt64Value
+		err = r.Invoke(ctx, "method", nil, &reply)
+
+		assert.Equal(t, codes.Canceled, status.Code(err))
+		assert.True(t, r.replicas[0].failed.IsZero())
+	})
+}
+
+func TestNewReplicas(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		r, err := NewReplicas([]grpc.ClientConnInterface{mocks.BaselineClientConn(t)}, WithHedges(2))
+
+		require.NoError(t, err)
+		assert.Len(t, r.replicas, 1)
+		assert.Equal(t, uint(2), r.cfg.Hedges)
+	})
+
+	t.Run("handles missing connections", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewReplicas(nil)
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/onflow/flow-go/engine/execution/state"
+	"github.com/onflow/flow-go/engine/execution/state/delta"
+	fstate "github.com/onflow/flow-go/fvm/state"
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common/pathfinder"
+	"github.com/onflow/flow-go/ledger/complete"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// accountState reads the keys of the account with the given address and the
+// names of the contracts deployed on it from the registers at the given height.
+// If the account does not exist at that height, it returns false.
+func accountState(ctx context.Context, index dps.RegisterReader, height uint64, address flow.Address) ([]flow.AccountPublicKey, []string, bool, error) {
+
+	read := func(owner string, controller string, key string) (flow.RegisterValue, error) {
+		regID := flow.NewRegisterID(owner, controller, key)
+		path, err := pathfinder.KeyToPath(state.RegisterIDToKey(regID), complete.DefaultPathFinderVersion)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert key to path: %w", err)
+		}
+		values, err := index.Values(ctx, height, []ledger.Path{path})
+		if err != nil {
+			return nil, fmt.Errorf("could not read register: %w", err)
+		}
+		return flow.RegisterValue(values[0]), nil
+	}
+
+	accounts := fstate.NewAccounts(fstate.NewStateHolder(fstate.NewState(delta.NewView(read))))
+
+	exists, err := accounts.Exists(address)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("could not check account existence: %w", err)
+	}
+	if !exists {
+		return nil, nil, false, nil
+	}
+
+	keys, err := accounts.GetPublicKeys(address)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("could not get account keys: %w", err)
+	}
+	contracts, err := accounts.GetContractNames(address)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("could not get account contracts: %w", err)
+	}
+
+	return keys, contracts, true, nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/crypto"
+	"github.com/onflow/flow-go/crypto/hash"
+	"github.com/onflow/flow-go/engine/execution/state"
+	"github.com/onflow/flow-go/engine/execution/state/delta"
+	fstate "github.com/onflow/flow-go/fvm/state"
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common/pathfinder"
+	"github.com/onflow/flow-go/ledger/complete"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestAccountState(t *testing.T) {
+	address := mocks.GenericAddress(0)
+	key, registers := accountRegisters(t, address, "Contract")
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineRegisterReader(t)
+		index.ValuesFunc = func(_ context.Context, height uint64, paths []ledger.Path) ([]ledger.Value, error) {
+			assert.Equal(t, mocks.GenericHeight, height)
+
+			return []ledger.Value{registers[paths[0]]}, nil
+		}
+
+		keys, contracts, ok, err := accountState(context.Background(), index, mocks.GenericHeight, address)
+
+		require.NoError(t, err)
+		assert.True(t, ok)
+		require.Len(t, keys, 1)
+		assert.True(t, key.PublicKey.Equals(keys[0].PublicKey))
+		assert.Equal(t, key.Weight, keys[0].Weight)
+		assert.Equal(t, []string{"Contract"}, contracts)
+	})
+
+	t.Run("nominal case for missing account", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineRegisterReader(t)
+		index.ValuesFunc = func(context.Context, uint64, []ledger.Path) ([]ledger.Value, error) {
+			return []ledger.Value{nil}, nil
+		}
+
+		_, _, ok, err := accountState(context.Background(), index, mocks.GenericHeight, address)
+
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("handles index failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineRegisterReader(t)
+		index.ValuesFunc = func(context.Context, uint64, []ledger.Path) ([]ledger.Value, error) {
+			return nil, mocks.GenericError
+		}
+
+		_, _, _, err := accountState(context.Background(), index, mocks.GenericHeight, address)
+
+		assert.Error(t, err)
+	})
+}
+
+// accountRegisters creates an account with a single key and the given contracts
+// at the given address, and returns its key and its registers by path.
+func accountRegisters(t *testing.T, address flow.Address, contracts ...string) (flow.AccountPublicKey, map[ledger.Path]ledger.Value) {
+	t.Helper()
+
+	seed := make([]byte, crypto.KeyGenSeedMinLenECDSAP256)
+	private, err := crypto.GeneratePrivateKey(crypto.ECDSAP256, seed)
+	require.NoError(t, err)
+
+	key := flow.AccountPublicKey{
+		PublicKey: private.PublicKey(),
+		SignAlgo:  crypto.ECDSAP256,
+		HashAlgo:  hash.SHA3_256,
+		Weight:    1000,
+	}
+
+	view := delta.NewView(func(string, string, string) (flow.RegisterValue, error) {
+		return nil, nil
+	})
+	accounts := fstate.NewAccounts(fstate.NewStateHolder(fstate.NewState(view)))
+	require.NoError(t, accounts.Create([]flow.AccountPublicKey{key}, address))
+	for _, contract := range contracts {
+		require.NoError(t, accounts.SetContract(contract, address, []byte("contract")))
+	}
+
+	registers := make(map[ledger.Path]ledger.Value)
+	ids, values := view.RegisterUpdates()
+	for i, id := range ids {
+		path, err := pathfinder.KeyToPath(state.RegisterIDToKey(id), complete.DefaultPathFinderVersion)
+		require.NoError(t, err)
+		registers[path] = ledger.Value(values[i])
+	}
+
+	return key, registers
+}
@@ -18,4 +18,7 @@
 // Add struct tags for validation.
 //go:generate protoc -I . -I /usr/local/include -I $HOME/.local/include -I $GOPATH/pkg/mod/github.com/srikrsna/protoc-gen-gotag@v0.6.1 --gotag_out=:. --gotag_opt=paths=source_relative ./api.proto
 
+// Generate the TypeScript and Python clients.
+//go:generate buf generate --include-imports
+
 package dps
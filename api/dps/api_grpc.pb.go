@@ -23,17 +23,105 @@ type APIClient interface {
 	GetHeightForBlock(ctx context.Context, in *GetHeightForBlockRequest, opts ...grpc.CallOption) (*GetHeightForBlockResponse, error)
 	GetCommit(ctx context.Context, in *GetCommitRequest, opts ...grpc.CallOption) (*GetCommitResponse, error)
 	GetHeader(ctx context.Context, in *GetHeaderRequest, opts ...grpc.CallOption) (*GetHeaderResponse, error)
+	// GetBlockStats returns the aggregate statistics of the blocks between the
+	// given start and end heights, which are computed when each block is
+	// indexed: its number of transactions and events, its total fees and
+	// computation, and its number of distinct transaction senders.
+	GetBlockStats(ctx context.Context, in *GetBlockStatsRequest, opts ...grpc.CallOption) (*GetBlockStatsResponse, error)
 	GetEvents(ctx context.Context, in *GetEventsRequest, opts ...grpc.CallOption) (*GetEventsResponse, error)
 	GetRegisterValues(ctx context.Context, in *GetRegisterValuesRequest, opts ...grpc.CallOption) (*GetRegisterValuesResponse, error)
+	// ListRegistersForOwner streams all registers owned by the account with the
+	// given address, as they were at the given height, in pages of at most the
+	// given number of registers. Each page carries the cursor after which the
+	// listing can be resumed; the cursor of the last page is empty.
+	ListRegistersForOwner(ctx context.Context, in *ListRegistersForOwnerRequest, opts ...grpc.CallOption) (API_ListRegistersForOwnerClient, error)
+	// DumpRegisters streams all registers of the execution state, as they were
+	// at the given height, in ascending order of their paths. The registers are
+	// sent in chunks of at most the given number of registers, which are
+	// compressed into zstd frames if requested, so that snapshots of the
+	// execution state can be exported without access to the index database.
+	// Each chunk carries the cursor after which the dump can be resumed; the
+	// cursor of the last chunk is empty.
+	DumpRegisters(ctx context.Context, in *DumpRegistersRequest, opts ...grpc.CallOption) (API_DumpRegistersClient, error)
+	// GetRegisterChurn returns the number of register writes between the given
+	// start and end heights, along with the owners and registers that were
+	// written the most, in descending order of writes. Each register is counted
+	// once per height at which it was written.
+	GetRegisterChurn(ctx context.Context, in *GetRegisterChurnRequest, opts ...grpc.CallOption) (*GetRegisterChurnResponse, error)
+	// GetRegisterDelta returns how the registers written by the finalized block
+	// at the given height changed, as the hashes of the leaf of each register in
+	// the execution state trie before and after the block, in ascending order of
+	// their paths. At most the given number of changes are returned; the cursor
+	// of the response is the one after which the next page starts, and it is
+	// empty on the last page. Changes are only recorded for heights that were
+	// indexed with register deltas enabled.
+	GetRegisterDelta(ctx context.Context, in *GetRegisterDeltaRequest, opts ...grpc.CallOption) (*GetRegisterDeltaResponse, error)
 	GetCollection(ctx context.Context, in *GetCollectionRequest, opts ...grpc.CallOption) (*GetCollectionResponse, error)
 	ListCollectionsForHeight(ctx context.Context, in *ListCollectionsForHeightRequest, opts ...grpc.CallOption) (*ListCollectionsForHeightResponse, error)
 	GetGuarantee(ctx context.Context, in *GetGuaranteeRequest, opts ...grpc.CallOption) (*GetGuaranteeResponse, error)
 	GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*GetTransactionResponse, error)
 	GetHeightForTransaction(ctx context.Context, in *GetHeightForTransactionRequest, opts ...grpc.CallOption) (*GetHeightForTransactionResponse, error)
+	// GetHeightForCommit returns the first height at which the execution state
+	// had the given state commitment.
+	GetHeightForCommit(ctx context.Context, in *GetHeightForCommitRequest, opts ...grpc.CallOption) (*GetHeightForCommitResponse, error)
 	ListTransactionsForHeight(ctx context.Context, in *ListTransactionsForHeightRequest, opts ...grpc.CallOption) (*ListTransactionsForHeightResponse, error)
+	// ListTransactionsForScript returns the identifiers of the transactions
+	// whose script has the given SHA-256 hash, between the given start and end
+	// heights, in ascending order of height.
+	ListTransactionsForScript(ctx context.Context, in *ListTransactionsForScriptRequest, opts ...grpc.CallOption) (*ListTransactionsForScriptResponse, error)
 	GetResult(ctx context.Context, in *GetResultRequest, opts ...grpc.CallOption) (*GetResultResponse, error)
 	GetSeal(ctx context.Context, in *GetSealRequest, opts ...grpc.CallOption) (*GetSealResponse, error)
 	ListSealsForHeight(ctx context.Context, in *ListSealsForHeightRequest, opts ...grpc.CallOption) (*ListSealsForHeightResponse, error)
+	// ListOwners returns the filter of the index: the addresses of the accounts
+	// that the indexed data was restricted to, and the addresses of the accounts
+	// whose data was excluded. For an index of all data, both lists are empty.
+	ListOwners(ctx context.Context, in *ListOwnersRequest, opts ...grpc.CallOption) (*ListOwnersResponse, error)
+	// ListEventTypes returns the statistics of every event type that was
+	// indexed, which are the first and last heights at which an event of the
+	// type was indexed and the number of indexed events of the type.
+	ListEventTypes(ctx context.Context, in *ListEventTypesRequest, opts ...grpc.CallOption) (*ListEventTypesResponse, error)
+	// GetAccountAtHeight returns the account with the given address as it was at
+	// the given height: the height at which it was created, the heights at which
+	// its keys were updated, and its keys and the names of its contracts, which
+	// are read from the execution state at that height.
+	GetAccountAtHeight(ctx context.Context, in *GetAccountAtHeightRequest, opts ...grpc.CallOption) (*GetAccountAtHeightResponse, error)
+	// GetContractHistory returns every change made to the contract with the
+	// given name on the account with the given address, in the order in which
+	// they happened, along with the hash of the code of each version.
+	GetContractHistory(ctx context.Context, in *GetContractHistoryRequest, opts ...grpc.CallOption) (*GetContractHistoryResponse, error)
+	// GetServiceEvents returns the service events, such as the epoch setup and
+	// epoch commit events, that were emitted by the system chunk between the
+	// given start and end heights, optionally restricted to the given types.
+	GetServiceEvents(ctx context.Context, in *GetServiceEventsRequest, opts ...grpc.CallOption) (*GetServiceEventsResponse, error)
+	// ListEpochs returns every indexed epoch, along with its views and the
+	// heights at which each of its phases started.
+	ListEpochs(ctx context.Context, in *ListEpochsRequest, opts ...grpc.CallOption) (*ListEpochsResponse, error)
+	// ListIdentitiesForEpoch returns the identity table of the epoch with the
+	// given counter, which contains the staked nodes participating in it.
+	ListIdentitiesForEpoch(ctx context.Context, in *ListIdentitiesForEpochRequest, opts ...grpc.CallOption) (*ListIdentitiesForEpochResponse, error)
+	// Deprecated: Do not use.
+	// GetVersion returns the version of the API and the version of the index
+	// schema, which allows clients to check whether the features they rely on
+	// are available before using them. It is deprecated in favor of GetInfo.
+	GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*GetVersionResponse, error)
+	// GetInfo returns the versions of the API and of the index schema, along
+	// with the optional features supported by the API and the list of deprecated
+	// methods, so that clients can adapt to the server they are talking to.
+	GetInfo(ctx context.Context, in *GetInfoRequest, opts ...grpc.CallOption) (*GetInfoResponse, error)
+	// GetRawHeader, GetRawEvents, GetRawCollection, GetRawGuarantee,
+	// GetRawTransaction, GetRawResult and GetRawSeal are variants of the methods
+	// with the same names without the Raw prefix. They return the values in the
+	// encoded form in which they are stored in the index, along with the
+	// identifier of the codec that encoded them, so that the server does not
+	// need to decode and encode them again. This makes them cheaper for bulk
+	// consumers that decode the values themselves.
+	GetRawHeader(ctx context.Context, in *GetRawHeaderRequest, opts ...grpc.CallOption) (*GetRawHeaderResponse, error)
+	GetRawEvents(ctx context.Context, in *GetRawEventsRequest, opts ...grpc.CallOption) (*GetRawEventsResponse, error)
+	GetRawCollection(ctx context.Context, in *GetRawCollectionRequest, opts ...grpc.CallOption) (*GetRawCollectionResponse, error)
+	GetRawGuarantee(ctx context.Context, in *GetRawGuaranteeRequest, opts ...grpc.CallOption) (*GetRawGuaranteeResponse, error)
+	GetRawTransaction(ctx context.Context, in *GetRawTransactionRequest, opts ...grpc.CallOption) (*GetRawTransactionResponse, error)
+	GetRawResult(ctx context.Context, in *GetRawResultRequest, opts ...grpc.CallOption) (*GetRawResultResponse, error)
+	GetRawSeal(ctx context.Context, in *GetRawSealRequest, opts ...grpc.CallOption) (*GetRawSealResponse, error)
 }
 
 type aPIClient struct {
@@ -46,7 +134,7 @@ func NewAPIClient(cc grpc.ClientConnInterface) APIClient {
 
 func (c *aPIClient) GetFirst(ctx context.Context, in *GetFirstRequest, opts ...grpc.CallOption) (*GetFirstResponse, error) {
 	out := new(GetFirstResponse)
-	err := c.cc.Invoke(ctx, "/API/GetFirst", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/dps.API/GetFirst", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -55,7 +143,7 @@ func (c *aPIClient) GetFirst(ctx context.Context, in *GetFirstRequest, opts ...g
 
 func (c *aPIClient) GetLast(ctx context.Context, in *GetLastRequest, opts ...grpc.CallOption) (*GetLastResponse, error) {
 	out := new(GetLastResponse)
-	err := c.cc.Invoke(ctx, "/API/GetLast", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/dps.API/GetLast", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -64,7 +152,7 @@ func (c *aPIClient) GetLast(ctx context.Context, in *GetLastRequest, opts ...grp
 
 func (c *aPIClient) GetHeightForBlock(ctx context.Context, in *GetHeightForBlockRequest, opts ...grpc.CallOption) (*GetHeightForBlockResponse, error) {
 	out := new(GetHeightForBlockResponse)
-	err := c.cc.Invoke(ctx, "/API/GetHeightForBlock", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/dps.API/GetHeightForBlock", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -73,7 +161,7 @@ func (c *aPIClient) GetHeightForBlock(ctx context.Context, in *GetHeightForBlock
 
 func (c *aPIClient) GetCommit(ctx context.Context, in *GetCommitRequest, opts ...grpc.CallOption) (*GetCommitResponse, error) {
 	out := new(GetCommitResponse)
-	err := c.cc.Invoke(ctx, "/API/GetCommit", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/dps.API/GetCommit", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -82,7 +170,16 @@ func (c *aPIClient) GetCommit(ctx context.Context, in *GetCommitRequest, opts ..
 
 func (c *aPIClient) GetHeader(ctx context.Context, in *GetHeaderRequest, opts ...grpc.CallOption) (*GetHeaderResponse, error) {
 	out := new(GetHeaderResponse)
-	err := c.cc.Invoke(ctx, "/API/GetHeader", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/dps.API/GetHeader", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetBlockStats(ctx context.Context, in *GetBlockStatsRequest, opts ...grpc.CallOption) (*GetBlockStatsResponse, error) {
+	out := new(GetBlockStatsResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/GetBlockStats", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -91,7 +188,7 @@ func (c *aPIClient) GetHeader(ctx context.Context, in *GetHeaderRequest, opts ..
 
 func (c *aPIClient) GetEvents(ctx context.Context, in *GetEventsRequest, opts ...grpc.CallOption) (*GetEventsResponse, error) {
 	out := new(GetEventsResponse)
-	err := c.cc.Invoke(ctx, "/API/GetEvents", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/dps.API/GetEvents", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -100,7 +197,89 @@ func (c *aPIClient) GetEvents(ctx context.Context, in *GetEventsRequest, opts ..
 
 func (c *aPIClient) GetRegisterValues(ctx context.Context, in *GetRegisterValuesRequest, opts ...grpc.CallOption) (*GetRegisterValuesResponse, error) {
 	out := new(GetRegisterValuesResponse)
-	err := c.cc.Invoke(ctx, "/API/GetRegisterValues", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/dps.API/GetRegisterValues", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) ListRegistersForOwner(ctx context.Context, in *ListRegistersForOwnerRequest, opts ...grpc.CallOption) (API_ListRegistersForOwnerClient, error) {
+	stream, err := c.cc.NewStream(ctx, &API_ServiceDesc.Streams[0], "/dps.API/ListRegistersForOwner", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIListRegistersForOwnerClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type API_ListRegistersForOwnerClient interface {
+	Recv() (*ListRegistersForOwnerResponse, error)
+	grpc.ClientStream
+}
+
+type aPIListRegistersForOwnerClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIListRegistersForOwnerClient) Recv() (*ListRegistersForOwnerResponse, error) {
+	m := new(ListRegistersForOwnerResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *aPIClient) DumpRegisters(ctx context.Context, in *DumpRegistersRequest, opts ...grpc.CallOption) (API_DumpRegistersClient, error) {
+	stream, err := c.cc.NewStream(ctx, &API_ServiceDesc.Streams[1], "/dps.API/DumpRegisters", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &aPIDumpRegistersClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type API_DumpRegistersClient interface {
+	Recv() (*DumpRegistersResponse, error)
+	grpc.ClientStream
+}
+
+type aPIDumpRegistersClient struct {
+	grpc.ClientStream
+}
+
+func (x *aPIDumpRegistersClient) Recv() (*DumpRegistersResponse, error) {
+	m := new(DumpRegistersResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *aPIClient) GetRegisterChurn(ctx context.Context, in *GetRegisterChurnRequest, opts ...grpc.CallOption) (*GetRegisterChurnResponse, error) {
+	out := new(GetRegisterChurnResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/GetRegisterChurn", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetRegisterDelta(ctx context.Context, in *GetRegisterDeltaRequest, opts ...grpc.CallOption) (*GetRegisterDeltaResponse, error) {
+	out := new(GetRegisterDeltaResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/GetRegisterDelta", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +288,7 @@ func (c *aPIClient) GetRegisterValues(ctx context.Context, in *GetRegisterValues
 
 func (c *aPIClient) GetCollection(ctx context.Context, in *GetCollectionRequest, opts ...grpc.CallOption) (*GetCollectionResponse, error) {
 	out := new(GetCollectionResponse)
-	err := c.cc.Invoke(ctx, "/API/GetCollection", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/dps.API/GetCollection", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -118,7 +297,7 @@ func (c *aPIClient) GetCollection(ctx context.Context, in *GetCollectionRequest,
 
 func (c *aPIClient) ListCollectionsForHeight(ctx context.Context, in *ListCollectionsForHeightRequest, opts ...grpc.CallOption) (*ListCollectionsForHeightResponse, error) {
 	out := new(ListCollectionsForHeightResponse)
-	err := c.cc.Invoke(ctx, "/API/ListCollectionsForHeight", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/dps.API/ListCollectionsForHeight", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -127,7 +306,7 @@ func (c *aPIClient) ListCollectionsForHeight(ctx context.Context, in *ListCollec
 
 func (c *aPIClient) GetGuarantee(ctx context.Context, in *GetGuaranteeRequest, opts ...grpc.CallOption) (*GetGuaranteeResponse, error) {
 	out := new(GetGuaranteeResponse)
-	err := c.cc.Invoke(ctx, "/API/GetGuarantee", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/dps.API/GetGuarantee", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -136,7 +315,7 @@ func (c *aPIClient) GetGuarantee(ctx context.Context, in *GetGuaranteeRequest, o
 
 func (c *aPIClient) GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*GetTransactionResponse, error) {
 	out := new(GetTransactionResponse)
-	err := c.cc.Invoke(ctx, "/API/GetTransaction", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/dps.API/GetTransaction", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -145,7 +324,16 @@ func (c *aPIClient) GetTransaction(ctx context.Context, in *GetTransactionReques
 
 func (c *aPIClient) GetHeightForTransaction(ctx context.Context, in *GetHeightForTransactionRequest, opts ...grpc.CallOption) (*GetHeightForTransactionResponse, error) {
 	out := new(GetHeightForTransactionResponse)
-	err := c.cc.Invoke(ctx, "/API/GetHeightForTransaction", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/dps.API/GetHeightForTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetHeightForCommit(ctx context.Context, in *GetHeightForCommitRequest, opts ...grpc.CallOption) (*GetHeightForCommitResponse, error) {
+	out := new(GetHeightForCommitResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/GetHeightForCommit", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -154,7 +342,16 @@ func (c *aPIClient) GetHeightForTransaction(ctx context.Context, in *GetHeightFo
 
 func (c *aPIClient) ListTransactionsForHeight(ctx context.Context, in *ListTransactionsForHeightRequest, opts ...grpc.CallOption) (*ListTransactionsForHeightResponse, error) {
 	out := new(ListTransactionsForHeightResponse)
-	err := c.cc.Invoke(ctx, "/API/ListTransactionsForHeight", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/dps.API/ListTransactionsForHeight", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) ListTransactionsForScript(ctx context.Context, in *ListTransactionsForScriptRequest, opts ...grpc.CallOption) (*ListTransactionsForScriptResponse, error) {
+	out := new(ListTransactionsForScriptResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/ListTransactionsForScript", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -163,7 +360,7 @@ func (c *aPIClient) ListTransactionsForHeight(ctx context.Context, in *ListTrans
 
 func (c *aPIClient) GetResult(ctx context.Context, in *GetResultRequest, opts ...grpc.CallOption) (*GetResultResponse, error) {
 	out := new(GetResultResponse)
-	err := c.cc.Invoke(ctx, "/API/GetResult", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/dps.API/GetResult", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -172,7 +369,7 @@ func (c *aPIClient) GetResult(ctx context.Context, in *GetResultRequest, opts ..
 
 func (c *aPIClient) GetSeal(ctx context.Context, in *GetSealRequest, opts ...grpc.CallOption) (*GetSealResponse, error) {
 	out := new(GetSealResponse)
-	err := c.cc.Invoke(ctx, "/API/GetSeal", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/dps.API/GetSeal", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -181,7 +378,152 @@ func (c *aPIClient) GetSeal(ctx context.Context, in *GetSealRequest, opts ...grp
 
 func (c *aPIClient) ListSealsForHeight(ctx context.Context, in *ListSealsForHeightRequest, opts ...grpc.CallOption) (*ListSealsForHeightResponse, error) {
 	out := new(ListSealsForHeightResponse)
-	err := c.cc.Invoke(ctx, "/API/ListSealsForHeight", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/dps.API/ListSealsForHeight", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) ListOwners(ctx context.Context, in *ListOwnersRequest, opts ...grpc.CallOption) (*ListOwnersResponse, error) {
+	out := new(ListOwnersResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/ListOwners", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) ListEventTypes(ctx context.Context, in *ListEventTypesRequest, opts ...grpc.CallOption) (*ListEventTypesResponse, error) {
+	out := new(ListEventTypesResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/ListEventTypes", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetAccountAtHeight(ctx context.Context, in *GetAccountAtHeightRequest, opts ...grpc.CallOption) (*GetAccountAtHeightResponse, error) {
+	out := new(GetAccountAtHeightResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/GetAccountAtHeight", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetContractHistory(ctx context.Context, in *GetContractHistoryRequest, opts ...grpc.CallOption) (*GetContractHistoryResponse, error) {
+	out := new(GetContractHistoryResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/GetContractHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetServiceEvents(ctx context.Context, in *GetServiceEventsRequest, opts ...grpc.CallOption) (*GetServiceEventsResponse, error) {
+	out := new(GetServiceEventsResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/GetServiceEvents", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) ListEpochs(ctx context.Context, in *ListEpochsRequest, opts ...grpc.CallOption) (*ListEpochsResponse, error) {
+	out := new(ListEpochsResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/ListEpochs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) ListIdentitiesForEpoch(ctx context.Context, in *ListIdentitiesForEpochRequest, opts ...grpc.CallOption) (*ListIdentitiesForEpochResponse, error) {
+	out := new(ListIdentitiesForEpochResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/ListIdentitiesForEpoch", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Deprecated: Do not use.
+func (c *aPIClient) GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*GetVersionResponse, error) {
+	out := new(GetVersionResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/GetVersion", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetInfo(ctx context.Context, in *GetInfoRequest, opts ...grpc.CallOption) (*GetInfoResponse, error) {
+	out := new(GetInfoResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/GetInfo", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetRawHeader(ctx context.Context, in *GetRawHeaderRequest, opts ...grpc.CallOption) (*GetRawHeaderResponse, error) {
+	out := new(GetRawHeaderResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/GetRawHeader", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetRawEvents(ctx context.Context, in *GetRawEventsRequest, opts ...grpc.CallOption) (*GetRawEventsResponse, error) {
+	out := new(GetRawEventsResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/GetRawEvents", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetRawCollection(ctx context.Context, in *GetRawCollectionRequest, opts ...grpc.CallOption) (*GetRawCollectionResponse, error) {
+	out := new(GetRawCollectionResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/GetRawCollection", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetRawGuarantee(ctx context.Context, in *GetRawGuaranteeRequest, opts ...grpc.CallOption) (*GetRawGuaranteeResponse, error) {
+	out := new(GetRawGuaranteeResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/GetRawGuarantee", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetRawTransaction(ctx context.Context, in *GetRawTransactionRequest, opts ...grpc.CallOption) (*GetRawTransactionResponse, error) {
+	out := new(GetRawTransactionResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/GetRawTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetRawResult(ctx context.Context, in *GetRawResultRequest, opts ...grpc.CallOption) (*GetRawResultResponse, error) {
+	out := new(GetRawResultResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/GetRawResult", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aPIClient) GetRawSeal(ctx context.Context, in *GetRawSealRequest, opts ...grpc.CallOption) (*GetRawSealResponse, error) {
+	out := new(GetRawSealResponse)
+	err := c.cc.Invoke(ctx, "/dps.API/GetRawSeal", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -197,17 +539,105 @@ type APIServer interface {
 	GetHeightForBlock(context.Context, *GetHeightForBlockRequest) (*GetHeightForBlockResponse, error)
 	GetCommit(context.Context, *GetCommitRequest) (*GetCommitResponse, error)
 	GetHeader(context.Context, *GetHeaderRequest) (*GetHeaderResponse, error)
+	// GetBlockStats returns the aggregate statistics of the blocks between the
+	// given start and end heights, which are computed when each block is
+	// indexed: its number of transactions and events, its total fees and
+	// computation, and its number of distinct transaction senders.
+	GetBlockStats(context.Context, *GetBlockStatsRequest) (*GetBlockStatsResponse, error)
 	GetEvents(context.Context, *GetEventsRequest) (*GetEventsResponse, error)
 	GetRegisterValues(context.Context, *GetRegisterValuesRequest) (*GetRegisterValuesResponse, error)
+	// ListRegistersForOwner streams all registers owned by the account with the
+	// given address, as they were at the given height, in pages of at most the
+	// given number of registers. Each page carries the cursor after which the
+	// listing can be resumed; the cursor of the last page is empty.
+	ListRegistersForOwner(*ListRegistersForOwnerRequest, API_ListRegistersForOwnerServer) error
+	// DumpRegisters streams all registers of the execution state, as they were
+	// at the given height, in ascending order of their paths. The registers are
+	// sent in chunks of at most the given number of registers, which are
+	// compressed into zstd frames if requested, so that snapshots of the
+	// execution state can be exported without access to the index database.
+	// Each chunk carries the cursor after which the dump can be resumed; the
+	// cursor of the last chunk is empty.
+	DumpRegisters(*DumpRegistersRequest, API_DumpRegistersServer) error
+	// GetRegisterChurn returns the number of register writes between the given
+	// start and end heights, along with the owners and registers that were
+	// written the most, in descending order of writes. Each register is counted
+	// once per height at which it was written.
+	GetRegisterChurn(context.Context, *GetRegisterChurnRequest) (*GetRegisterChurnResponse, error)
+	// GetRegisterDelta returns how the registers written by the finalized block
+	// at the given height changed, as the hashes of the leaf of each register in
+	// the execution state trie before and after the block, in ascending order of
+	// their paths. At most the given number of changes are returned; the cursor
+	// of the response is the one after which the next page starts, and it is
+	// empty on the last page. Changes are only recorded for heights that were
+	// indexed with register deltas enabled.
+	GetRegisterDelta(context.Context, *GetRegisterDeltaRequest) (*GetRegisterDeltaResponse, error)
 	GetCollection(context.Context, *GetCollectionRequest) (*GetCollectionResponse, error)
 	ListCollectionsForHeight(context.Context, *ListCollectionsForHeightRequest) (*ListCollectionsForHeightResponse, error)
 	GetGuarantee(context.Context, *GetGuaranteeRequest) (*GetGuaranteeResponse, error)
 	GetTransaction(context.Context, *GetTransactionRequest) (*GetTransactionResponse, error)
 	GetHeightForTransaction(context.Context, *GetHeightForTransactionRequest) (*GetHeightForTransactionResponse, error)
+	// GetHeightForCommit returns the first height at which the execution state
+	// had the given state commitment.
+	GetHeightForCommit(context.Context, *GetHeightForCommitRequest) (*GetHeightForCommitResponse, error)
 	ListTransactionsForHeight(context.Context, *ListTransactionsForHeightRequest) (*ListTransactionsForHeightResponse, error)
+	// ListTransactionsForScript returns the identifiers of the transactions
+	// whose script has the given SHA-256 hash, between the given start and end
+	// heights, in ascending order of height.
+	ListTransactionsForScript(context.Context, *ListTransactionsForScriptRequest) (*ListTransactionsForScriptResponse, error)
 	GetResult(context.Context, *GetResultRequest) (*GetResultResponse, error)
 	GetSeal(context.Context, *GetSealRequest) (*GetSealResponse, error)
 	ListSealsForHeight(context.Context, *ListSealsForHeightRequest) (*ListSealsForHeightResponse, error)
+	// ListOwners returns the filter of the index: the addresses of the accounts
+	// that the indexed data was restricted to, and the addresses of the accounts
+	// whose data was excluded. For an index of all data, both lists are empty.
+	ListOwners(context.Context, *ListOwnersRequest) (*ListOwnersResponse, error)
+	// ListEventTypes returns the statistics of every event type that was
+	// indexed, which are the first and last heights at which an event of the
+	// type was indexed and the number of indexed events of the type.
+	ListEventTypes(context.Context, *ListEventTypesRequest) (*ListEventTypesResponse, error)
+	// GetAccountAtHeight returns the account with the given address as it was at
+	// the given height: the height at which it was created, the heights at which
+	// its keys were updated, and its keys and the names of its contracts, which
+	// are read from the execution state at that height.
+	GetAccountAtHeight(context.Context, *GetAccountAtHeightRequest) (*GetAccountAtHeightResponse, error)
+	// GetContractHistory returns every change made to the contract with the
+	// given name on the account with the given address, in the order in which
+	// they happened, along with the hash of the code of each version.
+	GetContractHistory(context.Context, *GetContractHistoryRequest) (*GetContractHistoryResponse, error)
+	// GetServiceEvents returns the service events, such as the epoch setup and
+	// epoch commit events, that were emitted by the system chunk between the
+	// given start and end heights, optionally restricted to the given types.
+	GetServiceEvents(context.Context, *GetServiceEventsRequest) (*GetServiceEventsResponse, error)
+	// ListEpochs returns every indexed epoch, along with its views and the
+	// heights at which each of its phases started.
+	ListEpochs(context.Context, *ListEpochsRequest) (*ListEpochsResponse, error)
+	// ListIdentitiesForEpoch returns the identity table of the epoch with the
+	// given counter, which contains the staked nodes participating in it.
+	ListIdentitiesForEpoch(context.Context, *ListIdentitiesForEpochRequest) (*ListIdentitiesForEpochResponse, error)
+	// Deprecated: Do not use.
+	// GetVersion returns the version of the API and the version of the index
+	// schema, which allows clients to check whether the features they rely on
+	// are available before using them. It is deprecated in favor of GetInfo.
+	GetVersion(context.Context, *GetVersionRequest) (*GetVersionResponse, error)
+	// GetInfo returns the versions of the API and of the index schema, along
+	// with the optional features supported by the API and the list of deprecated
+	// methods, so that clients can adapt to the server they are talking to.
+	GetInfo(context.Context, *GetInfoRequest) (*GetInfoResponse, error)
+	// GetRawHeader, GetRawEvents, GetRawCollection, GetRawGuarantee,
+	// GetRawTransaction, GetRawResult and GetRawSeal are variants of the methods
+	// with the same names without the Raw prefix. They return the values in the
+	// encoded form in which they are stored in the index, along with the
+	// identifier of the codec that encoded them, so that the server does not
+	// need to decode and encode them again. This makes them cheaper for bulk
+	// consumers that decode the values themselves.
+	GetRawHeader(context.Context, *GetRawHeaderRequest) (*GetRawHeaderResponse, error)
+	GetRawEvents(context.Context, *GetRawEventsRequest) (*GetRawEventsResponse, error)
+	GetRawCollection(context.Context, *GetRawCollectionRequest) (*GetRawCollectionResponse, error)
+	GetRawGuarantee(context.Context, *GetRawGuaranteeRequest) (*GetRawGuaranteeResponse, error)
+	GetRawTransaction(context.Context, *GetRawTransactionRequest) (*GetRawTransactionResponse, error)
+	GetRawResult(context.Context, *GetRawResultRequest) (*GetRawResultResponse, error)
+	GetRawSeal(context.Context, *GetRawSealRequest) (*GetRawSealResponse, error)
 }
 
 // UnimplementedAPIServer should be embedded to have forward compatible implementations.
@@ -229,12 +659,27 @@ func (UnimplementedAPIServer) GetCommit(context.Context, *GetCommitRequest) (*Ge
 func (UnimplementedAPIServer) GetHeader(context.Context, *GetHeaderRequest) (*GetHeaderResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetHeader not implemented")
 }
+func (UnimplementedAPIServer) GetBlockStats(context.Context, *GetBlockStatsRequest) (*GetBlockStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlockStats not implemented")
+}
 func (UnimplementedAPIServer) GetEvents(context.Context, *GetEventsRequest) (*GetEventsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetEvents not implemented")
 }
 func (UnimplementedAPIServer) GetRegisterValues(context.Context, *GetRegisterValuesRequest) (*GetRegisterValuesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetRegisterValues not implemented")
 }
+func (UnimplementedAPIServer) ListRegistersForOwner(*ListRegistersForOwnerRequest, API_ListRegistersForOwnerServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListRegistersForOwner not implemented")
+}
+func (UnimplementedAPIServer) DumpRegisters(*DumpRegistersRequest, API_DumpRegistersServer) error {
+	return status.Errorf(codes.Unimplemented, "method DumpRegisters not implemented")
+}
+func (UnimplementedAPIServer) GetRegisterChurn(context.Context, *GetRegisterChurnRequest) (*GetRegisterChurnResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRegisterChurn not implemented")
+}
+func (UnimplementedAPIServer) GetRegisterDelta(context.Context, *GetRegisterDeltaRequest) (*GetRegisterDeltaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRegisterDelta not implemented")
+}
 func (UnimplementedAPIServer) GetCollection(context.Context, *GetCollectionRequest) (*GetCollectionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetCollection not implemented")
 }
@@ -250,9 +695,15 @@ func (UnimplementedAPIServer) GetTransaction(context.Context, *GetTransactionReq
 func (UnimplementedAPIServer) GetHeightForTransaction(context.Context, *GetHeightForTransactionRequest) (*GetHeightForTransactionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetHeightForTransaction not implemented")
 }
+func (UnimplementedAPIServer) GetHeightForCommit(context.Context, *GetHeightForCommitRequest) (*GetHeightForCommitResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHeightForCommit not implemented")
+}
 func (UnimplementedAPIServer) ListTransactionsForHeight(context.Context, *ListTransactionsForHeightRequest) (*ListTransactionsForHeightResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListTransactionsForHeight not implemented")
 }
+func (UnimplementedAPIServer) ListTransactionsForScript(context.Context, *ListTransactionsForScriptRequest) (*ListTransactionsForScriptResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTransactionsForScript not implemented")
+}
 func (UnimplementedAPIServer) GetResult(context.Context, *GetResultRequest) (*GetResultResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetResult not implemented")
 }
@@ -262,6 +713,54 @@ func (UnimplementedAPIServer) GetSeal(context.Context, *GetSealRequest) (*GetSea
 func (UnimplementedAPIServer) ListSealsForHeight(context.Context, *ListSealsForHeightRequest) (*ListSealsForHeightResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListSealsForHeight not implemented")
 }
+func (UnimplementedAPIServer) ListOwners(context.Context, *ListOwnersRequest) (*ListOwnersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListOwners not implemented")
+}
+func (UnimplementedAPIServer) ListEventTypes(context.Context, *ListEventTypesRequest) (*ListEventTypesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEventTypes not implemented")
+}
+func (UnimplementedAPIServer) GetAccountAtHeight(context.Context, *GetAccountAtHeightRequest) (*GetAccountAtHeightResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccountAtHeight not implemented")
+}
+func (UnimplementedAPIServer) GetContractHistory(context.Context, *GetContractHistoryRequest) (*GetContractHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetContractHistory not implemented")
+}
+func (UnimplementedAPIServer) GetServiceEvents(context.Context, *GetServiceEventsRequest) (*GetServiceEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetServiceEvents not implemented")
+}
+func (UnimplementedAPIServer) ListEpochs(context.Context, *ListEpochsRequest) (*ListEpochsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEpochs not implemented")
+}
+func (UnimplementedAPIServer) ListIdentitiesForEpoch(context.Context, *ListIdentitiesForEpochRequest) (*ListIdentitiesForEpochResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListIdentitiesForEpoch not implemented")
+}
+func (UnimplementedAPIServer) GetVersion(context.Context, *GetVersionRequest) (*GetVersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVersion not implemented")
+}
+func (UnimplementedAPIServer) GetInfo(context.Context, *GetInfoRequest) (*GetInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInfo not implemented")
+}
+func (UnimplementedAPIServer) GetRawHeader(context.Context, *GetRawHeaderRequest) (*GetRawHeaderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRawHeader not implemented")
+}
+func (UnimplementedAPIServer) GetRawEvents(context.Context, *GetRawEventsRequest) (*GetRawEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRawEvents not implemented")
+}
+func (UnimplementedAPIServer) GetRawCollection(context.Context, *GetRawCollectionRequest) (*GetRawCollectionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRawCollection not implemented")
+}
+func (UnimplementedAPIServer) GetRawGuarantee(context.Context, *GetRawGuaranteeRequest) (*GetRawGuaranteeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRawGuarantee not implemented")
+}
+func (UnimplementedAPIServer) GetRawTransaction(context.Context, *GetRawTransactionRequest) (*GetRawTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRawTransaction not implemented")
+}
+func (UnimplementedAPIServer) GetRawResult(context.Context, *GetRawResultRequest) (*GetRawResultResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRawResult not implemented")
+}
+func (UnimplementedAPIServer) GetRawSeal(context.Context, *GetRawSealRequest) (*GetRawSealResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRawSeal not implemented")
+}
 
 // UnsafeAPIServer may be embedded to opt out of forward compatibility for this service.
 // Use of this interface is not recommended, as added methods to APIServer will
@@ -284,7 +783,7 @@ func _API_GetFirst_Handler(srv interface{}, ctx context.Context, dec func(interf
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/API/GetFirst",
+		FullMethod: "/dps.API/GetFirst",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(APIServer).GetFirst(ctx, req.(*GetFirstRequest))
@@ -302,7 +801,7 @@ func _API_GetLast_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/API/GetLast",
+		FullMethod: "/dps.API/GetLast",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(APIServer).GetLast(ctx, req.(*GetLastRequest))
@@ -320,7 +819,7 @@ func _API_GetHeightForBlock_Handler(srv interface{}, ctx context.Context, dec fu
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/API/GetHeightForBlock",
+		FullMethod: "/dps.API/GetHeightForBlock",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(APIServer).GetHeightForBlock(ctx, req.(*GetHeightForBlockRequest))
@@ -338,7 +837,7 @@ func _API_GetCommit_Handler(srv interface{}, ctx context.Context, dec func(inter
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/API/GetCommit",
+		FullMethod: "/dps.API/GetCommit",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(APIServer).GetCommit(ctx, req.(*GetCommitRequest))
@@ -356,7 +855,7 @@ func _API_GetHeader_Handler(srv interface{}, ctx context.Context, dec func(inter
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/API/GetHeader",
+		FullMethod: "/dps.API/GetHeader",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(APIServer).GetHeader(ctx, req.(*GetHeaderRequest))
@@ -364,6 +863,24 @@ func _API_GetHeader_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_GetBlockStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBlockStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetBlockStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/GetBlockStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetBlockStats(ctx, req.(*GetBlockStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _API_GetEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetEventsRequest)
 	if err := dec(in); err != nil {
@@ -374,7 +891,7 @@ func _API_GetEvents_Handler(srv interface{}, ctx context.Context, dec func(inter
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/API/GetEvents",
+		FullMethod: "/dps.API/GetEvents",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(APIServer).GetEvents(ctx, req.(*GetEventsRequest))
@@ -392,7 +909,7 @@ func _API_GetRegisterValues_Handler(srv interface{}, ctx context.Context, dec fu
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/API/GetRegisterValues",
+		FullMethod: "/dps.API/GetRegisterValues",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(APIServer).GetRegisterValues(ctx, req.(*GetRegisterValuesRequest))
@@ -400,6 +917,84 @@ func _API_GetRegisterValues_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_ListRegistersForOwner_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRegistersForOwnerRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(APIServer).ListRegistersForOwner(m, &aPIListRegistersForOwnerServer{stream})
+}
+
+type API_ListRegistersForOwnerServer interface {
+	Send(*ListRegistersForOwnerResponse) error
+	grpc.ServerStream
+}
+
+type aPIListRegistersForOwnerServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIListRegistersForOwnerServer) Send(m *ListRegistersForOwnerResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _API_DumpRegisters_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DumpRegistersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(APIServer).DumpRegisters(m, &aPIDumpRegistersServer{stream})
+}
+
+type API_DumpRegistersServer interface {
+	Send(*DumpRegistersResponse) error
+	grpc.ServerStream
+}
+
+type aPIDumpRegistersServer struct {
+	grpc.ServerStream
+}
+
+func (x *aPIDumpRegistersServer) Send(m *DumpRegistersResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _API_GetRegisterChurn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRegisterChurnRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetRegisterChurn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/GetRegisterChurn",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetRegisterChurn(ctx, req.(*GetRegisterChurnRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetRegisterDelta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRegisterDeltaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetRegisterDelta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/GetRegisterDelta",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetRegisterDelta(ctx, req.(*GetRegisterDeltaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _API_GetCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetCollectionRequest)
 	if err := dec(in); err != nil {
@@ -410,7 +1005,7 @@ func _API_GetCollection_Handler(srv interface{}, ctx context.Context, dec func(i
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/API/GetCollection",
+		FullMethod: "/dps.API/GetCollection",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(APIServer).GetCollection(ctx, req.(*GetCollectionRequest))
@@ -428,7 +1023,7 @@ func _API_ListCollectionsForHeight_Handler(srv interface{}, ctx context.Context,
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/API/ListCollectionsForHeight",
+		FullMethod: "/dps.API/ListCollectionsForHeight",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(APIServer).ListCollectionsForHeight(ctx, req.(*ListCollectionsForHeightRequest))
@@ -446,7 +1041,7 @@ func _API_GetGuarantee_Handler(srv interface{}, ctx context.Context, dec func(in
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/API/GetGuarantee",
+		FullMethod: "/dps.API/GetGuarantee",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(APIServer).GetGuarantee(ctx, req.(*GetGuaranteeRequest))
@@ -464,7 +1059,7 @@ func _API_GetTransaction_Handler(srv interface{}, ctx context.Context, dec func(
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/API/GetTransaction",
+		FullMethod: "/dps.API/GetTransaction",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(APIServer).GetTransaction(ctx, req.(*GetTransactionRequest))
@@ -482,7 +1077,7 @@ func _API_GetHeightForTransaction_Handler(srv interface{}, ctx context.Context,
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/API/GetHeightForTransaction",
+		FullMethod: "/dps.API/GetHeightForTransaction",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(APIServer).GetHeightForTransaction(ctx, req.(*GetHeightForTransactionRequest))
@@ -490,56 +1085,92 @@ func _API_GetHeightForTransaction_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
-func _API_ListTransactionsForHeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ListTransactionsForHeightRequest)
+func _API_GetHeightForCommit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHeightForCommitRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(APIServer).ListTransactionsForHeight(ctx, in)
+		return srv.(APIServer).GetHeightForCommit(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/API/ListTransactionsForHeight",
+		FullMethod: "/dps.API/GetHeightForCommit",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(APIServer).ListTransactionsForHeight(ctx, req.(*ListTransactionsForHeightRequest))
+		return srv.(APIServer).GetHeightForCommit(ctx, req.(*GetHeightForCommitRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _API_GetResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetResultRequest)
+func _API_ListTransactionsForHeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTransactionsForHeightRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(APIServer).GetResult(ctx, in)
+		return srv.(APIServer).ListTransactionsForHeight(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/API/GetResult",
+		FullMethod: "/dps.API/ListTransactionsForHeight",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(APIServer).GetResult(ctx, req.(*GetResultRequest))
+		return srv.(APIServer).ListTransactionsForHeight(ctx, req.(*ListTransactionsForHeightRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _API_GetSeal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetSealRequest)
+func _API_ListTransactionsForScript_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTransactionsForScriptRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(APIServer).GetSeal(ctx, in)
+		return srv.(APIServer).ListTransactionsForScript(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/API/GetSeal",
+		FullMethod: "/dps.API/ListTransactionsForScript",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(APIServer).GetSeal(ctx, req.(*GetSealRequest))
+		return srv.(APIServer).ListTransactionsForScript(ctx, req.(*ListTransactionsForScriptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetResultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/GetResult",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetResult(ctx, req.(*GetResultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetSeal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSealRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetSeal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/GetSeal",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetSeal(ctx, req.(*GetSealRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -554,7 +1185,7 @@ func _API_ListSealsForHeight_Handler(srv interface{}, ctx context.Context, dec f
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/API/ListSealsForHeight",
+		FullMethod: "/dps.API/ListSealsForHeight",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
 		return srv.(APIServer).ListSealsForHeight(ctx, req.(*ListSealsForHeightRequest))
@@ -562,11 +1193,299 @@ func _API_ListSealsForHeight_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _API_ListOwners_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOwnersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).ListOwners(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/ListOwners",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).ListOwners(ctx, req.(*ListOwnersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_ListEventTypes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEventTypesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).ListEventTypes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/ListEventTypes",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).ListEventTypes(ctx, req.(*ListEventTypesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetAccountAtHeight_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAccountAtHeightRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetAccountAtHeight(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/GetAccountAtHeight",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetAccountAtHeight(ctx, req.(*GetAccountAtHeightRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetContractHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetContractHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetContractHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/GetContractHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetContractHistory(ctx, req.(*GetContractHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetServiceEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetServiceEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetServiceEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/GetServiceEvents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetServiceEvents(ctx, req.(*GetServiceEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_ListEpochs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEpochsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).ListEpochs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/ListEpochs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).ListEpochs(ctx, req.(*ListEpochsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_ListIdentitiesForEpoch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListIdentitiesForEpochRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).ListIdentitiesForEpoch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/ListIdentitiesForEpoch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).ListIdentitiesForEpoch(ctx, req.(*ListIdentitiesForEpochRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/GetVersion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetVersion(ctx, req.(*GetVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/GetInfo",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetInfo(ctx, req.(*GetInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetRawHeader_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRawHeaderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetRawHeader(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/GetRawHeader",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetRawHeader(ctx, req.(*GetRawHeaderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetRawEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRawEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetRawEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/GetRawEvents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetRawEvents(ctx, req.(*GetRawEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetRawCollection_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRawCollectionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetRawCollection(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/GetRawCollection",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetRawCollection(ctx, req.(*GetRawCollectionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetRawGuarantee_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRawGuaranteeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetRawGuarantee(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/GetRawGuarantee",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetRawGuarantee(ctx, req.(*GetRawGuaranteeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetRawTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRawTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetRawTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/GetRawTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetRawTransaction(ctx, req.(*GetRawTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetRawResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRawResultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetRawResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/GetRawResult",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetRawResult(ctx, req.(*GetRawResultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _API_GetRawSeal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRawSealRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(APIServer).GetRawSeal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/dps.API/GetRawSeal",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(APIServer).GetRawSeal(ctx, req.(*GetRawSealRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // API_ServiceDesc is the grpc.ServiceDesc for API service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var API_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "API",
+	ServiceName: "dps.API",
 	HandlerType: (*APIServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
@@ -589,6 +1508,10 @@ var API_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetHeader",
 			Handler:    _API_GetHeader_Handler,
 		},
+		{
+			MethodName: "GetBlockStats",
+			Handler:    _API_GetBlockStats_Handler,
+		},
 		{
 			MethodName: "GetEvents",
 			Handler:    _API_GetEvents_Handler,
@@ -597,6 +1520,14 @@ var API_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetRegisterValues",
 			Handler:    _API_GetRegisterValues_Handler,
 		},
+		{
+			MethodName: "GetRegisterChurn",
+			Handler:    _API_GetRegisterChurn_Handler,
+		},
+		{
+			MethodName: "GetRegisterDelta",
+			Handler:    _API_GetRegisterDelta_Handler,
+		},
 		{
 			MethodName: "GetCollection",
 			Handler:    _API_GetCollection_Handler,
@@ -617,10 +1548,18 @@ var API_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetHeightForTransaction",
 			Handler:    _API_GetHeightForTransaction_Handler,
 		},
+		{
+			MethodName: "GetHeightForCommit",
+			Handler:    _API_GetHeightForCommit_Handler,
+		},
 		{
 			MethodName: "ListTransactionsForHeight",
 			Handler:    _API_ListTransactionsForHeight_Handler,
 		},
+		{
+			MethodName: "ListTransactionsForScript",
+			Handler:    _API_ListTransactionsForScript_Handler,
+		},
 		{
 			MethodName: "GetResult",
 			Handler:    _API_GetResult_Handler,
@@ -633,7 +1572,82 @@ var API_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListSealsForHeight",
 			Handler:    _API_ListSealsForHeight_Handler,
 		},
+		{
+			MethodName: "ListOwners",
+			Handler:    _API_ListOwners_Handler,
+		},
+		{
+			MethodName: "ListEventTypes",
+			Handler:    _API_ListEventTypes_Handler,
+		},
+		{
+			MethodName: "GetAccountAtHeight",
+			Handler:    _API_GetAccountAtHeight_Handler,
+		},
+		{
+			MethodName: "GetContractHistory",
+			Handler:    _API_GetContractHistory_Handler,
+		},
+		{
+			MethodName: "GetServiceEvents",
+			Handler:    _API_GetServiceEvents_Handler,
+		},
+		{
+			MethodName: "ListEpochs",
+			Handler:    _API_ListEpochs_Handler,
+		},
+		{
+			MethodName: "ListIdentitiesForEpoch",
+			Handler:    _API_ListIdentitiesForEpoch_Handler,
+		},
+		{
+			MethodName: "GetVersion",
+			Handler:    _API_GetVersion_Handler,
+		},
+		{
+			MethodName: "GetInfo",
+			Handler:    _API_GetInfo_Handler,
+		},
+		{
+			MethodName: "GetRawHeader",
+			Handler:    _API_GetRawHeader_Handler,
+		},
+		{
+			MethodName: "GetRawEvents",
+			Handler:    _API_GetRawEvents_Handler,
+		},
+		{
+			MethodName: "GetRawCollection",
+			Handler:    _API_GetRawCollection_Handler,
+		},
+		{
+			MethodName: "GetRawGuarantee",
+			Handler:    _API_GetRawGuarantee_Handler,
+		},
+		{
+			MethodName: "GetRawTransaction",
+			Handler:    _API_GetRawTransaction_Handler,
+		},
+		{
+			MethodName: "GetRawResult",
+			Handler:    _API_GetRawResult_Handler,
+		},
+		{
+			MethodName: "GetRawSeal",
+			Handler:    _API_GetRawSeal_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListRegistersForOwner",
+			Handler:       _API_ListRegistersForOwner_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "DumpRegisters",
+			Handler:       _API_DumpRegisters_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api.proto",
 }
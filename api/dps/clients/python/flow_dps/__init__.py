@@ -0,0 +1,6 @@
+# The generated modules use absolute imports relative to the protobuf root, so
+# we add this package's directory to the module search path to resolve them.
+import os
+import sys
+
+sys.path.append(os.path.dirname(__file__))
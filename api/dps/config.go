@@ -0,0 +1,64 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// DefaultConfig is the default configuration for the DPS API server.
+var DefaultConfig = Config{
+	ProtocolOnly: false,
+	Chains:       nil,
+	PageSize:     1000,
+}
+
+// Config contains optional parameters for the DPS API server.
+type Config struct {
+	ProtocolOnly bool
+	Chains       map[string]dps.Reader
+	PageSize     uint
+}
+
+// WithProtocolOnly makes the server reject requests for execution data, such
+// as state commitments, events, transaction results and register values, for
+// indexes that were built from the protocol state only.
+func WithProtocolOnly(protocol bool) func(*Config) {
+	return func(cfg *Config) {
+		cfg.ProtocolOnly = protocol
+	}
+}
+
+// WithChain adds an index reader that is used for requests with the given
+// chain ID. Requests without a chain ID are served from the default index
+// reader given to the server. Together with namespaces in the storage library,
+// this allows serving multiple chains or sporks from a single index database.
+func WithChain(chainID string, index dps.Reader) func(*Config) {
+	return func(cfg *Config) {
+		if cfg.Chains == nil {
+			cfg.Chains = make(map[string]dps.Reader)
+		}
+		cfg.Chains[chainID] = index
+	}
+}
+
+// WithPageSize sets the maximum number of items in each page of a streamed
+// listing, which is also the page size used when a request does not specify
+// one.
+func WithPageSize(size uint) func(*Config) {
+	return func(cfg *Config) {
+		cfg.PageSize = size
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/optakt/flow-dps/service/logs"
+)
+
+// DeadlineInterceptor returns a unary server interceptor that limits the
+// execution time of each call to the given duration, and logs the calls that
+// take longer than the given slow threshold along with their parameters. A
+// zero duration disables the corresponding feature.
+//
+// When a call exceeds its execution time, the context given to the handler is
+// canceled, which stops the scans of the index that run on its behalf, and the
+// interceptor returns a `DeadlineExceeded` status code once the handler gave up,
+// so that pathological queries do not keep a client connection busy
+// indefinitely.
+func DeadlineInterceptor(log zerolog.Logger, limit time.Duration, slow time.Duration) grpc.UnaryServerInterceptor {
+	return ReloadableDeadlineInterceptor(log, func() (time.Duration, time.Duration) {
+		return limit, slow
+	})
+}
+
+// ReloadableDeadlineInterceptor works like the deadline interceptor, but gets
+// the execution time limit and the slow threshold from the given callback on
+// each call, so that they can be changed while the server runs.
+func ReloadableDeadlineInterceptor(log zerolog.Logger, limits func() (time.Duration, time.Duration)) grpc.UnaryServerInterceptor {
+
+	log = log.With().Str(logs.Component, "api_deadline").Logger()
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		limit, slow := limits()
+		start := time.Now()
+		defer func() {
+			duration := time.Since(start)
+			if slow == 0 || duration < slow {
+				return
+			}
+			log.Warn().
+				Str("method", info.FullMethod).
+				Interface("request", req).
+				Dur("duration", duration).
+				Msg("slow query")
+		}()
+
+		if limit == 0 {
+			return handler(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, limit)
+		defer cancel()
+
+		res, err := handler(ctx, req)
+		err = exceeded(ctx, limit, err)
+		if err != nil {
+			return nil, err
+		}
+
+		return res, nil
+	}
+}
+
+// DeadlineStreamInterceptor returns a stream server interceptor that limits the
+// execution time of each streaming call, and logs the slow ones, just like
+// `DeadlineInterceptor` does for unary calls. The limit applies to the whole
+// stream, and the parameters of streaming calls are not logged, as they are
+// only received by the handler.
+func DeadlineStreamInterceptor(log zerolog.Logger, limit time.Duration, slow time.Duration) grpc.StreamServerInterceptor {
+	return ReloadableDeadlineStreamInterceptor(log, func() (time.Duration, time.Duration) {
+		return limit, slow
+	})
+}
+
+// ReloadableDeadlineStreamInterceptor works like the deadline stream
+// interceptor, but gets the execution time limit and the slow threshold from
+// the given callback on each call.
+func ReloadableDeadlineStreamInterceptor(log zerolog.Logger, limits func() (time.Duration, time.Duration)) grpc.StreamServerInterceptor {
+
+	log = log.With().Str(logs.Component, "api_deadline").Logger()
+
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		limit, slow := limits()
+		start := time.Now()
+		defer func() {
+			duration := time.Since(start)
+			if slow == 0 || duration < slow {
+				return
+			}
+			log.Warn().
+				Str("method", info.FullMethod).
+				Dur("duration", duration).
+				Msg("slow query")
+		}()
+
+		if limit == 0 {
+			return handler(srv, stream)
+		}
+
+		ctx, cancel := context.WithTimeout(stream.Context(), limit)
+		defer cancel()
+
+		wrapped := middleware.WrapServerStream(stream)
+		wrapped.WrappedContext = ctx
+
+		err := handler(srv, wrapped)
+		return exceeded(ctx, limit, err)
+	}
+}
+
+// exceeded returns a `DeadlineExceeded` status code if the execution time of a
+// handler exceeded the given limit, even if the handler completed anyway, and
+// the status code of the context error if the handler failed after its call
+// was canceled. Otherwise, it returns the error of the handler unchanged.
+func exceeded(ctx context.Context, limit time.Duration, err error) error {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return status.Errorf(codes.DeadlineExceeded, "execution time exceeded limit of %s", limit)
+	case err != nil && ctx.Err() != nil:
+		return status.FromContextError(ctx.Err()).Err()
+	default:
+		return err
+	}
+}
@@ -0,0 +1,285 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestDeadlineInterceptor(t *testing.T) {
+	info := grpc.UnaryServerInfo{FullMethod: "/dps.API/GetEvents"}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := DeadlineInterceptor(mocks.NoopLogger, time.Minute, 0)
+
+		var deadline bool
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			_, deadline = ctx.Deadline()
+			return req, nil
+		}
+
+		req := &GetEventsRequest{Height: mocks.GenericHeight}
+		res, err := interceptor(context.Background(), req, &info, handler)
+
+		require.NoError(t, err)
+		assert.Equal(t, req, res)
+		assert.True(t, deadline)
+	})
+
+	t.Run("handles disabled limit", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := DeadlineInterceptor(mocks.NoopLogger, 0, 0)
+
+		var deadline bool
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			_, deadline = ctx.Deadline()
+			return req, nil
+		}
+
+		_, err := interceptor(context.Background(), &GetEventsRequest{}, &info, handler)
+
+		require.NoError(t, err)
+		assert.False(t, deadline)
+	})
+
+	t.Run("handles handler failure", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := DeadlineInterceptor(mocks.NoopLogger, time.Minute, 0)
+
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, mocks.GenericError
+		}
+
+		_, err := interceptor(context.Background(), &GetEventsRequest{}, &info, handler)
+
+		assert.True(t, errors.Is(err, mocks.GenericError))
+	})
+
+	t.Run("handles exceeded limit", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := DeadlineInterceptor(mocks.NoopLogger, 10*time.Millisecond, 0)
+
+		returned := false
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			<-ctx.Done()
+			returned = true
+			return nil, ctx.Err()
+		}
+
+		_, err := interceptor(context.Background(), &GetEventsRequest{}, &info, handler)
+
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+		assert.True(t, returned)
+	})
+
+	t.Run("handles handler completing after limit", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := DeadlineInterceptor(mocks.NoopLogger, 10*time.Millisecond, 0)
+
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			time.Sleep(20 * time.Millisecond)
+			return req, nil
+		}
+
+		_, err := interceptor(context.Background(), &GetEventsRequest{}, &info, handler)
+
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	})
+
+	t.Run("handles canceled call", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := DeadlineInterceptor(mocks.NoopLogger, time.Minute, 0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			cancel()
+			return nil, ctx.Err()
+		}
+
+		_, err := interceptor(ctx, &GetEventsRequest{}, &info, handler)
+
+		assert.Equal(t, codes.Canceled, status.Code(err))
+	})
+
+	t.Run("logs slow queries", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		log := zerolog.New(&buf)
+		interceptor := DeadlineInterceptor(log, 0, 10*time.Millisecond)
+
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			time.Sleep(20 * time.Millisecond)
+			return req, nil
+		}
+
+		_, err := interceptor(context.Background(), &GetEventsRequest{Height: mocks.GenericHeight}, &info, handler)
+
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "slow query")
+		assert.Contains(t, buf.String(), "/dps.API/GetEvents")
+		assert.Contains(t, buf.String(), `"height":`)
+	})
+
+	t.Run("does not log fast queries", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		log := zerolog.New(&buf)
+		interceptor := DeadlineInterceptor(log, 0, time.Minute)
+
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return req, nil
+		}
+
+		_, err := interceptor(context.Background(), &GetEventsRequest{}, &info, handler)
+
+		require.NoError(t, err)
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("applies reloaded limits", func(t *testing.T) {
+		t.Parallel()
+
+		limit := time.Duration(0)
+		interceptor := ReloadableDeadlineInterceptor(mocks.NoopLogger, func() (time.Duration, time.Duration) {
+			return limit, 0
+		})
+
+		var deadline bool
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			_, deadline = ctx.Deadline()
+			return req, nil
+		}
+
+		_, err := interceptor(context.Background(), &GetEventsRequest{}, &info, handler)
+		require.NoError(t, err)
+		assert.False(t, deadline)
+
+		limit = time.Minute
+		_, err = interceptor(context.Background(), &GetEventsRequest{}, &info, handler)
+		require.NoError(t, err)
+		assert.True(t, deadline)
+	})
+}
+
+func TestDeadlineStreamInterceptor(t *testing.T) {
+	info := grpc.StreamServerInfo{FullMethod: "/dps.API/ListRegistersForOwner"}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := DeadlineStreamInterceptor(mocks.NoopLogger, time.Minute, 0)
+
+		var deadline bool
+		handler := func(srv interface{}, stream grpc.ServerStream) error {
+			_, deadline = stream.Context().Deadline()
+			return nil
+		}
+
+		err := interceptor(nil, &registersServerMock{}, &info, handler)
+
+		require.NoError(t, err)
+		assert.True(t, deadline)
+	})
+
+	t.Run("handles disabled limit", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := DeadlineStreamInterceptor(mocks.NoopLogger, 0, 0)
+
+		var deadline bool
+		handler := func(srv interface{}, stream grpc.ServerStream) error {
+			_, deadline = stream.Context().Deadline()
+			return nil
+		}
+
+		err := interceptor(nil, &registersServerMock{}, &info, handler)
+
+		require.NoError(t, err)
+		assert.False(t, deadline)
+	})
+
+	t.Run("handles handler failure", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := DeadlineStreamInterceptor(mocks.NoopLogger, time.Minute, 0)
+
+		handler := func(srv interface{}, stream grpc.ServerStream) error {
+			return mocks.GenericError
+		}
+
+		err := interceptor(nil, &registersServerMock{}, &info, handler)
+
+		assert.ErrorIs(t, err, mocks.GenericError)
+	})
+
+	t.Run("handles exceeded limit", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor := DeadlineStreamInterceptor(mocks.NoopLogger, 10*time.Millisecond, 0)
+
+		returned := false
+		handler := func(srv interface{}, stream grpc.ServerStream) error {
+			<-stream.Context().Done()
+			returned = true
+			return stream.Context().Err()
+		}
+
+		err := interceptor(nil, &registersServerMock{}, &info, handler)
+
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+		assert.True(t, returned)
+	})
+
+	t.Run("logs slow queries", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		log := zerolog.New(&buf)
+		interceptor := DeadlineStreamInterceptor(log, 0, 10*time.Millisecond)
+
+		handler := func(srv interface{}, stream grpc.ServerStream) error {
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		}
+
+		err := interceptor(nil, &registersServerMock{}, &info, handler)
+
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "slow query")
+		assert.Contains(t, buf.String(), "/dps.API/ListRegistersForOwner")
+	})
+}
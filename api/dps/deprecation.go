@@ -0,0 +1,104 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata keys used in response headers to signal to clients that the method
+// they called is deprecated.
+const (
+	DeprecatedKey   = "dps-deprecated"
+	ReplacementKey  = "dps-replacement"
+	RemovalKey      = "dps-removal"
+	deprecatedValue = "true"
+)
+
+// DeprecationInterceptor returns a unary server interceptor that adds
+// deprecation metadata to the response headers of calls to deprecated methods
+// of the API, or to any method of a deprecated service. This allows clients
+// built against older versions of the API to detect deprecated methods without
+// having to call `GetInfo`.
+func DeprecationInterceptor() grpc.UnaryServerInterceptor {
+
+	deprecations := make(map[string]*Deprecation)
+	for _, deprecation := range Deprecations() {
+		deprecations[deprecation.Method] = deprecation
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		// The server info always holds the current name of the method, even
+		// when it was called through the legacy service name, so we rely on
+		// the name of the method that was actually called whenever we can.
+		method, ok := grpc.Method(ctx)
+		if !ok || method == "" {
+			method = info.FullMethod
+		}
+
+		replacement, removal, ok := deprecated(deprecations, method)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		// We don't want a request to fail only because we could not signal the
+		// deprecation of the method, so we ignore the error here. It can only
+		// happen if the headers were already sent, or if there is no stream.
+		md := metadata.Pairs(
+			DeprecatedKey, deprecatedValue,
+			ReplacementKey, replacement,
+			RemovalKey, removal,
+		)
+		_ = grpc.SetHeader(ctx, md)
+
+		return handler(ctx, req)
+	}
+}
+
+// deprecated looks up the deprecation of the given full method name, first for
+// the method itself and then for its service. For a deprecated service, the
+// replacement is the same method in the service that replaces it, unless that
+// method is deprecated too.
+func deprecated(deprecations map[string]*Deprecation, method string) (string, string, bool) {
+
+	deprecation, ok := deprecations[method]
+	if ok {
+		return deprecation.Replacement, deprecation.Removal, true
+	}
+
+	index := strings.LastIndex(method, "/")
+	if index < 0 {
+		return "", "", false
+	}
+	deprecation, ok = deprecations[method[:index+1]]
+	if !ok {
+		return "", "", false
+	}
+
+	// If the same method is deprecated in the replacing service as well, the
+	// client should move straight to its replacement.
+	replacement := deprecation.Replacement + method[index+1:]
+	next, ok := deprecations[replacement]
+	if ok {
+		replacement = next.Replacement
+	}
+
+	return replacement, deprecation.Removal, true
+}
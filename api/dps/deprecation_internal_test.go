@@ -0,0 +1,150 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestDeprecationInterceptor(t *testing.T) {
+	interceptor := DeprecationInterceptor()
+
+	t.Run("deprecated method", func(t *testing.T) {
+		t.Parallel()
+
+		stream := &streamMock{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		info := grpc.UnaryServerInfo{FullMethod: "/dps.API/GetVersion"}
+
+		called := false
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			called = true
+			return req, nil
+		}
+
+		_, err := interceptor(ctx, &GetVersionRequest{}, &info, handler)
+
+		require.NoError(t, err)
+		assert.True(t, called)
+		assert.Equal(t, []string{"true"}, stream.header.Get(DeprecatedKey))
+		assert.Equal(t, []string{"/dps.API/GetInfo"}, stream.header.Get(ReplacementKey))
+		assert.Equal(t, []string{"2.0.0"}, stream.header.Get(RemovalKey))
+	})
+
+	t.Run("legacy service method", func(t *testing.T) {
+		t.Parallel()
+
+		stream := &streamMock{method: "/API/GetLast"}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		info := grpc.UnaryServerInfo{FullMethod: "/dps.API/GetLast"}
+
+		called := false
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			called = true
+			return req, nil
+		}
+
+		_, err := interceptor(ctx, &GetLastRequest{}, &info, handler)
+
+		require.NoError(t, err)
+		assert.True(t, called)
+		assert.Equal(t, []string{"true"}, stream.header.Get(DeprecatedKey))
+		assert.Equal(t, []string{"/dps.API/GetLast"}, stream.header.Get(ReplacementKey))
+		assert.Equal(t, []string{"2.0.0"}, stream.header.Get(RemovalKey))
+	})
+
+	t.Run("legacy service deprecated method", func(t *testing.T) {
+		t.Parallel()
+
+		stream := &streamMock{method: "/API/GetVersion"}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		info := grpc.UnaryServerInfo{FullMethod: "/dps.API/GetVersion"}
+
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return req, nil
+		}
+
+		_, err := interceptor(ctx, &GetVersionRequest{}, &info, handler)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"true"}, stream.header.Get(DeprecatedKey))
+		assert.Equal(t, []string{"/dps.API/GetInfo"}, stream.header.Get(ReplacementKey))
+	})
+
+	t.Run("supported method", func(t *testing.T) {
+		t.Parallel()
+
+		stream := &streamMock{}
+		ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+		info := grpc.UnaryServerInfo{FullMethod: "/dps.API/GetInfo"}
+
+		called := false
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			called = true
+			return req, nil
+		}
+
+		_, err := interceptor(ctx, &GetInfoRequest{}, &info, handler)
+
+		require.NoError(t, err)
+		assert.True(t, called)
+		assert.Empty(t, stream.header)
+	})
+
+	t.Run("handles missing stream", func(t *testing.T) {
+		t.Parallel()
+
+		info := grpc.UnaryServerInfo{FullMethod: "/dps.API/GetVersion"}
+
+		called := false
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			called = true
+			return req, nil
+		}
+
+		_, err := interceptor(context.Background(), &GetVersionRequest{}, &info, handler)
+
+		require.NoError(t, err)
+		assert.True(t, called)
+	})
+}
+
+type streamMock struct {
+	method string
+	header metadata.MD
+}
+
+func (s *streamMock) Method() string {
+	return s.method
+}
+
+func (s *streamMock) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *streamMock) SendHeader(md metadata.MD) error {
+	return nil
+}
+
+func (s *streamMock) SetTrailer(md metadata.MD) error {
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// Drain gracefully stops the given GRPC server, so that it stops accepting new
+// connections and requests, and waits for the in-flight requests to finish. If
+// they are not finished after the given timeout, they are cancelled and Drain
+// returns false. A timeout of zero waits for them indefinitely.
+func Drain(server *grpc.Server, timeout time.Duration) bool {
+
+	drained := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(drained)
+	}()
+
+	if timeout == 0 {
+		<-drained
+		return true
+	}
+
+	select {
+	case <-drained:
+		return true
+	case <-time.After(timeout):
+		server.Stop()
+		<-drained
+		return false
+	}
+}
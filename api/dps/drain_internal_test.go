@@ -0,0 +1,107 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestDrain(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		server, _ := drainServer(t, func(context.Context) {})
+
+		drained := Drain(server, time.Second)
+
+		assert.True(t, drained)
+	})
+
+	t.Run("waits for in-flight requests", func(t *testing.T) {
+		t.Parallel()
+
+		release := make(chan struct{})
+		server, started := drainServer(t, func(context.Context) {
+			<-release
+		})
+		<-started
+
+		result := make(chan bool)
+		go func() {
+			result <- Drain(server, time.Minute)
+		}()
+
+		select {
+		case <-result:
+			t.Fatal("server drained before in-flight request finished")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		close(release)
+		assert.True(t, <-result)
+	})
+
+	t.Run("cancels in-flight requests after timeout", func(t *testing.T) {
+		t.Parallel()
+
+		server, started := drainServer(t, func(ctx context.Context) {
+			<-ctx.Done()
+		})
+		<-started
+
+		drained := Drain(server, 50*time.Millisecond)
+
+		assert.False(t, drained)
+	})
+}
+
+// drainServer starts a GRPC server on an in-memory listener, and sends it a
+// single request that is handled by the given function. The returned channel is
+// closed once the server started handling the request.
+func drainServer(t *testing.T, handle func(context.Context)) (*grpc.Server, <-chan struct{}) {
+	t.Helper()
+
+	started := make(chan struct{})
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+		close(started)
+		handle(stream.Context())
+		return nil
+	}))
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	}
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(dialer), grpc.WithInsecure())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		_ = conn.Invoke(context.Background(), "/dps.API/Drain", &GetFirstRequest{}, &GetFirstResponse{})
+	}()
+
+	return server, started
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// ErrorInterceptor returns a unary server interceptor that converts the
+// sentinel errors of the DPS into the matching gRPC status codes, so that
+// clients can tell missing data apart from data that is not available yet or
+// from a failure of the index. Errors that already carry a status code, as
+// well as errors that match no sentinel error, are returned unchanged.
+func ErrorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		res, err := handler(ctx, req)
+		if err == nil {
+			return res, nil
+		}
+
+		_, ok := status.FromError(err)
+		if ok {
+			return res, err
+		}
+
+		return res, status.Error(code(err), err.Error())
+	}
+}
+
+// ErrorStreamInterceptor returns a stream server interceptor that converts the
+// sentinel errors of the DPS into the matching gRPC status codes, just like
+// `ErrorInterceptor` does for unary calls.
+func ErrorStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		err := handler(srv, stream)
+		if err == nil {
+			return nil
+		}
+
+		_, ok := status.FromError(err)
+		if ok {
+			return err
+		}
+
+		return status.Error(code(err), err.Error())
+	}
+}
+
+// code returns the gRPC status code matching the sentinel error wrapped by the
+// given error.
+func code(err error) codes.Code {
+	switch {
+	case errors.Is(err, dps.ErrNotIndexed):
+		return codes.NotFound
+	case errors.Is(err, dps.ErrPruned):
+		return codes.OutOfRange
+	case errors.Is(err, dps.ErrBootstrapping), errors.Is(err, dps.ErrUnavailable), errors.Is(err, dps.ErrCold):
+		return codes.Unavailable
+	case errors.Is(err, dps.ErrCorrupted):
+		return codes.DataLoss
+	default:
+		return codes.Unknown
+	}
+}
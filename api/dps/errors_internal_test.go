@@ -0,0 +1,124 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestErrorInterceptor(t *testing.T) {
+	interceptor := ErrorInterceptor()
+	info := grpc.UnaryServerInfo{FullMethod: "/dps.API/GetHeader"}
+
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{name: "not indexed", err: dps.ErrNotIndexed, code: codes.NotFound},
+		{name: "pruned", err: dps.ErrPruned, code: codes.OutOfRange},
+		{name: "bootstrapping", err: dps.ErrBootstrapping, code: codes.Unavailable},
+		{name: "unavailable", err: dps.ErrUnavailable, code: codes.Unavailable},
+		{name: "cold", err: dps.ErrCold, code: codes.Unavailable},
+		{name: "corrupted", err: dps.ErrCorrupted, code: codes.DataLoss},
+		{name: "generic error", err: mocks.GenericError, code: codes.Unknown},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := fmt.Errorf("could not get header: %w", test.err)
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, err
+			}
+
+			_, got := interceptor(context.Background(), &GetHeaderRequest{}, &info, handler)
+
+			require.Error(t, got)
+			assert.Equal(t, test.code, status.Code(got))
+			assert.Equal(t, err.Error(), status.Convert(got).Message())
+		})
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		res := &GetHeaderResponse{}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return res, nil
+		}
+
+		got, err := interceptor(context.Background(), &GetHeaderRequest{}, &info, handler)
+
+		require.NoError(t, err)
+		assert.Same(t, res, got)
+	})
+
+	t.Run("keeps existing status code", func(t *testing.T) {
+		t.Parallel()
+
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, status.Error(codes.InvalidArgument, "invalid height")
+		}
+
+		_, err := interceptor(context.Background(), &GetHeaderRequest{}, &info, handler)
+
+		require.Error(t, err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestErrorStreamInterceptor(t *testing.T) {
+	interceptor := ErrorStreamInterceptor()
+	info := grpc.StreamServerInfo{FullMethod: "/dps.API/ListRegistersForOwner"}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		handler := func(srv interface{}, stream grpc.ServerStream) error {
+			return nil
+		}
+
+		err := interceptor(nil, nil, &info, handler)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("converts sentinel error", func(t *testing.T) {
+		t.Parallel()
+
+		handler := func(srv interface{}, stream grpc.ServerStream) error {
+			return fmt.Errorf("could not get registers: %w", dps.ErrBootstrapping)
+		}
+
+		err := interceptor(nil, nil, &info, handler)
+
+		require.Error(t, err)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+	})
+}
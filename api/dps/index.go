@@ -18,6 +18,9 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/proto"
+
 	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/model/flow"
 
@@ -47,10 +50,10 @@ func IndexFromAPI(client APIClient, codec dps.Codec) *Index {
 }
 
 // First returns the height of the first finalized block that was indexed.
-func (i *Index) First() (uint64, error) {
+func (i *Index) First(ctx context.Context) (uint64, error) {
 
 	req := GetFirstRequest{}
-	res, err := i.client.GetFirst(context.Background(), &req)
+	res, err := i.client.GetFirst(ctx, &req)
 	if err != nil {
 		return 0, fmt.Errorf("could not get first height: %w", err)
 	}
@@ -59,10 +62,10 @@ func (i *Index) First() (uint64, error) {
 }
 
 // Last returns the height of the last finalized block that was indexed.
-func (i *Index) Last() (uint64, error) {
+func (i *Index) Last(ctx context.Context) (uint64, error) {
 
 	req := GetLastRequest{}
-	res, err := i.client.GetLast(context.Background(), &req)
+	res, err := i.client.GetLast(ctx, &req)
 	if err != nil {
 		return 0, fmt.Errorf("could not get last height: %w", err)
 	}
@@ -71,12 +74,12 @@ func (i *Index) Last() (uint64, error) {
 }
 
 // HeightForBlock returns the height of the given blockID.
-func (i *Index) HeightForBlock(blockID flow.Identifier) (uint64, error) {
+func (i *Index) HeightForBlock(ctx context.Context, blockID flow.Identifier) (uint64, error) {
 
 	req := GetHeightForBlockRequest{
 		BlockID: blockID[:],
 	}
-	res, err := i.client.GetHeightForBlock(context.Background(), &req)
+	res, err := i.client.GetHeightForBlock(ctx, &req)
 	if err != nil {
 		return 0, fmt.Errorf("could not get height: %w", err)
 	}
@@ -86,12 +89,12 @@ func (i *Index) HeightForBlock(blockID flow.Identifier) (uint64, error) {
 
 // Commit returns the commitment of the execution state as it was after the
 // execution of the finalized block at the given height.
-func (i *Index) Commit(height uint64) (flow.StateCommitment, error) {
+func (i *Index) Commit(ctx context.Context, height uint64) (flow.StateCommitment, error) {
 
 	req := GetCommitRequest{
 		Height: height,
 	}
-	res, err := i.client.GetCommit(context.Background(), &req)
+	res, err := i.client.GetCommit(ctx, &req)
 	if err != nil {
 		return flow.DummyStateCommitment, fmt.Errorf("could not get commit: %w", err)
 	}
@@ -105,12 +108,12 @@ func (i *Index) Commit(height uint64) (flow.StateCommitment, error) {
 }
 
 // Header returns the header for the finalized block at the given height.
-func (i *Index) Header(height uint64) (*flow.Header, error) {
+func (i *Index) Header(ctx context.Context, height uint64) (*flow.Header, error) {
 
 	req := GetHeaderRequest{
 		Height: height,
 	}
-	res, err := i.client.GetHeader(context.Background(), &req)
+	res, err := i.client.GetHeader(ctx, &req)
 	if err != nil {
 		return nil, fmt.Errorf("could not get header: %w", err)
 	}
@@ -124,17 +127,45 @@ func (i *Index) Header(height uint64) (*flow.Header, error) {
 	return &header, nil
 }
 
+// BlockStats returns the aggregate statistics of the finalized blocks between
+// the given start and end heights.
+func (i *Index) BlockStats(ctx context.Context, start uint64, end uint64) ([]dps.BlockStats, error) {
+
+	req := GetBlockStatsRequest{
+		StartHeight: start,
+		EndHeight:   end,
+	}
+	res, err := i.client.GetBlockStats(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get block stats: %w", err)
+	}
+
+	stats := make([]dps.BlockStats, 0, len(res.Stats))
+	for _, entry := range res.Stats {
+		stats = append(stats, dps.BlockStats{
+			Height:       entry.Height,
+			Transactions: entry.Transactions,
+			Events:       entry.Events,
+			Fees:         entry.Fees,
+			Computation:  entry.Computation,
+			Senders:      entry.Senders,
+		})
+	}
+
+	return stats, nil
+}
+
 // Values returns the Ledger values of the execution state at the given paths
 // as they were after the execution of the finalized block at the given height.
 // For compatibility with existing Flow execution node code, a path that is not
 // found within the indexed execution state returns a nil value without error.
-func (i *Index) Values(height uint64, paths []ledger.Path) ([]ledger.Value, error) {
+func (i *Index) Values(ctx context.Context, height uint64, paths []ledger.Path) ([]ledger.Value, error) {
 
 	req := GetRegisterValuesRequest{
 		Height: height,
 		Paths:  convert.PathsToBytes(paths),
 	}
-	res, err := i.client.GetRegisterValues(context.Background(), &req)
+	res, err := i.client.GetRegisterValues(ctx, &req)
 	if err != nil {
 		return nil, fmt.Errorf("could not get registers: %w", err)
 	}
@@ -144,13 +175,185 @@ func (i *Index) Values(height uint64, paths []ledger.Path) ([]ledger.Value, erro
 	return values, nil
 }
 
+// Registers returns up to the given number of registers owned by the account
+// with the given address, as they were after the execution of the finalized
+// block at the given height, starting after the given path. It only reads the
+// first page streamed by the API, so fewer registers than the given number
+// might be returned if the server uses a smaller page size.
+func (i *Index) Registers(ctx context.Context, height uint64, owner flow.Address, after ledger.Path, limit uint) ([]dps.Register, error) {
+
+	req := ListRegistersForOwnerRequest{
+		Height: height,
+		Owner:  owner.Bytes(),
+		Limit:  uint32(limit),
+	}
+	if after != (ledger.Path{}) {
+		req.Cursor = after[:]
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := i.client.ListRegistersForOwner(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not list registers: %w", err)
+	}
+	res, err := stream.Recv()
+	if err != nil {
+		return nil, fmt.Errorf("could not receive registers: %w", err)
+	}
+
+	registers := make([]dps.Register, 0, len(res.Registers))
+	for _, msg := range res.Registers {
+		register, err := messageToRegister(owner, msg)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert register: %w", err)
+		}
+		registers = append(registers, register)
+	}
+
+	return registers, nil
+}
+
+// DumpRegisters returns up to the given number of registers of the execution
+// state, as they were after the execution of the finalized block at the given
+// height, starting after the given path. It requests the registers compressed
+// and only reads the first chunk streamed by the API, so fewer registers than
+// the given number might be returned if the server uses a smaller chunk size.
+func (i *Index) DumpRegisters(ctx context.Context, height uint64, after ledger.Path, limit uint) ([]dps.Register, error) {
+
+	req := DumpRegistersRequest{
+		Height:   height,
+		Limit:    uint32(limit),
+		Compress: true,
+	}
+	if after != (ledger.Path{}) {
+		req.Cursor = after[:]
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := i.client.DumpRegisters(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not dump registers: %w", err)
+	}
+	res, err := stream.Recv()
+	if err != nil {
+		return nil, fmt.Errorf("could not receive registers: %w", err)
+	}
+
+	msgs := res.Registers
+	if len(res.Compressed) > 0 {
+		decompressor, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, fmt.Errorf("could not create decompressor: %w", err)
+		}
+		defer decompressor.Close()
+		data, err := decompressor.DecodeAll(res.Compressed, nil)
+		if err != nil {
+			return nil, fmt.Errorf("could not decompress registers: %w", err)
+		}
+		var chunk RegisterChunk
+		err = proto.Unmarshal(data, &chunk)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode registers: %w", err)
+		}
+		msgs = chunk.Registers
+	}
+
+	registers := make([]dps.Register, 0, len(msgs))
+	for _, msg := range msgs {
+		register, err := messageToDump(msg)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert register: %w", err)
+		}
+		registers = append(registers, register)
+	}
+
+	return registers, nil
+}
+
+// RegisterChurn returns the report of the register writes between the given
+// start and end heights, with up to the given number of owners and registers
+// that were written the most.
+func (i *Index) RegisterChurn(ctx context.Context, start uint64, end uint64, limit uint) (*dps.ChurnReport, error) {
+
+	req := GetRegisterChurnRequest{
+		StartHeight: start,
+		EndHeight:   end,
+		Limit:       uint32(limit),
+	}
+	res, err := i.client.GetRegisterChurn(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get register churn: %w", err)
+	}
+
+	report := dps.ChurnReport{
+		StartHeight: res.StartHeight,
+		EndHeight:   res.EndHeight,
+		Writes:      res.Writes,
+		Owners:      make([]dps.OwnerWrites, 0, len(res.Owners)),
+		Registers:   make([]dps.RegisterWrites, 0, len(res.Registers)),
+	}
+	for _, entry := range res.Owners {
+		report.Owners = append(report.Owners, dps.OwnerWrites{
+			Owner:  flow.BytesToAddress(entry.Owner),
+			Writes: entry.Writes,
+		})
+	}
+	for _, entry := range res.Registers {
+		report.Registers = append(report.Registers, dps.RegisterWrites{
+			Owner:  flow.BytesToAddress(entry.Owner),
+			Key:    string(entry.Key),
+			Writes: entry.Writes,
+		})
+	}
+
+	return &report, nil
+}
+
+// RegisterDeltas returns up to the given number of changes to the registers
+// written by the finalized block at the given height, starting after the given
+// path.
+func (i *Index) RegisterDeltas(ctx context.Context, height uint64, after ledger.Path, limit uint) ([]dps.RegisterDelta, error) {
+
+	req := GetRegisterDeltaRequest{
+		Height: height,
+		Limit:  uint32(limit),
+	}
+	if after != (ledger.Path{}) {
+		req.Cursor = after[:]
+	}
+	res, err := i.client.GetRegisterDelta(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get register deltas: %w", err)
+	}
+
+	deltas := make([]dps.RegisterDelta, 0, len(res.Deltas))
+	for _, msg := range res.Deltas {
+		path, err := ledger.ToPath(msg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert path: %w", err)
+		}
+		deltas = append(deltas, dps.RegisterDelta{
+			Height:   res.Height,
+			Path:     path,
+			Previous: msg.Previous,
+			Current:  msg.Current,
+		})
+	}
+
+	return deltas, nil
+}
+
 // Collection returns the collection with the given ID.
-func (i *Index) Collection(collID flow.Identifier) (*flow.LightCollection, error) {
+func (i *Index) Collection(ctx context.Context, collID flow.Identifier) (*flow.LightCollection, error) {
 
 	req := GetCollectionRequest{
 		CollectionID: collID[:],
 	}
-	res, err := i.client.GetCollection(context.Background(), &req)
+	res, err := i.client.GetCollection(ctx, &req)
 	if err != nil {
 		return nil, fmt.Errorf("could not get collection: %w", err)
 	}
@@ -165,12 +368,12 @@ func (i *Index) Collection(collID flow.Identifier) (*flow.LightCollection, error
 }
 
 // CollectionsByHeight returns the transaction IDs within the given block.
-func (i *Index) CollectionsByHeight(height uint64) ([]flow.Identifier, error) {
+func (i *Index) CollectionsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error) {
 
 	req := ListCollectionsForHeightRequest{
 		Height: height,
 	}
-	res, err := i.client.ListCollectionsForHeight(context.Background(), &req)
+	res, err := i.client.ListCollectionsForHeight(ctx, &req)
 	if err != nil {
 		return nil, fmt.Errorf("could not get transactions: %w", err)
 	}
@@ -184,12 +387,12 @@ func (i *Index) CollectionsByHeight(height uint64) ([]flow.Identifier, error) {
 }
 
 // Guarantee returns the collection guarantee for the given collection ID.
-func (i *Index) Guarantee(collID flow.Identifier) (*flow.CollectionGuarantee, error) {
+func (i *Index) Guarantee(ctx context.Context, collID flow.Identifier) (*flow.CollectionGuarantee, error) {
 
 	req := GetGuaranteeRequest{
 		CollectionID: collID[:],
 	}
-	res, err := i.client.GetGuarantee(context.Background(), &req)
+	res, err := i.client.GetGuarantee(ctx, &req)
 	if err != nil {
 		return nil, fmt.Errorf("could not get guarantee: %w", err)
 	}
@@ -204,12 +407,12 @@ func (i *Index) Guarantee(collID flow.Identifier) (*flow.CollectionGuarantee, er
 }
 
 // Transaction returns the transaction with the given ID.
-func (i *Index) Transaction(txID flow.Identifier) (*flow.TransactionBody, error) {
+func (i *Index) Transaction(ctx context.Context, txID flow.Identifier) (*flow.TransactionBody, error) {
 
 	req := GetTransactionRequest{
 		TransactionID: txID[:],
 	}
-	res, err := i.client.GetTransaction(context.Background(), &req)
+	res, err := i.client.GetTransaction(ctx, &req)
 	if err != nil {
 		return nil, fmt.Errorf("could not get transaction: %w", err)
 	}
@@ -224,12 +427,27 @@ func (i *Index) Transaction(txID flow.Identifier) (*flow.TransactionBody, error)
 }
 
 // HeightForTransaction returns the height of the given transaction ID.
-func (i *Index) HeightForTransaction(txID flow.Identifier) (uint64, error) {
+func (i *Index) HeightForTransaction(ctx context.Context, txID flow.Identifier) (uint64, error) {
 
 	req := GetHeightForTransactionRequest{
 		TransactionID: txID[:],
 	}
-	res, err := i.client.GetHeightForTransaction(context.Background(), &req)
+	res, err := i.client.GetHeightForTransaction(ctx, &req)
+	if err != nil {
+		return 0, fmt.Errorf("could not get height: %w", err)
+	}
+
+	return res.Height, nil
+}
+
+// HeightForCommit returns the first height at which the execution state had
+// the given state commitment.
+func (i *Index) HeightForCommit(ctx context.Context, commit flow.StateCommitment) (uint64, error) {
+
+	req := GetHeightForCommitRequest{
+		Commit: commit[:],
+	}
+	res, err := i.client.GetHeightForCommit(ctx, &req)
 	if err != nil {
 		return 0, fmt.Errorf("could not get height: %w", err)
 	}
@@ -238,12 +456,12 @@ func (i *Index) HeightForTransaction(txID flow.Identifier) (uint64, error) {
 }
 
 // TransactionsByHeight returns the transaction IDs within the given block.
-func (i *Index) TransactionsByHeight(height uint64) ([]flow.Identifier, error) {
+func (i *Index) TransactionsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error) {
 
 	req := ListTransactionsForHeightRequest{
 		Height: height,
 	}
-	res, err := i.client.ListTransactionsForHeight(context.Background(), &req)
+	res, err := i.client.ListTransactionsForHeight(ctx, &req)
 	if err != nil {
 		return nil, fmt.Errorf("could not get transactions: %w", err)
 	}
@@ -256,13 +474,35 @@ func (i *Index) TransactionsByHeight(height uint64) ([]flow.Identifier, error) {
 	return txIDs, nil
 }
 
+// TransactionsForScript returns the identifiers of the transactions whose
+// script has the given hash, between the given start and end heights.
+func (i *Index) TransactionsForScript(ctx context.Context, hash flow.Identifier, start uint64, end uint64) ([]flow.Identifier, error) {
+
+	req := ListTransactionsForScriptRequest{
+		ScriptHash:  hash[:],
+		StartHeight: start,
+		EndHeight:   end,
+	}
+	res, err := i.client.ListTransactionsForScript(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not list transactions for script: %w", err)
+	}
+
+	txIDs := make([]flow.Identifier, 0, len(res.TransactionIDs))
+	for _, txID := range res.TransactionIDs {
+		txIDs = append(txIDs, flow.HashToID(txID))
+	}
+
+	return txIDs, nil
+}
+
 // Result returns the result for a given transaction ID.
-func (i *Index) Result(txID flow.Identifier) (*flow.TransactionResult, error) {
+func (i *Index) Result(ctx context.Context, txID flow.Identifier) (*flow.TransactionResult, error) {
 
 	req := GetResultRequest{
 		TransactionID: txID[:],
 	}
-	res, err := i.client.GetResult(context.Background(), &req)
+	res, err := i.client.GetResult(ctx, &req)
 	if err != nil {
 		return nil, fmt.Errorf("could not get transaction result: %w", err)
 	}
@@ -279,14 +519,14 @@ func (i *Index) Result(txID flow.Identifier) (*flow.TransactionResult, error) {
 // Events returns the events of all transactions that were part of the
 // finalized block at the given height. It can optionally filter them by event
 // type; if no event types are given, all events are returned.
-func (i *Index) Events(height uint64, types ...flow.EventType) ([]flow.Event, error) {
+func (i *Index) Events(ctx context.Context, height uint64, types ...flow.EventType) ([]flow.Event, error) {
 	tt := convert.TypesToStrings(types)
 
 	req := GetEventsRequest{
 		Height: height,
 		Types:  tt,
 	}
-	res, err := i.client.GetEvents(context.Background(), &req)
+	res, err := i.client.GetEvents(ctx, &req)
 	if err != nil {
 		return nil, fmt.Errorf("could not get events: %w", err)
 	}
@@ -301,12 +541,12 @@ func (i *Index) Events(height uint64, types ...flow.EventType) ([]flow.Event, er
 }
 
 // Seal returns the seal with the given ID.
-func (i *Index) Seal(sealID flow.Identifier) (*flow.Seal, error) {
+func (i *Index) Seal(ctx context.Context, sealID flow.Identifier) (*flow.Seal, error) {
 
 	req := GetSealRequest{
 		SealID: sealID[:],
 	}
-	res, err := i.client.GetSeal(context.Background(), &req)
+	res, err := i.client.GetSeal(ctx, &req)
 	if err != nil {
 		return nil, fmt.Errorf("could not get seal: %w", err)
 	}
@@ -321,12 +561,12 @@ func (i *Index) Seal(sealID flow.Identifier) (*flow.Seal, error) {
 }
 
 // SealsByHeight returns the seal IDs at the given height.
-func (i *Index) SealsByHeight(height uint64) ([]flow.Identifier, error) {
+func (i *Index) SealsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error) {
 
 	req := ListSealsForHeightRequest{
 		Height: height,
 	}
-	res, err := i.client.ListSealsForHeight(context.Background(), &req)
+	res, err := i.client.ListSealsForHeight(ctx, &req)
 	if err != nil {
 		return nil, fmt.Errorf("could not get seals: %w", err)
 	}
@@ -338,3 +578,175 @@ func (i *Index) SealsByHeight(height uint64) ([]flow.Identifier, error) {
 
 	return sealIDs, nil
 }
+
+// Filter returns the filter that restricted the indexed data to the data
+// involving some accounts.
+func (i *Index) Filter(ctx context.Context) (dps.Filter, error) {
+
+	req := ListOwnersRequest{}
+	res, err := i.client.ListOwners(ctx, &req)
+	if err != nil {
+		return dps.Filter{}, fmt.Errorf("could not list owners: %w", err)
+	}
+
+	var filter dps.Filter
+	for _, owner := range res.Owners {
+		filter.Allowed = append(filter.Allowed, flow.BytesToAddress(owner))
+	}
+	for _, owner := range res.Denied {
+		filter.Denied = append(filter.Denied, flow.BytesToAddress(owner))
+	}
+
+	return filter, nil
+}
+
+// Account returns the metadata of the account with the given address.
+func (i *Index) Account(ctx context.Context, address flow.Address) (*dps.Account, error) {
+
+	// Accounts are never removed, so the account as it is at the last indexed
+	// height includes all of its indexed metadata.
+	last, err := i.Last(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get last height: %w", err)
+	}
+
+	req := GetAccountAtHeightRequest{
+		Address: address.Bytes(),
+		Height:  last,
+	}
+	res, err := i.client.GetAccountAtHeight(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get account: %w", err)
+	}
+
+	account := dps.Account{
+		Address:    address,
+		Created:    res.Created,
+		KeyUpdates: res.KeyUpdates,
+	}
+
+	return &account, nil
+}
+
+// ContractHistory returns all changes to the contract with the given name on
+// the account with the given address.
+func (i *Index) ContractHistory(ctx context.Context, address flow.Address, name string) ([]dps.ContractVersion, error) {
+
+	req := GetContractHistoryRequest{
+		Address: address.Bytes(),
+		Name:    name,
+	}
+	res, err := i.client.GetContractHistory(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get contract history: %w", err)
+	}
+
+	versions := make([]dps.ContractVersion, 0, len(res.Versions))
+	for _, version := range res.Versions {
+		versions = append(versions, dps.ContractVersion{
+			Address:       address,
+			Name:          name,
+			Height:        version.Height,
+			TransactionID: flow.HashToID(version.TransactionID),
+			CodeHash:      version.CodeHash,
+			Removed:       version.Removed,
+		})
+	}
+
+	return versions, nil
+}
+
+// ServiceEvents returns the service events emitted between the given start and
+// end heights, inclusively, that have one of the given types. If no types are
+// given, all service events are returned.
+func (i *Index) ServiceEvents(ctx context.Context, start uint64, end uint64, types ...flow.EventType) ([]dps.ServiceEvent, error) {
+	tt := convert.TypesToStrings(types)
+
+	req := GetServiceEventsRequest{
+		StartHeight: start,
+		EndHeight:   end,
+		Types:       tt,
+	}
+	res, err := i.client.GetServiceEvents(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get service events: %w", err)
+	}
+
+	var events []dps.ServiceEvent
+	err = i.codec.Unmarshal(res.Data, &events)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode service events: %w", err)
+	}
+
+	return events, nil
+}
+
+// Epochs returns all indexed epochs, along with the heights at which each of
+// their phases started.
+func (i *Index) Epochs(ctx context.Context) ([]dps.Epoch, error) {
+
+	req := ListEpochsRequest{}
+	res, err := i.client.ListEpochs(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not list epochs: %w", err)
+	}
+
+	epochs := make([]dps.Epoch, 0, len(res.Epochs))
+	for _, epoch := range res.Epochs {
+		epochs = append(epochs, dps.Epoch{
+			Counter:            epoch.Counter,
+			FirstView:          epoch.FirstView,
+			DKGPhase1FinalView: epoch.DkgPhase1FinalView,
+			DKGPhase2FinalView: epoch.DkgPhase2FinalView,
+			DKGPhase3FinalView: epoch.DkgPhase3FinalView,
+			FinalView:          epoch.FinalView,
+			StakingHeight:      epoch.StakingHeight,
+			SetupHeight:        epoch.SetupHeight,
+			CommittedHeight:    epoch.CommittedHeight,
+		})
+	}
+
+	return epochs, nil
+}
+
+// Identities returns the identity table of the epoch with the given counter.
+func (i *Index) Identities(ctx context.Context, counter uint64) (flow.IdentityList, error) {
+
+	req := ListIdentitiesForEpochRequest{
+		Counter: counter,
+	}
+	res, err := i.client.ListIdentitiesForEpoch(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not list identities: %w", err)
+	}
+
+	var identities flow.IdentityList
+	err = i.codec.Unmarshal(res.Data, &identities)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode identities: %w", err)
+	}
+
+	return identities, nil
+}
+
+// EventTypes returns the statistics of all event types that were indexed.
+func (i *Index) EventTypes(ctx context.Context) ([]dps.EventTypeStats, error) {
+
+	req := ListEventTypesRequest{}
+	res, err := i.client.ListEventTypes(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not list event types: %w", err)
+	}
+
+	stats := make([]dps.EventTypeStats, 0, len(res.Types))
+	for _, typ := range res.Types {
+		stats = append(stats, dps.EventTypeStats{
+			Type:  flow.EventType(typ.Type),
+			First: typ.First,
+			Last:  typ.Last,
+			Count: typ.Count,
+		})
+	}
+
+	return stats, nil
+}
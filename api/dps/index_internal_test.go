@@ -19,11 +19,17 @@ import (
 	"testing"
 
 	"github.com/fxamacker/cbor/v2"
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
 
 	"github.com/optakt/flow-dps/models/convert"
+	"github.com/optakt/flow-dps/models/dps"
 	"github.com/optakt/flow-dps/testing/mocks"
 )
 
@@ -54,7 +60,7 @@ func TestIndex_First(t *testing.T) {
 			},
 		}
 
-		got, err := index.First()
+		got, err := index.First(context.Background())
 
 		require.NoError(t, err)
 		assert.Equal(t, mocks.GenericHeight, got)
@@ -71,7 +77,7 @@ func TestIndex_First(t *testing.T) {
 			},
 		}
 
-		_, err := index.First()
+		_, err := index.First(context.Background())
 		assert.Error(t, err)
 	})
 }
@@ -92,7 +98,7 @@ func TestIndex_Last(t *testing.T) {
 			},
 		}
 
-		got, err := index.Last()
+		got, err := index.Last(context.Background())
 
 		require.NoError(t, err)
 		assert.Equal(t, mocks.GenericHeight, got)
@@ -109,7 +115,7 @@ func TestIndex_Last(t *testing.T) {
 			},
 		}
 
-		_, err := index.Last()
+		_, err := index.Last(context.Background())
 		assert.Error(t, err)
 	})
 }
@@ -146,7 +152,7 @@ func TestIndex_Header(t *testing.T) {
 			},
 		}
 
-		got, err := index.Header(header.Height)
+		got, err := index.Header(context.Background(), header.Height)
 
 		require.NoError(t, err)
 		assert.Equal(t, header, got)
@@ -164,7 +170,7 @@ func TestIndex_Header(t *testing.T) {
 			},
 		}
 
-		_, err := index.Header(header.Height)
+		_, err := index.Header(context.Background(), header.Height)
 
 		assert.Error(t, err)
 	})
@@ -189,7 +195,249 @@ func TestIndex_Header(t *testing.T) {
 			},
 		}
 
-		_, err := index.Header(header.Height)
+		_, err := index.Header(context.Background(), header.Height)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_BlockStats(t *testing.T) {
+	stats := mocks.GenericBlockStats(3)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		entries := make([]*BlockStats, 0, len(stats))
+		for _, entry := range stats {
+			entries = append(entries, &BlockStats{
+				Height:       entry.Height,
+				Transactions: entry.Transactions,
+				Events:       entry.Events,
+				Fees:         entry.Fees,
+				Computation:  entry.Computation,
+				Senders:      entry.Senders,
+			})
+		}
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				GetBlockStatsFunc: func(_ context.Context, in *GetBlockStatsRequest, _ ...grpc.CallOption) (*GetBlockStatsResponse, error) {
+					assert.Equal(t, mocks.GenericHeight, in.StartHeight)
+					assert.Equal(t, mocks.GenericHeight+2, in.EndHeight)
+
+					return &GetBlockStatsResponse{
+						StartHeight: in.StartHeight,
+						EndHeight:   in.EndHeight,
+						Stats:       entries,
+					}, nil
+				},
+			},
+		}
+
+		got, err := index.BlockStats(context.Background(), mocks.GenericHeight, mocks.GenericHeight+2)
+
+		require.NoError(t, err)
+		assert.Equal(t, stats, got)
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				GetBlockStatsFunc: func(context.Context, *GetBlockStatsRequest, ...grpc.CallOption) (*GetBlockStatsResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.BlockStats(context.Background(), mocks.GenericHeight, mocks.GenericHeight+2)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_RegisterChurn(t *testing.T) {
+	report := dps.MergeChurn(mocks.GenericHeight, mocks.GenericHeight+1, mocks.GenericRegisterChurns(2), 10)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		owners := make([]*OwnerWrites, 0, len(report.Owners))
+		for _, entry := range report.Owners {
+			owners = append(owners, &OwnerWrites{
+				Owner:  entry.Owner.Bytes(),
+				Writes: entry.Writes,
+			})
+		}
+		registers := make([]*RegisterWrites, 0, len(report.Registers))
+		for _, entry := range report.Registers {
+			registers = append(registers, &RegisterWrites{
+				Owner:  entry.Owner.Bytes(),
+				Key:    []byte(entry.Key),
+				Writes: entry.Writes,
+			})
+		}
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				GetRegisterChurnFunc: func(_ context.Context, in *GetRegisterChurnRequest, _ ...grpc.CallOption) (*GetRegisterChurnResponse, error) {
+					assert.Equal(t, mocks.GenericHeight, in.StartHeight)
+					assert.Equal(t, mocks.GenericHeight+1, in.EndHeight)
+					assert.Equal(t, uint32(10), in.Limit)
+
+					return &GetRegisterChurnResponse{
+						StartHeight: in.StartHeight,
+						EndHeight:   in.EndHeight,
+						Writes:      report.Writes,
+						Owners:      owners,
+						Registers:   registers,
+					}, nil
+				},
+			},
+		}
+
+		got, err := index.RegisterChurn(context.Background(), mocks.GenericHeight, mocks.GenericHeight+1, 10)
+
+		require.NoError(t, err)
+		assert.Equal(t, &report, got)
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				GetRegisterChurnFunc: func(context.Context, *GetRegisterChurnRequest, ...grpc.CallOption) (*GetRegisterChurnResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.RegisterChurn(context.Background(), mocks.GenericHeight, mocks.GenericHeight+1, 10)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_RegisterDeltas(t *testing.T) {
+	deltas := mocks.GenericRegisterDeltas(4)
+	after := mocks.GenericLedgerPath(5)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		msgs := make([]*RegisterDelta, 0, len(deltas))
+		for _, delta := range deltas {
+			path := delta.Path
+			msgs = append(msgs, &RegisterDelta{
+				Path:     path[:],
+				Previous: delta.Previous,
+				Current:  delta.Current,
+			})
+		}
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				GetRegisterDeltaFunc: func(_ context.Context, in *GetRegisterDeltaRequest, _ ...grpc.CallOption) (*GetRegisterDeltaResponse, error) {
+					assert.Equal(t, mocks.GenericHeight, in.Height)
+					assert.Equal(t, after[:], in.Cursor)
+					assert.Equal(t, uint32(10), in.Limit)
+
+					return &GetRegisterDeltaResponse{
+						Height: in.Height,
+						Deltas: msgs,
+					}, nil
+				},
+			},
+		}
+
+		got, err := index.RegisterDeltas(context.Background(), mocks.GenericHeight, after, 10)
+
+		require.NoError(t, err)
+		assert.Equal(t, deltas, got)
+	})
+
+	t.Run("handles invalid path", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				GetRegisterDeltaFunc: func(context.Context, *GetRegisterDeltaRequest, ...grpc.CallOption) (*GetRegisterDeltaResponse, error) {
+					return &GetRegisterDeltaResponse{
+						Height: mocks.GenericHeight,
+						Deltas: []*RegisterDelta{{Path: mocks.GenericBytes}},
+					}, nil
+				},
+			},
+		}
+
+		_, err := index.RegisterDeltas(context.Background(), mocks.GenericHeight, ledger.Path{}, 10)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				GetRegisterDeltaFunc: func(context.Context, *GetRegisterDeltaRequest, ...grpc.CallOption) (*GetRegisterDeltaResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.RegisterDeltas(context.Background(), mocks.GenericHeight, ledger.Path{}, 10)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_HeightForCommit(t *testing.T) {
+	commit := mocks.GenericCommit(0)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			client: &apiMock{
+				GetHeightForCommitFunc: func(_ context.Context, in *GetHeightForCommitRequest, _ ...grpc.CallOption) (*GetHeightForCommitResponse, error) {
+					assert.Equal(t, commit[:], in.Commit)
+
+					return &GetHeightForCommitResponse{
+						Commit: in.Commit,
+						Height: mocks.GenericHeight,
+					}, nil
+				},
+			},
+		}
+
+		got, err := index.HeightForCommit(context.Background(), commit)
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, got)
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			client: &apiMock{
+				GetHeightForCommitFunc: func(context.Context, *GetHeightForCommitRequest, ...grpc.CallOption) (*GetHeightForCommitResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.HeightForCommit(context.Background(), commit)
 
 		assert.Error(t, err)
 	})
@@ -214,7 +462,7 @@ func TestIndex_Commit(t *testing.T) {
 			},
 		}
 
-		got, err := index.Commit(mocks.GenericHeight)
+		got, err := index.Commit(context.Background(), mocks.GenericHeight)
 
 		require.NoError(t, err)
 		assert.Equal(t, commit, got)
@@ -234,7 +482,7 @@ func TestIndex_Commit(t *testing.T) {
 			},
 		}
 
-		_, err := index.Commit(mocks.GenericHeight)
+		_, err := index.Commit(context.Background(), mocks.GenericHeight)
 
 		assert.Error(t, err)
 	})
@@ -258,7 +506,7 @@ func TestIndex_Commit(t *testing.T) {
 			},
 		}
 
-		_, err := index.Commit(mocks.GenericHeight)
+		_, err := index.Commit(context.Background(), mocks.GenericHeight)
 
 		assert.Error(t, err)
 	})
@@ -290,7 +538,7 @@ func TestIndex_Values(t *testing.T) {
 			},
 		}
 
-		got, err := index.Values(mocks.GenericHeight, paths)
+		got, err := index.Values(context.Background(), mocks.GenericHeight, paths)
 
 		require.NoError(t, err)
 		assert.Equal(t, values, got)
@@ -309,7 +557,7 @@ func TestIndex_Values(t *testing.T) {
 			},
 		}
 
-		_, err := index.Values(mocks.GenericHeight, paths)
+		_, err := index.Values(context.Background(), mocks.GenericHeight, paths)
 
 		assert.Error(t, err)
 	})
@@ -338,7 +586,7 @@ func TestIndex_Height(t *testing.T) {
 			},
 		}
 
-		got, err := index.HeightForBlock(blockID)
+		got, err := index.HeightForBlock(context.Background(), blockID)
 
 		require.NoError(t, err)
 		assert.Equal(t, header.Height, got)
@@ -358,273 +606,939 @@ func TestIndex_Height(t *testing.T) {
 			},
 		}
 
-		_, err := index.HeightForBlock(blockID)
+		_, err := index.HeightForBlock(context.Background(), blockID)
 
 		assert.Error(t, err)
 	})
 }
 
-func TestIndex_Collection(t *testing.T) {
-	collection := mocks.GenericCollection(0)
-	collID := collection.ID()
-	data, err := cbor.Marshal(collection)
-	require.NoError(t, err)
+func TestIndex_Registers(t *testing.T) {
+	registers := mocks.GenericRegisters(4)
+	owner := flow.BytesToAddress([]byte(`owner`))
+
+	msgs := make([]*Register, 0, len(registers))
+	for _, register := range registers {
+		msgs = append(msgs, registerToMessage(register))
+	}
 
 	t.Run("nominal case", func(t *testing.T) {
 		t.Parallel()
 
-		codec := mocks.BaselineCodec(t)
-		codec.UnmarshalFunc = cbor.Unmarshal
-
 		index := Index{
-			codec: codec,
 			client: &apiMock{
-				GetCollectionFunc: func(_ context.Context, in *GetCollectionRequest, _ ...grpc.CallOption) (*GetCollectionResponse, error) {
-					assert.Equal(t, collID[:], in.CollectionID)
-
-					return &GetCollectionResponse{
-						CollectionID: collID[:],
-						Data:         data,
-					}, nil
+				ListRegistersForOwnerFunc: func(_ context.Context, in *ListRegistersForOwnerRequest, _ ...grpc.CallOption) (API_ListRegistersForOwnerClient, error) {
+					assert.Equal(t, mocks.GenericHeight, in.Height)
+					assert.Equal(t, owner.Bytes(), in.Owner)
+					assert.Equal(t, registers[0].Path[:], in.Cursor)
+					assert.Equal(t, uint32(4), in.Limit)
+
+					stream := &registersClientMock{
+						RecvFunc: func() (*ListRegistersForOwnerResponse, error) {
+							return &ListRegistersForOwnerResponse{
+								Height:    mocks.GenericHeight,
+								Owner:     owner.Bytes(),
+								Registers: msgs,
+							}, nil
+						},
+					}
+
+					return stream, nil
 				},
 			},
 		}
 
-		got, err := index.Collection(collID)
+		got, err := index.Registers(context.Background(), mocks.GenericHeight, owner, registers[0].Path, 4)
 
 		require.NoError(t, err)
-		assert.Equal(t, collection, got)
+		require.Len(t, got, len(registers))
+		for i, register := range got {
+			assert.Equal(t, registers[i].Path, register.Path)
+			assert.Equal(t, registers[i].Payload.Value, register.Payload.Value)
+		}
 	})
 
-	t.Run("handles index failure on GetCollection", func(t *testing.T) {
+	t.Run("starts from first register without cursor", func(t *testing.T) {
 		t.Parallel()
 
-		codec := mocks.BaselineCodec(t)
-		codec.UnmarshalFunc = cbor.Unmarshal
-
 		index := Index{
-			codec: codec,
 			client: &apiMock{
-				GetCollectionFunc: func(context.Context, *GetCollectionRequest, ...grpc.CallOption) (*GetCollectionResponse, error) {
-					return nil, mocks.GenericError
+				ListRegistersForOwnerFunc: func(_ context.Context, in *ListRegistersForOwnerRequest, _ ...grpc.CallOption) (API_ListRegistersForOwnerClient, error) {
+					assert.Empty(t, in.Cursor)
+
+					stream := &registersClientMock{
+						RecvFunc: func() (*ListRegistersForOwnerResponse, error) {
+							return &ListRegistersForOwnerResponse{}, nil
+						},
+					}
+
+					return stream, nil
 				},
 			},
 		}
 
-		_, err := index.Collection(collID)
+		got, err := index.Registers(context.Background(), mocks.GenericHeight, owner, ledger.Path{}, 4)
 
-		assert.Error(t, err)
+		require.NoError(t, err)
+		assert.Empty(t, got)
 	})
-}
 
-func TestIndex_ListCollectionsForHeight(t *testing.T) {
-	t.Run("nominal case", func(t *testing.T) {
+	t.Run("handles index failures", func(t *testing.T) {
 		t.Parallel()
 
-		collIDs := mocks.GenericCollectionIDs(4)
-		data := make([][]byte, 0, len(collIDs))
-		for _, collID := range collIDs {
-			collID := collID
-			data = append(data, collID[:])
-		}
-
 		index := Index{
-			codec: mocks.BaselineCodec(t),
 			client: &apiMock{
-				ListCollectionsForHeightFunc: func(_ context.Context, in *ListCollectionsForHeightRequest, _ ...grpc.CallOption) (*ListCollectionsForHeightResponse, error) {
-					assert.Equal(t, mocks.GenericHeight, in.Height)
-
-					return &ListCollectionsForHeightResponse{
-						Height:        in.Height,
-						CollectionIDs: data,
-					}, nil
+				ListRegistersForOwnerFunc: func(context.Context, *ListRegistersForOwnerRequest, ...grpc.CallOption) (API_ListRegistersForOwnerClient, error) {
+					return nil, mocks.GenericError
 				},
 			},
 		}
 
-		got, err := index.CollectionsByHeight(mocks.GenericHeight)
+		_, err := index.Registers(context.Background(), mocks.GenericHeight, owner, ledger.Path{}, 4)
 
-		require.NoError(t, err)
-		assert.Equal(t, collIDs, got)
+		assert.Error(t, err)
 	})
 
-	t.Run("handles index failures", func(t *testing.T) {
+	t.Run("handles stream failures", func(t *testing.T) {
 		t.Parallel()
 
 		index := Index{
-			codec: mocks.BaselineCodec(t),
 			client: &apiMock{
-				ListCollectionsForHeightFunc: func(context.Context, *ListCollectionsForHeightRequest, ...grpc.CallOption) (*ListCollectionsForHeightResponse, error) {
-					return nil, mocks.GenericError
+				ListRegistersForOwnerFunc: func(context.Context, *ListRegistersForOwnerRequest, ...grpc.CallOption) (API_ListRegistersForOwnerClient, error) {
+					stream := &registersClientMock{
+						RecvFunc: func() (*ListRegistersForOwnerResponse, error) {
+							return nil, mocks.GenericError
+						},
+					}
+					return stream, nil
 				},
 			},
 		}
 
-		_, err := index.CollectionsByHeight(mocks.GenericHeight)
+		_, err := index.Registers(context.Background(), mocks.GenericHeight, owner, ledger.Path{}, 4)
 
 		assert.Error(t, err)
 	})
 }
 
-func TestIndex_Guarantee(t *testing.T) {
-	guarantee := mocks.GenericGuarantee(0)
-	collID := guarantee.ID()
-	testGuaranteeBytes, err := cbor.Marshal(guarantee)
+func TestIndex_DumpRegisters(t *testing.T) {
+	registers := mocks.GenericRegisters(4)
+
+	msgs := make([]*Register, 0, len(registers))
+	for _, register := range registers {
+		msgs = append(msgs, dumpToMessage(register))
+	}
+
+	compressor, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	data, err := proto.Marshal(&RegisterChunk{Registers: msgs})
 	require.NoError(t, err)
+	compressed := compressor.EncodeAll(data, nil)
+	require.NoError(t, compressor.Close())
 
 	t.Run("nominal case", func(t *testing.T) {
 		t.Parallel()
 
-		codec := mocks.BaselineCodec(t)
-		codec.UnmarshalFunc = cbor.Unmarshal
-
 		index := Index{
-			codec: codec,
 			client: &apiMock{
-				GetGuaranteeFunc: func(_ context.Context, in *GetGuaranteeRequest, _ ...grpc.CallOption) (*GetGuaranteeResponse, error) {
-					assert.Equal(t, collID[:], in.CollectionID)
-
-					return &GetGuaranteeResponse{
-						CollectionID: collID[:],
-						Data:         testGuaranteeBytes,
-					}, nil
+				DumpRegistersFunc: func(_ context.Context, in *DumpRegistersRequest, _ ...grpc.CallOption) (API_DumpRegistersClient, error) {
+					assert.Equal(t, mocks.GenericHeight, in.Height)
+					assert.Equal(t, registers[0].Path[:], in.Cursor)
+					assert.Equal(t, uint32(4), in.Limit)
+					assert.True(t, in.Compress)
+
+					stream := &dumpClientMock{
+						RecvFunc: func() (*DumpRegistersResponse, error) {
+							return &DumpRegistersResponse{
+								Height:     mocks.GenericHeight,
+								Compressed: compressed,
+							}, nil
+						},
+					}
+
+					return stream, nil
 				},
 			},
 		}
 
-		got, err := index.Guarantee(collID)
+		got, err := index.DumpRegisters(context.Background(), mocks.GenericHeight, registers[0].Path, 4)
 
 		require.NoError(t, err)
-		assert.Equal(t, guarantee, got)
+		require.Len(t, got, len(registers))
+		for i, register := range got {
+			assert.Equal(t, registers[i].Path, register.Path)
+			assert.Equal(t, registers[i].Payload.Key, register.Payload.Key)
+			assert.Equal(t, registers[i].Payload.Value, register.Payload.Value)
+		}
 	})
 
-	t.Run("handles index failure on GetGuarantee", func(t *testing.T) {
+	t.Run("handles uncompressed chunks", func(t *testing.T) {
 		t.Parallel()
 
-		codec := mocks.BaselineCodec(t)
-		codec.UnmarshalFunc = cbor.Unmarshal
-
 		index := Index{
-			codec: codec,
 			client: &apiMock{
-				GetGuaranteeFunc: func(context.Context, *GetGuaranteeRequest, ...grpc.CallOption) (*GetGuaranteeResponse, error) {
-					return nil, mocks.GenericError
+				DumpRegistersFunc: func(_ context.Context, in *DumpRegistersRequest, _ ...grpc.CallOption) (API_DumpRegistersClient, error) {
+					assert.Empty(t, in.Cursor)
+
+					stream := &dumpClientMock{
+						RecvFunc: func() (*DumpRegistersResponse, error) {
+							return &DumpRegistersResponse{
+								Height:    mocks.GenericHeight,
+								Registers: msgs,
+							}, nil
+						},
+					}
+
+					return stream, nil
 				},
 			},
 		}
 
-		_, err := index.Guarantee(guarantee.ID())
+		got, err := index.DumpRegisters(context.Background(), mocks.GenericHeight, ledger.Path{}, 4)
 
-		assert.Error(t, err)
+		require.NoError(t, err)
+		assert.Len(t, got, len(registers))
 	})
-}
-
-func TestIndex_Transaction(t *testing.T) {
-	tx := mocks.GenericTransaction(0)
-	txID := tx.ID()
 
-	data, err := cbor.Marshal(tx)
-	require.NoError(t, err)
-
-	t.Run("nominal case", func(t *testing.T) {
+	t.Run("handles corrupted chunks", func(t *testing.T) {
 		t.Parallel()
 
-		codec := mocks.BaselineCodec(t)
-		codec.UnmarshalFunc = cbor.Unmarshal
-
 		index := Index{
-			codec: codec,
+			client: &apiMock{
+				DumpRegistersFunc: func(context.Context, *DumpRegistersRequest, ...grpc.CallOption) (API_DumpRegistersClient, error) {
+					stream := &dumpClientMock{
+						RecvFunc: func() (*DumpRegistersResponse, error) {
+							return &DumpRegistersResponse{
+								Height:     mocks.GenericHeight,
+								Compressed: mocks.GenericBytes,
+							}, nil
+						},
+					}
+					return stream, nil
+				},
+			},
+		}
+
+		_, err := index.DumpRegisters(context.Background(), mocks.GenericHeight, ledger.Path{}, 4)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			client: &apiMock{
+				DumpRegistersFunc: func(context.Context, *DumpRegistersRequest, ...grpc.CallOption) (API_DumpRegistersClient, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.DumpRegisters(context.Background(), mocks.GenericHeight, ledger.Path{}, 4)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles stream failures", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			client: &apiMock{
+				DumpRegistersFunc: func(context.Context, *DumpRegistersRequest, ...grpc.CallOption) (API_DumpRegistersClient, error) {
+					stream := &dumpClientMock{
+						RecvFunc: func() (*DumpRegistersResponse, error) {
+							return nil, mocks.GenericError
+						},
+					}
+					return stream, nil
+				},
+			},
+		}
+
+		_, err := index.DumpRegisters(context.Background(), mocks.GenericHeight, ledger.Path{}, 4)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_Collection(t *testing.T) {
+	collection := mocks.GenericCollection(0)
+	collID := collection.ID()
+	data, err := cbor.Marshal(collection)
+	require.NoError(t, err)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = cbor.Unmarshal
+
+		index := Index{
+			codec: codec,
+			client: &apiMock{
+				GetCollectionFunc: func(_ context.Context, in *GetCollectionRequest, _ ...grpc.CallOption) (*GetCollectionResponse, error) {
+					assert.Equal(t, collID[:], in.CollectionID)
+
+					return &GetCollectionResponse{
+						CollectionID: collID[:],
+						Data:         data,
+					}, nil
+				},
+			},
+		}
+
+		got, err := index.Collection(context.Background(), collID)
+
+		require.NoError(t, err)
+		assert.Equal(t, collection, got)
+	})
+
+	t.Run("handles index failure on GetCollection", func(t *testing.T) {
+		t.Parallel()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = cbor.Unmarshal
+
+		index := Index{
+			codec: codec,
+			client: &apiMock{
+				GetCollectionFunc: func(context.Context, *GetCollectionRequest, ...grpc.CallOption) (*GetCollectionResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.Collection(context.Background(), collID)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_ListCollectionsForHeight(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		collIDs := mocks.GenericCollectionIDs(4)
+		data := make([][]byte, 0, len(collIDs))
+		for _, collID := range collIDs {
+			collID := collID
+			data = append(data, collID[:])
+		}
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				ListCollectionsForHeightFunc: func(_ context.Context, in *ListCollectionsForHeightRequest, _ ...grpc.CallOption) (*ListCollectionsForHeightResponse, error) {
+					assert.Equal(t, mocks.GenericHeight, in.Height)
+
+					return &ListCollectionsForHeightResponse{
+						Height:        in.Height,
+						CollectionIDs: data,
+					}, nil
+				},
+			},
+		}
+
+		got, err := index.CollectionsByHeight(context.Background(), mocks.GenericHeight)
+
+		require.NoError(t, err)
+		assert.Equal(t, collIDs, got)
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				ListCollectionsForHeightFunc: func(context.Context, *ListCollectionsForHeightRequest, ...grpc.CallOption) (*ListCollectionsForHeightResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.CollectionsByHeight(context.Background(), mocks.GenericHeight)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_Guarantee(t *testing.T) {
+	guarantee := mocks.GenericGuarantee(0)
+	collID := guarantee.ID()
+	testGuaranteeBytes, err := cbor.Marshal(guarantee)
+	require.NoError(t, err)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = cbor.Unmarshal
+
+		index := Index{
+			codec: codec,
+			client: &apiMock{
+				GetGuaranteeFunc: func(_ context.Context, in *GetGuaranteeRequest, _ ...grpc.CallOption) (*GetGuaranteeResponse, error) {
+					assert.Equal(t, collID[:], in.CollectionID)
+
+					return &GetGuaranteeResponse{
+						CollectionID: collID[:],
+						Data:         testGuaranteeBytes,
+					}, nil
+				},
+			},
+		}
+
+		got, err := index.Guarantee(context.Background(), collID)
+
+		require.NoError(t, err)
+		assert.Equal(t, guarantee, got)
+	})
+
+	t.Run("handles index failure on GetGuarantee", func(t *testing.T) {
+		t.Parallel()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = cbor.Unmarshal
+
+		index := Index{
+			codec: codec,
+			client: &apiMock{
+				GetGuaranteeFunc: func(context.Context, *GetGuaranteeRequest, ...grpc.CallOption) (*GetGuaranteeResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.Guarantee(context.Background(), guarantee.ID())
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_Transaction(t *testing.T) {
+	tx := mocks.GenericTransaction(0)
+	txID := tx.ID()
+
+	data, err := cbor.Marshal(tx)
+	require.NoError(t, err)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = cbor.Unmarshal
+
+		index := Index{
+			codec: codec,
 			client: &apiMock{
 				GetTransactionFunc: func(_ context.Context, in *GetTransactionRequest, _ ...grpc.CallOption) (*GetTransactionResponse, error) {
 					assert.Equal(t, txID[:], in.TransactionID)
 
-					return &GetTransactionResponse{
-						TransactionID: txID[:],
-						Data:          data,
+					return &GetTransactionResponse{
+						TransactionID: txID[:],
+						Data:          data,
+					}, nil
+				},
+			},
+		}
+
+		got, err := index.Transaction(context.Background(), txID)
+
+		require.NoError(t, err)
+		assert.Equal(t, tx, got)
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = cbor.Unmarshal
+
+		index := Index{
+			codec: codec,
+			client: &apiMock{
+				GetTransactionFunc: func(context.Context, *GetTransactionRequest, ...grpc.CallOption) (*GetTransactionResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.Transaction(context.Background(), tx.ID())
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_TransactionsForScript(t *testing.T) {
+	txIDs := mocks.GenericTransactionIDs(4)
+	hash := dps.ScriptHash([]byte(`transaction {}`))
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		data := make([][]byte, 0, len(txIDs))
+		for _, txID := range txIDs {
+			data = append(data, mocks.ByteSlice(txID))
+		}
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				ListTransactionsForScriptFunc: func(_ context.Context, in *ListTransactionsForScriptRequest, _ ...grpc.CallOption) (*ListTransactionsForScriptResponse, error) {
+					assert.Equal(t, hash[:], in.ScriptHash)
+					assert.Equal(t, mocks.GenericHeight, in.StartHeight)
+					assert.Equal(t, mocks.GenericHeight+10, in.EndHeight)
+
+					return &ListTransactionsForScriptResponse{
+						ScriptHash:     in.ScriptHash,
+						StartHeight:    in.StartHeight,
+						EndHeight:      in.EndHeight,
+						TransactionIDs: data,
+					}, nil
+				},
+			},
+		}
+
+		got, err := index.TransactionsForScript(context.Background(), hash, mocks.GenericHeight, mocks.GenericHeight+10)
+
+		require.NoError(t, err)
+		assert.Equal(t, txIDs, got)
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				ListTransactionsForScriptFunc: func(context.Context, *ListTransactionsForScriptRequest, ...grpc.CallOption) (*ListTransactionsForScriptResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.TransactionsForScript(context.Background(), hash, mocks.GenericHeight, mocks.GenericHeight+10)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_Result(t *testing.T) {
+	result := mocks.GenericResult(0)
+	txID := result.TransactionID
+
+	data, err := cbor.Marshal(result)
+	require.NoError(t, err)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = cbor.Unmarshal
+
+		index := Index{
+			codec: codec,
+			client: &apiMock{
+				GetResultFunc: func(_ context.Context, in *GetResultRequest, _ ...grpc.CallOption) (*GetResultResponse, error) {
+					assert.Equal(t, txID[:], in.TransactionID)
+
+					return &GetResultResponse{
+						TransactionID: txID[:],
+						Data:          data,
+					}, nil
+				},
+			},
+		}
+
+		got, err := index.Result(context.Background(), txID)
+
+		require.NoError(t, err)
+		assert.Equal(t, result, got)
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			client: &apiMock{
+				GetResultFunc: func(context.Context, *GetResultRequest, ...grpc.CallOption) (*GetResultResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.Result(context.Background(), result.ID())
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_Events(t *testing.T) {
+	events := mocks.GenericEvents(4)
+	types := mocks.GenericEventTypes(2)
+
+	data, err := cbor.Marshal(events)
+	require.NoError(t, err)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = cbor.Unmarshal
+
+		index := Index{
+			codec: codec,
+			client: &apiMock{
+				GetEventsFunc: func(_ context.Context, in *GetEventsRequest, _ ...grpc.CallOption) (*GetEventsResponse, error) {
+					assert.Equal(t, mocks.GenericHeight, in.Height)
+					assert.Equal(t, convert.TypesToStrings(types), in.Types)
+
+					return &GetEventsResponse{
+						Height: mocks.GenericHeight,
+						Types:  convert.TypesToStrings(types),
+						Data:   data,
+					}, nil
+				},
+			},
+		}
+
+		got, err := index.Events(context.Background(), mocks.GenericHeight, types...)
+
+		require.NoError(t, err)
+		assert.Equal(t, events, got)
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = cbor.Unmarshal
+
+		index := Index{
+			codec: codec,
+			client: &apiMock{
+				GetEventsFunc: func(context.Context, *GetEventsRequest, ...grpc.CallOption) (*GetEventsResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.Events(context.Background(), mocks.GenericHeight, types...)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles invalid indexed data", func(t *testing.T) {
+		t.Parallel()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = cbor.Unmarshal
+
+		index := Index{
+			codec: codec,
+			client: &apiMock{
+				GetEventsFunc: func(context.Context, *GetEventsRequest, ...grpc.CallOption) (*GetEventsResponse, error) {
+					return &GetEventsResponse{
+						Height: mocks.GenericHeight,
+						Types:  convert.TypesToStrings(types),
+						Data:   []byte(`invalid data`),
+					}, nil
+				},
+			},
+		}
+
+		_, err := index.Events(context.Background(), mocks.GenericHeight, types...)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_Seals(t *testing.T) {
+	seal := mocks.GenericSeal(0)
+	sealID := seal.ID()
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := cbor.Marshal(seal)
+		require.NoError(t, err)
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = cbor.Unmarshal
+
+		index := Index{
+			codec: codec,
+			client: &apiMock{
+				GetSealFunc: func(_ context.Context, in *GetSealRequest, _ ...grpc.CallOption) (*GetSealResponse, error) {
+					assert.Equal(t, sealID[:], in.SealID)
+
+					return &GetSealResponse{
+						SealID: sealID[:],
+						Data:   data,
+					}, nil
+				},
+			},
+		}
+
+		got, err := index.Seal(context.Background(), sealID)
+
+		require.NoError(t, err)
+		assert.Equal(t, seal, got)
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				GetSealFunc: func(context.Context, *GetSealRequest, ...grpc.CallOption) (*GetSealResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.Seal(context.Background(), sealID)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_ListSealsForHeight(t *testing.T) {
+	sealIDs := mocks.GenericSealIDs(4)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		data := make([][]byte, 0, len(sealIDs))
+		for _, sealID := range sealIDs {
+			data = append(data, mocks.ByteSlice(sealID))
+		}
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				ListSealsForHeightFunc: func(_ context.Context, in *ListSealsForHeightRequest, _ ...grpc.CallOption) (*ListSealsForHeightResponse, error) {
+					assert.Equal(t, mocks.GenericHeight, in.Height)
+
+					return &ListSealsForHeightResponse{
+						Height:  in.Height,
+						SealIDs: data,
+					}, nil
+				},
+			},
+		}
+
+		got, err := index.SealsByHeight(context.Background(), mocks.GenericHeight)
+
+		require.NoError(t, err)
+		assert.Equal(t, sealIDs, got)
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				ListSealsForHeightFunc: func(context.Context, *ListSealsForHeightRequest, ...grpc.CallOption) (*ListSealsForHeightResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.SealsByHeight(context.Background(), mocks.GenericHeight)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_Filter(t *testing.T) {
+	addresses := mocks.GenericAddresses(3)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				ListOwnersFunc: func(context.Context, *ListOwnersRequest, ...grpc.CallOption) (*ListOwnersResponse, error) {
+					return &ListOwnersResponse{
+						Owners: [][]byte{addresses[0].Bytes(), addresses[1].Bytes()},
+						Denied: [][]byte{addresses[2].Bytes()},
 					}, nil
 				},
 			},
 		}
 
-		got, err := index.Transaction(txID)
+		got, err := index.Filter(context.Background())
 
 		require.NoError(t, err)
-		assert.Equal(t, tx, got)
+		assert.Equal(t, addresses[:2], got.Allowed)
+		assert.Equal(t, addresses[2:], got.Denied)
 	})
 
-	t.Run("handles index failures", func(t *testing.T) {
+	t.Run("nominal case for full index", func(t *testing.T) {
 		t.Parallel()
 
-		codec := mocks.BaselineCodec(t)
-		codec.UnmarshalFunc = cbor.Unmarshal
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				ListOwnersFunc: func(context.Context, *ListOwnersRequest, ...grpc.CallOption) (*ListOwnersResponse, error) {
+					return &ListOwnersResponse{}, nil
+				},
+			},
+		}
+
+		got, err := index.Filter(context.Background())
+
+		require.NoError(t, err)
+		assert.True(t, got.Empty())
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
 
 		index := Index{
-			codec: codec,
+			codec: mocks.BaselineCodec(t),
 			client: &apiMock{
-				GetTransactionFunc: func(context.Context, *GetTransactionRequest, ...grpc.CallOption) (*GetTransactionResponse, error) {
+				ListOwnersFunc: func(context.Context, *ListOwnersRequest, ...grpc.CallOption) (*ListOwnersResponse, error) {
 					return nil, mocks.GenericError
 				},
 			},
 		}
 
-		_, err := index.Transaction(tx.ID())
+		_, err := index.Filter(context.Background())
 
 		assert.Error(t, err)
 	})
 }
 
-func TestIndex_Result(t *testing.T) {
-	result := mocks.GenericResult(0)
-	txID := result.TransactionID
+func TestIndex_Account(t *testing.T) {
+	address := mocks.GenericAddress(0)
+	account := mocks.GenericAccountMetadata(address)
 
-	data, err := cbor.Marshal(result)
-	require.NoError(t, err)
+	getLast := func(context.Context, *GetLastRequest, ...grpc.CallOption) (*GetLastResponse, error) {
+		return &GetLastResponse{Height: mocks.GenericHeight + 1}, nil
+	}
 
 	t.Run("nominal case", func(t *testing.T) {
 		t.Parallel()
 
-		codec := mocks.BaselineCodec(t)
-		codec.UnmarshalFunc = cbor.Unmarshal
+		index := Index{
+			client: &apiMock{
+				GetLastFunc: getLast,
+				GetAccountAtHeightFunc: func(_ context.Context, in *GetAccountAtHeightRequest, _ ...grpc.CallOption) (*GetAccountAtHeightResponse, error) {
+					assert.Equal(t, address.Bytes(), in.Address)
+					assert.Equal(t, mocks.GenericHeight+1, in.Height)
+
+					return &GetAccountAtHeightResponse{
+						Address:    in.Address,
+						Height:     in.Height,
+						Created:    account.Created,
+						KeyUpdates: account.KeyUpdates,
+					}, nil
+				},
+			},
+		}
+
+		got, err := index.Account(context.Background(), address)
+
+		require.NoError(t, err)
+		assert.Equal(t, account, got)
+	})
+
+	t.Run("handles failure to get last height", func(t *testing.T) {
+		t.Parallel()
 
 		index := Index{
-			codec: codec,
 			client: &apiMock{
-				GetResultFunc: func(_ context.Context, in *GetResultRequest, _ ...grpc.CallOption) (*GetResultResponse, error) {
-					assert.Equal(t, txID[:], in.TransactionID)
+				GetLastFunc: func(context.Context, *GetLastRequest, ...grpc.CallOption) (*GetLastResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
 
-					return &GetResultResponse{
-						TransactionID: txID[:],
-						Data:          data,
+		_, err := index.Account(context.Background(), address)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			client: &apiMock{
+				GetLastFunc: getLast,
+				GetAccountAtHeightFunc: func(context.Context, *GetAccountAtHeightRequest, ...grpc.CallOption) (*GetAccountAtHeightResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.Account(context.Background(), address)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_ContractHistory(t *testing.T) {
+	address := mocks.GenericAddress(0)
+	versions := mocks.GenericContractVersions(address, "Contract", 2)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			client: &apiMock{
+				GetContractHistoryFunc: func(_ context.Context, in *GetContractHistoryRequest, _ ...grpc.CallOption) (*GetContractHistoryResponse, error) {
+					assert.Equal(t, address.Bytes(), in.Address)
+					assert.Equal(t, "Contract", in.Name)
+
+					var history []*ContractVersion
+					for _, version := range versions {
+						history = append(history, &ContractVersion{
+							Height:        version.Height,
+							TransactionID: convert.IDToHash(version.TransactionID),
+							CodeHash:      version.CodeHash,
+							Removed:       version.Removed,
+						})
+					}
+					return &GetContractHistoryResponse{
+						Address:  in.Address,
+						Name:     in.Name,
+						Versions: history,
 					}, nil
 				},
 			},
 		}
 
-		got, err := index.Result(txID)
+		got, err := index.ContractHistory(context.Background(), address, "Contract")
 
 		require.NoError(t, err)
-		assert.Equal(t, result, got)
+		assert.Equal(t, versions, got)
 	})
 
-	t.Run("handles index failures", func(t *testing.T) {
+	t.Run("handles index failure", func(t *testing.T) {
 		t.Parallel()
 
 		index := Index{
 			client: &apiMock{
-				GetResultFunc: func(context.Context, *GetResultRequest, ...grpc.CallOption) (*GetResultResponse, error) {
+				GetContractHistoryFunc: func(context.Context, *GetContractHistoryRequest, ...grpc.CallOption) (*GetContractHistoryResponse, error) {
 					return nil, mocks.GenericError
 				},
 			},
 		}
 
-		_, err := index.Result(result.ID())
+		_, err := index.ContractHistory(context.Background(), address, "Contract")
 
 		assert.Error(t, err)
 	})
 }
 
-func TestIndex_Events(t *testing.T) {
-	events := mocks.GenericEvents(4)
+func TestIndex_ServiceEvents(t *testing.T) {
+	events := mocks.GenericServiceEvents(2)
 	types := mocks.GenericEventTypes(2)
 
 	data, err := cbor.Marshal(events)
@@ -639,20 +1553,22 @@ func TestIndex_Events(t *testing.T) {
 		index := Index{
 			codec: codec,
 			client: &apiMock{
-				GetEventsFunc: func(_ context.Context, in *GetEventsRequest, _ ...grpc.CallOption) (*GetEventsResponse, error) {
-					assert.Equal(t, mocks.GenericHeight, in.Height)
+				GetServiceEventsFunc: func(_ context.Context, in *GetServiceEventsRequest, _ ...grpc.CallOption) (*GetServiceEventsResponse, error) {
+					assert.Equal(t, mocks.GenericHeight, in.StartHeight)
+					assert.Equal(t, mocks.GenericHeight+1, in.EndHeight)
 					assert.Equal(t, convert.TypesToStrings(types), in.Types)
 
-					return &GetEventsResponse{
-						Height: mocks.GenericHeight,
-						Types:  convert.TypesToStrings(types),
-						Data:   data,
+					return &GetServiceEventsResponse{
+						StartHeight: in.StartHeight,
+						EndHeight:   in.EndHeight,
+						Types:       in.Types,
+						Data:        data,
 					}, nil
 				},
 			},
 		}
 
-		got, err := index.Events(mocks.GenericHeight, types...)
+		got, err := index.ServiceEvents(context.Background(), mocks.GenericHeight, mocks.GenericHeight+1, types...)
 
 		require.NoError(t, err)
 		assert.Equal(t, events, got)
@@ -661,19 +1577,16 @@ func TestIndex_Events(t *testing.T) {
 	t.Run("handles index failures", func(t *testing.T) {
 		t.Parallel()
 
-		codec := mocks.BaselineCodec(t)
-		codec.UnmarshalFunc = cbor.Unmarshal
-
 		index := Index{
-			codec: codec,
+			codec: mocks.BaselineCodec(t),
 			client: &apiMock{
-				GetEventsFunc: func(context.Context, *GetEventsRequest, ...grpc.CallOption) (*GetEventsResponse, error) {
+				GetServiceEventsFunc: func(context.Context, *GetServiceEventsRequest, ...grpc.CallOption) (*GetServiceEventsResponse, error) {
 					return nil, mocks.GenericError
 				},
 			},
 		}
 
-		_, err := index.Events(mocks.GenericHeight, types...)
+		_, err := index.ServiceEvents(context.Background(), mocks.GenericHeight, mocks.GenericHeight+1, types...)
 
 		assert.Error(t, err)
 	})
@@ -687,31 +1600,77 @@ func TestIndex_Events(t *testing.T) {
 		index := Index{
 			codec: codec,
 			client: &apiMock{
-				GetEventsFunc: func(context.Context, *GetEventsRequest, ...grpc.CallOption) (*GetEventsResponse, error) {
-					return &GetEventsResponse{
-						Height: mocks.GenericHeight,
-						Types:  convert.TypesToStrings(types),
-						Data:   []byte(`invalid data`),
-					}, nil
+				GetServiceEventsFunc: func(context.Context, *GetServiceEventsRequest, ...grpc.CallOption) (*GetServiceEventsResponse, error) {
+					return &GetServiceEventsResponse{Data: mocks.GenericBytes}, nil
 				},
 			},
 		}
 
-		_, err := index.Events(mocks.GenericHeight, types...)
+		_, err := index.ServiceEvents(context.Background(), mocks.GenericHeight, mocks.GenericHeight+1, types...)
 
 		assert.Error(t, err)
 	})
 }
 
-func TestIndex_Seals(t *testing.T) {
-	seal := mocks.GenericSeal(0)
-	sealID := seal.ID()
+func TestIndex_Epochs(t *testing.T) {
+	epochs := mocks.GenericEpochs(2)
 
 	t.Run("nominal case", func(t *testing.T) {
 		t.Parallel()
 
-		data, err := cbor.Marshal(seal)
+		index := Index{
+			client: &apiMock{
+				ListEpochsFunc: func(context.Context, *ListEpochsRequest, ...grpc.CallOption) (*ListEpochsResponse, error) {
+					var list []*Epoch
+					for _, epoch := range epochs {
+						list = append(list, &Epoch{
+							Counter:            epoch.Counter,
+							FirstView:          epoch.FirstView,
+							DkgPhase1FinalView: epoch.DKGPhase1FinalView,
+							DkgPhase2FinalView: epoch.DKGPhase2FinalView,
+							DkgPhase3FinalView: epoch.DKGPhase3FinalView,
+							FinalView:          epoch.FinalView,
+							StakingHeight:      epoch.StakingHeight,
+							SetupHeight:        epoch.SetupHeight,
+							CommittedHeight:    epoch.CommittedHeight,
+						})
+					}
+					return &ListEpochsResponse{Epochs: list}, nil
+				},
+			},
+		}
+
+		got, err := index.Epochs(context.Background())
+
 		require.NoError(t, err)
+		assert.Equal(t, epochs, got)
+	})
+
+	t.Run("handles index failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			client: &apiMock{
+				ListEpochsFunc: func(context.Context, *ListEpochsRequest, ...grpc.CallOption) (*ListEpochsResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.Epochs(context.Background())
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_Identities(t *testing.T) {
+	identities := mocks.GenericIdentities(4)
+
+	data, err := cbor.Marshal(identities)
+	require.NoError(t, err)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
 
 		codec := mocks.BaselineCodec(t)
 		codec.UnmarshalFunc = cbor.Unmarshal
@@ -719,70 +1678,89 @@ func TestIndex_Seals(t *testing.T) {
 		index := Index{
 			codec: codec,
 			client: &apiMock{
-				GetSealFunc: func(_ context.Context, in *GetSealRequest, _ ...grpc.CallOption) (*GetSealResponse, error) {
-					assert.Equal(t, sealID[:], in.SealID)
+				ListIdentitiesForEpochFunc: func(_ context.Context, in *ListIdentitiesForEpochRequest, _ ...grpc.CallOption) (*ListIdentitiesForEpochResponse, error) {
+					assert.Equal(t, uint64(1), in.Counter)
 
-					return &GetSealResponse{
-						SealID: sealID[:],
-						Data:   data,
+					return &ListIdentitiesForEpochResponse{
+						Counter: in.Counter,
+						Data:    data,
 					}, nil
 				},
 			},
 		}
 
-		got, err := index.Seal(sealID)
+		got, err := index.Identities(context.Background(), 1)
 
 		require.NoError(t, err)
-		assert.Equal(t, seal, got)
+		assert.Equal(t, identities, got)
 	})
 
-	t.Run("handles index failures", func(t *testing.T) {
+	t.Run("handles index failure", func(t *testing.T) {
 		t.Parallel()
 
 		index := Index{
 			codec: mocks.BaselineCodec(t),
 			client: &apiMock{
-				GetSealFunc: func(context.Context, *GetSealRequest, ...grpc.CallOption) (*GetSealResponse, error) {
+				ListIdentitiesForEpochFunc: func(context.Context, *ListIdentitiesForEpochRequest, ...grpc.CallOption) (*ListIdentitiesForEpochResponse, error) {
 					return nil, mocks.GenericError
 				},
 			},
 		}
 
-		_, err := index.Seal(sealID)
+		_, err := index.Identities(context.Background(), 1)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles invalid indexed data", func(t *testing.T) {
+		t.Parallel()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = cbor.Unmarshal
+
+		index := Index{
+			codec: codec,
+			client: &apiMock{
+				ListIdentitiesForEpochFunc: func(context.Context, *ListIdentitiesForEpochRequest, ...grpc.CallOption) (*ListIdentitiesForEpochResponse, error) {
+					return &ListIdentitiesForEpochResponse{Data: mocks.GenericBytes}, nil
+				},
+			},
+		}
+
+		_, err := index.Identities(context.Background(), 1)
 
 		assert.Error(t, err)
 	})
 }
 
-func TestIndex_ListSealsForHeight(t *testing.T) {
-	sealIDs := mocks.GenericSealIDs(4)
+func TestIndex_EventTypes(t *testing.T) {
+	stats := mocks.GenericEventTypeStats(2)
 
 	t.Run("nominal case", func(t *testing.T) {
 		t.Parallel()
 
-		data := make([][]byte, 0, len(sealIDs))
-		for _, sealID := range sealIDs {
-			data = append(data, mocks.ByteSlice(sealID))
-		}
-
 		index := Index{
 			codec: mocks.BaselineCodec(t),
 			client: &apiMock{
-				ListSealsForHeightFunc: func(_ context.Context, in *ListSealsForHeightRequest, _ ...grpc.CallOption) (*ListSealsForHeightResponse, error) {
-					assert.Equal(t, mocks.GenericHeight, in.Height)
-
-					return &ListSealsForHeightResponse{
-						Height:  in.Height,
-						SealIDs: data,
-					}, nil
+				ListEventTypesFunc: func(context.Context, *ListEventTypesRequest, ...grpc.CallOption) (*ListEventTypesResponse, error) {
+					var types []*EventTypeStats
+					for _, stat := range stats {
+						types = append(types, &EventTypeStats{
+							Type:  string(stat.Type),
+							First: stat.First,
+							Last:  stat.Last,
+							Count: stat.Count,
+						})
+					}
+					return &ListEventTypesResponse{Types: types}, nil
 				},
 			},
 		}
 
-		got, err := index.SealsByHeight(mocks.GenericHeight)
+		got, err := index.EventTypes(context.Background())
 
 		require.NoError(t, err)
-		assert.Equal(t, sealIDs, got)
+		assert.Equal(t, stats, got)
 	})
 
 	t.Run("handles index failures", func(t *testing.T) {
@@ -791,13 +1769,13 @@ func TestIndex_ListSealsForHeight(t *testing.T) {
 		index := Index{
 			codec: mocks.BaselineCodec(t),
 			client: &apiMock{
-				ListSealsForHeightFunc: func(context.Context, *ListSealsForHeightRequest, ...grpc.CallOption) (*ListSealsForHeightResponse, error) {
+				ListEventTypesFunc: func(context.Context, *ListEventTypesRequest, ...grpc.CallOption) (*ListEventTypesResponse, error) {
 					return nil, mocks.GenericError
 				},
 			},
 		}
 
-		_, err := index.SealsByHeight(mocks.GenericHeight)
+		_, err := index.EventTypes(context.Background())
 
 		assert.Error(t, err)
 	})
@@ -811,15 +1789,38 @@ type apiMock struct {
 	GetHeaderFunc                 func(ctx context.Context, in *GetHeaderRequest, opts ...grpc.CallOption) (*GetHeaderResponse, error)
 	GetEventsFunc                 func(ctx context.Context, in *GetEventsRequest, opts ...grpc.CallOption) (*GetEventsResponse, error)
 	GetRegisterValuesFunc         func(ctx context.Context, in *GetRegisterValuesRequest, opts ...grpc.CallOption) (*GetRegisterValuesResponse, error)
+	ListRegistersForOwnerFunc     func(ctx context.Context, in *ListRegistersForOwnerRequest, opts ...grpc.CallOption) (API_ListRegistersForOwnerClient, error)
+	DumpRegistersFunc             func(ctx context.Context, in *DumpRegistersRequest, opts ...grpc.CallOption) (API_DumpRegistersClient, error)
 	GetCollectionFunc             func(ctx context.Context, in *GetCollectionRequest, opts ...grpc.CallOption) (*GetCollectionResponse, error)
 	ListCollectionsForHeightFunc  func(ctx context.Context, in *ListCollectionsForHeightRequest, opts ...grpc.CallOption) (*ListCollectionsForHeightResponse, error)
 	GetGuaranteeFunc              func(ctx context.Context, in *GetGuaranteeRequest, opts ...grpc.CallOption) (*GetGuaranteeResponse, error)
 	GetTransactionFunc            func(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*GetTransactionResponse, error)
 	GetHeightForTransactionFunc   func(ctx context.Context, in *GetHeightForTransactionRequest, opts ...grpc.CallOption) (*GetHeightForTransactionResponse, error)
+	GetHeightForCommitFunc        func(ctx context.Context, in *GetHeightForCommitRequest, opts ...grpc.CallOption) (*GetHeightForCommitResponse, error)
 	ListTransactionsForHeightFunc func(ctx context.Context, in *ListTransactionsForHeightRequest, opts ...grpc.CallOption) (*ListTransactionsForHeightResponse, error)
+	ListTransactionsForScriptFunc func(ctx context.Context, in *ListTransactionsForScriptRequest, opts ...grpc.CallOption) (*ListTransactionsForScriptResponse, error)
+	GetBlockStatsFunc             func(ctx context.Context, in *GetBlockStatsRequest, opts ...grpc.CallOption) (*GetBlockStatsResponse, error)
+	GetRegisterChurnFunc          func(ctx context.Context, in *GetRegisterChurnRequest, opts ...grpc.CallOption) (*GetRegisterChurnResponse, error)
+	GetRegisterDeltaFunc          func(ctx context.Context, in *GetRegisterDeltaRequest, opts ...grpc.CallOption) (*GetRegisterDeltaResponse, error)
 	GetResultFunc                 func(ctx context.Context, in *GetResultRequest, opts ...grpc.CallOption) (*GetResultResponse, error)
 	GetSealFunc                   func(ctx context.Context, in *GetSealRequest, opts ...grpc.CallOption) (*GetSealResponse, error)
 	ListSealsForHeightFunc        func(ctx context.Context, in *ListSealsForHeightRequest, opts ...grpc.CallOption) (*ListSealsForHeightResponse, error)
+	ListOwnersFunc                func(ctx context.Context, in *ListOwnersRequest, opts ...grpc.CallOption) (*ListOwnersResponse, error)
+	ListEventTypesFunc            func(ctx context.Context, in *ListEventTypesRequest, opts ...grpc.CallOption) (*ListEventTypesResponse, error)
+	GetAccountAtHeightFunc        func(ctx context.Context, in *GetAccountAtHeightRequest, opts ...grpc.CallOption) (*GetAccountAtHeightResponse, error)
+	GetContractHistoryFunc        func(ctx context.Context, in *GetContractHistoryRequest, opts ...grpc.CallOption) (*GetContractHistoryResponse, error)
+	GetServiceEventsFunc          func(ctx context.Context, in *GetServiceEventsRequest, opts ...grpc.CallOption) (*GetServiceEventsResponse, error)
+	ListEpochsFunc                func(ctx context.Context, in *ListEpochsRequest, opts ...grpc.CallOption) (*ListEpochsResponse, error)
+	ListIdentitiesForEpochFunc    func(ctx context.Context, in *ListIdentitiesForEpochRequest, opts ...grpc.CallOption) (*ListIdentitiesForEpochResponse, error)
+	GetVersionFunc                func(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*GetVersionResponse, error)
+	GetInfoFunc                   func(ctx context.Context, in *GetInfoRequest, opts ...grpc.CallOption) (*GetInfoResponse, error)
+	GetRawHeaderFunc              func(ctx context.Context, in *GetRawHeaderRequest, opts ...grpc.CallOption) (*GetRawHeaderResponse, error)
+	GetRawEventsFunc              func(ctx context.Context, in *GetRawEventsRequest, opts ...grpc.CallOption) (*GetRawEventsResponse, error)
+	GetRawCollectionFunc          func(ctx context.Context, in *GetRawCollectionRequest, opts ...grpc.CallOption) (*GetRawCollectionResponse, error)
+	GetRawGuaranteeFunc           func(ctx context.Context, in *GetRawGuaranteeRequest, opts ...grpc.CallOption) (*GetRawGuaranteeResponse, error)
+	GetRawTransactionFunc         func(ctx context.Context, in *GetRawTransactionRequest, opts ...grpc.CallOption) (*GetRawTransactionResponse, error)
+	GetRawResultFunc              func(ctx context.Context, in *GetRawResultRequest, opts ...grpc.CallOption) (*GetRawResultResponse, error)
+	GetRawSealFunc                func(ctx context.Context, in *GetRawSealRequest, opts ...grpc.CallOption) (*GetRawSealResponse, error)
 }
 
 func (a *apiMock) GetFirst(ctx context.Context, in *GetFirstRequest, opts ...grpc.CallOption) (*GetFirstResponse, error) {
@@ -850,6 +1851,14 @@ func (a *apiMock) GetRegisterValues(ctx context.Context, in *GetRegisterValuesRe
 	return a.GetRegisterValuesFunc(ctx, in, opts...)
 }
 
+func (a *apiMock) ListRegistersForOwner(ctx context.Context, in *ListRegistersForOwnerRequest, opts ...grpc.CallOption) (API_ListRegistersForOwnerClient, error) {
+	return a.ListRegistersForOwnerFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) DumpRegisters(ctx context.Context, in *DumpRegistersRequest, opts ...grpc.CallOption) (API_DumpRegistersClient, error) {
+	return a.DumpRegistersFunc(ctx, in, opts...)
+}
+
 func (a *apiMock) GetCollection(ctx context.Context, in *GetCollectionRequest, opts ...grpc.CallOption) (*GetCollectionResponse, error) {
 	return a.GetCollectionFunc(ctx, in, opts...)
 }
@@ -870,10 +1879,30 @@ func (a *apiMock) GetHeightForTransaction(ctx context.Context, in *GetHeightForT
 	return a.GetHeightForTransactionFunc(ctx, in, opts...)
 }
 
+func (a *apiMock) GetHeightForCommit(ctx context.Context, in *GetHeightForCommitRequest, opts ...grpc.CallOption) (*GetHeightForCommitResponse, error) {
+	return a.GetHeightForCommitFunc(ctx, in, opts...)
+}
+
 func (a *apiMock) ListTransactionsForHeight(ctx context.Context, in *ListTransactionsForHeightRequest, opts ...grpc.CallOption) (*ListTransactionsForHeightResponse, error) {
 	return a.ListTransactionsForHeightFunc(ctx, in, opts...)
 }
 
+func (a *apiMock) ListTransactionsForScript(ctx context.Context, in *ListTransactionsForScriptRequest, opts ...grpc.CallOption) (*ListTransactionsForScriptResponse, error) {
+	return a.ListTransactionsForScriptFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) GetBlockStats(ctx context.Context, in *GetBlockStatsRequest, opts ...grpc.CallOption) (*GetBlockStatsResponse, error) {
+	return a.GetBlockStatsFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) GetRegisterChurn(ctx context.Context, in *GetRegisterChurnRequest, opts ...grpc.CallOption) (*GetRegisterChurnResponse, error) {
+	return a.GetRegisterChurnFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) GetRegisterDelta(ctx context.Context, in *GetRegisterDeltaRequest, opts ...grpc.CallOption) (*GetRegisterDeltaResponse, error) {
+	return a.GetRegisterDeltaFunc(ctx, in, opts...)
+}
+
 func (a *apiMock) GetResult(ctx context.Context, in *GetResultRequest, opts ...grpc.CallOption) (*GetResultResponse, error) {
 	return a.GetResultFunc(ctx, in, opts...)
 }
@@ -885,3 +1914,87 @@ func (a *apiMock) GetSeal(ctx context.Context, in *GetSealRequest, opts ...grpc.
 func (a *apiMock) ListSealsForHeight(ctx context.Context, in *ListSealsForHeightRequest, opts ...grpc.CallOption) (*ListSealsForHeightResponse, error) {
 	return a.ListSealsForHeightFunc(ctx, in, opts...)
 }
+
+func (a *apiMock) ListOwners(ctx context.Context, in *ListOwnersRequest, opts ...grpc.CallOption) (*ListOwnersResponse, error) {
+	return a.ListOwnersFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) ListEventTypes(ctx context.Context, in *ListEventTypesRequest, opts ...grpc.CallOption) (*ListEventTypesResponse, error) {
+	return a.ListEventTypesFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) GetAccountAtHeight(ctx context.Context, in *GetAccountAtHeightRequest, opts ...grpc.CallOption) (*GetAccountAtHeightResponse, error) {
+	return a.GetAccountAtHeightFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) GetContractHistory(ctx context.Context, in *GetContractHistoryRequest, opts ...grpc.CallOption) (*GetContractHistoryResponse, error) {
+	return a.GetContractHistoryFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) GetServiceEvents(ctx context.Context, in *GetServiceEventsRequest, opts ...grpc.CallOption) (*GetServiceEventsResponse, error) {
+	return a.GetServiceEventsFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) ListEpochs(ctx context.Context, in *ListEpochsRequest, opts ...grpc.CallOption) (*ListEpochsResponse, error) {
+	return a.ListEpochsFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) ListIdentitiesForEpoch(ctx context.Context, in *ListIdentitiesForEpochRequest, opts ...grpc.CallOption) (*ListIdentitiesForEpochResponse, error) {
+	return a.ListIdentitiesForEpochFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*GetVersionResponse, error) {
+	return a.GetVersionFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) GetInfo(ctx context.Context, in *GetInfoRequest, opts ...grpc.CallOption) (*GetInfoResponse, error) {
+	return a.GetInfoFunc(ctx, in, opts...)
+}
+
+type registersClientMock struct {
+	grpc.ClientStream
+
+	RecvFunc func() (*ListRegistersForOwnerResponse, error)
+}
+
+func (r *registersClientMock) Recv() (*ListRegistersForOwnerResponse, error) {
+	return r.RecvFunc()
+}
+
+type dumpClientMock struct {
+	grpc.ClientStream
+
+	RecvFunc func() (*DumpRegistersResponse, error)
+}
+
+func (d *dumpClientMock) Recv() (*DumpRegistersResponse, error) {
+	return d.RecvFunc()
+}
+
+func (a *apiMock) GetRawHeader(ctx context.Context, in *GetRawHeaderRequest, opts ...grpc.CallOption) (*GetRawHeaderResponse, error) {
+	return a.GetRawHeaderFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) GetRawEvents(ctx context.Context, in *GetRawEventsRequest, opts ...grpc.CallOption) (*GetRawEventsResponse, error) {
+	return a.GetRawEventsFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) GetRawCollection(ctx context.Context, in *GetRawCollectionRequest, opts ...grpc.CallOption) (*GetRawCollectionResponse, error) {
+	return a.GetRawCollectionFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) GetRawGuarantee(ctx context.Context, in *GetRawGuaranteeRequest, opts ...grpc.CallOption) (*GetRawGuaranteeResponse, error) {
+	return a.GetRawGuaranteeFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) GetRawTransaction(ctx context.Context, in *GetRawTransactionRequest, opts ...grpc.CallOption) (*GetRawTransactionResponse, error) {
+	return a.GetRawTransactionFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) GetRawResult(ctx context.Context, in *GetRawResultRequest, opts ...grpc.CallOption) (*GetRawResultResponse, error) {
+	return a.GetRawResultFunc(ctx, in, opts...)
+}
+
+func (a *apiMock) GetRawSeal(ctx context.Context, in *GetRawSealRequest, opts ...grpc.CallOption) (*GetRawSealResponse, error) {
+	return a.GetRawSealFunc(ctx, in, opts...)
+}
@@ -0,0 +1,35 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"google.golang.org/grpc"
+)
+
+// LegacyServiceName is the name under which the API service was registered
+// before it was moved into the `dps` protobuf package. Clients built against
+// older versions of the API call its methods as `/API/<Method>` rather than
+// `/dps.API/<Method>`.
+const LegacyServiceName = "API"
+
+// RegisterServer registers the given API server with the registrar under both
+// its current service name and its legacy one, so that clients built before
+// the service was moved into the `dps` package keep working.
+func RegisterServer(registrar grpc.ServiceRegistrar, server APIServer) {
+	RegisterAPIServer(registrar, server)
+	legacy := API_ServiceDesc
+	legacy.ServiceName = LegacyServiceName
+	registrar.RegisterService(&legacy, server)
+}
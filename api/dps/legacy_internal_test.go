@@ -0,0 +1,53 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type registrarMock struct {
+	descs []*grpc.ServiceDesc
+}
+
+func (r *registrarMock) RegisterService(desc *grpc.ServiceDesc, _ interface{}) {
+	r.descs = append(r.descs, desc)
+}
+
+func TestRegisterServer(t *testing.T) {
+	registrar := &registrarMock{}
+	server := &Server{}
+
+	RegisterServer(registrar, server)
+
+	require.Len(t, registrar.descs, 2)
+	assert.Equal(t, "dps.API", registrar.descs[0].ServiceName)
+	assert.Equal(t, LegacyServiceName, registrar.descs[1].ServiceName)
+	assert.Equal(t, "dps.API", API_ServiceDesc.ServiceName)
+
+	legacy := registrar.descs[1]
+	require.Len(t, legacy.Methods, len(API_ServiceDesc.Methods))
+	for i, method := range legacy.Methods {
+		assert.Equal(t, API_ServiceDesc.Methods[i].MethodName, method.MethodName)
+	}
+	require.Len(t, legacy.Streams, len(API_ServiceDesc.Streams))
+	for i, stream := range legacy.Streams {
+		assert.Equal(t, API_ServiceDesc.Streams[i].StreamName, stream.StreamName)
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/convert"
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// RawHeader returns the header for the finalized block at the given height, as
+// it is encoded in the index.
+func (i *Index) RawHeader(ctx context.Context, height uint64) ([]byte, error) {
+
+	req := GetRawHeaderRequest{
+		Height: height,
+	}
+	res, err := i.client.GetRawHeader(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get header: %w", err)
+	}
+
+	err = checkCodec(res.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Data, nil
+}
+
+// RawEvents returns the events of all transactions that were part of the
+// finalized block at the given height, as they are encoded in the index, in
+// one batch per event type. It can optionally filter them by event type; if no
+// event types are given, all batches are returned.
+func (i *Index) RawEvents(ctx context.Context, height uint64, types ...flow.EventType) ([][]byte, error) {
+	tt := convert.TypesToStrings(types)
+
+	req := GetRawEventsRequest{
+		Height: height,
+		Types:  tt,
+	}
+	res, err := i.client.GetRawEvents(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get events: %w", err)
+	}
+
+	err = checkCodec(res.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Data, nil
+}
+
+// RawCollection returns the collection with the given ID, as it is encoded in
+// the index.
+func (i *Index) RawCollection(ctx context.Context, collID flow.Identifier) ([]byte, error) {
+
+	req := GetRawCollectionRequest{
+		CollectionID: collID[:],
+	}
+	res, err := i.client.GetRawCollection(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get collection: %w", err)
+	}
+
+	err = checkCodec(res.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Data, nil
+}
+
+// RawGuarantee returns the guarantee with the given collection ID, as it is
+// encoded in the index.
+func (i *Index) RawGuarantee(ctx context.Context, collID flow.Identifier) ([]byte, error) {
+
+	req := GetRawGuaranteeRequest{
+		CollectionID: collID[:],
+	}
+	res, err := i.client.GetRawGuarantee(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get guarantee: %w", err)
+	}
+
+	err = checkCodec(res.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Data, nil
+}
+
+// RawTransaction returns the transaction with the given ID, as it is encoded
+// in the index.
+func (i *Index) RawTransaction(ctx context.Context, txID flow.Identifier) ([]byte, error) {
+
+	req := GetRawTransactionRequest{
+		TransactionID: txID[:],
+	}
+	res, err := i.client.GetRawTransaction(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get transaction: %w", err)
+	}
+
+	err = checkCodec(res.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Data, nil
+}
+
+// RawResult returns the transaction result for the given transaction ID, as it
+// is encoded in the index.
+func (i *Index) RawResult(ctx context.Context, txID flow.Identifier) ([]byte, error) {
+
+	req := GetRawResultRequest{
+		TransactionID: txID[:],
+	}
+	res, err := i.client.GetRawResult(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get transaction result: %w", err)
+	}
+
+	err = checkCodec(res.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Data, nil
+}
+
+// RawSeal returns the seal with the given ID, as it is encoded in the index.
+func (i *Index) RawSeal(ctx context.Context, sealID flow.Identifier) ([]byte, error) {
+
+	req := GetRawSealRequest{
+		SealID: sealID[:],
+	}
+	res, err := i.client.GetRawSeal(ctx, &req)
+	if err != nil {
+		return nil, fmt.Errorf("could not get seal: %w", err)
+	}
+
+	err = checkCodec(res.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Data, nil
+}
+
+// DecodeHeader decodes the header returned by the `GetRawHeader` method.
+func DecodeHeader(codec dps.Codec, res *GetRawHeaderResponse) (*flow.Header, error) {
+
+	err := checkCodec(res.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	var header flow.Header
+	err = codec.Unmarshal(res.Data, &header)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode header: %w", err)
+	}
+
+	return &header, nil
+}
+
+// DecodeEvents decodes the batches of events returned by the `GetRawEvents`
+// method, and returns all of their events.
+func DecodeEvents(codec dps.Codec, res *GetRawEventsResponse) ([]flow.Event, error) {
+
+	err := checkCodec(res.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []flow.Event
+	for _, data := range res.Data {
+		var batch []flow.Event
+		err = codec.Unmarshal(data, &batch)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode events: %w", err)
+		}
+		events = append(events, batch...)
+	}
+
+	return events, nil
+}
+
+// DecodeCollection decodes the collection returned by the `GetRawCollection`
+// method.
+func DecodeCollection(codec dps.Codec, res *GetRawCollectionResponse) (*flow.LightCollection, error) {
+
+	err := checkCodec(res.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	var collection flow.LightCollection
+	err = codec.Unmarshal(res.Data, &collection)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode collection: %w", err)
+	}
+
+	return &collection, nil
+}
+
+// DecodeGuarantee decodes the guarantee returned by the `GetRawGuarantee`
+// method.
+func DecodeGuarantee(codec dps.Codec, res *GetRawGuaranteeResponse) (*flow.CollectionGuarantee, error) {
+
+	err := checkCodec(res.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	var guarantee flow.CollectionGuarantee
+	err = codec.Unmarshal(res.Data, &guarantee)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode guarantee: %w", err)
+	}
+
+	return &guarantee, nil
+}
+
+// DecodeTransaction decodes the transaction returned by the
+// `GetRawTransaction` method.
+func DecodeTransaction(codec dps.Codec, res *GetRawTransactionResponse) (*flow.TransactionBody, error) {
+
+	err := checkCodec(res.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	var transaction flow.TransactionBody
+	err = codec.Unmarshal(res.Data, &transaction)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode transaction: %w", err)
+	}
+
+	return &transaction, nil
+}
+
+// DecodeResult decodes the transaction result returned by the `GetRawResult`
+// method.
+func DecodeResult(codec dps.Codec, res *GetRawResultResponse) (*flow.TransactionResult, error) {
+
+	err := checkCodec(res.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	var result flow.TransactionResult
+	err = codec.Unmarshal(res.Data, &result)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode transaction result: %w", err)
+	}
+
+	return &result, nil
+}
+
+// DecodeSeal decodes the seal returned by the `GetRawSeal` method.
+func DecodeSeal(codec dps.Codec, res *GetRawSealResponse) (*flow.Seal, error) {
+
+	err := checkCodec(res.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	var seal flow.Seal
+	err = codec.Unmarshal(res.Data, &seal)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode seal: %w", err)
+	}
+
+	return &seal, nil
+}
+
+// checkCodec makes sure that raw values were encoded with a codec that the
+// client knows how to decode.
+func checkCodec(codec string) error {
+	if codec != dps.CodecZbor {
+		return fmt.Errorf("unsupported codec (%s)", codec)
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/convert"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestIndex_RawHeader(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				GetRawHeaderFunc: func(_ context.Context, in *GetRawHeaderRequest, _ ...grpc.CallOption) (*GetRawHeaderResponse, error) {
+					assert.Equal(t, mocks.GenericHeight, in.Height)
+
+					return &GetRawHeaderResponse{
+						Height: mocks.GenericHeight,
+						Codec:  dps.CodecZbor,
+						Data:   mocks.GenericBytes,
+					}, nil
+				},
+			},
+		}
+
+		got, err := index.RawHeader(context.Background(), mocks.GenericHeight)
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericBytes, got)
+	})
+
+	t.Run("handles unsupported codec", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				GetRawHeaderFunc: func(context.Context, *GetRawHeaderRequest, ...grpc.CallOption) (*GetRawHeaderResponse, error) {
+					return &GetRawHeaderResponse{
+						Height: mocks.GenericHeight,
+						Codec:  "json",
+						Data:   mocks.GenericBytes,
+					}, nil
+				},
+			},
+		}
+
+		_, err := index.RawHeader(context.Background(), mocks.GenericHeight)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				GetRawHeaderFunc: func(context.Context, *GetRawHeaderRequest, ...grpc.CallOption) (*GetRawHeaderResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.RawHeader(context.Background(), mocks.GenericHeight)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_RawEvents(t *testing.T) {
+	types := mocks.GenericEventTypes(2)
+	data := [][]byte{mocks.GenericBytes, mocks.GenericBytes}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				GetRawEventsFunc: func(_ context.Context, in *GetRawEventsRequest, _ ...grpc.CallOption) (*GetRawEventsResponse, error) {
+					assert.Equal(t, mocks.GenericHeight, in.Height)
+					assert.Equal(t, convert.TypesToStrings(types), in.Types)
+
+					return &GetRawEventsResponse{
+						Height: mocks.GenericHeight,
+						Types:  in.Types,
+						Codec:  dps.CodecZbor,
+						Data:   data,
+					}, nil
+				},
+			},
+		}
+
+		got, err := index.RawEvents(context.Background(), mocks.GenericHeight, types...)
+
+		require.NoError(t, err)
+		assert.Equal(t, data, got)
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				GetRawEventsFunc: func(context.Context, *GetRawEventsRequest, ...grpc.CallOption) (*GetRawEventsResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.RawEvents(context.Background(), mocks.GenericHeight, types...)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndex_RawTransaction(t *testing.T) {
+	txID := mocks.GenericTransaction(0).ID()
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				GetRawTransactionFunc: func(_ context.Context, in *GetRawTransactionRequest, _ ...grpc.CallOption) (*GetRawTransactionResponse, error) {
+					assert.Equal(t, txID[:], in.TransactionID)
+
+					return &GetRawTransactionResponse{
+						TransactionID: in.TransactionID,
+						Codec:         dps.CodecZbor,
+						Data:          mocks.GenericBytes,
+					}, nil
+				},
+			},
+		}
+
+		got, err := index.RawTransaction(context.Background(), txID)
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericBytes, got)
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
+
+		index := Index{
+			codec: mocks.BaselineCodec(t),
+			client: &apiMock{
+				GetRawTransactionFunc: func(context.Context, *GetRawTransactionRequest, ...grpc.CallOption) (*GetRawTransactionResponse, error) {
+					return nil, mocks.GenericError
+				},
+			},
+		}
+
+		_, err := index.RawTransaction(context.Background(), txID)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestDecodeHeader(t *testing.T) {
+	data, err := cbor.Marshal(mocks.GenericHeader)
+	require.NoError(t, err)
+
+	codec := mocks.BaselineCodec(t)
+	codec.UnmarshalFunc = cbor.Unmarshal
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		res := GetRawHeaderResponse{
+			Height: mocks.GenericHeight,
+			Codec:  dps.CodecZbor,
+			Data:   data,
+		}
+
+		got, err := DecodeHeader(codec, &res)
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeader, got)
+	})
+
+	t.Run("handles unsupported codec", func(t *testing.T) {
+		t.Parallel()
+
+		res := GetRawHeaderResponse{
+			Height: mocks.GenericHeight,
+			Codec:  "json",
+			Data:   data,
+		}
+
+		_, err := DecodeHeader(codec, &res)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles decoding failure", func(t *testing.T) {
+		t.Parallel()
+
+		res := GetRawHeaderResponse{
+			Height: mocks.GenericHeight,
+			Codec:  dps.CodecZbor,
+			Data:   mocks.GenericBytes,
+		}
+
+		_, err := DecodeHeader(codec, &res)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestDecodeEvents(t *testing.T) {
+	types := mocks.GenericEventTypes(2)
+	events := mocks.GenericEvents(4, types...)
+
+	// The events are batched by type, as they are in the index.
+	var batches [][]flow.Event
+	for _, typ := range types {
+		var batch []flow.Event
+		for _, event := range events {
+			if event.Type == typ {
+				batch = append(batch, event)
+			}
+		}
+		batches = append(batches, batch)
+	}
+
+	var data [][]byte
+	var want []flow.Event
+	for _, batch := range batches {
+		encoded, err := cbor.Marshal(batch)
+		require.NoError(t, err)
+		data = append(data, encoded)
+		want = append(want, batch...)
+	}
+
+	codec := mocks.BaselineCodec(t)
+	codec.UnmarshalFunc = cbor.Unmarshal
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		res := GetRawEventsResponse{
+			Height: mocks.GenericHeight,
+			Codec:  dps.CodecZbor,
+			Data:   data,
+		}
+
+		got, err := DecodeEvents(codec, &res)
+
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("handles unsupported codec", func(t *testing.T) {
+		t.Parallel()
+
+		res := GetRawEventsResponse{
+			Height: mocks.GenericHeight,
+			Codec:  "json",
+			Data:   data,
+		}
+
+		_, err := DecodeEvents(codec, &res)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestDecodeSeal(t *testing.T) {
+	seal := mocks.GenericSeal(0)
+	data, err := cbor.Marshal(seal)
+	require.NoError(t, err)
+
+	codec := mocks.BaselineCodec(t)
+	codec.UnmarshalFunc = cbor.Unmarshal
+
+	res := GetRawSealResponse{
+		SealID: mocks.ByteSlice(seal.ID()),
+		Codec:  dps.CodecZbor,
+		Data:   data,
+	}
+
+	got, err := DecodeSeal(codec, &res)
+
+	require.NoError(t, err)
+	assert.Equal(t, seal, got)
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"fmt"
+
+	"github.com/onflow/flow-go/engine/execution/state"
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// registerToMessage converts the given register into its API representation,
+// which only includes the controller and key parts of its ledger key, as the
+// owner is the same for all registers in a response.
+func registerToMessage(register dps.Register) *Register {
+
+	msg := Register{
+		Path:  register.Path[:],
+		Value: register.Payload.Value,
+	}
+	for _, part := range register.Payload.Key.KeyParts {
+		switch part.Type {
+		case state.KeyPartController:
+			msg.Controller = part.Value
+		case state.KeyPartKey:
+			msg.Key = part.Value
+		}
+	}
+
+	return &msg
+}
+
+// dumpToMessage converts the given register into its API representation for
+// register dumps, which also includes the owner part of its ledger key, as the
+// registers of a dump belong to different owners.
+func dumpToMessage(register dps.Register) *Register {
+
+	msg := registerToMessage(register)
+	for _, part := range register.Payload.Key.KeyParts {
+		if part.Type == state.KeyPartOwner {
+			msg.Owner = part.Value
+		}
+	}
+
+	return msg
+}
+
+// messageToRegister converts the given API representation of a register owned
+// by the account with the given address back into a register.
+func messageToRegister(owner flow.Address, msg *Register) (dps.Register, error) {
+
+	path, err := ledger.ToPath(msg.Path)
+	if err != nil {
+		return dps.Register{}, fmt.Errorf("could not convert path (%x): %w", msg.Path, err)
+	}
+
+	key := ledger.NewKey([]ledger.KeyPart{
+		ledger.NewKeyPart(state.KeyPartOwner, owner.Bytes()),
+		ledger.NewKeyPart(state.KeyPartController, msg.Controller),
+		ledger.NewKeyPart(state.KeyPartKey, msg.Key),
+	})
+	register := dps.Register{
+		Path:    path,
+		Payload: ledger.NewPayload(key, msg.Value),
+	}
+
+	return register, nil
+}
+
+// messageToDump converts the given API representation of a register from a
+// register dump back into a register.
+func messageToDump(msg *Register) (dps.Register, error) {
+
+	path, err := ledger.ToPath(msg.Path)
+	if err != nil {
+		return dps.Register{}, fmt.Errorf("could not convert path (%x): %w", msg.Path, err)
+	}
+
+	key := ledger.NewKey([]ledger.KeyPart{
+		ledger.NewKeyPart(state.KeyPartOwner, msg.Owner),
+		ledger.NewKeyPart(state.KeyPartController, msg.Controller),
+		ledger.NewKeyPart(state.KeyPartKey, msg.Key),
+	})
+	register := dps.Register{
+		Path:    path,
+		Payload: ledger.NewPayload(key, msg.Value),
+	}
+
+	return register, nil
+}
@@ -17,9 +17,15 @@ package dps
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
+	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/model/flow"
 
 	"github.com/optakt/flow-dps/models/convert"
@@ -29,21 +35,29 @@ import (
 // Server is a simple implementation of the generated APIServer interface. It
 // uses an index reader interface as the backend to retrieve the desired data.
 // This is generally an on-disk interface, but could be a GRPC-based index as
-// well, in which case there is a double redirection.
+// well, in which case there is a double redirection. Each RPC only gets the
+// narrower reader for the part of the index it serves.
 type Server struct {
 	index dps.Reader
 	codec dps.Codec
+	cfg   Config
 
 	validate *validator.Validate
 }
 
 // NewServer creates a new server, using the provided index reader as a backend
 // for data retrieval.
-func NewServer(index dps.Reader, codec dps.Codec) *Server {
+func NewServer(index dps.Reader, codec dps.Codec, options ...func(*Config)) *Server {
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
 
 	s := Server{
 		index:    index,
 		codec:    codec,
+		cfg:      cfg,
 		validate: validator.New(),
 	}
 
@@ -51,9 +65,14 @@ func NewServer(index dps.Reader, codec dps.Codec) *Server {
 }
 
 // GetFirst implements the `GetFirst` method of the generated GRPC server.
-func (s *Server) GetFirst(_ context.Context, _ *GetFirstRequest) (*GetFirstResponse, error) {
+func (s *Server) GetFirst(ctx context.Context, req *GetFirstRequest) (*GetFirstResponse, error) {
+
+	index, err := s.heights(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
 
-	height, err := s.index.First()
+	height, err := index.First(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("could not get first height: %w", err)
 	}
@@ -66,9 +85,14 @@ func (s *Server) GetFirst(_ context.Context, _ *GetFirstRequest) (*GetFirstRespo
 }
 
 // GetLast implements the `GetLast` method of the generated GRPC server.
-func (s *Server) GetLast(_ context.Context, _ *GetLastRequest) (*GetLastResponse, error) {
+func (s *Server) GetLast(ctx context.Context, req *GetLastRequest) (*GetLastResponse, error) {
+
+	index, err := s.heights(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
 
-	height, err := s.index.Last()
+	height, err := index.Last(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("could not get last height: %w", err)
 	}
@@ -82,15 +106,20 @@ func (s *Server) GetLast(_ context.Context, _ *GetLastRequest) (*GetLastResponse
 
 // GetHeightForBlock implements the `GetHeightForBlock` method of the generated GRPC
 // server.
-func (s *Server) GetHeightForBlock(_ context.Context, req *GetHeightForBlockRequest) (*GetHeightForBlockResponse, error) {
+func (s *Server) GetHeightForBlock(ctx context.Context, req *GetHeightForBlockRequest) (*GetHeightForBlockResponse, error) {
 
 	err := s.validate.Struct(req)
 	if err != nil {
 		return nil, fmt.Errorf("bad request: %w", err)
 	}
 
+	index, err := s.heights(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
 	blockID := flow.HashToID(req.BlockID)
-	height, err := s.index.HeightForBlock(blockID)
+	height, err := index.HeightForBlock(ctx, blockID)
 	if err != nil {
 		return nil, fmt.Errorf("could not get height for block: %w", err)
 	}
@@ -104,14 +133,23 @@ func (s *Server) GetHeightForBlock(_ context.Context, req *GetHeightForBlockRequ
 }
 
 // GetCommit implements the `GetCommit` method of the generated GRPC server.
-func (s *Server) GetCommit(_ context.Context, req *GetCommitRequest) (*GetCommitResponse, error) {
+func (s *Server) GetCommit(ctx context.Context, req *GetCommitRequest) (*GetCommitResponse, error) {
+
+	if s.cfg.ProtocolOnly {
+		return nil, status.Error(codes.Unimplemented, "execution data is not available")
+	}
 
 	err := s.validate.Struct(req)
 	if err != nil {
 		return nil, fmt.Errorf("bad request: %w", err)
 	}
 
-	commit, err := s.index.Commit(req.Height)
+	index, err := s.heights(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := index.Commit(ctx, req.Height)
 	if err != nil {
 		return nil, fmt.Errorf("could not get commit: %w", err)
 	}
@@ -125,14 +163,19 @@ func (s *Server) GetCommit(_ context.Context, req *GetCommitRequest) (*GetCommit
 }
 
 // GetHeader implements the `GetHeader` method of the generated GRPC server.
-func (s *Server) GetHeader(_ context.Context, req *GetHeaderRequest) (*GetHeaderResponse, error) {
+func (s *Server) GetHeader(ctx context.Context, req *GetHeaderRequest) (*GetHeaderResponse, error) {
 
 	err := s.validate.Struct(req)
 	if err != nil {
 		return nil, fmt.Errorf("bad request: %w", err)
 	}
 
-	header, err := s.index.Header(req.Height)
+	index, err := s.heights(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := index.Header(ctx, req.Height)
 	if err != nil {
 		return nil, fmt.Errorf("could not get header: %w", err)
 	}
@@ -150,11 +193,64 @@ func (s *Server) GetHeader(_ context.Context, req *GetHeaderRequest) (*GetHeader
 	return &res, nil
 }
 
+// GetBlockStats implements the `GetBlockStats` method of the generated GRPC
+// server.
+func (s *Server) GetBlockStats(ctx context.Context, req *GetBlockStatsRequest) (*GetBlockStatsResponse, error) {
+
+	if s.cfg.ProtocolOnly {
+		return nil, status.Error(codes.Unimplemented, "execution data is not available")
+	}
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		return nil, fmt.Errorf("bad request: %w", err)
+	}
+
+	index, err := s.heights(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := index.BlockStats(ctx, req.StartHeight, req.EndHeight)
+	if err != nil {
+		return nil, fmt.Errorf("could not get block stats: %w", err)
+	}
+
+	entries := make([]*BlockStats, 0, len(stats))
+	for _, entry := range stats {
+		entries = append(entries, &BlockStats{
+			Height:       entry.Height,
+			Transactions: entry.Transactions,
+			Events:       entry.Events,
+			Fees:         entry.Fees,
+			Computation:  entry.Computation,
+			Senders:      entry.Senders,
+		})
+	}
+
+	res := GetBlockStatsResponse{
+		StartHeight: req.StartHeight,
+		EndHeight:   req.EndHeight,
+		Stats:       entries,
+	}
+
+	return &res, nil
+}
+
 // GetEvents implements the `GetEvents` method of the generated GRPC server.
-func (s *Server) GetEvents(_ context.Context, req *GetEventsRequest) (*GetEventsResponse, error) {
+func (s *Server) GetEvents(ctx context.Context, req *GetEventsRequest) (*GetEventsResponse, error) {
+
+	if s.cfg.ProtocolOnly {
+		return nil, status.Error(codes.Unimplemented, "execution data is not available")
+	}
+
+	index, err := s.events(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
 
 	types := convert.StringsToTypes(req.Types)
-	events, err := s.index.Events(req.Height, types...)
+	events, err := index.Events(ctx, req.Height, types...)
 	if err != nil {
 		return nil, fmt.Errorf("could not get events: %w", err)
 	}
@@ -175,7 +271,11 @@ func (s *Server) GetEvents(_ context.Context, req *GetEventsRequest) (*GetEvents
 
 // GetRegisterValues implements the `GetRegisterValues` method of the
 // generated GRPC server.
-func (s *Server) GetRegisterValues(_ context.Context, req *GetRegisterValuesRequest) (*GetRegisterValuesResponse, error) {
+func (s *Server) GetRegisterValues(ctx context.Context, req *GetRegisterValuesRequest) (*GetRegisterValuesResponse, error) {
+
+	if s.cfg.ProtocolOnly {
+		return nil, status.Error(codes.Unimplemented, "execution data is not available")
+	}
 
 	err := s.validate.Struct(req)
 	if err != nil {
@@ -187,7 +287,12 @@ func (s *Server) GetRegisterValues(_ context.Context, req *GetRegisterValuesRequ
 		return nil, fmt.Errorf("could not convert paths: %w", err)
 	}
 
-	values, err := s.index.Values(req.Height, paths)
+	index, err := s.registers(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := index.Values(ctx, req.Height, paths)
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve values: %w", err)
 	}
@@ -201,17 +306,312 @@ func (s *Server) GetRegisterValues(_ context.Context, req *GetRegisterValuesRequ
 	return &res, nil
 }
 
+// ListRegistersForOwner implements the `ListRegistersForOwner` method of the
+// generated GRPC server.
+func (s *Server) ListRegistersForOwner(req *ListRegistersForOwnerRequest, stream API_ListRegistersForOwnerServer) error {
+
+	if s.cfg.ProtocolOnly {
+		return status.Error(codes.Unimplemented, "execution data is not available")
+	}
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		return fmt.Errorf("bad request: %w", err)
+	}
+
+	index, err := s.registers(req.ChainID)
+	if err != nil {
+		return err
+	}
+	index, release := snapshot(index)
+	defer release()
+
+	limit := uint(req.Limit)
+	if limit == 0 || limit > s.cfg.PageSize {
+		limit = s.cfg.PageSize
+	}
+
+	var after ledger.Path
+	if len(req.Cursor) > 0 {
+		after, err = ledger.ToPath(req.Cursor)
+		if err != nil {
+			return fmt.Errorf("could not convert cursor: %w", err)
+		}
+	}
+
+	// We retrieve one register more than we send with each page, so that we
+	// know whether it is the last page, in which case its cursor stays empty.
+	ctx := stream.Context()
+	owner := flow.BytesToAddress(req.Owner)
+	for {
+		err = ctx.Err()
+		if err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		registers, err := index.Registers(ctx, req.Height, owner, after, limit+1)
+		if err != nil {
+			return fmt.Errorf("could not retrieve registers: %w", err)
+		}
+
+		res := ListRegistersForOwnerResponse{
+			Height: req.Height,
+			Owner:  req.Owner,
+		}
+		more := uint(len(registers)) > limit
+		if more {
+			registers = registers[:limit]
+			res.Cursor = registers[len(registers)-1].Path[:]
+			after = registers[len(registers)-1].Path
+		}
+		res.Registers = make([]*Register, 0, len(registers))
+		for _, register := range registers {
+			res.Registers = append(res.Registers, registerToMessage(register))
+		}
+
+		err = stream.Send(&res)
+		if err != nil {
+			return fmt.Errorf("could not send registers: %w", err)
+		}
+
+		if !more {
+			return nil
+		}
+	}
+}
+
+// DumpRegisters implements the `DumpRegisters` method of the generated GRPC
+// server. It streams the registers of the whole execution state in chunks,
+// which are compressed into independent zstd frames if requested, so that
+// clients can decompress each chunk as it arrives.
+func (s *Server) DumpRegisters(req *DumpRegistersRequest, stream API_DumpRegistersServer) error {
+
+	if s.cfg.ProtocolOnly {
+		return status.Error(codes.Unimplemented, "execution data is not available")
+	}
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		return fmt.Errorf("bad request: %w", err)
+	}
+
+	index, err := s.registers(req.ChainID)
+	if err != nil {
+		return err
+	}
+	index, release := snapshot(index)
+	defer release()
+
+	limit := uint(req.Limit)
+	if limit == 0 || limit > s.cfg.PageSize {
+		limit = s.cfg.PageSize
+	}
+
+	var after ledger.Path
+	if len(req.Cursor) > 0 {
+		after, err = ledger.ToPath(req.Cursor)
+		if err != nil {
+			return fmt.Errorf("could not convert cursor: %w", err)
+		}
+	}
+
+	var compressor *zstd.Encoder
+	if req.Compress {
+		compressor, err = zstd.NewWriter(nil)
+		if err != nil {
+			return fmt.Errorf("could not create compressor: %w", err)
+		}
+		defer compressor.Close()
+	}
+
+	// As for listings, we retrieve one register more than we send with each
+	// chunk, so that we know whether it is the last chunk, in which case its
+	// cursor stays empty.
+	ctx := stream.Context()
+	for {
+		err = ctx.Err()
+		if err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		registers, err := index.DumpRegisters(ctx, req.Height, after, limit+1)
+		if err != nil {
+			return fmt.Errorf("could not retrieve registers: %w", err)
+		}
+
+		res := DumpRegistersResponse{
+			Height: req.Height,
+		}
+		more := uint(len(registers)) > limit
+		if more {
+			registers = registers[:limit]
+			res.Cursor = registers[len(registers)-1].Path[:]
+			after = registers[len(registers)-1].Path
+		}
+		msgs := make([]*Register, 0, len(registers))
+		for _, register := range registers {
+			msgs = append(msgs, dumpToMessage(register))
+		}
+
+		if compressor == nil {
+			res.Registers = msgs
+		} else {
+			chunk := RegisterChunk{
+				Registers: msgs,
+			}
+			data, err := proto.Marshal(&chunk)
+			if err != nil {
+				return fmt.Errorf("could not encode registers: %w", err)
+			}
+			res.Compressed = compressor.EncodeAll(data, nil)
+		}
+
+		err = stream.Send(&res)
+		if err != nil {
+			return fmt.Errorf("could not send registers: %w", err)
+		}
+
+		if !more {
+			return nil
+		}
+	}
+}
+
+// GetRegisterChurn implements the `GetRegisterChurn` method of the generated
+// GRPC server. The number of owners and registers in the report is capped by
+// the page size of the server.
+func (s *Server) GetRegisterChurn(ctx context.Context, req *GetRegisterChurnRequest) (*GetRegisterChurnResponse, error) {
+
+	if s.cfg.ProtocolOnly {
+		return nil, status.Error(codes.Unimplemented, "execution data is not available")
+	}
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		return nil, fmt.Errorf("bad request: %w", err)
+	}
+
+	index, err := s.registers(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := uint(req.Limit)
+	if limit == 0 || limit > s.cfg.PageSize {
+		limit = s.cfg.PageSize
+	}
+
+	report, err := index.RegisterChurn(ctx, req.StartHeight, req.EndHeight, limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not get register churn: %w", err)
+	}
+
+	owners := make([]*OwnerWrites, 0, len(report.Owners))
+	for _, entry := range report.Owners {
+		owner := entry.Owner
+		owners = append(owners, &OwnerWrites{
+			Owner:  owner[:],
+			Writes: entry.Writes,
+		})
+	}
+
+	registers := make([]*RegisterWrites, 0, len(report.Registers))
+	for _, entry := range report.Registers {
+		owner := entry.Owner
+		registers = append(registers, &RegisterWrites{
+			Owner:  owner[:],
+			Key:    []byte(entry.Key),
+			Writes: entry.Writes,
+		})
+	}
+
+	res := GetRegisterChurnResponse{
+		StartHeight: req.StartHeight,
+		EndHeight:   req.EndHeight,
+		Writes:      report.Writes,
+		Owners:      owners,
+		Registers:   registers,
+	}
+
+	return &res, nil
+}
+
+// GetRegisterDelta implements the `GetRegisterDelta` method of the generated
+// GRPC server.
+func (s *Server) GetRegisterDelta(ctx context.Context, req *GetRegisterDeltaRequest) (*GetRegisterDeltaResponse, error) {
+
+	if s.cfg.ProtocolOnly {
+		return nil, status.Error(codes.Unimplemented, "execution data is not available")
+	}
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		return nil, fmt.Errorf("bad request: %w", err)
+	}
+
+	index, err := s.registers(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := uint(req.Limit)
+	if limit == 0 || limit > s.cfg.PageSize {
+		limit = s.cfg.PageSize
+	}
+
+	var after ledger.Path
+	if len(req.Cursor) > 0 {
+		after, err = ledger.ToPath(req.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert cursor: %w", err)
+		}
+	}
+
+	// We retrieve one delta more than we return, so that we know whether this
+	// is the last page, in which case the cursor stays empty.
+	deltas, err := index.RegisterDeltas(ctx, req.Height, after, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("could not get register deltas: %w", err)
+	}
+
+	res := GetRegisterDeltaResponse{
+		Height: req.Height,
+	}
+	if uint(len(deltas)) > limit {
+		deltas = deltas[:limit]
+		last := deltas[len(deltas)-1].Path
+		res.Cursor = last[:]
+	}
+
+	res.Deltas = make([]*RegisterDelta, 0, len(deltas))
+	for _, delta := range deltas {
+		path := delta.Path
+		res.Deltas = append(res.Deltas, &RegisterDelta{
+			Path:     path[:],
+			Previous: delta.Previous,
+			Current:  delta.Current,
+		})
+	}
+
+	return &res, nil
+}
+
 // GetCollection implements the `GetCollection` method of the generated GRPC
 // server.
-func (s *Server) GetCollection(_ context.Context, req *GetCollectionRequest) (*GetCollectionResponse, error) {
+func (s *Server) GetCollection(ctx context.Context, req *GetCollectionRequest) (*GetCollectionResponse, error) {
 
 	err := s.validate.Struct(req)
 	if err != nil {
 		return nil, fmt.Errorf("bad request: %w", err)
 	}
 
+	index, err := s.transactions(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
 	collID := flow.HashToID(req.CollectionID)
-	collection, err := s.index.Collection(collID)
+	collection, err := index.Collection(ctx, collID)
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve collection: %w", err)
 	}
@@ -231,13 +631,19 @@ func (s *Server) GetCollection(_ context.Context, req *GetCollectionRequest) (*G
 
 // ListCollectionsForHeight implements the `ListCollectionsForHeight` method of the generated GRPC
 // server.
-func (s *Server) ListCollectionsForHeight(_ context.Context, req *ListCollectionsForHeightRequest) (*ListCollectionsForHeightResponse, error) {
+func (s *Server) ListCollectionsForHeight(ctx context.Context, req *ListCollectionsForHeightRequest) (*ListCollectionsForHeightResponse, error) {
 
 	err := s.validate.Struct(req)
 	if err != nil {
 		return nil, fmt.Errorf("bad request: %w", err)
 	}
-	collIDs, err := s.index.CollectionsByHeight(req.Height)
+
+	index, err := s.transactions(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	collIDs, err := index.CollectionsByHeight(ctx, req.Height)
 	if err != nil {
 		return nil, fmt.Errorf("could not list collections by height: %w", err)
 	}
@@ -257,15 +663,20 @@ func (s *Server) ListCollectionsForHeight(_ context.Context, req *ListCollection
 
 // GetGuarantee implements the `GetGuarantee` method of the generated GRPC
 // server.
-func (s *Server) GetGuarantee(_ context.Context, req *GetGuaranteeRequest) (*GetGuaranteeResponse, error) {
+func (s *Server) GetGuarantee(ctx context.Context, req *GetGuaranteeRequest) (*GetGuaranteeResponse, error) {
 
 	err := s.validate.Struct(req)
 	if err != nil {
 		return nil, fmt.Errorf("bad request: %w", err)
 	}
 
+	index, err := s.transactions(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
 	collID := flow.HashToID(req.CollectionID)
-	guarantee, err := s.index.Guarantee(collID)
+	guarantee, err := index.Guarantee(ctx, collID)
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve guarantee: %w", err)
 	}
@@ -285,15 +696,20 @@ func (s *Server) GetGuarantee(_ context.Context, req *GetGuaranteeRequest) (*Get
 
 // GetTransaction implements the `GetTransaction` method of the generated GRPC
 // server.
-func (s *Server) GetTransaction(_ context.Context, req *GetTransactionRequest) (*GetTransactionResponse, error) {
+func (s *Server) GetTransaction(ctx context.Context, req *GetTransactionRequest) (*GetTransactionResponse, error) {
 
 	err := s.validate.Struct(req)
 	if err != nil {
 		return nil, fmt.Errorf("bad request: %w", err)
 	}
 
+	index, err := s.transactions(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
 	txID := flow.HashToID(req.TransactionID)
-	transaction, err := s.index.Transaction(txID)
+	transaction, err := index.Transaction(ctx, txID)
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve transaction: %w", err)
 	}
@@ -306,6 +722,7 @@ func (s *Server) GetTransaction(_ context.Context, req *GetTransactionRequest) (
 	res := GetTransactionResponse{
 		TransactionID: req.TransactionID,
 		Data:          data,
+		Transaction:   transactionToMessage(transaction),
 	}
 
 	return &res, nil
@@ -313,15 +730,20 @@ func (s *Server) GetTransaction(_ context.Context, req *GetTransactionRequest) (
 
 // GetHeightForTransaction implements the `GetHeightForTransaction` method of the generated GRPC
 // server.
-func (s *Server) GetHeightForTransaction(_ context.Context, req *GetHeightForTransactionRequest) (*GetHeightForTransactionResponse, error) {
+func (s *Server) GetHeightForTransaction(ctx context.Context, req *GetHeightForTransactionRequest) (*GetHeightForTransactionResponse, error) {
 
 	err := s.validate.Struct(req)
 	if err != nil {
 		return nil, fmt.Errorf("bad request: %w", err)
 	}
 
+	index, err := s.heights(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
 	txID := flow.HashToID(req.TransactionID)
-	height, err := s.index.HeightForTransaction(txID)
+	height, err := index.HeightForTransaction(ctx, txID)
 	if err != nil {
 		return nil, fmt.Errorf("could not get height for transaction: %w", err)
 	}
@@ -334,16 +756,56 @@ func (s *Server) GetHeightForTransaction(_ context.Context, req *GetHeightForTra
 	return &res, nil
 }
 
+// GetHeightForCommit implements the `GetHeightForCommit` method of the
+// generated GRPC server.
+func (s *Server) GetHeightForCommit(ctx context.Context, req *GetHeightForCommitRequest) (*GetHeightForCommitResponse, error) {
+
+	if s.cfg.ProtocolOnly {
+		return nil, status.Error(codes.Unimplemented, "execution data is not available")
+	}
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		return nil, fmt.Errorf("bad request: %w", err)
+	}
+
+	index, err := s.heights(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := flow.ToStateCommitment(req.Commit)
+	if err != nil {
+		return nil, fmt.Errorf("bad request: %w", err)
+	}
+	height, err := index.HeightForCommit(ctx, commit)
+	if err != nil {
+		return nil, fmt.Errorf("could not get height for commit: %w", err)
+	}
+
+	res := GetHeightForCommitResponse{
+		Commit: req.Commit,
+		Height: height,
+	}
+
+	return &res, nil
+}
+
 // ListTransactionsForHeight implements the `ListTransactionsForHeight` method of the generated GRPC
 // server.
-func (s *Server) ListTransactionsForHeight(_ context.Context, req *ListTransactionsForHeightRequest) (*ListTransactionsForHeightResponse, error) {
+func (s *Server) ListTransactionsForHeight(ctx context.Context, req *ListTransactionsForHeightRequest) (*ListTransactionsForHeightResponse, error) {
 
 	err := s.validate.Struct(req)
 	if err != nil {
 		return nil, fmt.Errorf("bad request: %w", err)
 	}
 
-	txIDs, err := s.index.TransactionsByHeight(req.Height)
+	index, err := s.transactions(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	txIDs, err := index.TransactionsByHeight(ctx, req.Height)
 	if err != nil {
 		return nil, fmt.Errorf("could not list transactions by height: %w", err)
 	}
@@ -361,17 +823,61 @@ func (s *Server) ListTransactionsForHeight(_ context.Context, req *ListTransacti
 	return &res, nil
 }
 
+// ListTransactionsForScript implements the `ListTransactionsForScript` method
+// of the generated GRPC server.
+func (s *Server) ListTransactionsForScript(ctx context.Context, req *ListTransactionsForScriptRequest) (*ListTransactionsForScriptResponse, error) {
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		return nil, fmt.Errorf("bad request: %w", err)
+	}
+
+	index, err := s.transactions(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := flow.HashToID(req.ScriptHash)
+	txIDs, err := index.TransactionsForScript(ctx, hash, req.StartHeight, req.EndHeight)
+	if err != nil {
+		return nil, fmt.Errorf("could not list transactions for script: %w", err)
+	}
+
+	transactionIDs := make([][]byte, 0, len(txIDs))
+	for _, txID := range txIDs {
+		transactionIDs = append(transactionIDs, convert.IDToHash(txID))
+	}
+
+	res := ListTransactionsForScriptResponse{
+		ScriptHash:     req.ScriptHash,
+		StartHeight:    req.StartHeight,
+		EndHeight:      req.EndHeight,
+		TransactionIDs: transactionIDs,
+	}
+
+	return &res, nil
+}
+
 // GetResult implements the `GetResult` method of the generated GRPC
 // server.
-func (s *Server) GetResult(_ context.Context, req *GetResultRequest) (*GetResultResponse, error) {
+func (s *Server) GetResult(ctx context.Context, req *GetResultRequest) (*GetResultResponse, error) {
+
+	if s.cfg.ProtocolOnly {
+		return nil, status.Error(codes.Unimplemented, "execution data is not available")
+	}
 
 	err := s.validate.Struct(req)
 	if err != nil {
 		return nil, fmt.Errorf("bad request: %w", err)
 	}
 
+	index, err := s.transactions(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
 	txID := flow.HashToID(req.TransactionID)
-	result, err := s.index.Result(txID)
+	result, err := index.Result(ctx, txID)
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve transaction result: %w", err)
 	}
@@ -391,15 +897,20 @@ func (s *Server) GetResult(_ context.Context, req *GetResultRequest) (*GetResult
 
 // GetSeal implements the `GetSeal` method of the generated GRPC
 // server.
-func (s *Server) GetSeal(_ context.Context, req *GetSealRequest) (*GetSealResponse, error) {
+func (s *Server) GetSeal(ctx context.Context, req *GetSealRequest) (*GetSealResponse, error) {
 
 	err := s.validate.Struct(req)
 	if err != nil {
 		return nil, fmt.Errorf("bad request: %w", err)
 	}
 
+	index, err := s.transactions(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
 	sealID := flow.HashToID(req.SealID)
-	seal, err := s.index.Seal(sealID)
+	seal, err := index.Seal(ctx, sealID)
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve seal: %w", err)
 	}
@@ -419,14 +930,19 @@ func (s *Server) GetSeal(_ context.Context, req *GetSealRequest) (*GetSealRespon
 
 // ListSealsForHeight implements the `ListSealsForHeight` method of the generated GRPC
 // server.
-func (s *Server) ListSealsForHeight(_ context.Context, req *ListSealsForHeightRequest) (*ListSealsForHeightResponse, error) {
+func (s *Server) ListSealsForHeight(ctx context.Context, req *ListSealsForHeightRequest) (*ListSealsForHeightResponse, error) {
 
 	err := s.validate.Struct(req)
 	if err != nil {
 		return nil, fmt.Errorf("bad request: %w", err)
 	}
 
-	sealIDs, err := s.index.SealsByHeight(req.Height)
+	index, err := s.transactions(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	sealIDs, err := index.SealsByHeight(ctx, req.Height)
 	if err != nil {
 		return nil, fmt.Errorf("could not list seals by height: %w", err)
 	}
@@ -443,3 +959,591 @@ func (s *Server) ListSealsForHeight(_ context.Context, req *ListSealsForHeightRe
 
 	return &res, nil
 }
+
+// ListOwners implements the `ListOwners` method of the generated GRPC server.
+func (s *Server) ListOwners(ctx context.Context, req *ListOwnersRequest) (*ListOwnersResponse, error) {
+
+	index, err := s.registers(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := index.Filter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get filter: %w", err)
+	}
+
+	owners := make([][]byte, 0, len(filter.Allowed))
+	for _, owner := range filter.Allowed {
+		owners = append(owners, owner.Bytes())
+	}
+	denied := make([][]byte, 0, len(filter.Denied))
+	for _, owner := range filter.Denied {
+		denied = append(denied, owner.Bytes())
+	}
+
+	res := ListOwnersResponse{
+		Owners: owners,
+		Denied: denied,
+	}
+
+	return &res, nil
+}
+
+// ListEventTypes implements the `ListEventTypes` method of the generated GRPC
+// server.
+func (s *Server) ListEventTypes(ctx context.Context, req *ListEventTypesRequest) (*ListEventTypesResponse, error) {
+
+	if s.cfg.ProtocolOnly {
+		return nil, status.Error(codes.Unimplemented, "execution data is not available")
+	}
+
+	index, err := s.events(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := index.EventTypes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get event types: %w", err)
+	}
+
+	types := make([]*EventTypeStats, 0, len(stats))
+	for _, stat := range stats {
+		types = append(types, &EventTypeStats{
+			Type:  string(stat.Type),
+			First: stat.First,
+			Last:  stat.Last,
+			Count: stat.Count,
+		})
+	}
+
+	res := ListEventTypesResponse{
+		Types: types,
+	}
+
+	return &res, nil
+}
+
+// GetAccountAtHeight implements the `GetAccountAtHeight` method of the
+// generated GRPC server.
+func (s *Server) GetAccountAtHeight(ctx context.Context, req *GetAccountAtHeightRequest) (*GetAccountAtHeightResponse, error) {
+
+	if s.cfg.ProtocolOnly {
+		return nil, status.Error(codes.Unimplemented, "execution data is not available")
+	}
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		return nil, fmt.Errorf("bad request: %w", err)
+	}
+
+	index, err := s.registers(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	index, release := snapshot(index)
+	defer release()
+
+	address := flow.BytesToAddress(req.Address)
+	account, err := index.Account(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("could not get account: %w", err)
+	}
+
+	keys, contracts, ok, err := accountState(ctx, index, req.Height, address)
+	if err != nil {
+		return nil, fmt.Errorf("could not read account state: %w", err)
+	}
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "account does not exist at height (address: %s, height: %d)", address, req.Height)
+	}
+
+	updates := make([]uint64, 0, len(account.KeyUpdates))
+	for _, height := range account.KeyUpdates {
+		if height > req.Height {
+			break
+		}
+		updates = append(updates, height)
+	}
+
+	accountKeys := make([]*AccountKey, 0, len(keys))
+	for _, key := range keys {
+		accountKeys = append(accountKeys, &AccountKey{
+			Index:          uint32(key.Index),
+			PublicKey:      key.PublicKey.Encode(),
+			SignAlgo:       uint32(key.SignAlgo),
+			HashAlgo:       uint32(key.HashAlgo),
+			Weight:         uint32(key.Weight),
+			SequenceNumber: key.SeqNumber,
+			Revoked:        key.Revoked,
+		})
+	}
+
+	res := GetAccountAtHeightResponse{
+		Address:    req.Address,
+		Height:     req.Height,
+		Created:    account.Created,
+		KeyUpdates: updates,
+		Keys:       accountKeys,
+		Contracts:  contracts,
+	}
+
+	return &res, nil
+}
+
+// GetContractHistory implements the `GetContractHistory` method of the
+// generated GRPC server.
+func (s *Server) GetContractHistory(ctx context.Context, req *GetContractHistoryRequest) (*GetContractHistoryResponse, error) {
+
+	if s.cfg.ProtocolOnly {
+		return nil, status.Error(codes.Unimplemented, "execution data is not available")
+	}
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		return nil, fmt.Errorf("bad request: %w", err)
+	}
+
+	index, err := s.registers(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	address := flow.BytesToAddress(req.Address)
+	history, err := index.ContractHistory(ctx, address, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("could not get contract history: %w", err)
+	}
+
+	versions := make([]*ContractVersion, 0, len(history))
+	for _, version := range history {
+		versions = append(versions, &ContractVersion{
+			Height:        version.Height,
+			TransactionID: convert.IDToHash(version.TransactionID),
+			CodeHash:      version.CodeHash,
+			Removed:       version.Removed,
+		})
+	}
+
+	res := GetContractHistoryResponse{
+		Address:  req.Address,
+		Name:     req.Name,
+		Versions: versions,
+	}
+
+	return &res, nil
+}
+
+// GetServiceEvents implements the `GetServiceEvents` method of the generated
+// GRPC server.
+func (s *Server) GetServiceEvents(ctx context.Context, req *GetServiceEventsRequest) (*GetServiceEventsResponse, error) {
+
+	if s.cfg.ProtocolOnly {
+		return nil, status.Error(codes.Unimplemented, "execution data is not available")
+	}
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		return nil, fmt.Errorf("bad request: %w", err)
+	}
+
+	index, err := s.events(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	types := convert.StringsToTypes(req.Types)
+	events, err := index.ServiceEvents(ctx, req.StartHeight, req.EndHeight, types...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get service events: %w", err)
+	}
+
+	data, err := s.codec.Marshal(events)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode service events: %w", err)
+	}
+
+	res := GetServiceEventsResponse{
+		StartHeight: req.StartHeight,
+		EndHeight:   req.EndHeight,
+		Types:       req.Types,
+		Data:        data,
+	}
+
+	return &res, nil
+}
+
+// ListEpochs implements the `ListEpochs` method of the generated GRPC server.
+func (s *Server) ListEpochs(ctx context.Context, req *ListEpochsRequest) (*ListEpochsResponse, error) {
+
+	index, err := s.events(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	indexed, err := index.Epochs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get epochs: %w", err)
+	}
+
+	epochs := make([]*Epoch, 0, len(indexed))
+	for _, epoch := range indexed {
+		epochs = append(epochs, &Epoch{
+			Counter:            epoch.Counter,
+			FirstView:          epoch.FirstView,
+			DkgPhase1FinalView: epoch.DKGPhase1FinalView,
+			DkgPhase2FinalView: epoch.DKGPhase2FinalView,
+			DkgPhase3FinalView: epoch.DKGPhase3FinalView,
+			FinalView:          epoch.FinalView,
+			StakingHeight:      epoch.StakingHeight,
+			SetupHeight:        epoch.SetupHeight,
+			CommittedHeight:    epoch.CommittedHeight,
+		})
+	}
+
+	res := ListEpochsResponse{
+		Epochs: epochs,
+	}
+
+	return &res, nil
+}
+
+// ListIdentitiesForEpoch implements the `ListIdentitiesForEpoch` method of the
+// generated GRPC server.
+func (s *Server) ListIdentitiesForEpoch(ctx context.Context, req *ListIdentitiesForEpochRequest) (*ListIdentitiesForEpochResponse, error) {
+
+	index, err := s.events(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := index.Identities(ctx, req.Counter)
+	if err != nil {
+		return nil, fmt.Errorf("could not get identities: %w", err)
+	}
+
+	data, err := s.codec.Marshal(identities)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode identities: %w", err)
+	}
+
+	res := ListIdentitiesForEpochResponse{
+		Counter: req.Counter,
+		Data:    data,
+	}
+
+	return &res, nil
+}
+
+// GetVersion implements the `GetVersion` method of the generated GRPC server.
+// It is deprecated in favor of `GetInfo`.
+func (s *Server) GetVersion(_ context.Context, _ *GetVersionRequest) (*GetVersionResponse, error) {
+
+	res := GetVersionResponse{
+		ApiVersion:    Version,
+		SchemaVersion: dps.SchemaVersion,
+	}
+
+	return &res, nil
+}
+
+// GetInfo implements the `GetInfo` method of the generated GRPC server.
+func (s *Server) GetInfo(_ context.Context, _ *GetInfoRequest) (*GetInfoResponse, error) {
+
+	// Scripts can only be executed on the client side, using the register
+	// values, and no proofs are provided for them yet.
+	features := Features{
+		Streaming:       true,
+		ScriptExecution: false,
+		Proofs:          false,
+		ExecutionData:   !s.cfg.ProtocolOnly,
+		RawValues:       true,
+	}
+
+	chainIDs := make([]string, 0, len(s.cfg.Chains))
+	for chainID := range s.cfg.Chains {
+		chainIDs = append(chainIDs, chainID)
+	}
+	sort.Strings(chainIDs)
+
+	res := GetInfoResponse{
+		ApiVersion:    Version,
+		SchemaVersion: dps.SchemaVersion,
+		Features:      &features,
+		Deprecations:  Deprecations(),
+		ChainIDs:      chainIDs,
+	}
+
+	return &res, nil
+}
+
+// GetRawHeader implements the `GetRawHeader` method of the generated GRPC server.
+// It returns the header in the encoded form in which it is stored in the
+// index.
+func (s *Server) GetRawHeader(ctx context.Context, req *GetRawHeaderRequest) (*GetRawHeaderResponse, error) {
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		return nil, fmt.Errorf("bad request: %w", err)
+	}
+
+	index, err := s.heights(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := index.RawHeader(ctx, req.Height)
+	if err != nil {
+		return nil, fmt.Errorf("could not get header: %w", err)
+	}
+
+	res := GetRawHeaderResponse{
+		Height: req.Height,
+		Codec:  dps.CodecZbor,
+		Data:   data,
+	}
+
+	return &res, nil
+}
+
+// GetRawEvents implements the `GetRawEvents` method of the generated GRPC
+// server. It returns the events in the encoded form in which they are stored
+// in the index, which is one batch per event type.
+func (s *Server) GetRawEvents(ctx context.Context, req *GetRawEventsRequest) (*GetRawEventsResponse, error) {
+
+	if s.cfg.ProtocolOnly {
+		return nil, status.Error(codes.Unimplemented, "execution data is not available")
+	}
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		return nil, fmt.Errorf("bad request: %w", err)
+	}
+
+	index, err := s.events(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	types := convert.StringsToTypes(req.Types)
+	data, err := index.RawEvents(ctx, req.Height, types...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get events: %w", err)
+	}
+
+	res := GetRawEventsResponse{
+		Height: req.Height,
+		Types:  req.Types,
+		Codec:  dps.CodecZbor,
+		Data:   data,
+	}
+
+	return &res, nil
+}
+
+// GetRawCollection implements the `GetRawCollection` method of the generated GRPC server.
+// It returns the collection in the encoded form in which it is stored in the
+// index.
+func (s *Server) GetRawCollection(ctx context.Context, req *GetRawCollectionRequest) (*GetRawCollectionResponse, error) {
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		return nil, fmt.Errorf("bad request: %w", err)
+	}
+
+	index, err := s.transactions(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	collID := flow.HashToID(req.CollectionID)
+	data, err := index.RawCollection(ctx, collID)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve collection: %w", err)
+	}
+
+	res := GetRawCollectionResponse{
+		CollectionID: req.CollectionID,
+		Codec:        dps.CodecZbor,
+		Data:         data,
+	}
+
+	return &res, nil
+}
+
+// GetRawGuarantee implements the `GetRawGuarantee` method of the generated GRPC server.
+// It returns the guarantee in the encoded form in which it is stored in the
+// index.
+func (s *Server) GetRawGuarantee(ctx context.Context, req *GetRawGuaranteeRequest) (*GetRawGuaranteeResponse, error) {
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		return nil, fmt.Errorf("bad request: %w", err)
+	}
+
+	index, err := s.transactions(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	collID := flow.HashToID(req.CollectionID)
+	data, err := index.RawGuarantee(ctx, collID)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve guarantee: %w", err)
+	}
+
+	res := GetRawGuaranteeResponse{
+		CollectionID: req.CollectionID,
+		Codec:        dps.CodecZbor,
+		Data:         data,
+	}
+
+	return &res, nil
+}
+
+// GetRawTransaction implements the `GetRawTransaction` method of the generated GRPC server.
+// It returns the transaction in the encoded form in which it is stored in the
+// index.
+func (s *Server) GetRawTransaction(ctx context.Context, req *GetRawTransactionRequest) (*GetRawTransactionResponse, error) {
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		return nil, fmt.Errorf("bad request: %w", err)
+	}
+
+	index, err := s.transactions(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	txID := flow.HashToID(req.TransactionID)
+	data, err := index.RawTransaction(ctx, txID)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve transaction: %w", err)
+	}
+
+	res := GetRawTransactionResponse{
+		TransactionID: req.TransactionID,
+		Codec:         dps.CodecZbor,
+		Data:          data,
+	}
+
+	return &res, nil
+}
+
+// GetRawResult implements the `GetRawResult` method of the generated GRPC server.
+// It returns the transaction result in the encoded form in which it is stored in the
+// index.
+func (s *Server) GetRawResult(ctx context.Context, req *GetRawResultRequest) (*GetRawResultResponse, error) {
+
+	if s.cfg.ProtocolOnly {
+		return nil, status.Error(codes.Unimplemented, "execution data is not available")
+	}
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		return nil, fmt.Errorf("bad request: %w", err)
+	}
+
+	index, err := s.transactions(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	txID := flow.HashToID(req.TransactionID)
+	data, err := index.RawResult(ctx, txID)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve transaction result: %w", err)
+	}
+
+	res := GetRawResultResponse{
+		TransactionID: req.TransactionID,
+		Codec:         dps.CodecZbor,
+		Data:          data,
+	}
+
+	return &res, nil
+}
+
+// GetRawSeal implements the `GetRawSeal` method of the generated GRPC server.
+// It returns the seal in the encoded form in which it is stored in the
+// index.
+func (s *Server) GetRawSeal(ctx context.Context, req *GetRawSealRequest) (*GetRawSealResponse, error) {
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		return nil, fmt.Errorf("bad request: %w", err)
+	}
+
+	index, err := s.transactions(req.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	sealID := flow.HashToID(req.SealID)
+	data, err := index.RawSeal(ctx, sealID)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve seal: %w", err)
+	}
+
+	res := GetRawSealResponse{
+		SealID: req.SealID,
+		Codec:  dps.CodecZbor,
+		Data:   data,
+	}
+
+	return &res, nil
+}
+
+// route returns the index reader for the given chain ID. The empty chain ID
+// routes to the default index.
+func (s *Server) route(chainID string) (dps.Reader, error) {
+
+	if chainID == "" {
+		return s.index, nil
+	}
+
+	index, ok := s.cfg.Chains[chainID]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown chain (chain ID: %s)", chainID)
+	}
+
+	return index, nil
+}
+
+// heights routes a request that only reads heights and headers, so that the
+// RPC serving it depends on the height reader of the index only.
+func (s *Server) heights(chainID string) (dps.HeightReader, error) {
+	return s.route(chainID)
+}
+
+// registers routes a request that only reads the execution state.
+func (s *Server) registers(chainID string) (dps.RegisterReader, error) {
+	return s.route(chainID)
+}
+
+// events routes a request that only reads events and the epochs derived from
+// them.
+func (s *Server) events(chainID string) (dps.EventReader, error) {
+	return s.route(chainID)
+}
+
+// transactions routes a request that only reads collections, transactions,
+// results and seals.
+func (s *Server) transactions(chainID string) (dps.TransactionReader, error) {
+	return s.route(chainID)
+}
+
+// snapshot returns a reader that serves all reads of a request from a single
+// consistent view of the given index, if the index supports it, along with a
+// function that releases the view once the request is done. Requests that read
+// several keys for the same height use it, so that a concurrent flush of the
+// indexer can not make them return data from before and after the flush.
+func snapshot(index dps.RegisterReader) (dps.RegisterReader, func()) {
+	snapshotter, ok := index.(dps.Snapshotter)
+	if !ok {
+		return index, func() {}
+	}
+	return snapshotter.Snapshot()
+}
@@ -23,7 +23,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 
+	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/model/flow"
 
 	"github.com/optakt/flow-dps/api/dps"
@@ -49,7 +51,7 @@ func TestIntegrationServer_GetFirst(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.First(mocks.GenericHeight))
+		require.NoError(t, writer.First(context.Background(), mocks.GenericHeight))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -97,7 +99,7 @@ func TestIntegrationServer_GetLast(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.Last(mocks.GenericHeight))
+		require.NoError(t, writer.Last(context.Background(), mocks.GenericHeight))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -147,7 +149,7 @@ func TestIntegrationServer_GetHeightForBlock(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.Height(blockID, height))
+		require.NoError(t, writer.Height(context.Background(), blockID, height))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -201,7 +203,7 @@ func TestIntegrationServer_GetCommit(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.Commit(height, commit))
+		require.NoError(t, writer.Commit(context.Background(), height, commit))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -256,7 +258,7 @@ func TestIntegrationServer_GetHeader(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.Header(height, header))
+		require.NoError(t, writer.Header(context.Background(), height, header))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -319,9 +321,9 @@ func TestIntegrationServer_GetEvents(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.First(height))
-		require.NoError(t, writer.Last(height))
-		require.NoError(t, writer.Events(height, events))
+		require.NoError(t, writer.First(context.Background(), height))
+		require.NoError(t, writer.Last(context.Background(), height))
+		require.NoError(t, writer.Events(context.Background(), height, events))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -352,9 +354,9 @@ func TestIntegrationServer_GetEvents(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.First(height))
-		require.NoError(t, writer.Last(height))
-		require.NoError(t, writer.Events(height, events))
+		require.NoError(t, writer.First(context.Background(), height))
+		require.NoError(t, writer.Last(context.Background(), height))
+		require.NoError(t, writer.Events(context.Background(), height, events))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -414,9 +416,9 @@ func TestIntegrationServer_GetRegisterValues(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.First(height))
-		require.NoError(t, writer.Last(height))
-		require.NoError(t, writer.Payloads(height, paths, payloads))
+		require.NoError(t, writer.First(context.Background(), height))
+		require.NoError(t, writer.Last(context.Background(), height))
+		require.NoError(t, writer.Payloads(context.Background(), height, paths, payloads))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -450,9 +452,9 @@ func TestIntegrationServer_GetRegisterValues(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.First(height))
-		require.NoError(t, writer.Last(height))
-		require.NoError(t, writer.Payloads(height, paths, payloads))
+		require.NoError(t, writer.First(context.Background(), height))
+		require.NoError(t, writer.Last(context.Background(), height))
+		require.NoError(t, writer.Payloads(context.Background(), height, paths, payloads))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -490,6 +492,69 @@ func TestIntegrationServer_GetRegisterValues(t *testing.T) {
 	})
 }
 
+func TestIntegrationServer_ListRegistersForOwner(t *testing.T) {
+	owner := flow.BytesToAddress([]byte(`owner`))
+	paths := mocks.GenericLedgerPaths(4)
+	payloads := mocks.GenericLedgerPayloads(4)
+	height := mocks.GenericHeight
+
+	t.Run("serves all pages from one view of the index", func(t *testing.T) {
+		t.Parallel()
+
+		codec := zbor.NewCodec()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		storage := storage.New(codec)
+		reader := index.NewReader(db, storage)
+		writer := index.NewWriter(db, storage)
+
+		// Insert mock data in database.
+		require.NoError(t, writer.First(context.Background(), height))
+		require.NoError(t, writer.Last(context.Background(), height))
+		require.NoError(t, writer.Payloads(context.Background(), height, paths, payloads))
+		require.NoError(t, writer.Close())
+
+		server := dps.NewServer(reader, codec)
+
+		// While the first page is sent, the registers of the height are
+		// deleted, as when staged heights are rolled back and indexed again.
+		// The following pages should still be served from the same view of
+		// the index as the first one.
+		var pages []*dps.ListRegistersForOwnerResponse
+		stream := &registersStream{
+			send: func(res *dps.ListRegistersForOwnerResponse) error {
+				if len(pages) == 0 {
+					for _, path := range paths {
+						deleted := ledger.NewPayload(mocks.GenericLedgerKey, nil)
+						err := db.Update(storage.SavePayload(height, path, deleted))
+						if err != nil {
+							return err
+						}
+					}
+				}
+				pages = append(pages, res)
+				return nil
+			},
+		}
+
+		req := &dps.ListRegistersForOwnerRequest{
+			Height: height,
+			Owner:  owner[:],
+			Limit:  1,
+		}
+		err := server.ListRegistersForOwner(req, stream)
+
+		require.NoError(t, err)
+		require.Len(t, pages, len(paths))
+		for _, page := range pages {
+			require.Len(t, page.Registers, 1)
+			assert.NotEmpty(t, page.Registers[0].Value)
+		}
+	})
+}
+
 func TestIntegrationServer_GetCollection(t *testing.T) {
 	collections := mocks.GenericCollections(4)
 	collID := collections[0].ID()
@@ -507,7 +572,7 @@ func TestIntegrationServer_GetCollection(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.Collections(mocks.GenericHeight, collections))
+		require.NoError(t, writer.Collections(context.Background(), mocks.GenericHeight, collections))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -565,7 +630,7 @@ func TestIntegrationServer_ListCollectionsForHeight(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.Collections(height, collections))
+		require.NoError(t, writer.Collections(context.Background(), height, collections))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -625,7 +690,7 @@ func TestIntegrationServer_GetGuarantee(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.Guarantees(mocks.GenericHeight, guarantees))
+		require.NoError(t, writer.Guarantees(context.Background(), mocks.GenericHeight, guarantees))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -683,7 +748,7 @@ func TestIntegrationServer_GetTransaction(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.Transactions(mocks.GenericHeight, transactions))
+		require.NoError(t, writer.Transactions(context.Background(), mocks.GenericHeight, transactions))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -741,7 +806,7 @@ func TestIntegrationServer_GetHeightForTransaction(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.Transactions(mocks.GenericHeight, transactions))
+		require.NoError(t, writer.Transactions(context.Background(), mocks.GenericHeight, transactions))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -796,7 +861,7 @@ func TestIntegrationServer_ListTransactionsForHeight(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.Transactions(height, transactions))
+		require.NoError(t, writer.Transactions(context.Background(), height, transactions))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -856,7 +921,7 @@ func TestIntegrationServer_GetResult(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.Results(results))
+		require.NoError(t, writer.Results(context.Background(), results))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -914,7 +979,7 @@ func TestIntegrationServer_GetSeal(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.Seals(mocks.GenericHeight, seals))
+		require.NoError(t, writer.Seals(context.Background(), mocks.GenericHeight, seals))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -972,7 +1037,7 @@ func TestIntegrationServer_ListSealsForHeight(t *testing.T) {
 		writer := index.NewWriter(db, storage)
 
 		// Insert mock data in database.
-		require.NoError(t, writer.Seals(height, seals))
+		require.NoError(t, writer.Seals(context.Background(), height, seals))
 		require.NoError(t, writer.Close())
 
 		server := dps.NewServer(reader, codec)
@@ -1014,3 +1079,19 @@ func TestIntegrationServer_ListSealsForHeight(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+// registersStream implements the server stream of the `ListRegistersForOwner`
+// method by handing each response to the given function.
+type registersStream struct {
+	grpc.ServerStream
+
+	send func(*dps.ListRegistersForOwnerResponse) error
+}
+
+func (r *registersStream) Send(res *dps.ListRegistersForOwnerResponse) error {
+	return r.send(res)
+}
+
+func (r *registersStream) Context() context.Context {
+	return context.Background()
+}
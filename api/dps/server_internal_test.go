@@ -19,13 +19,19 @@ import (
 	"testing"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/model/flow"
 
 	"github.com/optakt/flow-dps/models/convert"
+	"github.com/optakt/flow-dps/models/dps"
 	"github.com/optakt/flow-dps/testing/mocks"
 )
 
@@ -33,12 +39,13 @@ func TestNewServer(t *testing.T) {
 	index := mocks.BaselineReader(t)
 	codec := mocks.BaselineCodec(t)
 
-	s := NewServer(index, codec)
+	s := NewServer(index, codec, WithProtocolOnly(true))
 
 	assert.NotNil(t, s)
 	assert.NotNil(t, s.codec)
 	assert.Equal(t, index, s.index)
 	assert.Equal(t, codec, s.codec)
+	assert.True(t, s.cfg.ProtocolOnly)
 	assert.NotNil(t, s.validate)
 }
 
@@ -80,7 +87,7 @@ func TestServer_GetFirst(t *testing.T) {
 			t.Parallel()
 
 			index := mocks.BaselineReader(t)
-			index.FirstFunc = func() (uint64, error) {
+			index.FirstFunc = func(context.Context) (uint64, error) {
 				return mocks.GenericHeight, test.mockErr
 			}
 
@@ -140,7 +147,7 @@ func TestServer_GetLast(t *testing.T) {
 			t.Parallel()
 
 			index := mocks.BaselineReader(t)
-			index.LastFunc = func() (uint64, error) {
+			index.LastFunc = func(context.Context) (uint64, error) {
 				return mocks.GenericHeight, test.mockErr
 			}
 
@@ -213,7 +220,7 @@ func TestServer_GetHeightForBlock(t *testing.T) {
 			t.Parallel()
 
 			index := mocks.BaselineReader(t)
-			index.HeightForBlockFunc = func(blockID flow.Identifier) (uint64, error) {
+			index.HeightForBlockFunc = func(_ context.Context, blockID flow.Identifier) (uint64, error) {
 				return mocks.GenericHeight, test.mockErr
 			}
 
@@ -286,7 +293,7 @@ func TestServer_GetCommit(t *testing.T) {
 
 			var gotHeight uint64
 			index := mocks.BaselineReader(t)
-			index.CommitFunc = func(height uint64) (flow.StateCommitment, error) {
+			index.CommitFunc = func(_ context.Context, height uint64) (flow.StateCommitment, error) {
 				gotHeight = height
 				return test.mockCommit, test.mockErr
 			}
@@ -364,7 +371,7 @@ func TestServer_GetHeader(t *testing.T) {
 
 			var gotHeight uint64
 			index := mocks.BaselineReader(t)
-			index.HeaderFunc = func(height uint64) (*flow.Header, error) {
+			index.HeaderFunc = func(_ context.Context, height uint64) (*flow.Header, error) {
 				gotHeight = height
 				return test.mockHeader, test.mockErr
 			}
@@ -389,6 +396,302 @@ func TestServer_GetHeader(t *testing.T) {
 	}
 }
 
+func TestServer_GetBlockStats(t *testing.T) {
+	stats := mocks.GenericBlockStats(2)
+
+	tests := []struct {
+		name string
+
+		req      *GetBlockStatsRequest
+		mockErr  error
+		checkErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "nominal case",
+
+			req: &GetBlockStatsRequest{
+				StartHeight: mocks.GenericHeight,
+				EndHeight:   mocks.GenericHeight + 1,
+			},
+
+			checkErr: require.NoError,
+		},
+		{
+			name: "handles invalid height range",
+
+			req: &GetBlockStatsRequest{
+				StartHeight: mocks.GenericHeight + 1,
+				EndHeight:   mocks.GenericHeight,
+			},
+
+			checkErr: require.Error,
+		},
+		{
+			name: "handles index failure",
+
+			req: &GetBlockStatsRequest{
+				StartHeight: mocks.GenericHeight,
+				EndHeight:   mocks.GenericHeight + 1,
+			},
+			mockErr: mocks.GenericError,
+
+			checkErr: require.Error,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			index := mocks.BaselineReader(t)
+			index.BlockStatsFunc = func(_ context.Context, start uint64, end uint64) ([]dps.BlockStats, error) {
+				assert.Equal(t, mocks.GenericHeight, start)
+				assert.Equal(t, mocks.GenericHeight+1, end)
+
+				return stats, test.mockErr
+			}
+
+			s := Server{
+				index:    index,
+				validate: validator.New(),
+			}
+
+			gotRes, gotErr := s.GetBlockStats(context.Background(), test.req)
+
+			test.checkErr(t, gotErr)
+			if gotErr == nil {
+				assert.Equal(t, test.req.StartHeight, gotRes.StartHeight)
+				assert.Equal(t, test.req.EndHeight, gotRes.EndHeight)
+				require.Len(t, gotRes.Stats, len(stats))
+				for i, want := range stats {
+					got := gotRes.Stats[i]
+					assert.Equal(t, want.Height, got.Height)
+					assert.Equal(t, want.Transactions, got.Transactions)
+					assert.Equal(t, want.Events, got.Events)
+					assert.Equal(t, want.Fees, got.Fees)
+					assert.Equal(t, want.Computation, got.Computation)
+					assert.Equal(t, want.Senders, got.Senders)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_GetRegisterChurn(t *testing.T) {
+	report := dps.MergeChurn(mocks.GenericHeight, mocks.GenericHeight+1, mocks.GenericRegisterChurns(2), 10)
+
+	tests := []struct {
+		name string
+
+		req       *GetRegisterChurnRequest
+		wantLimit uint
+		mockErr   error
+		checkErr  require.ErrorAssertionFunc
+	}{
+		{
+			name: "nominal case",
+
+			req: &GetRegisterChurnRequest{
+				StartHeight: mocks.GenericHeight,
+				EndHeight:   mocks.GenericHeight + 1,
+				Limit:       5,
+			},
+			wantLimit: 5,
+
+			checkErr: require.NoError,
+		},
+		{
+			name: "caps limit to page size",
+
+			req: &GetRegisterChurnRequest{
+				StartHeight: mocks.GenericHeight,
+				EndHeight:   mocks.GenericHeight + 1,
+			},
+			wantLimit: 10,
+
+			checkErr: require.NoError,
+		},
+		{
+			name: "handles invalid height range",
+
+			req: &GetRegisterChurnRequest{
+				StartHeight: mocks.GenericHeight + 1,
+				EndHeight:   mocks.GenericHeight,
+			},
+
+			checkErr: require.Error,
+		},
+		{
+			name: "handles index failure",
+
+			req: &GetRegisterChurnRequest{
+				StartHeight: mocks.GenericHeight,
+				EndHeight:   mocks.GenericHeight + 1,
+			},
+			wantLimit: 10,
+			mockErr:   mocks.GenericError,
+
+			checkErr: require.Error,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			index := mocks.BaselineReader(t)
+			index.RegisterChurnFunc = func(_ context.Context, start uint64, end uint64, limit uint) (*dps.ChurnReport, error) {
+				assert.Equal(t, mocks.GenericHeight, start)
+				assert.Equal(t, mocks.GenericHeight+1, end)
+				assert.Equal(t, test.wantLimit, limit)
+
+				return &report, test.mockErr
+			}
+
+			s := Server{
+				index:    index,
+				cfg:      Config{PageSize: 10},
+				validate: validator.New(),
+			}
+
+			gotRes, gotErr := s.GetRegisterChurn(context.Background(), test.req)
+
+			test.checkErr(t, gotErr)
+			if gotErr == nil {
+				assert.Equal(t, test.req.StartHeight, gotRes.StartHeight)
+				assert.Equal(t, test.req.EndHeight, gotRes.EndHeight)
+				assert.Equal(t, report.Writes, gotRes.Writes)
+				require.Len(t, gotRes.Owners, len(report.Owners))
+				for i, want := range report.Owners {
+					assert.Equal(t, want.Owner.Bytes(), gotRes.Owners[i].Owner)
+					assert.Equal(t, want.Writes, gotRes.Owners[i].Writes)
+				}
+				require.Len(t, gotRes.Registers, len(report.Registers))
+				for i, want := range report.Registers {
+					assert.Equal(t, want.Owner.Bytes(), gotRes.Registers[i].Owner)
+					assert.Equal(t, []byte(want.Key), gotRes.Registers[i].Key)
+					assert.Equal(t, want.Writes, gotRes.Registers[i].Writes)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_GetRegisterDelta(t *testing.T) {
+	deltas := mocks.GenericRegisterDeltas(4)
+	cursor := mocks.GenericLedgerPath(5)
+
+	tests := []struct {
+		name string
+
+		req        *GetRegisterDeltaRequest
+		wantAfter  ledger.Path
+		wantLimit  uint
+		wantDeltas []dps.RegisterDelta
+		wantCursor []byte
+		mockErr    error
+		checkErr   require.ErrorAssertionFunc
+	}{
+		{
+			name: "nominal case",
+
+			req: &GetRegisterDeltaRequest{
+				Height: mocks.GenericHeight,
+				Limit:  10,
+			},
+			wantLimit:  11,
+			wantDeltas: deltas,
+
+			checkErr: require.NoError,
+		},
+		{
+			name: "sets cursor when there are more deltas",
+
+			req: &GetRegisterDeltaRequest{
+				Height: mocks.GenericHeight,
+				Cursor: cursor[:],
+				Limit:  3,
+			},
+			wantAfter:  cursor,
+			wantLimit:  4,
+			wantDeltas: deltas[:3],
+			wantCursor: deltas[2].Path[:],
+
+			checkErr: require.NoError,
+		},
+		{
+			name: "caps limit to page size",
+
+			req: &GetRegisterDeltaRequest{
+				Height: mocks.GenericHeight,
+			},
+			wantLimit:  11,
+			wantDeltas: deltas,
+
+			checkErr: require.NoError,
+		},
+		{
+			name: "handles invalid cursor",
+
+			req: &GetRegisterDeltaRequest{
+				Height: mocks.GenericHeight,
+				Cursor: mocks.GenericBytes,
+			},
+
+			checkErr: require.Error,
+		},
+		{
+			name: "handles index failure",
+
+			req: &GetRegisterDeltaRequest{
+				Height: mocks.GenericHeight,
+			},
+			wantLimit: 11,
+			mockErr:   mocks.GenericError,
+
+			checkErr: require.Error,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			index := mocks.BaselineReader(t)
+			index.RegisterDeltasFunc = func(_ context.Context, height uint64, after ledger.Path, limit uint) ([]dps.RegisterDelta, error) {
+				assert.Equal(t, mocks.GenericHeight, height)
+				assert.Equal(t, test.wantAfter, after)
+				assert.Equal(t, test.wantLimit, limit)
+
+				return deltas, test.mockErr
+			}
+
+			s := Server{
+				index:    index,
+				cfg:      Config{PageSize: 10},
+				validate: validator.New(),
+			}
+
+			gotRes, gotErr := s.GetRegisterDelta(context.Background(), test.req)
+
+			test.checkErr(t, gotErr)
+			if gotErr == nil {
+				assert.Equal(t, test.req.Height, gotRes.Height)
+				assert.Equal(t, test.wantCursor, gotRes.Cursor)
+				require.Len(t, gotRes.Deltas, len(test.wantDeltas))
+				for i, want := range test.wantDeltas {
+					assert.Equal(t, want.Path[:], gotRes.Deltas[i].Path)
+					assert.Equal(t, want.Previous, gotRes.Deltas[i].Previous)
+					assert.Equal(t, want.Current, gotRes.Deltas[i].Current)
+				}
+			}
+		})
+	}
+}
+
 func TestServer_GetEvents(t *testing.T) {
 	tests := []struct {
 		name string
@@ -455,7 +758,7 @@ func TestServer_GetEvents(t *testing.T) {
 			var gotHeight uint64
 			var gotTypes []flow.EventType
 			index := mocks.BaselineReader(t)
-			index.EventsFunc = func(height uint64, types ...flow.EventType) ([]flow.Event, error) {
+			index.EventsFunc = func(_ context.Context, height uint64, types ...flow.EventType) ([]flow.Event, error) {
 				gotHeight = height
 				gotTypes = types
 				return test.mockEvents, test.mockErr
@@ -559,7 +862,7 @@ func TestServer_GetRegisterValues(t *testing.T) {
 			var gotHeight uint64
 			var gotPaths []ledger.Path
 			index := mocks.BaselineReader(t)
-			index.ValuesFunc = func(height uint64, paths []ledger.Path) ([]ledger.Value, error) {
+			index.ValuesFunc = func(_ context.Context, height uint64, paths []ledger.Path) ([]ledger.Value, error) {
 				gotHeight = height
 				gotPaths = paths
 				return mocks.GenericLedgerValues(6), test.mockErr
@@ -586,6 +889,417 @@ func TestServer_GetRegisterValues(t *testing.T) {
 	}
 }
 
+func TestServer_ListRegistersForOwner(t *testing.T) {
+	registers := mocks.GenericRegisters(5)
+	owner := mocks.GenericAddress(0)
+
+	// The index serves the registers after the given path, in the order of the
+	// generic registers.
+	index := mocks.BaselineReader(t)
+	index.RegistersFunc = func(_ context.Context, height uint64, address flow.Address, after ledger.Path, limit uint) ([]dps.Register, error) {
+		assert.Equal(t, mocks.GenericHeight, height)
+		assert.Equal(t, owner, address)
+		start := 0
+		for i, register := range registers {
+			if register.Path == after {
+				start = i + 1
+			}
+		}
+		end := start + int(limit)
+		if end > len(registers) {
+			end = len(registers)
+		}
+		return registers[start:end], nil
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		s := Server{
+			index:    index,
+			cfg:      Config{PageSize: 10},
+			validate: validator.New(),
+		}
+
+		var pages []*ListRegistersForOwnerResponse
+		stream := &registersServerMock{
+			SendFunc: func(res *ListRegistersForOwnerResponse) error {
+				pages = append(pages, res)
+				return nil
+			},
+		}
+		req := ListRegistersForOwnerRequest{
+			Height: mocks.GenericHeight,
+			Owner:  owner.Bytes(),
+		}
+
+		err := s.ListRegistersForOwner(&req, stream)
+
+		require.NoError(t, err)
+		require.Len(t, pages, 1)
+		assert.Equal(t, mocks.GenericHeight, pages[0].Height)
+		assert.Equal(t, owner.Bytes(), pages[0].Owner)
+		assert.Empty(t, pages[0].Cursor)
+		require.Len(t, pages[0].Registers, len(registers))
+		for i, register := range registers {
+			assert.Equal(t, register.Path[:], pages[0].Registers[i].Path)
+			assert.Equal(t, []byte(register.Payload.Value), pages[0].Registers[i].Value)
+			assert.Equal(t, []byte(`controller`), pages[0].Registers[i].Controller)
+			assert.Equal(t, []byte(`key`), pages[0].Registers[i].Key)
+		}
+	})
+
+	t.Run("streams multiple pages", func(t *testing.T) {
+		t.Parallel()
+
+		s := Server{
+			index:    index,
+			cfg:      Config{PageSize: 10},
+			validate: validator.New(),
+		}
+
+		var pages []*ListRegistersForOwnerResponse
+		stream := &registersServerMock{
+			SendFunc: func(res *ListRegistersForOwnerResponse) error {
+				pages = append(pages, res)
+				return nil
+			},
+		}
+		req := ListRegistersForOwnerRequest{
+			Height: mocks.GenericHeight,
+			Owner:  owner.Bytes(),
+			Limit:  2,
+		}
+
+		err := s.ListRegistersForOwner(&req, stream)
+
+		require.NoError(t, err)
+		require.Len(t, pages, 3)
+		assert.Len(t, pages[0].Registers, 2)
+		assert.Equal(t, registers[1].Path[:], pages[0].Cursor)
+		assert.Len(t, pages[1].Registers, 2)
+		assert.Equal(t, registers[3].Path[:], pages[1].Cursor)
+		assert.Len(t, pages[2].Registers, 1)
+		assert.Empty(t, pages[2].Cursor)
+	})
+
+	t.Run("resumes after cursor", func(t *testing.T) {
+		t.Parallel()
+
+		s := Server{
+			index:    index,
+			cfg:      Config{PageSize: 10},
+			validate: validator.New(),
+		}
+
+		var pages []*ListRegistersForOwnerResponse
+		stream := &registersServerMock{
+			SendFunc: func(res *ListRegistersForOwnerResponse) error {
+				pages = append(pages, res)
+				return nil
+			},
+		}
+		req := ListRegistersForOwnerRequest{
+			Height: mocks.GenericHeight,
+			Owner:  owner.Bytes(),
+			Cursor: registers[2].Path[:],
+		}
+
+		err := s.ListRegistersForOwner(&req, stream)
+
+		require.NoError(t, err)
+		require.Len(t, pages, 1)
+		require.Len(t, pages[0].Registers, 2)
+		assert.Equal(t, registers[3].Path[:], pages[0].Registers[0].Path)
+	})
+
+	t.Run("caps page size", func(t *testing.T) {
+		t.Parallel()
+
+		s := Server{
+			index:    index,
+			cfg:      Config{PageSize: 3},
+			validate: validator.New(),
+		}
+
+		var pages []*ListRegistersForOwnerResponse
+		stream := &registersServerMock{
+			SendFunc: func(res *ListRegistersForOwnerResponse) error {
+				pages = append(pages, res)
+				return nil
+			},
+		}
+		req := ListRegistersForOwnerRequest{
+			Height: mocks.GenericHeight,
+			Owner:  owner.Bytes(),
+			Limit:  100,
+		}
+
+		err := s.ListRegistersForOwner(&req, stream)
+
+		require.NoError(t, err)
+		require.Len(t, pages, 2)
+		assert.Len(t, pages[0].Registers, 3)
+		assert.Len(t, pages[1].Registers, 2)
+	})
+
+	t.Run("handles invalid requests", func(t *testing.T) {
+		t.Parallel()
+
+		s := Server{
+			index:    index,
+			cfg:      Config{PageSize: 10},
+			validate: validator.New(),
+		}
+
+		err := s.ListRegistersForOwner(&ListRegistersForOwnerRequest{Height: mocks.GenericHeight, Owner: mocks.GenericBytes}, &registersServerMock{})
+		assert.Error(t, err)
+
+		err = s.ListRegistersForOwner(&ListRegistersForOwnerRequest{Height: mocks.GenericHeight, Owner: owner.Bytes(), Cursor: mocks.GenericBytes}, &registersServerMock{})
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.RegistersFunc = func(context.Context, uint64, flow.Address, ledger.Path, uint) ([]dps.Register, error) {
+			return nil, mocks.GenericError
+		}
+
+		s := Server{
+			index:    index,
+			cfg:      Config{PageSize: 10},
+			validate: validator.New(),
+		}
+
+		err := s.ListRegistersForOwner(&ListRegistersForOwnerRequest{Height: mocks.GenericHeight, Owner: owner.Bytes()}, &registersServerMock{})
+		assert.Error(t, err)
+	})
+
+	t.Run("handles stream failures", func(t *testing.T) {
+		t.Parallel()
+
+		s := Server{
+			index:    index,
+			cfg:      Config{PageSize: 10},
+			validate: validator.New(),
+		}
+
+		stream := &registersServerMock{
+			SendFunc: func(*ListRegistersForOwnerResponse) error {
+				return mocks.GenericError
+			},
+		}
+
+		err := s.ListRegistersForOwner(&ListRegistersForOwnerRequest{Height: mocks.GenericHeight, Owner: owner.Bytes()}, stream)
+		assert.Error(t, err)
+	})
+}
+
+func TestServer_DumpRegisters(t *testing.T) {
+	registers := mocks.GenericRegisters(5)
+
+	// The index serves the registers after the given path, in the order of the
+	// generic registers.
+	index := mocks.BaselineReader(t)
+	index.DumpRegistersFunc = func(_ context.Context, height uint64, after ledger.Path, limit uint) ([]dps.Register, error) {
+		assert.Equal(t, mocks.GenericHeight, height)
+		start := 0
+		for i, register := range registers {
+			if register.Path == after {
+				start = i + 1
+			}
+		}
+		end := start + int(limit)
+		if end > len(registers) {
+			end = len(registers)
+		}
+		return registers[start:end], nil
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		s := Server{
+			index:    index,
+			cfg:      Config{PageSize: 10},
+			validate: validator.New(),
+		}
+
+		var chunks []*DumpRegistersResponse
+		stream := &dumpServerMock{
+			SendFunc: func(res *DumpRegistersResponse) error {
+				chunks = append(chunks, res)
+				return nil
+			},
+		}
+		req := DumpRegistersRequest{
+			Height: mocks.GenericHeight,
+		}
+
+		err := s.DumpRegisters(&req, stream)
+
+		require.NoError(t, err)
+		require.Len(t, chunks, 1)
+		assert.Equal(t, mocks.GenericHeight, chunks[0].Height)
+		assert.Empty(t, chunks[0].Cursor)
+		assert.Empty(t, chunks[0].Compressed)
+		require.Len(t, chunks[0].Registers, len(registers))
+		for i, register := range registers {
+			assert.Equal(t, register.Path[:], chunks[0].Registers[i].Path)
+			assert.Equal(t, []byte(register.Payload.Value), chunks[0].Registers[i].Value)
+			assert.Equal(t, []byte(`owner`), chunks[0].Registers[i].Owner)
+			assert.Equal(t, []byte(`controller`), chunks[0].Registers[i].Controller)
+			assert.Equal(t, []byte(`key`), chunks[0].Registers[i].Key)
+		}
+	})
+
+	t.Run("streams multiple chunks", func(t *testing.T) {
+		t.Parallel()
+
+		s := Server{
+			index:    index,
+			cfg:      Config{PageSize: 10},
+			validate: validator.New(),
+		}
+
+		var chunks []*DumpRegistersResponse
+		stream := &dumpServerMock{
+			SendFunc: func(res *DumpRegistersResponse) error {
+				chunks = append(chunks, res)
+				return nil
+			},
+		}
+		req := DumpRegistersRequest{
+			Height: mocks.GenericHeight,
+			Cursor: registers[0].Path[:],
+			Limit:  2,
+		}
+
+		err := s.DumpRegisters(&req, stream)
+
+		require.NoError(t, err)
+		require.Len(t, chunks, 2)
+		assert.Len(t, chunks[0].Registers, 2)
+		assert.Equal(t, registers[2].Path[:], chunks[0].Cursor)
+		assert.Len(t, chunks[1].Registers, 2)
+		assert.Empty(t, chunks[1].Cursor)
+	})
+
+	t.Run("compresses chunks", func(t *testing.T) {
+		t.Parallel()
+
+		s := Server{
+			index:    index,
+			cfg:      Config{PageSize: 3},
+			validate: validator.New(),
+		}
+
+		var chunks []*DumpRegistersResponse
+		stream := &dumpServerMock{
+			SendFunc: func(res *DumpRegistersResponse) error {
+				chunks = append(chunks, res)
+				return nil
+			},
+		}
+		req := DumpRegistersRequest{
+			Height:   mocks.GenericHeight,
+			Compress: true,
+		}
+
+		err := s.DumpRegisters(&req, stream)
+
+		require.NoError(t, err)
+		require.Len(t, chunks, 2)
+
+		decompressor, err := zstd.NewReader(nil)
+		require.NoError(t, err)
+		defer decompressor.Close()
+
+		var got []*Register
+		for _, chunk := range chunks {
+			assert.Empty(t, chunk.Registers)
+			data, err := decompressor.DecodeAll(chunk.Compressed, nil)
+			require.NoError(t, err)
+			var decoded RegisterChunk
+			require.NoError(t, proto.Unmarshal(data, &decoded))
+			got = append(got, decoded.Registers...)
+		}
+		require.Len(t, got, len(registers))
+		for i, register := range registers {
+			assert.Equal(t, register.Path[:], got[i].Path)
+			assert.Equal(t, []byte(register.Payload.Value), got[i].Value)
+		}
+	})
+
+	t.Run("handles protocol-only index", func(t *testing.T) {
+		t.Parallel()
+
+		s := Server{
+			index:    index,
+			cfg:      Config{PageSize: 10, ProtocolOnly: true},
+			validate: validator.New(),
+		}
+
+		err := s.DumpRegisters(&DumpRegistersRequest{Height: mocks.GenericHeight}, &dumpServerMock{})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+
+	t.Run("handles invalid requests", func(t *testing.T) {
+		t.Parallel()
+
+		s := Server{
+			index:    index,
+			cfg:      Config{PageSize: 10},
+			validate: validator.New(),
+		}
+
+		err := s.DumpRegisters(&DumpRegistersRequest{}, &dumpServerMock{})
+		assert.Error(t, err)
+
+		err = s.DumpRegisters(&DumpRegistersRequest{Height: mocks.GenericHeight, Cursor: mocks.GenericBytes}, &dumpServerMock{})
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index failures", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.DumpRegistersFunc = func(context.Context, uint64, ledger.Path, uint) ([]dps.Register, error) {
+			return nil, mocks.GenericError
+		}
+
+		s := Server{
+			index:    index,
+			cfg:      Config{PageSize: 10},
+			validate: validator.New(),
+		}
+
+		err := s.DumpRegisters(&DumpRegistersRequest{Height: mocks.GenericHeight}, &dumpServerMock{})
+		assert.Error(t, err)
+	})
+
+	t.Run("handles stream failures", func(t *testing.T) {
+		t.Parallel()
+
+		s := Server{
+			index:    index,
+			cfg:      Config{PageSize: 10},
+			validate: validator.New(),
+		}
+
+		stream := &dumpServerMock{
+			SendFunc: func(*DumpRegistersResponse) error {
+				return mocks.GenericError
+			},
+		}
+
+		err := s.DumpRegisters(&DumpRegistersRequest{Height: mocks.GenericHeight}, stream)
+		assert.Error(t, err)
+	})
+}
+
 func TestServer_GetCollection(t *testing.T) {
 	collection := mocks.GenericCollection(0)
 
@@ -641,7 +1355,7 @@ func TestServer_GetCollection(t *testing.T) {
 			t.Parallel()
 
 			index := mocks.BaselineReader(t)
-			index.CollectionFunc = func(id flow.Identifier) (*flow.LightCollection, error) {
+			index.CollectionFunc = func(_ context.Context, id flow.Identifier) (*flow.LightCollection, error) {
 				return test.mockCollection, test.mockErr
 			}
 
@@ -701,7 +1415,7 @@ func TestServer_ListCollectionsForHeight(t *testing.T) {
 			t.Parallel()
 
 			index := mocks.BaselineReader(t)
-			index.CollectionsByHeightFunc = func(height uint64) ([]flow.Identifier, error) {
+			index.CollectionsByHeightFunc = func(_ context.Context, height uint64) ([]flow.Identifier, error) {
 				return test.mockCollections, test.mockErr
 			}
 
@@ -778,7 +1492,7 @@ func TestServer_GetGuarantee(t *testing.T) {
 			t.Parallel()
 
 			index := mocks.BaselineReader(t)
-			index.GuaranteeFunc = func(id flow.Identifier) (*flow.CollectionGuarantee, error) {
+			index.GuaranteeFunc = func(_ context.Context, id flow.Identifier) (*flow.CollectionGuarantee, error) {
 				return test.wantGuarantee, test.mockErr
 			}
 
@@ -801,6 +1515,13 @@ func TestServer_GetGuarantee(t *testing.T) {
 
 func TestServer_GetTransaction(t *testing.T) {
 	tx := mocks.GenericTransaction(0)
+	tx.Script = []byte(`transaction(amount: UFix64) {}`)
+	tx.Arguments = [][]byte{[]byte(`{"type":"UFix64","value":"1.0"}`)}
+	tx.GasLimit = 9999
+	tx.ProposalKey = flow.ProposalKey{Address: mocks.GenericAccount.Address, KeyIndex: 1, SequenceNumber: 42}
+	tx.Payer = mocks.GenericAccount.Address
+	tx.Authorizers = []flow.Address{mocks.GenericAccount.Address}
+	tx.EnvelopeSignatures = []flow.TransactionSignature{{Address: mocks.GenericAccount.Address, KeyIndex: 1, Signature: mocks.GenericBytes}}
 	tests := []struct {
 		name string
 
@@ -850,7 +1571,7 @@ func TestServer_GetTransaction(t *testing.T) {
 			t.Parallel()
 
 			index := mocks.BaselineReader(t)
-			index.TransactionFunc = func(transactionID flow.Identifier) (*flow.TransactionBody, error) {
+			index.TransactionFunc = func(_ context.Context, transactionID flow.Identifier) (*flow.TransactionBody, error) {
 				return test.wantTransaction, test.mockErr
 			}
 
@@ -867,6 +1588,19 @@ func TestServer_GetTransaction(t *testing.T) {
 			if test.wantTransaction != nil {
 				assert.Equal(t, gotRes.TransactionID, mocks.ByteSlice(tx.ID()))
 				assert.NotEmpty(t, gotRes.Data)
+
+				require.NotNil(t, gotRes.Transaction)
+				assert.Equal(t, mocks.ByteSlice(tx.ReferenceBlockID), gotRes.Transaction.ReferenceBlockID)
+				assert.Equal(t, tx.Script, gotRes.Transaction.Script)
+				assert.Equal(t, tx.Arguments, gotRes.Transaction.Arguments)
+				assert.Equal(t, tx.GasLimit, gotRes.Transaction.GasLimit)
+				assert.Equal(t, tx.ProposalKey.Address.Bytes(), gotRes.Transaction.ProposalKey.Address)
+				assert.Equal(t, tx.ProposalKey.SequenceNumber, gotRes.Transaction.ProposalKey.SequenceNumber)
+				assert.Equal(t, tx.Payer.Bytes(), gotRes.Transaction.Payer)
+				assert.Equal(t, [][]byte{tx.Authorizers[0].Bytes()}, gotRes.Transaction.Authorizers)
+				assert.Empty(t, gotRes.Transaction.PayloadSignatures)
+				require.Len(t, gotRes.Transaction.EnvelopeSignatures, 1)
+				assert.Equal(t, mocks.GenericBytes, gotRes.Transaction.EnvelopeSignatures[0].Signature)
 			}
 		})
 	}
@@ -926,7 +1660,7 @@ func TestServer_GetHeightForTransaction(t *testing.T) {
 			t.Parallel()
 
 			index := mocks.BaselineReader(t)
-			index.HeightForTransactionFunc = func(blockID flow.Identifier) (uint64, error) {
+			index.HeightForTransactionFunc = func(_ context.Context, blockID flow.Identifier) (uint64, error) {
 				return mocks.GenericHeight, test.mockErr
 			}
 
@@ -946,30 +1680,42 @@ func TestServer_GetHeightForTransaction(t *testing.T) {
 	}
 }
 
-func TestServer_ListTransactionsForHeight(t *testing.T) {
+func TestServer_GetHeightForCommit(t *testing.T) {
+	commit := mocks.GenericCommit(0)
+
 	tests := []struct {
 		name string
 
-		reqHeight uint64
+		req *GetHeightForCommitRequest
 
-		mockTransactions []flow.Identifier
-		mockErr          error
+		mockErr error
 
 		checkErr require.ErrorAssertionFunc
 	}{
 		{
 			name: "nominal case",
 
-			reqHeight: mocks.GenericHeight,
-
-			mockTransactions: mocks.GenericTransactionIDs(5),
+			req: &GetHeightForCommitRequest{
+				Commit: mocks.ByteSlice(commit),
+			},
 
 			checkErr: require.NoError,
 		},
+		{
+			name: "handles invalid commit",
+
+			req: &GetHeightForCommitRequest{
+				Commit: mocks.GenericBytes,
+			},
+
+			checkErr: require.Error,
+		},
 		{
 			name: "handles index failure",
 
-			reqHeight: mocks.GenericHeight,
+			req: &GetHeightForCommitRequest{
+				Commit: mocks.ByteSlice(commit),
+			},
 
 			mockErr: mocks.GenericError,
 
@@ -983,8 +1729,9 @@ func TestServer_ListTransactionsForHeight(t *testing.T) {
 			t.Parallel()
 
 			index := mocks.BaselineReader(t)
-			index.TransactionsByHeightFunc = func(height uint64) ([]flow.Identifier, error) {
-				return test.mockTransactions, test.mockErr
+			index.HeightForCommitFunc = func(_ context.Context, got flow.StateCommitment) (uint64, error) {
+				assert.Equal(t, commit, got)
+				return mocks.GenericHeight, test.mockErr
 			}
 
 			s := Server{
@@ -992,48 +1739,202 @@ func TestServer_ListTransactionsForHeight(t *testing.T) {
 				validate: validator.New(),
 			}
 
-			req := &ListTransactionsForHeightRequest{
-				Height: mocks.GenericHeight,
-			}
-			gotRes, gotErr := s.ListTransactionsForHeight(context.Background(), req)
+			gotRes, gotErr := s.GetHeightForCommit(context.Background(), test.req)
 
 			test.checkErr(t, gotErr)
 			if gotErr == nil {
-				assert.Equal(t, gotRes.Height, mocks.GenericHeight)
-				assert.Len(t, gotRes.TransactionIDs, len(test.mockTransactions))
-				for _, want := range test.mockTransactions {
-					assert.Contains(t, gotRes.TransactionIDs, want[:])
-				}
+				assert.Equal(t, mocks.GenericHeight, gotRes.Height)
+				assert.Equal(t, test.req.Commit, gotRes.Commit)
 			}
 		})
 	}
 }
 
-func TestServer_GetResult(t *testing.T) {
-	result := mocks.GenericResult(0)
+func TestServer_ListTransactionsForHeight(t *testing.T) {
 	tests := []struct {
 		name string
 
-		req *GetResultRequest
+		reqHeight uint64
 
-		mockResult *flow.TransactionResult
-		mockErr    error
+		mockTransactions []flow.Identifier
+		mockErr          error
 
 		checkErr require.ErrorAssertionFunc
 	}{
 		{
 			name: "nominal case",
 
-			req: &GetResultRequest{
-				TransactionID: mocks.ByteSlice(result.TransactionID),
-			},
+			reqHeight: mocks.GenericHeight,
 
-			mockResult: result,
+			mockTransactions: mocks.GenericTransactionIDs(5),
 
 			checkErr: require.NoError,
 		},
 		{
-			name: "handles invalid transaction ID",
+			name: "handles index failure",
+
+			reqHeight: mocks.GenericHeight,
+
+			mockErr: mocks.GenericError,
+
+			checkErr: require.Error,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			index := mocks.BaselineReader(t)
+			index.TransactionsByHeightFunc = func(_ context.Context, height uint64) ([]flow.Identifier, error) {
+				return test.mockTransactions, test.mockErr
+			}
+
+			s := Server{
+				index:    index,
+				validate: validator.New(),
+			}
+
+			req := &ListTransactionsForHeightRequest{
+				Height: mocks.GenericHeight,
+			}
+			gotRes, gotErr := s.ListTransactionsForHeight(context.Background(), req)
+
+			test.checkErr(t, gotErr)
+			if gotErr == nil {
+				assert.Equal(t, gotRes.Height, mocks.GenericHeight)
+				assert.Len(t, gotRes.TransactionIDs, len(test.mockTransactions))
+				for _, want := range test.mockTransactions {
+					assert.Contains(t, gotRes.TransactionIDs, want[:])
+				}
+			}
+		})
+	}
+}
+
+func TestServer_ListTransactionsForScript(t *testing.T) {
+	hash := dps.ScriptHash([]byte(`transaction {}`))
+	tests := []struct {
+		name string
+
+		req *ListTransactionsForScriptRequest
+
+		mockTransactions []flow.Identifier
+		mockErr          error
+
+		checkErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "nominal case",
+
+			req: &ListTransactionsForScriptRequest{
+				ScriptHash:  hash[:],
+				StartHeight: mocks.GenericHeight,
+				EndHeight:   mocks.GenericHeight + 10,
+			},
+
+			mockTransactions: mocks.GenericTransactionIDs(5),
+
+			checkErr: require.NoError,
+		},
+		{
+			name: "handles invalid script hash",
+
+			req: &ListTransactionsForScriptRequest{
+				ScriptHash:  hash[:8],
+				StartHeight: mocks.GenericHeight,
+				EndHeight:   mocks.GenericHeight + 10,
+			},
+
+			checkErr: require.Error,
+		},
+		{
+			name: "handles invalid height range",
+
+			req: &ListTransactionsForScriptRequest{
+				ScriptHash:  hash[:],
+				StartHeight: mocks.GenericHeight + 10,
+				EndHeight:   mocks.GenericHeight,
+			},
+
+			checkErr: require.Error,
+		},
+		{
+			name: "handles index failure",
+
+			req: &ListTransactionsForScriptRequest{
+				ScriptHash:  hash[:],
+				StartHeight: mocks.GenericHeight,
+				EndHeight:   mocks.GenericHeight + 10,
+			},
+
+			mockErr: mocks.GenericError,
+
+			checkErr: require.Error,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			index := mocks.BaselineReader(t)
+			index.TransactionsForScriptFunc = func(_ context.Context, got flow.Identifier, start uint64, end uint64) ([]flow.Identifier, error) {
+				assert.Equal(t, hash, got)
+				assert.Equal(t, test.req.StartHeight, start)
+				assert.Equal(t, test.req.EndHeight, end)
+
+				return test.mockTransactions, test.mockErr
+			}
+
+			s := Server{
+				index:    index,
+				validate: validator.New(),
+			}
+
+			gotRes, gotErr := s.ListTransactionsForScript(context.Background(), test.req)
+
+			test.checkErr(t, gotErr)
+			if gotErr == nil {
+				assert.Equal(t, test.req.ScriptHash, gotRes.ScriptHash)
+				assert.Equal(t, test.req.StartHeight, gotRes.StartHeight)
+				assert.Equal(t, test.req.EndHeight, gotRes.EndHeight)
+				require.Len(t, gotRes.TransactionIDs, len(test.mockTransactions))
+				for i, want := range test.mockTransactions {
+					assert.Equal(t, want[:], gotRes.TransactionIDs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestServer_GetResult(t *testing.T) {
+	result := mocks.GenericResult(0)
+	tests := []struct {
+		name string
+
+		req *GetResultRequest
+
+		mockResult *flow.TransactionResult
+		mockErr    error
+
+		checkErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "nominal case",
+
+			req: &GetResultRequest{
+				TransactionID: mocks.ByteSlice(result.TransactionID),
+			},
+
+			mockResult: result,
+
+			checkErr: require.NoError,
+		},
+		{
+			name: "handles invalid transaction ID",
 
 			req: &GetResultRequest{
 				TransactionID: mocks.GenericBytes,
@@ -1060,7 +1961,7 @@ func TestServer_GetResult(t *testing.T) {
 			t.Parallel()
 
 			index := mocks.BaselineReader(t)
-			index.ResultFunc = func(transactionID flow.Identifier) (*flow.TransactionResult, error) {
+			index.ResultFunc = func(_ context.Context, transactionID flow.Identifier) (*flow.TransactionResult, error) {
 				return test.mockResult, test.mockErr
 			}
 
@@ -1131,7 +2032,7 @@ func TestServer_GetSeal(t *testing.T) {
 			t.Parallel()
 
 			index := mocks.BaselineReader(t)
-			index.SealFunc = func(sealID flow.Identifier) (*flow.Seal, error) {
+			index.SealFunc = func(_ context.Context, sealID flow.Identifier) (*flow.Seal, error) {
 				return test.mockSeal, test.mockErr
 			}
 
@@ -1191,7 +2092,7 @@ func TestServer_ListSealsForHeight(t *testing.T) {
 			t.Parallel()
 
 			index := mocks.BaselineReader(t)
-			index.SealsByHeightFunc = func(height uint64) ([]flow.Identifier, error) {
+			index.SealsByHeightFunc = func(_ context.Context, height uint64) ([]flow.Identifier, error) {
 				return test.mockSeals, test.mockErr
 			}
 
@@ -1218,3 +2119,972 @@ func TestServer_ListSealsForHeight(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_ListOwners(t *testing.T) {
+	addresses := mocks.GenericAddresses(3)
+	tests := []struct {
+		name string
+
+		mockFilter dps.Filter
+		mockErr    error
+
+		checkErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "nominal case",
+
+			mockFilter: dps.Filter{Allowed: addresses[:2], Denied: addresses[2:]},
+
+			checkErr: require.NoError,
+		},
+		{
+			name: "nominal case for full index",
+
+			mockFilter: dps.Filter{},
+
+			checkErr: require.NoError,
+		},
+		{
+			name: "handles index failure",
+
+			mockErr: mocks.GenericError,
+
+			checkErr: require.Error,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			index := mocks.BaselineReader(t)
+			index.FilterFunc = func(context.Context) (dps.Filter, error) {
+				return test.mockFilter, test.mockErr
+			}
+
+			s := Server{
+				codec:    mocks.BaselineCodec(t),
+				index:    index,
+				validate: validator.New(),
+			}
+
+			gotRes, gotErr := s.ListOwners(context.Background(), &ListOwnersRequest{})
+
+			test.checkErr(t, gotErr)
+			if gotErr == nil {
+				assert.Len(t, gotRes.Owners, len(test.mockFilter.Allowed))
+				for _, want := range test.mockFilter.Allowed {
+					assert.Contains(t, gotRes.Owners, want.Bytes())
+				}
+				assert.Len(t, gotRes.Denied, len(test.mockFilter.Denied))
+				for _, want := range test.mockFilter.Denied {
+					assert.Contains(t, gotRes.Denied, want.Bytes())
+				}
+			}
+		})
+	}
+}
+
+func TestServer_ListEventTypes(t *testing.T) {
+	tests := []struct {
+		name string
+
+		mockStats []dps.EventTypeStats
+		mockErr   error
+
+		checkErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "nominal case",
+
+			mockStats: mocks.GenericEventTypeStats(2),
+
+			checkErr: require.NoError,
+		},
+		{
+			name: "nominal case without events",
+
+			mockStats: nil,
+
+			checkErr: require.NoError,
+		},
+		{
+			name: "handles index failure",
+
+			mockErr: mocks.GenericError,
+
+			checkErr: require.Error,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			index := mocks.BaselineReader(t)
+			index.EventTypesFunc = func(context.Context) ([]dps.EventTypeStats, error) {
+				return test.mockStats, test.mockErr
+			}
+
+			s := Server{
+				codec:    mocks.BaselineCodec(t),
+				index:    index,
+				validate: validator.New(),
+			}
+
+			gotRes, gotErr := s.ListEventTypes(context.Background(), &ListEventTypesRequest{})
+
+			test.checkErr(t, gotErr)
+			if gotErr == nil {
+				require.Len(t, gotRes.Types, len(test.mockStats))
+				for i, want := range test.mockStats {
+					got := gotRes.Types[i]
+					assert.Equal(t, string(want.Type), got.Type)
+					assert.Equal(t, want.First, got.First)
+					assert.Equal(t, want.Last, got.Last)
+					assert.Equal(t, want.Count, got.Count)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_GetAccountAtHeight(t *testing.T) {
+	address := mocks.GenericAddress(0)
+	key, registers := accountRegisters(t, address, "Contract")
+
+	values := func(_ context.Context, _ uint64, paths []ledger.Path) ([]ledger.Value, error) {
+		return []ledger.Value{registers[paths[0]]}, nil
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.AccountFunc = func(_ context.Context, got flow.Address) (*dps.Account, error) {
+			assert.Equal(t, address, got)
+
+			return mocks.GenericAccountMetadata(got), nil
+		}
+		index.ValuesFunc = values
+
+		s := Server{
+			codec:    mocks.BaselineCodec(t),
+			index:    index,
+			validate: validator.New(),
+		}
+
+		req := GetAccountAtHeightRequest{
+			Address: address.Bytes(),
+			Height:  mocks.GenericHeight,
+		}
+		res, err := s.GetAccountAtHeight(context.Background(), &req)
+
+		require.NoError(t, err)
+		assert.Equal(t, address.Bytes(), res.Address)
+		assert.Equal(t, mocks.GenericHeight, res.Height)
+		assert.Equal(t, mocks.GenericHeight, res.Created)
+		assert.Equal(t, []uint64{mocks.GenericHeight}, res.KeyUpdates)
+		assert.Equal(t, []string{"Contract"}, res.Contracts)
+		require.Len(t, res.Keys, 1)
+		assert.Equal(t, key.PublicKey.Encode(), res.Keys[0].PublicKey)
+		assert.Equal(t, uint32(key.SignAlgo), res.Keys[0].SignAlgo)
+		assert.Equal(t, uint32(key.HashAlgo), res.Keys[0].HashAlgo)
+		assert.Equal(t, uint32(key.Weight), res.Keys[0].Weight)
+	})
+
+	t.Run("handles missing account", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.ValuesFunc = func(context.Context, uint64, []ledger.Path) ([]ledger.Value, error) {
+			return []ledger.Value{nil}, nil
+		}
+
+		s := Server{
+			codec:    mocks.BaselineCodec(t),
+			index:    index,
+			validate: validator.New(),
+		}
+
+		req := GetAccountAtHeightRequest{
+			Address: address.Bytes(),
+			Height:  mocks.GenericHeight,
+		}
+		_, err := s.GetAccountAtHeight(context.Background(), &req)
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("handles index failure on account", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.AccountFunc = func(context.Context, flow.Address) (*dps.Account, error) {
+			return nil, mocks.GenericError
+		}
+		index.ValuesFunc = values
+
+		s := Server{
+			codec:    mocks.BaselineCodec(t),
+			index:    index,
+			validate: validator.New(),
+		}
+
+		req := GetAccountAtHeightRequest{
+			Address: address.Bytes(),
+			Height:  mocks.GenericHeight,
+		}
+		_, err := s.GetAccountAtHeight(context.Background(), &req)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles index failure on registers", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.ValuesFunc = func(context.Context, uint64, []ledger.Path) ([]ledger.Value, error) {
+			return nil, mocks.GenericError
+		}
+
+		s := Server{
+			codec:    mocks.BaselineCodec(t),
+			index:    index,
+			validate: validator.New(),
+		}
+
+		req := GetAccountAtHeightRequest{
+			Address: address.Bytes(),
+			Height:  mocks.GenericHeight,
+		}
+		_, err := s.GetAccountAtHeight(context.Background(), &req)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles invalid address", func(t *testing.T) {
+		t.Parallel()
+
+		s := Server{
+			codec:    mocks.BaselineCodec(t),
+			index:    mocks.BaselineReader(t),
+			validate: validator.New(),
+		}
+
+		req := GetAccountAtHeightRequest{
+			Address: mocks.GenericBytes,
+			Height:  mocks.GenericHeight,
+		}
+		_, err := s.GetAccountAtHeight(context.Background(), &req)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestServer_GetContractHistory(t *testing.T) {
+	address := mocks.GenericAddress(0)
+	versions := mocks.GenericContractVersions(address, "Contract", 2)
+
+	tests := []struct {
+		name string
+
+		req         *GetContractHistoryRequest
+		mockErr     error
+		wantVersion []dps.ContractVersion
+
+		checkErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "nominal case",
+
+			req:         &GetContractHistoryRequest{Address: address.Bytes(), Name: "Contract"},
+			wantVersion: versions,
+
+			checkErr: require.NoError,
+		},
+		{
+			name: "handles invalid address",
+
+			req: &GetContractHistoryRequest{Address: mocks.GenericBytes, Name: "Contract"},
+
+			checkErr: require.Error,
+		},
+		{
+			name: "handles missing name",
+
+			req: &GetContractHistoryRequest{Address: address.Bytes()},
+
+			checkErr: require.Error,
+		},
+		{
+			name: "handles index failure",
+
+			req:     &GetContractHistoryRequest{Address: address.Bytes(), Name: "Contract"},
+			mockErr: mocks.GenericError,
+
+			checkErr: require.Error,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			index := mocks.BaselineReader(t)
+			index.ContractHistoryFunc = func(_ context.Context, gotAddress flow.Address, gotName string) ([]dps.ContractVersion, error) {
+				assert.Equal(t, address, gotAddress)
+				assert.Equal(t, "Contract", gotName)
+
+				return versions, test.mockErr
+			}
+
+			s := Server{
+				codec:    mocks.BaselineCodec(t),
+				index:    index,
+				validate: validator.New(),
+			}
+
+			gotRes, gotErr := s.GetContractHistory(context.Background(), test.req)
+
+			test.checkErr(t, gotErr)
+			if gotErr == nil {
+				assert.Equal(t, test.req.Address, gotRes.Address)
+				assert.Equal(t, test.req.Name, gotRes.Name)
+				require.Len(t, gotRes.Versions, len(test.wantVersion))
+				for i, want := range test.wantVersion {
+					got := gotRes.Versions[i]
+					assert.Equal(t, want.Height, got.Height)
+					assert.Equal(t, want.TransactionID[:], got.TransactionID)
+					assert.Equal(t, want.CodeHash, got.CodeHash)
+					assert.Equal(t, want.Removed, got.Removed)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_GetServiceEvents(t *testing.T) {
+	types := mocks.GenericEventTypes(2)
+
+	tests := []struct {
+		name string
+
+		req      *GetServiceEventsRequest
+		mockErr  error
+		wantRes  *GetServiceEventsResponse
+		checkErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "nominal case",
+
+			req: &GetServiceEventsRequest{
+				StartHeight: mocks.GenericHeight,
+				EndHeight:   mocks.GenericHeight + 1,
+				Types:       convert.TypesToStrings(types),
+			},
+			wantRes: &GetServiceEventsResponse{
+				StartHeight: mocks.GenericHeight,
+				EndHeight:   mocks.GenericHeight + 1,
+				Types:       convert.TypesToStrings(types),
+				Data:        mocks.GenericBytes,
+			},
+
+			checkErr: require.NoError,
+		},
+		{
+			name: "handles invalid height range",
+
+			req: &GetServiceEventsRequest{
+				StartHeight: mocks.GenericHeight + 1,
+				EndHeight:   mocks.GenericHeight,
+			},
+
+			checkErr: require.Error,
+		},
+		{
+			name: "handles index failure",
+
+			req: &GetServiceEventsRequest{
+				StartHeight: mocks.GenericHeight,
+				EndHeight:   mocks.GenericHeight + 1,
+				Types:       convert.TypesToStrings(types),
+			},
+			mockErr: mocks.GenericError,
+
+			checkErr: require.Error,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			codec := mocks.BaselineCodec(t)
+			codec.MarshalFunc = func(v interface{}) ([]byte, error) {
+				assert.IsType(t, []dps.ServiceEvent{}, v)
+				return mocks.GenericBytes, nil
+			}
+
+			index := mocks.BaselineReader(t)
+			index.ServiceEventsFunc = func(_ context.Context, start uint64, end uint64, gotTypes ...flow.EventType) ([]dps.ServiceEvent, error) {
+				assert.Equal(t, mocks.GenericHeight, start)
+				assert.Equal(t, mocks.GenericHeight+1, end)
+				assert.Equal(t, types, gotTypes)
+
+				return mocks.GenericServiceEvents(2), test.mockErr
+			}
+
+			s := Server{
+				codec:    codec,
+				index:    index,
+				validate: validator.New(),
+			}
+
+			gotRes, gotErr := s.GetServiceEvents(context.Background(), test.req)
+
+			test.checkErr(t, gotErr)
+			if gotErr == nil {
+				assert.Equal(t, test.wantRes, gotRes)
+			}
+		})
+	}
+}
+
+func TestServer_ListEpochs(t *testing.T) {
+	epochs := mocks.GenericEpochs(2)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.EpochsFunc = func(context.Context) ([]dps.Epoch, error) {
+			return epochs, nil
+		}
+
+		s := Server{
+			codec:    mocks.BaselineCodec(t),
+			index:    index,
+			validate: validator.New(),
+		}
+
+		gotRes, gotErr := s.ListEpochs(context.Background(), &ListEpochsRequest{})
+
+		require.NoError(t, gotErr)
+		require.Len(t, gotRes.Epochs, len(epochs))
+		for i, want := range epochs {
+			got := gotRes.Epochs[i]
+			assert.Equal(t, want.Counter, got.Counter)
+			assert.Equal(t, want.FirstView, got.FirstView)
+			assert.Equal(t, want.DKGPhase1FinalView, got.DkgPhase1FinalView)
+			assert.Equal(t, want.DKGPhase2FinalView, got.DkgPhase2FinalView)
+			assert.Equal(t, want.DKGPhase3FinalView, got.DkgPhase3FinalView)
+			assert.Equal(t, want.FinalView, got.FinalView)
+			assert.Equal(t, want.StakingHeight, got.StakingHeight)
+			assert.Equal(t, want.SetupHeight, got.SetupHeight)
+			assert.Equal(t, want.CommittedHeight, got.CommittedHeight)
+		}
+	})
+
+	t.Run("handles index failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.EpochsFunc = func(context.Context) ([]dps.Epoch, error) {
+			return nil, mocks.GenericError
+		}
+
+		s := Server{
+			codec:    mocks.BaselineCodec(t),
+			index:    index,
+			validate: validator.New(),
+		}
+
+		_, gotErr := s.ListEpochs(context.Background(), &ListEpochsRequest{})
+
+		assert.Error(t, gotErr)
+	})
+}
+
+func TestServer_ListIdentitiesForEpoch(t *testing.T) {
+	tests := []struct {
+		name string
+
+		mockErr  error
+		codecErr error
+		wantRes  *ListIdentitiesForEpochResponse
+		checkErr require.ErrorAssertionFunc
+	}{
+		{
+			name: "nominal case",
+
+			wantRes: &ListIdentitiesForEpochResponse{
+				Counter: 1,
+				Data:    mocks.GenericBytes,
+			},
+
+			checkErr: require.NoError,
+		},
+		{
+			name: "handles index failure",
+
+			mockErr: mocks.GenericError,
+
+			checkErr: require.Error,
+		},
+		{
+			name: "handles codec failure",
+
+			codecErr: mocks.GenericError,
+
+			checkErr: require.Error,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			codec := mocks.BaselineCodec(t)
+			codec.MarshalFunc = func(v interface{}) ([]byte, error) {
+				assert.IsType(t, flow.IdentityList{}, v)
+				return mocks.GenericBytes, test.codecErr
+			}
+
+			index := mocks.BaselineReader(t)
+			index.IdentitiesFunc = func(_ context.Context, counter uint64) (flow.IdentityList, error) {
+				assert.Equal(t, uint64(1), counter)
+				return mocks.GenericIdentities(4), test.mockErr
+			}
+
+			s := Server{
+				codec:    codec,
+				index:    index,
+				validate: validator.New(),
+			}
+
+			gotRes, gotErr := s.ListIdentitiesForEpoch(context.Background(), &ListIdentitiesForEpochRequest{Counter: 1})
+
+			test.checkErr(t, gotErr)
+			if gotErr == nil {
+				assert.Equal(t, test.wantRes, gotRes)
+			}
+		})
+	}
+}
+
+func TestServer_GetVersion(t *testing.T) {
+	s := Server{
+		codec:    mocks.BaselineCodec(t),
+		index:    mocks.BaselineReader(t),
+		validate: validator.New(),
+	}
+
+	gotRes, gotErr := s.GetVersion(context.Background(), &GetVersionRequest{})
+
+	require.NoError(t, gotErr)
+	assert.Equal(t, Version, gotRes.ApiVersion)
+	assert.Equal(t, uint32(dps.SchemaVersion), gotRes.SchemaVersion)
+}
+
+func TestServer_GetRawHeader(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		codec := mocks.BaselineCodec(t)
+		codec.MarshalFunc = func(interface{}) ([]byte, error) {
+			t.Fatal("raw header should not be encoded again")
+			return nil, nil
+		}
+
+		var gotHeight uint64
+		index := mocks.BaselineReader(t)
+		index.RawHeaderFunc = func(_ context.Context, height uint64) ([]byte, error) {
+			gotHeight = height
+			return mocks.GenericBytes, nil
+		}
+
+		s := Server{
+			codec:    codec,
+			index:    index,
+			validate: validator.New(),
+		}
+
+		req := &GetRawHeaderRequest{
+			Height: mocks.GenericHeight,
+		}
+		gotRes, gotErr := s.GetRawHeader(context.Background(), req)
+
+		require.NoError(t, gotErr)
+		assert.Equal(t, mocks.GenericHeight, gotHeight)
+		want := &GetRawHeaderResponse{
+			Height: mocks.GenericHeight,
+			Codec:  dps.CodecZbor,
+			Data:   mocks.GenericBytes,
+		}
+		assert.Equal(t, want, gotRes)
+	})
+
+	t.Run("handles index failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.RawHeaderFunc = func(context.Context, uint64) ([]byte, error) {
+			return nil, mocks.GenericError
+		}
+
+		s := Server{
+			codec:    mocks.BaselineCodec(t),
+			index:    index,
+			validate: validator.New(),
+		}
+
+		_, gotErr := s.GetRawHeader(context.Background(), &GetRawHeaderRequest{Height: mocks.GenericHeight})
+
+		assert.Error(t, gotErr)
+	})
+
+	t.Run("handles invalid request", func(t *testing.T) {
+		t.Parallel()
+
+		s := Server{
+			codec:    mocks.BaselineCodec(t),
+			index:    mocks.BaselineReader(t),
+			validate: validator.New(),
+		}
+
+		_, gotErr := s.GetRawHeader(context.Background(), &GetRawHeaderRequest{})
+
+		assert.Error(t, gotErr)
+	})
+}
+
+func TestServer_GetRawEvents(t *testing.T) {
+	types := mocks.GenericEventTypes(2)
+	data := [][]byte{mocks.GenericBytes, mocks.GenericBytes}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		var gotTypes []flow.EventType
+		index := mocks.BaselineReader(t)
+		index.RawEventsFunc = func(_ context.Context, height uint64, types ...flow.EventType) ([][]byte, error) {
+			assert.Equal(t, mocks.GenericHeight, height)
+			gotTypes = types
+			return data, nil
+		}
+
+		s := Server{
+			codec:    mocks.BaselineCodec(t),
+			index:    index,
+			validate: validator.New(),
+		}
+
+		req := &GetRawEventsRequest{
+			Height: mocks.GenericHeight,
+			Types:  convert.TypesToStrings(types),
+		}
+		gotRes, gotErr := s.GetRawEvents(context.Background(), req)
+
+		require.NoError(t, gotErr)
+		assert.Equal(t, types, gotTypes)
+		assert.Equal(t, req.Types, gotRes.Types)
+		assert.Equal(t, dps.CodecZbor, gotRes.Codec)
+		assert.Equal(t, data, gotRes.Data)
+	})
+
+	t.Run("handles index failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.RawEventsFunc = func(context.Context, uint64, ...flow.EventType) ([][]byte, error) {
+			return nil, mocks.GenericError
+		}
+
+		s := Server{
+			codec:    mocks.BaselineCodec(t),
+			index:    index,
+			validate: validator.New(),
+		}
+
+		_, gotErr := s.GetRawEvents(context.Background(), &GetRawEventsRequest{Height: mocks.GenericHeight})
+
+		assert.Error(t, gotErr)
+	})
+}
+
+func TestServer_GetRawTransaction(t *testing.T) {
+	txID := mocks.GenericTransaction(0).ID()
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.RawTransactionFunc = func(_ context.Context, got flow.Identifier) ([]byte, error) {
+			assert.Equal(t, txID, got)
+			return mocks.GenericBytes, nil
+		}
+
+		s := Server{
+			codec:    mocks.BaselineCodec(t),
+			index:    index,
+			validate: validator.New(),
+		}
+
+		req := &GetRawTransactionRequest{
+			TransactionID: txID[:],
+		}
+		gotRes, gotErr := s.GetRawTransaction(context.Background(), req)
+
+		require.NoError(t, gotErr)
+		want := &GetRawTransactionResponse{
+			TransactionID: txID[:],
+			Codec:         dps.CodecZbor,
+			Data:          mocks.GenericBytes,
+		}
+		assert.Equal(t, want, gotRes)
+	})
+
+	t.Run("handles invalid request", func(t *testing.T) {
+		t.Parallel()
+
+		s := Server{
+			codec:    mocks.BaselineCodec(t),
+			index:    mocks.BaselineReader(t),
+			validate: validator.New(),
+		}
+
+		_, gotErr := s.GetRawTransaction(context.Background(), &GetRawTransactionRequest{TransactionID: mocks.GenericBytes})
+
+		assert.Error(t, gotErr)
+	})
+}
+
+func TestServer_GetRawSeal(t *testing.T) {
+	sealID := mocks.GenericSeal(0).ID()
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.RawSealFunc = func(_ context.Context, got flow.Identifier) ([]byte, error) {
+			assert.Equal(t, sealID, got)
+			return mocks.GenericBytes, nil
+		}
+
+		s := Server{
+			codec:    mocks.BaselineCodec(t),
+			index:    index,
+			validate: validator.New(),
+		}
+
+		gotRes, gotErr := s.GetRawSeal(context.Background(), &GetRawSealRequest{SealID: sealID[:]})
+
+		require.NoError(t, gotErr)
+		assert.Equal(t, sealID[:], gotRes.SealID)
+		assert.Equal(t, dps.CodecZbor, gotRes.Codec)
+		assert.Equal(t, mocks.GenericBytes, gotRes.Data)
+	})
+
+	t.Run("handles index failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.RawSealFunc = func(context.Context, flow.Identifier) ([]byte, error) {
+			return nil, mocks.GenericError
+		}
+
+		s := Server{
+			codec:    mocks.BaselineCodec(t),
+			index:    index,
+			validate: validator.New(),
+		}
+
+		_, gotErr := s.GetRawSeal(context.Background(), &GetRawSealRequest{SealID: sealID[:]})
+
+		assert.Error(t, gotErr)
+	})
+}
+
+func TestServer_GetInfo(t *testing.T) {
+	s := Server{
+		codec:    mocks.BaselineCodec(t),
+		index:    mocks.BaselineReader(t),
+		validate: validator.New(),
+	}
+
+	gotRes, gotErr := s.GetInfo(context.Background(), &GetInfoRequest{})
+
+	require.NoError(t, gotErr)
+	assert.Equal(t, Version, gotRes.ApiVersion)
+	assert.Equal(t, uint32(dps.SchemaVersion), gotRes.SchemaVersion)
+	require.NotNil(t, gotRes.Features)
+	assert.True(t, gotRes.Features.Streaming)
+	assert.False(t, gotRes.Features.ScriptExecution)
+	assert.False(t, gotRes.Features.Proofs)
+	assert.True(t, gotRes.Features.ExecutionData)
+	assert.True(t, gotRes.Features.RawValues)
+	assert.Len(t, gotRes.Deprecations, len(Deprecations()))
+	assert.Empty(t, gotRes.ChainIDs)
+}
+
+func TestServer_ProtocolOnly(t *testing.T) {
+	s := Server{
+		codec:    mocks.BaselineCodec(t),
+		index:    mocks.BaselineReader(t),
+		cfg:      Config{ProtocolOnly: true},
+		validate: validator.New(),
+	}
+
+	t.Run("reports missing execution data", func(t *testing.T) {
+		t.Parallel()
+
+		gotRes, gotErr := s.GetInfo(context.Background(), &GetInfoRequest{})
+
+		require.NoError(t, gotErr)
+		require.NotNil(t, gotRes.Features)
+		assert.False(t, gotRes.Features.ExecutionData)
+	})
+
+	t.Run("rejects execution data requests", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := s.GetCommit(context.Background(), &GetCommitRequest{Height: mocks.GenericHeight})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+		_, err = s.GetEvents(context.Background(), &GetEventsRequest{Height: mocks.GenericHeight})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+		_, err = s.GetHeightForCommit(context.Background(), &GetHeightForCommitRequest{Commit: mocks.ByteSlice(mocks.GenericCommit(0))})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+		_, err = s.GetRegisterValues(context.Background(), &GetRegisterValuesRequest{Height: mocks.GenericHeight})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+		_, err = s.GetResult(context.Background(), &GetResultRequest{TransactionID: mocks.ByteSlice(mocks.GenericTransaction(0).ID())})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+		_, err = s.ListEventTypes(context.Background(), &ListEventTypesRequest{})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+		_, err = s.GetAccountAtHeight(context.Background(), &GetAccountAtHeightRequest{Address: mocks.GenericAddress(0).Bytes(), Height: mocks.GenericHeight})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+		_, err = s.GetContractHistory(context.Background(), &GetContractHistoryRequest{Address: mocks.GenericAddress(0).Bytes(), Name: "Contract"})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+		_, err = s.GetServiceEvents(context.Background(), &GetServiceEventsRequest{StartHeight: mocks.GenericHeight, EndHeight: mocks.GenericHeight})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+		_, err = s.GetBlockStats(context.Background(), &GetBlockStatsRequest{StartHeight: mocks.GenericHeight, EndHeight: mocks.GenericHeight})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+		_, err = s.GetRegisterChurn(context.Background(), &GetRegisterChurnRequest{StartHeight: mocks.GenericHeight, EndHeight: mocks.GenericHeight})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+		_, err = s.GetRegisterDelta(context.Background(), &GetRegisterDeltaRequest{Height: mocks.GenericHeight})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+		err = s.ListRegistersForOwner(&ListRegistersForOwnerRequest{Height: mocks.GenericHeight, Owner: mocks.GenericAddress(0).Bytes()}, &registersServerMock{})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+		_, err = s.GetRawEvents(context.Background(), &GetRawEventsRequest{Height: mocks.GenericHeight})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+		_, err = s.GetRawResult(context.Background(), &GetRawResultRequest{TransactionID: mocks.ByteSlice(mocks.GenericTransaction(0).ID())})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+
+	t.Run("serves protocol data requests", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := s.GetHeader(context.Background(), &GetHeaderRequest{Height: mocks.GenericHeight})
+		assert.NoError(t, err)
+
+		_, err = s.ListEpochs(context.Background(), &ListEpochsRequest{})
+		assert.NoError(t, err)
+
+		_, err = s.ListIdentitiesForEpoch(context.Background(), &ListIdentitiesForEpochRequest{Counter: 1})
+		assert.NoError(t, err)
+	})
+}
+
+func TestServer_Chains(t *testing.T) {
+	chain := mocks.BaselineReader(t)
+	chain.LastFunc = func(context.Context) (uint64, error) {
+		return mocks.GenericHeight + 1, nil
+	}
+
+	s := NewServer(mocks.BaselineReader(t), mocks.BaselineCodec(t),
+		WithChain("first", chain),
+		WithChain("second", mocks.BaselineReader(t)),
+	)
+
+	t.Run("reports chain IDs", func(t *testing.T) {
+		t.Parallel()
+
+		gotRes, gotErr := s.GetInfo(context.Background(), &GetInfoRequest{})
+
+		require.NoError(t, gotErr)
+		assert.Equal(t, []string{"first", "second"}, gotRes.ChainIDs)
+	})
+
+	t.Run("routes requests without chain ID to default index", func(t *testing.T) {
+		t.Parallel()
+
+		gotRes, gotErr := s.GetLast(context.Background(), &GetLastRequest{})
+
+		require.NoError(t, gotErr)
+		assert.Equal(t, mocks.GenericHeight, gotRes.Height)
+	})
+
+	t.Run("routes requests with chain ID to chain index", func(t *testing.T) {
+		t.Parallel()
+
+		gotRes, gotErr := s.GetLast(context.Background(), &GetLastRequest{ChainID: "first"})
+
+		require.NoError(t, gotErr)
+		assert.Equal(t, mocks.GenericHeight+1, gotRes.Height)
+	})
+
+	t.Run("handles unknown chain ID", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := s.GetHeader(context.Background(), &GetHeaderRequest{Height: mocks.GenericHeight, ChainID: "unknown"})
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+type registersServerMock struct {
+	grpc.ServerStream
+
+	SendFunc func(*ListRegistersForOwnerResponse) error
+}
+
+func (r *registersServerMock) Send(res *ListRegistersForOwnerResponse) error {
+	return r.SendFunc(res)
+}
+
+func (r *registersServerMock) Context() context.Context {
+	return context.Background()
+}
+
+type dumpServerMock struct {
+	grpc.ServerStream
+
+	SendFunc func(*DumpRegistersResponse) error
+}
+
+func (d *dumpServerMock) Send(res *DumpRegistersResponse) error {
+	return d.SendFunc(res)
+}
+
+func (d *dumpServerMock) Context() context.Context {
+	return context.Background()
+}
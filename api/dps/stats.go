@@ -0,0 +1,116 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Recorder represents something that records the execution of API requests in
+// order to compute query statistics.
+type Recorder interface {
+	Record(query dps.Query)
+}
+
+// StatsInterceptor returns a unary server interceptor that records the
+// latency, the number of rows scanned and the number of bytes of the response
+// of each call with the given recorder.
+func StatsInterceptor(recorder Recorder) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+
+		start := time.Now()
+		ctx, scanned := dps.CountScans(ctx)
+		res, err := handler(ctx, req)
+
+		query := dps.Query{
+			Method:   info.FullMethod,
+			Peer:     address(ctx),
+			Start:    start,
+			Duration: time.Since(start),
+			Rows:     atomic.LoadUint64(scanned),
+			Failed:   err != nil,
+		}
+		msg, ok := res.(proto.Message)
+		if ok && err == nil {
+			query.Bytes = uint64(proto.Size(msg))
+		}
+
+		recorder.Record(query)
+
+		return res, err
+	}
+}
+
+// StatsStreamInterceptor returns a stream server interceptor that records the
+// same statistics as `StatsInterceptor` for each streaming call, over its whole
+// duration. The bytes are those of all of the messages sent on the stream.
+func StatsStreamInterceptor(recorder Recorder) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+		start := time.Now()
+		ctx, scanned := dps.CountScans(stream.Context())
+		wrapped := statsStream{WrappedServerStream: middleware.WrapServerStream(stream)}
+		wrapped.WrappedContext = ctx
+		err := handler(srv, &wrapped)
+
+		query := dps.Query{
+			Method:   info.FullMethod,
+			Peer:     address(ctx),
+			Start:    start,
+			Duration: time.Since(start),
+			Rows:     atomic.LoadUint64(scanned),
+			Bytes:    wrapped.bytes,
+			Failed:   err != nil,
+		}
+
+		recorder.Record(query)
+
+		return err
+	}
+}
+
+// statsStream counts the bytes of the messages sent on a server stream.
+type statsStream struct {
+	*middleware.WrappedServerStream
+	bytes uint64
+}
+
+func (s *statsStream) SendMsg(m interface{}) error {
+	err := s.WrappedServerStream.SendMsg(m)
+	msg, ok := m.(proto.Message)
+	if ok && err == nil {
+		s.bytes += uint64(proto.Size(msg))
+	}
+	return err
+}
+
+// address returns the address of the client that sent the request of the
+// given context, if it is known.
+func address(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return p.Addr.String()
+}
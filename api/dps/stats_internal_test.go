@@ -0,0 +1,195 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestStatsInterceptor(t *testing.T) {
+	info := grpc.UnaryServerInfo{FullMethod: "/dps.API/GetRegisterValues"}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		var got dps.Query
+		recorder := mocks.BaselineRecorder(t)
+		recorder.RecordFunc = func(query dps.Query) {
+			got = query
+		}
+
+		interceptor := StatsInterceptor(recorder)
+
+		addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234}
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+		res := &GetRegisterValuesResponse{
+			Height: mocks.GenericHeight,
+			Paths:  [][]byte{{1}, {2}, {3}},
+			Values: [][]byte{{4}, {5}, {6}},
+		}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			for i := 0; i < 5; i++ {
+				dps.Scanned(ctx)
+			}
+			return res, nil
+		}
+
+		_, err := interceptor(ctx, &GetRegisterValuesRequest{}, &info, handler)
+
+		require.NoError(t, err)
+		assert.Equal(t, info.FullMethod, got.Method)
+		assert.Equal(t, "127.0.0.1:1234", got.Peer)
+		assert.False(t, got.Start.IsZero())
+		assert.Equal(t, uint64(5), got.Rows)
+		assert.NotZero(t, got.Bytes)
+		assert.False(t, got.Failed)
+	})
+
+	t.Run("counts no rows without scans", func(t *testing.T) {
+		t.Parallel()
+
+		var got dps.Query
+		recorder := mocks.BaselineRecorder(t)
+		recorder.RecordFunc = func(query dps.Query) {
+			got = query
+		}
+
+		interceptor := StatsInterceptor(recorder)
+
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return &GetHeaderResponse{Height: mocks.GenericHeight, Data: mocks.GenericBytes}, nil
+		}
+
+		_, err := interceptor(context.Background(), &GetHeaderRequest{}, &info, handler)
+
+		require.NoError(t, err)
+		assert.Zero(t, got.Rows)
+		assert.NotZero(t, got.Bytes)
+		assert.Empty(t, got.Peer)
+	})
+
+	t.Run("handles handler failure", func(t *testing.T) {
+		t.Parallel()
+
+		var got dps.Query
+		recorder := mocks.BaselineRecorder(t)
+		recorder.RecordFunc = func(query dps.Query) {
+			got = query
+		}
+
+		interceptor := StatsInterceptor(recorder)
+
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, mocks.GenericError
+		}
+
+		_, err := interceptor(context.Background(), &GetHeaderRequest{}, &info, handler)
+
+		assert.ErrorIs(t, err, mocks.GenericError)
+		assert.True(t, got.Failed)
+		assert.Zero(t, got.Rows)
+		assert.Zero(t, got.Bytes)
+	})
+}
+
+func TestStatsStreamInterceptor(t *testing.T) {
+	info := grpc.StreamServerInfo{FullMethod: "/dps.API/ListRegistersForOwner", IsServerStream: true}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		var got dps.Query
+		recorder := mocks.BaselineRecorder(t)
+		recorder.RecordFunc = func(query dps.Query) {
+			got = query
+		}
+
+		interceptor := StatsStreamInterceptor(recorder)
+
+		addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234}
+		stream := &serverStreamMock{ctx: peer.NewContext(context.Background(), &peer.Peer{Addr: addr})}
+		res := &ListRegistersForOwnerResponse{Height: mocks.GenericHeight, Owner: mocks.GenericBytes}
+		handler := func(srv interface{}, stream grpc.ServerStream) error {
+			for i := 0; i < 3; i++ {
+				dps.Scanned(stream.Context())
+			}
+			err := stream.SendMsg(res)
+			if err != nil {
+				return err
+			}
+			return stream.SendMsg(res)
+		}
+
+		err := interceptor(nil, stream, &info, handler)
+
+		require.NoError(t, err)
+		assert.Equal(t, info.FullMethod, got.Method)
+		assert.Equal(t, "127.0.0.1:1234", got.Peer)
+		assert.False(t, got.Start.IsZero())
+		assert.Equal(t, uint64(3), got.Rows)
+		assert.Equal(t, uint64(2*proto.Size(res)), got.Bytes)
+		assert.False(t, got.Failed)
+		assert.Len(t, stream.sent, 2)
+	})
+
+	t.Run("handles handler failure", func(t *testing.T) {
+		t.Parallel()
+
+		var got dps.Query
+		recorder := mocks.BaselineRecorder(t)
+		recorder.RecordFunc = func(query dps.Query) {
+			got = query
+		}
+
+		interceptor := StatsStreamInterceptor(recorder)
+
+		handler := func(interface{}, grpc.ServerStream) error {
+			return mocks.GenericError
+		}
+
+		err := interceptor(nil, &serverStreamMock{ctx: context.Background()}, &info, handler)
+
+		assert.ErrorIs(t, err, mocks.GenericError)
+		assert.True(t, got.Failed)
+		assert.Zero(t, got.Bytes)
+	})
+}
+
+type serverStreamMock struct {
+	grpc.ServerStream
+
+	ctx  context.Context
+	sent []interface{}
+}
+
+func (s *serverStreamMock) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStreamMock) SendMsg(m interface{}) error {
+	s.sent = append(s.sent, m)
+	return nil
+}
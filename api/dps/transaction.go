@@ -0,0 +1,64 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// transactionToMessage converts the given transaction body into its API
+// representation.
+func transactionToMessage(transaction *flow.TransactionBody) *Transaction {
+
+	authorizers := make([][]byte, 0, len(transaction.Authorizers))
+	for _, authorizer := range transaction.Authorizers {
+		authorizers = append(authorizers, authorizer.Bytes())
+	}
+
+	msg := Transaction{
+		ReferenceBlockID: transaction.ReferenceBlockID[:],
+		Script:           transaction.Script,
+		Arguments:        transaction.Arguments,
+		GasLimit:         transaction.GasLimit,
+		ProposalKey: &ProposalKey{
+			Address:        transaction.ProposalKey.Address.Bytes(),
+			KeyIndex:       transaction.ProposalKey.KeyIndex,
+			SequenceNumber: transaction.ProposalKey.SequenceNumber,
+		},
+		Payer:              transaction.Payer.Bytes(),
+		Authorizers:        authorizers,
+		PayloadSignatures:  signaturesToMessages(transaction.PayloadSignatures),
+		EnvelopeSignatures: signaturesToMessages(transaction.EnvelopeSignatures),
+	}
+
+	return &msg
+}
+
+// signaturesToMessages converts the given transaction signatures into their
+// API representation.
+func signaturesToMessages(signatures []flow.TransactionSignature) []*TransactionSignature {
+
+	msgs := make([]*TransactionSignature, 0, len(signatures))
+	for _, signature := range signatures {
+		msgs = append(msgs, &TransactionSignature{
+			Address:     signature.Address.Bytes(),
+			SignerIndex: uint64(signature.SignerIndex),
+			KeyIndex:    signature.KeyIndex,
+			Signature:   signature.Signature,
+		})
+	}
+
+	return msgs
+}
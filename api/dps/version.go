@@ -0,0 +1,41 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+// Version is the version of the DPS API, as returned by the `GetInfo` RPC. It
+// follows semantic versioning: the minor version is incremented whenever
+// methods or fields are added, while the major version is incremented on
+// breaking changes.
+const Version = "1.19.0"
+
+// Deprecations returns the list of deprecated methods of the DPS API, along
+// with the methods that replace them and the API version in which they are
+// going to be removed. Deprecated services are listed with the prefix of their
+// methods, so that each entry covers all of their methods.
+func Deprecations() []*Deprecation {
+	deprecations := []*Deprecation{
+		{
+			Method:      "/dps.API/GetVersion",
+			Replacement: "/dps.API/GetInfo",
+			Removal:     "2.0.0",
+		},
+		{
+			Method:      "/" + LegacyServiceName + "/",
+			Replacement: "/" + API_ServiceDesc.ServiceName + "/",
+			Removal:     "2.0.0",
+		},
+	}
+	return deprecations
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package bench_test
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	api "github.com/optakt/flow-dps/api/dps"
+	"github.com/optakt/flow-dps/bench"
+	"github.com/optakt/flow-dps/testing/chain"
+)
+
+func BenchmarkAPIQueries(b *testing.B) {
+	for _, workload := range bench.Workloads {
+		workload := workload
+		b.Run(workload.Name, func(b *testing.B) {
+
+			ctx := context.Background()
+
+			harness, err := chain.NewHarness(zerolog.Nop(), workload.Options...)
+			require.NoError(b, err)
+
+			harness.Start()
+			defer func() {
+				require.NoError(b, harness.Stop())
+			}()
+
+			last, err := harness.Extend(workload.Blocks)
+			require.NoError(b, err)
+			require.NoError(b, harness.Wait(ctx, last))
+
+			// Register queries read the registers written by the last block at
+			// random heights, so that older versions of them are looked up too.
+			var paths [][]byte
+			for _, update := range harness.Generator().Last().TrieUpdates {
+				for _, path := range update.Paths {
+					path := path
+					paths = append(paths, path[:])
+				}
+			}
+			var txIDs [][]byte
+			for _, complete := range harness.Generator().Last().Collections {
+				for _, transaction := range complete.Transactions {
+					txID := transaction.ID()
+					txIDs = append(txIDs, txID[:])
+				}
+			}
+
+			server := harness.Server()
+			random := rand.New(rand.NewSource(1))
+			height := func() uint64 {
+				return uint64(random.Int63n(int64(last) + 1))
+			}
+
+			b.Run("header", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					_, err := server.GetHeader(ctx, &api.GetHeaderRequest{Height: height()})
+					require.NoError(b, err)
+				}
+			})
+
+			b.Run("events", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					_, err := server.GetEvents(ctx, &api.GetEventsRequest{Height: height()})
+					require.NoError(b, err)
+				}
+			})
+
+			b.Run("registers", func(b *testing.B) {
+				if len(paths) == 0 {
+					b.Skip("workload does not write registers")
+				}
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					req := api.GetRegisterValuesRequest{
+						Height: height(),
+						Paths:  [][]byte{paths[random.Intn(len(paths))]},
+					}
+					_, err := server.GetRegisterValues(ctx, &req)
+					require.NoError(b, err)
+				}
+			})
+
+			b.Run("transaction", func(b *testing.B) {
+				if len(txIDs) == 0 {
+					b.Skip("workload does not include transactions")
+				}
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					txID := txIDs[random.Intn(len(txIDs))]
+					_, err := server.GetTransaction(ctx, &api.GetTransactionRequest{TransactionID: txID})
+					require.NoError(b, err)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package bench_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps/bench"
+	"github.com/optakt/flow-dps/service/loader"
+)
+
+func BenchmarkCheckpointLoad(b *testing.B) {
+	for _, workload := range bench.Workloads {
+		workload := workload
+		b.Run(workload.Name, func(b *testing.B) {
+
+			data, err := workload.Checkpoint()
+			require.NoError(b, err)
+
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				load := loader.FromCheckpoint(bytes.NewReader(data))
+				_, err := load.Trie()
+				require.NoError(b, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package bench_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps/bench"
+	"github.com/optakt/flow-dps/testing/chain"
+)
+
+func BenchmarkMapperThroughput(b *testing.B) {
+	for _, workload := range bench.Workloads {
+		workload := workload
+		b.Run(workload.Name, func(b *testing.B) {
+
+			harness, err := chain.NewHarness(zerolog.Nop(), workload.Options...)
+			require.NoError(b, err)
+
+			harness.Start()
+			defer func() {
+				require.NoError(b, harness.Stop())
+			}()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			// Each operation is one finalized block going through the whole
+			// pipeline, from the streamer to the index. Blocks are generated
+			// while the mapper runs, so their generation is included.
+			height, err := harness.Extend(uint(b.N))
+			require.NoError(b, err)
+			require.NoError(b, harness.Wait(context.Background(), height))
+		})
+	}
+}
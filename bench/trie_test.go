@@ -0,0 +1,65 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package bench_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
+
+	"github.com/optakt/flow-dps/bench"
+)
+
+func BenchmarkTrieInsertion(b *testing.B) {
+	for _, workload := range bench.Workloads {
+		workload := workload
+		b.Run(workload.Name, func(b *testing.B) {
+
+			records, err := workload.Records()
+			require.NoError(b, err)
+
+			var updates []*ledger.TrieUpdate
+			var registers int
+			for _, record := range records {
+				for _, update := range record.TrieUpdates {
+					updates = append(updates, update)
+					registers += len(update.Paths)
+				}
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			// Each operation replays all of the trie updates of the workload
+			// onto the empty execution state.
+			for i := 0; i < b.N; i++ {
+				tree := trie.NewEmptyMTrie()
+				for _, update := range updates {
+					payloads := make([]ledger.Payload, 0, len(update.Payloads))
+					for _, payload := range update.Payloads {
+						payloads = append(payloads, *payload)
+					}
+					tree, err = trie.NewTrieWithUpdatedRegisters(tree, update.Paths, payloads)
+					require.NoError(b, err)
+				}
+			}
+
+			b.ReportMetric(float64(registers), "registers/op")
+		})
+	}
+}
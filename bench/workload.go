@@ -0,0 +1,138 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package bench
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/onflow/flow-go/engine/execution/computation/computer/uploader"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/flattener"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
+	"github.com/onflow/flow-go/ledger/complete/wal"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/testing/chain"
+)
+
+// Workload describes the synthetic chain that a benchmark runs against. Each
+// workload is deterministic, so that the results of two runs of the benchmarks
+// can be compared with each other.
+type Workload struct {
+	Name    string
+	Blocks  uint
+	Options []chain.Option
+}
+
+// Small is a workload with few accounts and transactions, which is useful to
+// measure the fixed costs of the pipeline.
+var Small = Workload{
+	Name:   "small",
+	Blocks: 100,
+	Options: []chain.Option{
+		chain.WithSeed(1),
+		chain.WithAccounts(16),
+		chain.WithTransactions(4),
+		chain.WithRegisters(2),
+		chain.WithEvents(1),
+		chain.WithValueSize(8),
+	},
+}
+
+// Mainnet is a workload that is shaped like a downsampled mainnet spork, with
+// a large set of active accounts, full collections and transactions that
+// touch several registers with values of realistic size.
+var Mainnet = Workload{
+	Name:   "mainnet",
+	Blocks: 100,
+	Options: []chain.Option{
+		chain.WithChainID(flow.Mainnet),
+		chain.WithSeed(1),
+		chain.WithAccounts(10_000),
+		chain.WithTransactions(40),
+		chain.WithRegisters(8),
+		chain.WithEvents(3),
+		chain.WithValueSize(256),
+	},
+}
+
+// Workloads are all of the workloads that the benchmarks are run against.
+var Workloads = []Workload{
+	Small,
+	Mainnet,
+}
+
+// Records generates the execution records of the blocks of the workload.
+func (w Workload) Records() ([]*uploader.BlockData, error) {
+
+	gen, err := chain.NewGenerator(w.Options...)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize generator: %w", err)
+	}
+
+	records := make([]*uploader.BlockData, 0, w.Blocks)
+	for i := uint(0); i < w.Blocks; i++ {
+		record, err := gen.Next()
+		if err != nil {
+			return nil, fmt.Errorf("could not generate block: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// Trie generates the execution state trie of the workload, as it is after its
+// last block.
+func (w Workload) Trie() (*trie.MTrie, error) {
+
+	gen, err := chain.NewGenerator(w.Options...)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize generator: %w", err)
+	}
+
+	for i := uint(0); i < w.Blocks; i++ {
+		_, err := gen.Next()
+		if err != nil {
+			return nil, fmt.Errorf("could not generate block: %w", err)
+		}
+	}
+
+	return gen.Tree(), nil
+}
+
+// Checkpoint encodes the execution state trie of the workload as a LedgerWAL
+// checkpoint, as the DPS loads it when bootstrapping.
+func (w Workload) Checkpoint() ([]byte, error) {
+
+	tree, err := w.Trie()
+	if err != nil {
+		return nil, fmt.Errorf("could not generate trie: %w", err)
+	}
+
+	flat, err := flattener.FlattenTrie(tree)
+	if err != nil {
+		return nil, fmt.Errorf("could not flatten trie: %w", err)
+	}
+	forest := flat.ToFlattenedForestWithASingleTrie()
+
+	buf := &bytes.Buffer{}
+	err = wal.StoreCheckpoint(forest, buf)
+	if err != nil {
+		return nil, fmt.Errorf("could not store checkpoint: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
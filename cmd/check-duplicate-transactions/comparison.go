@@ -25,6 +25,7 @@ import (
 
 	"github.com/optakt/flow-dps/codec/zbor"
 	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/logs"
 	"github.com/optakt/flow-dps/service/storage"
 )
 
@@ -33,12 +34,12 @@ func compareDuplicates(log zerolog.Logger, dataDir string, indexDir string, dupl
 	log.Info().Msg("comparing duplicates between databases")
 
 	// Initialize the databases.
-	protocol, err := badger.Open(dps.DefaultOptions(dataDir).WithReadOnly(true))
+	protocol, err := badger.Open(dps.ReadOnlyOptions(dataDir))
 	if err != nil {
 		return fmt.Errorf("could not open protocol state (dir: %s): %w", dataDir, err)
 	}
 	defer protocol.Close()
-	index, err := badger.Open(dps.DefaultOptions(indexDir).WithReadOnly(true))
+	index, err := badger.Open(dps.ReadOnlyOptions(indexDir))
 	if err != nil {
 		return fmt.Errorf("could not open state index (dir: %s): %w", indexDir, err)
 	}
@@ -50,7 +51,7 @@ func compareDuplicates(log zerolog.Logger, dataDir string, indexDir string, dupl
 	// Go through duplicates and compare number and IDs of duplicates beetween databases.
 	for height, duplicateIDs := range duplicates {
 
-		log := log.With().Uint64("height", height).Int("duplicates", len(duplicateIDs)).Logger()
+		log := log.With().Uint64(logs.Height, height).Int("duplicates", len(duplicateIDs)).Logger()
 
 		// create a lookup of duplicate IDs
 		lookup := make(map[flow.Identifier]struct{})
@@ -92,7 +93,7 @@ func compareDuplicates(log zerolog.Logger, dataDir string, indexDir string, dupl
 				_, ok := lookup[txID]
 				if ok {
 					firstFound++
-					log.Debug().Hex("transaction", txID[:]).Msg("duplicate from protocol state found in duplicates")
+					log.Debug().Hex(logs.TxID, txID[:]).Msg("duplicate from protocol state found in duplicates")
 				}
 			}
 		}
@@ -111,11 +112,11 @@ func compareDuplicates(log zerolog.Logger, dataDir string, indexDir string, dupl
 			_, ok := lookup[txID]
 			if ok {
 				secondFound++
-				log.Debug().Hex("transaction", txID[:]).Msg("transaction from state index found in duplicates")
+				log.Debug().Hex(logs.TxID, txID[:]).Msg("transaction from state index found in duplicates")
 			}
 		}
 
-		log.Info().Uint64("height", height).Hex("block", blockID[:]).Uint("first_count", firstCount).Uint("second_count", secondCount).Uint("first_found", firstFound).Uint("second_found", secondFound).Msg("compared duplicates for block")
+		log.Info().Uint64(logs.Height, height).Hex(logs.BlockID, blockID[:]).Uint("first_count", firstCount).Uint("second_count", secondCount).Uint("first_found", firstFound).Uint("second_found", secondFound).Msg("compared duplicates for block")
 	}
 
 	log.Info().Msg("duplicate comparison completed")
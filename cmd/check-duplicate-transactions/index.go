@@ -25,6 +25,7 @@ import (
 
 	"github.com/optakt/flow-dps/codec/zbor"
 	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/logs"
 	"github.com/optakt/flow-dps/service/storage"
 )
 
@@ -36,7 +37,7 @@ func indexCheck(log zerolog.Logger, dir string) (map[uint64][]flow.Identifier, e
 	log.Info().Str("index", dir).Msg("starting index state duplicate check")
 
 	// Open the index database.
-	index, err := badger.Open(dps.DefaultOptions(dir).WithReadOnly(true))
+	index, err := badger.Open(dps.ReadOnlyOptions(dir))
 	if err != nil {
 		return nil, fmt.Errorf("could not open state index (dir: %s): %w", dir, err)
 	}
@@ -56,7 +57,7 @@ func indexCheck(log zerolog.Logger, dir string) (map[uint64][]flow.Identifier, e
 	for height := first; ; height++ {
 		seen := make(map[flow.Identifier]struct{})
 
-		log := log.With().Uint64("height", height).Logger()
+		log := log.With().Uint64(logs.Height, height).Logger()
 
 		// height => txIDs
 		var txIDs []flow.Identifier
@@ -72,7 +73,7 @@ func indexCheck(log zerolog.Logger, dir string) (map[uint64][]flow.Identifier, e
 		var duplicateIDs []flow.Identifier
 		for _, txID := range txIDs {
 
-			log := log.With().Hex("transaction", txID[:]).Logger()
+			log := log.With().Hex(logs.TxID, txID[:]).Logger()
 
 			_, ok := seen[txID]
 			if ok {
@@ -26,6 +26,7 @@ import (
 	"github.com/onflow/flow-go/storage/badger/operation"
 
 	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/logs"
 )
 
 func protocolCheck(log zerolog.Logger, dir string) error {
@@ -33,7 +34,7 @@ func protocolCheck(log zerolog.Logger, dir string) error {
 	log.Info().Str("data", dir).Msg("starting protocol state duplicate check")
 
 	// Open the protocol state database.
-	protocol, err := badger.Open(dps.DefaultOptions(dir).WithReadOnly(true))
+	protocol, err := badger.Open(dps.ReadOnlyOptions(dir))
 	if err != nil {
 		return fmt.Errorf("could not open protocol state (dir: %s): %w", dir, err)
 	}
@@ -50,7 +51,7 @@ func protocolCheck(log zerolog.Logger, dir string) error {
 	for height := root; ; height++ {
 		seen := make(map[flow.Identifier]flow.Identifier)
 
-		log := log.With().Uint64("height", height).Logger()
+		log := log.With().Uint64(logs.Height, height).Logger()
 
 		// height => blockID
 		var blockID flow.Identifier
@@ -62,7 +63,7 @@ func protocolCheck(log zerolog.Logger, dir string) error {
 			return fmt.Errorf("could not look up block (height: %d): %w", height, err)
 		}
 
-		log = log.With().Hex("block", blockID[:]).Logger()
+		log = log.With().Hex(logs.BlockID, blockID[:]).Logger()
 
 		// blockID => collIDs
 		var collIDs []flow.Identifier
@@ -85,7 +86,7 @@ func protocolCheck(log zerolog.Logger, dir string) error {
 			// txID ? duplicate
 			for _, txID := range collection.Transactions {
 
-				log := log.With().Hex("transaction", txID[:]).Logger()
+				log := log.With().Hex(logs.TxID, txID[:]).Logger()
 
 				altID, ok := seen[txID]
 				if ok {
@@ -0,0 +1,100 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/journal"
+)
+
+// Finalization is the printable journal entry of a finalized block that the
+// consensus follower delivered, along with whether the block was indexed.
+type Finalization struct {
+	Height   uint64          `json:"height"`
+	BlockID  flow.Identifier `json:"block_id"`
+	Received time.Time       `json:"received"`
+	Indexed  bool            `json:"indexed"`
+}
+
+// analyzeFinalizations reads the entries of the given finalization journal and
+// checks for each of them whether its block was indexed.
+func analyzeFinalizations(db *badger.DB, lib dps.ReadLibrary, r io.Reader) ([]Finalization, error) {
+
+	entries, err := journal.Read(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read journal: %w", err)
+	}
+
+	finalizations := make([]Finalization, 0, len(entries))
+	for _, entry := range entries {
+		var height uint64
+		err := db.View(lib.LookupHeightForBlock(entry.BlockID, &height))
+		if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+			return nil, fmt.Errorf("could not look up height for block (%x): %w", entry.BlockID, err)
+		}
+		finalization := Finalization{
+			Height:   entry.Height,
+			BlockID:  entry.BlockID,
+			Received: entry.Received.UTC(),
+			Indexed:  err == nil,
+		}
+		finalizations = append(finalizations, finalization)
+	}
+
+	return finalizations, nil
+}
+
+// writeFinalizations writes the given finalizations in the given format.
+func writeFinalizations(w io.Writer, format string, finalizations []Finalization) error {
+
+	if format == formatJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(finalizations)
+	}
+
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"height", "block_id", "received", "indexed"})
+	if err != nil {
+		return fmt.Errorf("could not write header: %w", err)
+	}
+	for _, finalization := range finalizations {
+		record := []string{
+			strconv.FormatUint(finalization.Height, 10),
+			finalization.BlockID.String(),
+			finalization.Received.Format(time.RFC3339Nano),
+			strconv.FormatBool(finalization.Indexed),
+		}
+		err = writer.Write(record)
+		if err != nil {
+			return fmt.Errorf("could not write record: %w", err)
+		}
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
@@ -0,0 +1,242 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/onflow/flow-go/engine/execution/state"
+	fstate "github.com/onflow/flow-go/fvm/state"
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// pageSize is the number of register deltas that are read at once.
+const pageSize = 1000
+
+// Growth is the printable storage growth of an account, or of one of its
+// contracts, over a period of heights. Registers that are not owned by any
+// account are counted for the empty address, and registers that do not hold
+// contract code have an empty contract name.
+type Growth struct {
+	From     uint64       `json:"from"`
+	To       uint64       `json:"to"`
+	Owner    flow.Address `json:"owner"`
+	Contract string       `json:"contract"`
+	Created  uint64       `json:"created"`
+	Updated  uint64       `json:"updated"`
+	Removed  uint64       `json:"removed"`
+	Bytes    int64        `json:"bytes"`
+}
+
+// analyzeGrowth goes through the register deltas of the heights in the given
+// range and sums up, for each period of the given number of heights, how many
+// registers each account and contract created, updated and removed, and by how
+// many bytes their values grew. As the deltas only hold the hashes of the
+// registers, the values are looked up in the payload index.
+func analyzeGrowth(db *badger.DB, lib dps.ReadLibrary, from uint64, to uint64, interval uint64) ([]Growth, error) {
+
+	var first, last uint64
+	err := db.View(func(tx *badger.Txn) error {
+		err := lib.RetrieveFirst(&first)(tx)
+		if err != nil {
+			return fmt.Errorf("could not retrieve first height: %w", err)
+		}
+		err = lib.RetrieveLast(&last)(tx)
+		if err != nil {
+			return fmt.Errorf("could not retrieve last height: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if from < first || to > last {
+		return nil, fmt.Errorf("invalid height range (from: %d, to: %d, first: %d, last: %d): %w", from, to, first, last, dps.ErrNotIndexed)
+	}
+
+	type group struct {
+		from     uint64
+		owner    flow.Address
+		contract string
+	}
+
+	groups := make(map[group]*Growth)
+	for height := from; height <= to; height++ {
+
+		start := from + (height-from)/interval*interval
+		end := start + interval - 1
+		if end > to {
+			end = to
+		}
+
+		var after ledger.Path
+		for {
+			var deltas []dps.RegisterDelta
+			err := db.View(func(tx *badger.Txn) error {
+
+				err := lib.RetrieveRegisterDeltas(context.Background(), height, after, pageSize, &deltas)(tx)
+				if err != nil {
+					return fmt.Errorf("could not retrieve register deltas: %w", err)
+				}
+
+				for _, delta := range deltas {
+
+					// The payload of a removed register no longer has a key,
+					// so we identify registers by their previous payload in
+					// that case.
+					var previous, current ledger.Payload
+					if len(delta.Previous) > 0 {
+						err = lib.RetrievePayload(height-1, delta.Path, &previous)(tx)
+						if err != nil {
+							return fmt.Errorf("could not retrieve previous payload (path: %x): %w", delta.Path, err)
+						}
+					}
+					if len(delta.Current) > 0 {
+						err = lib.RetrievePayload(height, delta.Path, &current)(tx)
+						if err != nil {
+							return fmt.Errorf("could not retrieve current payload (path: %x): %w", delta.Path, err)
+						}
+					}
+					payload := &current
+					if len(delta.Current) == 0 {
+						payload = &previous
+					}
+
+					owner, _ := dps.PayloadOwner(payload)
+					key := group{
+						from:     start,
+						owner:    owner,
+						contract: contractName(payload),
+					}
+					growth, ok := groups[key]
+					if !ok {
+						growth = &Growth{
+							From:     start,
+							To:       end,
+							Owner:    owner,
+							Contract: key.contract,
+						}
+						groups[key] = growth
+					}
+
+					switch {
+					case len(delta.Previous) == 0 && len(delta.Current) > 0:
+						growth.Created++
+					case len(delta.Previous) > 0 && len(delta.Current) == 0:
+						growth.Removed++
+					case len(delta.Previous) > 0 && len(delta.Current) > 0:
+						growth.Updated++
+					}
+					growth.Bytes += int64(len(current.Value)) - int64(len(previous.Value))
+				}
+
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("could not analyze height %d: %w", height, err)
+			}
+
+			if len(deltas) < pageSize {
+				break
+			}
+			after = deltas[len(deltas)-1].Path
+		}
+	}
+
+	growths := make([]Growth, 0, len(groups))
+	for _, growth := range groups {
+		growths = append(growths, *growth)
+	}
+	sort.Slice(growths, func(i, j int) bool {
+		if growths[i].From != growths[j].From {
+			return growths[i].From < growths[j].From
+		}
+		if growths[i].Owner != growths[j].Owner {
+			return bytes.Compare(growths[i].Owner[:], growths[j].Owner[:]) < 0
+		}
+		return growths[i].Contract < growths[j].Contract
+	})
+
+	return growths, nil
+}
+
+// contractName returns the name of the contract whose code is held by the
+// register of the given payload, or nothing if it does not hold contract code.
+func contractName(payload *ledger.Payload) string {
+	prefix := fstate.KeyCode + "."
+	for _, part := range payload.Key.KeyParts {
+		if part.Type != state.KeyPartKey {
+			continue
+		}
+		key := string(part.Value)
+		if !strings.HasPrefix(key, prefix) {
+			return ""
+		}
+		return strings.TrimPrefix(key, prefix)
+	}
+	return ""
+}
+
+// writeGrowth writes the given growth report in the given format.
+func writeGrowth(w io.Writer, format string, growths []Growth) error {
+
+	if format == formatJSON {
+		// We always want to print a list, even when nothing changed.
+		if growths == nil {
+			growths = []Growth{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(growths)
+	}
+
+	writer := csv.NewWriter(w)
+	err := writer.Write([]string{"from", "to", "owner", "contract", "created", "updated", "removed", "bytes"})
+	if err != nil {
+		return fmt.Errorf("could not write header: %w", err)
+	}
+	for _, growth := range growths {
+		record := []string{
+			strconv.FormatUint(growth.From, 10),
+			strconv.FormatUint(growth.To, 10),
+			growth.Owner.Hex(),
+			growth.Contract,
+			strconv.FormatUint(growth.Created, 10),
+			strconv.FormatUint(growth.Updated, 10),
+			strconv.FormatUint(growth.Removed, 10),
+			strconv.FormatInt(growth.Bytes, 10),
+		}
+		err = writer.Write(record)
+		if err != nil {
+			return fmt.Errorf("could not write record: %w", err)
+		}
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
@@ -0,0 +1,195 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
+
+	"github.com/optakt/flow-dps/codec"
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/index"
+	"github.com/optakt/flow-dps/service/storage"
+)
+
+const (
+	success = 0
+	failure = 1
+)
+
+const (
+	formatCSV  = "csv"
+	formatJSON = "json"
+)
+
+const usage = `Usage: flow-dps-analyze [flags] <command> [arguments]
+
+Commands:
+  finalizations <journal>    report whether each block of a finalization journal of the live binary was indexed
+  state-growth <from> <to>   report the storage growth per account and contract in the given range
+
+Flags:
+`
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+
+	// Parse the command line arguments.
+	var (
+		flagFormat    string
+		flagIndex     string
+		flagInterval  uint64
+		flagLevel     string
+		flagNamespace string
+	)
+
+	pflag.StringVarP(&flagFormat, "format", "f", formatCSV, "output format, either csv or json")
+	pflag.StringVarP(&flagIndex, "index", "i", "index", "database directory for state index")
+	pflag.Uint64Var(&flagInterval, "interval", 1000, "number of heights aggregated into each period of the report")
+	pflag.StringVarP(&flagLevel, "level", "l", "info", "log output level")
+	pflag.StringVarP(&flagNamespace, "namespace", "n", "", "namespace in the index database to analyze (default namespace when left empty)")
+
+	pflag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		pflag.PrintDefaults()
+	}
+
+	pflag.Parse()
+
+	// Initialize the logger.
+	zerolog.TimestampFunc = func() time.Time { return time.Now().UTC() }
+	log := zerolog.New(os.Stderr).With().Timestamp().Logger().Level(zerolog.DebugLevel)
+	level, err := zerolog.ParseLevel(flagLevel)
+	if err != nil {
+		log.Error().Str("level", flagLevel).Err(err).Msg("could not parse log level")
+		return failure
+	}
+	log = log.Level(level)
+
+	if flagFormat != formatCSV && flagFormat != formatJSON {
+		log.Error().Str("format", flagFormat).Msg("invalid output format")
+		return failure
+	}
+	if flagInterval == 0 {
+		log.Error().Msg("interval must not be zero")
+		return failure
+	}
+
+	// We need at least the command to execute.
+	args := pflag.Args()
+	if len(args) == 0 {
+		pflag.Usage()
+		return failure
+	}
+	command, args := args[0], args[1:]
+
+	// Open the index database read-only, so that it can be analyzed while it
+	// is being used by another process.
+	db, err := badger.Open(dps.ReadOnlyOptions(flagIndex))
+	if err != nil {
+		log.Error().Str("index", flagIndex).Err(err).Msg("could not open state index")
+		return failure
+	}
+	defer db.Close()
+
+	// Initialize the storage library, which decodes values with the codec that
+	// was recorded when the index was created.
+	name, err := index.Codec(db, storage.New(zbor.NewCodec(), storage.WithNamespace(flagNamespace)))
+	if err != nil {
+		log.Error().Err(err).Msg("could not get codec of index")
+		return failure
+	}
+	if name == "" {
+		name = dps.CodecZbor
+	}
+	codec, err := codec.New(name)
+	if err != nil {
+		log.Error().Str("codec", name).Err(err).Msg("could not create codec")
+		return failure
+	}
+	lib := storage.New(codec, storage.WithNamespace(flagNamespace))
+
+	switch command {
+
+	case "finalizations":
+		if len(args) != 1 {
+			log.Error().Msg("finalizations command needs a journal file argument")
+			return failure
+		}
+		file, err := os.Open(args[0])
+		if err != nil {
+			log.Error().Str("journal", args[0]).Err(err).Msg("could not open journal")
+			return failure
+		}
+		defer file.Close()
+		finalizations, err := analyzeFinalizations(db, lib, file)
+		if err != nil {
+			log.Error().Str("journal", args[0]).Err(err).Msg("could not analyze finalizations")
+			return failure
+		}
+		err = writeFinalizations(os.Stdout, flagFormat, finalizations)
+		if err != nil {
+			log.Error().Err(err).Msg("could not write output")
+			return failure
+		}
+
+	case "state-growth":
+		if len(args) != 2 {
+			log.Error().Msg("state-growth command needs a start and an end height argument")
+			return failure
+		}
+		from, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			log.Error().Str("from", args[0]).Err(err).Msg("could not parse start height")
+			return failure
+		}
+		to, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			log.Error().Str("to", args[1]).Err(err).Msg("could not parse end height")
+			return failure
+		}
+		if from > to {
+			log.Error().Uint64("from", from).Uint64("to", to).Msg("start height must not be above end height")
+			return failure
+		}
+		growths, err := analyzeGrowth(db, lib, from, to, flagInterval)
+		if err != nil {
+			log.Error().Uint64("from", from).Uint64("to", to).Err(err).Msg("could not analyze state growth")
+			return failure
+		}
+		err = writeGrowth(os.Stdout, flagFormat, growths)
+		if err != nil {
+			log.Error().Err(err).Msg("could not write output")
+			return failure
+		}
+
+	default:
+		log.Error().Str("command", command).Msg("unknown command")
+		pflag.Usage()
+		return failure
+	}
+
+	return success
+}
@@ -15,6 +15,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
@@ -26,12 +27,14 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/onflow/cadence"
-	"github.com/onflow/cadence/encoding/json"
 
+	"github.com/optakt/flow-dps/api/client"
 	"github.com/optakt/flow-dps/api/dps"
+	"github.com/optakt/flow-dps/codec/cadencejson"
 	"github.com/optakt/flow-dps/codec/zbor"
 	"github.com/optakt/flow-dps/models/convert"
 	"github.com/optakt/flow-dps/service/invoker"
+	"github.com/optakt/flow-dps/service/logs"
 )
 
 const (
@@ -45,22 +48,31 @@ func main() {
 
 func run() int {
 
-	// Signal catching for clean shutdown.
+	// Signal catching for clean shutdown. An interrupt cancels the execution
+	// of the script, along with the API requests it is waiting on.
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-sig
+		cancel()
+	}()
 
 	// Command line parameter initialization.
 	var (
-		flagAPI    string
+		flagAPI    []string
 		flagCache  uint64
+		flagHedge  time.Duration
 		flagHeight uint64
 		flagLevel  string
 		flagParams string
 		flagScript string
 	)
 
-	pflag.StringVarP(&flagAPI, "api", "a", "", "host for GRPC API server")
+	pflag.StringSliceVarP(&flagAPI, "api", "a", nil, "hosts for GRPC API servers that serve the same index")
 	pflag.Uint64VarP(&flagCache, "cache", "e", 1_000_000_000, "maximum cache size for register reads in bytes")
+	pflag.DurationVar(&flagHedge, "hedge-delay", 0, "delay after which a request is also sent to the next API server (0s for disabled)")
 	pflag.Uint64VarP(&flagHeight, "height", "h", 0, "block height to execute the script at")
 	pflag.StringVarP(&flagLevel, "level", "l", "info", "log output level")
 	pflag.StringVarP(&flagParams, "params", "p", "", "comma-separated list of Cadence parameters")
@@ -79,27 +91,37 @@ func run() int {
 	log = log.Level(level)
 
 	// If no API server is given, choose based on height.
-	if flagAPI == "" {
+	if len(flagAPI) == 0 {
 		for _, spork := range DefaultSporks {
 			if flagHeight >= spork.First && flagHeight <= spork.Last {
-				log.Info().Uint64("height", flagHeight).Str("spork", spork.Name).Str("api", spork.API).Msg("spork and API chosen based on height")
-				flagAPI = spork.API
+				log.Info().Uint64(logs.Height, flagHeight).Str("spork", spork.Name).Str("api", spork.API).Msg("spork and API chosen based on height")
+				flagAPI = []string{spork.API}
 				break
 			}
 		}
 	}
-	if flagAPI == "" {
-		log.Error().Uint64("height", flagHeight).Msg("could not find spork and API for height")
+	if len(flagAPI) == 0 {
+		log.Error().Uint64(logs.Height, flagHeight).Msg("could not find spork and API for height")
 		return failure
 	}
 
-	// Initialize the API client.
-	conn, err := grpc.Dial(flagAPI, grpc.WithInsecure())
+	// Initialize the API client, which spreads its requests over all of the
+	// given API servers.
+	conns := make([]grpc.ClientConnInterface, 0, len(flagAPI))
+	for _, api := range flagAPI {
+		conn, err := grpc.Dial(api, grpc.WithInsecure())
+		if err != nil {
+			log.Error().Str("api", api).Err(err).Msg("could not dial API host")
+			return failure
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+	replicas, err := client.NewReplicas(conns, client.WithHedgeDelay(flagHedge))
 	if err != nil {
-		log.Error().Str("api", flagAPI).Err(err).Msg("could not dial API host")
+		log.Error().Err(err).Msg("could not initialize API replicas")
 		return failure
 	}
-	defer conn.Close()
 
 	// Read the script.
 	script, err := os.ReadFile(flagScript)
@@ -126,20 +148,20 @@ func run() int {
 	codec := zbor.NewCodec()
 
 	// Execute the script using remote lookup and read.
-	client := dps.NewAPIClient(conn)
-	invoke, err := invoker.New(dps.IndexFromAPI(client, codec), invoker.WithCacheSize(flagCache))
+	api := dps.NewAPIClient(replicas)
+	invoke, err := invoker.New(dps.IndexFromAPI(api, codec), invoker.WithCacheSize(flagCache))
 	if err != nil {
 		log.Error().Err(err).Msg("could not initialize invoker")
 		return failure
 	}
-	result, err := invoke.Script(flagHeight, script, args)
+	result, err := invoke.Script(ctx, flagHeight, script, args)
 	if err != nil {
 		log.Error().Err(err).Msg("could not invoke script")
 		return failure
 	}
-	output, err := json.Encode(result)
+	output, err := cadencejson.Encode(result)
 	if err != nil {
-		log.Error().Uint64("height", flagHeight).Err(err).Msg("could not encode result")
+		log.Error().Uint64(logs.Height, flagHeight).Err(err).Msg("could not encode result")
 		return failure
 	}
 
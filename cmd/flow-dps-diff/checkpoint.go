@@ -0,0 +1,86 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/rs/zerolog"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/loader"
+	"github.com/optakt/flow-dps/service/logs"
+)
+
+func compareCheckpoint(ctx context.Context, log zerolog.Logger, read dps.Reader, file io.Reader, paths []ledger.Path, height uint64) (uint, error) {
+
+	// A checkpoint represents the execution state at a single height, which is
+	// usually the first indexed height of a spork.
+	if height == 0 {
+		first, err := read.First(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("could not get first height: %w", err)
+		}
+		height = first
+	}
+
+	log = log.With().Uint64(logs.Height, height).Logger()
+
+	log.Info().Msg("starting checkpoint comparison")
+
+	tree, err := loader.FromCheckpoint(file).Trie()
+	if err != nil {
+		return 0, fmt.Errorf("could not load checkpoint trie: %w", err)
+	}
+
+	// checkpoint hash => commit
+	var divergences uint
+	hash := flow.StateCommitment(tree.RootHash())
+	commit, err := read.Commit(ctx, height)
+	if err != nil {
+		return 0, fmt.Errorf("could not get commit: %w", err)
+	}
+	if hash != commit {
+		log.Warn().Hex("checkpoint", hash[:]).Hex("index", commit[:]).Msg("state commitment diverges")
+		divergences++
+	}
+
+	// checkpoint payloads => sampled register values
+	if len(paths) > 0 {
+		values, err := read.Values(ctx, height, paths)
+		if err != nil {
+			return 0, fmt.Errorf("could not get values: %w", err)
+		}
+		// NOTE: We read from the tree one by one here, as reading multiple
+		// paths at once permutes the given paths in-place.
+		for i, path := range paths {
+			payloads := tree.UnsafeRead([]ledger.Path{path})
+			if !bytes.Equal(payloads[0].Value, values[i]) {
+				log.Warn().Hex("path", path[:]).Hex("checkpoint", payloads[0].Value).Hex("index", values[i]).Msg("register value diverges")
+				divergences++
+			}
+		}
+	}
+
+	log.Info().Uint("divergences", divergences).Msg("checkpoint comparison completed")
+
+	return divergences, nil
+}
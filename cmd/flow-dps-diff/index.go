@@ -0,0 +1,190 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/logs"
+)
+
+func compareIndexes(ctx context.Context, log zerolog.Logger, first dps.Reader, second dps.Reader, paths []ledger.Path, from uint64, to uint64) (uint, error) {
+
+	// By default, we compare the range of heights that is available in both
+	// state indexes.
+	start, end, err := overlap(ctx, first, second)
+	if err != nil {
+		return 0, fmt.Errorf("could not determine overlapping heights: %w", err)
+	}
+	if from != 0 {
+		start = from
+	}
+	if to != 0 {
+		end = to
+	}
+	if start > end {
+		return 0, fmt.Errorf("invalid height range (from: %d, to: %d)", start, end)
+	}
+
+	log.Info().Uint64("from", start).Uint64("to", end).Msg("starting state index comparison")
+
+	var divergences uint
+	for height := start; height <= end; height++ {
+
+		log := log.With().Uint64(logs.Height, height).Logger()
+
+		diverged, err := compareHeight(ctx, log, first, second, paths, height)
+		if err != nil {
+			return 0, fmt.Errorf("could not compare height (height: %d): %w", height, err)
+		}
+		divergences += diverged
+
+		if (height-start)%10000 == 0 {
+			log.Info().Uint("divergences", divergences).Msg("comparing state indexes")
+		}
+	}
+
+	log.Info().Uint("divergences", divergences).Msg("state index comparison completed")
+
+	return divergences, nil
+}
+
+func overlap(ctx context.Context, first dps.Reader, second dps.Reader) (uint64, uint64, error) {
+
+	firstStart, err := first.First(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not get first height of first index: %w", err)
+	}
+	secondStart, err := second.First(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not get first height of second index: %w", err)
+	}
+	firstEnd, err := first.Last(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not get last height of first index: %w", err)
+	}
+	secondEnd, err := second.Last(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("could not get last height of second index: %w", err)
+	}
+
+	start := firstStart
+	if secondStart > start {
+		start = secondStart
+	}
+	end := firstEnd
+	if secondEnd < end {
+		end = secondEnd
+	}
+
+	return start, end, nil
+}
+
+func compareHeight(ctx context.Context, log zerolog.Logger, first dps.Reader, second dps.Reader, paths []ledger.Path, height uint64) (uint, error) {
+
+	var divergences uint
+
+	// height => commit
+	firstCommit, err := first.Commit(ctx, height)
+	if err != nil {
+		return 0, fmt.Errorf("could not get first commit: %w", err)
+	}
+	secondCommit, err := second.Commit(ctx, height)
+	if err != nil {
+		return 0, fmt.Errorf("could not get second commit: %w", err)
+	}
+	if firstCommit != secondCommit {
+		log.Warn().Hex("first", firstCommit[:]).Hex("second", secondCommit[:]).Msg("state commitment diverges")
+		divergences++
+	}
+
+	// height => header
+	firstHeader, err := first.Header(ctx, height)
+	if err != nil {
+		return 0, fmt.Errorf("could not get first header: %w", err)
+	}
+	secondHeader, err := second.Header(ctx, height)
+	if err != nil {
+		return 0, fmt.Errorf("could not get second header: %w", err)
+	}
+	firstID := firstHeader.ID()
+	secondID := secondHeader.ID()
+	if firstID != secondID {
+		log.Warn().Hex("first", firstID[:]).Hex("second", secondID[:]).Msg("block header diverges")
+		divergences++
+	}
+
+	// height => event counts per type
+	firstEvents, err := first.Events(ctx, height)
+	if err != nil {
+		return 0, fmt.Errorf("could not get first events: %w", err)
+	}
+	secondEvents, err := second.Events(ctx, height)
+	if err != nil {
+		return 0, fmt.Errorf("could not get second events: %w", err)
+	}
+	firstCounts := countEvents(firstEvents)
+	secondCounts := countEvents(secondEvents)
+	for typ, count := range firstCounts {
+		if secondCounts[typ] != count {
+			log.Warn().Str("type", string(typ)).Uint("first", count).Uint("second", secondCounts[typ]).Msg("event count diverges")
+			divergences++
+		}
+	}
+	for typ, count := range secondCounts {
+		_, ok := firstCounts[typ]
+		if !ok {
+			log.Warn().Str("type", string(typ)).Uint("first", 0).Uint("second", count).Msg("event count diverges")
+			divergences++
+		}
+	}
+
+	// height => sampled register values
+	if len(paths) == 0 {
+		return divergences, nil
+	}
+	firstValues, err := first.Values(ctx, height, paths)
+	if err != nil {
+		return 0, fmt.Errorf("could not get first values: %w", err)
+	}
+	secondValues, err := second.Values(ctx, height, paths)
+	if err != nil {
+		return 0, fmt.Errorf("could not get second values: %w", err)
+	}
+	for i, path := range paths {
+		if !bytes.Equal(firstValues[i], secondValues[i]) {
+			log.Warn().Hex("path", path[:]).Hex("first", firstValues[i]).Hex("second", secondValues[i]).Msg("register value diverges")
+			divergences++
+		}
+	}
+
+	return divergences, nil
+}
+
+func countEvents(events []flow.Event) map[flow.EventType]uint {
+	counts := make(map[flow.EventType]uint)
+	for _, event := range events {
+		counts[event.Type]++
+	}
+	return counts
+}
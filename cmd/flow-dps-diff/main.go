@@ -0,0 +1,144 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
+
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/index"
+	"github.com/optakt/flow-dps/service/storage"
+)
+
+const (
+	success = 0
+	failure = 1
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+
+	// Parse the command line arguments.
+	var (
+		flagCheckpoint string
+		flagIndex      string
+		flagLevel      string
+		flagOther      string
+		flagSamples    uint
+
+		flagFrom uint64
+		flagTo   uint64
+	)
+
+	pflag.StringVarP(&flagCheckpoint, "checkpoint", "c", "", "path to checkpoint file to compare the state index against")
+	pflag.StringVarP(&flagIndex, "index", "i", "index", "database directory for state index")
+	pflag.StringVarP(&flagLevel, "level", "l", "info", "log output level")
+	pflag.StringVarP(&flagOther, "other", "o", "", "database directory for other state index to compare against")
+	pflag.UintVarP(&flagSamples, "samples", "n", 32, "number of ledger registers to sample for comparison")
+
+	pflag.Uint64Var(&flagFrom, "from", 0, "first height to compare (default first height available in both)")
+	pflag.Uint64Var(&flagTo, "to", 0, "last height to compare (default last height available in both)")
+
+	pflag.Parse()
+
+	// Initialize the logger.
+	zerolog.TimestampFunc = func() time.Time { return time.Now().UTC() }
+	log := zerolog.New(os.Stderr).With().Timestamp().Logger().Level(zerolog.DebugLevel)
+	level, err := zerolog.ParseLevel(flagLevel)
+	if err != nil {
+		log.Error().Str("level", flagLevel).Err(err).Msg("could not parse log level")
+		return failure
+	}
+	log = log.Level(level)
+
+	// We should have exactly one of the other index or the checkpoint to
+	// compare the state index against.
+	if (flagOther == "") == (flagCheckpoint == "") {
+		log.Error().Msg("need exactly one of other index (-o, --other) or checkpoint (-c, --checkpoint) to compare against")
+		return failure
+	}
+
+	// Open the state index database and initialize the reader on top of it.
+	db, err := badger.Open(dps.ReadOnlyOptions(flagIndex))
+	if err != nil {
+		log.Error().Str("index", flagIndex).Err(err).Msg("could not open state index")
+		return failure
+	}
+	defer db.Close()
+	lib := storage.New(zbor.NewCodec())
+	ctx := context.Background()
+	read := index.NewReader(db, lib)
+
+	// The same set of sampled ledger registers is compared at every height, so
+	// that changes to them can be tracked across the whole range.
+	paths, err := samplePaths(db, flagSamples)
+	if err != nil {
+		log.Error().Err(err).Msg("could not sample ledger registers")
+		return failure
+	}
+
+	log.Info().Int("samples", len(paths)).Msg("sampled ledger registers for comparison")
+
+	// If we compare against a checkpoint, there is only one height to compare,
+	// which defaults to the first indexed height.
+	var divergences uint
+	if flagCheckpoint != "" {
+		file, err := os.Open(flagCheckpoint)
+		if err != nil {
+			log.Error().Str("checkpoint", flagCheckpoint).Err(err).Msg("could not open checkpoint file")
+			return failure
+		}
+		defer file.Close()
+		divergences, err = compareCheckpoint(ctx, log, read, file, paths, flagFrom)
+		if err != nil {
+			log.Error().Err(err).Msg("could not compare state index against checkpoint")
+			return failure
+		}
+	}
+
+	// Otherwise, we compare the two state indexes height by height.
+	if flagOther != "" {
+		other, err := badger.Open(dps.ReadOnlyOptions(flagOther))
+		if err != nil {
+			log.Error().Str("other", flagOther).Err(err).Msg("could not open other state index")
+			return failure
+		}
+		defer other.Close()
+		divergences, err = compareIndexes(ctx, log, read, index.NewReader(other, lib), paths, flagFrom, flagTo)
+		if err != nil {
+			log.Error().Err(err).Msg("could not compare state indexes")
+			return failure
+		}
+	}
+
+	if divergences > 0 {
+		log.Warn().Uint("divergences", divergences).Msg("comparison found divergences")
+		return failure
+	}
+
+	log.Info().Msg("comparison found no divergences")
+
+	return success
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/onflow/flow-go/ledger"
+
+	"github.com/optakt/flow-dps/service/storage"
+)
+
+// samplePaths goes through the keys of all indexed ledger registers and picks a
+// uniformly distributed sample of distinct paths, using reservoir sampling. We
+// use a fixed seed, so that the same index always results in the same sample.
+func samplePaths(db *badger.DB, samples uint) ([]ledger.Path, error) {
+
+	prefix := storage.EncodeKey(storage.PrefixPayload)
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+	opts.Prefix = prefix
+
+	random := rand.New(rand.NewSource(0))
+	paths := make([]ledger.Path, 0, samples)
+	err := db.View(func(tx *badger.Txn) error {
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		// Keys for the same path are sorted next to each other, so we only need
+		// to remember the last path to skip the other heights of a register.
+		var last ledger.Path
+		seen := uint64(0)
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			key := it.Item().Key()
+			if len(key) != 1+len(last)+8 {
+				return fmt.Errorf("invalid payload key length (key: %x)", key)
+			}
+
+			var path ledger.Path
+			copy(path[:], key[1:1+len(path)])
+			if seen > 0 && path == last {
+				continue
+			}
+			last = path
+			seen++
+
+			if uint(len(paths)) < samples {
+				paths = append(paths, path)
+				continue
+			}
+			index := uint64(random.Int63n(int64(seen)))
+			if index < uint64(samples) {
+				paths[index] = path
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not iterate ledger registers: %w", err)
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/fork"
+	"github.com/optakt/flow-dps/service/index"
+	"github.com/optakt/flow-dps/service/logs"
+	"github.com/optakt/flow-dps/service/storage"
+)
+
+const (
+	success = 0
+	failure = 1
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+
+	// Parse the command line arguments.
+	var (
+		flagAuthorizers []string
+		flagGasLimit    uint64
+		flagHeight      uint64
+		flagIndex       string
+		flagLevel       string
+		flagPayer       string
+		flagScript      string
+	)
+
+	pflag.StringSliceVarP(&flagAuthorizers, "authorizers", "a", nil, "addresses of the accounts authorizing the transactions")
+	pflag.Uint64VarP(&flagGasLimit, "gas-limit", "g", 9999, "gas limit of the transactions")
+	pflag.Uint64Var(&flagHeight, "height", 0, "height of the state to fork (default last indexed height)")
+	pflag.StringVarP(&flagIndex, "index", "i", "index", "database directory for state index")
+	pflag.StringVarP(&flagLevel, "level", "l", "info", "log output level")
+	pflag.StringVarP(&flagPayer, "payer", "p", "", "address of the account paying for and proposing the transactions (default service account)")
+	pflag.StringVarP(&flagScript, "script", "s", "", "path to Cadence script to run on the fork after the transactions")
+
+	pflag.Parse()
+
+	// Initialize the logger.
+	zerolog.TimestampFunc = func() time.Time { return time.Now().UTC() }
+	log := zerolog.New(os.Stderr).With().Timestamp().Logger().Level(zerolog.DebugLevel)
+	level, err := zerolog.ParseLevel(flagLevel)
+	if err != nil {
+		log.Error().Str("level", flagLevel).Err(err).Msg("could not parse log level")
+		return failure
+	}
+	log = log.Level(level)
+
+	// Open the state index database and initialize the reader on top of it.
+	db, err := badger.Open(dps.ReadOnlyOptions(flagIndex))
+	if err != nil {
+		log.Error().Str("index", flagIndex).Err(err).Msg("could not open state index")
+		return failure
+	}
+	defer db.Close()
+	read := index.NewReader(db, storage.New(zbor.NewCodec()))
+
+	// Fork the state at the last indexed height, unless a height was given.
+	height := flagHeight
+	if height == 0 {
+		height, err = read.Last(context.Background())
+		if err != nil {
+			log.Error().Err(err).Msg("could not get last height")
+			return failure
+		}
+	}
+	log = log.With().Uint64(logs.Height, height).Logger()
+
+	// Transactions are not signed, so their validation is left disabled.
+	f, err := fork.New(read, height, fork.WithLogger(log))
+	if err != nil {
+		log.Error().Err(err).Msg("could not fork state index")
+		return failure
+	}
+
+	// The transactions are proposed and paid for by the service account of
+	// the chain by default, which always has funds to pay for fees.
+	header, err := read.Header(context.Background(), height)
+	if err != nil {
+		log.Error().Err(err).Msg("could not get header")
+		return failure
+	}
+	payer := header.ChainID.Chain().ServiceAddress()
+	if flagPayer != "" {
+		payer = flow.HexToAddress(flagPayer)
+	}
+	var authorizers []flow.Address
+	for _, authorizer := range flagAuthorizers {
+		authorizers = append(authorizers, flow.HexToAddress(authorizer))
+	}
+
+	// Execute the transactions given as arguments, in order, each on top of the
+	// registers written by the previous ones.
+	failed := false
+	for _, path := range pflag.Args() {
+		script, err := os.ReadFile(path)
+		if err != nil {
+			log.Error().Str("path", path).Err(err).Msg("could not read transaction")
+			return failure
+		}
+
+		tx := flow.NewTransactionBody().
+			SetScript(script).
+			SetReferenceBlockID(header.ID()).
+			SetGasLimit(flagGasLimit).
+			SetProposalKey(payer, 0, 0).
+			SetPayer(payer)
+		for _, authorizer := range authorizers {
+			tx.AddAuthorizer(authorizer)
+		}
+
+		proc, err := f.Execute(tx)
+		if err != nil {
+			log.Error().Str("path", path).Err(err).Msg("could not execute transaction")
+			return failure
+		}
+
+		log := log.With().Str("path", path).Hex(logs.TxID, proc.ID[:]).Logger()
+		for _, line := range proc.Logs {
+			log.Info().Str("log", line).Msg("transaction logged message")
+		}
+		for _, event := range proc.Events {
+			log.Info().Str("type", string(event.Type)).Str("payload", string(event.Payload)).Msg("transaction emitted event")
+		}
+		if proc.Err != nil {
+			log.Warn().Str("error", proc.Err.Error()).Msg("transaction failed")
+			failed = true
+			continue
+		}
+
+		log.Info().Uint64("computation", proc.ComputationUsed).Int("events", len(proc.Events)).Msg("transaction executed")
+	}
+
+	// Finally, run the script on the resulting state, if one was given.
+	if flagScript != "" {
+		script, err := os.ReadFile(flagScript)
+		if err != nil {
+			log.Error().Str("script", flagScript).Err(err).Msg("could not read script")
+			return failure
+		}
+		value, err := f.Script(script, nil)
+		if err != nil {
+			log.Error().Str("script", flagScript).Err(err).Msg("could not run script")
+			return failure
+		}
+		log.Info().Str("value", value.String()).Msg("script executed")
+	}
+
+	log.Info().Int("registers", len(f.Delta().Data)).Msg("fork execution complete")
+
+	if failed {
+		return failure
+	}
+
+	return success
+}
@@ -15,24 +15,30 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os"
 	"os/signal"
 	"runtime"
 	"time"
 
 	"github.com/dgraph-io/badger/v2"
-	"github.com/prometheus/tsdb/wal"
 	"github.com/rs/zerolog"
 	"github.com/spf13/pflag"
 
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/codec"
 	"github.com/optakt/flow-dps/codec/zbor"
 	"github.com/optakt/flow-dps/models/dps"
 	"github.com/optakt/flow-dps/service/chain"
+	"github.com/optakt/flow-dps/service/checkpoint"
 	"github.com/optakt/flow-dps/service/feeder"
 	"github.com/optakt/flow-dps/service/forest"
 	"github.com/optakt/flow-dps/service/index"
 	"github.com/optakt/flow-dps/service/loader"
+	"github.com/optakt/flow-dps/service/logs"
 	"github.com/optakt/flow-dps/service/mapper"
 	"github.com/optakt/flow-dps/service/storage"
 )
@@ -54,20 +60,66 @@ func run() int {
 
 	// Command line parameter initialization.
 	var (
-		flagCheckpoint string
-		flagData       string
-		flagIndex      string
-		flagLevel      string
-		flagTrie       string
-		flagSkip       bool
+		flagAudit              bool
+		flagCheckpoint         string
+		flagCheckpointInterval uint64
+		flagCheckpointOutput   string
+		flagCodec              string
+		flagCodecDeterministic bool
+		flagCodecValidate      bool
+		flagCompression        map[string]string
+		flagCompressionStats   bool
+		flagCorruption         string
+		flagData               string
+		flagEstimate           bool
+		flagIndex              string
+		flagLevel              string
+		flagLogFormat          string
+		flagLogOverrides       map[string]string
+		flagManifests          bool
+		flagNamespace          string
+		flagSizeStats          bool
+		flagTrie               string
+		flagDeny               []string
+		flagOwners             []string
+		flagFollow             bool
+		flagForestLimit        uint
+		flagPathFilters        bool
+		flagRegisterDeltas     bool
+		flagRepair             bool
+		flagSkip               bool
+		flagTakeover           bool
 	)
 
 	pflag.StringVarP(&flagCheckpoint, "checkpoint", "c", "", "path to root checkpoint file for execution state trie")
+	pflag.Uint64Var(&flagCheckpointInterval, "checkpoint-interval", 0, "number of heights between emitted checkpoints of the execution state trie (0 for disabled)")
+	pflag.StringVar(&flagCheckpointOutput, "checkpoint-output", "", "directory or bucket, such as gs://bucket/prefix, to which checkpoints of the execution state trie are emitted")
+	pflag.StringVar(&flagCorruption, "corruption", "halt", "policy for corrupted write-ahead log data (halt or skip)")
 	pflag.StringVarP(&flagData, "data", "d", "data", "path to database directory for protocol data")
+	pflag.BoolVar(&flagEstimate, "estimate", false, "scan the root checkpoint and report the estimated resources needed to bootstrap from it, without indexing")
 	pflag.StringVarP(&flagIndex, "index", "i", "index", "path to database directory for state index")
 	pflag.StringVarP(&flagLevel, "level", "l", "info", "log output level")
+	pflag.StringVar(&flagLogFormat, "log-format", "json", "format of the log output (json or console)")
+	pflag.StringToStringVar(&flagLogOverrides, "log-level-overrides", nil, "log output level per component (e.g. mapper=debug,gcp_streamer=warn)")
+	pflag.StringVarP(&flagNamespace, "namespace", "n", "", "namespace in the index database for the indexed data (default namespace when left empty)")
 	pflag.StringVarP(&flagTrie, "trie", "t", "", "path to data directory for execution state ledger")
+	pflag.StringSliceVarP(&flagOwners, "owners", "o", nil, "addresses of the accounts whose data is indexed (all accounts when left empty)")
+	pflag.StringSliceVar(&flagDeny, "deny", nil, "addresses of the accounts whose data is excluded from indexing")
+	pflag.BoolVarP(&flagFollow, "follow", "f", false, "follow the execution state ledger write-ahead log while it is being written")
 	pflag.BoolVarP(&flagSkip, "skip", "s", false, "skip indexing of execution state ledger registers")
+	pflag.StringToStringVar(&flagCompression, "compression", nil, "compression per resource, such as events=best,payload=none (none, fastest, default, better or best)")
+	pflag.BoolVar(&flagAudit, "audit", false, "check the sequence of indexed heights for gaps when resuming and refuse to start if any are found")
+	pflag.StringVar(&flagCodec, "codec", "", "codec with which the values of a new index are stored (zbor or protostore, recorded codec or zbor when left empty)")
+	pflag.BoolVar(&flagCodecDeterministic, "codec-deterministic", false, "encode stored values with deterministic CBOR encoding and reject values with non-deterministic encodings when decoding")
+	pflag.BoolVar(&flagCodecValidate, "codec-validate", false, "decode each stored value again right after encoding it and fail on lossy encodings (for debugging)")
+	pflag.BoolVar(&flagCompressionStats, "compression-stats", false, "record compression statistics for the stored values")
+	pflag.BoolVar(&flagSizeStats, "size-stats", false, "keep running size statistics of the stored keys and values per data category")
+	pflag.UintVar(&flagForestLimit, "forest-limit", 0, "maximum number of execution state tries kept in memory while waiting for sealed blocks (0 for unlimited)")
+	pflag.BoolVar(&flagManifests, "manifests", false, "record per-height integrity manifests of the indexed data")
+	pflag.BoolVar(&flagPathFilters, "path-filters", false, "maintain bloom filters of written register paths to speed up lookups of missing registers")
+	pflag.BoolVar(&flagRegisterDeltas, "register-deltas", false, "record the hashes of the registers written by each block before and after the block")
+	pflag.BoolVar(&flagRepair, "repair", false, "repair gaps in the sequence of indexed heights from the protocol state database when resuming (implies --audit)")
+	pflag.BoolVar(&flagTakeover, "takeover", false, "take over the index from another instance that stopped without releasing it")
 
 	pflag.Parse()
 
@@ -78,12 +130,60 @@ func run() int {
 	// Logger initialization.
 	zerolog.TimestampFunc = func() time.Time { return time.Now().UTC() }
 	log := zerolog.New(os.Stderr).With().Timestamp().Logger().Level(zerolog.DebugLevel)
+	out, err := logs.Output(flagLogFormat, os.Stderr)
+	if err != nil {
+		log.Error().Str("format", flagLogFormat).Err(err).Msg("could not initialize log output")
+		return failure
+	}
+	overrides, err := logs.ParseOverrides(flagLogOverrides)
+	if err != nil {
+		log.Error().Err(err).Msg("could not parse log level overrides")
+		return failure
+	}
+	levels := logs.NewFilter(out, overrides)
+	log = log.Output(levels)
 	level, err := zerolog.ParseLevel(flagLevel)
 	if err != nil {
 		log.Error().Str("level", flagLevel).Err(err).Msg("could not parse log level")
 		return failure
 	}
-	log = log.Level(level)
+	levels.SetLevel(level)
+
+	// Validate the policy for corrupted write-ahead log data.
+	if flagCorruption != "halt" && flagCorruption != "skip" {
+		log.Error().Str("corruption", flagCorruption).Msg("invalid corruption policy, please use halt or skip (--corruption)")
+		return failure
+	}
+
+	// In estimation mode, we only scan the root checkpoint to report what
+	// bootstrapping from it needs, so that machines can be sized before
+	// committing to a bootstrap.
+	if flagEstimate {
+		if flagCheckpoint == "" {
+			log.Error().Msg("no checkpoint to scan, please provide root checkpoint (-c, --checkpoint)")
+			return failure
+		}
+		file, err := os.Open(flagCheckpoint)
+		if err != nil {
+			log.Error().Err(err).Msg("could not open checkpoint file")
+			return failure
+		}
+		defer file.Close()
+		estimate, err := loader.FromCheckpoint(file).Estimate()
+		if err != nil {
+			log.Error().Err(err).Msg("could not scan checkpoint file")
+			return failure
+		}
+		log.Info().
+			Uint64("nodes", estimate.Nodes).
+			Uint64("leaves", estimate.Leaves).
+			Uint64("payload_bytes", estimate.Payloads).
+			Uint64("trie_memory_bytes", estimate.Trie).
+			Uint64("peak_memory_bytes", estimate.Peak).
+			Uint64("index_bytes", estimate.Index).
+			Msg("checkpoint scanned")
+		return success
+	}
 
 	// Open the needed databases.
 	indexDB, err := badger.Open(dps.DefaultOptions(flagIndex))
@@ -109,16 +209,63 @@ func run() int {
 		}
 	}()
 
+	// The codec with which the values are stored is chosen when the index is
+	// created and recorded in it, so that an existing index is always read
+	// and written with the same codec.
+	recorded, err := index.Codec(indexDB, storage.New(zbor.NewCodec(), storage.WithNamespace(flagNamespace)))
+	if err != nil {
+		log.Error().Err(err).Msg("could not get codec of index")
+		return failure
+	}
+	name := flagCodec
+	if name == "" {
+		name = recorded
+	}
+	if name == "" {
+		name = dps.CodecZbor
+	}
+	if recorded != "" && name != recorded {
+		log.Error().Str("codec", name).Str("recorded", recorded).Msg("index was created with another codec, please use the recorded codec (--codec)")
+		return failure
+	}
+
 	// The storage library is initialized with a codec and provides functions to
 	// interact with a Badger database while encoding and compressing
 	// transparently.
-	codec := zbor.NewCodec()
-	storage := storage.New(codec)
+	codec, err := codec.New(name,
+		zbor.WithDeterministic(flagCodecDeterministic),
+		zbor.WithValidation(flagCodecValidate),
+	)
+	if err != nil {
+		log.Error().Str("codec", name).Err(err).Msg("could not create codec")
+		return failure
+	}
+	storageOpts, err := storage.CompressionOptions(flagCompression)
+	if err != nil {
+		log.Error().Err(err).Msg("could not parse compression policy")
+		return failure
+	}
+	storageOpts = append(storageOpts,
+		storage.WithNamespace(flagNamespace),
+		storage.WithCompressionStats(flagCompressionStats),
+		storage.WithSizeStats(flagSizeStats),
+		storage.WithManifests(flagManifests),
+	)
+	storage := storage.New(codec, storageOpts...)
+
+	// The running size statistics continue from the totals that were persisted
+	// the last time the index was written to.
+	err = indexDB.View(storage.LoadSizeStats())
+	if err != nil {
+		log.Error().Err(err).Msg("could not load size statistics")
+		return failure
+	}
 
 	// Check if index already exists.
-	read := index.NewReader(indexDB, storage)
-	first, err := read.First()
-	empty := errors.Is(err, badger.ErrKeyNotFound)
+	ctx := context.Background()
+	read := index.NewReader(indexDB, storage, index.WithPathFilters(flagPathFilters))
+	first, err := read.First(ctx)
+	empty := errors.Is(err, dps.ErrBootstrapping)
 	if err != nil && !empty {
 		log.Error().Err(err).Msg("could not get first height from index reader")
 		return failure
@@ -128,22 +275,95 @@ func run() int {
 		return failure
 	}
 
+	// If indexing is restricted to the data of some accounts, the index is
+	// partial and it has to keep being restricted with the same filter.
+	var filter dps.Filter
+	for _, owner := range flagOwners {
+		filter.Allowed = append(filter.Allowed, flow.HexToAddress(owner))
+	}
+	for _, owner := range flagDeny {
+		filter.Denied = append(filter.Denied, flow.HexToAddress(owner))
+	}
+	if !empty {
+		indexed, err := read.Filter(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("could not get filter from index reader")
+			return failure
+		}
+		if filter.Empty() {
+			filter = indexed
+		}
+		if !filter.Equal(indexed) {
+			log.Error().Strs("owners", flagOwners).Strs("deny", flagDeny).Msg("index was not restricted with the same filter, please use the indexed accounts (-o, --owners and --deny)")
+			return failure
+		}
+	}
+
+	// A partial index does not contain the registers needed to restore the
+	// execution state trie, so it is always rebuilt from the root checkpoint
+	// by replaying all heights since the root height.
+	partial := !empty && !filter.Empty()
+	if partial && flagCheckpoint == "" {
+		log.Error().Msg("index is partial, please provide root checkpoint (-c, --checkpoint) to resume")
+		return failure
+	}
+
 	// The chain is responsible for reading blockchain data from the protocol state.
 	disk := chain.FromDisk(protocolDB)
 
 	// Feeder is responsible for reading the write-ahead log of the execution state.
-	segments, err := wal.NewSegmentsReader(flagTrie)
+	// When following the write-ahead log of a running execution node, we use a
+	// reader that waits for new records and segments instead of stopping at the
+	// end of the segments that exist on startup.
+	var reader feeder.WALReader
+	if flagFollow {
+		tail, err := feeder.NewTailReader(log, flagTrie)
+		if err != nil {
+			log.Error().Str("trie", flagTrie).Err(err).Msg("could not open tail reader")
+			return failure
+		}
+		defer tail.Close()
+		reader = tail
+	} else {
+		segments, err := feeder.NewSegmentsReader(flagTrie)
+		if err != nil {
+			log.Error().Str("trie", flagTrie).Err(err).Msg("could not open segments reader")
+			return failure
+		}
+		defer segments.Close()
+		reader = segments
+	}
+	feed := feeder.FromWAL(reader)
+
+	// Fence the index so that no other instance writes to it concurrently.
+	hostname, _ := os.Hostname()
+	holder := fmt.Sprintf("%s:%d", hostname, os.Getpid())
+	token, err := index.AcquireFence(indexDB, storage, holder, flagTakeover)
+	if errors.Is(err, dps.ErrFenced) {
+		log.Error().Err(err).Msg("index is being written by another instance, please stop it first or take over its index if it is no longer running (--takeover)")
+		return failure
+	}
 	if err != nil {
-		log.Error().Str("trie", flagTrie).Err(err).Msg("could not open segments reader")
+		log.Error().Err(err).Msg("could not acquire index fence")
 		return failure
 	}
-	feed := feeder.FromWAL(wal.NewReader(segments))
+
+	// A new index records the codec with which it is created.
+	if recorded == "" {
+		err = index.SaveCodec(indexDB, storage, name)
+		if err != nil {
+			log.Error().Err(err).Msg("could not save codec of index")
+			return failure
+		}
+	}
 
 	// Writer is responsible for writing the index data to the index database.
 	// We explicitly disable flushing at regular intervals to improve throughput
 	// of badger transactions when indexing from static on-disk data.
 	write := index.NewWriter(indexDB, storage,
 		index.WithFlushInterval(0),
+		index.WithPathFilters(flagPathFilters),
+		index.WithFence(token),
 	)
 	defer func() {
 		err := write.Close()
@@ -156,7 +376,7 @@ func run() int {
 	var load mapper.Loader
 	load = loader.FromIndex(log, storage, indexDB)
 	bootstrap := flagCheckpoint != ""
-	if empty {
+	if empty || partial {
 		file, err := os.Open(flagCheckpoint)
 		if err != nil {
 			log.Error().Err(err).Msg("could not open checkpoint file")
@@ -178,13 +398,46 @@ func run() int {
 		)
 	}
 
-	transitions := mapper.NewTransitions(log, load, disk, feed, read, write,
+	options := []mapper.Option{
 		mapper.WithBootstrapState(bootstrap),
 		mapper.WithSkipRegisters(flagSkip),
-	)
-	forest := forest.New()
+		mapper.WithRegisterDeltas(flagRegisterDeltas),
+		mapper.WithSkipCorrupted(flagCorruption == "skip"),
+		mapper.WithOwners(filter.Allowed...),
+		mapper.WithDenied(filter.Denied...),
+	}
+
+	// If enabled, the mapper checks the sequence of indexed heights for gaps
+	// when resuming, and repairs them from the protocol state database.
+	if flagAudit || flagRepair {
+		options = append(options, mapper.WithAuditor(read))
+	}
+	if flagRepair {
+		options = append(options, mapper.WithRepair(disk))
+	}
+
+	// If enabled, the mapper periodically emits checkpoints of the execution
+	// state trie, which other nodes can use to bootstrap near the tip.
+	var emitter *checkpoint.Emitter
+	if flagCheckpointInterval > 0 {
+		if flagCheckpointOutput == "" {
+			log.Error().Msg("no output for emitted checkpoints, please provide output (--checkpoint-output)")
+			return failure
+		}
+		sink, err := checkpoint.Open(flagCheckpointOutput)
+		if err != nil {
+			log.Error().Err(err).Str("output", flagCheckpointOutput).Msg("could not open checkpoint output")
+			return failure
+		}
+		emitter = checkpoint.NewEmitter(log, sink, checkpoint.WithInterval(flagCheckpointInterval))
+		options = append(options, mapper.WithEmitter(emitter))
+	}
+
+	transitions := mapper.NewTransitions(log, load, disk, feed, read, write, options...)
+	forest := forest.New(forest.WithLimit(flagForestLimit))
 	state := mapper.EmptyState(forest)
 	fsm := mapper.NewFSM(state,
+		mapper.WithMiddleware(mapper.Before(mapper.Throttle(write, 100*time.Millisecond), mapper.StatusMap, mapper.StatusForward)),
 		mapper.WithTransition(mapper.StatusInitialize, transitions.InitializeMapper),
 		mapper.WithTransition(mapper.StatusBootstrap, transitions.BootstrapState),
 		mapper.WithTransition(mapper.StatusResume, transitions.ResumeIndexing),
@@ -239,6 +492,9 @@ func run() int {
 		log.Error().Err(err).Msg("could not stop indexer")
 		return failure
 	}
+	if emitter != nil {
+		emitter.Wait()
+	}
 
 	return success
 }
@@ -0,0 +1,324 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/codec"
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/index"
+	"github.com/optakt/flow-dps/service/logs"
+	"github.com/optakt/flow-dps/service/storage"
+)
+
+const (
+	success = 0
+	failure = 1
+)
+
+const usage = `Usage: flow-dps-inspect [flags] <command> [arguments]
+
+Commands:
+  header <height>              dump the block header at the given height
+  events <height> [types...]   list the events at the given height, optionally filtered by type
+  payload <height> <path>      show the ledger payload for the hex-encoded path at the given height
+  stats                        print the number of keys and bytes for each key prefix
+  compression                  print the compression statistics recorded for each key prefix
+  sizes                        print the running size statistics recorded for each key prefix
+  corruptions                  list the heights at which corrupted write-ahead log data or execution records were skipped
+  verify <from> <to>           verify the integrity manifests of the heights in the given range
+  churn <from> <to> [limit]    list the owners and registers written the most in the given range
+
+Flags:
+`
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+
+	// Parse the command line arguments.
+	var (
+		flagIndex     string
+		flagLevel     string
+		flagNamespace string
+	)
+
+	pflag.StringVarP(&flagIndex, "index", "i", "index", "database directory for state index")
+	pflag.StringVarP(&flagLevel, "level", "l", "info", "log output level")
+	pflag.StringVarP(&flagNamespace, "namespace", "n", "", "namespace in the index database to inspect (default namespace when left empty)")
+
+	pflag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		pflag.PrintDefaults()
+	}
+
+	pflag.Parse()
+
+	// Initialize the logger.
+	zerolog.TimestampFunc = func() time.Time { return time.Now().UTC() }
+	log := zerolog.New(os.Stderr).With().Timestamp().Logger().Level(zerolog.DebugLevel)
+	level, err := zerolog.ParseLevel(flagLevel)
+	if err != nil {
+		log.Error().Str("level", flagLevel).Err(err).Msg("could not parse log level")
+		return failure
+	}
+	log = log.Level(level)
+
+	// We need at least the command to execute.
+	args := pflag.Args()
+	if len(args) == 0 {
+		pflag.Usage()
+		return failure
+	}
+	command, args := args[0], args[1:]
+
+	// Open the index database read-only, so that it can be inspected while
+	// it is being used by another process.
+	db, err := badger.Open(dps.ReadOnlyOptions(flagIndex))
+	if err != nil {
+		log.Error().Str("index", flagIndex).Err(err).Msg("could not open state index")
+		return failure
+	}
+	defer db.Close()
+
+	// Initialize the storage library, which decodes values with the codec that
+	// was recorded when the index was created.
+	name, err := index.Codec(db, storage.New(zbor.NewCodec(), storage.WithNamespace(flagNamespace)))
+	if err != nil {
+		log.Error().Err(err).Msg("could not get codec of index")
+		return failure
+	}
+	if name == "" {
+		name = dps.CodecZbor
+	}
+	codec, err := codec.New(name)
+	if err != nil {
+		log.Error().Str("codec", name).Err(err).Msg("could not create codec")
+		return failure
+	}
+	lib := storage.New(codec, storage.WithNamespace(flagNamespace))
+
+	exit := success
+	var output interface{}
+	switch command {
+
+	case "header":
+		if len(args) != 1 {
+			log.Error().Msg("header command needs a height argument")
+			return failure
+		}
+		height, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			log.Error().Str(logs.Height, args[0]).Err(err).Msg("could not parse height")
+			return failure
+		}
+		output, err = inspectHeader(db, lib, height)
+		if err != nil {
+			log.Error().Uint64(logs.Height, height).Err(err).Msg("could not inspect header")
+			return failure
+		}
+
+	case "events":
+		if len(args) < 1 {
+			log.Error().Msg("events command needs a height argument")
+			return failure
+		}
+		height, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			log.Error().Str(logs.Height, args[0]).Err(err).Msg("could not parse height")
+			return failure
+		}
+		types := make([]flow.EventType, 0, len(args)-1)
+		for _, typ := range args[1:] {
+			types = append(types, flow.EventType(typ))
+		}
+		output, err = inspectEvents(db, lib, height, types)
+		if err != nil {
+			log.Error().Uint64(logs.Height, height).Err(err).Msg("could not inspect events")
+			return failure
+		}
+
+	case "payload":
+		if len(args) != 2 {
+			log.Error().Msg("payload command needs a height and a path argument")
+			return failure
+		}
+		height, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			log.Error().Str(logs.Height, args[0]).Err(err).Msg("could not parse height")
+			return failure
+		}
+		data, err := hex.DecodeString(args[1])
+		if err != nil {
+			log.Error().Str("path", args[1]).Err(err).Msg("could not decode path")
+			return failure
+		}
+		path, err := ledger.ToPath(data)
+		if err != nil {
+			log.Error().Str("path", args[1]).Err(err).Msg("could not convert path")
+			return failure
+		}
+		output, err = inspectPayload(db, lib, height, path)
+		if err != nil {
+			log.Error().Uint64(logs.Height, height).Hex("path", path[:]).Err(err).Msg("could not inspect payload")
+			return failure
+		}
+
+	case "stats":
+		if len(args) != 0 {
+			log.Error().Msg("stats command does not take arguments")
+			return failure
+		}
+		output, err = inspectStats(db)
+		if err != nil {
+			log.Error().Err(err).Msg("could not inspect key-space statistics")
+			return failure
+		}
+
+	case "compression":
+		if len(args) != 0 {
+			log.Error().Msg("compression command does not take arguments")
+			return failure
+		}
+		output, err = inspectCompression(db, lib)
+		if err != nil {
+			log.Error().Err(err).Msg("could not inspect compression statistics")
+			return failure
+		}
+
+	case "sizes":
+		if len(args) != 0 {
+			log.Error().Msg("sizes command does not take arguments")
+			return failure
+		}
+		output, err = inspectSizes(db, lib)
+		if err != nil {
+			log.Error().Err(err).Msg("could not inspect size statistics")
+			return failure
+		}
+
+	case "corruptions":
+		if len(args) != 0 {
+			log.Error().Msg("corruptions command does not take arguments")
+			return failure
+		}
+		corruptions := make(map[uint64][]string)
+		err = db.View(lib.RetrieveCorruptions(context.Background(), corruptions))
+		if err != nil {
+			log.Error().Err(err).Msg("could not inspect corruptions")
+			return failure
+		}
+		output = corruptions
+
+	case "verify":
+		if len(args) != 2 {
+			log.Error().Msg("verify command needs a start and an end height argument")
+			return failure
+		}
+		from, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			log.Error().Str("from", args[0]).Err(err).Msg("could not parse start height")
+			return failure
+		}
+		to, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			log.Error().Str("to", args[1]).Err(err).Msg("could not parse end height")
+			return failure
+		}
+		if from > to {
+			log.Error().Uint64("from", from).Uint64("to", to).Msg("start height must not be above end height")
+			return failure
+		}
+		verification, err := inspectManifests(db, lib, from, to)
+		if err != nil {
+			log.Error().Uint64("from", from).Uint64("to", to).Err(err).Msg("could not verify manifests")
+			return failure
+		}
+		// We still print the mismatched heights, but fail, so that scripts
+		// can use the exit code to detect a corrupted index.
+		if len(verification.Mismatches) != 0 {
+			log.Error().Uints64("mismatches", verification.Mismatches).Msg("index data does not match integrity manifests")
+			exit = failure
+		}
+		output = verification
+
+	case "churn":
+		if len(args) != 2 && len(args) != 3 {
+			log.Error().Msg("churn command needs a start and an end height argument, and optionally a limit")
+			return failure
+		}
+		from, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			log.Error().Str("from", args[0]).Err(err).Msg("could not parse start height")
+			return failure
+		}
+		to, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			log.Error().Str("to", args[1]).Err(err).Msg("could not parse end height")
+			return failure
+		}
+		if from > to {
+			log.Error().Uint64("from", from).Uint64("to", to).Msg("start height must not be above end height")
+			return failure
+		}
+		limit := uint64(10)
+		if len(args) == 3 {
+			limit, err = strconv.ParseUint(args[2], 10, 32)
+			if err != nil {
+				log.Error().Str("limit", args[2]).Err(err).Msg("could not parse limit")
+				return failure
+			}
+		}
+		output, err = inspectChurn(db, lib, from, to, uint(limit))
+		if err != nil {
+			log.Error().Uint64("from", from).Uint64("to", to).Err(err).Msg("could not inspect register churn")
+			return failure
+		}
+
+	default:
+		log.Error().Str("command", command).Msg("unknown command")
+		pflag.Usage()
+		return failure
+	}
+
+	// All commands output a single JSON document on standard output, so that
+	// the output can be processed further with tools such as `jq`.
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(output)
+	if err != nil {
+		log.Error().Err(err).Msg("could not encode output")
+		return failure
+	}
+
+	return exit
+}
@@ -0,0 +1,208 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/index"
+)
+
+// Event is the printable representation of an indexed event. The event payload
+// is JSON-Cadence encoded, so we can embed it directly into the output.
+type Event struct {
+	Type             flow.EventType  `json:"type"`
+	TransactionID    flow.Identifier `json:"transaction_id"`
+	TransactionIndex uint32          `json:"transaction_index"`
+	EventIndex       uint32          `json:"event_index"`
+	Payload          json.RawMessage `json:"payload"`
+}
+
+// KeyPart is the printable representation of a part of a ledger key.
+type KeyPart struct {
+	Type  uint16 `json:"type"`
+	Value string `json:"value"`
+}
+
+// Payload is the printable representation of an indexed ledger payload.
+type Payload struct {
+	Path  string    `json:"path"`
+	Key   []KeyPart `json:"key"`
+	Value string    `json:"value"`
+}
+
+// Verification is the printable result of the verification of the integrity
+// manifests for a range of heights.
+type Verification struct {
+	From       uint64   `json:"from"`
+	To         uint64   `json:"to"`
+	Mismatches []uint64 `json:"mismatches"`
+}
+
+// Churn is the printable report of the register writes for a range of heights.
+type Churn struct {
+	From      uint64           `json:"from"`
+	To        uint64           `json:"to"`
+	Writes    uint64           `json:"writes"`
+	Owners    []OwnerWrites    `json:"owners"`
+	Registers []RegisterWrites `json:"registers"`
+}
+
+// OwnerWrites is the printable number of register writes for an owner.
+type OwnerWrites struct {
+	Owner  flow.Address `json:"owner"`
+	Writes uint64       `json:"writes"`
+}
+
+// RegisterWrites is the printable number of writes for a register. Keys that
+// are not valid UTF-8 are hex-encoded.
+type RegisterWrites struct {
+	Owner  flow.Address `json:"owner"`
+	Key    string       `json:"key"`
+	Writes uint64       `json:"writes"`
+}
+
+func inspectHeader(db *badger.DB, lib dps.ReadLibrary, height uint64) (*flow.Header, error) {
+
+	var header flow.Header
+	err := db.View(lib.RetrieveHeader(height, &header))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve header: %w", err)
+	}
+
+	return &header, nil
+}
+
+func inspectEvents(db *badger.DB, lib dps.ReadLibrary, height uint64, types []flow.EventType) ([]Event, error) {
+
+	var events []flow.Event
+	err := db.View(lib.RetrieveEvents(context.Background(), height, types, &events))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve events: %w", err)
+	}
+
+	// Events with a payload that is not valid JSON should not exist, but we
+	// still want to be able to inspect them, so we quote their payload.
+	output := make([]Event, 0, len(events))
+	for _, event := range events {
+		payload := json.RawMessage(event.Payload)
+		if !json.Valid(event.Payload) {
+			payload, _ = json.Marshal(string(event.Payload))
+		}
+		output = append(output, Event{
+			Type:             event.Type,
+			TransactionID:    event.TransactionID,
+			TransactionIndex: event.TransactionIndex,
+			EventIndex:       event.EventIndex,
+			Payload:          payload,
+		})
+	}
+
+	return output, nil
+}
+
+func inspectPayload(db *badger.DB, lib dps.ReadLibrary, height uint64, path ledger.Path) (*Payload, error) {
+
+	var payload ledger.Payload
+	err := db.View(lib.RetrievePayload(height, path, &payload))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve payload: %w", err)
+	}
+
+	parts := make([]KeyPart, 0, len(payload.Key.KeyParts))
+	for _, part := range payload.Key.KeyParts {
+		parts = append(parts, KeyPart{
+			Type:  part.Type,
+			Value: fmt.Sprintf("%x", part.Value),
+		})
+	}
+
+	output := Payload{
+		Path:  fmt.Sprintf("%x", path[:]),
+		Key:   parts,
+		Value: fmt.Sprintf("%x", []byte(payload.Value)),
+	}
+
+	return &output, nil
+}
+
+func inspectManifests(db *badger.DB, lib dps.ReadLibrary, from uint64, to uint64) (*Verification, error) {
+
+	mismatches, err := index.NewReader(db, lib).Verify(context.Background(), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("could not verify manifests: %w", err)
+	}
+
+	// We always want to print a list, even when there are no mismatches.
+	if mismatches == nil {
+		mismatches = []uint64{}
+	}
+
+	output := Verification{
+		From:       from,
+		To:         to,
+		Mismatches: mismatches,
+	}
+
+	return &output, nil
+}
+
+func inspectChurn(db *badger.DB, lib dps.ReadLibrary, from uint64, to uint64, limit uint) (*Churn, error) {
+
+	report, err := index.NewReader(db, lib).RegisterChurn(context.Background(), from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve register churn: %w", err)
+	}
+
+	owners := make([]OwnerWrites, 0, len(report.Owners))
+	for _, entry := range report.Owners {
+		owners = append(owners, OwnerWrites{
+			Owner:  entry.Owner,
+			Writes: entry.Writes,
+		})
+	}
+
+	registers := make([]RegisterWrites, 0, len(report.Registers))
+	for _, entry := range report.Registers {
+		key := entry.Key
+		if !utf8.ValidString(key) {
+			key = fmt.Sprintf("%x", key)
+		}
+		registers = append(registers, RegisterWrites{
+			Owner:  entry.Owner,
+			Key:    key,
+			Writes: entry.Writes,
+		})
+	}
+
+	output := Churn{
+		From:      from,
+		To:        to,
+		Writes:    report.Writes,
+		Owners:    owners,
+		Registers: registers,
+	}
+
+	return &output, nil
+}
@@ -0,0 +1,160 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/storage"
+)
+
+// Statistics contains the key-space statistics for a single key prefix.
+type Statistics struct {
+	Prefix     uint8  `json:"prefix"`
+	Name       string `json:"name"`
+	Keys       uint64 `json:"keys"`
+	KeyBytes   uint64 `json:"key_bytes"`
+	ValueBytes uint64 `json:"value_bytes"`
+}
+
+// Compression contains the compression statistics for a single key prefix.
+type Compression struct {
+	Prefix      uint8   `json:"prefix"`
+	Name        string  `json:"name"`
+	Values      uint64  `json:"values"`
+	RawBytes    uint64  `json:"raw_bytes"`
+	StoredBytes uint64  `json:"stored_bytes"`
+	Ratio       float64 `json:"ratio"`
+}
+
+func inspectCompression(db *badger.DB, lib *storage.Library) ([]Compression, error) {
+
+	var stats []dps.CompressionStats
+	err := db.View(lib.RetrieveCompressionStats(context.Background(), &stats))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve compression statistics: %w", err)
+	}
+
+	output := make([]Compression, 0, len(stats))
+	for _, entry := range stats {
+		name, ok := storage.PrefixNames[entry.Prefix]
+		if !ok {
+			name = "unknown"
+		}
+		output = append(output, Compression{
+			Prefix:      entry.Prefix,
+			Name:        name,
+			Values:      entry.Values,
+			RawBytes:    entry.RawBytes,
+			StoredBytes: entry.StoredBytes,
+			Ratio:       entry.Ratio(),
+		})
+	}
+
+	return output, nil
+}
+
+// Size contains the running size statistics for a single key prefix.
+type Size struct {
+	Prefix     uint8  `json:"prefix"`
+	Name       string `json:"name"`
+	Keys       uint64 `json:"keys"`
+	KeyBytes   uint64 `json:"key_bytes"`
+	ValueBytes uint64 `json:"value_bytes"`
+}
+
+func inspectSizes(db *badger.DB, lib *storage.Library) ([]Size, error) {
+
+	var stats []dps.SizeStats
+	err := db.View(lib.RetrieveSizeStats(context.Background(), &stats))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve size statistics: %w", err)
+	}
+
+	output := make([]Size, 0, len(stats))
+	for _, entry := range stats {
+		name, ok := storage.PrefixNames[entry.Prefix]
+		if !ok {
+			name = "unknown"
+		}
+		output = append(output, Size{
+			Prefix:     entry.Prefix,
+			Name:       name,
+			Keys:       entry.Keys,
+			KeyBytes:   entry.KeyBytes,
+			ValueBytes: entry.ValueBytes,
+		})
+	}
+
+	return output, nil
+}
+
+func inspectStats(db *badger.DB) ([]Statistics, error) {
+
+	// We only need the keys and the size of the values, which is stored along
+	// with the keys, so we do not need to prefetch the values.
+	opts := badger.DefaultIteratorOptions
+	opts.PrefetchValues = false
+
+	lookup := make(map[uint8]*Statistics)
+	err := db.View(func(tx *badger.Txn) error {
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			key := item.Key()
+			if len(key) == 0 {
+				continue
+			}
+
+			prefix := key[0]
+			stats, ok := lookup[prefix]
+			if !ok {
+				name, ok := storage.PrefixNames[prefix]
+				if !ok {
+					name = "unknown"
+				}
+				stats = &Statistics{Prefix: prefix, Name: name}
+				lookup[prefix] = stats
+			}
+
+			stats.Keys++
+			stats.KeyBytes += uint64(len(key))
+			stats.ValueBytes += uint64(item.ValueSize())
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not iterate keys: %w", err)
+	}
+
+	output := make([]Statistics, 0, len(lookup))
+	for _, stats := range lookup {
+		output = append(output, *stats)
+	}
+	sort.Slice(output, func(i, j int) bool {
+		return output[i].Prefix < output[j].Prefix
+	})
+
+	return output, nil
+}
@@ -15,46 +15,46 @@
 package main
 
 import (
-	"context"
-	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"math"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"runtime"
-	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
-	gcloud "cloud.google.com/go/storage"
-	"github.com/dgraph-io/badger/v2"
 	grpczerolog "github.com/grpc-ecosystem/go-grpc-middleware/providers/zerolog/v2"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/tags"
 	"github.com/rs/zerolog"
 	"github.com/spf13/pflag"
-	"google.golang.org/api/option"
+	"golang.org/x/net/netutil"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 
-	sdk "github.com/onflow/flow-go-sdk/crypto"
-	"github.com/onflow/flow-go/cmd/bootstrap/utils"
-	"github.com/onflow/flow-go/crypto"
-	unstaked "github.com/onflow/flow-go/follower"
-	"github.com/onflow/flow-go/model/bootstrap"
+	"github.com/onflow/flow-go/model/flow"
 
 	api "github.com/optakt/flow-dps/api/dps"
 	"github.com/optakt/flow-dps/codec/zbor"
 	"github.com/optakt/flow-dps/models/dps"
-	"github.com/optakt/flow-dps/service/cloud"
-	"github.com/optakt/flow-dps/service/forest"
-	"github.com/optakt/flow-dps/service/index"
-	"github.com/optakt/flow-dps/service/initializer"
-	"github.com/optakt/flow-dps/service/loader"
+	"github.com/optakt/flow-dps/service/admin"
+	"github.com/optakt/flow-dps/service/artifacts"
+	"github.com/optakt/flow-dps/service/checkpoint"
+	"github.com/optakt/flow-dps/service/disk"
+	"github.com/optakt/flow-dps/service/live"
+	"github.com/optakt/flow-dps/service/logs"
 	"github.com/optakt/flow-dps/service/mapper"
 	"github.com/optakt/flow-dps/service/metrics"
-	"github.com/optakt/flow-dps/service/storage"
-	"github.com/optakt/flow-dps/service/tracker"
+	"github.com/optakt/flow-dps/service/queries"
+	"github.com/optakt/flow-dps/service/settings"
+	"github.com/optakt/flow-dps/service/systemd"
+	"github.com/optakt/flow-dps/service/tier"
+	"github.com/optakt/flow-dps/service/watchdog"
 )
 
 const (
@@ -70,38 +70,178 @@ func run() int {
 
 	// Signal catching for clean shutdown.
 	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, os.Interrupt)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+
+	// Signal catching for reloading the settings that can be changed at runtime.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
 
 	// Command line parameter initialization.
 	var (
-		flagAddress    string
-		flagBootstrap  string
-		flagBucket     string
-		flagCheckpoint string
-		flagData       string
-		flagIndex      string
-		flagLevel      string
-		flagMetrics    string
-		flagSkip       bool
-
-		flagFlushInterval time.Duration
-		flagSeedAddress   string
-		flagSeedKey       string
+		flagAddress      string
+		flagBootstrap    string
+		flagBuckets      []string
+		flagCheckpoint   string
+		flagData         string
+		flagIndex        string
+		flagLevel        string
+		flagLogFormat    string
+		flagLogOverrides map[string]string
+		flagManifests    bool
+		flagMetrics      string
+		flagNamespace    string
+		flagOwners       []string
+		flagProtocol     bool
+		flagRecent       uint
+		flagSkip         bool
+
+		flagAdmin                string
+		flagArchiveRecords       bool
+		flagArtifacts            string
+		flagArtifactsCert        string
+		flagArtifactsDirs        map[string]string
+		flagArtifactsKey         string
+		flagAudit                bool
+		flagCheckpointInterval   uint64
+		flagCheckpointObject     string
+		flagCheckpointOutput     string
+		flagCheckpointSHA256     string
+		flagCheckpointURL        string
+		flagCodec                string
+		flagCodecDeterministic   bool
+		flagCodecValidate        bool
+		flagColdAge              uint64
+		flagColdCache            uint64
+		flagColdEndpoint         string
+		flagColdRegion           string
+		flagColdURL              string
+		flagCompression          map[string]string
+		flagCompressionStats     bool
+		flagContendedWrites      uint
+		flagCorruption           string
+		flagDiskData             uint64
+		flagDiskIndex            uint64
+		flagDeny                 []string
+		flagDrainTimeout         time.Duration
+		flagFlushInterval        time.Duration
+		flagFollowerTimeout      time.Duration
+		flagForestLimit          uint
+		flagGRPCKeepaliveMinTime time.Duration
+		flagGRPCKeepalivePermit  bool
+		flagGRPCMaxConnections   int
+		flagGRPCMaxRecvSize      int
+		flagGRPCMaxSendSize      int
+		flagGRPCMaxStreams       uint32
+		flagJournal              string
+		flagQuarantineAfter      uint
+		flagQueryLimit           time.Duration
+		flagQuerySlow            time.Duration
+		flagQueryStats           uint
+		flagPathFilters          bool
+		flagRecordLayout         string
+		flagRecordPeer           string
+		flagRecordTopic          string
+		flagRegisterDeltas       bool
+		flagRepair               bool
+		flagReplicaEndpoint      string
+		flagReplicaMaxLag        uint64
+		flagReplicaRegion        string
+		flagReplicaURL           string
+		flagSeedAddress          string
+		flagSeedKey              string
+		flagSeedRetry            time.Duration
+		flagSettings             string
+		flagSizeStats            bool
+		flagSpeculative          bool
+		flagStandby              bool
+		flagTakeover             bool
+		flagVerifyPayloads       bool
+		flagWatchdogGoroutines   uint
+		flagWatchdogMemory       uint64
+		flagWatchdogRetain       uint
 	)
 
 	pflag.StringVarP(&flagAddress, "address", "a", "127.0.0.1:5005", "bind address for serving DPS API")
 	pflag.StringVarP(&flagBootstrap, "bootstrap", "b", "bootstrap", "path to directory with bootstrap information for spork")
-	pflag.StringVarP(&flagBucket, "bucket", "u", "", "Google Cloude Storage bucket with block data records")
+	pflag.StringSliceVarP(&flagBuckets, "bucket", "u", nil, "Google Cloud Storage buckets with block data records, in order of preference")
 	pflag.StringVarP(&flagCheckpoint, "checkpoint", "c", "", "path to root checkpoint file for execution state trie")
 	pflag.StringVarP(&flagData, "data", "d", "data", "path to database directory for protocol data")
 	pflag.StringVarP(&flagIndex, "index", "i", "index", "path to database directory for state index")
 	pflag.StringVarP(&flagLevel, "level", "l", "info", "log output level")
+	pflag.StringVar(&flagLogFormat, "log-format", "json", "format of the log output (json or console)")
+	pflag.StringToStringVar(&flagLogOverrides, "log-level-overrides", nil, "log output level per component (e.g. mapper=debug,gcp_streamer=warn)")
 	pflag.StringVarP(&flagMetrics, "metrics", "m", "", "address on which to expose metrics (no metrics are exposed when left empty)")
+	pflag.StringVarP(&flagNamespace, "namespace", "n", "", "namespace in the index database for the indexed data (default namespace when left empty)")
+	pflag.StringSliceVarP(&flagOwners, "owners", "o", nil, "addresses of the accounts whose data is indexed (all accounts when left empty)")
+	pflag.BoolVarP(&flagProtocol, "protocol-only", "p", false, "index only protocol state data, without requiring execution data")
+	pflag.UintVarP(&flagRecent, "recent", "r", 0, "number of most recent heights for which register values are served from memory (0 for disabled)")
 	pflag.BoolVarP(&flagSkip, "skip", "s", false, "skip indexing of execution state ledger registers")
 
+	pflag.StringVar(&flagAdmin, "admin", "", "address on which to expose the admin API (no admin API is exposed when left empty)")
+	pflag.BoolVar(&flagArchiveRecords, "archive-records", false, "archive downloaded execution records in their original encoding in the index")
+	pflag.StringVar(&flagArtifacts, "artifacts", "", "address on which to serve generated artifacts, such as checkpoints and snapshots, for download (no artifacts are served when left empty)")
+	pflag.StringVar(&flagArtifactsCert, "artifacts-cert", "", "path to TLS certificate file for serving artifacts over HTTPS (served over HTTP when left empty)")
+	pflag.StringToStringVar(&flagArtifactsDirs, "artifacts-dirs", nil, "additional directories of served artifacts by name, such as snapshots=/var/snapshots")
+	pflag.StringVar(&flagArtifactsKey, "artifacts-key", "", "path to TLS private key file for serving artifacts over HTTPS")
+	pflag.BoolVar(&flagAudit, "audit", false, "check the sequence of indexed heights for gaps when resuming and refuse to start if any are found")
+	pflag.Uint64Var(&flagCheckpointInterval, "checkpoint-interval", 0, "number of heights between emitted checkpoints of the execution state trie (0 for disabled)")
+	pflag.StringVar(&flagCheckpointObject, "checkpoint-object", "", "name of root checkpoint object in bucket to download when index is empty and no checkpoint is given")
+	pflag.StringVar(&flagCheckpointOutput, "checkpoint-output", "", "directory or bucket, such as gs://bucket/prefix, to which checkpoints of the execution state trie are emitted")
+	pflag.StringVar(&flagCheckpointSHA256, "checkpoint-sha256", "", "hex-encoded SHA-256 checksum of downloaded root checkpoint (no validation when left empty)")
+	pflag.StringVar(&flagCheckpointURL, "checkpoint-url", "", "HTTP URL of root checkpoint to download when index is empty and no checkpoint is given")
+	pflag.StringVar(&flagCodec, "codec", "", "codec with which the values of a new index are stored (zbor or protostore, recorded codec or zbor when left empty)")
+	pflag.BoolVar(&flagCodecDeterministic, "codec-deterministic", false, "encode stored values with deterministic CBOR encoding and reject values with non-deterministic encodings when decoding")
+	pflag.BoolVar(&flagCodecValidate, "codec-validate", false, "decode each stored value again right after encoding it and fail on lossy encodings (for debugging)")
+	pflag.Uint64Var(&flagColdAge, "cold-age", 0, "number of heights after which replaced register payloads are moved to the cold tier (0 for disabled)")
+	pflag.Uint64Var(&flagColdCache, "cold-cache", 100_000_000, "size in bytes of the cache for payloads read from the cold tier")
+	pflag.StringVar(&flagColdEndpoint, "cold-endpoint", "https://s3.amazonaws.com", "endpoint of the S3-compatible object storage of the cold tier")
+	pflag.StringVar(&flagColdRegion, "cold-region", "us-east-1", "region of the S3-compatible object storage of the cold tier")
+	pflag.StringVar(&flagColdURL, "cold-url", "", "bucket of the cold tier, such as s3://bucket/prefix or gs://bucket/prefix (no cold tier when left empty)")
+	pflag.StringToStringVar(&flagCompression, "compression", nil, "compression per resource, such as events=best,payload=none (none, fastest, default, better or best)")
+	pflag.BoolVar(&flagCompressionStats, "compression-stats", false, "record compression statistics for the stored values")
+	pflag.UintVar(&flagContendedWrites, "contended-writes", 0, "maximum number of index transactions committed concurrently while API reads are in progress (0 for unlimited)")
+	pflag.StringVar(&flagCorruption, "corruption", "halt", "policy for quarantined execution records (halt or report)")
+	pflag.BoolVar(&flagSizeStats, "size-stats", false, "keep running size statistics of the stored keys and values per data category")
+	pflag.BoolVar(&flagManifests, "manifests", false, "record per-height integrity manifests of the indexed data")
+	pflag.StringSliceVar(&flagDeny, "deny", nil, "addresses of the accounts whose data is excluded from indexing")
+	pflag.Uint64Var(&flagDiskData, "disk-data", 0, "free space in bytes on the protocol database volume below which ingestion is paused (0 for disabled)")
+	pflag.Uint64Var(&flagDiskIndex, "disk-index", 0, "free space in bytes on the index database volume below which ingestion is paused (0 for disabled)")
 	pflag.DurationVar(&flagFlushInterval, "flush-interval", 1*time.Second, "interval for flushing badger transactions (0s for disabled)")
+	pflag.DurationVar(&flagDrainTimeout, "drain-timeout", 30*time.Second, "maximum duration to wait for in-flight API requests to finish on shutdown before cancelling them (0 for unlimited)")
+	pflag.DurationVar(&flagFollowerTimeout, "follower-timeout", 0, "duration without finalized blocks after which the consensus follower is reconnected to the seed node (0s for disabled)")
+	pflag.UintVar(&flagForestLimit, "forest-limit", 0, "maximum number of execution state tries kept in memory while waiting for sealed blocks (0 for unlimited)")
+	pflag.DurationVar(&flagGRPCKeepaliveMinTime, "grpc-keepalive-min-time", 5*time.Minute, "minimum interval between keepalive pings of clients, which are disconnected when they ping more often")
+	pflag.BoolVar(&flagGRPCKeepalivePermit, "grpc-keepalive-permit", false, "allow clients to send keepalive pings when they have no active streams")
+	pflag.IntVar(&flagGRPCMaxConnections, "grpc-max-connections", 0, "maximum number of simultaneous client connections to the GRPC API (0 for unlimited)")
+	pflag.IntVar(&flagGRPCMaxRecvSize, "grpc-max-recv-size", 4*1024*1024, "maximum size in bytes of messages received by the GRPC API")
+	pflag.IntVar(&flagGRPCMaxSendSize, "grpc-max-send-size", math.MaxInt32, "maximum size in bytes of messages sent by the GRPC API")
+	pflag.Uint32Var(&flagGRPCMaxStreams, "grpc-max-streams", 0, "maximum number of concurrent streams per client connection to the GRPC API (0 for unlimited)")
+	pflag.StringVar(&flagJournal, "journal", "", "path to file in which every finalized block delivered by the consensus follower is recorded (no journal when left empty)")
+	pflag.BoolVar(&flagPathFilters, "path-filters", false, "maintain bloom filters of written register paths to speed up lookups of missing registers")
+	pflag.UintVar(&flagQuarantineAfter, "quarantine-after", 3, "number of attempts after which an execution record that fails decoding or validation is quarantined (0 for disabled)")
+	pflag.DurationVar(&flagQueryLimit, "query-limit", 0, "maximum execution time of API requests (0s for unlimited)")
+	pflag.DurationVar(&flagQuerySlow, "query-slow", 0, "execution time above which API requests are logged as slow queries (0s for disabled)")
+	pflag.UintVar(&flagQueryStats, "query-stats", 10_000, "number of most recent API requests for which query statistics are served on the admin API (0 for disabled)")
+	pflag.StringVar(&flagRecordLayout, "record-layout", "", "layout of execution record object names in bucket, with {block} and {height} placeholders (detected when left empty)")
+	pflag.StringVar(&flagRecordPeer, "record-peer", "", "multiaddress of execution node publishing execution records (downloads from bucket when left empty)")
+	pflag.StringVar(&flagRecordTopic, "record-topic", "execution-records", "pub/sub topic on which execution records are published")
+	pflag.BoolVar(&flagRegisterDeltas, "register-deltas", false, "record the hashes of the registers written by each block before and after the block")
+	pflag.BoolVar(&flagRepair, "repair", false, "repair gaps in the sequence of indexed heights from the block data records when resuming (implies --audit)")
+	pflag.StringVar(&flagReplicaEndpoint, "replica-endpoint", "https://s3.amazonaws.com", "endpoint of the S3-compatible object storage of the secondary index")
+	pflag.Uint64Var(&flagReplicaMaxLag, "replica-max-lag", 1000, "number of heights by which the secondary index can lag behind before an alarm is raised (0 for disabled)")
+	pflag.StringVar(&flagReplicaRegion, "replica-region", "us-east-1", "region of the S3-compatible object storage of the secondary index")
+	pflag.StringVar(&flagReplicaURL, "replica-url", "", "bucket to which index writes are replicated, such as s3://bucket/prefix or gs://bucket/prefix (no replication when left empty)")
 	pflag.StringVar(&flagSeedAddress, "seed-address", "", "host address of seed node to follow consensus")
 	pflag.StringVar(&flagSeedKey, "seed-key", "", "hex-encoded public network key of seed node to follow consensus")
+	pflag.DurationVar(&flagSeedRetry, "seed-retry", 0, "interval at which to retry reaching the seed node, starting from local data while it is unreachable (0s to exit when it is unreachable)")
+	pflag.StringVar(&flagSettings, "settings", "", "path to JSON file with settings that are applied on startup and reloaded on SIGHUP, overriding the corresponding flags")
+	pflag.BoolVar(&flagSpeculative, "speculative", false, "stage heights until their execution results are sealed and roll them back if they are sealed with conflicting results")
+	pflag.BoolVar(&flagStandby, "standby", false, "follow consensus without writing to the index until promoted through the admin API")
+	pflag.BoolVar(&flagTakeover, "takeover", false, "take over the index from another instance that stopped without releasing it")
+	pflag.BoolVar(&flagVerifyPayloads, "verify-payloads", false, "verify that the key of each register payload read from the index matches its path")
+	pflag.UintVar(&flagWatchdogGoroutines, "watchdog-goroutines", 0, "number of goroutines above which heap and goroutine profiles are captured into the data directory (0 for disabled)")
+	pflag.Uint64Var(&flagWatchdogMemory, "watchdog-memory", 0, "resident memory in bytes above which heap and goroutine profiles are captured into the data directory (0 for disabled)")
+	pflag.UintVar(&flagWatchdogRetain, "watchdog-retain", 10, "number of most recent profile captures kept in the data directory (0 for unlimited)")
 
 	pflag.Parse()
 
@@ -112,318 +252,402 @@ func run() int {
 	// Logger initialization.
 	zerolog.TimestampFunc = func() time.Time { return time.Now().UTC() }
 	log := zerolog.New(os.Stderr).With().Timestamp().Logger().Level(zerolog.DebugLevel)
-	level, err := zerolog.ParseLevel(flagLevel)
+	out, err := logs.Output(flagLogFormat, os.Stderr)
 	if err != nil {
-		log.Error().Str("level", flagLevel).Err(err).Msg("could not parse log level")
+		log.Error().Str("format", flagLogFormat).Err(err).Msg("could not initialize log output")
 		return failure
 	}
-	log = log.Level(level)
-
-	// As a first step, we will open the protocol state and the index database.
-	// The protocol state database is what the consensus follower will write to
-	// and the mapper will read from. The index database is what the mapper will
-	// write to and the DPS API will read from.
-	indexDB, err := badger.Open(dps.DefaultOptions(flagIndex))
+	overrides, err := logs.ParseOverrides(flagLogOverrides)
 	if err != nil {
-		log.Error().Str("index", flagIndex).Err(err).Msg("could not open index database")
+		log.Error().Err(err).Msg("could not parse log level overrides")
 		return failure
 	}
-	defer func() {
-		err := indexDB.Close()
-		if err != nil {
-			log.Error().Err(err).Msg("could not close index database")
-		}
-	}()
-	protocolDB, err := badger.Open(dps.DefaultOptions(flagData))
+	levels := logs.NewFilter(out, overrides)
+	log = log.Output(levels)
+
+	// The log level and the query limits can be changed while the indexer runs,
+	// from the settings file or through the admin API, so that we don't have to
+	// restart it and drop the peer connections of the consensus follower. The
+	// log level is thus applied by the log filter, rather than by the logger.
+	initial := dps.Settings{
+		Level:      flagLevel,
+		QueryLimit: flagQueryLimit.String(),
+		QuerySlow:  flagQuerySlow.String(),
+	}
+	reloader, err := settings.NewReloader(log, levels, initial, flagSettings)
 	if err != nil {
-		log.Error().Err(err).Msg("could not open protocol state database")
+		log.Error().Err(err).Msg("could not initialize settings")
 		return failure
 	}
-	defer func() {
-		err := protocolDB.Close()
+	if flagSettings != "" {
+		err = reloader.Reload()
 		if err != nil {
-			log.Error().Err(err).Msg("could not close protocol state database")
+			log.Error().Err(err).Str("settings", flagSettings).Msg("could not load settings")
+			return failure
 		}
-	}()
+	}
 
-	// Next, we initialize the index reader and writer. They use a common codec
-	// and storage library to interact with the underlying database. If there
-	// already is an index database, we need the force flag to be set, as we do
-	// not want to start overwriting data in the index silently. We also need
-	// to flush the writer to make sure all data is written correctly when
-	// shutting down.
-	codec := zbor.NewCodec()
-	storage := storage.New(codec)
-	read := index.NewReader(indexDB, storage)
-	first, err := read.First()
-	if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
-		log.Error().Err(err).Msg("could not get first height from index reader")
+	// In speculative mode, the indexed heights are checked against the state
+	// commitments of their seals, which are not indexed without execution data.
+	if flagSpeculative && flagProtocol {
+		log.Error().Msg("speculative mode requires execution data, please disable protocol-only mode (-p, --protocol-only)")
 		return failure
 	}
-	empty := errors.Is(err, badger.ErrKeyNotFound)
-	if empty && flagCheckpoint == "" {
-		log.Error().Msg("index database is empty, please provide root checkpoint (-c, --checkpoint) to bootstrap")
+	if flagCheckpointInterval > 0 && !flagProtocol && flagCheckpointOutput == "" {
+		log.Error().Msg("no output for emitted checkpoints, please provide output (--checkpoint-output)")
 		return failure
 	}
-
-	// We initialize the writer with a flush interval, which will make sure that
-	// Badger transactions are committed to the database, even if they don't
-	// fill up fast enough. This avoids having latency between when we add data
-	// to the transaction and when it becomes available on-disk for serving the
-	// DPS API.
-	write := index.NewWriter(
-		indexDB,
-		storage,
-		index.WithFlushInterval(flagFlushInterval),
-	)
-
-	defer func() {
-		err := write.Close()
-		if err != nil {
-			log.Error().Err(err).Msg("could not close index writer")
-		}
-	}()
-
-	// Next, we want to initialize the consensus follower. One needed parameter
-	// is a network key, used to secure the peer-to-peer communication. However,
-	// as we do not need any specific key, we choose to just initialize a new
-	// key on each start of the live indexer.
-	seed := make([]byte, crypto.KeyGenSeedMinLenECDSASecp256k1)
-	n, err := rand.Read(seed)
-	if err != nil || n != crypto.KeyGenSeedMinLenECDSASecp256k1 {
-		log.Error().Err(err).Msg("could not generate private key seed")
+	if flagCorruption != "halt" && flagCorruption != "report" {
+		log.Error().Str("corruption", flagCorruption).Msg("invalid corruption policy, please use halt or report (--corruption)")
 		return failure
 	}
-	privKey, err := utils.GenerateUnstakedNetworkingKey(seed)
-	if err != nil {
-		log.Error().Err(err).Msg("could not generate private network key")
+	if !flagProtocol && flagRecordPeer == "" && len(flagBuckets) == 0 {
+		log.Error().Msg("no bucket to download execution records from, please provide bucket (-u, --bucket) or record peer (--record-peer)")
 		return failure
 	}
-
-	// Here, we finally initialize the unstaked consensus follower. It connects
-	// to a staked access node for bootstrapping the peer-to-peer network, which
-	// is shared between staked access nodes and unstaked consensus followers.
-	// For every finalized block, it calls the callback for all registered
-	// finalization listeners.
-	seedHost, port, err := net.SplitHostPort(flagSeedAddress)
+	checksum, err := hex.DecodeString(flagCheckpointSHA256)
 	if err != nil {
-		log.Error().Err(err).Str("address", flagSeedAddress).Msg("could not parse seed node address")
+		log.Error().Err(err).Str("checksum", flagCheckpointSHA256).Msg("could not decode checkpoint checksum")
 		return failure
 	}
-	seedPort, err := strconv.ParseUint(port, 10, 16)
-	if err != nil {
-		log.Error().Err(err).Str("port", port).Msg("could not parse seed node port")
+
+	// In standby mode, we only follow consensus until the indexer is promoted
+	// through the admin API, so that it can quickly take over the index when
+	// the active instance fails. Until then, the index database is not opened,
+	// as it is still being written to by the active instance. Blocks finalized
+	// in the meantime are caught up on once the indexer is promoted.
+	if flagStandby && flagAdmin == "" {
+		log.Error().Msg("standby mode requires the admin API, please provide admin address (--admin)")
 		return failure
 	}
-	seedKey, err := sdk.DecodePublicKeyHex(sdk.ECDSA_P256, flagSeedKey)
-	if err != nil {
-		log.Error().Err(err).Str("key", flagSeedKey).Msg("could not parse seed node network public key")
-		return failure
+	// The query recorder keeps track of the most recent API requests, so that
+	// the admin API can serve statistics about them.
+	recorder := queries.NewRecorder(flagQueryStats)
+
+	// The mapper monitor keeps track of the progress of the mapper, so that it
+	// can be inspected through the metrics and the admin API.
+	var monitorOpts []func(*mapper.Monitor)
+	if flagMetrics != "" {
+		monitorOpts = append(monitorOpts, mapper.WithMetrics(mapper.NewMetrics()))
 	}
-	seedNodes := []unstaked.BootstrapNodeInfo{{
-		Host:             seedHost,
-		Port:             uint(seedPort),
-		NetworkPublicKey: seedKey,
-	}}
-	follow, err := unstaked.NewConsensusFollower(
-		privKey,
-		"0.0.0.0:0", // automatically choose port, listen on all IPs
-		seedNodes,
-		unstaked.WithBootstrapDir(flagBootstrap),
-		unstaked.WithDB(protocolDB),
-		unstaked.WithLogLevel(flagLevel),
-	)
-	if err != nil {
-		log.Error().Err(err).Str("bucket", flagBucket).Msg("could not create consensus follower")
-		return failure
+	monitor := mapper.NewMonitor(monitorOpts...)
+
+	// The disk monitor keeps track of the free space on the volumes of both
+	// databases, so that ingestion can be paused before Badger runs out of
+	// space, while the API keeps serving the data that was already indexed.
+	var volumes []disk.Volume
+	if flagDiskData > 0 {
+		volumes = append(volumes, disk.Volume{Name: "data", Path: flagData, Threshold: flagDiskData})
+	}
+	if flagDiskIndex > 0 {
+		volumes = append(volumes, disk.Volume{Name: "index", Path: flagIndex, Threshold: flagDiskIndex})
+	}
+	var space *disk.Monitor
+	if len(volumes) > 0 {
+		space = disk.NewMonitor(log, volumes)
 	}
 
-	// There is a problem with the Flow consensus follower API which makes it
-	// impossible to use it to bootstrap the protocol state. The consensus
-	// follower will only bootstrap it when it's starting. This makes it
-	// impossible to initialize our consensus tracker, which needs a valid
-	// protocol state, and to add it to the consensus follower for block
-	// finalization, without missing some blocks. As a work-around, we manually
-	// bootstrap the Flow protocol state using the bootstrap data here.
-	path := filepath.Join(flagBootstrap, bootstrap.PathRootProtocolStateSnapshot)
-	file, err := os.Open(path)
-	if err != nil {
-		log.Error().Err(err).Str("path", path).Msg("could not open protocol state snapshot")
-		return failure
+	// If enabled, the artifacts server lets other operators download the
+	// checkpoints emitted to a local directory, as well as the files of any
+	// additional directories, to bootstrap their own nodes.
+	if flagArtifacts != "" {
+		if (flagArtifactsCert == "") != (flagArtifactsKey == "") {
+			log.Error().Msg("incomplete TLS configuration for artifacts, please provide both certificate and key (--artifacts-cert and --artifacts-key)")
+			return failure
+		}
+		var dirs []artifacts.Directory
+		if flagCheckpointOutput != "" && !strings.HasPrefix(flagCheckpointOutput, checkpoint.SchemeGCS+"://") {
+			dirs = append(dirs, artifacts.Directory{Name: "checkpoints", Path: flagCheckpointOutput})
+		}
+		for name, path := range flagArtifactsDirs {
+			dirs = append(dirs, artifacts.Directory{Name: name, Path: path})
+		}
+		var opts []artifacts.Option
+		if flagArtifactsCert != "" {
+			opts = append(opts, artifacts.WithTLS(flagArtifactsCert, flagArtifactsKey))
+		}
+		files := artifacts.NewServer(log, flagArtifacts, dirs, opts...)
+		go func() {
+			log.Info().Msg("artifacts server starting")
+			err := files.Start()
+			if err != nil {
+				log.Warn().Err(err).Msg("artifacts server failed")
+			}
+			log.Info().Msg("artifacts server stopped")
+		}()
+		defer func() {
+			err := files.Stop()
+			if err != nil {
+				log.Error().Err(err).Msg("could not stop artifacts server")
+			}
+		}()
 	}
-	defer file.Close()
-	err = initializer.ProtocolState(file, protocolDB)
-	if err != nil {
-		log.Error().Err(err).Msg("could not initialize protocol state")
-		return failure
+
+	// The live node does the actual indexing. It follows consensus, indexes the
+	// execution records of finalized blocks into the index database, and
+	// provides the reader on top of which we serve the DPS API.
+	cfg := live.DefaultConfig
+	cfg.DataDir = flagData
+	cfg.BootstrapDir = flagBootstrap
+	cfg.SeedAddress = flagSeedAddress
+	cfg.SeedKey = flagSeedKey
+	cfg.SeedRetry = flagSeedRetry
+	cfg.FollowerTimeout = flagFollowerTimeout
+	cfg.Journal = flagJournal
+	cfg.IndexDir = flagIndex
+	cfg.Namespace = flagNamespace
+	cfg.ProtocolOnly = flagProtocol
+	cfg.SkipRegisters = flagSkip
+	cfg.RegisterDeltas = flagRegisterDeltas
+	cfg.Recent = flagRecent
+	cfg.Speculative = flagSpeculative
+	cfg.Takeover = flagTakeover
+	cfg.Audit = flagAudit
+	cfg.Repair = flagRepair
+	cfg.FlushInterval = flagFlushInterval
+	cfg.ForestLimit = flagForestLimit
+	cfg.ContendedWrites = flagContendedWrites
+	cfg.PathFilters = flagPathFilters
+	cfg.VerifyPayloads = flagVerifyPayloads
+	cfg.ReportCorrupted = flagCorruption == "report"
+	cfg.Codec = flagCodec
+	cfg.CodecDeterministic = flagCodecDeterministic
+	cfg.CodecValidate = flagCodecValidate
+	cfg.Compression = flagCompression
+	cfg.CompressionStats = flagCompressionStats
+	cfg.SizeStats = flagSizeStats
+	cfg.Manifests = flagManifests
+	cfg.Checkpoint = flagCheckpoint
+	cfg.CheckpointObject = flagCheckpointObject
+	cfg.CheckpointURL = flagCheckpointURL
+	cfg.CheckpointSHA256 = checksum
+	cfg.CheckpointInterval = flagCheckpointInterval
+	cfg.CheckpointOutput = flagCheckpointOutput
+	cfg.Buckets = flagBuckets
+	cfg.RecordLayout = flagRecordLayout
+	cfg.RecordPeer = flagRecordPeer
+	cfg.RecordTopic = flagRecordTopic
+	cfg.QuarantineAfter = flagQuarantineAfter
+	cfg.ArchiveRecords = flagArchiveRecords
+	cfg.ColdURL = flagColdURL
+	cfg.ColdEndpoint = flagColdEndpoint
+	cfg.ColdRegion = flagColdRegion
+	cfg.ColdCache = flagColdCache
+	cfg.ColdAge = flagColdAge
+	cfg.ReplicaURL = flagReplicaURL
+	cfg.ReplicaEndpoint = flagReplicaEndpoint
+	cfg.ReplicaRegion = flagReplicaRegion
+	cfg.ReplicaMaxLag = flagReplicaMaxLag
+	cfg.Metrics = flagMetrics != ""
+	cfg.Monitor = monitor
+
+	// The credentials for S3-compatible object storage, which the cold tier and
+	// the secondary index use, are taken from the usual environment variables.
+	cfg.Credentials = tier.Credentials{
+		AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
 	}
 
-	// If we are resuming, and the consensus follower has already finalized some
-	// blocks that were not yet indexed, we need to download them again in the
-	// cloud streamer. Here, we figure out which blocks these are.
-	blockIDs, err := initializer.CatchupBlocks(protocolDB, read)
-	if err != nil {
-		log.Error().Err(err).Msg("could not initialize catch-up blocks")
-		return failure
+	// The consensus follower creates its own logger, so the log filter does not
+	// see its output and we hand it its override directly, if there is one.
+	cfg.FollowerLevel = flagLevel
+	override, ok := overrides["follower"]
+	if ok {
+		cfg.FollowerLevel = override.String()
 	}
 
-	// On the other side, we also need access to the execution data. The cloud
-	// streamer is responsible for retrieving block execution records from a
-	// Google Cloud Storage bucket. This component plays the role of what would
-	// otherwise be a network protocol, such as a publish socket.
-	client, err := gcloud.NewClient(context.Background(),
-		option.WithoutAuthentication(),
-	)
+	// If indexing is restricted to the data of some accounts, the index is
+	// partial and it has to keep being restricted with the same accounts.
+	for _, owner := range flagOwners {
+		cfg.Owners = append(cfg.Owners, flow.HexToAddress(owner))
+	}
+	for _, owner := range flagDeny {
+		cfg.Denied = append(cfg.Denied, flow.HexToAddress(owner))
+	}
+
+	// While a volume is low on space, the mapper waits before it starts indexing
+	// the next height, so that no height is left partially indexed.
+	if space != nil {
+		cfg.Pause = space.Wait
+	}
+
+	node, err := live.NewNode(log, cfg)
 	if err != nil {
-		log.Error().Err(err).Msg("could not connect GCP client")
+		log.Error().Err(err).Msg("could not create live node")
 		return failure
 	}
 	defer func() {
-		err := client.Close()
+		err := node.Stop()
 		if err != nil {
-			log.Error().Err(err).Msg("could not close GCP client")
+			log.Error().Err(err).Msg("could not stop live node")
 		}
 	}()
-	bucket := client.Bucket(flagBucket)
-	stream := cloud.NewGCPStreamer(log, bucket,
-		cloud.WithCatchupBlocks(blockIDs),
-	)
 
-	// Next, we can initialize our consensus and execution trackers. They are
-	// responsible for tracking changes to the available data, for the consensus
-	// follower and related consensus data on one side, and the cloud streamer
-	// and available execution records on the other side.
-	execution, err := tracker.NewExecution(log, protocolDB, stream)
-	if err != nil {
-		log.Error().Err(err).Msg("could not initialize execution tracker")
+	// The admin API serves the archived execution records from the node, once
+	// it is started, so it is only created along with it.
+	var asvr *admin.Server
+	if flagAdmin != "" {
+		opts := []admin.Option{
+			admin.WithMapper(monitor),
+			admin.WithSettings(reloader),
+		}
+		if space != nil {
+			opts = append(opts, admin.WithDisk(space))
+		}
+		if flagQueryStats > 0 {
+			opts = append(opts, admin.WithQueries(recorder))
+		}
+		if flagArchiveRecords {
+			opts = append(opts, admin.WithRecords(node))
+		}
+		asvr = admin.NewServer(log, flagAdmin, !flagStandby, opts...)
+		go func() {
+			log.Info().Msg("admin server starting")
+			err := asvr.Start()
+			if err != nil {
+				log.Warn().Err(err).Msg("admin server failed")
+			}
+			log.Info().Msg("admin server stopped")
+		}()
+		defer func() {
+			err := asvr.Stop()
+			if err != nil {
+				log.Error().Err(err).Msg("could not stop admin server")
+			}
+		}()
+	}
+
+	if flagStandby {
+		node.Follow()
+		log.Info().Msg("Flow DPS Live Indexer in standby")
+		notify(log, systemd.Ready)
+		select {
+		case <-sig:
+			log.Info().Msg("Flow DPS Live Indexer stopping")
+			notify(log, systemd.Stopping)
+			return success
+		case <-asvr.Promoted():
+			log.Info().Msg("Flow DPS Live Indexer promoted")
+		}
+	}
+
+	// Starting the node acquires the index fence, see `index.AcquireFence`.
+	err = node.Start()
+	if errors.Is(err, dps.ErrFenced) {
+		log.Error().Err(err).Msg("index is being written by another instance, please stop it first or take over its index if it is no longer running (--takeover)")
 		return failure
 	}
-	consensus, err := tracker.NewConsensus(log, protocolDB, execution)
 	if err != nil {
-		log.Error().Err(err).Msg("could not initialize consensus tracker")
+		log.Error().Err(err).Msg("could not start live node")
 		return failure
 	}
 
-	// We can now register the consensus tracker and the cloud streamer as
-	// finalization listeners with the consensus follower. The consensus tracker
-	// will use the callback to make additional data available to the mapper,
-	// while the cloud streamer will use the callback to download execution data
-	// for finalized blocks.
-	follow.AddOnBlockFinalizedConsumer(stream.OnBlockFinalized)
-	follow.AddOnBlockFinalizedConsumer(consensus.OnBlockFinalized)
-
-	// If we have an empty database, we want a loader to bootstrap from the
-	// checkpoint; if we don't, we can optionally use the root checkpoint to
-	// speed up the restart/restoration.
-	var load mapper.Loader
-	load = loader.FromIndex(log, storage, indexDB)
-	if empty {
-		file, err := os.Open(flagCheckpoint)
-		if err != nil {
-			log.Error().Err(err).Msg("could not open checkpoint file")
-			return failure
-		}
-		defer file.Close()
-		load = loader.FromCheckpoint(file)
-	} else if flagCheckpoint != "" {
-		file, err := os.Open(flagCheckpoint)
-		if err != nil {
-			log.Error().Err(err).Msg("could not open checkpoint file")
-			return failure
-		}
-		defer file.Close()
-		initialize := loader.FromCheckpoint(file)
-		load = loader.FromIndex(log, storage, indexDB,
-			loader.WithInitializer(initialize),
-			loader.WithExclude(loader.ExcludeAtOrBelow(first)),
+	// The watchdog captures heap and goroutine profiles into the data directory
+	// when the indexer uses too much memory or too many goroutines, so that we
+	// can find out what happened if it is killed for running out of memory.
+	var dog *watchdog.Watchdog
+	if flagWatchdogMemory > 0 || flagWatchdogGoroutines > 0 {
+		dog = watchdog.New(log, filepath.Join(flagData, "profiles"),
+			watchdog.WithMemoryLimit(flagWatchdogMemory),
+			watchdog.WithGoroutineLimit(flagWatchdogGoroutines),
+			watchdog.WithRetain(flagWatchdogRetain),
 		)
 	}
 
-	// If metrics are enabled, the mapper should use the metrics writer. Otherwise, it can
-	// use the regular one.
-	writer := dps.Writer(write)
-	metricsEnabled := flagMetrics != ""
-	if metricsEnabled {
-		writer = index.NewMetricsWriter(write)
-	}
-
-	// At this point, we can initialize the core business logic of the indexer,
-	// with the mapper's finite state machine and transitions. We also want to
-	// load and inject the root checkpoint if it is given as a parameter.
-	transitions := mapper.NewTransitions(log, load, consensus, execution, read, writer,
-		mapper.WithBootstrapState(empty),
-		mapper.WithSkipRegisters(flagSkip),
-	)
-	forest := forest.New()
-	state := mapper.EmptyState(forest)
-	fsm := mapper.NewFSM(state,
-		mapper.WithTransition(mapper.StatusInitialize, transitions.InitializeMapper),
-		mapper.WithTransition(mapper.StatusBootstrap, transitions.BootstrapState),
-		mapper.WithTransition(mapper.StatusResume, transitions.ResumeIndexing),
-		mapper.WithTransition(mapper.StatusIndex, transitions.IndexChain),
-		mapper.WithTransition(mapper.StatusUpdate, transitions.UpdateTree),
-		mapper.WithTransition(mapper.StatusCollect, transitions.CollectRegisters),
-		mapper.WithTransition(mapper.StatusMap, transitions.MapRegisters),
-		mapper.WithTransition(mapper.StatusForward, transitions.ForwardHeight),
-	)
-
 	// Next, we initialize the GRPC server that will serve the DPS API on top of
 	// the index database that is generated live by the mapper.
 	logOpts := []logging.Option{
 		logging.WithLevels(logging.DefaultServerCodeToLevel),
 	}
-	interceptor := grpczerolog.InterceptorLogger(log.With().Str("component", "grpc_server").Logger())
+	interceptor := grpczerolog.InterceptorLogger(log.With().Str(logs.Component, "grpc_server").Logger())
 	gsvr := grpc.NewServer(
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             flagGRPCKeepaliveMinTime,
+			PermitWithoutStream: flagGRPCKeepalivePermit,
+		}),
+		grpc.MaxRecvMsgSize(flagGRPCMaxRecvSize),
+		grpc.MaxSendMsgSize(flagGRPCMaxSendSize),
+		grpc.MaxConcurrentStreams(flagGRPCMaxStreams),
 		grpc.ChainUnaryInterceptor(
 			tags.UnaryServerInterceptor(),
+			api.DeprecationInterceptor(),
 			logging.UnaryServerInterceptor(interceptor, logOpts...),
+			api.StatsInterceptor(recorder),
+			api.ReloadableDeadlineInterceptor(log, reloader.Limits),
+			api.ErrorInterceptor(),
 		),
 		grpc.ChainStreamInterceptor(
 			tags.StreamServerInterceptor(),
 			logging.StreamServerInterceptor(interceptor, logOpts...),
+			api.StatsStreamInterceptor(recorder),
+			api.ReloadableDeadlineStreamInterceptor(log, reloader.Limits),
+			api.ErrorStreamInterceptor(),
 		),
 	)
-	server := api.NewServer(read, codec)
+	codec := zbor.NewCodec(
+		zbor.WithDeterministic(flagCodecDeterministic),
+		zbor.WithValidation(flagCodecValidate),
+	)
+	server := api.NewServer(node.Reader(), codec,
+		api.WithProtocolOnly(flagProtocol),
+	)
 
-	// This section launches the main executing components in their own
-	// goroutine, so they can run concurrently. Afterwards, we wait for an
-	// interrupt signal in order to proceed with the shutdown.
+	// This section launches the remaining components in their own goroutine,
+	// so they can run concurrently. Afterwards, we wait for an interrupt signal
+	// in order to proceed with the shutdown.
 	listener, err := net.Listen("tcp", flagAddress)
 	if err != nil {
 		log.Error().Str("address", flagAddress).Err(err).Msg("could not create listener")
 		return failure
 	}
-	done := make(chan struct{})
-	failed := make(chan struct{})
-	ctx, cancel := context.WithCancel(context.Background())
+	if flagGRPCMaxConnections > 0 {
+		listener = netutil.LimitListener(listener, flagGRPCMaxConnections)
+	}
+	go func() {
+		log.Info().Msg("Flow DPS Live Server starting")
+		api.RegisterServer(gsvr, server)
+		err = gsvr.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Warn().Err(err).Msg("Flow DPS Server failed")
+		}
+		log.Info().Msg("Flow DPS Live Server stopped")
+	}()
 	go func() {
-		follow.Run(ctx)
+		for range hup {
+			log.Info().Msg("reloading settings")
+			err := reloader.Reload()
+			if err != nil {
+				log.Error().Err(err).Msg("could not reload settings")
+			}
+		}
 	}()
 	go func() {
-		start := time.Now()
-		log.Info().Time("start", start).Msg("Flow DPS Live Indexer starting")
-		err := fsm.Run()
+		if dog == nil {
+			return
+		}
+
+		log.Info().Msg("watchdog starting")
+		err := dog.Run()
 		if err != nil {
-			log.Warn().Err(err).Msg("Flow DPS Live Indexer failed")
-			close(failed)
-		} else {
-			close(done)
+			log.Warn().Err(err).Msg("watchdog failed")
 		}
-		finish := time.Now()
-		duration := finish.Sub(start)
-		log.Info().Time("finish", finish).Str("duration", duration.Round(time.Second).String()).Msg("Flow DPS Indexer stopped")
+		log.Info().Msg("watchdog stopped")
 	}()
 	go func() {
-		log.Info().Msg("Flow DPS Live Server starting")
-		api.RegisterAPIServer(gsvr, server)
-		err = gsvr.Serve(listener)
-		if err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Warn().Err(err).Msg("Flow DPS Server failed")
+		if space == nil {
+			return
 		}
-		log.Info().Msg("Flow DPS Live Server stopped")
+
+		log.Info().Msg("disk monitor starting")
+		err := space.Run()
+		if err != nil {
+			log.Warn().Err(err).Msg("disk monitor failed")
+		}
+		log.Info().Msg("disk monitor stopped")
 	}()
 	go func() {
-		if !metricsEnabled {
+		if flagMetrics == "" {
 			return
 		}
 
@@ -436,35 +660,66 @@ func run() int {
 		log.Info().Msg("metrics server stopped")
 	}()
 
-	// Here, we are waiting for a signal, or for one of the components to fail
-	// or finish. In both cases, we proceed to shut down everything, while also
-	// entering a goroutine that allows us to force shut down by sending
-	// another signal.
+	// Once all components are launched, we let systemd know that we are ready,
+	// if we were started by it. Here, we are waiting for a signal, or for the
+	// live node to fail or finish. In both cases, we proceed to shut down
+	// everything, while also entering a goroutine that allows us to force shut
+	// down by sending another signal.
+	notify(log, systemd.Ready)
 	select {
 	case <-sig:
 		log.Info().Msg("Flow DPS Indexer stopping")
-	case <-done:
-		log.Info().Msg("Flow DPS Indexer done")
-	case <-failed:
-		log.Warn().Msg("Flow DPS Indexer aborted")
+	case <-node.Done():
+		if node.Err() != nil {
+			log.Warn().Msg("Flow DPS Indexer aborted")
+		} else {
+			log.Info().Msg("Flow DPS Indexer done")
+		}
 	}
 	go func() {
 		<-sig
 		log.Warn().Msg("forcing exit")
 		os.Exit(1)
 	}()
-
-	// We first stop serving the DPS API by shutting down the GRPC server. Next,
-	// we shut down the consensus follower, so that there is no indexing to be
-	// done anymore. Lastly, we stop the mapper logic itself.
-	gsvr.GracefulStop()
-	cancel()
-	<-follow.NodeBuilder.Done()
-	err = fsm.Stop()
+	notify(log, systemd.Stopping)
+
+	// We first drain the DPS API by gracefully shutting down the GRPC server, so
+	// that it stops accepting new requests and finishes the in-flight ones. We
+	// then stop the disk monitor, which releases the mapper if it is paused.
+	// Lastly, we stop the live node, which shuts down the consensus follower and
+	// the mapper, and the watchdog.
+	drained := api.Drain(gsvr, flagDrainTimeout)
+	if !drained {
+		log.Warn().Dur("timeout", flagDrainTimeout).Msg("in-flight API requests cancelled after drain timeout")
+	}
+	if space != nil {
+		err = space.Stop()
+		if err != nil {
+			log.Error().Err(err).Msg("could not stop disk monitor")
+			return failure
+		}
+	}
+	err = node.Stop()
 	if err != nil {
-		log.Error().Err(err).Msg("could not stop indexer")
+		log.Error().Err(err).Msg("could not stop live node")
 		return failure
 	}
+	if dog != nil {
+		err = dog.Stop()
+		if err != nil {
+			log.Error().Err(err).Msg("could not stop watchdog")
+			return failure
+		}
+	}
 
 	return success
 }
+
+// notify sends the given state to systemd, if we were started by it. Failing
+// to do so does not prevent the indexer from running, so we only log it.
+func notify(log zerolog.Logger, state string) {
+	err := systemd.Notify(state)
+	if err != nil {
+		log.Warn().Str("state", state).Err(err).Msg("could not notify systemd")
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
+
+	"github.com/optakt/flow-dps/codec"
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/chain"
+	"github.com/optakt/flow-dps/service/feeder"
+	"github.com/optakt/flow-dps/service/forest"
+	"github.com/optakt/flow-dps/service/index"
+	"github.com/optakt/flow-dps/service/loader"
+	"github.com/optakt/flow-dps/service/logs"
+	"github.com/optakt/flow-dps/service/mapper"
+	"github.com/optakt/flow-dps/service/storage"
+)
+
+const (
+	success = 0
+	failure = 1
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+
+	// Signal catching for clean shutdown.
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+
+	// Command line parameter initialization.
+	var (
+		flagCodecDeterministic bool
+		flagCodecValidate      bool
+		flagCompression        map[string]string
+		flagCompressionStats   bool
+		flagCorruption         string
+		flagData               string
+		flagIndex              string
+		flagLevel              string
+		flagLogFormat          string
+		flagLogOverrides       map[string]string
+		flagManifests          bool
+		flagNamespace          string
+		flagSizeStats          bool
+		flagTrie               string
+		flagTakeover           bool
+
+		flagFrom uint64
+		flagTo   uint64
+	)
+
+	pflag.StringVar(&flagCorruption, "corruption", "halt", "policy for corrupted write-ahead log data (halt or skip)")
+	pflag.StringVarP(&flagData, "data", "d", "data", "path to database directory for protocol data")
+	pflag.StringVarP(&flagIndex, "index", "i", "index", "path to database directory for state index")
+	pflag.StringVarP(&flagLevel, "level", "l", "info", "log output level")
+	pflag.StringVar(&flagLogFormat, "log-format", "json", "format of the log output (json or console)")
+	pflag.StringToStringVar(&flagLogOverrides, "log-level-overrides", nil, "log output level per component (e.g. mapper=debug,gcp_streamer=warn)")
+	pflag.StringVarP(&flagNamespace, "namespace", "n", "", "namespace in the index database for the indexed data (default namespace when left empty)")
+	pflag.StringVarP(&flagTrie, "trie", "t", "", "path to data directory for execution state ledger")
+
+	pflag.Uint64Var(&flagFrom, "from", 0, "first height of the range to reindex")
+	pflag.Uint64Var(&flagTo, "to", 0, "last height of the range to reindex")
+	pflag.StringToStringVar(&flagCompression, "compression", nil, "compression per resource, such as events=best,payload=none (none, fastest, default, better or best)")
+	pflag.BoolVar(&flagCodecDeterministic, "codec-deterministic", false, "encode stored values with deterministic CBOR encoding and reject values with non-deterministic encodings when decoding")
+	pflag.BoolVar(&flagCodecValidate, "codec-validate", false, "decode each stored value again right after encoding it and fail on lossy encodings (for debugging)")
+	pflag.BoolVar(&flagCompressionStats, "compression-stats", false, "record compression statistics for the stored values")
+	pflag.BoolVar(&flagSizeStats, "size-stats", false, "keep running size statistics of the stored keys and values per data category")
+	pflag.BoolVar(&flagManifests, "manifests", false, "record per-height integrity manifests of the indexed data")
+	pflag.BoolVar(&flagTakeover, "takeover", false, "take over the index from another instance that stopped without releasing it")
+
+	pflag.Parse()
+
+	// Increase the GOMAXPROCS value in order to use the full IOPS available, see:
+	// https://groups.google.com/g/golang-nuts/c/jPb_h3TvlKE
+	_ = runtime.GOMAXPROCS(128)
+
+	// Logger initialization.
+	zerolog.TimestampFunc = func() time.Time { return time.Now().UTC() }
+	log := zerolog.New(os.Stderr).With().Timestamp().Logger().Level(zerolog.DebugLevel)
+	out, err := logs.Output(flagLogFormat, os.Stderr)
+	if err != nil {
+		log.Error().Str("format", flagLogFormat).Err(err).Msg("could not initialize log output")
+		return failure
+	}
+	overrides, err := logs.ParseOverrides(flagLogOverrides)
+	if err != nil {
+		log.Error().Err(err).Msg("could not parse log level overrides")
+		return failure
+	}
+	levels := logs.NewFilter(out, overrides)
+	log = log.Output(levels)
+	level, err := zerolog.ParseLevel(flagLevel)
+	if err != nil {
+		log.Error().Str("level", flagLevel).Err(err).Msg("could not parse log level")
+		return failure
+	}
+	levels.SetLevel(level)
+
+	// We need a valid, non-empty range of heights to reindex. The more detailed
+	// validation against the existing index happens in the mapper.
+	if flagFrom == 0 || flagTo < flagFrom {
+		log.Error().Uint64("from", flagFrom).Uint64("to", flagTo).Msg("invalid height range, please provide valid range (--from, --to)")
+		return failure
+	}
+
+	// Validate the policy for corrupted write-ahead log data.
+	if flagCorruption != "halt" && flagCorruption != "skip" {
+		log.Error().Str("corruption", flagCorruption).Msg("invalid corruption policy, please use halt or skip (--corruption)")
+		return failure
+	}
+
+	// Open the needed databases.
+	indexDB, err := badger.Open(dps.DefaultOptions(flagIndex))
+	if err != nil {
+		log.Error().Str("index", flagIndex).Err(err).Msg("could not open index database")
+		return failure
+	}
+	defer func() {
+		err := indexDB.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("could not close index database")
+		}
+	}()
+	protocolDB, err := badger.Open(dps.DefaultOptions(flagData))
+	if err != nil {
+		log.Error().Err(err).Msg("could not open protocol state database")
+		return failure
+	}
+	defer func() {
+		err := protocolDB.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("could not close protocol state database")
+		}
+	}()
+
+	// The heights are reindexed with the codec that was recorded when the
+	// index was created, so that they are stored like all other heights.
+	name, err := index.Codec(indexDB, storage.New(zbor.NewCodec(), storage.WithNamespace(flagNamespace)))
+	if err != nil {
+		log.Error().Err(err).Msg("could not get codec of index")
+		return failure
+	}
+	if name == "" {
+		name = dps.CodecZbor
+	}
+
+	// The storage library is initialized with a codec and provides functions to
+	// interact with a Badger database while encoding and compressing
+	// transparently.
+	codec, err := codec.New(name,
+		zbor.WithDeterministic(flagCodecDeterministic),
+		zbor.WithValidation(flagCodecValidate),
+	)
+	if err != nil {
+		log.Error().Str("codec", name).Err(err).Msg("could not create codec")
+		return failure
+	}
+	storageOpts, err := storage.CompressionOptions(flagCompression)
+	if err != nil {
+		log.Error().Err(err).Msg("could not parse compression policy")
+		return failure
+	}
+	storageOpts = append(storageOpts,
+		storage.WithNamespace(flagNamespace),
+		storage.WithCompressionStats(flagCompressionStats),
+		storage.WithSizeStats(flagSizeStats),
+		storage.WithManifests(flagManifests),
+	)
+	storage := storage.New(codec, storageOpts...)
+
+	// The running size statistics continue from the totals that were persisted
+	// the last time the index was written to.
+	err = indexDB.View(storage.LoadSizeStats())
+	if err != nil {
+		log.Error().Err(err).Msg("could not load size statistics")
+		return failure
+	}
+
+	// The chain is responsible for reading blockchain data from the protocol
+	// state, while the feeder is responsible for reading the write-ahead log
+	// of the execution state. Trie updates that precede the range will simply
+	// be skipped by the mapper, as they don't apply to the restored trie.
+	disk := chain.FromDisk(protocolDB)
+	segments, err := feeder.NewSegmentsReader(flagTrie)
+	if err != nil {
+		log.Error().Str("trie", flagTrie).Err(err).Msg("could not open segments reader")
+		return failure
+	}
+	defer segments.Close()
+	feed := feeder.FromWAL(segments)
+
+	// Fence the index so that no other instance writes to it concurrently.
+	hostname, _ := os.Hostname()
+	holder := fmt.Sprintf("%s:%d", hostname, os.Getpid())
+	token, err := index.AcquireFence(indexDB, storage, holder, flagTakeover)
+	if errors.Is(err, dps.ErrFenced) {
+		log.Error().Err(err).Msg("index is being written by another instance, please stop it first or take over its index if it is no longer running (--takeover)")
+		return failure
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("could not acquire index fence")
+		return failure
+	}
+
+	// The reader is used by the mapper to validate the range against the
+	// existing index, and the writer will overwrite the data for the range.
+	read := index.NewReader(indexDB, storage)
+	write := index.NewWriter(indexDB, storage,
+		index.WithFlushInterval(0),
+		index.WithFence(token),
+	)
+	defer func() {
+		err := write.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("could not close index")
+		}
+	}()
+
+	// The loader restores the execution state trie as it was right before the
+	// first height of the range, by ignoring all registers indexed above it.
+	load := loader.FromIndex(log, storage, indexDB,
+		loader.WithExclude(loader.ExcludeAbove(flagFrom-1)),
+	)
+
+	transitions := mapper.NewTransitions(log, load, disk, feed, read, write,
+		mapper.WithReindexRange(flagFrom, flagTo),
+		mapper.WithSkipCorrupted(flagCorruption == "skip"),
+	)
+	forest := forest.New()
+	state := mapper.EmptyState(forest)
+	fsm := mapper.NewFSM(state,
+		mapper.WithMiddleware(mapper.Before(mapper.Throttle(write, 100*time.Millisecond), mapper.StatusMap, mapper.StatusForward)),
+		mapper.WithTransition(mapper.StatusInitialize, transitions.InitializeMapper),
+		mapper.WithTransition(mapper.StatusResume, transitions.ResumeIndexing),
+		mapper.WithTransition(mapper.StatusIndex, transitions.IndexChain),
+		mapper.WithTransition(mapper.StatusUpdate, transitions.UpdateTree),
+		mapper.WithTransition(mapper.StatusCollect, transitions.CollectRegisters),
+		mapper.WithTransition(mapper.StatusMap, transitions.MapRegisters),
+		mapper.WithTransition(mapper.StatusForward, transitions.ForwardHeight),
+	)
+
+	// This section launches the main executing components in their own
+	// goroutine, so they can run concurrently. Afterwards, we wait for an
+	// interrupt signal in order to proceed with the next section.
+	done := make(chan struct{})
+	failed := make(chan struct{})
+	go func() {
+		start := time.Now()
+		log.Info().Time("start", start).Uint64("from", flagFrom).Uint64("to", flagTo).Msg("Flow DPS Reindexer starting")
+		err := fsm.Run()
+		if err != nil {
+			log.Warn().Err(err).Msg("Flow DPS Reindexer failed")
+			close(failed)
+		} else {
+			close(done)
+		}
+		finish := time.Now()
+		duration := finish.Sub(start)
+		log.Info().Time("finish", finish).Str("duration", duration.Round(time.Second).String()).Msg("Flow DPS Reindexer stopped")
+	}()
+
+	select {
+	case <-sig:
+		log.Info().Msg("Flow DPS Reindexer stopping")
+	case <-done:
+		log.Info().Msg("Flow DPS Reindexer done")
+	case <-failed:
+		log.Warn().Msg("Flow DPS Reindexer aborted")
+		return failure
+	}
+	go func() {
+		<-sig
+		log.Warn().Msg("forcing exit")
+		os.Exit(1)
+	}()
+
+	// The following code starts a shut down with a certain timeout and makes
+	// sure that the main executing components are shutting down within the
+	// allocated shutdown time. Otherwise, we will force the shutdown and log
+	// an error. We then wait for shutdown on each component to complete.
+	err = fsm.Stop()
+	if err != nil {
+		log.Error().Err(err).Msg("could not stop reindexer")
+		return failure
+	}
+
+	return success
+}
@@ -16,26 +16,37 @@ package main
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/dgraph-io/badger/v2"
 	"github.com/rs/zerolog"
 	"github.com/spf13/pflag"
+	"golang.org/x/net/netutil"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
 
 	grpczerolog "github.com/grpc-ecosystem/go-grpc-middleware/providers/zerolog/v2"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/tags"
 
 	api "github.com/optakt/flow-dps/api/dps"
+	"github.com/optakt/flow-dps/codec"
 	"github.com/optakt/flow-dps/codec/zbor"
 	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/admin"
 	"github.com/optakt/flow-dps/service/index"
+	"github.com/optakt/flow-dps/service/logs"
+	"github.com/optakt/flow-dps/service/queries"
 	"github.com/optakt/flow-dps/service/storage"
+	"github.com/optakt/flow-dps/service/systemd"
+	"github.com/optakt/flow-dps/service/tier"
 )
 
 const (
@@ -51,59 +62,220 @@ func run() int {
 
 	// Signal catching for clean shutdown.
 	sig := make(chan os.Signal, 1)
-	signal.Notify(sig, os.Interrupt)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
 
 	// Command line parameter initialization.
 	var (
-		flagAddress string
-		flagLevel   string
-		flagIndex   string
+		flagAddress      string
+		flagLevel        string
+		flagLogFormat    string
+		flagLogOverrides map[string]string
+		flagIndex        string
+		flagChains       []string
+		flagPathFilters  bool
+
+		flagAdmin                string
+		flagColdCache            uint64
+		flagColdEndpoint         string
+		flagColdRegion           string
+		flagColdURL              string
+		flagDrainTimeout         time.Duration
+		flagGRPCKeepaliveMinTime time.Duration
+		flagGRPCKeepalivePermit  bool
+		flagGRPCMaxConnections   int
+		flagGRPCMaxRecvSize      int
+		flagGRPCMaxSendSize      int
+		flagGRPCMaxStreams       uint32
+		flagQueryLimit           time.Duration
+		flagQuerySlow            time.Duration
+		flagQueryStats           uint
+		flagVerifyPayloads       bool
 	)
 
 	pflag.StringVarP(&flagAddress, "address", "a", "127.0.0.1:5005", "bind address for serving DPS API")
 	pflag.StringVarP(&flagIndex, "index", "i", "index", "path to database directory for state index")
 	pflag.StringVarP(&flagLevel, "level", "l", "info", "log output level")
+	pflag.StringVar(&flagLogFormat, "log-format", "json", "format of the log output (json or console)")
+	pflag.StringToStringVar(&flagLogOverrides, "log-level-overrides", nil, "log output level per component (e.g. mapper=debug,gcp_streamer=warn)")
+	pflag.StringSliceVarP(&flagChains, "chains", "c", nil, "chain IDs of additional namespaces in the index to serve")
+	pflag.BoolVar(&flagPathFilters, "path-filters", false, "use bloom filters of written register paths to speed up lookups of missing registers")
+	pflag.DurationVar(&flagQueryLimit, "query-limit", 0, "maximum execution time of API requests (0s for unlimited)")
+	pflag.DurationVar(&flagQuerySlow, "query-slow", 0, "execution time above which API requests are logged as slow queries (0s for disabled)")
+	pflag.UintVar(&flagQueryStats, "query-stats", 10_000, "number of most recent API requests for which query statistics are served on the admin API (0 for disabled)")
+	pflag.StringVar(&flagAdmin, "admin", "", "address on which to expose the admin API (no admin API is exposed when left empty)")
+	pflag.Uint64Var(&flagColdCache, "cold-cache", 100_000_000, "size in bytes of the cache for payloads read from the cold tier")
+	pflag.StringVar(&flagColdEndpoint, "cold-endpoint", "https://s3.amazonaws.com", "endpoint of the S3-compatible object storage of the cold tier")
+	pflag.StringVar(&flagColdRegion, "cold-region", "us-east-1", "region of the S3-compatible object storage of the cold tier")
+	pflag.StringVar(&flagColdURL, "cold-url", "", "bucket of the cold tier, such as s3://bucket/prefix or gs://bucket/prefix (no cold tier when left empty)")
+	pflag.DurationVar(&flagDrainTimeout, "drain-timeout", 30*time.Second, "maximum duration to wait for in-flight API requests to finish on shutdown before cancelling them (0 for unlimited)")
+	pflag.DurationVar(&flagGRPCKeepaliveMinTime, "grpc-keepalive-min-time", 5*time.Minute, "minimum interval between keepalive pings of clients, which are disconnected when they ping more often")
+	pflag.BoolVar(&flagGRPCKeepalivePermit, "grpc-keepalive-permit", false, "allow clients to send keepalive pings when they have no active streams")
+	pflag.IntVar(&flagGRPCMaxConnections, "grpc-max-connections", 0, "maximum number of simultaneous client connections to the GRPC API (0 for unlimited)")
+	pflag.IntVar(&flagGRPCMaxRecvSize, "grpc-max-recv-size", 4*1024*1024, "maximum size in bytes of messages received by the GRPC API")
+	pflag.IntVar(&flagGRPCMaxSendSize, "grpc-max-send-size", math.MaxInt32, "maximum size in bytes of messages sent by the GRPC API")
+	pflag.Uint32Var(&flagGRPCMaxStreams, "grpc-max-streams", 0, "maximum number of concurrent streams per client connection to the GRPC API (0 for unlimited)")
+	pflag.BoolVar(&flagVerifyPayloads, "verify-payloads", false, "verify that the key of each register payload read from the index matches its path")
 
 	pflag.Parse()
 
 	// Logger initialization.
 	zerolog.TimestampFunc = func() time.Time { return time.Now().UTC() }
 	log := zerolog.New(os.Stderr).With().Timestamp().Logger().Level(zerolog.DebugLevel)
+	out, err := logs.Output(flagLogFormat, os.Stderr)
+	if err != nil {
+		log.Error().Str("format", flagLogFormat).Err(err).Msg("could not initialize log output")
+		return failure
+	}
+	overrides, err := logs.ParseOverrides(flagLogOverrides)
+	if err != nil {
+		log.Error().Err(err).Msg("could not parse log level overrides")
+		return failure
+	}
+	levels := logs.NewFilter(out, overrides)
+	log = log.Output(levels)
 	level, err := zerolog.ParseLevel(flagLevel)
 	if err != nil {
 		log.Error().Str("level", flagLevel).Err(err).Msg("could not parse log level")
 		return failure
 	}
-	log = log.Level(level)
+	levels.SetLevel(level)
 
 	// Initialize the index core state and open database in read-only mode.
-	db, err := badger.Open(dps.DefaultOptions(flagIndex).WithReadOnly(true))
+	db, err := badger.Open(dps.ReadOnlyOptions(flagIndex))
 	if err != nil {
 		log.Error().Str("index", flagIndex).Err(err).Msg("could not open index DB")
 		return failure
 	}
 	defer db.Close()
 
-	// Initialize storage library.
-	codec := zbor.NewCodec()
-	storage := storage.New(codec)
+	// The query recorder keeps track of the most recent API requests, so that
+	// the admin API can serve statistics about them. As the server never writes
+	// to the index, it is always active.
+	recorder := queries.NewRecorder(flagQueryStats)
+	if flagAdmin != "" {
+		var opts []admin.Option
+		if flagQueryStats > 0 {
+			opts = append(opts, admin.WithQueries(recorder))
+		}
+		asvr := admin.NewServer(log, flagAdmin, true, opts...)
+		go func() {
+			log.Info().Msg("admin server starting")
+			err := asvr.Start()
+			if err != nil {
+				log.Warn().Err(err).Msg("admin server failed")
+			}
+			log.Info().Msg("admin server stopped")
+		}()
+		defer func() {
+			err := asvr.Stop()
+			if err != nil {
+				log.Error().Err(err).Msg("could not stop admin server")
+			}
+		}()
+	}
 
 	// GRPC API initialization.
 	opts := []logging.Option{
 		logging.WithLevels(logging.DefaultServerCodeToLevel),
 	}
 	gsvr := grpc.NewServer(
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             flagGRPCKeepaliveMinTime,
+			PermitWithoutStream: flagGRPCKeepalivePermit,
+		}),
+		grpc.MaxRecvMsgSize(flagGRPCMaxRecvSize),
+		grpc.MaxSendMsgSize(flagGRPCMaxSendSize),
+		grpc.MaxConcurrentStreams(flagGRPCMaxStreams),
 		grpc.ChainUnaryInterceptor(
 			tags.UnaryServerInterceptor(),
+			api.DeprecationInterceptor(),
 			logging.UnaryServerInterceptor(grpczerolog.InterceptorLogger(log), opts...),
+			api.StatsInterceptor(recorder),
+			api.DeadlineInterceptor(log, flagQueryLimit, flagQuerySlow),
+			api.ErrorInterceptor(),
 		),
 		grpc.ChainStreamInterceptor(
 			tags.StreamServerInterceptor(),
 			logging.StreamServerInterceptor(grpczerolog.InterceptorLogger(log), opts...),
+			api.StatsStreamInterceptor(recorder),
+			api.DeadlineStreamInterceptor(log, flagQueryLimit, flagQuerySlow),
+			api.ErrorStreamInterceptor(),
 		),
 	)
-	index := index.NewReader(db, storage)
-	server := api.NewServer(index, codec)
+	// If a cold tier is configured, payloads that were moved to it are read
+	// from its object storage, with the segments of each namespace kept apart.
+	// The credentials for S3-compatible object storage are taken from the usual
+	// environment variables.
+	var store tier.Store
+	if flagColdURL != "" {
+		creds := tier.Credentials{
+			AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		}
+		store, err = tier.Open(flagColdURL, flagColdEndpoint, flagColdRegion, creds)
+		if err != nil {
+			log.Error().Err(err).Str("url", flagColdURL).Msg("could not open cold tier")
+			return failure
+		}
+	}
+	// Each namespace is read with the codec it was created with, which was
+	// recorded when it was created.
+	library := func(namespace string) (*storage.Library, error) {
+		name, err := index.Codec(db, storage.New(zbor.NewCodec(), storage.WithNamespace(namespace)))
+		if err != nil {
+			return nil, fmt.Errorf("could not get codec of index: %w", err)
+		}
+		if name == "" {
+			name = dps.CodecZbor
+		}
+		codec, err := codec.New(name)
+		if err != nil {
+			return nil, fmt.Errorf("could not create codec: %w", err)
+		}
+		opts := []func(*storage.Config){
+			storage.WithNamespace(namespace),
+			storage.WithVerification(flagVerifyPayloads),
+		}
+		if store != nil {
+			cold, err := tier.NewReader(store,
+				tier.WithCacheSize(flagColdCache),
+				tier.WithNamespace(namespace),
+			)
+			if err != nil {
+				return nil, fmt.Errorf("could not initialize cold tier reader: %w", err)
+			}
+			opts = append(opts, storage.WithColdTier(cold))
+		}
+		return storage.New(codec, opts...), nil
+	}
+
+	// Each additional chain is served from the namespace with the same name in
+	// the index database, while requests without chain ID use the default one.
+	// The index is opened read-only, so the first and last heights of each
+	// namespace never change while it is served, and can be cached.
+	options := make([]func(*api.Config), 0, len(flagChains))
+	for _, chainID := range flagChains {
+		namespace, err := library(chainID)
+		if err != nil {
+			log.Error().Err(err).Str("chain", chainID).Msg("could not initialize storage library")
+			return failure
+		}
+		options = append(options, api.WithChain(chainID, index.NewReader(db, namespace,
+			index.WithPathFilters(flagPathFilters),
+			index.WithHeights(index.NewHeights()),
+		)))
+	}
+	lib, err := library("")
+	if err != nil {
+		log.Error().Err(err).Msg("could not initialize storage library")
+		return failure
+	}
+	index := index.NewReader(db, lib,
+		index.WithPathFilters(flagPathFilters),
+		index.WithHeights(index.NewHeights()),
+	)
+	server := api.NewServer(index, zbor.NewCodec(), options...)
 
 	// This section launches the main executing components in their own
 	// goroutine, so they can run concurrently. Afterwards, we wait for an
@@ -113,11 +285,14 @@ func run() int {
 		log.Error().Str("address", flagAddress).Err(err).Msg("could not create listener")
 		return failure
 	}
+	if flagGRPCMaxConnections > 0 {
+		listener = netutil.LimitListener(listener, flagGRPCMaxConnections)
+	}
 	done := make(chan struct{})
 	failed := make(chan struct{})
 	go func() {
 		log.Info().Msg("Flow DPS Server starting")
-		api.RegisterAPIServer(gsvr, server)
+		api.RegisterServer(gsvr, server)
 		err = gsvr.Serve(listener)
 		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Warn().Err(err).Msg("Flow DPS Server failed")
@@ -128,6 +303,9 @@ func run() int {
 		log.Info().Msg("Flow DPS Server stopped")
 	}()
 
+	// Once the server is launched, we let systemd know that we are ready, if we
+	// were started by it.
+	notify(log, systemd.Ready)
 	select {
 	case <-sig:
 		log.Info().Msg("Flow DPS Server stopping")
@@ -142,8 +320,23 @@ func run() int {
 		log.Warn().Msg("forcing exit")
 		os.Exit(1)
 	}()
+	notify(log, systemd.Stopping)
 
-	gsvr.GracefulStop()
+	// We drain the DPS API by gracefully shutting down the GRPC server, so that
+	// it stops accepting new requests and finishes the in-flight ones.
+	drained := api.Drain(gsvr, flagDrainTimeout)
+	if !drained {
+		log.Warn().Dur("timeout", flagDrainTimeout).Msg("in-flight API requests cancelled after drain timeout")
+	}
 
 	return success
 }
+
+// notify sends the given state to systemd, if we were started by it. Failing
+// to do so does not prevent the server from running, so we only log it.
+func notify(log zerolog.Logger, state string) {
+	err := systemd.Notify(state)
+	if err != nil {
+		log.Warn().Str("state", state).Err(err).Msg("could not notify systemd")
+	}
+}
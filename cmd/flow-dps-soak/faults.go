@@ -0,0 +1,113 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+
+	"github.com/onflow/flow-go/engine/execution/computation/computer/uploader"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/chain"
+)
+
+// errInjected is the error returned by injected faults. It lets us tell apart
+// the crashes we caused from the ones that reveal an actual problem.
+var errInjected = errors.New("injected fault")
+
+// Injector decides when to inject faults. Each kind of fault is injected with
+// the same probability, and the injector keeps count of how many faults of
+// each kind it injected.
+type Injector struct {
+	mutex  *sync.Mutex
+	random *rand.Rand
+	rate   float64
+	counts map[string]uint
+}
+
+// NewInjector creates a new injector that injects faults with the given
+// probability, using a random source with the given seed.
+func NewInjector(seed int64, rate float64) *Injector {
+
+	i := Injector{
+		mutex:  &sync.Mutex{},
+		random: rand.New(rand.NewSource(seed)),
+		rate:   rate,
+		counts: make(map[string]uint),
+	}
+
+	return &i
+}
+
+// Inject returns true if a fault of the given kind should be injected.
+func (i *Injector) Inject(kind string) bool {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.random.Float64() >= i.rate {
+		return false
+	}
+	i.counts[kind]++
+	return true
+}
+
+// Counts returns the number of injected faults for each kind of fault.
+func (i *Injector) Counts() map[string]uint {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	counts := make(map[string]uint, len(i.counts))
+	for kind, count := range i.counts {
+		counts[kind] = count
+	}
+	return counts
+}
+
+// Streamer wraps the in-memory streamer of the synthetic chain and makes it
+// fail at random, like the cloud streamer does when it can not download an
+// execution record.
+type Streamer struct {
+	stream *chain.Streamer
+	faults *Injector
+}
+
+// Next returns the next execution record from the wrapped streamer, unless a
+// fault is injected.
+func (s *Streamer) Next() (*uploader.BlockData, error) {
+	if s.faults.Inject("streamer") {
+		return nil, errInjected
+	}
+	return s.stream.Next()
+}
+
+// Writer wraps an index writer and makes some of its writes fail at random,
+// like Badger does when a transaction can not be committed. As the data of a
+// height is written as a single batch, a failed write leaves none of the data
+// of its height in the index.
+type Writer struct {
+	dps.Writer
+	faults *Injector
+}
+
+// WriteHeightBatch indexes the data of a height, unless a fault is injected.
+func (w *Writer) WriteHeightBatch(ctx context.Context, data dps.HeightData) error {
+	if w.faults.Inject("height") {
+		return errInjected
+	}
+	return w.Writer.WriteHeightBatch(ctx, data)
+}
@@ -0,0 +1,302 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
+
+	"github.com/onflow/flow-go/engine/execution/computation/computer/uploader"
+	"github.com/onflow/flow-go/storage/badger/operation"
+
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/index"
+	"github.com/optakt/flow-dps/service/storage"
+	"github.com/optakt/flow-dps/testing/chain"
+)
+
+const (
+	success = 0
+	failure = 1
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+
+	// Signal catching for clean shutdown.
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+
+	// Command line parameter initialization.
+	var (
+		flagAccounts     uint
+		flagBlocks       uint64
+		flagDir          string
+		flagEvents       uint
+		flagInterval     time.Duration
+		flagLevel        string
+		flagRate         float64
+		flagRegisters    uint
+		flagSeed         int64
+		flagTransactions uint
+	)
+
+	pflag.UintVar(&flagAccounts, "accounts", 16, "number of accounts sending transactions on the synthetic chain")
+	pflag.Uint64VarP(&flagBlocks, "blocks", "b", 1000, "number of blocks to finalize and index")
+	pflag.StringVarP(&flagDir, "dir", "d", "", "path to directory for the protocol state and index databases (temporary directory when left empty)")
+	pflag.UintVar(&flagEvents, "events", 1, "number of events emitted by each transaction")
+	pflag.DurationVar(&flagInterval, "interval", 5*time.Millisecond, "interval between finalized blocks")
+	pflag.StringVarP(&flagLevel, "level", "l", "info", "log output level")
+	pflag.Float64VarP(&flagRate, "rate", "r", 0.002, "probability of injecting each kind of fault at each opportunity")
+	pflag.UintVar(&flagRegisters, "registers", 2, "number of registers written by each transaction")
+	pflag.Int64VarP(&flagSeed, "seed", "s", 1, "seed for the synthetic chain and the fault injection")
+	pflag.UintVar(&flagTransactions, "transactions", 4, "number of transactions in each block")
+
+	pflag.Parse()
+
+	// Logger initialization.
+	zerolog.TimestampFunc = func() time.Time { return time.Now().UTC() }
+	log := zerolog.New(os.Stderr).With().Timestamp().Logger().Level(zerolog.DebugLevel)
+	level, err := zerolog.ParseLevel(flagLevel)
+	if err != nil {
+		log.Error().Str("level", flagLevel).Err(err).Msg("could not parse log level")
+		return failure
+	}
+	log = log.Level(level)
+
+	// With a fault on every opportunity, the pipeline would never make any
+	// progress.
+	if flagRate < 0 || flagRate >= 1 {
+		log.Error().Float64("rate", flagRate).Msg("invalid fault rate, please provide rate between zero and one (--rate)")
+		return failure
+	}
+
+	// Without a directory, we run on a temporary one that we clean up after.
+	if flagDir == "" {
+		dir, err := os.MkdirTemp("", "flow-dps-soak-")
+		if err != nil {
+			log.Error().Err(err).Msg("could not create temporary directory")
+			return failure
+		}
+		defer os.RemoveAll(dir)
+		flagDir = dir
+	}
+
+	// Open the needed databases. They stay open across the runs of the
+	// pipeline, like the files on disk stay around when the live indexer
+	// crashes.
+	protocolDB, err := badger.Open(dps.DefaultOptions(filepath.Join(flagDir, "protocol")).WithLogger(nil))
+	if err != nil {
+		log.Error().Err(err).Msg("could not open protocol state database")
+		return failure
+	}
+	defer func() {
+		err := protocolDB.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("could not close protocol state database")
+		}
+	}()
+	indexDB, err := badger.Open(dps.DefaultOptions(filepath.Join(flagDir, "index")).WithLogger(nil))
+	if err != nil {
+		log.Error().Err(err).Msg("could not open index database")
+		return failure
+	}
+	defer func() {
+		err := indexDB.Close()
+		if err != nil {
+			log.Error().Err(err).Msg("could not close index database")
+		}
+	}()
+
+	// The synthetic chain starts out with only its root block in the protocol
+	// state, like a fresh spork.
+	gen, err := chain.NewGenerator(
+		chain.WithSeed(flagSeed),
+		chain.WithAccounts(flagAccounts),
+		chain.WithTransactions(flagTransactions),
+		chain.WithRegisters(flagRegisters),
+		chain.WithEvents(flagEvents),
+	)
+	if err != nil {
+		log.Error().Err(err).Msg("could not initialize chain generator")
+		return failure
+	}
+	err = gen.Bootstrap(protocolDB)
+	if err != nil {
+		log.Error().Err(err).Msg("could not bootstrap protocol state")
+		return failure
+	}
+
+	lib := storage.New(zbor.NewCodec())
+	faults := NewInjector(flagSeed, flagRate)
+	random := rand.New(rand.NewSource(flagSeed))
+	records := []*uploader.BlockData{gen.Root()}
+	root := gen.Root().Block.Header.Height
+	target := root + flagBlocks
+
+	// Each run of the pipeline lasts until the mapper crashes on an injected
+	// fault, at which point we start a new run on the same databases, or until
+	// all blocks are indexed.
+	for run := 1; ; run++ {
+
+		log := log.With().Int("run", run).Logger()
+
+		pipe, err := NewPipeline(log, protocolDB, indexDB, lib, records, faults)
+		if err != nil {
+			log.Error().Err(err).Msg("could not initialize pipeline")
+			return failure
+		}
+		pipe.Start()
+
+		log.Info().Uint64("finalized", gen.Last().Block.Header.Height).Msg("pipeline started")
+
+		indexed := false
+		crashed := false
+		for !indexed && !crashed {
+
+			select {
+			case <-sig:
+				log.Info().Msg("soak test interrupted")
+				_ = pipe.Stop()
+				return failure
+			case <-pipe.Done():
+				crashed = true
+				continue
+			case <-time.After(flagInterval):
+			}
+
+			// As long as we are below the target height, we finalize one more
+			// block per interval, while injecting faults on the side of the
+			// consensus follower.
+			if gen.Last().Block.Header.Height < target {
+				record, err := gen.Next()
+				if err != nil {
+					log.Error().Err(err).Msg("could not generate block")
+					return failure
+				}
+				err = gen.Finalize(protocolDB, record)
+				if err != nil {
+					log.Error().Err(err).Msg("could not finalize block")
+					return failure
+				}
+				records = append(records, record)
+				pipe.Stream(record)
+				pipe.Finalize(record.Block.Header.ID())
+
+				err = inject(pipe, protocolDB, faults, random, records)
+				if err != nil {
+					log.Error().Err(err).Msg("could not inject follower fault")
+					return failure
+				}
+				continue
+			}
+
+			last, err := pipe.Last()
+			if err != nil {
+				log.Error().Err(err).Msg("could not get last indexed height")
+				return failure
+			}
+			indexed = last >= target
+		}
+
+		// If the mapper stopped, it should be because of an injected fault, in
+		// which case we start a new run. Anything else means that the pipeline
+		// can not recover on its own.
+		err = pipe.Stop()
+		if crashed {
+			if err != nil {
+				log.Warn().Err(err).Msg("could not stop crashed pipeline cleanly")
+			}
+			if !errors.Is(pipe.Err(), errInjected) {
+				log.Error().Err(pipe.Err()).Msg("pipeline failed")
+				return failure
+			}
+			log.Warn().Err(pipe.Err()).Msg("pipeline crashed on injected fault")
+			continue
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("could not stop pipeline")
+			return failure
+		}
+
+		log.Info().Uint64("last", target).Msg("all blocks indexed")
+		break
+	}
+
+	// Finally, we check the index against the synthetic chain, with a fresh
+	// reader that does not share any state with the pipeline runs.
+	read := index.NewReader(indexDB, lib)
+	err = validate(context.Background(), read, records)
+	if err != nil {
+		log.Error().Err(err).Msg("index validation failed")
+		return failure
+	}
+
+	log.Info().
+		Uint64("blocks", flagBlocks).
+		Interface("faults", faults.Counts()).
+		Msg("index validated after soak test")
+
+	return success
+}
+
+// inject injects the faults on the side of the consensus follower: restarts of
+// the follower, notifications for stale finalized blocks and for blocks of
+// forks that were never finalized, as well as duplicate execution records.
+func inject(pipe *Pipeline, db *badger.DB, faults *Injector, random *rand.Rand, records []*uploader.BlockData) error {
+
+	if faults.Inject("restart") {
+		err := pipe.Restart()
+		if err != nil {
+			return err
+		}
+	}
+
+	if faults.Inject("stale") {
+		record := records[random.Intn(len(records))]
+		pipe.Finalize(record.Block.Header.ID())
+	}
+
+	if faults.Inject("fork") {
+		fork := *records[len(records)-1].Block.Header
+		fork.View += 1_000_000
+		forkID := fork.ID()
+		err := db.Update(operation.InsertHeader(forkID, &fork))
+		if err != nil {
+			return err
+		}
+		pipe.Finalize(forkID)
+	}
+
+	if faults.Inject("duplicate") {
+		record := records[random.Intn(len(records))]
+		pipe.Stream(record)
+	}
+
+	return nil
+}
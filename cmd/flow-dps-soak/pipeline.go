@@ -0,0 +1,210 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/rs/zerolog"
+
+	"github.com/onflow/flow-go/engine/execution/computation/computer/uploader"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/storage/badger/operation"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/forest"
+	"github.com/optakt/flow-dps/service/index"
+	"github.com/optakt/flow-dps/service/loader"
+	"github.com/optakt/flow-dps/service/mapper"
+	"github.com/optakt/flow-dps/service/storage"
+	"github.com/optakt/flow-dps/service/tracker"
+	"github.com/optakt/flow-dps/testing/chain"
+)
+
+// waitInterval is the interval at which the mapper retries to get data that is
+// not available yet, and at which the index writer flushes its transactions.
+const waitInterval = 10 * time.Millisecond
+
+// Pipeline is a single run of the live indexing pipeline, from the streamer of
+// execution records to the index on disk, as it exists between a start and a
+// crash of the live indexer. It is set up the same way as the live indexer sets
+// it up when it starts with an existing index.
+type Pipeline struct {
+	log       zerolog.Logger
+	db        *badger.DB
+	stream    *chain.Streamer
+	execution *tracker.Execution
+	follow    *chain.Follower
+	read      *index.Reader
+	write     *index.Writer
+	fsm       *mapper.FSM
+	done      chan struct{}
+	err       error
+}
+
+// NewPipeline sets up a new run of the pipeline on top of the given protocol
+// state and index databases. The execution records of the finalized blocks
+// that are not indexed yet are streamed again, like the cloud streamer does
+// with its catch-up blocks.
+func NewPipeline(log zerolog.Logger, protocolDB *badger.DB, indexDB *badger.DB, lib *storage.Library, records []*uploader.BlockData, faults *Injector) (*Pipeline, error) {
+
+	ctx := context.Background()
+
+	read := index.NewReader(indexDB, lib)
+	last, err := read.Last(ctx)
+	empty := errors.Is(err, dps.ErrBootstrapping)
+	if err != nil && !empty {
+		return nil, fmt.Errorf("could not get last height: %w", err)
+	}
+
+	var finalized uint64
+	err = protocolDB.View(operation.RetrieveFinalizedHeight(&finalized))
+	if err != nil {
+		return nil, fmt.Errorf("could not get finalized height: %w", err)
+	}
+	stream := chain.NewStreamer()
+	for _, record := range records {
+		height := record.Block.Header.Height
+		if height > last && height <= finalized {
+			stream.Push(record)
+		}
+	}
+
+	err = tracker.Rewind(protocolDB, last)
+	if err != nil {
+		return nil, fmt.Errorf("could not rewind execution tracker: %w", err)
+	}
+	execution, err := tracker.NewExecution(log, protocolDB, &Streamer{stream: stream, faults: faults})
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize execution tracker: %w", err)
+	}
+	consensus, err := tracker.NewConsensus(log, protocolDB, execution)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize consensus tracker: %w", err)
+	}
+	follow := chain.NewFollower(execution, consensus)
+
+	// A crash can leave the payloads of the height that was being indexed in
+	// the index, without the height being marked as indexed. We therefore
+	// restore the execution state trie as it was at the last indexed height.
+	var load mapper.Loader = loader.FromScratch()
+	if !empty {
+		load = loader.FromIndex(log, lib, indexDB, loader.WithExclude(loader.ExcludeAbove(last)))
+	}
+
+	write := index.NewWriter(indexDB, lib, index.WithFlushInterval(waitInterval))
+	writer := Writer{
+		Writer: write,
+		faults: faults,
+	}
+
+	transitions := mapper.NewTransitions(log, load, follow, follow, read, &writer,
+		mapper.WithBootstrapState(empty),
+		mapper.WithWaitInterval(waitInterval),
+	)
+	state := mapper.EmptyState(forest.New())
+	fsm := mapper.NewFSM(state,
+		mapper.WithTransition(mapper.StatusInitialize, transitions.InitializeMapper),
+		mapper.WithTransition(mapper.StatusBootstrap, transitions.BootstrapState),
+		mapper.WithTransition(mapper.StatusResume, transitions.ResumeIndexing),
+		mapper.WithTransition(mapper.StatusIndex, transitions.IndexChain),
+		mapper.WithTransition(mapper.StatusUpdate, transitions.UpdateTree),
+		mapper.WithTransition(mapper.StatusCollect, transitions.CollectRegisters),
+		mapper.WithTransition(mapper.StatusMap, transitions.MapRegisters),
+		mapper.WithTransition(mapper.StatusForward, transitions.ForwardHeight),
+	)
+
+	p := Pipeline{
+		log:       log,
+		db:        protocolDB,
+		stream:    stream,
+		execution: execution,
+		follow:    follow,
+		read:      read,
+		write:     write,
+		fsm:       fsm,
+		done:      make(chan struct{}),
+	}
+
+	return &p, nil
+}
+
+// Start starts the mapper of the pipeline in the background.
+func (p *Pipeline) Start() {
+	go func() {
+		p.err = p.fsm.Run()
+		close(p.done)
+	}()
+}
+
+// Done returns a channel that is closed when the mapper stops.
+func (p *Pipeline) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err returns the error that stopped the mapper, once it has stopped.
+func (p *Pipeline) Err() error {
+	return p.err
+}
+
+// Last returns the last indexed height, or zero if nothing was indexed yet.
+func (p *Pipeline) Last() (uint64, error) {
+	last, err := p.read.Last(context.Background())
+	if errors.Is(err, dps.ErrBootstrapping) {
+		return 0, nil
+	}
+	return last, err
+}
+
+// Stream streams the given execution record.
+func (p *Pipeline) Stream(record *uploader.BlockData) {
+	p.stream.Push(record)
+}
+
+// Finalize notifies the consensus tracker of the given finalized block.
+func (p *Pipeline) Finalize(blockID flow.Identifier) {
+	p.follow.OnBlockFinalized(blockID)
+}
+
+// Restart replaces the consensus tracker with a new one, which picks up the
+// finalized height from the protocol state database, as happens when the
+// consensus follower restarts.
+func (p *Pipeline) Restart() error {
+	consensus, err := tracker.NewConsensus(p.log, p.db, p.execution)
+	if err != nil {
+		return fmt.Errorf("could not initialize consensus tracker: %w", err)
+	}
+	p.follow.Restart(consensus)
+	return nil
+}
+
+// Stop stops the mapper, if it is still running, and closes the index writer.
+func (p *Pipeline) Stop() error {
+
+	err := p.fsm.Stop()
+	if err != nil {
+		return fmt.Errorf("could not stop mapper: %w", err)
+	}
+	err = p.write.Close()
+	if err != nil {
+		return fmt.Errorf("could not close index writer: %w", err)
+	}
+
+	return nil
+}
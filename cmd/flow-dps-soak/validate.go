@@ -0,0 +1,155 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/onflow/flow-go/engine/execution/computation/computer/uploader"
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// validate checks that the index holds exactly the data of the given execution
+// records, which should cover all heights from the root height onwards.
+func validate(ctx context.Context, read dps.Reader, records []*uploader.BlockData) error {
+
+	root := records[0].Block.Header.Height
+	final := records[len(records)-1].Block.Header.Height
+
+	first, err := read.First(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get first height: %w", err)
+	}
+	if first != root {
+		return fmt.Errorf("wrong first height (first: %d, root: %d)", first, root)
+	}
+	last, err := read.Last(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get last height: %w", err)
+	}
+	if last != final {
+		return fmt.Errorf("wrong last height (last: %d, finalized: %d)", last, final)
+	}
+
+	// We keep track of the latest value of each register, so that we can check
+	// the whole execution state at the last height in the end.
+	state := make(map[ledger.Path]ledger.Value)
+	for _, record := range records {
+		err := validateHeight(ctx, read, record)
+		if err != nil {
+			return fmt.Errorf("invalid index at height %d: %w", record.Block.Header.Height, err)
+		}
+		for _, update := range record.TrieUpdates {
+			for i, path := range update.Paths {
+				state[path] = update.Payloads[i].Value
+			}
+		}
+	}
+
+	paths := make([]ledger.Path, 0, len(state))
+	for path := range state {
+		paths = append(paths, path)
+	}
+	values, err := read.Values(ctx, last, paths)
+	if err != nil {
+		return fmt.Errorf("could not get values at last height: %w", err)
+	}
+	for i, path := range paths {
+		if !bytes.Equal(values[i], state[path]) {
+			return fmt.Errorf("wrong register value at last height (path: %x)", path)
+		}
+	}
+
+	return nil
+}
+
+// validateHeight checks that the index holds the data of the given execution
+// record at its height.
+func validateHeight(ctx context.Context, read dps.Reader, record *uploader.BlockData) error {
+
+	height := record.Block.Header.Height
+
+	header, err := read.Header(ctx, height)
+	if err != nil {
+		return fmt.Errorf("could not get header: %w", err)
+	}
+	if header.ID() != record.Block.Header.ID() {
+		return fmt.Errorf("wrong block (block: %x, indexed: %x)", record.Block.Header.ID(), header.ID())
+	}
+
+	commit, err := read.Commit(ctx, height)
+	if err != nil {
+		return fmt.Errorf("could not get commit: %w", err)
+	}
+	if commit != record.FinalStateCommitment {
+		return fmt.Errorf("wrong commit (commit: %x, indexed: %x)", record.FinalStateCommitment, commit)
+	}
+
+	events, err := read.Events(ctx, height)
+	if err != nil {
+		return fmt.Errorf("could not get events: %w", err)
+	}
+	if len(events) != len(record.Events) {
+		return fmt.Errorf("wrong number of events (events: %d, indexed: %d)", len(record.Events), len(events))
+	}
+	indexed := make(map[flow.Identifier]struct{}, len(events))
+	for _, event := range events {
+		indexed[event.ID()] = struct{}{}
+	}
+	for _, event := range record.Events {
+		_, ok := indexed[event.ID()]
+		if !ok {
+			return fmt.Errorf("missing event (transaction: %x, index: %d)", event.TransactionID, event.EventIndex)
+		}
+	}
+
+	if len(record.Collections) > 0 {
+		txIDs, err := read.TransactionsByHeight(ctx, height)
+		if err != nil {
+			return fmt.Errorf("could not get transactions: %w", err)
+		}
+		lookup := make(map[flow.Identifier]struct{})
+		for _, txID := range txIDs {
+			lookup[txID] = struct{}{}
+		}
+		for _, complete := range record.Collections {
+			for _, transaction := range complete.Transactions {
+				_, ok := lookup[transaction.ID()]
+				if !ok {
+					return fmt.Errorf("missing transaction (transaction: %x)", transaction.ID())
+				}
+			}
+		}
+	}
+
+	for _, update := range record.TrieUpdates {
+		values, err := read.Values(ctx, height, update.Paths)
+		if err != nil {
+			return fmt.Errorf("could not get values: %w", err)
+		}
+		for i, path := range update.Paths {
+			if !bytes.Equal(values[i], update.Payloads[i].Value) {
+				return fmt.Errorf("wrong register value (path: %x)", path)
+			}
+		}
+	}
+
+	return nil
+}
@@ -16,6 +16,7 @@ package main
 
 import (
 	"compress/gzip"
+	"context"
 	"encoding/base64"
 	"encoding/hex"
 	"io"
@@ -62,11 +63,13 @@ func run() int {
 		flagCompression string
 		flagEncoding    string
 		flagIndex       string
+		flagVerify      bool
 	)
 
 	pflag.StringVarP(&flagCompression, "compression", "c", compressionZstd, "compression algorithm (\"none\", \"zstd\" or \"gzip\")")
 	pflag.StringVarP(&flagEncoding, "encoding", "e", encodingNone, "output encoding (\"none\", \"hex\" or \"base64\")")
 	pflag.StringVarP(&flagIndex, "index", "i", "index", "database directory for state index")
+	pflag.BoolVar(&flagVerify, "verify", false, "verify the restored index against its integrity manifests")
 
 	pflag.Parse()
 
@@ -83,8 +86,9 @@ func run() int {
 	defer db.Close()
 
 	// Check if the database is empty.
+	ctx := context.Background()
 	index := index.NewReader(db, storage.New(zbor.NewCodec()))
-	_, err = index.First()
+	_, err = index.First(ctx)
 	if err == nil {
 		log.Error().Msg("database directory already contains index database")
 		return failure
@@ -133,5 +137,33 @@ func run() int {
 
 	log.Info().Msg("snapshot restoration complete")
 
+	if !flagVerify {
+		return success
+	}
+
+	// If requested, we check the restored data of all indexed heights against
+	// the integrity manifests that were recorded when it was indexed.
+	first, err := index.First(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("could not get first height")
+		return failure
+	}
+	last, err := index.Last(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("could not get last height")
+		return failure
+	}
+	mismatches, err := index.Verify(ctx, first, last)
+	if err != nil {
+		log.Error().Err(err).Msg("could not verify integrity manifests")
+		return failure
+	}
+	if len(mismatches) != 0 {
+		log.Error().Uints64("mismatches", mismatches).Msg("restored index does not match integrity manifests")
+		return failure
+	}
+
+	log.Info().Uint64("first", first).Uint64("last", last).Msg("snapshot verification complete")
+
 	return success
 }
@@ -0,0 +1,67 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package cadencejson
+
+import (
+	"fmt"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/encoding/json"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// Encode encodes the given Cadence value to JSON-Cadence, which is the format
+// used for event payloads, script arguments and script results on Flow.
+func Encode(value cadence.Value) ([]byte, error) {
+	data, err := json.Encode(value)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode cadence value: %w", err)
+	}
+	return data, nil
+}
+
+// Decode decodes the given JSON-Cadence data into a Cadence value.
+func Decode(data []byte) (cadence.Value, error) {
+	value, err := json.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode cadence value: %w", err)
+	}
+	return value, nil
+}
+
+// DecodeEvent decodes the JSON-Cadence payload of the given indexed Flow event
+// into a Cadence event value.
+func DecodeEvent(event flow.Event) (cadence.Event, error) {
+	value, err := Decode(event.Payload)
+	if err != nil {
+		return cadence.Event{}, fmt.Errorf("could not decode event payload: %w", err)
+	}
+	decoded, ok := value.(cadence.Event)
+	if !ok {
+		return cadence.Event{}, fmt.Errorf("invalid event payload type (%T)", value)
+	}
+	return decoded, nil
+}
+
+// Unmarshal decodes the given JSON-Cadence data and stores the result in the
+// value pointed to by v. See Convert for the supported conversions.
+func Unmarshal(data []byte, v interface{}) error {
+	value, err := Decode(data)
+	if err != nil {
+		return err
+	}
+	return Convert(value, v)
+}
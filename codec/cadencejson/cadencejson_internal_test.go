@@ -0,0 +1,284 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package cadencejson
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/tests/utils"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestDecodeEvent(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		event := mocks.GenericEvents(1)[0]
+
+		got, err := DecodeEvent(event)
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericCadenceEvent(0).Fields, got.Fields)
+		assert.Equal(t, mocks.GenericCadenceEvent(0).EventType.QualifiedIdentifier, got.EventType.QualifiedIdentifier)
+	})
+
+	t.Run("handles invalid payload", func(t *testing.T) {
+		t.Parallel()
+
+		event := mocks.GenericEvents(1)[0]
+		event.Payload = mocks.GenericBytes
+
+		_, err := DecodeEvent(event)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles non-event payload", func(t *testing.T) {
+		t.Parallel()
+
+		payload, err := Encode(cadence.NewUInt64(42))
+		require.NoError(t, err)
+
+		event := mocks.GenericEvents(1)[0]
+		event.Payload = payload
+
+		_, err = DecodeEvent(event)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestNative(t *testing.T) {
+	address := mocks.GenericAddress(0)
+	path := cadence.Path{Domain: "public", Identifier: "vault"}
+
+	tests := []struct {
+		name     string
+		value    cadence.Value
+		want     interface{}
+		checkErr assert.ErrorAssertionFunc
+	}{
+		{
+			name:     "void",
+			value:    cadence.NewVoid(),
+			want:     nil,
+			checkErr: assert.NoError,
+		},
+		{
+			name:     "empty optional",
+			value:    cadence.NewOptional(nil),
+			want:     nil,
+			checkErr: assert.NoError,
+		},
+		{
+			name:     "optional string",
+			value:    cadence.NewOptional(cadence.String("test")),
+			want:     "test",
+			checkErr: assert.NoError,
+		},
+		{
+			name:     "address",
+			value:    cadence.NewAddress(address),
+			want:     address,
+			checkErr: assert.NoError,
+		},
+		{
+			name:     "fixed-size integer",
+			value:    cadence.NewUInt64(42),
+			want:     uint64(42),
+			checkErr: assert.NoError,
+		},
+		{
+			name:     "big integer",
+			value:    cadence.NewInt(-42),
+			want:     big.NewInt(-42),
+			checkErr: assert.NoError,
+		},
+		{
+			name:     "fixed-point number",
+			value:    cadence.UFix64(150_000_000),
+			want:     "1.50000000",
+			checkErr: assert.NoError,
+		},
+		{
+			name:     "array",
+			value:    cadence.NewArray([]cadence.Value{cadence.NewBool(true), cadence.String("test")}),
+			want:     []interface{}{true, "test"},
+			checkErr: assert.NoError,
+		},
+		{
+			name: "dictionary",
+			value: cadence.NewDictionary([]cadence.KeyValuePair{
+				{Key: cadence.NewUInt8(1), Value: cadence.String("one")},
+			}),
+			want:     map[string]interface{}{"1": "one"},
+			checkErr: assert.NoError,
+		},
+		{
+			name:     "event",
+			value:    mocks.GenericCadenceEvent(0),
+			want:     map[string]interface{}{"amount": mocks.GenericCadenceEvent(0).Fields[0].ToGoValue(), "address": address},
+			checkErr: assert.NoError,
+		},
+		{
+			name:     "path",
+			value:    path,
+			want:     "/public/vault",
+			checkErr: assert.NoError,
+		},
+		{
+			name:     "capability",
+			value:    cadence.Capability{Path: path, Address: cadence.NewAddress(address), BorrowType: "&Vault"},
+			want:     Capability{Path: "/public/vault", Address: address, BorrowType: "&Vault"},
+			checkErr: assert.NoError,
+		},
+		{
+			name:     "link",
+			value:    cadence.NewLink(path, "&Vault"),
+			want:     Link{TargetPath: "/public/vault", BorrowType: "&Vault"},
+			checkErr: assert.NoError,
+		},
+		{
+			name:     "handles untyped composite",
+			value:    cadence.NewStruct([]cadence.Value{cadence.NewBool(true)}),
+			checkErr: assert.Error,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Native(test.value)
+
+			test.checkErr(t, err)
+			if err == nil {
+				assert.Equal(t, test.want, got)
+			}
+		})
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		type Deposit struct {
+			Amount  uint64       `cadence:"amount"`
+			Address flow.Address `cadence:"address"`
+		}
+
+		event := mocks.GenericEvents(1)[0]
+
+		var got Deposit
+		err := Unmarshal(event.Payload, &got)
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(mocks.GenericCadenceEvent(0).Fields[0].(cadence.UInt64)), got.Amount)
+		assert.Equal(t, mocks.GenericAddress(0), got.Address)
+	})
+
+	t.Run("handles nested composites and capabilities", func(t *testing.T) {
+		t.Parallel()
+
+		type Receiver struct {
+			Owner    *string
+			Balance  float64
+			Tags     map[string]int
+			Receiver cadence.Capability `cadence:"receiver"`
+			Ignored  bool               `cadence:"-"`
+		}
+
+		path := cadence.Path{Domain: "public", Identifier: "receiver"}
+		capability := cadence.Capability{Path: path, Address: cadence.NewAddress(mocks.GenericAddress(0)), BorrowType: "&Receiver"}
+		typ := &cadence.StructType{
+			Location:            utils.TestLocation,
+			QualifiedIdentifier: "Receiver",
+			Fields: []cadence.Field{
+				{Identifier: "Owner", Type: cadence.OptionalType{Type: cadence.StringType{}}},
+				{Identifier: "Balance", Type: cadence.UFix64Type{}},
+				{Identifier: "Tags", Type: cadence.DictionaryType{KeyType: cadence.StringType{}, ElementType: cadence.Int8Type{}}},
+				{Identifier: "receiver", Type: cadence.CapabilityType{BorrowType: cadence.AnyType{}}},
+				{Identifier: "Ignored", Type: cadence.BoolType{}},
+			},
+		}
+		value := cadence.NewStruct([]cadence.Value{
+			cadence.NewOptional(cadence.String("alice")),
+			cadence.UFix64(250_000_000),
+			cadence.NewDictionary([]cadence.KeyValuePair{{Key: cadence.String("a"), Value: cadence.NewInt8(-1)}}),
+			capability,
+			cadence.NewBool(true),
+		}).WithType(typ)
+
+		data, err := Encode(value)
+		require.NoError(t, err)
+
+		var got Receiver
+		err = Unmarshal(data, &got)
+
+		require.NoError(t, err)
+		require.NotNil(t, got.Owner)
+		assert.Equal(t, "alice", *got.Owner)
+		assert.Equal(t, 2.5, got.Balance)
+		assert.Equal(t, map[string]int{"a": -1}, got.Tags)
+		assert.Equal(t, capability, got.Receiver)
+		assert.False(t, got.Ignored)
+	})
+
+	t.Run("handles integer overflow", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := Encode(cadence.NewUInt16(300))
+		require.NoError(t, err)
+
+		var got uint8
+		err = Unmarshal(data, &got)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles type mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := Encode(cadence.String("test"))
+		require.NoError(t, err)
+
+		var got int
+		err = Unmarshal(data, &got)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles invalid target", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := Encode(cadence.String("test"))
+		require.NoError(t, err)
+
+		var got string
+		err = Unmarshal(data, got)
+
+		assert.Error(t, err)
+	})
+}
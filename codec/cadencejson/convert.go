@@ -0,0 +1,339 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package cadencejson
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/onflow/cadence"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// fixedPointFactor is the scaling factor of Cadence fixed-point numbers.
+const fixedPointFactor = 100_000_000
+
+var (
+	emptyType   = reflect.TypeOf((*interface{})(nil)).Elem()
+	bigIntType  = reflect.TypeOf(big.Int{})
+	addressType = reflect.TypeOf(flow.Address{})
+)
+
+// Convert stores the given Cadence value in the value pointed to by v, which
+// has to be a non-nil pointer. Beyond the conversions done by `Native` for
+// empty interfaces, Convert supports the following target types:
+//   - any Cadence value type the value can be assigned to;
+//   - Go integers, with overflow checks, and `big.Int`, for Cadence integers;
+//   - `float64` and `float32` for Cadence fixed-point numbers;
+//   - `flow.Address` for Cadence addresses;
+//   - strings for Cadence strings, addresses, paths, type values and
+//     fixed-point numbers;
+//   - slices and arrays for Cadence arrays;
+//   - maps for Cadence dictionaries;
+//   - structs for Cadence composites, where fields are matched using the
+//     `cadence` struct tag, or the Go field name if there is no tag, and
+//     where Cadence fields without matching Go field are ignored;
+//   - pointers to any of the above, which are allocated as needed.
+func Convert(value cadence.Value, v interface{}) error {
+
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("invalid conversion target (%T)", v)
+	}
+
+	return convert(value, target.Elem())
+}
+
+func convert(value cadence.Value, target reflect.Value) error {
+
+	// If the target can hold the Cadence value as it is, we don't need to
+	// convert it at all. This covers targets of type `cadence.Value` as well as
+	// concrete Cadence types.
+	if value != nil && target.Type() != emptyType && reflect.TypeOf(value).AssignableTo(target.Type()) {
+		target.Set(reflect.ValueOf(value))
+		return nil
+	}
+
+	// Optionals and void values are unwrapped, where a missing value sets the
+	// target to its zero value.
+	switch v := value.(type) {
+	case nil, cadence.Void:
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	case cadence.Optional:
+		return convert(v.Value, target)
+	}
+
+	switch target.Kind() {
+
+	case reflect.Ptr:
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		return convert(value, target.Elem())
+
+	case reflect.Interface:
+		if target.Type().NumMethod() != 0 {
+			return fmt.Errorf("unsupported interface target (%s)", target.Type())
+		}
+		native, err := Native(value)
+		if err != nil {
+			return err
+		}
+		if native == nil {
+			target.Set(reflect.Zero(target.Type()))
+			return nil
+		}
+		target.Set(reflect.ValueOf(native))
+		return nil
+
+	case reflect.Bool:
+		v, ok := value.(cadence.Bool)
+		if !ok {
+			return mismatch(value, target)
+		}
+		target.SetBool(bool(v))
+		return nil
+
+	case reflect.String:
+		switch v := value.(type) {
+		case cadence.String:
+			target.SetString(string(v))
+		case cadence.Address:
+			target.SetString(flow.Address(v).Hex())
+		case cadence.Path:
+			target.SetString(v.String())
+		case cadence.TypeValue:
+			target.SetString(v.StaticType)
+		case cadence.Fix64:
+			target.SetString(v.String())
+		case cadence.UFix64:
+			target.SetString(v.String())
+		default:
+			return mismatch(value, target)
+		}
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, err := integer(value)
+		if err != nil {
+			return mismatch(value, target)
+		}
+		if !number.IsInt64() || target.OverflowInt(number.Int64()) {
+			return fmt.Errorf("integer overflow (value: %s, target: %s)", number, target.Type())
+		}
+		target.SetInt(number.Int64())
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number, err := integer(value)
+		if err != nil {
+			return mismatch(value, target)
+		}
+		if !number.IsUint64() || target.OverflowUint(number.Uint64()) {
+			return fmt.Errorf("integer overflow (value: %s, target: %s)", number, target.Type())
+		}
+		target.SetUint(number.Uint64())
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		switch v := value.(type) {
+		case cadence.Fix64:
+			target.SetFloat(float64(v) / fixedPointFactor)
+		case cadence.UFix64:
+			target.SetFloat(float64(v) / fixedPointFactor)
+		default:
+			return mismatch(value, target)
+		}
+		return nil
+
+	case reflect.Slice:
+		switch v := value.(type) {
+		case cadence.Bytes:
+			if target.Type().Elem().Kind() != reflect.Uint8 {
+				return mismatch(value, target)
+			}
+			target.SetBytes(append([]byte{}, v...))
+			return nil
+		case cadence.Array:
+			slice := reflect.MakeSlice(target.Type(), len(v.Values), len(v.Values))
+			for i, element := range v.Values {
+				err := convert(element, slice.Index(i))
+				if err != nil {
+					return fmt.Errorf("could not convert array element (index: %d): %w", i, err)
+				}
+			}
+			target.Set(slice)
+			return nil
+		default:
+			return mismatch(value, target)
+		}
+
+	case reflect.Array:
+		if target.Type() == addressType {
+			v, ok := value.(cadence.Address)
+			if !ok {
+				return mismatch(value, target)
+			}
+			target.Set(reflect.ValueOf(flow.Address(v)))
+			return nil
+		}
+		v, ok := value.(cadence.Array)
+		if !ok {
+			return mismatch(value, target)
+		}
+		if len(v.Values) != target.Len() {
+			return fmt.Errorf("mismatching array length (value: %d, target: %d)", len(v.Values), target.Len())
+		}
+		for i, element := range v.Values {
+			err := convert(element, target.Index(i))
+			if err != nil {
+				return fmt.Errorf("could not convert array element (index: %d): %w", i, err)
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		v, ok := value.(cadence.Dictionary)
+		if !ok {
+			return mismatch(value, target)
+		}
+		pairs := reflect.MakeMapWithSize(target.Type(), len(v.Pairs))
+		for _, pair := range v.Pairs {
+			key := reflect.New(target.Type().Key()).Elem()
+			err := convert(pair.Key, key)
+			if err != nil {
+				return fmt.Errorf("could not convert dictionary key: %w", err)
+			}
+			element := reflect.New(target.Type().Elem()).Elem()
+			err = convert(pair.Value, element)
+			if err != nil {
+				return fmt.Errorf("could not convert dictionary value (key: %v): %w", key, err)
+			}
+			pairs.SetMapIndex(key, element)
+		}
+		target.Set(pairs)
+		return nil
+
+	case reflect.Struct:
+		return convertStruct(value, target)
+
+	default:
+		return mismatch(value, target)
+	}
+}
+
+func convertStruct(value cadence.Value, target reflect.Value) error {
+
+	// Arbitrary precision integers are stored by value in the target.
+	if target.Type() == bigIntType {
+		number, err := integer(value)
+		if err != nil {
+			return mismatch(value, target)
+		}
+		target.Set(reflect.ValueOf(number).Elem())
+		return nil
+	}
+
+	// Links and capabilities have a native representation that can be used as
+	// target directly.
+	switch value.(type) {
+	case cadence.Link, cadence.Capability:
+		native, err := Native(value)
+		if err != nil {
+			return err
+		}
+		if !reflect.TypeOf(native).AssignableTo(target.Type()) {
+			return mismatch(value, target)
+		}
+		target.Set(reflect.ValueOf(native))
+		return nil
+	}
+
+	fields, values, err := composite(value)
+	if err != nil {
+		return mismatch(value, target)
+	}
+
+	// Map the Cadence field names to the indices of the Go struct fields.
+	indices := make(map[string]int, target.NumField())
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		tag, ok := field.Tag.Lookup("cadence")
+		if ok {
+			name = strings.Split(tag, ",")[0]
+		}
+		if name == "-" {
+			continue
+		}
+		indices[name] = i
+	}
+
+	for i, field := range fields {
+		index, ok := indices[field.Identifier]
+		if !ok {
+			continue
+		}
+		err := convert(values[i], target.Field(index))
+		if err != nil {
+			return fmt.Errorf("could not convert composite field (name: %s): %w", field.Identifier, err)
+		}
+	}
+
+	return nil
+}
+
+// integer returns the value of a Cadence integer as a big integer.
+func integer(value cadence.Value) (*big.Int, error) {
+	switch v := value.ToGoValue().(type) {
+	case *big.Int:
+		return new(big.Int).Set(v), nil
+	case int8:
+		return big.NewInt(int64(v)), nil
+	case int16:
+		return big.NewInt(int64(v)), nil
+	case int32:
+		return big.NewInt(int64(v)), nil
+	case int64:
+		if _, ok := value.(cadence.Fix64); ok {
+			break
+		}
+		return big.NewInt(v), nil
+	case uint8:
+		return new(big.Int).SetUint64(uint64(v)), nil
+	case uint16:
+		return new(big.Int).SetUint64(uint64(v)), nil
+	case uint32:
+		return new(big.Int).SetUint64(uint64(v)), nil
+	case uint64:
+		if _, ok := value.(cadence.UFix64); ok {
+			break
+		}
+		return new(big.Int).SetUint64(v), nil
+	}
+	return nil, errors.New("not an integer")
+}
+
+func mismatch(value cadence.Value, target reflect.Value) error {
+	return fmt.Errorf("could not convert %T to %s", value, target.Type())
+}
@@ -0,0 +1,199 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package cadencejson
+
+import (
+	"fmt"
+
+	"github.com/onflow/cadence"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// Link is the native Go representation of a Cadence link.
+type Link struct {
+	TargetPath string `json:"target_path"`
+	BorrowType string `json:"borrow_type"`
+}
+
+// Capability is the native Go representation of a Cadence capability.
+type Capability struct {
+	Path       string       `json:"path"`
+	Address    flow.Address `json:"address"`
+	BorrowType string       `json:"borrow_type"`
+}
+
+// Native converts the given Cadence value into a native Go value, which can be
+// used directly or marshalled with the standard library JSON encoder:
+//   - `Void` and empty optionals become `nil`, other optionals are unwrapped;
+//   - booleans, strings and byte slices become their Go equivalent;
+//   - addresses become `flow.Address`;
+//   - fixed-size integers become the matching Go integer type, while arbitrary
+//     precision integers and 128/256-bit integers become `*big.Int`;
+//   - fixed-point numbers become their lossless decimal string representation;
+//   - arrays become `[]interface{}`;
+//   - dictionaries become `map[string]interface{}`, with non-string keys being
+//     formatted with their default Go format;
+//   - composites (structs, resources, events, contracts and enums) become
+//     `map[string]interface{}` with one entry per field;
+//   - paths and type values become their string representation;
+//   - links and capabilities become `Link` and `Capability` respectively.
+func Native(value cadence.Value) (interface{}, error) {
+
+	switch v := value.(type) {
+
+	case nil, cadence.Void:
+		return nil, nil
+
+	case cadence.Optional:
+		return Native(v.Value)
+
+	case cadence.Bool:
+		return bool(v), nil
+
+	case cadence.String:
+		return string(v), nil
+
+	case cadence.Bytes:
+		return []byte(v), nil
+
+	case cadence.Address:
+		return flow.Address(v), nil
+
+	case cadence.Fix64:
+		return v.String(), nil
+
+	case cadence.UFix64:
+		return v.String(), nil
+
+	case cadence.Int, cadence.Int8, cadence.Int16, cadence.Int32, cadence.Int64, cadence.Int128, cadence.Int256,
+		cadence.UInt, cadence.UInt8, cadence.UInt16, cadence.UInt32, cadence.UInt64, cadence.UInt128, cadence.UInt256,
+		cadence.Word8, cadence.Word16, cadence.Word32, cadence.Word64:
+		return v.ToGoValue(), nil
+
+	case cadence.Array:
+		values := make([]interface{}, 0, len(v.Values))
+		for i, element := range v.Values {
+			native, err := Native(element)
+			if err != nil {
+				return nil, fmt.Errorf("could not convert array element (index: %d): %w", i, err)
+			}
+			values = append(values, native)
+		}
+		return values, nil
+
+	case cadence.Dictionary:
+		pairs := make(map[string]interface{}, len(v.Pairs))
+		for _, pair := range v.Pairs {
+			key, err := Native(pair.Key)
+			if err != nil {
+				return nil, fmt.Errorf("could not convert dictionary key: %w", err)
+			}
+			value, err := Native(pair.Value)
+			if err != nil {
+				return nil, fmt.Errorf("could not convert dictionary value (key: %v): %w", key, err)
+			}
+			pairs[fmt.Sprint(key)] = value
+		}
+		return pairs, nil
+
+	case cadence.Struct, cadence.Resource, cadence.Event, cadence.Contract, cadence.Enum:
+		fields, values, err := composite(v)
+		if err != nil {
+			return nil, err
+		}
+		natives := make(map[string]interface{}, len(fields))
+		for i, field := range fields {
+			native, err := Native(values[i])
+			if err != nil {
+				return nil, fmt.Errorf("could not convert composite field (name: %s): %w", field.Identifier, err)
+			}
+			natives[field.Identifier] = native
+		}
+		return natives, nil
+
+	case cadence.Path:
+		return v.String(), nil
+
+	case cadence.TypeValue:
+		return v.StaticType, nil
+
+	case cadence.Link:
+		link := Link{
+			TargetPath: v.TargetPath.String(),
+			BorrowType: v.BorrowType,
+		}
+		return link, nil
+
+	case cadence.Capability:
+		capability := Capability{
+			Path:       v.Path.String(),
+			Address:    flow.Address(v.Address),
+			BorrowType: v.BorrowType,
+		}
+		return capability, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported cadence value type (%T)", value)
+	}
+}
+
+// composite returns the field definitions and the field values of the given
+// composite Cadence value. Composite values decoded from JSON-Cadence always
+// have their type set, but values created manually might not.
+func composite(value cadence.Value) ([]cadence.Field, []cadence.Value, error) {
+
+	var fields []cadence.Field
+	var values []cadence.Value
+	var typed bool
+	switch v := value.(type) {
+	case cadence.Struct:
+		typed, values = v.StructType != nil, v.Fields
+		if typed {
+			fields = v.StructType.Fields
+		}
+	case cadence.Resource:
+		typed, values = v.ResourceType != nil, v.Fields
+		if typed {
+			fields = v.ResourceType.Fields
+		}
+	case cadence.Event:
+		typed, values = v.EventType != nil, v.Fields
+		if typed {
+			fields = v.EventType.Fields
+		}
+	case cadence.Contract:
+		typed, values = v.ContractType != nil, v.Fields
+		if typed {
+			fields = v.ContractType.Fields
+		}
+	case cadence.Enum:
+		typed, values = v.EnumType != nil, v.Fields
+		if typed {
+			fields = v.EnumType.Fields
+		}
+	default:
+		return nil, nil, fmt.Errorf("invalid composite value type (%T)", value)
+	}
+
+	if !typed {
+		return nil, nil, fmt.Errorf("missing composite type (%T)", value)
+	}
+	if len(fields) != len(values) {
+		return nil, nil, fmt.Errorf("mismatching composite field count (fields: %d, values: %d)", len(fields), len(values))
+	}
+
+	return fields, values, nil
+}
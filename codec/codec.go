@@ -0,0 +1,41 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package codec
+
+import (
+	"fmt"
+
+	"github.com/optakt/flow-dps/codec/protostore"
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// New creates the codec with the given name, which is one of the names of the
+// codecs with which an index can be stored. The given options configure the
+// zbor codec, which the other codecs use for the values they do not encode
+// themselves.
+func New(name string, options ...zbor.Option) (dps.Codec, error) {
+
+	fallback := zbor.NewCodec(options...)
+
+	switch name {
+	case dps.CodecZbor:
+		return fallback, nil
+	case dps.CodecProtostore:
+		return protostore.NewCodec(fallback), nil
+	default:
+		return nil, fmt.Errorf("unknown codec (name: %s)", name)
+	}
+}
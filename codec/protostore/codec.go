@@ -0,0 +1,313 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package protostore
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// zstdMagic is the magic number at the start of each zstandard frame. As the
+// first byte of an encoded message is the tag of one of its fields, it never
+// starts a message that was stored without compression.
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// Codec encodes the values that are read the most from the index, which are
+// the batches of events and the payloads of registers, as protobuf messages
+// compressed with zstandard, as they decode significantly faster than their
+// CBOR encoding. All other values are encoded by a zbor codec, so that they
+// are stored exactly as in an index created with the zbor codec.
+type Codec struct {
+	fallback     *zbor.Codec
+	compressor   *zstd.Encoder
+	decompressor *zstd.Decoder
+
+	mutex   *sync.Mutex
+	leveled map[zstd.EncoderLevel]*zstd.Encoder // compressors for non-default levels, created on demand
+}
+
+// NewCodec creates a new Codec, which uses the given zbor codec for values
+// that have no protobuf message.
+func NewCodec(fallback *zbor.Codec) *Codec {
+
+	// We should never fail here if the options are valid, so use panic to keep
+	// the function signature for the codec clean.
+	compressor, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	if err != nil {
+		panic(err)
+	}
+	decompressor, err := zstd.NewReader(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	c := Codec{
+		fallback:     fallback,
+		compressor:   compressor,
+		decompressor: decompressor,
+
+		mutex:   &sync.Mutex{},
+		leveled: make(map[zstd.EncoderLevel]*zstd.Encoder),
+	}
+
+	return &c
+}
+
+// Encode returns the protobuf encoding of the given value, or its CBOR
+// encoding if it has no protobuf message.
+func (c *Codec) Encode(value interface{}) ([]byte, error) {
+	msg, ok := toMessage(value)
+	if !ok {
+		return c.fallback.Encode(value)
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+}
+
+// Compress encodes the given bytes into a compressed format using zstandard.
+func (c *Codec) Compress(data []byte) ([]byte, error) {
+	return c.fallback.Compress(data)
+}
+
+// Marshal encodes the given value and then compresses it, and returns the
+// resulting slice of bytes.
+func (c *Codec) Marshal(value interface{}) ([]byte, error) {
+	return c.MarshalWith(value, dps.CompressionDefault)
+}
+
+// MarshalWith encodes the given value and then compresses it with the given
+// compression level, and returns the resulting slice of bytes.
+func (c *Codec) MarshalWith(value interface{}, compression dps.Compression) ([]byte, error) {
+
+	msg, ok := toMessage(value)
+	if !ok {
+		return c.fallback.MarshalWith(value, compression)
+	}
+
+	var level zstd.EncoderLevel
+	switch compression {
+	case dps.CompressionDefault:
+		level = zstd.SpeedDefault
+	case dps.CompressionNone:
+		level = 0
+	case dps.CompressionFastest:
+		level = zstd.SpeedFastest
+	case dps.CompressionBetter:
+		level = zstd.SpeedBetterCompression
+	case dps.CompressionBest:
+		level = zstd.SpeedBestCompression
+	default:
+		return nil, fmt.Errorf("unknown compression (compression: %d)", compression)
+	}
+
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode value: %w", err)
+	}
+	if compression == dps.CompressionNone {
+		return data, nil
+	}
+
+	compressor, err := c.compressorFor(level)
+	if err != nil {
+		return nil, fmt.Errorf("could not create compressor: %w", err)
+	}
+
+	compressed := compressor.EncodeAll(data, nil)
+
+	return compressed, nil
+}
+
+// compressorFor returns the compressor for the given level. The compressors
+// for non-default levels are created on first use, as they are only needed
+// for the resources that are configured with such a level.
+func (c *Codec) compressorFor(level zstd.EncoderLevel) (*zstd.Encoder, error) {
+
+	if level == zstd.SpeedDefault {
+		return c.compressor, nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	compressor, ok := c.leveled[level]
+	if ok {
+		return compressor, nil
+	}
+
+	compressor, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(level))
+	if err != nil {
+		return nil, err
+	}
+	c.leveled[level] = compressor
+
+	return compressor, nil
+}
+
+// Decode parses the protobuf encoding of the given value, or its CBOR encoding
+// if it has no protobuf message.
+func (c *Codec) Decode(data []byte, value interface{}) error {
+
+	msg, ok := newMessage(value)
+	if !ok {
+		return c.fallback.Decode(data, value)
+	}
+
+	err := proto.Unmarshal(data, msg)
+	if err != nil {
+		return err
+	}
+	fromMessage(msg, value)
+
+	return nil
+}
+
+// Decompress reads compressed data that uses the zstandard format and returns
+// the original uncompressed byte slice.
+func (c *Codec) Decompress(compressed []byte) ([]byte, error) {
+	return c.fallback.Decompress(compressed)
+}
+
+// Unmarshal decompresses the given bytes and decodes the resulting data into
+// the given value.
+func (c *Codec) Unmarshal(compressed []byte, value interface{}) error {
+
+	_, ok := newMessage(value)
+	if !ok {
+		return c.fallback.Unmarshal(compressed, value)
+	}
+
+	data := compressed
+	if bytes.HasPrefix(compressed, zstdMagic) {
+		var err error
+		data, err = c.decompressor.DecodeAll(compressed, nil)
+		if err != nil {
+			return fmt.Errorf("could not decompress value: %w", err)
+		}
+	}
+
+	err := c.Decode(data, value)
+	if err != nil {
+		return fmt.Errorf("could not decode value: %w", err)
+	}
+
+	return nil
+}
+
+// Transcode converts the given value, as it was marshaled by the codec, into
+// the encoding of the zbor codec, with which clients decode the values that
+// are served to them as stored. The given value is the pointer into which the
+// value is decoded on the way.
+func (c *Codec) Transcode(compressed []byte, value interface{}) ([]byte, error) {
+
+	_, ok := newMessage(value)
+	if !ok {
+		return compressed, nil
+	}
+
+	err := c.Unmarshal(compressed, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.fallback.Marshal(reflect.ValueOf(value).Elem().Interface())
+}
+
+// toMessage converts the given value into its protobuf message, if it has one.
+func toMessage(value interface{}) (proto.Message, bool) {
+
+	switch v := value.(type) {
+	case *ledger.Payload:
+		msg := Payload{
+			KeyParts: make([]*KeyPart, 0, len(v.Key.KeyParts)),
+			Value:    v.Value,
+		}
+		for _, part := range v.Key.KeyParts {
+			msg.KeyParts = append(msg.KeyParts, &KeyPart{Type: uint32(part.Type), Value: part.Value})
+		}
+		return &msg, true
+
+	case []flow.Event:
+		msg := Events{
+			Events: make([]*Event, 0, len(v)),
+		}
+		for _, event := range v {
+			txID := event.TransactionID
+			msg.Events = append(msg.Events, &Event{
+				Type:             string(event.Type),
+				TransactionID:    txID[:],
+				TransactionIndex: event.TransactionIndex,
+				EventIndex:       event.EventIndex,
+				Payload:          event.Payload,
+			})
+		}
+		return &msg, true
+
+	default:
+		return nil, false
+	}
+}
+
+// newMessage returns an empty protobuf message into which the given value can
+// be decoded, if it has one.
+func newMessage(value interface{}) (proto.Message, bool) {
+	switch value.(type) {
+	case *ledger.Payload:
+		return &Payload{}, true
+	case *[]flow.Event:
+		return &Events{}, true
+	default:
+		return nil, false
+	}
+}
+
+// fromMessage converts the given protobuf message into the given value, which
+// has to be of the type for which the message was created.
+func fromMessage(msg proto.Message, value interface{}) {
+
+	switch v := value.(type) {
+	case *ledger.Payload:
+		m := msg.(*Payload)
+		parts := make([]ledger.KeyPart, 0, len(m.KeyParts))
+		for _, part := range m.KeyParts {
+			parts = append(parts, ledger.NewKeyPart(uint16(part.Type), part.Value))
+		}
+		*v = *ledger.NewPayload(ledger.NewKey(parts), m.Value)
+
+	case *[]flow.Event:
+		m := msg.(*Events)
+		events := make([]flow.Event, 0, len(m.Events))
+		for _, event := range m.Events {
+			events = append(events, flow.Event{
+				Type:             flow.EventType(event.Type),
+				TransactionID:    flow.HashToID(event.TransactionID),
+				TransactionIndex: event.TransactionIndex,
+				EventIndex:       event.EventIndex,
+				Payload:          event.Payload,
+			})
+		}
+		*v = events
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package protostore_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/codec/protostore"
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestCodec(t *testing.T) {
+	events := mocks.GenericEvents(4, mocks.GenericEventTypes(2)...)
+	payload := mocks.GenericLedgerPayload(0)
+
+	t.Run("round-trips events", func(t *testing.T) {
+		t.Parallel()
+
+		codec := protostore.NewCodec(zbor.NewCodec())
+
+		data, err := codec.Marshal(events)
+		require.NoError(t, err)
+
+		var got []flow.Event
+		require.NoError(t, codec.Unmarshal(data, &got))
+		assert.Equal(t, events, got)
+	})
+
+	t.Run("round-trips payloads", func(t *testing.T) {
+		t.Parallel()
+
+		codec := protostore.NewCodec(zbor.NewCodec())
+
+		data, err := codec.Marshal(payload)
+		require.NoError(t, err)
+
+		var got ledger.Payload
+		require.NoError(t, codec.Unmarshal(data, &got))
+		assert.Equal(t, *payload, got)
+	})
+
+	t.Run("round-trips uncompressed values", func(t *testing.T) {
+		t.Parallel()
+
+		codec := protostore.NewCodec(zbor.NewCodec())
+
+		data, err := codec.MarshalWith(events, dps.CompressionNone)
+		require.NoError(t, err)
+		encoded, err := codec.Encode(events)
+		require.NoError(t, err)
+		assert.Equal(t, encoded, data)
+
+		var got []flow.Event
+		require.NoError(t, codec.Unmarshal(data, &got))
+		assert.Equal(t, events, got)
+	})
+
+	t.Run("delegates other values to fallback", func(t *testing.T) {
+		t.Parallel()
+
+		fallback := zbor.NewCodec()
+		codec := protostore.NewCodec(fallback)
+
+		want, err := fallback.Marshal(mocks.GenericHeader)
+		require.NoError(t, err)
+		got, err := codec.Marshal(mocks.GenericHeader)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+
+		var header flow.Header
+		require.NoError(t, codec.Unmarshal(got, &header))
+		assert.Equal(t, mocks.GenericHeader.ID(), header.ID())
+	})
+
+	t.Run("transcodes events for clients", func(t *testing.T) {
+		t.Parallel()
+
+		fallback := zbor.NewCodec()
+		codec := protostore.NewCodec(fallback)
+
+		data, err := codec.Marshal(events)
+		require.NoError(t, err)
+
+		var decoded []flow.Event
+		transcoded, err := codec.Transcode(data, &decoded)
+		require.NoError(t, err)
+
+		var got []flow.Event
+		require.NoError(t, fallback.Unmarshal(transcoded, &got))
+		assert.Equal(t, events, got)
+	})
+
+	t.Run("handles unknown compression", func(t *testing.T) {
+		t.Parallel()
+
+		codec := protostore.NewCodec(zbor.NewCodec())
+
+		_, err := codec.MarshalWith(events, dps.Compression(255))
+		assert.Error(t, err)
+	})
+}
+
+// BenchmarkCodec compares the encoding and decoding of the values that the
+// protobuf codec stores differently from the zbor codec.
+func BenchmarkCodec(b *testing.B) {
+
+	fallback := zbor.NewCodec()
+	codecs := []struct {
+		name  string
+		codec dps.Codec
+	}{
+		{name: "zbor", codec: fallback},
+		{name: "protostore", codec: protostore.NewCodec(fallback)},
+	}
+
+	events := mocks.GenericEvents(64, mocks.GenericEventTypes(8)...)
+	payload := mocks.GenericLedgerPayload(0)
+
+	for _, c := range codecs {
+		codec := c.codec
+		b.Run(c.name, func(b *testing.B) {
+
+			data, err := codec.Marshal(events)
+			require.NoError(b, err)
+
+			b.Run("marshal events", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					_, err := codec.Marshal(events)
+					require.NoError(b, err)
+				}
+			})
+
+			b.Run("unmarshal events", func(b *testing.B) {
+				b.ReportAllocs()
+				b.ReportMetric(float64(len(data)), "bytes")
+				for i := 0; i < b.N; i++ {
+					var got []flow.Event
+					err := codec.Unmarshal(data, &got)
+					require.NoError(b, err)
+				}
+			})
+
+			encoded, err := codec.Marshal(payload)
+			require.NoError(b, err)
+
+			b.Run("marshal payload", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					_, err := codec.Marshal(payload)
+					require.NoError(b, err)
+				}
+			})
+
+			b.Run("unmarshal payload", func(b *testing.B) {
+				b.ReportAllocs()
+				b.ReportMetric(float64(len(encoded)), "bytes")
+				for i := 0; i < b.N; i++ {
+					var got ledger.Payload
+					err := codec.Unmarshal(encoded, &got)
+					require.NoError(b, err)
+				}
+			})
+		})
+	}
+}
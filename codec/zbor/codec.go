@@ -15,17 +15,33 @@
 package zbor
 
 import (
+	"bytes"
 	"fmt"
+	"sync"
 
 	"github.com/fxamacker/cbor/v2"
 	"github.com/klauspost/compress/zstd"
 
 	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
 )
 
+// zstdMagic is the magic number at the start of each zstandard frame. It allows
+// us to recognize values that were stored without compression, as a valid CBOR
+// encoding can not start with these bytes.
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// leveledKey identifies a compressor by the dictionary it uses and its level.
+type leveledKey struct {
+	dictionary string
+	level      zstd.EncoderLevel
+}
+
 // Codec encodes and decodes Go values using cbor encoding and zstandard compression.
 type Codec struct {
+	cfg     Config
 	encoder cbor.EncMode
 	decoder cbor.DecMode
 
@@ -37,14 +53,25 @@ type Codec struct {
 	eventDecompressor       *zstd.Decoder
 	transactionCompressor   *zstd.Encoder
 	transactionDecompressor *zstd.Decoder
+
+	mutex   *sync.Mutex
+	leveled map[leveledKey]*zstd.Encoder // compressors for non-default levels, created on demand
 }
 
 // NewCodec creates a new Codec.
-func NewCodec() *Codec {
+func NewCodec(options ...Option) *Codec {
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
 
 	// We should never fail here if the options are valid, so use panic to keep
 	// the function signature for the codec clean.
 	encOptions := cbor.CanonicalEncOptions()
+	if cfg.Deterministic {
+		encOptions = cbor.CoreDetEncOptions()
+	}
 	encOptions.Time = cbor.TimeRFC3339Nano
 	encoder, err := encOptions.EncMode()
 	if err != nil {
@@ -54,6 +81,10 @@ func NewCodec() *Codec {
 	decOptions := cbor.DecOptions{
 		ExtraReturnErrors: cbor.ExtraDecErrorUnknownField,
 	}
+	if cfg.Deterministic {
+		decOptions.DupMapKey = cbor.DupMapKeyEnforcedAPF
+		decOptions.IndefLength = cbor.IndefLengthForbidden
+	}
 	decoder, err := decOptions.DecMode()
 	if err != nil {
 		panic(err)
@@ -134,6 +165,7 @@ func NewCodec() *Codec {
 	}
 
 	c := Codec{
+		cfg:     cfg,
 		encoder: encoder,
 		decoder: decoder,
 
@@ -145,14 +177,32 @@ func NewCodec() *Codec {
 		eventDecompressor:       eventDecompressor,
 		transactionCompressor:   transactionCompressor,
 		transactionDecompressor: transactionDecompressor,
+
+		mutex:   &sync.Mutex{},
+		leveled: make(map[leveledKey]*zstd.Encoder),
 	}
 
 	return &c
 }
 
-// Encode returns the CBOR encoding of the given value.
+// Encode returns the CBOR encoding of the given value, in the current schema
+// version. The version is tagged onto the encoding, so that decoding can use
+// the matching schema even after the representation of the value changed.
 func (c *Codec) Encode(value interface{}) ([]byte, error) {
-	return c.encoder.Marshal(value)
+
+	data, err := c.encoder.Marshal(encodeV2(value))
+	if err != nil {
+		return nil, err
+	}
+
+	if c.cfg.Validation {
+		err = c.validate(value, data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid round trip (type: %T): %w", value, err)
+		}
+	}
+
+	return data, nil
 }
 
 // Compress encodes the given bytes into a compressed format using zstandard.
@@ -183,9 +233,86 @@ func (c *Codec) Marshal(value interface{}) ([]byte, error) {
 	return compressed, nil
 }
 
-// Decode parses CBOR-encoded data into the given value.
+// MarshalWith encodes the given value and then compresses it with the given
+// compression level, and returns the resulting slice of bytes. Values marshaled
+// without compression are recognized as such when unmarshaling.
+func (c *Codec) MarshalWith(value interface{}, compression dps.Compression) ([]byte, error) {
+
+	var level zstd.EncoderLevel
+	switch compression {
+	case dps.CompressionDefault:
+		return c.Marshal(value)
+	case dps.CompressionNone:
+		return c.Encode(value)
+	case dps.CompressionFastest:
+		level = zstd.SpeedFastest
+	case dps.CompressionBetter:
+		level = zstd.SpeedBetterCompression
+	case dps.CompressionBest:
+		level = zstd.SpeedBestCompression
+	default:
+		return nil, fmt.Errorf("unknown compression (compression: %d)", compression)
+	}
+
+	data, err := c.Encode(value)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode value: %w", err)
+	}
+
+	compressor, err := c.compressorFor(value, level)
+	if err != nil {
+		return nil, fmt.Errorf("could not create compressor: %w", err)
+	}
+
+	compressed := compressor.EncodeAll(data, nil)
+
+	return compressed, nil
+}
+
+// compressorFor returns the compressor with the dictionary for the given value
+// at the given level. The compressors are created on first use, as they are
+// only needed for the resources that are configured with a non-default level.
+func (c *Codec) compressorFor(value interface{}, level zstd.EncoderLevel) (*zstd.Encoder, error) {
+
+	var name string
+	var dictionary []byte
+	switch value.(type) {
+	case *ledger.Payload:
+		name, dictionary = "payload", payloadDictionary
+	case []flow.Event:
+		name, dictionary = "event", eventDictionary
+	case *flow.TransactionBody:
+		name, dictionary = "transaction", transactionDictionary
+	default:
+		name, dictionary = "generic", genericDictionary
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := leveledKey{dictionary: name, level: level}
+	compressor, ok := c.leveled[key]
+	if ok {
+		return compressor, nil
+	}
+
+	compressor, err := zstd.NewWriter(nil,
+		zstd.WithEncoderLevel(level),
+		zstd.WithEncoderDict(dictionary),
+	)
+	if err != nil {
+		return nil, err
+	}
+	c.leveled[key] = compressor
+
+	return compressor, nil
+}
+
+// Decode parses CBOR-encoded data into the given value. Data encoded with any
+// known schema version can be decoded, including data without a version tag,
+// which was encoded before schema versions were introduced.
 func (c *Codec) Decode(data []byte, value interface{}) error {
-	return c.decoder.Unmarshal(data, value)
+	return decode(c.decoder, data, value)
 }
 
 // Decompress reads compressed data that uses the zstandard format and returns the original
@@ -199,6 +326,15 @@ func (c *Codec) Decompress(compressed []byte) ([]byte, error) {
 // the given value.
 func (c *Codec) Unmarshal(compressed []byte, value interface{}) error {
 
+	// Values that were stored without compression can be decoded directly.
+	if !bytes.HasPrefix(compressed, zstdMagic) {
+		err := c.Decode(compressed, value)
+		if err != nil {
+			return fmt.Errorf("could not decode value: %w", err)
+		}
+		return nil
+	}
+
 	var data []byte
 	var err error
 	switch value.(type) {
@@ -0,0 +1,123 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package zbor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+)
+
+func TestNewCodec(t *testing.T) {
+	codec := NewCodec(WithDeterministic(true), WithValidation(true))
+
+	assert.True(t, codec.cfg.Deterministic)
+	assert.True(t, codec.cfg.Validation)
+}
+
+func TestCodec_Deterministic(t *testing.T) {
+	// A map with the same key twice, and an array of indefinite length.
+	duplicate := []byte{0xa2, 0x61, 0x61, 0x01, 0x61, 0x61, 0x02}
+	indefinite := []byte{0x9f, 0x01, 0xff}
+
+	t.Run("default mode", func(t *testing.T) {
+		t.Parallel()
+
+		codec := NewCodec()
+
+		var m map[string]uint64
+		assert.NoError(t, codec.Decode(duplicate, &m))
+		var s []uint64
+		assert.NoError(t, codec.Decode(indefinite, &s))
+	})
+
+	t.Run("deterministic mode", func(t *testing.T) {
+		t.Parallel()
+
+		codec := NewCodec(WithDeterministic(true))
+
+		var m map[string]uint64
+		assert.Error(t, codec.Decode(duplicate, &m))
+		var s []uint64
+		assert.Error(t, codec.Decode(indefinite, &s))
+
+		value := map[string]uint64{"a": 1, "bb": 2, "c": 3}
+		first, err := codec.Encode(value)
+		require.NoError(t, err)
+		for i := 0; i < 10; i++ {
+			again, err := codec.Encode(value)
+			require.NoError(t, err)
+			assert.Equal(t, first, again)
+		}
+	})
+}
+
+func TestCodec_Validation(t *testing.T) {
+	type lossless struct {
+		Height uint64
+		Name   string
+	}
+	type lossy struct {
+		Height uint64
+		name   string
+	}
+
+	payload := ledger.NewPayload(
+		ledger.NewKey([]ledger.KeyPart{ledger.NewKeyPart(0, []byte(`owner`))}),
+		ledger.Value(`value`),
+	)
+	events := []flow.Event{{Type: "A.0x1.Test.Event", EventIndex: 1, Payload: []byte(`payload`)}}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		codec := NewCodec(WithValidation(true))
+
+		_, err := codec.Marshal(uint64(42))
+		assert.NoError(t, err)
+		_, err = codec.Marshal(&lossless{Height: 42, Name: "name"})
+		assert.NoError(t, err)
+		_, err = codec.Marshal(payload)
+		assert.NoError(t, err)
+		_, err = codec.Marshal(events)
+		assert.NoError(t, err)
+		_, err = codec.Marshal((*lossless)(nil))
+		assert.NoError(t, err)
+	})
+
+	t.Run("handles lossy encodings", func(t *testing.T) {
+		t.Parallel()
+
+		codec := NewCodec(WithValidation(true))
+
+		_, err := codec.Marshal(&lossy{Height: 42, name: "name"})
+		assert.Error(t, err)
+		_, err = codec.Marshal(lossy{Height: 42, name: "name"})
+		assert.Error(t, err)
+	})
+
+	t.Run("ignores lossy encodings without validation", func(t *testing.T) {
+		t.Parallel()
+
+		codec := NewCodec()
+
+		_, err := codec.Marshal(&lossy{Height: 42, name: "name"})
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,51 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package zbor
+
+// DefaultConfig is the default configuration for the codec.
+var DefaultConfig = Config{
+	Deterministic: false,
+	Validation:    false,
+}
+
+// Config is the configuration for the codec.
+type Config struct {
+	Deterministic bool
+	Validation    bool
+}
+
+// Option is a function that can be applied to a Config.
+type Option func(*Config)
+
+// WithDeterministic makes the codec use the core deterministic encoding of
+// CBOR, as defined in RFC 8949, for all values, and reject encoded data with
+// duplicate map keys or indefinite-length items when decoding, so that the
+// same value is always stored as the same bytes.
+func WithDeterministic(deterministic bool) Option {
+	return func(cfg *Config) {
+		cfg.Deterministic = deterministic
+	}
+}
+
+// WithValidation makes the codec decode every value again right after it
+// encodes it, and fail if the decoded value differs from the original or if
+// it is encoded differently, which catches fields of new model versions that
+// do not survive the round trip. As it doubles the work of each encoding, it
+// is meant for debugging.
+func WithValidation(validation bool) Option {
+	return func(cfg *Config) {
+		cfg.Validation = validation
+	}
+}
@@ -0,0 +1,366 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package zbor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// Schema versions of the values encoded by the codec.
+const (
+	// VersionLegacy is the version of values that were encoded before their
+	// schema was versioned. They carry no version tag.
+	VersionLegacy = 0
+	// Version1 encodes payloads and events as arrays instead of maps, which
+	// leaves out the field names. All other values keep their representation.
+	Version1 = 1
+	// Version2 encodes batches of events column by column, with the types and
+	// transaction identifiers they share and the common prefix of their
+	// payloads stored only once. All other values keep their representation.
+	Version2 = 2
+	// VersionCurrent is the version with which values are encoded.
+	VersionCurrent = Version2
+)
+
+// tagVersion is the number of the CBOR tag that wraps the values encoded with
+// the first schema version. Each following version uses the next number, so
+// that the version of a value can be read from its first five bytes.
+const tagVersion = 0x7a627200
+
+// tagHeader is the initial byte of a CBOR tag with a four-byte number.
+const tagHeader = 0xda
+
+// decodeFunc decodes data encoded with a given schema version into the given
+// value.
+type decodeFunc func(decoder cbor.DecMode, data []byte, value interface{}) error
+
+// decoders holds the decoding function for each schema version that the codec
+// can read. Indexes written by older binaries can thus be read without being
+// reindexed, as long as the decoding function of their version is kept here.
+var decoders = map[uint64]decodeFunc{
+	VersionLegacy: decodeLegacy,
+	Version1:      decodeV1,
+	Version2:      decodeV2,
+}
+
+// Version returns the schema version of the given CBOR-encoded data.
+func Version(data []byte) uint64 {
+	version, _ := split(data)
+	return version
+}
+
+// split returns the schema version of the given CBOR-encoded data, along with
+// the data without its version tag.
+func split(data []byte) (uint64, []byte) {
+	if len(data) < 5 || data[0] != tagHeader {
+		return VersionLegacy, data
+	}
+	number := uint64(binary.BigEndian.Uint32(data[1:5]))
+	if number < tagVersion || number > tagVersion+0xff {
+		return VersionLegacy, data
+	}
+	return number - tagVersion + Version1, data[5:]
+}
+
+// payloadV1 is the representation of a ledger payload in the first schema
+// version.
+type payloadV1 struct {
+	_     struct{} `cbor:",toarray"`
+	Parts []keyPartV1
+	Value []byte
+}
+
+// keyPartV1 is the representation of a ledger key part in the first schema
+// version.
+type keyPartV1 struct {
+	_     struct{} `cbor:",toarray"`
+	Type  uint16
+	Value []byte
+}
+
+// eventV1 is the representation of an event in the first schema version.
+type eventV1 struct {
+	_                struct{} `cbor:",toarray"`
+	Type             flow.EventType
+	TransactionID    flow.Identifier
+	TransactionIndex uint32
+	EventIndex       uint32
+	Payload          []byte
+}
+
+// encodeV1 converts the given value into its representation in the first
+// schema version, wrapped in the matching version tag.
+func encodeV1(value interface{}) interface{} {
+
+	content := value
+	switch v := value.(type) {
+	case *ledger.Payload:
+		payload := payloadV1{
+			Parts: make([]keyPartV1, 0, len(v.Key.KeyParts)),
+			Value: v.Value,
+		}
+		for _, part := range v.Key.KeyParts {
+			payload.Parts = append(payload.Parts, keyPartV1{Type: part.Type, Value: part.Value})
+		}
+		content = payload
+	case []flow.Event:
+		events := make([]eventV1, 0, len(v))
+		for _, event := range v {
+			events = append(events, eventV1{
+				Type:             event.Type,
+				TransactionID:    event.TransactionID,
+				TransactionIndex: event.TransactionIndex,
+				EventIndex:       event.EventIndex,
+				Payload:          event.Payload,
+			})
+		}
+		content = events
+	}
+
+	tag := cbor.Tag{
+		Number:  tagVersion,
+		Content: content,
+	}
+
+	return tag
+}
+
+// eventsV2 is the representation of a batch of events in the second schema
+// version. The events are stored column by column, and refer to the tables of
+// the types and transaction identifiers they share. Events are stored in one
+// batch per type, so the payloads of a batch usually start with the same type
+// identifier and field names, which are stored once as their common prefix.
+type eventsV2 struct {
+	_                  struct{} `cbor:",toarray"`
+	Types              []flow.EventType
+	TypeRefs           []uint32 // nil when all events have the first type
+	TransactionIDs     []flow.Identifier
+	TransactionRefs    []uint32
+	TransactionIndexes []uint32
+	EventIndexes       []uint32
+	Prefix             []byte
+	Payloads           [][]byte // payloads without their common prefix
+}
+
+// encodeV2 converts the given value into its representation in the second
+// schema version, wrapped in the matching version tag.
+func encodeV2(value interface{}) interface{} {
+
+	content := encodeV1(value).(cbor.Tag).Content
+	events, ok := value.([]flow.Event)
+	if ok {
+		content = columns(events)
+	}
+
+	tag := cbor.Tag{
+		Number:  tagVersion + Version2 - Version1,
+		Content: content,
+	}
+
+	return tag
+}
+
+// columns converts the given batch of events into its columnar
+// representation.
+func columns(events []flow.Event) eventsV2 {
+
+	batch := eventsV2{
+		TransactionRefs:    make([]uint32, 0, len(events)),
+		TransactionIndexes: make([]uint32, 0, len(events)),
+		EventIndexes:       make([]uint32, 0, len(events)),
+		Payloads:           make([][]byte, 0, len(events)),
+	}
+
+	typeRefs := make([]uint32, 0, len(events))
+	types := make(map[flow.EventType]uint32)
+	txIDs := make(map[flow.Identifier]uint32)
+	for i, event := range events {
+		ref, ok := types[event.Type]
+		if !ok {
+			ref = uint32(len(batch.Types))
+			types[event.Type] = ref
+			batch.Types = append(batch.Types, event.Type)
+		}
+		typeRefs = append(typeRefs, ref)
+
+		ref, ok = txIDs[event.TransactionID]
+		if !ok {
+			ref = uint32(len(batch.TransactionIDs))
+			txIDs[event.TransactionID] = ref
+			batch.TransactionIDs = append(batch.TransactionIDs, event.TransactionID)
+		}
+		batch.TransactionRefs = append(batch.TransactionRefs, ref)
+
+		batch.TransactionIndexes = append(batch.TransactionIndexes, event.TransactionIndex)
+		batch.EventIndexes = append(batch.EventIndexes, event.EventIndex)
+
+		if i == 0 {
+			batch.Prefix = event.Payload
+			continue
+		}
+		batch.Prefix = batch.Prefix[:common(batch.Prefix, event.Payload)]
+	}
+	if len(batch.Types) > 1 {
+		batch.TypeRefs = typeRefs
+	}
+
+	// Payloads that are nil keep a nil suffix, so that they can be told apart
+	// from empty payloads when decoding.
+	for _, event := range events {
+		batch.Payloads = append(batch.Payloads, event.Payload[len(batch.Prefix):])
+	}
+
+	return batch
+}
+
+// common returns the length of the common prefix of the given byte slices.
+func common(a []byte, b []byte) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// decodeLegacy decodes values that were encoded before their schema was
+// versioned, which use the default representation of their types.
+func decodeLegacy(decoder cbor.DecMode, data []byte, value interface{}) error {
+	return decoder.Unmarshal(data, value)
+}
+
+// decodeV1 decodes values encoded with the first schema version.
+func decodeV1(decoder cbor.DecMode, data []byte, value interface{}) error {
+
+	switch v := value.(type) {
+	case *ledger.Payload:
+		var payload payloadV1
+		err := decoder.Unmarshal(data, &payload)
+		if err != nil {
+			return err
+		}
+		parts := make([]ledger.KeyPart, 0, len(payload.Parts))
+		for _, part := range payload.Parts {
+			parts = append(parts, ledger.NewKeyPart(part.Type, part.Value))
+		}
+		*v = *ledger.NewPayload(ledger.NewKey(parts), payload.Value)
+		return nil
+
+	case *[]flow.Event:
+		var events []eventV1
+		err := decoder.Unmarshal(data, &events)
+		if err != nil {
+			return err
+		}
+		*v = make([]flow.Event, 0, len(events))
+		for _, event := range events {
+			*v = append(*v, flow.Event{
+				Type:             event.Type,
+				TransactionID:    event.TransactionID,
+				TransactionIndex: event.TransactionIndex,
+				EventIndex:       event.EventIndex,
+				Payload:          event.Payload,
+			})
+		}
+		return nil
+
+	default:
+		return decoder.Unmarshal(data, value)
+	}
+}
+
+// decodeV2 decodes values encoded with the second schema version.
+func decodeV2(decoder cbor.DecMode, data []byte, value interface{}) error {
+
+	v, ok := value.(*[]flow.Event)
+	if !ok {
+		return decodeV1(decoder, data, value)
+	}
+
+	var batch eventsV2
+	err := decoder.Unmarshal(data, &batch)
+	if err != nil {
+		return err
+	}
+
+	count := len(batch.Payloads)
+	if len(batch.TransactionRefs) != count || len(batch.TransactionIndexes) != count || len(batch.EventIndexes) != count {
+		return errors.New("mismatching lengths of event columns")
+	}
+	if batch.TypeRefs != nil && len(batch.TypeRefs) != count {
+		return errors.New("mismatching lengths of event columns")
+	}
+	if count > 0 && len(batch.Types) == 0 {
+		return errors.New("missing event types")
+	}
+
+	*v = make([]flow.Event, 0, count)
+	for i, suffix := range batch.Payloads {
+
+		typeRef := uint32(0)
+		if batch.TypeRefs != nil {
+			typeRef = batch.TypeRefs[i]
+		}
+		if int(typeRef) >= len(batch.Types) {
+			return fmt.Errorf("invalid event type reference (reference: %d)", typeRef)
+		}
+
+		txRef := batch.TransactionRefs[i]
+		if int(txRef) >= len(batch.TransactionIDs) {
+			return fmt.Errorf("invalid transaction reference (reference: %d)", txRef)
+		}
+
+		var payload []byte
+		if suffix != nil || len(batch.Prefix) > 0 {
+			payload = make([]byte, 0, len(batch.Prefix)+len(suffix))
+			payload = append(payload, batch.Prefix...)
+			payload = append(payload, suffix...)
+		}
+
+		*v = append(*v, flow.Event{
+			Type:             batch.Types[typeRef],
+			TransactionID:    batch.TransactionIDs[txRef],
+			TransactionIndex: batch.TransactionIndexes[i],
+			EventIndex:       batch.EventIndexes[i],
+			Payload:          payload,
+		})
+	}
+
+	return nil
+}
+
+// decode decodes the given CBOR-encoded data into the given value, with the
+// decoding function for the schema version of the data.
+func decode(decoder cbor.DecMode, data []byte, value interface{}) error {
+
+	version, content := split(data)
+	decodeVersion, ok := decoders[version]
+	if !ok {
+		return fmt.Errorf("unsupported schema version (version: %d)", version)
+	}
+
+	return decodeVersion(decoder, content, value)
+}
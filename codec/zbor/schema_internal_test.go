@@ -0,0 +1,242 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package zbor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+)
+
+func TestCodec_Versions(t *testing.T) {
+	payload := ledger.NewPayload(
+		ledger.NewKey([]ledger.KeyPart{
+			ledger.NewKeyPart(0, []byte(`owner`)),
+			ledger.NewKeyPart(1, []byte(`controller`)),
+			ledger.NewKeyPart(2, []byte(`key`)),
+		}),
+		ledger.Value(`value`),
+	)
+	events := []flow.Event{
+		{
+			Type:             "A.0x1.Test.Event",
+			TransactionID:    flow.Identifier{0x1},
+			TransactionIndex: 1,
+			EventIndex:       2,
+			Payload:          []byte(`payload`),
+		},
+	}
+
+	// Legacy values were encoded with the default representation of their
+	// types and without a version tag.
+	legacy, err := cbor.CanonicalEncOptions().EncMode()
+	require.NoError(t, err)
+
+	t.Run("encodes with current version", func(t *testing.T) {
+		t.Parallel()
+
+		codec := NewCodec()
+
+		data, err := codec.Encode(payload)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(VersionCurrent), Version(data))
+
+		data, err = codec.Encode(uint64(42))
+		require.NoError(t, err)
+		assert.Equal(t, uint64(VersionCurrent), Version(data))
+	})
+
+	t.Run("round trips payloads", func(t *testing.T) {
+		t.Parallel()
+
+		codec := NewCodec()
+
+		data, err := codec.Marshal(payload)
+		require.NoError(t, err)
+
+		var got ledger.Payload
+		require.NoError(t, codec.Unmarshal(data, &got))
+		assert.Equal(t, payload, &got)
+	})
+
+	t.Run("round trips events", func(t *testing.T) {
+		t.Parallel()
+
+		codec := NewCodec()
+
+		data, err := codec.Marshal(events)
+		require.NoError(t, err)
+
+		var got []flow.Event
+		require.NoError(t, codec.Unmarshal(data, &got))
+		assert.Equal(t, events, got)
+	})
+
+	t.Run("round trips other values", func(t *testing.T) {
+		t.Parallel()
+
+		codec := NewCodec()
+
+		data, err := codec.Marshal(uint64(42))
+		require.NoError(t, err)
+
+		var got uint64
+		require.NoError(t, codec.Unmarshal(data, &got))
+		assert.Equal(t, uint64(42), got)
+	})
+
+	t.Run("decodes legacy payloads", func(t *testing.T) {
+		t.Parallel()
+
+		codec := NewCodec()
+
+		data, err := legacy.Marshal(payload)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(VersionLegacy), Version(data))
+
+		var got ledger.Payload
+		require.NoError(t, codec.Unmarshal(data, &got))
+		assert.Equal(t, payload, &got)
+
+		compressed := codec.payloadCompressor.EncodeAll(data, nil)
+		got = ledger.Payload{}
+		require.NoError(t, codec.Unmarshal(compressed, &got))
+		assert.Equal(t, payload, &got)
+	})
+
+	t.Run("decodes legacy events", func(t *testing.T) {
+		t.Parallel()
+
+		codec := NewCodec()
+
+		data, err := legacy.Marshal(events)
+		require.NoError(t, err)
+		compressed := codec.eventCompressor.EncodeAll(data, nil)
+
+		var got []flow.Event
+		require.NoError(t, codec.Unmarshal(compressed, &got))
+		assert.Equal(t, events, got)
+	})
+
+	t.Run("encodes payloads more compactly", func(t *testing.T) {
+		t.Parallel()
+
+		codec := NewCodec()
+
+		current, err := codec.Encode(payload)
+		require.NoError(t, err)
+		old, err := legacy.Marshal(payload)
+		require.NoError(t, err)
+
+		assert.Less(t, len(current), len(old))
+	})
+
+	t.Run("round trips mixed batches of events", func(t *testing.T) {
+		t.Parallel()
+
+		codec := NewCodec()
+
+		batch := []flow.Event{
+			{Type: "A.0x1.Test.First", TransactionID: flow.Identifier{0x1}, EventIndex: 0, Payload: []byte(`{"id":"first","value":1}`)},
+			{Type: "A.0x1.Test.Second", TransactionID: flow.Identifier{0x1}, EventIndex: 1, Payload: []byte(`{"id":"second"}`)},
+			{Type: "A.0x1.Test.First", TransactionID: flow.Identifier{0x2}, TransactionIndex: 1, EventIndex: 2, Payload: []byte(`{"id":"first","value":2}`)},
+			{Type: "A.0x1.Test.Second", TransactionID: flow.Identifier{0x2}, TransactionIndex: 1, EventIndex: 3},
+		}
+
+		data, err := codec.Marshal(batch)
+		require.NoError(t, err)
+
+		var got []flow.Event
+		require.NoError(t, codec.Unmarshal(data, &got))
+		assert.Equal(t, batch, got)
+	})
+
+	t.Run("decodes version 1 events", func(t *testing.T) {
+		t.Parallel()
+
+		codec := NewCodec()
+
+		data, err := codec.encoder.Marshal(encodeV1(events))
+		require.NoError(t, err)
+		assert.Equal(t, uint64(Version1), Version(data))
+
+		var got []flow.Event
+		require.NoError(t, codec.Decode(data, &got))
+		assert.Equal(t, events, got)
+	})
+
+	t.Run("encodes batches of events more compactly", func(t *testing.T) {
+		t.Parallel()
+
+		codec := NewCodec()
+
+		var batch []flow.Event
+		for i := 0; i < 64; i++ {
+			batch = append(batch, flow.Event{
+				Type:             "A.0x1.Test.Event",
+				TransactionID:    flow.Identifier{byte(i / 4)},
+				TransactionIndex: uint32(i / 4),
+				EventIndex:       uint32(i),
+				Payload:          []byte(fmt.Sprintf(`{"type":"Event","value":{"id":"A.0x1.Test.Event","fields":[{"name":"id","value":{"type":"UInt64","value":"%d"}}]}}`, i)),
+			})
+		}
+
+		current, err := codec.Encode(batch)
+		require.NoError(t, err)
+		old, err := codec.encoder.Marshal(encodeV1(batch))
+		require.NoError(t, err)
+
+		assert.Less(t, len(current), len(old)/2)
+	})
+
+	t.Run("handles invalid event columns", func(t *testing.T) {
+		t.Parallel()
+
+		codec := NewCodec()
+
+		batch := eventsV2{
+			Types:              []flow.EventType{"A.0x1.Test.Event"},
+			TransactionIDs:     []flow.Identifier{{0x1}},
+			TransactionRefs:    []uint32{1},
+			TransactionIndexes: []uint32{0},
+			EventIndexes:       []uint32{0},
+			Payloads:           [][]byte{[]byte(`payload`)},
+		}
+		data, err := codec.encoder.Marshal(cbor.Tag{Number: tagVersion + 1, Content: batch})
+		require.NoError(t, err)
+
+		var got []flow.Event
+		assert.Error(t, codec.Decode(data, &got))
+	})
+
+	t.Run("handles unsupported versions", func(t *testing.T) {
+		t.Parallel()
+
+		codec := NewCodec()
+
+		data, err := legacy.Marshal(cbor.Tag{Number: tagVersion + 0x10, Content: uint64(42)})
+		require.NoError(t, err)
+		assert.Equal(t, uint64(Version1+0x10), Version(data))
+
+		var got uint64
+		assert.Error(t, codec.Decode(data, &got))
+	})
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package zbor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// validate checks that the given encoding of the given value decodes back into
+// an identical value, which is encoded into identical bytes again. Values whose
+// types have fields that are not encoded, or that change when encoded, fail
+// the check.
+func (c *Codec) validate(value interface{}, data []byte) error {
+
+	// Values are decoded into a new value of their type. For pointers, we
+	// decode into a new value of the type they point to instead, as the
+	// decoding of some schema versions is specific to those types.
+	original := reflect.ValueOf(value)
+	if !original.IsValid() {
+		return nil
+	}
+	pointer := original.Kind() == reflect.Ptr
+	if pointer {
+		if original.IsNil() {
+			return nil
+		}
+		original = original.Elem()
+	}
+
+	decoded := reflect.New(original.Type())
+	err := c.Decode(data, decoded.Interface())
+	if err != nil {
+		return fmt.Errorf("could not decode value: %w", err)
+	}
+
+	if !reflect.DeepEqual(original.Interface(), decoded.Elem().Interface()) {
+		return errors.New("decoded value differs from original value")
+	}
+
+	// The decoded value is encoded in the same form as the original value, so
+	// that it uses the same representation.
+	reencoded := decoded.Elem().Interface()
+	if pointer {
+		reencoded = decoded.Interface()
+	}
+	again, err := c.encoder.Marshal(encodeV2(reencoded))
+	if err != nil {
+		return fmt.Errorf("could not encode decoded value: %w", err)
+	}
+	if !bytes.Equal(data, again) {
+		return errors.New("decoded value is encoded differently")
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// Account holds the metadata of an account that was indexed from the events
+// related to it: the height at which it was created, which is zero if it was
+// created before the first indexed height, and the heights at which keys were
+// added to or removed from it.
+type Account struct {
+	Address    flow.Address
+	Created    uint64
+	KeyUpdates []uint64
+}
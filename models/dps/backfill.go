@@ -0,0 +1,31 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+// Backfill is the progress of building a secondary index for the heights that
+// were indexed before it was introduced. It holds the range of heights that are
+// backfilled, and the next height to build the index for, which is above the
+// last height of the range once the backfill is complete.
+type Backfill struct {
+	Name string
+	From uint64
+	To   uint64
+	Next uint64
+}
+
+// Complete returns whether the index was built for all heights of the range.
+func (b Backfill) Complete() bool {
+	return b.Next > b.To
+}
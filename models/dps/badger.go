@@ -15,6 +15,8 @@
 package dps
 
 import (
+	"runtime"
+
 	"github.com/dgraph-io/badger/v2"
 	"github.com/dgraph-io/badger/v2/options"
 )
@@ -36,3 +38,11 @@ func DefaultOptions(dir string) badger.Options {
 		WithBlockCacheSize(0).
 		WithLogger(nil)
 }
+
+// ReadOnlyOptions returns the default Badger options preferred by the DPS for
+// reading its index database without writing to it. Badger does not support the
+// read-only mode on Windows, so there, the database is opened normally instead,
+// which means that it can not be read while another process writes to it.
+func ReadOnlyOptions(dir string) badger.Options {
+	return DefaultOptions(dir).WithReadOnly(runtime.GOOS != "windows")
+}
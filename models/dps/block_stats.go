@@ -0,0 +1,29 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+// BlockStats holds the aggregate statistics of the finalized block at a given
+// height, which are computed when the block is indexed so that they don't need
+// to be recomputed from the transactions, results and events of the block.
+// Fees are given in the fixed-point units of the FLOW token, where one FLOW is
+// 10^8 units, and senders are the distinct proposers of the transactions.
+type BlockStats struct {
+	Height       uint64
+	Transactions uint64
+	Events       uint64
+	Fees         uint64
+	Computation  uint64
+	Senders      uint64
+}
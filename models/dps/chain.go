@@ -21,6 +21,7 @@ import (
 // Chain represents something that has access to chain data.
 type Chain interface {
 	Root() (uint64, error)
+	RootSeal() (*flow.Seal, error)
 	Header(height uint64) (*flow.Header, error)
 	Commit(height uint64) (flow.StateCommitment, error)
 	Events(height uint64) ([]flow.Event, error)
@@ -29,4 +30,6 @@ type Chain interface {
 	Transactions(height uint64) ([]*flow.TransactionBody, error)
 	Results(height uint64) ([]*flow.TransactionResult, error)
 	Seals(height uint64) ([]*flow.Seal, error)
+	EpochStatus(height uint64) (*flow.EpochStatus, error)
+	EpochSetup(height uint64) (*flow.EpochSetup, error)
 }
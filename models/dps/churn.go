@@ -0,0 +1,124 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// RegisterChurn holds the number of registers that were written by the
+// finalized block at a given height, grouped by owner and by register. Each
+// register is counted once per height, no matter how many times it was written
+// within the block. Global registers are counted for the empty address.
+type RegisterChurn struct {
+	Height    uint64
+	Owners    []OwnerWrites
+	Registers []RegisterWrites
+}
+
+// OwnerWrites holds the number of register writes for the registers owned by
+// an account.
+type OwnerWrites struct {
+	Owner  flow.Address
+	Writes uint64
+}
+
+// RegisterWrites holds the number of writes for the register with the given
+// key within the registers owned by an account.
+type RegisterWrites struct {
+	Owner  flow.Address
+	Key    string
+	Writes uint64
+}
+
+// ChurnReport holds the register churn over a range of heights, with the total
+// number of register writes and the owners and registers that were written the
+// most, in descending order of writes.
+type ChurnReport struct {
+	StartHeight uint64
+	EndHeight   uint64
+	Writes      uint64
+	Owners      []OwnerWrites
+	Registers   []RegisterWrites
+}
+
+// MergeChurn merges the register churn of the given heights into a report for
+// the range between the given start and end heights. Only the given number of
+// top owners and registers are kept in the report; ties are broken by address
+// and key, so that reports are deterministic.
+func MergeChurn(start uint64, end uint64, churns []RegisterChurn, limit uint) ChurnReport {
+
+	type register struct {
+		owner flow.Address
+		key   string
+	}
+
+	writes := uint64(0)
+	owners := make(map[flow.Address]uint64)
+	registers := make(map[register]uint64)
+	for _, churn := range churns {
+		for _, entry := range churn.Owners {
+			owners[entry.Owner] += entry.Writes
+			writes += entry.Writes
+		}
+		for _, entry := range churn.Registers {
+			registers[register{owner: entry.Owner, key: entry.Key}] += entry.Writes
+		}
+	}
+
+	report := ChurnReport{
+		StartHeight: start,
+		EndHeight:   end,
+		Writes:      writes,
+		Owners:      make([]OwnerWrites, 0, len(owners)),
+		Registers:   make([]RegisterWrites, 0, len(registers)),
+	}
+	for owner, count := range owners {
+		report.Owners = append(report.Owners, OwnerWrites{Owner: owner, Writes: count})
+	}
+	for reg, count := range registers {
+		report.Registers = append(report.Registers, RegisterWrites{Owner: reg.owner, Key: reg.key, Writes: count})
+	}
+
+	sort.Slice(report.Owners, func(i, j int) bool {
+		left, right := report.Owners[i], report.Owners[j]
+		if left.Writes != right.Writes {
+			return left.Writes > right.Writes
+		}
+		return bytes.Compare(left.Owner[:], right.Owner[:]) < 0
+	})
+	sort.Slice(report.Registers, func(i, j int) bool {
+		left, right := report.Registers[i], report.Registers[j]
+		if left.Writes != right.Writes {
+			return left.Writes > right.Writes
+		}
+		if left.Owner != right.Owner {
+			return bytes.Compare(left.Owner[:], right.Owner[:]) < 0
+		}
+		return left.Key < right.Key
+	})
+
+	if uint(len(report.Owners)) > limit {
+		report.Owners = report.Owners[:limit]
+	}
+	if uint(len(report.Registers)) > limit {
+		report.Registers = report.Registers[:limit]
+	}
+
+	return report
+}
@@ -0,0 +1,97 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+func TestMergeChurn(t *testing.T) {
+	first := flow.HexToAddress("0000000000000001")
+	second := flow.HexToAddress("0000000000000002")
+
+	churns := []dps.RegisterChurn{
+		{
+			Height: 1,
+			Owners: []dps.OwnerWrites{
+				{Owner: first, Writes: 2},
+				{Owner: second, Writes: 1},
+			},
+			Registers: []dps.RegisterWrites{
+				{Owner: first, Key: "storage_used", Writes: 1},
+				{Owner: first, Key: "public_key_0", Writes: 1},
+				{Owner: second, Key: "storage_used", Writes: 1},
+			},
+		},
+		{
+			Height: 2,
+			Owners: []dps.OwnerWrites{
+				{Owner: second, Writes: 3},
+			},
+			Registers: []dps.RegisterWrites{
+				{Owner: second, Key: "storage_used", Writes: 1},
+				{Owner: second, Key: "public_key_0", Writes: 1},
+				{Owner: second, Key: "public_key_1", Writes: 1},
+			},
+		},
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		report := dps.MergeChurn(1, 2, churns, 10)
+
+		assert.Equal(t, uint64(1), report.StartHeight)
+		assert.Equal(t, uint64(2), report.EndHeight)
+		assert.Equal(t, uint64(6), report.Writes)
+		assert.Equal(t, []dps.OwnerWrites{
+			{Owner: second, Writes: 4},
+			{Owner: first, Writes: 2},
+		}, report.Owners)
+		assert.Equal(t, []dps.RegisterWrites{
+			{Owner: second, Key: "storage_used", Writes: 2},
+			{Owner: first, Key: "public_key_0", Writes: 1},
+			{Owner: first, Key: "storage_used", Writes: 1},
+			{Owner: second, Key: "public_key_0", Writes: 1},
+			{Owner: second, Key: "public_key_1", Writes: 1},
+		}, report.Registers)
+	})
+
+	t.Run("limits top entries", func(t *testing.T) {
+		t.Parallel()
+
+		report := dps.MergeChurn(1, 2, churns, 1)
+
+		assert.Equal(t, uint64(6), report.Writes)
+		assert.Equal(t, []dps.OwnerWrites{{Owner: second, Writes: 4}}, report.Owners)
+		assert.Equal(t, []dps.RegisterWrites{{Owner: second, Key: "storage_used", Writes: 2}}, report.Registers)
+	})
+
+	t.Run("handles empty range", func(t *testing.T) {
+		t.Parallel()
+
+		report := dps.MergeChurn(3, 4, nil, 10)
+
+		assert.Zero(t, report.Writes)
+		assert.Empty(t, report.Owners)
+		assert.Empty(t, report.Registers)
+	})
+}
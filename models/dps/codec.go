@@ -14,6 +14,16 @@
 
 package dps
 
+// CodecZbor identifies the encoding used by the DPS to store values in the
+// index, which is CBOR compressed with zstandard. Values returned in their
+// stored form are tagged with it, so that clients can pick the matching codec.
+const CodecZbor = "zbor"
+
+// CodecProtostore identifies the alternative encoding in which the events and
+// the register payloads are stored as protobuf messages compressed with
+// zstandard. Values stored with it are still returned with the zbor encoding.
+const CodecProtostore = "protostore"
+
 // Codec represents something that can encode and decode data, as well as compress and decompress it.
 type Codec interface {
 	Encode(value interface{}) ([]byte, error)
@@ -23,5 +33,6 @@ type Codec interface {
 	Decompress(compressed []byte) ([]byte, error)
 
 	Marshal(value interface{}) ([]byte, error)
+	MarshalWith(value interface{}, compression Compression) ([]byte, error)
 	Unmarshal(compressed []byte, value interface{}) error
 }
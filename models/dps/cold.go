@@ -0,0 +1,38 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+// ColdValue locates a value that was moved from the index to the cold tier. The
+// value is stored as-is within a segment, which is an object in cold storage
+// holding many values back to back.
+type ColdValue struct {
+	Segment uint64
+	Offset  uint64
+	Length  uint64
+}
+
+// Segment describes a segment of the cold tier, which holds the values of the
+// registers that were replaced at or below its cutoff height.
+type Segment struct {
+	Number uint64
+	Cutoff uint64
+	Values uint64
+	Bytes  uint64
+}
+
+// ColdReader represents something that reads values from the cold tier.
+type ColdReader interface {
+	Read(value ColdValue) ([]byte, error)
+}
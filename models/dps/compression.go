@@ -0,0 +1,79 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"fmt"
+)
+
+// Compression is the level of compression applied to the values stored in the
+// index. The default compression is the one the codec uses for the type of the
+// value; the other levels trade CPU time against storage space, or disable the
+// compression entirely for values that do not compress well.
+type Compression uint8
+
+// Supported compression levels.
+const (
+	CompressionDefault Compression = iota
+	CompressionNone
+	CompressionFastest
+	CompressionBetter
+	CompressionBest
+)
+
+var compressionNames = map[Compression]string{
+	CompressionDefault: "default",
+	CompressionNone:    "none",
+	CompressionFastest: "fastest",
+	CompressionBetter:  "better",
+	CompressionBest:    "best",
+}
+
+// ParseCompression returns the compression level with the given name.
+func ParseCompression(name string) (Compression, error) {
+	for compression, candidate := range compressionNames {
+		if candidate == name {
+			return compression, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown compression (name: %s)", name)
+}
+
+// String returns the name of the compression level.
+func (c Compression) String() string {
+	name, ok := compressionNames[c]
+	if !ok {
+		return fmt.Sprintf("unknown(%d)", uint8(c))
+	}
+	return name
+}
+
+// CompressionStats holds the number of values stored under a key prefix of the
+// index, along with their size before and after compression.
+type CompressionStats struct {
+	Prefix      uint8
+	Values      uint64
+	RawBytes    uint64
+	StoredBytes uint64
+}
+
+// Ratio returns the achieved compression ratio, which is the size of the
+// values before compression divided by their stored size.
+func (c CompressionStats) Ratio() float64 {
+	if c.StoredBytes == 0 {
+		return 0
+	}
+	return float64(c.RawBytes) / float64(c.StoredBytes)
+}
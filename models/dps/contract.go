@@ -0,0 +1,32 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// ContractVersion describes a change to a contract deployed on an account, as
+// indexed from the events emitted when contracts are added, updated or
+// removed. For removed contracts, the code hash is the hash of the code that
+// was removed.
+type ContractVersion struct {
+	Address       flow.Address
+	Name          string
+	Height        uint64
+	TransactionID flow.Identifier
+	CodeHash      []byte
+	Removed       bool
+}
@@ -0,0 +1,33 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"github.com/onflow/flow-go/ledger"
+)
+
+// RegisterDelta holds the change to a register that was written by the
+// finalized block at a given height, as the hashes of the leaf of the register
+// in the execution state trie before and after the block. As the leaf hash
+// covers the path and the value of the register, it can be checked against
+// proofs of the execution state. The hash is empty for a register that did not
+// exist before the block, or that the block removed by setting it to an empty
+// value.
+type RegisterDelta struct {
+	Height   uint64
+	Path     ledger.Path
+	Previous []byte
+	Current  []byte
+}
@@ -19,7 +19,37 @@ import (
 )
 
 // Sentinel errors.
+//
+// Components wrap these errors, so that callers can tell the reason for a
+// failure apart with `errors.Is`, regardless of the underlying database. The
+// API maps them to the matching gRPC status codes.
 var (
-	ErrFinished    = errors.New("finished")
+	// ErrFinished is returned when there is no more data to process.
+	ErrFinished = errors.New("finished")
+	// ErrUnavailable is returned when data is not available yet, but might
+	// become available later.
 	ErrUnavailable = errors.New("unavailable")
+	// ErrCorrupted is returned when data is inconsistent with the data it
+	// should match, such as a state commitment that differs from the indexed
+	// one.
+	ErrCorrupted = errors.New("corrupted")
+	// ErrNotIndexed is returned when the requested data is not in the index,
+	// either because it does not exist or because it was excluded from
+	// indexing.
+	ErrNotIndexed = errors.New("not indexed")
+	// ErrPruned is returned when the requested data was in the index, but was
+	// removed from it since.
+	ErrPruned = errors.New("pruned")
+	// ErrBootstrapping is returned when the index holds no data yet, because
+	// it is still being bootstrapped.
+	ErrBootstrapping = errors.New("bootstrapping")
+	// ErrFenced is returned when the index is written by another writer, which
+	// either holds its fence or took it over since.
+	ErrFenced = errors.New("fenced")
+	// ErrCold is returned when the requested data was moved to the cold tier,
+	// but the cold tier can not be reached to read it.
+	ErrCold = errors.New("cold")
+	// ErrConflict is returned when a staged height was sealed with an
+	// execution result that conflicts with the indexed one.
+	ErrConflict = errors.New("conflict")
 )
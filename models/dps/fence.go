@@ -0,0 +1,29 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+// Fence is the instance lock of an index database. Each writer that acquires
+// it gets a new token, which it checks before writing to the index, so that a
+// writer whose fence was taken over by another instance can no longer write.
+// The holder is empty once the fence was released.
+type Fence struct {
+	Token  uint64
+	Holder string
+}
+
+// Held returns whether the fence is currently held by a writer.
+func (f Fence) Held() bool {
+	return f.Holder != ""
+}
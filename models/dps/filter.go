@@ -0,0 +1,122 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"strings"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// Filter restricts the indexed data to the data involving some accounts. When
+// accounts are allowed, only data involving at least one of them is indexed;
+// data involving any denied account is never indexed. An empty filter indexes
+// everything.
+type Filter struct {
+	Allowed []flow.Address
+	Denied  []flow.Address
+}
+
+// Empty returns whether the filter lets all data through.
+func (f Filter) Empty() bool {
+	return len(f.Allowed) == 0 && len(f.Denied) == 0
+}
+
+// Equal returns whether both filters restrict indexing to the same data,
+// regardless of the order of their accounts.
+func (f Filter) Equal(other Filter) bool {
+	return same(f.Allowed, other.Allowed) && same(f.Denied, other.Denied)
+}
+
+// Address returns whether data owned by the given account is indexed.
+func (f Filter) Address(address flow.Address) bool {
+	if contains(f.Denied, address) {
+		return false
+	}
+	return len(f.Allowed) == 0 || contains(f.Allowed, address)
+}
+
+// Transaction returns whether the given transaction is indexed, based on the
+// accounts of its payer, its proposer and its authorizers.
+func (f Filter) Transaction(tx *flow.TransactionBody) bool {
+	addresses := make([]flow.Address, 0, len(tx.Authorizers)+2)
+	addresses = append(addresses, tx.Payer, tx.ProposalKey.Address)
+	addresses = append(addresses, tx.Authorizers...)
+	involved := len(f.Allowed) == 0
+	for _, address := range addresses {
+		if contains(f.Denied, address) {
+			return false
+		}
+		if contains(f.Allowed, address) {
+			involved = true
+		}
+	}
+	return involved
+}
+
+// EventType returns whether events of the given type can be indexed. This is
+// not the case for events emitted by contracts deployed on denied accounts.
+func (f Filter) EventType(typ flow.EventType) bool {
+	address, ok := EventAddress(typ)
+	return !ok || !contains(f.Denied, address)
+}
+
+// Event returns whether the given event is indexed, given whether the
+// transaction that emitted it is indexed. Events of contracts deployed on
+// denied accounts are never indexed, while events of contracts deployed on
+// allowed accounts are always indexed. Any other event is indexed along with
+// its transaction.
+func (f Filter) Event(event flow.Event, transaction bool) bool {
+	address, ok := EventAddress(event.Type)
+	if !ok {
+		return transaction
+	}
+	if contains(f.Denied, address) {
+		return false
+	}
+	return transaction || contains(f.Allowed, address)
+}
+
+// EventAddress returns the address of the account on which the contract that
+// emits events of the given type is deployed. Events emitted by the protocol
+// itself have no such address.
+func EventAddress(typ flow.EventType) (flow.Address, bool) {
+	parts := strings.Split(string(typ), ".")
+	if len(parts) < 4 || parts[0] != "A" {
+		return flow.EmptyAddress, false
+	}
+	return flow.HexToAddress(parts[1]), true
+}
+
+func same(addresses []flow.Address, others []flow.Address) bool {
+	if len(addresses) != len(others) {
+		return false
+	}
+	for _, address := range addresses {
+		if !contains(others, address) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(addresses []flow.Address, address flow.Address) bool {
+	for _, candidate := range addresses {
+		if candidate == address {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestFilter_Address(t *testing.T) {
+	addresses := mocks.GenericAddresses(3)
+
+	t.Run("empty filter", func(t *testing.T) {
+		t.Parallel()
+
+		filter := dps.Filter{}
+
+		assert.True(t, filter.Empty())
+		assert.True(t, filter.Address(addresses[0]))
+	})
+
+	t.Run("allowed addresses", func(t *testing.T) {
+		t.Parallel()
+
+		filter := dps.Filter{Allowed: addresses[:1]}
+
+		assert.False(t, filter.Empty())
+		assert.True(t, filter.Address(addresses[0]))
+		assert.False(t, filter.Address(addresses[1]))
+	})
+
+	t.Run("denied addresses", func(t *testing.T) {
+		t.Parallel()
+
+		filter := dps.Filter{Allowed: addresses[:2], Denied: addresses[1:]}
+
+		assert.True(t, filter.Address(addresses[0]))
+		assert.False(t, filter.Address(addresses[1]))
+		assert.False(t, filter.Address(addresses[2]))
+	})
+}
+
+func TestFilter_Equal(t *testing.T) {
+	addresses := mocks.GenericAddresses(3)
+
+	filter := dps.Filter{Allowed: addresses[:2], Denied: addresses[2:]}
+
+	assert.True(t, filter.Equal(dps.Filter{Allowed: []flow.Address{addresses[1], addresses[0]}, Denied: addresses[2:]}))
+	assert.False(t, filter.Equal(dps.Filter{Allowed: addresses[:2]}))
+	assert.False(t, filter.Equal(dps.Filter{Allowed: addresses[:1], Denied: addresses[2:]}))
+	assert.True(t, dps.Filter{}.Equal(dps.Filter{}))
+}
+
+func TestFilter_Transaction(t *testing.T) {
+	addresses := mocks.GenericAddresses(4)
+	tx := flow.TransactionBody{
+		Payer:       addresses[0],
+		ProposalKey: flow.ProposalKey{Address: addresses[0]},
+		Authorizers: []flow.Address{addresses[1]},
+	}
+
+	t.Run("empty filter", func(t *testing.T) {
+		t.Parallel()
+
+		filter := dps.Filter{}
+
+		assert.True(t, filter.Transaction(&tx))
+	})
+
+	t.Run("allowed authorizer", func(t *testing.T) {
+		t.Parallel()
+
+		filter := dps.Filter{Allowed: addresses[1:2]}
+
+		assert.True(t, filter.Transaction(&tx))
+	})
+
+	t.Run("uninvolved allowed account", func(t *testing.T) {
+		t.Parallel()
+
+		filter := dps.Filter{Allowed: addresses[2:]}
+
+		assert.False(t, filter.Transaction(&tx))
+	})
+
+	t.Run("denied payer", func(t *testing.T) {
+		t.Parallel()
+
+		filter := dps.Filter{Allowed: addresses[1:2], Denied: addresses[:1]}
+
+		assert.False(t, filter.Transaction(&tx))
+	})
+}
+
+func TestFilter_Event(t *testing.T) {
+	addresses := mocks.GenericAddresses(2)
+	allowed := flow.Event{Type: flow.EventType("A." + addresses[0].Hex() + ".Contract.Event")}
+	denied := flow.Event{Type: flow.EventType("A." + addresses[1].Hex() + ".Contract.Event")}
+	protocol := flow.Event{Type: flow.EventAccountCreated}
+
+	filter := dps.Filter{Allowed: addresses[:1], Denied: addresses[1:]}
+
+	assert.True(t, filter.Event(allowed, false))
+	assert.False(t, filter.Event(denied, true))
+	assert.True(t, filter.Event(protocol, true))
+	assert.False(t, filter.Event(protocol, false))
+
+	assert.True(t, filter.EventType(allowed.Type))
+	assert.False(t, filter.EventType(denied.Type))
+	assert.True(t, filter.EventType(protocol.Type))
+}
+
+func TestEventAddress(t *testing.T) {
+	address := mocks.GenericAddress(0)
+
+	got, ok := dps.EventAddress(flow.EventType("A." + address.Hex() + ".Contract.Event"))
+	assert.True(t, ok)
+	assert.Equal(t, address, got)
+
+	_, ok = dps.EventAddress(flow.EventAccountCreated)
+	assert.False(t, ok)
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// HeightData holds everything that is indexed for the finalized block at a
+// given height, so that it can be written to the index as a single unit. The
+// registers of a height can also be spread over several batches that precede
+// the one that completes it, so that they do not all have to be held at once.
+// Such a height is not written atomically: it is only hidden from readers until
+// the batch that completes it also sets the last height.
+//
+// The block data, from the block ID to the seals, is only indexed when the
+// header is given, and the execution data only when the state commitment is
+// given. The register churn, the block statistics and the updated statistics
+// of event types are optional, while the paths and payloads of the updated
+// registers must be given in pairs.
+type HeightData struct {
+	Height uint64
+
+	BlockID    flow.Identifier
+	Header     *flow.Header
+	Guarantees []*flow.CollectionGuarantee
+	Seals      []*flow.Seal
+
+	Commit        *flow.StateCommitment
+	Collections   []*flow.LightCollection
+	Transactions  []*flow.TransactionBody
+	Results       []*flow.TransactionResult
+	Events        []flow.Event
+	ServiceEvents []flow.Event
+	BlockStats    *BlockStats
+	EventTypes    []EventTypeStats
+
+	Accounts   []flow.Address
+	KeyUpdates []flow.Address
+	Contracts  []ContractVersion
+
+	RegisterChurn  *RegisterChurn
+	RegisterDeltas []RegisterDelta
+	Paths          []ledger.Path
+	Payloads       []*ledger.Payload
+
+	// Complete is set when the data completes the height, in which case the
+	// integrity manifest of the height is sealed and the height is staged for
+	// rollback. First and Last are set when the height becomes the first or
+	// the last indexed height, respectively.
+	Complete bool
+	First    bool
+	Last     bool
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/bits"
+)
+
+// Manifest is the integrity manifest of an indexed height. It holds the number
+// of entries that were written to the index for the height, and a checksum over
+// their keys and values. The checksum is the sum of the hashes of all entries,
+// so it does not depend on the order in which they were written, and it can be
+// computed again from the index to prove that the data of the height is intact.
+type Manifest struct {
+	Height   uint64
+	Entries  uint64
+	Checksum [32]byte
+}
+
+// Add adds the entry with the given key and value to the manifest.
+func (m *Manifest) Add(key []byte, value []byte) {
+
+	// The length of the key is hashed before the key itself, so that moving
+	// bytes between the key and the value changes the hash of the entry.
+	length := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(length, uint64(len(key)))
+
+	h := sha256.New()
+	_, _ = h.Write(length[:n])
+	_, _ = h.Write(key)
+	_, _ = h.Write(value)
+	hash := h.Sum(nil)
+
+	// The hash is added to the checksum as a 256-bit big-endian integer, with
+	// the overflow being discarded.
+	var carry uint64
+	for i := len(m.Checksum) - 8; i >= 0; i -= 8 {
+		sum, c := bits.Add64(
+			binary.BigEndian.Uint64(m.Checksum[i:]),
+			binary.BigEndian.Uint64(hash[i:]),
+			carry,
+		)
+		binary.BigEndian.PutUint64(m.Checksum[i:], sum)
+		carry = c
+	}
+
+	m.Entries++
+}
+
+// Matches returns whether the given manifest covers the same entries as this
+// manifest.
+func (m Manifest) Matches(other Manifest) bool {
+	return m.Entries == other.Entries && m.Checksum == other.Checksum
+}
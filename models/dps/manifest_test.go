@@ -0,0 +1,91 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+func TestManifest(t *testing.T) {
+	keys := [][]byte{[]byte("first"), []byte("second"), []byte("third")}
+	values := [][]byte{[]byte("one"), []byte("two"), []byte("three")}
+
+	t.Run("empty manifest", func(t *testing.T) {
+		t.Parallel()
+
+		var manifest dps.Manifest
+
+		assert.Zero(t, manifest.Entries)
+		assert.True(t, manifest.Matches(dps.Manifest{Height: 1}))
+	})
+
+	t.Run("independent from order", func(t *testing.T) {
+		t.Parallel()
+
+		var forward dps.Manifest
+		for i := range keys {
+			forward.Add(keys[i], values[i])
+		}
+
+		var backward dps.Manifest
+		for i := len(keys) - 1; i >= 0; i-- {
+			backward.Add(keys[i], values[i])
+		}
+
+		assert.Equal(t, uint64(len(keys)), forward.Entries)
+		assert.True(t, forward.Matches(backward))
+	})
+
+	t.Run("detects changed value", func(t *testing.T) {
+		t.Parallel()
+
+		var manifest dps.Manifest
+		manifest.Add(keys[0], values[0])
+
+		var changed dps.Manifest
+		changed.Add(keys[0], values[1])
+
+		assert.False(t, manifest.Matches(changed))
+	})
+
+	t.Run("detects moved bytes", func(t *testing.T) {
+		t.Parallel()
+
+		var manifest dps.Manifest
+		manifest.Add([]byte("ab"), []byte("c"))
+
+		var moved dps.Manifest
+		moved.Add([]byte("a"), []byte("bc"))
+
+		assert.False(t, manifest.Matches(moved))
+	})
+
+	t.Run("detects missing entry", func(t *testing.T) {
+		t.Parallel()
+
+		var manifest dps.Manifest
+		manifest.Add(keys[0], values[0])
+		manifest.Add(keys[1], values[1])
+
+		var missing dps.Manifest
+		missing.Add(keys[0], values[0])
+
+		assert.False(t, manifest.Matches(missing))
+	})
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"time"
+)
+
+// MapperInfo is the introspection of the mapper's finite state machine. It
+// holds the status that the mapper is currently in, the last height that it
+// finished processing, the time it spent in each transition and the last error
+// that it encountered, if any.
+type MapperInfo struct {
+	Status      string           `json:"status"`
+	Height      uint64           `json:"height"`
+	Processed   *time.Time       `json:"processed,omitempty"`
+	Transitions []TransitionInfo `json:"transitions"`
+	Error       string           `json:"error,omitempty"`
+	Failed      *time.Time       `json:"failed,omitempty"`
+}
+
+// TransitionInfo is the introspection of a single transition of the mapper's
+// finite state machine, with the number of times it was applied and the total
+// time spent applying it, in seconds.
+type TransitionInfo struct {
+	Status  string  `json:"status"`
+	Count   uint64  `json:"count"`
+	Seconds float64 `json:"seconds"`
+}
@@ -0,0 +1,155 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"encoding/binary"
+
+	"github.com/onflow/flow-go/ledger"
+)
+
+// PathFilterWindow is the number of heights covered by each path filter.
+const PathFilterWindow = 10000
+
+// Parameters of the path filter stages. With twelve bits and eight hashes per
+// path, each stage has a false positive rate of about 0.3% once full. The
+// capacity of the stages doubles with each new stage, so that the number of
+// stages, and thus the false positive rate of the whole filter, grows only
+// logarithmically with the number of paths.
+const (
+	pathFilterCapacity    = 1 << 16
+	pathFilterBitsPerPath = 12
+	pathFilterHashes      = 8
+)
+
+// PathFilter is a scalable bloom filter over the ledger paths that were
+// written between two heights of the same window. It can tell with certainty
+// that a path was not written between these heights, which allows lookups of
+// registers that never existed to skip the index entirely.
+type PathFilter struct {
+	From   uint64
+	To     uint64
+	Stages []PathFilterStage
+}
+
+// PathFilterStage is a single bloom filter with a fixed capacity, which is one
+// of the stages of a path filter.
+type PathFilterStage struct {
+	Capacity uint64
+	Count    uint64
+	Bits     []byte
+}
+
+// NewPathFilter creates a new empty path filter starting at the given height.
+func NewPathFilter(from uint64) *PathFilter {
+
+	f := PathFilter{
+		From: from,
+		To:   from,
+	}
+
+	return &f
+}
+
+// WindowForHeight returns the number of the path filter window that contains
+// the given height.
+func WindowForHeight(height uint64) uint64 {
+	return height / PathFilterWindow
+}
+
+// Add adds the given path to the filter.
+func (f *PathFilter) Add(path ledger.Path) {
+
+	// Paths that might already be in the filter don't count towards the
+	// capacity, which avoids adding stages for registers that are written
+	// over and over again.
+	if f.Has(path) {
+		return
+	}
+
+	if len(f.Stages) == 0 || f.Stages[len(f.Stages)-1].Count >= f.Stages[len(f.Stages)-1].Capacity {
+		capacity := uint64(pathFilterCapacity)
+		if len(f.Stages) > 0 {
+			capacity = 2 * f.Stages[len(f.Stages)-1].Capacity
+		}
+		stage := PathFilterStage{
+			Capacity: capacity,
+			Bits:     make([]byte, capacity*pathFilterBitsPerPath/8),
+		}
+		f.Stages = append(f.Stages, stage)
+	}
+
+	stage := &f.Stages[len(f.Stages)-1]
+	size := uint64(len(stage.Bits)) * 8
+	h1, h2 := pathHashes(path)
+	for i := uint64(0); i < pathFilterHashes; i++ {
+		bit := (h1 + i*h2) % size
+		stage.Bits[bit/8] |= 1 << (bit % 8)
+	}
+	stage.Count++
+}
+
+// Has returns whether the given path might have been added to the filter. If
+// it returns false, the path was definitely never added.
+func (f *PathFilter) Has(path ledger.Path) bool {
+	h1, h2 := pathHashes(path)
+	for _, stage := range f.Stages {
+		if stage.has(h1, h2) {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge adds all paths of the given filter to this filter, and extends its
+// height range to include the heights of the given filter. Merging filters
+// with disjoint height ranges would make the merged filter cover heights that
+// neither of them covers, so it should only be done for filters that overlap
+// or are adjacent.
+func (f *PathFilter) Merge(other *PathFilter) {
+	if other.From < f.From {
+		f.From = other.From
+	}
+	if other.To > f.To {
+		f.To = other.To
+	}
+	stages := make([]PathFilterStage, 0, len(other.Stages)+len(f.Stages))
+	stages = append(stages, other.Stages...)
+	stages = append(stages, f.Stages...)
+	f.Stages = stages
+}
+
+func (s PathFilterStage) has(h1 uint64, h2 uint64) bool {
+	size := uint64(len(s.Bits)) * 8
+	if size == 0 {
+		return false
+	}
+	for i := uint64(0); i < pathFilterHashes; i++ {
+		bit := (h1 + i*h2) % size
+		if s.Bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// pathHashes returns the two hashes used to derive the bit positions of the
+// given path by double hashing. Paths are already the output of a
+// cryptographic hash function, so their bytes can be used directly.
+func pathHashes(path ledger.Path) (uint64, uint64) {
+	h1 := binary.BigEndian.Uint64(path[0:8])
+	h2 := binary.BigEndian.Uint64(path[8:16]) | 1
+	return h1, h2
+}
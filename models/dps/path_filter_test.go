@@ -0,0 +1,110 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestPathFilter(t *testing.T) {
+	paths := mocks.GenericLedgerPaths(200000)
+	added := paths[:100000]
+	missing := paths[100000:]
+
+	t.Run("empty filter", func(t *testing.T) {
+		t.Parallel()
+
+		filter := dps.NewPathFilter(mocks.GenericHeight)
+
+		assert.Equal(t, mocks.GenericHeight, filter.From)
+		assert.Equal(t, mocks.GenericHeight, filter.To)
+		assert.False(t, filter.Has(paths[0]))
+	})
+
+	t.Run("no false negatives", func(t *testing.T) {
+		t.Parallel()
+
+		filter := dps.NewPathFilter(mocks.GenericHeight)
+		for _, path := range added {
+			filter.Add(path)
+		}
+
+		assert.Len(t, filter.Stages, 2)
+		for _, path := range added {
+			assert.True(t, filter.Has(path))
+		}
+	})
+
+	t.Run("few false positives", func(t *testing.T) {
+		t.Parallel()
+
+		filter := dps.NewPathFilter(mocks.GenericHeight)
+		for _, path := range added {
+			filter.Add(path)
+		}
+
+		positives := 0
+		for _, path := range missing {
+			if filter.Has(path) {
+				positives++
+			}
+		}
+
+		assert.Less(t, positives, len(missing)/100)
+	})
+
+	t.Run("repeated paths do not add stages", func(t *testing.T) {
+		t.Parallel()
+
+		filter := dps.NewPathFilter(mocks.GenericHeight)
+		for i := 0; i < 100000; i++ {
+			filter.Add(paths[0])
+		}
+
+		assert.Len(t, filter.Stages, 1)
+		assert.Equal(t, uint64(1), filter.Stages[0].Count)
+	})
+
+	t.Run("merge filters", func(t *testing.T) {
+		t.Parallel()
+
+		filter := dps.NewPathFilter(10)
+		filter.To = 19
+		filter.Add(paths[0])
+
+		other := dps.NewPathFilter(0)
+		other.To = 9
+		other.Add(paths[1])
+
+		filter.Merge(other)
+
+		assert.Equal(t, uint64(0), filter.From)
+		assert.Equal(t, uint64(19), filter.To)
+		assert.True(t, filter.Has(paths[0]))
+		assert.True(t, filter.Has(paths[1]))
+		assert.False(t, filter.Has(paths[2]))
+	})
+}
+
+func TestWindowForHeight(t *testing.T) {
+	assert.Equal(t, uint64(0), dps.WindowForHeight(0))
+	assert.Equal(t, uint64(0), dps.WindowForHeight(dps.PathFilterWindow-1))
+	assert.Equal(t, uint64(1), dps.WindowForHeight(dps.PathFilterWindow))
+}
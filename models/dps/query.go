@@ -0,0 +1,91 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Query is the record of the execution of a single API request. The rows are
+// the number of items that range scans of the index visited for the request,
+// such as events or heights, which can be far more than the items in the
+// response; requests that only look up items by their key scan no rows. The
+// bytes are the size of the encoded response.
+type Query struct {
+	Method   string
+	Peer     string
+	Start    time.Time
+	Duration time.Duration
+	Rows     uint64
+	Bytes    uint64
+	Failed   bool
+}
+
+// QueryStats are the statistics of the most recent API requests, both per
+// method and for the slowest individual requests. Latencies are given in
+// seconds.
+type QueryStats struct {
+	Queries uint64        `json:"queries"`
+	Since   time.Time     `json:"since"`
+	Methods []MethodStats `json:"methods"`
+	Slowest []QueryRecord `json:"slowest"`
+}
+
+// MethodStats are the statistics of the recent API requests to a single method.
+type MethodStats struct {
+	Method  string  `json:"method"`
+	Count   uint64  `json:"count"`
+	Failed  uint64  `json:"failed"`
+	P50     float64 `json:"latency_p50"`
+	P90     float64 `json:"latency_p90"`
+	P99     float64 `json:"latency_p99"`
+	Max     float64 `json:"latency_max"`
+	Rows    uint64  `json:"rows"`
+	MaxRows uint64  `json:"rows_max"`
+	Bytes   uint64  `json:"bytes"`
+}
+
+// QueryRecord is the JSON representation of a single API request in the query
+// statistics.
+type QueryRecord struct {
+	Method  string    `json:"method"`
+	Peer    string    `json:"peer"`
+	Start   time.Time `json:"start"`
+	Latency float64   `json:"latency"`
+	Rows    uint64    `json:"rows"`
+	Bytes   uint64    `json:"bytes"`
+	Failed  bool      `json:"failed"`
+}
+
+type scanKey struct{}
+
+// CountScans returns a context that counts the items that range scans of the
+// index visit on behalf of the request of the given context, along with the
+// counter.
+func CountScans(ctx context.Context) (context.Context, *uint64) {
+	var count uint64
+	return context.WithValue(ctx, scanKey{}, &count), &count
+}
+
+// Scanned counts an item visited by a range scan of the index, if the given
+// context counts them.
+func Scanned(ctx context.Context) {
+	count, ok := ctx.Value(scanKey{}).(*uint64)
+	if ok {
+		atomic.AddUint64(count, 1)
+	}
+}
@@ -15,30 +15,98 @@
 package dps
 
 import (
+	"context"
+
 	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/model/flow"
 )
 
-// Reader represents something that can read from a DPS index.
+// Reader represents something that can read from a DPS index. Reads stop
+// early when their context is done, so that a canceled API request does not
+// keep iterating over the index, and the context carries request-scoped values
+// such as tracing spans down to the index.
+//
+// It is composed of narrower readers, so that components which only need part
+// of the index can depend on, and be tested against, only that part.
 type Reader interface {
-	First() (uint64, error)
-	Last() (uint64, error)
-
-	HeightForBlock(blockID flow.Identifier) (uint64, error)
-	HeightForTransaction(txID flow.Identifier) (uint64, error)
-
-	Commit(height uint64) (flow.StateCommitment, error)
-	Header(height uint64) (*flow.Header, error)
-	Events(height uint64, types ...flow.EventType) ([]flow.Event, error)
-	Values(height uint64, paths []ledger.Path) ([]ledger.Value, error)
-
-	Collection(collID flow.Identifier) (*flow.LightCollection, error)
-	Guarantee(collID flow.Identifier) (*flow.CollectionGuarantee, error)
-	Transaction(txID flow.Identifier) (*flow.TransactionBody, error)
-	Seal(sealID flow.Identifier) (*flow.Seal, error)
-	Result(txID flow.Identifier) (*flow.TransactionResult, error)
-
-	CollectionsByHeight(height uint64) ([]flow.Identifier, error)
-	TransactionsByHeight(height uint64) ([]flow.Identifier, error)
-	SealsByHeight(height uint64) ([]flow.Identifier, error)
+	HeightReader
+	RegisterReader
+	EventReader
+	TransactionReader
+}
+
+// Snapshotter represents an index reader that can serve a sequence of reads
+// from a single consistent view of the index, so that data written to the
+// index in the meantime can not tear reads across keys. The returned function
+// releases the view and has to be called once the reads are done. A snapshot
+// should not be used concurrently.
+type Snapshotter interface {
+	Snapshot() (Reader, func())
+}
+
+// HeightReader represents something that can read the range of indexed heights,
+// look up the height for block, transaction and commit identifiers, and read
+// the headers, state commitments and block statistics indexed at a given
+// height.
+type HeightReader interface {
+	First(ctx context.Context) (uint64, error)
+	Last(ctx context.Context) (uint64, error)
+
+	HeightForBlock(ctx context.Context, blockID flow.Identifier) (uint64, error)
+	HeightForTransaction(ctx context.Context, txID flow.Identifier) (uint64, error)
+	HeightForCommit(ctx context.Context, commit flow.StateCommitment) (uint64, error)
+
+	Commit(ctx context.Context, height uint64) (flow.StateCommitment, error)
+	Header(ctx context.Context, height uint64) (*flow.Header, error)
+	RawHeader(ctx context.Context, height uint64) ([]byte, error)
+	BlockStats(ctx context.Context, start uint64, end uint64) ([]BlockStats, error)
+}
+
+// RegisterReader represents something that can read the execution state
+// registers of the index and the statistics of their writes, as well as the
+// account and contract data that is derived from them.
+type RegisterReader interface {
+	Values(ctx context.Context, height uint64, paths []ledger.Path) ([]ledger.Value, error)
+	Registers(ctx context.Context, height uint64, owner flow.Address, after ledger.Path, limit uint) ([]Register, error)
+	DumpRegisters(ctx context.Context, height uint64, after ledger.Path, limit uint) ([]Register, error)
+	RegisterChurn(ctx context.Context, start uint64, end uint64, limit uint) (*ChurnReport, error)
+	RegisterDeltas(ctx context.Context, height uint64, after ledger.Path, limit uint) ([]RegisterDelta, error)
+
+	Account(ctx context.Context, address flow.Address) (*Account, error)
+	ContractHistory(ctx context.Context, address flow.Address, name string) ([]ContractVersion, error)
+
+	Filter(ctx context.Context) (Filter, error)
+}
+
+// EventReader represents something that can read the events of the index, as
+// well as the epochs and identities that are derived from service events.
+type EventReader interface {
+	Events(ctx context.Context, height uint64, types ...flow.EventType) ([]flow.Event, error)
+	RawEvents(ctx context.Context, height uint64, types ...flow.EventType) ([][]byte, error)
+	EventTypes(ctx context.Context) ([]EventTypeStats, error)
+	ServiceEvents(ctx context.Context, start uint64, end uint64, types ...flow.EventType) ([]ServiceEvent, error)
+
+	Epochs(ctx context.Context) ([]Epoch, error)
+	Identities(ctx context.Context, counter uint64) (flow.IdentityList, error)
+}
+
+// TransactionReader represents something that can read the collections,
+// guarantees, transactions, results and seals of the index.
+type TransactionReader interface {
+	Collection(ctx context.Context, collID flow.Identifier) (*flow.LightCollection, error)
+	Guarantee(ctx context.Context, collID flow.Identifier) (*flow.CollectionGuarantee, error)
+	Transaction(ctx context.Context, txID flow.Identifier) (*flow.TransactionBody, error)
+	Seal(ctx context.Context, sealID flow.Identifier) (*flow.Seal, error)
+	Result(ctx context.Context, txID flow.Identifier) (*flow.TransactionResult, error)
+
+	RawCollection(ctx context.Context, collID flow.Identifier) ([]byte, error)
+	RawGuarantee(ctx context.Context, collID flow.Identifier) ([]byte, error)
+	RawTransaction(ctx context.Context, txID flow.Identifier) ([]byte, error)
+	RawSeal(ctx context.Context, sealID flow.Identifier) ([]byte, error)
+	RawResult(ctx context.Context, txID flow.Identifier) ([]byte, error)
+
+	CollectionsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error)
+	TransactionsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error)
+	TransactionsForScript(ctx context.Context, hash flow.Identifier, start uint64, end uint64) ([]flow.Identifier, error)
+	SealsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error)
 }
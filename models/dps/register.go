@@ -0,0 +1,44 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"github.com/onflow/flow-go/engine/execution/state"
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// Register is a register of the execution state, along with the path at which
+// it is stored in the execution state trie.
+type Register struct {
+	Path    ledger.Path
+	Payload *ledger.Payload
+}
+
+// PayloadOwner returns the address of the account that owns the register of
+// the given payload. Registers that are not owned by any account, such as the
+// global registers of the execution state, return false.
+func PayloadOwner(payload *ledger.Payload) (flow.Address, bool) {
+	for _, part := range payload.Key.KeyParts {
+		if part.Type != state.KeyPartOwner {
+			continue
+		}
+		if len(part.Value) == 0 {
+			return flow.EmptyAddress, false
+		}
+		return flow.BytesToAddress(part.Value), true
+	}
+	return flow.EmptyAddress, false
+}
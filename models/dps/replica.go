@@ -0,0 +1,46 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+// ReplicaEntry is a write to the index that is replicated to a secondary index.
+// The key and value are stored as they are in the index, so that the secondary
+// index can apply them without decoding them.
+type ReplicaEntry struct {
+	Key     []byte
+	Value   []byte
+	Deleted bool
+	// Height is the height that the entry sets as the last indexed height, or
+	// zero if the entry does not update it.
+	Height uint64
+}
+
+// ReplicaBatch is a batch of consecutive writes to the index, which are written
+// to the secondary index together. Batches end after the write that updates
+// the last indexed height, so that each indexed height is replicated as soon
+// as it is complete.
+type ReplicaBatch struct {
+	First   uint64
+	Last    uint64
+	Height  uint64
+	Entries []ReplicaEntry
+}
+
+// ReplicaState is the progress of the replication to the secondary index. It
+// holds the sequence number of the last write that was replicated, and the
+// last indexed height that was replicated along with it.
+type ReplicaState struct {
+	Sequence uint64
+	Height   uint64
+}
@@ -0,0 +1,28 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+import (
+	"crypto/sha256"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// ScriptHash returns the hash under which transactions are indexed by their
+// script. It is the SHA-256 hash of the script source, so that clients can
+// compute it for known transaction templates with standard tools.
+func ScriptHash(script []byte) flow.Identifier {
+	return flow.Identifier(sha256.Sum256(script))
+}
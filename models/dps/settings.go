@@ -0,0 +1,25 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+// Settings are the settings of the live indexer that can be changed while it
+// runs, without a restart that would drop the peer connections of its consensus
+// follower. Durations are given as strings, such as `5s`. When settings are
+// updated, the ones that are left empty keep their current value.
+type Settings struct {
+	Level      string `json:"level,omitempty"`
+	QueryLimit string `json:"query_limit,omitempty"`
+	QuerySlow  string `json:"query_slow,omitempty"`
+}
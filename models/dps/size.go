@@ -0,0 +1,26 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+// SizeStats holds the number of entries stored under a key prefix of the
+// index, along with the total size of their keys and values. They are kept up
+// to date as entries are written and deleted, so that the size of each kind of
+// data can be known without scanning the database.
+type SizeStats struct {
+	Prefix     uint8
+	Keys       uint64
+	KeyBytes   uint64
+	ValueBytes uint64
+}
@@ -15,6 +15,8 @@
 package dps
 
 import (
+	"context"
+
 	"github.com/dgraph-io/badger/v2"
 
 	"github.com/onflow/flow-go/ledger"
@@ -33,17 +35,28 @@ type Library interface {
 type ReadLibrary interface {
 	RetrieveFirst(height *uint64) func(*badger.Txn) error
 	RetrieveLast(height *uint64) func(*badger.Txn) error
+	RetrieveCodec(name *string) func(*badger.Txn) error
 
 	LookupHeightForBlock(blockID flow.Identifier, height *uint64) func(*badger.Txn) error
 	LookupHeightForTransaction(txID flow.Identifier, height *uint64) func(*badger.Txn) error
+	LookupHeightForCommit(commit flow.StateCommitment, height *uint64) func(*badger.Txn) error
+	LookupHeightForAccount(address flow.Address, height *uint64) func(*badger.Txn) error
+	LookupKeyUpdates(ctx context.Context, address flow.Address, heights *[]uint64) func(*badger.Txn) error
+	LookupPathsForOwner(ctx context.Context, address flow.Address, after ledger.Path, limit uint, paths *[]ledger.Path) func(*badger.Txn) error
+	LookupPaths(ctx context.Context, after ledger.Path, limit uint, paths *[]ledger.Path) func(*badger.Txn) error
 
 	RetrieveCommit(height uint64, commit *flow.StateCommitment) func(*badger.Txn) error
 	RetrieveHeader(height uint64, header *flow.Header) func(*badger.Txn) error
-	RetrieveEvents(height uint64, types []flow.EventType, events *[]flow.Event) func(*badger.Txn) error
+	RetrieveBlockStats(ctx context.Context, start uint64, end uint64, stats *[]BlockStats) func(*badger.Txn) error
+	LookupHeights(ctx context.Context, from uint64, to uint64, heights *[]uint64) func(*badger.Txn) error
+	RetrieveEvents(ctx context.Context, height uint64, types []flow.EventType, events *[]flow.Event) func(*badger.Txn) error
 	RetrievePayload(height uint64, path ledger.Path, payload *ledger.Payload) func(*badger.Txn) error
+	RetrieveRegisterChurn(ctx context.Context, start uint64, end uint64, churns *[]RegisterChurn) func(*badger.Txn) error
+	RetrieveRegisterDeltas(ctx context.Context, height uint64, after ledger.Path, limit uint, deltas *[]RegisterDelta) func(*badger.Txn) error
 
 	LookupTransactionsForHeight(height uint64, txIDs *[]flow.Identifier) func(*badger.Txn) error
 	LookupTransactionsForCollection(collID flow.Identifier, txIDs *[]flow.Identifier) func(*badger.Txn) error
+	LookupTransactionsForScript(ctx context.Context, hash flow.Identifier, start uint64, end uint64, txIDs *[]flow.Identifier) func(*badger.Txn) error
 	LookupCollectionsForHeight(height uint64, collIDs *[]flow.Identifier) func(*badger.Txn) error
 	LookupSealsForHeight(height uint64, sealIDs *[]flow.Identifier) func(*badger.Txn) error
 
@@ -53,7 +66,36 @@ type ReadLibrary interface {
 	RetrieveResult(txID flow.Identifier, result *flow.TransactionResult) func(*badger.Txn) error
 	RetrieveSeal(sealID flow.Identifier, seal *flow.Seal) func(*badger.Txn) error
 
-	IterateLedger(exclude func(height uint64) bool, process func(path ledger.Path, payload *ledger.Payload) error) func(*badger.Txn) error
+	RetrieveRawHeader(height uint64, data *[]byte) func(*badger.Txn) error
+	RetrieveRawEvents(ctx context.Context, height uint64, types []flow.EventType, data *[][]byte) func(*badger.Txn) error
+	RetrieveRawCollection(collID flow.Identifier, data *[]byte) func(*badger.Txn) error
+	RetrieveRawGuarantee(collID flow.Identifier, data *[]byte) func(*badger.Txn) error
+	RetrieveRawTransaction(txID flow.Identifier, data *[]byte) func(*badger.Txn) error
+	RetrieveRawResult(txID flow.Identifier, data *[]byte) func(*badger.Txn) error
+	RetrieveRawSeal(sealID flow.Identifier, data *[]byte) func(*badger.Txn) error
+
+	RetrieveCorruptions(ctx context.Context, corruptions map[uint64][]string) func(*badger.Txn) error
+	RetrieveExecutionRecord(blockID flow.Identifier, data *[]byte) func(*badger.Txn) error
+	RetrieveBackfill(name string, backfill *Backfill) func(*badger.Txn) error
+	RetrieveEventTypeStats(ctx context.Context, stats *[]EventTypeStats) func(*badger.Txn) error
+	RetrieveContractVersions(ctx context.Context, address flow.Address, name string, versions *[]ContractVersion) func(*badger.Txn) error
+	RetrieveServiceEvents(ctx context.Context, start uint64, end uint64, types []flow.EventType, events *[]ServiceEvent) func(*badger.Txn) error
+	RetrieveEpochs(ctx context.Context, epochs *[]Epoch) func(*badger.Txn) error
+	LookupEpochPhase(counter uint64, phase flow.EpochPhase, height *uint64) func(*badger.Txn) error
+	RetrieveIdentities(counter uint64, identities *flow.IdentityList) func(*badger.Txn) error
+	RetrieveOwners(owners *[]flow.Address) func(*badger.Txn) error
+	RetrieveDenied(denied *[]flow.Address) func(*badger.Txn) error
+	RetrievePathFilter(window uint64, filter *PathFilter) func(*badger.Txn) error
+	RetrieveManifest(height uint64, manifest *Manifest) func(*badger.Txn) error
+	ComputeManifests(ctx context.Context, from uint64, to uint64, manifests map[uint64]*Manifest) func(*badger.Txn) error
+	LookupStaging(ctx context.Context, heights *[]uint64) func(*badger.Txn) error
+
+	RetrieveSegments(ctx context.Context, segments *[]Segment) func(*badger.Txn) error
+	RetrieveReplicaState(state *ReplicaState) func(*badger.Txn) error
+
+	IterateLedger(ctx context.Context, exclude func(height uint64) bool, process func(path ledger.Path, payload *ledger.Payload) error) func(*badger.Txn) error
+	IterateHistory(ctx context.Context, cutoff uint64, process func(path ledger.Path, height uint64, value []byte) error) func(*badger.Txn) error
+	IterateReplicaLog(ctx context.Context, from uint64, process func(sequence uint64, entry ReplicaEntry) (bool, error)) func(*badger.Txn) error
 }
 
 // WriteLibrary represents something that produces operations to write on
@@ -61,17 +103,31 @@ type ReadLibrary interface {
 type WriteLibrary interface {
 	SaveFirst(height uint64) func(*badger.Txn) error
 	SaveLast(height uint64) func(*badger.Txn) error
+	SaveCodec(name string) func(*badger.Txn) error
 
 	IndexHeightForBlock(blockID flow.Identifier, height uint64) func(*badger.Txn) error
 	IndexHeightForTransaction(txID flow.Identifier, height uint64) func(*badger.Txn) error
+	IndexHeightForCommit(commit flow.StateCommitment, height uint64) func(*badger.Txn) error
+	IndexHeightForAccount(address flow.Address, height uint64) func(*badger.Txn) error
+	IndexKeyUpdate(address flow.Address, height uint64, count uint) func(*badger.Txn) error
+	IndexPathForOwner(address flow.Address, path ledger.Path, height uint64) func(*badger.Txn) error
+	SaveContractVersions(address flow.Address, name string, height uint64, versions []ContractVersion) func(*badger.Txn) error
+	SaveServiceEvents(height uint64, events []flow.Event) func(*badger.Txn) error
+	SaveEpoch(epoch *Epoch) func(*badger.Txn) error
+	IndexEpochPhase(counter uint64, phase flow.EpochPhase, height uint64) func(*badger.Txn) error
+	SaveIdentities(counter uint64, identities flow.IdentityList) func(*badger.Txn) error
 
 	SaveCommit(height uint64, commit flow.StateCommitment) func(*badger.Txn) error
 	SaveHeader(height uint64, header *flow.Header) func(*badger.Txn) error
+	SaveBlockStats(stats BlockStats) func(*badger.Txn) error
 	SaveEvents(height uint64, typ flow.EventType, events []flow.Event) func(*badger.Txn) error
 	SavePayload(height uint64, path ledger.Path, payload *ledger.Payload) func(*badger.Txn) error
+	SaveRegisterChurn(churn RegisterChurn) func(*badger.Txn) error
+	SaveRegisterDelta(delta RegisterDelta) func(*badger.Txn) error
 
 	IndexTransactionsForHeight(height uint64, txIDs []flow.Identifier) func(*badger.Txn) error
 	IndexTransactionsForCollection(collID flow.Identifier, txIDs []flow.Identifier) func(*badger.Txn) error
+	IndexTransactionsForScript(hash flow.Identifier, height uint64, txIDs []flow.Identifier) func(*badger.Txn) error
 	IndexCollectionsForHeight(height uint64, collIDs []flow.Identifier) func(*badger.Txn) error
 	IndexSealsForHeight(height uint64, sealIDs []flow.Identifier) func(*badger.Txn) error
 
@@ -80,4 +136,29 @@ type WriteLibrary interface {
 	SaveTransaction(transaction *flow.TransactionBody) func(*badger.Txn) error
 	SaveResult(results *flow.TransactionResult) func(*badger.Txn) error
 	SaveSeal(seal *flow.Seal) func(*badger.Txn) error
+
+	SaveCorruption(height uint64, reason string) func(*badger.Txn) error
+	SaveExecutionRecord(blockID flow.Identifier, data []byte) func(*badger.Txn) error
+	SaveBackfill(backfill *Backfill) func(*badger.Txn) error
+	SaveEventTypeStats(stats *EventTypeStats) func(*badger.Txn) error
+	UpdateCompressionStats() func(*badger.Txn) error
+	SaveSizeStats() func(*badger.Txn) error
+	UpdatePathFilter(window uint64, filter *PathFilter) func(*badger.Txn) error
+	SaveManifest(height uint64) func(*badger.Txn) error
+	SaveStaging(height uint64) func(*badger.Txn) error
+	PromoteStaging(height uint64) func(*badger.Txn) error
+	RollbackStaging(height uint64) func(*badger.Txn) error
+	SaveOwners(owners []flow.Address) func(*badger.Txn) error
+	SaveDenied(denied []flow.Address) func(*badger.Txn) error
+	SaveSegment(segment *Segment) func(*badger.Txn) error
+	SaveColdPayload(height uint64, path ledger.Path, value []byte, cold ColdValue) func(*badger.Txn) error
+	TrimReplicaLog(from uint64, state ReplicaState) func(*badger.Txn) error
+
+	TrackChanges() func(*badger.Txn) error
+	RevertChanges() func(*badger.Txn) error
+	ReleaseChanges() func(*badger.Txn) error
+
+	AcquireFence(holder string, force bool, fence *Fence) func(*badger.Txn) error
+	CheckFence(token uint64) func(*badger.Txn) error
+	ReleaseFence(token uint64) func(*badger.Txn) error
 }
@@ -0,0 +1,26 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package dps
+
+// VolumeInfo is the state of a volume on which the indexer stores data, with
+// the free space on it and the threshold below which the indexer stops
+// ingesting data, so that its databases do not run out of space mid-write.
+type VolumeInfo struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Free      uint64 `json:"free"`
+	Threshold uint64 `json:"threshold"`
+	Low       bool   `json:"low"`
+}
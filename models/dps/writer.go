@@ -15,25 +15,27 @@
 package dps
 
 import (
-	"github.com/onflow/flow-go/ledger"
+	"context"
+
 	"github.com/onflow/flow-go/model/flow"
 )
 
-// Writer represents something that can write on a DPS index.
+// Writer represents something that can write on a DPS index. Writes that have
+// not been applied yet are abandoned when their context is done.
+//
+// Everything that is indexed for a height is written with `WriteHeightBatch`.
+// The other methods write data that does not belong to the batch of a height,
+// such as the epochs derived from service events or the corruption of a
+// height that could not be indexed.
 type Writer interface {
-	First(height uint64) error
-	Last(height uint64) error
+	WriteHeightBatch(ctx context.Context, data HeightData) error
 
-	Height(blockID flow.Identifier, height uint64) error
+	First(ctx context.Context, height uint64) error
+	Filter(ctx context.Context, filter Filter) error
 
-	Commit(height uint64, commit flow.StateCommitment) error
-	Header(height uint64, header *flow.Header) error
-	Events(height uint64, events []flow.Event) error
-	Payloads(height uint64, paths []ledger.Path, values []*ledger.Payload) error
+	Epoch(ctx context.Context, setup *flow.EpochSetup) error
+	Phase(ctx context.Context, counter uint64, phase flow.EpochPhase, height uint64) error
 
-	Collections(height uint64, collections []*flow.LightCollection) error
-	Guarantees(height uint64, guarantees []*flow.CollectionGuarantee) error
-	Transactions(height uint64, transactions []*flow.TransactionBody) error
-	Results(results []*flow.TransactionResult) error
-	Seals(height uint64, seals []*flow.Seal) error
+	Corruption(ctx context.Context, height uint64, reason string) error
+	ExecutionRecord(ctx context.Context, blockID flow.Identifier, data []byte) error
 }
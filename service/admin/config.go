@@ -0,0 +1,80 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package admin
+
+// DefaultConfig is the default configuration for the admin server.
+var DefaultConfig = Config{
+	Queries:  nil,
+	Mapper:   nil,
+	Disk:     nil,
+	Settings: nil,
+	Records:  nil,
+}
+
+// Config is the configuration for the admin server.
+type Config struct {
+	Queries  Queries
+	Mapper   Mapper
+	Disk     Disk
+	Settings Settings
+	Records  Records
+}
+
+// Option is a function that can be applied to a Config.
+type Option func(*Config)
+
+// WithQueries sets the source of the query statistics that are served on the
+// `/queries` endpoint of the admin API. Without it, the endpoint is disabled.
+func WithQueries(queries Queries) Option {
+	return func(cfg *Config) {
+		cfg.Queries = queries
+	}
+}
+
+// WithMapper sets the source of the introspection of the mapper that is served
+// on the `/mapper` endpoint of the admin API. Without it, the endpoint is
+// disabled.
+func WithMapper(mapper Mapper) Option {
+	return func(cfg *Config) {
+		cfg.Mapper = mapper
+	}
+}
+
+// WithDisk sets the source of the free space on the volumes of the indexer that
+// is served on the `/health` endpoint of the admin API. Without it, the
+// endpoint always reports the indexer as healthy.
+func WithDisk(disk Disk) Option {
+	return func(cfg *Config) {
+		cfg.Disk = disk
+	}
+}
+
+// WithSettings sets the holder of the settings that are served and updated on
+// the `/settings` endpoint of the admin API. Without it, the endpoint is
+// disabled.
+func WithSettings(settings Settings) Option {
+	return func(cfg *Config) {
+		cfg.Settings = settings
+	}
+}
+
+// WithRecords sets the source of the archived execution records that are
+// served on the `/records/{block}` endpoint of the admin API. Without it, the
+// endpoint is disabled.
+func WithRecords(records Records) Option {
+	return func(cfg *Config) {
+		cfg.Records = records
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package admin
+
+import (
+	"context"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// Records represents something that provides the archived execution records of
+// the indexer, in their original encoding.
+type Records interface {
+	ExecutionRecord(ctx context.Context, blockID flow.Identifier) ([]byte, error)
+}
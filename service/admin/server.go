@@ -0,0 +1,327 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/logs"
+)
+
+// Modes in which the live indexer can run.
+const (
+	ModeActive  = "active"
+	ModeStandby = "standby"
+)
+
+// Status is the status of the live indexer, as returned by the admin API.
+type Status struct {
+	Mode string `json:"mode"`
+}
+
+// Health is the health of the live indexer, as returned by the admin API. The
+// indexer is unhealthy while one of its volumes is low on space, in which case
+// it keeps serving reads but stops ingesting data.
+type Health struct {
+	Healthy bool             `json:"healthy"`
+	Volumes []dps.VolumeInfo `json:"volumes"`
+}
+
+// Server is the http server that serves the admin API of the live indexer. It
+// lets operators check whether the indexer is active or in standby, and
+// promote it from standby to active, for example to fail over from another
+// instance that stopped. It also serves statistics about the most recent API
+// requests, to help identify expensive query patterns, the progress of the
+// mapper and the free space on the volumes of its databases, lets them change
+// the settings that do not require a restart, and serves the archived
+// execution records, so that they can be processed again without downloading
+// them from the bucket.
+type Server struct {
+	log      zerolog.Logger
+	cfg      Config
+	server   *http.Server
+	mutex    *sync.Mutex
+	active   bool
+	promoted chan struct{}
+}
+
+// NewServer creates a new server that exposes the admin API on the given
+// address. An indexer that is not active starts in standby, until it is
+// promoted through the API.
+func NewServer(log zerolog.Logger, address string, active bool, options ...Option) *Server {
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	s := Server{
+		log:      log.With().Str(logs.Component, "admin_server").Logger(),
+		cfg:      cfg,
+		mutex:    &sync.Mutex{},
+		active:   active,
+		promoted: make(chan struct{}),
+	}
+
+	if active {
+		close(s.promoted)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.status)
+	mux.HandleFunc("/promote", s.promote)
+	mux.HandleFunc("/queries", s.queries)
+	mux.HandleFunc("/mapper", s.mapper)
+	mux.HandleFunc("/health", s.health)
+	mux.HandleFunc("/settings", s.settings)
+	mux.HandleFunc("/records/", s.records)
+
+	s.server = &http.Server{
+		Addr:    address,
+		Handler: mux,
+	}
+
+	return &s
+}
+
+// Promoted returns a channel that is closed once the indexer is active, either
+// because it never was in standby or because it was promoted.
+func (s *Server) Promoted() <-chan struct{} {
+	return s.promoted
+}
+
+// Start launches the server. It blocks until the server is stopped.
+func (s *Server) Start() error {
+	err := s.server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("could not listen and serve: %w", err)
+	}
+
+	return nil
+}
+
+// Stop stops the server.
+func (s *Server) Stop() error {
+	err := s.server.Close()
+	if err != nil {
+		return fmt.Errorf("could not close server: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Server) status(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mutex.Lock()
+	status := Status{Mode: ModeStandby}
+	if s.active {
+		status.Mode = ModeActive
+	}
+	s.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(status)
+	if err != nil {
+		s.log.Warn().Err(err).Msg("could not write status")
+	}
+}
+
+func (s *Server) promote(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.active {
+		http.Error(w, "indexer is already active", http.StatusConflict)
+		return
+	}
+
+	s.log.Info().Msg("indexer promoted to active")
+
+	s.active = true
+	close(s.promoted)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) queries(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.cfg.Queries == nil {
+		http.Error(w, "query statistics are disabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(s.cfg.Queries.Stats())
+	if err != nil {
+		s.log.Warn().Err(err).Msg("could not write query statistics")
+	}
+}
+
+func (s *Server) mapper(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.cfg.Mapper == nil {
+		http.Error(w, "mapper introspection is disabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(s.cfg.Mapper.Info())
+	if err != nil {
+		s.log.Warn().Err(err).Msg("could not write mapper introspection")
+	}
+}
+
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	health := Health{
+		Healthy: true,
+		Volumes: []dps.VolumeInfo{},
+	}
+	if s.cfg.Disk != nil {
+		health.Volumes = s.cfg.Disk.Volumes()
+	}
+	for _, volume := range health.Volumes {
+		if volume.Low {
+			health.Healthy = false
+		}
+	}
+
+	// Load balancers and orchestrators usually only look at the status code of
+	// health checks, so we signal an unhealthy indexer with it as well.
+	w.Header().Set("Content-Type", "application/json")
+	if !health.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	err := json.NewEncoder(w).Encode(health)
+	if err != nil {
+		s.log.Warn().Err(err).Msg("could not write health")
+	}
+}
+
+func (s *Server) settings(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.cfg.Settings == nil {
+		http.Error(w, "settings are disabled", http.StatusNotFound)
+		return
+	}
+
+	// Settings are updated by posting the ones to change; the others keep
+	// their current value. Unknown settings are rejected, as they might be
+	// settings that can only be changed with a restart.
+	if r.Method == http.MethodPost {
+		var settings dps.Settings
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		err := dec.Decode(&settings)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("could not decode settings: %s", err), http.StatusBadRequest)
+			return
+		}
+		err = s.cfg.Settings.Update(settings)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("could not update settings: %s", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(s.cfg.Settings.Settings())
+	if err != nil {
+		s.log.Warn().Err(err).Msg("could not write settings")
+	}
+}
+
+func (s *Server) records(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.cfg.Records == nil {
+		http.Error(w, "execution record archive is disabled", http.StatusNotFound)
+		return
+	}
+
+	blockID, err := flow.HexStringToIdentifier(strings.TrimPrefix(r.URL.Path, "/records/"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not decode block ID: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// Records are served in the original encoding in which they were
+	// downloaded, so that they can be decoded like the records in the bucket.
+	data, err := s.cfg.Records.ExecutionRecord(r.Context(), blockID)
+	if errors.Is(err, dps.ErrNotIndexed) {
+		http.Error(w, "execution record not archived", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, dps.ErrUnavailable) {
+		http.Error(w, "execution record archive not available yet", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		s.log.Error().Err(err).Hex(logs.BlockID, blockID[:]).Msg("could not get execution record")
+		http.Error(w, "could not get execution record", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/cbor")
+	_, err = w.Write(data)
+	if err != nil {
+		s.log.Warn().Err(err).Msg("could not write execution record")
+	}
+}
@@ -0,0 +1,488 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestNewServer(t *testing.T) {
+	t.Run("active", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true)
+
+		assert.True(t, s.active)
+		assert.Equal(t, "127.0.0.1:0", s.server.Addr)
+		select {
+		case <-s.Promoted():
+		default:
+			t.Error("active server should be promoted")
+		}
+	})
+
+	t.Run("standby", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", false)
+
+		assert.False(t, s.active)
+		select {
+		case <-s.Promoted():
+			t.Error("standby server should not be promoted")
+		default:
+		}
+	})
+}
+
+func TestServer_Status(t *testing.T) {
+	t.Run("standby", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", false)
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var got Status
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+		assert.Equal(t, ModeStandby, got.Mode)
+	})
+
+	t.Run("active", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true)
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var got Status
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+		assert.Equal(t, ModeActive, got.Mode)
+	})
+
+	t.Run("handles invalid method", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true)
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/status", nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestServer_Promote(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", false)
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/promote", nil))
+
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.True(t, s.active)
+		select {
+		case <-s.Promoted():
+		default:
+			t.Error("active server should be promoted")
+		}
+	})
+
+	t.Run("handles already active indexer", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", false)
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/promote", nil))
+		require.Equal(t, http.StatusAccepted, rec.Code)
+
+		rec = httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/promote", nil))
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("handles invalid method", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", false)
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/promote", nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		assert.False(t, s.active)
+	})
+}
+
+func TestServer_Queries(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		stats := dps.QueryStats{
+			Queries: 1,
+			Methods: []dps.MethodStats{{Method: "/dps.API/GetEvents", Count: 1, Rows: 42}},
+		}
+		queries := mocks.BaselineRecorder(t)
+		queries.StatsFunc = func() dps.QueryStats {
+			return stats
+		}
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true, WithQueries(queries))
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/queries", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var got dps.QueryStats
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+		assert.Equal(t, stats.Queries, got.Queries)
+		assert.Equal(t, stats.Methods, got.Methods)
+	})
+
+	t.Run("handles disabled statistics", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true)
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/queries", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("handles invalid method", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true, WithQueries(mocks.BaselineRecorder(t)))
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/queries", nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestServer_Mapper(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true, WithMapper(mocks.BaselineMapper(t)))
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/mapper", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var got dps.MapperInfo
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+		assert.Equal(t, "index", got.Status)
+		assert.Equal(t, mocks.GenericHeight, got.Height)
+	})
+
+	t.Run("handles disabled introspection", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true)
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/mapper", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("handles invalid method", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true, WithMapper(mocks.BaselineMapper(t)))
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mapper", nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestServer_Health(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true, WithDisk(mocks.BaselineDisk(t)))
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var got Health
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+		assert.True(t, got.Healthy)
+		assert.Len(t, got.Volumes, 2)
+	})
+
+	t.Run("handles volume low on space", func(t *testing.T) {
+		t.Parallel()
+
+		disk := mocks.BaselineDisk(t)
+		disk.VolumesFunc = func() []dps.VolumeInfo {
+			return []dps.VolumeInfo{
+				{Name: "index", Path: "index", Free: 500, Threshold: 1000, Low: true},
+			}
+		}
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true, WithDisk(disk))
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		var got Health
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+		assert.False(t, got.Healthy)
+	})
+
+	t.Run("handles disabled disk monitoring", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true)
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var got Health
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+		assert.True(t, got.Healthy)
+		assert.Empty(t, got.Volumes)
+	})
+
+	t.Run("handles invalid method", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true)
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/health", nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestServer_Settings(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true, WithSettings(mocks.BaselineSettings(t)))
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/settings", nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var got dps.Settings
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+		assert.Equal(t, "info", got.Level)
+	})
+
+	t.Run("updates settings", func(t *testing.T) {
+		t.Parallel()
+
+		var updated dps.Settings
+		settings := mocks.BaselineSettings(t)
+		settings.UpdateFunc = func(s dps.Settings) error {
+			updated = s
+			return nil
+		}
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true, WithSettings(settings))
+
+		rec := httptest.NewRecorder()
+		body := strings.NewReader(`{"level": "debug"}`)
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/settings", body))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, dps.Settings{Level: "debug"}, updated)
+	})
+
+	t.Run("handles invalid settings", func(t *testing.T) {
+		t.Parallel()
+
+		settings := mocks.BaselineSettings(t)
+		settings.UpdateFunc = func(dps.Settings) error {
+			return mocks.GenericError
+		}
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true, WithSettings(settings))
+
+		rec := httptest.NewRecorder()
+		body := strings.NewReader(`{"level": "loud"}`)
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/settings", body))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("handles unknown settings", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true, WithSettings(mocks.BaselineSettings(t)))
+
+		rec := httptest.NewRecorder()
+		body := strings.NewReader(`{"cold_age": 100}`)
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/settings", body))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("handles disabled settings", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true)
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/settings", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("handles invalid method", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true, WithSettings(mocks.BaselineSettings(t)))
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/settings", nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
+
+func TestServer_Records(t *testing.T) {
+	blockID := mocks.GenericHeader.ID()
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		records := mocks.BaselineRecords(t)
+		records.ExecutionRecordFunc = func(_ context.Context, gotID flow.Identifier) ([]byte, error) {
+			assert.Equal(t, blockID, gotID)
+			return mocks.GenericBytes, nil
+		}
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true, WithRecords(records))
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/records/"+blockID.String(), nil))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/cbor", rec.Header().Get("Content-Type"))
+		assert.Equal(t, mocks.GenericBytes, rec.Body.Bytes())
+	})
+
+	t.Run("handles record not archived", func(t *testing.T) {
+		t.Parallel()
+
+		records := mocks.BaselineRecords(t)
+		records.ExecutionRecordFunc = func(context.Context, flow.Identifier) ([]byte, error) {
+			return nil, dps.ErrNotIndexed
+		}
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true, WithRecords(records))
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/records/"+blockID.String(), nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("handles archive not available yet", func(t *testing.T) {
+		t.Parallel()
+
+		records := mocks.BaselineRecords(t)
+		records.ExecutionRecordFunc = func(context.Context, flow.Identifier) ([]byte, error) {
+			return nil, dps.ErrUnavailable
+		}
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", false, WithRecords(records))
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/records/"+blockID.String(), nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+
+	t.Run("handles reader failure", func(t *testing.T) {
+		t.Parallel()
+
+		records := mocks.BaselineRecords(t)
+		records.ExecutionRecordFunc = func(context.Context, flow.Identifier) ([]byte, error) {
+			return nil, mocks.GenericError
+		}
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true, WithRecords(records))
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/records/"+blockID.String(), nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+
+	t.Run("handles invalid block ID", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true, WithRecords(mocks.BaselineRecords(t)))
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/records/invalid", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("handles disabled archive", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true)
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/records/"+blockID.String(), nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("handles invalid method", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewServer(zerolog.Nop(), "127.0.0.1:0", true, WithRecords(mocks.BaselineRecords(t)))
+
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/records/"+blockID.String(), nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
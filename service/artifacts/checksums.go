@@ -0,0 +1,86 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package artifacts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// checksums caches the SHA-256 checksums of the served files, so that large
+// artifacts such as checkpoints are only hashed once. A cached checksum is
+// discarded as soon as the size or the modification time of its file changes.
+type checksums struct {
+	mutex   *sync.Mutex
+	entries map[string]checksum
+}
+
+type checksum struct {
+	size     int64
+	modified time.Time
+	sum      string
+}
+
+func newChecksums() *checksums {
+
+	c := checksums{
+		mutex:   &sync.Mutex{},
+		entries: make(map[string]checksum),
+	}
+
+	return &c
+}
+
+// Sum returns the hex-encoded SHA-256 checksum of the file at the given path,
+// which is described by the given file info.
+func (c *checksums) Sum(path string, info os.FileInfo) (string, error) {
+
+	c.mutex.Lock()
+	entry, ok := c.entries[path]
+	c.mutex.Unlock()
+	if ok && entry.size == info.Size() && entry.modified.Equal(info.ModTime()) {
+		return entry.sum, nil
+	}
+
+	// The file is hashed without holding the lock, so that hashing a large
+	// file does not block the listings of other directories.
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("could not open file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", fmt.Errorf("could not hash file: %w", err)
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+
+	c.mutex.Lock()
+	c.entries[path] = checksum{
+		size:     info.Size(),
+		modified: info.ModTime(),
+		sum:      sum,
+	}
+	c.mutex.Unlock()
+
+	return sum, nil
+}
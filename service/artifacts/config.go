@@ -0,0 +1,40 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package artifacts
+
+// DefaultConfig is the default configuration for the artifacts server.
+var DefaultConfig = Config{
+	CertFile: "",
+	KeyFile:  "",
+}
+
+// Config is the configuration for the artifacts server.
+type Config struct {
+	CertFile string
+	KeyFile  string
+}
+
+// Option is a function that can be applied to a Config.
+type Option func(*Config)
+
+// WithTLS makes the server serve the artifacts over HTTPS, using the
+// certificate and private key in the given PEM files. Without it, the
+// artifacts are served over plain HTTP.
+func WithTLS(certFile string, keyFile string) Option {
+	return func(cfg *Config) {
+		cfg.CertFile = certFile
+		cfg.KeyFile = keyFile
+	}
+}
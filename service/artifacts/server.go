@@ -0,0 +1,303 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package artifacts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/optakt/flow-dps/service/logs"
+)
+
+// SuffixChecksum is the suffix that is appended to the path of a served file
+// to retrieve its checksum, in the format used by `sha256sum`.
+const SuffixChecksum = ".sha256"
+
+// Directory is a local directory whose files are served under the given name.
+type Directory struct {
+	Name string
+	Path string
+}
+
+// Entry is a file or subdirectory in a directory listing of the artifacts
+// server.
+type Entry struct {
+	Name     string    `json:"name"`
+	Dir      bool      `json:"dir,omitempty"`
+	Size     int64     `json:"size,omitempty"`
+	Modified time.Time `json:"modified"`
+	SHA256   string    `json:"sha256,omitempty"`
+}
+
+// Server is the http server that serves the artifacts produced by the node,
+// such as checkpoints of the execution state trie or snapshots of the index,
+// so that operators can bootstrap new nodes directly from a running one. It is
+// read-only: directories are listed as JSON, with the SHA-256 checksum of each
+// file, and files are served with support for range requests, so that
+// interrupted downloads can be resumed.
+type Server struct {
+	log    zerolog.Logger
+	cfg    Config
+	server *http.Server
+	dirs   map[string]string
+	sums   *checksums
+}
+
+// NewServer creates a new server that serves the files of the given
+// directories on the given address.
+func NewServer(log zerolog.Logger, address string, dirs []Directory, options ...Option) *Server {
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	s := Server{
+		log:  log.With().Str(logs.Component, "artifacts_server").Logger(),
+		cfg:  cfg,
+		dirs: make(map[string]string),
+		sums: newChecksums(),
+	}
+
+	for _, dir := range dirs {
+		s.dirs[dir.Name] = dir.Path
+	}
+
+	s.server = &http.Server{
+		Addr:    address,
+		Handler: http.HandlerFunc(s.serve),
+	}
+
+	return &s
+}
+
+// Start launches the server. It blocks until the server is stopped.
+func (s *Server) Start() error {
+
+	var err error
+	if s.cfg.CertFile != "" {
+		err = s.server.ListenAndServeTLS(s.cfg.CertFile, s.cfg.KeyFile)
+	} else {
+		err = s.server.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("could not listen and serve: %w", err)
+	}
+
+	return nil
+}
+
+// Stop stops the server.
+func (s *Server) Stop() error {
+	err := s.server.Close()
+	if err != nil {
+		return fmt.Errorf("could not close server: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Cleaning the path removes any `..` elements, so that the requested path
+	// can never point outside of the served directories.
+	clean := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if clean == "" {
+		s.root(w)
+		return
+	}
+
+	parts := strings.SplitN(clean, "/", 2)
+	dir, ok := s.dirs[parts[0]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	rel := ""
+	if len(parts) > 1 {
+		rel = parts[1]
+	}
+	for _, name := range strings.Split(rel, "/") {
+		if hidden(name) || !local(name) {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	if strings.HasSuffix(rel, SuffixChecksum) {
+		s.checksum(w, r, filepath.Join(dir, filepath.FromSlash(strings.TrimSuffix(rel, SuffixChecksum))))
+		return
+	}
+
+	full := filepath.Join(dir, filepath.FromSlash(rel))
+	info, err := os.Lstat(full)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case info.IsDir():
+		s.list(w, full)
+	case info.Mode().IsRegular():
+		s.file(w, r, full)
+	default:
+		// Symbolic links and other special files are never served, as they
+		// could point outside of the served directories.
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) root(w http.ResponseWriter) {
+
+	entries := make([]Entry, 0, len(s.dirs))
+	for name, dir := range s.dirs {
+		entry := Entry{
+			Name: name,
+			Dir:  true,
+		}
+		info, err := os.Stat(dir)
+		if err == nil {
+			entry.Modified = info.ModTime().UTC()
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i int, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	s.encode(w, entries)
+}
+
+func (s *Server) list(w http.ResponseWriter, dir string) {
+
+	infos, err := os.ReadDir(dir)
+	if err != nil {
+		s.log.Warn().Err(err).Str("dir", dir).Msg("could not read directory")
+		http.Error(w, "could not read directory", http.StatusInternalServerError)
+		return
+	}
+
+	entries := make([]Entry, 0, len(infos))
+	for _, item := range infos {
+		if hidden(item.Name()) {
+			continue
+		}
+		info, err := item.Info()
+		if err != nil {
+			// The file was removed since the directory was read.
+			continue
+		}
+		entry := Entry{
+			Name:     item.Name(),
+			Modified: info.ModTime().UTC(),
+		}
+		switch {
+		case info.IsDir():
+			entry.Dir = true
+		case info.Mode().IsRegular():
+			entry.Size = info.Size()
+			entry.SHA256, err = s.sums.Sum(filepath.Join(dir, item.Name()), info)
+			if err != nil {
+				s.log.Warn().Err(err).Str("file", item.Name()).Msg("could not compute checksum")
+				continue
+			}
+		default:
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	s.encode(w, entries)
+}
+
+func (s *Server) file(w http.ResponseWriter, r *http.Request, name string) {
+
+	file, err := os.Open(name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, "could not read file", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
+
+func (s *Server) checksum(w http.ResponseWriter, r *http.Request, name string) {
+
+	info, err := os.Lstat(name)
+	if err != nil || !info.Mode().IsRegular() {
+		http.NotFound(w, r)
+		return
+	}
+
+	sum, err := s.sums.Sum(name, info)
+	if err != nil {
+		s.log.Warn().Err(err).Str("file", name).Msg("could not compute checksum")
+		http.Error(w, "could not compute checksum", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, err = fmt.Fprintf(w, "%s  %s\n", sum, info.Name())
+	if err != nil {
+		s.log.Warn().Err(err).Msg("could not write checksum")
+	}
+}
+
+func (s *Server) encode(w http.ResponseWriter, entries []Entry) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(entries)
+	if err != nil {
+		s.log.Warn().Err(err).Msg("could not write directory listing")
+	}
+}
+
+// hidden returns whether the file with the given name is kept out of the
+// listings and downloads, which is the case for dot files and for the
+// temporary files of artifacts that are still being written.
+func hidden(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".tmp")
+}
+
+// local returns whether the given element of a requested path can only point to
+// an entry of its directory. On Windows, backslashes are separators and names
+// can start with a drive letter, so cleaning the requested path is not enough to
+// keep it within the served directories.
+func local(name string) bool {
+	return !strings.ContainsRune(name, filepath.Separator) && filepath.VolumeName(name) == ""
+}
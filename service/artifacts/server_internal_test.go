@@ -0,0 +1,180 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package artifacts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServer(t *testing.T) {
+	dirs := []Directory{
+		{Name: "checkpoints", Path: "/checkpoints"},
+		{Name: "snapshots", Path: "/snapshots"},
+	}
+
+	s := NewServer(zerolog.Nop(), "127.0.0.1:0", dirs, WithTLS("cert.pem", "key.pem"))
+
+	assert.Equal(t, "127.0.0.1:0", s.server.Addr)
+	assert.Equal(t, "cert.pem", s.cfg.CertFile)
+	assert.Equal(t, "key.pem", s.cfg.KeyFile)
+	assert.Equal(t, "/checkpoints", s.dirs["checkpoints"])
+	assert.Equal(t, "/snapshots", s.dirs["snapshots"])
+}
+
+func TestServer_Serve(t *testing.T) {
+	data := []byte("checkpoint data")
+	hash := sha256.Sum256(data)
+	sum := hex.EncodeToString(hash[:])
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "checkpoint-100"), data, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "checkpoint-200.1234.tmp"), data, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden"), data, 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "nested", "snapshot"), data, 0644))
+
+	outside := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(outside, data, 0644))
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "link")))
+
+	s := NewServer(zerolog.Nop(), "127.0.0.1:0", []Directory{{Name: "checkpoints", Path: dir}})
+
+	serve := func(method string, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	t.Run("lists directories", func(t *testing.T) {
+		t.Parallel()
+
+		rec := serve(http.MethodGet, "/")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var got []Entry
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+		require.Len(t, got, 1)
+		assert.Equal(t, "checkpoints", got[0].Name)
+		assert.True(t, got[0].Dir)
+	})
+
+	t.Run("lists files with checksums", func(t *testing.T) {
+		t.Parallel()
+
+		rec := serve(http.MethodGet, "/checkpoints/")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var got []Entry
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&got))
+		require.Len(t, got, 2)
+		assert.Equal(t, "checkpoint-100", got[0].Name)
+		assert.False(t, got[0].Dir)
+		assert.Equal(t, int64(len(data)), got[0].Size)
+		assert.Equal(t, sum, got[0].SHA256)
+		assert.Equal(t, "nested", got[1].Name)
+		assert.True(t, got[1].Dir)
+	})
+
+	t.Run("serves files", func(t *testing.T) {
+		t.Parallel()
+
+		rec := serve(http.MethodGet, "/checkpoints/nested/snapshot")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, data, rec.Body.Bytes())
+	})
+
+	t.Run("serves ranges of files", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/checkpoints/checkpoint-100", nil)
+		req.Header.Set("Range", "bytes=11-")
+		rec := httptest.NewRecorder()
+		s.server.Handler.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusPartialContent, rec.Code)
+		got, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		assert.Equal(t, data[11:], got)
+	})
+
+	t.Run("serves checksums", func(t *testing.T) {
+		t.Parallel()
+
+		rec := serve(http.MethodGet, "/checkpoints/checkpoint-100"+SuffixChecksum)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, sum+"  checkpoint-100\n", rec.Body.String())
+	})
+
+	t.Run("hides temporary and hidden files", func(t *testing.T) {
+		t.Parallel()
+
+		rec := serve(http.MethodGet, "/checkpoints/checkpoint-200.1234.tmp")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = serve(http.MethodGet, "/checkpoints/.hidden")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("does not follow symbolic links", func(t *testing.T) {
+		t.Parallel()
+
+		rec := serve(http.MethodGet, "/checkpoints/link")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = serve(http.MethodGet, "/checkpoints/link"+SuffixChecksum)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("does not escape directories", func(t *testing.T) {
+		t.Parallel()
+
+		rec := serve(http.MethodGet, "/checkpoints/../../"+filepath.Base(outside))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = serve(http.MethodGet, "/checkpoints/nested%5C..%5C..%5C"+filepath.Base(outside))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("handles unknown directories", func(t *testing.T) {
+		t.Parallel()
+
+		rec := serve(http.MethodGet, "/snapshots/")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("rejects writes", func(t *testing.T) {
+		t.Parallel()
+
+		rec := serve(http.MethodPut, "/checkpoints/checkpoint-100")
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+		rec = serve(http.MethodDelete, "/checkpoints/checkpoint-100")
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
@@ -0,0 +1,33 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package backfill
+
+import (
+	"context"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Builder builds a secondary index for a single height, from the data that was
+// already indexed at that height. It returns the operations that write the
+// entries of the index, which the runner applies in the same transaction as its
+// progress. Builders should be idempotent, as a height is built again when the
+// runner is stopped before its progress was saved.
+type Builder interface {
+	Name() string
+	Build(ctx context.Context, read dps.Reader, write dps.WriteLibrary, height uint64) ([]func(*badger.Txn) error, error)
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package backfill
+
+import (
+	"time"
+)
+
+// DefaultConfig is the default configuration for backfills.
+var DefaultConfig = Config{
+	Rate:     100,
+	Interval: time.Minute,
+	Fence:    0,
+}
+
+// Config is the configuration for the runner of a backfill.
+type Config struct {
+	Rate     uint
+	Interval time.Duration
+	Fence    uint64
+}
+
+// Option is a function that can be applied to a Config.
+type Option func(*Config)
+
+// WithRate sets the maximum number of heights per second for which the runner
+// builds the index, so that the backfill does not slow down the indexing of new
+// heights. A rate of zero disables the throttling.
+func WithRate(rate uint) Option {
+	return func(cfg *Config) {
+		cfg.Rate = rate
+	}
+}
+
+// WithInterval sets the interval at which the runner logs its progress, and at
+// which it checks whether the index has any heights yet when it starts.
+func WithInterval(interval time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.Interval = interval
+	}
+}
+
+// WithFence sets the fencing token that the runner checks before writing to the
+// index, so that it stops when its index writer was fenced off by another
+// instance.
+func WithFence(token uint64) Option {
+	return func(cfg *Config) {
+		cfg.Fence = token
+	}
+}
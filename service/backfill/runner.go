@@ -0,0 +1,221 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/rs/zerolog"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/logs"
+)
+
+// Runner builds a newly introduced secondary index for the heights that were
+// indexed before it was introduced, in the background. When it starts for the
+// first time, it records the range of heights that are indexed at that point,
+// and then builds the index one height after the other, saving its progress
+// along with the entries of each height, so that it resumes where it left off
+// after a restart. Heights indexed after the backfill started are expected to
+// be built by the index writer.
+type Runner struct {
+	log   zerolog.Logger
+	db    *badger.DB
+	lib   dps.Library
+	read  dps.Reader
+	build Builder
+	cfg   Config
+	stop  chan struct{}
+	wg    *sync.WaitGroup
+}
+
+// NewRunner returns a new runner, which uses the given builder to backfill its
+// index in the given database. The library should not record its entries for
+// the height that is being indexed, as they belong to past heights.
+func NewRunner(log zerolog.Logger, db *badger.DB, lib dps.Library, read dps.Reader, build Builder, options ...Option) *Runner {
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	r := Runner{
+		log:   log.With().Str(logs.Component, "backfill").Str("backfill", build.Name()).Logger(),
+		db:    db,
+		lib:   lib,
+		read:  read,
+		build: build,
+		cfg:   cfg,
+		stop:  make(chan struct{}),
+		wg:    &sync.WaitGroup{},
+	}
+
+	return &r
+}
+
+// Run builds the index for the remaining heights of the backfill, until it is
+// complete or the runner is stopped.
+func (r *Runner) Run() error {
+	r.wg.Add(1)
+	defer r.wg.Done()
+
+	progress, err := r.progress()
+	if errors.Is(err, dps.ErrFinished) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not get backfill progress: %w", err)
+	}
+
+	if progress.Complete() {
+		r.log.Debug().Msg("backfill already complete")
+		return nil
+	}
+
+	r.log.Info().
+		Uint64("from", progress.From).
+		Uint64("to", progress.To).
+		Uint64("next", progress.Next).
+		Msg("backfill started")
+
+	var delay time.Duration
+	if r.cfg.Rate > 0 {
+		delay = time.Second / time.Duration(r.cfg.Rate)
+	}
+	logged := time.Now()
+	for !progress.Complete() {
+
+		height := progress.Next
+		ops, err := r.build.Build(context.Background(), r.read, r.lib, height)
+		if err != nil {
+			return fmt.Errorf("could not build index (height: %d): %w", height, err)
+		}
+
+		progress.Next = height + 1
+		ops = append(ops, r.lib.SaveBackfill(&progress))
+		err = r.update(ops...)
+		if err != nil {
+			return fmt.Errorf("could not write index (height: %d): %w", height, err)
+		}
+
+		if time.Since(logged) >= r.cfg.Interval {
+			r.log.Info().Uint64(logs.Height, height).Uint64("to", progress.To).Msg("backfill in progress")
+			logged = time.Now()
+		}
+
+		select {
+		case <-r.stop:
+			return nil
+		case <-time.After(delay):
+			// continue
+		}
+	}
+
+	r.log.Info().
+		Uint64("from", progress.From).
+		Uint64("to", progress.To).
+		Msg("backfill complete")
+
+	return nil
+}
+
+// Stop gracefully stops the runner, which finishes writing the height that it
+// is currently building the index for.
+func (r *Runner) Stop() error {
+	close(r.stop)
+	r.wg.Wait()
+	return nil
+}
+
+// progress returns the progress of the backfill. When the backfill did not
+// start yet, its range is set to the heights that are indexed, once there are
+// any.
+func (r *Runner) progress() (dps.Backfill, error) {
+
+	progress := dps.Backfill{Name: r.build.Name()}
+	err := r.db.View(r.lib.RetrieveBackfill(progress.Name, &progress))
+	if err == nil {
+		return progress, nil
+	}
+	if !errors.Is(err, badger.ErrKeyNotFound) {
+		return dps.Backfill{}, fmt.Errorf("could not retrieve backfill: %w", err)
+	}
+
+	var first, last uint64
+	for {
+		err = r.db.View(func(tx *badger.Txn) error {
+			err := r.lib.RetrieveFirst(&first)(tx)
+			if err != nil {
+				return err
+			}
+			return r.lib.RetrieveLast(&last)(tx)
+		})
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, badger.ErrKeyNotFound) {
+			return dps.Backfill{}, fmt.Errorf("could not retrieve indexed heights: %w", err)
+		}
+
+		select {
+		case <-r.stop:
+			return dps.Backfill{}, dps.ErrFinished
+		case <-time.After(r.cfg.Interval):
+			// continue
+		}
+	}
+
+	progress.From = first
+	progress.To = last
+	progress.Next = first
+	err = r.update(r.lib.SaveBackfill(&progress))
+	if err != nil {
+		return dps.Backfill{}, fmt.Errorf("could not save backfill: %w", err)
+	}
+
+	return progress, nil
+}
+
+// update applies the given operations in a transaction, which also checks the
+// fence when fencing is enabled. When the transaction conflicts with one of the
+// index writer, it is applied again.
+func (r *Runner) update(ops ...func(*badger.Txn) error) error {
+	for {
+		err := r.db.Update(func(tx *badger.Txn) error {
+			if r.cfg.Fence != 0 {
+				err := r.lib.CheckFence(r.cfg.Fence)(tx)
+				if err != nil {
+					return err
+				}
+			}
+			for _, op := range ops {
+				err := op(tx)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if errors.Is(err, badger.ErrConflict) {
+			continue
+		}
+		return err
+	}
+}
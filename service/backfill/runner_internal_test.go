@@ -0,0 +1,196 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package backfill
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/storage"
+	"github.com/optakt/flow-dps/testing/helpers"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestRunner_Run(t *testing.T) {
+
+	// setup indexes the heights 10 to 15.
+	setup := func(t *testing.T) (*badger.DB, *storage.Library) {
+		t.Helper()
+
+		db := helpers.InMemoryDB(t)
+		lib := storage.New(zbor.NewCodec())
+
+		require.NoError(t, db.Update(lib.SaveFirst(10)))
+		require.NoError(t, db.Update(lib.SaveLast(15)))
+
+		return db, lib
+	}
+
+	// commits returns a builder that indexes a commit for each height, and
+	// records the heights that it was called for.
+	commits := func(t *testing.T, heights *[]uint64) *mocks.Builder {
+		t.Helper()
+
+		build := mocks.BaselineBuilder(t)
+		build.BuildFunc = func(_ context.Context, _ dps.Reader, write dps.WriteLibrary, height uint64) ([]func(*badger.Txn) error, error) {
+			*heights = append(*heights, height)
+			return []func(*badger.Txn) error{write.SaveCommit(height, mocks.GenericCommit(int(height)))}, nil
+		}
+
+		return build
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setup(t)
+		defer db.Close()
+
+		var heights []uint64
+		build := commits(t, &heights)
+
+		r := NewRunner(zerolog.Nop(), db, lib, mocks.BaselineReader(t), build, WithRate(0))
+
+		err := r.Run()
+		require.NoError(t, err)
+
+		assert.Equal(t, []uint64{10, 11, 12, 13, 14, 15}, heights)
+		for _, height := range heights {
+			var commit flow.StateCommitment
+			require.NoError(t, db.View(lib.RetrieveCommit(height, &commit)))
+			assert.Equal(t, mocks.GenericCommit(int(height)), commit)
+		}
+
+		var progress dps.Backfill
+		require.NoError(t, db.View(lib.RetrieveBackfill(build.Name(), &progress)))
+		assert.Equal(t, dps.Backfill{Name: build.Name(), From: 10, To: 15, Next: 16}, progress)
+	})
+
+	t.Run("resumes from saved progress", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setup(t)
+		defer db.Close()
+
+		var heights []uint64
+		build := commits(t, &heights)
+
+		progress := dps.Backfill{Name: build.Name(), From: 10, To: 15, Next: 13}
+		require.NoError(t, db.Update(lib.SaveBackfill(&progress)))
+
+		r := NewRunner(zerolog.Nop(), db, lib, mocks.BaselineReader(t), build, WithRate(0))
+
+		err := r.Run()
+		require.NoError(t, err)
+
+		assert.Equal(t, []uint64{13, 14, 15}, heights)
+	})
+
+	t.Run("does not extend completed backfill", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setup(t)
+		defer db.Close()
+
+		var heights []uint64
+		build := commits(t, &heights)
+
+		progress := dps.Backfill{Name: build.Name(), From: 10, To: 12, Next: 13}
+		require.NoError(t, db.Update(lib.SaveBackfill(&progress)))
+
+		r := NewRunner(zerolog.Nop(), db, lib, mocks.BaselineReader(t), build, WithRate(0))
+
+		err := r.Run()
+		require.NoError(t, err)
+
+		assert.Empty(t, heights)
+	})
+
+	t.Run("handles builder failure", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setup(t)
+		defer db.Close()
+
+		build := mocks.BaselineBuilder(t)
+		build.BuildFunc = func(context.Context, dps.Reader, dps.WriteLibrary, uint64) ([]func(*badger.Txn) error, error) {
+			return nil, mocks.GenericError
+		}
+
+		r := NewRunner(zerolog.Nop(), db, lib, mocks.BaselineReader(t), build, WithRate(0))
+
+		err := r.Run()
+		assert.ErrorIs(t, err, mocks.GenericError)
+
+		var progress dps.Backfill
+		require.NoError(t, db.View(lib.RetrieveBackfill(build.Name(), &progress)))
+		assert.Equal(t, uint64(10), progress.Next)
+	})
+
+	t.Run("stops when fenced off", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setup(t)
+		defer db.Close()
+
+		var fence dps.Fence
+		require.NoError(t, db.Update(lib.AcquireFence("first", false, &fence)))
+		require.NoError(t, db.Update(lib.AcquireFence("second", true, &dps.Fence{})))
+
+		var heights []uint64
+		build := commits(t, &heights)
+
+		r := NewRunner(zerolog.Nop(), db, lib, mocks.BaselineReader(t), build, WithRate(0), WithFence(fence.Token))
+
+		err := r.Run()
+		assert.ErrorIs(t, err, dps.ErrFenced)
+	})
+
+	t.Run("stops while waiting for indexed heights", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+		lib := storage.New(zbor.NewCodec())
+
+		build := mocks.BaselineBuilder(t)
+		build.BuildFunc = func(context.Context, dps.Reader, dps.WriteLibrary, uint64) ([]func(*badger.Txn) error, error) {
+			t.Fatal("builder called for empty index")
+			return nil, nil
+		}
+
+		r := NewRunner(zerolog.Nop(), db, lib, mocks.BaselineReader(t), build, WithInterval(time.Hour))
+
+		done := make(chan error)
+		go func() {
+			done <- r.Run()
+		}()
+
+		// Stopping the runner before it started waiting only closes the stop
+		// channel early, which makes it return just the same.
+		require.NoError(t, r.Stop())
+		assert.NoError(t, <-done)
+	})
+}
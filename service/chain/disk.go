@@ -57,6 +57,34 @@ func (d *Disk) Root() (uint64, error) {
 	return height, nil
 }
 
+// RootSeal retrieves the seal of the root block of the chain, which contains
+// the state commitment of the execution state at the root height.
+func (d *Disk) RootSeal() (*flow.Seal, error) {
+
+	height, err := d.Root()
+	if err != nil {
+		return nil, fmt.Errorf("could not get root height: %w", err)
+	}
+	blockID, err := d.block(height)
+	if err != nil {
+		return nil, fmt.Errorf("could not get block for root height: %w", err)
+	}
+
+	var sealID flow.Identifier
+	err = d.db.View(operation.LookupBlockSeal(blockID, &sealID))
+	if err != nil {
+		return nil, fmt.Errorf("could not look up root seal: %w", err)
+	}
+
+	var seal flow.Seal
+	err = d.db.View(operation.RetrieveSeal(sealID, &seal))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve root seal: %w", err)
+	}
+
+	return &seal, nil
+}
+
 // Commit retrieves the state commitment at the given height.
 func (d *Disk) Commit(height uint64) (flow.StateCommitment, error) {
 
@@ -256,6 +284,42 @@ func (d *Disk) Events(height uint64) ([]flow.Event, error) {
 	return events, nil
 }
 
+// EpochStatus retrieves the epoch status of the protocol state at the given
+// height, which identifies the service events of the current epoch.
+func (d *Disk) EpochStatus(height uint64) (*flow.EpochStatus, error) {
+
+	blockID, err := d.block(height)
+	if err != nil {
+		return nil, fmt.Errorf("could not get block for height: %w", err)
+	}
+
+	var status flow.EpochStatus
+	err = d.db.View(operation.RetrieveEpochStatus(blockID, &status))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve epoch status: %w", err)
+	}
+
+	return &status, nil
+}
+
+// EpochSetup retrieves the setup event of the current epoch at the given
+// height.
+func (d *Disk) EpochSetup(height uint64) (*flow.EpochSetup, error) {
+
+	status, err := d.EpochStatus(height)
+	if err != nil {
+		return nil, fmt.Errorf("could not get epoch status: %w", err)
+	}
+
+	var setup flow.EpochSetup
+	err = d.db.View(operation.RetrieveEpochSetup(status.CurrentEpoch.SetupID, &setup))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve epoch setup: %w", err)
+	}
+
+	return &setup, nil
+}
+
 func (d *Disk) block(height uint64) (flow.Identifier, error) {
 
 	if d.height == height {
@@ -461,3 +461,174 @@ func TestDisk_Seals(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestDisk_EpochStatus(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.IndexBlockHeight(mocks.GenericHeight, mocks.GenericHeader.ID())))
+		require.NoError(t, db.Update(operation.InsertEpochStatus(mocks.GenericHeader.ID(), mocks.GenericEpochStatus)))
+
+		c := chain.FromDisk(db)
+
+		status, err := c.EpochStatus(mocks.GenericHeight)
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericEpochStatus, status)
+	})
+
+	t.Run("handles missing entry for indexed height", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.IndexBlockHeight(mocks.GenericHeight, mocks.GenericHeader.ID())))
+
+		c := chain.FromDisk(db)
+
+		_, err := c.EpochStatus(mocks.GenericHeight)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles call on non-indexed height", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		c := chain.FromDisk(db)
+
+		_, err := c.EpochStatus(mocks.GenericHeight)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestDisk_EpochSetup(t *testing.T) {
+	setup := mocks.GenericEpochSetup(1)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.IndexBlockHeight(mocks.GenericHeight, mocks.GenericHeader.ID())))
+		require.NoError(t, db.Update(operation.InsertEpochStatus(mocks.GenericHeader.ID(), mocks.GenericEpochStatus)))
+		require.NoError(t, db.Update(operation.InsertEpochSetup(mocks.GenericEpochStatus.CurrentEpoch.SetupID, setup)))
+
+		c := chain.FromDisk(db)
+
+		got, err := c.EpochSetup(mocks.GenericHeight)
+
+		require.NoError(t, err)
+		assert.Equal(t, setup.Counter, got.Counter)
+		assert.Equal(t, setup.FinalView, got.FinalView)
+		assert.Len(t, got.Participants, len(setup.Participants))
+	})
+
+	t.Run("handles missing epoch setup", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.IndexBlockHeight(mocks.GenericHeight, mocks.GenericHeader.ID())))
+		require.NoError(t, db.Update(operation.InsertEpochStatus(mocks.GenericHeader.ID(), mocks.GenericEpochStatus)))
+
+		c := chain.FromDisk(db)
+
+		_, err := c.EpochSetup(mocks.GenericHeight)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles missing epoch status", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.IndexBlockHeight(mocks.GenericHeight, mocks.GenericHeader.ID())))
+
+		c := chain.FromDisk(db)
+
+		_, err := c.EpochSetup(mocks.GenericHeight)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestDisk_RootSeal(t *testing.T) {
+	seal := mocks.GenericSeal(0)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.InsertRootHeight(mocks.GenericHeight)))
+		require.NoError(t, db.Update(operation.IndexBlockHeight(mocks.GenericHeight, mocks.GenericHeader.ID())))
+		require.NoError(t, db.Update(operation.IndexBlockSeal(mocks.GenericHeader.ID(), seal.ID())))
+		require.NoError(t, db.Update(operation.InsertSeal(seal.ID(), seal)))
+
+		c := chain.FromDisk(db)
+
+		got, err := c.RootSeal()
+
+		require.NoError(t, err)
+		assert.Equal(t, seal, got)
+	})
+
+	t.Run("handles missing root height entry in db", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		c := chain.FromDisk(db)
+
+		_, err := c.RootSeal()
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles missing root seal index in db", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.InsertRootHeight(mocks.GenericHeight)))
+		require.NoError(t, db.Update(operation.IndexBlockHeight(mocks.GenericHeight, mocks.GenericHeader.ID())))
+
+		c := chain.FromDisk(db)
+
+		_, err := c.RootSeal()
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles missing root seal in db", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.InsertRootHeight(mocks.GenericHeight)))
+		require.NoError(t, db.Update(operation.IndexBlockHeight(mocks.GenericHeight, mocks.GenericHeader.ID())))
+		require.NoError(t, db.Update(operation.IndexBlockSeal(mocks.GenericHeader.ID(), seal.ID())))
+
+		c := chain.FromDisk(db)
+
+		_, err := c.RootSeal()
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+
+	"cloud.google.com/go/storage"
+)
+
+// Bucket is a sink that uploads checkpoints as objects to a Google Cloud
+// Storage bucket.
+type Bucket struct {
+	bucket *storage.BucketHandle
+	prefix string
+}
+
+// NewBucket returns a sink that uploads checkpoints to the given bucket, with
+// the given prefix prepended to their names.
+func NewBucket(bucket *storage.BucketHandle, prefix string) *Bucket {
+
+	b := Bucket{
+		bucket: bucket,
+		prefix: prefix,
+	}
+
+	return &b
+}
+
+// Store streams the checkpoint with the given name to its object. If writing
+// the checkpoint fails, the upload is aborted, so that no partial object is
+// created.
+func (b *Bucket) Store(name string, write func(w io.Writer) error) error {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	object := b.bucket.Object(path.Join(b.prefix, name))
+	writer := object.NewWriter(ctx)
+	err := write(writer)
+	if err != nil {
+		cancel()
+		_ = writer.Close()
+		return fmt.Errorf("could not write object: %w", err)
+	}
+
+	// The object is only created once the writer is closed, so this is where
+	// the upload fails.
+	err = writer.Close()
+	if err != nil {
+		return fmt.Errorf("could not close object writer: %w", err)
+	}
+
+	return nil
+}
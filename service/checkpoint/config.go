@@ -0,0 +1,36 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package checkpoint
+
+// DefaultConfig is the default configuration for the checkpoint emitter.
+var DefaultConfig = Config{
+	Interval: 100_000,
+}
+
+// Config is the configuration for the checkpoint emitter.
+type Config struct {
+	Interval uint64
+}
+
+// Option is a function that can be applied to a Config.
+type Option func(*Config)
+
+// WithInterval sets the number of heights between two emitted checkpoints. A
+// checkpoint is emitted for each height that is a multiple of the interval.
+func WithInterval(interval uint64) Option {
+	return func(cfg *Config) {
+		cfg.Interval = interval
+	}
+}
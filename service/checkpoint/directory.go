@@ -0,0 +1,78 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package checkpoint
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Directory is a sink that writes checkpoints as files to a local directory.
+type Directory struct {
+	dir string
+}
+
+// NewDirectory returns a sink that writes checkpoints to the given directory.
+func NewDirectory(dir string) *Directory {
+
+	d := Directory{
+		dir: dir,
+	}
+
+	return &d
+}
+
+// Store writes the checkpoint with the given name to a temporary file first,
+// and only moves it to its final name once it was completely written, so that
+// readers of the directory never see a partial checkpoint.
+func (d *Directory) Store(name string, write func(w io.Writer) error) error {
+
+	file, err := os.CreateTemp(d.dir, name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("could not create temporary file: %w", err)
+	}
+	temp := file.Name()
+
+	buffered := bufio.NewWriter(file)
+	err = write(buffered)
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(temp)
+		return fmt.Errorf("could not write checkpoint file: %w", err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		_ = os.Remove(temp)
+		return fmt.Errorf("could not close checkpoint file: %w", err)
+	}
+
+	err = os.Rename(temp, filepath.Join(d.dir, name))
+	if err != nil {
+		_ = os.Remove(temp)
+		return fmt.Errorf("could not rename checkpoint file: %w", err)
+	}
+
+	return nil
+}
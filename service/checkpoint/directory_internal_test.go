@@ -0,0 +1,101 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package checkpoint
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestDirectory_Store(t *testing.T) {
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		d := NewDirectory(dir)
+
+		err := d.Store("checkpoint.00000010", func(w io.Writer) error {
+			_, err := w.Write(mocks.GenericBytes)
+			return err
+		})
+
+		require.NoError(t, err)
+		data, err := os.ReadFile(filepath.Join(dir, "checkpoint.00000010"))
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericBytes, data)
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("handles write failure", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		d := NewDirectory(dir)
+
+		err := d.Store("checkpoint.00000010", func(w io.Writer) error {
+			_, _ = w.Write(mocks.GenericBytes)
+			return mocks.GenericError
+		})
+
+		assert.Error(t, err)
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("handles missing directory", func(t *testing.T) {
+		t.Parallel()
+
+		d := NewDirectory(filepath.Join(t.TempDir(), "missing"))
+
+		err := d.Store("checkpoint.00000010", func(io.Writer) error {
+			return nil
+		})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestOpen(t *testing.T) {
+
+	t.Run("nominal case with directory", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := Open("/var/checkpoints")
+
+		require.NoError(t, err)
+		require.IsType(t, &Directory{}, got)
+		assert.Equal(t, "/var/checkpoints", got.(*Directory).dir)
+	})
+
+	t.Run("handles missing bucket", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Open("gs:///prefix")
+
+		assert.Error(t, err)
+	})
+}
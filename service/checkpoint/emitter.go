@@ -0,0 +1,111 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package checkpoint
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/rs/zerolog"
+
+	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
+	"github.com/onflow/flow-go/ledger/complete/wal"
+
+	"github.com/optakt/flow-dps/service/logs"
+)
+
+// Emitter emits checkpoints of the execution state trie at a regular interval of
+// heights. The checkpoints use the LedgerWAL checkpoint format, so they can be
+// used to bootstrap Flow nodes as well as other instances of the DPS.
+type Emitter struct {
+	log  zerolog.Logger
+	sink Sink
+	cfg  Config
+	busy chan struct{}
+	wg   *sync.WaitGroup
+}
+
+// NewEmitter returns a new emitter, which writes checkpoints to the given sink.
+func NewEmitter(log zerolog.Logger, sink Sink, options ...Option) *Emitter {
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	e := Emitter{
+		log:  log.With().Str(logs.Component, "checkpoint_emitter").Logger(),
+		sink: sink,
+		cfg:  cfg,
+		busy: make(chan struct{}, 1),
+		wg:   &sync.WaitGroup{},
+	}
+
+	return &e
+}
+
+// Add hands over the execution state trie of the given height to the emitter.
+// If the height is on the configured interval, a checkpoint of the trie is
+// emitted in the background. Tries are immutable, so the mapper can keep
+// updating the execution state while the checkpoint is being written. Only one
+// checkpoint is written at a time; if the previous one is still being written,
+// the height is skipped.
+func (e *Emitter) Add(height uint64, tree *trie.MTrie) {
+
+	if e.cfg.Interval == 0 || height%e.cfg.Interval != 0 {
+		return
+	}
+
+	select {
+	case e.busy <- struct{}{}:
+	default:
+		e.log.Warn().Uint64(logs.Height, height).Msg("previous checkpoint still being emitted, skipping height")
+		return
+	}
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		defer func() { <-e.busy }()
+
+		err := e.emit(height, tree)
+		if err != nil {
+			e.log.Error().Err(err).Uint64(logs.Height, height).Msg("could not emit checkpoint")
+			return
+		}
+
+		commit := tree.RootHash()
+		e.log.Info().Uint64(logs.Height, height).Hex(logs.Commit, commit[:]).Msg("checkpoint emitted")
+	}()
+}
+
+// Wait waits for the checkpoint that is currently being emitted, if any.
+func (e *Emitter) Wait() {
+	e.wg.Wait()
+}
+
+func (e *Emitter) emit(height uint64, tree *trie.MTrie) error {
+
+	name := wal.NumberToFilename(int(height))
+	err := e.sink.Store(name, func(w io.Writer) error {
+		return Write(w, tree)
+	})
+	if err != nil {
+		return fmt.Errorf("could not store checkpoint: %w", err)
+	}
+
+	return nil
+}
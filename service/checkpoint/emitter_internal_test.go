@@ -0,0 +1,132 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package checkpoint
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger/complete/mtrie/flattener"
+	"github.com/onflow/flow-go/ledger/complete/wal"
+
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestEmitter_Add(t *testing.T) {
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			gotName string
+			buffer  bytes.Buffer
+		)
+		sink := mocks.BaselineSink(t)
+		sink.StoreFunc = func(name string, write func(w io.Writer) error) error {
+			gotName = name
+			return write(&buffer)
+		}
+
+		e := NewEmitter(zerolog.Nop(), sink, WithInterval(10))
+		e.Add(20, mocks.GenericTrie)
+		e.Wait()
+
+		assert.Equal(t, wal.NumberToFilename(20), gotName)
+
+		checkpoint, err := wal.ReadCheckpoint(&buffer)
+		require.NoError(t, err)
+		trees, err := flattener.RebuildTries(checkpoint)
+		require.NoError(t, err)
+		require.Len(t, trees, 1)
+		assert.Equal(t, mocks.GenericTrie.RootHash(), trees[0].RootHash())
+	})
+
+	t.Run("skips heights outside of interval", func(t *testing.T) {
+		t.Parallel()
+
+		sink := mocks.BaselineSink(t)
+		sink.StoreFunc = func(string, func(w io.Writer) error) error {
+			t.Fatal("checkpoint should not be stored outside of interval")
+			return nil
+		}
+
+		e := NewEmitter(zerolog.Nop(), sink, WithInterval(10))
+		e.Add(21, mocks.GenericTrie)
+		e.Wait()
+	})
+
+	t.Run("skips all heights with zero interval", func(t *testing.T) {
+		t.Parallel()
+
+		sink := mocks.BaselineSink(t)
+		sink.StoreFunc = func(string, func(w io.Writer) error) error {
+			t.Fatal("checkpoint should not be stored with zero interval")
+			return nil
+		}
+
+		e := NewEmitter(zerolog.Nop(), sink, WithInterval(0))
+		e.Add(20, mocks.GenericTrie)
+		e.Wait()
+	})
+
+	t.Run("skips height while previous checkpoint is being emitted", func(t *testing.T) {
+		t.Parallel()
+
+		var names []string
+		release := make(chan struct{})
+		sink := mocks.BaselineSink(t)
+		sink.StoreFunc = func(name string, write func(w io.Writer) error) error {
+			names = append(names, name)
+			<-release
+			return write(io.Discard)
+		}
+
+		e := NewEmitter(zerolog.Nop(), sink, WithInterval(10))
+		e.Add(10, mocks.GenericTrie)
+		e.Add(20, mocks.GenericTrie)
+		close(release)
+		e.Wait()
+
+		e.Add(30, mocks.GenericTrie)
+		e.Wait()
+
+		assert.Equal(t, []string{wal.NumberToFilename(10), wal.NumberToFilename(30)}, names)
+	})
+
+	t.Run("handles sink failure", func(t *testing.T) {
+		t.Parallel()
+
+		sink := mocks.BaselineSink(t)
+		sink.StoreFunc = func(string, func(w io.Writer) error) error {
+			return mocks.GenericError
+		}
+
+		e := NewEmitter(zerolog.Nop(), sink, WithInterval(10))
+		e.Add(10, mocks.GenericTrie)
+		e.Wait()
+
+		// The emitter should be available again after a failure.
+		select {
+		case e.busy <- struct{}{}:
+		default:
+			t.Fatal("emitter should not be busy after failure")
+		}
+	})
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"cloud.google.com/go/storage"
+)
+
+// SchemeGCS is the scheme of Google Cloud Storage sink addresses.
+const SchemeGCS = "gs"
+
+// Sink represents something that checkpoints can be written to. The given write
+// function is called with the writer for the checkpoint with the given name; if
+// it fails, no checkpoint should be left behind under that name.
+type Sink interface {
+	Store(name string, write func(w io.Writer) error) error
+}
+
+// Open returns the sink for the given address, which is either the path to a
+// local directory, or a Google Cloud Storage bucket with an optional prefix for
+// the object names, such as `gs://bucket/prefix`.
+func Open(address string) (Sink, error) {
+
+	if !strings.HasPrefix(address, SchemeGCS+"://") {
+		return NewDirectory(address), nil
+	}
+
+	parsed, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse address: %w", err)
+	}
+	bucket := parsed.Host
+	prefix := strings.TrimPrefix(parsed.Path, "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("missing bucket in address (%s)", address)
+	}
+
+	client, err := storage.NewClient(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("could not create client: %w", err)
+	}
+
+	return NewBucket(client.Bucket(bucket), prefix), nil
+}
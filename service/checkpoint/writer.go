@@ -0,0 +1,136 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package checkpoint
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common/encoding"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/flattener"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/node"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
+	"github.com/onflow/flow-go/ledger/complete/wal"
+)
+
+// Write writes a checkpoint of the given execution state trie to the given
+// writer, in the same format as `wal.StoreCheckpoint`. Instead of flattening
+// the whole trie before writing it, which holds a storable copy of each of its
+// nodes in memory at once, it encodes the nodes one by one while iterating
+// through the trie, so it only needs memory proportional to the height of the
+// trie.
+func Write(w io.Writer, tree *trie.MTrie) error {
+
+	// The header contains the number of nodes, so we need a first pass through
+	// the trie to count them. Within a single trie, each node has only one
+	// parent, so every node is encountered exactly once.
+	var count uint64
+	for it := flattener.NewNodeIterator(tree); it.Next(); {
+		count++
+	}
+
+	crc := wal.NewCRC32Writer(w)
+
+	header := make([]byte, 2+2+8+2)
+	binary.BigEndian.PutUint16(header[0:], wal.MagicBytes)
+	binary.BigEndian.PutUint16(header[2:], wal.VersionV3)
+	binary.BigEndian.PutUint64(header[4:], count)
+	binary.BigEndian.PutUint16(header[12:], 1)
+	_, err := crc.Write(header)
+	if err != nil {
+		return fmt.Errorf("could not write header: %w", err)
+	}
+
+	// The iterator returns the descendants of each node before the node itself,
+	// so the indices of the children of a node are always the most recent ones
+	// that were not yet claimed by a parent. Keeping them on a stack allows us
+	// to reference them without keeping an index for every node of the trie.
+	// Index zero stands for a missing child.
+	indices := make([]uint64, 0, ledger.NodeMaxHeight+1)
+	pop := func() uint64 {
+		index := indices[len(indices)-1]
+		indices = indices[:len(indices)-1]
+		return index
+	}
+	var index uint64
+	for it := flattener.NewNodeIterator(tree); it.Next(); {
+		n := it.Value()
+
+		var left, right uint64
+		if n.RightChild() != nil {
+			right = pop()
+		}
+		if n.LeftChild() != nil {
+			left = pop()
+		}
+
+		index++
+		_, err = crc.Write(flattener.EncodeStorableNode(storableNode(n, left, right)))
+		if err != nil {
+			return fmt.Errorf("could not write node (index: %d): %w", index, err)
+		}
+
+		indices = append(indices, index)
+	}
+
+	// The root node is the last node returned by the iterator, or there is no
+	// node at all for an empty trie.
+	hash := tree.RootHash()
+	root := flattener.StorableTrie{
+		RootIndex: index,
+		RootHash:  hash[:],
+	}
+	_, err = crc.Write(flattener.EncodeStorableTrie(&root))
+	if err != nil {
+		return fmt.Errorf("could not write trie: %w", err)
+	}
+
+	checksum := make([]byte, 4)
+	binary.BigEndian.PutUint32(checksum, crc.Crc32())
+	_, err = w.Write(checksum)
+	if err != nil {
+		return fmt.Errorf("could not write checksum: %w", err)
+	}
+
+	return nil
+}
+
+// storableNode converts the given node into its storable representation, with
+// the given indices for its children.
+func storableNode(n *node.Node, left uint64, right uint64) *flattener.StorableNode {
+
+	// Only leaves have a path; for interim nodes, it stays empty.
+	var path []byte
+	if n.IsLeaf() {
+		leaf := *n.Path()
+		path = leaf[:]
+	}
+
+	hash := n.Hash()
+	storable := flattener.StorableNode{
+		LIndex:     left,
+		RIndex:     right,
+		Height:     uint16(n.Height()),
+		Path:       path,
+		EncPayload: encoding.EncodePayload(n.Payload()),
+		HashValue:  hash[:],
+		MaxDepth:   n.MaxDepth(),
+		RegCount:   n.RegCount(),
+	}
+
+	return &storable
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package checkpoint
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/flattener"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
+	"github.com/onflow/flow-go/ledger/complete/wal"
+
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestWrite(t *testing.T) {
+
+	payloads := make([]ledger.Payload, 0, 6)
+	for _, payload := range mocks.GenericLedgerPayloads(6) {
+		payloads = append(payloads, *payload)
+	}
+	updated, err := trie.NewTrieWithUpdatedRegisters(trie.NewEmptyMTrie(), mocks.GenericLedgerPaths(6), payloads)
+	require.NoError(t, err)
+
+	tries := map[string]*trie.MTrie{
+		"generic trie": mocks.GenericTrie,
+		"updated trie": updated,
+		"empty trie":   trie.NewEmptyMTrie(),
+	}
+	for name, tree := range tries {
+		tree := tree
+		t.Run("nominal case with "+name, func(t *testing.T) {
+			t.Parallel()
+
+			// The streamed checkpoint should be identical to the one written
+			// from the flattened trie.
+			flat, err := flattener.FlattenTrie(tree)
+			require.NoError(t, err)
+			var want bytes.Buffer
+			require.NoError(t, wal.StoreCheckpoint(flat.ToFlattenedForestWithASingleTrie(), &want))
+
+			var got bytes.Buffer
+			err = Write(&got, tree)
+
+			require.NoError(t, err)
+			assert.Equal(t, want.Bytes(), got.Bytes())
+
+			checkpoint, err := wal.ReadCheckpoint(&got)
+			require.NoError(t, err)
+			trees, err := flattener.RebuildTries(checkpoint)
+			require.NoError(t, err)
+			require.Len(t, trees, 1)
+			assert.Equal(t, tree.RootHash(), trees[0].RootHash())
+		})
+	}
+
+	t.Run("handles writer failure", func(t *testing.T) {
+		t.Parallel()
+
+		err := Write(failingWriter{}, mocks.GenericTrie)
+
+		assert.Error(t, err)
+	})
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, mocks.GenericError
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package cloud
+
+import (
+	"cloud.google.com/go/storage"
+)
+
+// Possible results of an execution record request to a bucket.
+const (
+	resultSuccess   = "success"
+	resultMissing   = "missing"
+	resultFailure   = "failure"
+	resultCorrupted = "corrupted"
+)
+
+// bucket is one of the buckets from which the streamer downloads execution
+// records, along with the candidate layouts of its object names and whether
+// its last request was served without failure.
+type bucket struct {
+	name    string
+	handle  *storage.BucketHandle
+	layouts []string
+	healthy bool
+}
+
+func newBucket(handle *storage.BucketHandle, layouts []string) *bucket {
+
+	// The bucket handle does not expose its name, but object handles do.
+	name := handle.Object("").BucketName()
+
+	b := bucket{
+		name:    name,
+		handle:  handle,
+		layouts: layouts,
+		healthy: true,
+	}
+
+	bucketHealth.WithLabelValues(name).Set(1)
+
+	return &b
+}
+
+// record updates the health of the bucket and its metrics with the result of
+// a request.
+func (b *bucket) record(result string) {
+	bucketRequests.WithLabelValues(b.name, result).Inc()
+	b.healthy = result != resultFailure
+	if b.healthy {
+		bucketHealth.WithLabelValues(b.name).Set(1)
+	} else {
+		bucketHealth.WithLabelValues(b.name).Set(0)
+	}
+}
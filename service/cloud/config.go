@@ -15,19 +15,46 @@
 package cloud
 
 import (
+	"cloud.google.com/go/storage"
+
 	"github.com/onflow/flow-go/model/flow"
 )
 
+// Placeholders that can be used in the layout of execution record object names.
+const (
+	PlaceholderBlock  = "{block}"
+	PlaceholderHeight = "{height}"
+)
+
+// KnownLayouts are the layouts of execution record object names used by
+// different versions of the execution node uploader. The current uploader
+// appends a `.cbor` extension to the block ID, while earlier versions used the
+// plain block ID.
+var KnownLayouts = []string{
+	PlaceholderBlock + ".cbor",
+	PlaceholderBlock,
+}
+
 // DefaultConfig is the default configuration for the Google Cloud Streamer.
 var DefaultConfig = Config{
-	BufferSize:    32,
-	CatchupBlocks: []flow.Identifier{},
+	BufferSize:      32,
+	CatchupBlocks:   []flow.Identifier{},
+	Layout:          "",
+	LookupHeight:    nil,
+	FallbackBuckets: nil,
+	QuarantineAfter: 3,
+	Archive:         nil,
 }
 
 // Config is the configuration for a Google Cloud Streamer.
 type Config struct {
-	BufferSize    uint
-	CatchupBlocks []flow.Identifier
+	BufferSize      uint
+	CatchupBlocks   []flow.Identifier
+	Layout          string
+	LookupHeight    func(flow.Identifier) (uint64, error)
+	FallbackBuckets []*storage.BucketHandle
+	QuarantineAfter uint
+	Archive         func(flow.Identifier, []byte) error
 }
 
 // Option is a function that can be applied to a Config.
@@ -48,3 +75,48 @@ func WithCatchupBlocks(blockIDs []flow.Identifier) Option {
 		cfg.CatchupBlocks = blockIDs
 	}
 }
+
+// WithLayout sets the layout of the execution record object names in the
+// bucket, where the block ID and height placeholders are replaced for each
+// record. When no layout is given, the streamer detects which of the known
+// layouts the bucket uses.
+func WithLayout(layout string) Option {
+	return func(cfg *Config) {
+		cfg.Layout = layout
+	}
+}
+
+// WithLookupHeight injects the function used to look up the height of a block,
+// which is needed for layouts that include the height placeholder.
+func WithLookupHeight(lookup func(flow.Identifier) (uint64, error)) Option {
+	return func(cfg *Config) {
+		cfg.LookupHeight = lookup
+	}
+}
+
+// WithFallbackBuckets adds buckets from which a Google Cloud Streamer downloads
+// execution records when they are missing from the primary bucket, or when the
+// primary bucket fails to serve them.
+func WithFallbackBuckets(buckets ...*storage.BucketHandle) Option {
+	return func(cfg *Config) {
+		cfg.FallbackBuckets = buckets
+	}
+}
+
+// WithQuarantineAfter sets the number of attempts after which an execution
+// record that can not be decoded or fails validation is quarantined, instead
+// of being downloaded again forever. Zero disables the quarantine.
+func WithQuarantineAfter(attempts uint) Option {
+	return func(cfg *Config) {
+		cfg.QuarantineAfter = attempts
+	}
+}
+
+// WithArchive injects the function used to archive each downloaded execution
+// record in its original encoding, before it is returned by the streamer. When
+// archiving a record fails, it is downloaded again later.
+func WithArchive(archive func(flow.Identifier, []byte) error) Option {
+	return func(cfg *Config) {
+		cfg.Archive = archive
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"cloud.google.com/go/storage"
+	"github.com/rs/zerolog"
+	"google.golang.org/api/googleapi"
+
+	"github.com/optakt/flow-dps/service/logs"
+)
+
+// Source is a remote location from which a file can be read starting at a
+// given byte offset, which allows interrupted downloads to be resumed.
+type Source interface {
+	Open(offset int64) (io.ReadCloser, error)
+}
+
+// BucketSource reads a file from an object in a Google Cloud Storage bucket.
+type BucketSource struct {
+	object *storage.ObjectHandle
+}
+
+// NewBucketSource returns a source for the object with the given name in the
+// given bucket.
+func NewBucketSource(bucket *storage.BucketHandle, name string) *BucketSource {
+	b := BucketSource{
+		object: bucket.Object(name),
+	}
+	return &b
+}
+
+// Open returns a reader for the object contents starting at the given offset.
+// If the offset is at the end of the object, the reader is empty.
+func (b *BucketSource) Open(offset int64) (io.ReadCloser, error) {
+	reader, err := b.object.NewRangeReader(context.Background(), offset, -1)
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) && gerr.Code == http.StatusRequestedRangeNotSatisfiable && offset > 0 {
+		return io.NopCloser(&bytes.Buffer{}), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not create object reader: %w", err)
+	}
+	return reader, nil
+}
+
+// URLSource reads a file from an HTTP URL.
+type URLSource struct {
+	client *http.Client
+	url    string
+}
+
+// NewURLSource returns a source for the file at the given HTTP URL.
+func NewURLSource(client *http.Client, url string) *URLSource {
+	u := URLSource{
+		client: client,
+		url:    url,
+	}
+	return &u
+}
+
+// Open returns a reader for the file contents starting at the given offset. If
+// the server does not support range requests, the bytes before the offset are
+// skipped on the client side.
+func (u *URLSource) Open(offset int64) (io.ReadCloser, error) {
+
+	req, err := http.NewRequest(http.MethodGet, u.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	res, err := u.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute request: %w", err)
+	}
+
+	switch {
+
+	// If we already have the complete file, the server tells us that the range
+	// can not be satisfied, and there is nothing left to read.
+	case res.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		_ = res.Body.Close()
+		return io.NopCloser(&bytes.Buffer{}), nil
+
+	case res.StatusCode == http.StatusPartialContent && offset > 0:
+		return res.Body, nil
+
+	case res.StatusCode == http.StatusOK:
+		_, err = io.CopyN(io.Discard, res.Body, offset)
+		if err != nil {
+			_ = res.Body.Close()
+			return nil, fmt.Errorf("could not skip downloaded bytes: %w", err)
+		}
+		return res.Body, nil
+
+	default:
+		_ = res.Body.Close()
+		return nil, fmt.Errorf("unexpected response status (status: %s)", res.Status)
+	}
+}
+
+// Downloader downloads a file from a source to disk. The data is first written
+// to a partial file next to the destination, so that an interrupted download
+// can be resumed, and the partial file is only moved to the destination once
+// it is complete and its checksum is valid.
+type Downloader struct {
+	log      zerolog.Logger
+	source   Source
+	checksum []byte
+	attempts uint
+}
+
+// NewDownloader returns a new downloader for the given source. If the given
+// checksum is not empty, the SHA-256 hash of the downloaded file has to match
+// it for the download to succeed.
+func NewDownloader(log zerolog.Logger, source Source, checksum []byte) *Downloader {
+	d := Downloader{
+		log:      log.With().Str(logs.Component, "downloader").Logger(),
+		source:   source,
+		checksum: checksum,
+		attempts: 3,
+	}
+	return &d
+}
+
+// Download downloads the file to the given path.
+func (d *Downloader) Download(path string) error {
+
+	partial := path + ".part"
+	file, err := os.OpenFile(partial, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open partial file: %w", err)
+	}
+	defer file.Close()
+
+	// We try to download the remaining data a number of times; each attempt
+	// resumes after the data that was already written to the partial file,
+	// which includes data written before a restart.
+	for attempt := uint(1); ; attempt++ {
+		err = d.resume(file)
+		if err == nil {
+			break
+		}
+		if attempt >= d.attempts {
+			return fmt.Errorf("could not download file (attempts: %d): %w", attempt, err)
+		}
+		d.log.Warn().Err(err).Uint("attempt", attempt).Msg("download interrupted, resuming")
+	}
+
+	err = file.Close()
+	if err != nil {
+		return fmt.Errorf("could not close partial file: %w", err)
+	}
+
+	// Once the download is complete, we validate the checksum of the file. If
+	// it does not match, the partial file is unusable and we remove it, so that
+	// the next download starts from scratch.
+	if len(d.checksum) > 0 {
+		sum, err := hashFile(partial)
+		if err != nil {
+			return fmt.Errorf("could not hash downloaded file: %w", err)
+		}
+		if !bytes.Equal(sum, d.checksum) {
+			_ = os.Remove(partial)
+			return fmt.Errorf("downloaded file does not match checksum (expected: %x, actual: %x)", d.checksum, sum)
+		}
+	}
+
+	err = os.Rename(partial, path)
+	if err != nil {
+		return fmt.Errorf("could not move downloaded file: %w", err)
+	}
+
+	return nil
+}
+
+func (d *Downloader) resume(file *os.File) error {
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("could not stat partial file: %w", err)
+	}
+	offset := info.Size()
+
+	reader, err := d.source.Open(offset)
+	if err != nil {
+		return fmt.Errorf("could not open source (offset: %d): %w", offset, err)
+	}
+	defer reader.Close()
+
+	d.log.Info().Int64("offset", offset).Msg("downloading file")
+
+	n, err := io.Copy(file, reader)
+	if err != nil {
+		return fmt.Errorf("could not copy data (offset: %d, copied: %d): %w", offset, n, err)
+	}
+
+	d.log.Info().Int64("size", offset+n).Msg("file downloaded")
+
+	return nil
+}
+
+func hashFile(path string) ([]byte, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file: %w", err)
+	}
+
+	return hash.Sum(nil), nil
+}
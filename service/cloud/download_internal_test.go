@@ -0,0 +1,303 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package cloud
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gcloud "cloud.google.com/go/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+type sourceFunc func(offset int64) (io.ReadCloser, error)
+
+func (s sourceFunc) Open(offset int64) (io.ReadCloser, error) {
+	return s(offset)
+}
+
+func serveContent(data []byte) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		http.ServeContent(rw, req, "root.checkpoint", time.Time{}, bytes.NewReader(data))
+	})
+}
+
+func TestBucketSource_Open(t *testing.T) {
+
+	data := []byte("checkpoint data")
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(serveContent(data))
+		defer server.Close()
+
+		client, err := gcloud.NewClient(
+			context.Background(),
+			option.WithoutAuthentication(),
+			option.WithEndpoint(server.URL),
+		)
+		require.NoError(t, err)
+
+		source := NewBucketSource(client.Bucket("test"), "root.checkpoint")
+		reader, err := source.Open(5)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		got, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, data[5:], got)
+	})
+
+	t.Run("handles offset at end of object", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(serveContent(data))
+		defer server.Close()
+
+		client, err := gcloud.NewClient(
+			context.Background(),
+			option.WithoutAuthentication(),
+			option.WithEndpoint(server.URL),
+		)
+		require.NoError(t, err)
+
+		source := NewBucketSource(client.Bucket("test"), "root.checkpoint")
+		reader, err := source.Open(int64(len(data)))
+		require.NoError(t, err)
+		defer reader.Close()
+
+		got, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run("handles missing object", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		client, err := gcloud.NewClient(
+			context.Background(),
+			option.WithoutAuthentication(),
+			option.WithEndpoint(server.URL),
+		)
+		require.NoError(t, err)
+
+		source := NewBucketSource(client.Bucket("test"), "root.checkpoint")
+		_, err = source.Open(0)
+		assert.Error(t, err)
+	})
+}
+
+func TestURLSource_Open(t *testing.T) {
+
+	data := []byte("checkpoint data")
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(serveContent(data))
+		defer server.Close()
+
+		source := NewURLSource(server.Client(), server.URL)
+		reader, err := source.Open(5)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		got, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, data[5:], got)
+	})
+
+	t.Run("handles server without range support", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+			_, _ = rw.Write(data)
+		}))
+		defer server.Close()
+
+		source := NewURLSource(server.Client(), server.URL)
+		reader, err := source.Open(5)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		got, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, data[5:], got)
+	})
+
+	t.Run("handles offset at end of file", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(serveContent(data))
+		defer server.Close()
+
+		source := NewURLSource(server.Client(), server.URL)
+		reader, err := source.Open(int64(len(data)))
+		require.NoError(t, err)
+		defer reader.Close()
+
+		got, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run("handles error status", func(t *testing.T) {
+		t.Parallel()
+
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		source := NewURLSource(server.Client(), server.URL)
+		_, err := source.Open(0)
+		assert.Error(t, err)
+	})
+}
+
+func TestDownloader_Download(t *testing.T) {
+
+	data := []byte("checkpoint data")
+	sum := sha256.Sum256(data)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "root.checkpoint")
+		source := sourceFunc(func(offset int64) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data[offset:])), nil
+		})
+
+		download := NewDownloader(mocks.NoopLogger, source, sum[:])
+		err := download.Download(path)
+		require.NoError(t, err)
+
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, data, got)
+		assert.NoFileExists(t, path+".part")
+	})
+
+	t.Run("resumes from partial file", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "root.checkpoint")
+		err := os.WriteFile(path+".part", data[:5], 0644)
+		require.NoError(t, err)
+
+		var requested int64
+		source := sourceFunc(func(offset int64) (io.ReadCloser, error) {
+			requested = offset
+			return io.NopCloser(bytes.NewReader(data[offset:])), nil
+		})
+
+		download := NewDownloader(mocks.NoopLogger, source, sum[:])
+		err = download.Download(path)
+		require.NoError(t, err)
+
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, data, got)
+		assert.Equal(t, int64(5), requested)
+	})
+
+	t.Run("resumes after interrupted attempt", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "root.checkpoint")
+
+		var calls int
+		source := sourceFunc(func(offset int64) (io.ReadCloser, error) {
+			calls++
+			if calls == 1 {
+				return io.NopCloser(io.MultiReader(bytes.NewReader(data[:5]), &failReader{})), nil
+			}
+			return io.NopCloser(bytes.NewReader(data[offset:])), nil
+		})
+
+		download := NewDownloader(mocks.NoopLogger, source, sum[:])
+		err := download.Download(path)
+		require.NoError(t, err)
+
+		got, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, data, got)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("handles too many failed attempts", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "root.checkpoint")
+		source := sourceFunc(func(int64) (io.ReadCloser, error) {
+			return nil, mocks.GenericError
+		})
+
+		download := NewDownloader(mocks.NoopLogger, source, sum[:])
+		err := download.Download(path)
+		assert.ErrorIs(t, err, mocks.GenericError)
+		assert.NoFileExists(t, path)
+	})
+
+	t.Run("handles checksum mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "root.checkpoint")
+		source := sourceFunc(func(offset int64) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader([]byte("corrupted data")[offset:])), nil
+		})
+
+		download := NewDownloader(mocks.NoopLogger, source, sum[:])
+		err := download.Download(path)
+		assert.Error(t, err)
+		assert.NoFileExists(t, path)
+		assert.NoFileExists(t, path+".part")
+	})
+
+	t.Run("skips validation without checksum", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "root.checkpoint")
+		source := sourceFunc(func(offset int64) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data[offset:])), nil
+		})
+
+		download := NewDownloader(mocks.NoopLogger, source, nil)
+		err := download.Download(path)
+		require.NoError(t, err)
+		assert.FileExists(t, path)
+	})
+}
+
+type failReader struct{}
+
+func (failReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
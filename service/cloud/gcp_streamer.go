@@ -19,16 +19,19 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 	"sync/atomic"
 
 	"cloud.google.com/go/storage"
-	"github.com/fxamacker/cbor/v2"
 	"github.com/rs/zerolog"
 
 	"github.com/onflow/flow-go/engine/execution/computation/computer/uploader"
 	"github.com/onflow/flow-go/model/flow"
 
 	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/logs"
+	"github.com/optakt/flow-dps/service/records"
 )
 
 // GCPStreamer is a component that downloads block data from a Google Cloud bucket.
@@ -38,43 +41,63 @@ import (
 // contains.
 type GCPStreamer struct {
 	log     zerolog.Logger
-	decoder cbor.DecMode
-	bucket  *storage.BucketHandle
-	queue   *dps.SafeDeque // queue of block identifiers for next downloads
-	buffer  *dps.SafeDeque // queue of downloaded execution data records
-	limit   uint           // buffer size limit for downloaded records
-	busy    uint32         // used as a guard to avoid concurrent polling
+	decoder *records.Decoder
+	buckets []*bucket                             // primary bucket followed by fallbacks
+	heights func(flow.Identifier) (uint64, error) // used for the height placeholder
+	queue   *dps.SafeDeque                        // queue of block identifiers for next downloads
+	buffer  *dps.SafeDeque                        // queue of downloaded execution data records
+	limit   uint                                  // buffer size limit for downloaded records
+	busy    uint32                                // used as a guard to avoid concurrent polling
+	archive func(flow.Identifier, []byte) error   // archives original records, if enabled
+
+	attempts   map[flow.Identifier]uint // failed attempts per corrupted execution record
+	quarantine uint                     // attempts after which a corrupted record is quarantined
 }
 
-// NewGCPStreamer returns a new GCP Streamer using the given bucket and options.
-func NewGCPStreamer(log zerolog.Logger, bucket *storage.BucketHandle, options ...Option) *GCPStreamer {
+// quarantined is pushed into the buffer in place of an execution record that
+// was quarantined, so that its failure is reported in the order of the stream.
+type quarantined struct {
+	blockID flow.Identifier
+	reason  string
+}
+
+// NewGCPStreamer returns a new GCP Streamer using the given primary bucket and
+// options.
+func NewGCPStreamer(log zerolog.Logger, primary *storage.BucketHandle, options ...Option) *GCPStreamer {
 
 	cfg := DefaultConfig
 	for _, option := range options {
 		option(&cfg)
 	}
 
-	decOptions := cbor.DecOptions{
-		ExtraReturnErrors: cbor.ExtraDecErrorUnknownField,
+	layouts := KnownLayouts
+	if cfg.Layout != "" {
+		layouts = []string{cfg.Layout}
 	}
-	decoder, err := decOptions.DecMode()
-	if err != nil {
-		panic(err)
+
+	buckets := []*bucket{newBucket(primary, layouts)}
+	for _, fallback := range cfg.FallbackBuckets {
+		buckets = append(buckets, newBucket(fallback, layouts))
 	}
 
 	g := GCPStreamer{
-		log:     log.With().Str("component", "gcp_streamer").Logger(),
-		decoder: decoder,
-		bucket:  bucket,
+		log:     log.With().Str(logs.Component, "gcp_streamer").Logger(),
+		decoder: records.NewDecoder(),
+		buckets: buckets,
+		heights: cfg.LookupHeight,
 		queue:   dps.NewDeque(),
 		buffer:  dps.NewDeque(),
 		limit:   cfg.BufferSize,
 		busy:    0,
+		archive: cfg.Archive,
+
+		attempts:   make(map[flow.Identifier]uint),
+		quarantine: cfg.QuarantineAfter,
 	}
 
 	for _, blockID := range cfg.CatchupBlocks {
 		g.queue.PushFront(blockID)
-		g.log.Debug().Hex("block", blockID[:]).Msg("execution record queued for catch-up")
+		g.log.Debug().Hex(logs.BlockID, blockID[:]).Msg("execution record queued for catch-up")
 	}
 
 	return &g
@@ -88,7 +111,7 @@ func (g *GCPStreamer) OnBlockFinalized(blockID flow.Identifier) {
 	// download the blocks in a FIFO manner.
 	g.queue.PushFront(blockID)
 
-	g.log.Debug().Hex("block", blockID[:]).Msg("execution record queued for download")
+	g.log.Debug().Hex(logs.BlockID, blockID[:]).Msg("execution record queued for download")
 }
 
 // Next returns the next available block data. It returns an ErrUnavailable if no block
@@ -112,9 +135,15 @@ func (g *GCPStreamer) Next() (*uploader.BlockData, error) {
 
 	// If we have a record in the buffer, we will just return it. The buffer is
 	// concurrency safe, so there is no problem with popping from the back while
-	// the poll is pushing new items in the front.
-	record := g.buffer.PopBack()
-	return record.(*uploader.BlockData), nil
+	// the poll is pushing new items in the front. If the record was
+	// quarantined, we report it as corrupted, so that the consumer's policy for
+	// corrupted data decides whether to record it before halting.
+	item := g.buffer.PopBack()
+	poison, ok := item.(quarantined)
+	if ok {
+		return nil, fmt.Errorf("execution record quarantined (block: %x, reason: %s): %w", poison.blockID, poison.reason, dps.ErrCorrupted)
+	}
+	return item.(*uploader.BlockData), nil
 }
 
 func (g *GCPStreamer) poll() {
@@ -166,56 +195,210 @@ func (g *GCPStreamer) download() error {
 			return nil
 		}
 
-		// Get the name of the file based on the block ID. The file name is
-		// made up of the block ID in hex and a `.cbor` extension, see:
-		// Maks: "thats correct. In fact the full name is `<blockID>.cbor`"
-		// If we encounter an error, such as that the file is not found, we put
-		// the block ID back into the queue and return `nil` to stop pulling.
+		// The name of the file is derived from the block ID, and possibly the
+		// height, according to the layout of the bucket. If we encounter an
+		// error, such as that the file is not found, we put the block ID back
+		// into the queue and return to stop pulling.
 		blockID := g.queue.PopBack().(flow.Identifier)
-		name := blockID.String() + ".cbor"
-		record, err := g.pullRecord(name)
+		record, data, name, err := g.pullRecord(blockID)
+		if errors.Is(err, dps.ErrCorrupted) && g.quarantined(blockID, err) {
+			continue
+		}
 		if err != nil {
 			g.queue.PushBack(blockID)
-			return fmt.Errorf("could not pull execution record (name: %s): %w", name, err)
+			return fmt.Errorf("could not pull execution record (block: %x): %w", blockID, err)
+		}
+
+		// If enabled, the record is archived in its original encoding before
+		// it is pushed into the buffer, so that no record is indexed without
+		// being archived. If that fails, we download it again later.
+		if g.archive != nil {
+			err = g.archive(blockID, data)
+			if err != nil {
+				g.queue.PushBack(blockID)
+				return fmt.Errorf("could not archive execution record (block: %x): %w", blockID, err)
+			}
 		}
 
 		g.log.Debug().
 			Str("name", name).
-			Uint64("height", record.Block.Header.Height).
-			Hex("block", blockID[:]).
+			Uint64(logs.Height, record.Block.Header.Height).
+			Hex(logs.BlockID, blockID[:]).
 			Msg("pushing execution record into buffer")
 
+		delete(g.attempts, blockID)
 		g.buffer.PushFront(record)
 	}
 }
 
-func (g *GCPStreamer) pullRecord(name string) (*uploader.BlockData, error) {
+// quarantined counts a failed attempt to download a corrupted execution
+// record, and returns whether the record was quarantined as a result. Once a
+// record is quarantined, it is no longer downloaded, and a placeholder takes
+// its place in the buffer.
+func (g *GCPStreamer) quarantined(blockID flow.Identifier, err error) bool {
+
+	g.attempts[blockID]++
+	attempts := g.attempts[blockID]
+	if g.quarantine == 0 || attempts < g.quarantine {
+		g.log.Warn().Err(err).Hex(logs.BlockID, blockID[:]).Uint("attempts", attempts).Msg("execution record corrupted, retrying download")
+		return false
+	}
+
+	delete(g.attempts, blockID)
+	recordsQuarantined.Inc()
+
+	g.log.Error().Err(err).Hex(logs.BlockID, blockID[:]).Uint("attempts", attempts).Msg("execution record quarantined")
+
+	g.buffer.PushFront(quarantined{blockID: blockID, reason: err.Error()})
+
+	return true
+}
+
+func (g *GCPStreamer) pullRecord(blockID flow.Identifier) (*uploader.BlockData, []byte, string, error) {
+
+	// We try the buckets that served their last request without failure first,
+	// in the configured order, and only then the ones that failed. If a record
+	// is missing from a bucket, or the bucket fails, we fall back to the next
+	// one. Only if all of them fail do we report the failure; if the record is
+	// simply missing everywhere, it was most likely not uploaded yet.
+	ordered := make([]*bucket, 0, len(g.buckets))
+	for _, b := range g.buckets {
+		if b.healthy {
+			ordered = append(ordered, b)
+		}
+	}
+	for _, b := range g.buckets {
+		if !b.healthy {
+			ordered = append(ordered, b)
+		}
+	}
+
+	var failure, corruption error
+	for _, b := range ordered {
+
+		names, err := g.objectNames(b.layouts, blockID)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("could not get object names: %w", err)
+		}
+
+		record, data, name, err := g.pullFrom(b, names)
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			b.record(resultMissing)
+			continue
+		}
+		if errors.Is(err, dps.ErrCorrupted) {
+			b.record(resultCorrupted)
+			g.log.Warn().Err(err).Str("bucket", b.name).Hex(logs.BlockID, blockID[:]).Msg("corrupted execution record in bucket")
+			corruption = err
+			continue
+		}
+		if err != nil {
+			b.record(resultFailure)
+			g.log.Warn().Err(err).Str("bucket", b.name).Hex(logs.BlockID, blockID[:]).Msg("could not pull execution record from bucket")
+			failure = err
+			continue
+		}
+
+		b.record(resultSuccess)
+		return record, data, name, nil
+	}
+
+	// A record is only considered corrupted if no bucket failed to serve it,
+	// as a failing bucket might still have an intact copy of it.
+	if failure != nil {
+		return nil, nil, "", failure
+	}
+	if corruption != nil {
+		return nil, nil, "", corruption
+	}
+
+	return nil, nil, "", storage.ErrObjectNotExist
+}
+
+func (g *GCPStreamer) pullFrom(b *bucket, names []string) (*uploader.BlockData, []byte, string, error) {
+
+	// We try the object name of each candidate layout in order. As soon as an
+	// object is found with one of them, we know the layout used by the bucket
+	// and stop trying the others for subsequent records.
+	for i, name := range names {
+
+		record, data, err := g.pullObject(b.handle, name)
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, "", err
+		}
+
+		if len(b.layouts) > 1 {
+			layout := b.layouts[i]
+			g.log.Info().Str("bucket", b.name).Str("layout", layout).Msg("execution record layout detected")
+			b.layouts = []string{layout}
+		}
+
+		return record, data, name, nil
+	}
+
+	return nil, nil, "", storage.ErrObjectNotExist
+}
+
+func (g *GCPStreamer) objectNames(layouts []string, blockID flow.Identifier) ([]string, error) {
+	names := make([]string, 0, len(layouts))
+	for _, layout := range layouts {
+		name, err := g.objectName(layout, blockID)
+		if err != nil {
+			return nil, fmt.Errorf("could not get object name (layout: %s): %w", layout, err)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (g *GCPStreamer) objectName(layout string, blockID flow.Identifier) (string, error) {
+
+	name := strings.ReplaceAll(layout, PlaceholderBlock, blockID.String())
+	if !strings.Contains(name, PlaceholderHeight) {
+		return name, nil
+	}
+
+	if g.heights == nil {
+		return "", fmt.Errorf("no height lookup for layout with height placeholder")
+	}
+	height, err := g.heights(blockID)
+	if err != nil {
+		return "", fmt.Errorf("could not look up height: %w", err)
+	}
+	name = strings.ReplaceAll(name, PlaceholderHeight, strconv.FormatUint(height, 10))
+
+	return name, nil
+}
+
+func (g *GCPStreamer) pullObject(bucket *storage.BucketHandle, name string) (*uploader.BlockData, []byte, error) {
 
-	object := g.bucket.Object(name)
+	object := bucket.Object(name)
 	reader, err := object.NewReader(context.Background())
 	if err != nil {
-		return nil, fmt.Errorf("could not create object reader: %w", err)
+		return nil, nil, fmt.Errorf("could not create object reader: %w", err)
 	}
 	defer reader.Close()
 
 	data, err := io.ReadAll(reader)
 	if err != nil {
-		return nil, fmt.Errorf("could not read execution record: %w", err)
+		return nil, nil, fmt.Errorf("could not read execution record: %w", err)
 	}
 
-	var record uploader.BlockData
-	err = g.decoder.Unmarshal(data, &record)
+	record, err := g.decoder.Decode(data)
 	if err != nil {
-		return nil, fmt.Errorf("could not decode execution record: %w", err)
+		return nil, nil, fmt.Errorf("could not decode execution record (%s): %w", err, dps.ErrCorrupted)
 	}
 
 	if record.FinalStateCommitment == flow.DummyStateCommitment {
-		return nil, fmt.Errorf("execution record contains empty state commitment")
+		return nil, nil, fmt.Errorf("execution record contains empty state commitment: %w", dps.ErrCorrupted)
 	}
 
-	if record.Block.Header.Height == 0 {
-		return nil, fmt.Errorf("execution record contains empty block data")
+	if record.Block == nil || record.Block.Header == nil || record.Block.Header.Height == 0 {
+		return nil, nil, fmt.Errorf("execution record contains empty block data: %w", dps.ErrCorrupted)
 	}
 
-	return &record, nil
+	return record, data, nil
 }
@@ -16,6 +16,7 @@ package cloud
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -29,13 +30,17 @@ import (
 	"github.com/stretchr/testify/require"
 	"google.golang.org/api/option"
 
+	"github.com/onflow/flow-go/model/flow"
+
 	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/records"
 	"github.com/optakt/flow-dps/testing/mocks"
 )
 
 func TestNewGCPStreamer(t *testing.T) {
 	log := zerolog.Nop()
 	bucket := &storage.BucketHandle{}
+	fallback := &storage.BucketHandle{}
 	limit := uint(42)
 	blockIDs := mocks.GenericBlockIDs(4)
 
@@ -44,11 +49,16 @@ func TestNewGCPStreamer(t *testing.T) {
 		bucket,
 		WithBufferSize(limit),
 		WithCatchupBlocks(blockIDs),
+		WithFallbackBuckets(fallback),
 	)
 
 	require.NotNil(t, streamer)
 	assert.NotZero(t, streamer.log)
-	assert.Equal(t, bucket, streamer.bucket)
+	require.Len(t, streamer.buckets, 2)
+	assert.Equal(t, bucket, streamer.buckets[0].handle)
+	assert.Equal(t, fallback, streamer.buckets[1].handle)
+	assert.Equal(t, KnownLayouts, streamer.buckets[0].layouts)
+	assert.True(t, streamer.buckets[0].healthy)
 	assert.Equal(t, limit, streamer.limit)
 	assert.NotNil(t, streamer.queue)
 	assert.NotNil(t, streamer.buffer)
@@ -78,9 +88,7 @@ func TestGCPStreamer_Next(t *testing.T) {
 	data, err := cbor.Marshal(record)
 	require.NoError(t, err)
 
-	decOptions := cbor.DecOptions{ExtraReturnErrors: cbor.ExtraDecErrorUnknownField}
-	decoder, err := decOptions.DecMode()
-	require.NoError(t, err)
+	decoder := records.NewDecoder()
 
 	t.Run("returns available record if buffer not empty", func(t *testing.T) {
 		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
@@ -93,11 +101,11 @@ func TestGCPStreamer_Next(t *testing.T) {
 			option.WithEndpoint(server.URL),
 		)
 		require.NoError(t, err)
-		bucket := client.Bucket("test")
+		handle := client.Bucket("test")
 
 		streamer := &GCPStreamer{
 			log:     zerolog.Nop(),
-			bucket:  bucket,
+			buckets: []*bucket{newBucket(handle, KnownLayouts)},
 			decoder: decoder,
 			queue:   dps.NewDeque(),
 			buffer:  dps.NewDeque(),
@@ -123,11 +131,11 @@ func TestGCPStreamer_Next(t *testing.T) {
 			option.WithEndpoint(server.URL),
 		)
 		require.NoError(t, err)
-		bucket := client.Bucket("test")
+		handle := client.Bucket("test")
 
 		streamer := &GCPStreamer{
 			log:     zerolog.Nop(),
-			bucket:  bucket,
+			buckets: []*bucket{newBucket(handle, KnownLayouts)},
 			decoder: decoder,
 			queue:   dps.NewDeque(),
 			buffer:  dps.NewDeque(),
@@ -153,11 +161,11 @@ func TestGCPStreamer_Next(t *testing.T) {
 			option.WithEndpoint(server.URL),
 		)
 		require.NoError(t, err)
-		bucket := client.Bucket("test")
+		handle := client.Bucket("test")
 
 		streamer := &GCPStreamer{
 			log:     zerolog.Nop(),
-			bucket:  bucket,
+			buckets: []*bucket{newBucket(handle, KnownLayouts)},
 			decoder: decoder,
 			queue:   dps.NewDeque(),
 			buffer:  dps.NewDeque(),
@@ -180,3 +188,411 @@ func TestGCPStreamer_Next(t *testing.T) {
 		assert.Zero(t, streamer.queue.Len())
 	})
 }
+
+func TestGCPStreamer_Quarantine(t *testing.T) {
+	blockID := mocks.GenericHeader.ID()
+
+	serve := func() (*httptest.Server, *gcloud.BucketHandle) {
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+			_, _ = rw.Write(mocks.GenericBytes)
+		}))
+		client, err := gcloud.NewClient(
+			context.Background(),
+			option.WithoutAuthentication(),
+			option.WithEndpoint(server.URL),
+		)
+		require.NoError(t, err)
+		return server, client.Bucket("test")
+	}
+
+	t.Run("quarantines record after repeated corruption", func(t *testing.T) {
+		t.Parallel()
+
+		server, bucket := serve()
+		defer server.Close()
+
+		streamer := NewGCPStreamer(zerolog.Nop(), bucket,
+			WithQuarantineAfter(2),
+		)
+		streamer.queue.PushFront(blockID)
+
+		err := streamer.download()
+		require.ErrorIs(t, err, dps.ErrCorrupted)
+		assert.Equal(t, 1, streamer.queue.Len())
+		assert.Zero(t, streamer.buffer.Len())
+
+		err = streamer.download()
+		require.NoError(t, err)
+		assert.Zero(t, streamer.queue.Len())
+		assert.Empty(t, streamer.attempts)
+
+		_, err = streamer.Next()
+		assert.ErrorIs(t, err, dps.ErrCorrupted)
+		assert.Contains(t, err.Error(), blockID.String())
+	})
+
+	t.Run("keeps retrying when quarantine is disabled", func(t *testing.T) {
+		t.Parallel()
+
+		server, bucket := serve()
+		defer server.Close()
+
+		streamer := NewGCPStreamer(zerolog.Nop(), bucket,
+			WithQuarantineAfter(0),
+		)
+		streamer.queue.PushFront(blockID)
+
+		for i := 0; i < 5; i++ {
+			err := streamer.download()
+			require.ErrorIs(t, err, dps.ErrCorrupted)
+		}
+
+		assert.Equal(t, 1, streamer.queue.Len())
+		assert.Zero(t, streamer.buffer.Len())
+		assert.Equal(t, uint(5), streamer.attempts[blockID])
+	})
+}
+
+func TestGCPStreamer_Archive(t *testing.T) {
+	record := mocks.GenericRecord()
+	data, err := cbor.Marshal(record)
+	require.NoError(t, err)
+	blockID := record.Block.ID()
+
+	serve := func() (*httptest.Server, *gcloud.BucketHandle) {
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+			_, _ = rw.Write(data)
+		}))
+		client, err := gcloud.NewClient(
+			context.Background(),
+			option.WithoutAuthentication(),
+			option.WithEndpoint(server.URL),
+		)
+		require.NoError(t, err)
+		return server, client.Bucket("test")
+	}
+
+	t.Run("archives original record before buffering it", func(t *testing.T) {
+		t.Parallel()
+
+		server, bucket := serve()
+		defer server.Close()
+
+		var archived []byte
+		archive := func(gotID flow.Identifier, gotData []byte) error {
+			assert.Equal(t, blockID, gotID)
+			archived = gotData
+			return nil
+		}
+
+		streamer := NewGCPStreamer(zerolog.Nop(), bucket,
+			WithArchive(archive),
+		)
+		streamer.queue.PushFront(blockID)
+
+		err := streamer.download()
+
+		require.NoError(t, err)
+		assert.Equal(t, data, archived)
+		assert.Equal(t, 1, streamer.buffer.Len())
+	})
+
+	t.Run("downloads record again when archiving fails", func(t *testing.T) {
+		t.Parallel()
+
+		server, bucket := serve()
+		defer server.Close()
+
+		archive := func(flow.Identifier, []byte) error {
+			return mocks.GenericError
+		}
+
+		streamer := NewGCPStreamer(zerolog.Nop(), bucket,
+			WithArchive(archive),
+		)
+		streamer.queue.PushFront(blockID)
+
+		err := streamer.download()
+
+		require.ErrorIs(t, err, mocks.GenericError)
+		assert.Equal(t, 1, streamer.queue.Len())
+		assert.Zero(t, streamer.buffer.Len())
+	})
+}
+
+func TestGCPStreamer_PullRecord(t *testing.T) {
+	record := mocks.GenericRecord()
+	data, err := cbor.Marshal(record)
+	require.NoError(t, err)
+	blockID := record.Block.ID()
+
+	serveStatus := func(name string, status int) (*httptest.Server, *gcloud.BucketHandle) {
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if status != http.StatusOK {
+				rw.WriteHeader(status)
+				return
+			}
+			if req.URL.Path != "/test/"+name {
+				rw.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = rw.Write(data)
+		}))
+		client, err := gcloud.NewClient(
+			context.Background(),
+			option.WithoutAuthentication(),
+			option.WithEndpoint(server.URL),
+		)
+		require.NoError(t, err)
+		return server, client.Bucket("test")
+	}
+	serve := func(name string) (*httptest.Server, *gcloud.BucketHandle) {
+		return serveStatus(name, http.StatusOK)
+	}
+
+	t.Run("detects current layout", func(t *testing.T) {
+		t.Parallel()
+
+		server, bucket := serve(blockID.String() + ".cbor")
+		defer server.Close()
+
+		streamer := NewGCPStreamer(zerolog.Nop(), bucket)
+
+		got, gotData, name, err := streamer.pullRecord(blockID)
+
+		require.NoError(t, err)
+		assert.Equal(t, record, got)
+		assert.Equal(t, data, gotData)
+		assert.Equal(t, blockID.String()+".cbor", name)
+		assert.Equal(t, []string{KnownLayouts[0]}, streamer.buckets[0].layouts)
+	})
+
+	t.Run("detects historical layout", func(t *testing.T) {
+		t.Parallel()
+
+		server, bucket := serve(blockID.String())
+		defer server.Close()
+
+		streamer := NewGCPStreamer(zerolog.Nop(), bucket)
+
+		got, _, name, err := streamer.pullRecord(blockID)
+
+		require.NoError(t, err)
+		assert.Equal(t, record, got)
+		assert.Equal(t, blockID.String(), name)
+		assert.Equal(t, []string{KnownLayouts[1]}, streamer.buckets[0].layouts)
+	})
+
+	t.Run("uses configured layout with height", func(t *testing.T) {
+		t.Parallel()
+
+		name := fmt.Sprintf("records/%d/%s.cbor", mocks.GenericHeight, blockID)
+		server, bucket := serve(name)
+		defer server.Close()
+
+		streamer := NewGCPStreamer(zerolog.Nop(), bucket,
+			WithLayout("records/{height}/{block}.cbor"),
+			WithLookupHeight(func(flow.Identifier) (uint64, error) {
+				return mocks.GenericHeight, nil
+			}),
+		)
+
+		got, _, gotName, err := streamer.pullRecord(blockID)
+
+		require.NoError(t, err)
+		assert.Equal(t, record, got)
+		assert.Equal(t, name, gotName)
+	})
+
+	t.Run("handles missing height lookup", func(t *testing.T) {
+		t.Parallel()
+
+		server, bucket := serve("")
+		defer server.Close()
+
+		streamer := NewGCPStreamer(zerolog.Nop(), bucket,
+			WithLayout("{height}.cbor"),
+		)
+
+		_, _, _, err := streamer.pullRecord(blockID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles height lookup failure", func(t *testing.T) {
+		t.Parallel()
+
+		server, bucket := serve("")
+		defer server.Close()
+
+		streamer := NewGCPStreamer(zerolog.Nop(), bucket,
+			WithLayout("{height}.cbor"),
+			WithLookupHeight(func(flow.Identifier) (uint64, error) {
+				return 0, mocks.GenericError
+			}),
+		)
+
+		_, _, _, err := streamer.pullRecord(blockID)
+
+		assert.ErrorIs(t, err, mocks.GenericError)
+	})
+
+	t.Run("handles record not available", func(t *testing.T) {
+		t.Parallel()
+
+		server, bucket := serve("")
+		defer server.Close()
+
+		streamer := NewGCPStreamer(zerolog.Nop(), bucket)
+
+		_, _, _, err := streamer.pullRecord(blockID)
+
+		assert.ErrorIs(t, err, gcloud.ErrObjectNotExist)
+		assert.Equal(t, KnownLayouts, streamer.buckets[0].layouts)
+	})
+
+	t.Run("falls back to secondary bucket when record is missing", func(t *testing.T) {
+		t.Parallel()
+
+		primaryServer, primary := serve("")
+		defer primaryServer.Close()
+		fallbackServer, fallback := serve(blockID.String() + ".cbor")
+		defer fallbackServer.Close()
+
+		streamer := NewGCPStreamer(zerolog.Nop(), primary,
+			WithFallbackBuckets(fallback),
+		)
+
+		got, _, _, err := streamer.pullRecord(blockID)
+
+		require.NoError(t, err)
+		assert.Equal(t, record, got)
+		assert.True(t, streamer.buckets[0].healthy)
+		assert.True(t, streamer.buckets[1].healthy)
+	})
+
+	t.Run("falls back to secondary bucket when primary fails", func(t *testing.T) {
+		t.Parallel()
+
+		primaryServer, primary := serveStatus("", http.StatusForbidden)
+		defer primaryServer.Close()
+		fallbackServer, fallback := serve(blockID.String() + ".cbor")
+		defer fallbackServer.Close()
+
+		streamer := NewGCPStreamer(zerolog.Nop(), primary,
+			WithFallbackBuckets(fallback),
+		)
+
+		got, _, _, err := streamer.pullRecord(blockID)
+
+		require.NoError(t, err)
+		assert.Equal(t, record, got)
+		assert.False(t, streamer.buckets[0].healthy)
+		assert.True(t, streamer.buckets[1].healthy)
+	})
+
+	t.Run("tries healthy buckets first", func(t *testing.T) {
+		t.Parallel()
+
+		var primaryCalls int
+		primaryServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+			primaryCalls++
+			_, _ = rw.Write(data)
+		}))
+		defer primaryServer.Close()
+		client, err := gcloud.NewClient(
+			context.Background(),
+			option.WithoutAuthentication(),
+			option.WithEndpoint(primaryServer.URL),
+		)
+		require.NoError(t, err)
+		primary := client.Bucket("test")
+
+		fallbackServer, fallback := serve(blockID.String() + ".cbor")
+		defer fallbackServer.Close()
+
+		streamer := NewGCPStreamer(zerolog.Nop(), primary,
+			WithFallbackBuckets(fallback),
+		)
+		streamer.buckets[0].healthy = false
+
+		_, _, _, err = streamer.pullRecord(blockID)
+
+		require.NoError(t, err)
+		assert.Zero(t, primaryCalls)
+	})
+
+	t.Run("falls back to secondary bucket when primary is corrupted", func(t *testing.T) {
+		t.Parallel()
+
+		primaryServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+			_, _ = rw.Write(mocks.GenericBytes)
+		}))
+		defer primaryServer.Close()
+		client, err := gcloud.NewClient(
+			context.Background(),
+			option.WithoutAuthentication(),
+			option.WithEndpoint(primaryServer.URL),
+		)
+		require.NoError(t, err)
+
+		fallbackServer, fallback := serve(blockID.String() + ".cbor")
+		defer fallbackServer.Close()
+
+		streamer := NewGCPStreamer(zerolog.Nop(), client.Bucket("test"),
+			WithFallbackBuckets(fallback),
+		)
+
+		got, _, _, err := streamer.pullRecord(blockID)
+
+		require.NoError(t, err)
+		assert.Equal(t, record, got)
+		assert.True(t, streamer.buckets[0].healthy)
+	})
+
+	t.Run("prefers bucket failure over corruption", func(t *testing.T) {
+		t.Parallel()
+
+		primaryServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+			_, _ = rw.Write(mocks.GenericBytes)
+		}))
+		defer primaryServer.Close()
+		client, err := gcloud.NewClient(
+			context.Background(),
+			option.WithoutAuthentication(),
+			option.WithEndpoint(primaryServer.URL),
+		)
+		require.NoError(t, err)
+
+		fallbackServer, fallback := serveStatus("", http.StatusForbidden)
+		defer fallbackServer.Close()
+
+		streamer := NewGCPStreamer(zerolog.Nop(), client.Bucket("test"),
+			WithFallbackBuckets(fallback),
+		)
+
+		_, _, _, err = streamer.pullRecord(blockID)
+
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, dps.ErrCorrupted)
+	})
+
+	t.Run("handles failure of all buckets", func(t *testing.T) {
+		t.Parallel()
+
+		primaryServer, primary := serveStatus("", http.StatusForbidden)
+		defer primaryServer.Close()
+		fallbackServer, fallback := serve("")
+		defer fallbackServer.Close()
+
+		streamer := NewGCPStreamer(zerolog.Nop(), primary,
+			WithFallbackBuckets(fallback),
+		)
+
+		_, _, _, err := streamer.pullRecord(blockID)
+
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, gcloud.ErrObjectNotExist)
+		assert.False(t, streamer.buckets[0].healthy)
+	})
+}
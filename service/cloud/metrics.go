@@ -0,0 +1,37 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package cloud
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	bucketRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bucket_requests_total",
+		Help: "number of execution record requests per bucket and result",
+	}, []string{"bucket", "result"})
+
+	bucketHealth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bucket_healthy",
+		Help: "whether the last execution record request to the bucket was served without failure",
+	}, []string{"bucket"})
+
+	recordsQuarantined = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "execution_records_quarantined_total",
+		Help: "number of execution records quarantined after repeatedly failing decoding or validation",
+	})
+)
@@ -0,0 +1,39 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+//go:build windows
+// +build windows
+
+package disk
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// available returns the space in bytes that is available to the user of the
+// process on the volume of the given path, taking quotas into account.
+func available(path string) (uint64, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, fmt.Errorf("could not convert path: %w", err)
+	}
+	var free, total, totalFree uint64
+	err = windows.GetDiskFreeSpaceEx(name, &free, &total, &totalFree)
+	if err != nil {
+		return 0, fmt.Errorf("could not get disk free space: %w", err)
+	}
+	return free, nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package disk
+
+import (
+	"time"
+)
+
+// DefaultConfig is the default configuration for the disk monitor.
+var DefaultConfig = Config{
+	Interval: 10 * time.Second,
+}
+
+// Config is the configuration for the disk monitor.
+type Config struct {
+	Interval time.Duration
+}
+
+// Option is a function that can be applied to a Config.
+type Option func(*Config)
+
+// WithInterval sets the interval at which the monitor checks the free space on
+// its volumes, which is also the interval at which paused ingestion checks
+// whether it can resume.
+func WithInterval(interval time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.Interval = interval
+	}
+}
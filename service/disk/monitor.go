@@ -0,0 +1,195 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package disk
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/logs"
+)
+
+// Volume is a directory whose volume is monitored, along with the free space in
+// bytes below which the volume is considered to be low on space.
+type Volume struct {
+	Name      string
+	Path      string
+	Threshold uint64
+}
+
+// Monitor periodically checks the free space on the volumes of the indexer's
+// databases. While any of them is below its threshold, ingestion waits for
+// space to be freed, instead of letting Badger run out of space in the middle
+// of a write or a compaction, which can corrupt the database.
+type Monitor struct {
+	log     zerolog.Logger
+	cfg     Config
+	volumes []Volume
+	free    func(path string) (uint64, error)
+	mutex   *sync.RWMutex
+	infos   []dps.VolumeInfo
+	low     bool
+	stop    chan struct{}
+	wg      *sync.WaitGroup
+}
+
+// NewMonitor returns a new disk monitor for the given volumes.
+func NewMonitor(log zerolog.Logger, volumes []Volume, options ...Option) *Monitor {
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	infos := make([]dps.VolumeInfo, 0, len(volumes))
+	for _, volume := range volumes {
+		info := dps.VolumeInfo{
+			Name:      volume.Name,
+			Path:      volume.Path,
+			Threshold: volume.Threshold,
+		}
+		infos = append(infos, info)
+	}
+
+	m := Monitor{
+		log:     log.With().Str(logs.Component, "disk_monitor").Logger(),
+		cfg:     cfg,
+		volumes: volumes,
+		free:    available,
+		mutex:   &sync.RWMutex{},
+		infos:   infos,
+		stop:    make(chan struct{}),
+		wg:      &sync.WaitGroup{},
+	}
+
+	return &m
+}
+
+// Run checks the free space on the volumes at the configured interval, until
+// the monitor is stopped.
+func (m *Monitor) Run() error {
+	m.wg.Add(1)
+	defer m.wg.Done()
+
+	for {
+		err := m.check()
+		if err != nil {
+			return fmt.Errorf("could not check free space: %w", err)
+		}
+
+		select {
+		case <-m.stop:
+			return nil
+		case <-time.After(m.cfg.Interval):
+			// continue
+		}
+	}
+}
+
+// Stop stops the monitor, which also releases any caller that is waiting for
+// free space.
+func (m *Monitor) Stop() error {
+	close(m.stop)
+	m.wg.Wait()
+	return nil
+}
+
+// Wait blocks for as long as any of the volumes is low on space. It returns
+// `dps.ErrFinished` if the monitor is stopped while waiting.
+func (m *Monitor) Wait() error {
+	for {
+		if !m.Low() {
+			return nil
+		}
+
+		select {
+		case <-m.stop:
+			return dps.ErrFinished
+		case <-time.After(m.cfg.Interval):
+			// continue
+		}
+	}
+}
+
+// Low returns whether any of the volumes was low on space when it was last
+// checked.
+func (m *Monitor) Low() bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.low
+}
+
+// Volumes returns the state of the volumes as of their last check.
+func (m *Monitor) Volumes() []dps.VolumeInfo {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return append([]dps.VolumeInfo{}, m.infos...)
+}
+
+func (m *Monitor) check() error {
+
+	infos := make([]dps.VolumeInfo, 0, len(m.volumes))
+	low := false
+	for _, volume := range m.volumes {
+		free, err := m.free(volume.Path)
+		if err != nil {
+			return fmt.Errorf("could not get free space (path: %s): %w", volume.Path, err)
+		}
+
+		info := dps.VolumeInfo{
+			Name:      volume.Name,
+			Path:      volume.Path,
+			Free:      free,
+			Threshold: volume.Threshold,
+			Low:       free < volume.Threshold,
+		}
+		infos = append(infos, info)
+
+		low = low || info.Low
+	}
+
+	m.mutex.Lock()
+	previous := m.low
+	m.infos = infos
+	m.low = low
+	m.mutex.Unlock()
+
+	// We only log changes of the state, so that a volume that stays low on
+	// space does not flood the logs.
+	if low && !previous {
+		for _, info := range infos {
+			if !info.Low {
+				continue
+			}
+			m.log.Warn().
+				Str("volume", info.Name).
+				Str("path", info.Path).
+				Uint64("free", info.Free).
+				Uint64("threshold", info.Threshold).
+				Msg("volume low on space, pausing ingestion")
+		}
+	}
+	if !low && previous {
+		m.log.Info().Msg("space freed, resuming ingestion")
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package disk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestMonitor(t *testing.T) {
+
+	volumes := []Volume{
+		{Name: "index", Path: "/index", Threshold: 1000},
+		{Name: "data", Path: "/data", Threshold: 100},
+	}
+
+	// setup creates a monitor that reads the free space of its volumes from the
+	// given map instead of the file system.
+	setup := func(t *testing.T, space map[string]uint64) *Monitor {
+		t.Helper()
+
+		m := NewMonitor(zerolog.Nop(), volumes, WithInterval(time.Millisecond))
+		m.free = func(path string) (uint64, error) {
+			return space[path], nil
+		}
+
+		return m
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		m := setup(t, map[string]uint64{"/index": 2000, "/data": 200})
+
+		err := m.check()
+		require.NoError(t, err)
+
+		assert.False(t, m.Low())
+		assert.NoError(t, m.Wait())
+
+		got := m.Volumes()
+		require.Len(t, got, 2)
+		assert.Equal(t, dps.VolumeInfo{Name: "index", Path: "/index", Free: 2000, Threshold: 1000}, got[0])
+		assert.Equal(t, dps.VolumeInfo{Name: "data", Path: "/data", Free: 200, Threshold: 100}, got[1])
+	})
+
+	t.Run("handles low volume", func(t *testing.T) {
+		t.Parallel()
+
+		m := setup(t, map[string]uint64{"/index": 2000, "/data": 50})
+
+		err := m.check()
+		require.NoError(t, err)
+
+		assert.True(t, m.Low())
+		got := m.Volumes()
+		require.Len(t, got, 2)
+		assert.False(t, got[0].Low)
+		assert.True(t, got[1].Low)
+	})
+
+	t.Run("waits until space is freed", func(t *testing.T) {
+		t.Parallel()
+
+		m := setup(t, map[string]uint64{"/index": 500, "/data": 200})
+		require.NoError(t, m.check())
+
+		done := make(chan error)
+		go func() {
+			done <- m.Wait()
+		}()
+
+		select {
+		case <-done:
+			t.Fatal("wait returned while volume was low on space")
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		m.free = func(string) (uint64, error) { return 5000, nil }
+		require.NoError(t, m.check())
+
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("wait did not return after space was freed")
+		}
+	})
+
+	t.Run("handles stop while waiting", func(t *testing.T) {
+		t.Parallel()
+
+		m := setup(t, map[string]uint64{"/index": 500, "/data": 200})
+		require.NoError(t, m.check())
+
+		done := make(chan error)
+		go func() {
+			done <- m.Wait()
+		}()
+
+		require.NoError(t, m.Stop())
+
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, dps.ErrFinished)
+		case <-time.After(time.Second):
+			t.Fatal("wait did not return after monitor was stopped")
+		}
+	})
+
+	t.Run("handles free space failure", func(t *testing.T) {
+		t.Parallel()
+
+		m := setup(t, nil)
+		m.free = func(string) (uint64, error) { return 0, mocks.GenericError }
+
+		err := m.check()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,221 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package execution
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/onflow/flow-go/engine/execution/state"
+	"github.com/onflow/flow-go/engine/execution/state/delta"
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common/pathfinder"
+	"github.com/onflow/flow-go/ledger/complete"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/model/messages"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// State presents a DPS index as the read-only execution state of flow-go, so
+// that flow-go tools which read registers can run directly against the index.
+// Registers are read at the height of the given state commitment. Data that
+// only execution nodes keep, such as chunk data packs and proofs, is not part
+// of the index, and reading it fails with `dps.ErrNotIndexed`.
+type State struct {
+	index  dps.Reader
+	filter dps.Filter
+}
+
+// NewState returns a new read-only execution state backed by the given index.
+func NewState(index dps.Reader) (*State, error) {
+
+	// If the index only contains the registers of some owners, we keep track
+	// of its filter, so that reading other registers fails instead of
+	// returning empty values.
+	filter, err := index.Filter(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("could not get index filter: %w", err)
+	}
+
+	s := State{
+		index:  index,
+		filter: filter,
+	}
+
+	return &s, nil
+}
+
+// View returns a read-only view of the execution state at the given height.
+func (s *State) View(ctx context.Context, height uint64) *delta.View {
+	return delta.NewView(s.read(ctx, height))
+}
+
+// Registers returns the values of the given registers at the given height.
+func (s *State) Registers(ctx context.Context, height uint64, regIDs []flow.RegisterID) ([]flow.RegisterValue, error) {
+
+	paths := make([]ledger.Path, 0, len(regIDs))
+	for _, regID := range regIDs {
+		err := s.check(regID.Owner)
+		if err != nil {
+			return nil, err
+		}
+		path, err := pathfinder.KeyToPath(state.RegisterIDToKey(regID), complete.DefaultPathFinderVersion)
+		if err != nil {
+			return nil, fmt.Errorf("could not convert key to path: %w", err)
+		}
+		paths = append(paths, path)
+	}
+
+	values, err := s.index.Values(ctx, height, paths)
+	if err != nil {
+		return nil, fmt.Errorf("could not read registers: %w", err)
+	}
+
+	registers := make([]flow.RegisterValue, 0, len(values))
+	for _, value := range values {
+		registers = append(registers, flow.RegisterValue(value))
+	}
+
+	return registers, nil
+}
+
+// NewView returns a read-only view of the execution state at the given state
+// commitment. As the signature of the view can not return an error, a commit
+// that is not indexed makes every read of the view fail instead.
+func (s *State) NewView(commit flow.StateCommitment) *delta.View {
+	ctx := context.Background()
+	height, err := s.index.HeightForCommit(ctx, commit)
+	if err != nil {
+		return delta.NewView(func(string, string, string) (flow.RegisterValue, error) {
+			return nil, fmt.Errorf("could not get height for commit (%x): %w", commit, err)
+		})
+	}
+	return s.View(ctx, height)
+}
+
+// GetRegisters returns the values of the given registers at the given state
+// commitment.
+func (s *State) GetRegisters(ctx context.Context, commit flow.StateCommitment, regIDs []flow.RegisterID) ([]flow.RegisterValue, error) {
+	height, err := s.index.HeightForCommit(ctx, commit)
+	if err != nil {
+		return nil, fmt.Errorf("could not get height for commit (%x): %w", commit, err)
+	}
+	return s.Registers(ctx, height, regIDs)
+}
+
+// GetProof is not supported, as the index does not keep the trie nodes that
+// are needed to build proofs.
+func (s *State) GetProof(context.Context, flow.StateCommitment, []flow.RegisterID) (flow.StorageProof, error) {
+	return nil, fmt.Errorf("could not get proof: %w", dps.ErrNotIndexed)
+}
+
+// StateCommitmentByBlockID returns the state commitment at the end of the
+// given block.
+func (s *State) StateCommitmentByBlockID(ctx context.Context, blockID flow.Identifier) (flow.StateCommitment, error) {
+	height, err := s.index.HeightForBlock(ctx, blockID)
+	if err != nil {
+		return flow.DummyStateCommitment, fmt.Errorf("could not get height for block (%x): %w", blockID, err)
+	}
+	commit, err := s.index.Commit(ctx, height)
+	if err != nil {
+		return flow.DummyStateCommitment, fmt.Errorf("could not get commit (height: %d): %w", height, err)
+	}
+	return commit, nil
+}
+
+// ChunkDataPackByChunkID is not supported, as the index does not keep chunk
+// data packs.
+func (s *State) ChunkDataPackByChunkID(context.Context, flow.Identifier) (*flow.ChunkDataPack, error) {
+	return nil, fmt.Errorf("could not get chunk data pack: %w", dps.ErrNotIndexed)
+}
+
+// GetExecutionResultID is not supported, as the index does not keep execution
+// results.
+func (s *State) GetExecutionResultID(context.Context, flow.Identifier) (flow.Identifier, error) {
+	return flow.ZeroID, fmt.Errorf("could not get execution result: %w", dps.ErrNotIndexed)
+}
+
+// RetrieveStateDelta is not supported, as the index does not keep the state
+// deltas of blocks.
+func (s *State) RetrieveStateDelta(context.Context, flow.Identifier) (*messages.ExecutionStateDelta, error) {
+	return nil, fmt.Errorf("could not get state delta: %w", dps.ErrNotIndexed)
+}
+
+// GetHighestExecutedBlockID returns the height and the identifier of the last
+// indexed block.
+func (s *State) GetHighestExecutedBlockID(ctx context.Context) (uint64, flow.Identifier, error) {
+	height, err := s.index.Last(ctx)
+	if err != nil {
+		return 0, flow.ZeroID, fmt.Errorf("could not get last height: %w", err)
+	}
+	header, err := s.index.Header(ctx, height)
+	if err != nil {
+		return 0, flow.ZeroID, fmt.Errorf("could not get header (height: %d): %w", height, err)
+	}
+	return height, header.ID(), nil
+}
+
+// GetCollection returns the collection with the given identifier, along with
+// the bodies of its transactions.
+func (s *State) GetCollection(collID flow.Identifier) (*flow.Collection, error) {
+	ctx := context.Background()
+	light, err := s.index.Collection(ctx, collID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get collection: %w", err)
+	}
+	transactions := make([]*flow.TransactionBody, 0, len(light.Transactions))
+	for _, txID := range light.Transactions {
+		tx, err := s.index.Transaction(ctx, txID)
+		if err != nil {
+			return nil, fmt.Errorf("could not get transaction (%x): %w", txID, err)
+		}
+		transactions = append(transactions, tx)
+	}
+	collection := flow.Collection{
+		Transactions: transactions,
+	}
+	return &collection, nil
+}
+
+// GetBlockIDByChunkID is not supported, as the index does not keep execution
+// results and their chunks.
+func (s *State) GetBlockIDByChunkID(flow.Identifier) (flow.Identifier, error) {
+	return flow.ZeroID, fmt.Errorf("could not get block for chunk: %w", dps.ErrNotIndexed)
+}
+
+func (s *State) read(ctx context.Context, height uint64) delta.GetRegisterFunc {
+	return func(owner string, controller string, key string) (flow.RegisterValue, error) {
+		values, err := s.Registers(ctx, height, []flow.RegisterID{flow.NewRegisterID(owner, controller, key)})
+		if err != nil {
+			return nil, err
+		}
+		return values[0], nil
+	}
+}
+
+// check fails precisely for registers of owners that the index filters out,
+// as we can't tell whether such a register is missing or was simply not
+// indexed.
+func (s *State) check(owner string) error {
+	if owner == "" {
+		return nil
+	}
+	address := flow.BytesToAddress([]byte(owner))
+	if !s.filter.Address(address) {
+		return fmt.Errorf("could not read register (owner: %s): %w", address, dps.ErrNotIndexed)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package execution_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/engine/execution/state"
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/execution"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestNewState(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+
+		got, err := execution.NewState(index)
+
+		require.NoError(t, err)
+		assert.Implements(t, (*state.ReadOnlyExecutionState)(nil), got)
+	})
+
+	t.Run("handles index failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.FilterFunc = func(context.Context) (dps.Filter, error) {
+			return dps.Filter{}, mocks.GenericError
+		}
+
+		_, err := execution.NewState(index)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestState_GetRegisters(t *testing.T) {
+	owner := string(mocks.GenericLedgerKey.KeyParts[0].Value)
+	controller := string(mocks.GenericLedgerKey.KeyParts[1].Value)
+	key := string(mocks.GenericLedgerKey.KeyParts[2].Value)
+	regIDs := []flow.RegisterID{flow.NewRegisterID(owner, controller, key)}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.HeightForCommitFunc = func(_ context.Context, commit flow.StateCommitment) (uint64, error) {
+			assert.Equal(t, mocks.GenericCommit(0), commit)
+			return mocks.GenericHeight, nil
+		}
+		index.ValuesFunc = func(_ context.Context, height uint64, paths []ledger.Path) ([]ledger.Value, error) {
+			assert.Equal(t, mocks.GenericHeight, height)
+			assert.Len(t, paths, 1)
+			return []ledger.Value{mocks.GenericBytes}, nil
+		}
+
+		s, err := execution.NewState(index)
+		require.NoError(t, err)
+
+		got, err := s.GetRegisters(context.Background(), mocks.GenericCommit(0), regIDs)
+
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, mocks.GenericBytes, got[0][:])
+	})
+
+	t.Run("handles unknown commit", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.HeightForCommitFunc = func(context.Context, flow.StateCommitment) (uint64, error) {
+			return 0, dps.ErrNotIndexed
+		}
+
+		s, err := execution.NewState(index)
+		require.NoError(t, err)
+
+		_, err = s.GetRegisters(context.Background(), mocks.GenericCommit(0), regIDs)
+
+		assert.ErrorIs(t, err, dps.ErrNotIndexed)
+	})
+
+	t.Run("handles filtered owner", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.FilterFunc = func(context.Context) (dps.Filter, error) {
+			return dps.Filter{Denied: []flow.Address{flow.BytesToAddress([]byte(owner))}}, nil
+		}
+
+		s, err := execution.NewState(index)
+		require.NoError(t, err)
+
+		_, err = s.GetRegisters(context.Background(), mocks.GenericCommit(0), regIDs)
+
+		assert.ErrorIs(t, err, dps.ErrNotIndexed)
+	})
+
+	t.Run("handles index failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.ValuesFunc = func(context.Context, uint64, []ledger.Path) ([]ledger.Value, error) {
+			return nil, mocks.GenericError
+		}
+
+		s, err := execution.NewState(index)
+		require.NoError(t, err)
+
+		_, err = s.GetRegisters(context.Background(), mocks.GenericCommit(0), regIDs)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestState_NewView(t *testing.T) {
+	owner := string(mocks.GenericLedgerKey.KeyParts[0].Value)
+	controller := string(mocks.GenericLedgerKey.KeyParts[1].Value)
+	key := string(mocks.GenericLedgerKey.KeyParts[2].Value)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.ValuesFunc = func(context.Context, uint64, []ledger.Path) ([]ledger.Value, error) {
+			return []ledger.Value{mocks.GenericBytes}, nil
+		}
+
+		s, err := execution.NewState(index)
+		require.NoError(t, err)
+
+		view := s.NewView(mocks.GenericCommit(0))
+		got, err := view.Get(owner, controller, key)
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericBytes, got[:])
+	})
+
+	t.Run("handles unknown commit", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.HeightForCommitFunc = func(context.Context, flow.StateCommitment) (uint64, error) {
+			return 0, dps.ErrNotIndexed
+		}
+
+		s, err := execution.NewState(index)
+		require.NoError(t, err)
+
+		view := s.NewView(mocks.GenericCommit(0))
+		_, err = view.Get(owner, controller, key)
+
+		assert.ErrorIs(t, err, dps.ErrNotIndexed)
+	})
+}
+
+func TestState_StateCommitmentByBlockID(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.HeightForBlockFunc = func(_ context.Context, blockID flow.Identifier) (uint64, error) {
+			assert.Equal(t, mocks.GenericHeader.ID(), blockID)
+			return mocks.GenericHeight, nil
+		}
+
+		s, err := execution.NewState(index)
+		require.NoError(t, err)
+
+		got, err := s.StateCommitmentByBlockID(context.Background(), mocks.GenericHeader.ID())
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericCommit(0), got)
+	})
+
+	t.Run("handles index failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.CommitFunc = func(context.Context, uint64) (flow.StateCommitment, error) {
+			return flow.DummyStateCommitment, mocks.GenericError
+		}
+
+		s, err := execution.NewState(index)
+		require.NoError(t, err)
+
+		_, err = s.StateCommitmentByBlockID(context.Background(), mocks.GenericHeader.ID())
+
+		assert.Error(t, err)
+	})
+}
+
+func TestState_GetHighestExecutedBlockID(t *testing.T) {
+	index := mocks.BaselineReader(t)
+
+	s, err := execution.NewState(index)
+	require.NoError(t, err)
+
+	height, blockID, err := s.GetHighestExecutedBlockID(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, mocks.GenericHeight, height)
+	assert.Equal(t, mocks.GenericHeader.ID(), blockID)
+}
+
+func TestState_GetCollection(t *testing.T) {
+	index := mocks.BaselineReader(t)
+
+	s, err := execution.NewState(index)
+	require.NoError(t, err)
+
+	got, err := s.GetCollection(mocks.GenericCollection(0).ID())
+
+	require.NoError(t, err)
+	assert.Len(t, got.Transactions, len(mocks.GenericCollection(0).Transactions))
+}
+
+func TestState_Unsupported(t *testing.T) {
+	index := mocks.BaselineReader(t)
+
+	s, err := execution.NewState(index)
+	require.NoError(t, err)
+
+	_, err = s.GetProof(context.Background(), mocks.GenericCommit(0), nil)
+	assert.ErrorIs(t, err, dps.ErrNotIndexed)
+
+	_, err = s.ChunkDataPackByChunkID(context.Background(), flow.ZeroID)
+	assert.ErrorIs(t, err, dps.ErrNotIndexed)
+
+	_, err = s.GetExecutionResultID(context.Background(), flow.ZeroID)
+	assert.ErrorIs(t, err, dps.ErrNotIndexed)
+
+	_, err = s.RetrieveStateDelta(context.Background(), flow.ZeroID)
+	assert.ErrorIs(t, err, dps.ErrNotIndexed)
+
+	_, err = s.GetBlockIDByChunkID(flow.ZeroID)
+	assert.ErrorIs(t, err, dps.ErrNotIndexed)
+}
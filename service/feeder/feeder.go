@@ -15,10 +15,12 @@
 package feeder
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/ledger/complete/wal"
+	twal "github.com/prometheus/tsdb/wal"
 
 	"github.com/optakt/flow-dps/models/dps"
 )
@@ -26,6 +28,7 @@ import (
 // Feeder is a component that retrieves trie updates and feeds them to its consumer.
 type Feeder struct {
 	reader WALReader
+	skip   bool // whether the reader stopped on corrupted data that we reported
 }
 
 // FromWAL creates a trie update feeder that sources state deltas from a WAL reader.
@@ -38,9 +41,25 @@ func FromWAL(reader WALReader) *Feeder {
 	return &f
 }
 
-// Update returns the next trie update.
+// Update returns the next trie update. Corrupted records and segments are
+// reported with a `dps.ErrCorrupted` error; if the caller decides to carry on
+// and calls `Update` again, the feeder moves past the corrupted data.
 func (f *Feeder) Update() (*ledger.TrieUpdate, error) {
 
+	// If we reported a corrupted segment on the last call, the reader stopped
+	// and we need to skip to the next segment before we can read again.
+	if f.skip {
+		skipper, ok := f.reader.(Skipper)
+		if !ok {
+			return nil, fmt.Errorf("reader does not support skipping corrupted segments")
+		}
+		err := skipper.Skip()
+		if err != nil {
+			return nil, fmt.Errorf("could not skip corrupted segment: %w", err)
+		}
+		f.skip = false
+	}
+
 	// We read in a loop because the WAL contains entries that are not trie
 	// updates; we don't really need to care about them, so we can just skip
 	// them until we find a trie update.
@@ -51,6 +70,11 @@ func (f *Feeder) Update() (*ledger.TrieUpdate, error) {
 		// trie update.
 		next := f.reader.Next()
 		err := f.reader.Err()
+		var corruption *twal.CorruptionErr
+		if !next && errors.As(err, &corruption) {
+			f.skip = true
+			return nil, fmt.Errorf("could not read next record: %w (segment: %d, offset: %d): %s", dps.ErrCorrupted, corruption.Segment, corruption.Offset, corruption.Err)
+		}
 		if !next && err != nil {
 			return nil, fmt.Errorf("could not read next record: %w", err)
 		}
@@ -60,7 +84,7 @@ func (f *Feeder) Update() (*ledger.TrieUpdate, error) {
 		record := f.reader.Record()
 		operation, _, update, err := wal.Decode(record)
 		if err != nil {
-			return nil, fmt.Errorf("could not decode record: %w", err)
+			return nil, fmt.Errorf("could not decode record: %w: %s", dps.ErrCorrupted, err)
 		}
 		if operation != wal.WALUpdate {
 			continue
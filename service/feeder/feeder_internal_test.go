@@ -15,9 +15,11 @@
 package feeder
 
 import (
+	"os"
 	"strings"
 	"testing"
 
+	twal "github.com/prometheus/tsdb/wal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -126,6 +128,60 @@ func TestFeeder_Update(t *testing.T) {
 
 		_, err := feeder.Update()
 
+		assert.ErrorIs(t, err, dps.ErrCorrupted)
+	})
+
+	t.Run("handles corrupted segment", func(t *testing.T) {
+		t.Parallel()
+
+		record := append([]byte{byte(wal.WALUpdate)}, data...)
+		corrupted := frame(record)
+		corrupted[len(corrupted)-1] ^= 0xff
+
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(twal.SegmentName(dir, 0), corrupted, 0600))
+		require.NoError(t, os.WriteFile(twal.SegmentName(dir, 1), frame(record), 0600))
+
+		reader, err := NewSegmentsReader(dir)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		feeder := &Feeder{
+			reader: reader,
+		}
+
+		_, err = feeder.Update()
+
+		require.ErrorIs(t, err, dps.ErrCorrupted)
+
+		got, err := feeder.Update()
+
+		require.NoError(t, err)
+		assert.Equal(t, update, got)
+	})
+
+	t.Run("handles corrupted segment with reader that can't skip", func(t *testing.T) {
+		t.Parallel()
+
+		reader := mocks.BaselineWALReader(t)
+		reader.NextFunc = func() bool {
+			return false
+		}
+		reader.ErrFunc = func() error {
+			return &twal.CorruptionErr{Segment: 1, Offset: 2, Err: mocks.GenericError}
+		}
+
+		feeder := &Feeder{
+			reader: reader,
+		}
+
+		_, err := feeder.Update()
+
+		require.ErrorIs(t, err, dps.ErrCorrupted)
+
+		_, err = feeder.Update()
+
 		assert.Error(t, err)
+		assert.NotErrorIs(t, err, dps.ErrCorrupted)
 	})
 }
@@ -20,3 +20,9 @@ type WALReader interface {
 	Err() error
 	Record() []byte
 }
+
+// Skipper represents a write-ahead log reader that can move past corrupted data
+// after it has stopped with an error.
+type Skipper interface {
+	Skip() error
+}
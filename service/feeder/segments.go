@@ -0,0 +1,137 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package feeder
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/prometheus/tsdb/wal"
+)
+
+// SegmentsReader is a write-ahead log reader that reads the WAL segments in a
+// directory one after the other. Every record is verified against its CRC
+// checksum and framing, and corruptions are reported as `wal.CorruptionErr`
+// with the affected segment and offset. Because records never span across
+// segments, the reader can skip the rest of a corrupted segment and resume
+// reading at the start of the next one.
+type SegmentsReader struct {
+	dir      string
+	indices  []int
+	position int
+	segment  io.ReadCloser
+	reader   *wal.Reader
+	err      error
+}
+
+// NewSegmentsReader creates a new reader for the WAL segments that are present
+// in the given directory.
+func NewSegmentsReader(dir string) (*SegmentsReader, error) {
+
+	indices, err := listSegments(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not list segments: %w", err)
+	}
+
+	s := SegmentsReader{
+		dir:     dir,
+		indices: indices,
+	}
+
+	return &s, nil
+}
+
+// Next returns true if the next record was read successfully. If it returns
+// false and `Err` returns nil, all segments have been read.
+func (s *SegmentsReader) Next() bool {
+
+	if s.err != nil {
+		return false
+	}
+
+	for {
+
+		// If the current segment is not open yet, we open the next one, unless
+		// we have already read all of them.
+		if s.reader == nil {
+			if s.position >= len(s.indices) {
+				return false
+			}
+			index := s.indices[s.position]
+			segment, err := wal.NewSegmentsRangeReader(wal.SegmentRange{Dir: s.dir, First: index, Last: index})
+			if err != nil {
+				s.err = fmt.Errorf("could not open segment (index: %d): %w", index, err)
+				return false
+			}
+			s.segment = segment
+			s.reader = wal.NewReader(segment)
+		}
+
+		// The WAL reader already wraps all of its errors in a corruption error
+		// that includes the segment index and offset.
+		if s.reader.Next() {
+			return true
+		}
+		err := s.reader.Err()
+		if err != nil {
+			s.err = err
+			return false
+		}
+
+		err = s.Close()
+		if err != nil {
+			s.err = err
+			return false
+		}
+		s.position++
+	}
+}
+
+// Err returns the error that made the reader stop.
+func (s *SegmentsReader) Err() error {
+	return s.err
+}
+
+// Record returns the current record. It is only valid until the next call to
+// `Next`.
+func (s *SegmentsReader) Record() []byte {
+	return s.reader.Record()
+}
+
+// Skip discards the rest of the current segment and resets the error, so
+// that reading can resume with the next segment.
+func (s *SegmentsReader) Skip() error {
+	err := s.Close()
+	if err != nil {
+		return err
+	}
+	s.position++
+	s.err = nil
+	return nil
+}
+
+// Close closes the currently open segment.
+func (s *SegmentsReader) Close() error {
+	if s.segment == nil {
+		return nil
+	}
+	err := s.segment.Close()
+	if err != nil {
+		return fmt.Errorf("could not close segment (index: %d): %w", s.indices[s.position], err)
+	}
+	s.segment = nil
+	s.reader = nil
+	return nil
+}
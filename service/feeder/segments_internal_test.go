@@ -0,0 +1,142 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package feeder
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/prometheus/tsdb/wal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSegmentsReader(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(wal.SegmentName(dir, 4), nil, 0600))
+		require.NoError(t, os.WriteFile(wal.SegmentName(dir, 3), nil, 0600))
+		require.NoError(t, os.WriteFile(dir+"/checkpoint.00000002", nil, 0600))
+
+		reader, err := NewSegmentsReader(dir)
+
+		require.NoError(t, err)
+		assert.Equal(t, dir, reader.dir)
+		assert.Equal(t, []int{3, 4}, reader.indices)
+	})
+
+	t.Run("handles missing directory", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewSegmentsReader("/does/not/exist")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestSegmentsReader_Next(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		// Use segments of a single page, so that records are spread over
+		// multiple segments.
+		dir := t.TempDir()
+		writer, err := wal.NewSize(nil, nil, dir, 32*1024)
+		require.NoError(t, err)
+
+		var records [][]byte
+		for i := 0; i < 8; i++ {
+			record := bytes.Repeat([]byte{byte(i)}, 10_000)
+			records = append(records, record)
+			require.NoError(t, writer.Log(record))
+		}
+		require.NoError(t, writer.Close())
+
+		reader, err := NewSegmentsReader(dir)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		for _, record := range records {
+			require.True(t, reader.Next())
+			assert.Equal(t, record, reader.Record())
+		}
+		assert.False(t, reader.Next())
+		assert.NoError(t, reader.Err())
+	})
+
+	t.Run("handles empty directory", func(t *testing.T) {
+		t.Parallel()
+
+		reader, err := NewSegmentsReader(t.TempDir())
+		require.NoError(t, err)
+
+		assert.False(t, reader.Next())
+		assert.NoError(t, reader.Err())
+	})
+
+	t.Run("handles corrupted record", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		data := frame([]byte("corrupted record"))
+		data[len(data)-1] ^= 0xff
+
+		require.NoError(t, os.WriteFile(wal.SegmentName(dir, 0), data, 0600))
+
+		reader, err := NewSegmentsReader(dir)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		assert.False(t, reader.Next())
+
+		var corruption *wal.CorruptionErr
+		require.ErrorAs(t, reader.Err(), &corruption)
+		assert.Equal(t, 0, corruption.Segment)
+		assert.False(t, reader.Next())
+	})
+}
+
+func TestSegmentsReader_Skip(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		corrupted := frame([]byte("corrupted record"))
+		corrupted[len(corrupted)-1] ^= 0xff
+		skipped := frame([]byte("skipped record"))
+		record := []byte("valid record")
+
+		require.NoError(t, os.WriteFile(wal.SegmentName(dir, 0), append(corrupted, skipped...), 0600))
+		require.NoError(t, os.WriteFile(wal.SegmentName(dir, 1), frame(record), 0600))
+
+		reader, err := NewSegmentsReader(dir)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		require.False(t, reader.Next())
+		require.Error(t, reader.Err())
+
+		err = reader.Skip()
+
+		require.NoError(t, err)
+		require.True(t, reader.Next())
+		assert.Equal(t, record, reader.Record())
+		assert.False(t, reader.Next())
+		assert.NoError(t, reader.Err())
+	})
+}
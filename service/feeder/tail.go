@@ -0,0 +1,225 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package feeder
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/prometheus/tsdb/wal"
+	"github.com/rs/zerolog"
+
+	"github.com/optakt/flow-dps/service/logs"
+)
+
+// TailReader is a write-ahead log reader that follows a directory of WAL
+// segments while it is still being written to, such as the ledger WAL of a
+// running execution node. Instead of failing when reaching the end of the
+// written data, it waits for more records, and it moves on to the next
+// segment once the writer has created it.
+type TailReader struct {
+	log     zerolog.Logger
+	dir     string
+	index   int
+	segment *os.File
+	reader  *wal.LiveReader
+	err     error
+}
+
+// NewTailReader creates a new reader that follows the WAL segments in the
+// given directory, starting with the lowest segment that is present.
+func NewTailReader(log zerolog.Logger, dir string) (*TailReader, error) {
+
+	index, err := firstSegment(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not find first segment: %w", err)
+	}
+
+	t := TailReader{
+		log:   log.With().Str(logs.Component, "tail_reader").Logger(),
+		dir:   dir,
+		index: index,
+	}
+
+	return &t, nil
+}
+
+// Next returns true if a full record is available. If it returns false and
+// `Err` returns nil, no complete record was written yet, and `Next` can be
+// called again later.
+func (t *TailReader) Next() bool {
+
+	if t.err != nil {
+		return false
+	}
+
+	for {
+
+		// If we have not opened the current segment yet, we try to do so now.
+		// If it doesn't exist yet, we have to wait for the writer to create it.
+		if t.reader == nil {
+			segment, err := os.Open(wal.SegmentName(t.dir, t.index))
+			if errors.Is(err, os.ErrNotExist) {
+				return false
+			}
+			if err != nil {
+				t.err = fmt.Errorf("could not open segment (index: %d): %w", t.index, err)
+				return false
+			}
+			t.segment = segment
+			t.reader = wal.NewLiveReader(walLogger{log: t.log}, segment)
+			t.log.Debug().Int("index", t.index).Msg("opened next segment")
+		}
+
+		// The writer only creates the next segment after it has finished
+		// writing the current one, so we need to check for its existence
+		// before we read the current segment for the last time. Otherwise, we
+		// might miss records that were written in the meantime.
+		_, err := os.Stat(wal.SegmentName(t.dir, t.index+1))
+		finished := err == nil
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			t.err = fmt.Errorf("could not check next segment (index: %d): %w", t.index+1, err)
+			return false
+		}
+
+		// If we can read a full record, we are done. The live reader keeps
+		// partially written records in its buffer, so a truncated record at
+		// the tail of the segment will be completed on a later call.
+		if t.reader.Next() {
+			return true
+		}
+		err = t.reader.Err()
+		if err != nil && err != io.EOF {
+			t.err = &wal.CorruptionErr{Dir: t.dir, Segment: t.index, Offset: t.reader.Offset(), Err: err}
+			return false
+		}
+
+		// If the next segment doesn't exist yet, we have reached the end of
+		// the written data and need to wait for more.
+		if !finished {
+			return false
+		}
+
+		// Otherwise, the current segment is complete and we can switch to the
+		// next one.
+		err = t.segment.Close()
+		if err != nil {
+			t.err = fmt.Errorf("could not close segment (index: %d): %w", t.index, err)
+			return false
+		}
+		t.index++
+		t.segment = nil
+		t.reader = nil
+	}
+}
+
+// Err returns the error that made the reader stop. Once it returns an error,
+// the reader should not be used anymore, unless it is skipped past the
+// corrupted segment with `Skip`.
+func (t *TailReader) Err() error {
+	return t.err
+}
+
+// Skip discards the rest of the current segment and resets the error, so
+// that reading can resume with the next segment once it exists.
+func (t *TailReader) Skip() error {
+	err := t.Close()
+	if err != nil {
+		return err
+	}
+	t.index++
+	t.err = nil
+	return nil
+}
+
+// Record returns the current record. It is only valid until the next call to
+// `Next`.
+func (t *TailReader) Record() []byte {
+	return t.reader.Record()
+}
+
+// Close closes the currently open segment.
+func (t *TailReader) Close() error {
+	if t.segment == nil {
+		return nil
+	}
+	err := t.segment.Close()
+	if err != nil {
+		return fmt.Errorf("could not close segment (index: %d): %w", t.index, err)
+	}
+	t.segment = nil
+	t.reader = nil
+	return nil
+}
+
+// firstSegment returns the lowest segment index in the given directory. If
+// there is no segment yet, it returns zero, which is the index of the first
+// segment the writer will create.
+func firstSegment(dir string) (int, error) {
+
+	indices, err := listSegments(dir)
+	if err != nil {
+		return 0, err
+	}
+	if len(indices) == 0 {
+		return 0, nil
+	}
+
+	return indices[0], nil
+}
+
+// listSegments returns the sorted indices of the segments in the given
+// directory.
+func listSegments(dir string) ([]int, error) {
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read directory: %w", err)
+	}
+
+	// Besides segments, the directory can also contain checkpoint files, which
+	// don't have a purely numerical name.
+	var indices []int
+	for _, entry := range entries {
+		index, err := strconv.Atoi(entry.Name())
+		if err != nil || entry.IsDir() {
+			continue
+		}
+		indices = append(indices, index)
+	}
+
+	sort.Ints(indices)
+
+	return indices, nil
+}
+
+// walLogger adapts a zerolog logger to the key-value logging interface used
+// by the WAL live reader.
+type walLogger struct {
+	log zerolog.Logger
+}
+
+func (w walLogger) Log(keyvals ...interface{}) error {
+	event := w.log.Warn()
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		event = event.Interface(fmt.Sprint(keyvals[i]), keyvals[i+1])
+	}
+	event.Msg("live reader warning")
+	return nil
+}
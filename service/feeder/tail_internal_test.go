@@ -0,0 +1,191 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package feeder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"testing"
+
+	"github.com/prometheus/tsdb/wal"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTailReader(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(wal.SegmentName(dir, 3), nil, 0600))
+		require.NoError(t, os.WriteFile(wal.SegmentName(dir, 4), nil, 0600))
+		require.NoError(t, os.WriteFile(dir+"/checkpoint.00000002", nil, 0600))
+
+		reader, err := NewTailReader(zerolog.Nop(), dir)
+
+		require.NoError(t, err)
+		assert.Equal(t, dir, reader.dir)
+		assert.Equal(t, 3, reader.index)
+	})
+
+	t.Run("handles empty directory", func(t *testing.T) {
+		t.Parallel()
+
+		reader, err := NewTailReader(zerolog.Nop(), t.TempDir())
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, reader.index)
+	})
+
+	t.Run("handles missing directory", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewTailReader(zerolog.Nop(), "/does/not/exist")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestTailReader_Next(t *testing.T) {
+	t.Run("follows segments while they are written", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		reader, err := NewTailReader(zerolog.Nop(), dir)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		// Nothing was written yet, so we should wait without error.
+		assert.False(t, reader.Next())
+		assert.NoError(t, reader.Err())
+
+		// Use segments of a single page, so that records are spread over
+		// multiple segments.
+		writer, err := wal.NewSize(nil, nil, dir, 32*1024)
+		require.NoError(t, err)
+		defer writer.Close()
+
+		var records [][]byte
+		for i := 0; i < 8; i++ {
+			record := bytes.Repeat([]byte{byte(i)}, 10_000)
+			records = append(records, record)
+			require.NoError(t, writer.Log(record))
+		}
+
+		for _, record := range records {
+			require.True(t, reader.Next())
+			assert.Equal(t, record, reader.Record())
+		}
+		assert.False(t, reader.Next())
+		assert.NoError(t, reader.Err())
+		assert.Greater(t, reader.index, 0)
+
+		// Records written later should still be picked up.
+		record := []byte("late record")
+		require.NoError(t, writer.Log(record))
+
+		require.True(t, reader.Next())
+		assert.Equal(t, record, reader.Record())
+	})
+
+	t.Run("waits for truncated tail record", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		record := []byte("truncated record")
+		data := frame(record)
+
+		segment, err := os.Create(wal.SegmentName(dir, 0))
+		require.NoError(t, err)
+		defer segment.Close()
+		_, err = segment.Write(data[:10])
+		require.NoError(t, err)
+
+		reader, err := NewTailReader(zerolog.Nop(), dir)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		assert.False(t, reader.Next())
+		assert.NoError(t, reader.Err())
+
+		_, err = segment.Write(data[10:])
+		require.NoError(t, err)
+
+		require.True(t, reader.Next())
+		assert.Equal(t, record, reader.Record())
+	})
+
+	t.Run("handles corrupted record", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		data := frame([]byte("corrupted record"))
+		data[len(data)-1] ^= 0xff
+
+		require.NoError(t, os.WriteFile(wal.SegmentName(dir, 0), data, 0600))
+
+		reader, err := NewTailReader(zerolog.Nop(), dir)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		assert.False(t, reader.Next())
+		assert.Error(t, reader.Err())
+		assert.False(t, reader.Next())
+	})
+}
+
+func TestTailReader_Skip(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		corrupted := frame([]byte("corrupted record"))
+		corrupted[len(corrupted)-1] ^= 0xff
+		record := []byte("valid record")
+
+		require.NoError(t, os.WriteFile(wal.SegmentName(dir, 0), corrupted, 0600))
+
+		reader, err := NewTailReader(zerolog.Nop(), dir)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		require.False(t, reader.Next())
+		require.Error(t, reader.Err())
+
+		err = reader.Skip()
+
+		// The next segment doesn't exist yet, so we should wait for it.
+		require.NoError(t, err)
+		assert.False(t, reader.Next())
+		assert.NoError(t, reader.Err())
+
+		require.NoError(t, os.WriteFile(wal.SegmentName(dir, 1), frame(record), 0600))
+
+		require.True(t, reader.Next())
+		assert.Equal(t, record, reader.Record())
+	})
+}
+
+// frame returns the given record framed as a full WAL record.
+func frame(record []byte) []byte {
+	header := make([]byte, 7)
+	header[0] = 1 // full record type
+	binary.BigEndian.PutUint16(header[1:], uint16(len(record)))
+	binary.BigEndian.PutUint32(header[3:], crc32.Checksum(record, crc32.MakeTable(crc32.Castagnoli)))
+	return append(header, record...)
+}
@@ -0,0 +1,50 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package forest
+
+// DefaultConfig is the default configuration of the forest.
+var DefaultConfig = Config{
+	Limit:   0,
+	Metrics: nil,
+}
+
+// Config is the configuration of the forest.
+type Config struct {
+	Limit   uint
+	Metrics *Metrics
+}
+
+// Option is an option that can be given to the forest to configure it.
+type Option func(*Config)
+
+// WithLimit sets the maximum number of tries that the forest retains. Once it
+// is reached, the least recently used tries are evicted, which keeps the
+// memory usage bounded when sealing lags behind finalization and the execution
+// state keeps growing branches that are never indexed. The limit needs to be
+// large enough to hold all of the tries between two finalized blocks, or the
+// registers of a block can no longer be collected. Zero disables the limit.
+func WithLimit(limit uint) Option {
+	return func(cfg *Config) {
+		cfg.Limit = limit
+	}
+}
+
+// WithMetrics makes the forest expose the number of tries it retains and the
+// number of tries it evicted with the given metrics.
+func WithMetrics(metrics *Metrics) Option {
+	return func(cfg *Config) {
+		cfg.Metrics = metrics
+	}
+}
@@ -15,6 +15,8 @@
 package forest
 
 import (
+	"container/list"
+
 	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
 	"github.com/onflow/flow-go/model/flow"
@@ -24,30 +26,59 @@ type step struct {
 	tree   *trie.MTrie
 	paths  []ledger.Path
 	parent flow.StateCommitment
+	used   *list.Element
 }
 
 // Forest is a representation of multiple tries mapped by their state commitment hash.
 type Forest struct {
-	steps map[flow.StateCommitment]step
+	cfg   Config
+	steps map[flow.StateCommitment]*step
+	order *list.List // state commitments, from most to least recently used
+	base  flow.StateCommitment
 }
 
 // New returns a new empty forest.
-func New() *Forest {
+func New(options ...Option) *Forest {
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
+
 	f := Forest{
-		steps: make(map[flow.StateCommitment]step),
+		cfg:   cfg,
+		steps: make(map[flow.StateCommitment]*step),
+		order: list.New(),
+		base:  flow.DummyStateCommitment,
 	}
+
 	return &f
 }
 
-// Save adds a tree to the forest.
+// Save adds a tree to the forest. If the forest already holds its maximum
+// number of trees, the least recently used trees are evicted. The first tree
+// saved into an empty forest is its base, from which all other trees derive,
+// so it is never evicted.
 func (f *Forest) Save(tree *trie.MTrie, paths []ledger.Path, parent flow.StateCommitment) {
 	commit := flow.StateCommitment(tree.RootHash())
-	s := step{
+	if len(f.steps) == 0 {
+		f.base = commit
+	}
+
+	s, ok := f.steps[commit]
+	if ok {
+		f.order.Remove(s.used)
+	}
+	s = &step{
 		tree:   tree,
 		paths:  paths,
 		parent: parent,
+		used:   f.order.PushFront(commit),
 	}
 	f.steps[commit] = s
+
+	f.evict()
+	f.observe()
 }
 
 // Has returns whether a state commitment matches one of the trees within the forest.
@@ -56,12 +87,14 @@ func (f *Forest) Has(commit flow.StateCommitment) bool {
 	return ok
 }
 
-// Tree returns the matching tree for the given state commitment.
+// Tree returns the matching tree for the given state commitment, and marks it
+// as the most recently used tree of the forest.
 func (f *Forest) Tree(commit flow.StateCommitment) (*trie.MTrie, bool) {
 	s, ok := f.steps[commit]
 	if !ok {
 		return nil, false
 	}
+	f.order.MoveToFront(s.used)
 	return s.tree, true
 }
 
@@ -83,11 +116,46 @@ func (f *Forest) Parent(commit flow.StateCommitment) (flow.StateCommitment, bool
 	return s.parent, true
 }
 
-// Reset deletes all tries that do not match the given state commitment.
+// Reset deletes all tries that do not match the given state commitment, which
+// becomes the new base of the forest.
 func (f *Forest) Reset(finalized flow.StateCommitment) {
-	for commit := range f.steps {
+	for commit, s := range f.steps {
 		if commit != finalized {
+			f.order.Remove(s.used)
 			delete(f.steps, commit)
 		}
 	}
+	f.base = finalized
+	f.observe()
+}
+
+// evict deletes the least recently used tries until the forest holds no more
+// than its maximum number of tries. The base of the forest is skipped, as the
+// tries for the heights that were not yet indexed derive from it.
+func (f *Forest) evict() {
+	if f.cfg.Limit == 0 {
+		return
+	}
+
+	element := f.order.Back()
+	for uint(len(f.steps)) > f.cfg.Limit && element != nil {
+		previous := element.Prev()
+		commit := element.Value.(flow.StateCommitment)
+		if commit != f.base {
+			f.order.Remove(element)
+			delete(f.steps, commit)
+			if f.cfg.Metrics != nil {
+				f.cfg.Metrics.evictions.Inc()
+			}
+		}
+		element = previous
+	}
+}
+
+// observe updates the metrics with the number of tries in the forest.
+func (f *Forest) observe() {
+	if f.cfg.Metrics == nil {
+		return
+	}
+	f.cfg.Metrics.tries.Set(float64(len(f.steps)))
 }
@@ -0,0 +1,128 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package forest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestForest_Save(t *testing.T) {
+	trees := genericTrees(t, 4)
+	commits := make([]flow.StateCommitment, 0, len(trees))
+	for _, tree := range trees {
+		commits = append(commits, flow.StateCommitment(tree.RootHash()))
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		f := New()
+		for i, tree := range trees {
+			f.Save(tree, nil, commits[0])
+			assert.True(t, f.Has(commits[i]))
+		}
+
+		assert.Len(t, f.steps, len(trees))
+	})
+
+	t.Run("evicts least recently used trees", func(t *testing.T) {
+		t.Parallel()
+
+		f := New(WithLimit(3))
+		f.Save(trees[0], nil, flow.DummyStateCommitment)
+		f.Save(trees[1], nil, commits[0])
+		f.Save(trees[2], nil, commits[0])
+
+		// Using the first child makes the second one the least recently used
+		// tree that can be evicted.
+		_, ok := f.Tree(commits[1])
+		require.True(t, ok)
+
+		f.Save(trees[3], nil, commits[1])
+
+		assert.Len(t, f.steps, 3)
+		assert.Equal(t, 3, f.order.Len())
+		assert.True(t, f.Has(commits[0]))
+		assert.True(t, f.Has(commits[1]))
+		assert.False(t, f.Has(commits[2]))
+		assert.True(t, f.Has(commits[3]))
+	})
+
+	t.Run("does not evict base tree", func(t *testing.T) {
+		t.Parallel()
+
+		f := New(WithLimit(2))
+		f.Save(trees[0], nil, flow.DummyStateCommitment)
+		f.Save(trees[1], nil, commits[0])
+		f.Save(trees[2], nil, commits[1])
+
+		assert.Len(t, f.steps, 2)
+		assert.True(t, f.Has(commits[0]))
+		assert.False(t, f.Has(commits[1]))
+		assert.True(t, f.Has(commits[2]))
+	})
+}
+
+func TestForest_Reset(t *testing.T) {
+	trees := genericTrees(t, 3)
+	commits := make([]flow.StateCommitment, 0, len(trees))
+	for _, tree := range trees {
+		commits = append(commits, flow.StateCommitment(tree.RootHash()))
+	}
+
+	f := New(WithLimit(2))
+	f.Save(trees[0], nil, flow.DummyStateCommitment)
+	f.Save(trees[1], nil, commits[0])
+
+	f.Reset(commits[1])
+
+	assert.Len(t, f.steps, 1)
+	assert.Equal(t, 1, f.order.Len())
+	assert.Equal(t, commits[1], f.base)
+
+	// The tree kept on reset becomes the base of the forest, so it is not
+	// evicted when the limit is reached.
+	f.Save(trees[0], nil, commits[1])
+	f.Save(trees[2], nil, commits[1])
+
+	assert.True(t, f.Has(commits[1]))
+	assert.False(t, f.Has(commits[0]))
+	assert.True(t, f.Has(commits[2]))
+}
+
+func genericTrees(t *testing.T, number int) []*trie.MTrie {
+	t.Helper()
+
+	paths := mocks.GenericLedgerPaths(number)
+	payloads := mocks.GenericLedgerPayloads(number)
+
+	trees := make([]*trie.MTrie, 0, number)
+	for i := 0; i < number; i++ {
+		tree, err := trie.NewTrieWithUpdatedRegisters(trie.NewEmptyMTrie(), []ledger.Path{paths[i]}, []ledger.Payload{*payloads[i]})
+		require.NoError(t, err)
+		trees = append(trees, tree)
+	}
+
+	return trees
+}
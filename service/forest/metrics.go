@@ -0,0 +1,49 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package forest
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics are the prometheus metrics that expose the memory usage of the
+// forest.
+type Metrics struct {
+	tries     prometheus.Gauge
+	evictions prometheus.Counter
+}
+
+// NewMetrics creates and registers the metrics of the forest.
+func NewMetrics() *Metrics {
+	triesOpts := prometheus.GaugeOpts{
+		Name: "forest_tries",
+		Help: "number of execution state tries retained in the forest",
+	}
+	tries := promauto.NewGauge(triesOpts)
+
+	evictionsOpts := prometheus.CounterOpts{
+		Name: "forest_evictions",
+		Help: "number of execution state tries evicted from the forest",
+	}
+	evictions := promauto.NewCounter(evictionsOpts)
+
+	m := Metrics{
+		tries:     tries,
+		evictions: evictions,
+	}
+
+	return &m
+}
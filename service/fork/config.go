@@ -0,0 +1,42 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package fork
+
+import (
+	"github.com/rs/zerolog"
+)
+
+// Config is the configuration for a fork.
+type Config struct {
+	Log      zerolog.Logger
+	Validate bool
+}
+
+// WithLogger sets the logger that transactions log their Cadence log output
+// and failures to.
+func WithLogger(log zerolog.Logger) func(*Config) {
+	return func(cfg *Config) {
+		cfg.Log = log
+	}
+}
+
+// WithValidation specifies whether the signatures and sequence numbers of
+// transactions are checked. It is disabled by default, so that transactions
+// can be executed on behalf of any account on the fork without its keys.
+func WithValidation(validate bool) func(*Config) {
+	return func(cfg *Config) {
+		cfg.Validate = validate
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package fork
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go/engine/execution/state/delta"
+	"github.com/onflow/flow-go/fvm"
+	"github.com/onflow/flow-go/fvm/programs"
+	"github.com/onflow/flow-go/fvm/state"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/execution"
+)
+
+// VirtualMachine represents a Flow Virtual Machine on which to run
+// transactions and scripts.
+type VirtualMachine interface {
+	Run(ctx fvm.Context, proc fvm.Procedure, v state.View, programs *programs.Programs) error
+}
+
+// Fork executes transactions locally on top of the execution state of the
+// index at a given height. The registers written by transactions are kept in
+// memory, so that later transactions and scripts see them, while the index
+// itself is never modified. This lets developers try out transactions against
+// a copy of the real state of the network, without needing its keys.
+type Fork struct {
+	sync.Mutex
+	vm       VirtualMachine
+	vctx     fvm.Context
+	view     *delta.View
+	programs *programs.Programs
+	height   uint64
+	count    uint32
+}
+
+// New returns a new fork of the execution state of the index at the given
+// height.
+func New(index dps.Reader, height uint64, options ...func(*Config)) (*Fork, error) {
+
+	cfg := Config{
+		Log:      zerolog.Nop(),
+		Validate: false,
+	}
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	// The state adapter fails on reads of registers which are excluded from
+	// the index, so that transactions never run on partial state.
+	exec, err := execution.NewState(index)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize execution state: %w", err)
+	}
+
+	ctx := context.Background()
+	header, err := index.Header(ctx, height)
+	if err != nil {
+		return nil, fmt.Errorf("could not get header (height: %d): %w", height, err)
+	}
+
+	// Unless validation is enabled, we skip the processors which check the
+	// signatures and the sequence numbers of transactions, so that they can
+	// use any account as proposer, payer or authorizer.
+	processors := []fvm.TransactionProcessor{
+		fvm.NewTransactionAccountFrozenChecker(),
+		fvm.NewTransactionAccountFrozenEnabler(),
+		fvm.NewTransactionInvocator(cfg.Log),
+	}
+	if cfg.Validate {
+		processors = []fvm.TransactionProcessor{
+			fvm.NewTransactionAccountFrozenChecker(),
+			fvm.NewTransactionSignatureVerifier(fvm.AccountKeyWeightThreshold),
+			fvm.NewTransactionSequenceNumberChecker(),
+			fvm.NewTransactionAccountFrozenEnabler(),
+			fvm.NewTransactionInvocator(cfg.Log),
+		}
+	}
+
+	vctx := fvm.NewContext(cfg.Log,
+		fvm.WithChain(header.ChainID.Chain()),
+		fvm.WithBlockHeader(header),
+		fvm.WithCadenceLogging(true),
+		fvm.WithTransactionProcessors(processors...),
+	)
+
+	rt := fvm.NewInterpreterRuntime()
+	vm := fvm.NewVirtualMachine(rt)
+
+	f := Fork{
+		vm:       vm,
+		vctx:     vctx,
+		view:     exec.View(ctx, height),
+		programs: programs.NewEmptyPrograms(),
+		height:   height,
+	}
+
+	return &f, nil
+}
+
+// Height returns the height of the index the fork is based on.
+func (f *Fork) Height() uint64 {
+	return f.height
+}
+
+// Execute executes the given transaction on the fork. The returned procedure
+// holds the events and logs of the transaction, as well as its error if it
+// failed; an error is only returned if the transaction could not be executed
+// at all.
+func (f *Fork) Execute(tx *flow.TransactionBody) (*fvm.TransactionProcedure, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	proc := fvm.Transaction(tx, f.count)
+	err := f.vm.Run(f.vctx, proc, f.view, f.programs)
+	if err != nil {
+		return nil, fmt.Errorf("could not run transaction: %w", err)
+	}
+
+	f.count++
+
+	return proc, nil
+}
+
+// Script executes the given Cadence script on the fork and returns its result.
+// Scripts run on a child view, so registers they write are discarded.
+func (f *Fork) Script(script []byte, arguments [][]byte) (cadence.Value, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	proc := fvm.Script(script).WithArguments(arguments...)
+	err := f.vm.Run(f.vctx, proc, f.view.NewChild(), f.programs)
+	if err != nil {
+		return nil, fmt.Errorf("could not run script: %w", err)
+	}
+	if proc.Err != nil {
+		return nil, fmt.Errorf("script execution encountered error: %w", proc.Err)
+	}
+
+	return proc.Value, nil
+}
+
+// Delta returns the registers written on the fork since it was created or
+// last reset.
+func (f *Fork) Delta() delta.Delta {
+	f.Lock()
+	defer f.Unlock()
+
+	return f.view.Delta()
+}
+
+// Reset discards all registers written on the fork, so that it goes back to
+// the execution state of the index at its height.
+func (f *Fork) Reset() {
+	f.Lock()
+	defer f.Unlock()
+
+	f.view.DropDelta()
+	f.programs = programs.NewEmptyPrograms()
+	f.count = 0
+}
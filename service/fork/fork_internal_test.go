@@ -0,0 +1,176 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package fork
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go/engine/execution/state/delta"
+	"github.com/onflow/flow-go/fvm"
+	"github.com/onflow/flow-go/fvm/errors"
+	"github.com/onflow/flow-go/fvm/programs"
+	"github.com/onflow/flow-go/fvm/state"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+
+		f, err := New(index, mocks.GenericHeight, WithValidation(true))
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, f.Height())
+		assert.Len(t, f.vctx.TransactionProcessors, 5)
+		assert.Equal(t, mocks.GenericHeader, f.vctx.BlockHeader)
+	})
+
+	t.Run("skips validation by default", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+
+		f, err := New(index, mocks.GenericHeight)
+
+		require.NoError(t, err)
+		assert.Len(t, f.vctx.TransactionProcessors, 3)
+	})
+
+	t.Run("handles index failure", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.HeaderFunc = func(context.Context, uint64) (*flow.Header, error) {
+			return nil, mocks.GenericError
+		}
+
+		_, err := New(index, mocks.GenericHeight)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestFork_Execute(t *testing.T) {
+	tx := mocks.GenericTransaction(0)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		vm := mocks.BaselineVirtualMachine(t)
+		vm.RunFunc = func(_ fvm.Context, proc fvm.Procedure, v state.View, _ *programs.Programs) error {
+			return v.Set("owner", "controller", "key", mocks.GenericBytes)
+		}
+
+		f := baselineFork(t)
+		f.vm = vm
+
+		first, err := f.Execute(tx)
+		require.NoError(t, err)
+		second, err := f.Execute(tx)
+		require.NoError(t, err)
+
+		assert.Equal(t, tx.ID(), first.ID)
+		assert.Equal(t, uint32(0), first.TxIndex)
+		assert.Equal(t, uint32(1), second.TxIndex)
+
+		value, ok := f.Delta().Get("owner", "controller", "key")
+		assert.True(t, ok)
+		assert.Equal(t, mocks.GenericBytes, value[:])
+
+		f.Reset()
+
+		_, ok = f.Delta().Get("owner", "controller", "key")
+		assert.False(t, ok)
+	})
+
+	t.Run("handles virtual machine failure", func(t *testing.T) {
+		t.Parallel()
+
+		vm := mocks.BaselineVirtualMachine(t)
+		vm.RunFunc = func(fvm.Context, fvm.Procedure, state.View, *programs.Programs) error {
+			return mocks.GenericError
+		}
+
+		f := baselineFork(t)
+		f.vm = vm
+
+		_, err := f.Execute(tx)
+
+		assert.Error(t, err)
+		assert.Equal(t, uint32(0), f.count)
+	})
+}
+
+func TestFork_Script(t *testing.T) {
+	t.Run("nominal case discards writes", func(t *testing.T) {
+		t.Parallel()
+
+		vm := mocks.BaselineVirtualMachine(t)
+		vm.RunFunc = func(_ fvm.Context, proc fvm.Procedure, v state.View, _ *programs.Programs) error {
+			proc.(*fvm.ScriptProcedure).Value = cadence.NewInt(42)
+			return v.Set("owner", "controller", "key", mocks.GenericBytes)
+		}
+
+		f := baselineFork(t)
+		f.vm = vm
+
+		got, err := f.Script(mocks.GenericBytes, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, cadence.NewInt(42), got)
+		assert.Empty(t, f.Delta().Data)
+	})
+
+	t.Run("handles script failure", func(t *testing.T) {
+		t.Parallel()
+
+		vm := mocks.BaselineVirtualMachine(t)
+		vm.RunFunc = func(_ fvm.Context, proc fvm.Procedure, _ state.View, _ *programs.Programs) error {
+			proc.(*fvm.ScriptProcedure).Err = errors.NewFVMInternalErrorf("dummy error")
+			return nil
+		}
+
+		f := baselineFork(t)
+		f.vm = vm
+
+		_, err := f.Script(mocks.GenericBytes, nil)
+
+		assert.Error(t, err)
+	})
+}
+
+func baselineFork(t *testing.T) *Fork {
+	t.Helper()
+
+	f := Fork{
+		vm:       mocks.BaselineVirtualMachine(t),
+		vctx:     fvm.NewContext(zerolog.Nop()),
+		view:     delta.NewView(delta.AlwaysEmptyGetRegisterFunc),
+		programs: programs.NewEmptyPrograms(),
+		height:   mocks.GenericHeight,
+	}
+
+	return &f
+}
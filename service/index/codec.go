@@ -0,0 +1,54 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package index
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Codec returns the name of the codec with which the values of the index in
+// the given database are stored, or an empty name if the index was not created
+// yet.
+func Codec(db *badger.DB, lib dps.ReadLibrary) (string, error) {
+
+	var name string
+	err := db.View(lib.RetrieveCodec(&name))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not retrieve codec: %w", err)
+	}
+
+	return name, nil
+}
+
+// SaveCodec records the name of the codec with which the values of the index
+// in the given database are stored. It should only be called when the index
+// is created, as the values already stored are not converted.
+func SaveCodec(db *badger.DB, lib dps.WriteLibrary, name string) error {
+
+	err := db.Update(lib.SaveCodec(name))
+	if err != nil {
+		return fmt.Errorf("could not save codec: %w", err)
+	}
+
+	return nil
+}
@@ -22,12 +22,24 @@ import (
 var DefaultConfig = Config{
 	ConcurrentTransactions: 16,          // same value as used for batches in badger
 	FlushInterval:          time.Second, // maximum idle time before flushing transaction
+	EncodingWorkers:        4,           // goroutines encoding values before they are applied
+	QueueSize:              64,          // calls waiting to be applied before the writer blocks
+	PathFilters:            false,
+	Fence:                  0,
+	Scheduler:              nil,
+	Heights:                nil,
 }
 
 // Config is the configuration of a DPS index.
 type Config struct {
 	ConcurrentTransactions uint
 	FlushInterval          time.Duration
+	EncodingWorkers        uint
+	QueueSize              uint
+	PathFilters            bool
+	Fence                  uint64
+	Scheduler              *Scheduler
+	Heights                *Heights
 }
 
 // WithConcurrentTransactions specifies the maximum concurrent transactions
@@ -46,3 +58,57 @@ func WithFlushInterval(interval time.Duration) func(*Config) {
 		cfg.FlushInterval = interval
 	}
 }
+
+// WithEncodingWorkers sets the number of goroutines on which a DPS index writer
+// encodes and compresses the values it writes. At least one is always used.
+func WithEncodingWorkers(workers uint) func(*Config) {
+	return func(cfg *Config) {
+		cfg.EncodingWorkers = workers
+	}
+}
+
+// WithQueueSize sets the number of calls to a DPS index writer whose
+// operations can wait to be applied. Once the queue is full, calls to the
+// writer block until there is room again.
+func WithQueueSize(size uint) func(*Config) {
+	return func(cfg *Config) {
+		cfg.QueueSize = size
+	}
+}
+
+// WithPathFilters enables the path filters of a DPS index. Writers build a
+// bloom filter over the paths written in each window of heights, and readers
+// use them to skip the lookup of registers that were never written.
+func WithPathFilters(enabled bool) func(*Config) {
+	return func(cfg *Config) {
+		cfg.PathFilters = enabled
+	}
+}
+
+// WithFence sets the token of the fence acquired for a DPS index writer. The
+// writer then checks the fence in each of its transactions, and stops writing
+// as soon as the fence is taken over by another writer. Zero disables fencing.
+func WithFence(token uint64) func(*Config) {
+	return func(cfg *Config) {
+		cfg.Fence = token
+	}
+}
+
+// WithScheduler sets the scheduler that gives the reads of a DPS index reader
+// priority over the transactions committed by a DPS index writer. The reader
+// and the writer have to share the same scheduler. Nil disables scheduling.
+func WithScheduler(scheduler *Scheduler) func(*Config) {
+	return func(cfg *Config) {
+		cfg.Scheduler = scheduler
+	}
+}
+
+// WithHeights sets the cache for the first and last heights of a DPS index.
+// Readers serve the heights from the cache, and writers invalidate it when
+// they commit new heights, so the readers and the writer of an index have to
+// share the same cache. Nil disables caching.
+func WithHeights(heights *Heights) func(*Config) {
+	return func(cfg *Config) {
+		cfg.Heights = heights
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package index
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// AcquireFence acquires the fence of the given index database for the given
+// holder, and returns the token that index writers should be given with
+// `WithFence`. The fence makes sure that no two instances write to the same
+// index at the same time, for example after a botched failover, as their
+// writes would interleave and corrupt the index.
+//
+// It fails with `dps.ErrFenced` if another writer holds the fence, unless
+// forced to take it over. Taking over is only safe when the other writer is
+// known to have stopped without releasing the fence, such as after a crash,
+// or when a standby instance replaces the one it followed. Taking over the
+// fence gives out a higher token, so that any write of the previous holder
+// fails with `dps.ErrFenced` instead of corrupting the index.
+func AcquireFence(db *badger.DB, lib dps.WriteLibrary, holder string, force bool) (uint64, error) {
+
+	var fence dps.Fence
+	err := db.Update(lib.AcquireFence(holder, force, &fence))
+	if err != nil {
+		return 0, fmt.Errorf("could not acquire fence: %w", err)
+	}
+
+	return fence.Token, nil
+}
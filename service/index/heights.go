@@ -0,0 +1,91 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package index
+
+import (
+	"sync"
+)
+
+// Heights caches the first and last indexed heights of a DPS index, which are
+// read by nearly every API request. Readers that share it only read the heights
+// from the index when they are not cached, and writers that share it invalidate
+// them whenever a transaction that updates them was committed. Failed reads are
+// not cached, so that they are retried on the next call.
+type Heights struct {
+	mutex      *sync.RWMutex
+	generation uint64
+	first      *uint64
+	last       *uint64
+}
+
+// NewHeights creates a new cache for the first and last heights of an index.
+func NewHeights() *Heights {
+
+	h := Heights{
+		mutex: &sync.RWMutex{},
+	}
+
+	return &h
+}
+
+// First returns the cached first height, or reads it with the given function
+// and caches it if it is not cached.
+func (h *Heights) First(read func() (uint64, error)) (uint64, error) {
+	return h.get(&h.first, read)
+}
+
+// Last returns the cached last height, or reads it with the given function and
+// caches it if it is not cached.
+func (h *Heights) Last(read func() (uint64, error)) (uint64, error) {
+	return h.get(&h.last, read)
+}
+
+// Invalidate discards the cached heights, so that they are read again on the
+// next call.
+func (h *Heights) Invalidate() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.generation++
+	h.first = nil
+	h.last = nil
+}
+
+func (h *Heights) get(cached **uint64, read func() (uint64, error)) (uint64, error) {
+
+	h.mutex.RLock()
+	if *cached != nil {
+		height := **cached
+		h.mutex.RUnlock()
+		return height, nil
+	}
+	generation := h.generation
+	h.mutex.RUnlock()
+
+	height, err := read()
+	if err != nil {
+		return 0, err
+	}
+
+	// If the heights were invalidated while we were reading, the height we
+	// read might already be outdated, so we do not cache it.
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.generation == generation {
+		*cached = &height
+	}
+
+	return height, nil
+}
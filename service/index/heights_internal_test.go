@@ -0,0 +1,120 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package index
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestHeights(t *testing.T) {
+
+	// counter returns a read function that returns the given height, along
+	// with the number of times it was called.
+	counter := func(height uint64) (func() (uint64, error), *int) {
+		calls := 0
+		read := func() (uint64, error) {
+			calls++
+			return height, nil
+		}
+		return read, &calls
+	}
+
+	t.Run("caches heights", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewHeights()
+		first, firstCalls := counter(mocks.GenericHeight)
+		last, lastCalls := counter(mocks.GenericHeight + 1)
+
+		for i := 0; i < 3; i++ {
+			got, err := h.First(first)
+			require.NoError(t, err)
+			assert.Equal(t, mocks.GenericHeight, got)
+
+			got, err = h.Last(last)
+			require.NoError(t, err)
+			assert.Equal(t, mocks.GenericHeight+1, got)
+		}
+
+		assert.Equal(t, 1, *firstCalls)
+		assert.Equal(t, 1, *lastCalls)
+	})
+
+	t.Run("reads heights again after invalidation", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewHeights()
+		first, firstCalls := counter(mocks.GenericHeight)
+		last, lastCalls := counter(mocks.GenericHeight + 1)
+
+		_, err := h.First(first)
+		require.NoError(t, err)
+		_, err = h.Last(last)
+		require.NoError(t, err)
+
+		h.Invalidate()
+
+		_, err = h.First(first)
+		require.NoError(t, err)
+		_, err = h.Last(last)
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, *firstCalls)
+		assert.Equal(t, 2, *lastCalls)
+	})
+
+	t.Run("does not cache failed reads", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewHeights()
+		failing := func() (uint64, error) {
+			return 0, mocks.GenericError
+		}
+		last, calls := counter(mocks.GenericHeight)
+
+		_, err := h.Last(failing)
+		assert.Error(t, err)
+
+		got, err := h.Last(last)
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, got)
+		assert.Equal(t, 1, *calls)
+	})
+
+	t.Run("does not cache heights read during invalidation", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewHeights()
+		stale := func() (uint64, error) {
+			h.Invalidate()
+			return mocks.GenericHeight, nil
+		}
+		last, calls := counter(mocks.GenericHeight + 1)
+
+		got, err := h.Last(stale)
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, got)
+
+		got, err = h.Last(last)
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight+1, got)
+		assert.Equal(t, 1, *calls)
+	})
+}
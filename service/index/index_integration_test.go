@@ -18,15 +18,18 @@
 package index_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/dgraph-io/badger/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/model/flow"
 
 	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
 	"github.com/optakt/flow-dps/service/index"
 	"github.com/optakt/flow-dps/service/storage"
 	"github.com/optakt/flow-dps/testing/helpers"
@@ -40,11 +43,11 @@ func TestIndex(t *testing.T) {
 		reader, writer, db := setupIndex(t)
 		defer db.Close()
 
-		assert.NoError(t, writer.First(mocks.GenericHeight))
+		assert.NoError(t, writer.First(context.Background(), mocks.GenericHeight))
 		// Close the writer to make it commit its transactions.
 		require.NoError(t, writer.Close())
 
-		got, err := reader.First()
+		got, err := reader.First(context.Background())
 
 		require.NoError(t, err)
 		assert.Equal(t, mocks.GenericHeight, got)
@@ -56,16 +59,45 @@ func TestIndex(t *testing.T) {
 		reader, writer, db := setupIndex(t)
 		defer db.Close()
 
-		assert.NoError(t, writer.Last(mocks.GenericHeight))
+		assert.NoError(t, writer.Last(context.Background(), mocks.GenericHeight))
 		// Close the writer to make it commit its transactions.
 		require.NoError(t, writer.Close())
 
-		got, err := reader.Last()
+		got, err := reader.Last(context.Background())
 
 		require.NoError(t, err)
 		assert.Equal(t, mocks.GenericHeight, got)
 	})
 
+	t.Run("cached heights", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		lib := storage.New(zbor.NewCodec())
+		heights := index.NewHeights()
+		reader := index.NewReader(db, lib, index.WithHeights(heights))
+
+		writer := index.NewWriter(db, lib, index.WithHeights(heights))
+		assert.NoError(t, writer.Last(context.Background(), mocks.GenericHeight))
+		require.NoError(t, writer.Close())
+
+		got, err := reader.Last(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, got)
+
+		// Once the new last height was committed, the cached one is no longer
+		// served.
+		writer = index.NewWriter(db, lib, index.WithHeights(heights))
+		assert.NoError(t, writer.Last(context.Background(), mocks.GenericHeight+1))
+		require.NoError(t, writer.Close())
+
+		got, err = reader.Last(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight+1, got)
+	})
+
 	t.Run("height", func(t *testing.T) {
 		t.Parallel()
 
@@ -73,11 +105,11 @@ func TestIndex(t *testing.T) {
 		defer db.Close()
 
 		blockID := mocks.GenericHeader.ID()
-		assert.NoError(t, writer.Height(blockID, mocks.GenericHeight))
+		assert.NoError(t, writer.Height(context.Background(), blockID, mocks.GenericHeight))
 		// Close the writer to make it commit its transactions.
 		require.NoError(t, writer.Close())
 
-		got, err := reader.HeightForBlock(blockID)
+		got, err := reader.HeightForBlock(context.Background(), blockID)
 
 		require.NoError(t, err)
 		assert.Equal(t, mocks.GenericHeight, got)
@@ -89,14 +121,38 @@ func TestIndex(t *testing.T) {
 		reader, writer, db := setupIndex(t)
 		defer db.Close()
 
-		assert.NoError(t, writer.Commit(mocks.GenericHeight, mocks.GenericCommit(0)))
+		assert.NoError(t, writer.Commit(context.Background(), mocks.GenericHeight, mocks.GenericCommit(0)))
+		assert.NoError(t, writer.Commit(context.Background(), mocks.GenericHeight+1, mocks.GenericCommit(0)))
+		assert.NoError(t, writer.Commit(context.Background(), mocks.GenericHeight+2, mocks.GenericCommit(1)))
 		// Close the writer to make it commit its transactions.
 		require.NoError(t, writer.Close())
 
-		got, err := reader.Commit(mocks.GenericHeight)
+		// NOTE: The following subtests should NOT be run in parallel, because of the deferral
+		// to close the database above.
+		t.Run("retrieve commit", func(t *testing.T) {
+			got, err := reader.Commit(context.Background(), mocks.GenericHeight)
 
-		require.NoError(t, err)
-		assert.Equal(t, mocks.GenericCommit(0), got)
+			require.NoError(t, err)
+			assert.Equal(t, mocks.GenericCommit(0), got)
+		})
+
+		t.Run("retrieve first height for commit", func(t *testing.T) {
+			got, err := reader.HeightForCommit(context.Background(), mocks.GenericCommit(0))
+
+			require.NoError(t, err)
+			assert.Equal(t, mocks.GenericHeight, got)
+
+			got, err = reader.HeightForCommit(context.Background(), mocks.GenericCommit(1))
+
+			require.NoError(t, err)
+			assert.Equal(t, mocks.GenericHeight+2, got)
+		})
+
+		t.Run("missing height for commit", func(t *testing.T) {
+			_, err := reader.HeightForCommit(context.Background(), mocks.GenericCommit(2))
+
+			assert.ErrorIs(t, err, dps.ErrNotIndexed)
+		})
 	})
 
 	t.Run("header", func(t *testing.T) {
@@ -105,11 +161,11 @@ func TestIndex(t *testing.T) {
 		reader, writer, db := setupIndex(t)
 		defer db.Close()
 
-		assert.NoError(t, writer.Header(mocks.GenericHeight, mocks.GenericHeader))
+		assert.NoError(t, writer.Header(context.Background(), mocks.GenericHeight, mocks.GenericHeader))
 		// Close the writer to make it commit its transactions.
 		require.NoError(t, writer.Close())
 
-		got, err := reader.Header(mocks.GenericHeight)
+		got, err := reader.Header(context.Background(), mocks.GenericHeight)
 
 		require.NoError(t, err)
 		assert.Equal(t, mocks.GenericHeader, got)
@@ -125,18 +181,155 @@ func TestIndex(t *testing.T) {
 		payloads := mocks.GenericLedgerPayloads(4)
 		values := mocks.GenericLedgerValues(4)
 
-		assert.NoError(t, writer.First(mocks.GenericHeight))
-		assert.NoError(t, writer.Last(mocks.GenericHeight))
-		assert.NoError(t, writer.Payloads(mocks.GenericHeight, paths, payloads))
+		assert.NoError(t, writer.First(context.Background(), mocks.GenericHeight))
+		assert.NoError(t, writer.Last(context.Background(), mocks.GenericHeight))
+		assert.NoError(t, writer.Payloads(context.Background(), mocks.GenericHeight, paths, payloads))
 		// Close the writer to make it commit its transactions.
 		require.NoError(t, writer.Close())
 
-		got, err := reader.Values(mocks.GenericHeight, paths)
+		got, err := reader.Values(context.Background(), mocks.GenericHeight, paths)
 
 		require.NoError(t, err)
 		assert.ElementsMatch(t, values, got)
 	})
 
+	t.Run("registers", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer, db := setupIndex(t)
+		defer db.Close()
+
+		owner := flow.BytesToAddress([]byte(`owner`))
+		paths := mocks.GenericLedgerPaths(4)
+		payloads := mocks.GenericLedgerPayloads(4)
+		deleted := ledger.NewPayload(mocks.GenericLedgerKey, nil)
+
+		assert.NoError(t, writer.First(context.Background(), mocks.GenericHeight))
+		assert.NoError(t, writer.Payloads(context.Background(), mocks.GenericHeight, paths, payloads))
+		assert.NoError(t, writer.Payloads(context.Background(), mocks.GenericHeight+1, paths[:1], []*ledger.Payload{deleted}))
+		assert.NoError(t, writer.Last(context.Background(), mocks.GenericHeight+1))
+		// Close the writer to make it commit its transactions.
+		require.NoError(t, writer.Close())
+
+		t.Run("list all registers", func(t *testing.T) {
+			got, err := reader.Registers(context.Background(), mocks.GenericHeight, owner, ledger.Path{}, 10)
+
+			require.NoError(t, err)
+			assert.Len(t, got, 4)
+		})
+
+		t.Run("list registers in pages", func(t *testing.T) {
+			first, err := reader.Registers(context.Background(), mocks.GenericHeight, owner, ledger.Path{}, 2)
+			require.NoError(t, err)
+			require.Len(t, first, 2)
+
+			second, err := reader.Registers(context.Background(), mocks.GenericHeight, owner, first[1].Path, 2)
+			require.NoError(t, err)
+			require.Len(t, second, 2)
+
+			var got []ledger.Path
+			for _, register := range append(first, second...) {
+				got = append(got, register.Path)
+			}
+			assert.ElementsMatch(t, paths, got)
+		})
+
+		t.Run("skip deleted registers", func(t *testing.T) {
+			got, err := reader.Registers(context.Background(), mocks.GenericHeight+1, owner, ledger.Path{}, 10)
+
+			require.NoError(t, err)
+			assert.Len(t, got, 3)
+			for _, register := range got {
+				assert.NotEqual(t, paths[0], register.Path)
+			}
+		})
+
+		t.Run("list registers of other owner", func(t *testing.T) {
+			got, err := reader.Registers(context.Background(), mocks.GenericHeight, mocks.GenericAddress(0), ledger.Path{}, 10)
+
+			require.NoError(t, err)
+			assert.Empty(t, got)
+		})
+	})
+
+	t.Run("register churn", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer, db := setupIndex(t)
+		defer db.Close()
+
+		churns := mocks.GenericRegisterChurns(3)
+		for _, churn := range churns {
+			assert.NoError(t, writer.RegisterChurn(context.Background(), churn))
+		}
+		// Close the writer to make it commit its transactions.
+		require.NoError(t, writer.Close())
+
+		t.Run("report top writers", func(t *testing.T) {
+			report, err := reader.RegisterChurn(context.Background(), churns[0].Height, churns[1].Height, 1)
+
+			require.NoError(t, err)
+			assert.Equal(t, churns[0].Height, report.StartHeight)
+			assert.Equal(t, churns[1].Height, report.EndHeight)
+			assert.Equal(t, uint64(5), report.Writes)
+			assert.Equal(t, churns[1].Owners, report.Owners)
+			assert.Equal(t, churns[1].Registers[1:], report.Registers)
+		})
+
+		t.Run("invalid height range", func(t *testing.T) {
+			_, err := reader.RegisterChurn(context.Background(), churns[1].Height, churns[0].Height, 1)
+
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer, db := setupIndex(t)
+		defer db.Close()
+
+		owner := flow.BytesToAddress([]byte(`owner`))
+		paths := mocks.GenericLedgerPaths(4)
+		payloads := mocks.GenericLedgerPayloads(4)
+		values := mocks.GenericLedgerValues(4)
+
+		assert.NoError(t, writer.First(context.Background(), mocks.GenericHeight))
+		assert.NoError(t, writer.Last(context.Background(), mocks.GenericHeight))
+		assert.NoError(t, writer.Payloads(context.Background(), mocks.GenericHeight, paths, payloads))
+		// Close the writer to make it commit its transactions.
+		require.NoError(t, writer.Close())
+
+		snapshot, discard := reader.Snapshot()
+		defer discard()
+
+		// Overwrite the registers of the height after the snapshot was taken,
+		// as when staged heights are rolled back and indexed again.
+		deleted := make([]*ledger.Payload, 0, len(paths))
+		for range paths {
+			deleted = append(deleted, ledger.NewPayload(mocks.GenericLedgerKey, nil))
+		}
+		overwrite := index.NewWriter(db, storage.New(zbor.NewCodec()))
+		assert.NoError(t, overwrite.Payloads(context.Background(), mocks.GenericHeight, paths, deleted))
+		require.NoError(t, overwrite.Close())
+
+		t.Run("snapshot reads are not affected by later writes", func(t *testing.T) {
+			got, err := snapshot.Values(context.Background(), mocks.GenericHeight, paths)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, values, got)
+
+			registers, err := snapshot.Registers(context.Background(), mocks.GenericHeight, owner, ledger.Path{}, 10)
+			require.NoError(t, err)
+			assert.Len(t, registers, 4)
+		})
+
+		t.Run("reads outside of the snapshot see later writes", func(t *testing.T) {
+			registers, err := reader.Registers(context.Background(), mocks.GenericHeight, owner, ledger.Path{}, 10)
+			require.NoError(t, err)
+			assert.Empty(t, registers)
+		})
+	})
+
 	t.Run("collections", func(t *testing.T) {
 		t.Parallel()
 
@@ -145,21 +338,21 @@ func TestIndex(t *testing.T) {
 		reader, writer, db := setupIndex(t)
 		defer db.Close()
 
-		assert.NoError(t, writer.Collections(mocks.GenericHeight, collections))
+		assert.NoError(t, writer.Collections(context.Background(), mocks.GenericHeight, collections))
 		// Close the writer to make it commit its transactions.
 		require.NoError(t, writer.Close())
 
 		// NOTE: The following subtests should NOT be run in parallel, because of the deferral
 		// to close the database above.
 		t.Run("retrieve collection by ID", func(t *testing.T) {
-			got, err := reader.Collection(collections[0].ID())
+			got, err := reader.Collection(context.Background(), collections[0].ID())
 
 			require.NoError(t, err)
 			assert.Equal(t, collections[0], got)
 		})
 
 		t.Run("retrieve collections by height", func(t *testing.T) {
-			got, err := reader.CollectionsByHeight(mocks.GenericHeight)
+			got, err := reader.CollectionsByHeight(context.Background(), mocks.GenericHeight)
 
 			require.NoError(t, err)
 			assert.ElementsMatch(t, mocks.GenericCollectionIDs(4), got)
@@ -176,12 +369,12 @@ func TestIndex(t *testing.T) {
 		reader, writer, db := setupIndex(t)
 		defer db.Close()
 
-		assert.NoError(t, writer.Guarantees(mocks.GenericHeight, mocks.GenericGuarantees(4)))
+		assert.NoError(t, writer.Guarantees(context.Background(), mocks.GenericHeight, mocks.GenericGuarantees(4)))
 		// Close the writer to make it commit its transactions.
 		require.NoError(t, writer.Close())
 
 		guarantee := mocks.GenericGuarantee(0)
-		got, err := reader.Guarantee(guarantee.ID())
+		got, err := reader.Guarantee(context.Background(), guarantee.ID())
 
 		require.NoError(t, err)
 		assert.Equal(t, guarantee, got)
@@ -201,32 +394,40 @@ func TestIndex(t *testing.T) {
 			transactions[3].ID(),
 		}
 
-		assert.NoError(t, writer.Transactions(mocks.GenericHeight, transactions))
+		assert.NoError(t, writer.Transactions(context.Background(), mocks.GenericHeight, transactions))
 		// Close the writer to make it commit its transactions.
 		require.NoError(t, writer.Close())
 
 		// NOTE: The following subtests should NOT be run in parallel, because of the deferral
 		// to close the database above.
 		t.Run("retrieve transactions by height", func(t *testing.T) {
-			gotTxIDs, err := reader.TransactionsByHeight(mocks.GenericHeight)
+			gotTxIDs, err := reader.TransactionsByHeight(context.Background(), mocks.GenericHeight)
 
 			require.NoError(t, err)
 			assert.ElementsMatch(t, txIDs, gotTxIDs)
 		})
 
 		t.Run("retrieve transaction by ID", func(t *testing.T) {
-			gotTx, err := reader.Transaction(transactions[0].ID())
+			gotTx, err := reader.Transaction(context.Background(), transactions[0].ID())
 
 			require.NoError(t, err)
 			assert.Equal(t, transactions[0], gotTx)
 		})
 
 		t.Run("retrieve height for transaction", func(t *testing.T) {
-			gotTx, err := reader.HeightForTransaction(transactions[0].ID())
+			gotTx, err := reader.HeightForTransaction(context.Background(), transactions[0].ID())
 
 			require.NoError(t, err)
 			assert.Equal(t, mocks.GenericHeight, gotTx)
 		})
+
+		t.Run("retrieve transactions for script", func(t *testing.T) {
+			hash := dps.ScriptHash(transactions[0].Script)
+			gotTxIDs, err := reader.TransactionsForScript(context.Background(), hash, mocks.GenericHeight, mocks.GenericHeight)
+
+			require.NoError(t, err)
+			assert.Equal(t, txIDs, gotTxIDs)
+		})
 	})
 
 	t.Run("results", func(t *testing.T) {
@@ -237,11 +438,11 @@ func TestIndex(t *testing.T) {
 
 		results := mocks.GenericResults(4)
 
-		assert.NoError(t, writer.Results(results))
+		assert.NoError(t, writer.Results(context.Background(), results))
 		// Close the writer to make it commit its transactions.
 		require.NoError(t, writer.Close())
 
-		got, err := reader.Result(results[0].TransactionID)
+		got, err := reader.Result(context.Background(), results[0].TransactionID)
 
 		require.NoError(t, err)
 		assert.Equal(t, results[0], got)
@@ -259,28 +460,28 @@ func TestIndex(t *testing.T) {
 		deposits := mocks.GenericEvents(2, depositType)
 		events := append(withdrawals, deposits...)
 
-		assert.NoError(t, writer.First(mocks.GenericHeight))
-		assert.NoError(t, writer.Last(mocks.GenericHeight))
-		assert.NoError(t, writer.Events(mocks.GenericHeight, events))
+		assert.NoError(t, writer.First(context.Background(), mocks.GenericHeight))
+		assert.NoError(t, writer.Last(context.Background(), mocks.GenericHeight))
+		assert.NoError(t, writer.Events(context.Background(), mocks.GenericHeight, events))
 		// Close the writer to make it commit its transactions.
 		require.NoError(t, writer.Close())
 
 		// NOTE: The following subtests should NOT be run in parallel, because of the deferral
 		// to close the database above.
 		t.Run("no types specified", func(t *testing.T) {
-			got, err := reader.Events(mocks.GenericHeight)
+			got, err := reader.Events(context.Background(), mocks.GenericHeight)
 
 			require.NoError(t, err)
 			assert.ElementsMatch(t, events, got)
 		})
 
 		t.Run("type specified", func(t *testing.T) {
-			got1, err := reader.Events(mocks.GenericHeight, withdrawalType)
+			got1, err := reader.Events(context.Background(), mocks.GenericHeight, withdrawalType)
 
 			require.NoError(t, err)
 			assert.Len(t, got1, 2)
 
-			got2, err := reader.Events(mocks.GenericHeight, depositType)
+			got2, err := reader.Events(context.Background(), mocks.GenericHeight, depositType)
 
 			require.NoError(t, err)
 			assert.Len(t, got1, 2)
@@ -289,6 +490,233 @@ func TestIndex(t *testing.T) {
 		})
 	})
 
+	t.Run("filter", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer, db := setupIndex(t)
+		defer db.Close()
+
+		addresses := mocks.GenericAddresses(2)
+		filter := dps.Filter{
+			Allowed: addresses[:1],
+			Denied:  addresses[1:],
+		}
+
+		assert.NoError(t, writer.First(context.Background(), mocks.GenericHeight))
+		assert.NoError(t, writer.Last(context.Background(), mocks.GenericHeight))
+		assert.NoError(t, writer.Filter(context.Background(), filter))
+		// Close the writer to make it commit its transactions.
+		require.NoError(t, writer.Close())
+
+		// NOTE: The following subtests should NOT be run in parallel, because of the deferral
+		// to close the database above.
+		t.Run("retrieve filter", func(t *testing.T) {
+			got, err := reader.Filter(context.Background())
+
+			require.NoError(t, err)
+			assert.Equal(t, filter, got)
+		})
+
+		t.Run("missing transaction is not indexed", func(t *testing.T) {
+			_, err := reader.Transaction(context.Background(), mocks.GenericTransaction(0).ID())
+
+			assert.ErrorIs(t, err, dps.ErrNotIndexed)
+		})
+
+		t.Run("events of denied contract are not indexed", func(t *testing.T) {
+			typ := flow.EventType("A." + addresses[1].Hex() + ".Contract.Event")
+
+			_, err := reader.Events(context.Background(), mocks.GenericHeight, typ)
+
+			assert.ErrorIs(t, err, dps.ErrNotIndexed)
+		})
+
+		t.Run("account of denied owner is not indexed", func(t *testing.T) {
+			_, err := reader.Account(context.Background(), addresses[1])
+
+			assert.ErrorIs(t, err, dps.ErrNotIndexed)
+		})
+	})
+
+	t.Run("empty filter", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer, db := setupIndex(t)
+		defer db.Close()
+
+		// Close the writer to make it commit its transactions.
+		require.NoError(t, writer.Close())
+
+		got, err := reader.Filter(context.Background())
+		require.NoError(t, err)
+		assert.True(t, got.Empty())
+
+		_, err = reader.Transaction(context.Background(), mocks.GenericTransaction(0).ID())
+		assert.ErrorIs(t, err, dps.ErrNotIndexed)
+	})
+
+	t.Run("accounts", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer, db := setupIndex(t)
+		defer db.Close()
+
+		addresses := mocks.GenericAddresses(3)
+
+		assert.NoError(t, writer.Accounts(context.Background(), mocks.GenericHeight, addresses[:2]))
+		assert.NoError(t, writer.KeyUpdates(context.Background(), mocks.GenericHeight, addresses[:1]))
+		assert.NoError(t, writer.KeyUpdates(context.Background(), mocks.GenericHeight+1, []flow.Address{addresses[0], addresses[0], addresses[2]}))
+		// Close the writer to make it commit its transactions.
+		require.NoError(t, writer.Close())
+
+		// NOTE: The following subtests should NOT be run in parallel, because of the deferral
+		// to close the database above.
+		t.Run("retrieve account with key updates", func(t *testing.T) {
+			got, err := reader.Account(context.Background(), addresses[0])
+
+			require.NoError(t, err)
+			assert.Equal(t, addresses[0], got.Address)
+			assert.Equal(t, mocks.GenericHeight, got.Created)
+			assert.Equal(t, []uint64{mocks.GenericHeight, mocks.GenericHeight + 1}, got.KeyUpdates)
+		})
+
+		t.Run("retrieve account without key updates", func(t *testing.T) {
+			got, err := reader.Account(context.Background(), addresses[1])
+
+			require.NoError(t, err)
+			assert.Equal(t, mocks.GenericHeight, got.Created)
+			assert.Empty(t, got.KeyUpdates)
+		})
+
+		t.Run("retrieve account created before indexing", func(t *testing.T) {
+			got, err := reader.Account(context.Background(), addresses[2])
+
+			require.NoError(t, err)
+			assert.Zero(t, got.Created)
+			assert.Equal(t, []uint64{mocks.GenericHeight + 1}, got.KeyUpdates)
+		})
+	})
+
+	t.Run("contracts", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer, db := setupIndex(t)
+		defer db.Close()
+
+		address := mocks.GenericAddress(0)
+		versions := mocks.GenericContractVersions(address, "Contract", 3)
+		versions[2].Height = versions[1].Height
+		versions[2].Removed = true
+		others := mocks.GenericContractVersions(address, "Other", 1)
+
+		assert.NoError(t, writer.Contracts(context.Background(), versions[0].Height, []dps.ContractVersion{versions[0], others[0]}))
+		assert.NoError(t, writer.Contracts(context.Background(), versions[1].Height, versions[1:]))
+		// Close the writer to make it commit its transactions.
+		require.NoError(t, writer.Close())
+
+		got, err := reader.ContractHistory(context.Background(), address, "Contract")
+
+		require.NoError(t, err)
+		assert.Equal(t, versions, got)
+	})
+
+	t.Run("service events", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer, db := setupIndex(t)
+		defer db.Close()
+
+		setupType := mocks.GenericEventType(0)
+		commitType := mocks.GenericEventType(1)
+		setup := mocks.GenericEvents(1, setupType)
+		commit := mocks.GenericEvents(1, commitType)
+
+		assert.NoError(t, writer.ServiceEvents(context.Background(), mocks.GenericHeight, setup))
+		assert.NoError(t, writer.ServiceEvents(context.Background(), mocks.GenericHeight+1, commit))
+		// Close the writer to make it commit its transactions.
+		require.NoError(t, writer.Close())
+
+		// NOTE: The following subtests should NOT be run in parallel, because of the deferral
+		// to close the database above.
+		t.Run("no types specified", func(t *testing.T) {
+			got, err := reader.ServiceEvents(context.Background(), mocks.GenericHeight, mocks.GenericHeight+1)
+
+			require.NoError(t, err)
+			want := []dps.ServiceEvent{
+				{Height: mocks.GenericHeight, Event: setup[0]},
+				{Height: mocks.GenericHeight + 1, Event: commit[0]},
+			}
+			assert.Equal(t, want, got)
+		})
+
+		t.Run("type specified", func(t *testing.T) {
+			got, err := reader.ServiceEvents(context.Background(), mocks.GenericHeight, mocks.GenericHeight+1, commitType)
+
+			require.NoError(t, err)
+			assert.Equal(t, []dps.ServiceEvent{{Height: mocks.GenericHeight + 1, Event: commit[0]}}, got)
+		})
+
+		t.Run("height range", func(t *testing.T) {
+			got, err := reader.ServiceEvents(context.Background(), mocks.GenericHeight, mocks.GenericHeight)
+
+			require.NoError(t, err)
+			assert.Equal(t, []dps.ServiceEvent{{Height: mocks.GenericHeight, Event: setup[0]}}, got)
+		})
+
+		t.Run("invalid height range", func(t *testing.T) {
+			_, err := reader.ServiceEvents(context.Background(), mocks.GenericHeight+1, mocks.GenericHeight)
+
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("epochs", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer, db := setupIndex(t)
+		defer db.Close()
+
+		setup := mocks.GenericEpochSetup(1)
+
+		assert.NoError(t, writer.Epoch(context.Background(), setup))
+		assert.NoError(t, writer.Phase(context.Background(), setup.Counter, flow.EpochPhaseSetup, mocks.GenericHeight))
+		assert.NoError(t, writer.Phase(context.Background(), setup.Counter, flow.EpochPhaseCommitted, mocks.GenericHeight+1))
+		// Close the writer to make it commit its transactions.
+		require.NoError(t, writer.Close())
+
+		// NOTE: The following subtests should NOT be run in parallel, because of the deferral
+		// to close the database above.
+		t.Run("retrieve epochs", func(t *testing.T) {
+			got, err := reader.Epochs(context.Background())
+
+			require.NoError(t, err)
+			want := []dps.Epoch{{
+				Counter:            setup.Counter,
+				FirstView:          setup.FirstView,
+				DKGPhase1FinalView: setup.DKGPhase1FinalView,
+				DKGPhase2FinalView: setup.DKGPhase2FinalView,
+				DKGPhase3FinalView: setup.DKGPhase3FinalView,
+				FinalView:          setup.FinalView,
+				SetupHeight:        mocks.GenericHeight,
+				CommittedHeight:    mocks.GenericHeight + 1,
+			}}
+			assert.Equal(t, want, got)
+		})
+
+		t.Run("retrieve identities", func(t *testing.T) {
+			got, err := reader.Identities(context.Background(), setup.Counter)
+
+			require.NoError(t, err)
+			assert.Equal(t, setup.Participants, got)
+		})
+
+		t.Run("missing identities", func(t *testing.T) {
+			_, err := reader.Identities(context.Background(), setup.Counter+1)
+
+			assert.Error(t, err)
+		})
+	})
+
 	t.Run("seals", func(t *testing.T) {
 		t.Parallel()
 
@@ -297,26 +725,539 @@ func TestIndex(t *testing.T) {
 
 		seals := mocks.GenericSeals(4)
 
-		assert.NoError(t, writer.Seals(mocks.GenericHeight, seals))
+		assert.NoError(t, writer.Seals(context.Background(), mocks.GenericHeight, seals))
 		// Close the writer to make it commit its transactions.
 		require.NoError(t, writer.Close())
 
 		// NOTE: The following subtests should NOT be run in parallel, because of the deferral
 		// to close the database above.
 		t.Run("retrieve seal by ID", func(t *testing.T) {
-			got, err := reader.Seal(seals[0].ID())
+			got, err := reader.Seal(context.Background(), seals[0].ID())
 
 			require.NoError(t, err)
 			assert.Equal(t, seals[0], got)
 		})
 
 		t.Run("retrieve seals by height", func(t *testing.T) {
-			got, err := reader.SealsByHeight(mocks.GenericHeight)
+			got, err := reader.SealsByHeight(context.Background(), mocks.GenericHeight)
 
 			require.NoError(t, err)
 			assert.ElementsMatch(t, got, mocks.GenericSealIDs(4))
 		})
 	})
+
+	t.Run("path filters", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		lib := storage.New(zbor.NewCodec())
+		writer := index.NewWriter(db, lib, index.WithConcurrentTransactions(4), index.WithPathFilters(true))
+
+		paths := mocks.GenericLedgerPaths(4)
+		payloads := mocks.GenericLedgerPayloads(4)
+		values := mocks.GenericLedgerValues(4)
+
+		// We index two heights at the end of one window and the first height of
+		// the next window.
+		first := uint64(2*dps.PathFilterWindow - 2)
+		assert.NoError(t, writer.Payloads(context.Background(), first, paths[:1], payloads[:1]))
+		assert.NoError(t, writer.First(context.Background(), first))
+		assert.NoError(t, writer.Last(context.Background(), first))
+		assert.NoError(t, writer.Payloads(context.Background(), first+1, paths[1:2], payloads[1:2]))
+		assert.NoError(t, writer.Last(context.Background(), first+1))
+		assert.NoError(t, writer.Payloads(context.Background(), first+2, paths[2:3], payloads[2:3]))
+		assert.NoError(t, writer.Last(context.Background(), first+2))
+		// Close the writer to make it commit its transactions.
+		require.NoError(t, writer.Close())
+
+		// A payload indexed without path filters is not part of the filters,
+		// so readers using them don't find it.
+		other := index.NewWriter(db, lib, index.WithConcurrentTransactions(4))
+		assert.NoError(t, other.Payloads(context.Background(), first, paths[3:], payloads[3:]))
+		require.NoError(t, other.Close())
+
+		t.Run("written registers are found", func(t *testing.T) {
+			reader := index.NewReader(db, lib, index.WithPathFilters(true))
+
+			got, err := reader.Values(context.Background(), first+2, paths[:3])
+
+			require.NoError(t, err)
+			assert.Equal(t, values[:3], got)
+		})
+
+		t.Run("unwritten registers are skipped", func(t *testing.T) {
+			reader := index.NewReader(db, lib, index.WithPathFilters(true))
+
+			got, err := reader.Values(context.Background(), first+2, paths[3:])
+
+			require.NoError(t, err)
+			assert.Equal(t, []ledger.Value{nil}, got)
+		})
+
+		t.Run("registers are looked up without path filters", func(t *testing.T) {
+			reader := index.NewReader(db, lib)
+
+			got, err := reader.Values(context.Background(), first+2, paths[3:])
+
+			require.NoError(t, err)
+			assert.Equal(t, values[3:], got)
+		})
+	})
+
+	t.Run("incomplete path filters", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		lib := storage.New(zbor.NewCodec())
+
+		// The first writer indexes the first height without path filters.
+		first := uint64(2*dps.PathFilterWindow - 2)
+		writer := index.NewWriter(db, lib, index.WithConcurrentTransactions(4))
+		assert.NoError(t, writer.Payloads(context.Background(), first, mocks.GenericLedgerPaths(1), mocks.GenericLedgerPayloads(1)))
+		assert.NoError(t, writer.First(context.Background(), first))
+		assert.NoError(t, writer.Last(context.Background(), first))
+		require.NoError(t, writer.Close())
+
+		// The second writer resumes from the next height with path filters, so
+		// the filter of the window does not cover the first height.
+		other := index.NewWriter(db, lib, index.WithConcurrentTransactions(4), index.WithPathFilters(true))
+		assert.NoError(t, other.Last(context.Background(), first+1))
+		require.NoError(t, other.Close())
+
+		reader := index.NewReader(db, lib, index.WithPathFilters(true))
+
+		got, err := reader.Values(context.Background(), first+1, mocks.GenericLedgerPaths(1))
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericLedgerValues(1), got)
+	})
+
+	t.Run("height batch", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer, db := setupIndex(t)
+		defer db.Close()
+
+		commit := mocks.GenericCommit(0)
+		paths := mocks.GenericLedgerPaths(4)
+		payloads := mocks.GenericLedgerPayloads(4)
+		values := mocks.GenericLedgerValues(4)
+		types := []dps.EventTypeStats{{
+			Type:  mocks.GenericEventType(0),
+			First: mocks.GenericHeight,
+			Last:  mocks.GenericHeight,
+			Count: 4,
+		}}
+
+		data := dps.HeightData{
+			Height:       mocks.GenericHeight,
+			BlockID:      mocks.GenericHeader.ID(),
+			Header:       mocks.GenericHeader,
+			Seals:        mocks.GenericSeals(2),
+			Commit:       &commit,
+			Transactions: mocks.GenericTransactions(2),
+			EventTypes:   types,
+			Paths:        paths,
+			Payloads:     payloads,
+			Complete:     true,
+			First:        true,
+			Last:         true,
+		}
+		assert.NoError(t, writer.WriteHeightBatch(context.Background(), data))
+		// Close the writer to make it commit its transactions.
+		require.NoError(t, writer.Close())
+
+		last, err := reader.Last(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, last)
+
+		height, err := reader.HeightForBlock(context.Background(), mocks.GenericHeader.ID())
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, height)
+
+		header, err := reader.Header(context.Background(), mocks.GenericHeight)
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeader, header)
+
+		got, err := reader.Commit(context.Background(), mocks.GenericHeight)
+		require.NoError(t, err)
+		assert.Equal(t, commit, got)
+
+		txIDs, err := reader.TransactionsByHeight(context.Background(), mocks.GenericHeight)
+		require.NoError(t, err)
+		assert.Len(t, txIDs, 2)
+
+		sealIDs, err := reader.SealsByHeight(context.Background(), mocks.GenericHeight)
+		require.NoError(t, err)
+		assert.Len(t, sealIDs, 2)
+
+		registers, err := reader.Values(context.Background(), mocks.GenericHeight, paths)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, values, registers)
+
+		stats, err := reader.EventTypes(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, types, stats)
+	})
+
+	t.Run("height batch with mismatched payloads", func(t *testing.T) {
+		t.Parallel()
+
+		_, writer, db := setupIndex(t)
+		defer db.Close()
+
+		data := dps.HeightData{
+			Height:   mocks.GenericHeight,
+			Paths:    mocks.GenericLedgerPaths(2),
+			Payloads: mocks.GenericLedgerPayloads(1),
+			Last:     true,
+		}
+		assert.Error(t, writer.WriteHeightBatch(context.Background(), data))
+		require.NoError(t, writer.Close())
+	})
+
+	t.Run("height batch too big for a transaction", func(t *testing.T) {
+		t.Parallel()
+
+		// With small tables, badger only fits a few dozen entries into each
+		// transaction, so the registers below have to be split.
+		opts := badger.DefaultOptions("").WithInMemory(true).WithLogger(nil).WithMaxTableSize(64 << 10)
+		db, err := badger.Open(opts)
+		require.NoError(t, err)
+		defer db.Close()
+
+		lib := storage.New(zbor.NewCodec(), storage.WithSizeStats(true))
+		writer := index.NewWriter(db, lib)
+
+		height := mocks.GenericHeight + 1
+		paths := mocks.GenericLedgerPaths(200)
+		payloads := mocks.GenericLedgerPayloads(200)
+		values := make([]ledger.Value, 0, len(payloads))
+		for _, payload := range payloads {
+			values = append(values, payload.Value)
+		}
+
+		previous := dps.HeightData{
+			Height:   mocks.GenericHeight,
+			BlockID:  mocks.GenericHeader.ID(),
+			Header:   mocks.GenericHeader,
+			Complete: true,
+			First:    true,
+			Last:     true,
+		}
+		assert.NoError(t, writer.WriteHeightBatch(context.Background(), previous))
+		require.NoError(t, writer.Close())
+		sizes := lib.SizeStats()
+		writer = index.NewWriter(db, lib)
+
+		// A height that does not fit within a single transaction is not
+		// committed at all, so the index stays at the previous height.
+		data := dps.HeightData{
+			Height:   height,
+			BlockID:  mocks.GenericHeader.ID(),
+			Header:   mocks.GenericHeader,
+			Paths:    paths,
+			Payloads: payloads,
+			Complete: true,
+			Last:     true,
+		}
+		assert.NoError(t, writer.WriteHeightBatch(context.Background(), data))
+		assert.ErrorIs(t, writer.Close(), badger.ErrTxnTooBig)
+
+		// Neither are the changes it made to the statistics of the library.
+		assert.Equal(t, sizes, lib.SizeStats())
+
+		reader := index.NewReader(db, lib)
+
+		last, err := reader.Last(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, last)
+
+		_, err = reader.Header(context.Background(), height)
+		assert.ErrorIs(t, err, dps.ErrNotIndexed)
+
+		// After a restart, the height is indexed again, with its registers
+		// written in a chunk that is spread over several transactions ahead of
+		// the rest of its data.
+		writer = index.NewWriter(db, lib)
+
+		chunk := dps.HeightData{
+			Height:   height,
+			Paths:    paths,
+			Payloads: payloads,
+		}
+		assert.NoError(t, writer.WriteHeightBatch(context.Background(), chunk))
+
+		data.Paths = nil
+		data.Payloads = nil
+		assert.NoError(t, writer.WriteHeightBatch(context.Background(), data))
+		require.NoError(t, writer.Close())
+
+		reader = index.NewReader(db, lib)
+
+		last, err = reader.Last(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, height, last)
+
+		header, err := reader.Header(context.Background(), height)
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeader, header)
+
+		registers, err := reader.Values(context.Background(), height, paths)
+		require.NoError(t, err)
+		assert.Equal(t, values, registers)
+	})
+
+	t.Run("height interrupted between register chunks", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer, db := setupIndex(t)
+		defer db.Close()
+
+		height := mocks.GenericHeight + 1
+		paths := mocks.GenericLedgerPaths(4)
+		payloads := mocks.GenericLedgerPayloads(4)
+
+		previous := dps.HeightData{
+			Height:   mocks.GenericHeight,
+			BlockID:  mocks.GenericHeader.ID(),
+			Header:   mocks.GenericHeader,
+			Complete: true,
+			First:    true,
+			Last:     true,
+		}
+		assert.NoError(t, writer.WriteHeightBatch(context.Background(), previous))
+
+		// The indexer stops after the first chunk of registers of the next
+		// height, before writing the second chunk and the batch that completes
+		// the height.
+		chunk := dps.HeightData{
+			Height:   height,
+			Paths:    paths[:2],
+			Payloads: payloads[:2],
+		}
+		assert.NoError(t, writer.WriteHeightBatch(context.Background(), chunk))
+		require.NoError(t, writer.Close())
+
+		last, err := reader.Last(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, last)
+
+		_, err = reader.Values(context.Background(), height, paths)
+		assert.ErrorIs(t, err, dps.ErrUnavailable)
+
+		// The registers of the interrupted height do not leak into the last
+		// height either.
+		registers, err := reader.Values(context.Background(), mocks.GenericHeight, paths)
+		require.NoError(t, err)
+		assert.Equal(t, make([]ledger.Value, len(paths)), registers)
+	})
+
+	t.Run("queued writes", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		lib := storage.New(zbor.NewCodec())
+		writer := index.NewWriter(db, lib,
+			index.WithConcurrentTransactions(4),
+			index.WithEncodingWorkers(8),
+			index.WithQueueSize(1),
+		)
+
+		// The payloads are encoded on the workers, while the last height is
+		// not, but they are still applied in the order of the calls.
+		paths := mocks.GenericLedgerPaths(1)
+		payloads := mocks.GenericLedgerPayloads(10)
+		assert.NoError(t, writer.First(context.Background(), mocks.GenericHeight))
+		for i, payload := range payloads {
+			height := mocks.GenericHeight + uint64(i)
+			assert.NoError(t, writer.Payloads(context.Background(), height, paths, []*ledger.Payload{payload}))
+			assert.NoError(t, writer.Last(context.Background(), height))
+		}
+		require.NoError(t, writer.Close())
+
+		reader := index.NewReader(db, lib)
+
+		last, err := reader.Last(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight+uint64(len(payloads)-1), last)
+
+		for i, payload := range payloads {
+			got, err := reader.Values(context.Background(), mocks.GenericHeight+uint64(i), paths)
+			require.NoError(t, err)
+			assert.Equal(t, []ledger.Value{payload.Value}, got)
+		}
+	})
+
+	t.Run("cancelled write", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer, db := setupIndex(t)
+		defer db.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.ErrorIs(t, writer.Last(ctx, mocks.GenericHeight), context.Canceled)
+		require.NoError(t, writer.Close())
+
+		_, err := reader.Last(context.Background())
+		assert.ErrorIs(t, err, dps.ErrBootstrapping)
+	})
+
+	t.Run("fencing", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		lib := storage.New(zbor.NewCodec())
+
+		token, err := index.AcquireFence(db, lib, "first", false)
+		require.NoError(t, err)
+
+		writer := index.NewWriter(db, lib, index.WithConcurrentTransactions(4), index.WithFence(token))
+		assert.NoError(t, writer.First(context.Background(), mocks.GenericHeight))
+		assert.NoError(t, writer.Last(context.Background(), mocks.GenericHeight))
+
+		// A second instance can not acquire the fence while it is held.
+		_, err = index.AcquireFence(db, lib, "second", false)
+		assert.ErrorIs(t, err, dps.ErrFenced)
+
+		// Once the second instance takes over the fence, the writes of the
+		// first instance are rejected.
+		other, err := index.AcquireFence(db, lib, "second", true)
+		require.NoError(t, err)
+		assert.Greater(t, other, token)
+
+		assert.NoError(t, writer.Last(context.Background(), mocks.GenericHeight+1))
+		assert.ErrorIs(t, writer.Close(), dps.ErrFenced)
+
+		reader := index.NewReader(db, lib)
+		_, err = reader.Last(context.Background())
+		assert.ErrorIs(t, err, dps.ErrBootstrapping)
+
+		// The second instance writes normally, and releases the fence when
+		// it is closed.
+		writer = index.NewWriter(db, lib, index.WithConcurrentTransactions(4), index.WithFence(other))
+		assert.NoError(t, writer.First(context.Background(), mocks.GenericHeight))
+		assert.NoError(t, writer.Last(context.Background(), mocks.GenericHeight))
+		require.NoError(t, writer.Close())
+
+		last, err := reader.Last(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, last)
+
+		_, err = index.AcquireFence(db, lib, "third", false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("staging", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		lib := storage.New(zbor.NewCodec(), storage.WithStaging(true))
+		writer := index.NewWriter(db, lib, index.WithConcurrentTransactions(4))
+
+		// Each height is staged with its state commitment and the last height.
+		for i := 0; i < 3; i++ {
+			height := mocks.GenericHeight + uint64(i)
+			assert.NoError(t, writer.Commit(context.Background(), height, mocks.GenericCommit(i)))
+			assert.NoError(t, writer.Last(context.Background(), height))
+			assert.NoError(t, writer.Stage(context.Background(), height))
+		}
+		// Close the writer to make it commit its transactions.
+		require.NoError(t, writer.Close())
+
+		// The first height is sealed with the indexed state commitment, the
+		// second one with a conflicting one, and the third one is not sealed.
+		seals := mocks.BaselineSealer(t)
+		seals.SealedFunc = func(height uint64) (flow.StateCommitment, error) {
+			switch height {
+			case mocks.GenericHeight:
+				return mocks.GenericCommit(0), nil
+			case mocks.GenericHeight + 1:
+				return mocks.GenericCommit(3), nil
+			default:
+				return flow.DummyStateCommitment, dps.ErrUnavailable
+			}
+		}
+		staging := index.NewStaging(db, lib, seals)
+
+		err := staging.Settle()
+		assert.ErrorIs(t, err, dps.ErrConflict)
+
+		rolled, err := staging.Rollback()
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{mocks.GenericHeight + 1, mocks.GenericHeight + 2}, rolled)
+
+		pending, err := staging.Pending()
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+
+		reader := index.NewReader(db, lib)
+		last, err := reader.Last(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, last)
+		_, err = reader.Commit(context.Background(), mocks.GenericHeight+1)
+		assert.Error(t, err)
+	})
+
+	t.Run("manifests", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		lib := storage.New(zbor.NewCodec(), storage.WithManifests(true))
+		writer := index.NewWriter(db, lib, index.WithConcurrentTransactions(4))
+
+		paths := mocks.GenericLedgerPaths(2)
+		payloads := mocks.GenericLedgerPayloads(2)
+
+		// The first height is indexed with a manifest, while the height before
+		// it has none and is skipped by the verification.
+		assert.NoError(t, writer.Header(context.Background(), mocks.GenericHeight, mocks.GenericHeader))
+		assert.NoError(t, writer.Payloads(context.Background(), mocks.GenericHeight, paths[:1], payloads[:1]))
+		assert.NoError(t, writer.Manifest(context.Background(), mocks.GenericHeight))
+		assert.NoError(t, writer.Payloads(context.Background(), mocks.GenericHeight+1, paths[1:], payloads[1:]))
+		assert.NoError(t, writer.Seals(context.Background(), mocks.GenericHeight+1, mocks.GenericSeals(2)))
+		assert.NoError(t, writer.Manifest(context.Background(), mocks.GenericHeight+1))
+		// Close the writer to make it commit its transactions.
+		require.NoError(t, writer.Close())
+
+		reader := index.NewReader(db, lib)
+
+		// NOTE: The following subtests should NOT be run in parallel, as the
+		// second one corrupts the index.
+		t.Run("intact index", func(t *testing.T) {
+			got, err := reader.Verify(context.Background(), mocks.GenericHeight-1, mocks.GenericHeight+1)
+
+			require.NoError(t, err)
+			assert.Empty(t, got)
+		})
+
+		t.Run("corrupted index", func(t *testing.T) {
+			err := db.Update(func(tx *badger.Txn) error {
+				key := storage.EncodeKey(storage.PrefixPayload, paths[1], mocks.GenericHeight+1)
+				return tx.Set(key, mocks.GenericBytes)
+			})
+			require.NoError(t, err)
+
+			got, err := reader.Verify(context.Background(), mocks.GenericHeight-1, mocks.GenericHeight+1)
+
+			require.NoError(t, err)
+			assert.Equal(t, []uint64{mocks.GenericHeight + 1}, got)
+		})
+	})
 }
 
 func setupIndex(t *testing.T) (*index.Reader, *index.Writer, *badger.DB) {
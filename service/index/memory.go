@@ -0,0 +1,351 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package index
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Memory wraps an index reader and serves the register values for the most
+// recently indexed heights directly from their execution state tries, which
+// are kept in memory. This makes register values available as soon as a
+// height has been mapped, before they are flushed to the index database.
+// All other data, including the last indexed height, is read from the wrapped
+// index reader.
+type Memory struct {
+	read  dps.Reader
+	size  uint
+	mutex *sync.RWMutex
+	tries map[uint64]*trie.MTrie
+	last  uint64
+}
+
+// NewMemory creates a new index reader that keeps the execution state tries
+// of the given number of most recent heights in memory.
+func NewMemory(read dps.Reader, size uint) *Memory {
+
+	m := Memory{
+		read:  read,
+		size:  size,
+		mutex: &sync.RWMutex{},
+		tries: make(map[uint64]*trie.MTrie, size),
+	}
+
+	return &m
+}
+
+// Add adds the execution state trie for the given height, and evicts the
+// tries of heights that are no longer among the most recent ones. Tries are
+// immutable and share their unchanged nodes, so keeping several of them only
+// costs the memory for the registers that changed between them.
+func (m *Memory) Add(height uint64, tree *trie.MTrie) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.tries[height] = tree
+	if height > m.last {
+		m.last = height
+	}
+
+	for recent := range m.tries {
+		if recent+uint64(m.size) <= m.last {
+			delete(m.tries, recent)
+		}
+	}
+}
+
+// Snapshot returns a reader that serves register values from the execution
+// state tries that are in memory at the time of the call, and all other data
+// from a snapshot of the wrapped index reader if it supports snapshots, along
+// with a function that releases the snapshot.
+func (m *Memory) Snapshot() (dps.Reader, func()) {
+
+	read, release := m.read, func() {}
+	snapshotter, ok := m.read.(dps.Snapshotter)
+	if ok {
+		read, release = snapshotter.Snapshot()
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	tries := make(map[uint64]*trie.MTrie, len(m.tries))
+	for height, tree := range m.tries {
+		tries[height] = tree
+	}
+
+	snapshot := Memory{
+		read:  read,
+		size:  m.size,
+		mutex: &sync.RWMutex{},
+		tries: tries,
+		last:  m.last,
+	}
+
+	return &snapshot, release
+}
+
+// First returns the height of the first finalized block that was indexed.
+func (m *Memory) First(ctx context.Context) (uint64, error) {
+	return m.read.First(ctx)
+}
+
+// Last returns the height of the last finalized block that was indexed. It is
+// always the last height flushed to the index database, even when the registers
+// of more recent heights are available in memory, as the other data of those
+// heights can not be read yet.
+func (m *Memory) Last(ctx context.Context) (uint64, error) {
+	return m.read.Last(ctx)
+}
+
+// HeightForBlock returns the height of the given blockID.
+func (m *Memory) HeightForBlock(ctx context.Context, blockID flow.Identifier) (uint64, error) {
+	return m.read.HeightForBlock(ctx, blockID)
+}
+
+// HeightForCommit returns the first height at which the execution state had
+// the given state commitment.
+func (m *Memory) HeightForCommit(ctx context.Context, commit flow.StateCommitment) (uint64, error) {
+	return m.read.HeightForCommit(ctx, commit)
+}
+
+// HeightForTransaction returns the height of the block within which the given
+// transaction identifier is.
+func (m *Memory) HeightForTransaction(ctx context.Context, txID flow.Identifier) (uint64, error) {
+	return m.read.HeightForTransaction(ctx, txID)
+}
+
+// Account returns the metadata of the account with the given address.
+func (m *Memory) Account(ctx context.Context, address flow.Address) (*dps.Account, error) {
+	return m.read.Account(ctx, address)
+}
+
+// ContractHistory returns all changes to the contract with the given name on
+// the account with the given address.
+func (m *Memory) ContractHistory(ctx context.Context, address flow.Address, name string) ([]dps.ContractVersion, error) {
+	return m.read.ContractHistory(ctx, address, name)
+}
+
+// Commit returns the commitment of the execution state as it was after the
+// execution of the finalized block at the given height.
+func (m *Memory) Commit(ctx context.Context, height uint64) (flow.StateCommitment, error) {
+	return m.read.Commit(ctx, height)
+}
+
+// Header returns the header for the finalized block at the given height.
+func (m *Memory) Header(ctx context.Context, height uint64) (*flow.Header, error) {
+	return m.read.Header(ctx, height)
+}
+
+// BlockStats returns the aggregate statistics of the finalized blocks between
+// the given start and end heights.
+func (m *Memory) BlockStats(ctx context.Context, start uint64, end uint64) ([]dps.BlockStats, error) {
+	return m.read.BlockStats(ctx, start, end)
+}
+
+// Events returns the events of all transactions that were part of the
+// finalized block at the given height. It can optionally filter them by event
+// type; if no event types are given, all events are returned.
+func (m *Memory) Events(ctx context.Context, height uint64, types ...flow.EventType) ([]flow.Event, error) {
+	return m.read.Events(ctx, height, types...)
+}
+
+// ServiceEvents returns the service events emitted between the given heights
+// that have one of the given types.
+func (m *Memory) ServiceEvents(ctx context.Context, start uint64, end uint64, types ...flow.EventType) ([]dps.ServiceEvent, error) {
+	return m.read.ServiceEvents(ctx, start, end, types...)
+}
+
+// Epochs returns all indexed epochs, along with the heights at which each of
+// their phases started.
+func (m *Memory) Epochs(ctx context.Context) ([]dps.Epoch, error) {
+	return m.read.Epochs(ctx)
+}
+
+// Identities returns the identity table of the epoch with the given counter.
+func (m *Memory) Identities(ctx context.Context, counter uint64) (flow.IdentityList, error) {
+	return m.read.Identities(ctx, counter)
+}
+
+// EventTypes returns the statistics of all event types that were indexed.
+func (m *Memory) EventTypes(ctx context.Context) ([]dps.EventTypeStats, error) {
+	return m.read.EventTypes(ctx)
+}
+
+// Values returns the Ledger values of the execution state at the given paths
+// as they were after the execution of the finalized block at the given height.
+// If the execution state trie for the height is in memory, the values are read
+// from it directly.
+func (m *Memory) Values(ctx context.Context, height uint64, paths []ledger.Path) ([]ledger.Value, error) {
+
+	m.mutex.RLock()
+	tree, ok := m.tries[height]
+	m.mutex.RUnlock()
+	if !ok {
+		return m.read.Values(ctx, height, paths)
+	}
+
+	// Reading from the trie permutes the paths in place, so we read with a
+	// copy of the paths and then map the payloads back to the given order.
+	lookup := make([]ledger.Path, len(paths))
+	copy(lookup, paths)
+	payloads := tree.UnsafeRead(lookup)
+	if len(payloads) != len(lookup) {
+		return nil, fmt.Errorf("mismatch between paths and payloads counts (paths: %d, payloads: %d)", len(lookup), len(payloads))
+	}
+	registers := make(map[ledger.Path]*ledger.Payload, len(lookup))
+	for i, path := range lookup {
+		registers[path] = payloads[i]
+	}
+
+	values := make([]ledger.Value, 0, len(paths))
+	for _, path := range paths {
+		values = append(values, registers[path].Value)
+	}
+
+	return values, nil
+}
+
+// Registers returns up to the given number of registers owned by the account
+// with the given address at the given height, starting after the given path.
+// Listing registers requires the paths indexed on disk, so it is always served
+// by the wrapped index reader.
+func (m *Memory) Registers(ctx context.Context, height uint64, owner flow.Address, after ledger.Path, limit uint) ([]dps.Register, error) {
+	return m.read.Registers(ctx, height, owner, after, limit)
+}
+
+// DumpRegisters returns up to the given number of registers of the execution
+// state at the given height, starting after the given path. It is always served
+// by the wrapped index reader, as the memory only holds the registers of the
+// most recent heights.
+func (m *Memory) DumpRegisters(ctx context.Context, height uint64, after ledger.Path, limit uint) ([]dps.Register, error) {
+	return m.read.DumpRegisters(ctx, height, after, limit)
+}
+
+// RegisterChurn returns the report of the register writes between the given
+// start and end heights, with up to the given number of top owners and
+// registers.
+func (m *Memory) RegisterChurn(ctx context.Context, start uint64, end uint64, limit uint) (*dps.ChurnReport, error) {
+	return m.read.RegisterChurn(ctx, start, end, limit)
+}
+
+// RegisterDeltas returns up to the given number of changes to the registers
+// made by the finalized block at the given height, starting after the given
+// path. They are always served by the wrapped index reader.
+func (m *Memory) RegisterDeltas(ctx context.Context, height uint64, after ledger.Path, limit uint) ([]dps.RegisterDelta, error) {
+	return m.read.RegisterDeltas(ctx, height, after, limit)
+}
+
+// Collection returns the collection with the given ID.
+func (m *Memory) Collection(ctx context.Context, collID flow.Identifier) (*flow.LightCollection, error) {
+	return m.read.Collection(ctx, collID)
+}
+
+// Guarantee returns the guarantee with the given collection ID.
+func (m *Memory) Guarantee(ctx context.Context, collID flow.Identifier) (*flow.CollectionGuarantee, error) {
+	return m.read.Guarantee(ctx, collID)
+}
+
+// Transaction returns the transaction with the given ID.
+func (m *Memory) Transaction(ctx context.Context, txID flow.Identifier) (*flow.TransactionBody, error) {
+	return m.read.Transaction(ctx, txID)
+}
+
+// Seal returns the seal with the given ID.
+func (m *Memory) Seal(ctx context.Context, sealID flow.Identifier) (*flow.Seal, error) {
+	return m.read.Seal(ctx, sealID)
+}
+
+// Result returns the transaction result for the given transaction ID.
+func (m *Memory) Result(ctx context.Context, txID flow.Identifier) (*flow.TransactionResult, error) {
+	return m.read.Result(ctx, txID)
+}
+
+// RawHeader returns the header for the finalized block at the given height, as
+// it is encoded in the index.
+func (m *Memory) RawHeader(ctx context.Context, height uint64) ([]byte, error) {
+	return m.read.RawHeader(ctx, height)
+}
+
+// RawEvents returns the events of all transactions that were part of the
+// finalized block at the given height, as they are encoded in the index.
+func (m *Memory) RawEvents(ctx context.Context, height uint64, types ...flow.EventType) ([][]byte, error) {
+	return m.read.RawEvents(ctx, height, types...)
+}
+
+// RawCollection returns the collection with the given ID, as it is encoded in
+// the index.
+func (m *Memory) RawCollection(ctx context.Context, collID flow.Identifier) ([]byte, error) {
+	return m.read.RawCollection(ctx, collID)
+}
+
+// RawGuarantee returns the guarantee with the given collection ID, as it is
+// encoded in the index.
+func (m *Memory) RawGuarantee(ctx context.Context, collID flow.Identifier) ([]byte, error) {
+	return m.read.RawGuarantee(ctx, collID)
+}
+
+// RawTransaction returns the transaction with the given ID, as it is encoded
+// in the index.
+func (m *Memory) RawTransaction(ctx context.Context, txID flow.Identifier) ([]byte, error) {
+	return m.read.RawTransaction(ctx, txID)
+}
+
+// RawSeal returns the seal with the given ID, as it is encoded in the index.
+func (m *Memory) RawSeal(ctx context.Context, sealID flow.Identifier) ([]byte, error) {
+	return m.read.RawSeal(ctx, sealID)
+}
+
+// RawResult returns the transaction result for the given transaction ID, as it
+// is encoded in the index.
+func (m *Memory) RawResult(ctx context.Context, txID flow.Identifier) ([]byte, error) {
+	return m.read.RawResult(ctx, txID)
+}
+
+// CollectionsByHeight returns the collection IDs at the given height.
+func (m *Memory) CollectionsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error) {
+	return m.read.CollectionsByHeight(ctx, height)
+}
+
+// TransactionsByHeight returns the transaction IDs within the given height.
+func (m *Memory) TransactionsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error) {
+	return m.read.TransactionsByHeight(ctx, height)
+}
+
+// TransactionsForScript returns the identifiers of the transactions whose
+// script has the given hash, between the given start and end heights.
+func (m *Memory) TransactionsForScript(ctx context.Context, hash flow.Identifier, start uint64, end uint64) ([]flow.Identifier, error) {
+	return m.read.TransactionsForScript(ctx, hash, start, end)
+}
+
+// SealsByHeight returns all of the seals that were part of the finalized block
+// at the given height.
+func (m *Memory) SealsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error) {
+	return m.read.SealsByHeight(ctx, height)
+}
+
+// Filter returns the filter that restricted the indexed data to the data
+// involving some accounts.
+func (m *Memory) Filter(ctx context.Context) (dps.Filter, error) {
+	return m.read.Filter(ctx)
+}
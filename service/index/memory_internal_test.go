@@ -0,0 +1,216 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package index
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestMemory_Add(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		memory := NewMemory(mocks.BaselineReader(t), 2)
+
+		memory.Add(mocks.GenericHeight, mocks.GenericTrie)
+
+		assert.Len(t, memory.tries, 1)
+		assert.Equal(t, mocks.GenericHeight, memory.last)
+	})
+
+	t.Run("evicts old heights", func(t *testing.T) {
+		t.Parallel()
+
+		memory := NewMemory(mocks.BaselineReader(t), 2)
+
+		memory.Add(mocks.GenericHeight, mocks.GenericTrie)
+		memory.Add(mocks.GenericHeight+1, mocks.GenericTrie)
+		memory.Add(mocks.GenericHeight+2, mocks.GenericTrie)
+
+		assert.Len(t, memory.tries, 2)
+		assert.NotContains(t, memory.tries, mocks.GenericHeight)
+		assert.Contains(t, memory.tries, mocks.GenericHeight+1)
+		assert.Contains(t, memory.tries, mocks.GenericHeight+2)
+		assert.Equal(t, mocks.GenericHeight+2, memory.last)
+	})
+}
+
+func TestMemory_Last(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		memory := NewMemory(mocks.BaselineReader(t), 2)
+
+		last, err := memory.Last(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, last)
+	})
+
+	t.Run("ignores heights only in memory", func(t *testing.T) {
+		t.Parallel()
+
+		memory := NewMemory(mocks.BaselineReader(t), 2)
+		memory.Add(mocks.GenericHeight+1, mocks.GenericTrie)
+
+		last, err := memory.Last(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, last)
+	})
+
+	t.Run("handles index failure", func(t *testing.T) {
+		t.Parallel()
+
+		read := mocks.BaselineReader(t)
+		read.LastFunc = func(context.Context) (uint64, error) {
+			return 0, mocks.GenericError
+		}
+
+		memory := NewMemory(read, 2)
+		memory.Add(mocks.GenericHeight+1, mocks.GenericTrie)
+
+		_, err := memory.Last(context.Background())
+
+		assert.Error(t, err)
+	})
+}
+
+func TestMemory_Snapshot(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		memory := NewMemory(mocks.BaselineReader(t), 2)
+		memory.Add(mocks.GenericHeight, mocks.GenericTrie)
+
+		read, release := memory.Snapshot()
+		defer release()
+
+		memory.Add(mocks.GenericHeight+1, mocks.GenericTrie)
+		memory.Add(mocks.GenericHeight+2, mocks.GenericTrie)
+
+		snapshot, ok := read.(*Memory)
+		require.True(t, ok)
+		assert.Len(t, snapshot.tries, 1)
+		assert.Contains(t, snapshot.tries, mocks.GenericHeight)
+		assert.Equal(t, mocks.GenericHeight, snapshot.last)
+		assert.NotContains(t, memory.tries, mocks.GenericHeight)
+	})
+
+	t.Run("uses snapshot of wrapped reader", func(t *testing.T) {
+		t.Parallel()
+
+		released := false
+		wrapped := mocks.BaselineReader(t)
+		read := &snapshotterMock{
+			Reader: wrapped,
+			SnapshotFunc: func() (dps.Reader, func()) {
+				return wrapped, func() { released = true }
+			},
+		}
+
+		memory := NewMemory(read, 2)
+
+		snapshot, release := memory.Snapshot()
+		release()
+
+		assert.Same(t, wrapped, snapshot.(*Memory).read)
+		assert.True(t, released)
+	})
+}
+
+// snapshotterMock wraps a reader mock so that it also implements the
+// `dps.Snapshotter` interface.
+type snapshotterMock struct {
+	*mocks.Reader
+
+	SnapshotFunc func() (dps.Reader, func())
+}
+
+func (s *snapshotterMock) Snapshot() (dps.Reader, func()) {
+	return s.SnapshotFunc()
+}
+
+func TestMemory_Values(t *testing.T) {
+	paths := mocks.GenericLedgerPaths(4)
+	payloads := mocks.GenericLedgerPayloads(4)
+	updates := make([]ledger.Payload, 0, len(payloads))
+	for _, payload := range payloads {
+		updates = append(updates, *payload)
+	}
+
+	// Building the trie permutes the paths and payloads in place.
+	tree, err := trie.NewTrieWithUpdatedRegisters(trie.NewEmptyMTrie(), append([]ledger.Path{}, paths...), updates)
+	require.NoError(t, err)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		read := mocks.BaselineReader(t)
+		read.ValuesFunc = func(context.Context, uint64, []ledger.Path) ([]ledger.Value, error) {
+			t.Fatal("unexpected read from index")
+			return nil, nil
+		}
+
+		memory := NewMemory(read, 2)
+		memory.Add(mocks.GenericHeight, tree)
+
+		// Request the paths in reverse order to make sure the values are
+		// returned in the requested order.
+		lookup := []ledger.Path{paths[3], paths[1], paths[0]}
+		values, err := memory.Values(context.Background(), mocks.GenericHeight, lookup)
+
+		require.NoError(t, err)
+		assert.Equal(t, []ledger.Value{payloads[3].Value, payloads[1].Value, payloads[0].Value}, values)
+		assert.Equal(t, []ledger.Path{paths[3], paths[1], paths[0]}, lookup)
+	})
+
+	t.Run("falls back to index", func(t *testing.T) {
+		t.Parallel()
+
+		memory := NewMemory(mocks.BaselineReader(t), 2)
+		memory.Add(mocks.GenericHeight, tree)
+
+		values, err := memory.Values(context.Background(), mocks.GenericHeight+1, paths)
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericLedgerValues(6), values)
+	})
+
+	t.Run("handles index failure", func(t *testing.T) {
+		t.Parallel()
+
+		read := mocks.BaselineReader(t)
+		read.ValuesFunc = func(context.Context, uint64, []ledger.Path) ([]ledger.Value, error) {
+			return nil, mocks.GenericError
+		}
+
+		memory := NewMemory(read, 2)
+
+		_, err := memory.Values(context.Background(), mocks.GenericHeight, paths)
+
+		assert.Error(t, err)
+	})
+}
@@ -15,11 +15,14 @@
 package index
 
 import (
+	"context"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 
-	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
 )
 
 // MetricsWriter wraps the writer and records metrics for the data it writes.
@@ -73,6 +76,16 @@ func NewMetricsWriter(write *Writer) *MetricsWriter {
 	}
 	seal := promauto.NewCounter(sealOpts)
 
+	// The queue of the writer fills up when it applies operations slower than
+	// they are submitted, which is when the writer holds back its callers.
+	queueOpts := prometheus.GaugeOpts{
+		Name: "index_write_queue",
+		Help: "number of index writer calls waiting to be applied",
+	}
+	promauto.NewGaugeFunc(queueOpts, func() float64 {
+		return float64(write.Queued())
+	})
+
 	w := MetricsWriter{
 		write: write,
 
@@ -87,56 +100,38 @@ func NewMetricsWriter(write *Writer) *MetricsWriter {
 	return &w
 }
 
-func (w *MetricsWriter) Header(height uint64, header *flow.Header) error {
-	w.block.Inc()
-	return w.write.Header(height, header)
-}
-
-func (w *MetricsWriter) Payloads(height uint64, paths []ledger.Path, payloads []*ledger.Payload) error {
-	w.register.Add(float64(len(paths)))
-	return w.write.Payloads(height, paths, payloads)
-}
-
-func (w *MetricsWriter) Collections(height uint64, collections []*flow.LightCollection) error {
-	w.collection.Add(float64(len(collections)))
-	return w.write.Collections(height, collections)
-}
-
-func (w *MetricsWriter) Transactions(height uint64, transactions []*flow.TransactionBody) error {
-	w.transaction.Add(float64(len(transactions)))
-	return w.write.Transactions(height, transactions)
-}
-
-func (w *MetricsWriter) Events(height uint64, events []flow.Event) error {
-	w.event.Add(float64(len(events)))
-	return w.write.Events(height, events)
-}
-
-func (w *MetricsWriter) Seals(height uint64, seals []*flow.Seal) error {
-	w.seal.Add(float64(len(seals)))
-	return w.write.Seals(height, seals)
+func (w *MetricsWriter) WriteHeightBatch(ctx context.Context, data dps.HeightData) error {
+	if data.Header != nil {
+		w.block.Inc()
+	}
+	w.register.Add(float64(len(data.Paths)))
+	w.collection.Add(float64(len(data.Collections)))
+	w.transaction.Add(float64(len(data.Transactions)))
+	w.event.Add(float64(len(data.Events)))
+	w.seal.Add(float64(len(data.Seals)))
+	return w.write.WriteHeightBatch(ctx, data)
 }
 
-func (w *MetricsWriter) First(height uint64) error {
-	return w.write.First(height)
+func (w *MetricsWriter) Epoch(ctx context.Context, setup *flow.EpochSetup) error {
+	return w.write.Epoch(ctx, setup)
 }
 
-func (w *MetricsWriter) Last(height uint64) error {
-	return w.write.Last(height)
+func (w *MetricsWriter) Phase(ctx context.Context, counter uint64, phase flow.EpochPhase, height uint64) error {
+	return w.write.Phase(ctx, counter, phase, height)
 }
 
-func (w *MetricsWriter) Height(blockID flow.Identifier, height uint64) error {
-	return w.write.Height(blockID, height)
+func (w *MetricsWriter) First(ctx context.Context, height uint64) error {
+	return w.write.First(ctx, height)
 }
 
-func (w *MetricsWriter) Commit(height uint64, commit flow.StateCommitment) error {
-	return w.write.Commit(height, commit)
+func (w *MetricsWriter) Corruption(ctx context.Context, height uint64, reason string) error {
+	return w.write.Corruption(ctx, height, reason)
 }
 
-func (w *MetricsWriter) Guarantees(height uint64, guarantees []*flow.CollectionGuarantee) error {
-	return w.write.Guarantees(height, guarantees)
+func (w *MetricsWriter) ExecutionRecord(ctx context.Context, blockID flow.Identifier, data []byte) error {
+	return w.write.ExecutionRecord(ctx, blockID, data)
 }
 
-func (w *MetricsWriter) Results(results []*flow.TransactionResult) error {
-	return w.write.Results(results)
+func (w *MetricsWriter) Filter(ctx context.Context, filter dps.Filter) error {
+	return w.write.Filter(ctx, filter)
 }
@@ -15,8 +15,10 @@
 package index
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/dgraph-io/badger/v2"
 
@@ -31,78 +33,166 @@ import (
 type Reader struct {
 	db  *badger.DB
 	lib dps.ReadLibrary
+	cfg Config
+	tx  *badger.Txn // read-only transaction serving all reads of a snapshot
+
+	mutex   *sync.Mutex                // guards the path filter cache against concurrent access
+	filters map[uint64]*dps.PathFilter // path filters of complete windows
 }
 
 // NewReader creates a new index reader, using the given database as the
 // underlying state repository. It is recommended to provide a read-only Badger
 // database.
-func NewReader(db *badger.DB, lib dps.ReadLibrary) *Reader {
+func NewReader(db *badger.DB, lib dps.ReadLibrary, options ...func(*Config)) *Reader {
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
 
 	r := Reader{
 		db:  db,
 		lib: lib,
+		cfg: cfg,
+
+		mutex:   &sync.Mutex{},
+		filters: make(map[uint64]*dps.PathFilter),
 	}
 
 	return &r
 }
 
-// First returns the height of the first finalized block that was indexed.
-func (r *Reader) First() (uint64, error) {
-	var height uint64
-	err := r.db.View(r.lib.RetrieveFirst(&height))
-	return height, err
+// Snapshot returns a reader that serves all of its reads from the state of the
+// index at the time of the call, within a single read-only transaction, along
+// with a function that discards the transaction. Reads of a snapshot are never
+// torn by data that is written to the index concurrently.
+func (r *Reader) Snapshot() (dps.Reader, func()) {
+	return r.snapshot()
 }
 
-// Last returns the height of the last finalized block that was indexed.
-func (r *Reader) Last() (uint64, error) {
-	var height uint64
-	err := r.db.View(r.lib.RetrieveLast(&height))
-	return height, err
+// snapshot returns a copy of the reader that serves all of its reads from a
+// new read-only transaction, along with a function that discards it. Methods
+// that read several keys for a height run on a snapshot if they are not called
+// on one already, so that their own reads are consistent.
+func (r *Reader) snapshot() (*Reader, func()) {
+	snapshot := *r
+	snapshot.tx = r.db.NewTransaction(false)
+	if r.cfg.Scheduler == nil {
+		return &snapshot, snapshot.tx.Discard
+	}
+
+	// With a scheduler, the reads of a snapshot are in progress until it is
+	// discarded.
+	done := r.cfg.Scheduler.Read()
+	discard := func() {
+		snapshot.tx.Discard()
+		done()
+	}
+
+	return &snapshot, discard
+}
+
+// First returns the height of the first finalized block that was indexed. If
+// no block was indexed yet, it fails with `dps.ErrBootstrapping`.
+func (r *Reader) First(ctx context.Context) (uint64, error) {
+	read := func() (uint64, error) {
+		var height uint64
+		err := r.view(ctx, r.lib.RetrieveFirst(&height))
+		return height, bootstrapping(err)
+	}
+	if !r.cached() {
+		return read()
+	}
+	return r.cfg.Heights.First(read)
+}
+
+// Last returns the height of the last finalized block that was indexed. If no
+// block was indexed yet, it fails with `dps.ErrBootstrapping`.
+func (r *Reader) Last(ctx context.Context) (uint64, error) {
+	read := func() (uint64, error) {
+		var height uint64
+		err := r.view(ctx, r.lib.RetrieveLast(&height))
+		return height, bootstrapping(err)
+	}
+	if !r.cached() {
+		return read()
+	}
+	return r.cfg.Heights.Last(read)
 }
 
 // HeightForBlock returns the height for the given block identifier.
-func (r *Reader) HeightForBlock(blockID flow.Identifier) (uint64, error) {
+func (r *Reader) HeightForBlock(ctx context.Context, blockID flow.Identifier) (uint64, error) {
 	var height uint64
-	err := r.db.View(r.lib.LookupHeightForBlock(blockID, &height))
+	err := r.view(ctx, r.lib.LookupHeightForBlock(blockID, &height))
 	return height, err
 }
 
 // Commit returns the commitment of the execution state as it was after the
 // execution of the finalized block at the given height.
-func (r *Reader) Commit(height uint64) (flow.StateCommitment, error) {
+func (r *Reader) Commit(ctx context.Context, height uint64) (flow.StateCommitment, error) {
 	var commit flow.StateCommitment
-	err := r.db.View(r.lib.RetrieveCommit(height, &commit))
+	err := r.view(ctx, r.lib.RetrieveCommit(height, &commit))
 	return commit, err
 }
 
 // Header returns the header for the finalized block at the given height.
-func (r *Reader) Header(height uint64) (*flow.Header, error) {
+func (r *Reader) Header(ctx context.Context, height uint64) (*flow.Header, error) {
 	var header flow.Header
-	err := r.db.View(r.lib.RetrieveHeader(height, &header))
+	err := r.view(ctx, r.lib.RetrieveHeader(height, &header))
 	return &header, err
 }
 
+// BlockStats returns the aggregate statistics of the finalized blocks between
+// the given start and end heights, inclusively, in ascending order of height.
+func (r *Reader) BlockStats(ctx context.Context, start uint64, end uint64) ([]dps.BlockStats, error) {
+
+	if start > end {
+		return nil, fmt.Errorf("invalid height range (start: %d, end: %d)", start, end)
+	}
+
+	var stats []dps.BlockStats
+	err := r.view(ctx, r.lib.RetrieveBlockStats(ctx, start, end, &stats))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve block stats: %w", err)
+	}
+
+	return stats, nil
+}
+
 // Values returns the Ledger values of the execution state at the given paths
 // as they were after the execution of the finalized block at the given height.
 // For compatibility with existing Flow execution node code, a path that is not
 // found within the indexed execution state returns a nil value without error.
-func (r *Reader) Values(height uint64, paths []ledger.Path) ([]ledger.Value, error) {
-	first, err := r.First()
-	if err != nil {
-		return nil, fmt.Errorf("could not check first height: %w", err)
+func (r *Reader) Values(ctx context.Context, height uint64, paths []ledger.Path) ([]ledger.Value, error) {
+	if r.tx == nil {
+		snapshot, discard := r.snapshot()
+		defer discard()
+		return snapshot.Values(ctx, height, paths)
 	}
-	last, err := r.Last()
+	first, err := r.indexed(ctx, height)
 	if err != nil {
-		return nil, fmt.Errorf("could not check last height: %w", err)
+		return nil, err
 	}
-	if height < first || height > last {
-		return nil, fmt.Errorf("invalid height (given: %d, first: %d, last: %d)", height, first, last)
+	var filters []*dps.PathFilter
+	if r.cfg.PathFilters {
+		filters, err = r.pathFilters(ctx, first, height)
+		if err != nil {
+			return nil, fmt.Errorf("could not get path filters: %w", err)
+		}
 	}
 	values := make([]ledger.Value, 0, len(paths))
-	err = r.db.View(func(tx *badger.Txn) error {
+	err = r.view(ctx, func(tx *badger.Txn) error {
 		for _, path := range paths {
+			err := ctx.Err()
+			if err != nil {
+				return err
+			}
+			if filters != nil && !written(filters, path) {
+				values = append(values, nil)
+				continue
+			}
 			var payload ledger.Payload
-			err := r.lib.RetrievePayload(height, path, &payload)(tx)
+			err = r.lib.RetrievePayload(height, path, &payload)(tx)
 			if errors.Is(err, badger.ErrKeyNotFound) {
 				values = append(values, nil)
 				continue
@@ -117,74 +207,400 @@ func (r *Reader) Values(height uint64, paths []ledger.Path) ([]ledger.Value, err
 	return values, err
 }
 
+// Registers returns up to the given number of registers owned by the account
+// with the given address, as they were after the execution of the finalized
+// block at the given height. The registers are in ascending order of their
+// paths, starting after the given path, so that the last path returned can be
+// used to get the next page. The zero path starts from the first register.
+// Registers that did not exist at the given height, or that were deleted, are
+// skipped.
+func (r *Reader) Registers(ctx context.Context, height uint64, owner flow.Address, after ledger.Path, limit uint) ([]dps.Register, error) {
+	if r.tx == nil {
+		snapshot, discard := r.snapshot()
+		defer discard()
+		return snapshot.Registers(ctx, height, owner, after, limit)
+	}
+	_, err := r.indexed(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	var registers []dps.Register
+	err = r.view(ctx, func(tx *badger.Txn) error {
+		for uint(len(registers)) < limit {
+			err := ctx.Err()
+			if err != nil {
+				return err
+			}
+			var paths []ledger.Path
+			err = r.lib.LookupPathsForOwner(ctx, owner, after, limit-uint(len(registers)), &paths)(tx)
+			if err != nil {
+				return fmt.Errorf("could not look up paths: %w", err)
+			}
+			if len(paths) == 0 {
+				return nil
+			}
+			for _, path := range paths {
+				var payload ledger.Payload
+				err := r.lib.RetrievePayload(height, path, &payload)(tx)
+				if errors.Is(err, badger.ErrKeyNotFound) {
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("could not retrieve payload (path: %x): %w", path, err)
+				}
+				if len(payload.Value) == 0 {
+					continue
+				}
+				registers = append(registers, dps.Register{Path: path, Payload: &payload})
+			}
+			after = paths[len(paths)-1]
+		}
+		return nil
+	})
+	return registers, err
+}
+
+// DumpRegisters returns up to the given number of registers of the execution
+// state, regardless of their owner, as they were after the execution of the
+// finalized block at the given height. The registers are in ascending order of
+// their paths, starting after the given path, so that the last path returned
+// can be used to get the next chunk. The zero path starts from the first
+// register. Registers that did not exist at the given height, or that were
+// deleted, are skipped.
+func (r *Reader) DumpRegisters(ctx context.Context, height uint64, after ledger.Path, limit uint) ([]dps.Register, error) {
+	if r.tx == nil {
+		snapshot, discard := r.snapshot()
+		defer discard()
+		return snapshot.DumpRegisters(ctx, height, after, limit)
+	}
+	_, err := r.indexed(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	var registers []dps.Register
+	err = r.view(ctx, func(tx *badger.Txn) error {
+		for uint(len(registers)) < limit {
+			err := ctx.Err()
+			if err != nil {
+				return err
+			}
+			var paths []ledger.Path
+			err = r.lib.LookupPaths(ctx, after, limit-uint(len(registers)), &paths)(tx)
+			if err != nil {
+				return fmt.Errorf("could not look up paths: %w", err)
+			}
+			if len(paths) == 0 {
+				return nil
+			}
+			for _, path := range paths {
+				var payload ledger.Payload
+				err := r.lib.RetrievePayload(height, path, &payload)(tx)
+				if errors.Is(err, badger.ErrKeyNotFound) {
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("could not retrieve payload (path: %x): %w", path, err)
+				}
+				if len(payload.Value) == 0 {
+					continue
+				}
+				registers = append(registers, dps.Register{Path: path, Payload: &payload})
+			}
+			after = paths[len(paths)-1]
+		}
+		return nil
+	})
+	return registers, err
+}
+
+// RegisterChurn returns the report of the register writes between the given
+// start and end heights, inclusively, with up to the given number of owners
+// and registers that were written the most.
+func (r *Reader) RegisterChurn(ctx context.Context, start uint64, end uint64, limit uint) (*dps.ChurnReport, error) {
+
+	if start > end {
+		return nil, fmt.Errorf("invalid height range (start: %d, end: %d)", start, end)
+	}
+
+	var churns []dps.RegisterChurn
+	err := r.view(ctx, r.lib.RetrieveRegisterChurn(ctx, start, end, &churns))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve register churn: %w", err)
+	}
+
+	report := dps.MergeChurn(start, end, churns, limit)
+
+	return &report, nil
+}
+
+// RegisterDeltas returns up to the given number of changes to the registers
+// made by the finalized block at the given height, in ascending order of their
+// paths, starting after the given path, so that the last path returned can be
+// used to get the next page. The zero path starts from the first change.
+// Changes are only recorded when the index is built with register deltas, so
+// heights indexed without them return no changes.
+func (r *Reader) RegisterDeltas(ctx context.Context, height uint64, after ledger.Path, limit uint) ([]dps.RegisterDelta, error) {
+
+	_, err := r.indexed(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+
+	var deltas []dps.RegisterDelta
+	err = r.view(ctx, r.lib.RetrieveRegisterDeltas(ctx, height, after, limit, &deltas))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve register deltas: %w", err)
+	}
+
+	return deltas, nil
+}
+
+// indexed checks whether the given height is within the range of indexed
+// heights, and returns the first indexed height. Heights above the last
+// indexed height fail with `dps.ErrUnavailable`, as they might still be
+// indexed, while heights below the first indexed height fail with
+// `dps.ErrNotIndexed`.
+func (r *Reader) indexed(ctx context.Context, height uint64) (uint64, error) {
+	first, err := r.First(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not check first height: %w", err)
+	}
+	last, err := r.Last(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("could not check last height: %w", err)
+	}
+	if height < first {
+		return 0, fmt.Errorf("invalid height (given: %d, first: %d, last: %d): %w", height, first, last, dps.ErrNotIndexed)
+	}
+	if height > last {
+		return 0, fmt.Errorf("invalid height (given: %d, first: %d, last: %d): %w", height, first, last, dps.ErrUnavailable)
+	}
+	return first, nil
+}
+
+// pathFilters returns the path filters covering all heights from the given
+// first height up to the given height. If any of these heights is not covered
+// by a path filter, it returns no filters, as we can't know whether a path was
+// written at that height.
+func (r *Reader) pathFilters(ctx context.Context, first uint64, height uint64) ([]*dps.PathFilter, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var filters []*dps.PathFilter
+	for window := dps.WindowForHeight(first); window <= dps.WindowForHeight(height); window++ {
+
+		filter, ok := r.filters[window]
+		if !ok {
+			filter = &dps.PathFilter{}
+			err := r.view(ctx, r.lib.RetrievePathFilter(window, filter))
+			if errors.Is(err, dps.ErrNotIndexed) {
+				return nil, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("could not retrieve path filter (window: %d): %w", window, err)
+			}
+		}
+
+		// The filter of the window has to start at the first height of the
+		// window, or at the first indexed height for the first window. It also
+		// has to extend to the requested height, or to the last height of the
+		// window for windows below the requested height.
+		start := window * dps.PathFilterWindow
+		if start < first {
+			start = first
+		}
+		end := start - start%dps.PathFilterWindow + dps.PathFilterWindow - 1
+		if end > height {
+			end = height
+		}
+		if filter.From > start || filter.To < end {
+			return nil, nil
+		}
+
+		// Only filters of complete windows are cached, as the others might
+		// still be extended by the indexer.
+		if filter.To%dps.PathFilterWindow == dps.PathFilterWindow-1 {
+			r.filters[window] = filter
+		}
+
+		filters = append(filters, filter)
+	}
+
+	return filters, nil
+}
+
+// written returns whether the given path might have been written within the
+// heights covered by any of the given path filters.
+func written(filters []*dps.PathFilter, path ledger.Path) bool {
+	for _, filter := range filters {
+		if filter.Has(path) {
+			return true
+		}
+	}
+	return false
+}
+
 // Collection returns the collection with the given ID.
-func (r *Reader) Collection(collID flow.Identifier) (*flow.LightCollection, error) {
+func (r *Reader) Collection(ctx context.Context, collID flow.Identifier) (*flow.LightCollection, error) {
 	var collection flow.LightCollection
-	err := r.db.View(r.lib.RetrieveCollection(collID, &collection))
+	err := r.view(ctx, r.lib.RetrieveCollection(collID, &collection))
 	return &collection, err
 }
 
 // CollectionsByHeight returns the collection IDs at the given height.
-func (r *Reader) CollectionsByHeight(height uint64) ([]flow.Identifier, error) {
+func (r *Reader) CollectionsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error) {
 	var collIDs []flow.Identifier
-	err := r.db.View(r.lib.LookupCollectionsForHeight(height, &collIDs))
+	err := r.view(ctx, r.lib.LookupCollectionsForHeight(height, &collIDs))
 	return collIDs, err
 }
 
 // Guarantee returns the guarantee with the given collection ID.
-func (r *Reader) Guarantee(collID flow.Identifier) (*flow.CollectionGuarantee, error) {
+func (r *Reader) Guarantee(ctx context.Context, collID flow.Identifier) (*flow.CollectionGuarantee, error) {
 	var collection flow.CollectionGuarantee
-	err := r.db.View(r.lib.RetrieveGuarantee(collID, &collection))
+	err := r.view(ctx, r.lib.RetrieveGuarantee(collID, &collection))
 	return &collection, err
 }
 
 // Transaction returns the transaction with the given ID.
-func (r *Reader) Transaction(txID flow.Identifier) (*flow.TransactionBody, error) {
+func (r *Reader) Transaction(ctx context.Context, txID flow.Identifier) (*flow.TransactionBody, error) {
 	var transaction flow.TransactionBody
-	err := r.db.View(r.lib.RetrieveTransaction(txID, &transaction))
-	return &transaction, err
+	err := r.view(ctx, r.lib.RetrieveTransaction(txID, &transaction))
+	if err != nil {
+		return nil, r.filtered(ctx, err)
+	}
+	return &transaction, nil
 }
 
 // HeightForTransaction returns the height of the block within which the given
 // transaction identifier is.
-func (r *Reader) HeightForTransaction(txID flow.Identifier) (uint64, error) {
+func (r *Reader) HeightForTransaction(ctx context.Context, txID flow.Identifier) (uint64, error) {
+	var height uint64
+	err := r.view(ctx, r.lib.LookupHeightForTransaction(txID, &height))
+	if err != nil {
+		return 0, r.filtered(ctx, err)
+	}
+	return height, nil
+}
+
+// HeightForCommit returns the first height at which the execution state had
+// the given state commitment.
+func (r *Reader) HeightForCommit(ctx context.Context, commit flow.StateCommitment) (uint64, error) {
 	var height uint64
-	err := r.db.View(r.lib.LookupHeightForTransaction(txID, &height))
+	err := r.view(ctx, r.lib.LookupHeightForCommit(commit, &height))
 	return height, err
 }
 
+// Account returns the metadata of the account with the given address. Accounts
+// that were created before the first indexed height and whose keys were not
+// updated since have no metadata besides their address.
+func (r *Reader) Account(ctx context.Context, address flow.Address) (*dps.Account, error) {
+
+	filter, err := r.Filter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get filter: %w", err)
+	}
+	if !filter.Address(address) {
+		return nil, fmt.Errorf("account excluded by index filter: %w", dps.ErrNotIndexed)
+	}
+
+	account := dps.Account{
+		Address: address,
+	}
+	err = r.view(ctx, func(tx *badger.Txn) error {
+		err := r.lib.LookupHeightForAccount(address, &account.Created)(tx)
+		if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+			return fmt.Errorf("could not look up account creation: %w", err)
+		}
+		err = r.lib.LookupKeyUpdates(ctx, address, &account.KeyUpdates)(tx)
+		if err != nil {
+			return fmt.Errorf("could not look up key updates: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// ContractHistory returns all changes to the contract with the given name on
+// the account with the given address, in the order in which they happened.
+func (r *Reader) ContractHistory(ctx context.Context, address flow.Address, name string) ([]dps.ContractVersion, error) {
+
+	filter, err := r.Filter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not get filter: %w", err)
+	}
+	if !filter.Address(address) {
+		return nil, fmt.Errorf("account excluded by index filter: %w", dps.ErrNotIndexed)
+	}
+
+	var versions []dps.ContractVersion
+	err = r.view(ctx, r.lib.RetrieveContractVersions(ctx, address, name, &versions))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve contract versions: %w", err)
+	}
+
+	return versions, nil
+}
+
 // TransactionsByHeight returns the transaction IDs within the block with the given ID.
-func (r *Reader) TransactionsByHeight(height uint64) ([]flow.Identifier, error) {
+func (r *Reader) TransactionsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error) {
 	var txIDs []flow.Identifier
-	err := r.db.View(r.lib.LookupTransactionsForHeight(height, &txIDs))
+	err := r.view(ctx, r.lib.LookupTransactionsForHeight(height, &txIDs))
 	return txIDs, err
 }
 
+// TransactionsForScript returns the identifiers of the transactions whose
+// script has the given hash, between the given start and end heights,
+// inclusively, in ascending order of height.
+func (r *Reader) TransactionsForScript(ctx context.Context, hash flow.Identifier, start uint64, end uint64) ([]flow.Identifier, error) {
+
+	if start > end {
+		return nil, fmt.Errorf("invalid height range (start: %d, end: %d)", start, end)
+	}
+
+	var txIDs []flow.Identifier
+	err := r.view(ctx, r.lib.LookupTransactionsForScript(ctx, hash, start, end, &txIDs))
+	if err != nil {
+		return nil, fmt.Errorf("could not look up transactions for script: %w", err)
+	}
+
+	return txIDs, nil
+}
+
 // Result returns the transaction result for the given transaction ID.
-func (r *Reader) Result(txID flow.Identifier) (*flow.TransactionResult, error) {
+func (r *Reader) Result(ctx context.Context, txID flow.Identifier) (*flow.TransactionResult, error) {
 	var result flow.TransactionResult
-	err := r.db.View(r.lib.RetrieveResult(txID, &result))
-	return &result, err
+	err := r.view(ctx, r.lib.RetrieveResult(txID, &result))
+	if err != nil {
+		return nil, r.filtered(ctx, err)
+	}
+	return &result, nil
 }
 
 // Events returns the events of all transactions that were part of the
 // finalized block at the given height. It can optionally filter them by event
 // type; if no event types are given, all events are returned.
-func (r *Reader) Events(height uint64, types ...flow.EventType) ([]flow.Event, error) {
-	first, err := r.First()
-	if err != nil {
-		return nil, fmt.Errorf("could not check first height: %w", err)
+func (r *Reader) Events(ctx context.Context, height uint64, types ...flow.EventType) ([]flow.Event, error) {
+	if r.tx == nil {
+		snapshot, discard := r.snapshot()
+		defer discard()
+		return snapshot.Events(ctx, height, types...)
 	}
-	last, err := r.Last()
+	_, err := r.indexed(ctx, height)
 	if err != nil {
-		return nil, fmt.Errorf("could not check last height: %w", err)
+		return nil, err
 	}
-	if height < first || height > last {
-		return nil, fmt.Errorf("invalid height (given: %d, first: %d, last: %d)", height, first, last)
+
+	err = r.eventTypes(ctx, types)
+	if err != nil {
+		return nil, err
 	}
 
 	var events []flow.Event
-	err = r.db.View(r.lib.RetrieveEvents(height, types, &events))
+	err = r.view(ctx, r.lib.RetrieveEvents(ctx, height, types, &events))
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve events: %w", err)
 	}
@@ -192,16 +608,330 @@ func (r *Reader) Events(height uint64, types ...flow.EventType) ([]flow.Event, e
 	return events, nil
 }
 
+// eventTypes checks whether events of the given types can have been indexed.
+// If the index is filtered, we fail on event types that can never have been
+// indexed, rather than returning no events.
+func (r *Reader) eventTypes(ctx context.Context, types []flow.EventType) error {
+	if len(types) == 0 {
+		return nil
+	}
+	filter, err := r.Filter(ctx)
+	if err != nil {
+		return fmt.Errorf("could not get filter: %w", err)
+	}
+	for _, typ := range types {
+		if !filter.EventType(typ) {
+			return fmt.Errorf("could not retrieve events (type: %s): %w", typ, dps.ErrNotIndexed)
+		}
+	}
+	return nil
+}
+
+// ServiceEvents returns the service events emitted between the given start and
+// end heights, inclusively, that have one of the given types. If no types are
+// given, all service events are returned.
+func (r *Reader) ServiceEvents(ctx context.Context, start uint64, end uint64, types ...flow.EventType) ([]dps.ServiceEvent, error) {
+
+	if start > end {
+		return nil, fmt.Errorf("invalid height range (start: %d, end: %d)", start, end)
+	}
+
+	var events []dps.ServiceEvent
+	err := r.view(ctx, r.lib.RetrieveServiceEvents(ctx, start, end, types, &events))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve service events: %w", err)
+	}
+
+	return events, nil
+}
+
+// Epochs returns all indexed epochs, in ascending order of their counters,
+// along with the heights at which each of their phases started.
+func (r *Reader) Epochs(ctx context.Context) ([]dps.Epoch, error) {
+
+	var epochs []dps.Epoch
+	err := r.view(ctx, func(tx *badger.Txn) error {
+		err := r.lib.RetrieveEpochs(ctx, &epochs)(tx)
+		if err != nil {
+			return fmt.Errorf("could not retrieve epochs: %w", err)
+		}
+
+		// Phases that started before the first indexed height are not in the
+		// index, in which case we leave their height at zero.
+		for i := range epochs {
+			epoch := &epochs[i]
+			phases := map[flow.EpochPhase]*uint64{
+				flow.EpochPhaseStaking:   &epoch.StakingHeight,
+				flow.EpochPhaseSetup:     &epoch.SetupHeight,
+				flow.EpochPhaseCommitted: &epoch.CommittedHeight,
+			}
+			for phase, height := range phases {
+				err = r.lib.LookupEpochPhase(epoch.Counter, phase, height)(tx)
+				if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+					return fmt.Errorf("could not look up epoch phase (counter: %d, phase: %s): %w", epoch.Counter, phase, err)
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return epochs, nil
+}
+
+// Identities returns the identity table of the epoch with the given counter.
+func (r *Reader) Identities(ctx context.Context, counter uint64) (flow.IdentityList, error) {
+	var identities flow.IdentityList
+	err := r.view(ctx, r.lib.RetrieveIdentities(counter, &identities))
+	return identities, err
+}
+
+// EventTypes returns the statistics of all event types that were indexed.
+func (r *Reader) EventTypes(ctx context.Context) ([]dps.EventTypeStats, error) {
+	var stats []dps.EventTypeStats
+	err := r.view(ctx, r.lib.RetrieveEventTypeStats(ctx, &stats))
+	return stats, err
+}
+
 // Seal returns the seal with the given ID.
-func (r *Reader) Seal(sealID flow.Identifier) (*flow.Seal, error) {
+func (r *Reader) Seal(ctx context.Context, sealID flow.Identifier) (*flow.Seal, error) {
 	var seal flow.Seal
-	err := r.db.View(r.lib.RetrieveSeal(sealID, &seal))
+	err := r.view(ctx, r.lib.RetrieveSeal(sealID, &seal))
 	return &seal, err
 }
 
 // SealsByHeight returns all of the seals that were part of the finalized block at the given height.
-func (r *Reader) SealsByHeight(height uint64) ([]flow.Identifier, error) {
+func (r *Reader) SealsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error) {
 	var sealIDs []flow.Identifier
-	err := r.db.View(r.lib.LookupSealsForHeight(height, &sealIDs))
+	err := r.view(ctx, r.lib.LookupSealsForHeight(height, &sealIDs))
 	return sealIDs, err
 }
+
+// RawHeader returns the header for the finalized block at the given height, as
+// it is encoded in the index.
+func (r *Reader) RawHeader(ctx context.Context, height uint64) ([]byte, error) {
+	var data []byte
+	err := r.view(ctx, r.lib.RetrieveRawHeader(height, &data))
+	return data, err
+}
+
+// RawEvents returns the events of all transactions that were part of the
+// finalized block at the given height, as they are encoded in the index, in
+// one batch per event type. It can optionally filter them by event type; if no
+// event types are given, all batches are returned.
+func (r *Reader) RawEvents(ctx context.Context, height uint64, types ...flow.EventType) ([][]byte, error) {
+	if r.tx == nil {
+		snapshot, discard := r.snapshot()
+		defer discard()
+		return snapshot.RawEvents(ctx, height, types...)
+	}
+	_, err := r.indexed(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.eventTypes(ctx, types)
+	if err != nil {
+		return nil, err
+	}
+
+	var data [][]byte
+	err = r.view(ctx, r.lib.RetrieveRawEvents(ctx, height, types, &data))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve events: %w", err)
+	}
+
+	return data, nil
+}
+
+// RawCollection returns the collection with the given ID, as it is encoded in
+// the index.
+func (r *Reader) RawCollection(ctx context.Context, collID flow.Identifier) ([]byte, error) {
+	var data []byte
+	err := r.view(ctx, r.lib.RetrieveRawCollection(collID, &data))
+	return data, err
+}
+
+// RawGuarantee returns the guarantee with the given collection ID, as it is
+// encoded in the index.
+func (r *Reader) RawGuarantee(ctx context.Context, collID flow.Identifier) ([]byte, error) {
+	var data []byte
+	err := r.view(ctx, r.lib.RetrieveRawGuarantee(collID, &data))
+	return data, err
+}
+
+// RawTransaction returns the transaction with the given ID, as it is encoded
+// in the index.
+func (r *Reader) RawTransaction(ctx context.Context, txID flow.Identifier) ([]byte, error) {
+	var data []byte
+	err := r.view(ctx, r.lib.RetrieveRawTransaction(txID, &data))
+	if err != nil {
+		return nil, r.filtered(ctx, err)
+	}
+	return data, nil
+}
+
+// RawSeal returns the seal with the given ID, as it is encoded in the index.
+func (r *Reader) RawSeal(ctx context.Context, sealID flow.Identifier) ([]byte, error) {
+	var data []byte
+	err := r.view(ctx, r.lib.RetrieveRawSeal(sealID, &data))
+	return data, err
+}
+
+// RawResult returns the transaction result for the given transaction ID, as it
+// is encoded in the index.
+func (r *Reader) RawResult(ctx context.Context, txID flow.Identifier) ([]byte, error) {
+	var data []byte
+	err := r.view(ctx, r.lib.RetrieveRawResult(txID, &data))
+	if err != nil {
+		return nil, r.filtered(ctx, err)
+	}
+	return data, nil
+}
+
+// ExecutionRecord returns the archived execution record of the block with the
+// given ID, in the original encoding in which it was downloaded. Execution
+// records are only archived when enabled, so it fails with dps.ErrNotIndexed
+// for blocks whose record was not archived.
+func (r *Reader) ExecutionRecord(ctx context.Context, blockID flow.Identifier) ([]byte, error) {
+	var data []byte
+	err := r.view(ctx, r.lib.RetrieveExecutionRecord(blockID, &data))
+	return data, err
+}
+
+// Filter returns the filter that restricted the indexed data to the data
+// involving some accounts. If all data was indexed, the filter is empty.
+func (r *Reader) Filter(ctx context.Context) (dps.Filter, error) {
+	var filter dps.Filter
+	err := r.view(ctx, func(tx *badger.Txn) error {
+		err := r.lib.RetrieveOwners(&filter.Allowed)(tx)
+		if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+			return fmt.Errorf("could not retrieve owners: %w", err)
+		}
+		err = r.lib.RetrieveDenied(&filter.Denied)(tx)
+		if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+			return fmt.Errorf("could not retrieve denied: %w", err)
+		}
+		return nil
+	})
+	return filter, err
+}
+
+// Verify computes the integrity manifests of the heights in the given range from
+// the data in the index, and returns the heights whose data does not match the
+// manifest stored for them. Heights without a stored manifest are skipped, as
+// they were indexed without integrity manifests.
+func (r *Reader) Verify(ctx context.Context, from uint64, to uint64) ([]uint64, error) {
+
+	var mismatches []uint64
+	err := r.view(ctx, func(tx *badger.Txn) error {
+
+		computed := make(map[uint64]*dps.Manifest)
+		err := r.lib.ComputeManifests(ctx, from, to, computed)(tx)
+		if err != nil {
+			return fmt.Errorf("could not compute manifests: %w", err)
+		}
+
+		for height := from; height <= to; height++ {
+			err = ctx.Err()
+			if err != nil {
+				return err
+			}
+			var stored dps.Manifest
+			err = r.lib.RetrieveManifest(height, &stored)(tx)
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("could not retrieve manifest (height: %d): %w", height, err)
+			}
+			if !stored.Matches(*computed[height]) {
+				mismatches = append(mismatches, height)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mismatches, nil
+}
+
+// Heights returns the heights in the given range for which the block data was
+// indexed, in ascending order.
+func (r *Reader) Heights(ctx context.Context, from uint64, to uint64) ([]uint64, error) {
+	var heights []uint64
+	err := r.view(ctx, r.lib.LookupHeights(ctx, from, to, &heights))
+	return heights, err
+}
+
+// filtered checks whether data that was not found in the index might have been
+// excluded by the filter of the index, in which case it fails precisely.
+func (r *Reader) filtered(ctx context.Context, err error) error {
+	if !errors.Is(err, dps.ErrNotIndexed) {
+		return err
+	}
+	filter, ferr := r.Filter(ctx)
+	if ferr != nil {
+		return fmt.Errorf("could not get filter: %w", ferr)
+	}
+	if filter.Empty() {
+		return err
+	}
+	return fmt.Errorf("data might be excluded by index filter: %w", dps.ErrNotIndexed)
+}
+
+// view executes the given operation in a read-only transaction, or in the
+// transaction of the snapshot, unless the given context is already done. Range
+// scans are given the same context, so that they stop as soon as it is done. If
+// the data it reads is missing from the index, it fails with
+// `dps.ErrNotIndexed` instead of the error of the underlying database.
+func (r *Reader) view(ctx context.Context, op func(*badger.Txn) error) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+	if r.tx != nil {
+		err = op(r.tx)
+	} else {
+		err = r.db.View(r.scheduled(op))
+	}
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return fmt.Errorf("%s: %w", err, dps.ErrNotIndexed)
+	}
+	return err
+}
+
+// scheduled registers the given operation as a read with the scheduler of the
+// reader, if it has one, for as long as the operation runs.
+func (r *Reader) scheduled(op func(*badger.Txn) error) func(*badger.Txn) error {
+	if r.cfg.Scheduler == nil {
+		return op
+	}
+	return func(tx *badger.Txn) error {
+		done := r.cfg.Scheduler.Read()
+		defer done()
+		return op(tx)
+	}
+}
+
+// cached returns whether the first and last heights are served from the cache
+// of the reader. Snapshots always read them from their own transaction, as the
+// cache can already hold heights that were committed after it was opened.
+func (r *Reader) cached() bool {
+	return r.cfg.Heights != nil && r.tx == nil
+}
+
+// bootstrapping translates a missing first or last height into
+// `dps.ErrBootstrapping`, as the index holds no data until it is bootstrapped.
+func bootstrapping(err error) error {
+	if errors.Is(err, dps.ErrNotIndexed) {
+		return fmt.Errorf("index is empty: %w", dps.ErrBootstrapping)
+	}
+	return err
+}
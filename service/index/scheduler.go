@@ -0,0 +1,130 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package index
+
+import (
+	"context"
+	"sync"
+)
+
+// Scheduler gives reads of the index priority over the transactions that bulk
+// writes commit to it. Reads are never held back; they only register while
+// they are in progress. Writers take a ticket before they commit a
+// transaction, and while any read is in progress, tickets are only handed out
+// as long as fewer than the contended number of transactions are committing.
+// Tickets are handed out in the order in which they were requested, so that
+// writers are slowed down, but never starved, by a steady stream of reads.
+type Scheduler struct {
+	mutex     *sync.Mutex
+	contended uint
+	reads     uint
+	writes    uint
+	queue     []chan struct{}
+}
+
+// NewScheduler creates a new scheduler, which limits the number of write
+// transactions that commit concurrently to the given number while reads are in
+// progress. The limit is at least one, so that writes always make progress.
+func NewScheduler(contended uint) *Scheduler {
+
+	if contended == 0 {
+		contended = 1
+	}
+
+	s := Scheduler{
+		mutex:     &sync.Mutex{},
+		contended: contended,
+	}
+
+	return &s
+}
+
+// Read registers a read of the index, which lasts until the returned function
+// is called.
+func (s *Scheduler) Read() func() {
+	s.mutex.Lock()
+	s.reads++
+	s.mutex.Unlock()
+
+	return func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.reads--
+		s.dispatch()
+	}
+}
+
+// Write waits for a ticket to commit a write transaction, until the given
+// context is done. The ticket has to be returned with Done once the
+// transaction was committed.
+func (s *Scheduler) Write(ctx context.Context) error {
+
+	s.mutex.Lock()
+	if len(s.queue) == 0 && s.available() {
+		s.writes++
+		s.mutex.Unlock()
+		return nil
+	}
+	ticket := make(chan struct{})
+	s.queue = append(s.queue, ticket)
+	s.mutex.Unlock()
+
+	select {
+	case <-ticket:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, waiting := range s.queue {
+		if waiting == ticket {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			return ctx.Err()
+		}
+	}
+
+	// If the ticket is no longer queued, it was handed out just as the context
+	// was done, so we return it for the next writer.
+	s.writes--
+	s.dispatch()
+
+	return ctx.Err()
+}
+
+// Done returns the ticket of a write transaction that was committed.
+func (s *Scheduler) Done() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.writes--
+	s.dispatch()
+}
+
+// available returns whether a ticket can be handed out. It must be called
+// while holding the mutex.
+func (s *Scheduler) available() bool {
+	return s.reads == 0 || s.writes < s.contended
+}
+
+// dispatch hands out tickets to the queued writers, for as long as they are
+// available. It must be called while holding the mutex.
+func (s *Scheduler) dispatch() {
+	for len(s.queue) > 0 && s.available() {
+		close(s.queue[0])
+		s.queue = s.queue[1:]
+		s.writes++
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package index
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_Write(t *testing.T) {
+
+	// waiting returns whether a call to write is still waiting for its ticket
+	// shortly after it was made.
+	waiting := func(done <-chan error) bool {
+		select {
+		case <-done:
+			return false
+		case <-time.After(20 * time.Millisecond):
+			return true
+		}
+	}
+
+	write := func(ctx context.Context, s *Scheduler) <-chan error {
+		done := make(chan error, 1)
+		go func() {
+			done <- s.Write(ctx)
+		}()
+		return done
+	}
+
+	t.Run("does not limit writes without reads", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewScheduler(1)
+
+		require.NoError(t, s.Write(context.Background()))
+		require.NoError(t, s.Write(context.Background()))
+		require.NoError(t, s.Write(context.Background()))
+
+		assert.Equal(t, uint(3), s.writes)
+	})
+
+	t.Run("limits writes during reads", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewScheduler(1)
+
+		done := s.Read()
+		require.NoError(t, s.Write(context.Background()))
+
+		second := write(context.Background(), s)
+		assert.True(t, waiting(second))
+
+		// Once the first write is done, the second one gets its ticket even
+		// though the read is still in progress.
+		s.Done()
+		assert.NoError(t, <-second)
+
+		third := write(context.Background(), s)
+		assert.True(t, waiting(third))
+
+		done()
+		assert.NoError(t, <-third)
+	})
+
+	t.Run("hands out tickets in order", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewScheduler(1)
+
+		done := s.Read()
+		defer done()
+		require.NoError(t, s.Write(context.Background()))
+
+		first := write(context.Background(), s)
+		require.True(t, waiting(first))
+		second := write(context.Background(), s)
+		require.True(t, waiting(second))
+
+		s.Done()
+		assert.NoError(t, <-first)
+		assert.True(t, waiting(second))
+
+		s.Done()
+		assert.NoError(t, <-second)
+	})
+
+	t.Run("stops waiting when context is done", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewScheduler(1)
+
+		done := s.Read()
+		defer done()
+		require.NoError(t, s.Write(context.Background()))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		canceled := write(ctx, s)
+		require.True(t, waiting(canceled))
+
+		cancel()
+		assert.ErrorIs(t, <-canceled, context.Canceled)
+
+		s.Done()
+		assert.Empty(t, s.queue)
+		assert.Zero(t, s.writes)
+	})
+
+	t.Run("allows at least one write", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewScheduler(0)
+
+		done := s.Read()
+		defer done()
+
+		assert.NoError(t, s.Write(context.Background()))
+	})
+}
@@ -0,0 +1,167 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Sealer represents something that can provide the final state commitment of
+// the sealed execution result for the block at a given height.
+type Sealer interface {
+	Sealed(height uint64) (flow.StateCommitment, error)
+}
+
+// Staging manages the heights that were indexed speculatively, before their
+// execution results were sealed. It promotes them once they are sealed with the
+// state commitment that was indexed for them, and rolls them back when they
+// are sealed with a conflicting one.
+type Staging struct {
+	db    *badger.DB
+	lib   dps.Library
+	seals Sealer
+}
+
+// NewStaging creates a new staging manager for the given index database, whose
+// storage library stages its entries, using the given seals.
+func NewStaging(db *badger.DB, lib dps.Library, seals Sealer) *Staging {
+
+	s := Staging{
+		db:    db,
+		lib:   lib,
+		seals: seals,
+	}
+
+	return &s
+}
+
+// Pending returns the staged heights, which are neither promoted nor rolled
+// back yet, in ascending order.
+func (s *Staging) Pending() ([]uint64, error) {
+	var heights []uint64
+	err := s.db.View(s.lib.LookupStaging(context.Background(), &heights))
+	return heights, err
+}
+
+// Settle promotes the staged heights that were sealed with the state commitment
+// that was indexed for them. It stops at the first height that is not sealed
+// yet, and fails with `dps.ErrConflict` at the first height that was sealed
+// with a conflicting state commitment.
+func (s *Staging) Settle() error {
+
+	heights, err := s.Pending()
+	if err != nil {
+		return fmt.Errorf("could not get staged heights: %w", err)
+	}
+
+	for _, height := range heights {
+		ok, err := s.check(height)
+		if errors.Is(err, dps.ErrUnavailable) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not check staged height (height: %d): %w", height, err)
+		}
+		if !ok {
+			return fmt.Errorf("indexed state commitment does not match seal (height: %d): %w", height, dps.ErrConflict)
+		}
+
+		err = s.db.Update(s.lib.PromoteStaging(height))
+		if err != nil {
+			return fmt.Errorf("could not promote staged height (height: %d): %w", height, err)
+		}
+	}
+
+	return nil
+}
+
+// Rollback promotes the staged heights that were sealed with the state
+// commitment that was indexed for them, and rolls back all staged heights from
+// the first height that was sealed with a conflicting state commitment. It
+// returns the heights that were rolled back, if any. It should only be used
+// while nothing is writing to the index.
+func (s *Staging) Rollback() ([]uint64, error) {
+
+	heights, err := s.Pending()
+	if err != nil {
+		return nil, fmt.Errorf("could not get staged heights: %w", err)
+	}
+
+	conflict := -1
+	for i, height := range heights {
+		ok, err := s.check(height)
+		if errors.Is(err, dps.ErrUnavailable) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not check staged height (height: %d): %w", height, err)
+		}
+		if !ok {
+			conflict = i
+			break
+		}
+
+		err = s.db.Update(s.lib.PromoteStaging(height))
+		if err != nil {
+			return nil, fmt.Errorf("could not promote staged height (height: %d): %w", height, err)
+		}
+	}
+	if conflict < 0 {
+		return nil, nil
+	}
+
+	// Heights are rolled back from the most recent one downwards, so that each
+	// entry ends up with the value it had before the conflicting height.
+	rolled := heights[conflict:]
+	for i := len(rolled) - 1; i >= 0; i-- {
+		err = s.db.Update(s.lib.RollbackStaging(rolled[i]))
+		if err != nil {
+			return nil, fmt.Errorf("could not roll back staged height (height: %d): %w", rolled[i], err)
+		}
+	}
+
+	return rolled, nil
+}
+
+// check returns whether the given staged height was sealed with the state
+// commitment that was indexed for it. It fails with `dps.ErrUnavailable` if
+// the height is not sealed yet, or if its state commitment was not written to
+// the index yet.
+func (s *Staging) check(height uint64) (bool, error) {
+
+	sealed, err := s.seals.Sealed(height)
+	if err != nil {
+		return false, fmt.Errorf("could not get sealed commit: %w", err)
+	}
+
+	var indexed flow.StateCommitment
+	err = s.db.View(s.lib.RetrieveCommit(height, &indexed))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return false, dps.ErrUnavailable
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not get indexed commit: %w", err)
+	}
+
+	return sealed == indexed, nil
+}
@@ -15,9 +15,11 @@
 package index
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -33,6 +35,23 @@ import (
 
 // Writer implements the `index.Writer` interface to write indexing data to
 // an underlying Badger database.
+//
+// The operations of each call are applied to the pending transaction in the
+// order of the calls by a single committer goroutine, which the calls feed
+// through a bounded queue. The values of large sets of data are encoded and
+// compressed on worker goroutines before they reach the committer, so that
+// callers are not blocked by encoding them. Once the queue is full, calls block
+// until there is room, which slows down the caller to the pace at which data is
+// committed; callers can check whether it is full with `Saturated` to hold back
+// before they call the writer. The data given to the writer must not be modified after a call,
+// as it may still be encoded after the call returns, and an error applying the
+// operations of a call is returned by all later calls. The data of a height
+// written as a single batch is committed atomically, and fails to be written
+// when it does not fit within a single transaction.
+//
+// The methods that write a single kind of data for a height are not part of
+// `dps.Writer`, so that indexers can only write heights as a whole. They remain
+// for tools and tests that need to write partial data.
 type Writer struct {
 	sync.RWMutex
 	db   *badger.DB
@@ -42,9 +61,53 @@ type Writer struct {
 	sema *semaphore.Weighted
 	err  chan error
 
-	done  chan struct{}   // signals when no more new operations will be added
-	mutex *sync.Mutex     // guards the current transaction against concurrent access
-	wg    *sync.WaitGroup // keeps track of when the flush goroutine should exit
+	done   chan struct{}   // signals when no more new operations will be added
+	mutex  *sync.Mutex     // guards the current transaction against concurrent access
+	wg     *sync.WaitGroup // keeps track of when the flush goroutine should exit
+	fenced bool            // whether the current transaction already checked the fence
+	dirty  bool            // whether the current transaction holds any operations
+	bounds bool            // whether the current transaction updates the first or last height
+
+	filterMutex *sync.Mutex     // guards the path filter against concurrent access
+	filter      *dps.PathFilter // path filter of the current window, if not yet saved
+	window      uint64          // window of the current path filter
+
+	queue     chan *batch     // batches waiting to be applied, in the order of the calls
+	jobs      chan *batch     // batches waiting to be built by an encoding worker
+	order     *sync.Mutex     // keeps the batches in the same order on both channels
+	pipeline  *sync.WaitGroup // keeps track of when the workers and the committer exit
+	failMutex *sync.Mutex     // guards the failure against concurrent access
+	failure   error           // first error applying a batch, returned by all later calls
+}
+
+// batch holds the operations of a single call to the writer. When it has a
+// build function, its operations are built by an encoding worker, which closes
+// the ready channel once they are. The operations of an atomic batch are
+// committed together, or not at all.
+type batch struct {
+	build  func() []func(*badger.Txn) error
+	ops    []func(*badger.Txn) error
+	ready  chan struct{}
+	atomic bool
+}
+
+// byPath sorts payloads along with their paths, in the order of the paths.
+type byPath struct {
+	paths    []ledger.Path
+	payloads []*ledger.Payload
+}
+
+func (b *byPath) Len() int {
+	return len(b.paths)
+}
+
+func (b *byPath) Less(i int, j int) bool {
+	return bytes.Compare(b.paths[i][:], b.paths[j][:]) < 0
+}
+
+func (b *byPath) Swap(i int, j int) {
+	b.paths[i], b.paths[j] = b.paths[j], b.paths[i]
+	b.payloads[i], b.payloads[j] = b.payloads[j], b.payloads[i]
 }
 
 // NewWriter creates a new index writer that writes new indexing data to the
@@ -67,7 +130,28 @@ func NewWriter(db *badger.DB, lib dps.WriteLibrary, options ...func(*Config)) *W
 		done:  make(chan struct{}),
 		mutex: &sync.Mutex{},
 		wg:    &sync.WaitGroup{},
+
+		filterMutex: &sync.Mutex{},
+
+		queue:     make(chan *batch, cfg.QueueSize),
+		jobs:      make(chan *batch, cfg.QueueSize+1),
+		order:     &sync.Mutex{},
+		pipeline:  &sync.WaitGroup{},
+		failMutex: &sync.Mutex{},
+	}
+
+	// Batches waiting in the queue can be waiting for a worker, as can the one
+	// the committer is waiting for, so the jobs channel never blocks. There is
+	// always at least one worker, so that all batches are eventually built.
+	workers := cfg.EncodingWorkers
+	if workers == 0 {
+		workers = 1
 	}
+	w.pipeline.Add(int(workers) + 1)
+	for i := uint(0); i < workers; i++ {
+		go w.work()
+	}
+	go w.commit()
 
 	// No flush interval means that flushing is disabled, and we only commit
 	// badger transactions that are full. This optimizes throughput of writing
@@ -82,52 +166,438 @@ func NewWriter(db *badger.DB, lib dps.WriteLibrary, options ...func(*Config)) *W
 }
 
 // First indexes the height of the first finalized block.
-func (w *Writer) First(height uint64) error {
-	return w.apply(w.lib.SaveFirst(height))
+func (w *Writer) First(ctx context.Context, height uint64) error {
+	return w.apply(ctx, w.bound(w.lib.SaveFirst(height)))
 }
 
 // Last indexes the height of the last finalized block.
-func (w *Writer) Last(height uint64) error {
-	return w.apply(w.lib.SaveLast(height))
+func (w *Writer) Last(ctx context.Context, height uint64) error {
+
+	if w.cfg.PathFilters {
+		err := w.complete(height)
+		if err != nil {
+			return fmt.Errorf("could not complete path filter: %w", err)
+		}
+	}
+
+	// The running size statistics are persisted along with the last height,
+	// so that they are never far behind the indexed data.
+	return w.apply(ctx, w.bound(w.lib.SaveLast(height)), w.lib.SaveSizeStats())
+}
+
+// Manifest indexes the integrity manifest of the given height, which covers the
+// data indexed for it since the manifest of the previous height. It does nothing
+// unless the storage library records integrity manifests.
+func (w *Writer) Manifest(ctx context.Context, height uint64) error {
+	return w.apply(ctx, w.lib.SaveManifest(height))
+}
+
+// Stage indexes the previous values of the data indexed for the given height
+// since the previous staged height, so that it can be rolled back until it is
+// promoted. It does nothing unless the storage library stages its entries.
+func (w *Writer) Stage(ctx context.Context, height uint64) error {
+	return w.apply(ctx, w.lib.SaveStaging(height))
+}
+
+// WriteHeightBatch indexes all of the given data of the finalized block at a
+// given height as a single unit. Its operations are applied in one go, in a
+// transaction that holds no operations of previous heights, so that the data
+// of the height is committed atomically, along with the first and last heights
+// when they are given. If the data does not fit within a single transaction,
+// none of it is committed, and the writer fails.
+//
+// Batches that only hold a chunk of the registers of a height are the
+// exception: they are applied like the operations of any other call, and can
+// be spread over several transactions, so a height whose registers are split
+// into chunks is NOT written atomically. Its registers are only hidden because
+// the last height is written with the batch that completes the height, and
+// never with a chunk, so that readers refuse the height until then. If the
+// indexer stops between chunks, the registers that were committed stay in the
+// index, unreadable, and are written again once it resumes from the last
+// height. When heights at or below the last height are written again, as when
+// reindexing, their chunks are visible as soon as they are committed.
+func (w *Writer) WriteHeightBatch(ctx context.Context, data dps.HeightData) error {
+
+	if len(data.Paths) != len(data.Payloads) {
+		return fmt.Errorf("mismatch between paths and payloads counts")
+	}
+
+	for _, delta := range data.RegisterDeltas {
+		if delta.Height != data.Height {
+			return fmt.Errorf("mismatch between delta height and indexed height (delta: %d, height: %d)", delta.Height, data.Height)
+		}
+	}
+
+	if w.cfg.PathFilters {
+		err := w.track(data.Height, data.Paths)
+		if err != nil {
+			return fmt.Errorf("could not track paths: %w", err)
+		}
+		if data.Last {
+			err = w.complete(data.Height)
+			if err != nil {
+				return fmt.Errorf("could not complete path filter: %w", err)
+			}
+		}
+	}
+
+	// Without block or execution data, and without completing the height, the
+	// batch can only hold a chunk of the registers of the height.
+	chunk := data.Header == nil && data.Commit == nil && !data.Complete && !data.First && !data.Last
+	b := batch{
+		build:  func() []func(*badger.Txn) error { return w.heightOps(data) },
+		ready:  make(chan struct{}),
+		atomic: !chunk,
+	}
+
+	return w.submit(ctx, &b)
+}
+
+// heightOps builds the operations that index the given data of a height, in
+// the order in which they would be applied by the individual calls.
+func (w *Writer) heightOps(data dps.HeightData) []func(*badger.Txn) error {
+
+	height := data.Height
+
+	var ops []func(*badger.Txn) error
+	if data.Header != nil {
+		ops = append(ops, w.lib.IndexHeightForBlock(data.BlockID, height))
+		ops = append(ops, w.lib.SaveHeader(height, data.Header))
+		ops = append(ops, w.guaranteeOps(data.Guarantees)...)
+		ops = append(ops, w.sealOps(height, data.Seals)...)
+	}
+	if data.Commit != nil {
+		ops = append(ops, w.lib.SaveCommit(height, *data.Commit))
+		ops = append(ops, w.lib.IndexHeightForCommit(*data.Commit, height))
+	}
+	if data.Header != nil {
+		ops = append(ops, w.collectionOps(height, data.Collections)...)
+		ops = append(ops, w.transactionOps(height, data.Transactions)...)
+	}
+	ops = append(ops, w.resultOps(data.Results)...)
+	ops = append(ops, w.eventOps(height, data.Events)...)
+	if data.BlockStats != nil {
+		ops = append(ops, w.lib.SaveBlockStats(*data.BlockStats))
+	}
+	ops = append(ops, w.eventTypeOps(data.EventTypes)...)
+	if len(data.ServiceEvents) > 0 {
+		ops = append(ops, w.lib.SaveServiceEvents(height, data.ServiceEvents))
+	}
+	ops = append(ops, w.accountOps(height, data.Accounts)...)
+	ops = append(ops, w.keyUpdateOps(height, data.KeyUpdates)...)
+	ops = append(ops, w.contractOps(height, data.Contracts)...)
+	if data.RegisterChurn != nil {
+		ops = append(ops, w.lib.SaveRegisterChurn(*data.RegisterChurn))
+	}
+	ops = append(ops, w.deltaOps(data.RegisterDeltas)...)
+
+	// Within a height, the payloads are saved in the order of their paths, so
+	// that the keys of the transaction are written in sorted order.
+	paths := make([]ledger.Path, len(data.Paths))
+	payloads := make([]*ledger.Payload, len(data.Payloads))
+	copy(paths, data.Paths)
+	copy(payloads, data.Payloads)
+	sort.Sort(&byPath{paths: paths, payloads: payloads})
+	ops = append(ops, w.payloadOps(height, paths, payloads)...)
+
+	if data.Complete {
+		ops = append(ops, w.lib.SaveManifest(height))
+	}
+	if data.First {
+		ops = append(ops, w.bound(w.lib.SaveFirst(height)))
+	}
+	if data.Last {
+		ops = append(ops, w.bound(w.lib.SaveLast(height)), w.lib.SaveSizeStats())
+	}
+	if data.Complete {
+		ops = append(ops, w.lib.SaveStaging(height))
+	}
+
+	return ops
 }
 
 // Height indexes the height for the given block ID.
-func (w *Writer) Height(blockID flow.Identifier, height uint64) error {
-	return w.apply(w.lib.IndexHeightForBlock(blockID, height))
+func (w *Writer) Height(ctx context.Context, blockID flow.Identifier, height uint64) error {
+	return w.apply(ctx, w.lib.IndexHeightForBlock(blockID, height))
+}
+
+// Accounts indexes the given height as the creation height of the accounts
+// with the given addresses.
+func (w *Writer) Accounts(ctx context.Context, height uint64, addresses []flow.Address) error {
+	return w.apply(ctx, w.accountOps(height, addresses)...)
+}
+
+// KeyUpdates indexes the given height as a height at which keys were added to
+// or removed from the accounts with the given addresses. Each address is given
+// once per key that was added or removed.
+func (w *Writer) KeyUpdates(ctx context.Context, height uint64, addresses []flow.Address) error {
+	return w.apply(ctx, w.keyUpdateOps(height, addresses)...)
+}
+
+// Contracts indexes the given changes to contracts at the given height.
+func (w *Writer) Contracts(ctx context.Context, height uint64, versions []dps.ContractVersion) error {
+	return w.encode(ctx, func() []func(*badger.Txn) error {
+		return w.contractOps(height, versions)
+	})
 }
 
 // Commit indexes the given commitment of the execution state as it was after
 // the execution of the finalized block at the given height.
-func (w *Writer) Commit(height uint64, commit flow.StateCommitment) error {
-	return w.apply(w.lib.SaveCommit(height, commit))
+func (w *Writer) Commit(ctx context.Context, height uint64, commit flow.StateCommitment) error {
+	return w.apply(ctx,
+		w.lib.SaveCommit(height, commit),
+		w.lib.IndexHeightForCommit(commit, height),
+	)
 }
 
 // Header indexes the given header of a finalized block at the given height.
-func (w *Writer) Header(height uint64, header *flow.Header) error {
-	return w.apply(w.lib.SaveHeader(height, header))
+func (w *Writer) Header(ctx context.Context, height uint64, header *flow.Header) error {
+	return w.apply(ctx, w.lib.SaveHeader(height, header))
+}
+
+// BlockStats indexes the given aggregate statistics of a finalized block.
+func (w *Writer) BlockStats(ctx context.Context, stats dps.BlockStats) error {
+	return w.apply(ctx, w.lib.SaveBlockStats(stats))
 }
 
 // Payloads indexes the given payloads, which should represent a trie update
 // of the execution state contained within the finalized block at the given
 // height.
-func (w *Writer) Payloads(height uint64, paths []ledger.Path, payloads []*ledger.Payload) error {
+func (w *Writer) Payloads(ctx context.Context, height uint64, paths []ledger.Path, payloads []*ledger.Payload) error {
 
 	if len(paths) != len(payloads) {
 		return fmt.Errorf("mismatch between paths and payloads counts")
 	}
 
-	ops := make([]func(*badger.Txn) error, 0, len(payloads))
+	if w.cfg.PathFilters {
+		err := w.track(height, paths)
+		if err != nil {
+			return fmt.Errorf("could not track paths: %w", err)
+		}
+	}
+
+	return w.encode(ctx, func() []func(*badger.Txn) error {
+		return w.payloadOps(height, paths, payloads)
+	})
+}
+
+// RegisterChurn indexes the given register churn of a finalized block.
+func (w *Writer) RegisterChurn(ctx context.Context, churn dps.RegisterChurn) error {
+	return w.apply(ctx, w.lib.SaveRegisterChurn(churn))
+}
+
+// RegisterDeltas indexes the given changes to the registers made by the
+// finalized block at the given height.
+func (w *Writer) RegisterDeltas(ctx context.Context, height uint64, deltas []dps.RegisterDelta) error {
+
+	for _, delta := range deltas {
+		if delta.Height != height {
+			return fmt.Errorf("mismatch between delta height and indexed height (delta: %d, height: %d)", delta.Height, height)
+		}
+	}
+
+	return w.encode(ctx, func() []func(*badger.Txn) error {
+		return w.deltaOps(deltas)
+	})
+}
 
+// Collections indexes the collections at the given height.
+func (w *Writer) Collections(ctx context.Context, height uint64, collections []*flow.LightCollection) error {
+	return w.encode(ctx, func() []func(*badger.Txn) error {
+		return w.collectionOps(height, collections)
+	})
+}
+
+// Guarantees indexes the guarantees at the given height.
+func (w *Writer) Guarantees(ctx context.Context, _ uint64, guarantees []*flow.CollectionGuarantee) error {
+	return w.encode(ctx, func() []func(*badger.Txn) error {
+		return w.guaranteeOps(guarantees)
+	})
+}
+
+// Transactions indexes the transactions at the given height.
+func (w *Writer) Transactions(ctx context.Context, height uint64, transactions []*flow.TransactionBody) error {
+	return w.encode(ctx, func() []func(*badger.Txn) error {
+		return w.transactionOps(height, transactions)
+	})
+}
+
+// Results indexes the transaction results at the given height.
+func (w *Writer) Results(ctx context.Context, results []*flow.TransactionResult) error {
+	return w.encode(ctx, func() []func(*badger.Txn) error {
+		return w.resultOps(results)
+	})
+}
+
+// Events indexes the events, which should represent all events of the finalized
+// block at the given height.
+func (w *Writer) Events(ctx context.Context, height uint64, events []flow.Event) error {
+	return w.encode(ctx, func() []func(*badger.Txn) error {
+		return w.eventOps(height, events)
+	})
+}
+
+// ServiceEvents indexes the given service events emitted at the given height.
+func (w *Writer) ServiceEvents(ctx context.Context, height uint64, events []flow.Event) error {
+	return w.encode(ctx, func() []func(*badger.Txn) error {
+		return []func(*badger.Txn) error{w.lib.SaveServiceEvents(height, events)}
+	})
+}
+
+// Epoch indexes the description and the identity table of the epoch that was
+// set up by the given epoch setup event.
+func (w *Writer) Epoch(ctx context.Context, setup *flow.EpochSetup) error {
+
+	epoch := dps.Epoch{
+		Counter:            setup.Counter,
+		FirstView:          setup.FirstView,
+		DKGPhase1FinalView: setup.DKGPhase1FinalView,
+		DKGPhase2FinalView: setup.DKGPhase2FinalView,
+		DKGPhase3FinalView: setup.DKGPhase3FinalView,
+		FinalView:          setup.FinalView,
+	}
+
+	return w.encode(ctx, func() []func(*badger.Txn) error {
+		return []func(*badger.Txn) error{
+			w.lib.SaveEpoch(&epoch),
+			w.lib.SaveIdentities(setup.Counter, setup.Participants),
+		}
+	})
+}
+
+// Phase indexes the height at which the given phase of the epoch with the
+// given counter started.
+func (w *Writer) Phase(ctx context.Context, counter uint64, phase flow.EpochPhase, height uint64) error {
+	return w.apply(ctx, w.lib.IndexEpochPhase(counter, phase, height))
+}
+
+// EventTypes updates the registry of indexed event types with the given
+// statistics.
+func (w *Writer) EventTypes(ctx context.Context, stats []dps.EventTypeStats) error {
+	return w.apply(ctx, w.eventTypeOps(stats)...)
+}
+
+// Seals indexes the seals, which should represent all seals in the finalized
+// block at the given height.
+func (w *Writer) Seals(ctx context.Context, height uint64, seals []*flow.Seal) error {
+	return w.encode(ctx, func() []func(*badger.Txn) error {
+		return w.sealOps(height, seals)
+	})
+}
+
+// Corruption records that corrupted write-ahead log data or execution records
+// were skipped while indexing the given height.
+func (w *Writer) Corruption(ctx context.Context, height uint64, reason string) error {
+	return w.apply(ctx, w.lib.SaveCorruption(height, reason))
+}
+
+// ExecutionRecord archives the given execution record of the block with the
+// given ID, in its original encoding.
+func (w *Writer) ExecutionRecord(ctx context.Context, blockID flow.Identifier, data []byte) error {
+	return w.encode(ctx, func() []func(*badger.Txn) error {
+		return []func(*badger.Txn) error{w.lib.SaveExecutionRecord(blockID, data)}
+	})
+}
+
+// Filter records the filter that restricts the indexed data to the data
+// involving some accounts.
+func (w *Writer) Filter(ctx context.Context, filter dps.Filter) error {
+	var ops []func(*badger.Txn) error
+	if len(filter.Allowed) > 0 {
+		ops = append(ops, w.lib.SaveOwners(filter.Allowed))
+	}
+	if len(filter.Denied) > 0 {
+		ops = append(ops, w.lib.SaveDenied(filter.Denied))
+	}
+	return w.apply(ctx, ops...)
+}
+
+// accountOps builds the operations that index the given height as the creation
+// height of the accounts with the given addresses.
+func (w *Writer) accountOps(height uint64, addresses []flow.Address) []func(*badger.Txn) error {
+	ops := make([]func(*badger.Txn) error, 0, len(addresses))
+	for _, address := range addresses {
+		ops = append(ops, w.lib.IndexHeightForAccount(address, height))
+	}
+	return ops
+}
+
+// keyUpdateOps builds the operations that index the key updates of the accounts
+// with the given addresses at the given height.
+func (w *Writer) keyUpdateOps(height uint64, addresses []flow.Address) []func(*badger.Txn) error {
+
+	counts := make(map[flow.Address]uint)
+	for _, address := range addresses {
+		counts[address]++
+	}
+
+	ops := make([]func(*badger.Txn) error, 0, len(counts))
+	for address, count := range counts {
+		ops = append(ops, w.lib.IndexKeyUpdate(address, height, count))
+	}
+
+	return ops
+}
+
+// contractOps builds the operations that index the given changes to contracts
+// at the given height.
+func (w *Writer) contractOps(height uint64, versions []dps.ContractVersion) []func(*badger.Txn) error {
+
+	type contract struct {
+		address flow.Address
+		name    string
+	}
+
+	// Changes to the same contract at the same height are stored together, in
+	// the order in which they happened.
+	var contracts []contract
+	grouped := make(map[contract][]dps.ContractVersion)
+	for _, version := range versions {
+		c := contract{address: version.Address, name: version.Name}
+		_, ok := grouped[c]
+		if !ok {
+			contracts = append(contracts, c)
+		}
+		grouped[c] = append(grouped[c], version)
+	}
+
+	ops := make([]func(*badger.Txn) error, 0, len(contracts))
+	for _, c := range contracts {
+		ops = append(ops, w.lib.SaveContractVersions(c.address, c.name, height, grouped[c]))
+	}
+
+	return ops
+}
+
+// payloadOps builds the operations that index the given payloads at the given
+// height. Besides the payload itself, we record the path of each register
+// under the address of its owner, so that all registers of an account can be
+// listed without knowing their keys.
+func (w *Writer) payloadOps(height uint64, paths []ledger.Path, payloads []*ledger.Payload) []func(*badger.Txn) error {
+	ops := make([]func(*badger.Txn) error, 0, 2*len(payloads))
 	for i, path := range paths {
 		payload := payloads[i]
 		ops = append(ops, w.lib.SavePayload(height, path, payload))
+		owner, ok := dps.PayloadOwner(payload)
+		if ok {
+			ops = append(ops, w.lib.IndexPathForOwner(owner, path, height))
+		}
 	}
+	return ops
+}
 
-	return w.apply(ops...)
+// deltaOps builds the operations that index the given register deltas.
+func (w *Writer) deltaOps(deltas []dps.RegisterDelta) []func(*badger.Txn) error {
+	ops := make([]func(*badger.Txn) error, 0, len(deltas))
+	for _, delta := range deltas {
+		ops = append(ops, w.lib.SaveRegisterDelta(delta))
+	}
+	return ops
 }
 
-// Collections indexes the collections at the given height.
-func (w *Writer) Collections(height uint64, collections []*flow.LightCollection) error {
+// collectionOps builds the operations that index the given collections at the
+// given height.
+func (w *Writer) collectionOps(height uint64, collections []*flow.LightCollection) []func(*badger.Txn) error {
 
 	ops := make([]func(*badger.Txn) error, 0, 2*len(collections)+1)
 
@@ -141,24 +611,28 @@ func (w *Writer) Collections(height uint64, collections []*flow.LightCollection)
 
 	ops = append(ops, w.lib.IndexCollectionsForHeight(height, collIDs))
 
-	return w.apply(ops...)
+	return ops
 }
 
-// Guarantees indexes the guarantees at the given height.
-func (w *Writer) Guarantees(_ uint64, guarantees []*flow.CollectionGuarantee) error {
-
+// guaranteeOps builds the operations that index the given guarantees.
+func (w *Writer) guaranteeOps(guarantees []*flow.CollectionGuarantee) []func(*badger.Txn) error {
 	ops := make([]func(*badger.Txn) error, 0, len(guarantees))
 	for _, guarantee := range guarantees {
 		ops = append(ops, w.lib.SaveGuarantee(guarantee))
 	}
-
-	return w.apply(ops...)
+	return ops
 }
 
-// Transactions indexes the transactions at the given height.
-func (w *Writer) Transactions(height uint64, transactions []*flow.TransactionBody) error {
+// transactionOps builds the operations that index the given transactions at
+// the given height.
+func (w *Writer) transactionOps(height uint64, transactions []*flow.TransactionBody) []func(*badger.Txn) error {
+
+	ops := make([]func(*badger.Txn) error, 0, 3*len(transactions)+1)
 
-	ops := make([]func(*badger.Txn) error, 0, 2*len(transactions)+1)
+	// Transactions are also indexed by the hash of their script, so that
+	// all uses of a known transaction template can be listed.
+	var hashes []flow.Identifier
+	scripts := make(map[flow.Identifier][]flow.Identifier)
 
 	txIDs := make([]flow.Identifier, 0, len(transactions))
 	for _, transaction := range transactions {
@@ -166,28 +640,35 @@ func (w *Writer) Transactions(height uint64, transactions []*flow.TransactionBod
 		txIDs = append(txIDs, txID)
 		ops = append(ops, w.lib.SaveTransaction(transaction))
 		ops = append(ops, w.lib.IndexHeightForTransaction(txID, height))
+
+		hash := dps.ScriptHash(transaction.Script)
+		_, ok := scripts[hash]
+		if !ok {
+			hashes = append(hashes, hash)
+		}
+		scripts[hash] = append(scripts[hash], txID)
 	}
 
 	ops = append(ops, w.lib.IndexTransactionsForHeight(height, txIDs))
+	for _, hash := range hashes {
+		ops = append(ops, w.lib.IndexTransactionsForScript(hash, height, scripts[hash]))
+	}
 
-	return w.apply(ops...)
+	return ops
 }
 
-// Results indexes the transaction results at the given height.
-func (w *Writer) Results(results []*flow.TransactionResult) error {
-
+// resultOps builds the operations that index the given transaction results.
+func (w *Writer) resultOps(results []*flow.TransactionResult) []func(*badger.Txn) error {
 	ops := make([]func(*badger.Txn) error, 0, len(results))
-
 	for _, result := range results {
 		ops = append(ops, w.lib.SaveResult(result))
 	}
-
-	return w.apply(ops...)
+	return ops
 }
 
-// Events indexes the events, which should represent all events of the finalized
-// block at the given height.
-func (w *Writer) Events(height uint64, events []flow.Event) error {
+// eventOps builds the operations that index the given events at the given
+// height, grouped by event type.
+func (w *Writer) eventOps(height uint64, events []flow.Event) []func(*badger.Txn) error {
 
 	buckets := make(map[flow.EventType][]flow.Event)
 	for _, event := range events {
@@ -195,17 +676,27 @@ func (w *Writer) Events(height uint64, events []flow.Event) error {
 	}
 
 	ops := make([]func(*badger.Txn) error, 0, len(buckets))
-
 	for typ, set := range buckets {
 		ops = append(ops, w.lib.SaveEvents(height, typ, set))
 	}
 
-	return w.apply(ops...)
+	return ops
 }
 
-// Seals indexes the seals, which should represent all seals in the finalized
-// block at the given height.
-func (w *Writer) Seals(height uint64, seals []*flow.Seal) error {
+// eventTypeOps builds the operations that index the given statistics of event
+// types.
+func (w *Writer) eventTypeOps(stats []dps.EventTypeStats) []func(*badger.Txn) error {
+
+	ops := make([]func(*badger.Txn) error, 0, len(stats))
+	for i := range stats {
+		ops = append(ops, w.lib.SaveEventTypeStats(&stats[i]))
+	}
+
+	return ops
+}
+
+// sealOps builds the operations that index the given seals at the given height.
+func (w *Writer) sealOps(height uint64, seals []*flow.Seal) []func(*badger.Txn) error {
 
 	ops := make([]func(*badger.Txn) error, 0, len(seals)+1)
 
@@ -218,34 +709,204 @@ func (w *Writer) Seals(height uint64, seals []*flow.Seal) error {
 
 	ops = append(ops, w.lib.IndexSealsForHeight(height, sealIDs))
 
-	return w.apply(ops...)
+	return ops
 }
 
-func (w *Writer) apply(ops ...func(*badger.Txn) error) error {
+// track adds the given paths written at the given height to the path filter
+// of the window that contains the height.
+func (w *Writer) track(height uint64, paths []ledger.Path) error {
+	w.filterMutex.Lock()
+	defer w.filterMutex.Unlock()
 
-	// Before applying an additional operation to the transaction we are
-	// currently building, we want to see if there was an error committing any
-	// previous transaction.
+	err := w.roll(height)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		w.filter.Add(path)
+	}
+
+	return nil
+}
+
+// complete marks the given height as fully indexed in the path filter of its
+// window. Once the last height of a window is complete, the filter is saved,
+// so that readers can use it right away.
+func (w *Writer) complete(height uint64) error {
+	w.filterMutex.Lock()
+	defer w.filterMutex.Unlock()
+
+	err := w.roll(height)
+	if err != nil {
+		return err
+	}
+
+	if height > w.filter.To {
+		w.filter.To = height
+	}
+
+	if height%dps.PathFilterWindow != dps.PathFilterWindow-1 {
+		return nil
+	}
+
+	return w.save()
+}
+
+// roll makes sure that the current path filter is the one for the window that
+// contains the given height. The filter of the previous window is saved first,
+// unless it was already saved.
+func (w *Writer) roll(height uint64) error {
+
+	window := dps.WindowForHeight(height)
+	if w.filter != nil && w.window == window {
+		return nil
+	}
+
+	err := w.save()
+	if err != nil {
+		return err
+	}
+
+	w.filter = dps.NewPathFilter(height)
+	w.window = window
+
+	return nil
+}
+
+// save merges the current path filter into the one persisted for its window.
+// The filter is written directly to the database, rather than as part of the
+// pending transactions, because merging requires reading the persisted filter.
+// A filter that is saved before the payloads it covers is never a problem, as
+// it can only cause a lookup that would have happened anyway.
+func (w *Writer) save() error {
+
+	if w.filter == nil {
+		return nil
+	}
+
+	err := w.update(w.lib.UpdatePathFilter(w.window, w.filter))
+	if err != nil {
+		return fmt.Errorf("could not update path filter (window: %d): %w", w.window, err)
+	}
+
+	w.filter = nil
+
+	return nil
+}
+
+// apply submits the given operations, which are already built, so that they
+// are applied after the operations of all previous calls.
+func (w *Writer) apply(ctx context.Context, ops ...func(*badger.Txn) error) error {
+	ready := make(chan struct{})
+	close(ready)
+	b := batch{
+		ops:   ops,
+		ready: ready,
+	}
+	return w.submit(ctx, &b)
+}
+
+// encode submits the operations built by the given function, which is called
+// on one of the encoding workers, so that the values of large sets of data are
+// encoded and compressed without blocking the caller. The operations are still
+// applied after the operations of all previous calls.
+func (w *Writer) encode(ctx context.Context, build func() []func(*badger.Txn) error) error {
+	b := batch{
+		build: build,
+		ready: make(chan struct{}),
+	}
+	return w.submit(ctx, &b)
+}
+
+// submit adds the given batch to the queue of the committer, and to the jobs
+// of the workers if it still has to be built. It blocks while the queue is
+// full, unless the given context is done, in which case the batch is dropped.
+func (w *Writer) submit(ctx context.Context, b *batch) error {
+
+	// Before submitting additional operations, we want to see if there was an
+	// error committing a previous transaction, or applying the operations of
+	// a previous call.
 	select {
 	case err := <-w.err:
 		return fmt.Errorf("could not commit transaction: %w", err)
 	default:
 		// skip
 	}
+	err := w.failed()
+	if err != nil {
+		return err
+	}
+	err = ctx.Err()
+	if err != nil {
+		return err
+	}
+
+	w.order.Lock()
+	defer w.order.Unlock()
+
+	select {
+	case w.queue <- b:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if b.build != nil {
+		w.jobs <- b
+	}
+
+	return nil
+}
+
+// work builds the operations of submitted batches until the writer is closed.
+func (w *Writer) work() {
+	defer w.pipeline.Done()
+
+	for b := range w.jobs {
+		b.ops = b.build()
+		close(b.ready)
+	}
+}
+
+// commit applies the operations of queued batches, in the order in which they
+// were submitted, until the writer is closed. After the first failure, the
+// remaining batches are dropped, as the index would otherwise miss some of
+// the data of the failed batch.
+func (w *Writer) commit() {
+	defer w.pipeline.Done()
+
+	for b := range w.queue {
+		<-b.ready
+		if w.failed() != nil {
+			continue
+		}
+		var err error
+		if b.atomic {
+			err = w.writeAtomic(b.ops)
+		} else {
+			err = w.write(b.ops)
+		}
+		if err != nil {
+			w.fail(err)
+		}
+	}
+}
+
+// write applies the given operations to the current transaction. If the
+// transaction is already too big, we simply commit it with our callback and
+// start a new transaction. Transaction creation is guarded by a semaphore that
+// limits it to the configured number of inflight transactions.
+func (w *Writer) write(ops []func(*badger.Txn) error) error {
 
-	// If we had no error in a previous transaction, we try applying the
-	// operation to the current transaction. If the transaction is already too
-	// big, we simply commit it with our callback and start a new transaction.
-	// Transaction creation is guarded by a semaphore that limits it to the
-	// configured number of inflight transactions.
 	for _, op := range ops {
 		w.mutex.Lock()
-		err := op(w.tx)
+		err := w.execute(op)
 		if errors.Is(err, badger.ErrTxnTooBig) {
-			_ = w.sema.Acquire(context.Background(), 1)
-			w.tx.CommitWith(w.committed)
-			w.tx = w.db.NewTransaction(true)
-			err = op(w.tx)
+			err = w.rotate()
+			if err != nil {
+				w.mutex.Unlock()
+				return err
+			}
+			err = w.execute(op)
 		}
 		w.mutex.Unlock()
 		if err != nil {
@@ -256,20 +917,225 @@ func (w *Writer) apply(ops ...func(*badger.Txn) error) error {
 	return nil
 }
 
-func (w *Writer) committed(err error) {
+// writeAtomic applies the given operations, which hold all of the data of a
+// height, so that they are committed together. If the current transaction
+// already holds operations, it is committed first, so that the height starts
+// with a fresh transaction, and the mutex is held until all operations are
+// applied, so that the transaction can not be flushed with only part of them.
+// When the operations do not fit within a single transaction, the transaction
+// is discarded, so that none of them are committed, and the changes that they
+// made to the state of the library, such as its statistics, are reverted.
+func (w *Writer) writeAtomic(ops []func(*badger.Txn) error) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.dirty {
+		err := w.rotate()
+		if err != nil {
+			return err
+		}
+	}
+
+	// The transaction is tracked for as long as we might discard it, which is
+	// until all of the operations are applied.
+	tx := w.tx
+	err := w.lib.TrackChanges()(tx)
+	if err != nil {
+		return fmt.Errorf("could not track library changes: %w", err)
+	}
+	defer w.lib.ReleaseChanges()(tx)
+
+	for _, op := range ops {
+		err := w.execute(op)
+		if errors.Is(err, badger.ErrTxnTooBig) {
+			_ = w.lib.RevertChanges()(tx)
+			w.discard()
+			return fmt.Errorf("could not fit height data in a single transaction: %w", err)
+		}
+		if err != nil {
+			return fmt.Errorf("could not apply operation: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// rotate commits the current transaction with our callback and starts a new
+// transaction, once it claims a slot for inflight transactions. It must be
+// called while holding the mutex.
+func (w *Writer) rotate() error {
+
+	err := w.acquire(context.Background())
+	if err != nil {
+		return fmt.Errorf("could not acquire transaction slot: %w", err)
+	}
+
+	w.tx.CommitWith(w.callback())
+	w.tx = w.db.NewTransaction(true)
+	w.fenced = false
+	w.dirty = false
+
+	return nil
+}
+
+// discard drops the operations applied to the current transaction and starts a
+// new one in its place. It must be called while holding the mutex.
+func (w *Writer) discard() {
+	w.tx.Discard()
+	w.tx = w.db.NewTransaction(true)
+	w.fenced = false
+	w.dirty = false
+	w.bounds = false
+}
+
+// fail records the given error as the failure of the writer, unless it already
+// failed.
+func (w *Writer) fail(err error) {
+	w.failMutex.Lock()
+	defer w.failMutex.Unlock()
+	if w.failure == nil {
+		w.failure = err
+	}
+}
+
+// failed returns the failure of the writer, if it failed.
+func (w *Writer) failed() error {
+	w.failMutex.Lock()
+	defer w.failMutex.Unlock()
+	return w.failure
+}
+
+// Queued returns the number of calls whose operations are waiting to be
+// applied. It reaches the configured queue size when the writer applies
+// operations slower than they are submitted.
+func (w *Writer) Queued() int {
+	return len(w.queue)
+}
+
+// Saturated returns whether the queue of the writer is full, in which case
+// further calls block until the writer applied the operations ahead of them.
+// Without a queue, the writer is never considered saturated.
+func (w *Writer) Saturated() bool {
+	return cap(w.queue) > 0 && len(w.queue) >= cap(w.queue)
+}
+
+// execute applies the given operation to the current transaction. When fencing
+// is enabled, the transaction first reads the fence, so that it fails right
+// away if the fence was taken over, and fails to commit if it is taken over
+// before the transaction is committed. It must be called while holding the
+// mutex.
+func (w *Writer) execute(op func(*badger.Txn) error) error {
+
+	if w.cfg.Fence != 0 && !w.fenced {
+		err := w.lib.CheckFence(w.cfg.Fence)(w.tx)
+		if err != nil {
+			return err
+		}
+		w.fenced = true
+	}
+
+	err := op(w.tx)
+	if err != nil {
+		return err
+	}
+	w.dirty = true
+
+	return nil
+}
+
+// update applies the given operation in a transaction of its own, which also
+// checks the fence when fencing is enabled.
+func (w *Writer) update(op func(*badger.Txn) error) error {
+	return w.db.Update(func(tx *badger.Txn) error {
+		if w.cfg.Fence != 0 {
+			err := w.lib.CheckFence(w.cfg.Fence)(tx)
+			if err != nil {
+				return err
+			}
+		}
+		return op(tx)
+	})
+}
+
+// fence converts a transaction conflict into a fencing error when fencing is
+// enabled. Writer transactions only ever read the fence, so a conflict means
+// that it was taken over by another writer before the commit.
+func (w *Writer) fence(err error) error {
+	if w.cfg.Fence == 0 || !errors.Is(err, badger.ErrConflict) {
+		return err
+	}
+	return fmt.Errorf("%s: %w", err, dps.ErrFenced)
+}
+
+// bound wraps the given operation, which updates the first or last height, so
+// that the transaction it is applied to invalidates the cached heights once it
+// is committed. The operation is executed while holding the mutex.
+func (w *Writer) bound(op func(*badger.Txn) error) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+		err := op(tx)
+		if err != nil {
+			return err
+		}
+		w.bounds = true
+		return nil
+	}
+}
+
+// callback returns the commit callback for the current transaction. It must be
+// called while holding the mutex, right before the transaction is committed.
+func (w *Writer) callback() func(error) {
+	bounds := w.bounds
+	w.bounds = false
+	return func(err error) {
+		w.committed(err, bounds)
+	}
+}
+
+func (w *Writer) committed(err error, bounds bool) {
 
 	// When a transaction is fully committed, we get the result in this
 	// callback. In case of an error, we pipe it to the apply function through
-	// the error channel.
+	// the error channel. If it updated the first or last height, the readers
+	// that cache them have to read them again.
 	if err != nil {
-		w.err <- err
+		w.err <- w.fence(err)
+	}
+	if err == nil && bounds && w.cfg.Heights != nil {
+		w.cfg.Heights.Invalidate()
 	}
 
 	// Releasing one resource on the semaphore will free up one slot for
-	// inflight transactions.
+	// inflight transactions, and the ticket of the scheduler lets the next
+	// transaction be committed.
+	if w.cfg.Scheduler != nil {
+		w.cfg.Scheduler.Done()
+	}
 	w.sema.Release(1)
 }
 
+// acquire claims a slot for an inflight transaction, and then a ticket from the
+// scheduler, if there is one, so that transactions are committed with priority
+// given to reads.
+func (w *Writer) acquire(ctx context.Context) error {
+
+	err := w.sema.Acquire(ctx, 1)
+	if err != nil {
+		return err
+	}
+
+	if w.cfg.Scheduler == nil {
+		return nil
+	}
+
+	err = w.cfg.Scheduler.Write(ctx)
+	if err != nil {
+		w.sema.Release(1)
+		return err
+	}
+
+	return nil
+}
+
 // Close closes the writer and commits the pending transaction, if there is one.
 func (w *Writer) Close() error {
 
@@ -278,6 +1144,15 @@ func (w *Writer) Close() error {
 	close(w.done)
 	w.wg.Wait()
 
+	// We assume that we are no longer called when we call `Close`, so we can
+	// stop the pipeline, which applies all of the submitted operations before
+	// the workers and the committer exit.
+	w.order.Lock()
+	close(w.queue)
+	close(w.jobs)
+	w.order.Unlock()
+	w.pipeline.Wait()
+
 	// The first transaction we created did not claim a slot on the semaphore.
 	// This makes sense, because we only want to limit in-flight (committing)
 	// transactions. The currently building transaction is not in-progress.
@@ -287,7 +1162,10 @@ func (w *Writer) Close() error {
 	// here, without using the callback.
 	err := w.tx.Commit()
 	if err != nil {
-		return fmt.Errorf("could not commit final transaction: %w", err)
+		return fmt.Errorf("could not commit final transaction: %w", w.fence(err))
+	}
+	if w.bounds && w.cfg.Heights != nil {
+		w.cfg.Heights.Invalidate()
 	}
 
 	// Once we acquire all semaphore resources, it means all transactions have
@@ -299,6 +1177,41 @@ func (w *Writer) Close() error {
 	for err := range w.err {
 		merr = multierror.Append(merr, err)
 	}
+	err = w.failed()
+	if err != nil {
+		merr = multierror.Append(merr, err)
+	}
+
+	// The path filter of the current window covers the heights indexed so far,
+	// so we save it in order for the next run to be able to resume it.
+	w.filterMutex.Lock()
+	err = w.save()
+	w.filterMutex.Unlock()
+	if err != nil {
+		merr = multierror.Append(merr, fmt.Errorf("could not save path filter: %w", err))
+	}
+
+	// At this point, no more values are being saved, so we can persist the
+	// compression and size statistics recorded by the storage library, if
+	// enabled.
+	err = w.update(w.lib.UpdateCompressionStats())
+	if err != nil {
+		merr = multierror.Append(merr, fmt.Errorf("could not update compression statistics: %w", err))
+	}
+	err = w.update(w.lib.SaveSizeStats())
+	if err != nil {
+		merr = multierror.Append(merr, fmt.Errorf("could not save size statistics: %w", err))
+	}
+
+	// Finally, we release the fence, so that another writer can acquire it
+	// without having to take it over. If it was already taken over, this does
+	// not affect the new holder.
+	if w.cfg.Fence != 0 {
+		err = w.db.Update(w.lib.ReleaseFence(w.cfg.Fence))
+		if err != nil {
+			merr = multierror.Append(merr, fmt.Errorf("could not release fence: %w", err))
+		}
+	}
 
 	return merr.ErrorOrNil()
 }
@@ -314,9 +1227,11 @@ func (w *Writer) flush() {
 
 		case <-ticker.C:
 			w.mutex.Lock()
-			_ = w.sema.Acquire(context.Background(), 1)
-			w.tx.CommitWith(w.committed)
+			_ = w.acquire(context.Background())
+			w.tx.CommitWith(w.callback())
 			w.tx = w.db.NewTransaction(true)
+			w.fenced = false
+			w.dirty = false
 			w.mutex.Unlock()
 
 		case <-w.done:
@@ -15,6 +15,7 @@
 package initializer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -30,12 +31,12 @@ import (
 // CatchupBlocks will determine, based on what is in the protocol state and
 // index databases, which blocks we need to download the execution records for
 // in order to properly resume catching up with consensus.
-func CatchupBlocks(db *badger.DB, read dps.Reader) ([]flow.Identifier, error) {
+func CatchupBlocks(ctx context.Context, db *badger.DB, read dps.HeightReader) ([]flow.Identifier, error) {
 
 	// We need to know for which blocks we don't need the execution records
 	// anymore, which is basically up to the last indexed block.
-	indexed, err := read.Last()
-	if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+	indexed, err := read.Last(ctx)
+	if err != nil && !errors.Is(err, dps.ErrBootstrapping) {
 		return nil, fmt.Errorf("could not get last indexed: %w", err)
 	}
 
@@ -43,7 +44,7 @@ func CatchupBlocks(db *badger.DB, read dps.Reader) ([]flow.Identifier, error) {
 	// records just after root height (for all the blocks), so we put the
 	// last indexed height at root. If there is no root height, we don't need
 	// to catch up with anything, because the protocol state is also empty.
-	if errors.Is(err, badger.ErrKeyNotFound) {
+	if errors.Is(err, dps.ErrBootstrapping) {
 		var root uint64
 		err = db.View(operation.RetrieveRootHeight(&root))
 		if errors.Is(err, storage.ErrNotFound) {
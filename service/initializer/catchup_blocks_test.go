@@ -15,14 +15,15 @@
 package initializer_test
 
 import (
+	"context"
 	"testing"
 
-	"github.com/dgraph-io/badger/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/onflow/flow-go/storage/badger/operation"
 
+	"github.com/optakt/flow-dps/models/dps"
 	"github.com/optakt/flow-dps/service/initializer"
 	"github.com/optakt/flow-dps/testing/helpers"
 	"github.com/optakt/flow-dps/testing/mocks"
@@ -46,12 +47,12 @@ func TestCatchupBlocks(t *testing.T) {
 			require.NoError(t, db.Update(operation.IndexBlockHeight(lastHeight+i, blockIDs[i-1])))
 		}
 
-		reader := mocks.BaselineReader(t)
-		reader.LastFunc = func() (uint64, error) {
+		reader := mocks.BaselineHeightReader(t)
+		reader.LastFunc = func(context.Context) (uint64, error) {
 			return lastHeight, nil
 		}
 
-		got, err := initializer.CatchupBlocks(db, reader)
+		got, err := initializer.CatchupBlocks(context.Background(), db, reader)
 
 		require.NoError(t, err)
 		assert.Equal(t, blockIDs, got)
@@ -69,12 +70,12 @@ func TestCatchupBlocks(t *testing.T) {
 			require.NoError(t, db.Update(operation.IndexBlockHeight(i, blockIDs[i-1])))
 		}
 
-		reader := mocks.BaselineReader(t)
-		reader.LastFunc = func() (uint64, error) {
-			return 0, badger.ErrKeyNotFound
+		reader := mocks.BaselineHeightReader(t)
+		reader.LastFunc = func(context.Context) (uint64, error) {
+			return 0, dps.ErrBootstrapping
 		}
 
-		got, err := initializer.CatchupBlocks(db, reader)
+		got, err := initializer.CatchupBlocks(context.Background(), db, reader)
 
 		require.NoError(t, err)
 		assert.Equal(t, blockIDs, got)
@@ -92,12 +93,12 @@ func TestCatchupBlocks(t *testing.T) {
 			require.NoError(t, db.Update(operation.IndexBlockHeight(i, blockIDs[i-1])))
 		}
 
-		reader := mocks.BaselineReader(t)
-		reader.LastFunc = func() (uint64, error) {
+		reader := mocks.BaselineHeightReader(t)
+		reader.LastFunc = func(context.Context) (uint64, error) {
 			return 0, mocks.GenericError
 		}
 
-		_, err := initializer.CatchupBlocks(db, reader)
+		_, err := initializer.CatchupBlocks(context.Background(), db, reader)
 
 		assert.Error(t, err)
 	})
@@ -111,12 +112,12 @@ func TestCatchupBlocks(t *testing.T) {
 		require.NoError(t, db.Update(operation.InsertRootHeight(rootHeight)))
 		require.NoError(t, db.Update(operation.InsertFinalizedHeight(toIndex)))
 
-		reader := mocks.BaselineReader(t)
-		reader.LastFunc = func() (uint64, error) {
-			return 0, badger.ErrKeyNotFound
+		reader := mocks.BaselineHeightReader(t)
+		reader.LastFunc = func(context.Context) (uint64, error) {
+			return 0, dps.ErrBootstrapping
 		}
 
-		_, err := initializer.CatchupBlocks(db, reader)
+		_, err := initializer.CatchupBlocks(context.Background(), db, reader)
 
 		assert.Error(t, err)
 	})
@@ -15,27 +15,29 @@
 package invoker
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/dgraph-io/ristretto"
 	"github.com/rs/zerolog"
 
 	"github.com/onflow/cadence"
-	"github.com/onflow/cadence/encoding/json"
 	"github.com/onflow/flow-go/engine/execution/state/delta"
 	"github.com/onflow/flow-go/fvm"
 	"github.com/onflow/flow-go/fvm/programs"
 	"github.com/onflow/flow-go/model/flow"
 
+	"github.com/optakt/flow-dps/codec/cadencejson"
 	"github.com/optakt/flow-dps/models/dps"
 )
 
 // Invoker retrieves account information from and executes Cadence scripts against
 // the Flow virtual machine.
 type Invoker struct {
-	index dps.Reader
-	vm    VirtualMachine
-	cache Cache
+	index  dps.Reader
+	vm     VirtualMachine
+	cache  Cache
+	filter dps.Filter
 }
 
 // New returns a new Invoker with the given configuration.
@@ -67,20 +69,29 @@ func New(index dps.Reader, options ...func(*Config)) (*Invoker, error) {
 		return nil, fmt.Errorf("could not initialize cache: %w", err)
 	}
 
+	// If the index only contains the registers of some owners, we keep track
+	// of its filter, so that reading other registers fails instead of
+	// returning empty values.
+	filter, err := index.Filter(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("could not get index filter: %w", err)
+	}
+
 	i := Invoker{
-		index: index,
-		vm:    vm,
-		cache: cache,
+		index:  index,
+		vm:     vm,
+		cache:  cache,
+		filter: filter,
 	}
 
 	return &i, nil
 }
 
 // Key returns the public key of the account with the given address.
-func (i *Invoker) Key(height uint64, address flow.Address, index int) (*flow.AccountPublicKey, error) {
+func (i *Invoker) Key(ctx context.Context, height uint64, address flow.Address, index int) (*flow.AccountPublicKey, error) {
 
 	// Retrieve the account at the specified block height.
-	account, err := i.Account(height, address)
+	account, err := i.Account(ctx, height, address)
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve account: %w", err)
 	}
@@ -104,27 +115,27 @@ func (i *Invoker) Key(height uint64, address flow.Address, index int) (*flow.Acc
 }
 
 // Account returns the account with the given address.
-func (i *Invoker) Account(height uint64, address flow.Address) (*flow.Account, error) {
+func (i *Invoker) Account(ctx context.Context, height uint64, address flow.Address) (*flow.Account, error) {
 
 	// Look up the current block and commit for the block.
-	header, err := i.index.Header(height)
+	header, err := i.index.Header(ctx, height)
 	if err != nil {
 		return nil, fmt.Errorf("could not get header: %w", err)
 	}
 
-	ctx := fvm.NewContext(zerolog.Nop(), fvm.WithBlockHeader(header))
+	vctx := fvm.NewContext(zerolog.Nop(), fvm.WithBlockHeader(header))
 
 	// Initialize the read function. We use a shared cache between all heights
 	// here. It's a smart cache, which means that items that are accessed often
 	// are more likely to be kept, regardless of height. This allows us to put
 	// an upper bound on total cache size while using it for all heights.
-	read := readRegister(i.index, i.cache, header.Height)
+	read := readRegister(ctx, i.index, i.cache, i.filter, header.Height)
 
 	// Initialize the view of the execution state on top of the ledger by
 	// using the read function at a specific commit.
 	view := delta.NewView(read)
 
-	account, err := i.vm.GetAccount(ctx, address, view, programs.NewEmptyPrograms())
+	account, err := i.vm.GetAccount(vctx, address, view, programs.NewEmptyPrograms())
 	if err != nil {
 		return nil, fmt.Errorf("could not get account at height %d: %w", header.Height, err)
 	}
@@ -133,12 +144,12 @@ func (i *Invoker) Account(height uint64, address flow.Address) (*flow.Account, e
 }
 
 // Script executes the given Cadence script and returns its result.
-func (i *Invoker) Script(height uint64, script []byte, arguments []cadence.Value) (cadence.Value, error) {
+func (i *Invoker) Script(ctx context.Context, height uint64, script []byte, arguments []cadence.Value) (cadence.Value, error) {
 
 	// Encode the arguments from Cadence values to byte slices.
 	var args [][]byte
 	for _, argument := range arguments {
-		arg, err := json.Encode(argument)
+		arg, err := cadencejson.Encode(argument)
 		if err != nil {
 			return nil, fmt.Errorf("could not encode value: %w", err)
 		}
@@ -146,20 +157,20 @@ func (i *Invoker) Script(height uint64, script []byte, arguments []cadence.Value
 	}
 
 	// Look up the current block and commit for the block.
-	header, err := i.index.Header(height)
+	header, err := i.index.Header(ctx, height)
 	if err != nil {
 		return nil, fmt.Errorf("could not get header: %w", err)
 	}
 
 	// Initialize the virtual machine context with the given block header so
 	// that parameters related to the block are available from within the script.
-	ctx := fvm.NewContext(zerolog.Nop(), fvm.WithBlockHeader(header))
+	vctx := fvm.NewContext(zerolog.Nop(), fvm.WithBlockHeader(header))
 
 	// Initialize the read function. We use a shared cache between all heights
 	// here. It's a smart cache, which means that items that are accessed often
 	// are more likely to be kept, regardless of height. This allows us to put
 	// an upper bound on total cache size while using it for all heights.
-	read := readRegister(i.index, i.cache, height)
+	read := readRegister(ctx, i.index, i.cache, i.filter, height)
 
 	// Initialize the view of the execution state on top of the ledger by
 	// using the read function at a specific commit.
@@ -174,7 +185,7 @@ func (i *Invoker) Script(height uint64, script []byte, arguments []cadence.Value
 
 	// The script procedure is then run using the Flow virtual machine and all
 	// the constructed contextual parameters.
-	err = i.vm.Run(ctx, proc, view, programs)
+	err = i.vm.Run(vctx, proc, view, programs)
 	if err != nil {
 		return nil, fmt.Errorf("could not run script: %w", err)
 	}
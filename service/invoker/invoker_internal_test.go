@@ -15,6 +15,7 @@
 package invoker
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -27,6 +28,7 @@ import (
 	"github.com/onflow/flow-go/fvm/state"
 	"github.com/onflow/flow-go/model/flow"
 
+	"github.com/optakt/flow-dps/models/dps"
 	"github.com/optakt/flow-dps/testing/mocks"
 )
 
@@ -45,6 +47,34 @@ func TestNew(t *testing.T) {
 		assert.NotNil(t, invoke.vm)
 	})
 
+	t.Run("nominal case with partial index", func(t *testing.T) {
+		t.Parallel()
+
+		filter := dps.Filter{Allowed: mocks.GenericAddresses(2)}
+		index := mocks.BaselineReader(t)
+		index.FilterFunc = func(context.Context) (dps.Filter, error) {
+			return filter, nil
+		}
+
+		invoke, err := New(index, WithCacheSize(1_000_000))
+
+		require.NoError(t, err)
+		assert.Equal(t, filter, invoke.filter)
+	})
+
+	t.Run("handles index failure on filter", func(t *testing.T) {
+		t.Parallel()
+
+		index := mocks.BaselineReader(t)
+		index.FilterFunc = func(context.Context) (dps.Filter, error) {
+			return dps.Filter{}, mocks.GenericError
+		}
+
+		_, err := New(index, WithCacheSize(1_000_000))
+
+		assert.Error(t, err)
+	})
+
 	t.Run("handles invalid cache configuration", func(t *testing.T) {
 		t.Parallel()
 
@@ -63,7 +93,7 @@ func TestInvoker_Script(t *testing.T) {
 		t.Parallel()
 
 		index := mocks.BaselineReader(t)
-		index.HeaderFunc = func(height uint64) (*flow.Header, error) {
+		index.HeaderFunc = func(_ context.Context, height uint64) (*flow.Header, error) {
 			assert.Equal(t, mocks.GenericHeight, height)
 
 			return mocks.GenericHeader, nil
@@ -91,7 +121,7 @@ func TestInvoker_Script(t *testing.T) {
 			cadence.NewUInt64(1337),
 		}
 
-		val, err := invoke.Script(mocks.GenericHeight, mocks.GenericBytes, values)
+		val, err := invoke.Script(context.Background(), mocks.GenericHeight, mocks.GenericBytes, values)
 
 		require.NoError(t, err)
 		assert.Equal(t, testValue, val)
@@ -101,14 +131,14 @@ func TestInvoker_Script(t *testing.T) {
 		t.Parallel()
 
 		index := mocks.BaselineReader(t)
-		index.HeaderFunc = func(uint64) (*flow.Header, error) {
+		index.HeaderFunc = func(context.Context, uint64) (*flow.Header, error) {
 			return nil, mocks.GenericError
 		}
 
 		invoke := baselineInvoker(t)
 		invoke.index = index
 
-		_, err := invoke.Script(mocks.GenericHeight, mocks.GenericBytes, []cadence.Value{})
+		_, err := invoke.Script(context.Background(), mocks.GenericHeight, mocks.GenericBytes, []cadence.Value{})
 
 		assert.Error(t, err)
 	})
@@ -128,7 +158,7 @@ func TestInvoker_Script(t *testing.T) {
 		invoke := baselineInvoker(t)
 		invoke.vm = vm
 
-		_, err := invoke.Script(mocks.GenericHeight, mocks.GenericBytes, []cadence.Value{})
+		_, err := invoke.Script(context.Background(), mocks.GenericHeight, mocks.GenericBytes, []cadence.Value{})
 
 		assert.Error(t, err)
 	})
@@ -144,7 +174,7 @@ func TestInvoker_Script(t *testing.T) {
 		invoke := baselineInvoker(t)
 		invoke.vm = vm
 
-		_, err := invoke.Script(mocks.GenericHeight, mocks.GenericBytes, []cadence.Value{})
+		_, err := invoke.Script(context.Background(), mocks.GenericHeight, mocks.GenericBytes, []cadence.Value{})
 
 		assert.Error(t, err)
 	})
@@ -165,7 +195,7 @@ func TestInvoker_Account(t *testing.T) {
 		}
 
 		index := mocks.BaselineReader(t)
-		index.HeaderFunc = func(height uint64) (*flow.Header, error) {
+		index.HeaderFunc = func(_ context.Context, height uint64) (*flow.Header, error) {
 			assert.Equal(t, mocks.GenericHeight, height)
 
 			return mocks.GenericHeader, nil
@@ -175,7 +205,7 @@ func TestInvoker_Account(t *testing.T) {
 		invoke.vm = vm
 		invoke.index = index
 
-		account, err := invoke.Account(mocks.GenericHeight, mocks.GenericAccount.Address)
+		account, err := invoke.Account(context.Background(), mocks.GenericHeight, mocks.GenericAccount.Address)
 
 		require.NoError(t, err)
 		assert.Equal(t, &mocks.GenericAccount, account)
@@ -185,14 +215,14 @@ func TestInvoker_Account(t *testing.T) {
 		t.Parallel()
 
 		index := mocks.BaselineReader(t)
-		index.HeaderFunc = func(height uint64) (*flow.Header, error) {
+		index.HeaderFunc = func(_ context.Context, height uint64) (*flow.Header, error) {
 			return nil, mocks.GenericError
 		}
 
 		invoke := baselineInvoker(t)
 		invoke.index = index
 
-		_, err := invoke.Account(mocks.GenericHeight, mocks.GenericAccount.Address)
+		_, err := invoke.Account(context.Background(), mocks.GenericHeight, mocks.GenericAccount.Address)
 
 		assert.Error(t, err)
 	})
@@ -208,7 +238,7 @@ func TestInvoker_Account(t *testing.T) {
 		invoke := baselineInvoker(t)
 		invoke.vm = vm
 
-		_, err := invoke.Account(mocks.GenericHeight, mocks.GenericAccount.Address)
+		_, err := invoke.Account(context.Background(), mocks.GenericHeight, mocks.GenericAccount.Address)
 
 		assert.Error(t, err)
 	})
@@ -15,6 +15,7 @@
 package invoker
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/onflow/flow-go/engine/execution/state"
@@ -27,9 +28,19 @@ import (
 	"github.com/optakt/flow-dps/models/dps"
 )
 
-func readRegister(index dps.Reader, cache Cache, height uint64) delta.GetRegisterFunc {
+func readRegister(ctx context.Context, index dps.RegisterReader, cache Cache, filter dps.Filter, height uint64) delta.GetRegisterFunc {
 	return func(owner string, controller string, key string) (flow.RegisterValue, error) {
 
+		// If the index only contains the registers of some owners, we can't
+		// tell whether a register of another owner is missing or was simply
+		// not indexed, so we fail precisely instead of returning a nil value.
+		if owner != "" {
+			address := flow.BytesToAddress([]byte(owner))
+			if !filter.Address(address) {
+				return nil, fmt.Errorf("could not read register (owner: %s): %w", address, dps.ErrNotIndexed)
+			}
+		}
+
 		cacheKey := fmt.Sprintf("%d/%x/%x/%s", height, owner, controller, key)
 		cacheValue, ok := cache.Get(cacheKey)
 		if ok {
@@ -42,7 +53,7 @@ func readRegister(index dps.Reader, cache Cache, height uint64) delta.GetRegiste
 			return nil, fmt.Errorf("could not convert key to path: %w", err)
 		}
 
-		values, err := index.Values(height, []ledger.Path{path})
+		values, err := index.Values(ctx, height, []ledger.Path{path})
 		if err != nil {
 			return nil, fmt.Errorf("could not read register: %w", err)
 		}
@@ -15,12 +15,16 @@
 package invoker
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
 	"github.com/optakt/flow-dps/testing/mocks"
 )
 
@@ -39,13 +43,13 @@ func TestReadRegister(t *testing.T) {
 		}
 
 		var indexCalled bool
-		index := mocks.BaselineReader(t)
-		index.ValuesFunc = func(uint64, []ledger.Path) ([]ledger.Value, error) {
+		index := mocks.BaselineRegisterReader(t)
+		index.ValuesFunc = func(context.Context, uint64, []ledger.Path) ([]ledger.Value, error) {
 			indexCalled = true
 			return nil, nil
 		}
 
-		readFunc := readRegister(index, cache, mocks.GenericHeight)
+		readFunc := readRegister(context.Background(), index, cache, dps.Filter{}, mocks.GenericHeight)
 		value, err := readFunc(owner, controller, key)
 
 		require.NoError(t, err)
@@ -63,13 +67,13 @@ func TestReadRegister(t *testing.T) {
 		}
 
 		var indexCalled bool
-		index := mocks.BaselineReader(t)
-		index.ValuesFunc = func(uint64, []ledger.Path) ([]ledger.Value, error) {
+		index := mocks.BaselineRegisterReader(t)
+		index.ValuesFunc = func(context.Context, uint64, []ledger.Path) ([]ledger.Value, error) {
 			indexCalled = true
 			return []ledger.Value{mocks.GenericBytes}, nil
 		}
 
-		readFunc := readRegister(index, cache, mocks.GenericHeight)
+		readFunc := readRegister(context.Background(), index, cache, dps.Filter{}, mocks.GenericHeight)
 		value, err := readFunc(owner, controller, key)
 
 		require.NoError(t, err)
@@ -77,6 +81,56 @@ func TestReadRegister(t *testing.T) {
 		assert.True(t, indexCalled)
 	})
 
+	t.Run("nominal case with indexed owner", func(t *testing.T) {
+		t.Parallel()
+
+		cache := mocks.BaselineCache(t)
+		cache.GetFunc = func(key interface{}) (interface{}, bool) {
+			return nil, false
+		}
+
+		index := mocks.BaselineRegisterReader(t)
+		index.ValuesFunc = func(context.Context, uint64, []ledger.Path) ([]ledger.Value, error) {
+			return []ledger.Value{mocks.GenericBytes}, nil
+		}
+
+		filter := dps.Filter{
+			Allowed: []flow.Address{flow.BytesToAddress([]byte(owner))},
+		}
+
+		readFunc := readRegister(context.Background(), index, cache, filter, mocks.GenericHeight)
+		value, err := readFunc(owner, controller, key)
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericBytes, value[:])
+	})
+
+	t.Run("handles register of denied owner", func(t *testing.T) {
+		t.Parallel()
+
+		cache := mocks.BaselineCache(t)
+		cache.GetFunc = func(key interface{}) (interface{}, bool) {
+			return nil, false
+		}
+
+		var indexCalled bool
+		index := mocks.BaselineRegisterReader(t)
+		index.ValuesFunc = func(context.Context, uint64, []ledger.Path) ([]ledger.Value, error) {
+			indexCalled = true
+			return []ledger.Value{mocks.GenericBytes}, nil
+		}
+
+		filter := dps.Filter{
+			Denied: []flow.Address{flow.BytesToAddress([]byte(owner))},
+		}
+
+		readFunc := readRegister(context.Background(), index, cache, filter, mocks.GenericHeight)
+		_, err := readFunc(owner, controller, key)
+
+		assert.ErrorIs(t, err, dps.ErrNotIndexed)
+		assert.False(t, indexCalled)
+	})
+
 	t.Run("handles indexer failure on Values", func(t *testing.T) {
 		t.Parallel()
 
@@ -86,12 +140,12 @@ func TestReadRegister(t *testing.T) {
 			return nil, false
 		}
 
-		index := mocks.BaselineReader(t)
-		index.ValuesFunc = func(uint64, []ledger.Path) ([]ledger.Value, error) {
+		index := mocks.BaselineRegisterReader(t)
+		index.ValuesFunc = func(context.Context, uint64, []ledger.Path) ([]ledger.Value, error) {
 			return nil, mocks.GenericError
 		}
 
-		readFunc := readRegister(index, cache, mocks.GenericHeight)
+		readFunc := readRegister(context.Background(), index, cache, dps.Filter{}, mocks.GenericHeight)
 		_, err := readFunc(owner, controller, key)
 
 		assert.Error(t, err)
@@ -0,0 +1,164 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package journal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/rs/zerolog"
+
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/storage/badger/operation"
+
+	"github.com/optakt/flow-dps/service/logs"
+)
+
+// idSize is the size of a block identifier, and entrySize the size of an
+// encoded journal entry: the height, the block ID and the time at which the
+// block was received, in nanoseconds.
+const (
+	idSize    = len(flow.Identifier{})
+	entrySize = 8 + idSize + 8
+)
+
+// Entry is a single finalization callback of the consensus follower.
+type Entry struct {
+	Height   uint64
+	BlockID  flow.Identifier
+	Received time.Time
+}
+
+// Journal is an append-only file that records every finalization callback of
+// the consensus follower, independently of the protocol state database. It
+// allows telling apart, after an incident, blocks that the consensus follower
+// never delivered from blocks that were delivered but never indexed. The
+// callback of the journal is not safe for concurrent use, which is fine, as the
+// consensus follower calls its consumers one after the other.
+type Journal struct {
+	log  zerolog.Logger
+	db   *badger.DB
+	file *os.File
+	now  func() time.Time
+}
+
+// Open opens the journal at the given path, creating it if it does not exist
+// yet. The protocol state database is used to look up the heights of finalized
+// blocks. When the previous process stopped in the middle of writing an entry,
+// the incomplete entry is discarded, so that new entries are aligned.
+func Open(log zerolog.Logger, db *badger.DB, path string) (*Journal, error) {
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open journal file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("could not stat journal file: %w", err)
+	}
+	partial := info.Size() % int64(entrySize)
+	if partial != 0 {
+		err = file.Truncate(info.Size() - partial)
+		if err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("could not truncate incomplete journal entry: %w", err)
+		}
+	}
+
+	j := Journal{
+		log:  log.With().Str(logs.Component, "finalization_journal").Logger(),
+		db:   db,
+		file: file,
+		now:  time.Now,
+	}
+
+	return &j, nil
+}
+
+// OnBlockFinalized is a callback for the Flow consensus follower. It appends
+// an entry for the finalized block to the journal. When the height of the
+// block can not be looked up, the entry is still appended, with a zero height,
+// as the delivery of the block is what the journal records.
+func (j *Journal) OnBlockFinalized(blockID flow.Identifier) {
+
+	received := j.now()
+
+	var header flow.Header
+	err := j.db.View(operation.RetrieveHeader(blockID, &header))
+	if err != nil {
+		j.log.Error().Err(err).Hex(logs.BlockID, blockID[:]).Msg("could not get header")
+	}
+
+	entry := Entry{
+		Height:   header.Height,
+		BlockID:  blockID,
+		Received: received,
+	}
+	_, err = j.file.Write(encode(entry))
+	if err != nil {
+		j.log.Error().Err(err).Hex(logs.BlockID, blockID[:]).Uint64(logs.Height, header.Height).Msg("could not write journal entry")
+		return
+	}
+}
+
+// Close closes the journal file.
+func (j *Journal) Close() error {
+	return j.file.Close()
+}
+
+// Read reads all entries of a journal, in the order in which they were
+// appended. A trailing incomplete entry, left by a process that stopped while
+// writing it, is ignored.
+func Read(r io.Reader) ([]Entry, error) {
+
+	reader := bufio.NewReader(r)
+	var entries []Entry
+	for {
+		data := make([]byte, entrySize)
+		_, err := io.ReadFull(reader, data)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read journal entry: %w", err)
+		}
+		entries = append(entries, decode(data))
+	}
+
+	return entries, nil
+}
+
+func encode(entry Entry) []byte {
+	data := make([]byte, entrySize)
+	binary.BigEndian.PutUint64(data[0:8], entry.Height)
+	copy(data[8:8+idSize], entry.BlockID[:])
+	binary.BigEndian.PutUint64(data[8+idSize:], uint64(entry.Received.UnixNano()))
+	return data
+}
+
+func decode(data []byte) Entry {
+	var entry Entry
+	entry.Height = binary.BigEndian.Uint64(data[0:8])
+	copy(entry.BlockID[:], data[8:8+idSize])
+	entry.Received = time.Unix(0, int64(binary.BigEndian.Uint64(data[8+idSize:])))
+	return entry
+}
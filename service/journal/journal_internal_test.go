@@ -0,0 +1,175 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package journal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/storage/badger/operation"
+
+	"github.com/optakt/flow-dps/testing/helpers"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestJournal(t *testing.T) {
+	header := mocks.GenericHeader
+	received := time.Unix(1634000000, 42)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+		require.NoError(t, db.Update(operation.InsertHeader(header.ID(), header)))
+
+		path := filepath.Join(t.TempDir(), "journal")
+		j, err := Open(zerolog.Nop(), db, path)
+		require.NoError(t, err)
+		j.now = func() time.Time { return received }
+
+		j.OnBlockFinalized(header.ID())
+		require.NoError(t, j.Close())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		entries, err := Read(bytes.NewReader(data))
+
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, header.Height, entries[0].Height)
+		assert.Equal(t, header.ID(), entries[0].BlockID)
+		assert.True(t, received.Equal(entries[0].Received))
+	})
+
+	t.Run("records block with unknown header", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		path := filepath.Join(t.TempDir(), "journal")
+		j, err := Open(zerolog.Nop(), db, path)
+		require.NoError(t, err)
+
+		j.OnBlockFinalized(header.ID())
+		require.NoError(t, j.Close())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		entries, err := Read(bytes.NewReader(data))
+
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Zero(t, entries[0].Height)
+		assert.Equal(t, header.ID(), entries[0].BlockID)
+	})
+
+	t.Run("appends to existing journal", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+		require.NoError(t, db.Update(operation.InsertHeader(header.ID(), header)))
+
+		path := filepath.Join(t.TempDir(), "journal")
+		for i := 0; i < 2; i++ {
+			j, err := Open(zerolog.Nop(), db, path)
+			require.NoError(t, err)
+			j.OnBlockFinalized(header.ID())
+			require.NoError(t, j.Close())
+		}
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		entries, err := Read(bytes.NewReader(data))
+
+		require.NoError(t, err)
+		assert.Len(t, entries, 2)
+	})
+
+	t.Run("discards incomplete entry", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+		require.NoError(t, db.Update(operation.InsertHeader(header.ID(), header)))
+
+		entry := Entry{Height: 1, BlockID: flow.Identifier{1}, Received: received}
+		path := filepath.Join(t.TempDir(), "journal")
+		data := append(encode(entry), encode(entry)[:entrySize/2]...)
+		require.NoError(t, os.WriteFile(path, data, 0644))
+
+		j, err := Open(zerolog.Nop(), db, path)
+		require.NoError(t, err)
+		j.OnBlockFinalized(header.ID())
+		require.NoError(t, j.Close())
+
+		data, err = os.ReadFile(path)
+		require.NoError(t, err)
+		entries, err := Read(bytes.NewReader(data))
+
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		assert.Equal(t, entry.BlockID, entries[0].BlockID)
+		assert.Equal(t, header.ID(), entries[1].BlockID)
+	})
+
+	t.Run("handles invalid path", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		_, err := Open(zerolog.Nop(), db, filepath.Join(t.TempDir(), "missing", "journal"))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestRead(t *testing.T) {
+	entry := Entry{Height: 42, BlockID: flow.Identifier{1, 2, 3}, Received: time.Unix(1634000000, 42)}
+
+	t.Run("ignores trailing incomplete entry", func(t *testing.T) {
+		t.Parallel()
+
+		data := append(encode(entry), encode(entry)[:10]...)
+
+		entries, err := Read(bytes.NewReader(data))
+
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, entry.Height, entries[0].Height)
+		assert.Equal(t, entry.BlockID, entries[0].BlockID)
+		assert.True(t, entry.Received.Equal(entries[0].Received))
+	})
+
+	t.Run("handles empty journal", func(t *testing.T) {
+		t.Parallel()
+
+		entries, err := Read(bytes.NewReader(nil))
+
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
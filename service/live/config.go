@@ -0,0 +1,138 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package live
+
+import (
+	"time"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/service/backfill"
+	"github.com/optakt/flow-dps/service/mapper"
+	"github.com/optakt/flow-dps/service/tier"
+)
+
+// DefaultConfig is the default configuration for a live node. It still needs
+// the directories of its databases and bootstrap data, the seed node to follow
+// consensus from, and a source of execution records.
+var DefaultConfig = Config{
+	DataDir:         "data",
+	IndexDir:        "index",
+	BootstrapDir:    "bootstrap",
+	FollowerLevel:   "info",
+	FlushInterval:   time.Second,
+	RecordTopic:     "execution-records",
+	QuarantineAfter: 3,
+	ColdCache:       100_000_000,
+	ColdEndpoint:    "https://s3.amazonaws.com",
+	ColdRegion:      "us-east-1",
+	ReplicaEndpoint: "https://s3.amazonaws.com",
+	ReplicaRegion:   "us-east-1",
+	ReplicaMaxLag:   1000,
+	BackfillRate:    backfill.DefaultConfig.Rate,
+}
+
+// Config is the configuration for a live node. Its fields correspond to the
+// flags of the live binary, which documents them in more detail.
+type Config struct {
+
+	// Protocol state and consensus follower. When a seed retry interval is
+	// set, the consensus follower is only started once the seed node can be
+	// reached, so that the node can start from local data while it is offline.
+	// When a follower timeout is set, the consensus follower is reconnected to
+	// the seed node whenever it receives no finalized block for that long.
+	// When a journal path is set, every finalized block that the consensus
+	// follower delivers is recorded in that file.
+	DataDir         string
+	BootstrapDir    string
+	SeedAddress     string
+	SeedKey         string
+	SeedRetry       time.Duration
+	FollowerLevel   string
+	FollowerTimeout time.Duration
+	Journal         string
+
+	// Index database and what is indexed into it.
+	IndexDir        string
+	Namespace       string
+	Owners          []flow.Address
+	Denied          []flow.Address
+	ProtocolOnly    bool
+	SkipRegisters   bool
+	RegisterDeltas  bool
+	Recent          uint
+	Speculative     bool
+	Takeover        bool
+	Audit           bool
+	Repair          bool
+	FlushInterval   time.Duration
+	ForestLimit     uint
+	ContendedWrites uint
+	PathFilters     bool
+	VerifyPayloads  bool
+	ReportCorrupted bool
+
+	// Encoding and storage of the indexed values.
+	Codec              string
+	CodecDeterministic bool
+	CodecValidate      bool
+	Compression        map[string]string
+	CompressionStats   bool
+	SizeStats          bool
+	Manifests          bool
+
+	// Root checkpoint of a new index, and checkpoints emitted while indexing.
+	Checkpoint         string
+	CheckpointObject   string
+	CheckpointURL      string
+	CheckpointSHA256   []byte
+	CheckpointInterval uint64
+	CheckpointOutput   string
+
+	// Source of the execution records.
+	Buckets         []string
+	RecordLayout    string
+	RecordPeer      string
+	RecordTopic     string
+	QuarantineAfter uint
+	ArchiveRecords  bool
+
+	// Cold tier and secondary index in object storage, which share the same
+	// credentials for S3-compatible object storage.
+	Credentials     tier.Credentials
+	ColdURL         string
+	ColdEndpoint    string
+	ColdRegion      string
+	ColdCache       uint64
+	ColdAge         uint64
+	ReplicaURL      string
+	ReplicaEndpoint string
+	ReplicaRegion   string
+	ReplicaMaxLag   uint64
+
+	// Secondary indexes that were introduced after the index was created, and
+	// are built for the heights indexed before in the background, at the given
+	// rate in heights per second.
+	Backfills    []backfill.Builder
+	BackfillRate uint
+
+	// Monitoring of the node by the embedding process. The monitor keeps track
+	// of the progress of the mapper, and the pause function is called before
+	// each height is indexed, so that it can hold back indexing, for example
+	// while a volume is low on space.
+	Metrics bool
+	Monitor *mapper.Monitor
+	Pause   func() error
+}
@@ -0,0 +1,37 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package live
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	followerPeers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "follower_peers",
+		Help: "number of peers the consensus follower is connected to",
+	})
+
+	followerFinalized = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "follower_last_finalized_timestamp_seconds",
+		Help: "time at which the consensus follower last received a finalized block",
+	})
+
+	followerReconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "follower_reconnects_total",
+		Help: "number of times the consensus follower was reconnected to the seed node after receiving no finalized blocks",
+	})
+)
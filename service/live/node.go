@@ -0,0 +1,504 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package live
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/hashicorp/go-multierror"
+	"github.com/rs/zerolog"
+
+	sdk "github.com/onflow/flow-go-sdk/crypto"
+	"github.com/onflow/flow-go/cmd/bootstrap/utils"
+	"github.com/onflow/flow-go/crypto"
+	unstaked "github.com/onflow/flow-go/follower"
+	"github.com/onflow/flow-go/model/bootstrap"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/network/p2p"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/backfill"
+	"github.com/optakt/flow-dps/service/checkpoint"
+	"github.com/optakt/flow-dps/service/index"
+	"github.com/optakt/flow-dps/service/initializer"
+	"github.com/optakt/flow-dps/service/journal"
+	"github.com/optakt/flow-dps/service/logs"
+	"github.com/optakt/flow-dps/service/mapper"
+	"github.com/optakt/flow-dps/service/pubsub"
+	"github.com/optakt/flow-dps/service/replica"
+	"github.com/optakt/flow-dps/service/tier"
+)
+
+// healthInterval is the interval at which the connectivity of the consensus
+// follower is checked.
+const healthInterval = 10 * time.Second
+
+// Node is a live indexer that can be embedded into another process. It follows
+// consensus with an unstaked consensus follower, downloads the execution records
+// of finalized blocks, and indexes their data into its index database, from
+// which its reader serves it.
+//
+// A node is created with the protocol state database and consensus follower.
+// It can then follow consensus on its own, as a hot standby, before it starts
+// indexing, which is when it opens the index database. Stopping the node stops
+// all of its components and releases all of its resources.
+type Node struct {
+	log zerolog.Logger
+	cfg Config
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	protocolDB *badger.DB
+	follow     *unstaked.ConsensusFollowerImpl
+	following  bool
+	running    chan struct{} // closed once the consensus follower runs
+	seed       flow.Identity // identity of the seed node, for reconnecting to it
+	finalized  atomic.Value  // time at which the last finalized block was received
+
+	// The consensus follower notifies its consumers of finalized blocks
+	// through a relay, so that consumers can be subscribed while it runs
+	// without missing any blocks.
+	relay     *sync.Mutex
+	consumers []func(flow.Identifier)
+
+	read       dps.Reader
+	records    atomic.Value // index reader of archived execution records, once started
+	fsm        *mapper.FSM
+	subscriber *pubsub.Streamer
+	mover      *tier.Mover
+	replicator *replica.Replicator
+	backfills  []*backfill.Runner
+	emitter    *checkpoint.Emitter
+	wg         *sync.WaitGroup
+
+	// The resources of the node are released in the reverse order from which
+	// they were acquired.
+	closers []func() error
+
+	done chan struct{}
+	err  error
+	once *sync.Once
+	stop error
+}
+
+// NewNode creates a new live node with the given configuration. It opens the
+// protocol state database, bootstraps the protocol state and creates the
+// consensus follower, which is only started by Follow or Start.
+func NewNode(log zerolog.Logger, cfg Config) (*Node, error) {
+
+	// In speculative mode, the indexed heights are checked against the state
+	// commitments of their seals, which are not indexed without execution data.
+	if cfg.Speculative && cfg.ProtocolOnly {
+		return nil, fmt.Errorf("speculative mode requires execution data, which is not indexed in protocol-only mode")
+	}
+	if cfg.CheckpointInterval > 0 && !cfg.ProtocolOnly && cfg.CheckpointOutput == "" {
+		return nil, fmt.Errorf("no output for emitted checkpoints")
+	}
+	if !cfg.ProtocolOnly && cfg.RecordPeer == "" && len(cfg.Buckets) == 0 {
+		return nil, fmt.Errorf("no bucket to download execution records from and no record peer")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := Node{
+		log:     log.With().Str(logs.Component, "live_node").Logger(),
+		cfg:     cfg,
+		ctx:     ctx,
+		cancel:  cancel,
+		relay:   &sync.Mutex{},
+		running: make(chan struct{}),
+		wg:      &sync.WaitGroup{},
+		done:    make(chan struct{}),
+		once:    &sync.Once{},
+	}
+
+	err := n.open()
+	if err != nil {
+		_ = n.release()
+		return nil, err
+	}
+
+	return &n, nil
+}
+
+// open opens the protocol state database and creates the consensus follower.
+func (n *Node) open() error {
+
+	// As a first step, we open the protocol state database. The consensus
+	// follower writes to it and the mapper reads from it.
+	protocolDB, err := badger.Open(dps.DefaultOptions(n.cfg.DataDir))
+	if err != nil {
+		return fmt.Errorf("could not open protocol state database: %w", err)
+	}
+	n.protocolDB = protocolDB
+	n.closers = append(n.closers, protocolDB.Close)
+
+	// Next, we need a network key to secure the peer-to-peer communication of
+	// the consensus follower. As we do not need any specific key, we generate a
+	// new one each time the node is created.
+	seed := make([]byte, crypto.KeyGenSeedMinLenECDSASecp256k1)
+	count, err := rand.Read(seed)
+	if err != nil || count != crypto.KeyGenSeedMinLenECDSASecp256k1 {
+		return fmt.Errorf("could not generate private key seed: %w", err)
+	}
+	privKey, err := utils.GenerateUnstakedNetworkingKey(seed)
+	if err != nil {
+		return fmt.Errorf("could not generate private network key: %w", err)
+	}
+
+	// The unstaked consensus follower connects to a staked access node for
+	// bootstrapping the peer-to-peer network, which is shared between staked
+	// access nodes and unstaked consensus followers. For every finalized block,
+	// it calls the callback of all registered finalization consumers.
+	host, port, err := net.SplitHostPort(n.cfg.SeedAddress)
+	if err != nil {
+		return fmt.Errorf("could not parse seed node address (%s): %w", n.cfg.SeedAddress, err)
+	}
+	seedPort, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return fmt.Errorf("could not parse seed node port (%s): %w", port, err)
+	}
+	seedKey, err := sdk.DecodePublicKeyHex(sdk.ECDSA_P256, n.cfg.SeedKey)
+	if err != nil {
+		return fmt.Errorf("could not parse seed node network public key: %w", err)
+	}
+	n.seed = flow.Identity{
+		Role:          flow.RoleAccess,
+		NetworkPubKey: seedKey,
+		Address:       n.cfg.SeedAddress,
+	}
+	seedNodes := []unstaked.BootstrapNodeInfo{{
+		Host:             host,
+		Port:             uint(seedPort),
+		NetworkPublicKey: seedKey,
+	}}
+	follow, err := unstaked.NewConsensusFollower(
+		privKey,
+		"0.0.0.0:0", // automatically choose port, listen on all IPs
+		seedNodes,
+		unstaked.WithBootstrapDir(n.cfg.BootstrapDir),
+		unstaked.WithDB(protocolDB),
+		unstaked.WithLogLevel(n.cfg.FollowerLevel),
+	)
+	if err != nil {
+		return fmt.Errorf("could not create consensus follower: %w", err)
+	}
+	follow.AddOnBlockFinalizedConsumer(func(blockID flow.Identifier) {
+		n.finalized.Store(time.Now())
+		followerFinalized.SetToCurrentTime()
+		n.relay.Lock()
+		defer n.relay.Unlock()
+		for _, consume := range n.consumers {
+			consume(blockID)
+		}
+	})
+	n.follow = follow
+
+	// When a journal is configured, it is the first consumer of finalized
+	// blocks, so that it records every block that the consensus follower
+	// delivers, whether or not it is then indexed.
+	if n.cfg.Journal != "" {
+		journal, err := journal.Open(n.log, protocolDB, n.cfg.Journal)
+		if err != nil {
+			return fmt.Errorf("could not open finalization journal: %w", err)
+		}
+		n.closers = append(n.closers, journal.Close)
+		n.consumers = append(n.consumers, journal.OnBlockFinalized)
+	}
+
+	// The consensus follower only bootstraps the protocol state when it starts,
+	// which is too late for our consensus tracker, as it needs a valid protocol
+	// state before it can be subscribed to the consensus follower without
+	// missing some blocks. As a work-around, we manually bootstrap the protocol
+	// state using the bootstrap data here.
+	path := filepath.Join(n.cfg.BootstrapDir, bootstrap.PathRootProtocolStateSnapshot)
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not open protocol state snapshot (path: %s): %w", path, err)
+	}
+	defer file.Close()
+	err = initializer.ProtocolState(file, protocolDB)
+	if err != nil {
+		return fmt.Errorf("could not initialize protocol state: %w", err)
+	}
+
+	return nil
+}
+
+// Follow starts following consensus without indexing, so that the node can
+// quickly start indexing when it takes over an index, for example from a failed
+// instance. Blocks that are finalized in the meantime are caught up on once the
+// node is started. With a seed retry interval, the consensus follower is only
+// started once the seed node can be reached.
+func (n *Node) Follow() {
+	if n.following {
+		return
+	}
+	n.following = true
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		if n.cfg.SeedRetry > 0 && !n.reach() {
+			return
+		}
+		close(n.running)
+		n.finalized.Store(time.Now())
+		n.wg.Add(1)
+		go n.watch()
+		n.follow.Run(n.ctx)
+	}()
+}
+
+// watch checks the connectivity of the consensus follower at regular intervals
+// until the node is stopped.
+func (n *Node) watch() {
+	defer n.wg.Done()
+
+	interval := healthInterval
+	if n.cfg.FollowerTimeout > 0 && n.cfg.FollowerTimeout < interval {
+		interval = n.cfg.FollowerTimeout
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-ticker.C:
+			n.check()
+		}
+	}
+}
+
+// check reports the number of peers of the consensus follower, and reconnects
+// it to the seed node if it did not receive any finalized block for the
+// follower timeout. A follower that lost its peers silently stops receiving
+// blocks, without failing, so this is the only way to notice it.
+func (n *Node) check() {
+
+	// The libp2p node of the consensus follower is only created once it
+	// starts, so it might not be available yet.
+	var node *p2p.Node
+	if n.follow != nil && n.follow.NodeBuilder != nil {
+		node = n.follow.NodeBuilder.LibP2PNode
+	}
+	peers := 0
+	if node != nil {
+		peers = len(node.Host().Network().Peers())
+	}
+	followerPeers.Set(float64(peers))
+
+	last, _ := n.finalized.Load().(time.Time)
+	if n.cfg.FollowerTimeout == 0 || time.Since(last) < n.cfg.FollowerTimeout {
+		return
+	}
+
+	// We restart the timeout, so that we only try to reconnect once per
+	// timeout, even if the reconnection does not help.
+	n.finalized.Store(time.Now())
+
+	log := n.log.With().Str("seed", n.cfg.SeedAddress).Int("peers", peers).Time("last_finalized", last).Logger()
+	log.Warn().Msg("no finalized blocks received, reconnecting consensus follower to seed node")
+	if node == nil {
+		log.Warn().Msg("could not reconnect consensus follower, network not started")
+		return
+	}
+	info, err := p2p.PeerAddressInfo(n.seed)
+	if err != nil {
+		log.Error().Err(err).Msg("could not get address of seed node")
+		return
+	}
+	ctx, cancel := context.WithTimeout(n.ctx, healthInterval)
+	defer cancel()
+	err = node.AddPeer(ctx, info)
+	if err != nil {
+		log.Warn().Err(err).Msg("could not reconnect consensus follower to seed node")
+		return
+	}
+	followerReconnects.Inc()
+}
+
+// reach waits until the seed node accepts connections, checking it again at
+// the seed retry interval. The consensus follower exits the whole process when
+// it can not connect to the seed node, so it should only be started once it
+// is reachable. It returns false if the node is stopped in the meantime.
+func (n *Node) reach() bool {
+	dialer := net.Dialer{Timeout: n.cfg.SeedRetry}
+	for {
+		conn, err := dialer.DialContext(n.ctx, "tcp", n.cfg.SeedAddress)
+		if err == nil {
+			_ = conn.Close()
+			return true
+		}
+
+		n.log.Warn().Err(err).Str("seed", n.cfg.SeedAddress).Dur("retry", n.cfg.SeedRetry).Msg("seed node unreachable, continuing from local data")
+
+		select {
+		case <-n.ctx.Done():
+			return false
+		case <-time.After(n.cfg.SeedRetry):
+		}
+	}
+}
+
+// Reader returns the reader that serves the indexed data. It is only available
+// once the node is started.
+func (n *Node) Reader() dps.Reader {
+	return n.read
+}
+
+// ExecutionRecord returns the archived execution record of the block with the
+// given ID, in its original encoding. Execution records are only archived when
+// enabled, and they are only available once the node is started; until then,
+// it fails with dps.ErrUnavailable.
+func (n *Node) ExecutionRecord(ctx context.Context, blockID flow.Identifier) ([]byte, error) {
+	read, ok := n.records.Load().(*index.Reader)
+	if !ok {
+		return nil, dps.ErrUnavailable
+	}
+	return read.ExecutionRecord(ctx, blockID)
+}
+
+// Done returns a channel that is closed once the node stops indexing, either
+// because it failed or because it was stopped.
+func (n *Node) Done() <-chan struct{} {
+	return n.done
+}
+
+// Err returns the error with which the node stopped indexing, if any. It should
+// only be called once the channel returned by Done is closed.
+func (n *Node) Err() error {
+	return n.err
+}
+
+// Stop stops following consensus and indexing, waits for all components of the
+// node to finish, and releases its resources. It can be called more than once,
+// and at any point after the node is created.
+func (n *Node) Stop() error {
+	n.once.Do(func() {
+		n.stop = n.shutdown()
+	})
+	return n.stop
+}
+
+func (n *Node) shutdown() error {
+
+	// We first shut down the consensus follower, so that there is no indexing
+	// to be done anymore. We then stop the mapper logic itself, the mover of
+	// the cold tier, the replicator and the backfills, and wait for the
+	// checkpoint being emitted, if any.
+	n.cancel()
+	select {
+	case <-n.running:
+		<-n.follow.NodeBuilder.Done()
+	default:
+	}
+
+	var errs error
+	if n.fsm != nil {
+		err := n.fsm.Stop()
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("could not stop mapper: %w", err))
+		}
+	}
+	if n.mover != nil {
+		err := n.mover.Stop()
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("could not stop cold tier mover: %w", err))
+		}
+	}
+	if n.replicator != nil {
+		err := n.replicator.Stop()
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("could not stop replicator: %w", err))
+		}
+	}
+	for _, runner := range n.backfills {
+		err := runner.Stop()
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("could not stop backfill: %w", err))
+		}
+	}
+	n.wg.Wait()
+	if n.emitter != nil {
+		n.emitter.Wait()
+	}
+
+	err := n.release()
+	if err != nil {
+		errs = multierror.Append(errs, err)
+	}
+
+	return errs
+}
+
+// release releases the resources of the node in the reverse order from which
+// they were acquired.
+func (n *Node) release() error {
+	var errs error
+	for i := len(n.closers) - 1; i >= 0; i-- {
+		err := n.closers[i]()
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	n.closers = nil
+	return errs
+}
+
+// run launches the given function in its own goroutine, which the node waits
+// for when it is stopped.
+func (n *Node) run(name string, run func() error) {
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.log.Info().Msg(name + " starting")
+		err := run()
+		if err != nil {
+			n.log.Warn().Err(err).Msg(name + " failed")
+		}
+		n.log.Info().Msg(name + " stopped")
+	}()
+}
+
+// index runs the mapper until it finishes or fails.
+func (n *Node) index() {
+	start := time.Now()
+	n.log.Info().Time("start", start).Msg("Flow DPS Live Indexer starting")
+	err := n.fsm.Run()
+	if errors.Is(err, dps.ErrConflict) {
+		n.log.Warn().Err(err).Msg("staged height was sealed with conflicting execution result, restart to roll it back")
+	}
+	if err != nil {
+		n.log.Warn().Err(err).Msg("Flow DPS Live Indexer failed")
+	}
+	n.err = err
+	close(n.done)
+	finish := time.Now()
+	duration := finish.Sub(start)
+	n.log.Info().Time("finish", finish).Str("duration", duration.Round(time.Second).String()).Msg("Flow DPS Indexer stopped")
+}
@@ -0,0 +1,211 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package live
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewNode(t *testing.T) {
+	t.Run("handles speculative mode without execution data", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := DefaultConfig
+		cfg.Speculative = true
+		cfg.ProtocolOnly = true
+
+		_, err := NewNode(zerolog.Nop(), cfg)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles missing checkpoint output", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := DefaultConfig
+		cfg.Buckets = []string{"bucket"}
+		cfg.CheckpointInterval = 100
+
+		_, err := NewNode(zerolog.Nop(), cfg)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles missing execution records", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := DefaultConfig
+
+		_, err := NewNode(zerolog.Nop(), cfg)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestNode_Reach(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		n := Node{
+			log: zerolog.Nop(),
+			cfg: Config{
+				SeedAddress: listener.Addr().String(),
+				SeedRetry:   10 * time.Millisecond,
+			},
+			ctx: ctx,
+		}
+
+		assert.True(t, n.reach())
+	})
+
+	t.Run("retries until seed node is reachable", func(t *testing.T) {
+		t.Parallel()
+
+		// We reserve an address, and only listen on it again after the first
+		// attempts to reach it failed.
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		address := listener.Addr().String()
+		require.NoError(t, listener.Close())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		n := Node{
+			log: zerolog.Nop(),
+			cfg: Config{
+				SeedAddress: address,
+				SeedRetry:   10 * time.Millisecond,
+			},
+			ctx: ctx,
+		}
+
+		reached := make(chan bool, 1)
+		go func() {
+			reached <- n.reach()
+		}()
+
+		time.Sleep(50 * time.Millisecond)
+		listener, err = net.Listen("tcp", address)
+		require.NoError(t, err)
+		defer listener.Close()
+
+		select {
+		case ok := <-reached:
+			assert.True(t, ok)
+		case <-time.After(time.Second):
+			t.Fatal("seed node was not reached")
+		}
+	})
+
+	t.Run("stops when node is stopped", func(t *testing.T) {
+		t.Parallel()
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		address := listener.Addr().String()
+		require.NoError(t, listener.Close())
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		n := Node{
+			log: zerolog.Nop(),
+			cfg: Config{
+				SeedAddress: address,
+				SeedRetry:   10 * time.Millisecond,
+			},
+			ctx: ctx,
+		}
+
+		reached := make(chan bool, 1)
+		go func() {
+			reached <- n.reach()
+		}()
+
+		cancel()
+
+		select {
+		case ok := <-reached:
+			assert.False(t, ok)
+		case <-time.After(time.Second):
+			t.Fatal("reaching seed node did not stop")
+		}
+	})
+}
+
+func TestNode_Check(t *testing.T) {
+	t.Run("does nothing before follower timeout", func(t *testing.T) {
+		t.Parallel()
+
+		n := Node{
+			log: zerolog.Nop(),
+			cfg: Config{FollowerTimeout: time.Hour},
+			ctx: context.Background(),
+		}
+		last := time.Now().Add(-time.Minute)
+		n.finalized.Store(last)
+
+		n.check()
+
+		assert.Equal(t, last, n.finalized.Load())
+	})
+
+	t.Run("does nothing without follower timeout", func(t *testing.T) {
+		t.Parallel()
+
+		n := Node{
+			log: zerolog.Nop(),
+			ctx: context.Background(),
+		}
+		last := time.Now().Add(-time.Hour)
+		n.finalized.Store(last)
+
+		n.check()
+
+		assert.Equal(t, last, n.finalized.Load())
+	})
+
+	t.Run("restarts follower timeout once reached", func(t *testing.T) {
+		t.Parallel()
+
+		n := Node{
+			log: zerolog.Nop(),
+			cfg: Config{FollowerTimeout: time.Minute},
+			ctx: context.Background(),
+		}
+		last := time.Now().Add(-time.Hour)
+		n.finalized.Store(last)
+
+		n.check()
+
+		restarted, ok := n.finalized.Load().(time.Time)
+		require.True(t, ok)
+		assert.True(t, restarted.After(last))
+	})
+}
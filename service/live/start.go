@@ -0,0 +1,643 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package live
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	gcloud "cloud.google.com/go/storage"
+	"github.com/dgraph-io/badger/v2"
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p-core/peer"
+	p2p "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/multiformats/go-multiaddr"
+	"google.golang.org/api/option"
+
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/storage/badger/operation"
+
+	"github.com/optakt/flow-dps/codec"
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/backfill"
+	"github.com/optakt/flow-dps/service/checkpoint"
+	"github.com/optakt/flow-dps/service/cloud"
+	"github.com/optakt/flow-dps/service/forest"
+	"github.com/optakt/flow-dps/service/index"
+	"github.com/optakt/flow-dps/service/initializer"
+	"github.com/optakt/flow-dps/service/loader"
+	"github.com/optakt/flow-dps/service/mapper"
+	"github.com/optakt/flow-dps/service/metrics"
+	"github.com/optakt/flow-dps/service/pubsub"
+	"github.com/optakt/flow-dps/service/replica"
+	"github.com/optakt/flow-dps/service/storage"
+	"github.com/optakt/flow-dps/service/tier"
+	"github.com/optakt/flow-dps/service/tracker"
+)
+
+// Start opens the index database and starts indexing into it. If the node is
+// not following consensus yet, it starts following it as well. Once Start
+// returns, the reader of the node serves the indexed data.
+func (n *Node) Start() error {
+
+	// Finalized blocks are held back until all consumers are subscribed, so
+	// that no block finalized in between is missed by any of them, in case we
+	// are already following consensus.
+	n.relay.Lock()
+	defer n.relay.Unlock()
+
+	// The index database is what the mapper writes to and what the reader
+	// serves the data from.
+	indexDB, err := badger.Open(dps.DefaultOptions(n.cfg.IndexDir))
+	if err != nil {
+		return fmt.Errorf("could not open index database: %w", err)
+	}
+	n.closers = append(n.closers, indexDB.Close)
+
+	// The codec with which the values are stored is chosen when the index is
+	// created and recorded in it.
+	recorded, err := index.Codec(indexDB, storage.New(zbor.NewCodec(), storage.WithNamespace(n.cfg.Namespace)))
+	if err != nil {
+		return fmt.Errorf("could not get codec of index: %w", err)
+	}
+	name := n.cfg.Codec
+	if name == "" {
+		name = recorded
+	}
+	if name == "" {
+		name = dps.CodecZbor
+	}
+	if recorded != "" && name != recorded {
+		return fmt.Errorf("index was created with another codec (codec: %s, recorded: %s)", name, recorded)
+	}
+	codec, err := codec.New(name,
+		zbor.WithDeterministic(n.cfg.CodecDeterministic),
+		zbor.WithValidation(n.cfg.CodecValidate),
+	)
+	if err != nil {
+		return fmt.Errorf("could not create codec (%s): %w", name, err)
+	}
+	storageOpts, err := storage.CompressionOptions(n.cfg.Compression)
+	if err != nil {
+		return fmt.Errorf("could not parse compression policy: %w", err)
+	}
+	storageOpts = append(storageOpts,
+		storage.WithNamespace(n.cfg.Namespace),
+		storage.WithCompressionStats(n.cfg.CompressionStats),
+		storage.WithSizeStats(n.cfg.SizeStats),
+		storage.WithManifests(n.cfg.Manifests),
+		storage.WithStaging(n.cfg.Speculative),
+		storage.WithReplication(n.cfg.ReplicaURL != ""),
+		storage.WithVerification(n.cfg.VerifyPayloads),
+	)
+
+	// If a cold tier is configured, payloads that were moved to it are read
+	// from its object storage.
+	var store tier.Store
+	if n.cfg.ColdURL != "" {
+		store, err = tier.Open(n.cfg.ColdURL, n.cfg.ColdEndpoint, n.cfg.ColdRegion, n.cfg.Credentials)
+		if err != nil {
+			return fmt.Errorf("could not open cold tier (url: %s): %w", n.cfg.ColdURL, err)
+		}
+		cold, err := tier.NewReader(store,
+			tier.WithCacheSize(n.cfg.ColdCache),
+			tier.WithNamespace(n.cfg.Namespace),
+		)
+		if err != nil {
+			return fmt.Errorf("could not initialize cold tier reader: %w", err)
+		}
+		storageOpts = append(storageOpts, storage.WithColdTier(cold))
+	}
+	lib := storage.New(codec, storageOpts...)
+
+	// The running size statistics continue from the totals that were persisted
+	// the last time the index was written to, and the replication log continues
+	// after the writes that were already logged or replicated.
+	err = indexDB.View(lib.LoadSizeStats())
+	if err != nil {
+		return fmt.Errorf("could not load size statistics: %w", err)
+	}
+	err = indexDB.View(lib.LoadReplicaLog())
+	if err != nil {
+		return fmt.Errorf("could not load replication log: %w", err)
+	}
+	// When reads are given priority over writes, the reader and the writer
+	// share a scheduler that holds back the commits of the writer while reads
+	// are in progress.
+	var scheduler *index.Scheduler
+	if n.cfg.ContendedWrites > 0 {
+		scheduler = index.NewScheduler(n.cfg.ContendedWrites)
+	}
+	// The first and last heights are read by nearly every API request, so the
+	// served reader caches them, and the writer invalidates the cache whenever
+	// it commits new heights.
+	heights := index.NewHeights()
+	read := index.NewReader(indexDB, lib, index.WithPathFilters(n.cfg.PathFilters))
+	first, err := read.First(n.ctx)
+	if err != nil && !errors.Is(err, dps.ErrBootstrapping) {
+		return fmt.Errorf("could not get first height from index reader: %w", err)
+	}
+	empty := errors.Is(err, dps.ErrBootstrapping)
+
+	// If the index is empty and no root checkpoint was given, we can download
+	// it from the bucket or from a URL.
+	root := n.cfg.Checkpoint
+	if empty && root == "" && !n.cfg.ProtocolOnly && (n.cfg.CheckpointObject != "" || n.cfg.CheckpointURL != "") {
+		root, err = n.download()
+		if err != nil {
+			return fmt.Errorf("could not download root checkpoint: %w", err)
+		}
+	}
+	if empty && root == "" && !n.cfg.ProtocolOnly {
+		return fmt.Errorf("index database is empty and no root checkpoint was given")
+	}
+
+	// If indexing is restricted to the data of some accounts, the index is
+	// partial and it has to keep being restricted with the same filter. As
+	// the execution state trie can not be restored from a partial index, and
+	// the live indexer can not replay all heights since the root height, such
+	// an index can only be resumed without execution data.
+	filter := dps.Filter{
+		Allowed: n.cfg.Owners,
+		Denied:  n.cfg.Denied,
+	}
+	if !empty {
+		indexed, err := read.Filter(n.ctx)
+		if err != nil {
+			return fmt.Errorf("could not get filter from index reader: %w", err)
+		}
+		if filter.Empty() {
+			filter = indexed
+		}
+		if !filter.Equal(indexed) {
+			return fmt.Errorf("index was not restricted with the same filter")
+		}
+	}
+	if !empty && !filter.Empty() && !n.cfg.ProtocolOnly {
+		return fmt.Errorf("filtered index can not be resumed with execution data")
+	}
+
+	// A node that followed consensus in standby takes over the fence.
+	hostname, _ := os.Hostname()
+	holder := fmt.Sprintf("%s:%d", hostname, os.Getpid())
+	token, err := index.AcquireFence(indexDB, lib, holder, n.cfg.Takeover || n.following)
+	if err != nil {
+		return fmt.Errorf("could not acquire index fence: %w", err)
+	}
+
+	// A new index records the codec with which it is created.
+	if recorded == "" {
+		err = index.SaveCodec(indexDB, lib, name)
+		if err != nil {
+			return fmt.Errorf("could not save codec of index: %w", err)
+		}
+	}
+
+	// In speculative mode, heights are indexed before their execution results
+	// are sealed, and staged until they are. If a staged height was sealed
+	// with a conflicting execution result while we were stopped, we roll back
+	// all of the staged heights from there on before resuming.
+	var staging *index.Staging
+	if n.cfg.Speculative {
+		staging = index.NewStaging(indexDB, lib, tracker.NewSeals(n.protocolDB))
+		rolled, err := staging.Rollback()
+		if err != nil {
+			return fmt.Errorf("could not roll back conflicting staged heights: %w", err)
+		}
+		if len(rolled) > 0 {
+			n.log.Warn().Uint64("from", rolled[0]).Uint64("to", rolled[len(rolled)-1]).Msg("rolled back staged heights with conflicting seals")
+		}
+	}
+
+	// We initialize the writer with a flush interval, which makes sure that
+	// Badger transactions are committed to the database, even if they don't
+	// fill up fast enough, so that the indexed data is available quickly.
+	write := index.NewWriter(
+		indexDB,
+		lib,
+		index.WithFlushInterval(n.cfg.FlushInterval),
+		index.WithPathFilters(n.cfg.PathFilters),
+		index.WithFence(token),
+		index.WithScheduler(scheduler),
+		index.WithHeights(heights),
+	)
+	n.closers = append(n.closers, write.Close)
+
+	// The mover of the cold tier and the replicator hold the same fence as the
+	// writer.
+	if store != nil && n.cfg.ColdAge > 0 {
+		n.mover = tier.NewMover(n.log, indexDB, lib, store,
+			tier.WithAge(n.cfg.ColdAge),
+			tier.WithFence(token),
+			tier.WithNamespace(n.cfg.Namespace),
+		)
+	}
+	if n.cfg.ReplicaURL != "" {
+		remote, err := tier.Open(n.cfg.ReplicaURL, n.cfg.ReplicaEndpoint, n.cfg.ReplicaRegion, n.cfg.Credentials)
+		if err != nil {
+			return fmt.Errorf("could not open secondary index (url: %s): %w", n.cfg.ReplicaURL, err)
+		}
+		n.replicator = replica.NewReplicator(n.log, indexDB, lib, codec, remote,
+			replica.WithMaxLag(n.cfg.ReplicaMaxLag),
+			replica.WithFence(token),
+			replica.WithNamespace(n.cfg.Namespace),
+		)
+	}
+
+	// The backfills build secondary indexes for the heights that were indexed
+	// before the indexes were introduced, behind the same fence. Their entries
+	// belong to past heights, so they are not recorded in the manifest or the
+	// staging of the height being indexed.
+	for _, build := range n.cfg.Backfills {
+		runner := backfill.NewRunner(n.log, indexDB, lib.Detached(), read, build,
+			backfill.WithRate(n.cfg.BackfillRate),
+			backfill.WithFence(token),
+		)
+		n.backfills = append(n.backfills, runner)
+	}
+
+	// In protocol-only mode, we don't need any execution data. The consensus
+	// tracker then uses a record holder that builds partial block records from
+	// the protocol state, and the mapper never uses the feeder or the loader.
+	var hold tracker.RecordHolder
+	var feed mapper.Feeder
+	var load mapper.Loader
+	if n.cfg.ProtocolOnly {
+		hold = tracker.NewProtocol(n.protocolDB)
+	} else {
+		execution, err := n.execution(read, write)
+		if err != nil {
+			return err
+		}
+		hold = execution
+		feed = execution
+
+		// If we have an empty database, we want a loader to bootstrap from the
+		// checkpoint; if we don't, we can optionally use the root checkpoint to
+		// speed up the restart.
+		load = loader.FromIndex(n.log, lib, indexDB)
+		if root != "" {
+			file, err := os.Open(root)
+			if err != nil {
+				return fmt.Errorf("could not open checkpoint file: %w", err)
+			}
+			n.closers = append(n.closers, file.Close)
+			load = loader.FromCheckpoint(file)
+			if !empty {
+				load = loader.FromIndex(n.log, lib, indexDB,
+					loader.WithInitializer(load),
+					loader.WithExclude(loader.ExcludeAtOrBelow(first)),
+				)
+			}
+		}
+	}
+
+	// The consensus tracker is responsible for tracking changes to the
+	// available data for the consensus follower and related consensus data,
+	// and it uses the record holder to complement it.
+	consensus, err := tracker.NewConsensus(n.log, n.protocolDB, hold)
+	if err != nil {
+		return fmt.Errorf("could not initialize consensus tracker: %w", err)
+	}
+	n.consumers = append(n.consumers, consensus.OnBlockFinalized)
+
+	// If metrics are enabled, the mapper uses the metrics writer.
+	writer := dps.Writer(write)
+	if n.cfg.Metrics {
+		writer = index.NewMetricsWriter(write)
+	}
+	if n.cfg.Metrics && n.cfg.SizeStats {
+		err = metrics.RegisterSizeMetrics(lib)
+		if err != nil {
+			return fmt.Errorf("could not register size metrics: %w", err)
+		}
+	}
+
+	// If enabled, we keep the execution state tries of the most recent heights
+	// in memory, so that the reader can serve their register values before
+	// they are flushed to the index database.
+	options := []mapper.Option{
+		mapper.WithBootstrapState(empty),
+		mapper.WithSkipRegisters(n.cfg.SkipRegisters),
+		mapper.WithRegisterDeltas(n.cfg.RegisterDeltas),
+		mapper.WithSkipCorrupted(n.cfg.ReportCorrupted),
+		mapper.WithProtocolOnly(n.cfg.ProtocolOnly),
+		mapper.WithOwners(filter.Allowed...),
+		mapper.WithDenied(filter.Denied...),
+	}
+	if staging != nil {
+		options = append(options, mapper.WithStaging(staging))
+	}
+	// Only the reads served to API clients are scheduled, so that the reads of
+	// the indexer itself do not hold back its own writes.
+	served := index.NewReader(indexDB, lib,
+		index.WithPathFilters(n.cfg.PathFilters),
+		index.WithScheduler(scheduler),
+		index.WithHeights(heights),
+	)
+	n.read = served
+	n.records.Store(read)
+	if n.cfg.Recent > 0 && !n.cfg.ProtocolOnly {
+		memory := index.NewMemory(served, n.cfg.Recent)
+		options = append(options, mapper.WithRecent(memory))
+		n.read = memory
+	}
+
+	// If enabled, the mapper checks the sequence of indexed heights for gaps
+	// when resuming, and repairs them from the block data records.
+	if n.cfg.Audit || n.cfg.Repair {
+		options = append(options, mapper.WithAuditor(read))
+	}
+	if n.cfg.Repair {
+		options = append(options, mapper.WithRepair(consensus))
+	}
+
+	// If enabled, the mapper periodically emits checkpoints of the execution
+	// state trie, which other nodes can use to bootstrap near the tip.
+	if n.cfg.CheckpointInterval > 0 && !n.cfg.ProtocolOnly {
+		sink, err := checkpoint.Open(n.cfg.CheckpointOutput)
+		if err != nil {
+			return fmt.Errorf("could not open checkpoint output (%s): %w", n.cfg.CheckpointOutput, err)
+		}
+		n.emitter = checkpoint.NewEmitter(n.log, sink, checkpoint.WithInterval(n.cfg.CheckpointInterval))
+		options = append(options, mapper.WithEmitter(n.emitter))
+	}
+
+	// At this point, we can initialize the core business logic of the indexer,
+	// with the mapper's finite state machine and transitions.
+	forestOpts := []forest.Option{
+		forest.WithLimit(n.cfg.ForestLimit),
+	}
+	if n.cfg.Metrics {
+		forestOpts = append(forestOpts, forest.WithMetrics(forest.NewMetrics()))
+	}
+	monitor := n.cfg.Monitor
+	if monitor == nil && n.cfg.Metrics {
+		monitor = mapper.NewMonitor(mapper.WithMetrics(mapper.NewMetrics()))
+	}
+	if monitor == nil {
+		monitor = mapper.NewMonitor()
+	}
+	transitions := mapper.NewTransitions(n.log, load, consensus, feed, read, writer, options...)
+	state := mapper.EmptyState(forest.New(forestOpts...))
+	// The pause function is called before the mapper starts indexing the next
+	// height, so that no height is left partially indexed.
+	pause := func(mapper.Status, *mapper.State) error {
+		if n.cfg.Pause == nil {
+			return nil
+		}
+		return n.cfg.Pause()
+	}
+	n.fsm = mapper.NewFSM(state,
+		mapper.WithMonitor(monitor),
+		mapper.WithMiddleware(mapper.Before(pause, mapper.StatusIndex)),
+		mapper.WithMiddleware(mapper.Before(mapper.Throttle(write, 100*time.Millisecond), mapper.StatusMap, mapper.StatusForward)),
+		mapper.WithTransition(mapper.StatusInitialize, transitions.InitializeMapper),
+		mapper.WithTransition(mapper.StatusBootstrap, transitions.BootstrapState),
+		mapper.WithTransition(mapper.StatusResume, transitions.ResumeIndexing),
+		mapper.WithTransition(mapper.StatusIndex, transitions.IndexChain),
+		mapper.WithTransition(mapper.StatusUpdate, transitions.UpdateTree),
+		mapper.WithTransition(mapper.StatusCollect, transitions.CollectRegisters),
+		mapper.WithTransition(mapper.StatusMap, transitions.MapRegisters),
+		mapper.WithTransition(mapper.StatusForward, transitions.ForwardHeight),
+	)
+
+	// Finally, we launch all components in their own goroutine, so they can
+	// run concurrently.
+	n.Follow()
+	if n.subscriber != nil {
+		n.run("pub/sub streamer", func() error {
+			return n.subscriber.Run(n.ctx)
+		})
+	}
+	if n.mover != nil {
+		n.run("cold tier mover", n.mover.Run)
+	}
+	if n.replicator != nil {
+		n.run("replicator", n.replicator.Run)
+	}
+	for i, runner := range n.backfills {
+		n.run("backfill "+n.cfg.Backfills[i].Name(), runner.Run)
+	}
+	go n.index()
+
+	return nil
+}
+
+// download downloads the root checkpoint from the configured bucket or URL,
+// and returns the path to which it was downloaded. The download is written next
+// to the index database and resumed if it was interrupted before.
+func (n *Node) download() (string, error) {
+
+	var source cloud.Source
+	if n.cfg.CheckpointURL != "" {
+		source = cloud.NewURLSource(http.DefaultClient, n.cfg.CheckpointURL)
+	} else {
+		if len(n.cfg.Buckets) == 0 {
+			return "", fmt.Errorf("no bucket to download checkpoint object from")
+		}
+		client, err := gcloud.NewClient(context.Background(),
+			option.WithoutAuthentication(),
+		)
+		if err != nil {
+			return "", fmt.Errorf("could not connect GCP client: %w", err)
+		}
+		n.closers = append(n.closers, client.Close)
+		source = cloud.NewBucketSource(client.Bucket(n.cfg.Buckets[0]), n.cfg.CheckpointObject)
+	}
+
+	path := filepath.Clean(n.cfg.IndexDir) + ".checkpoint"
+	_, err := os.Stat(path)
+	if err == nil {
+		return path, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", fmt.Errorf("could not check downloaded checkpoint (path: %s): %w", path, err)
+	}
+
+	if len(n.cfg.CheckpointSHA256) == 0 {
+		n.log.Warn().Msg("no checkpoint checksum given, downloaded checkpoint will not be validated")
+	}
+	download := cloud.NewDownloader(n.log, source, n.cfg.CheckpointSHA256)
+	err = download.Download(path)
+	if err != nil {
+		return "", fmt.Errorf("could not download checkpoint (path: %s): %w", path, err)
+	}
+
+	return path, nil
+}
+
+// execution creates the execution tracker, which tracks the available execution
+// records from the record streamer. It serves as the record holder for the
+// consensus tracker and as the feeder for the mapper.
+func (n *Node) execution(read dps.Reader, write dps.Writer) (*tracker.Execution, error) {
+
+	// If we are resuming, and the consensus follower has already finalized
+	// some blocks that were not yet indexed, we need to download them again.
+	// Here, we figure out which blocks these are.
+	blockIDs, err := initializer.CatchupBlocks(n.ctx, n.protocolDB, read)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize catch-up blocks: %w", err)
+	}
+
+	// If enabled, the streamer archives the execution records in the index, in
+	// their original encoding, so that data can later be indexed from them
+	// without downloading them again.
+	var archive func(flow.Identifier, []byte) error
+	if n.cfg.ArchiveRecords {
+		archive = func(blockID flow.Identifier, data []byte) error {
+			return write.ExecutionRecord(n.ctx, blockID, data)
+		}
+	}
+
+	// By default, the cloud streamer retrieves block execution records from a
+	// Google Cloud Storage bucket. Alternatively, the pub/sub streamer receives
+	// them over a libp2p topic, on which a cooperating execution node publishes
+	// them as it executes blocks.
+	var stream interface {
+		tracker.RecordStreamer
+		OnBlockFinalized(blockID flow.Identifier)
+	}
+	if n.cfg.RecordPeer != "" {
+		stream, err = n.subscribe(len(blockIDs), archive)
+	} else {
+		stream, err = n.stream(blockIDs, archive)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The execution tracker keeps track of the last execution record it
+	// processed, in order to skip duplicates. Records that were processed
+	// before a restart, but whose data was not indexed yet, need to be
+	// processed again, so we rewind it to the last indexed height.
+	last, err := read.Last(n.ctx)
+	if err != nil && !errors.Is(err, dps.ErrBootstrapping) {
+		return nil, fmt.Errorf("could not get last height from index reader: %w", err)
+	}
+	err = tracker.Rewind(n.protocolDB, last)
+	if err != nil {
+		return nil, fmt.Errorf("could not rewind execution tracker: %w", err)
+	}
+	execution, err := tracker.NewExecution(n.log, n.protocolDB, stream)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize execution tracker: %w", err)
+	}
+
+	// The streamer uses the finalization callback to stream the execution
+	// data of finalized blocks in order.
+	n.consumers = append(n.consumers, stream.OnBlockFinalized)
+
+	return execution, nil
+}
+
+// subscribe creates the pub/sub streamer, which receives the execution records
+// from the configured record peer.
+func (n *Node) subscribe(missing int, archive func(flow.Identifier, []byte) error) (*pubsub.Streamer, error) {
+
+	// Records that were published while we were offline are lost, so we can
+	// only resume with the pub/sub streamer if there is nothing to catch up on.
+	if missing > 0 {
+		return nil, fmt.Errorf("missing execution records of %d blocks can not be received over pub/sub", missing)
+	}
+
+	address, err := multiaddr.NewMultiaddr(n.cfg.RecordPeer)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse record peer address (%s): %w", n.cfg.RecordPeer, err)
+	}
+	info, err := peer.AddrInfoFromP2pAddr(address)
+	if err != nil {
+		return nil, fmt.Errorf("could not get record peer information: %w", err)
+	}
+	host, err := libp2p.New(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("could not create libp2p host: %w", err)
+	}
+	n.closers = append(n.closers, host.Close)
+	err = host.Connect(context.Background(), *info)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to record peer (%s): %w", n.cfg.RecordPeer, err)
+	}
+	gossip, err := p2p.NewGossipSub(context.Background(), host)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize gossip pub/sub: %w", err)
+	}
+	topic, err := gossip.Join(n.cfg.RecordTopic)
+	if err != nil {
+		return nil, fmt.Errorf("could not join record topic (%s): %w", n.cfg.RecordTopic, err)
+	}
+	sub, err := topic.Subscribe()
+	if err != nil {
+		return nil, fmt.Errorf("could not subscribe to record topic (%s): %w", n.cfg.RecordTopic, err)
+	}
+	n.closers = append(n.closers, func() error {
+		sub.Cancel()
+		return nil
+	})
+
+	n.subscriber = pubsub.NewStreamer(n.log, sub,
+		pubsub.WithArchive(archive),
+	)
+
+	return n.subscriber, nil
+}
+
+// stream creates the cloud streamer, which downloads the execution records
+// from the configured buckets.
+func (n *Node) stream(blockIDs []flow.Identifier, archive func(flow.Identifier, []byte) error) (*cloud.GCPStreamer, error) {
+
+	client, err := gcloud.NewClient(context.Background(),
+		option.WithoutAuthentication(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect GCP client: %w", err)
+	}
+	n.closers = append(n.closers, client.Close)
+
+	// The height of a block, which some object name layouts use, is looked up
+	// in the protocol state, where the consensus follower stores the headers of
+	// finalized blocks.
+	lookup := func(blockID flow.Identifier) (uint64, error) {
+		var header flow.Header
+		err := n.protocolDB.View(operation.RetrieveHeader(blockID, &header))
+		if err != nil {
+			return 0, err
+		}
+		return header.Height, nil
+	}
+
+	// The first bucket is the primary one; the others are only used when a
+	// record is missing from it, or when it fails.
+	var fallbacks []*gcloud.BucketHandle
+	for _, name := range n.cfg.Buckets[1:] {
+		fallbacks = append(fallbacks, client.Bucket(name))
+	}
+	stream := cloud.NewGCPStreamer(n.log, client.Bucket(n.cfg.Buckets[0]),
+		cloud.WithCatchupBlocks(blockIDs),
+		cloud.WithFallbackBuckets(fallbacks...),
+		cloud.WithLayout(n.cfg.RecordLayout),
+		cloud.WithLookupHeight(lookup),
+		cloud.WithQuarantineAfter(n.cfg.QuarantineAfter),
+		cloud.WithArchive(archive),
+	)
+
+	return stream, nil
+}
@@ -75,3 +75,13 @@ func ExcludeAtOrBelow(threshold uint64) Exclude {
 		return height <= threshold
 	}
 }
+
+// ExcludeAbove is an exclude function that ignores heights above the given
+// threshold height. It can be used to restore the execution state trie as it
+// was at a height below the last indexed height, such as when reindexing a
+// range of heights.
+func ExcludeAbove(threshold uint64) Exclude {
+	return func(height uint64) bool {
+		return height > threshold
+	}
+}
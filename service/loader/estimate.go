@@ -0,0 +1,140 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package loader
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"unsafe"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common/encoding"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/flattener"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/node"
+	"github.com/onflow/flow-go/ledger/complete/wal"
+)
+
+// payloadKeySize is the size of the key under which the index stores a register
+// payload, which is made of the prefix, the path and the height.
+const payloadKeySize = 1 + 32 + 8
+
+// Estimate holds the resources that bootstrapping from a checkpoint is expected
+// to need. The memory estimates cover the trie and its payloads only, and the
+// index size covers the register payloads before compression, so they are a
+// rough lower bound of what is actually used.
+type Estimate struct {
+	Nodes    uint64 // number of nodes of the trie
+	Leaves   uint64 // number of leaves of the trie, each holding a register
+	Payloads uint64 // total size of the encoded register payloads, in bytes
+	Trie     uint64 // memory used by the restored trie, in bytes
+	Peak     uint64 // memory used while the trie is restored, in bytes
+	Index    uint64 // size of the register payloads in the index, in bytes
+}
+
+// Estimate scans the checkpoint and estimates the resources that loading its
+// trie and bootstrapping an index from it need. The nodes of the checkpoint are
+// read one after the other, so the scan itself only needs little memory. It
+// consumes the reader of the checkpoint, so the loader can no longer load the
+// trie afterwards.
+func (c *Checkpoint) Estimate() (*Estimate, error) {
+
+	reader := bufio.NewReader(c.file)
+	crc := wal.NewCRC32Reader(reader)
+
+	header := make([]byte, 2+2+8+2)
+	_, err := io.ReadFull(crc, header)
+	if err != nil {
+		return nil, fmt.Errorf("could not read checkpoint header: %w", err)
+	}
+	magic := binary.BigEndian.Uint16(header[0:2])
+	version := binary.BigEndian.Uint16(header[2:4])
+	count := binary.BigEndian.Uint64(header[4:12])
+	tries := binary.BigEndian.Uint16(header[12:14])
+	if magic != wal.MagicBytes {
+		return nil, fmt.Errorf("invalid checkpoint magic bytes (magic: %x)", magic)
+	}
+	if version != wal.VersionV1 && version != wal.VersionV3 {
+		return nil, fmt.Errorf("unsupported checkpoint version (version: %x)", version)
+	}
+
+	// Only version 3 checkpoints have a checksum, which is computed over all
+	// of the data that precedes it.
+	var nodes io.Reader = crc
+	if version != wal.VersionV3 {
+		nodes = reader
+	}
+
+	nodeSize := uint64(unsafe.Sizeof(node.Node{}))
+	storableSize := uint64(unsafe.Sizeof(flattener.StorableNode{})) + uint64(unsafe.Sizeof(&flattener.StorableNode{}))
+	payloadSize := uint64(unsafe.Sizeof(ledger.Payload{}))
+	partSize := uint64(unsafe.Sizeof(ledger.KeyPart{}))
+
+	estimate := Estimate{
+		Nodes: count,
+	}
+	var storables uint64
+	for i := uint64(1); i <= count; i++ {
+		storable, err := flattener.ReadStorableNode(nodes)
+		if err != nil {
+			return nil, fmt.Errorf("could not read checkpoint node (index: %d): %w", i, err)
+		}
+
+		// While the trie is restored, all of the nodes of the checkpoint are
+		// held in memory along with the nodes of the trie.
+		storables += storableSize + uint64(len(storable.Path)+len(storable.EncPayload)+len(storable.HashValue))
+		estimate.Trie += nodeSize
+
+		if storable.LIndex != 0 || storable.RIndex != 0 {
+			continue
+		}
+
+		payload, err := encoding.DecodePayload(storable.EncPayload)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode checkpoint payload (index: %d): %w", i, err)
+		}
+
+		estimate.Leaves++
+		estimate.Payloads += uint64(len(storable.EncPayload))
+		estimate.Trie += payloadSize + uint64(len(payload.Value))
+		for _, part := range payload.Key.KeyParts {
+			estimate.Trie += partSize + uint64(len(part.Value))
+		}
+		estimate.Index += payloadKeySize + uint64(len(storable.EncPayload))
+	}
+	estimate.Peak = estimate.Trie + storables
+
+	// We read the tries to get to the checksum of the checkpoint, so that the
+	// estimate is not based on a corrupted checkpoint.
+	for i := uint16(0); i < tries; i++ {
+		_, err := flattener.ReadStorableTrie(nodes)
+		if err != nil {
+			return nil, fmt.Errorf("could not read checkpoint trie (index: %d): %w", i, err)
+		}
+	}
+	if version == wal.VersionV3 {
+		checksum := make([]byte, 4)
+		_, err = io.ReadFull(reader, checksum)
+		if err != nil {
+			return nil, fmt.Errorf("could not read checkpoint checksum: %w", err)
+		}
+		if binary.BigEndian.Uint32(checksum) != crc.Crc32() {
+			return nil, fmt.Errorf("invalid checkpoint checksum (expected: %x, actual: %x)", binary.BigEndian.Uint32(checksum), crc.Crc32())
+		}
+	}
+
+	return &estimate, nil
+}
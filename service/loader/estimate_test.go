@@ -0,0 +1,96 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package loader_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/flattener"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
+	"github.com/onflow/flow-go/ledger/complete/wal"
+
+	"github.com/optakt/flow-dps/service/loader"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestCheckpoint_Estimate(t *testing.T) {
+
+	// checkpoint returns a checkpoint of a trie with three registers.
+	checkpoint := func(t *testing.T) []byte {
+		t.Helper()
+
+		paths := mocks.GenericLedgerPaths(3)
+		payloads := make([]ledger.Payload, 0, 3)
+		for _, payload := range mocks.GenericLedgerPayloads(3) {
+			payloads = append(payloads, *payload)
+		}
+		tree, err := trie.NewTrieWithUpdatedRegisters(trie.NewEmptyMTrie(), paths, payloads)
+		require.NoError(t, err)
+
+		flat, err := flattener.FlattenTrie(tree)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = wal.StoreCheckpoint(flat.ToFlattenedForestWithASingleTrie(), &buf)
+		require.NoError(t, err)
+
+		return buf.Bytes()
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		data := checkpoint(t)
+
+		estimate, err := loader.FromCheckpoint(bytes.NewReader(data)).Estimate()
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(3), estimate.Leaves)
+		assert.GreaterOrEqual(t, estimate.Nodes, estimate.Leaves)
+		assert.NotZero(t, estimate.Payloads)
+		assert.Greater(t, estimate.Peak, estimate.Trie)
+		assert.Greater(t, estimate.Index, estimate.Payloads)
+
+		// The estimate does not keep the trie in memory, but the loader can
+		// still restore it from the same checkpoint.
+		tree, err := loader.FromCheckpoint(bytes.NewReader(data)).Trie()
+		require.NoError(t, err)
+		assert.Len(t, tree.AllPayloads(), int(estimate.Leaves))
+	})
+
+	t.Run("handles corrupted checkpoint", func(t *testing.T) {
+		t.Parallel()
+
+		data := checkpoint(t)
+		data[len(data)/2] ^= 0xff
+
+		_, err := loader.FromCheckpoint(bytes.NewReader(data)).Estimate()
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles invalid checkpoint", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := loader.FromCheckpoint(bytes.NewReader(mocks.GenericBytes)).Estimate()
+
+		assert.Error(t, err)
+	})
+}
@@ -15,6 +15,7 @@
 package loader
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/dgraph-io/badger/v2"
@@ -24,6 +25,7 @@ import (
 	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
 
 	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/logs"
 )
 
 // Index implements an execution state trie loader on top of a DPS index,
@@ -46,7 +48,7 @@ func FromIndex(log zerolog.Logger, lib dps.ReadLibrary, db *badger.DB, options .
 	}
 
 	i := Index{
-		log: log.With().Str("component", "index_loader").Logger(),
+		log: log.With().Str(logs.Component, "index_loader").Logger(),
 		lib: lib,
 		db:  db,
 		cfg: cfg,
@@ -79,7 +81,7 @@ func (i *Index) Trie() (*trie.MTrie, error) {
 		return nil
 	}
 
-	err = i.db.View(i.lib.IterateLedger(i.cfg.ExcludeHeight, process))
+	err = i.db.View(i.lib.IterateLedger(context.Background(), i.cfg.ExcludeHeight, process))
 	if err != nil {
 		return nil, fmt.Errorf("could not iterate ledger: %w", err)
 	}
@@ -0,0 +1,149 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package logs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// componentKey is how the component field starts in JSON log messages.
+var componentKey = []byte(`"` + Component + `":"`)
+
+// Filter is a log writer that drops the messages of each component that are
+// below its log level. The level of a component is the one overridden for it,
+// or the default level otherwise. An override for a name also applies to the
+// components whose names start with it followed by an underscore, so that an
+// override for `mapper` applies to `mapper_transitions` as well.
+//
+// The filter only sees the messages that pass the global log level, so it
+// keeps the global log level at the most verbose of its levels.
+type Filter struct {
+	out       zerolog.LevelWriter
+	mutex     *sync.RWMutex
+	level     zerolog.Level
+	overrides map[string]zerolog.Level
+}
+
+// NewFilter returns a new filter that writes the messages it keeps to the given
+// writer, with the given overrides of the log level per component. It starts
+// with the debug level as default level.
+func NewFilter(out io.Writer, overrides map[string]zerolog.Level) *Filter {
+
+	lw, ok := out.(zerolog.LevelWriter)
+	if !ok {
+		lw = levelWriter{Writer: out}
+	}
+
+	f := Filter{
+		out:       lw,
+		mutex:     &sync.RWMutex{},
+		overrides: overrides,
+	}
+	f.SetLevel(zerolog.DebugLevel)
+
+	return &f
+}
+
+// SetLevel sets the default log level, for the components without override.
+func (f *Filter) SetLevel(level zerolog.Level) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.level = level
+
+	lowest := level
+	for _, override := range f.overrides {
+		if override < lowest {
+			lowest = override
+		}
+	}
+	zerolog.SetGlobalLevel(lowest)
+}
+
+// Write writes messages without level as they are.
+func (f *Filter) Write(p []byte) (int, error) {
+	return f.out.Write(p)
+}
+
+// WriteLevel writes the given message if its level is at or above the level of
+// the component that logged it, and drops it otherwise.
+func (f *Filter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < f.threshold(component(p)) {
+		return len(p), nil
+	}
+	return f.out.WriteLevel(level, p)
+}
+
+func (f *Filter) threshold(name string) zerolog.Level {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	for name != "" {
+		level, ok := f.overrides[name]
+		if ok {
+			return level
+		}
+		index := strings.LastIndexByte(name, '_')
+		if index < 0 {
+			break
+		}
+		name = name[:index]
+	}
+
+	return f.level
+}
+
+// component returns the name of the component that logged the given message,
+// or an empty string if the message has no component.
+func component(p []byte) string {
+	start := bytes.Index(p, componentKey)
+	if start < 0 {
+		return ""
+	}
+	p = p[start+len(componentKey):]
+	end := bytes.IndexByte(p, '"')
+	if end < 0 {
+		return ""
+	}
+	return string(p[:end])
+}
+
+// ParseOverrides parses the given log levels per component.
+func ParseOverrides(values map[string]string) (map[string]zerolog.Level, error) {
+	overrides := make(map[string]zerolog.Level, len(values))
+	for name, value := range values {
+		level, err := zerolog.ParseLevel(value)
+		if err != nil || value == "" {
+			return nil, fmt.Errorf("invalid log level for component %s (%s)", name, value)
+		}
+		overrides[name] = level
+	}
+	return overrides, nil
+}
+
+// levelWriter adapts a writer that ignores levels to a level writer.
+type levelWriter struct {
+	io.Writer
+}
+
+func (l levelWriter) WriteLevel(_ zerolog.Level, p []byte) (int, error) {
+	return l.Write(p)
+}
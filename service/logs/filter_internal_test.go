@@ -0,0 +1,118 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package logs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The filter sets the global log level, so its tests do not run in parallel and
+// restore the level once they are done.
+
+func TestFilter(t *testing.T) {
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+
+	overrides := map[string]zerolog.Level{
+		"mapper":       zerolog.DebugLevel,
+		"gcp_streamer": zerolog.WarnLevel,
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		var buf bytes.Buffer
+		filter := NewFilter(&buf, overrides)
+		filter.SetLevel(zerolog.InfoLevel)
+
+		assert.Equal(t, zerolog.DebugLevel, zerolog.GlobalLevel())
+
+		log := zerolog.New(filter)
+		mapper := log.With().Str(Component, "mapper_transitions").Logger()
+		streamer := log.With().Str(Component, "gcp_streamer").Logger()
+		tracker := log.With().Str(Component, "consensus_tracker").Logger()
+
+		mapper.Debug().Msg("mapper debug")
+		streamer.Info().Msg("streamer info")
+		streamer.Warn().Msg("streamer warn")
+		tracker.Debug().Msg("tracker debug")
+		tracker.Info().Msg("tracker info")
+		log.Debug().Msg("main debug")
+		log.Info().Msg("main info")
+
+		out := buf.String()
+		assert.Contains(t, out, "mapper debug")
+		assert.NotContains(t, out, "streamer info")
+		assert.Contains(t, out, "streamer warn")
+		assert.NotContains(t, out, "tracker debug")
+		assert.Contains(t, out, "tracker info")
+		assert.NotContains(t, out, "main debug")
+		assert.Contains(t, out, "main info")
+	})
+
+	t.Run("applies new default level", func(t *testing.T) {
+		var buf bytes.Buffer
+		filter := NewFilter(&buf, overrides)
+		filter.SetLevel(zerolog.ErrorLevel)
+
+		log := zerolog.New(filter)
+		log.Warn().Msg("first warn")
+		filter.SetLevel(zerolog.WarnLevel)
+		log.Warn().Msg("second warn")
+
+		assert.NotContains(t, buf.String(), "first warn")
+		assert.Contains(t, buf.String(), "second warn")
+	})
+
+	t.Run("keeps global level without overrides", func(t *testing.T) {
+		filter := NewFilter(&bytes.Buffer{}, nil)
+		filter.SetLevel(zerolog.WarnLevel)
+
+		assert.Equal(t, zerolog.WarnLevel, zerolog.GlobalLevel())
+	})
+}
+
+func TestParseOverrides(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := ParseOverrides(map[string]string{"mapper": "debug", "gcp_streamer": "warn"})
+
+		require.NoError(t, err)
+		want := map[string]zerolog.Level{
+			"mapper":       zerolog.DebugLevel,
+			"gcp_streamer": zerolog.WarnLevel,
+		}
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("handles invalid level", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := ParseOverrides(map[string]string{"mapper": "loud"})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles empty level", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := ParseOverrides(map[string]string{"mapper": ""})
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,43 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package logs
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Formats of the log output.
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Output wraps the given writer so that log messages are written to it in the
+// given format. JSON output is meant to be processed by log aggregators, while
+// console output is meant to be read by humans.
+func Output(format string, w io.Writer) (io.Writer, error) {
+	switch format {
+	case FormatJSON:
+		return w, nil
+	case FormatConsole:
+		return zerolog.ConsoleWriter{Out: w, TimeFormat: time.RFC3339}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format (%s)", format)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package logs
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutput(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		out, err := Output(FormatJSON, &buf)
+		require.NoError(t, err)
+
+		log := zerolog.New(out)
+		log.Info().Uint64(Height, 42).Msg("test")
+
+		assert.JSONEq(t, `{"level":"info","height":42,"message":"test"}`, buf.String())
+	})
+
+	t.Run("console", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		out, err := Output(FormatConsole, &buf)
+		require.NoError(t, err)
+
+		log := zerolog.New(out)
+		log.Info().Uint64(Height, 42).Msg("test")
+
+		assert.Contains(t, buf.String(), "test")
+		assert.Contains(t, buf.String(), "height=")
+		assert.NotContains(t, buf.String(), `"message"`)
+	})
+
+	t.Run("handles unknown format", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Output("xml", &bytes.Buffer{})
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,27 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package logs
+
+// Names of the fields that identify what a log message is about. All
+// components use the same names, so that the messages about an indexing
+// failure can be correlated across components by filtering on a single field.
+// Identifiers are logged in hexadecimal.
+const (
+	Component = "component" // name of the component that logs the message
+	Height    = "height"    // height of the block being processed
+	BlockID   = "block_id"  // identifier of the block being processed
+	TxID      = "tx_id"     // identifier of the transaction being processed
+	Commit    = "commit"    // state commitment of the execution state trie
+)
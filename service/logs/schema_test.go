@@ -0,0 +1,102 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package logs_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps/service/logs"
+)
+
+// TestSchema makes sure that log fields which identify what a message is about
+// use the names of the logging schema, rather than literal names that could
+// drift apart between components.
+func TestSchema(t *testing.T) {
+
+	// reserved holds the names of the schema, which should be referred to by
+	// their constants, and their former or likely aliases.
+	reserved := map[string]string{
+		logs.Component: "logs.Component",
+		logs.Height:    "logs.Height",
+		logs.BlockID:   "logs.BlockID",
+		logs.TxID:      "logs.TxID",
+		logs.Commit:    "logs.Commit",
+		"block":        "logs.BlockID",
+		"blockID":      "logs.BlockID",
+		"transaction":  "logs.TxID",
+		"tx":           "logs.TxID",
+		"txID":         "logs.TxID",
+	}
+
+	// fields holds the methods of zerolog events and contexts that add a field
+	// with the name given as their first argument.
+	fields := map[string]bool{
+		"Str": true, "Strs": true, "Stringer": true, "Hex": true, "Bytes": true,
+		"Uint": true, "Uint32": true, "Uint64": true, "Int": true, "Int64": true,
+		"Interface": true, "Dur": true, "Time": true, "Bool": true, "Float64": true,
+	}
+
+	for _, root := range []string{"../../api", "../../cmd", "../../service"} {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+				return nil
+			}
+
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, path, nil, 0)
+			if err != nil {
+				return err
+			}
+
+			ast.Inspect(file, func(node ast.Node) bool {
+				call, ok := node.(*ast.CallExpr)
+				if !ok || len(call.Args) == 0 {
+					return true
+				}
+				sel, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok || !fields[sel.Sel.Name] {
+					return true
+				}
+				lit, ok := call.Args[0].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					return true
+				}
+				name, err := strconv.Unquote(lit.Value)
+				if err != nil {
+					return true
+				}
+				constant, ok := reserved[name]
+				assert.Falsef(t, ok, "%s: log field %q should be %s", fset.Position(lit.Pos()), name, constant)
+				return true
+			})
+
+			return nil
+		})
+		require.NoError(t, err)
+	}
+}
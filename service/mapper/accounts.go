@@ -0,0 +1,115 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"fmt"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/codec/cadencejson"
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Event types of the protocol events emitted when keys are added to or removed
+// from an account, and when contracts are added to, updated on or removed from
+// an account.
+const (
+	eventAccountKeyAdded   flow.EventType = "flow.AccountKeyAdded"
+	eventAccountKeyRemoved flow.EventType = "flow.AccountKeyRemoved"
+
+	eventContractAdded   flow.EventType = "flow.AccountContractAdded"
+	eventContractUpdated flow.EventType = "flow.AccountContractUpdated"
+	eventContractRemoved flow.EventType = "flow.AccountContractRemoved"
+)
+
+// accountEvents returns the addresses of the accounts that were created by the
+// given events, and the addresses of the accounts whose keys were updated by
+// them, once per added or removed key. Accounts that don't pass the given
+// filter are skipped.
+func accountEvents(filter dps.Filter, events []flow.Event) ([]flow.Address, []flow.Address, error) {
+
+	var created, updated []flow.Address
+	for _, event := range events {
+
+		if event.Type != flow.EventAccountCreated &&
+			event.Type != eventAccountKeyAdded &&
+			event.Type != eventAccountKeyRemoved {
+			continue
+		}
+
+		var payload struct {
+			Address flow.Address `cadence:"address"`
+		}
+		err := cadencejson.Unmarshal(event.Payload, &payload)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not decode account event (type: %s): %w", event.Type, err)
+		}
+
+		if !filter.Address(payload.Address) {
+			continue
+		}
+
+		if event.Type == flow.EventAccountCreated {
+			created = append(created, payload.Address)
+			continue
+		}
+		updated = append(updated, payload.Address)
+	}
+
+	return created, updated, nil
+}
+
+// contractEvents returns the changes to contracts made by the given events at
+// the given height. Changes to contracts of accounts that don't pass the given
+// filter are skipped.
+func contractEvents(filter dps.Filter, height uint64, events []flow.Event) ([]dps.ContractVersion, error) {
+
+	var versions []dps.ContractVersion
+	for _, event := range events {
+
+		if event.Type != eventContractAdded &&
+			event.Type != eventContractUpdated &&
+			event.Type != eventContractRemoved {
+			continue
+		}
+
+		var payload struct {
+			Address  flow.Address `cadence:"address"`
+			CodeHash []byte       `cadence:"codeHash"`
+			Contract string       `cadence:"contract"`
+		}
+		err := cadencejson.Unmarshal(event.Payload, &payload)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode contract event (type: %s): %w", event.Type, err)
+		}
+
+		if !filter.Address(payload.Address) {
+			continue
+		}
+
+		version := dps.ContractVersion{
+			Address:       payload.Address,
+			Name:          payload.Contract,
+			Height:        height,
+			TransactionID: event.TransactionID,
+			CodeHash:      payload.CodeHash,
+			Removed:       event.Type == eventContractRemoved,
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime/tests/utils"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestAccountEvents(t *testing.T) {
+	addresses := mocks.GenericAddresses(4)
+	events := mocks.GenericEvents(4, flow.EventAccountCreated, eventAccountKeyAdded, eventAccountKeyRemoved, mocks.GenericEventType(0))
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		created, updated, err := accountEvents(dps.Filter{}, events)
+
+		require.NoError(t, err)
+		assert.Equal(t, addresses[:1], created)
+		assert.Equal(t, addresses[1:3], updated)
+	})
+
+	t.Run("nominal case with filter", func(t *testing.T) {
+		t.Parallel()
+
+		filter := dps.Filter{Denied: addresses[:2]}
+
+		created, updated, err := accountEvents(filter, events)
+
+		require.NoError(t, err)
+		assert.Empty(t, created)
+		assert.Equal(t, addresses[2:3], updated)
+	})
+
+	t.Run("handles invalid payload", func(t *testing.T) {
+		t.Parallel()
+
+		invalid := []flow.Event{{Type: flow.EventAccountCreated, Payload: mocks.GenericBytes}}
+
+		_, _, err := accountEvents(dps.Filter{}, invalid)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestContractEvents(t *testing.T) {
+	addresses := mocks.GenericAddresses(2)
+	hash := mocks.GenericCommit(0)
+	txID := mocks.GenericTransaction(0).ID()
+
+	events := []flow.Event{
+		contractEvent(t, eventContractAdded, txID, addresses[0], "First", hash[:]),
+		contractEvent(t, eventContractUpdated, txID, addresses[0], "First", hash[:]),
+		contractEvent(t, eventContractRemoved, txID, addresses[1], "Second", hash[:]),
+		mocks.GenericEvents(1)[0],
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := contractEvents(dps.Filter{}, mocks.GenericHeight, events)
+
+		want := []dps.ContractVersion{
+			{Address: addresses[0], Name: "First", Height: mocks.GenericHeight, TransactionID: txID, CodeHash: hash[:]},
+			{Address: addresses[0], Name: "First", Height: mocks.GenericHeight, TransactionID: txID, CodeHash: hash[:]},
+			{Address: addresses[1], Name: "Second", Height: mocks.GenericHeight, TransactionID: txID, CodeHash: hash[:], Removed: true},
+		}
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("nominal case with filter", func(t *testing.T) {
+		t.Parallel()
+
+		filter := dps.Filter{Allowed: addresses[1:]}
+
+		got, err := contractEvents(filter, mocks.GenericHeight, events)
+
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, "Second", got[0].Name)
+	})
+
+	t.Run("handles invalid payload", func(t *testing.T) {
+		t.Parallel()
+
+		invalid := []flow.Event{{Type: eventContractAdded, Payload: mocks.GenericBytes}}
+
+		_, err := contractEvents(dps.Filter{}, mocks.GenericHeight, invalid)
+
+		assert.Error(t, err)
+	})
+}
+
+// contractEvent creates a contract event of the given type with a valid payload.
+func contractEvent(t *testing.T, typ flow.EventType, txID flow.Identifier, address flow.Address, name string, hash []byte) flow.Event {
+	t.Helper()
+
+	values := make([]cadence.Value, 0, len(hash))
+	for _, b := range hash {
+		values = append(values, cadence.NewUInt8(b))
+	}
+	contract, err := cadence.NewString(name)
+	require.NoError(t, err)
+
+	eventType := cadence.EventType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: string(typ),
+		Fields: []cadence.Field{
+			{Identifier: "address", Type: cadence.AddressType{}},
+			{Identifier: "codeHash", Type: cadence.VariableSizedArrayType{ElementType: cadence.UInt8Type{}}},
+			{Identifier: "contract", Type: cadence.StringType{}},
+		},
+	}
+	event := cadence.NewEvent([]cadence.Value{
+		cadence.NewAddress(address),
+		cadence.NewArray(values),
+		contract,
+	}).WithType(&eventType)
+
+	payload, err := json.Encode(event)
+	require.NoError(t, err)
+
+	return flow.Event{
+		Type:          typ,
+		TransactionID: txID,
+		Payload:       payload,
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"time"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Backlog represents the queue of writes of an index writer, which is saturated
+// when the writer applies writes slower than the mapper submits them.
+type Backlog interface {
+	Saturated() bool
+}
+
+// Throttle returns a hook that holds back transitions while the given backlog
+// is saturated, checking it again at the given interval. Used before the
+// transitions that write to the index, it slows the mapper down to the pace at
+// which the index is written, instead of blocking it in the middle of a write.
+// When the state machine is stopped while it waits, the hook finishes it
+// without applying the transition.
+func Throttle(backlog Backlog, interval time.Duration) Hook {
+	return func(_ Status, s *State) error {
+		for backlog.Saturated() {
+			select {
+			case <-s.done:
+				return dps.ErrFinished
+			case <-time.After(interval):
+			}
+		}
+		return nil
+	}
+}
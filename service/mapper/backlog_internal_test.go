@@ -0,0 +1,101 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestThrottle(t *testing.T) {
+	interval := 10 * time.Millisecond
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		backlog := mocks.BaselineBacklog(t)
+
+		err := Throttle(backlog, time.Hour)(StatusForward, &State{done: make(chan struct{})})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("waits while saturated", func(t *testing.T) {
+		t.Parallel()
+
+		checks := 0
+		backlog := mocks.BaselineBacklog(t)
+		backlog.SaturatedFunc = func() bool {
+			checks++
+			return checks <= 3
+		}
+
+		start := time.Now()
+		err := Throttle(backlog, interval)(StatusForward, &State{done: make(chan struct{})})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4, checks)
+		assert.GreaterOrEqual(t, time.Since(start), 3*interval)
+	})
+
+	t.Run("finishes when stopped", func(t *testing.T) {
+		t.Parallel()
+
+		backlog := mocks.BaselineBacklog(t)
+		backlog.SaturatedFunc = func() bool {
+			return true
+		}
+
+		done := make(chan struct{})
+		close(done)
+		err := Throttle(backlog, time.Hour)(StatusForward, &State{done: done})
+
+		assert.ErrorIs(t, err, dps.ErrFinished)
+	})
+
+	t.Run("slows down the mapper", func(t *testing.T) {
+		t.Parallel()
+
+		// The queue of the writer is full for a while, so the height is only
+		// forwarded once the writer caught up.
+		start := time.Now()
+		backlog := mocks.BaselineBacklog(t)
+		backlog.SaturatedFunc = func() bool {
+			return time.Since(start) < 5*interval
+		}
+
+		var forwarded time.Time
+		forward := func(*State) error {
+			forwarded = time.Now()
+			return dps.ErrFinished
+		}
+
+		st := EmptyState(mocks.BaselineForest(t, true))
+		st.status = StatusForward
+		f := NewFSM(st,
+			WithMiddleware(Before(Throttle(backlog, interval), StatusMap, StatusForward)),
+			WithTransition(StatusForward, forward),
+		)
+
+		require.NoError(t, f.Run())
+		assert.GreaterOrEqual(t, forwarded.Sub(start), 5*interval)
+	})
+}
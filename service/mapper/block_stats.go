@@ -0,0 +1,90 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"fmt"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go/fvm"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/codec/cadencejson"
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// blockStats returns the aggregate statistics of the block at the given height
+// from all of its transactions, results and events. The fees are summed from
+// the deposits into the fees vault of the chain, so they are left at zero on
+// chains without a known fees contract.
+func blockStats(chainID flow.ChainID, height uint64, transactions []*flow.TransactionBody, results []*flow.TransactionResult, events []flow.Event) (dps.BlockStats, error) {
+
+	stats := dps.BlockStats{
+		Height:       height,
+		Transactions: uint64(len(transactions)),
+		Events:       uint64(len(events)),
+	}
+
+	senders := make(map[flow.Address]struct{})
+	for _, transaction := range transactions {
+		senders[transaction.ProposalKey.Address] = struct{}{}
+	}
+	stats.Senders = uint64(len(senders))
+
+	for _, result := range results {
+		stats.Computation += result.ComputationUsed
+	}
+
+	typ, ok := feesEventType(chainID)
+	if !ok {
+		return stats, nil
+	}
+	for _, event := range events {
+
+		if event.Type != typ {
+			continue
+		}
+
+		var payload struct {
+			Amount cadence.UFix64 `cadence:"amount"`
+		}
+		err := cadencejson.Unmarshal(event.Payload, &payload)
+		if err != nil {
+			return dps.BlockStats{}, fmt.Errorf("could not decode fees event (type: %s): %w", event.Type, err)
+		}
+
+		stats.Fees += uint64(payload.Amount)
+	}
+
+	return stats, nil
+}
+
+// feesEventType returns the type of the event emitted when transaction fees
+// are deposited into the fees vault of the chain with the given ID.
+func feesEventType(chainID flow.ChainID) (flow.EventType, bool) {
+
+	// Getting the chain of an unknown chain ID panics, so we only look up the
+	// address of the fees contract for the chains that Flow knows about.
+	switch chainID {
+	case flow.Mainnet, flow.Testnet, flow.Canary, flow.Benchnet, flow.Localnet, flow.Emulator, flow.MonotonicEmulator:
+	default:
+		return "", false
+	}
+
+	address := fvm.FlowFeesAddress(chainID.Chain())
+	typ := flow.EventType(fmt.Sprintf("A.%s.FlowFees.TokensDeposited", address.Hex()))
+
+	return typ, true
+}
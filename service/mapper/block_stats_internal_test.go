@@ -0,0 +1,111 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime/tests/utils"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestBlockStats(t *testing.T) {
+	chainID := mocks.GenericHeader.ChainID
+	typ, ok := feesEventType(chainID)
+	require.True(t, ok)
+
+	addresses := mocks.GenericAddresses(2)
+	transactions := mocks.GenericTransactions(3)
+	transactions[0].ProposalKey.Address = addresses[0]
+	transactions[1].ProposalKey.Address = addresses[1]
+	transactions[2].ProposalKey.Address = addresses[0]
+
+	results := mocks.GenericResults(3)
+	for i, result := range results {
+		result.ComputationUsed = uint64(10 * (i + 1))
+	}
+
+	events := append(mocks.GenericEvents(2), feesEvent(t, typ, 150), feesEvent(t, typ, 250))
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := blockStats(chainID, mocks.GenericHeight, transactions, results, events)
+
+		want := dps.BlockStats{
+			Height:       mocks.GenericHeight,
+			Transactions: 3,
+			Events:       4,
+			Fees:         400,
+			Computation:  60,
+			Senders:      2,
+		}
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("nominal case with unknown chain", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := blockStats("unknown", mocks.GenericHeight, transactions, results, events)
+
+		require.NoError(t, err)
+		assert.Zero(t, got.Fees)
+		assert.Equal(t, uint64(4), got.Events)
+	})
+
+	t.Run("handles invalid payload", func(t *testing.T) {
+		t.Parallel()
+
+		invalid := []flow.Event{{Type: typ, Payload: mocks.GenericBytes}}
+
+		_, err := blockStats(chainID, mocks.GenericHeight, transactions, results, invalid)
+
+		assert.Error(t, err)
+	})
+}
+
+// feesEvent creates a fees event of the given type for the given amount, in
+// fixed-point units.
+func feesEvent(t *testing.T, typ flow.EventType, amount uint64) flow.Event {
+	t.Helper()
+
+	eventType := cadence.EventType{
+		Location:            utils.TestLocation,
+		QualifiedIdentifier: string(typ),
+		Fields: []cadence.Field{
+			{Identifier: "amount", Type: cadence.UFix64Type{}},
+		},
+	}
+	event := cadence.NewEvent([]cadence.Value{
+		cadence.UFix64(amount),
+	}).WithType(&eventType)
+
+	payload, err := json.Encode(event)
+	require.NoError(t, err)
+
+	return flow.Event{
+		Type:    typ,
+		Payload: payload,
+	}
+}
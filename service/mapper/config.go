@@ -16,20 +16,46 @@ package mapper
 
 import (
 	"time"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
 )
 
 // DefaultConfig is the default configuration for the Mapper.
 var DefaultConfig = Config{
 	BootstrapState: false,
 	SkipRegisters:  false,
+	RegisterDeltas: false,
 	WaitInterval:   100 * time.Millisecond,
+	ReindexFrom:    0,
+	ReindexTo:      0,
+	SkipCorrupted:  false,
+	ProtocolOnly:   false,
+	Recent:         nil,
+	Emitter:        nil,
+	Auditor:        nil,
+	Repair:         nil,
+	Staging:        nil,
+	Filter:         dps.Filter{},
 }
 
 // Config contains optional parameters for the Mapper.
 type Config struct {
 	BootstrapState bool
 	SkipRegisters  bool
+	RegisterDeltas bool
 	WaitInterval   time.Duration
+	ReindexFrom    uint64
+	ReindexTo      uint64
+	SkipCorrupted  bool
+	ProtocolOnly   bool
+	Recent         Recent
+	Emitter        Emitter
+	Auditor        Auditor
+	Repair         dps.Chain
+	Staging        Staging
+	Filter         dps.Filter
 }
 
 // Option is an option that can be given to the mapper to configure optional
@@ -52,6 +78,18 @@ func WithSkipRegisters(skip bool) Option {
 	}
 }
 
+// WithRegisterDeltas makes the mapper record, for each height, the hashes of
+// the registers written by the finalized block before and after the block, so
+// that the changes made by a block can be audited without comparing the state
+// at two heights. The registers of the root height are loaded from the root
+// checkpoint rather than written by a block, so no changes are recorded for
+// it.
+func WithRegisterDeltas(enabled bool) Option {
+	return func(cfg *Config) {
+		cfg.RegisterDeltas = enabled
+	}
+}
+
 // WithWaitInterval sets the wait interval that we will wait before retrying
 // to retrieve a trie update when it wasn't available.
 func WithWaitInterval(interval time.Duration) Option {
@@ -59,3 +97,114 @@ func WithWaitInterval(interval time.Duration) Option {
 		cfg.WaitInterval = interval
 	}
 }
+
+// WithReindexRange makes the mapper replay the given range of heights into an
+// existing index, instead of resuming from the last indexed height. The state
+// trie is restored at the height right before the range, and the mapper stops
+// once the last height of the range has been indexed.
+func WithReindexRange(from uint64, to uint64) Option {
+	return func(cfg *Config) {
+		cfg.ReindexFrom = from
+		cfg.ReindexTo = to
+	}
+}
+
+// WithSkipCorrupted makes the mapper skip trie updates that the feeder reports
+// as corrupted, instead of halting. The heights at which corrupted data was
+// skipped are recorded in the index, so that they can be verified later. If
+// the next trie update can then not be applied, the skipped update might have
+// been on the path to the commit of the next finalized block, and the mapper
+// halts with an error instead of waiting for that commit forever. Corrupted
+// execution data of a finalized block, such as a quarantined execution record,
+// can never be skipped, as the state of all later heights builds on it: its
+// height is recorded in the index, and the mapper then halts and reports it.
+func WithSkipCorrupted(skip bool) Option {
+	return func(cfg *Config) {
+		cfg.SkipCorrupted = skip
+	}
+}
+
+// WithProtocolOnly makes the mapper index only the data that is available
+// from the protocol state, without requiring any execution data. No state
+// commitments, events, transaction results or ledger registers are indexed,
+// and no execution state trie is maintained.
+func WithProtocolOnly(protocol bool) Option {
+	return func(cfg *Config) {
+		cfg.ProtocolOnly = protocol
+	}
+}
+
+// WithRecent makes the mapper hand over the execution state trie of each
+// height it finishes indexing to the given component, so that the registers
+// of recent heights can be served from memory.
+func WithRecent(recent Recent) Option {
+	return func(cfg *Config) {
+		cfg.Recent = recent
+	}
+}
+
+// WithEmitter makes the mapper hand over the execution state trie of each
+// height it finishes indexing to the given emitter, so that checkpoints of the
+// execution state can be emitted for other nodes to bootstrap from.
+func WithEmitter(emitter Emitter) Option {
+	return func(cfg *Config) {
+		cfg.Emitter = emitter
+	}
+}
+
+// WithAuditor makes the mapper check the sequence of indexed heights for gaps
+// when resuming, as well as for heights that were written out of order beyond
+// the last indexed height. If any are found, the mapper refuses to resume with
+// a precise report of the affected heights, unless a chain to repair the index
+// from is given with `WithRepair`.
+func WithAuditor(auditor Auditor) Option {
+	return func(cfg *Config) {
+		cfg.Auditor = auditor
+	}
+}
+
+// WithRepair makes the mapper repair the gaps found by the auditor when
+// resuming, by indexing the block data of the missing heights again from the
+// given chain. The registers of the missing heights can not be repaired this
+// way, as they are derived from the execution state trie; the repaired heights
+// are logged so that they can be reindexed.
+func WithRepair(chain dps.Chain) Option {
+	return func(cfg *Config) {
+		cfg.Repair = chain
+	}
+}
+
+// WithStaging makes the mapper stage each indexed height, so that it can be
+// rolled back until its execution result is sealed, and settle the staged
+// heights with the given staging after each height. Mapping stops with
+// `dps.ErrConflict` when a height was sealed with a state commitment that
+// conflicts with the indexed one. The storage library of the writer has to
+// stage its entries for the heights to be staged.
+func WithStaging(staging Staging) Option {
+	return func(cfg *Config) {
+		cfg.Staging = staging
+	}
+}
+
+// WithOwners makes the mapper index only the data involving the given
+// accounts: the ledger registers they own, along with the global registers
+// that have no owner, the transactions they pay for, propose or authorize,
+// and the events of these transactions or of their contracts. The resulting
+// index is partial, and its filter is recorded in it so that readers can tell
+// data that was not indexed apart from missing data. The execution state trie
+// is still maintained in full, as it is needed to match the state commitments
+// of finalized blocks.
+func WithOwners(owners ...flow.Address) Option {
+	return func(cfg *Config) {
+		cfg.Filter.Allowed = owners
+	}
+}
+
+// WithDenied makes the mapper exclude the data involving the given accounts
+// from indexing. It can be combined with `WithOwners`, in which case denied
+// accounts take precedence.
+func WithDenied(denied ...flow.Address) Option {
+	return func(cfg *Config) {
+		cfg.Filter.Denied = denied
+	}
+}
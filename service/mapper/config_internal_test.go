@@ -19,6 +19,9 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
 )
 
 func TestWithBootstrapState(t *testing.T) {
@@ -53,3 +56,94 @@ func TestWithIndexHeader(t *testing.T) {
 
 	assert.Equal(t, interval, c.WaitInterval)
 }
+
+func TestWithReindexRange(t *testing.T) {
+	c := &Config{
+		ReindexFrom: 0,
+		ReindexTo:   0,
+	}
+	from := uint64(1337)
+	to := uint64(1338)
+
+	WithReindexRange(from, to)(c)
+
+	assert.Equal(t, from, c.ReindexFrom)
+	assert.Equal(t, to, c.ReindexTo)
+}
+
+func TestWithRecent(t *testing.T) {
+	c := &Config{
+		Recent: nil,
+	}
+	recent := mocks.BaselineRecent(t)
+
+	WithRecent(recent)(c)
+
+	assert.Equal(t, recent, c.Recent)
+}
+
+func TestWithEmitter(t *testing.T) {
+	c := &Config{
+		Emitter: nil,
+	}
+	emitter := mocks.BaselineEmitter(t)
+
+	WithEmitter(emitter)(c)
+
+	assert.Equal(t, emitter, c.Emitter)
+}
+
+func TestWithOwners(t *testing.T) {
+	c := &Config{
+		Filter: dps.Filter{},
+	}
+	owners := mocks.GenericAddresses(2)
+
+	WithOwners(owners...)(c)
+
+	assert.Equal(t, owners, c.Filter.Allowed)
+}
+
+func TestWithDenied(t *testing.T) {
+	c := &Config{
+		Filter: dps.Filter{},
+	}
+	denied := mocks.GenericAddresses(2)
+
+	WithDenied(denied...)(c)
+
+	assert.Equal(t, denied, c.Filter.Denied)
+}
+
+func TestWithAuditor(t *testing.T) {
+	c := &Config{
+		Auditor: nil,
+	}
+	auditor := mocks.BaselineAuditor(t)
+
+	WithAuditor(auditor)(c)
+
+	assert.Equal(t, auditor, c.Auditor)
+}
+
+func TestWithRepair(t *testing.T) {
+	c := &Config{
+		Repair: nil,
+	}
+	chain := mocks.BaselineChain(t)
+
+	WithRepair(chain)(c)
+
+	assert.Equal(t, chain, c.Repair)
+}
+
+func TestWithStaging(t *testing.T) {
+	c := &Config{
+		Staging: nil,
+	}
+	staging := mocks.BaselineStaging(t)
+
+	WithStaging(staging)(c)
+
+	assert.Equal(t, staging, c.Staging)
+}
@@ -0,0 +1,134 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// epoch keeps track of the epoch and of the epoch phase at the last indexed
+// height, so that we can detect when a new epoch or phase starts.
+type epoch struct {
+	setupID flow.Identifier
+	counter uint64
+	phase   flow.EpochPhase
+}
+
+// epochAt returns the epoch and the epoch phase at the given height of the
+// chain.
+func epochAt(chain dps.Chain, height uint64) (*epoch, error) {
+
+	status, err := chain.EpochStatus(height)
+	if err != nil {
+		return nil, fmt.Errorf("could not get epoch status: %w", err)
+	}
+	phase, err := status.Phase()
+	if err != nil {
+		return nil, fmt.Errorf("could not get epoch phase: %w", err)
+	}
+	setup, err := chain.EpochSetup(height)
+	if err != nil {
+		return nil, fmt.Errorf("could not get epoch setup: %w", err)
+	}
+
+	e := epoch{
+		setupID: status.CurrentEpoch.SetupID,
+		counter: setup.Counter,
+		phase:   phase,
+	}
+
+	return &e, nil
+}
+
+// indexEpoch indexes the epoch at the given height when it is the first height
+// of the epoch that is indexed, and the height itself when it is the first
+// height of an epoch phase.
+func (t *Transitions) indexEpoch(ctx context.Context, height uint64) error {
+
+	// When we just started, we look up the epoch at the previous height, so we
+	// can tell whether the given height starts a new epoch or phase. At the
+	// root height, there is no previous height, so we index the epoch without
+	// knowing at which height its current phase started.
+	if t.epoch == nil {
+		root, err := t.chain.Root()
+		if err != nil {
+			return fmt.Errorf("could not get root height: %w", err)
+		}
+		if height > root {
+			t.epoch, err = epochAt(t.chain, height-1)
+			if err != nil {
+				return fmt.Errorf("could not get previous epoch: %w", err)
+			}
+		}
+	}
+
+	status, err := t.chain.EpochStatus(height)
+	if err != nil {
+		return fmt.Errorf("could not get epoch status: %w", err)
+	}
+	phase, err := status.Phase()
+	if err != nil {
+		return fmt.Errorf("could not get epoch phase: %w", err)
+	}
+
+	previous := t.epoch
+	current := epoch{
+		setupID: status.CurrentEpoch.SetupID,
+		phase:   phase,
+	}
+
+	switch {
+
+	// If the epoch changed, we index the new epoch along with its identity
+	// table, and the start of its first phase.
+	case previous == nil || previous.setupID != current.setupID:
+		setup, err := t.chain.EpochSetup(height)
+		if err != nil {
+			return fmt.Errorf("could not get epoch setup: %w", err)
+		}
+		err = t.write.Epoch(ctx, setup)
+		if err != nil {
+			return fmt.Errorf("could not index epoch: %w", err)
+		}
+		current.counter = setup.Counter
+		if previous == nil {
+			break
+		}
+		err = t.write.Phase(ctx, current.counter, current.phase, height)
+		if err != nil {
+			return fmt.Errorf("could not index epoch phase: %w", err)
+		}
+
+	// If only the phase changed, we index the start of the new phase.
+	case previous.phase != current.phase:
+		current.counter = previous.counter
+		err = t.write.Phase(ctx, current.counter, current.phase, height)
+		if err != nil {
+			return fmt.Errorf("could not index epoch phase: %w", err)
+		}
+
+	default:
+		current.counter = previous.counter
+	}
+
+	t.epoch = &current
+
+	return nil
+}
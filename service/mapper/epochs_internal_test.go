@@ -0,0 +1,258 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestTransitions_IndexEpoch(t *testing.T) {
+	staking := mocks.GenericEpochStatus
+	setup := staking.Copy()
+	setup.NextEpoch.SetupID = mocks.GenericSeal(0).ID()
+	next := &flow.EpochStatus{
+		PreviousEpoch: staking.CurrentEpoch,
+		CurrentEpoch: flow.EventIDs{
+			SetupID:  mocks.GenericSeal(1).ID(),
+			CommitID: mocks.GenericSeal(2).ID(),
+		},
+	}
+
+	// chainWith returns a chain whose epoch status is the given status at the
+	// generic height, and the staking status of epoch 1 before it.
+	chainWith := func(t *testing.T, status *flow.EpochStatus) *mocks.Chain {
+		chain := mocks.BaselineChain(t)
+		chain.RootFunc = func() (uint64, error) {
+			return mocks.GenericHeight - 1, nil
+		}
+		chain.EpochStatusFunc = func(height uint64) (*flow.EpochStatus, error) {
+			if height < mocks.GenericHeight {
+				return staking, nil
+			}
+			return status, nil
+		}
+		chain.EpochSetupFunc = func(height uint64) (*flow.EpochSetup, error) {
+			if height < mocks.GenericHeight {
+				return mocks.GenericEpochSetup(1), nil
+			}
+			return mocks.GenericEpochSetup(2), nil
+		}
+		return chain
+	}
+
+	t.Run("nominal case at root height", func(t *testing.T) {
+		t.Parallel()
+
+		chain := mocks.BaselineChain(t)
+
+		var indexed bool
+		write := mocks.BaselineWriter(t)
+		write.EpochFunc = func(_ context.Context, got *flow.EpochSetup) error {
+			assert.Equal(t, mocks.GenericEpochSetup(1), got)
+			indexed = true
+			return nil
+		}
+		write.PhaseFunc = func(context.Context, uint64, flow.EpochPhase, uint64) error {
+			t.Error("phase should not be indexed without previous height")
+			return nil
+		}
+
+		tr, _ := baselineFSM(t, StatusIndex, withChain(chain), withWriter(write))
+
+		err := tr.indexEpoch(context.Background(), mocks.GenericHeight)
+
+		require.NoError(t, err)
+		assert.True(t, indexed)
+		require.NotNil(t, tr.epoch)
+		assert.Equal(t, uint64(1), tr.epoch.counter)
+		assert.Equal(t, flow.EpochPhaseStaking, tr.epoch.phase)
+	})
+
+	t.Run("nominal case without changes", func(t *testing.T) {
+		t.Parallel()
+
+		write := mocks.BaselineWriter(t)
+		write.EpochFunc = func(context.Context, *flow.EpochSetup) error {
+			t.Error("epoch should not be indexed without changes")
+			return nil
+		}
+		write.PhaseFunc = func(context.Context, uint64, flow.EpochPhase, uint64) error {
+			t.Error("phase should not be indexed without changes")
+			return nil
+		}
+
+		tr, _ := baselineFSM(t, StatusIndex, withChain(chainWith(t, staking)), withWriter(write))
+
+		err := tr.indexEpoch(context.Background(), mocks.GenericHeight)
+
+		require.NoError(t, err)
+		require.NotNil(t, tr.epoch)
+		assert.Equal(t, uint64(1), tr.epoch.counter)
+	})
+
+	t.Run("nominal case with new phase", func(t *testing.T) {
+		t.Parallel()
+
+		var indexed bool
+		write := mocks.BaselineWriter(t)
+		write.EpochFunc = func(context.Context, *flow.EpochSetup) error {
+			t.Error("epoch should not be indexed for new phase")
+			return nil
+		}
+		write.PhaseFunc = func(_ context.Context, counter uint64, phase flow.EpochPhase, height uint64) error {
+			assert.Equal(t, uint64(1), counter)
+			assert.Equal(t, flow.EpochPhaseSetup, phase)
+			assert.Equal(t, mocks.GenericHeight, height)
+			indexed = true
+			return nil
+		}
+
+		tr, _ := baselineFSM(t, StatusIndex, withChain(chainWith(t, setup)), withWriter(write))
+
+		err := tr.indexEpoch(context.Background(), mocks.GenericHeight)
+
+		require.NoError(t, err)
+		assert.True(t, indexed)
+		require.NotNil(t, tr.epoch)
+		assert.Equal(t, flow.EpochPhaseSetup, tr.epoch.phase)
+	})
+
+	t.Run("nominal case with new epoch", func(t *testing.T) {
+		t.Parallel()
+
+		var epochIndexed, phaseIndexed bool
+		write := mocks.BaselineWriter(t)
+		write.EpochFunc = func(_ context.Context, got *flow.EpochSetup) error {
+			assert.Equal(t, mocks.GenericEpochSetup(2), got)
+			epochIndexed = true
+			return nil
+		}
+		write.PhaseFunc = func(_ context.Context, counter uint64, phase flow.EpochPhase, height uint64) error {
+			assert.Equal(t, uint64(2), counter)
+			assert.Equal(t, flow.EpochPhaseStaking, phase)
+			assert.Equal(t, mocks.GenericHeight, height)
+			phaseIndexed = true
+			return nil
+		}
+
+		tr, _ := baselineFSM(t, StatusIndex, withChain(chainWith(t, next)), withWriter(write))
+
+		err := tr.indexEpoch(context.Background(), mocks.GenericHeight)
+
+		require.NoError(t, err)
+		assert.True(t, epochIndexed)
+		assert.True(t, phaseIndexed)
+		require.NotNil(t, tr.epoch)
+		assert.Equal(t, uint64(2), tr.epoch.counter)
+	})
+
+	t.Run("handles chain failure on root height", func(t *testing.T) {
+		t.Parallel()
+
+		chain := mocks.BaselineChain(t)
+		chain.RootFunc = func() (uint64, error) {
+			return 0, mocks.GenericError
+		}
+
+		tr, _ := baselineFSM(t, StatusIndex, withChain(chain))
+
+		err := tr.indexEpoch(context.Background(), mocks.GenericHeight)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles chain failure on epoch status", func(t *testing.T) {
+		t.Parallel()
+
+		chain := mocks.BaselineChain(t)
+		chain.EpochStatusFunc = func(uint64) (*flow.EpochStatus, error) {
+			return nil, mocks.GenericError
+		}
+
+		tr, _ := baselineFSM(t, StatusIndex, withChain(chain))
+
+		err := tr.indexEpoch(context.Background(), mocks.GenericHeight)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles invalid epoch status", func(t *testing.T) {
+		t.Parallel()
+
+		chain := mocks.BaselineChain(t)
+		chain.EpochStatusFunc = func(uint64) (*flow.EpochStatus, error) {
+			return &flow.EpochStatus{}, nil
+		}
+
+		tr, _ := baselineFSM(t, StatusIndex, withChain(chain))
+
+		err := tr.indexEpoch(context.Background(), mocks.GenericHeight)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles chain failure on epoch setup", func(t *testing.T) {
+		t.Parallel()
+
+		chain := mocks.BaselineChain(t)
+		chain.EpochSetupFunc = func(uint64) (*flow.EpochSetup, error) {
+			return nil, mocks.GenericError
+		}
+
+		tr, _ := baselineFSM(t, StatusIndex, withChain(chain))
+
+		err := tr.indexEpoch(context.Background(), mocks.GenericHeight)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles writer failure on epoch", func(t *testing.T) {
+		t.Parallel()
+
+		write := mocks.BaselineWriter(t)
+		write.EpochFunc = func(context.Context, *flow.EpochSetup) error {
+			return mocks.GenericError
+		}
+
+		tr, _ := baselineFSM(t, StatusIndex, withWriter(write))
+
+		err := tr.indexEpoch(context.Background(), mocks.GenericHeight)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles writer failure on phase", func(t *testing.T) {
+		t.Parallel()
+
+		write := mocks.BaselineWriter(t)
+		write.PhaseFunc = func(context.Context, uint64, flow.EpochPhase, uint64) error {
+			return mocks.GenericError
+		}
+
+		tr, _ := baselineFSM(t, StatusIndex, withChain(chainWith(t, setup)), withWriter(write))
+
+		err := tr.indexEpoch(context.Background(), mocks.GenericHeight)
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// filterTransactions returns the transactions that pass the given filter, along
+// with a lookup of their IDs.
+func filterTransactions(filter dps.Filter, transactions []*flow.TransactionBody) ([]*flow.TransactionBody, map[flow.Identifier]struct{}) {
+	filtered := make([]*flow.TransactionBody, 0, len(transactions))
+	lookup := make(map[flow.Identifier]struct{}, len(transactions))
+	for _, tx := range transactions {
+		if !filter.Transaction(tx) {
+			continue
+		}
+		filtered = append(filtered, tx)
+		lookup[tx.ID()] = struct{}{}
+	}
+	return filtered, lookup
+}
+
+// filterResults returns the transaction results of the given transactions.
+func filterResults(lookup map[flow.Identifier]struct{}, results []*flow.TransactionResult) []*flow.TransactionResult {
+	filtered := make([]*flow.TransactionResult, 0, len(results))
+	for _, result := range results {
+		_, ok := lookup[result.TransactionID]
+		if !ok {
+			continue
+		}
+		filtered = append(filtered, result)
+	}
+	return filtered
+}
+
+// filterEvents returns the events that pass the given filter, depending on
+// whether the transaction that emitted them passed it.
+func filterEvents(filter dps.Filter, lookup map[flow.Identifier]struct{}, events []flow.Event) []flow.Event {
+	filtered := make([]flow.Event, 0, len(events))
+	for _, event := range events {
+		_, ok := lookup[event.TransactionID]
+		if !filter.Event(event, ok) {
+			continue
+		}
+		filtered = append(filtered, event)
+	}
+	return filtered
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestFilters(t *testing.T) {
+	addresses := mocks.GenericAddresses(2)
+
+	allowed := mocks.GenericTransaction(0)
+	allowed.Payer = addresses[0]
+	other := mocks.GenericTransaction(1)
+	other.Payer = addresses[1]
+
+	results := []*flow.TransactionResult{
+		{TransactionID: allowed.ID()},
+		{TransactionID: other.ID()},
+	}
+
+	contract := flow.EventType("A." + addresses[0].Hex() + ".Contract.Event")
+	events := []flow.Event{
+		{TransactionID: allowed.ID(), Type: flow.EventAccountCreated},
+		{TransactionID: other.ID(), Type: flow.EventAccountCreated},
+		{TransactionID: other.ID(), Type: contract},
+	}
+
+	filter := dps.Filter{Allowed: addresses[:1]}
+
+	transactions, lookup := filterTransactions(filter, []*flow.TransactionBody{allowed, other})
+	assert.Equal(t, []*flow.TransactionBody{allowed}, transactions)
+	assert.Contains(t, lookup, allowed.ID())
+	assert.NotContains(t, lookup, other.ID())
+
+	gotResults := filterResults(lookup, results)
+	assert.Equal(t, results[:1], gotResults)
+
+	gotEvents := filterEvents(filter, lookup, events)
+	assert.Equal(t, []flow.Event{events[0], events[2]}, gotEvents)
+}
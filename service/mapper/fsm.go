@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/optakt/flow-dps/models/dps"
 )
@@ -27,6 +28,8 @@ import (
 type FSM struct {
 	state       *State
 	transitions map[Status]TransitionFunc
+	middleware  []Middleware
+	monitor     *Monitor
 	wg          *sync.WaitGroup
 }
 
@@ -36,6 +39,7 @@ func NewFSM(state *State, options ...func(*FSM)) *FSM {
 	f := FSM{
 		state:       state,
 		transitions: make(map[Status]TransitionFunc),
+		monitor:     NewMonitor(),
 		wg:          &sync.WaitGroup{},
 	}
 
@@ -43,6 +47,15 @@ func NewFSM(state *State, options ...func(*FSM)) *FSM {
 		option(&f)
 	}
 
+	// The middleware is applied in reverse order, so that the first middleware
+	// that was given is the outermost one around each transition.
+	for status, transition := range f.transitions {
+		for i := len(f.middleware) - 1; i >= 0; i-- {
+			transition = f.middleware[i](status, transition)
+		}
+		f.transitions[status] = transition
+	}
+
 	return &f
 }
 
@@ -59,12 +72,16 @@ func (f *FSM) Run() error {
 			// continue
 		}
 
-		transition, ok := f.transitions[f.state.status]
+		status := f.state.status
+		transition, ok := f.transitions[status]
 		if !ok {
-			return fmt.Errorf("could not find transition for status (%d)", f.state.status)
+			return fmt.Errorf("could not find transition for status (%d)", status)
 		}
 
+		f.monitor.Enter(status)
+		start := time.Now()
 		err := transition(f.state)
+		f.monitor.Exit(status, f.state.height, time.Since(start), err)
 		if errors.Is(err, dps.ErrFinished) {
 			return nil
 		}
@@ -74,6 +91,11 @@ func (f *FSM) Run() error {
 	}
 }
 
+// Monitor returns the monitor that keeps track of the state machine's progress.
+func (f *FSM) Monitor() *Monitor {
+	return f.monitor
+}
+
 // Stop gracefully stops the state machine.
 func (f *FSM) Stop() error {
 	close(f.state.done)
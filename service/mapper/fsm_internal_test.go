@@ -15,12 +15,20 @@
 package mapper
 
 import (
+	"context"
+	"fmt"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
 	"github.com/optakt/flow-dps/testing/mocks"
 )
 
@@ -45,6 +53,43 @@ func TestNewFSM(t *testing.T) {
 		assert.Equal(t, st, f.state)
 		assert.Len(t, f.transitions, 1)
 	})
+
+	t.Run("nominal case with monitor option", func(t *testing.T) {
+		t.Parallel()
+
+		monitor := NewMonitor()
+		f := NewFSM(st, WithMonitor(monitor))
+
+		assert.Same(t, monitor, f.Monitor())
+	})
+
+	t.Run("nominal case with middleware option", func(t *testing.T) {
+		t.Parallel()
+
+		var calls []string
+		middleware := func(name string) Middleware {
+			return func(status Status, transition TransitionFunc) TransitionFunc {
+				return func(s *State) error {
+					calls = append(calls, name)
+					return transition(s)
+				}
+			}
+		}
+		transition := func(*State) error {
+			calls = append(calls, "transition")
+			return nil
+		}
+
+		f := NewFSM(st,
+			WithMiddleware(middleware("first"), middleware("second")),
+			WithTransition(StatusIndex, transition),
+		)
+
+		err := f.transitions[StatusIndex](st)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"first", "second", "transition"}, calls)
+	})
 }
 
 func TestFSM_Run(t *testing.T) {
@@ -69,7 +114,8 @@ func TestFSM_Run(t *testing.T) {
 					return nil
 				},
 			},
-			wg: &sync.WaitGroup{},
+			monitor: NewMonitor(),
+			wg:      &sync.WaitGroup{},
 		}
 
 		done := make(chan struct{})
@@ -90,6 +136,90 @@ func TestFSM_Run(t *testing.T) {
 
 		assert.NotZero(t, emptyCalls)
 		assert.NotZero(t, matchedCalls)
+
+		info := f.monitor.Info()
+		require.Len(t, info.Transitions, 2)
+		assert.Equal(t, "bootstrap", info.Transitions[0].Status)
+		assert.Equal(t, uint64(emptyCalls), info.Transitions[0].Count)
+		assert.Equal(t, "collect", info.Transitions[1].Status)
+		assert.Equal(t, uint64(matchedCalls), info.Transitions[1].Count)
+	})
+
+	t.Run("skips past corrupted segment", func(t *testing.T) {
+		t.Parallel()
+
+		// The first trie update is in a corrupted segment, while the next one
+		// leads the forest to the commit of the finalized block.
+		calls := 0
+		feed := mocks.BaselineFeeder(t)
+		feed.UpdateFunc = func() (*ledger.TrieUpdate, error) {
+			calls++
+			if calls == 1 {
+				return nil, fmt.Errorf("could not read next record: %w", dps.ErrCorrupted)
+			}
+			return mocks.GenericTrieUpdate(0), nil
+		}
+
+		var recorded int
+		write := mocks.BaselineWriter(t)
+		write.CorruptionFunc = func(context.Context, uint64, string) error {
+			recorded++
+			return nil
+		}
+
+		saved := false
+		forest := mocks.BaselineForest(t, false)
+		forest.SaveFunc = func(*trie.MTrie, []ledger.Path, flow.StateCommitment) {
+			saved = true
+		}
+		forest.HasFunc = func(flow.StateCommitment) bool {
+			return saved
+		}
+
+		tr, st := baselineFSM(t, StatusUpdate, withFeeder(feed))
+		tr.cfg.SkipCorrupted = true
+		tr.write = write
+		st.forest = forest
+
+		f := NewFSM(st,
+			WithTransition(StatusUpdate, tr.UpdateTree),
+			WithTransition(StatusCollect, func(*State) error { return dps.ErrFinished }),
+		)
+
+		err := f.Run()
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, recorded)
+		assert.Equal(t, 2, calls)
+		assert.False(t, st.skipped)
+		assert.Equal(t, StatusCollect, st.status)
+	})
+
+	t.Run("halts at corrupted execution data", func(t *testing.T) {
+		t.Parallel()
+
+		chain := mocks.BaselineChain(t)
+		chain.CommitFunc = func(uint64) (flow.StateCommitment, error) {
+			return flow.DummyStateCommitment, fmt.Errorf("execution record quarantined: %w", dps.ErrCorrupted)
+		}
+
+		var recorded int
+		write := mocks.BaselineWriter(t)
+		write.CorruptionFunc = func(context.Context, uint64, string) error {
+			recorded++
+			return nil
+		}
+
+		tr, st := baselineFSM(t, StatusIndex, withChain(chain))
+		tr.cfg.SkipCorrupted = true
+		tr.write = write
+
+		f := NewFSM(st, WithTransition(StatusIndex, tr.IndexChain))
+
+		err := f.Run()
+
+		assert.ErrorIs(t, err, dps.ErrCorrupted)
+		assert.Equal(t, 1, recorded)
 	})
 
 	t.Run("transition does not exist for given state", func(t *testing.T) {
@@ -102,7 +232,8 @@ func TestFSM_Run(t *testing.T) {
 			transitions: map[Status]TransitionFunc{
 				StatusForward: func(*State) error { return nil },
 			},
-			wg: &sync.WaitGroup{},
+			monitor: NewMonitor(),
+			wg:      &sync.WaitGroup{},
 		}
 
 		err := f.Run()
@@ -122,12 +253,14 @@ func TestFSM_Run(t *testing.T) {
 			transitions: map[Status]TransitionFunc{
 				StatusBootstrap: failingTransition,
 			},
-			wg: &sync.WaitGroup{},
+			monitor: NewMonitor(),
+			wg:      &sync.WaitGroup{},
 		}
 
 		err := f.Run()
 
 		assert.Error(t, err)
+		assert.Equal(t, mocks.GenericError.Error(), f.monitor.Info().Error)
 	})
 }
 
@@ -0,0 +1,176 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// span is a range of consecutive heights, with both bounds included.
+type span struct {
+	from uint64
+	to   uint64
+}
+
+// String implements the Stringer interface.
+func (s span) String() string {
+	if s.from == s.to {
+		return fmt.Sprint(s.from)
+	}
+	return fmt.Sprintf("%d-%d", s.from, s.to)
+}
+
+// gaps returns the ranges of heights between the given first and last heights
+// that are missing from the given sorted heights, as well as the ranges of
+// heights that were indexed beyond the height right after the last one. The
+// height right after the last one can be partially indexed if the indexer
+// stopped while processing it, which is expected.
+func gaps(first uint64, last uint64, heights []uint64) ([]span, []span) {
+
+	var missing []span
+	var ahead []span
+	expected := first
+	for _, height := range heights {
+		switch {
+		case height < expected:
+			continue
+		case height <= last:
+			if height > expected {
+				missing = append(missing, span{from: expected, to: height - 1})
+			}
+			expected = height + 1
+		case height > last+1:
+			n := len(ahead)
+			if n > 0 && ahead[n-1].to == height-1 {
+				ahead[n-1].to = height
+				continue
+			}
+			ahead = append(ahead, span{from: height, to: height})
+		}
+	}
+	if expected <= last {
+		missing = append(missing, span{from: expected, to: last})
+	}
+
+	return missing, ahead
+}
+
+// join returns the given ranges of heights as a comma-separated list.
+func join(spans []span) string {
+	parts := make([]string, 0, len(spans))
+	for _, s := range spans {
+		parts = append(parts, s.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// auditHeights checks the sequence of indexed heights between the given first
+// and last heights for gaps, as well as for heights that were written out of
+// order beyond the last height. If a chain to repair the index from is
+// configured, the block data of the missing heights is indexed again from it;
+// otherwise, the check fails with a precise report of the affected heights.
+func (t *Transitions) auditHeights(ctx context.Context, first uint64, last uint64) error {
+
+	heights, err := t.cfg.Auditor.Heights(ctx, first, math.MaxUint64)
+	if err != nil {
+		return fmt.Errorf("could not get indexed heights: %w", err)
+	}
+
+	missing, ahead := gaps(first, last, heights)
+	if len(missing) == 0 && len(ahead) == 0 {
+		t.log.Info().Uint64("first", first).Uint64("last", last).Msg("verified sequence of indexed heights")
+		return nil
+	}
+
+	if t.cfg.Repair == nil {
+		var problems []string
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("missing heights: %s", join(missing)))
+		}
+		if len(ahead) > 0 {
+			problems = append(problems, fmt.Sprintf("heights indexed beyond last height %d: %s", last, join(ahead)))
+		}
+		return fmt.Errorf("invalid sequence of indexed heights (%s)", strings.Join(problems, "; "))
+	}
+
+	// Heights that were indexed beyond the last height are overwritten once the
+	// indexer reaches them, so they do not need to be repaired.
+	if len(ahead) > 0 {
+		t.log.Warn().Uint64("last", last).Str("heights", join(ahead)).Msg("heights indexed beyond last height will be overwritten")
+	}
+
+	for _, s := range missing {
+		for height := s.from; height <= s.to; height++ {
+			err = t.repairHeight(ctx, height)
+			if err != nil {
+				return fmt.Errorf("could not repair height %d: %w", height, err)
+			}
+		}
+
+		// The registers are only indexed from the execution state trie, which
+		// is not available for past heights, so we can not repair them here.
+		// Once the block data is repaired, the reindexer can replay the range.
+		t.log.Warn().
+			Uint64("from", s.from).
+			Uint64("to", s.to).
+			Msg("repaired block data of missing heights, reindex them to repair their registers")
+	}
+
+	return nil
+}
+
+// repairHeight indexes the block data for the given height again from the
+// chain configured for repairs, as a single batch.
+func (t *Transitions) repairHeight(ctx context.Context, height uint64) error {
+
+	chain := t.cfg.Repair
+	header, err := chain.Header(height)
+	if err != nil {
+		return fmt.Errorf("could not get header: %w", err)
+	}
+	data := dps.HeightData{Height: height}
+	err = t.blockData(chain, height, header, &data)
+	if err != nil {
+		return fmt.Errorf("could not get block data: %w", err)
+	}
+
+	if t.cfg.ProtocolOnly {
+		err = t.protocolData(chain, height, &data)
+		if err != nil {
+			return fmt.Errorf("could not get protocol data: %w", err)
+		}
+	} else {
+		commit, err := chain.Commit(height)
+		if err != nil {
+			return fmt.Errorf("could not get commit: %w", err)
+		}
+		err = t.executionData(chain, height, header, commit, &data)
+		if err != nil {
+			return fmt.Errorf("could not get execution data: %w", err)
+		}
+	}
+
+	err = t.write.WriteHeightBatch(ctx, data)
+	if err != nil {
+		return fmt.Errorf("could not index height data: %w", err)
+	}
+
+	return nil
+}
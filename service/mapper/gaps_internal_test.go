@@ -0,0 +1,260 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestGaps(t *testing.T) {
+	tests := []struct {
+		name    string
+		heights []uint64
+		missing []span
+		ahead   []span
+	}{
+		{
+			name:    "complete sequence",
+			heights: []uint64{10, 11, 12, 13, 14, 15},
+		},
+		{
+			name:    "partially indexed next height",
+			heights: []uint64{10, 11, 12, 13, 14, 15, 16},
+		},
+		{
+			name:    "single missing height",
+			heights: []uint64{10, 11, 13, 14, 15},
+			missing: []span{{from: 12, to: 12}},
+		},
+		{
+			name:    "missing ranges",
+			heights: []uint64{11, 12, 15},
+			missing: []span{{from: 10, to: 10}, {from: 13, to: 14}},
+		},
+		{
+			name:    "missing last heights",
+			heights: []uint64{10, 11, 12},
+			missing: []span{{from: 13, to: 15}},
+		},
+		{
+			name:    "heights indexed ahead",
+			heights: []uint64{10, 11, 12, 13, 14, 15, 18, 19, 21},
+			ahead:   []span{{from: 18, to: 19}, {from: 21, to: 21}},
+		},
+		{
+			name:    "empty index",
+			heights: nil,
+			missing: []span{{from: 10, to: 15}},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			missing, ahead := gaps(10, 15, test.heights)
+
+			assert.Equal(t, test.missing, missing)
+			assert.Equal(t, test.ahead, ahead)
+		})
+	}
+}
+
+func TestJoin(t *testing.T) {
+	got := join([]span{{from: 10, to: 10}, {from: 13, to: 14}})
+
+	assert.Equal(t, "10, 13-14", got)
+}
+
+func TestTransitions_AuditHeights(t *testing.T) {
+	first := mocks.GenericHeight
+	last := mocks.GenericHeight + 4
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		auditor := mocks.BaselineAuditor(t)
+		auditor.HeightsFunc = func(_ context.Context, from uint64, to uint64) ([]uint64, error) {
+			assert.Equal(t, first, from)
+			assert.Equal(t, uint64(math.MaxUint64), to)
+
+			return []uint64{first, first + 1, first + 2, first + 3, first + 4}, nil
+		}
+
+		tr, _ := baselineFSM(t, StatusResume)
+		tr.cfg.Auditor = auditor
+
+		err := tr.auditHeights(context.Background(), first, last)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("reports gaps without repair", func(t *testing.T) {
+		t.Parallel()
+
+		auditor := mocks.BaselineAuditor(t)
+		auditor.HeightsFunc = func(context.Context, uint64, uint64) ([]uint64, error) {
+			return []uint64{first, first + 3, first + 4, first + 7}, nil
+		}
+
+		tr, _ := baselineFSM(t, StatusResume)
+		tr.cfg.Auditor = auditor
+
+		err := tr.auditHeights(context.Background(), first, last)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "missing heights: 43-44")
+		assert.Contains(t, err.Error(), "heights indexed beyond last height 46: 49")
+	})
+
+	t.Run("repairs gaps", func(t *testing.T) {
+		t.Parallel()
+
+		auditor := mocks.BaselineAuditor(t)
+		auditor.HeightsFunc = func(context.Context, uint64, uint64) ([]uint64, error) {
+			return []uint64{first, first + 3, first + 4}, nil
+		}
+
+		var repaired []uint64
+		var commits []uint64
+		writer := mocks.BaselineWriter(t)
+		writer.WriteHeightBatchFunc = func(_ context.Context, data dps.HeightData) error {
+			assert.NotNil(t, data.Header)
+			assert.False(t, data.Complete)
+			assert.False(t, data.First)
+			assert.False(t, data.Last)
+			repaired = append(repaired, data.Height)
+			if data.Commit != nil {
+				commits = append(commits, data.Height)
+			}
+			return nil
+		}
+
+		tr, _ := baselineFSM(t, StatusResume, withWriter(writer))
+		tr.cfg.Auditor = auditor
+		tr.cfg.Repair = mocks.BaselineChain(t)
+
+		err := tr.auditHeights(context.Background(), first, last)
+
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{first + 1, first + 2}, repaired)
+		assert.Equal(t, []uint64{first + 1, first + 2}, commits)
+	})
+
+	t.Run("repairs gaps without execution data", func(t *testing.T) {
+		t.Parallel()
+
+		auditor := mocks.BaselineAuditor(t)
+		auditor.HeightsFunc = func(context.Context, uint64, uint64) ([]uint64, error) {
+			return []uint64{first, first + 2, first + 3, first + 4}, nil
+		}
+
+		chain := mocks.BaselineChain(t)
+		chain.CommitFunc = func(uint64) (flow.StateCommitment, error) {
+			t.Fatal("commit should not be requested without execution data")
+			return flow.DummyStateCommitment, nil
+		}
+
+		var repaired []uint64
+		writer := mocks.BaselineWriter(t)
+		writer.WriteHeightBatchFunc = func(_ context.Context, data dps.HeightData) error {
+			assert.Nil(t, data.Commit)
+			assert.NotNil(t, data.Collections)
+			repaired = append(repaired, data.Height)
+			return nil
+		}
+
+		tr, _ := baselineFSM(t, StatusResume, withWriter(writer))
+		tr.cfg.ProtocolOnly = true
+		tr.cfg.Auditor = auditor
+		tr.cfg.Repair = chain
+
+		err := tr.auditHeights(context.Background(), first, last)
+
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{first + 1}, repaired)
+	})
+
+	t.Run("handles writer failure on repair", func(t *testing.T) {
+		t.Parallel()
+
+		auditor := mocks.BaselineAuditor(t)
+		auditor.HeightsFunc = func(context.Context, uint64, uint64) ([]uint64, error) {
+			return []uint64{first, first + 2, first + 3, first + 4}, nil
+		}
+
+		writer := mocks.BaselineWriter(t)
+		writer.WriteHeightBatchFunc = func(context.Context, dps.HeightData) error {
+			return mocks.GenericError
+		}
+
+		tr, _ := baselineFSM(t, StatusResume, withWriter(writer))
+		tr.cfg.Auditor = auditor
+		tr.cfg.Repair = mocks.BaselineChain(t)
+
+		err := tr.auditHeights(context.Background(), first, last)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles auditor failure", func(t *testing.T) {
+		t.Parallel()
+
+		auditor := mocks.BaselineAuditor(t)
+		auditor.HeightsFunc = func(context.Context, uint64, uint64) ([]uint64, error) {
+			return nil, mocks.GenericError
+		}
+
+		tr, _ := baselineFSM(t, StatusResume)
+		tr.cfg.Auditor = auditor
+
+		err := tr.auditHeights(context.Background(), first, last)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles repair failure", func(t *testing.T) {
+		t.Parallel()
+
+		auditor := mocks.BaselineAuditor(t)
+		auditor.HeightsFunc = func(context.Context, uint64, uint64) ([]uint64, error) {
+			return []uint64{first}, nil
+		}
+
+		chain := mocks.BaselineChain(t)
+		chain.HeaderFunc = func(uint64) (*flow.Header, error) {
+			return nil, mocks.GenericError
+		}
+
+		tr, _ := baselineFSM(t, StatusResume)
+		tr.cfg.Auditor = auditor
+		tr.cfg.Repair = chain
+
+		err := tr.auditHeights(context.Background(), first, last)
+
+		assert.Error(t, err)
+	})
+}
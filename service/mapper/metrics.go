@@ -0,0 +1,73 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Metrics are the prometheus metrics that expose the progress of the mapper's
+// finite state machine.
+type Metrics struct {
+	status      prometheus.Gauge
+	height      prometheus.Gauge
+	transitions *prometheus.CounterVec
+	seconds     *prometheus.CounterVec
+	errors      prometheus.Counter
+}
+
+// NewMetrics creates and registers the metrics of the mapper.
+func NewMetrics() *Metrics {
+	statusOpts := prometheus.GaugeOpts{
+		Name: "mapper_status",
+		Help: "status of the transition that the mapper is currently applying",
+	}
+	status := promauto.NewGauge(statusOpts)
+
+	heightOpts := prometheus.GaugeOpts{
+		Name: "mapper_height",
+		Help: "last height processed by the mapper",
+	}
+	height := promauto.NewGauge(heightOpts)
+
+	transitionsOpts := prometheus.CounterOpts{
+		Name: "mapper_transitions",
+		Help: "number of transitions applied by the mapper",
+	}
+	transitions := promauto.NewCounterVec(transitionsOpts, []string{"status"})
+
+	secondsOpts := prometheus.CounterOpts{
+		Name: "mapper_transition_seconds",
+		Help: "time spent by the mapper applying transitions",
+	}
+	seconds := promauto.NewCounterVec(secondsOpts, []string{"status"})
+
+	errorsOpts := prometheus.CounterOpts{
+		Name: "mapper_errors",
+		Help: "number of transitions of the mapper that failed",
+	}
+	errors := promauto.NewCounter(errorsOpts)
+
+	m := Metrics{
+		status:      status,
+		height:      height,
+		transitions: transitions,
+		seconds:     seconds,
+		errors:      errors,
+	}
+
+	return &m
+}
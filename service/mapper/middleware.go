@@ -0,0 +1,75 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+// Middleware wraps the transition of the state machine for the given status,
+// which allows extensions to run their own logic around existing transitions
+// without modifying them. A middleware can for example publish indexed data,
+// maintain additional indexes or check the consistency of the state.
+type Middleware func(status Status, transition TransitionFunc) TransitionFunc
+
+// Hook is a callback that is called with the status of a transition and the
+// state of the state machine. Hooks must not modify the state.
+type Hook func(status Status, s *State) error
+
+// Before returns a middleware that calls the given hook before each transition
+// for one of the given statuses, or before all transitions if no statuses are
+// given. If the hook fails, the transition is not applied and the state
+// machine stops with the error of the hook.
+func Before(hook Hook, statuses ...Status) Middleware {
+	return func(status Status, transition TransitionFunc) TransitionFunc {
+		if !matches(status, statuses) {
+			return transition
+		}
+		return func(s *State) error {
+			err := hook(status, s)
+			if err != nil {
+				return err
+			}
+			return transition(s)
+		}
+	}
+}
+
+// After returns a middleware that calls the given hook after each successful
+// transition for one of the given statuses, or after all successful
+// transitions if no statuses are given. If the hook fails, the state machine
+// stops with the error of the hook.
+func After(hook Hook, statuses ...Status) Middleware {
+	return func(status Status, transition TransitionFunc) TransitionFunc {
+		if !matches(status, statuses) {
+			return transition
+		}
+		return func(s *State) error {
+			err := transition(s)
+			if err != nil {
+				return err
+			}
+			return hook(status, s)
+		}
+	}
+}
+
+func matches(status Status, statuses []Status) bool {
+	if len(statuses) == 0 {
+		return true
+	}
+	for _, candidate := range statuses {
+		if candidate == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestBefore(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		var calls []string
+		hook := func(status Status, s *State) error {
+			assert.Equal(t, StatusIndex, status)
+			calls = append(calls, "hook")
+			return nil
+		}
+		transition := func(*State) error {
+			calls = append(calls, "transition")
+			return nil
+		}
+
+		wrapped := Before(hook, StatusIndex)(StatusIndex, transition)
+		err := wrapped(&State{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"hook", "transition"}, calls)
+	})
+
+	t.Run("applies to all statuses without filter", func(t *testing.T) {
+		t.Parallel()
+
+		called := false
+		hook := func(Status, *State) error {
+			called = true
+			return nil
+		}
+
+		wrapped := Before(hook)(StatusForward, func(*State) error { return nil })
+		err := wrapped(&State{})
+
+		assert.NoError(t, err)
+		assert.True(t, called)
+	})
+
+	t.Run("ignores other statuses", func(t *testing.T) {
+		t.Parallel()
+
+		called := false
+		hook := func(Status, *State) error {
+			called = true
+			return nil
+		}
+
+		wrapped := Before(hook, StatusIndex)(StatusForward, func(*State) error { return nil })
+		err := wrapped(&State{})
+
+		assert.NoError(t, err)
+		assert.False(t, called)
+	})
+
+	t.Run("handles hook failure", func(t *testing.T) {
+		t.Parallel()
+
+		applied := false
+		hook := func(Status, *State) error {
+			return mocks.GenericError
+		}
+		transition := func(*State) error {
+			applied = true
+			return nil
+		}
+
+		wrapped := Before(hook)(StatusIndex, transition)
+		err := wrapped(&State{})
+
+		assert.ErrorIs(t, err, mocks.GenericError)
+		assert.False(t, applied)
+	})
+}
+
+func TestAfter(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		var calls []string
+		hook := func(status Status, s *State) error {
+			assert.Equal(t, StatusForward, status)
+			assert.Equal(t, mocks.GenericHeight, s.Height())
+			calls = append(calls, "hook")
+			return nil
+		}
+		transition := func(s *State) error {
+			s.height = mocks.GenericHeight
+			calls = append(calls, "transition")
+			return nil
+		}
+
+		wrapped := After(hook, StatusForward)(StatusForward, transition)
+		err := wrapped(&State{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"transition", "hook"}, calls)
+	})
+
+	t.Run("ignores other statuses", func(t *testing.T) {
+		t.Parallel()
+
+		called := false
+		hook := func(Status, *State) error {
+			called = true
+			return nil
+		}
+
+		wrapped := After(hook, StatusForward)(StatusIndex, func(*State) error { return nil })
+		err := wrapped(&State{})
+
+		assert.NoError(t, err)
+		assert.False(t, called)
+	})
+
+	t.Run("handles transition failure", func(t *testing.T) {
+		t.Parallel()
+
+		called := false
+		hook := func(Status, *State) error {
+			called = true
+			return nil
+		}
+
+		wrapped := After(hook)(StatusIndex, func(*State) error { return mocks.GenericError })
+		err := wrapped(&State{})
+
+		assert.ErrorIs(t, err, mocks.GenericError)
+		assert.False(t, called)
+	})
+
+	t.Run("handles hook failure", func(t *testing.T) {
+		t.Parallel()
+
+		hook := func(Status, *State) error {
+			return mocks.GenericError
+		}
+
+		wrapped := After(hook)(StatusIndex, func(*State) error { return nil })
+		err := wrapped(&State{})
+
+		assert.ErrorIs(t, err, mocks.GenericError)
+	})
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Monitor keeps track of the progress of the mapper's finite state machine, so
+// that it can be inspected while the mapper runs, through metrics and the
+// admin API.
+type Monitor struct {
+	mutex     *sync.Mutex
+	metrics   *Metrics
+	status    Status
+	height    uint64
+	processed time.Time
+	counts    map[Status]uint64
+	durations map[Status]time.Duration
+	err       error
+	failed    time.Time
+}
+
+// NewMonitor returns a new monitor for the mapper's finite state machine.
+func NewMonitor(options ...func(*Monitor)) *Monitor {
+
+	m := Monitor{
+		mutex:     &sync.Mutex{},
+		metrics:   nil,
+		counts:    make(map[Status]uint64),
+		durations: make(map[Status]time.Duration),
+	}
+
+	for _, option := range options {
+		option(&m)
+	}
+
+	return &m
+}
+
+// WithMetrics makes the monitor expose the progress of the mapper with the
+// given metrics.
+func WithMetrics(metrics *Metrics) func(*Monitor) {
+	return func(m *Monitor) {
+		m.metrics = metrics
+	}
+}
+
+// Enter records that the state machine started applying the transition for
+// the given status.
+func (m *Monitor) Enter(status Status) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.status = status
+
+	if m.metrics != nil {
+		m.metrics.status.Set(float64(status))
+	}
+}
+
+// Exit records that the state machine finished applying the transition for
+// the given status, which took the given duration and left the state at the
+// given height.
+func (m *Monitor) Exit(status Status, height uint64, duration time.Duration, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.counts[status]++
+	m.durations[status] += duration
+
+	// The forward transition increases the height once it is done indexing a
+	// height, so the last processed height is the one right before.
+	if status == StatusForward && err == nil {
+		m.height = height - 1
+		m.processed = time.Now()
+	}
+
+	failed := err != nil && !errors.Is(err, dps.ErrFinished)
+	if failed {
+		m.err = err
+		m.failed = time.Now()
+	}
+
+	if m.metrics == nil {
+		return
+	}
+
+	label := status.String()
+	m.metrics.transitions.WithLabelValues(label).Inc()
+	m.metrics.seconds.WithLabelValues(label).Add(duration.Seconds())
+	if status == StatusForward && err == nil {
+		m.metrics.height.Set(float64(m.height))
+	}
+	if failed {
+		m.metrics.errors.Inc()
+	}
+}
+
+// Info returns the current introspection of the state machine.
+func (m *Monitor) Info() dps.MapperInfo {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	info := dps.MapperInfo{
+		Status:      m.status.String(),
+		Height:      m.height,
+		Transitions: make([]dps.TransitionInfo, 0, len(m.counts)),
+	}
+	if !m.processed.IsZero() {
+		processed := m.processed
+		info.Processed = &processed
+	}
+	if m.err != nil {
+		failed := m.failed
+		info.Error = m.err.Error()
+		info.Failed = &failed
+	}
+
+	statuses := make([]Status, 0, len(m.counts))
+	for status := range m.counts {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i int, j int) bool {
+		return statuses[i] < statuses[j]
+	})
+	for _, status := range statuses {
+		transition := dps.TransitionInfo{
+			Status:  status.String(),
+			Count:   m.counts[status],
+			Seconds: m.durations[status].Seconds(),
+		}
+		info.Transitions = append(info.Transitions, transition)
+	}
+
+	return info
+}
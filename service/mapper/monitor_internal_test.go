@@ -0,0 +1,125 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestNewMonitor(t *testing.T) {
+	m := NewMonitor()
+
+	assert.Nil(t, m.metrics)
+	assert.Empty(t, m.counts)
+	assert.Empty(t, m.durations)
+	assert.NoError(t, m.err)
+}
+
+func TestMonitor_Enter(t *testing.T) {
+	m := NewMonitor()
+
+	m.Enter(StatusCollect)
+
+	assert.Equal(t, StatusCollect, m.status)
+	assert.Equal(t, "collect", m.Info().Status)
+}
+
+func TestMonitor_Exit(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		m := NewMonitor()
+
+		m.Exit(StatusIndex, mocks.GenericHeight, time.Second, nil)
+		m.Exit(StatusIndex, mocks.GenericHeight, time.Second, nil)
+		m.Exit(StatusForward, mocks.GenericHeight+1, time.Millisecond, nil)
+
+		assert.Equal(t, uint64(2), m.counts[StatusIndex])
+		assert.Equal(t, 2*time.Second, m.durations[StatusIndex])
+		assert.Equal(t, uint64(1), m.counts[StatusForward])
+		assert.Equal(t, mocks.GenericHeight, m.height)
+		assert.False(t, m.processed.IsZero())
+		assert.NoError(t, m.err)
+	})
+
+	t.Run("records failures", func(t *testing.T) {
+		t.Parallel()
+
+		m := NewMonitor()
+
+		m.Exit(StatusForward, mocks.GenericHeight+1, time.Second, mocks.GenericError)
+
+		assert.Equal(t, mocks.GenericError, m.err)
+		assert.False(t, m.failed.IsZero())
+		assert.Zero(t, m.height)
+		assert.True(t, m.processed.IsZero())
+	})
+
+	t.Run("ignores finished state machine", func(t *testing.T) {
+		t.Parallel()
+
+		m := NewMonitor()
+
+		m.Exit(StatusForward, mocks.GenericHeight, time.Second, dps.ErrFinished)
+
+		assert.NoError(t, m.err)
+	})
+}
+
+func TestMonitor_Info(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		m := NewMonitor()
+		m.Enter(StatusForward)
+		m.Exit(StatusForward, mocks.GenericHeight+1, 2*time.Second, nil)
+		m.Exit(StatusIndex, mocks.GenericHeight+1, time.Second, nil)
+		m.Enter(StatusUpdate)
+		m.Exit(StatusUpdate, mocks.GenericHeight+1, time.Second, mocks.GenericError)
+
+		info := m.Info()
+
+		assert.Equal(t, "update", info.Status)
+		assert.Equal(t, mocks.GenericHeight, info.Height)
+		assert.NotNil(t, info.Processed)
+		assert.Equal(t, mocks.GenericError.Error(), info.Error)
+		assert.NotNil(t, info.Failed)
+		require.Len(t, info.Transitions, 3)
+		assert.Equal(t, dps.TransitionInfo{Status: "index", Count: 1, Seconds: 1}, info.Transitions[0])
+		assert.Equal(t, dps.TransitionInfo{Status: "update", Count: 1, Seconds: 1}, info.Transitions[1])
+		assert.Equal(t, dps.TransitionInfo{Status: "forward", Count: 1, Seconds: 2}, info.Transitions[2])
+	})
+
+	t.Run("handles unstarted state machine", func(t *testing.T) {
+		t.Parallel()
+
+		m := NewMonitor()
+
+		info := m.Info()
+
+		assert.Zero(t, info.Height)
+		assert.Nil(t, info.Processed)
+		assert.Empty(t, info.Error)
+		assert.Nil(t, info.Failed)
+		assert.Empty(t, info.Transitions)
+	})
+}
@@ -21,3 +21,21 @@ func WithTransition(status Status, transition TransitionFunc) func(*FSM) {
 		f.transitions[status] = transition
 	}
 }
+
+// WithMonitor specifies the monitor that keeps track of the state machine's
+// progress, so that it can be shared with components that are created before
+// the state machine, such as the admin API.
+func WithMonitor(monitor *Monitor) func(*FSM) {
+	return func(f *FSM) {
+		f.monitor = monitor
+	}
+}
+
+// WithMiddleware adds middleware around the transitions of the state machine,
+// regardless of whether the transitions are specified before or after it. The
+// first middleware that is given is the outermost one.
+func WithMiddleware(middleware ...Middleware) func(*FSM) {
+	return func(f *FSM) {
+		f.middleware = append(f.middleware, middleware...)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"github.com/onflow/flow-go/engine/execution/state"
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// registerChurn counts the given registers, which should be all of the
+// registers written by the finalized block at the given height, by owner and by
+// register key. Registers without an owner are counted for the empty address.
+func registerChurn(height uint64, registers map[ledger.Path]*ledger.Payload) dps.RegisterChurn {
+
+	type register struct {
+		owner flow.Address
+		key   string
+	}
+
+	owners := make(map[flow.Address]uint64)
+	keys := make(map[register]uint64)
+	for _, payload := range registers {
+		owner, _ := dps.PayloadOwner(payload)
+		key := ""
+		for _, part := range payload.Key.KeyParts {
+			if part.Type == state.KeyPartKey {
+				key = string(part.Value)
+				break
+			}
+		}
+		owners[owner]++
+		keys[register{owner: owner, key: key}]++
+	}
+
+	churn := dps.RegisterChurn{
+		Height:    height,
+		Owners:    make([]dps.OwnerWrites, 0, len(owners)),
+		Registers: make([]dps.RegisterWrites, 0, len(keys)),
+	}
+	for owner, writes := range owners {
+		churn.Owners = append(churn.Owners, dps.OwnerWrites{Owner: owner, Writes: writes})
+	}
+	for reg, writes := range keys {
+		churn.Registers = append(churn.Registers, dps.RegisterWrites{Owner: reg.owner, Key: reg.key, Writes: writes})
+	}
+
+	return churn
+}
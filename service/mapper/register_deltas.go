@@ -0,0 +1,61 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common/hash"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// registerDeltas compares the given registers, which should be all of the
+// registers written by the finalized block at the given height, to their
+// payloads in the given execution state trie of the previous height. The
+// changes are returned in ascending order of their paths.
+func registerDeltas(height uint64, previous *trie.MTrie, registers map[ledger.Path]*ledger.Payload) []dps.RegisterDelta {
+
+	deltas := make([]dps.RegisterDelta, 0, len(registers))
+	for path, payload := range registers {
+		before := previous.UnsafeRead([]ledger.Path{path})[0]
+		delta := dps.RegisterDelta{
+			Height:   height,
+			Path:     path,
+			Previous: leafHash(path, before),
+			Current:  leafHash(path, payload),
+		}
+		deltas = append(deltas, delta)
+	}
+
+	sort.Slice(deltas, func(i, j int) bool {
+		return bytes.Compare(deltas[i].Path[:], deltas[j].Path[:]) < 0
+	})
+
+	return deltas
+}
+
+// leafHash returns the hash of the leaf for the given register in the execution
+// state trie, or nothing if the register has no value.
+func leafHash(path ledger.Path, payload *ledger.Payload) []byte {
+	if payload == nil || len(payload.Value) == 0 {
+		return nil
+	}
+	leaf := hash.HashLeaf(hash.Hash(path), payload.Value)
+	return leaf[:]
+}
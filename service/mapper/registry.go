@@ -0,0 +1,66 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// registry keeps track of the statistics of every event type that was indexed.
+type registry map[flow.EventType]dps.EventTypeStats
+
+// newRegistry creates a registry from the given statistics.
+func newRegistry(stats []dps.EventTypeStats) registry {
+	r := make(registry, len(stats))
+	for _, stat := range stats {
+		r[stat.Type] = stat
+	}
+	return r
+}
+
+// update adds the events of the given height to the registry and returns the
+// statistics that changed. Heights that were already counted for an event type
+// are skipped, so that replaying a height does not inflate the counts.
+func (r registry) update(height uint64, events []flow.Event) []dps.EventTypeStats {
+
+	counts := make(map[flow.EventType]uint64)
+	var types []flow.EventType
+	for _, event := range events {
+		_, ok := counts[event.Type]
+		if !ok {
+			types = append(types, event.Type)
+		}
+		counts[event.Type]++
+	}
+
+	var changed []dps.EventTypeStats
+	for _, typ := range types {
+		stat, ok := r[typ]
+		if ok && height <= stat.Last {
+			continue
+		}
+		if !ok {
+			stat = dps.EventTypeStats{Type: typ, First: height}
+		}
+		stat.Last = height
+		stat.Count += counts[typ]
+		r[typ] = stat
+		changed = append(changed, stat)
+	}
+
+	return changed
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestRegistry(t *testing.T) {
+	types := mocks.GenericEventTypes(2)
+	events := mocks.GenericEvents(6, types...)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		reg := newRegistry(nil)
+
+		changed := reg.update(mocks.GenericHeight, events)
+
+		want := []dps.EventTypeStats{
+			{Type: types[0], First: mocks.GenericHeight, Last: mocks.GenericHeight, Count: 3},
+			{Type: types[1], First: mocks.GenericHeight, Last: mocks.GenericHeight, Count: 3},
+		}
+		assert.Equal(t, want, changed)
+
+		changed = reg.update(mocks.GenericHeight+1, events[:1])
+
+		want = []dps.EventTypeStats{
+			{Type: types[0], First: mocks.GenericHeight, Last: mocks.GenericHeight + 1, Count: 4},
+		}
+		assert.Equal(t, want, changed)
+		assert.Equal(t, want[0], reg[types[0]])
+	})
+
+	t.Run("skips heights already counted", func(t *testing.T) {
+		t.Parallel()
+
+		reg := newRegistry(mocks.GenericEventTypeStats(2))
+
+		changed := reg.update(mocks.GenericHeight, events)
+
+		want := []dps.EventTypeStats{
+			{Type: types[0], First: mocks.GenericHeight, Last: mocks.GenericHeight, Count: 1},
+			{Type: types[1], First: mocks.GenericHeight, Last: mocks.GenericHeight + 1, Count: 2},
+		}
+		assert.Empty(t, changed)
+		assert.Equal(t, want[0], reg[types[0]])
+		assert.Equal(t, want[1], reg[types[1]])
+	})
+}
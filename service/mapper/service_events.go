@@ -0,0 +1,53 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"github.com/onflow/flow-go/fvm/systemcontracts"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// serviceEvents returns the service events among the given events, such as the
+// setup and commit events of epochs. Chains without known service events have
+// none, and service events of contracts that don't pass the filter are skipped.
+func serviceEvents(chainID flow.ChainID, filter dps.Filter, events []flow.Event) []flow.Event {
+
+	known, err := systemcontracts.ServiceEventsForChain(chainID)
+	if err != nil {
+		return nil
+	}
+
+	lookup := make(map[flow.EventType]struct{})
+	for _, event := range known.All() {
+		typ := event.EventType()
+		if !filter.EventType(typ) {
+			continue
+		}
+		lookup[typ] = struct{}{}
+	}
+
+	var filtered []flow.Event
+	for _, event := range events {
+		_, ok := lookup[event.Type]
+		if !ok {
+			continue
+		}
+		filtered = append(filtered, event)
+	}
+
+	return filtered
+}
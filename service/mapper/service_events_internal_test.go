@@ -0,0 +1,63 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mapper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/fvm/systemcontracts"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestServiceEvents(t *testing.T) {
+	known, err := systemcontracts.ServiceEventsForChain(dps.FlowTestnet)
+	require.NoError(t, err)
+
+	setup := known.EpochSetup.EventType()
+	commit := known.EpochCommit.EventType()
+	events := mocks.GenericEvents(3, setup, mocks.GenericEventType(0), commit)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		got := serviceEvents(dps.FlowTestnet, dps.Filter{}, events)
+
+		assert.Equal(t, []flow.Event{events[0], events[2]}, got)
+	})
+
+	t.Run("nominal case with filter", func(t *testing.T) {
+		t.Parallel()
+
+		filter := dps.Filter{Denied: []flow.Address{known.EpochSetup.Address}}
+
+		got := serviceEvents(dps.FlowTestnet, filter, events)
+
+		assert.Empty(t, got)
+	})
+
+	t.Run("nominal case with unknown chain", func(t *testing.T) {
+		t.Parallel()
+
+		got := serviceEvents(flow.ChainID("unknown"), dps.Filter{}, events)
+
+		assert.Empty(t, got)
+	})
+}
@@ -15,20 +15,26 @@
 package mapper
 
 import (
+	"context"
 	"math"
 
 	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
 )
 
 // State is the state machine's state.
 type State struct {
+	ctx       context.Context // given to all reads and writes of the index
 	forest    Forest
 	status    Status
 	height    uint64
 	last      flow.StateCommitment
 	next      flow.StateCommitment
 	registers map[ledger.Path]*ledger.Payload
+	data      dps.HeightData // data of the height, written when it is forwarded
+	skipped   bool           // whether a trie update was skipped on the way to the next commit
 	done      chan struct{}
 }
 
@@ -36,14 +42,43 @@ type State struct {
 func EmptyState(forest Forest) *State {
 
 	s := State{
+		ctx:       context.Background(),
 		forest:    forest,
 		status:    StatusInitialize,
 		height:    math.MaxUint64,
 		last:      flow.DummyStateCommitment,
 		next:      flow.DummyStateCommitment,
 		registers: make(map[ledger.Path]*ledger.Payload),
+		skipped:   false,
 		done:      make(chan struct{}),
 	}
 
 	return &s
 }
+
+// Status returns the current status of the state machine.
+func (s *State) Status() Status {
+	return s.status
+}
+
+// Height returns the height that the state machine is currently processing.
+func (s *State) Height() uint64 {
+	return s.height
+}
+
+// Last returns the state commitment of the last indexed height.
+func (s *State) Last() flow.StateCommitment {
+	return s.last
+}
+
+// Next returns the state commitment of the height that is being processed.
+func (s *State) Next() flow.StateCommitment {
+	return s.next
+}
+
+// Registers returns the registers that were updated at the height that is
+// being processed, and that have not been mapped to the index yet. The
+// returned map must not be modified.
+func (s *State) Registers() map[ledger.Path]*ledger.Payload {
+	return s.registers
+}
@@ -20,6 +20,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/onflow/flow-go/ledger"
+
 	"github.com/optakt/flow-dps/testing/mocks"
 )
 
@@ -28,6 +30,7 @@ func TestEmptyState(t *testing.T) {
 	s := EmptyState(f)
 
 	assert.Equal(t, f, s.forest)
+	assert.NotNil(t, s.ctx)
 	assert.Equal(t, StatusInitialize, s.status)
 	assert.Equal(t, s.height, uint64(math.MaxUint64))
 	assert.Zero(t, s.last)
@@ -36,3 +39,22 @@ func TestEmptyState(t *testing.T) {
 	assert.Empty(t, s.registers)
 	assert.NotNil(t, s.done)
 }
+
+func TestState_Accessors(t *testing.T) {
+	registers := map[ledger.Path]*ledger.Payload{
+		mocks.GenericLedgerPath(0): mocks.GenericLedgerPayload(0),
+	}
+	s := State{
+		status:    StatusMap,
+		height:    mocks.GenericHeight,
+		last:      mocks.GenericCommit(0),
+		next:      mocks.GenericCommit(1),
+		registers: registers,
+	}
+
+	assert.Equal(t, StatusMap, s.Status())
+	assert.Equal(t, mocks.GenericHeight, s.Height())
+	assert.Equal(t, mocks.GenericCommit(0), s.Last())
+	assert.Equal(t, mocks.GenericCommit(1), s.Next())
+	assert.Equal(t, registers, s.Registers())
+}
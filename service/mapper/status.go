@@ -33,6 +33,8 @@ const (
 // String implements the Stringer interface.
 func (s Status) String() string {
 	switch s {
+	case StatusInitialize:
+		return "initialize"
 	case StatusBootstrap:
 		return "bootstrap"
 	case StatusResume:
@@ -27,6 +27,7 @@ import (
 	"github.com/onflow/flow-go/model/flow"
 
 	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/logs"
 )
 
 // TransitionFunc is a function that is applied onto the state machine's
@@ -43,6 +44,8 @@ type Transitions struct {
 	read  dps.Reader
 	write dps.Writer
 	once  *sync.Once
+	types registry
+	epoch *epoch
 }
 
 // NewTransitions returns a Transitions component using the given dependencies and using the given options
@@ -54,7 +57,7 @@ func NewTransitions(log zerolog.Logger, load Loader, chain dps.Chain, feed Feede
 	}
 
 	t := Transitions{
-		log:   log.With().Str("component", "mapper_transitions").Logger(),
+		log:   log.With().Str(logs.Component, "mapper_transitions").Logger(),
 		cfg:   cfg,
 		load:  load,
 		chain: chain,
@@ -90,6 +93,28 @@ func (t *Transitions) BootstrapState(s *State) error {
 		return fmt.Errorf("invalid status for bootstrapping state (%s)", s.status)
 	}
 
+	// If we only index the data of some accounts, we record the filter in the
+	// index, so that readers know that the index is partial.
+	if !t.cfg.Filter.Empty() {
+		err := t.write.Filter(s.ctx, t.cfg.Filter)
+		if err != nil {
+			return fmt.Errorf("could not index filter: %w", err)
+		}
+		t.log.Info().Int("owners", len(t.cfg.Filter.Allowed)).Int("denied", len(t.cfg.Filter.Denied)).Msg("restricted indexing with filter")
+	}
+
+	// Without execution data, there is no execution state trie to bootstrap,
+	// so all we need is the root height to start indexing from.
+	if t.cfg.ProtocolOnly {
+		height, err := t.chain.Root()
+		if err != nil {
+			return fmt.Errorf("could not get root height: %w", err)
+		}
+		s.height = height
+		s.status = StatusIndex
+		return nil
+	}
+
 	// We always need at least one step in our forest, which is used as the
 	// stopping point when indexing the payloads since the last finalized
 	// block. We thus introduce an empty tree, with no paths and an
@@ -103,7 +128,7 @@ func (t *Transitions) BootstrapState(s *State) error {
 	s.last = flow.DummyStateCommitment
 	s.next = first
 
-	t.log.Info().Hex("commit", first[:]).Msg("added empty tree to forest")
+	t.log.Info().Hex(logs.Commit, first[:]).Msg("added empty tree to forest")
 
 	// Then, we can load the root height and apply it to the state. That
 	// will allow us to load the root blockchain data in the next step.
@@ -119,11 +144,23 @@ func (t *Transitions) BootstrapState(s *State) error {
 	if err != nil {
 		return fmt.Errorf("could not load root trie: %w", err)
 	}
+	// Before using the checkpoint, we make sure that it matches the execution
+	// state sealed by the root seal of the protocol state. Otherwise, a wrong
+	// checkpoint would only be noticed once the state commitments of later
+	// blocks stop matching.
+	seal, err := t.chain.RootSeal()
+	if err != nil {
+		return fmt.Errorf("could not get root seal: %w", err)
+	}
+	second := flow.StateCommitment(tree.RootHash())
+	if second != seal.FinalState {
+		return fmt.Errorf("checkpoint does not match root seal (height: %d, checkpoint: %x, sealed: %x): %w", s.height, second, seal.FinalState, dps.ErrCorrupted)
+	}
+
 	paths := allPaths(tree)
 	s.forest.Save(tree, paths, first)
 
-	second := tree.RootHash()
-	t.log.Info().Uint64("height", s.height).Hex("commit", second[:]).Int("registers", len(paths)).Msg("added checkpoint tree to forest")
+	t.log.Info().Uint64(logs.Height, s.height).Hex(logs.Commit, second[:]).Int("registers", len(paths)).Msg("added checkpoint tree to forest")
 
 	// We have successfully bootstrapped. However, no chain data for the root
 	// block has been indexed yet. This is why we "pretend" that we just
@@ -151,18 +188,47 @@ func (t *Transitions) ResumeIndexing(s *State) error {
 	if err != nil {
 		return fmt.Errorf("could not get root height: %w", err)
 	}
-	t.once.Do(func() { err = t.write.First(first) })
+	t.once.Do(func() { err = t.write.First(s.ctx, first) })
 	if err != nil {
 		return fmt.Errorf("could not write first: %w", err)
 	}
 
 	// We need to know what the last indexed height was at the point we stopped
 	// indexing.
-	last, err := t.read.Last()
+	last, err := t.read.Last(s.ctx)
 	if err != nil {
 		return fmt.Errorf("could not get last height: %w", err)
 	}
 
+	// Before resuming, we make sure that no heights are missing from the index,
+	// so that we do not discover them only once API clients request them.
+	if t.cfg.Auditor != nil {
+		err = t.auditHeights(s.ctx, first, last)
+		if err != nil {
+			return fmt.Errorf("could not audit indexed heights: %w", err)
+		}
+	}
+
+	// If we are reindexing a range of heights, we do not resume from the last
+	// indexed height, but from the height right before the range. The range
+	// has to be fully contained within the already indexed heights, and it
+	// can not include the root height, as its state comes from a checkpoint.
+	if t.cfg.ReindexTo != 0 {
+		from, to := t.cfg.ReindexFrom, t.cfg.ReindexTo
+		if from <= first || to > last || from > to {
+			return fmt.Errorf("invalid reindex range (from: %d, to: %d, first: %d, last: %d)", from, to, first, last)
+		}
+		last = from - 1
+	}
+
+	// Without execution data, there is no execution state trie to restore, so
+	// we can go straight to indexing the next height.
+	if t.cfg.ProtocolOnly {
+		s.height = last + 1
+		s.status = StatusIndex
+		return nil
+	}
+
 	// When resuming, the loader injected into the mapper rebuilds the trie from
 	// the paths and payloads stored in the index database.
 	tree, err := t.load.Trie()
@@ -173,12 +239,12 @@ func (t *Transitions) ResumeIndexing(s *State) error {
 	// After loading the trie, we should do a sanity check on its hash against
 	// the commit we indexed for it.
 	hash := flow.StateCommitment(tree.RootHash())
-	commit, err := t.read.Commit(last)
+	commit, err := t.read.Commit(s.ctx, last)
 	if err != nil {
 		return fmt.Errorf("could not get last commit: %w", err)
 	}
 	if hash != commit {
-		return fmt.Errorf("restored trie hash does not match last commit (hash: %x, commit: %x)", hash, commit)
+		return fmt.Errorf("restored trie hash does not match last commit (hash: %x, commit: %x): %w", hash, commit, dps.ErrCorrupted)
 	}
 
 	// At this point, we can store the restored trie in our forest, as the trie
@@ -205,7 +271,7 @@ func (t *Transitions) IndexChain(s *State) error {
 		return fmt.Errorf("invalid status for indexing chain (%s)", s.status)
 	}
 
-	log := t.log.With().Uint64("height", s.height).Logger()
+	log := t.log.With().Uint64(logs.Height, s.height).Logger()
 
 	// We try to retrieve the next header until it becomes available, which
 	// means all data coming from the protocol state is available after this
@@ -220,37 +286,38 @@ func (t *Transitions) IndexChain(s *State) error {
 		return fmt.Errorf("could not get header: %w", err)
 	}
 
-	// At this point, we can retrieve the data from the consensus state. This is
-	// a slight optimization for the live indexer, as it allows us to process
-	// some data before the full execution data becomes available.
-	guarantees, err := t.chain.Guarantees(s.height)
+	// All of the data of the height is gathered into a single batch, which is
+	// only written once the height is forwarded, so that either all of it is
+	// indexed or none of it is. We start with the data related to the
+	// consensus state, before dealing with anything related to execution data,
+	// which might go into the wait state.
+	s.data = dps.HeightData{Height: s.height}
+	err = t.blockData(t.chain, s.height, header, &s.data)
 	if err != nil {
-		return fmt.Errorf("could not get guarantees: %w", err)
-	}
-	seals, err := t.chain.Seals(s.height)
-	if err != nil {
-		return fmt.Errorf("could not get seals: %w", err)
+		return fmt.Errorf("could not get block data: %w", err)
 	}
 
-	// We can also proceed to already indexing the data related to the consensus
-	// state, before dealing with anything related to execution data, which
-	// might go into the wait state.
-	blockID := header.ID()
-	err = t.write.Height(blockID, s.height)
-	if err != nil {
-		return fmt.Errorf("could not index height: %w", err)
-	}
-	err = t.write.Header(s.height, header)
+	// The protocol state also tells us which epoch and epoch phase the block
+	// is in, which we index whenever a new epoch or phase starts.
+	err = t.indexEpoch(s.ctx, s.height)
 	if err != nil {
-		return fmt.Errorf("could not index header: %w", err)
+		return fmt.Errorf("could not index epoch: %w", err)
 	}
-	err = t.write.Guarantees(s.height, guarantees)
-	if err != nil {
-		return fmt.Errorf("could not index guarantees: %w", err)
-	}
-	err = t.write.Seals(s.height, seals)
-	if err != nil {
-		return fmt.Errorf("could not index seals: %w", err)
+
+	// Without execution data, the only remaining data we can index are the
+	// collections and transactions that are available from the protocol
+	// state. We then skip all of the execution state steps and forward to
+	// the next height directly.
+	if t.cfg.ProtocolOnly {
+		err = t.protocolData(t.chain, s.height, &s.data)
+		if err != nil {
+			return fmt.Errorf("could not get protocol data: %w", err)
+		}
+
+		log.Info().Msg("collected protocol data for finalized block")
+
+		s.status = StatusForward
+		return nil
 	}
 
 	// Next, we try to retrieve the next commit until it becomes available,
@@ -262,67 +329,187 @@ func (t *Transitions) IndexChain(s *State) error {
 		time.Sleep(t.cfg.WaitInterval)
 		return nil
 	}
+
+	// If the execution data for the height is corrupted, for example because
+	// its execution record was quarantined, we can not skip it, as the state
+	// of all later heights builds on it, so we halt. When we are configured to
+	// skip corrupted data, we report the height in the index before halting,
+	// so that it can be found and repaired.
+	if t.cfg.SkipCorrupted && errors.Is(err, dps.ErrCorrupted) {
+		log.Error().Err(err).Msg("halting at corrupted execution data")
+		cerr := t.write.Corruption(s.ctx, s.height, err.Error())
+		if cerr != nil {
+			return fmt.Errorf("could not index corruption: %w", cerr)
+		}
+		return fmt.Errorf("could not index corrupted execution data, repair it and restart: %w", err)
+	}
 	if err != nil {
 		return fmt.Errorf("could not get commit: %w", err)
 	}
-	collections, err := t.chain.Collections(s.height)
+
+	// When reindexing a range of heights, the commit for the height is already
+	// in the index. We validate that the replayed execution data leads to the
+	// same commit, so that we never overwrite the index with diverging data.
+	if t.cfg.ReindexTo != 0 {
+		stored, err := t.read.Commit(s.ctx, s.height)
+		if err != nil {
+			return fmt.Errorf("could not get indexed commit: %w", err)
+		}
+		if stored != commit {
+			return fmt.Errorf("commit does not match indexed commit (commit: %x, indexed: %x): %w", commit, stored, dps.ErrCorrupted)
+		}
+	}
+
+	err = t.executionData(t.chain, s.height, header, commit, &s.data)
 	if err != nil {
-		return fmt.Errorf("could not get collections: %w", err)
+		return fmt.Errorf("could not get execution data: %w", err)
 	}
-	transactions, err := t.chain.Transactions(s.height)
+
+	// We also keep a registry of all event types that were indexed, which we
+	// load from the index the first time we need it. The statistics of the
+	// event types that changed are indexed along with the rest of the height.
+	if t.types == nil {
+		stats, err := t.read.EventTypes(s.ctx)
+		if err != nil {
+			return fmt.Errorf("could not get event types: %w", err)
+		}
+		t.types = newRegistry(stats)
+	}
+	s.data.EventTypes = t.types.update(s.height, s.data.Events)
+
+	// At this point, we need to forward the `last` state commitment to
+	// `next`, so we know what the state commitment was at the last finalized
+	// block we processed. This will allow us to know when to stop when
+	// walking back through the forest to collect trie updates.
+	s.last = s.next
+
+	// Last but not least, we need to update `next` to point to the commit we
+	// have just retrieved for the new block height. This is the sentinel that
+	// tells us when we have collected enough trie updates for the forest to
+	// have reached the next finalized block.
+	s.next = commit
+
+	log.Info().Msg("collected blockchain data for finalized block")
+
+	// After collecting the blockchain data, we can go back to updating the state
+	// tree until we find the commit of the finalized block. This will allow us
+	// to index the payloads then.
+	s.status = StatusUpdate
+	return nil
+}
+
+// blockData adds the data of the block at the given height that comes from the
+// consensus state to the given height data.
+func (t *Transitions) blockData(chain dps.Chain, height uint64, header *flow.Header, data *dps.HeightData) error {
+
+	guarantees, err := chain.Guarantees(height)
 	if err != nil {
-		return fmt.Errorf("could not get transactions: %w", err)
+		return fmt.Errorf("could not get guarantees: %w", err)
 	}
-	results, err := t.chain.Results(s.height)
+	seals, err := chain.Seals(height)
 	if err != nil {
-		return fmt.Errorf("could not get transaction results: %w", err)
+		return fmt.Errorf("could not get seals: %w", err)
 	}
-	events, err := t.chain.Events(s.height)
+
+	data.BlockID = header.ID()
+	data.Header = header
+	data.Guarantees = guarantees
+	data.Seals = seals
+
+	return nil
+}
+
+// protocolData adds the collections and transactions of the block at the given
+// height that are available from the protocol state to the given height data,
+// for indexes built without execution data.
+func (t *Transitions) protocolData(chain dps.Chain, height uint64, data *dps.HeightData) error {
+
+	collections, err := chain.Collections(height)
 	if err != nil {
-		return fmt.Errorf("could not get events: %w", err)
+		return fmt.Errorf("could not get collections: %w", err)
+	}
+	transactions, err := chain.Transactions(height)
+	if err != nil {
+		return fmt.Errorf("could not get transactions: %w", err)
+	}
+	if !t.cfg.Filter.Empty() {
+		transactions, _ = filterTransactions(t.cfg.Filter, transactions)
 	}
 
-	// Next, all we need to do is index the remaining data and we have fully
-	// processed indexing for this block height.
-	err = t.write.Commit(s.height, commit)
+	data.Collections = collections
+	data.Transactions = transactions
+
+	return nil
+}
+
+// executionData adds the execution data of the block at the given height, with
+// the exception of the ledger registers, to the given height data.
+func (t *Transitions) executionData(chain dps.Chain, height uint64, header *flow.Header, commit flow.StateCommitment, data *dps.HeightData) error {
+
+	collections, err := chain.Collections(height)
 	if err != nil {
-		return fmt.Errorf("could not index commit: %w", err)
+		return fmt.Errorf("could not get collections: %w", err)
 	}
-	err = t.write.Collections(s.height, collections)
+	transactions, err := chain.Transactions(height)
 	if err != nil {
-		return fmt.Errorf("could not index collections: %w", err)
+		return fmt.Errorf("could not get transactions: %w", err)
 	}
-	err = t.write.Transactions(s.height, transactions)
+	results, err := chain.Results(height)
 	if err != nil {
-		return fmt.Errorf("could not index transactions: %w", err)
+		return fmt.Errorf("could not get transaction results: %w", err)
 	}
-	err = t.write.Results(results)
+	events, err := chain.Events(height)
 	if err != nil {
-		return fmt.Errorf("could not index transaction results: %w", err)
+		return fmt.Errorf("could not get events: %w", err)
 	}
-	err = t.write.Events(s.height, events)
+
+	// Service events are emitted by the system chunk, which is not part of
+	// the transactions of the block, so we pick them out before filtering the
+	// events by transaction.
+	service := serviceEvents(header.ChainID, t.cfg.Filter, events)
+
+	// The statistics of the block are also computed before filtering, as they
+	// describe the block as a whole, rather than the data that is indexed.
+	stats, err := blockStats(header.ChainID, height, transactions, results, events)
 	if err != nil {
-		return fmt.Errorf("could not index events: %w", err)
+		return fmt.Errorf("could not compute block stats: %w", err)
 	}
 
-	// At this point, we need to forward the `last` state commitment to
-	// `next`, so we know what the state commitment was at the last finalized
-	// block we processed. This will allow us to know when to stop when
-	// walking back through the forest to collect trie updates.
-	s.last = s.next
+	// If we only index the data of some accounts, we skip the transactions
+	// that don't involve them, along with their results and events. Events
+	// of contracts deployed on these accounts are kept regardless.
+	if !t.cfg.Filter.Empty() {
+		var lookup map[flow.Identifier]struct{}
+		transactions, lookup = filterTransactions(t.cfg.Filter, transactions)
+		results = filterResults(lookup, results)
+		events = filterEvents(t.cfg.Filter, lookup, events)
+	}
 
-	// Last but not least, we need to update `next` to point to the commit we
-	// have just retrieved for the new block height. This is the sentinel that
-	// tells us when we have collected enough trie updates for the forest to
-	// have reached the next finalized block.
-	s.next = commit
+	// The account events tell us when accounts were created, and when keys
+	// were added to or removed from them.
+	created, updated, err := accountEvents(t.cfg.Filter, events)
+	if err != nil {
+		return fmt.Errorf("could not get account events: %w", err)
+	}
 
-	log.Info().Msg("indexed blockchain data for finalized block")
+	// The contract events tell us when contracts were deployed, updated or
+	// removed, and the hash of their code.
+	versions, err := contractEvents(t.cfg.Filter, height, events)
+	if err != nil {
+		return fmt.Errorf("could not get contract events: %w", err)
+	}
+
+	data.Commit = &commit
+	data.Collections = collections
+	data.Transactions = transactions
+	data.Results = results
+	data.Events = events
+	data.ServiceEvents = service
+	data.BlockStats = &stats
+	data.Accounts = created
+	data.KeyUpdates = updated
+	data.Contracts = versions
 
-	// After indexing the blockchain data, we can go back to updating the state
-	// tree until we find the commit of the finalized block. This will allow us
-	// to index the payloads then.
-	s.status = StatusUpdate
 	return nil
 }
 
@@ -333,14 +520,15 @@ func (t *Transitions) UpdateTree(s *State) error {
 		return fmt.Errorf("invalid status for updating tree (%s)", s.status)
 	}
 
-	log := t.log.With().Uint64("height", s.height).Hex("last", s.last[:]).Hex("next", s.next[:]).Logger()
+	log := t.log.With().Uint64(logs.Height, s.height).Hex("last", s.last[:]).Hex("next", s.next[:]).Logger()
 
 	// If the forest contains a tree for the commit of the next finalized block,
 	// we have reached our goal, and we can go to the next step in order to
 	// collect the register payloads we want to index for that block.
 	ok := s.forest.Has(s.next)
 	if ok {
-		log.Info().Hex("commit", s.next[:]).Msg("matched commit of finalized block")
+		log.Info().Hex(logs.Commit, s.next[:]).Msg("matched commit of finalized block")
+		s.skipped = false
 		s.status = StatusCollect
 		return nil
 	}
@@ -355,11 +543,36 @@ func (t *Transitions) UpdateTree(s *State) error {
 		log.Debug().Msg("waiting for next trie update")
 		return nil
 	}
+
+	// If the update was corrupted and we are configured to skip corrupted
+	// data, we record the height we are currently trying to reach, and carry
+	// on with the next update. This only works out if the skipped update was
+	// not on the path to the next commit, for example because it was meant for
+	// a pruned branch.
+	if t.cfg.SkipCorrupted && errors.Is(err, dps.ErrCorrupted) {
+		log.Warn().Err(err).Msg("skipping corrupted trie update")
+		err = t.write.Corruption(s.ctx, s.height, err.Error())
+		if err != nil {
+			return fmt.Errorf("could not index corruption: %w", err)
+		}
+		s.skipped = true
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("could not feed update: %w", err)
 	}
+
+	// If we can't find the tree to apply the update to, the update was meant
+	// for a pruned branch, unless we skipped an update on the way to the next
+	// commit: the update could then be meant for the tree that the skipped
+	// update would have created, and if that tree is on the path to the next
+	// commit, the forest will never reach it. As we can not tell the two cases
+	// apart, we stop instead of waiting for a commit that might never come.
 	parent := flow.StateCommitment(update.RootHash)
 	tree, ok := s.forest.Tree(parent)
+	if !ok && s.skipped {
+		return fmt.Errorf("could not apply trie update after skipping corrupted trie update, commit of finalized block might be unreachable (parent: %x, next: %x): %w", parent, s.next, dps.ErrCorrupted)
+	}
 	if !ok {
 		log.Warn().Msg("state commitment mismatch, retrieving next trie update")
 		return nil
@@ -376,7 +589,7 @@ func (t *Transitions) UpdateTree(s *State) error {
 	s.forest.Save(tree, paths, parent)
 
 	hash := tree.RootHash()
-	log.Info().Hex("commit", hash[:]).Int("registers", len(paths)).Msg("updated tree with register payloads")
+	log.Info().Hex(logs.Commit, hash[:]).Int("registers", len(paths)).Msg("updated tree with register payloads")
 
 	return nil
 }
@@ -384,7 +597,7 @@ func (t *Transitions) UpdateTree(s *State) error {
 // CollectRegisters reads the payloads for the next block to be indexed from the state's forest, unless payload
 // indexing is disabled.
 func (t *Transitions) CollectRegisters(s *State) error {
-	log := t.log.With().Uint64("height", s.height).Hex("commit", s.next[:]).Logger()
+	log := t.log.With().Uint64(logs.Height, s.height).Hex(logs.Commit, s.next[:]).Logger()
 	if s.status != StatusCollect {
 		return fmt.Errorf("invalid status for collecting registers (%s)", s.status)
 	}
@@ -404,6 +617,7 @@ func (t *Transitions) CollectRegisters(s *State) error {
 	// the forest, and we use the paths we recorded changes on to retrieve the
 	// changed payloads at each step.
 	commit := s.next
+	skipped := 0
 	for commit != s.last {
 
 		// We do this check only once, so that we don't need to do it for
@@ -429,7 +643,13 @@ func (t *Transitions) CollectRegisters(s *State) error {
 				continue
 			}
 			payloads := tree.UnsafeRead([]ledger.Path{path})
-			s.registers[path] = payloads[0]
+			payload := payloads[0]
+			owner, ok := dps.PayloadOwner(payload)
+			if ok && !t.cfg.Filter.Address(owner) {
+				skipped++
+				continue
+			}
+			s.registers[path] = payload
 		}
 
 		log.Debug().Int("batch", len(paths)).Msg("collected register batch for finalized block")
@@ -439,7 +659,23 @@ func (t *Transitions) CollectRegisters(s *State) error {
 		commit = parent
 	}
 
-	log.Info().Int("registers", len(s.registers)).Msg("collected all registers for finalized block")
+	log.Info().Int("registers", len(s.registers)).Int("skipped", skipped).Msg("collected all registers for finalized block")
+
+	// Before the registers are mapped in batches, we record how many of them
+	// each owner wrote, so that the drivers of state growth can be tracked.
+	churn := registerChurn(s.height, s.registers)
+	s.data.RegisterChurn = &churn
+
+	// If enabled, we also record how each of the registers changed, compared
+	// to the tree of the last indexed height. At the root height, that tree is
+	// the empty tree, and the registers come from the root checkpoint.
+	if t.cfg.RegisterDeltas && s.last != flow.StateCommitment(trie.EmptyTrieRootHash()) {
+		previous, ok := s.forest.Tree(s.last)
+		if !ok {
+			return fmt.Errorf("could not load tree (commit: %x)", s.last)
+		}
+		s.data.RegisterDeltas = registerDeltas(s.height, previous, s.registers)
+	}
 
 	// At this point, we have collected all the payloads, so we go to the next
 	// step, where we will index them.
@@ -453,20 +689,28 @@ func (t *Transitions) MapRegisters(s *State) error {
 		return fmt.Errorf("invalid status for indexing registers (%s)", s.status)
 	}
 
-	log := t.log.With().Uint64("height", s.height).Hex("commit", s.next[:]).Logger()
+	log := t.log.With().Uint64(logs.Height, s.height).Hex(logs.Commit, s.next[:]).Logger()
 
-	// If there are no registers left to be indexed, we can go to the next step,
+	// If there are no registers left to be mapped, we can go to the next step,
 	// which is about forwarding the height to the next finalized block.
 	if len(s.registers) == 0 {
-		log.Info().Msg("indexed all registers for finalized block")
+		log.Info().Msg("mapped all registers for finalized block")
 		s.status = StatusForward
 		return nil
 	}
 
-	// We will now collect and index 1000 registers at a time. This gives the
-	// FSM the chance to exit the loop between every 1000 payloads we index. It
-	// doesn't really matter for badger if they are in random order, so this
-	// way of iterating should be fine.
+	// We will now write 1000 registers at a time, each chunk as a batch of its
+	// own, so that the registers of a height are never all held in a single
+	// batch. This matters most at the root height, where the registers are
+	// those of the whole root checkpoint. The chunks do not complete the
+	// height, so they only become visible once the rest of its data is written
+	// along with the last height; if we stop before that, they are written
+	// again when we resume from the last height. When reindexing, the height is
+	// already visible, so each chunk is visible as soon as it is committed, and
+	// the height is not written atomically. This also gives the FSM the
+	// chance to exit the loop between every 1000 payloads we map. The writer
+	// sorts them by path when they are indexed, so this way of iterating
+	// should be fine.
 	n := 1000
 	paths := make([]ledger.Path, 0, n)
 	payloads := make([]*ledger.Payload, 0, n)
@@ -479,13 +723,17 @@ func (t *Transitions) MapRegisters(s *State) error {
 		}
 	}
 
-	// Then we store the (maximum) 1000 paths and payloads.
-	err := t.write.Payloads(s.height, paths, payloads)
+	chunk := dps.HeightData{
+		Height:   s.height,
+		Paths:    paths,
+		Payloads: payloads,
+	}
+	err := t.write.WriteHeightBatch(s.ctx, chunk)
 	if err != nil {
 		return fmt.Errorf("could not index registers: %w", err)
 	}
 
-	log.Debug().Int("batch", len(paths)).Int("remaining", len(s.registers)).Msg("indexed register batch for finalized block")
+	log.Debug().Int("batch", len(paths)).Int("remaining", len(s.registers)).Msg("mapped register batch for finalized block")
 
 	return nil
 }
@@ -496,17 +744,47 @@ func (t *Transitions) ForwardHeight(s *State) error {
 		return fmt.Errorf("invalid status for forwarding height (%s)", s.status)
 	}
 
-	// After finishing the indexing of the payloads for a finalized block, or
-	// skipping it, we should document the last indexed height. On the first
-	// pass, we will also index the first indexed height here.
-	var err error
-	t.once.Do(func() { err = t.write.First(s.height) })
+	// After mapping the registers of a finalized block, we index the rest of
+	// its data as a single batch. The batch completes the height, so it also
+	// seals the integrity manifest of the height, which covers all of the data
+	// we indexed for it, including its registers and when reindexing, and
+	// stages the height, so that it can be rolled back if it is sealed with a
+	// conflicting execution result. It also documents the last indexed height,
+	// and on the first pass the first indexed height. When reindexing a range
+	// of heights, both are outside of the range, so we leave them be.
+	s.data.Height = s.height
+	s.data.Complete = true
+	if t.cfg.ReindexTo == 0 {
+		t.once.Do(func() { s.data.First = true })
+		s.data.Last = true
+	}
+	err := t.write.WriteHeightBatch(s.ctx, s.data)
 	if err != nil {
-		return fmt.Errorf("could not index first height: %w", err)
+		return fmt.Errorf("could not index height data: %w", err)
 	}
-	err = t.write.Last(s.height)
-	if err != nil {
-		return fmt.Errorf("could not index last height: %w", err)
+	s.data = dps.HeightData{}
+
+	// We then settle the staged heights whose execution results were sealed
+	// in the meantime.
+	if t.cfg.Staging != nil {
+		err = t.cfg.Staging.Settle()
+		if err != nil {
+			return fmt.Errorf("could not settle staged heights: %w", err)
+		}
+	}
+
+	// If recent tries are kept in memory, we hand over the tree of the height
+	// we just indexed before resetting the forest, so that its registers can
+	// be served before they are flushed to disk. The same goes for emitting
+	// checkpoints of the execution state.
+	if t.cfg.Recent != nil || t.cfg.Emitter != nil {
+		tree, ok := s.forest.Tree(s.next)
+		if ok && t.cfg.Recent != nil {
+			t.cfg.Recent.Add(s.height, tree)
+		}
+		if ok && t.cfg.Emitter != nil {
+			t.cfg.Emitter.Add(s.height, tree)
+		}
 	}
 
 	// Now that we have indexed the heights, we can forward to the next height,
@@ -514,7 +792,14 @@ func (t *Transitions) ForwardHeight(s *State) error {
 	s.height++
 	s.forest.Reset(s.next)
 
-	t.log.Info().Uint64("height", s.height).Msg("forwarded finalized block to next height")
+	// If we are reindexing a range of heights and just went past the end of
+	// the range, we are done.
+	if t.cfg.ReindexTo != 0 && s.height > t.cfg.ReindexTo {
+		t.log.Info().Uint64(logs.Height, t.cfg.ReindexTo).Msg("reindexed last height of range")
+		return dps.ErrFinished
+	}
+
+	t.log.Info().Uint64(logs.Height, s.height).Msg("forwarded finalized block to next height")
 
 	// Once the height is forwarded, we can set the status so that we index
 	// the blockchain data next.
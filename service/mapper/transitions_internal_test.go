@@ -15,13 +15,18 @@
 package mapper
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/onflow/flow-go/fvm/systemcontracts"
 	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common/hash"
 	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
 	"github.com/onflow/flow-go/model/flow"
 
@@ -99,6 +104,52 @@ func TestTransitions_BootstrapState(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("nominal case without execution data", func(t *testing.T) {
+		t.Parallel()
+
+		loader := mocks.BaselineLoader(t)
+		loader.TrieFunc = func() (*trie.MTrie, error) {
+			t.Fatal("loader should not be used without execution data")
+			return nil, nil
+		}
+
+		forest := mocks.BaselineForest(t, true)
+		forest.SaveFunc = func(*trie.MTrie, []ledger.Path, flow.StateCommitment) {
+			t.Fatal("forest should not be used without execution data")
+		}
+
+		tr, st := baselineFSM(t, StatusBootstrap, withLoader(loader))
+		tr.cfg.ProtocolOnly = true
+		st.forest = forest
+
+		err := tr.BootstrapState(st)
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusIndex, st.status)
+		assert.Equal(t, mocks.GenericHeight, st.height)
+	})
+
+	t.Run("nominal case with filter", func(t *testing.T) {
+		t.Parallel()
+
+		filter := dps.Filter{Allowed: mocks.GenericAddresses(2)}
+
+		var indexed dps.Filter
+		writer := mocks.BaselineWriter(t)
+		writer.FilterFunc = func(_ context.Context, got dps.Filter) error {
+			indexed = got
+			return nil
+		}
+
+		tr, st := baselineFSM(t, StatusBootstrap, withWriter(writer))
+		tr.cfg.Filter = filter
+
+		err := tr.BootstrapState(st)
+
+		require.NoError(t, err)
+		assert.Equal(t, filter, indexed)
+	})
+
 	t.Run("invalid state", func(t *testing.T) {
 		t.Parallel()
 
@@ -108,6 +159,21 @@ func TestTransitions_BootstrapState(t *testing.T) {
 		assert.Error(t, err)
 	})
 
+	t.Run("handles writer failure on filter", func(t *testing.T) {
+		t.Parallel()
+
+		writer := mocks.BaselineWriter(t)
+		writer.FilterFunc = func(context.Context, dps.Filter) error {
+			return mocks.GenericError
+		}
+
+		tr, st := baselineFSM(t, StatusBootstrap, withWriter(writer))
+		tr.cfg.Filter = dps.Filter{Denied: mocks.GenericAddresses(2)}
+
+		err := tr.BootstrapState(st)
+		assert.Error(t, err)
+	})
+
 	t.Run("handles failure to get root height", func(t *testing.T) {
 		t.Parallel()
 
@@ -123,6 +189,35 @@ func TestTransitions_BootstrapState(t *testing.T) {
 		err := tr.BootstrapState(st)
 		assert.Error(t, err)
 	})
+
+	t.Run("handles failure to get root seal", func(t *testing.T) {
+		t.Parallel()
+
+		chain := mocks.BaselineChain(t)
+		chain.RootSealFunc = func() (*flow.Seal, error) {
+			return nil, mocks.GenericError
+		}
+
+		tr, st := baselineFSM(t, StatusBootstrap, withChain(chain))
+
+		err := tr.BootstrapState(st)
+		assert.Error(t, err)
+	})
+
+	t.Run("handles checkpoint not matching root seal", func(t *testing.T) {
+		t.Parallel()
+
+		chain := mocks.BaselineChain(t)
+		chain.RootSealFunc = func() (*flow.Seal, error) {
+			return mocks.GenericSeal(0), nil
+		}
+
+		tr, st := baselineFSM(t, StatusBootstrap, withChain(chain))
+
+		err := tr.BootstrapState(st)
+		assert.Error(t, err)
+		assert.Equal(t, StatusBootstrap, st.status)
+	})
 }
 
 func TestTransitions_IndexChain(t *testing.T) {
@@ -171,69 +266,88 @@ func TestTransitions_IndexChain(t *testing.T) {
 			return mocks.GenericSeals(4), nil
 		}
 
-		write := mocks.BaselineWriter(t)
-		write.HeaderFunc = func(height uint64, header *flow.Header) error {
-			assert.Equal(t, mocks.GenericHeight, height)
-			assert.Equal(t, mocks.GenericHeader, header)
+		tr, st := baselineFSM(t, StatusIndex)
+		tr.chain = chain
 
-			return nil
-		}
-		write.CommitFunc = func(height uint64, commit flow.StateCommitment) error {
-			assert.Equal(t, mocks.GenericHeight, height)
-			assert.Equal(t, mocks.GenericCommit(0), commit)
+		err := tr.IndexChain(st)
 
-			return nil
-		}
-		write.HeightFunc = func(blockID flow.Identifier, height uint64) error {
-			assert.Equal(t, mocks.GenericHeight, height)
-			assert.Equal(t, mocks.GenericHeader.ID(), blockID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusUpdate, st.status)
 
-			return nil
-		}
-		write.CollectionsFunc = func(height uint64, collections []*flow.LightCollection) error {
-			assert.Equal(t, mocks.GenericHeight, height)
-			assert.Equal(t, mocks.GenericCollections(2), collections)
+		commit := mocks.GenericCommit(0)
+		assert.Equal(t, mocks.GenericHeight, st.data.Height)
+		assert.Equal(t, mocks.GenericHeader.ID(), st.data.BlockID)
+		assert.Equal(t, mocks.GenericHeader, st.data.Header)
+		assert.Equal(t, &commit, st.data.Commit)
+		assert.Equal(t, mocks.GenericCollections(2), st.data.Collections)
+		assert.Equal(t, mocks.GenericGuarantees(2), st.data.Guarantees)
+		assert.Equal(t, mocks.GenericTransactions(4), st.data.Transactions)
+		assert.Equal(t, mocks.GenericResults(4), st.data.Results)
+		assert.Equal(t, mocks.GenericEvents(8), st.data.Events)
+		assert.Equal(t, mocks.GenericSeals(4), st.data.Seals)
+	})
 
-			return nil
-		}
-		write.GuaranteesFunc = func(height uint64, guarantees []*flow.CollectionGuarantee) error {
-			assert.Equal(t, mocks.GenericHeight, height)
-			assert.Equal(t, mocks.GenericGuarantees(2), guarantees)
+	t.Run("nominal case with filter", func(t *testing.T) {
+		t.Parallel()
 
-			return nil
-		}
-		write.TransactionsFunc = func(height uint64, transactions []*flow.TransactionBody) error {
-			assert.Equal(t, mocks.GenericHeight, height)
-			assert.Equal(t, mocks.GenericTransactions(4), transactions)
+		tr, st := baselineFSM(t, StatusIndex)
+		tr.cfg.Filter = dps.Filter{Allowed: mocks.GenericAddresses(2)}
 
-			return nil
-		}
-		write.ResultsFunc = func(results []*flow.TransactionResult) error {
-			assert.Equal(t, mocks.GenericResults(4), results)
+		err := tr.IndexChain(st)
 
-			return nil
-		}
-		write.EventsFunc = func(height uint64, events []flow.Event) error {
-			assert.Equal(t, mocks.GenericHeight, height)
-			assert.Equal(t, mocks.GenericEvents(8), events)
+		require.NoError(t, err)
+		assert.Equal(t, StatusUpdate, st.status)
+		assert.Empty(t, st.data.Transactions)
+		assert.Empty(t, st.data.Results)
+		assert.Empty(t, st.data.Events)
+	})
 
-			return nil
-		}
-		write.SealsFunc = func(height uint64, seals []*flow.Seal) error {
-			assert.Equal(t, mocks.GenericHeight, height)
-			assert.Equal(t, mocks.GenericSeals(4), seals)
+	t.Run("nominal case without execution data", func(t *testing.T) {
+		t.Parallel()
 
-			return nil
+		chain := mocks.BaselineChain(t)
+		chain.CommitFunc = func(uint64) (flow.StateCommitment, error) {
+			t.Fatal("commit should not be retrieved without execution data")
+			return flow.DummyStateCommitment, nil
+		}
+		chain.ResultsFunc = func(uint64) ([]*flow.TransactionResult, error) {
+			t.Fatal("results should not be retrieved without execution data")
+			return nil, nil
+		}
+		chain.EventsFunc = func(uint64) ([]flow.Event, error) {
+			t.Fatal("events should not be retrieved without execution data")
+			return nil, nil
 		}
 
 		tr, st := baselineFSM(t, StatusIndex)
+		tr.cfg.ProtocolOnly = true
 		tr.chain = chain
-		tr.write = write
 
 		err := tr.IndexChain(st)
 
 		require.NoError(t, err)
-		assert.Equal(t, StatusUpdate, st.status)
+		assert.Equal(t, StatusForward, st.status)
+		assert.Equal(t, mocks.GenericHeader, st.data.Header)
+		assert.Nil(t, st.data.Commit)
+		assert.NotEmpty(t, st.data.Collections)
+		assert.NotEmpty(t, st.data.Transactions)
+	})
+
+	t.Run("handles chain failure to retrieve transactions without execution data", func(t *testing.T) {
+		t.Parallel()
+
+		chain := mocks.BaselineChain(t)
+		chain.TransactionsFunc = func(uint64) ([]*flow.TransactionBody, error) {
+			return nil, mocks.GenericError
+		}
+
+		tr, st := baselineFSM(t, StatusIndex)
+		tr.cfg.ProtocolOnly = true
+		tr.chain = chain
+
+		err := tr.IndexChain(st)
+
+		assert.Error(t, err)
 	})
 
 	t.Run("handles invalid status", func(t *testing.T) {
@@ -262,59 +376,79 @@ func TestTransitions_IndexChain(t *testing.T) {
 		assert.Error(t, err)
 	})
 
-	t.Run("handles writer failure to index commit", func(t *testing.T) {
+	t.Run("reports corrupted execution data before halting", func(t *testing.T) {
 		t.Parallel()
 
+		chain := mocks.BaselineChain(t)
+		chain.CommitFunc = func(uint64) (flow.StateCommitment, error) {
+			return flow.DummyStateCommitment, fmt.Errorf("execution record quarantined: %w", dps.ErrCorrupted)
+		}
+
+		var recorded int
 		write := mocks.BaselineWriter(t)
-		write.CommitFunc = func(uint64, flow.StateCommitment) error {
-			return mocks.GenericError
+		write.CorruptionFunc = func(_ context.Context, height uint64, reason string) error {
+			recorded++
+			assert.Equal(t, mocks.GenericHeight, height)
+			assert.NotEmpty(t, reason)
+			return nil
 		}
 
 		tr, st := baselineFSM(t, StatusIndex)
+		tr.cfg.SkipCorrupted = true
+		tr.chain = chain
 		tr.write = write
 
 		err := tr.IndexChain(st)
 
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, dps.ErrCorrupted)
+		assert.Equal(t, 1, recorded)
+		assert.Equal(t, StatusIndex, st.status)
 	})
 
-	t.Run("handles chain failure to retrieve header", func(t *testing.T) {
+	t.Run("handles writer failure when reporting corrupted execution data", func(t *testing.T) {
 		t.Parallel()
 
 		chain := mocks.BaselineChain(t)
-		chain.HeaderFunc = func(uint64) (*flow.Header, error) {
-			return nil, mocks.GenericError
+		chain.CommitFunc = func(uint64) (flow.StateCommitment, error) {
+			return flow.DummyStateCommitment, fmt.Errorf("execution record quarantined: %w", dps.ErrCorrupted)
+		}
+
+		write := mocks.BaselineWriter(t)
+		write.CorruptionFunc = func(context.Context, uint64, string) error {
+			return mocks.GenericError
 		}
 
 		tr, st := baselineFSM(t, StatusIndex)
+		tr.cfg.SkipCorrupted = true
 		tr.chain = chain
+		tr.write = write
 
 		err := tr.IndexChain(st)
 
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, mocks.GenericError)
 	})
 
-	t.Run("handles writer failure to index header", func(t *testing.T) {
+	t.Run("handles corrupted execution data when not skipping", func(t *testing.T) {
 		t.Parallel()
 
-		write := mocks.BaselineWriter(t)
-		write.HeaderFunc = func(uint64, *flow.Header) error {
-			return mocks.GenericError
+		chain := mocks.BaselineChain(t)
+		chain.CommitFunc = func(uint64) (flow.StateCommitment, error) {
+			return flow.DummyStateCommitment, fmt.Errorf("execution record quarantined: %w", dps.ErrCorrupted)
 		}
 
 		tr, st := baselineFSM(t, StatusIndex)
-		tr.write = write
+		tr.chain = chain
 
 		err := tr.IndexChain(st)
 
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, dps.ErrCorrupted)
 	})
 
-	t.Run("handles chain failure to retrieve transactions", func(t *testing.T) {
+	t.Run("handles chain failure to retrieve header", func(t *testing.T) {
 		t.Parallel()
 
 		chain := mocks.BaselineChain(t)
-		chain.TransactionsFunc = func(uint64) ([]*flow.TransactionBody, error) {
+		chain.HeaderFunc = func(uint64) (*flow.Header, error) {
 			return nil, mocks.GenericError
 		}
 
@@ -326,11 +460,11 @@ func TestTransitions_IndexChain(t *testing.T) {
 		assert.Error(t, err)
 	})
 
-	t.Run("handles chain failure to retrieve transaction results", func(t *testing.T) {
+	t.Run("handles chain failure to retrieve transactions", func(t *testing.T) {
 		t.Parallel()
 
 		chain := mocks.BaselineChain(t)
-		chain.ResultsFunc = func(uint64) ([]*flow.TransactionResult, error) {
+		chain.TransactionsFunc = func(uint64) ([]*flow.TransactionBody, error) {
 			return nil, mocks.GenericError
 		}
 
@@ -342,16 +476,16 @@ func TestTransitions_IndexChain(t *testing.T) {
 		assert.Error(t, err)
 	})
 
-	t.Run("handles writer failure to index transactions", func(t *testing.T) {
+	t.Run("handles chain failure to retrieve transaction results", func(t *testing.T) {
 		t.Parallel()
 
-		write := mocks.BaselineWriter(t)
-		write.ResultsFunc = func([]*flow.TransactionResult) error {
-			return mocks.GenericError
+		chain := mocks.BaselineChain(t)
+		chain.ResultsFunc = func(uint64) ([]*flow.TransactionResult, error) {
+			return nil, mocks.GenericError
 		}
 
 		tr, st := baselineFSM(t, StatusIndex)
-		tr.write = write
+		tr.chain = chain
 
 		err := tr.IndexChain(st)
 
@@ -374,27 +508,27 @@ func TestTransitions_IndexChain(t *testing.T) {
 		assert.Error(t, err)
 	})
 
-	t.Run("handles writer failure to index collections", func(t *testing.T) {
+	t.Run("handles chain failure to retrieve guarantees", func(t *testing.T) {
 		t.Parallel()
 
-		write := mocks.BaselineWriter(t)
-		write.CollectionsFunc = func(uint64, []*flow.LightCollection) error {
-			return mocks.GenericError
+		chain := mocks.BaselineChain(t)
+		chain.GuaranteesFunc = func(uint64) ([]*flow.CollectionGuarantee, error) {
+			return nil, mocks.GenericError
 		}
 
 		tr, st := baselineFSM(t, StatusIndex)
-		tr.write = write
+		tr.chain = chain
 
 		err := tr.IndexChain(st)
 
 		assert.Error(t, err)
 	})
 
-	t.Run("handles chain failure to retrieve guarantees", func(t *testing.T) {
+	t.Run("handles chain failure to retrieve events", func(t *testing.T) {
 		t.Parallel()
 
 		chain := mocks.BaselineChain(t)
-		chain.GuaranteesFunc = func(uint64) ([]*flow.CollectionGuarantee, error) {
+		chain.EventsFunc = func(uint64) ([]flow.Event, error) {
 			return nil, mocks.GenericError
 		}
 
@@ -406,119 +540,264 @@ func TestTransitions_IndexChain(t *testing.T) {
 		assert.Error(t, err)
 	})
 
-	t.Run("handles writer failure to index guarantees", func(t *testing.T) {
+	t.Run("nominal case with block stats", func(t *testing.T) {
 		t.Parallel()
 
-		write := mocks.BaselineWriter(t)
-		write.GuaranteesFunc = func(uint64, []*flow.CollectionGuarantee) error {
-			return mocks.GenericError
-		}
-
 		tr, st := baselineFSM(t, StatusIndex)
-		tr.write = write
 
 		err := tr.IndexChain(st)
 
-		assert.Error(t, err)
+		require.NoError(t, err)
+		assert.Equal(t, StatusUpdate, st.status)
+		require.NotNil(t, st.data.BlockStats)
+		assert.Equal(t, mocks.GenericHeight, st.data.BlockStats.Height)
+		assert.Equal(t, uint64(4), st.data.BlockStats.Transactions)
+		assert.Equal(t, uint64(4), st.data.BlockStats.Events)
 	})
 
-	t.Run("handles chain failure to retrieve events", func(t *testing.T) {
+	t.Run("nominal case with account events", func(t *testing.T) {
 		t.Parallel()
 
+		addresses := mocks.GenericAddresses(3)
+
 		chain := mocks.BaselineChain(t)
 		chain.EventsFunc = func(uint64) ([]flow.Event, error) {
-			return nil, mocks.GenericError
+			return mocks.GenericEvents(3, flow.EventAccountCreated, eventAccountKeyAdded, eventAccountKeyRemoved), nil
 		}
 
-		tr, st := baselineFSM(t, StatusIndex)
-		tr.chain = chain
+		tr, st := baselineFSM(t, StatusIndex, withChain(chain))
 
 		err := tr.IndexChain(st)
 
-		assert.Error(t, err)
+		require.NoError(t, err)
+		assert.Equal(t, StatusUpdate, st.status)
+		assert.Equal(t, addresses[:1], st.data.Accounts)
+		assert.Equal(t, addresses[1:], st.data.KeyUpdates)
 	})
 
-	t.Run("handles writer failure to index events", func(t *testing.T) {
+	t.Run("nominal case with contract events", func(t *testing.T) {
 		t.Parallel()
 
-		write := mocks.BaselineWriter(t)
-		write.EventsFunc = func(uint64, []flow.Event) error {
-			return mocks.GenericError
+		address := mocks.GenericAddress(0)
+		hash := mocks.GenericCommit(0)
+		txID := mocks.GenericTransaction(0).ID()
+
+		chain := mocks.BaselineChain(t)
+		chain.EventsFunc = func(uint64) ([]flow.Event, error) {
+			return []flow.Event{contractEvent(t, eventContractAdded, txID, address, "Contract", hash[:])}, nil
 		}
 
-		tr, st := baselineFSM(t, StatusIndex)
-		tr.write = write
+		tr, st := baselineFSM(t, StatusIndex, withChain(chain))
 
 		err := tr.IndexChain(st)
 
-		assert.Error(t, err)
+		require.NoError(t, err)
+		assert.Equal(t, StatusUpdate, st.status)
+
+		want := dps.ContractVersion{
+			Address:       address,
+			Name:          "Contract",
+			Height:        mocks.GenericHeight,
+			TransactionID: txID,
+			CodeHash:      hash[:],
+		}
+		assert.Equal(t, []dps.ContractVersion{want}, st.data.Contracts)
 	})
 
-	t.Run("handles chain failure to retrieve seals", func(t *testing.T) {
+	t.Run("nominal case with service events", func(t *testing.T) {
 		t.Parallel()
 
+		known, err := systemcontracts.ServiceEventsForChain(mocks.GenericHeader.ChainID)
+		require.NoError(t, err)
+		events := mocks.GenericEvents(2, known.EpochSetup.EventType(), mocks.GenericEventType(0))
+
 		chain := mocks.BaselineChain(t)
-		chain.SealsFunc = func(uint64) ([]*flow.Seal, error) {
-			return nil, mocks.GenericError
+		chain.EventsFunc = func(uint64) ([]flow.Event, error) {
+			return events, nil
 		}
 
-		tr, st := baselineFSM(t, StatusIndex)
-		tr.chain = chain
+		tr, st := baselineFSM(t, StatusIndex, withChain(chain))
 
-		err := tr.IndexChain(st)
+		err = tr.IndexChain(st)
 
-		assert.Error(t, err)
+		require.NoError(t, err)
+		assert.Equal(t, StatusUpdate, st.status)
+		assert.Equal(t, events[:1], st.data.ServiceEvents)
 	})
 
-	t.Run("handles writer failure to index seals", func(t *testing.T) {
+	t.Run("handles chain failure to get epoch status", func(t *testing.T) {
 		t.Parallel()
 
-		write := mocks.BaselineWriter(t)
-		write.SealsFunc = func(uint64, []*flow.Seal) error {
-			return mocks.GenericError
+		chain := mocks.BaselineChain(t)
+		chain.EpochStatusFunc = func(uint64) (*flow.EpochStatus, error) {
+			return nil, mocks.GenericError
 		}
 
-		tr, st := baselineFSM(t, StatusIndex)
-		tr.write = write
+		tr, st := baselineFSM(t, StatusIndex, withChain(chain))
 
 		err := tr.IndexChain(st)
 
 		assert.Error(t, err)
 	})
-}
-
-func TestTransitions_UpdateTree(t *testing.T) {
-	update := mocks.GenericTrieUpdate(0)
-	tree := mocks.GenericTrie
 
-	t.Run("nominal case without match", func(t *testing.T) {
+	t.Run("nominal case with new event types", func(t *testing.T) {
 		t.Parallel()
 
-		tr, st := baselineFSM(t, StatusUpdate)
-
-		forest := mocks.BaselineForest(t, false)
-		forest.SaveFunc = func(tree *trie.MTrie, paths []ledger.Path, parent flow.StateCommitment) {
-			// Parent is RootHash of the mocks.GenericTrie.
-			assert.Equal(t, update.RootHash[:], parent[:])
-			assert.ElementsMatch(t, paths, update.Paths)
-			assert.NotZero(t, tree)
-		}
-		forest.TreeFunc = func(commit flow.StateCommitment) (*trie.MTrie, bool) {
-			assert.Equal(t, update.RootHash[:], commit[:])
-			return tree, true
+		read := mocks.BaselineReader(t)
+		read.EventTypesFunc = func(context.Context) ([]dps.EventTypeStats, error) {
+			return nil, nil
 		}
-		st.forest = forest
 
-		err := tr.UpdateTree(st)
+		tr, st := baselineFSM(t, StatusIndex, withReader(read))
+
+		err := tr.IndexChain(st)
 
 		require.NoError(t, err)
 		assert.Equal(t, StatusUpdate, st.status)
+
+		want := dps.EventTypeStats{
+			Type:  mocks.GenericEventType(0),
+			First: mocks.GenericHeight,
+			Last:  mocks.GenericHeight,
+			Count: 4,
+		}
+		assert.Equal(t, []dps.EventTypeStats{want}, st.data.EventTypes)
 	})
 
-	t.Run("nominal case with no available update temporarily", func(t *testing.T) {
+	t.Run("nominal case with event types already counted", func(t *testing.T) {
 		t.Parallel()
 
-		tr, st := baselineFSM(t, StatusUpdate)
+		tr, st := baselineFSM(t, StatusIndex)
+
+		err := tr.IndexChain(st)
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusUpdate, st.status)
+		assert.Empty(t, st.data.EventTypes)
+	})
+
+	t.Run("handles reader failure to retrieve event types", func(t *testing.T) {
+		t.Parallel()
+
+		read := mocks.BaselineReader(t)
+		read.EventTypesFunc = func(context.Context) ([]dps.EventTypeStats, error) {
+			return nil, mocks.GenericError
+		}
+
+		tr, st := baselineFSM(t, StatusIndex, withReader(read))
+
+		err := tr.IndexChain(st)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles chain failure to retrieve seals", func(t *testing.T) {
+		t.Parallel()
+
+		chain := mocks.BaselineChain(t)
+		chain.SealsFunc = func(uint64) ([]*flow.Seal, error) {
+			return nil, mocks.GenericError
+		}
+
+		tr, st := baselineFSM(t, StatusIndex)
+		tr.chain = chain
+
+		err := tr.IndexChain(st)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("nominal case when reindexing with matching indexed commit", func(t *testing.T) {
+		t.Parallel()
+
+		read := mocks.BaselineReader(t)
+		read.CommitFunc = func(_ context.Context, height uint64) (flow.StateCommitment, error) {
+			assert.Equal(t, mocks.GenericHeight, height)
+
+			return mocks.GenericCommit(0), nil
+		}
+
+		tr, st := baselineFSM(t, StatusIndex)
+		tr.cfg.ReindexFrom = mocks.GenericHeight
+		tr.cfg.ReindexTo = mocks.GenericHeight
+		tr.read = read
+
+		err := tr.IndexChain(st)
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusUpdate, st.status)
+	})
+
+	t.Run("handles mismatch with indexed commit when reindexing", func(t *testing.T) {
+		t.Parallel()
+
+		read := mocks.BaselineReader(t)
+		read.CommitFunc = func(context.Context, uint64) (flow.StateCommitment, error) {
+			return mocks.GenericCommit(1), nil
+		}
+
+		tr, st := baselineFSM(t, StatusIndex)
+		tr.cfg.ReindexFrom = mocks.GenericHeight
+		tr.cfg.ReindexTo = mocks.GenericHeight
+		tr.read = read
+
+		err := tr.IndexChain(st)
+
+		assert.ErrorIs(t, err, dps.ErrCorrupted)
+		assert.Nil(t, st.data.Commit, "commit should not be collected on mismatch")
+	})
+
+	t.Run("handles reader failure on indexed commit when reindexing", func(t *testing.T) {
+		t.Parallel()
+
+		read := mocks.BaselineReader(t)
+		read.CommitFunc = func(context.Context, uint64) (flow.StateCommitment, error) {
+			return flow.DummyStateCommitment, mocks.GenericError
+		}
+
+		tr, st := baselineFSM(t, StatusIndex)
+		tr.cfg.ReindexFrom = mocks.GenericHeight
+		tr.cfg.ReindexTo = mocks.GenericHeight
+		tr.read = read
+
+		err := tr.IndexChain(st)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestTransitions_UpdateTree(t *testing.T) {
+	update := mocks.GenericTrieUpdate(0)
+	tree := mocks.GenericTrie
+
+	t.Run("nominal case without match", func(t *testing.T) {
+		t.Parallel()
+
+		tr, st := baselineFSM(t, StatusUpdate)
+
+		forest := mocks.BaselineForest(t, false)
+		forest.SaveFunc = func(tree *trie.MTrie, paths []ledger.Path, parent flow.StateCommitment) {
+			// Parent is RootHash of the mocks.GenericTrie.
+			assert.Equal(t, update.RootHash[:], parent[:])
+			assert.ElementsMatch(t, paths, update.Paths)
+			assert.NotZero(t, tree)
+		}
+		forest.TreeFunc = func(commit flow.StateCommitment) (*trie.MTrie, bool) {
+			assert.Equal(t, update.RootHash[:], commit[:])
+			return tree, true
+		}
+		st.forest = forest
+
+		err := tr.UpdateTree(st)
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusUpdate, st.status)
+	})
+
+	t.Run("nominal case with no available update temporarily", func(t *testing.T) {
+		t.Parallel()
+
+		tr, st := baselineFSM(t, StatusUpdate)
 
 		// Set up the mock feeder to return an unavailable error on the first call and return successfully
 		// to subsequent calls.
@@ -606,6 +885,110 @@ func TestTransitions_UpdateTree(t *testing.T) {
 
 		assert.NoError(t, err)
 	})
+
+	t.Run("nominal case with skipped corrupted update", func(t *testing.T) {
+		t.Parallel()
+
+		feed := mocks.BaselineFeeder(t)
+		feed.UpdateFunc = func() (*ledger.TrieUpdate, error) {
+			return nil, fmt.Errorf("could not decode record: %w", dps.ErrCorrupted)
+		}
+
+		var recorded bool
+		write := mocks.BaselineWriter(t)
+		write.CorruptionFunc = func(_ context.Context, height uint64, reason string) error {
+			recorded = true
+			assert.Equal(t, mocks.GenericHeight, height)
+			assert.NotEmpty(t, reason)
+			return nil
+		}
+
+		tr, st := baselineFSM(t, StatusUpdate)
+		st.forest = mocks.BaselineForest(t, false)
+		tr.cfg.SkipCorrupted = true
+		tr.feed = feed
+		tr.write = write
+
+		err := tr.UpdateTree(st)
+
+		require.NoError(t, err)
+		assert.True(t, recorded)
+		assert.True(t, st.skipped)
+		assert.Equal(t, StatusUpdate, st.status)
+	})
+
+	t.Run("handles unreachable commit after skipped corrupted update", func(t *testing.T) {
+		t.Parallel()
+
+		forest := mocks.BaselineForest(t, false)
+		forest.TreeFunc = func(_ flow.StateCommitment) (*trie.MTrie, bool) {
+			return nil, false
+		}
+
+		tr, st := baselineFSM(t, StatusUpdate)
+		st.forest = forest
+		st.skipped = true
+		tr.cfg.SkipCorrupted = true
+
+		err := tr.UpdateTree(st)
+
+		assert.ErrorIs(t, err, dps.ErrCorrupted)
+	})
+
+	t.Run("forgets skipped corrupted update once commit is reached", func(t *testing.T) {
+		t.Parallel()
+
+		tr, st := baselineFSM(t, StatusUpdate)
+		st.forest = mocks.BaselineForest(t, true)
+		st.skipped = true
+
+		err := tr.UpdateTree(st)
+
+		require.NoError(t, err)
+		assert.False(t, st.skipped)
+		assert.Equal(t, StatusCollect, st.status)
+	})
+
+	t.Run("handles corrupted update when not skipping", func(t *testing.T) {
+		t.Parallel()
+
+		feed := mocks.BaselineFeeder(t)
+		feed.UpdateFunc = func() (*ledger.TrieUpdate, error) {
+			return nil, fmt.Errorf("could not decode record: %w", dps.ErrCorrupted)
+		}
+
+		tr, st := baselineFSM(t, StatusUpdate)
+		st.forest = mocks.BaselineForest(t, false)
+		tr.feed = feed
+
+		err := tr.UpdateTree(st)
+
+		assert.ErrorIs(t, err, dps.ErrCorrupted)
+	})
+
+	t.Run("handles writer failure on corruption", func(t *testing.T) {
+		t.Parallel()
+
+		feed := mocks.BaselineFeeder(t)
+		feed.UpdateFunc = func() (*ledger.TrieUpdate, error) {
+			return nil, fmt.Errorf("could not decode record: %w", dps.ErrCorrupted)
+		}
+
+		write := mocks.BaselineWriter(t)
+		write.CorruptionFunc = func(context.Context, uint64, string) error {
+			return mocks.GenericError
+		}
+
+		tr, st := baselineFSM(t, StatusUpdate)
+		st.forest = mocks.BaselineForest(t, false)
+		tr.cfg.SkipCorrupted = true
+		tr.feed = feed
+		tr.write = write
+
+		err := tr.UpdateTree(st)
+
+		assert.Error(t, err)
+	})
 }
 
 func TestTransitions_CollectRegisters(t *testing.T) {
@@ -631,6 +1014,173 @@ func TestTransitions_CollectRegisters(t *testing.T) {
 		}
 	})
 
+	t.Run("nominal case with allowed owner", func(t *testing.T) {
+		t.Parallel()
+
+		payloads := make([]ledger.Payload, 0, 6)
+		for _, payload := range mocks.GenericLedgerPayloads(6) {
+			payloads = append(payloads, *payload)
+		}
+		tree, err := trie.NewTrieWithUpdatedRegisters(trie.NewEmptyMTrie(), mocks.GenericLedgerPaths(6), payloads)
+		require.NoError(t, err)
+
+		forest := mocks.BaselineForest(t, true)
+		forest.TreeFunc = func(flow.StateCommitment) (*trie.MTrie, bool) {
+			return tree, true
+		}
+		forest.ParentFunc = func(commit flow.StateCommitment) (flow.StateCommitment, bool) {
+			return mocks.GenericCommit(1), true
+		}
+
+		tr, st := baselineFSM(t, StatusCollect)
+		tr.cfg.Filter = dps.Filter{Allowed: []flow.Address{flow.BytesToAddress([]byte(`owner`))}}
+		st.forest = forest
+
+		err = tr.CollectRegisters(st)
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusMap, st.status)
+		for _, wantPath := range mocks.GenericLedgerPaths(6) {
+			assert.Contains(t, st.registers, wantPath)
+		}
+	})
+
+	t.Run("skips registers of other owners", func(t *testing.T) {
+		t.Parallel()
+
+		payloads := make([]ledger.Payload, 0, 6)
+		for _, payload := range mocks.GenericLedgerPayloads(6) {
+			payloads = append(payloads, *payload)
+		}
+		tree, err := trie.NewTrieWithUpdatedRegisters(trie.NewEmptyMTrie(), mocks.GenericLedgerPaths(6), payloads)
+		require.NoError(t, err)
+
+		forest := mocks.BaselineForest(t, true)
+		forest.TreeFunc = func(flow.StateCommitment) (*trie.MTrie, bool) {
+			return tree, true
+		}
+		forest.ParentFunc = func(commit flow.StateCommitment) (flow.StateCommitment, bool) {
+			return mocks.GenericCommit(1), true
+		}
+
+		tr, st := baselineFSM(t, StatusCollect)
+		tr.cfg.Filter = dps.Filter{Allowed: mocks.GenericAddresses(2)}
+		st.forest = forest
+
+		err = tr.CollectRegisters(st)
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusMap, st.status)
+		assert.Empty(t, st.registers)
+	})
+
+	t.Run("nominal case with register churn", func(t *testing.T) {
+		t.Parallel()
+
+		payloads := make([]ledger.Payload, 0, 6)
+		for _, payload := range mocks.GenericLedgerPayloads(6) {
+			payloads = append(payloads, *payload)
+		}
+		tree, err := trie.NewTrieWithUpdatedRegisters(trie.NewEmptyMTrie(), mocks.GenericLedgerPaths(6), payloads)
+		require.NoError(t, err)
+
+		forest := mocks.BaselineForest(t, true)
+		forest.TreeFunc = func(flow.StateCommitment) (*trie.MTrie, bool) {
+			return tree, true
+		}
+		forest.ParentFunc = func(commit flow.StateCommitment) (flow.StateCommitment, bool) {
+			return mocks.GenericCommit(1), true
+		}
+
+		tr, st := baselineFSM(t, StatusCollect)
+		st.forest = forest
+
+		err = tr.CollectRegisters(st)
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusMap, st.status)
+
+		owner := flow.BytesToAddress([]byte(`owner`))
+		churn := st.data.RegisterChurn
+		require.NotNil(t, churn)
+		assert.Equal(t, mocks.GenericHeight, churn.Height)
+		assert.Equal(t, []dps.OwnerWrites{{Owner: owner, Writes: 6}}, churn.Owners)
+		assert.Equal(t, []dps.RegisterWrites{{Owner: owner, Key: "key", Writes: 6}}, churn.Registers)
+	})
+
+	t.Run("nominal case with register deltas", func(t *testing.T) {
+		t.Parallel()
+
+		paths := mocks.GenericLedgerPaths(6)
+		payloads := make([]ledger.Payload, 0, 6)
+		for _, payload := range mocks.GenericLedgerPayloads(6) {
+			payloads = append(payloads, *payload)
+		}
+		previous, err := trie.NewTrieWithUpdatedRegisters(trie.NewEmptyMTrie(), paths[:3], payloads[:3])
+		require.NoError(t, err)
+		current, err := trie.NewTrieWithUpdatedRegisters(previous, paths[3:], payloads[3:])
+		require.NoError(t, err)
+
+		forest := mocks.BaselineForest(t, true)
+		forest.TreeFunc = func(commit flow.StateCommitment) (*trie.MTrie, bool) {
+			if commit == mocks.GenericCommit(1) {
+				return previous, true
+			}
+			return current, true
+		}
+
+		tr, st := baselineFSM(t, StatusCollect)
+		tr.cfg.RegisterDeltas = true
+		st.forest = forest
+
+		err = tr.CollectRegisters(st)
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusMap, st.status)
+
+		got := st.data.RegisterDeltas
+		require.Len(t, got, len(paths))
+		for i, delta := range got {
+			if i > 0 {
+				assert.Negative(t, bytes.Compare(got[i-1].Path[:], delta.Path[:]))
+			}
+			assert.Equal(t, mocks.GenericHeight, delta.Height)
+			for j, path := range paths {
+				if path != delta.Path {
+					continue
+				}
+				leaf := hash.HashLeaf(hash.Hash(path), payloads[j].Value)
+				assert.Equal(t, leaf[:], delta.Current)
+				if j < 3 {
+					assert.Equal(t, leaf[:], delta.Previous)
+				} else {
+					assert.Empty(t, delta.Previous)
+				}
+			}
+		}
+	})
+
+	t.Run("register deltas not recorded for root height", func(t *testing.T) {
+		t.Parallel()
+
+		root := flow.StateCommitment(trie.EmptyTrieRootHash())
+		forest := mocks.BaselineForest(t, true)
+		forest.ParentFunc = func(flow.StateCommitment) (flow.StateCommitment, bool) {
+			return root, true
+		}
+
+		tr, st := baselineFSM(t, StatusCollect)
+		tr.cfg.RegisterDeltas = true
+		st.forest = forest
+		st.last = root
+
+		err := tr.CollectRegisters(st)
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusMap, st.status)
+		assert.Empty(t, st.data.RegisterDeltas)
+	})
+
 	t.Run("indexing payloads disabled", func(t *testing.T) {
 		t.Parallel()
 
@@ -681,87 +1231,206 @@ func TestTransitions_MapRegisters(t *testing.T) {
 			mocks.GenericLedgerPath(4): mocks.GenericLedgerPayload(4),
 			mocks.GenericLedgerPath(5): mocks.GenericLedgerPayload(5),
 		}
+		want := make(map[ledger.Path]*ledger.Payload, len(testRegisters))
+		for path, payload := range testRegisters {
+			want[path] = payload
+		}
 
+		var written int
 		write := mocks.BaselineWriter(t)
-		write.PayloadsFunc = func(height uint64, paths []ledger.Path, value []*ledger.Payload) error {
-			assert.Equal(t, mocks.GenericHeight, height)
+		write.WriteHeightBatchFunc = func(_ context.Context, data dps.HeightData) error {
+			assert.Equal(t, mocks.GenericHeight, data.Height)
+			assert.False(t, data.Complete)
+			assert.False(t, data.First)
+			assert.False(t, data.Last)
+
+			// Expect the 5 entries from the map in the chunk of registers.
+			require.Len(t, data.Paths, 5)
+			require.Len(t, data.Payloads, 5)
+			for i, path := range data.Paths {
+				assert.Equal(t, want[path], data.Payloads[i])
+			}
+			written++
 
-			// Expect the 5 entries from the map.
-			assert.Len(t, paths, 5)
-			assert.Len(t, value, 5)
 			return nil
 		}
 
-		tr, st := baselineFSM(t, StatusMap)
-		tr.write = write
+		tr, st := baselineFSM(t, StatusMap, withWriter(write))
 		st.registers = testRegisters
 
 		err := tr.MapRegisters(st)
 
 		require.NoError(t, err)
+		assert.Equal(t, 1, written)
+
+		// The registers are not held in the data of the height.
+		assert.Empty(t, st.data.Paths)
+		assert.Empty(t, st.data.Payloads)
 
 		// Should not be StateIndexed because registers map was not empty.
 		assert.Empty(t, st.registers)
 		assert.Equal(t, StatusMap, st.status)
 	})
 
-	t.Run("nominal case no more registers left to write", func(t *testing.T) {
-		t.Parallel()
+	t.Run("nominal case with more registers than a chunk", func(t *testing.T) {
+		t.Parallel()
+
+		var written []int
+		write := mocks.BaselineWriter(t)
+		write.WriteHeightBatchFunc = func(_ context.Context, data dps.HeightData) error {
+			written = append(written, len(data.Paths))
+			return nil
+		}
+
+		tr, st := baselineFSM(t, StatusMap, withWriter(write))
+		paths := mocks.GenericLedgerPaths(1500)
+		payloads := mocks.GenericLedgerPayloads(1500)
+		for i, path := range paths {
+			st.registers[path] = payloads[i]
+		}
+
+		err := tr.MapRegisters(st)
+		require.NoError(t, err)
+		err = tr.MapRegisters(st)
+		require.NoError(t, err)
+		err = tr.MapRegisters(st)
+		require.NoError(t, err)
+
+		assert.Equal(t, []int{1000, 500}, written)
+		assert.Equal(t, StatusForward, st.status)
+	})
+
+	t.Run("handles writer error on registers", func(t *testing.T) {
+		t.Parallel()
+
+		write := mocks.BaselineWriter(t)
+		write.WriteHeightBatchFunc = func(context.Context, dps.HeightData) error {
+			return mocks.GenericError
+		}
+
+		tr, st := baselineFSM(t, StatusMap, withWriter(write))
+		st.registers = map[ledger.Path]*ledger.Payload{
+			mocks.GenericLedgerPath(0): mocks.GenericLedgerPayload(0),
+		}
+
+		err := tr.MapRegisters(st)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("nominal case no more registers left to write", func(t *testing.T) {
+		t.Parallel()
+
+		tr, st := baselineFSM(t, StatusMap)
+
+		err := tr.MapRegisters(st)
+
+		assert.NoError(t, err)
+		assert.Equal(t, StatusForward, st.status)
+	})
+
+	t.Run("handles invalid status", func(t *testing.T) {
+		t.Parallel()
+
+		testRegisters := map[ledger.Path]*ledger.Payload{
+			mocks.GenericLedgerPath(0): mocks.GenericLedgerPayload(0),
+			mocks.GenericLedgerPath(1): mocks.GenericLedgerPayload(1),
+			mocks.GenericLedgerPath(2): mocks.GenericLedgerPayload(2),
+			mocks.GenericLedgerPath(3): mocks.GenericLedgerPayload(3),
+			mocks.GenericLedgerPath(4): mocks.GenericLedgerPayload(4),
+			mocks.GenericLedgerPath(5): mocks.GenericLedgerPayload(5),
+		}
+
+		tr, st := baselineFSM(t, StatusBootstrap)
+		st.registers = testRegisters
+
+		err := tr.MapRegisters(st)
+
+		assert.Error(t, err)
+	})
+
+}
+
+func TestTransitions_ForwardHeight(t *testing.T) {
+	t.Run("nominal case with recent tries", func(t *testing.T) {
+		t.Parallel()
+
+		var added bool
+		recent := mocks.BaselineRecent(t)
+		recent.AddFunc = func(height uint64, tree *trie.MTrie) {
+			assert.Equal(t, mocks.GenericHeight, height)
+			assert.Equal(t, mocks.GenericTrie, tree)
+			added = true
+		}
+
+		forest := mocks.BaselineForest(t, true)
+		forest.TreeFunc = func(commit flow.StateCommitment) (*trie.MTrie, bool) {
+			assert.Equal(t, mocks.GenericCommit(0), commit)
+			return mocks.GenericTrie, true
+		}
 
-		tr, st := baselineFSM(t, StatusMap)
+		tr, st := baselineFSM(t, StatusForward)
+		tr.cfg.Recent = recent
+		st.forest = forest
 
-		err := tr.MapRegisters(st)
+		err := tr.ForwardHeight(st)
 
-		assert.NoError(t, err)
-		assert.Equal(t, StatusForward, st.status)
+		require.NoError(t, err)
+		assert.True(t, added)
+		assert.Equal(t, mocks.GenericHeight+1, st.height)
 	})
 
-	t.Run("handles invalid status", func(t *testing.T) {
+	t.Run("nominal case with checkpoint emitter", func(t *testing.T) {
 		t.Parallel()
 
-		testRegisters := map[ledger.Path]*ledger.Payload{
-			mocks.GenericLedgerPath(0): mocks.GenericLedgerPayload(0),
-			mocks.GenericLedgerPath(1): mocks.GenericLedgerPayload(1),
-			mocks.GenericLedgerPath(2): mocks.GenericLedgerPayload(2),
-			mocks.GenericLedgerPath(3): mocks.GenericLedgerPayload(3),
-			mocks.GenericLedgerPath(4): mocks.GenericLedgerPayload(4),
-			mocks.GenericLedgerPath(5): mocks.GenericLedgerPayload(5),
+		var emitted bool
+		emitter := mocks.BaselineEmitter(t)
+		emitter.AddFunc = func(height uint64, tree *trie.MTrie) {
+			assert.Equal(t, mocks.GenericHeight, height)
+			assert.Equal(t, mocks.GenericTrie, tree)
+			emitted = true
 		}
 
-		tr, st := baselineFSM(t, StatusBootstrap)
-		st.registers = testRegisters
+		forest := mocks.BaselineForest(t, true)
+		forest.TreeFunc = func(commit flow.StateCommitment) (*trie.MTrie, bool) {
+			assert.Equal(t, mocks.GenericCommit(0), commit)
+			return mocks.GenericTrie, true
+		}
 
-		err := tr.MapRegisters(st)
+		tr, st := baselineFSM(t, StatusForward)
+		tr.cfg.Emitter = emitter
+		st.forest = forest
 
-		assert.Error(t, err)
+		err := tr.ForwardHeight(st)
+
+		require.NoError(t, err)
+		assert.True(t, emitted)
+		assert.Equal(t, mocks.GenericHeight+1, st.height)
 	})
 
-	t.Run("handles writer failure", func(t *testing.T) {
+	t.Run("nominal case with recent tries and missing tree", func(t *testing.T) {
 		t.Parallel()
 
-		testRegisters := map[ledger.Path]*ledger.Payload{
-			mocks.GenericLedgerPath(0): mocks.GenericLedgerPayload(0),
-			mocks.GenericLedgerPath(1): mocks.GenericLedgerPayload(1),
-			mocks.GenericLedgerPath(2): mocks.GenericLedgerPayload(2),
-			mocks.GenericLedgerPath(3): mocks.GenericLedgerPayload(3),
-			mocks.GenericLedgerPath(4): mocks.GenericLedgerPayload(4),
-			mocks.GenericLedgerPath(5): mocks.GenericLedgerPayload(5),
+		recent := mocks.BaselineRecent(t)
+		recent.AddFunc = func(uint64, *trie.MTrie) {
+			t.Fatal("tree should not be added if it is not in the forest")
 		}
 
-		write := mocks.BaselineWriter(t)
-		write.PayloadsFunc = func(uint64, []ledger.Path, []*ledger.Payload) error { return mocks.GenericError }
+		forest := mocks.BaselineForest(t, true)
+		forest.TreeFunc = func(flow.StateCommitment) (*trie.MTrie, bool) {
+			return nil, false
+		}
 
-		tr, st := baselineFSM(t, StatusMap)
-		tr.write = write
-		st.registers = testRegisters
+		tr, st := baselineFSM(t, StatusForward)
+		tr.cfg.Recent = recent
+		st.forest = forest
 
-		err := tr.MapRegisters(st)
+		err := tr.ForwardHeight(st)
 
-		assert.Error(t, err)
+		require.NoError(t, err)
+		assert.Equal(t, StatusIndex, st.status)
 	})
-}
 
-func TestTransitions_ForwardHeight(t *testing.T) {
 	t.Run("nominal case", func(t *testing.T) {
 		t.Parallel()
 
@@ -770,13 +1439,14 @@ func TestTransitions_ForwardHeight(t *testing.T) {
 			lastCalled  int
 		)
 		write := mocks.BaselineWriter(t)
-		write.FirstFunc = func(height uint64) error {
-			assert.Equal(t, mocks.GenericHeight, height)
-			firstCalled++
-			return nil
-		}
-		write.LastFunc = func(height uint64) error {
-			assert.Equal(t, mocks.GenericHeight+uint64(lastCalled), height)
+		write.WriteHeightBatchFunc = func(_ context.Context, data dps.HeightData) error {
+			assert.Equal(t, mocks.GenericHeight+uint64(lastCalled), data.Height)
+			assert.True(t, data.Complete)
+			assert.True(t, data.Last)
+			if data.First {
+				assert.Equal(t, mocks.GenericHeight, data.Height)
+				firstCalled++
+			}
 			lastCalled++
 			return nil
 		}
@@ -804,8 +1474,9 @@ func TestTransitions_ForwardHeight(t *testing.T) {
 		assert.Equal(t, StatusIndex, st.status)
 		assert.Equal(t, mocks.GenericHeight+2, st.height)
 
-		// First should have been called only once.
+		// First should have been set only once.
 		assert.Equal(t, 1, firstCalled)
+		assert.Equal(t, 2, lastCalled)
 	})
 
 	t.Run("handles invalid status", func(t *testing.T) {
@@ -818,27 +1489,42 @@ func TestTransitions_ForwardHeight(t *testing.T) {
 		assert.Error(t, err)
 	})
 
-	t.Run("handles writer error on first", func(t *testing.T) {
+	t.Run("nominal case with height data", func(t *testing.T) {
 		t.Parallel()
 
+		header := mocks.GenericHeader
+		events := mocks.GenericEvents(2)
+
+		var written bool
 		write := mocks.BaselineWriter(t)
-		write.FirstFunc = func(uint64) error {
-			return mocks.GenericError
+		write.WriteHeightBatchFunc = func(_ context.Context, data dps.HeightData) error {
+			assert.Equal(t, mocks.GenericHeight, data.Height)
+			assert.Equal(t, header, data.Header)
+			assert.Equal(t, events, data.Events)
+			written = true
+			return nil
 		}
 
 		tr, st := baselineFSM(t, StatusForward)
 		tr.write = write
+		st.data = dps.HeightData{
+			Height: mocks.GenericHeight,
+			Header: header,
+			Events: events,
+		}
 
 		err := tr.ForwardHeight(st)
 
-		assert.Error(t, err)
+		require.NoError(t, err)
+		assert.True(t, written)
+		assert.Equal(t, dps.HeightData{}, st.data)
 	})
 
-	t.Run("handles writer error on last", func(t *testing.T) {
+	t.Run("handles writer error on height data", func(t *testing.T) {
 		t.Parallel()
 
 		write := mocks.BaselineWriter(t)
-		write.LastFunc = func(uint64) error {
+		write.WriteHeightBatchFunc = func(context.Context, dps.HeightData) error {
 			return mocks.GenericError
 		}
 
@@ -848,6 +1534,87 @@ func TestTransitions_ForwardHeight(t *testing.T) {
 		err := tr.ForwardHeight(st)
 
 		assert.Error(t, err)
+		assert.Equal(t, mocks.GenericHeight, st.height)
+	})
+
+	t.Run("nominal case with staging", func(t *testing.T) {
+		t.Parallel()
+
+		var staged bool
+		write := mocks.BaselineWriter(t)
+		write.WriteHeightBatchFunc = func(_ context.Context, data dps.HeightData) error {
+			assert.Equal(t, mocks.GenericHeight, data.Height)
+			assert.True(t, data.Complete)
+			staged = true
+			return nil
+		}
+
+		var settled bool
+		staging := mocks.BaselineStaging(t)
+		staging.SettleFunc = func() error {
+			assert.True(t, staged)
+			settled = true
+			return nil
+		}
+
+		tr, st := baselineFSM(t, StatusForward)
+		tr.write = write
+		tr.cfg.Staging = staging
+
+		err := tr.ForwardHeight(st)
+
+		require.NoError(t, err)
+		assert.True(t, settled)
+		assert.Equal(t, mocks.GenericHeight+1, st.height)
+	})
+
+	t.Run("handles conflicting seal", func(t *testing.T) {
+		t.Parallel()
+
+		staging := mocks.BaselineStaging(t)
+		staging.SettleFunc = func() error {
+			return dps.ErrConflict
+		}
+
+		tr, st := baselineFSM(t, StatusForward)
+		tr.cfg.Staging = staging
+
+		err := tr.ForwardHeight(st)
+
+		assert.ErrorIs(t, err, dps.ErrConflict)
+	})
+
+	t.Run("nominal case when reindexing", func(t *testing.T) {
+		t.Parallel()
+
+		var manifests int
+		write := mocks.BaselineWriter(t)
+		write.WriteHeightBatchFunc = func(_ context.Context, data dps.HeightData) error {
+			assert.Equal(t, mocks.GenericHeight+uint64(manifests), data.Height)
+			assert.True(t, data.Complete)
+			assert.False(t, data.First, "first height should not be indexed when reindexing")
+			assert.False(t, data.Last, "last height should not be indexed when reindexing")
+			manifests++
+			return nil
+		}
+
+		tr, st := baselineFSM(t, StatusForward)
+		tr.cfg.ReindexFrom = mocks.GenericHeight
+		tr.cfg.ReindexTo = mocks.GenericHeight + 1
+		tr.write = write
+
+		err := tr.ForwardHeight(st)
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusIndex, st.status)
+		assert.Equal(t, mocks.GenericHeight+1, st.height)
+
+		// Forwarding past the end of the range should finish the mapper.
+		st.status = StatusForward
+		err = tr.ForwardHeight(st)
+
+		assert.ErrorIs(t, err, dps.ErrFinished)
+		assert.Equal(t, 2, manifests)
 	})
 }
 
@@ -904,7 +1671,7 @@ func TestTransitions_ResumeIndexing(t *testing.T) {
 		}
 
 		writer := mocks.BaselineWriter(t)
-		writer.FirstFunc = func(height uint64) error {
+		writer.FirstFunc = func(_ context.Context, height uint64) error {
 			assert.Equal(t, header.Height, height)
 
 			return nil
@@ -916,10 +1683,10 @@ func TestTransitions_ResumeIndexing(t *testing.T) {
 		}
 
 		reader := mocks.BaselineReader(t)
-		reader.LastFunc = func() (uint64, error) {
+		reader.LastFunc = func(context.Context) (uint64, error) {
 			return header.Height, nil
 		}
-		reader.CommitFunc = func(height uint64) (flow.StateCommitment, error) {
+		reader.CommitFunc = func(_ context.Context, height uint64) (flow.StateCommitment, error) {
 			assert.Equal(t, header.Height, height)
 
 			return commit, nil
@@ -943,6 +1710,39 @@ func TestTransitions_ResumeIndexing(t *testing.T) {
 		assert.Equal(t, commit, st.next)
 	})
 
+	t.Run("nominal case without execution data", func(t *testing.T) {
+		t.Parallel()
+
+		loader := mocks.BaselineLoader(t)
+		loader.TrieFunc = func() (*trie.MTrie, error) {
+			t.Fatal("loader should not be used without execution data")
+			return nil, nil
+		}
+
+		reader := mocks.BaselineReader(t)
+		reader.LastFunc = func(context.Context) (uint64, error) {
+			return header.Height, nil
+		}
+		reader.CommitFunc = func(context.Context, uint64) (flow.StateCommitment, error) {
+			t.Fatal("commit should not be retrieved without execution data")
+			return flow.DummyStateCommitment, nil
+		}
+
+		tr, st := baselineFSM(
+			t,
+			StatusResume,
+			withReader(reader),
+			withLoader(loader),
+		)
+		tr.cfg.ProtocolOnly = true
+
+		err := tr.ResumeIndexing(st)
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusIndex, st.status)
+		assert.Equal(t, header.Height+1, st.height)
+	})
+
 	t.Run("handles chain failure on Root", func(t *testing.T) {
 		t.Parallel()
 
@@ -957,10 +1757,10 @@ func TestTransitions_ResumeIndexing(t *testing.T) {
 		}
 
 		reader := mocks.BaselineReader(t)
-		reader.LastFunc = func() (uint64, error) {
+		reader.LastFunc = func(context.Context) (uint64, error) {
 			return header.Height, nil
 		}
-		reader.CommitFunc = func(uint64) (flow.StateCommitment, error) {
+		reader.CommitFunc = func(context.Context, uint64) (flow.StateCommitment, error) {
 			return commit, nil
 		}
 
@@ -986,7 +1786,7 @@ func TestTransitions_ResumeIndexing(t *testing.T) {
 		}
 
 		writer := mocks.BaselineWriter(t)
-		writer.FirstFunc = func(uint64) error {
+		writer.FirstFunc = func(context.Context, uint64) error {
 			return mocks.GenericError
 		}
 
@@ -996,10 +1796,10 @@ func TestTransitions_ResumeIndexing(t *testing.T) {
 		}
 
 		reader := mocks.BaselineReader(t)
-		reader.LastFunc = func() (uint64, error) {
+		reader.LastFunc = func(context.Context) (uint64, error) {
 			return header.Height, nil
 		}
-		reader.CommitFunc = func(uint64) (flow.StateCommitment, error) {
+		reader.CommitFunc = func(context.Context, uint64) (flow.StateCommitment, error) {
 			return commit, nil
 		}
 
@@ -1031,10 +1831,10 @@ func TestTransitions_ResumeIndexing(t *testing.T) {
 		}
 
 		reader := mocks.BaselineReader(t)
-		reader.LastFunc = func() (uint64, error) {
+		reader.LastFunc = func(context.Context) (uint64, error) {
 			return 0, mocks.GenericError
 		}
-		reader.CommitFunc = func(uint64) (flow.StateCommitment, error) {
+		reader.CommitFunc = func(context.Context, uint64) (flow.StateCommitment, error) {
 			return commit, nil
 		}
 
@@ -1065,10 +1865,10 @@ func TestTransitions_ResumeIndexing(t *testing.T) {
 		}
 
 		reader := mocks.BaselineReader(t)
-		reader.LastFunc = func() (uint64, error) {
+		reader.LastFunc = func(context.Context) (uint64, error) {
 			return header.Height, nil
 		}
-		reader.CommitFunc = func(uint64) (flow.StateCommitment, error) {
+		reader.CommitFunc = func(context.Context, uint64) (flow.StateCommitment, error) {
 			return flow.DummyStateCommitment, mocks.GenericError
 		}
 
@@ -1099,10 +1899,10 @@ func TestTransitions_ResumeIndexing(t *testing.T) {
 		}
 
 		reader := mocks.BaselineReader(t)
-		reader.LastFunc = func() (uint64, error) {
+		reader.LastFunc = func(context.Context) (uint64, error) {
 			return header.Height, nil
 		}
-		reader.CommitFunc = func(uint64) (flow.StateCommitment, error) {
+		reader.CommitFunc = func(context.Context, uint64) (flow.StateCommitment, error) {
 			return commit, nil
 		}
 
@@ -1133,10 +1933,10 @@ func TestTransitions_ResumeIndexing(t *testing.T) {
 		}
 
 		reader := mocks.BaselineReader(t)
-		reader.LastFunc = func() (uint64, error) {
+		reader.LastFunc = func(context.Context) (uint64, error) {
 			return header.Height, nil
 		}
-		reader.CommitFunc = func(uint64) (flow.StateCommitment, error) {
+		reader.CommitFunc = func(context.Context, uint64) (flow.StateCommitment, error) {
 			return differentCommit, nil
 		}
 
@@ -1150,9 +1950,169 @@ func TestTransitions_ResumeIndexing(t *testing.T) {
 
 		err := tr.ResumeIndexing(st)
 
+		assert.ErrorIs(t, err, dps.ErrCorrupted)
+	})
+
+	t.Run("nominal case when reindexing", func(t *testing.T) {
+		t.Parallel()
+
+		chain := mocks.BaselineChain(t)
+		chain.RootFunc = func() (uint64, error) {
+			return header.Height - 10, nil
+		}
+
+		loader := mocks.BaselineLoader(t)
+		loader.TrieFunc = func() (*trie.MTrie, error) {
+			return tree, nil
+		}
+
+		reader := mocks.BaselineReader(t)
+		reader.LastFunc = func(context.Context) (uint64, error) {
+			return header.Height, nil
+		}
+		reader.CommitFunc = func(_ context.Context, height uint64) (flow.StateCommitment, error) {
+			assert.Equal(t, header.Height-5, height)
+
+			return commit, nil
+		}
+
+		tr, st := baselineFSM(
+			t,
+			StatusResume,
+			withReader(reader),
+			withLoader(loader),
+			withChain(chain),
+		)
+		tr.cfg.ReindexFrom = header.Height - 4
+		tr.cfg.ReindexTo = header.Height - 2
+
+		err := tr.ResumeIndexing(st)
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusIndex, st.status)
+		assert.Equal(t, header.Height-4, st.height)
+		assert.Equal(t, commit, st.next)
+	})
+
+	t.Run("handles invalid reindex range", func(t *testing.T) {
+		t.Parallel()
+
+		chain := mocks.BaselineChain(t)
+		chain.RootFunc = func() (uint64, error) {
+			return header.Height - 10, nil
+		}
+
+		reader := mocks.BaselineReader(t)
+		reader.LastFunc = func(context.Context) (uint64, error) {
+			return header.Height, nil
+		}
+
+		ranges := [][2]uint64{
+			{header.Height - 10, header.Height},    // includes root height
+			{header.Height - 4, header.Height + 1}, // goes past last height
+			{header.Height - 2, header.Height - 4}, // from after to
+		}
+		for _, r := range ranges {
+			tr, st := baselineFSM(
+				t,
+				StatusResume,
+				withReader(reader),
+				withChain(chain),
+			)
+			tr.cfg.ReindexFrom = r[0]
+			tr.cfg.ReindexTo = r[1]
+
+			err := tr.ResumeIndexing(st)
+
+			assert.Error(t, err)
+		}
+	})
+
+	t.Run("handles missing heights", func(t *testing.T) {
+		t.Parallel()
+
+		chain := mocks.BaselineChain(t)
+		chain.RootFunc = func() (uint64, error) {
+			return header.Height - 2, nil
+		}
+
+		reader := mocks.BaselineReader(t)
+		reader.LastFunc = func(context.Context) (uint64, error) {
+			return header.Height, nil
+		}
+
+		auditor := mocks.BaselineAuditor(t)
+		auditor.HeightsFunc = func(_ context.Context, from uint64, to uint64) ([]uint64, error) {
+			assert.Equal(t, header.Height-2, from)
+
+			return []uint64{header.Height - 2, header.Height}, nil
+		}
+
+		tr, st := baselineFSM(
+			t,
+			StatusResume,
+			withReader(reader),
+			withChain(chain),
+		)
+		tr.cfg.Auditor = auditor
+
+		err := tr.ResumeIndexing(st)
+
 		assert.Error(t, err)
 	})
 
+	t.Run("repairs missing heights", func(t *testing.T) {
+		t.Parallel()
+
+		chain := mocks.BaselineChain(t)
+		chain.RootFunc = func() (uint64, error) {
+			return header.Height - 2, nil
+		}
+
+		reader := mocks.BaselineReader(t)
+		reader.LastFunc = func(context.Context) (uint64, error) {
+			return header.Height, nil
+		}
+		reader.CommitFunc = func(context.Context, uint64) (flow.StateCommitment, error) {
+			return commit, nil
+		}
+
+		loader := mocks.BaselineLoader(t)
+		loader.TrieFunc = func() (*trie.MTrie, error) {
+			return tree, nil
+		}
+
+		auditor := mocks.BaselineAuditor(t)
+		auditor.HeightsFunc = func(context.Context, uint64, uint64) ([]uint64, error) {
+			return []uint64{header.Height - 2, header.Height}, nil
+		}
+
+		var repaired []uint64
+		writer := mocks.BaselineWriter(t)
+		writer.WriteHeightBatchFunc = func(_ context.Context, data dps.HeightData) error {
+			repaired = append(repaired, data.Height)
+			return nil
+		}
+
+		tr, st := baselineFSM(
+			t,
+			StatusResume,
+			withReader(reader),
+			withWriter(writer),
+			withLoader(loader),
+			withChain(chain),
+		)
+		tr.cfg.Auditor = auditor
+		tr.cfg.Repair = mocks.BaselineChain(t)
+
+		err := tr.ResumeIndexing(st)
+
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{header.Height - 1}, repaired)
+		assert.Equal(t, StatusIndex, st.status)
+		assert.Equal(t, header.Height+1, st.height)
+	})
+
 	t.Run("handles invalid status", func(t *testing.T) {
 		t.Parallel()
 
@@ -1167,10 +2127,10 @@ func TestTransitions_ResumeIndexing(t *testing.T) {
 		}
 
 		reader := mocks.BaselineReader(t)
-		reader.LastFunc = func() (uint64, error) {
+		reader.LastFunc = func(context.Context) (uint64, error) {
 			return header.Height, nil
 		}
-		reader.CommitFunc = func(uint64) (flow.StateCommitment, error) {
+		reader.CommitFunc = func(context.Context, uint64) (flow.StateCommitment, error) {
 			return commit, nil
 		}
 
@@ -1221,6 +2181,7 @@ func baselineFSM(t *testing.T, status Status, opts ...func(tr *Transitions)) (*T
 	}
 
 	st := State{
+		ctx:       context.Background(),
 		forest:    forest,
 		status:    status,
 		height:    mocks.GenericHeight,
@@ -20,6 +20,8 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
+
+	"github.com/optakt/flow-dps/service/logs"
 )
 
 // Server is the http server that will be serving the /metrics request for prometheus.
@@ -39,7 +41,7 @@ func NewServer(log zerolog.Logger, address string) *Server {
 			Addr:    address,
 			Handler: mux,
 		},
-		log: log,
+		log: log.With().Str(logs.Component, "metrics_server").Logger(),
 	}
 
 	return &m
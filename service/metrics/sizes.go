@@ -0,0 +1,78 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/optakt/flow-dps/service/storage"
+)
+
+// RegisterSizeMetrics registers the running size statistics of the given
+// storage library as metrics, labelled with the name of each key prefix. The
+// library should have size statistics enabled.
+func RegisterSizeMetrics(lib *storage.Library) error {
+	err := prometheus.Register(newSizeCollector(lib))
+	if err != nil {
+		return fmt.Errorf("failed to register size metrics: %w", err)
+	}
+	return nil
+}
+
+// sizeCollector reads the size statistics from the storage library whenever
+// metrics are collected, so that they are always up to date.
+type sizeCollector struct {
+	lib *storage.Library
+
+	keys       *prometheus.Desc
+	keyBytes   *prometheus.Desc
+	valueBytes *prometheus.Desc
+}
+
+func newSizeCollector(lib *storage.Library) *sizeCollector {
+
+	labels := []string{"prefix"}
+	s := sizeCollector{
+		lib: lib,
+
+		keys:       prometheus.NewDesc("index_keys", "number of keys stored in the index", labels, nil),
+		keyBytes:   prometheus.NewDesc("index_key_bytes", "number of bytes of the keys stored in the index", labels, nil),
+		valueBytes: prometheus.NewDesc("index_value_bytes", "number of bytes of the values stored in the index", labels, nil),
+	}
+
+	return &s
+}
+
+// Describe implements the `prometheus.Collector` interface.
+func (s *sizeCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- s.keys
+	descs <- s.keyBytes
+	descs <- s.valueBytes
+}
+
+// Collect implements the `prometheus.Collector` interface.
+func (s *sizeCollector) Collect(metrics chan<- prometheus.Metric) {
+	for _, stats := range s.lib.SizeStats() {
+		name, ok := storage.PrefixNames[stats.Prefix]
+		if !ok {
+			name = "unknown"
+		}
+		metrics <- prometheus.MustNewConstMetric(s.keys, prometheus.GaugeValue, float64(stats.Keys), name)
+		metrics <- prometheus.MustNewConstMetric(s.keyBytes, prometheus.GaugeValue, float64(stats.KeyBytes), name)
+		metrics <- prometheus.MustNewConstMetric(s.valueBytes, prometheus.GaugeValue, float64(stats.ValueBytes), name)
+	}
+}
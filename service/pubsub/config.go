@@ -0,0 +1,52 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package pubsub
+
+import (
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// DefaultConfig is the default configuration for the pub/sub streamer.
+var DefaultConfig = Config{
+	BufferSize: 256,
+	Archive:    nil,
+}
+
+// Config is the configuration for a pub/sub streamer.
+type Config struct {
+	BufferSize uint
+	Archive    func(flow.Identifier, []byte) error
+}
+
+// Option is a function that can be applied to a Config.
+type Option func(*Config)
+
+// WithBufferSize can be used to specify the maximum number of received
+// execution records that the pub/sub streamer holds on to, while waiting for
+// their blocks to be finalized.
+func WithBufferSize(size uint) Option {
+	return func(cfg *Config) {
+		cfg.BufferSize = size
+	}
+}
+
+// WithArchive injects the function used to archive the execution record of
+// each finalized block in its original encoding, before it is returned by the
+// streamer. Records of blocks that are not finalized are not archived.
+func WithArchive(archive func(flow.Identifier, []byte) error) Option {
+	return func(cfg *Config) {
+		cfg.Archive = archive
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gammazero/deque"
+	"github.com/rs/zerolog"
+
+	"github.com/onflow/flow-go/engine/execution/computation/computer/uploader"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/logs"
+	"github.com/optakt/flow-dps/service/records"
+)
+
+// Streamer is a component that receives block execution records over a
+// pub/sub topic, on which a cooperating execution node publishes them as soon
+// as it executes a block. Like the Google Cloud Streamer, it exposes a callback
+// to be used by the consensus follower to notify it when a new block has been
+// finalized, and it only returns the records of finalized blocks, in the order
+// in which they were finalized.
+type Streamer struct {
+	log     zerolog.Logger
+	decoder *records.Decoder
+	sub     Subscription
+	limit   uint                                // buffer size limit for received records
+	archive func(flow.Identifier, []byte) error // archives original records, if enabled
+
+	mutex   *sync.Mutex
+	queue   *deque.Deque                            // queue of finalized block identifiers
+	records map[flow.Identifier]*uploader.BlockData // received records by block identifier
+	raw     map[flow.Identifier][]byte              // original encoding of received records, if archived
+	last    uint64                                  // height of the last returned record
+}
+
+// NewStreamer returns a new pub/sub streamer reading from the given
+// subscription.
+func NewStreamer(log zerolog.Logger, sub Subscription, options ...Option) *Streamer {
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	s := Streamer{
+		log:     log.With().Str(logs.Component, "pubsub_streamer").Logger(),
+		decoder: records.NewDecoder(),
+		sub:     sub,
+		limit:   cfg.BufferSize,
+		archive: cfg.Archive,
+		mutex:   &sync.Mutex{},
+		queue:   deque.New(),
+		records: make(map[flow.Identifier]*uploader.BlockData),
+		raw:     make(map[flow.Identifier][]byte),
+		last:    0,
+	}
+
+	return &s
+}
+
+// Run receives execution records from the subscription until the given
+// context is canceled.
+func (s *Streamer) Run(ctx context.Context) error {
+
+	for {
+		msg, err := s.sub.Next(ctx)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not receive next message: %w", err)
+		}
+
+		// Messages on a pub/sub topic can come from any peer subscribed to
+		// it, so we only skip invalid records instead of failing.
+		record, err := s.decode(msg.Data)
+		if err != nil {
+			s.log.Warn().Err(err).Str("peer", msg.ReceivedFrom.String()).Msg("could not decode execution record, skipping")
+			continue
+		}
+
+		s.add(record, msg.Data)
+	}
+}
+
+// OnBlockFinalized is a callback for the Flow consensus follower. It is called
+// each time a block is finalized by the Flow consensus algorithm.
+func (s *Streamer) OnBlockFinalized(blockID flow.Identifier) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.queue.PushFront(blockID)
+
+	s.log.Debug().Hex(logs.BlockID, blockID[:]).Msg("execution record queued for streaming")
+}
+
+// Next returns the execution record of the next finalized block. It returns
+// an ErrUnavailable if the record was not received yet.
+func (s *Streamer) Next() (*uploader.BlockData, error) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.queue.Len() == 0 {
+		s.log.Debug().Msg("queue empty, no execution record available")
+		return nil, dps.ErrUnavailable
+	}
+
+	blockID := s.queue.Back().(flow.Identifier)
+	record, ok := s.records[blockID]
+	if !ok {
+		s.log.Debug().Hex(logs.BlockID, blockID[:]).Msg("next execution record not received yet")
+		return nil, dps.ErrUnavailable
+	}
+
+	// If enabled, the record is archived in its original encoding once its
+	// block is finalized, so that records of abandoned forks are not archived.
+	// If that fails, the record stays next in line.
+	if s.archive != nil {
+		err := s.archive(blockID, s.raw[blockID])
+		if err != nil {
+			return nil, fmt.Errorf("could not archive execution record (block: %x): %w", blockID, err)
+		}
+	}
+
+	s.queue.PopBack()
+	s.last = record.Block.Header.Height
+
+	// Once a block is finalized, the records of any other block at the same or
+	// a lower height belong to forks that were abandoned, so we can drop them.
+	for blockID, record := range s.records {
+		if record.Block.Header.Height <= s.last {
+			delete(s.records, blockID)
+			delete(s.raw, blockID)
+		}
+	}
+
+	return record, nil
+}
+
+func (s *Streamer) add(record *uploader.BlockData, data []byte) {
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	height := record.Block.Header.Height
+	blockID := record.Block.Header.ID()
+	if height <= s.last {
+		s.log.Debug().Uint64(logs.Height, height).Hex(logs.BlockID, blockID[:]).Msg("execution record already streamed, skipping")
+		return
+	}
+
+	s.records[blockID] = record
+	if s.archive != nil {
+		s.raw[blockID] = data
+	}
+
+	s.log.Debug().Uint64(logs.Height, height).Hex(logs.BlockID, blockID[:]).Msg("execution record received")
+
+	// If we are over the buffer size limit, we drop the record with the highest
+	// height, as the records with lower heights will be needed first.
+	if uint(len(s.records)) <= s.limit {
+		return
+	}
+	var highest *uploader.BlockData
+	for _, record := range s.records {
+		if highest == nil || record.Block.Header.Height > highest.Block.Header.Height {
+			highest = record
+		}
+	}
+	blockID = highest.Block.Header.ID()
+	delete(s.records, blockID)
+	delete(s.raw, blockID)
+
+	s.log.Warn().Uint("limit", s.limit).Hex(logs.BlockID, blockID[:]).Msg("buffer full, dropping execution record")
+}
+
+func (s *Streamer) decode(data []byte) (*uploader.BlockData, error) {
+
+	record, err := s.decoder.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode execution record: %w", err)
+	}
+
+	if record.FinalStateCommitment == flow.DummyStateCommitment {
+		return nil, fmt.Errorf("execution record contains empty state commitment")
+	}
+
+	if record.Block == nil || record.Block.Header == nil || record.Block.Header.Height == 0 {
+		return nil, fmt.Errorf("execution record contains empty block data")
+	}
+
+	return record, nil
+}
@@ -0,0 +1,298 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package pubsub
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/gammazero/deque"
+	p2p "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/engine/execution/computation/computer/uploader"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/records"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestNewStreamer(t *testing.T) {
+	sub := mocks.BaselineSubscription(t)
+
+	streamer := NewStreamer(zerolog.Nop(), sub, WithBufferSize(42))
+
+	require.NotNil(t, streamer)
+	assert.Equal(t, sub, streamer.sub)
+	assert.Equal(t, uint(42), streamer.limit)
+	assert.NotNil(t, streamer.mutex)
+	assert.NotNil(t, streamer.queue)
+	assert.NotNil(t, streamer.records)
+}
+
+func TestStreamer_Run(t *testing.T) {
+	record := mocks.GenericRecord()
+	data, err := cbor.Marshal(record)
+	require.NoError(t, err)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		sub := mocks.BaselineSubscription(t)
+		sub.NextFunc = func(ctx context.Context) (*p2p.Message, error) {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			defer cancel()
+			return &p2p.Message{Message: &pb.Message{Data: data}}, nil
+		}
+
+		streamer := baselineStreamer(t)
+		streamer.sub = sub
+
+		err := streamer.Run(ctx)
+
+		require.NoError(t, err)
+		assert.Contains(t, streamer.records, record.Block.Header.ID())
+	})
+
+	t.Run("skips invalid records", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		sub := mocks.BaselineSubscription(t)
+		sub.NextFunc = func(ctx context.Context) (*p2p.Message, error) {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			defer cancel()
+			return &p2p.Message{Message: &pb.Message{Data: mocks.GenericBytes}}, nil
+		}
+
+		streamer := baselineStreamer(t)
+		streamer.sub = sub
+
+		err := streamer.Run(ctx)
+
+		require.NoError(t, err)
+		assert.Empty(t, streamer.records)
+	})
+
+	t.Run("stops on canceled context", func(t *testing.T) {
+		t.Parallel()
+
+		sub := mocks.BaselineSubscription(t)
+		sub.NextFunc = func(ctx context.Context) (*p2p.Message, error) {
+			return nil, context.Canceled
+		}
+
+		streamer := baselineStreamer(t)
+		streamer.sub = sub
+
+		err := streamer.Run(context.Background())
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("handles subscription failure", func(t *testing.T) {
+		t.Parallel()
+
+		sub := mocks.BaselineSubscription(t)
+		sub.NextFunc = func(ctx context.Context) (*p2p.Message, error) {
+			return nil, mocks.GenericError
+		}
+
+		streamer := baselineStreamer(t)
+		streamer.sub = sub
+
+		err := streamer.Run(context.Background())
+
+		assert.Error(t, err)
+	})
+}
+
+func TestStreamer_OnBlockFinalized(t *testing.T) {
+	blockID := mocks.GenericHeader.ID()
+
+	streamer := baselineStreamer(t)
+
+	streamer.OnBlockFinalized(blockID)
+
+	require.Equal(t, 1, streamer.queue.Len())
+	assert.Equal(t, blockID, streamer.queue.PopBack())
+}
+
+func TestStreamer_Next(t *testing.T) {
+	record := mocks.GenericRecord()
+	blockID := record.Block.Header.ID()
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		// Add a record for a block at the same height on an abandoned fork.
+		header := *record.Block.Header
+		header.View++
+		fork := &uploader.BlockData{
+			Block: &flow.Block{Header: &header},
+		}
+
+		streamer := baselineStreamer(t)
+		streamer.queue.PushFront(blockID)
+		streamer.records[blockID] = record
+		streamer.records[header.ID()] = fork
+
+		got, err := streamer.Next()
+
+		require.NoError(t, err)
+		assert.Equal(t, record, got)
+		assert.Equal(t, 0, streamer.queue.Len())
+		assert.Empty(t, streamer.records)
+		assert.Equal(t, record.Block.Header.Height, streamer.last)
+	})
+
+	t.Run("archives record of finalized block", func(t *testing.T) {
+		t.Parallel()
+
+		var archived []byte
+		streamer := baselineStreamer(t)
+		streamer.archive = func(gotID flow.Identifier, data []byte) error {
+			assert.Equal(t, blockID, gotID)
+			archived = data
+			return nil
+		}
+		streamer.queue.PushFront(blockID)
+		streamer.records[blockID] = record
+		streamer.raw[blockID] = mocks.GenericBytes
+
+		got, err := streamer.Next()
+
+		require.NoError(t, err)
+		assert.Equal(t, record, got)
+		assert.Equal(t, mocks.GenericBytes, archived)
+		assert.Empty(t, streamer.raw)
+	})
+
+	t.Run("keeps record when archiving fails", func(t *testing.T) {
+		t.Parallel()
+
+		streamer := baselineStreamer(t)
+		streamer.archive = func(flow.Identifier, []byte) error {
+			return mocks.GenericError
+		}
+		streamer.queue.PushFront(blockID)
+		streamer.records[blockID] = record
+		streamer.raw[blockID] = mocks.GenericBytes
+
+		_, err := streamer.Next()
+
+		assert.ErrorIs(t, err, mocks.GenericError)
+		assert.Equal(t, 1, streamer.queue.Len())
+		assert.Contains(t, streamer.records, blockID)
+	})
+
+	t.Run("returns unavailable when no block finalized", func(t *testing.T) {
+		t.Parallel()
+
+		streamer := baselineStreamer(t)
+		streamer.records[blockID] = record
+
+		_, err := streamer.Next()
+
+		assert.ErrorIs(t, err, dps.ErrUnavailable)
+	})
+
+	t.Run("returns unavailable when record not received", func(t *testing.T) {
+		t.Parallel()
+
+		streamer := baselineStreamer(t)
+		streamer.queue.PushFront(blockID)
+
+		_, err := streamer.Next()
+
+		assert.ErrorIs(t, err, dps.ErrUnavailable)
+		assert.Equal(t, 1, streamer.queue.Len())
+	})
+}
+
+func TestStreamer_Add(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		record := mocks.GenericRecord()
+
+		streamer := baselineStreamer(t)
+		streamer.add(record, mocks.GenericBytes)
+
+		assert.Equal(t, record, streamer.records[record.Block.Header.ID()])
+	})
+
+	t.Run("skips records already streamed", func(t *testing.T) {
+		t.Parallel()
+
+		record := mocks.GenericRecord()
+
+		streamer := baselineStreamer(t)
+		streamer.last = record.Block.Header.Height
+		streamer.add(record, mocks.GenericBytes)
+
+		assert.Empty(t, streamer.records)
+	})
+
+	t.Run("drops highest record when buffer full", func(t *testing.T) {
+		t.Parallel()
+
+		low := mocks.GenericRecord()
+		header := *low.Block.Header
+		header.Height++
+		high := &uploader.BlockData{
+			Block: &flow.Block{Header: &header},
+		}
+
+		streamer := baselineStreamer(t)
+		streamer.limit = 1
+		streamer.add(low, mocks.GenericBytes)
+		streamer.add(high, mocks.GenericBytes)
+
+		assert.Len(t, streamer.records, 1)
+		assert.Contains(t, streamer.records, low.Block.Header.ID())
+	})
+}
+
+func baselineStreamer(t *testing.T) *Streamer {
+	t.Helper()
+
+	s := Streamer{
+		log:     zerolog.Nop(),
+		decoder: records.NewDecoder(),
+		sub:     mocks.BaselineSubscription(t),
+		limit:   DefaultConfig.BufferSize,
+		mutex:   &sync.Mutex{},
+		queue:   deque.New(),
+		records: make(map[flow.Identifier]*uploader.BlockData),
+		raw:     make(map[flow.Identifier][]byte),
+		last:    0,
+	}
+
+	return &s
+}
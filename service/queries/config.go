@@ -0,0 +1,36 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package queries
+
+// DefaultConfig is the default configuration for the query recorder.
+var DefaultConfig = Config{
+	Slowest: 10,
+}
+
+// Config is the configuration for the query recorder.
+type Config struct {
+	Slowest uint
+}
+
+// Option is a function that can be applied to a Config.
+type Option func(*Config)
+
+// WithSlowest sets the number of slowest individual queries that are included
+// in the statistics.
+func WithSlowest(slowest uint) Option {
+	return func(cfg *Config) {
+		cfg.Slowest = slowest
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package queries
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Recorder keeps the records of the most recent API requests in a ring buffer
+// of fixed size, and computes statistics over them on demand. This lets
+// operators identify expensive query patterns and abusive clients without
+// having to rely on external tracing infrastructure.
+type Recorder struct {
+	mutex   *sync.Mutex
+	queries []dps.Query
+	next    int
+	full    bool
+	slowest int
+}
+
+// NewRecorder creates a new recorder that keeps the records of the given
+// number of most recent API requests.
+func NewRecorder(size uint, options ...Option) *Recorder {
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	r := Recorder{
+		mutex:   &sync.Mutex{},
+		queries: make([]dps.Query, size),
+		slowest: int(cfg.Slowest),
+	}
+
+	return &r
+}
+
+// Record adds the given query to the ring buffer, replacing the oldest one if
+// the buffer is full.
+func (r *Recorder) Record(query dps.Query) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if len(r.queries) == 0 {
+		return
+	}
+
+	r.queries[r.next] = query
+	r.next++
+	if r.next == len(r.queries) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// Stats computes the statistics over the queries that are currently held in
+// the ring buffer.
+func (r *Recorder) Stats() dps.QueryStats {
+
+	r.mutex.Lock()
+	queries := r.queries[:r.next]
+	if r.full {
+		queries = r.queries
+	}
+	queries = append([]dps.Query(nil), queries...)
+	r.mutex.Unlock()
+
+	stats := dps.QueryStats{
+		Queries: uint64(len(queries)),
+		Methods: []dps.MethodStats{},
+		Slowest: []dps.QueryRecord{},
+	}
+	if len(queries) == 0 {
+		return stats
+	}
+
+	// Sorting the queries by duration once gives us both the slowest queries
+	// and the latencies of each method in order, which we need to determine
+	// their percentiles.
+	sort.Slice(queries, func(i int, j int) bool {
+		return queries[i].Duration < queries[j].Duration
+	})
+
+	stats.Since = queries[0].Start
+	lookup := make(map[string]int)
+	latencies := make(map[string][]float64)
+	for _, query := range queries {
+		if query.Start.Before(stats.Since) {
+			stats.Since = query.Start
+		}
+
+		index, ok := lookup[query.Method]
+		if !ok {
+			index = len(stats.Methods)
+			lookup[query.Method] = index
+			stats.Methods = append(stats.Methods, dps.MethodStats{Method: query.Method})
+		}
+
+		method := &stats.Methods[index]
+		method.Count++
+		if query.Failed {
+			method.Failed++
+		}
+		method.Rows += query.Rows
+		if query.Rows > method.MaxRows {
+			method.MaxRows = query.Rows
+		}
+		method.Bytes += query.Bytes
+
+		latencies[query.Method] = append(latencies[query.Method], query.Duration.Seconds())
+	}
+
+	for i := range stats.Methods {
+		method := &stats.Methods[i]
+		sorted := latencies[method.Method]
+		method.P50 = percentile(sorted, 0.50)
+		method.P90 = percentile(sorted, 0.90)
+		method.P99 = percentile(sorted, 0.99)
+		method.Max = sorted[len(sorted)-1]
+	}
+
+	sort.Slice(stats.Methods, func(i int, j int) bool {
+		return stats.Methods[i].Method < stats.Methods[j].Method
+	})
+
+	for i := len(queries) - 1; i >= 0 && len(stats.Slowest) < r.slowest; i-- {
+		query := queries[i]
+		record := dps.QueryRecord{
+			Method:  query.Method,
+			Peer:    query.Peer,
+			Start:   query.Start,
+			Latency: query.Duration.Seconds(),
+			Rows:    query.Rows,
+			Bytes:   query.Bytes,
+			Failed:  query.Failed,
+		}
+		stats.Slowest = append(stats.Slowest, record)
+	}
+
+	return stats
+}
+
+// percentile returns the value at the given percentile of the given sorted
+// values, using the nearest-rank method.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
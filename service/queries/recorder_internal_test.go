@@ -0,0 +1,137 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package queries
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+func TestNewRecorder(t *testing.T) {
+	r := NewRecorder(8, WithSlowest(3))
+
+	assert.Len(t, r.queries, 8)
+	assert.Equal(t, 3, r.slowest)
+	assert.Zero(t, r.next)
+	assert.False(t, r.full)
+}
+
+func TestRecorder_Record(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRecorder(4)
+		r.Record(dps.Query{Method: "a"})
+		r.Record(dps.Query{Method: "b"})
+
+		assert.Equal(t, 2, r.next)
+		assert.False(t, r.full)
+	})
+
+	t.Run("replaces oldest queries", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRecorder(2)
+		r.Record(dps.Query{Method: "a"})
+		r.Record(dps.Query{Method: "b"})
+		r.Record(dps.Query{Method: "c"})
+
+		assert.True(t, r.full)
+		assert.Equal(t, 1, r.next)
+		assert.Equal(t, "c", r.queries[0].Method)
+		assert.Equal(t, "b", r.queries[1].Method)
+	})
+
+	t.Run("handles zero size", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRecorder(0)
+
+		assert.NotPanics(t, func() { r.Record(dps.Query{Method: "a"}) })
+		assert.Zero(t, r.Stats().Queries)
+	})
+}
+
+func TestRecorder_Stats(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		start := time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC)
+		r := NewRecorder(200, WithSlowest(2))
+		for i := 1; i <= 100; i++ {
+			r.Record(dps.Query{
+				Method:   "/dps.API/GetEvents",
+				Peer:     "127.0.0.1:1234",
+				Start:    start.Add(time.Duration(i) * time.Second),
+				Duration: time.Duration(i) * time.Millisecond,
+				Rows:     uint64(i),
+				Bytes:    10,
+			})
+		}
+		r.Record(dps.Query{
+			Method:   "/dps.API/GetHeader",
+			Start:    start,
+			Duration: time.Second,
+			Failed:   true,
+		})
+
+		stats := r.Stats()
+
+		assert.Equal(t, uint64(101), stats.Queries)
+		assert.Equal(t, start, stats.Since)
+		require.Len(t, stats.Methods, 2)
+
+		events := stats.Methods[0]
+		assert.Equal(t, "/dps.API/GetEvents", events.Method)
+		assert.Equal(t, uint64(100), events.Count)
+		assert.Zero(t, events.Failed)
+		assert.InDelta(t, 0.050, events.P50, 1e-9)
+		assert.InDelta(t, 0.090, events.P90, 1e-9)
+		assert.InDelta(t, 0.099, events.P99, 1e-9)
+		assert.InDelta(t, 0.100, events.Max, 1e-9)
+		assert.Equal(t, uint64(5050), events.Rows)
+		assert.Equal(t, uint64(100), events.MaxRows)
+		assert.Equal(t, uint64(1000), events.Bytes)
+
+		header := stats.Methods[1]
+		assert.Equal(t, "/dps.API/GetHeader", header.Method)
+		assert.Equal(t, uint64(1), header.Failed)
+		assert.InDelta(t, 1.0, header.P50, 1e-9)
+
+		require.Len(t, stats.Slowest, 2)
+		assert.Equal(t, "/dps.API/GetHeader", stats.Slowest[0].Method)
+		assert.True(t, stats.Slowest[0].Failed)
+		assert.Equal(t, "/dps.API/GetEvents", stats.Slowest[1].Method)
+		assert.Equal(t, "127.0.0.1:1234", stats.Slowest[1].Peer)
+		assert.InDelta(t, 0.100, stats.Slowest[1].Latency, 1e-9)
+	})
+
+	t.Run("handles empty recorder", func(t *testing.T) {
+		t.Parallel()
+
+		r := NewRecorder(10)
+
+		stats := r.Stats()
+
+		assert.Zero(t, stats.Queries)
+		assert.Empty(t, stats.Methods)
+		assert.Empty(t, stats.Slowest)
+	})
+}
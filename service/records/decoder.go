@@ -0,0 +1,125 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package records
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/onflow/flow-go/engine/execution/computation/computer/uploader"
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// ErrUnsupportedVersion is returned when an execution record does not match
+// the model of any of the supported flow-go releases.
+var ErrUnsupportedVersion = errors.New("unsupported record version")
+
+// Decoder decodes the CBOR-encoded execution records that execution nodes
+// upload. Within a spork, execution nodes can run different flow-go releases,
+// whose models of the records can add or remove fields. The decoder maps the
+// fields of each record to the model of the release that wrote it, and fails
+// with a precise error for records that do not match any known model, instead
+// of a generic CBOR error.
+type Decoder struct {
+	decoder cbor.DecMode
+	schemas []schema
+}
+
+// NewDecoder returns a new decoder for execution records.
+func NewDecoder() *Decoder {
+
+	// Unknown fields within the fields of a record are still rejected, as
+	// ignoring them could change the identifiers computed from the decoded
+	// values, such as the block ID computed from the header.
+	decOptions := cbor.DecOptions{
+		ExtraReturnErrors: cbor.ExtraDecErrorUnknownField,
+	}
+	decoder, err := decOptions.DecMode()
+	if err != nil {
+		panic(err)
+	}
+
+	d := Decoder{
+		decoder: decoder,
+		schemas: schemas,
+	}
+
+	return &d
+}
+
+// Decode decodes the given execution record.
+func (d *Decoder) Decode(data []byte) (*uploader.BlockData, error) {
+
+	var fields map[string]cbor.RawMessage
+	err := d.decoder.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode execution record fields: %w", err)
+	}
+
+	// We look for the most recent schema that the fields of the record match,
+	// so that the record is decoded with the model that wrote it.
+	var match *schema
+	for i := len(d.schemas) - 1; i >= 0; i-- {
+		if d.schemas[i].matches(fields) {
+			match = &d.schemas[i]
+			break
+		}
+	}
+	if match == nil {
+		latest := d.schemas[len(d.schemas)-1]
+		return nil, fmt.Errorf("%w (block: %s, unknown fields: [%s], missing fields: [%s])",
+			ErrUnsupportedVersion, d.identify(fields), strings.Join(latest.unknown(fields), ","), strings.Join(latest.missing(fields), ","))
+	}
+
+	var record uploader.BlockData
+	for name, raw := range fields {
+		err = d.decoder.Unmarshal(raw, match.fields[name](&record))
+		var unknown *cbor.UnknownFieldError
+		if errors.As(err, &unknown) {
+			return nil, fmt.Errorf("%w (block: %s, version: %d, field: %s): %s", ErrUnsupportedVersion, d.identify(fields), match.version, name, err)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not decode execution record field (version: %d, field: %s): %w", match.version, name, err)
+		}
+	}
+
+	return &record, nil
+}
+
+// identify returns the hex-encoded ID of the block of the given record fields,
+// if its block can be decoded at all.
+func (d *Decoder) identify(fields map[string]cbor.RawMessage) string {
+	raw, ok := fields[fieldBlock]
+	if !ok {
+		return "unknown"
+	}
+	var block flow.Block
+	err := d.decoder.Unmarshal(raw, &block)
+	if err != nil || block.Header == nil {
+		return "unknown"
+	}
+	return block.ID().String()
+}
+
+// sorted returns the given field names in alphabetical order, so that errors
+// are deterministic.
+func sorted(names []string) []string {
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package records_test
+
+import (
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps/service/records"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestDecoder_Decode(t *testing.T) {
+	record := mocks.GenericRecord()
+	blockID := record.Block.ID()
+
+	// fields returns the encoded fields of the generic record, so that test
+	// cases can add, remove or change fields before encoding it again.
+	fields := func(t *testing.T) map[string]cbor.RawMessage {
+		t.Helper()
+		data, err := cbor.Marshal(record)
+		require.NoError(t, err)
+		var fields map[string]cbor.RawMessage
+		err = cbor.Unmarshal(data, &fields)
+		require.NoError(t, err)
+		return fields
+	}
+	encode := func(t *testing.T, value interface{}) []byte {
+		t.Helper()
+		data, err := cbor.Marshal(value)
+		require.NoError(t, err)
+		return data
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := records.NewDecoder().Decode(encode(t, record))
+
+		require.NoError(t, err)
+		assert.Equal(t, record, got)
+	})
+
+	t.Run("nominal case with removed optional field", func(t *testing.T) {
+		t.Parallel()
+
+		data := fields(t)
+		delete(data, "Events")
+
+		got, err := records.NewDecoder().Decode(encode(t, data))
+
+		require.NoError(t, err)
+		assert.Nil(t, got.Events)
+		assert.Equal(t, record.Block, got.Block)
+		assert.Equal(t, record.TrieUpdates, got.TrieUpdates)
+	})
+
+	t.Run("handles unknown field", func(t *testing.T) {
+		t.Parallel()
+
+		data := fields(t)
+		data["ExecutionDataID"] = encode(t, mocks.GenericBytes)
+
+		_, err := records.NewDecoder().Decode(encode(t, data))
+
+		require.ErrorIs(t, err, records.ErrUnsupportedVersion)
+		assert.Contains(t, err.Error(), blockID.String())
+		assert.Contains(t, err.Error(), "ExecutionDataID")
+	})
+
+	t.Run("handles missing required field", func(t *testing.T) {
+		t.Parallel()
+
+		data := fields(t)
+		delete(data, "FinalStateCommitment")
+
+		_, err := records.NewDecoder().Decode(encode(t, data))
+
+		require.ErrorIs(t, err, records.ErrUnsupportedVersion)
+		assert.Contains(t, err.Error(), blockID.String())
+		assert.Contains(t, err.Error(), "FinalStateCommitment")
+	})
+
+	t.Run("handles unknown field within field", func(t *testing.T) {
+		t.Parallel()
+
+		data := fields(t)
+		var block map[string]cbor.RawMessage
+		err := cbor.Unmarshal(data["Block"], &block)
+		require.NoError(t, err)
+		block["Extension"] = encode(t, mocks.GenericBytes)
+		data["Block"] = encode(t, block)
+
+		_, err = records.NewDecoder().Decode(encode(t, data))
+
+		require.ErrorIs(t, err, records.ErrUnsupportedVersion)
+		assert.Contains(t, err.Error(), "field: Block")
+	})
+
+	t.Run("handles invalid data", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := records.NewDecoder().Decode(mocks.GenericBytes)
+
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, records.ErrUnsupportedVersion)
+	})
+}
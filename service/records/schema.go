@@ -0,0 +1,92 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package records
+
+import (
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/onflow/flow-go/engine/execution/computation/computer/uploader"
+)
+
+// Names of the fields of execution records.
+const (
+	fieldBlock       = "Block"
+	fieldCollections = "Collections"
+	fieldTxResults   = "TxResults"
+	fieldEvents      = "Events"
+	fieldTrieUpdates = "TrieUpdates"
+	fieldFinalCommit = "FinalStateCommitment"
+)
+
+// schema describes the model of the execution records written by a range of
+// flow-go releases. It maps the name of each field of the model to where its
+// value is decoded in the block data, and lists the fields without which a
+// record can not be indexed. Other fields can be missing, for example because
+// a release removed them, in which case they are left empty.
+type schema struct {
+	version  uint
+	required []string
+	fields   map[string]func(*uploader.BlockData) interface{}
+}
+
+// schemas are the supported models of execution records, from oldest to most
+// recent. A new schema should be added whenever a flow-go release changes the
+// fields of the uploaded records.
+var schemas = []schema{
+	{
+		// The model of the records uploaded since flow-go v0.21.
+		version:  1,
+		required: []string{fieldBlock, fieldFinalCommit},
+		fields: map[string]func(*uploader.BlockData) interface{}{
+			fieldBlock:       func(r *uploader.BlockData) interface{} { return &r.Block },
+			fieldCollections: func(r *uploader.BlockData) interface{} { return &r.Collections },
+			fieldTxResults:   func(r *uploader.BlockData) interface{} { return &r.TxResults },
+			fieldEvents:      func(r *uploader.BlockData) interface{} { return &r.Events },
+			fieldTrieUpdates: func(r *uploader.BlockData) interface{} { return &r.TrieUpdates },
+			fieldFinalCommit: func(r *uploader.BlockData) interface{} { return &r.FinalStateCommitment },
+		},
+	},
+}
+
+// matches returns whether the given record fields match the schema.
+func (s schema) matches(fields map[string]cbor.RawMessage) bool {
+	return len(s.unknown(fields)) == 0 && len(s.missing(fields)) == 0
+}
+
+// unknown returns the names of the given record fields that are not part of
+// the schema.
+func (s schema) unknown(fields map[string]cbor.RawMessage) []string {
+	var names []string
+	for name := range fields {
+		_, ok := s.fields[name]
+		if !ok {
+			names = append(names, name)
+		}
+	}
+	return sorted(names)
+}
+
+// missing returns the names of the required fields of the schema that are
+// missing from the given record fields.
+func (s schema) missing(fields map[string]cbor.RawMessage) []string {
+	var names []string
+	for _, name := range s.required {
+		_, ok := fields[name]
+		if !ok {
+			names = append(names, name)
+		}
+	}
+	return sorted(names)
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package replica
+
+import (
+	"time"
+)
+
+// DefaultConfig is the default configuration for the replicator.
+var DefaultConfig = Config{
+	Interval:  time.Second,
+	BatchSize: 16 * 1024 * 1024,
+	MaxLag:    1000,
+	Fence:     0,
+	Namespace: "",
+}
+
+// Config is the configuration for the replicator.
+type Config struct {
+	Interval  time.Duration
+	BatchSize uint64
+	MaxLag    uint64
+	Fence     uint64
+	Namespace string
+}
+
+// Option is a function that can be applied to a Config.
+type Option func(*Config)
+
+// WithInterval sets the interval at which the replicator checks the replication
+// log for writes to replicate.
+func WithInterval(interval time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.Interval = interval
+	}
+}
+
+// WithBatchSize sets the size in bytes above which the replicator uploads the
+// writes it collected as a batch, even if the height is not complete yet.
+func WithBatchSize(size uint64) Option {
+	return func(cfg *Config) {
+		cfg.BatchSize = size
+	}
+}
+
+// WithMaxLag sets the number of heights by which the secondary index can lag
+// behind the index before the replicator raises an alarm. Zero disables the
+// alarm.
+func WithMaxLag(lag uint64) Option {
+	return func(cfg *Config) {
+		cfg.MaxLag = lag
+	}
+}
+
+// WithFence sets the fencing token that the replicator checks before trimming
+// the replication log, so that it stops when its index writer was fenced off by
+// another instance.
+func WithFence(token uint64) Option {
+	return func(cfg *Config) {
+		cfg.Fence = token
+	}
+}
+
+// WithNamespace sets the namespace of the index whose writes are replicated.
+// The batches of each namespace are kept apart in the store, so that the
+// namespaces of an index can share a secondary index.
+func WithNamespace(namespace string) Option {
+	return func(cfg *Config) {
+		cfg.Namespace = namespace
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package replica
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	replicaHeight = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "replica_height",
+		Help: "last indexed height that was replicated to the secondary index",
+	})
+
+	replicaLag = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "replica_lag_heights",
+		Help: "number of indexed heights that were not replicated to the secondary index yet",
+	})
+
+	replicaLagging = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "replica_lagging",
+		Help: "whether the secondary index lags behind the index by more than the maximum lag",
+	})
+
+	replicaBatches = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "replica_batches_total",
+		Help: "number of batches of writes replicated to the secondary index",
+	})
+
+	replicaBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "replica_bytes_total",
+		Help: "number of bytes uploaded to the secondary index",
+	})
+
+	replicaDivergences = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "replica_divergences_total",
+		Help: "number of batches that read back differently from the secondary index than they were written",
+	})
+)
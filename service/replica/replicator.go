@@ -0,0 +1,319 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package replica
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/rs/zerolog"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/logs"
+	"github.com/optakt/flow-dps/service/tier"
+)
+
+// batchEntries is the maximum number of writes in a batch, which bounds the
+// size of the transaction that trims them from the replication log.
+const batchEntries = 10_000
+
+// Replicator replicates the writes to the index to a secondary index in object
+// storage, for disaster recovery. It reads the writes from the replication log
+// of the index, uploads them in batches that follow the indexed heights, reads
+// each batch back to verify it and then trims it from the log. The secondary
+// index can be rebuilt by applying the batches in order.
+type Replicator struct {
+	log   zerolog.Logger
+	db    *badger.DB
+	lib   dps.Library
+	codec dps.Codec
+	store tier.Store
+	cfg   Config
+	gap   uint64 // sequence number of the write the last pass waited for
+	stop  chan struct{}
+	wg    *sync.WaitGroup
+}
+
+// NewReplicator returns a new replicator, which replicates the writes to the
+// index in the given database to the given store.
+func NewReplicator(log zerolog.Logger, db *badger.DB, lib dps.Library, codec dps.Codec, store tier.Store, options ...Option) *Replicator {
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	r := Replicator{
+		log:   log.With().Str(logs.Component, "replicator").Logger(),
+		db:    db,
+		lib:   lib,
+		codec: codec,
+		store: store,
+		cfg:   cfg,
+		stop:  make(chan struct{}),
+		wg:    &sync.WaitGroup{},
+	}
+
+	return &r
+}
+
+// Run replicates the writes to the index at the configured interval, until the
+// replicator is stopped. Failures to replicate are retried at the next
+// interval, so that an unavailable secondary index does not stop indexing; the
+// writes are queued in the replication log in the meantime.
+func (r *Replicator) Run() error {
+	r.wg.Add(1)
+	defer r.wg.Done()
+
+	for {
+		err := r.replicate()
+		if errors.Is(err, dps.ErrFinished) {
+			return nil
+		}
+		if errors.Is(err, dps.ErrFenced) {
+			return fmt.Errorf("could not replicate index writes: %w", err)
+		}
+		if err != nil {
+			r.log.Warn().Err(err).Msg("could not replicate index writes")
+		}
+
+		select {
+		case <-r.stop:
+			return nil
+		case <-time.After(r.cfg.Interval):
+			// continue
+		}
+	}
+}
+
+// Stop gracefully stops the replicator, which finishes uploading the batch
+// that it is currently replicating.
+func (r *Replicator) Stop() error {
+	close(r.stop)
+	r.wg.Wait()
+	return nil
+}
+
+func (r *Replicator) replicate() error {
+
+	var state dps.ReplicaState
+	err := r.db.View(r.lib.RetrieveReplicaState(&state))
+	if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+		return fmt.Errorf("could not retrieve replica state: %w", err)
+	}
+
+	for {
+		batch, waiting, err := r.collect(state.Sequence + 1)
+		if err != nil {
+			return fmt.Errorf("could not collect batch: %w", err)
+		}
+		if len(batch.Entries) == 0 {
+			break
+		}
+
+		err = r.flush(batch)
+		if err != nil {
+			return fmt.Errorf("could not flush batch (first: %d): %w", batch.First, err)
+		}
+
+		// Once the batch is safely in the secondary index, we remove its
+		// writes from the replication log, along with any gap before it.
+		from := state.Sequence + 1
+		state.Sequence = batch.Last
+		if batch.Height != 0 {
+			state.Height = batch.Height
+		}
+		err = r.update(r.lib.TrimReplicaLog(from, state))
+		if err != nil {
+			return fmt.Errorf("could not trim replication log: %w", err)
+		}
+
+		replicaHeight.Set(float64(state.Height))
+
+		if waiting {
+			break
+		}
+
+		select {
+		case <-r.stop:
+			return dps.ErrFinished
+		default:
+			// continue
+		}
+	}
+
+	return r.observe(state)
+}
+
+// collect collects the next batch of writes from the replication log, starting
+// at the given sequence number. It also returns whether it stopped at a gap in
+// the sequence numbers that it waits for.
+func (r *Replicator) collect(from uint64) (dps.ReplicaBatch, bool, error) {
+
+	batch := dps.ReplicaBatch{
+		First: from,
+	}
+	expected := from
+	waiting := false
+	var size uint64
+	err := r.db.View(r.lib.IterateReplicaLog(context.Background(), from, func(sequence uint64, entry dps.ReplicaEntry) (bool, error) {
+
+		// A gap in the sequence numbers is a write whose transaction was not
+		// committed yet, or whose transaction was discarded. We wait for it
+		// for one interval, and skip it if it is still missing afterwards.
+		if sequence != expected {
+			if r.gap != expected {
+				r.gap = expected
+				waiting = true
+				return false, nil
+			}
+			r.log.Warn().
+				Uint64("from", expected).
+				Uint64("to", sequence-1).
+				Msg("skipping missing writes in replication log")
+		}
+		if len(batch.Entries) == 0 {
+			batch.First = sequence
+		}
+		expected = sequence + 1
+
+		batch.Last = sequence
+		batch.Entries = append(batch.Entries, entry)
+		size += uint64(len(entry.Key) + len(entry.Value))
+
+		// Each batch ends with the write that completes a height, so that the
+		// secondary index always holds complete heights once a batch is in.
+		if entry.Height != 0 {
+			batch.Height = entry.Height
+			return false, nil
+		}
+
+		return size < r.cfg.BatchSize && len(batch.Entries) < batchEntries, nil
+	}))
+	if err != nil {
+		return dps.ReplicaBatch{}, false, err
+	}
+
+	return batch, waiting, nil
+}
+
+// flush uploads the given batch to the store, and reads it back to verify that
+// the secondary index holds the same writes as the index.
+func (r *Replicator) flush(batch dps.ReplicaBatch) error {
+
+	data, err := r.codec.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("could not encode batch: %w", err)
+	}
+
+	name := batchName(r.cfg.Namespace, batch.First)
+	err = r.store.Upload(name, data)
+	if err != nil {
+		return fmt.Errorf("could not upload batch: %w", err)
+	}
+
+	check, err := r.store.Download(name, 0, uint64(len(data)))
+	if err != nil {
+		return fmt.Errorf("could not download batch: %w", err)
+	}
+	if !bytes.Equal(check, data) {
+		replicaDivergences.Inc()
+		r.log.Error().
+			Str("name", name).
+			Uint64("first", batch.First).
+			Uint64("last", batch.Last).
+			Msg("secondary index diverges from index")
+		return fmt.Errorf("batch diverges after upload (name: %s)", name)
+	}
+
+	replicaBatches.Inc()
+	replicaBytes.Add(float64(len(data)))
+
+	r.log.Debug().
+		Uint64("first", batch.First).
+		Uint64("last", batch.Last).
+		Uint64(logs.Height, batch.Height).
+		Int("entries", len(batch.Entries)).
+		Msg("batch replicated to secondary index")
+
+	return nil
+}
+
+// observe updates the lag of the secondary index behind the index, and raises
+// an alarm when it lags behind by more than the maximum lag.
+func (r *Replicator) observe(state dps.ReplicaState) error {
+
+	var last uint64
+	err := r.db.View(r.lib.RetrieveLast(&last))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not retrieve last height: %w", err)
+	}
+
+	var lag uint64
+	if last > state.Height {
+		lag = last - state.Height
+	}
+	replicaLag.Set(float64(lag))
+
+	if r.cfg.MaxLag == 0 || lag <= r.cfg.MaxLag {
+		replicaLagging.Set(0)
+		return nil
+	}
+
+	replicaLagging.Set(1)
+	r.log.Error().
+		Uint64("last", last).
+		Uint64("replicated", state.Height).
+		Uint64("lag", lag).
+		Msg("secondary index lags behind index")
+
+	return nil
+}
+
+// update applies the given operation in a transaction, which also checks the
+// fence when fencing is enabled. When the transaction conflicts with one of the
+// index writer, it is applied again, as trimming the log is idempotent.
+func (r *Replicator) update(op func(*badger.Txn) error) error {
+	for {
+		err := r.db.Update(func(tx *badger.Txn) error {
+			if r.cfg.Fence != 0 {
+				err := r.lib.CheckFence(r.cfg.Fence)(tx)
+				if err != nil {
+					return err
+				}
+			}
+			return op(tx)
+		})
+		if errors.Is(err, badger.ErrConflict) {
+			continue
+		}
+		return err
+	}
+}
+
+// batchName returns the name of the object holding the batch of writes that
+// starts at the given sequence number.
+func batchName(namespace string, first uint64) string {
+	return path.Join(namespace, fmt.Sprintf("%016d.batch", first))
+}
@@ -0,0 +1,169 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package replica
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/storage"
+	"github.com/optakt/flow-dps/testing/helpers"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestReplicator_Replicate(t *testing.T) {
+	header := mocks.GenericHeader
+
+	// setup indexes a header and two heights with replication enabled.
+	setup := func(t *testing.T) (*badger.DB, *storage.Library) {
+		t.Helper()
+
+		db := helpers.InMemoryDB(t)
+		lib := storage.New(zbor.NewCodec(), storage.WithReplication(true))
+		require.NoError(t, db.View(lib.LoadReplicaLog()))
+
+		require.NoError(t, db.Update(lib.SaveHeader(header.Height, header)))
+		require.NoError(t, db.Update(lib.SaveLast(header.Height)))
+		require.NoError(t, db.Update(lib.SaveLast(header.Height+1)))
+
+		return db, lib
+	}
+
+	// memory returns a store that keeps uploaded objects in memory.
+	memory := func(t *testing.T) (*mocks.Store, map[string][]byte) {
+		t.Helper()
+
+		var mutex sync.Mutex
+		objects := make(map[string][]byte)
+		store := mocks.BaselineStore(t)
+		store.UploadFunc = func(name string, data []byte) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			objects[name] = data
+			return nil
+		}
+		store.DownloadFunc = func(name string, offset uint64, length uint64) ([]byte, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return objects[name][offset : offset+length], nil
+		}
+
+		return store, objects
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setup(t)
+		defer db.Close()
+		store, objects := memory(t)
+		codec := zbor.NewCodec()
+
+		r := NewReplicator(zerolog.Nop(), db, lib, codec, store, WithNamespace("test"))
+		require.NoError(t, r.replicate())
+
+		// Each batch ends with the height that it completes.
+		require.Len(t, objects, 2)
+		var first dps.ReplicaBatch
+		require.NoError(t, codec.Unmarshal(objects["test/0000000000000001.batch"], &first))
+		assert.Equal(t, uint64(1), first.First)
+		assert.Equal(t, uint64(2), first.Last)
+		assert.Equal(t, header.Height, first.Height)
+		assert.Len(t, first.Entries, 2)
+
+		var second dps.ReplicaBatch
+		require.NoError(t, codec.Unmarshal(objects["test/0000000000000003.batch"], &second))
+		assert.Equal(t, uint64(3), second.First)
+		assert.Equal(t, uint64(3), second.Last)
+		assert.Equal(t, header.Height+1, second.Height)
+
+		var state dps.ReplicaState
+		require.NoError(t, db.View(lib.RetrieveReplicaState(&state)))
+		assert.Equal(t, dps.ReplicaState{Sequence: 3, Height: header.Height + 1}, state)
+
+		var remaining int
+		require.NoError(t, db.View(lib.IterateReplicaLog(context.Background(), 0, func(uint64, dps.ReplicaEntry) (bool, error) {
+			remaining++
+			return true, nil
+		})))
+		assert.Zero(t, remaining)
+	})
+
+	t.Run("waits for then skips missing writes", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setup(t)
+		defer db.Close()
+		store, objects := memory(t)
+
+		require.NoError(t, db.Update(func(tx *badger.Txn) error {
+			return tx.Delete(storage.EncodeKey(storage.PrefixReplicaLog, uint64(2)))
+		}))
+
+		r := NewReplicator(zerolog.Nop(), db, lib, zbor.NewCodec(), store)
+		require.NoError(t, r.replicate())
+
+		var state dps.ReplicaState
+		require.NoError(t, db.View(lib.RetrieveReplicaState(&state)))
+		assert.Equal(t, uint64(1), state.Sequence)
+		assert.Len(t, objects, 1)
+
+		require.NoError(t, r.replicate())
+
+		require.NoError(t, db.View(lib.RetrieveReplicaState(&state)))
+		assert.Equal(t, dps.ReplicaState{Sequence: 3, Height: header.Height + 1}, state)
+		assert.Len(t, objects, 2)
+	})
+
+	t.Run("handles divergence", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setup(t)
+		defer db.Close()
+		store, _ := memory(t)
+		store.DownloadFunc = func(string, uint64, uint64) ([]byte, error) {
+			return mocks.GenericBytes, nil
+		}
+
+		r := NewReplicator(zerolog.Nop(), db, lib, zbor.NewCodec(), store)
+		assert.Error(t, r.replicate())
+
+		var state dps.ReplicaState
+		err := db.View(lib.RetrieveReplicaState(&state))
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+	})
+
+	t.Run("handles upload failure", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setup(t)
+		defer db.Close()
+		store := mocks.BaselineStore(t)
+		store.UploadFunc = func(string, []byte) error {
+			return mocks.GenericError
+		}
+
+		r := NewReplicator(zerolog.Nop(), db, lib, zbor.NewCodec(), store)
+		assert.Error(t, r.replicate())
+	})
+}
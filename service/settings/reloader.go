@@ -0,0 +1,179 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/logs"
+)
+
+// Reloader holds the settings of the live indexer that can be changed while it
+// runs, and applies them whenever they are updated, either from the settings
+// file when it is reloaded, or through the admin API.
+//
+// The log level is applied as the default level of the given log filter, so the
+// loggers of the indexer should not set a more restrictive level of their own.
+// The query limits are read by the API on each request.
+type Reloader struct {
+	log      zerolog.Logger
+	filter   *logs.Filter
+	path     string
+	mutex    *sync.RWMutex
+	settings dps.Settings
+	level    zerolog.Level
+	limit    time.Duration
+	slow     time.Duration
+}
+
+// NewReloader returns a new reloader that starts with the given settings, and
+// reads updated settings from the file at the given path when it is reloaded.
+// Without a path, the settings can only be updated through the admin API.
+func NewReloader(log zerolog.Logger, filter *logs.Filter, initial dps.Settings, path string) (*Reloader, error) {
+
+	r := Reloader{
+		log:    log.With().Str(logs.Component, "settings_reloader").Logger(),
+		filter: filter,
+		path:   path,
+		mutex:  &sync.RWMutex{},
+		level:  zerolog.InfoLevel,
+	}
+
+	err := r.Update(initial)
+	if err != nil {
+		return nil, fmt.Errorf("could not apply initial settings: %w", err)
+	}
+
+	return &r, nil
+}
+
+// Settings returns the current settings.
+func (r *Reloader) Settings() dps.Settings {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.settings
+}
+
+// Limits returns the current maximum execution time of API requests, and the
+// execution time above which they are logged as slow queries.
+func (r *Reloader) Limits() (time.Duration, time.Duration) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.limit, r.slow
+}
+
+// Update validates the given settings and applies them. Settings that are left
+// empty keep their current value. If any of the settings is invalid, none of
+// them are applied.
+func (r *Reloader) Update(settings dps.Settings) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	level := r.level
+	limit := r.limit
+	slow := r.slow
+
+	if settings.Level != "" {
+		var err error
+		level, err = zerolog.ParseLevel(settings.Level)
+		if err != nil {
+			return fmt.Errorf("could not parse log level: %w", err)
+		}
+	}
+
+	if settings.QueryLimit != "" {
+		var err error
+		limit, err = parseDuration(settings.QueryLimit)
+		if err != nil {
+			return fmt.Errorf("could not parse query limit: %w", err)
+		}
+	}
+
+	if settings.QuerySlow != "" {
+		var err error
+		slow, err = parseDuration(settings.QuerySlow)
+		if err != nil {
+			return fmt.Errorf("could not parse slow query threshold: %w", err)
+		}
+	}
+
+	updated := dps.Settings{
+		Level:      level.String(),
+		QueryLimit: limit.String(),
+		QuerySlow:  slow.String(),
+	}
+
+	r.filter.SetLevel(level)
+	r.settings = updated
+	r.level = level
+	r.limit = limit
+	r.slow = slow
+
+	r.log.Info().
+		Str("level", updated.Level).
+		Str("query_limit", updated.QueryLimit).
+		Str("query_slow", updated.QuerySlow).
+		Msg("settings applied")
+
+	return nil
+}
+
+// Reload reads the settings file again and applies the settings it holds.
+func (r *Reloader) Reload() error {
+
+	if r.path == "" {
+		return errors.New("no settings file configured")
+	}
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		return fmt.Errorf("could not read settings file: %w", err)
+	}
+
+	// Unknown fields are rejected, so that a setting with a typo in its name
+	// does not go unnoticed, or a setting that can not be changed at runtime is
+	// not mistaken for one that was applied.
+	var settings dps.Settings
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&settings)
+	if err != nil {
+		return fmt.Errorf("could not decode settings file: %w", err)
+	}
+
+	return r.Update(settings)
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("negative duration (%s)", value)
+	}
+	return duration, nil
+}
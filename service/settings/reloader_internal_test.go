@@ -0,0 +1,125 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package settings
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/logs"
+)
+
+// The log filter of the reloader sets the global log level, so its tests do not
+// run in parallel and restore the level once they are done.
+
+func TestReloader_Update(t *testing.T) {
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+
+	initial := dps.Settings{
+		Level:      "info",
+		QueryLimit: "5s",
+		QuerySlow:  "0s",
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		r, err := NewReloader(zerolog.Nop(), logs.NewFilter(io.Discard, nil), initial, "")
+		require.NoError(t, err)
+
+		err = r.Update(dps.Settings{Level: "debug", QuerySlow: "1s"})
+		require.NoError(t, err)
+
+		want := dps.Settings{Level: "debug", QueryLimit: "5s", QuerySlow: "1s"}
+		assert.Equal(t, want, r.Settings())
+		assert.Equal(t, zerolog.DebugLevel, zerolog.GlobalLevel())
+
+		limit, slow := r.Limits()
+		assert.Equal(t, 5*time.Second, limit)
+		assert.Equal(t, time.Second, slow)
+	})
+
+	t.Run("handles invalid settings", func(t *testing.T) {
+		r, err := NewReloader(zerolog.Nop(), logs.NewFilter(io.Discard, nil), initial, "")
+		require.NoError(t, err)
+
+		assert.Error(t, r.Update(dps.Settings{Level: "loud"}))
+		assert.Error(t, r.Update(dps.Settings{Level: "debug", QueryLimit: "soon"}))
+		assert.Error(t, r.Update(dps.Settings{QuerySlow: "-1s"}))
+
+		assert.Equal(t, initial, r.Settings())
+		assert.Equal(t, zerolog.InfoLevel, zerolog.GlobalLevel())
+	})
+
+	t.Run("handles invalid initial settings", func(t *testing.T) {
+		_, err := NewReloader(zerolog.Nop(), logs.NewFilter(io.Discard, nil), dps.Settings{QueryLimit: "soon"}, "")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestReloader_Reload(t *testing.T) {
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+
+	initial := dps.Settings{
+		Level:      "info",
+		QueryLimit: "0s",
+		QuerySlow:  "0s",
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "settings.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"level": "warn", "query_limit": "30s"}`), 0644))
+
+		r, err := NewReloader(zerolog.Nop(), logs.NewFilter(io.Discard, nil), initial, path)
+		require.NoError(t, err)
+
+		require.NoError(t, r.Reload())
+
+		want := dps.Settings{Level: "warn", QueryLimit: "30s", QuerySlow: "0s"}
+		assert.Equal(t, want, r.Settings())
+	})
+
+	t.Run("handles unknown setting", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "settings.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"level": "warn", "cold_age": 100}`), 0644))
+
+		r, err := NewReloader(zerolog.Nop(), logs.NewFilter(io.Discard, nil), initial, path)
+		require.NoError(t, err)
+
+		assert.Error(t, r.Reload())
+		assert.Equal(t, initial, r.Settings())
+	})
+
+	t.Run("handles missing file", func(t *testing.T) {
+		r, err := NewReloader(zerolog.Nop(), logs.NewFilter(io.Discard, nil), initial, filepath.Join(t.TempDir(), "missing.json"))
+		require.NoError(t, err)
+
+		assert.Error(t, r.Reload())
+	})
+
+	t.Run("handles no settings file", func(t *testing.T) {
+		r, err := NewReloader(zerolog.Nop(), logs.NewFilter(io.Discard, nil), initial, "")
+		require.NoError(t, err)
+
+		assert.Error(t, r.Reload())
+	})
+}
@@ -15,10 +15,14 @@
 package storage
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	"github.com/dgraph-io/badger/v2"
 	"github.com/hashicorp/go-multierror"
+
+	"github.com/optakt/flow-dps/models/dps"
 )
 
 // Fallback goes through the provided operations until one of them succeeds.
@@ -72,18 +76,225 @@ func (l *Library) retrieve(key []byte, v interface{}) func(tx *badger.Txn) error
 	}
 }
 
-func (l *Library) save(key []byte, value interface{}) func(*badger.Txn) error {
+// retrieveRaw retrieves the value at the given key as it is stored, without
+// decoding it.
+func (l *Library) retrieveRaw(key []byte, val *[]byte) func(tx *badger.Txn) error {
 	return func(tx *badger.Txn) error {
-		val, err := l.codec.Marshal(value)
+		item, err := tx.Get(key)
+		if err != nil {
+			return fmt.Errorf("could not get value (key: %x): %w", key, err)
+		}
+
+		*val, err = item.ValueCopy(nil)
+		if err != nil {
+			return fmt.Errorf("could not copy value (key: %x): %w", key, err)
+		}
+
+		return nil
+	}
+}
+
+// save returns an operation that sets the given key to the encoded value. The
+// value is encoded when the operation is created rather than when it is
+// applied, so that the index writer can encode values on its workers, and
+// apply the resulting operations without encoding anything.
+func (l *Library) save(key []byte, value interface{}) func(*badger.Txn) error {
+
+	// The prefix of the key follows the namespace, and determines which
+	// compression is used for the value.
+	prefix := l.prefix(key)
+	compression := l.compression[prefix]
+
+	var val []byte
+	var err error
+	if compression == dps.CompressionDefault {
+		val, err = l.codec.Marshal(value)
+	} else {
+		val, err = l.codec.MarshalWith(value, compression)
+	}
+	if err != nil {
+		err = fmt.Errorf("could not encode value (key: %x): %w", key, err)
+		return func(*badger.Txn) error {
+			return err
+		}
+	}
+
+	// The statistics compare the size of the value before and after
+	// compression, so the uncompressed encoding is only needed with them. The
+	// statistics themselves are left out.
+	var raw []byte
+	counted := l.stats != nil && prefix != PrefixCompressionStats
+	if counted {
+		raw, err = l.codec.Encode(value)
 		if err != nil {
-			return fmt.Errorf("could not encode value (key: %x): %w", key, err)
+			err = fmt.Errorf("could not encode value for statistics (key: %x): %w", key, err)
+			return func(*badger.Txn) error {
+				return err
+			}
+		}
+	}
+
+	return func(tx *badger.Txn) error {
+
+		// Before overwriting the entry, we record its previous value for the
+		// height being staged, so that the height can be rolled back. Only the
+		// first value is recorded, as later ones were set at the same height.
+		if l.staging != nil && staged(prefix) && !l.staging.has(key) {
+			entry, err := stagedEntryFor(tx, key)
+			if err != nil {
+				return fmt.Errorf("could not stage previous value (key: %x): %w", key, err)
+			}
+			l.staging.add(entry)
+			l.journal.record(tx, func() { l.staging.remove(key) })
 		}
 
-		err = tx.Set(key, val)
+		err := l.set(tx, key, val)
 		if err != nil {
 			return fmt.Errorf("could not set value (key: %x): %w", key, err)
 		}
 
+		// We only record the statistics once the value was set, as the
+		// operation is applied again when the transaction was too big.
+		if counted {
+			l.stats.record(prefix, len(raw), len(val))
+			l.journal.record(tx, func() { l.stats.remove(prefix, len(raw), len(val)) })
+		}
+
+		// Likewise, entries are only added to the manifest of the height once
+		// they were set. Entries that are overwritten at later heights, or that
+		// do not belong to a single height, are left out.
+		if l.manifest != nil && Manifested[prefix] {
+			previous, ok := l.manifest.add(key, val)
+			l.journal.record(tx, func() { l.manifest.undo(key, previous, ok) })
+		}
+
 		return nil
 	}
 }
+
+// iterate calls the given callback for each item under the given prefix, from
+// the given start key onwards, along with the segment of its key that follows
+// the prefix. It stops once the callback returns false or an error, or once the
+// given context is done.
+func (l *Library) iterate(ctx context.Context, tx *badger.Txn, prefix []byte, start []byte, process func(item *badger.Item, segment []byte) (bool, error)) error {
+
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = prefix
+
+	it := tx.NewIterator(opts)
+	defer it.Close()
+
+	for it.Seek(start); it.ValidForPrefix(prefix); it.Next() {
+		err := l.step(ctx)
+		if err != nil {
+			return err
+		}
+		item := it.Item()
+		proceed, err := process(item, item.Key()[len(prefix):])
+		if err != nil {
+			return err
+		}
+		if !proceed {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// step is called for each item visited by a range scan, before the item is
+// processed. It fails once the given context is done, so that scans on behalf of
+// cancelled or expired requests stop instead of running to completion, and
+// counts the item for the statistics of the request otherwise.
+func (l *Library) step(ctx context.Context) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+	dps.Scanned(ctx)
+	return nil
+}
+
+// set sets the given value for the given key. It records the change in size of
+// the entry if size statistics are enabled, and appends the write to the
+// replication log if replication is enabled.
+func (l *Library) set(tx *badger.Txn, key []byte, val []byte) error {
+
+	prefix := l.prefix(key)
+	sized := l.sizes != nil && prefix != PrefixSizeStats
+
+	var previous int64
+	var found bool
+	if sized {
+		var err error
+		previous, found, err = entrySize(tx, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := tx.Set(key, val)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !sized:
+	case found:
+		l.resize(tx, prefix, 0, 0, int64(len(val))-previous)
+	default:
+		l.resize(tx, prefix, 1, int64(len(key)), int64(len(val)))
+	}
+
+	return l.replicate(tx, prefix, dps.ReplicaEntry{Key: key, Value: val})
+}
+
+// delete deletes the given key. It records the change in size if size
+// statistics are enabled, and appends the deletion to the replication log if
+// replication is enabled.
+func (l *Library) delete(tx *badger.Txn, key []byte) error {
+
+	prefix := l.prefix(key)
+	sized := l.sizes != nil && prefix != PrefixSizeStats
+
+	var previous int64
+	var found bool
+	if sized {
+		var err error
+		previous, found, err = entrySize(tx, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := tx.Delete(key)
+	if err != nil {
+		return err
+	}
+
+	if sized && found {
+		l.resize(tx, prefix, -1, -int64(len(key)), -previous)
+	}
+
+	return l.replicate(tx, prefix, dps.ReplicaEntry{Key: key, Deleted: true})
+}
+
+// resize records the given change in size for the given prefix, and how to
+// undo it should the given transaction be discarded.
+func (l *Library) resize(tx *badger.Txn, prefix uint8, keys int64, keyBytes int64, valueBytes int64) {
+	l.sizes.record(prefix, keys, keyBytes, valueBytes)
+	l.journal.record(tx, func() { l.sizes.undo(prefix, keys, keyBytes, valueBytes) })
+}
+
+// entrySize returns the size of the value currently stored at the given key,
+// as seen by the given transaction, and whether there is such a value.
+func entrySize(tx *badger.Txn, key []byte) (int64, bool, error) {
+	item, err := tx.Get(key)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("could not get previous value (key: %x): %w", key, err)
+	}
+	return item.ValueSize(), true, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// coldMarker is the first byte of the values that locate a value in the cold
+// tier. It is the CBOR break code, which never starts a valid CBOR item, and it
+// does not start the magic number of zstandard frames either, so it can not be
+// confused with values encoded by the codec.
+const coldMarker = 0xff
+
+// coldLength is the length of an encoded cold value location.
+const coldLength = 1 + 3*8
+
+// encodeCold encodes the location of a value in the cold tier, so that it can
+// be stored in place of the value.
+func encodeCold(cold dps.ColdValue) []byte {
+	val := make([]byte, coldLength)
+	val[0] = coldMarker
+	binary.BigEndian.PutUint64(val[1:], cold.Segment)
+	binary.BigEndian.PutUint64(val[9:], cold.Offset)
+	binary.BigEndian.PutUint64(val[17:], cold.Length)
+	return val
+}
+
+// decodeCold decodes the location of a value in the cold tier, if the given
+// stored value is one.
+func decodeCold(val []byte) (dps.ColdValue, bool) {
+	if len(val) != coldLength || val[0] != coldMarker {
+		return dps.ColdValue{}, false
+	}
+	cold := dps.ColdValue{
+		Segment: binary.BigEndian.Uint64(val[1:]),
+		Offset:  binary.BigEndian.Uint64(val[9:]),
+		Length:  binary.BigEndian.Uint64(val[17:]),
+	}
+	return cold, true
+}
+
+// resolve returns the value that was originally stored, by reading it from the
+// cold tier if it was moved there.
+func (l *Library) resolve(val []byte) ([]byte, error) {
+
+	cold, ok := decodeCold(val)
+	if !ok {
+		return val, nil
+	}
+
+	if l.cold == nil {
+		return nil, fmt.Errorf("no cold tier to read value from (segment: %d): %w", cold.Segment, dps.ErrCold)
+	}
+
+	val, err := l.cold.Read(cold)
+	if err != nil {
+		return nil, fmt.Errorf("could not read cold value (segment: %d, offset: %d): %w", cold.Segment, cold.Offset, err)
+	}
+
+	return val, nil
+}
+
+// unmarshal decodes the given stored value, after reading it from the cold tier
+// if it was moved there.
+func (l *Library) unmarshal(val []byte, v interface{}) error {
+
+	val, err := l.resolve(val)
+	if err != nil {
+		return err
+	}
+
+	return l.codec.Unmarshal(val, v)
+}
@@ -0,0 +1,142 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Resources maps the names of the resources stored in the index to the key
+// prefix under which their values are stored, so that compression policies
+// can be configured by name.
+var Resources = map[string]uint8{
+	"header":            PrefixHeader,
+	"events":            PrefixEvents,
+	"payload":           PrefixPayload,
+	"transaction":       PrefixTransaction,
+	"collection":        PrefixCollection,
+	"guarantee":         PrefixGuarantee,
+	"results":           PrefixResults,
+	"seal":              PrefixSeal,
+	"contract_versions": PrefixContractVersions,
+	"service_events":    PrefixServiceEvents,
+	"identities":        PrefixIdentities,
+	"path_filters":      PrefixPathFilters,
+	"execution_records": PrefixExecutionRecords,
+}
+
+// CompressionOptions returns the options that configure the compression of the
+// resources with the given names to the compression levels with the given
+// names, such as `events=best` or `payload=none`.
+func CompressionOptions(policy map[string]string) ([]func(*Config), error) {
+	var options []func(*Config)
+	for resource, level := range policy {
+		prefix, ok := Resources[resource]
+		if !ok {
+			return nil, fmt.Errorf("unknown resource (resource: %s)", resource)
+		}
+		compression, err := dps.ParseCompression(level)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse compression (resource: %s): %w", resource, err)
+		}
+		options = append(options, WithCompression(prefix, compression))
+	}
+	return options, nil
+}
+
+// compressionRecorder accumulates the compression statistics of the values
+// saved by the library, per key prefix, until they are persisted.
+type compressionRecorder struct {
+	sync.Mutex
+	stats map[uint8]*dps.CompressionStats
+}
+
+func newCompressionRecorder() *compressionRecorder {
+	c := compressionRecorder{
+		stats: make(map[uint8]*dps.CompressionStats),
+	}
+	return &c
+}
+
+func (c *compressionRecorder) record(prefix uint8, raw int, stored int) {
+	c.Lock()
+	defer c.Unlock()
+
+	stats, ok := c.stats[prefix]
+	if !ok {
+		stats = &dps.CompressionStats{Prefix: prefix}
+		c.stats[prefix] = stats
+	}
+	stats.Values++
+	stats.RawBytes += uint64(raw)
+	stats.StoredBytes += uint64(stored)
+}
+
+// remove takes back a value that was recorded with the given sizes.
+func (c *compressionRecorder) remove(prefix uint8, raw int, stored int) {
+	c.Lock()
+	defer c.Unlock()
+
+	stats, ok := c.stats[prefix]
+	if !ok || stats.Values == 0 {
+		return
+	}
+	stats.Values--
+	stats.RawBytes -= uint64(raw)
+	stats.StoredBytes -= uint64(stored)
+	if stats.Values == 0 {
+		delete(c.stats, prefix)
+	}
+}
+
+// restore adds the given drained statistics back to the ones accumulated
+// since, so that they are part of the next update.
+func (c *compressionRecorder) restore(drained []dps.CompressionStats) {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, delta := range drained {
+		stats, ok := c.stats[delta.Prefix]
+		if !ok {
+			stats = &dps.CompressionStats{Prefix: delta.Prefix}
+			c.stats[delta.Prefix] = stats
+		}
+		stats.Values += delta.Values
+		stats.RawBytes += delta.RawBytes
+		stats.StoredBytes += delta.StoredBytes
+	}
+}
+
+// drain returns the statistics accumulated so far, sorted by prefix, and
+// resets them.
+func (c *compressionRecorder) drain() []dps.CompressionStats {
+	c.Lock()
+	defer c.Unlock()
+
+	drained := make([]dps.CompressionStats, 0, len(c.stats))
+	for _, stats := range c.stats {
+		drained = append(drained, *stats)
+	}
+	sort.Slice(drained, func(i, j int) bool {
+		return drained[i].Prefix < drained[j].Prefix
+	})
+	c.stats = make(map[uint8]*dps.CompressionStats)
+
+	return drained
+}
@@ -0,0 +1,230 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/helpers"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestCompressionOptions(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		options, err := CompressionOptions(map[string]string{
+			"events":  "best",
+			"payload": "none",
+		})
+		require.NoError(t, err)
+
+		cfg := DefaultConfig
+		for _, option := range options {
+			option(&cfg)
+		}
+
+		assert.Equal(t, map[uint8]dps.Compression{
+			PrefixEvents:  dps.CompressionBest,
+			PrefixPayload: dps.CompressionNone,
+		}, cfg.Compression)
+		assert.Nil(t, DefaultConfig.Compression)
+	})
+
+	t.Run("handles unknown resource", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := CompressionOptions(map[string]string{"blocks": "best"})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles unknown compression", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := CompressionOptions(map[string]string{"events": "maximum"})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestLibrary_SaveWithCompression(t *testing.T) {
+	t.Run("uses configured compression", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := mocks.BaselineCodec(t)
+		codec.MarshalFunc = func(interface{}) ([]byte, error) {
+			t.Fatal("default compression used for configured prefix")
+			return nil, nil
+		}
+		codec.MarshalWithFunc = func(v interface{}, compression dps.Compression) ([]byte, error) {
+			assert.IsType(t, &ledger.Payload{}, v)
+			assert.Equal(t, dps.CompressionNone, compression)
+			return mocks.GenericBytes, nil
+		}
+
+		lib := New(codec, WithCompression(PrefixPayload, dps.CompressionNone))
+
+		err := db.Update(lib.SavePayload(mocks.GenericHeight, mocks.GenericLedgerPath(0), mocks.GenericLedgerPayload(0)))
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("uses configured compression within namespace", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := mocks.BaselineCodec(t)
+		called := false
+		codec.MarshalWithFunc = func(_ interface{}, compression dps.Compression) ([]byte, error) {
+			called = true
+			assert.Equal(t, dps.CompressionBest, compression)
+			return mocks.GenericBytes, nil
+		}
+
+		lib := New(codec,
+			WithNamespace("testnet"),
+			WithCompression(PrefixEvents, dps.CompressionBest),
+		)
+
+		err := db.Update(lib.SaveEvents(mocks.GenericHeight, mocks.GenericEventType(0), mocks.GenericEvents(2)))
+
+		require.NoError(t, err)
+		assert.True(t, called)
+	})
+
+	t.Run("handles codec failure", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := mocks.BaselineCodec(t)
+		codec.MarshalWithFunc = func(interface{}, dps.Compression) ([]byte, error) {
+			return nil, mocks.GenericError
+		}
+
+		lib := New(codec, WithCompression(PrefixHeader, dps.CompressionBetter))
+
+		err := db.Update(lib.SaveHeader(mocks.GenericHeight, mocks.GenericHeader))
+
+		assert.ErrorIs(t, err, mocks.GenericError)
+	})
+
+	t.Run("values remain readable across compressions", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := zbor.NewCodec()
+		compressions := []dps.Compression{
+			dps.CompressionDefault,
+			dps.CompressionNone,
+			dps.CompressionFastest,
+			dps.CompressionBetter,
+			dps.CompressionBest,
+		}
+
+		for i, compression := range compressions {
+			lib := New(codec,
+				WithCompression(PrefixPayload, compression),
+				WithCompression(PrefixHeader, compression),
+			)
+
+			height := mocks.GenericHeight + uint64(i)
+			path := mocks.GenericLedgerPath(0)
+			payload := mocks.GenericLedgerPayload(i)
+			require.NoError(t, db.Update(lib.SavePayload(height, path, payload)))
+			require.NoError(t, db.Update(lib.SaveHeader(height, mocks.GenericHeader)))
+
+			// Values are read back with a library using the default policy, as
+			// the compression must not matter for reading.
+			read := New(codec)
+
+			var gotPayload ledger.Payload
+			require.NoError(t, db.View(read.RetrievePayload(height, path, &gotPayload)), compression.String())
+			assert.Equal(t, *payload, gotPayload)
+
+			var gotHeader flow.Header
+			require.NoError(t, db.View(read.RetrieveHeader(height, &gotHeader)), compression.String())
+			assert.Equal(t, mocks.GenericHeader.ID(), gotHeader.ID())
+		}
+	})
+}
+
+func TestLibrary_CompressionStats(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := zbor.NewCodec()
+		lib := New(codec, WithCompressionStats(true))
+
+		events := mocks.GenericEvents(4)
+		raw, err := codec.Encode(events)
+		require.NoError(t, err)
+
+		require.NoError(t, db.Update(lib.SaveEvents(mocks.GenericHeight, mocks.GenericEventType(0), events)))
+		require.NoError(t, db.Update(lib.UpdateCompressionStats()))
+		require.NoError(t, db.Update(lib.SaveEvents(mocks.GenericHeight+1, mocks.GenericEventType(0), events)))
+		require.NoError(t, db.Update(lib.UpdateCompressionStats()))
+
+		var got []dps.CompressionStats
+		err = db.View(lib.RetrieveCompressionStats(context.Background(), &got))
+
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, uint8(PrefixEvents), got[0].Prefix)
+		assert.Equal(t, uint64(2), got[0].Values)
+		assert.Equal(t, uint64(2*len(raw)), got[0].RawBytes)
+		assert.NotZero(t, got[0].StoredBytes)
+		assert.Less(t, got[0].StoredBytes, got[0].RawBytes)
+	})
+
+	t.Run("does nothing when disabled", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		lib := New(zbor.NewCodec())
+
+		require.NoError(t, db.Update(lib.SaveHeader(mocks.GenericHeight, mocks.GenericHeader)))
+		require.NoError(t, db.Update(lib.UpdateCompressionStats()))
+
+		var got []dps.CompressionStats
+		err := db.View(lib.RetrieveCompressionStats(context.Background(), &got))
+
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
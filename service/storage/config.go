@@ -0,0 +1,138 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package storage
+
+import (
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// DefaultConfig is the default configuration for the storage library.
+var DefaultConfig = Config{
+	Namespace:        "",
+	Compression:      nil,
+	CompressionStats: false,
+	SizeStats:        false,
+	Manifests:        false,
+	Staging:          false,
+	Replication:      false,
+	Verification:     false,
+	Cold:             nil,
+}
+
+// Config contains optional parameters for the storage library.
+type Config struct {
+	Namespace        string
+	Compression      map[uint8]dps.Compression
+	CompressionStats bool
+	SizeStats        bool
+	Manifests        bool
+	Staging          bool
+	Replication      bool
+	Verification     bool
+	Cold             dps.ColdReader
+}
+
+// WithNamespace sets the namespace under which the storage library stores and
+// retrieves all of its data. This allows multiple chains or sporks to share a
+// single index database without their keys overlapping. The default empty
+// namespace uses the same keys as indexes without namespaces.
+func WithNamespace(namespace string) func(*Config) {
+	return func(cfg *Config) {
+		cfg.Namespace = namespace
+	}
+}
+
+// WithCompression sets the compression used for the values stored under the
+// given key prefix. Values under prefixes without a configured compression use
+// the default compression of the codec. Changing the compression only affects
+// values stored from then on, and values stored with any compression remain
+// readable.
+func WithCompression(prefix uint8, compression dps.Compression) func(*Config) {
+	return func(cfg *Config) {
+		if cfg.Compression == nil {
+			cfg.Compression = make(map[uint8]dps.Compression)
+		}
+		cfg.Compression[prefix] = compression
+	}
+}
+
+// WithCompressionStats enables the collection of compression statistics for
+// the values saved by the storage library. It requires encoding each value a
+// second time to know its size before compression, so it is disabled by
+// default.
+func WithCompressionStats(enabled bool) func(*Config) {
+	return func(cfg *Config) {
+		cfg.CompressionStats = enabled
+	}
+}
+
+// WithSizeStats enables the running size statistics of the storage library.
+// It then keeps track of the number of keys and bytes stored under each key
+// prefix, which requires reading the previous value of each entry it writes or
+// deletes, so it is disabled by default. Entries written while it was disabled
+// are not accounted for.
+func WithSizeStats(enabled bool) func(*Config) {
+	return func(cfg *Config) {
+		cfg.SizeStats = enabled
+	}
+}
+
+// WithReplication enables the replication log of the storage library. It then
+// appends each entry that it writes or deletes to the log, within the same
+// transaction, so that the entries can be replicated to a secondary index.
+func WithReplication(enabled bool) func(*Config) {
+	return func(cfg *Config) {
+		cfg.Replication = enabled
+	}
+}
+
+// WithManifests enables the integrity manifests of the storage library. It then
+// adds each entry that it saves to the manifest of the height being indexed,
+// which is stored once the height is complete.
+func WithManifests(enabled bool) func(*Config) {
+	return func(cfg *Config) {
+		cfg.Manifests = enabled
+	}
+}
+
+// WithStaging enables the staging of the storage library. It then records the
+// previous value of each entry that it saves, or its absence, for the height
+// being indexed. Once the height is complete, these entries are stored in the
+// staging keyspace, so that the height can be rolled back until it is promoted.
+func WithStaging(enabled bool) func(*Config) {
+	return func(cfg *Config) {
+		cfg.Staging = enabled
+	}
+}
+
+// WithVerification enables the verification of the payloads that the storage
+// library reads. It then checks that the key of each payload it retrieves maps
+// to the path it was stored under, so that a payload that was corrupted on
+// disk results in an error wrapping dps.ErrCorrupted instead of a wrong
+// register value. It requires hashing the key of each payload that is read, so
+// it is disabled by default.
+func WithVerification(enabled bool) func(*Config) {
+	return func(cfg *Config) {
+		cfg.Verification = enabled
+	}
+}
+
+// WithColdTier sets the reader for the values that were moved from the index to
+// the cold tier. Without it, reading such a value fails.
+func WithColdTier(cold dps.ColdReader) func(*Config) {
+	return func(cfg *Config) {
+		cfg.Cold = cold
+	}
+}
@@ -27,6 +27,7 @@ func TestEncodeKey(t *testing.T) {
 	id := mocks.GenericHeader.ID()
 	path := mocks.GenericLedgerPath(0)
 	commit := mocks.GenericCommit(0)
+	address := mocks.GenericAddress(0)
 	fullKey := bytes.Join([][]byte{
 		{
 			0x1,                                     // prefix
@@ -35,6 +36,7 @@ func TestEncodeKey(t *testing.T) {
 		id[:],
 		path[:],
 		commit[:],
+		address[:],
 	}, nil)
 
 	tests := []struct {
@@ -53,6 +55,7 @@ func TestEncodeKey(t *testing.T) {
 				id,
 				path,
 				commit,
+				address,
 			},
 
 			wantPanic: false,
@@ -0,0 +1,126 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package storage
+
+import (
+	"sync"
+
+	"github.com/dgraph-io/badger/v2"
+)
+
+// TrackChanges is an operation that starts tracking the changes that the
+// operations applied to the same transaction make to the state that the library
+// keeps in memory, which are its statistics, the position of its replication
+// log, and the manifest and staging of the height being indexed. It should be
+// applied to transactions that might be discarded, along with RevertChanges
+// when they are, and ReleaseChanges when they are not.
+func (l *Library) TrackChanges() func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+		l.journal.track(tx)
+		return nil
+	}
+}
+
+// RevertChanges is an operation that undoes the tracked changes of the
+// transaction, in the reverse order in which they were made, and stops tracking
+// it. Sequence numbers of the replication log are only given back when no
+// other transaction appended entries since.
+func (l *Library) RevertChanges() func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+		l.journal.revert(tx)
+		return nil
+	}
+}
+
+// ReleaseChanges is an operation that stops tracking the changes of the
+// transaction, and keeps them.
+func (l *Library) ReleaseChanges() func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+		l.journal.release(tx)
+		return nil
+	}
+}
+
+// journal keeps, for each transaction that it tracks, how to undo the changes
+// that operations applied to the transaction made to the state that the library
+// keeps in memory. Those changes are not part of the transaction, so they would
+// otherwise remain when the transaction is discarded instead of committed.
+type journal struct {
+	sync.Mutex
+	undo map[*badger.Txn][]func()
+}
+
+func newJournal() *journal {
+	j := journal{
+		undo: make(map[*badger.Txn][]func()),
+	}
+	return &j
+}
+
+func (j *journal) track(tx *badger.Txn) {
+	if j == nil {
+		return
+	}
+
+	j.Lock()
+	defer j.Unlock()
+
+	j.undo[tx] = []func(){}
+}
+
+// record adds the given undo to the changes of the given transaction. It does
+// nothing when the transaction is not tracked.
+func (j *journal) record(tx *badger.Txn, undo func()) {
+	if j == nil {
+		return
+	}
+
+	j.Lock()
+	defer j.Unlock()
+
+	undos, ok := j.undo[tx]
+	if !ok {
+		return
+	}
+	j.undo[tx] = append(undos, undo)
+}
+
+// revert undoes the changes of the given transaction in reverse order, and
+// stops tracking it.
+func (j *journal) revert(tx *badger.Txn) {
+	if j == nil {
+		return
+	}
+
+	j.Lock()
+	undos := j.undo[tx]
+	delete(j.undo, tx)
+	j.Unlock()
+
+	for i := len(undos) - 1; i >= 0; i-- {
+		undos[i]()
+	}
+}
+
+func (j *journal) release(tx *badger.Txn) {
+	if j == nil {
+		return
+	}
+
+	j.Lock()
+	defer j.Unlock()
+
+	delete(j.undo, tx)
+}
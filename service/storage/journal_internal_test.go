@@ -0,0 +1,158 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/helpers"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestLibrary_Changes(t *testing.T) {
+	header := mocks.GenericHeader
+
+	// libraryState is the state that the library keeps in memory.
+	type libraryState struct {
+		sizes       []dps.SizeStats
+		compression map[uint8]dps.CompressionStats
+		manifest    map[string][]byte
+		staging     map[string]stagedEntry
+		next        uint64
+	}
+
+	state := func(lib *Library) libraryState {
+		s := libraryState{
+			sizes:       lib.SizeStats(),
+			compression: make(map[uint8]dps.CompressionStats),
+			manifest:    make(map[string][]byte),
+			staging:     make(map[string]stagedEntry),
+			next:        lib.replica.next,
+		}
+		for prefix, stats := range lib.stats.stats {
+			s.compression[prefix] = *stats
+		}
+		for key, value := range lib.manifest.entries {
+			s.manifest[key] = value
+		}
+		for key, entry := range lib.staging.entries {
+			s.staging[key] = entry
+		}
+		return s
+	}
+
+	// height returns the operations of a height, which change all of the state
+	// that the library keeps in memory.
+	height := func(lib *Library, height uint64) []func(*badger.Txn) error {
+		return []func(*badger.Txn) error{
+			lib.SaveHeader(height, header),
+			lib.SaveManifest(height),
+			lib.SaveStaging(height),
+			lib.UpdateCompressionStats(),
+			lib.SaveLast(height),
+		}
+	}
+
+	setup := func(t *testing.T) (*badger.DB, *Library) {
+		t.Helper()
+
+		db := helpers.InMemoryDB(t)
+		lib := New(zbor.NewCodec(),
+			WithSizeStats(true),
+			WithCompressionStats(true),
+			WithManifests(true),
+			WithStaging(true),
+			WithReplication(true),
+		)
+
+		for _, op := range height(lib, header.Height) {
+			require.NoError(t, db.Update(op))
+		}
+		require.NoError(t, db.Update(lib.SaveHeader(header.Height+1, header)))
+
+		return db, lib
+	}
+
+	t.Run("reverts changes of discarded transaction", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setup(t)
+		defer db.Close()
+
+		want := state(lib)
+
+		tx := db.NewTransaction(true)
+		require.NoError(t, lib.TrackChanges()(tx))
+		for _, op := range height(lib, header.Height+2) {
+			require.NoError(t, op(tx))
+		}
+		assert.NotEqual(t, want, state(lib))
+
+		require.NoError(t, lib.RevertChanges()(tx))
+		tx.Discard()
+
+		assert.Equal(t, want, state(lib))
+		assert.Empty(t, lib.journal.undo)
+	})
+
+	t.Run("keeps changes of released transaction", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setup(t)
+		defer db.Close()
+
+		tx := db.NewTransaction(true)
+		require.NoError(t, lib.TrackChanges()(tx))
+		for _, op := range height(lib, header.Height+2) {
+			require.NoError(t, op(tx))
+		}
+		want := state(lib)
+
+		require.NoError(t, lib.ReleaseChanges()(tx))
+		require.NoError(t, tx.Commit())
+
+		// Once released, reverting the transaction has no effect.
+		require.NoError(t, lib.RevertChanges()(tx))
+
+		assert.Equal(t, want, state(lib))
+		assert.Empty(t, lib.journal.undo)
+	})
+
+	t.Run("does not track other transactions", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setup(t)
+		defer db.Close()
+
+		tx := db.NewTransaction(true)
+		require.NoError(t, lib.TrackChanges()(tx))
+
+		for _, op := range height(lib, header.Height+2) {
+			require.NoError(t, db.Update(op))
+		}
+		want := state(lib)
+
+		require.NoError(t, lib.RevertChanges()(tx))
+		tx.Discard()
+
+		assert.Equal(t, want, state(lib))
+	})
+}
@@ -15,19 +15,119 @@
 package storage
 
 import (
+	"encoding/binary"
+
 	"github.com/optakt/flow-dps/models/dps"
 )
 
 // Library is the storage library.
 type Library struct {
-	codec dps.Codec
+	codec       dps.Codec
+	namespace   []byte
+	compression map[uint8]dps.Compression
+	stats       *compressionRecorder // nil when compression statistics are disabled
+	sizes       *sizeRecorder        // nil when size statistics are disabled
+	manifest    *manifestRecorder    // nil when integrity manifests are disabled
+	staging     *stagingRecorder     // nil when staging is disabled
+	replica     *replicaLog          // nil when replication is disabled
+	cold        dps.ColdReader       // nil when there is no cold tier
+	journal     *journal
+	verify      bool
 }
 
 // New returns a new storage library using the given codec.
-func New(codec dps.Codec) *Library {
+func New(codec dps.Codec, options ...func(*Config)) *Library {
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	// Keys of the default namespace use the plain key layout, so that indexes
+	// created before namespaces were introduced remain readable. Keys of any
+	// other namespace are prefixed with a dedicated prefix byte, followed by
+	// the length of the namespace and its name.
+	var namespace []byte
+	if cfg.Namespace != "" {
+		length := make([]byte, binary.MaxVarintLen64)
+		n := binary.PutUvarint(length, uint64(len(cfg.Namespace)))
+		namespace = append(namespace, PrefixNamespace)
+		namespace = append(namespace, length[:n]...)
+		namespace = append(namespace, cfg.Namespace...)
+	}
+
+	compression := make(map[uint8]dps.Compression, len(cfg.Compression))
+	for prefix, level := range cfg.Compression {
+		compression[prefix] = level
+	}
+
+	var stats *compressionRecorder
+	if cfg.CompressionStats {
+		stats = newCompressionRecorder()
+	}
+
+	var sizes *sizeRecorder
+	if cfg.SizeStats {
+		sizes = newSizeRecorder()
+	}
+
+	var manifest *manifestRecorder
+	if cfg.Manifests {
+		manifest = newManifestRecorder()
+	}
+
+	var staging *stagingRecorder
+	if cfg.Staging {
+		staging = newStagingRecorder()
+	}
+
+	var replica *replicaLog
+	if cfg.Replication {
+		replica = newReplicaLog()
+	}
+
 	lib := Library{
-		codec: codec,
+		codec:       codec,
+		namespace:   namespace,
+		compression: compression,
+		stats:       stats,
+		sizes:       sizes,
+		manifest:    manifest,
+		staging:     staging,
+		replica:     replica,
+		cold:        cfg.Cold,
+		journal:     newJournal(),
+		verify:      cfg.Verification,
 	}
 
 	return &lib
 }
+
+// Detached returns a library that shares the configuration, statistics and
+// replication log of this library, but does not record the entries that it
+// saves in the manifest or the staging of the height being indexed. It is used
+// to write entries for past heights while heights are being indexed.
+func (l *Library) Detached() *Library {
+	lib := *l
+	lib.manifest = nil
+	lib.staging = nil
+	return &lib
+}
+
+// key encodes the key for the given prefix and segments within the namespace
+// of the library.
+func (l *Library) key(prefix uint8, segments ...interface{}) []byte {
+	key := make([]byte, 0, len(l.namespace)+1)
+	key = append(key, l.namespace...)
+	key = append(key, EncodeKey(prefix, segments...)...)
+	return key
+}
+
+// prefix returns the prefix of the given key, which follows the namespace of
+// the library.
+func (l *Library) prefix(key []byte) uint8 {
+	if len(key) <= len(l.namespace) {
+		return 0
+	}
+	return key[len(l.namespace)]
+}
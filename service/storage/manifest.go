@@ -0,0 +1,127 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package storage
+
+import (
+	"sync"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Manifested lists the key prefixes whose entries are covered by the integrity
+// manifests. The entries that are left out are overwritten at later heights,
+// such as the last indexed height or the height of a transaction that was
+// included in more than one block, or they do not belong to a single height,
+// such as the epochs or the statistics of the index.
+var Manifested = map[uint8]bool{
+	PrefixCommit:                    true,
+	PrefixHeader:                    true,
+	PrefixBlockStats:                true,
+	PrefixRegisterChurn:             true,
+	PrefixRegisterDeltas:            true,
+	PrefixEvents:                    true,
+	PrefixPayload:                   true,
+	PrefixTransaction:               true,
+	PrefixCollection:                true,
+	PrefixGuarantee:                 true,
+	PrefixSeal:                      true,
+	PrefixResults:                   true,
+	PrefixTransactionsForHeight:     true,
+	PrefixTransactionsForScript:     true,
+	PrefixTransactionsForCollection: true,
+	PrefixCollectionsForHeight:      true,
+	PrefixSealsForHeight:            true,
+	PrefixHeightForBlock:            true,
+	PrefixHeightForCommit:           true,
+	PrefixHeightForAccount:          true,
+	PrefixKeyUpdates:                true,
+	PrefixContractVersions:          true,
+	PrefixServiceEvents:             true,
+	PrefixEpochPhases:               true,
+	PrefixCorruption:                true,
+}
+
+// manifestRecorder accumulates the entries saved by the library for the height
+// that is being indexed, until its manifest is persisted. Entries are recorded
+// by key, so that an operation which is retried after its transaction became
+// too big does not count the entries it saved before the retry twice.
+type manifestRecorder struct {
+	sync.Mutex
+	entries map[string][]byte
+}
+
+func newManifestRecorder() *manifestRecorder {
+	m := manifestRecorder{
+		entries: make(map[string][]byte),
+	}
+	return &m
+}
+
+// add records the given entry, and returns the value previously recorded for
+// its key, if any, so that it can be restored.
+func (m *manifestRecorder) add(key []byte, value []byte) ([]byte, bool) {
+	m.Lock()
+	defer m.Unlock()
+
+	previous, ok := m.entries[string(key)]
+	m.entries[string(key)] = value
+	return previous, ok
+}
+
+// undo puts back the given previous value of the given key, as returned when an
+// entry was added, or drops the key if there was none.
+func (m *manifestRecorder) undo(key []byte, previous []byte, ok bool) {
+	m.Lock()
+	defer m.Unlock()
+
+	if !ok {
+		delete(m.entries, string(key))
+		return
+	}
+	m.entries[string(key)] = previous
+}
+
+// manifest returns the manifest of the entries recorded so far, for the given
+// height.
+func (m *manifestRecorder) manifest(height uint64) dps.Manifest {
+	m.Lock()
+	defer m.Unlock()
+
+	manifest := dps.Manifest{Height: height}
+	for key, value := range m.entries {
+		manifest.Add([]byte(key), value)
+	}
+
+	return manifest
+}
+
+// reset starts recording the entries of the next height, and returns the
+// entries recorded so far, so that they can be restored.
+func (m *manifestRecorder) reset() map[string][]byte {
+	m.Lock()
+	defer m.Unlock()
+
+	entries := m.entries
+	m.entries = make(map[string][]byte)
+	return entries
+}
+
+// restore replaces the recorded entries with the given ones.
+func (m *manifestRecorder) restore(entries map[string][]byte) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.entries = entries
+}
@@ -15,9 +15,13 @@
 package storage
 
 import (
+	"bytes"
+	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
+	"sort"
 
 	"github.com/OneOfOne/xxhash"
 	"github.com/dgraph-io/badger/v2"
@@ -25,323 +29,1791 @@ import (
 	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/ledger/common/pathfinder"
 	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
 )
 
 // SaveFirst is an operation that writes the height of the first indexed block.
 func (l *Library) SaveFirst(height uint64) func(*badger.Txn) error {
-	return l.save(EncodeKey(PrefixFirst), height)
+	return l.save(l.key(PrefixFirst), height)
 }
 
 // SaveLast is an operation that writes the height of the last indexed block.
 func (l *Library) SaveLast(height uint64) func(*badger.Txn) error {
-	return l.save(EncodeKey(PrefixLast), height)
+	return l.save(l.key(PrefixLast), height)
 }
 
 // IndexHeightForBlock is an operation that indexes the given height for its block identifier.
 func (l *Library) IndexHeightForBlock(blockID flow.Identifier, height uint64) func(*badger.Txn) error {
-	return l.save(EncodeKey(PrefixHeightForBlock, blockID), height)
+	return l.save(l.key(PrefixHeightForBlock, blockID), height)
 }
 
 // SaveCommit is an operation that writes the height of a state commitment.
 func (l *Library) SaveCommit(height uint64, commit flow.StateCommitment) func(*badger.Txn) error {
-	return l.save(EncodeKey(PrefixCommit, height), commit)
+	return l.save(l.key(PrefixCommit, height), commit)
 }
 
 // SaveHeader is an operation that writes the height of a header.
 func (l *Library) SaveHeader(height uint64, header *flow.Header) func(*badger.Txn) error {
-	return l.save(EncodeKey(PrefixHeader, height), header)
+	return l.save(l.key(PrefixHeader, height), header)
 }
 
 // SaveEvents is an operation that writes the height and type of a slice of events.
 func (l *Library) SaveEvents(height uint64, typ flow.EventType, events []flow.Event) func(*badger.Txn) error {
 	hash := xxhash.ChecksumString64(string(typ))
-	return l.save(EncodeKey(PrefixEvents, height, hash), events)
+	return l.save(l.key(PrefixEvents, height, hash), events)
 }
 
 // SavePayload is an operation that writes the height of a slice of paths and a slice of payloads.
 func (l *Library) SavePayload(height uint64, path ledger.Path, payload *ledger.Payload) func(*badger.Txn) error {
-	return l.save(EncodeKey(PrefixPayload, path, height), payload)
+	return l.save(l.key(PrefixPayload, path, height), payload)
+}
+
+// SaveRegisterDelta is an operation that writes the change to a register made
+// by the finalized block at the height of the given delta.
+func (l *Library) SaveRegisterDelta(delta dps.RegisterDelta) func(*badger.Txn) error {
+	return l.save(l.key(PrefixRegisterDeltas, delta.Height, delta.Path), delta)
+}
+
+// SaveRegisterChurn is an operation that writes the register churn of the
+// block at the height of the given churn.
+func (l *Library) SaveRegisterChurn(churn dps.RegisterChurn) func(*badger.Txn) error {
+	return l.save(l.key(PrefixRegisterChurn, churn.Height), churn)
 }
 
 // SaveTransaction is an operation that writes the given transaction.
 func (l *Library) SaveTransaction(transaction *flow.TransactionBody) func(*badger.Txn) error {
-	return l.save(EncodeKey(PrefixTransaction, transaction.ID()), transaction)
+	return l.save(l.key(PrefixTransaction, transaction.ID()), transaction)
 }
 
 // IndexHeightForTransaction is an operation that writes the height a transaction identifier.
 func (l *Library) IndexHeightForTransaction(txID flow.Identifier, height uint64) func(*badger.Txn) error {
-	return l.save(EncodeKey(PrefixHeightForTransaction, txID), height)
+	return l.save(l.key(PrefixHeightForTransaction, txID), height)
+}
+
+// IndexHeightForCommit is an operation that indexes the given height as one of
+// the heights at which the execution state had the given state commitment.
+func (l *Library) IndexHeightForCommit(commit flow.StateCommitment, height uint64) func(*badger.Txn) error {
+	return l.save(l.key(PrefixHeightForCommit, commit, height), height)
 }
 
 // SaveCollection is an operation that writes the given collection.
 func (l *Library) SaveCollection(collection *flow.LightCollection) func(*badger.Txn) error {
-	return l.save(EncodeKey(PrefixCollection, collection.ID()), collection)
+	return l.save(l.key(PrefixCollection, collection.ID()), collection)
 }
 
 // SaveGuarantee is an operation that writes the given guarantee.
 func (l *Library) SaveGuarantee(guarantee *flow.CollectionGuarantee) func(*badger.Txn) error {
-	return l.save(EncodeKey(PrefixGuarantee, guarantee.CollectionID), guarantee)
+	return l.save(l.key(PrefixGuarantee, guarantee.CollectionID), guarantee)
 }
 
 // SaveSeal is an operation that writes the given seal.
 func (l *Library) SaveSeal(seal *flow.Seal) func(*badger.Txn) error {
-	return l.save(EncodeKey(PrefixSeal, seal.ID()), seal)
+	return l.save(l.key(PrefixSeal, seal.ID()), seal)
 }
 
 // IndexTransactionsForHeight is an operation that indexes the height of a slice of transaction identifiers.
 func (l *Library) IndexTransactionsForHeight(height uint64, txIDs []flow.Identifier) func(*badger.Txn) error {
-	return l.save(EncodeKey(PrefixTransactionsForHeight, height), txIDs)
+	return l.save(l.key(PrefixTransactionsForHeight, height), txIDs)
+}
+
+// IndexTransactionsForScript is an operation that indexes the identifiers of the transactions at the
+// given height whose script has the given hash.
+func (l *Library) IndexTransactionsForScript(hash flow.Identifier, height uint64, txIDs []flow.Identifier) func(*badger.Txn) error {
+	return l.save(l.key(PrefixTransactionsForScript, hash, height), txIDs)
 }
 
 // IndexTransactionsForCollection is an operation that indexes the collection identifier to which a slice
 // of transactions belongs.
 func (l *Library) IndexTransactionsForCollection(collID flow.Identifier, txIDs []flow.Identifier) func(*badger.Txn) error {
-	return l.save(EncodeKey(PrefixTransactionsForCollection, collID), txIDs)
+	return l.save(l.key(PrefixTransactionsForCollection, collID), txIDs)
 }
 
 // IndexCollectionsForHeight is an operation that indexes the height of a slice of collection identifiers.
 func (l *Library) IndexCollectionsForHeight(height uint64, collIDs []flow.Identifier) func(*badger.Txn) error {
-	return l.save(EncodeKey(PrefixCollectionsForHeight, height), collIDs)
+	return l.save(l.key(PrefixCollectionsForHeight, height), collIDs)
 }
 
 // IndexSealsForHeight is an operation that indexes the height of a slice of seal identifiers.
 func (l *Library) IndexSealsForHeight(height uint64, sealIDs []flow.Identifier) func(*badger.Txn) error {
-	return l.save(EncodeKey(PrefixSealsForHeight, height), sealIDs)
+	return l.save(l.key(PrefixSealsForHeight, height), sealIDs)
 }
 
 // SaveResult is an operation that writes the given transaction result.
 func (l *Library) SaveResult(result *flow.TransactionResult) func(*badger.Txn) error {
-	return l.save(EncodeKey(PrefixResults, result.TransactionID), result)
-}
-
-// RetrieveFirst retrieves the first indexed height.
-func (l *Library) RetrieveFirst(height *uint64) func(*badger.Txn) error {
-	return l.retrieve(EncodeKey(PrefixFirst), height)
+	return l.save(l.key(PrefixResults, result.TransactionID), result)
 }
 
-// RetrieveLast retrieves the last indexed height.
-func (l *Library) RetrieveLast(height *uint64) func(*badger.Txn) error {
-	return l.retrieve(EncodeKey(PrefixLast), height)
+// SaveCorruption is an operation that records that corrupted write-ahead log
+// data or execution records were skipped while indexing the given height,
+// along with the reason. Each distinct reason is recorded separately, so that
+// several corruptions skipped at the same height are all kept.
+func (l *Library) SaveCorruption(height uint64, reason string) func(*badger.Txn) error {
+	hash := xxhash.ChecksumString64(reason)
+	return l.save(l.key(PrefixCorruption, height, hash), reason)
 }
 
-// LookupHeightForBlock retrieves the height of the given block identifier.
-func (l *Library) LookupHeightForBlock(blockID flow.Identifier, height *uint64) func(*badger.Txn) error {
-	return l.retrieve(EncodeKey(PrefixHeightForBlock, blockID), height)
+// SaveExecutionRecord is an operation that archives the given execution record
+// of the block with the given ID, in its original encoding, so that data can
+// later be indexed from it without downloading it again.
+func (l *Library) SaveExecutionRecord(blockID flow.Identifier, data []byte) func(*badger.Txn) error {
+	return l.save(l.key(PrefixExecutionRecords, blockID), data)
 }
 
-// RetrieveHeader retrieves the header at the given height.
-func (l *Library) RetrieveHeader(height uint64, header *flow.Header) func(*badger.Txn) error {
-	return l.retrieve(EncodeKey(PrefixHeader, height), header)
+// SaveBackfill is an operation that records the progress of the backfill with
+// the given name.
+func (l *Library) SaveBackfill(backfill *dps.Backfill) func(*badger.Txn) error {
+	hash := xxhash.ChecksumString64(backfill.Name)
+	return l.save(l.key(PrefixBackfills, hash), backfill)
 }
 
-// RetrieveCommit retrieves the commit at the given height.
-func (l *Library) RetrieveCommit(height uint64, commit *flow.StateCommitment) func(*badger.Txn) error {
-	return l.retrieve(EncodeKey(PrefixCommit, height), commit)
+// SaveEventTypeStats is an operation that writes the statistics of an event
+// type to the registry of indexed event types.
+func (l *Library) SaveEventTypeStats(stats *dps.EventTypeStats) func(*badger.Txn) error {
+	hash := xxhash.ChecksumString64(string(stats.Type))
+	return l.save(l.key(PrefixEventTypeStats, hash), stats)
 }
 
-// RetrieveEvents retrieves the events at the given height that match with the specified types.
-// If no types were provided, all events are retrieved.
-func (l *Library) RetrieveEvents(height uint64, types []flow.EventType, events *[]flow.Event) func(*badger.Txn) error {
+// UpdateCompressionStats is an operation that adds the compression statistics
+// recorded since the last update to the persisted statistics of each key
+// prefix. As it reads the persisted statistics before writing them, it should
+// not be applied concurrently with other updates. It does nothing when
+// compression statistics are disabled.
+func (l *Library) UpdateCompressionStats() func(*badger.Txn) error {
 	return func(tx *badger.Txn) error {
-		lookup := make(map[uint64]struct{})
-		for _, typ := range types {
-			hash := xxhash.ChecksumString64(string(typ))
-			lookup[hash] = struct{}{}
+
+		if l.stats == nil {
+			return nil
 		}
 
-		prefix := EncodeKey(PrefixEvents, height)
-		opts := badger.DefaultIteratorOptions
-		// NOTE: this is an optimization only, it does not enforce that all
-		// results in the iteration have this prefix.
-		opts.Prefix = prefix
+		drained := l.stats.drain()
+		l.journal.record(tx, func() { l.stats.restore(drained) })
+		for _, delta := range drained {
 
-		it := tx.NewIterator(opts)
-		defer it.Close()
+			key := l.key(PrefixCompressionStats, uint64(delta.Prefix))
+			stats := dps.CompressionStats{Prefix: delta.Prefix}
+			err := l.retrieve(key, &stats)(tx)
+			if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+				return fmt.Errorf("could not retrieve compression statistics (prefix: %d): %w", delta.Prefix, err)
+			}
 
-		// Iterate on all keys with the right prefix.
-		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
-			// If types were given for filtering, discard events which should not be included.
-			hash := binary.BigEndian.Uint64(it.Item().Key()[1+8:])
-			_, ok := lookup[hash]
-			if len(lookup) != 0 && !ok {
-				continue
+			stats.Values += delta.Values
+			stats.RawBytes += delta.RawBytes
+			stats.StoredBytes += delta.StoredBytes
+
+			err = l.save(key, &stats)(tx)
+			if err != nil {
+				return fmt.Errorf("could not save compression statistics (prefix: %d): %w", delta.Prefix, err)
 			}
+		}
 
-			// Unmarshal event batch and append them to result slice.
-			var evts []flow.Event
-			err := it.Item().Value(func(val []byte) error {
-				return l.codec.Unmarshal(val, &evts)
-			})
+		return nil
+	}
+}
+
+// SaveSizeStats is an operation that persists the running size statistics of
+// all key prefixes. It does nothing when size statistics are disabled.
+func (l *Library) SaveSizeStats() func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		if l.sizes == nil {
+			return nil
+		}
+
+		for _, stats := range l.sizes.current() {
+			err := l.save(l.key(PrefixSizeStats, uint64(stats.Prefix)), stats)(tx)
 			if err != nil {
-				return fmt.Errorf("could not unmarshal events: %w", err)
+				return fmt.Errorf("could not save size statistics (prefix: %d): %w", stats.Prefix, err)
 			}
+		}
 
-			*events = append(*events, evts...)
+		return nil
+	}
+}
+
+// LoadSizeStats is an operation that loads the persisted size statistics, so
+// that the running size statistics continue from them. It should be applied
+// before any entries are written. It does nothing when size statistics are
+// disabled.
+func (l *Library) LoadSizeStats() func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		if l.sizes == nil {
+			return nil
+		}
+
+		var stats []dps.SizeStats
+		err := l.RetrieveSizeStats(context.Background(), &stats)(tx)
+		if err != nil {
+			return fmt.Errorf("could not retrieve size statistics: %w", err)
 		}
 
+		l.sizes.load(stats)
+
 		return nil
 	}
 }
 
-// RetrievePayload retrieves the ledger payloads at the given height that match the given path.
-func (l *Library) RetrievePayload(height uint64, path ledger.Path, payload *ledger.Payload) func(*badger.Txn) error {
+// SizeStats returns the running size statistics of the library, sorted by key
+// prefix. It returns nothing when size statistics are disabled.
+func (l *Library) SizeStats() []dps.SizeStats {
+	if l.sizes == nil {
+		return nil
+	}
+	return l.sizes.current()
+}
+
+// UpdatePathFilter is an operation that merges the given path filter into the
+// path filter persisted for the given window. If the height ranges of both
+// filters neither overlap nor are adjacent, the persisted filter is replaced
+// instead, as the merged filter would claim to cover the heights in between.
+// As it reads the persisted filter before writing it, it should not be applied
+// concurrently with other updates of the same window.
+func (l *Library) UpdatePathFilter(window uint64, filter *dps.PathFilter) func(*badger.Txn) error {
 	return func(tx *badger.Txn) error {
 
-		key := EncodeKey(PrefixPayload, path, height)
-		it := tx.NewIterator(badger.IteratorOptions{
-			PrefetchSize:   0,
-			PrefetchValues: false,
-			Reverse:        true,
-			AllVersions:    false,
-			InternalAccess: false,
-			Prefix:         key[:1+pathfinder.PathByteSize],
-		})
-		defer it.Close()
+		key := l.key(PrefixPathFilters, window)
+		var persisted dps.PathFilter
+		err := l.retrieve(key, &persisted)(tx)
+		if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+			return fmt.Errorf("could not retrieve path filter (window: %d): %w", window, err)
+		}
 
-		it.Seek(key)
-		if !it.Valid() {
-			return badger.ErrKeyNotFound
+		merged := *filter
+		if err == nil && persisted.From <= filter.To+1 && filter.From <= persisted.To+1 {
+			merged.Merge(&persisted)
 		}
 
-		err := it.Item().Value(func(val []byte) error {
-			return l.codec.Unmarshal(val, payload)
-		})
+		err = l.save(key, &merged)(tx)
+		if err != nil {
+			return fmt.Errorf("could not save path filter (window: %d): %w", window, err)
+		}
 
-		return err
+		return nil
 	}
 }
 
-// RetrieveCollection retrieves the collection with the given identifier.
-func (l *Library) RetrieveCollection(collectionID flow.Identifier, collection *flow.LightCollection) func(*badger.Txn) error {
-	return l.retrieve(EncodeKey(PrefixCollection, collectionID), collection)
+// IndexHeightForAccount is an operation that writes the height at which the
+// account with the given address was created.
+func (l *Library) IndexHeightForAccount(address flow.Address, height uint64) func(*badger.Txn) error {
+	return l.save(l.key(PrefixHeightForAccount, address), height)
 }
 
-// RetrieveGuarantee retrieves the guarantee with the given collection identifier.
-func (l *Library) RetrieveGuarantee(collectionID flow.Identifier, guarantee *flow.CollectionGuarantee) func(*badger.Txn) error {
-	return l.retrieve(EncodeKey(PrefixGuarantee, collectionID), guarantee)
+// IndexKeyUpdate is an operation that records the number of keys that were
+// added to or removed from the account with the given address at the given
+// height.
+func (l *Library) IndexKeyUpdate(address flow.Address, height uint64, count uint) func(*badger.Txn) error {
+	return l.save(l.key(PrefixKeyUpdates, address, height), count)
 }
 
-// RetrieveTransaction retrieves the transaction with the given identifier.
-func (l *Library) RetrieveTransaction(transactionID flow.Identifier, transaction *flow.TransactionBody) func(*badger.Txn) error {
-	return l.retrieve(EncodeKey(PrefixTransaction, transactionID), transaction)
+// IndexPathForOwner is an operation that records the path of a register owned
+// by the account with the given address, along with the last height at which
+// the register was written.
+func (l *Library) IndexPathForOwner(address flow.Address, path ledger.Path, height uint64) func(*badger.Txn) error {
+	return l.save(l.key(PrefixPathsForOwner, address, path), height)
 }
 
-// LookupHeightForTransaction retrieves the height of the transaction with the given identifier.
-func (l *Library) LookupHeightForTransaction(txID flow.Identifier, height *uint64) func(*badger.Txn) error {
-	return l.retrieve(EncodeKey(PrefixHeightForTransaction, txID), height)
+// SaveContractVersions is an operation that writes the changes to the contract
+// with the given name on the account with the given address at the given height.
+func (l *Library) SaveContractVersions(address flow.Address, name string, height uint64, versions []dps.ContractVersion) func(*badger.Txn) error {
+	hash := xxhash.ChecksumString64(name)
+	return l.save(l.key(PrefixContractVersions, address, hash, height), versions)
 }
 
-// RetrieveSeal retrieves the seal with the given identifier.
-func (l *Library) RetrieveSeal(sealID flow.Identifier, seal *flow.Seal) func(*badger.Txn) error {
-	return l.retrieve(EncodeKey(PrefixSeal, sealID), seal)
+// SaveBlockStats is an operation that writes the aggregate statistics of the
+// block at the height of the given statistics.
+func (l *Library) SaveBlockStats(stats dps.BlockStats) func(*badger.Txn) error {
+	return l.save(l.key(PrefixBlockStats, stats.Height), stats)
 }
 
-// LookupCollectionsForHeight retrieves the identifiers of collections at the given height.
-func (l *Library) LookupCollectionsForHeight(height uint64, collIDs *[]flow.Identifier) func(*badger.Txn) error {
-	return l.retrieve(EncodeKey(PrefixCollectionsForHeight, height), collIDs)
+// SaveServiceEvents is an operation that writes the service events emitted at
+// the given height.
+func (l *Library) SaveServiceEvents(height uint64, events []flow.Event) func(*badger.Txn) error {
+	return l.save(l.key(PrefixServiceEvents, height), events)
 }
 
-// LookupTransactionsForHeight retrieves the identifiers of transactions at the given height.
-func (l *Library) LookupTransactionsForHeight(height uint64, txIDs *[]flow.Identifier) func(*badger.Txn) error {
-	return l.retrieve(EncodeKey(PrefixTransactionsForHeight, height), txIDs)
+// SaveEpoch is an operation that writes the description of an epoch.
+func (l *Library) SaveEpoch(epoch *dps.Epoch) func(*badger.Txn) error {
+	return l.save(l.key(PrefixEpochs, epoch.Counter), epoch)
 }
 
-// LookupTransactionsForCollection retrieves the identifiers of transactions within the collection
-// with the given identifier.
-func (l *Library) LookupTransactionsForCollection(collID flow.Identifier, txIDs *[]flow.Identifier) func(*badger.Txn) error {
-	return l.retrieve(EncodeKey(PrefixTransactionsForCollection, collID), txIDs)
+// IndexEpochPhase is an operation that indexes the height at which the given
+// phase of the epoch with the given counter started.
+func (l *Library) IndexEpochPhase(counter uint64, phase flow.EpochPhase, height uint64) func(*badger.Txn) error {
+	return l.save(l.key(PrefixEpochPhases, counter, uint64(phase)), height)
 }
 
-// LookupSealsForHeight retrieves the identifiers of seals at the given height.
-func (l *Library) LookupSealsForHeight(height uint64, sealIDs *[]flow.Identifier) func(*badger.Txn) error {
-	return l.retrieve(EncodeKey(PrefixSealsForHeight, height), sealIDs)
+// SaveIdentities is an operation that writes the identity table of the epoch
+// with the given counter.
+func (l *Library) SaveIdentities(counter uint64, identities flow.IdentityList) func(*badger.Txn) error {
+	return l.save(l.key(PrefixIdentities, counter), identities)
 }
 
-// RetrieveResult retrieves the result with the given transaction identifier.
-func (l *Library) RetrieveResult(txID flow.Identifier, result *flow.TransactionResult) func(*badger.Txn) error {
-	return l.retrieve(EncodeKey(PrefixResults, txID), result)
+// SaveOwners is an operation that records the owner addresses to which the
+// indexing of ledger registers was restricted.
+func (l *Library) SaveOwners(owners []flow.Address) func(*badger.Txn) error {
+	return l.save(l.key(PrefixOwners), owners)
 }
 
-// IterateLedger steps through the entire ledger for ledger keys and payloads
-// and call the given callback for each of them.
-func (l *Library) IterateLedger(exclude func(height uint64) bool, process func(path ledger.Path, payload *ledger.Payload) error) func(*badger.Txn) error {
+// SaveManifest is an operation that stores the integrity manifest of the given
+// height, which covers the entries saved since the previous manifest, and then
+// starts a new manifest for the next height. It does nothing when integrity
+// manifests are disabled.
+func (l *Library) SaveManifest(height uint64) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
 
-	prefix := EncodeKey(PrefixPayload)
-	opts := badger.IteratorOptions{
-		PrefetchSize:   100,
-		PrefetchValues: false,
-		Reverse:        true,
-		AllVersions:    false,
-		InternalAccess: false,
-		Prefix:         prefix,
+		if l.manifest == nil {
+			return nil
+		}
+
+		manifest := l.manifest.manifest(height)
+		err := l.save(l.key(PrefixManifests, height), &manifest)(tx)
+		if err != nil {
+			return fmt.Errorf("could not save manifest (height: %d): %w", height, err)
+		}
+
+		entries := l.manifest.reset()
+		l.journal.record(tx, func() { l.manifest.restore(entries) })
+
+		return nil
 	}
-	highest := ledger.Path{
-		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
-		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
-		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
-		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+}
+
+// SaveStaging is an operation that stores the previous values of the entries
+// saved since the previous staged height in the staging keyspace, for the given
+// height, and then starts recording the entries of the next height. It does
+// nothing when staging is disabled.
+func (l *Library) SaveStaging(height uint64) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		if l.staging == nil {
+			return nil
+		}
+
+		entries := l.staging.staged()
+		err := l.save(l.key(PrefixStaging, height), entries)(tx)
+		if err != nil {
+			return fmt.Errorf("could not save staged entries (height: %d): %w", height, err)
+		}
+
+		previous := l.staging.reset()
+		l.journal.record(tx, func() { l.staging.restore(previous) })
+
+		return nil
 	}
+}
 
+// PromoteStaging is an operation that promotes the given staged height, by
+// deleting its previous values from the staging keyspace. The height can no
+// longer be rolled back afterwards.
+func (l *Library) PromoteStaging(height uint64) func(*badger.Txn) error {
 	return func(tx *badger.Txn) error {
+		key := l.key(PrefixStaging, height)
+		err := l.delete(tx, key)
+		if err != nil {
+			return fmt.Errorf("could not delete staged entries (key: %x): %w", key, err)
+		}
+		return nil
+	}
+}
 
-		it := tx.NewIterator(opts)
-		defer it.Close()
+// RollbackStaging is an operation that rolls back the given staged height, by
+// restoring the previous values of all entries that were saved for it, and
+// deleting the entries that did not exist before. Heights have to be rolled
+// back from the most recent one downwards.
+func (l *Library) RollbackStaging(height uint64) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
 
-		sentinel := EncodeKey(PrefixPayload, highest, uint64(math.MaxUint64))
-		for it.Seek(sentinel); it.ValidForPrefix(prefix); {
+		key := l.key(PrefixStaging, height)
+		var entries []stagedEntry
+		err := l.retrieve(key, &entries)(tx)
+		if err != nil {
+			return fmt.Errorf("could not retrieve staged entries (height: %d): %w", height, err)
+		}
 
-			// First, we extract the height from the item's key, and check if
-			// we should just skip past this entry.
-			item := it.Item()
-			key := item.Key()
-			height := binary.BigEndian.Uint64(key[33:41])
-			if exclude(height) {
-				it.Next()
-				continue
+		for _, entry := range entries {
+			if entry.Found {
+				err = l.set(tx, entry.Key, entry.Value)
+			} else {
+				err = l.delete(tx, entry.Key)
+			}
+			if err != nil {
+				return fmt.Errorf("could not restore staged entry (key: %x): %w", entry.Key, err)
 			}
+		}
 
-			// Next, we can get the path from the key and the payload from the
-			// value.
-			var path ledger.Path
-			var payload ledger.Payload
-			copy(path[:], key[1:33])
-			err := item.Value(func(val []byte) error {
-				err := l.codec.Unmarshal(val, &payload)
-				if err != nil {
-					return err
-				}
+		err = l.delete(tx, key)
+		if err != nil {
+			return fmt.Errorf("could not delete staged entries (key: %x): %w", key, err)
+		}
+
+		return nil
+	}
+}
+
+// SaveSegment is an operation that records a segment of the cold tier.
+func (l *Library) SaveSegment(segment *dps.Segment) func(*badger.Txn) error {
+	return l.save(l.key(PrefixSegments, segment.Number), segment)
+}
+
+// SaveColdPayload is an operation that replaces the stored value of the payload
+// at the given height and path with the location of the value in the cold tier.
+// The stored value is only replaced if it is still the given one, so that a
+// payload that was indexed again in the meantime is left as it is.
+func (l *Library) SaveColdPayload(height uint64, path ledger.Path, value []byte, cold dps.ColdValue) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		key := l.key(PrefixPayload, path, height)
+		item, err := tx.Get(key)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not get value (key: %x): %w", key, err)
+		}
+
+		stored, err := item.ValueCopy(nil)
+		if err != nil {
+			return fmt.Errorf("could not copy value (key: %x): %w", key, err)
+		}
+		if !bytes.Equal(stored, value) {
+			return nil
+		}
+
+		err = l.set(tx, key, encodeCold(cold))
+		if err != nil {
+			return fmt.Errorf("could not set value (key: %x): %w", key, err)
+		}
+
+		return nil
+	}
+}
+
+// SaveDenied is an operation that records the addresses of the accounts whose
+// data is excluded from indexing.
+func (l *Library) SaveDenied(denied []flow.Address) func(*badger.Txn) error {
+	return l.save(l.key(PrefixDenied), denied)
+}
+
+// SaveCodec is an operation that records the name of the codec with which the
+// values of the index are stored. The name is stored as is, so that it can be
+// read before knowing which codec to use.
+func (l *Library) SaveCodec(name string) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+		return l.set(tx, l.key(PrefixCodec), []byte(name))
+	}
+}
+
+// AcquireFence is an operation that acquires the fence of the index for the
+// given holder, with a token higher than any previously given out. It fails if
+// the fence is held by another holder, unless forced to take it over, in which
+// case the previous holder can no longer pass the fence check.
+func (l *Library) AcquireFence(holder string, force bool, fence *dps.Fence) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		key := l.key(PrefixFence)
+		var current dps.Fence
+		err := l.retrieve(key, &current)(tx)
+		if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+			return fmt.Errorf("could not retrieve fence: %w", err)
+		}
+
+		if current.Held() && !force {
+			return fmt.Errorf("index is held by another writer (holder: %s, token: %d): %w", current.Holder, current.Token, dps.ErrFenced)
+		}
+
+		acquired := dps.Fence{
+			Token:  current.Token + 1,
+			Holder: holder,
+		}
+		err = l.save(key, &acquired)(tx)
+		if err != nil {
+			return fmt.Errorf("could not save fence: %w", err)
+		}
+
+		*fence = acquired
+
+		return nil
+	}
+}
+
+// CheckFence is an operation that fails if the fence of the index is no longer
+// held with the given token. As it reads the fence, a transaction that applies
+// it can not be committed once the fence was acquired by another writer.
+func (l *Library) CheckFence(token uint64) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		var current dps.Fence
+		err := l.retrieve(l.key(PrefixFence), &current)(tx)
+		if err != nil {
+			return fmt.Errorf("could not retrieve fence: %w", err)
+		}
+
+		if !current.Held() || current.Token != token {
+			return fmt.Errorf("fence was taken over (token: %d, current: %d): %w", token, current.Token, dps.ErrFenced)
+		}
+
+		return nil
+	}
+}
+
+// ReleaseFence is an operation that releases the fence of the index, if it is
+// still held with the given token. The token is kept, so that the next holder
+// gets a higher one.
+func (l *Library) ReleaseFence(token uint64) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		key := l.key(PrefixFence)
+		var current dps.Fence
+		err := l.retrieve(key, &current)(tx)
+		if err != nil {
+			return fmt.Errorf("could not retrieve fence: %w", err)
+		}
+
+		if current.Token != token {
+			return nil
+		}
+
+		released := dps.Fence{
+			Token: current.Token,
+		}
+		err = l.save(key, &released)(tx)
+		if err != nil {
+			return fmt.Errorf("could not save fence: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// RetrieveFirst retrieves the first indexed height.
+func (l *Library) RetrieveFirst(height *uint64) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixFirst), height)
+}
+
+// RetrieveCodec retrieves the name of the codec with which the values of the
+// index are stored. Indexes that were created before the codec was recorded
+// were always stored with the zbor codec, so it is returned for any index that
+// has a first height without a recorded codec.
+func (l *Library) RetrieveCodec(name *string) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		item, err := tx.Get(l.key(PrefixCodec))
+		if err == nil {
+			return item.Value(func(val []byte) error {
+				*name = string(val)
 				return nil
 			})
-			if err != nil {
-				return fmt.Errorf("could not decode value (path: %x): %w", path, err)
-			}
+		}
+		if !errors.Is(err, badger.ErrKeyNotFound) {
+			return fmt.Errorf("could not get codec: %w", err)
+		}
 
-			// Then, we process the ledger path and payload with the callback.
-			err = process(path, &payload)
-			if err != nil {
-				return fmt.Errorf("could not process register (path: %x): %w", path, err)
-			}
+		_, err = tx.Get(l.key(PrefixFirst))
+		if err != nil {
+			return fmt.Errorf("could not get first height: %w", err)
+		}
 
-			// We need want to go to the first value that is below the current
-			// path. In order to cover all potential cases, including payloads
-			// at height zero, we need to decrement the current path by one and
-			// use the maximum possible height. If the decrement doesn't work,
-			// we have reached the zero path and we can break; otherwise, we
-			// would just wrap around to the maximum key again.
-			var zero ledger.Path
-			if path == zero {
-				break
-			}
+		*name = dps.CodecZbor
+
+		return nil
+	}
+}
+
+// RetrieveLast retrieves the last indexed height.
+func (l *Library) RetrieveLast(height *uint64) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixLast), height)
+}
+
+// LookupHeightForBlock retrieves the height of the given block identifier.
+func (l *Library) LookupHeightForBlock(blockID flow.Identifier, height *uint64) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixHeightForBlock, blockID), height)
+}
+
+// RetrieveHeader retrieves the header at the given height.
+func (l *Library) RetrieveHeader(height uint64, header *flow.Header) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixHeader, height), header)
+}
+
+// LookupHeights retrieves the heights in the given range for which a header
+// was indexed, in ascending order. Only the keys of the headers are read, so
+// that the whole sequence of indexed heights can be scanned efficiently.
+func (l *Library) LookupHeights(ctx context.Context, from uint64, to uint64, heights *[]uint64) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		prefix := l.key(PrefixHeader)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		opts.PrefetchValues = false
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(l.key(PrefixHeader, from)); it.ValidForPrefix(prefix); it.Next() {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+			height := binary.BigEndian.Uint64(it.Item().Key()[len(prefix):])
+			if height > to {
+				break
+			}
+			*heights = append(*heights, height)
+		}
+
+		return nil
+	}
+}
+
+// RetrieveCommit retrieves the commit at the given height.
+func (l *Library) RetrieveCommit(height uint64, commit *flow.StateCommitment) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixCommit, height), commit)
+}
+
+// RetrieveEvents retrieves the events at the given height that match with the specified types.
+// If no types were provided, all events are retrieved.
+func (l *Library) RetrieveEvents(ctx context.Context, height uint64, types []flow.EventType, events *[]flow.Event) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+		return l.iterateEvents(ctx, tx, height, types, func(item *badger.Item) error {
+
+			// Unmarshal event batch and append them to result slice.
+			var evts []flow.Event
+			err := item.Value(func(val []byte) error {
+				return l.codec.Unmarshal(val, &evts)
+			})
+			if err != nil {
+				return fmt.Errorf("could not unmarshal events: %w", err)
+			}
+
+			*events = append(*events, evts...)
+
+			return nil
+		})
+	}
+}
+
+// iterateEvents calls the given function for each batch of events at the given
+// height that matches the given types, in the order of their keys. If no types
+// are given, all batches are iterated on. Otherwise, the batches of the given
+// types are looked up directly, so that the batches of other types, which can
+// be much larger, are never read.
+func (l *Library) iterateEvents(ctx context.Context, tx *badger.Txn, height uint64, types []flow.EventType, fn func(*badger.Item) error) error {
+
+	if len(types) == 0 {
+		prefix := l.key(PrefixEvents, height)
+		opts := badger.DefaultIteratorOptions
+		// NOTE: this is an optimization only, it does not enforce that all
+		// results in the iteration have this prefix.
+		opts.Prefix = prefix
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		// Iterate on all keys with the right prefix.
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+			err = fn(it.Item())
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	// The batches are keyed by the hash of their type, so we look them up in
+	// the order of their hashes to return them in the same order as above.
+	lookup := make(map[uint64]struct{}, len(types))
+	hashes := make([]uint64, 0, len(types))
+	for _, typ := range types {
+		hash := xxhash.ChecksumString64(string(typ))
+		_, ok := lookup[hash]
+		if ok {
+			continue
+		}
+		lookup[hash] = struct{}{}
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return hashes[i] < hashes[j]
+	})
+
+	for _, hash := range hashes {
+		err := l.step(ctx)
+		if err != nil {
+			return err
+		}
+		item, err := tx.Get(l.key(PrefixEvents, height, hash))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("could not get events (hash: %x): %w", hash, err)
+		}
+		err = fn(item)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RetrievePayload retrieves the ledger payloads at the given height that match the given path.
+func (l *Library) RetrievePayload(height uint64, path ledger.Path, payload *ledger.Payload) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		key := l.key(PrefixPayload, path, height)
+		it := tx.NewIterator(badger.IteratorOptions{
+			PrefetchSize:   0,
+			PrefetchValues: false,
+			Reverse:        true,
+			AllVersions:    false,
+			InternalAccess: false,
+			Prefix:         key[:len(l.namespace)+1+pathfinder.PathByteSize],
+		})
+		defer it.Close()
+
+		it.Seek(key)
+		if !it.Valid() {
+			return badger.ErrKeyNotFound
+		}
+
+		err := it.Item().Value(func(val []byte) error {
+			return l.unmarshal(val, payload)
+		})
+		if err != nil {
+			return err
+		}
+
+		return l.verifyPayload(path, payload)
+	}
+}
+
+// RetrieveCollection retrieves the collection with the given identifier.
+func (l *Library) RetrieveCollection(collectionID flow.Identifier, collection *flow.LightCollection) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixCollection, collectionID), collection)
+}
+
+// RetrieveGuarantee retrieves the guarantee with the given collection identifier.
+func (l *Library) RetrieveGuarantee(collectionID flow.Identifier, guarantee *flow.CollectionGuarantee) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixGuarantee, collectionID), guarantee)
+}
+
+// RetrieveTransaction retrieves the transaction with the given identifier.
+func (l *Library) RetrieveTransaction(transactionID flow.Identifier, transaction *flow.TransactionBody) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixTransaction, transactionID), transaction)
+}
+
+// LookupHeightForTransaction retrieves the height of the transaction with the given identifier.
+func (l *Library) LookupHeightForTransaction(txID flow.Identifier, height *uint64) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixHeightForTransaction, txID), height)
+}
+
+// LookupHeightForCommit retrieves the first height at which the execution state
+// had the given state commitment. Blocks without transactions do not change the
+// execution state, so several consecutive heights can share the same commit.
+func (l *Library) LookupHeightForCommit(commit flow.StateCommitment, height *uint64) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		prefix := l.key(PrefixHeightForCommit, commit)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		opts.PrefetchValues = false
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		it.Seek(prefix)
+		if !it.ValidForPrefix(prefix) {
+			return badger.ErrKeyNotFound
+		}
+
+		*height = binary.BigEndian.Uint64(it.Item().Key()[len(prefix):])
+
+		return nil
+	}
+}
+
+// RetrieveSeal retrieves the seal with the given identifier.
+func (l *Library) RetrieveSeal(sealID flow.Identifier, seal *flow.Seal) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixSeal, sealID), seal)
+}
+
+// LookupCollectionsForHeight retrieves the identifiers of collections at the given height.
+func (l *Library) LookupCollectionsForHeight(height uint64, collIDs *[]flow.Identifier) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixCollectionsForHeight, height), collIDs)
+}
+
+// LookupTransactionsForHeight retrieves the identifiers of transactions at the given height.
+func (l *Library) LookupTransactionsForHeight(height uint64, txIDs *[]flow.Identifier) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixTransactionsForHeight, height), txIDs)
+}
+
+// LookupTransactionsForScript retrieves the identifiers of the transactions whose script has the
+// given hash, between the given start and end heights, inclusively, in ascending order of height.
+func (l *Library) LookupTransactionsForScript(ctx context.Context, hash flow.Identifier, start uint64, end uint64, txIDs *[]flow.Identifier) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		prefix := l.key(PrefixTransactionsForScript, hash)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(l.key(PrefixTransactionsForScript, hash, start)); it.ValidForPrefix(prefix); it.Next() {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+			item := it.Item()
+			height := binary.BigEndian.Uint64(item.Key()[len(prefix):])
+			if height > end {
+				break
+			}
+
+			var ids []flow.Identifier
+			err = item.Value(func(val []byte) error {
+				return l.codec.Unmarshal(val, &ids)
+			})
+			if err != nil {
+				return fmt.Errorf("could not decode transaction identifiers (height: %d): %w", height, err)
+			}
+
+			*txIDs = append(*txIDs, ids...)
+		}
+
+		return nil
+	}
+}
+
+// LookupTransactionsForCollection retrieves the identifiers of transactions within the collection
+// with the given identifier.
+func (l *Library) LookupTransactionsForCollection(collID flow.Identifier, txIDs *[]flow.Identifier) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixTransactionsForCollection, collID), txIDs)
+}
+
+// LookupSealsForHeight retrieves the identifiers of seals at the given height.
+func (l *Library) LookupSealsForHeight(height uint64, sealIDs *[]flow.Identifier) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixSealsForHeight, height), sealIDs)
+}
+
+// RetrieveResult retrieves the result with the given transaction identifier.
+func (l *Library) RetrieveResult(txID flow.Identifier, result *flow.TransactionResult) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixResults, txID), result)
+}
+
+// RetrieveOwners retrieves the owner addresses to which the indexing of ledger
+// registers was restricted.
+func (l *Library) RetrieveOwners(owners *[]flow.Address) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixOwners), owners)
+}
+
+// RetrieveDenied retrieves the addresses of the accounts whose data is excluded
+// from indexing.
+func (l *Library) RetrieveDenied(denied *[]flow.Address) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixDenied), denied)
+}
+
+// LookupHeightForAccount retrieves the height at which the account with the
+// given address was created.
+func (l *Library) LookupHeightForAccount(address flow.Address, height *uint64) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixHeightForAccount, address), height)
+}
+
+// LookupKeyUpdates retrieves the heights at which keys were added to or removed
+// from the account with the given address, in ascending order.
+func (l *Library) LookupKeyUpdates(ctx context.Context, address flow.Address, heights *[]uint64) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		prefix := l.key(PrefixKeyUpdates, address)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		opts.PrefetchValues = false
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+			height := binary.BigEndian.Uint64(it.Item().Key()[len(prefix):])
+			*heights = append(*heights, height)
+		}
+
+		return nil
+	}
+}
+
+// LookupPathsForOwner retrieves up to the given number of paths of registers
+// owned by the account with the given address, in ascending order, starting
+// after the given path. The zero path starts from the first path.
+func (l *Library) LookupPathsForOwner(ctx context.Context, address flow.Address, after ledger.Path, limit uint, paths *[]ledger.Path) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		prefix := l.key(PrefixPathsForOwner, address)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		opts.PrefetchValues = false
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		start := l.key(PrefixPathsForOwner, address, after)
+		for it.Seek(start); it.ValidForPrefix(prefix) && uint(len(*paths)) < limit; it.Next() {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+			var path ledger.Path
+			copy(path[:], it.Item().Key()[len(prefix):])
+			if path == after && after != (ledger.Path{}) {
+				continue
+			}
+			*paths = append(*paths, path)
+		}
+
+		return nil
+	}
+}
+
+// LookupPaths retrieves up to the given number of paths of registers of the
+// execution state, in ascending order, starting after the given path. The zero
+// path starts from the first path.
+func (l *Library) LookupPaths(ctx context.Context, after ledger.Path, limit uint, paths *[]ledger.Path) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		prefix := l.key(PrefixPayload)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		opts.PrefetchValues = false
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		// There is one payload entry per height at which a register changed,
+		// so after each path, we seek past all of its heights. No payload is
+		// ever indexed at the maximum height, which means that seeking to it
+		// lands on the first entry of the next path.
+		next := prefix
+		if after != (ledger.Path{}) {
+			next = l.key(PrefixPayload, after, uint64(math.MaxUint64))
+		}
+		for it.Seek(next); it.ValidForPrefix(prefix) && uint(len(*paths)) < limit; it.Seek(next) {
+			var path ledger.Path
+			copy(path[:], it.Item().Key()[len(prefix):])
+			*paths = append(*paths, path)
+			next = l.key(PrefixPayload, path, uint64(math.MaxUint64))
+		}
+
+		return nil
+	}
+}
+
+// RetrieveContractVersions retrieves all changes to the contract with the given
+// name on the account with the given address, in ascending order of height.
+func (l *Library) RetrieveContractVersions(ctx context.Context, address flow.Address, name string, versions *[]dps.ContractVersion) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		hash := xxhash.ChecksumString64(name)
+		prefix := l.key(PrefixContractVersions, address, hash)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+			item := it.Item()
+
+			var entries []dps.ContractVersion
+			err = item.Value(func(val []byte) error {
+				return l.codec.Unmarshal(val, &entries)
+			})
+			if err != nil {
+				return fmt.Errorf("could not decode contract versions (key: %x): %w", item.Key(), err)
+			}
+
+			// Different contract names could have the same hash, so we make
+			// sure to only keep the versions of the requested contract.
+			for _, entry := range entries {
+				if entry.Name != name {
+					continue
+				}
+				*versions = append(*versions, entry)
+			}
+		}
+
+		return nil
+	}
+}
+
+// RetrieveRegisterChurn retrieves the register churn of the blocks between the
+// given start and end heights, inclusively, in ascending order of height.
+// Heights without register churn are skipped.
+func (l *Library) RetrieveRegisterChurn(ctx context.Context, start uint64, end uint64, churns *[]dps.RegisterChurn) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		prefix := l.key(PrefixRegisterChurn)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(l.key(PrefixRegisterChurn, start)); it.ValidForPrefix(prefix); it.Next() {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+			item := it.Item()
+			height := binary.BigEndian.Uint64(item.Key()[len(prefix):])
+			if height > end {
+				break
+			}
+
+			var churn dps.RegisterChurn
+			err = item.Value(func(val []byte) error {
+				return l.codec.Unmarshal(val, &churn)
+			})
+			if err != nil {
+				return fmt.Errorf("could not decode register churn (height: %d): %w", height, err)
+			}
+
+			*churns = append(*churns, churn)
+		}
+
+		return nil
+	}
+}
+
+// RetrieveRegisterDeltas retrieves up to the given number of changes to the
+// registers made by the finalized block at the given height, in ascending order
+// of their paths, starting after the given path. The zero path starts from the
+// first change.
+func (l *Library) RetrieveRegisterDeltas(ctx context.Context, height uint64, after ledger.Path, limit uint, deltas *[]dps.RegisterDelta) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		prefix := l.key(PrefixRegisterDeltas, height)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(l.key(PrefixRegisterDeltas, height, after)); it.ValidForPrefix(prefix) && uint(len(*deltas)) < limit; it.Next() {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+			item := it.Item()
+			var path ledger.Path
+			copy(path[:], item.Key()[len(prefix):])
+			if after != (ledger.Path{}) && path == after {
+				continue
+			}
+
+			var delta dps.RegisterDelta
+			err = item.Value(func(val []byte) error {
+				return l.codec.Unmarshal(val, &delta)
+			})
+			if err != nil {
+				return fmt.Errorf("could not decode register delta (path: %x): %w", path, err)
+			}
+
+			*deltas = append(*deltas, delta)
+		}
+
+		return nil
+	}
+}
+
+// RetrieveBlockStats retrieves the aggregate statistics of the blocks between
+// the given start and end heights, inclusively, in ascending order of height.
+// Heights without statistics are skipped.
+func (l *Library) RetrieveBlockStats(ctx context.Context, start uint64, end uint64, stats *[]dps.BlockStats) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		prefix := l.key(PrefixBlockStats)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(l.key(PrefixBlockStats, start)); it.ValidForPrefix(prefix); it.Next() {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+			item := it.Item()
+			height := binary.BigEndian.Uint64(item.Key()[len(prefix):])
+			if height > end {
+				break
+			}
+
+			var entry dps.BlockStats
+			err = item.Value(func(val []byte) error {
+				return l.codec.Unmarshal(val, &entry)
+			})
+			if err != nil {
+				return fmt.Errorf("could not decode block stats (height: %d): %w", height, err)
+			}
+
+			*stats = append(*stats, entry)
+		}
+
+		return nil
+	}
+}
+
+// RetrieveServiceEvents retrieves the service events emitted between the given
+// start and end heights, inclusively, that match with the specified types. If
+// no types were provided, all service events are retrieved.
+func (l *Library) RetrieveServiceEvents(ctx context.Context, start uint64, end uint64, types []flow.EventType, events *[]dps.ServiceEvent) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+		lookup := make(map[flow.EventType]struct{})
+		for _, typ := range types {
+			lookup[typ] = struct{}{}
+		}
+
+		prefix := l.key(PrefixServiceEvents)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		// Service events are only indexed at the heights at which they were
+		// emitted, so we can iterate over the range of heights without having
+		// to look at every height of it.
+		for it.Seek(l.key(PrefixServiceEvents, start)); it.ValidForPrefix(prefix); it.Next() {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+			item := it.Item()
+			height := binary.BigEndian.Uint64(item.Key()[len(prefix):])
+			if height > end {
+				break
+			}
+
+			var evts []flow.Event
+			err = item.Value(func(val []byte) error {
+				return l.codec.Unmarshal(val, &evts)
+			})
+			if err != nil {
+				return fmt.Errorf("could not decode service events (height: %d): %w", height, err)
+			}
+
+			for _, event := range evts {
+				_, ok := lookup[event.Type]
+				if len(lookup) != 0 && !ok {
+					continue
+				}
+				*events = append(*events, dps.ServiceEvent{Height: height, Event: event})
+			}
+		}
+
+		return nil
+	}
+}
+
+// RetrieveEpochs retrieves the descriptions of all indexed epochs, in
+// ascending order of their counters.
+func (l *Library) RetrieveEpochs(ctx context.Context, epochs *[]dps.Epoch) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		prefix := l.key(PrefixEpochs)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+			item := it.Item()
+
+			var epoch dps.Epoch
+			err = item.Value(func(val []byte) error {
+				return l.codec.Unmarshal(val, &epoch)
+			})
+			if err != nil {
+				return fmt.Errorf("could not decode epoch (key: %x): %w", item.Key(), err)
+			}
+
+			*epochs = append(*epochs, epoch)
+		}
+
+		return nil
+	}
+}
+
+// LookupEpochPhase retrieves the height at which the given phase of the epoch
+// with the given counter started.
+func (l *Library) LookupEpochPhase(counter uint64, phase flow.EpochPhase, height *uint64) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixEpochPhases, counter, uint64(phase)), height)
+}
+
+// RetrieveIdentities retrieves the identity table of the epoch with the given
+// counter.
+func (l *Library) RetrieveIdentities(counter uint64, identities *flow.IdentityList) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixIdentities, counter), identities)
+}
+
+// RetrieveEventTypeStats retrieves the statistics of all event types in the
+// registry of indexed event types.
+func (l *Library) RetrieveEventTypeStats(ctx context.Context, stats *[]dps.EventTypeStats) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		prefix := l.key(PrefixEventTypeStats)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+			item := it.Item()
+
+			var entry dps.EventTypeStats
+			err = item.Value(func(val []byte) error {
+				return l.codec.Unmarshal(val, &entry)
+			})
+			if err != nil {
+				return fmt.Errorf("could not decode event type statistics (key: %x): %w", item.Key(), err)
+			}
+
+			*stats = append(*stats, entry)
+		}
+
+		return nil
+	}
+}
+
+// RetrievePathFilter retrieves the path filter persisted for the given window.
+func (l *Library) RetrievePathFilter(window uint64, filter *dps.PathFilter) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixPathFilters, window), filter)
+}
+
+// RetrieveManifest retrieves the integrity manifest stored for the given height.
+func (l *Library) RetrieveManifest(height uint64, manifest *dps.Manifest) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixManifests, height), manifest)
+}
+
+// LookupStaging retrieves the staged heights, which were indexed but neither
+// promoted nor rolled back yet, in ascending order.
+func (l *Library) LookupStaging(ctx context.Context, heights *[]uint64) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		prefix := l.key(PrefixStaging)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		opts.PrefetchValues = false
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+			height := binary.BigEndian.Uint64(it.Item().Key()[len(prefix):])
+			*heights = append(*heights, height)
+		}
+
+		return nil
+	}
+}
+
+// ComputeManifests computes the integrity manifests of the heights in the given
+// range from the entries that are currently in the index, so that they can be
+// compared with the stored manifests. Entries keyed by height are found within
+// the range, while entries with the height further in the key, or in the value,
+// require a scan of their whole prefix. Entries keyed by identifier are found
+// through the identifiers indexed for each height.
+func (l *Library) ComputeManifests(ctx context.Context, from uint64, to uint64, manifests map[uint64]*dps.Manifest) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		for height := from; height <= to; height++ {
+			manifests[height] = &dps.Manifest{Height: height}
+		}
+
+		add := func(height uint64, item *badger.Item) error {
+			manifest, ok := manifests[height]
+			if !ok {
+				return nil
+			}
+			// Values that were moved to the cold tier are read from it, so
+			// that their integrity is verified as well.
+			return item.Value(func(val []byte) error {
+				val, err := l.resolve(val)
+				if err != nil {
+					return err
+				}
+				manifest.Add(item.Key(), val)
+				return nil
+			})
+		}
+
+		// Entries whose key starts with the height can be iterated for the
+		// range of heights only.
+		for _, prefix := range []uint8{
+			PrefixCommit,
+			PrefixHeader,
+			PrefixBlockStats,
+			PrefixRegisterChurn,
+			PrefixRegisterDeltas,
+			PrefixEvents,
+			PrefixTransactionsForHeight,
+			PrefixCollectionsForHeight,
+			PrefixSealsForHeight,
+			PrefixServiceEvents,
+			PrefixCorruption,
+		} {
+			err := l.iterate(ctx, tx, l.key(prefix), l.key(prefix, from), func(item *badger.Item, segment []byte) (bool, error) {
+				height := binary.BigEndian.Uint64(segment)
+				if height > to {
+					return false, nil
+				}
+				return true, add(height, item)
+			})
+			if err != nil {
+				return fmt.Errorf("could not compute manifests (prefix: %d): %w", prefix, err)
+			}
+		}
+
+		// Entries whose key ends with the height, after a segment of a fixed
+		// length, require a scan of the whole prefix.
+		offsets := map[uint8]int{
+			PrefixPayload:               len(ledger.Path{}),
+			PrefixHeightForCommit:       len(flow.StateCommitment{}),
+			PrefixKeyUpdates:            flow.AddressLength,
+			PrefixContractVersions:      flow.AddressLength + 8,
+			PrefixTransactionsForScript: len(flow.Identifier{}),
+		}
+		for prefix, offset := range offsets {
+			err := l.iterate(ctx, tx, l.key(prefix), l.key(prefix), func(item *badger.Item, segment []byte) (bool, error) {
+				height := binary.BigEndian.Uint64(segment[offset:])
+				return true, add(height, item)
+			})
+			if err != nil {
+				return fmt.Errorf("could not compute manifests (prefix: %d): %w", prefix, err)
+			}
+		}
+
+		// Entries whose value is the height also require a scan of the whole
+		// prefix, and the decoding of each value.
+		for _, prefix := range []uint8{
+			PrefixHeightForBlock,
+			PrefixHeightForAccount,
+			PrefixEpochPhases,
+		} {
+			err := l.iterate(ctx, tx, l.key(prefix), l.key(prefix), func(item *badger.Item, _ []byte) (bool, error) {
+				var height uint64
+				err := item.Value(func(val []byte) error {
+					return l.codec.Unmarshal(val, &height)
+				})
+				if err != nil {
+					return false, fmt.Errorf("could not decode height: %w", err)
+				}
+				return true, add(height, item)
+			})
+			if err != nil {
+				return fmt.Errorf("could not compute manifests (prefix: %d): %w", prefix, err)
+			}
+		}
+
+		// Entries keyed by identifier are looked up with the identifiers that
+		// are indexed for each height. Identifiers that are missing from the
+		// index are skipped, as they were never written.
+		lookup := func(height uint64, key []byte) error {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+			item, err := tx.Get(key)
+			if errors.Is(err, badger.ErrKeyNotFound) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("could not get value (key: %x): %w", key, err)
+			}
+			return add(height, item)
+		}
+		for height := from; height <= to; height++ {
+
+			var txIDs []flow.Identifier
+			err := l.LookupTransactionsForHeight(height, &txIDs)(tx)
+			if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+				return fmt.Errorf("could not look up transactions (height: %d): %w", height, err)
+			}
+			for _, txID := range txIDs {
+				err = lookup(height, l.key(PrefixTransaction, txID))
+				if err == nil {
+					err = lookup(height, l.key(PrefixResults, txID))
+				}
+				if err != nil {
+					return fmt.Errorf("could not compute manifest (height: %d, transaction: %x): %w", height, txID, err)
+				}
+			}
+
+			var collIDs []flow.Identifier
+			err = l.LookupCollectionsForHeight(height, &collIDs)(tx)
+			if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+				return fmt.Errorf("could not look up collections (height: %d): %w", height, err)
+			}
+			for _, collID := range collIDs {
+				err = lookup(height, l.key(PrefixCollection, collID))
+				if err == nil {
+					err = lookup(height, l.key(PrefixGuarantee, collID))
+				}
+				if err == nil {
+					err = lookup(height, l.key(PrefixTransactionsForCollection, collID))
+				}
+				if err != nil {
+					return fmt.Errorf("could not compute manifest (height: %d, collection: %x): %w", height, collID, err)
+				}
+			}
+
+			var sealIDs []flow.Identifier
+			err = l.LookupSealsForHeight(height, &sealIDs)(tx)
+			if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+				return fmt.Errorf("could not look up seals (height: %d): %w", height, err)
+			}
+			for _, sealID := range sealIDs {
+				err = lookup(height, l.key(PrefixSeal, sealID))
+				if err != nil {
+					return fmt.Errorf("could not compute manifest (height: %d, seal: %x): %w", height, sealID, err)
+				}
+			}
+		}
+
+		return nil
+	}
+}
+
+// RetrieveCompressionStats retrieves the persisted compression statistics of
+// all key prefixes.
+func (l *Library) RetrieveCompressionStats(ctx context.Context, stats *[]dps.CompressionStats) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		prefix := l.key(PrefixCompressionStats)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+			item := it.Item()
+
+			var entry dps.CompressionStats
+			err = item.Value(func(val []byte) error {
+				return l.codec.Unmarshal(val, &entry)
+			})
+			if err != nil {
+				return fmt.Errorf("could not decode compression statistics (key: %x): %w", item.Key(), err)
+			}
+
+			*stats = append(*stats, entry)
+		}
+
+		return nil
+	}
+}
+
+// RetrieveSizeStats retrieves the persisted size statistics of all key
+// prefixes, sorted by prefix.
+func (l *Library) RetrieveSizeStats(ctx context.Context, stats *[]dps.SizeStats) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		prefix := l.key(PrefixSizeStats)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+			item := it.Item()
+
+			var entry dps.SizeStats
+			err = item.Value(func(val []byte) error {
+				return l.codec.Unmarshal(val, &entry)
+			})
+			if err != nil {
+				return fmt.Errorf("could not decode size statistics (key: %x): %w", item.Key(), err)
+			}
+
+			*stats = append(*stats, entry)
+		}
+
+		return nil
+	}
+}
+
+// RetrieveCorruptions retrieves the reasons for all corrupted write-ahead log
+// data and execution records that were skipped while indexing, keyed by the
+// affected height.
+func (l *Library) RetrieveCorruptions(ctx context.Context, corruptions map[uint64][]string) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		prefix := l.key(PrefixCorruption)
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+			item := it.Item()
+			height := binary.BigEndian.Uint64(item.Key()[len(prefix):])
+
+			var reason string
+			err = item.Value(func(val []byte) error {
+				return l.codec.Unmarshal(val, &reason)
+			})
+			if err != nil {
+				return fmt.Errorf("could not decode corruption (height: %d): %w", height, err)
+			}
+
+			corruptions[height] = append(corruptions[height], reason)
+		}
+
+		return nil
+	}
+}
+
+// RetrieveExecutionRecord retrieves the archived execution record of the block
+// with the given ID, in its original encoding.
+func (l *Library) RetrieveExecutionRecord(blockID flow.Identifier, data *[]byte) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixExecutionRecords, blockID), data)
+}
+
+// RetrieveBackfill retrieves the progress of the backfill with the given name.
+func (l *Library) RetrieveBackfill(name string, backfill *dps.Backfill) func(*badger.Txn) error {
+	hash := xxhash.ChecksumString64(name)
+	return l.retrieve(l.key(PrefixBackfills, hash), backfill)
+}
+
+// RetrieveSegments retrieves the segments of the cold tier, in order.
+func (l *Library) RetrieveSegments(ctx context.Context, segments *[]dps.Segment) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		prefix := l.key(PrefixSegments)
+		return l.iterate(ctx, tx, prefix, prefix, func(item *badger.Item, _ []byte) (bool, error) {
+			var segment dps.Segment
+			err := item.Value(func(val []byte) error {
+				return l.codec.Unmarshal(val, &segment)
+			})
+			if err != nil {
+				return false, fmt.Errorf("could not decode segment: %w", err)
+			}
+
+			*segments = append(*segments, segment)
+
+			return true, nil
+		})
+	}
+}
+
+// IterateLedger steps through the entire ledger for ledger keys and payloads
+// and call the given callback for each of them.
+func (l *Library) IterateLedger(ctx context.Context, exclude func(height uint64) bool, process func(path ledger.Path, payload *ledger.Payload) error) func(*badger.Txn) error {
+
+	prefix := l.key(PrefixPayload)
+	opts := badger.IteratorOptions{
+		PrefetchSize:   100,
+		PrefetchValues: false,
+		Reverse:        true,
+		AllVersions:    false,
+		InternalAccess: false,
+		Prefix:         prefix,
+	}
+	highest := ledger.Path{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	}
+
+	return func(tx *badger.Txn) error {
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		sentinel := l.key(PrefixPayload, highest, uint64(math.MaxUint64))
+		for it.Seek(sentinel); it.ValidForPrefix(prefix); {
+
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+
+			// First, we extract the height from the item's key, and check if
+			// we should just skip past this entry.
+			item := it.Item()
+			key := item.Key()
+			height := binary.BigEndian.Uint64(key[len(prefix)+32:])
+			if exclude(height) {
+				it.Next()
+				continue
+			}
+
+			// Next, we can get the path from the key and the payload from the
+			// value.
+			var path ledger.Path
+			var payload ledger.Payload
+			copy(path[:], key[len(prefix):])
+			err = item.Value(func(val []byte) error {
+				err := l.unmarshal(val, &payload)
+				if err != nil {
+					return err
+				}
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("could not decode value (path: %x): %w", path, err)
+			}
+			err = l.verifyPayload(path, &payload)
+			if err != nil {
+				return err
+			}
+
+			// Then, we process the ledger path and payload with the callback.
+			err = process(path, &payload)
+			if err != nil {
+				return fmt.Errorf("could not process register (path: %x): %w", path, err)
+			}
+
+			// We need want to go to the first value that is below the current
+			// path. In order to cover all potential cases, including payloads
+			// at height zero, we need to decrement the current path by one and
+			// use the maximum possible height. If the decrement doesn't work,
+			// we have reached the zero path and we can break; otherwise, we
+			// would just wrap around to the maximum key again.
+			var zero ledger.Path
+			if path == zero {
+				break
+			}
 			for i := len(path) - 1; i >= 0; i-- {
 				path[i] = path[i] - 1
 				if path[i] != 0xff {
 					break
 				}
 			}
-			sentinel = EncodeKey(PrefixPayload, path, uint64(math.MaxUint64))
+			sentinel = l.key(PrefixPayload, path, uint64(math.MaxUint64))
 			it.Seek(sentinel)
 		}
 
 		return nil
 	}
 }
+
+// IterateHistory steps through the payloads of the ledger that were replaced by
+// another payload of the same register at or below the given cutoff height, and
+// calls the given callback with their stored value. Such payloads are no longer
+// needed to look up registers above the cutoff height. Payloads that were
+// already moved to the cold tier are skipped, and the value is only valid until
+// the callback returns.
+func (l *Library) IterateHistory(ctx context.Context, cutoff uint64, process func(path ledger.Path, height uint64, value []byte) error) func(*badger.Txn) error {
+
+	prefix := l.key(PrefixPayload)
+	opts := badger.IteratorOptions{
+		PrefetchSize:   100,
+		PrefetchValues: false,
+		Reverse:        true,
+		AllVersions:    false,
+		InternalAccess: false,
+		Prefix:         prefix,
+	}
+	highest := ledger.Path{
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	}
+
+	return func(tx *badger.Txn) error {
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		// As we iterate in reverse, we see the payloads of each register from
+		// the most recent one to the oldest one. The first payload at or below
+		// the cutoff height is the one that is current at the cutoff height,
+		// and all payloads after it were replaced.
+		var current ledger.Path
+		kept := false
+		sentinel := l.key(PrefixPayload, highest, uint64(math.MaxUint64))
+		for it.Seek(sentinel); it.ValidForPrefix(prefix); it.Next() {
+			err := l.step(ctx)
+			if err != nil {
+				return err
+			}
+
+			item := it.Item()
+			key := item.Key()
+			var path ledger.Path
+			copy(path[:], key[len(prefix):])
+			height := binary.BigEndian.Uint64(key[len(prefix)+pathfinder.PathByteSize:])
+
+			if path != current {
+				current = path
+				kept = false
+			}
+			if height > cutoff {
+				continue
+			}
+			if !kept {
+				kept = true
+				continue
+			}
+
+			err = item.Value(func(val []byte) error {
+				_, ok := decodeCold(val)
+				if ok {
+					return nil
+				}
+				return process(path, height, val)
+			})
+			if err != nil {
+				return fmt.Errorf("could not process payload (path: %x, height: %d): %w", path, height, err)
+			}
+		}
+
+		return nil
+	}
+}
@@ -18,6 +18,7 @@
 package storage_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/dgraph-io/badger/v2"
@@ -110,6 +111,23 @@ func TestLibrary(t *testing.T) {
 		assert.Equal(t, *mocks.GenericHeader, got)
 	})
 
+	t.Run("heights", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setupLibrary(t)
+
+		for _, height := range []uint64{mocks.GenericHeight, mocks.GenericHeight + 1, mocks.GenericHeight + 3, mocks.GenericHeight + 10} {
+			err := db.Update(lib.SaveHeader(height, mocks.GenericHeader))
+			require.NoError(t, err)
+		}
+
+		var got []uint64
+		err := db.View(lib.LookupHeights(context.Background(), mocks.GenericHeight+1, mocks.GenericHeight+5, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{mocks.GenericHeight + 1, mocks.GenericHeight + 3}, got)
+	})
+
 	t.Run("events", func(t *testing.T) {
 		t.Parallel()
 
@@ -132,7 +150,7 @@ func TestLibrary(t *testing.T) {
 			t.Parallel()
 
 			var got []flow.Event
-			err = db.View(lib.RetrieveEvents(mocks.GenericHeight, mocks.GenericEventTypes(1), &got))
+			err = db.View(lib.RetrieveEvents(context.Background(), mocks.GenericHeight, mocks.GenericEventTypes(1), &got))
 
 			require.NoError(t, err)
 			assert.ElementsMatch(t, events1, got)
@@ -142,7 +160,7 @@ func TestLibrary(t *testing.T) {
 			t.Parallel()
 
 			var got []flow.Event
-			err = db.View(lib.RetrieveEvents(mocks.GenericHeight, []flow.EventType{}, &got))
+			err = db.View(lib.RetrieveEvents(context.Background(), mocks.GenericHeight, []flow.EventType{}, &got))
 
 			require.NoError(t, err)
 			assert.ElementsMatch(t, allEvents, got)
@@ -152,7 +170,7 @@ func TestLibrary(t *testing.T) {
 			t.Parallel()
 
 			var got []flow.Event
-			err = db.View(lib.RetrieveEvents(mocks.GenericHeight, mocks.GenericEventTypes(4), &got))
+			err = db.View(lib.RetrieveEvents(context.Background(), mocks.GenericHeight, mocks.GenericEventTypes(4), &got))
 
 			require.NoError(t, err)
 			assert.ElementsMatch(t, allEvents, got)
@@ -162,13 +180,92 @@ func TestLibrary(t *testing.T) {
 			t.Parallel()
 
 			var got []flow.Event
-			err = db.View(lib.RetrieveEvents(mocks.GenericHeight, []flow.EventType{mocks.GenericEventType(2)}, &got))
+			err = db.View(lib.RetrieveEvents(context.Background(), mocks.GenericHeight, []flow.EventType{mocks.GenericEventType(2)}, &got))
 
 			require.NoError(t, err)
 			assert.Empty(t, got)
 		})
 	})
 
+	t.Run("raw values", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setupLibrary(t)
+		codec := zbor.NewCodec()
+
+		events := mocks.GenericEvents(8)
+		transaction := mocks.GenericTransaction(0)
+		seal := mocks.GenericSeal(0)
+
+		require.NoError(t, db.Update(lib.SaveHeader(mocks.GenericHeight, mocks.GenericHeader)))
+		require.NoError(t, db.Update(lib.SaveEvents(mocks.GenericHeight, mocks.GenericEventType(0), events[0:4])))
+		require.NoError(t, db.Update(lib.SaveEvents(mocks.GenericHeight, mocks.GenericEventType(1), events[4:8])))
+		require.NoError(t, db.Update(lib.SaveTransaction(transaction)))
+		require.NoError(t, db.Update(lib.SaveSeal(seal)))
+
+		t.Run("header", func(t *testing.T) {
+			t.Parallel()
+
+			var data []byte
+			err := db.View(lib.RetrieveRawHeader(mocks.GenericHeight, &data))
+			require.NoError(t, err)
+
+			var got flow.Header
+			require.NoError(t, codec.Unmarshal(data, &got))
+			assert.Equal(t, *mocks.GenericHeader, got)
+		})
+
+		t.Run("events", func(t *testing.T) {
+			t.Parallel()
+
+			var data [][]byte
+			err := db.View(lib.RetrieveRawEvents(context.Background(), mocks.GenericHeight, mocks.GenericEventTypes(1), &data))
+			require.NoError(t, err)
+			require.Len(t, data, 1)
+
+			var got []flow.Event
+			require.NoError(t, codec.Unmarshal(data[0], &got))
+			assert.ElementsMatch(t, events[0:4], got)
+
+			data = nil
+			err = db.View(lib.RetrieveRawEvents(context.Background(), mocks.GenericHeight, nil, &data))
+			require.NoError(t, err)
+			assert.Len(t, data, 2)
+		})
+
+		t.Run("transaction", func(t *testing.T) {
+			t.Parallel()
+
+			var data []byte
+			err := db.View(lib.RetrieveRawTransaction(transaction.ID(), &data))
+			require.NoError(t, err)
+
+			var got flow.TransactionBody
+			require.NoError(t, codec.Unmarshal(data, &got))
+			assert.Equal(t, *transaction, got)
+		})
+
+		t.Run("seal", func(t *testing.T) {
+			t.Parallel()
+
+			var data []byte
+			err := db.View(lib.RetrieveRawSeal(seal.ID(), &data))
+			require.NoError(t, err)
+
+			var got flow.Seal
+			require.NoError(t, codec.Unmarshal(data, &got))
+			assert.Equal(t, *seal, got)
+		})
+
+		t.Run("missing value", func(t *testing.T) {
+			t.Parallel()
+
+			var data []byte
+			err := db.View(lib.RetrieveRawHeader(mocks.GenericHeight+1, &data))
+			assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+		})
+	})
+
 	t.Run("payload", func(t *testing.T) {
 		t.Parallel()
 
@@ -337,6 +434,49 @@ func TestLibrary(t *testing.T) {
 		assert.NoError(t, err)
 		assert.ElementsMatch(t, sealIDs, got)
 	})
+
+	t.Run("staging", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		lib := storage.New(zbor.NewCodec(), storage.WithStaging(true))
+
+		err := db.Update(lib.SaveLast(mocks.GenericHeight))
+		require.NoError(t, err)
+		err = db.Update(lib.SaveStaging(mocks.GenericHeight))
+		require.NoError(t, err)
+
+		err = db.Update(lib.SaveLast(mocks.GenericHeight + 1))
+		require.NoError(t, err)
+		err = db.Update(lib.SaveCommit(mocks.GenericHeight+1, mocks.GenericCommit(0)))
+		require.NoError(t, err)
+		err = db.Update(lib.SaveStaging(mocks.GenericHeight + 1))
+		require.NoError(t, err)
+
+		var heights []uint64
+		err = db.View(lib.LookupStaging(context.Background(), &heights))
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{mocks.GenericHeight, mocks.GenericHeight + 1}, heights)
+
+		err = db.Update(lib.PromoteStaging(mocks.GenericHeight))
+		require.NoError(t, err)
+		err = db.Update(lib.RollbackStaging(mocks.GenericHeight + 1))
+		require.NoError(t, err)
+
+		heights = nil
+		err = db.View(lib.LookupStaging(context.Background(), &heights))
+		require.NoError(t, err)
+		assert.Empty(t, heights)
+
+		var last uint64
+		err = db.View(lib.RetrieveLast(&last))
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, last)
+
+		var commit flow.StateCommitment
+		err = db.View(lib.RetrieveCommit(mocks.GenericHeight+1, &commit))
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+	})
 }
 
 func setupLibrary(t *testing.T) (*badger.DB, *storage.Library) {
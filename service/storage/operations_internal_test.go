@@ -15,6 +15,9 @@
 package storage
 
 import (
+	"bytes"
+	"context"
+	"sort"
 	"testing"
 
 	"github.com/OneOfOne/xxhash"
@@ -25,7 +28,9 @@ import (
 	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/model/flow"
 
+	"github.com/optakt/flow-dps/codec/protostore"
 	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
 	"github.com/optakt/flow-dps/service/loader"
 	"github.com/optakt/flow-dps/testing/helpers"
 	"github.com/optakt/flow-dps/testing/mocks"
@@ -302,7 +307,7 @@ func TestLibrary_SaveAndRetrieveEvents(t *testing.T) {
 		}
 
 		var got []flow.Event
-		err = db.View(l.RetrieveEvents(mocks.GenericHeight, mocks.GenericEventTypes(2), &got))
+		err = db.View(l.RetrieveEvents(context.Background(), mocks.GenericHeight, mocks.GenericEventTypes(2), &got))
 
 		assert.NoError(t, err)
 		assert.Equal(t, 2, decodeCallCount)
@@ -340,7 +345,7 @@ func TestLibrary_SaveAndRetrieveEvents(t *testing.T) {
 		}
 
 		var got []flow.Event
-		err = db.View(l.RetrieveEvents(mocks.GenericHeight, []flow.EventType{}, &got))
+		err = db.View(l.RetrieveEvents(context.Background(), mocks.GenericHeight, []flow.EventType{}, &got))
 
 		assert.Equal(t, 2, decodeCallCount)
 		assert.NoError(t, err)
@@ -378,7 +383,7 @@ func TestLibrary_SaveAndRetrieveEvents(t *testing.T) {
 		}
 
 		var got []flow.Event
-		err = db.View(l.RetrieveEvents(mocks.GenericHeight, []flow.EventType{mocks.GenericEventType(0), "another-type"}, &got))
+		err = db.View(l.RetrieveEvents(context.Background(), mocks.GenericHeight, []flow.EventType{mocks.GenericEventType(0), "another-type"}, &got))
 
 		assert.NoError(t, err)
 		assert.Equal(t, 1, decodeCallCount)
@@ -416,7 +421,7 @@ func TestLibrary_SaveAndRetrieveEvents(t *testing.T) {
 		}
 
 		var got []flow.Event
-		err = db.View(l.RetrieveEvents(mocks.GenericHeight, []flow.EventType{"another-type", mocks.GenericEventType(1)}, &got))
+		err = db.View(l.RetrieveEvents(context.Background(), mocks.GenericHeight, []flow.EventType{"another-type", mocks.GenericEventType(1)}, &got))
 
 		assert.NoError(t, err)
 		assert.Equal(t, 1, decodeCallCount)
@@ -913,6 +918,54 @@ func TestIndexAndLookup_TransactionsForHeight(t *testing.T) {
 	})
 }
 
+func TestIndexAndLookup_TransactionsForScript(t *testing.T) {
+	hash := dps.ScriptHash([]byte(`transaction {}`))
+	other := dps.ScriptHash([]byte(`transaction { execute {} }`))
+	txIDs := mocks.GenericTransactionIDs(5)
+
+	t.Run("index and look up transactions for script", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		require.NoError(t, db.Update(l.IndexTransactionsForScript(hash, mocks.GenericHeight-1, txIDs[:1])))
+		require.NoError(t, db.Update(l.IndexTransactionsForScript(hash, mocks.GenericHeight, txIDs[1:3])))
+		require.NoError(t, db.Update(l.IndexTransactionsForScript(other, mocks.GenericHeight+1, txIDs[3:4])))
+		require.NoError(t, db.Update(l.IndexTransactionsForScript(hash, mocks.GenericHeight+2, txIDs[4:])))
+		require.NoError(t, db.Update(l.IndexTransactionsForScript(hash, mocks.GenericHeight+3, txIDs[:1])))
+
+		var got []flow.Identifier
+		err := db.View(l.LookupTransactionsForScript(context.Background(), hash, mocks.GenericHeight, mocks.GenericHeight+2, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, []flow.Identifier{txIDs[1], txIDs[2], txIDs[4]}, got)
+	})
+
+	t.Run("handles decoding failure", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = func([]byte, interface{}) error {
+			return mocks.GenericError
+		}
+
+		l := &Library{codec: codec}
+
+		require.NoError(t, db.Update(l.IndexTransactionsForScript(hash, mocks.GenericHeight, txIDs)))
+
+		var got []flow.Identifier
+		err := db.View(l.LookupTransactionsForScript(context.Background(), hash, mocks.GenericHeight, mocks.GenericHeight, &got))
+
+		assert.Error(t, err)
+	})
+}
+
 func TestSaveAndRetrieve_Collection(t *testing.T) {
 	collection := mocks.GenericCollection(0)
 	testKey := EncodeKey(PrefixCollection, collection.ID())
@@ -1258,122 +1311,1800 @@ func TestIndexAndLookup_Seals(t *testing.T) {
 	})
 }
 
-func TestLibrary_IterateLedger(t *testing.T) {
-	entries := 5
-	paths := mocks.GenericLedgerPaths(entries)
-	payloads := mocks.GenericLedgerPayloads(entries)
+func TestSaveAndRetrieve_Corruptions(t *testing.T) {
+	testKey := EncodeKey(PrefixCorruption, mocks.GenericHeight, xxhash.ChecksumString64("reason"))
 
-	t.Run("nominal case", func(t *testing.T) {
+	t.Run("save corruption", func(t *testing.T) {
 		t.Parallel()
 
 		db := helpers.InMemoryDB(t)
 		defer db.Close()
 
-		codec := zbor.NewCodec()
-		l := &Library{codec}
+		codec := mocks.BaselineCodec(t)
+		codec.MarshalFunc = func(v interface{}) ([]byte, error) {
+			assert.Equal(t, "reason", v)
+			return mocks.GenericBytes, nil
+		}
 
-		for i := 0; i < entries; i++ {
-			height := mocks.GenericHeight + uint64(i)
-			require.NoError(t, db.Update(l.SavePayload(height, paths[i], payloads[i])))
+		l := &Library{
+			codec: codec,
 		}
 
-		got := make(map[ledger.Path]*ledger.Payload)
-		op := l.IterateLedger(loader.ExcludeNone(), func(path ledger.Path, payload *ledger.Payload) error {
-			got[path] = payload
+		err := db.Update(l.SaveCorruption(mocks.GenericHeight, "reason"))
+		require.NoError(t, err)
 
-			return nil
+		err = db.View(func(tx *badger.Txn) error {
+			_, err := tx.Get(testKey)
+			return err
 		})
-
-		err := db.View(op)
-
 		assert.NoError(t, err)
-		assert.Len(t, got, entries)
-		for i := 0; i < entries; i++ {
-			assert.Equal(t, payloads[i], got[paths[i]])
-		}
 	})
 
-	t.Run("handles multiple payloads with the same path", func(t *testing.T) {
+	t.Run("two corruptions at the same height", func(t *testing.T) {
 		t.Parallel()
 
 		db := helpers.InMemoryDB(t)
 		defer db.Close()
 
-		codec := zbor.NewCodec()
-		l := &Library{codec}
+		l := &Library{codec: zbor.NewCodec()}
 
-		// Always use paths[0] for every payload.
-		path := paths[0]
-		for i := 0; i < entries; i++ {
-			height := mocks.GenericHeight + uint64(i)
-			require.NoError(t, db.Update(l.SavePayload(height, path, payloads[i])))
-		}
+		err := db.Update(l.SaveCorruption(mocks.GenericHeight, "first reason"))
+		require.NoError(t, err)
+		err = db.Update(l.SaveCorruption(mocks.GenericHeight, "second reason"))
+		require.NoError(t, err)
+		err = db.Update(l.SaveCorruption(mocks.GenericHeight+1, "third reason"))
+		require.NoError(t, err)
 
-		got := make(map[ledger.Path]*ledger.Payload)
-		op := l.IterateLedger(loader.ExcludeNone(), func(path ledger.Path, payload *ledger.Payload) error {
-			got[path] = payload
+		got := make(map[uint64][]string)
+		err = db.View(l.RetrieveCorruptions(context.Background(), got))
 
-			return nil
+		require.NoError(t, err)
+		require.Len(t, got, 2)
+		assert.ElementsMatch(t, []string{"first reason", "second reason"}, got[mocks.GenericHeight])
+		assert.Equal(t, []string{"third reason"}, got[mocks.GenericHeight+1])
+	})
+
+	t.Run("retrieve corruptions", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		err := db.Update(func(tx *badger.Txn) error {
+			return tx.Set(testKey, mocks.GenericBytes)
 		})
+		require.NoError(t, err)
 
-		err := db.View(op)
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = func(b []byte, v interface{}) error {
+			assert.Equal(t, mocks.GenericBytes, b)
+			require.IsType(t, new(string), v)
+			*(v.(*string)) = "reason"
+			return nil
+		}
+
+		l := &Library{
+			codec: codec,
+		}
+
+		got := make(map[uint64][]string)
+		err = db.View(l.RetrieveCorruptions(context.Background(), got))
 
 		require.NoError(t, err)
-		// Verify that only the payload with the greatest height was used in the callback.
-		assert.Len(t, got, 1)
-		assert.Equal(t, payloads[entries-1], got[path])
+		assert.Equal(t, map[uint64][]string{mocks.GenericHeight: {"reason"}}, got)
 	})
 
-	t.Run("handles codec failure", func(t *testing.T) {
+	t.Run("handles decoding failure", func(t *testing.T) {
 		t.Parallel()
 
 		db := helpers.InMemoryDB(t)
 		defer db.Close()
 
+		err := db.Update(func(tx *badger.Txn) error {
+			return tx.Set(testKey, mocks.GenericBytes)
+		})
+		require.NoError(t, err)
+
 		codec := mocks.BaselineCodec(t)
 		codec.UnmarshalFunc = func([]byte, interface{}) error {
 			return mocks.GenericError
 		}
-		l := &Library{codec}
 
-		for i := 0; i < entries; i++ {
-			height := mocks.GenericHeight + uint64(i)
-			require.NoError(t, db.Update(l.SavePayload(height, paths[i], payloads[i])))
+		l := &Library{
+			codec: codec,
 		}
 
-		got := make(map[ledger.Path]*ledger.Payload)
-		op := l.IterateLedger(loader.ExcludeNone(), func(path ledger.Path, payload *ledger.Payload) error {
-			got[path] = payload
+		err = db.View(l.RetrieveCorruptions(context.Background(), make(map[uint64][]string)))
 
-			return nil
-		})
+		assert.Error(t, err)
+	})
+}
 
-		err := db.View(op)
+func TestSaveAndRetrieve_ExecutionRecord(t *testing.T) {
+	blockID := mocks.GenericHeader.ID()
 
-		assert.Error(t, err)
-		assert.Len(t, got, 0)
+	t.Run("save and retrieve execution record", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		err := db.Update(l.SaveExecutionRecord(blockID, mocks.GenericBytes))
+		require.NoError(t, err)
+
+		var got []byte
+		err = db.View(l.RetrieveExecutionRecord(blockID, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericBytes, got)
 	})
 
-	t.Run("handles callback error", func(t *testing.T) {
+	t.Run("handles missing execution record", func(t *testing.T) {
 		t.Parallel()
 
 		db := helpers.InMemoryDB(t)
 		defer db.Close()
 
-		codec := zbor.NewCodec()
-		l := &Library{codec}
+		l := &Library{codec: zbor.NewCodec()}
 
-		for i := 0; i < entries; i++ {
-			height := mocks.GenericHeight + uint64(i)
-			require.NoError(t, db.Update(l.SavePayload(height, paths[i], payloads[i])))
+		var got []byte
+		err := db.View(l.RetrieveExecutionRecord(blockID, &got))
+
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+	})
+}
+
+func TestSaveAndRetrieve_Backfill(t *testing.T) {
+	backfill := dps.Backfill{Name: "accounts", From: 10, To: 20, Next: 15}
+
+	t.Run("save and retrieve backfill", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		err := db.Update(l.SaveBackfill(&backfill))
+		require.NoError(t, err)
+
+		var got dps.Backfill
+		err = db.View(l.RetrieveBackfill(backfill.Name, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, backfill, got)
+	})
+
+	t.Run("handles missing backfill", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		err := db.Update(l.SaveBackfill(&backfill))
+		require.NoError(t, err)
+
+		var got dps.Backfill
+		err = db.View(l.RetrieveBackfill("events", &got))
+
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+	})
+}
+
+func TestSaveAndRetrieve_EventTypeStats(t *testing.T) {
+	stats := []dps.EventTypeStats{
+		{Type: mocks.GenericEventType(0), First: 1, Last: 3, Count: 5},
+		{Type: mocks.GenericEventType(1), First: 2, Last: 2, Count: 1},
+	}
+
+	t.Run("save and retrieve event type statistics", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		for i := range stats {
+			err := db.Update(l.SaveEventTypeStats(&stats[i]))
+			require.NoError(t, err)
 		}
 
-		op := l.IterateLedger(loader.ExcludeNone(), func(path ledger.Path, payload *ledger.Payload) error {
-			return mocks.GenericError
+		var got []dps.EventTypeStats
+		err := db.View(l.RetrieveEventTypeStats(context.Background(), &got))
+
+		require.NoError(t, err)
+		assert.ElementsMatch(t, stats, got)
+	})
+
+	t.Run("overwrites event type statistics", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		updated := stats[0]
+		updated.Last = 4
+		updated.Count = 6
+
+		require.NoError(t, db.Update(l.SaveEventTypeStats(&stats[0])))
+		require.NoError(t, db.Update(l.SaveEventTypeStats(&updated)))
+
+		var got []dps.EventTypeStats
+		err := db.View(l.RetrieveEventTypeStats(context.Background(), &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, []dps.EventTypeStats{updated}, got)
+	})
+
+	t.Run("handles decoding failure", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		err := db.Update(func(tx *badger.Txn) error {
+			return tx.Set(EncodeKey(PrefixEventTypeStats, uint64(1)), mocks.GenericBytes)
 		})
+		require.NoError(t, err)
 
-		err := db.View(op)
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = func([]byte, interface{}) error {
+			return mocks.GenericError
+		}
+
+		l := &Library{codec: codec}
+
+		var got []dps.EventTypeStats
+		err = db.View(l.RetrieveEventTypeStats(context.Background(), &got))
 
 		assert.Error(t, err)
 	})
 }
+
+func TestIndexAndLookup_PathsForOwner(t *testing.T) {
+	owner := mocks.GenericAddress(0)
+	other := mocks.GenericAddress(1)
+	paths := mocks.GenericLedgerPaths(4)
+
+	sorted := make([]ledger.Path, len(paths))
+	copy(sorted, paths)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0
+	})
+
+	db := helpers.InMemoryDB(t)
+	defer db.Close()
+
+	l := &Library{codec: zbor.NewCodec()}
+
+	for _, path := range paths {
+		require.NoError(t, db.Update(l.IndexPathForOwner(owner, path, mocks.GenericHeight)))
+	}
+	require.NoError(t, db.Update(l.IndexPathForOwner(other, paths[0], mocks.GenericHeight)))
+
+	t.Run("lookup all paths", func(t *testing.T) {
+		var got []ledger.Path
+		err := db.View(l.LookupPathsForOwner(context.Background(), owner, ledger.Path{}, 10, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, sorted, got)
+	})
+
+	t.Run("lookup limited paths", func(t *testing.T) {
+		var got []ledger.Path
+		err := db.View(l.LookupPathsForOwner(context.Background(), owner, ledger.Path{}, 2, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, sorted[:2], got)
+	})
+
+	t.Run("lookup paths after path", func(t *testing.T) {
+		var got []ledger.Path
+		err := db.View(l.LookupPathsForOwner(context.Background(), owner, sorted[1], 10, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, sorted[2:], got)
+	})
+
+	t.Run("lookup paths of other owner", func(t *testing.T) {
+		var got []ledger.Path
+		err := db.View(l.LookupPathsForOwner(context.Background(), other, ledger.Path{}, 10, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, paths[:1], got)
+	})
+}
+
+func TestSaveAndLookup_Paths(t *testing.T) {
+	paths := mocks.GenericLedgerPaths(4)
+	payloads := mocks.GenericLedgerPayloads(4)
+
+	sorted := make([]ledger.Path, len(paths))
+	copy(sorted, paths)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0
+	})
+
+	db := helpers.InMemoryDB(t)
+	defer db.Close()
+
+	l := &Library{codec: zbor.NewCodec()}
+
+	// Each path has payloads at several heights, which should only be looked up
+	// once.
+	for i, path := range paths {
+		require.NoError(t, db.Update(l.SavePayload(mocks.GenericHeight, path, payloads[i])))
+		require.NoError(t, db.Update(l.SavePayload(mocks.GenericHeight+1, path, payloads[i])))
+	}
+
+	t.Run("lookup all paths", func(t *testing.T) {
+		var got []ledger.Path
+		err := db.View(l.LookupPaths(context.Background(), ledger.Path{}, 10, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, sorted, got)
+	})
+
+	t.Run("lookup limited paths", func(t *testing.T) {
+		var got []ledger.Path
+		err := db.View(l.LookupPaths(context.Background(), ledger.Path{}, 2, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, sorted[:2], got)
+	})
+
+	t.Run("lookup paths after path", func(t *testing.T) {
+		var got []ledger.Path
+		err := db.View(l.LookupPaths(context.Background(), sorted[1], 10, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, sorted[2:], got)
+	})
+
+	t.Run("lookup paths after last path", func(t *testing.T) {
+		var got []ledger.Path
+		err := db.View(l.LookupPaths(context.Background(), sorted[3], 10, &got))
+
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
+
+func TestUpdateAndRetrieve_PathFilter(t *testing.T) {
+	paths := mocks.GenericLedgerPaths(3)
+
+	first := dps.NewPathFilter(10000)
+	first.To = 10004
+	first.Add(paths[0])
+
+	second := dps.NewPathFilter(10005)
+	second.To = 10009
+	second.Add(paths[1])
+
+	disjoint := dps.NewPathFilter(10007)
+	disjoint.To = 10009
+	disjoint.Add(paths[2])
+
+	t.Run("save and retrieve path filter", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		err := db.Update(l.UpdatePathFilter(1, first))
+		require.NoError(t, err)
+
+		var got dps.PathFilter
+		err = db.View(l.RetrievePathFilter(1, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(10000), got.From)
+		assert.Equal(t, uint64(10004), got.To)
+		assert.True(t, got.Has(paths[0]))
+		assert.False(t, got.Has(paths[1]))
+	})
+
+	t.Run("merges adjacent path filters", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		require.NoError(t, db.Update(l.UpdatePathFilter(1, first)))
+		require.NoError(t, db.Update(l.UpdatePathFilter(1, second)))
+
+		var got dps.PathFilter
+		err := db.View(l.RetrievePathFilter(1, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(10000), got.From)
+		assert.Equal(t, uint64(10009), got.To)
+		assert.True(t, got.Has(paths[0]))
+		assert.True(t, got.Has(paths[1]))
+	})
+
+	t.Run("replaces disjoint path filters", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		require.NoError(t, db.Update(l.UpdatePathFilter(1, first)))
+		require.NoError(t, db.Update(l.UpdatePathFilter(1, disjoint)))
+
+		var got dps.PathFilter
+		err := db.View(l.RetrievePathFilter(1, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(10007), got.From)
+		assert.Equal(t, uint64(10009), got.To)
+		assert.False(t, got.Has(paths[0]))
+		assert.True(t, got.Has(paths[2]))
+	})
+
+	t.Run("missing path filter", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		var got dps.PathFilter
+		err := db.View(l.RetrievePathFilter(1, &got))
+
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+	})
+}
+
+func TestSaveAndCompute_Manifests(t *testing.T) {
+	height := mocks.GenericHeight
+	path := mocks.GenericLedgerPath(0)
+	payloads := mocks.GenericLedgerPayloads(2)
+	transactions := mocks.GenericTransactions(2)
+	results := mocks.GenericResults(2)
+	collections := mocks.GenericCollections(2)
+	guarantees := mocks.GenericGuarantees(2)
+	seals := mocks.GenericSeals(2)
+	blockIDs := mocks.GenericBlockIDs(2)
+	commits := mocks.GenericCommits(2)
+
+	// The results and guarantees are looked up through the identifiers of the
+	// transactions and collections of their height.
+	for i := range results {
+		results[i].TransactionID = transactions[i].ID()
+		guarantees[i].CollectionID = collections[i].ID()
+	}
+
+	// index writes the data of the given height, including some entries that
+	// are not covered by the manifests, and seals its manifest.
+	index := func(t *testing.T, db *badger.DB, l *Library, index int) {
+		t.Helper()
+
+		height := height + uint64(index)
+		ops := []func(*badger.Txn) error{
+			l.SaveHeader(height, mocks.GenericHeader),
+			l.SaveCommit(height, commits[index]),
+			l.IndexHeightForBlock(blockIDs[index], height),
+			l.IndexHeightForCommit(commits[index], height),
+			l.SavePayload(height, path, payloads[index]),
+			l.SaveTransaction(transactions[index]),
+			l.SaveResult(results[index]),
+			l.IndexTransactionsForHeight(height, []flow.Identifier{transactions[index].ID()}),
+			l.IndexHeightForTransaction(transactions[index].ID(), height),
+			l.SaveCollection(collections[index]),
+			l.SaveGuarantee(guarantees[index]),
+			l.IndexCollectionsForHeight(height, []flow.Identifier{collections[index].ID()}),
+			l.IndexTransactionsForCollection(collections[index].ID(), collections[index].Transactions),
+			l.SaveSeal(seals[index]),
+			l.IndexSealsForHeight(height, []flow.Identifier{seals[index].ID()}),
+			l.IndexKeyUpdate(mocks.GenericAddress(0), height, 1),
+			l.SaveLast(height),
+			l.SaveManifest(height),
+		}
+		for _, op := range ops {
+			require.NoError(t, db.Update(op))
+		}
+	}
+
+	t.Run("save and retrieve manifest", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec(), manifest: newManifestRecorder()}
+
+		index(t, db, l, 0)
+
+		var got dps.Manifest
+		err := db.View(l.RetrieveManifest(height, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, height, got.Height)
+		assert.Equal(t, uint64(15), got.Entries)
+		assert.NotEqual(t, [32]byte{}, got.Checksum)
+	})
+
+	t.Run("records each key once", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec(), manifest: newManifestRecorder()}
+
+		// Applying an operation again, like the index writer does when its
+		// transaction was too big, does not change the manifest.
+		require.NoError(t, db.Update(l.SaveHeader(height, mocks.GenericHeader)))
+		require.NoError(t, db.Update(l.SaveHeader(height, mocks.GenericHeader)))
+		require.NoError(t, db.Update(l.SaveManifest(height)))
+
+		var got dps.Manifest
+		err := db.View(l.RetrieveManifest(height, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), got.Entries)
+	})
+
+	t.Run("computed manifests match saved manifests", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec(), manifest: newManifestRecorder()}
+
+		index(t, db, l, 0)
+		index(t, db, l, 1)
+
+		computed := make(map[uint64]*dps.Manifest)
+		err := db.View(l.ComputeManifests(context.Background(), height, height+1, computed))
+		require.NoError(t, err)
+
+		for _, height := range []uint64{height, height + 1} {
+			var stored dps.Manifest
+			require.NoError(t, db.View(l.RetrieveManifest(height, &stored)))
+
+			require.Contains(t, computed, height)
+			assert.True(t, stored.Matches(*computed[height]))
+		}
+	})
+
+	t.Run("computed manifest differs on corrupted entry", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec(), manifest: newManifestRecorder()}
+
+		index(t, db, l, 0)
+		index(t, db, l, 1)
+
+		err := db.Update(func(tx *badger.Txn) error {
+			return tx.Set(l.key(PrefixPayload, path, height+1), mocks.GenericBytes)
+		})
+		require.NoError(t, err)
+
+		computed := make(map[uint64]*dps.Manifest)
+		err = db.View(l.ComputeManifests(context.Background(), height, height+1, computed))
+		require.NoError(t, err)
+
+		var stored dps.Manifest
+		require.NoError(t, db.View(l.RetrieveManifest(height, &stored)))
+		assert.True(t, stored.Matches(*computed[height]))
+
+		require.NoError(t, db.View(l.RetrieveManifest(height+1, &stored)))
+		assert.False(t, stored.Matches(*computed[height+1]))
+	})
+
+	t.Run("does not record entries saved by detached library", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec(), manifest: newManifestRecorder()}
+
+		require.NoError(t, db.Update(l.SaveHeader(height, mocks.GenericHeader)))
+		require.NoError(t, db.Update(l.Detached().SaveHeader(height+1, mocks.GenericHeader)))
+		require.NoError(t, db.Update(l.SaveManifest(height)))
+
+		var got dps.Manifest
+		err := db.View(l.RetrieveManifest(height, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), got.Entries)
+	})
+
+	t.Run("does not save manifest when disabled", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		index(t, db, l, 0)
+
+		var got dps.Manifest
+		err := db.View(l.RetrieveManifest(height, &got))
+
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+	})
+}
+
+func TestIterateAndSave_ColdPayloads(t *testing.T) {
+	paths := mocks.GenericLedgerPaths(2)
+	payloads := mocks.GenericLedgerPayloads(4)
+
+	// index writes two versions of the first register, and three versions of
+	// the second one, at the given heights.
+	index := func(t *testing.T, db *badger.DB, l *Library) {
+		t.Helper()
+
+		ops := []func(*badger.Txn) error{
+			l.SavePayload(10, paths[0], payloads[0]),
+			l.SavePayload(20, paths[0], payloads[1]),
+			l.SavePayload(10, paths[1], payloads[1]),
+			l.SavePayload(20, paths[1], payloads[2]),
+			l.SavePayload(30, paths[1], payloads[3]),
+		}
+		for _, op := range ops {
+			require.NoError(t, db.Update(op))
+		}
+	}
+
+	type version struct {
+		path   ledger.Path
+		height uint64
+	}
+
+	t.Run("iterate replaced payloads", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+		index(t, db, l)
+
+		var got []version
+		err := db.View(l.IterateHistory(context.Background(), 25, func(path ledger.Path, height uint64, value []byte) error {
+			got = append(got, version{path: path, height: height})
+			return nil
+		}))
+
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []version{{paths[0], 10}, {paths[1], 10}}, got)
+	})
+
+	t.Run("replace payload with cold value", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		// The cold tier returns the value that was stored for the payload.
+		var value []byte
+		cold := dps.ColdValue{Segment: 1, Offset: 2, Length: 3}
+		reader := mocks.BaselineColdReader(t)
+		reader.ReadFunc = func(got dps.ColdValue) ([]byte, error) {
+			assert.Equal(t, cold, got)
+			return value, nil
+		}
+
+		l := &Library{codec: zbor.NewCodec(), cold: reader}
+		index(t, db, l)
+
+		err := db.View(l.IterateHistory(context.Background(), 30, func(path ledger.Path, height uint64, val []byte) error {
+			if path == paths[0] && height == 10 {
+				value = append([]byte(nil), val...)
+			}
+			return nil
+		}))
+		require.NoError(t, err)
+		require.NotNil(t, value)
+
+		err = db.Update(l.SaveColdPayload(10, paths[0], value, cold))
+		require.NoError(t, err)
+
+		// The cold payload is read from the cold tier, and skipped by further
+		// iterations.
+		var got ledger.Payload
+		err = db.View(l.RetrievePayload(15, paths[0], &got))
+		require.NoError(t, err)
+		assert.Equal(t, *payloads[0], got)
+
+		err = db.View(l.IterateHistory(context.Background(), 30, func(path ledger.Path, height uint64, _ []byte) error {
+			assert.False(t, path == paths[0] && height == 10)
+			return nil
+		}))
+		assert.NoError(t, err)
+	})
+
+	t.Run("does not replace payload that was written again", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+		index(t, db, l)
+
+		err := db.Update(l.SaveColdPayload(10, paths[0], mocks.GenericBytes, dps.ColdValue{Segment: 1}))
+		require.NoError(t, err)
+
+		var got ledger.Payload
+		err = db.View(l.RetrievePayload(10, paths[0], &got))
+		require.NoError(t, err)
+		assert.Equal(t, *payloads[0], got)
+	})
+
+	t.Run("handles cold payload without cold tier", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+		index(t, db, l)
+
+		err := db.Update(func(tx *badger.Txn) error {
+			return tx.Set(l.key(PrefixPayload, paths[0], uint64(10)), encodeCold(dps.ColdValue{Segment: 1}))
+		})
+		require.NoError(t, err)
+
+		var got ledger.Payload
+		err = db.View(l.RetrievePayload(10, paths[0], &got))
+		assert.ErrorIs(t, err, dps.ErrCold)
+	})
+
+	t.Run("save and retrieve segments", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		segments := []dps.Segment{
+			{Number: 1, Cutoff: 10, Values: 2, Bytes: 20},
+			{Number: 2, Cutoff: 20, Values: 3, Bytes: 30},
+		}
+		for i := range segments {
+			require.NoError(t, db.Update(l.SaveSegment(&segments[i])))
+		}
+
+		var got []dps.Segment
+		err := db.View(l.RetrieveSegments(context.Background(), &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, segments, got)
+	})
+}
+
+func TestSaveAndRetrieve_Filter(t *testing.T) {
+	owners := mocks.GenericAddresses(2)
+
+	t.Run("save and retrieve owners", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		err := db.Update(l.SaveOwners(owners))
+		require.NoError(t, err)
+
+		var got []flow.Address
+		err = db.View(l.RetrieveOwners(&got))
+
+		require.NoError(t, err)
+		assert.Equal(t, owners, got)
+	})
+
+	t.Run("save and retrieve denied", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		err := db.Update(l.SaveDenied(owners))
+		require.NoError(t, err)
+
+		var got []flow.Address
+		err = db.View(l.RetrieveDenied(&got))
+
+		require.NoError(t, err)
+		assert.Equal(t, owners, got)
+
+		err = db.View(l.RetrieveOwners(&got))
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+	})
+
+	t.Run("handles missing owners", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		var got []flow.Address
+		err := db.View(l.RetrieveOwners(&got))
+
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+	})
+}
+
+func TestAcquireCheckAndRelease_Fence(t *testing.T) {
+	t.Run("acquire and check fence", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		var fence dps.Fence
+		err := db.Update(l.AcquireFence("first", false, &fence))
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), fence.Token)
+		assert.Equal(t, "first", fence.Holder)
+
+		err = db.View(l.CheckFence(fence.Token))
+		assert.NoError(t, err)
+	})
+
+	t.Run("fails to acquire held fence", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		var fence dps.Fence
+		require.NoError(t, db.Update(l.AcquireFence("first", false, &fence)))
+
+		var other dps.Fence
+		err := db.Update(l.AcquireFence("second", false, &other))
+
+		assert.ErrorIs(t, err, dps.ErrFenced)
+
+		err = db.View(l.CheckFence(fence.Token))
+		assert.NoError(t, err)
+	})
+
+	t.Run("takes over held fence when forced", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		var fence dps.Fence
+		require.NoError(t, db.Update(l.AcquireFence("first", false, &fence)))
+
+		var other dps.Fence
+		err := db.Update(l.AcquireFence("second", true, &other))
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), other.Token)
+		assert.Equal(t, "second", other.Holder)
+
+		err = db.View(l.CheckFence(fence.Token))
+		assert.ErrorIs(t, err, dps.ErrFenced)
+
+		err = db.View(l.CheckFence(other.Token))
+		assert.NoError(t, err)
+
+		// Releasing with the stale token does not release the new holder.
+		require.NoError(t, db.Update(l.ReleaseFence(fence.Token)))
+		err = db.View(l.CheckFence(other.Token))
+		assert.NoError(t, err)
+	})
+
+	t.Run("acquires released fence", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		var fence dps.Fence
+		require.NoError(t, db.Update(l.AcquireFence("first", false, &fence)))
+		require.NoError(t, db.Update(l.ReleaseFence(fence.Token)))
+
+		err := db.View(l.CheckFence(fence.Token))
+		assert.ErrorIs(t, err, dps.ErrFenced)
+
+		var other dps.Fence
+		err = db.Update(l.AcquireFence("second", false, &other))
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), other.Token)
+	})
+
+	t.Run("handles missing fence", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		err := db.View(l.CheckFence(1))
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+	})
+}
+
+func TestLibrary_IterateLedger(t *testing.T) {
+	entries := 5
+	paths := mocks.GenericLedgerPaths(entries)
+	payloads := mocks.GenericLedgerPayloads(entries)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := zbor.NewCodec()
+		l := &Library{codec: codec}
+
+		for i := 0; i < entries; i++ {
+			height := mocks.GenericHeight + uint64(i)
+			require.NoError(t, db.Update(l.SavePayload(height, paths[i], payloads[i])))
+		}
+
+		got := make(map[ledger.Path]*ledger.Payload)
+		op := l.IterateLedger(context.Background(), loader.ExcludeNone(), func(path ledger.Path, payload *ledger.Payload) error {
+			got[path] = payload
+
+			return nil
+		})
+
+		err := db.View(op)
+
+		assert.NoError(t, err)
+		assert.Len(t, got, entries)
+		for i := 0; i < entries; i++ {
+			assert.Equal(t, payloads[i], got[paths[i]])
+		}
+	})
+
+	t.Run("handles multiple payloads with the same path", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := zbor.NewCodec()
+		l := &Library{codec: codec}
+
+		// Always use paths[0] for every payload.
+		path := paths[0]
+		for i := 0; i < entries; i++ {
+			height := mocks.GenericHeight + uint64(i)
+			require.NoError(t, db.Update(l.SavePayload(height, path, payloads[i])))
+		}
+
+		got := make(map[ledger.Path]*ledger.Payload)
+		op := l.IterateLedger(context.Background(), loader.ExcludeNone(), func(path ledger.Path, payload *ledger.Payload) error {
+			got[path] = payload
+
+			return nil
+		})
+
+		err := db.View(op)
+
+		require.NoError(t, err)
+		// Verify that only the payload with the greatest height was used in the callback.
+		assert.Len(t, got, 1)
+		assert.Equal(t, payloads[entries-1], got[path])
+	})
+
+	t.Run("handles codec failure", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = func([]byte, interface{}) error {
+			return mocks.GenericError
+		}
+		l := &Library{codec: codec}
+
+		for i := 0; i < entries; i++ {
+			height := mocks.GenericHeight + uint64(i)
+			require.NoError(t, db.Update(l.SavePayload(height, paths[i], payloads[i])))
+		}
+
+		got := make(map[ledger.Path]*ledger.Payload)
+		op := l.IterateLedger(context.Background(), loader.ExcludeNone(), func(path ledger.Path, payload *ledger.Payload) error {
+			got[path] = payload
+
+			return nil
+		})
+
+		err := db.View(op)
+
+		assert.Error(t, err)
+		assert.Len(t, got, 0)
+	})
+
+	t.Run("handles callback error", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := zbor.NewCodec()
+		l := &Library{codec: codec}
+
+		for i := 0; i < entries; i++ {
+			height := mocks.GenericHeight + uint64(i)
+			require.NoError(t, db.Update(l.SavePayload(height, paths[i], payloads[i])))
+		}
+
+		op := l.IterateLedger(context.Background(), loader.ExcludeNone(), func(path ledger.Path, payload *ledger.Payload) error {
+			return mocks.GenericError
+		})
+
+		err := db.View(op)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestLibrary_Namespaces(t *testing.T) {
+	paths := mocks.GenericLedgerPaths(2)
+	payloads := mocks.GenericLedgerPayloads(2)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := zbor.NewCodec()
+		plain := New(codec)
+		first := New(codec, WithNamespace("first"))
+		second := New(codec, WithNamespace("second"))
+
+		require.NoError(t, db.Update(plain.SaveLast(mocks.GenericHeight)))
+		require.NoError(t, db.Update(first.SaveLast(mocks.GenericHeight+1)))
+		require.NoError(t, db.Update(second.SaveLast(mocks.GenericHeight+2)))
+
+		var height uint64
+		require.NoError(t, db.View(plain.RetrieveLast(&height)))
+		assert.Equal(t, mocks.GenericHeight, height)
+		require.NoError(t, db.View(first.RetrieveLast(&height)))
+		assert.Equal(t, mocks.GenericHeight+1, height)
+		require.NoError(t, db.View(second.RetrieveLast(&height)))
+		assert.Equal(t, mocks.GenericHeight+2, height)
+	})
+
+	t.Run("uses plain keys for default namespace", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := New(zbor.NewCodec(), WithNamespace(""))
+
+		require.NoError(t, db.Update(l.SaveFirst(mocks.GenericHeight)))
+
+		err := db.View(func(tx *badger.Txn) error {
+			_, err := tx.Get(EncodeKey(PrefixFirst))
+			return err
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("handles missing data in namespace", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := zbor.NewCodec()
+		plain := New(codec)
+		other := New(codec, WithNamespace("other"))
+
+		require.NoError(t, db.Update(plain.SaveFirst(mocks.GenericHeight)))
+		require.NoError(t, db.Update(plain.SavePayload(mocks.GenericHeight, paths[0], payloads[0])))
+
+		var height uint64
+		err := db.View(other.RetrieveFirst(&height))
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+
+		var payload ledger.Payload
+		err = db.View(other.RetrievePayload(mocks.GenericHeight, paths[0], &payload))
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+	})
+
+	t.Run("separates payloads between namespaces", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := zbor.NewCodec()
+		plain := New(codec)
+		other := New(codec, WithNamespace("other"))
+
+		require.NoError(t, db.Update(plain.SavePayload(mocks.GenericHeight, paths[0], payloads[0])))
+		require.NoError(t, db.Update(other.SavePayload(mocks.GenericHeight, paths[1], payloads[1])))
+
+		var payload ledger.Payload
+		require.NoError(t, db.View(other.RetrievePayload(mocks.GenericHeight, paths[1], &payload)))
+		assert.Equal(t, *payloads[1], payload)
+
+		got := make(map[ledger.Path]*ledger.Payload)
+		op := other.IterateLedger(context.Background(), loader.ExcludeNone(), func(path ledger.Path, payload *ledger.Payload) error {
+			got[path] = payload
+			return nil
+		})
+		require.NoError(t, db.View(op))
+		assert.Equal(t, map[ledger.Path]*ledger.Payload{paths[1]: payloads[1]}, got)
+	})
+}
+
+func TestIndexAndLookup_Accounts(t *testing.T) {
+	address := mocks.GenericAddress(0)
+	other := mocks.GenericAddress(1)
+
+	t.Run("index and lookup height of account", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		err := db.Update(l.IndexHeightForAccount(address, mocks.GenericHeight))
+		require.NoError(t, err)
+
+		var got uint64
+		err = db.View(l.LookupHeightForAccount(address, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, got)
+
+		err = db.View(l.LookupHeightForAccount(other, &got))
+
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+	})
+
+	t.Run("index and lookup key updates of account", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		require.NoError(t, db.Update(l.IndexKeyUpdate(address, mocks.GenericHeight+1, 2)))
+		require.NoError(t, db.Update(l.IndexKeyUpdate(address, mocks.GenericHeight, 1)))
+		require.NoError(t, db.Update(l.IndexKeyUpdate(other, mocks.GenericHeight, 1)))
+
+		var got []uint64
+		err := db.View(l.LookupKeyUpdates(context.Background(), address, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, []uint64{mocks.GenericHeight, mocks.GenericHeight + 1}, got)
+	})
+
+	t.Run("lookup key updates of account without updates", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		var got []uint64
+		err := db.View(l.LookupKeyUpdates(context.Background(), address, &got))
+
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+}
+
+func TestSaveAndRetrieve_ContractVersions(t *testing.T) {
+	address := mocks.GenericAddress(0)
+	added := dps.ContractVersion{
+		Address:       address,
+		Name:          "Contract",
+		Height:        mocks.GenericHeight,
+		TransactionID: mocks.GenericTransaction(0).ID(),
+		CodeHash:      mocks.GenericBytes,
+	}
+	updated := added
+	updated.Height = mocks.GenericHeight + 1
+	removed := updated
+	removed.Removed = true
+
+	t.Run("save and retrieve contract versions", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		other := added
+		other.Name = "Other"
+
+		require.NoError(t, db.Update(l.SaveContractVersions(address, "Contract", updated.Height, []dps.ContractVersion{updated, removed})))
+		require.NoError(t, db.Update(l.SaveContractVersions(address, "Contract", added.Height, []dps.ContractVersion{added})))
+		require.NoError(t, db.Update(l.SaveContractVersions(address, "Other", added.Height, []dps.ContractVersion{other})))
+
+		var got []dps.ContractVersion
+		err := db.View(l.RetrieveContractVersions(context.Background(), address, "Contract", &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, []dps.ContractVersion{added, updated, removed}, got)
+	})
+
+	t.Run("skips versions of contracts with colliding hash", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		other := added
+		other.Name = "Other"
+
+		// Simulate a hash collision by saving another contract under the key
+		// of the requested one.
+		require.NoError(t, db.Update(l.SaveContractVersions(address, "Contract", added.Height, []dps.ContractVersion{added, other})))
+
+		var got []dps.ContractVersion
+		err := db.View(l.RetrieveContractVersions(context.Background(), address, "Contract", &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, []dps.ContractVersion{added}, got)
+	})
+
+	t.Run("handles decoding failure", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = func([]byte, interface{}) error {
+			return mocks.GenericError
+		}
+
+		l := &Library{codec: codec}
+
+		require.NoError(t, db.Update(l.SaveContractVersions(address, "Contract", added.Height, []dps.ContractVersion{added})))
+
+		var got []dps.ContractVersion
+		err := db.View(l.RetrieveContractVersions(context.Background(), address, "Contract", &got))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestSaveAndRetrieve_BlockStats(t *testing.T) {
+	stats := mocks.GenericBlockStats(4)
+
+	t.Run("save and retrieve block stats", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		for _, entry := range stats {
+			require.NoError(t, db.Update(l.SaveBlockStats(entry)))
+		}
+
+		var got []dps.BlockStats
+		err := db.View(l.RetrieveBlockStats(context.Background(), stats[1].Height, stats[2].Height, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, stats[1:3], got)
+	})
+
+	t.Run("handles decoding failure", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = func([]byte, interface{}) error {
+			return mocks.GenericError
+		}
+
+		l := &Library{codec: codec}
+
+		require.NoError(t, db.Update(l.SaveBlockStats(stats[0])))
+
+		var got []dps.BlockStats
+		err := db.View(l.RetrieveBlockStats(context.Background(), stats[0].Height, stats[0].Height, &got))
+
+		assert.Error(t, err)
+	})
+
+	t.Run("stops once the context is cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// The context is cancelled halfway through the scan, once the second
+		// entry was decoded.
+		decoded := 0
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = func(data []byte, v interface{}) error {
+			decoded++
+			if decoded == 2 {
+				cancel()
+			}
+			return zbor.NewCodec().Unmarshal(data, v)
+		}
+
+		l := &Library{codec: zbor.NewCodec()}
+		for _, entry := range stats {
+			require.NoError(t, db.Update(l.SaveBlockStats(entry)))
+		}
+		l.codec = codec
+
+		var got []dps.BlockStats
+		err := db.View(l.RetrieveBlockStats(ctx, stats[0].Height, stats[3].Height, &got))
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, stats[:2], got)
+	})
+
+	t.Run("counts scanned entries", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+		for _, entry := range stats {
+			require.NoError(t, db.Update(l.SaveBlockStats(entry)))
+		}
+
+		ctx, scanned := dps.CountScans(context.Background())
+
+		var got []dps.BlockStats
+		err := db.View(l.RetrieveBlockStats(ctx, stats[1].Height, stats[2].Height, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, stats[1:3], got)
+
+		// The scan also visits the entry after the range, to find its end.
+		assert.Equal(t, uint64(3), *scanned)
+	})
+}
+
+func TestSaveAndRetrieve_RegisterChurn(t *testing.T) {
+	churns := mocks.GenericRegisterChurns(4)
+
+	t.Run("save and retrieve register churn", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		for _, churn := range churns {
+			require.NoError(t, db.Update(l.SaveRegisterChurn(churn)))
+		}
+
+		var got []dps.RegisterChurn
+		err := db.View(l.RetrieveRegisterChurn(context.Background(), churns[1].Height, churns[2].Height, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, churns[1:3], got)
+	})
+
+	t.Run("handles decoding failure", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = func([]byte, interface{}) error {
+			return mocks.GenericError
+		}
+
+		l := &Library{codec: codec}
+
+		require.NoError(t, db.Update(l.SaveRegisterChurn(churns[0])))
+
+		var got []dps.RegisterChurn
+		err := db.View(l.RetrieveRegisterChurn(context.Background(), churns[0].Height, churns[0].Height, &got))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestSaveAndRetrieve_RegisterDeltas(t *testing.T) {
+	deltas := mocks.GenericRegisterDeltas(4)
+	sorted := make([]dps.RegisterDelta, len(deltas))
+	copy(sorted, deltas)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].Path[:], sorted[j].Path[:]) < 0
+	})
+
+	other := deltas[0]
+	other.Height = mocks.GenericHeight + 1
+
+	t.Run("save and retrieve register deltas", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		for _, delta := range append(deltas, other) {
+			require.NoError(t, db.Update(l.SaveRegisterDelta(delta)))
+		}
+
+		var got []dps.RegisterDelta
+		err := db.View(l.RetrieveRegisterDeltas(context.Background(), mocks.GenericHeight, ledger.Path{}, 10, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, sorted, got)
+	})
+
+	t.Run("retrieves page after path", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		for _, delta := range append(deltas, other) {
+			require.NoError(t, db.Update(l.SaveRegisterDelta(delta)))
+		}
+
+		var got []dps.RegisterDelta
+		err := db.View(l.RetrieveRegisterDeltas(context.Background(), mocks.GenericHeight, sorted[0].Path, 2, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, sorted[1:3], got)
+	})
+
+	t.Run("handles decoding failure", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = func([]byte, interface{}) error {
+			return mocks.GenericError
+		}
+
+		l := &Library{codec: codec}
+
+		require.NoError(t, db.Update(l.SaveRegisterDelta(deltas[0])))
+
+		var got []dps.RegisterDelta
+		err := db.View(l.RetrieveRegisterDeltas(context.Background(), mocks.GenericHeight, ledger.Path{}, 10, &got))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestSaveAndRetrieve_ServiceEvents(t *testing.T) {
+	types := mocks.GenericEventTypes(2)
+	events := mocks.GenericEvents(4, types...)
+
+	t.Run("save and retrieve service events", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		require.NoError(t, db.Update(l.SaveServiceEvents(mocks.GenericHeight-1, events[:1])))
+		require.NoError(t, db.Update(l.SaveServiceEvents(mocks.GenericHeight, events[1:3])))
+		require.NoError(t, db.Update(l.SaveServiceEvents(mocks.GenericHeight+2, events[3:])))
+		require.NoError(t, db.Update(l.SaveServiceEvents(mocks.GenericHeight+3, events[:1])))
+
+		var got []dps.ServiceEvent
+		err := db.View(l.RetrieveServiceEvents(context.Background(), mocks.GenericHeight, mocks.GenericHeight+2, nil, &got))
+
+		want := []dps.ServiceEvent{
+			{Height: mocks.GenericHeight, Event: events[1]},
+			{Height: mocks.GenericHeight, Event: events[2]},
+			{Height: mocks.GenericHeight + 2, Event: events[3]},
+		}
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("retrieve service events of given types", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		require.NoError(t, db.Update(l.SaveServiceEvents(mocks.GenericHeight, events)))
+
+		var got []dps.ServiceEvent
+		err := db.View(l.RetrieveServiceEvents(context.Background(), mocks.GenericHeight, mocks.GenericHeight, types[1:], &got))
+
+		want := []dps.ServiceEvent{
+			{Height: mocks.GenericHeight, Event: events[1]},
+			{Height: mocks.GenericHeight, Event: events[3]},
+		}
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("handles decoding failure", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = func([]byte, interface{}) error {
+			return mocks.GenericError
+		}
+
+		l := &Library{codec: codec}
+
+		require.NoError(t, db.Update(l.SaveServiceEvents(mocks.GenericHeight, events)))
+
+		var got []dps.ServiceEvent
+		err := db.View(l.RetrieveServiceEvents(context.Background(), mocks.GenericHeight, mocks.GenericHeight, nil, &got))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestSaveAndRetrieve_Epochs(t *testing.T) {
+	epochs := mocks.GenericEpochs(3)
+
+	t.Run("save and retrieve epochs", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		for i := len(epochs) - 1; i >= 0; i-- {
+			require.NoError(t, db.Update(l.SaveEpoch(&epochs[i])))
+		}
+
+		var got []dps.Epoch
+		err := db.View(l.RetrieveEpochs(context.Background(), &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, epochs, got)
+	})
+
+	t.Run("handles decoding failure", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		codec := mocks.BaselineCodec(t)
+		codec.UnmarshalFunc = func([]byte, interface{}) error {
+			return mocks.GenericError
+		}
+
+		l := &Library{codec: codec}
+
+		require.NoError(t, db.Update(l.SaveEpoch(&epochs[0])))
+
+		var got []dps.Epoch
+		err := db.View(l.RetrieveEpochs(context.Background(), &got))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestIndexAndLookup_EpochPhase(t *testing.T) {
+	t.Run("index and lookup epoch phase", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		require.NoError(t, db.Update(l.IndexEpochPhase(1, flow.EpochPhaseSetup, mocks.GenericHeight)))
+		require.NoError(t, db.Update(l.IndexEpochPhase(1, flow.EpochPhaseCommitted, mocks.GenericHeight+1)))
+
+		var got uint64
+		err := db.View(l.LookupEpochPhase(1, flow.EpochPhaseSetup, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, got)
+	})
+
+	t.Run("handles missing phase", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		require.NoError(t, db.Update(l.IndexEpochPhase(1, flow.EpochPhaseSetup, mocks.GenericHeight)))
+
+		var got uint64
+		err := db.View(l.LookupEpochPhase(1, flow.EpochPhaseStaking, &got))
+
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+	})
+}
+
+func TestSaveAndRetrieve_Identities(t *testing.T) {
+	identities := mocks.GenericIdentities(4)
+
+	db := helpers.InMemoryDB(t)
+	defer db.Close()
+
+	l := &Library{codec: zbor.NewCodec()}
+
+	require.NoError(t, db.Update(l.SaveIdentities(1, identities)))
+
+	var got flow.IdentityList
+	err := db.View(l.RetrieveIdentities(1, &got))
+
+	require.NoError(t, err)
+	assert.Equal(t, identities, got)
+}
+
+func TestLibrary_IndexAndLookupHeightForCommit(t *testing.T) {
+	commit := mocks.GenericCommit(0)
+
+	t.Run("lookup first height for commit", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		require.NoError(t, db.Update(l.IndexHeightForCommit(commit, mocks.GenericHeight+1)))
+		require.NoError(t, db.Update(l.IndexHeightForCommit(commit, mocks.GenericHeight)))
+		require.NoError(t, db.Update(l.IndexHeightForCommit(mocks.GenericCommit(1), mocks.GenericHeight-1)))
+
+		var got uint64
+		err := db.View(l.LookupHeightForCommit(commit, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, got)
+	})
+
+	t.Run("handles missing commit", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		require.NoError(t, db.Update(l.IndexHeightForCommit(mocks.GenericCommit(1), mocks.GenericHeight)))
+
+		var got uint64
+		err := db.View(l.LookupHeightForCommit(commit, &got))
+
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+	})
+}
+
+func TestSaveAndRetrieve_Codec(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		require.NoError(t, db.Update(l.SaveCodec(dps.CodecProtostore)))
+
+		var got string
+		err := db.View(l.RetrieveCodec(&got))
+
+		require.NoError(t, err)
+		assert.Equal(t, dps.CodecProtostore, got)
+	})
+
+	t.Run("handles index without recorded codec", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		require.NoError(t, db.Update(l.SaveFirst(mocks.GenericHeight)))
+
+		var got string
+		err := db.View(l.RetrieveCodec(&got))
+
+		require.NoError(t, err)
+		assert.Equal(t, dps.CodecZbor, got)
+	})
+
+	t.Run("handles new index", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := &Library{codec: zbor.NewCodec()}
+
+		var got string
+		err := db.View(l.RetrieveCodec(&got))
+
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+	})
+}
+
+func TestRetrieveRawEvents_Transcoding(t *testing.T) {
+	db := helpers.InMemoryDB(t)
+	defer db.Close()
+
+	fallback := zbor.NewCodec()
+	l := &Library{codec: protostore.NewCodec(fallback)}
+
+	events := mocks.GenericEvents(4)
+	require.NoError(t, db.Update(l.SaveEvents(mocks.GenericHeight, mocks.GenericEventType(0), events)))
+
+	var data [][]byte
+	err := db.View(l.RetrieveRawEvents(context.Background(), mocks.GenericHeight, nil, &data))
+	require.NoError(t, err)
+	require.Len(t, data, 1)
+
+	var got []flow.Event
+	require.NoError(t, fallback.Unmarshal(data[0], &got))
+	assert.Equal(t, events, got)
+}
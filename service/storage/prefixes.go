@@ -20,6 +20,7 @@ const (
 
 	PrefixHeightForBlock       = 7
 	PrefixHeightForTransaction = 16
+	PrefixHeightForCommit      = 30
 
 	PrefixCommit  = 4
 	PrefixHeader  = 3
@@ -37,4 +38,109 @@ const (
 
 	PrefixSeal           = 14
 	PrefixSealsForHeight = 15
+
+	PrefixCorruption = 18
+
+	PrefixNamespace = 19
+
+	PrefixOwners = 20
+	PrefixDenied = 21
+
+	PrefixEventTypeStats = 22
+
+	PrefixHeightForAccount = 23
+	PrefixKeyUpdates       = 24
+
+	PrefixContractVersions = 25
+
+	PrefixServiceEvents = 26
+
+	PrefixEpochs      = 27
+	PrefixEpochPhases = 28
+	PrefixIdentities  = 29
+
+	PrefixCompressionStats = 31
+
+	PrefixPathFilters = 32
+
+	PrefixPathsForOwner = 33
+
+	PrefixFence = 34
+
+	PrefixManifests = 35
+
+	PrefixSegments = 36
+
+	PrefixStaging = 37
+
+	PrefixCodec = 38
+
+	PrefixTransactionsForScript = 39
+
+	PrefixBlockStats = 40
+
+	PrefixRegisterChurn = 41
+
+	PrefixSizeStats = 42
+
+	PrefixReplicaLog   = 43
+	PrefixReplicaState = 44
+
+	PrefixExecutionRecords = 45
+
+	PrefixBackfills = 46
+
+	PrefixRegisterDeltas = 47
 )
+
+// PrefixNames maps the key prefixes of the index database to readable names,
+// which are used to label statistics and metrics by key prefix.
+var PrefixNames = map[uint8]string{
+	PrefixFirst:                     "first",
+	PrefixLast:                      "last",
+	PrefixHeightForBlock:            "height_for_block",
+	PrefixHeightForTransaction:      "height_for_transaction",
+	PrefixCommit:                    "commit",
+	PrefixHeader:                    "header",
+	PrefixEvents:                    "events",
+	PrefixPayload:                   "payload",
+	PrefixTransaction:               "transaction",
+	PrefixCollection:                "collection",
+	PrefixGuarantee:                 "guarantee",
+	PrefixTransactionsForHeight:     "transactions_for_height",
+	PrefixTransactionsForCollection: "transactions_for_collection",
+	PrefixCollectionsForHeight:      "collections_for_height",
+	PrefixResults:                   "results",
+	PrefixSeal:                      "seal",
+	PrefixSealsForHeight:            "seals_for_height",
+	PrefixCorruption:                "corruption",
+	PrefixNamespace:                 "namespace",
+	PrefixOwners:                    "owners",
+	PrefixDenied:                    "denied",
+	PrefixEventTypeStats:            "event_type_stats",
+	PrefixHeightForAccount:          "height_for_account",
+	PrefixKeyUpdates:                "key_updates",
+	PrefixContractVersions:          "contract_versions",
+	PrefixServiceEvents:             "service_events",
+	PrefixEpochs:                    "epochs",
+	PrefixEpochPhases:               "epoch_phases",
+	PrefixIdentities:                "identities",
+	PrefixHeightForCommit:           "height_for_commit",
+	PrefixCompressionStats:          "compression_stats",
+	PrefixPathFilters:               "path_filters",
+	PrefixPathsForOwner:             "paths_for_owner",
+	PrefixFence:                     "fence",
+	PrefixManifests:                 "manifests",
+	PrefixSegments:                  "segments",
+	PrefixStaging:                   "staging",
+	PrefixCodec:                     "codec",
+	PrefixTransactionsForScript:     "transactions_for_script",
+	PrefixBlockStats:                "block_stats",
+	PrefixRegisterChurn:             "register_churn",
+	PrefixSizeStats:                 "size_stats",
+	PrefixReplicaLog:                "replica_log",
+	PrefixReplicaState:              "replica_state",
+	PrefixExecutionRecords:          "execution_records",
+	PrefixBackfills:                 "backfills",
+	PrefixRegisterDeltas:            "register_deltas",
+}
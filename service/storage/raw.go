@@ -0,0 +1,97 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// transcoder is implemented by codecs that store some values with another
+// encoding than the zbor codec, with which clients decode the raw values they
+// are given. It converts such a stored value into its zbor encoding.
+type transcoder interface {
+	Transcode(compressed []byte, value interface{}) ([]byte, error)
+}
+
+// RetrieveRawHeader retrieves the encoded header at the given height, as it is
+// stored in the index.
+func (l *Library) RetrieveRawHeader(height uint64, data *[]byte) func(*badger.Txn) error {
+	return l.retrieveRaw(l.key(PrefixHeader, height), data)
+}
+
+// RetrieveRawEvents retrieves the encoded batches of events at the given height
+// that match with the specified types, as they are stored in the index. Events
+// are stored in one batch per event type. If no types were provided, all
+// batches are retrieved. Batches stored by a codec with another encoding are
+// converted into their zbor encoding.
+func (l *Library) RetrieveRawEvents(ctx context.Context, height uint64, types []flow.EventType, data *[][]byte) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+		return l.iterateEvents(ctx, tx, height, types, func(item *badger.Item) error {
+
+			val, err := item.ValueCopy(nil)
+			if err != nil {
+				return fmt.Errorf("could not copy events: %w", err)
+			}
+
+			transcoder, ok := l.codec.(transcoder)
+			if ok {
+				var events []flow.Event
+				val, err = transcoder.Transcode(val, &events)
+				if err != nil {
+					return fmt.Errorf("could not transcode events: %w", err)
+				}
+			}
+
+			*data = append(*data, val)
+
+			return nil
+		})
+	}
+}
+
+// RetrieveRawCollection retrieves the encoded collection with the given
+// identifier, as it is stored in the index.
+func (l *Library) RetrieveRawCollection(collectionID flow.Identifier, data *[]byte) func(*badger.Txn) error {
+	return l.retrieveRaw(l.key(PrefixCollection, collectionID), data)
+}
+
+// RetrieveRawGuarantee retrieves the encoded guarantee with the given
+// collection identifier, as it is stored in the index.
+func (l *Library) RetrieveRawGuarantee(collectionID flow.Identifier, data *[]byte) func(*badger.Txn) error {
+	return l.retrieveRaw(l.key(PrefixGuarantee, collectionID), data)
+}
+
+// RetrieveRawTransaction retrieves the encoded transaction with the given
+// identifier, as it is stored in the index.
+func (l *Library) RetrieveRawTransaction(transactionID flow.Identifier, data *[]byte) func(*badger.Txn) error {
+	return l.retrieveRaw(l.key(PrefixTransaction, transactionID), data)
+}
+
+// RetrieveRawResult retrieves the encoded result with the given transaction
+// identifier, as it is stored in the index.
+func (l *Library) RetrieveRawResult(txID flow.Identifier, data *[]byte) func(*badger.Txn) error {
+	return l.retrieveRaw(l.key(PrefixResults, txID), data)
+}
+
+// RetrieveRawSeal retrieves the encoded seal with the given identifier, as it
+// is stored in the index.
+func (l *Library) RetrieveRawSeal(sealID flow.Identifier, data *[]byte) func(*badger.Txn) error {
+	return l.retrieveRaw(l.key(PrefixSeal, sealID), data)
+}
@@ -0,0 +1,201 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// replicaLog assigns consecutive sequence numbers to the entries that the
+// library appends to the replication log.
+type replicaLog struct {
+	sync.Mutex
+	next uint64
+}
+
+func newReplicaLog() *replicaLog {
+	r := replicaLog{
+		next: 1,
+	}
+	return &r
+}
+
+// append calls the given callback with the next sequence number, and only
+// moves on to the following one if the callback succeeds, so that operations
+// that are applied again after their transaction was too big do not leave gaps.
+func (r *replicaLog) append(write func(sequence uint64) error) error {
+	r.Lock()
+	defer r.Unlock()
+
+	err := write(r.next)
+	if err != nil {
+		return err
+	}
+	r.next++
+
+	return nil
+}
+
+// rewind gives back the given sequence number, so that it is assigned to the
+// next entry again. It only does so if no other entry was appended since, as
+// the replicator skips over the gap that is left otherwise.
+func (r *replicaLog) rewind(sequence uint64) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.next == sequence+1 {
+		r.next = sequence
+	}
+}
+
+// replicate appends the given entry to the replication log, within the given
+// transaction. It does nothing when replication is disabled, and for the
+// entries of the replication itself.
+func (l *Library) replicate(tx *badger.Txn, prefix uint8, entry dps.ReplicaEntry) error {
+
+	if l.replica == nil || prefix == PrefixReplicaLog || prefix == PrefixReplicaState {
+		return nil
+	}
+
+	// The entry that updates the last indexed height completes the height, so
+	// we note the height on it for the replicator to know where it stands.
+	if prefix == PrefixLast && !entry.Deleted {
+		err := l.codec.Unmarshal(entry.Value, &entry.Height)
+		if err != nil {
+			return fmt.Errorf("could not decode last height: %w", err)
+		}
+	}
+
+	val, err := l.codec.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("could not encode replica entry (key: %x): %w", entry.Key, err)
+	}
+
+	return l.replica.append(func(sequence uint64) error {
+		err := l.set(tx, l.key(PrefixReplicaLog, sequence), val)
+		if err != nil {
+			return err
+		}
+		l.journal.record(tx, func() { l.replica.rewind(sequence) })
+		return nil
+	})
+}
+
+// LoadReplicaLog is an operation that loads the position of the replication
+// log, so that new entries are appended after the ones that were persisted or
+// already replicated. It should be applied before any entries are written. It
+// does nothing when replication is disabled.
+func (l *Library) LoadReplicaLog() func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		if l.replica == nil {
+			return nil
+		}
+
+		var state dps.ReplicaState
+		err := l.RetrieveReplicaState(&state)(tx)
+		if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+			return fmt.Errorf("could not retrieve replica state: %w", err)
+		}
+		next := state.Sequence + 1
+
+		prefix := l.key(PrefixReplicaLog)
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Reverse = true
+		opts.Prefix = prefix
+
+		it := tx.NewIterator(opts)
+		defer it.Close()
+
+		it.Seek(l.key(PrefixReplicaLog, uint64(math.MaxUint64)))
+		if it.ValidForPrefix(prefix) {
+			last := binary.BigEndian.Uint64(it.Item().Key()[len(prefix):])
+			if last >= next {
+				next = last + 1
+			}
+		}
+
+		l.replica.Lock()
+		l.replica.next = next
+		l.replica.Unlock()
+
+		return nil
+	}
+}
+
+// IterateReplicaLog steps through the entries of the replication log, from the
+// given sequence number onwards, and calls the given callback with each of
+// them. It stops once the callback returns false or an error.
+func (l *Library) IterateReplicaLog(ctx context.Context, from uint64, process func(sequence uint64, entry dps.ReplicaEntry) (bool, error)) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		prefix := l.key(PrefixReplicaLog)
+		start := l.key(PrefixReplicaLog, from)
+
+		return l.iterate(ctx, tx, prefix, start, func(item *badger.Item, segment []byte) (bool, error) {
+
+			sequence := binary.BigEndian.Uint64(segment)
+
+			var entry dps.ReplicaEntry
+			err := item.Value(func(val []byte) error {
+				return l.codec.Unmarshal(val, &entry)
+			})
+			if err != nil {
+				return false, fmt.Errorf("could not decode replica entry (sequence: %d): %w", sequence, err)
+			}
+
+			return process(sequence, entry)
+		})
+	}
+}
+
+// RetrieveReplicaState retrieves the progress of the replication to the
+// secondary index.
+func (l *Library) RetrieveReplicaState(state *dps.ReplicaState) func(*badger.Txn) error {
+	return l.retrieve(l.key(PrefixReplicaState), state)
+}
+
+// TrimReplicaLog is an operation that deletes the entries of the replication
+// log from the given sequence number up to the sequence number of the given
+// state, once they were replicated, and saves the state.
+func (l *Library) TrimReplicaLog(from uint64, state dps.ReplicaState) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+
+		for sequence := from; sequence <= state.Sequence; sequence++ {
+			key := l.key(PrefixReplicaLog, sequence)
+			err := l.delete(tx, key)
+			if err != nil {
+				return fmt.Errorf("could not delete replica entry (sequence: %d): %w", sequence, err)
+			}
+		}
+
+		err := l.save(l.key(PrefixReplicaState), state)(tx)
+		if err != nil {
+			return fmt.Errorf("could not save replica state: %w", err)
+		}
+
+		return nil
+	}
+}
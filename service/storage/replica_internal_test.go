@@ -0,0 +1,126 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/helpers"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestLibrary_ReplicaLog(t *testing.T) {
+	header := mocks.GenericHeader
+
+	// entries returns all entries of the replication log, by sequence number.
+	entries := func(t *testing.T, db *badger.DB, lib *Library) map[uint64]dps.ReplicaEntry {
+		t.Helper()
+
+		got := make(map[uint64]dps.ReplicaEntry)
+		err := db.View(lib.IterateReplicaLog(context.Background(), 0, func(sequence uint64, entry dps.ReplicaEntry) (bool, error) {
+			got[sequence] = entry
+			return true, nil
+		}))
+		require.NoError(t, err)
+
+		return got
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		lib := New(zbor.NewCodec(), WithReplication(true))
+		require.NoError(t, db.View(lib.LoadReplicaLog()))
+
+		require.NoError(t, db.Update(lib.SaveHeader(header.Height, header)))
+		require.NoError(t, db.Update(lib.SaveLast(header.Height)))
+		require.NoError(t, db.Update(func(tx *badger.Txn) error {
+			return lib.delete(tx, lib.key(PrefixHeader, header.Height))
+		}))
+
+		var value []byte
+		require.NoError(t, db.View(lib.retrieveRaw(lib.key(PrefixLast), &value)))
+
+		got := entries(t, db, lib)
+		require.Len(t, got, 3)
+		assert.Equal(t, lib.key(PrefixHeader, header.Height), got[1].Key)
+		assert.NotEmpty(t, got[1].Value)
+		assert.False(t, got[1].Deleted)
+		assert.Zero(t, got[1].Height)
+		assert.Equal(t, dps.ReplicaEntry{Key: lib.key(PrefixLast), Value: value, Height: header.Height}, got[2])
+		assert.Equal(t, lib.key(PrefixHeader, header.Height), got[3].Key)
+		assert.Empty(t, got[3].Value)
+		assert.True(t, got[3].Deleted)
+
+		state := dps.ReplicaState{Sequence: 2, Height: header.Height}
+		require.NoError(t, db.Update(lib.TrimReplicaLog(1, state)))
+
+		got = entries(t, db, lib)
+		assert.Len(t, got, 1)
+		assert.Contains(t, got, uint64(3))
+
+		var retrieved dps.ReplicaState
+		require.NoError(t, db.View(lib.RetrieveReplicaState(&retrieved)))
+		assert.Equal(t, state, retrieved)
+
+		// A library that is opened again continues after the last entry.
+		reopened := New(zbor.NewCodec(), WithReplication(true))
+		require.NoError(t, db.View(reopened.LoadReplicaLog()))
+		require.NoError(t, db.Update(reopened.SaveLast(header.Height+1)))
+
+		got = entries(t, db, reopened)
+		assert.Len(t, got, 2)
+		assert.Equal(t, header.Height+1, got[4].Height)
+	})
+
+	t.Run("continues after replicated entries", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		lib := New(zbor.NewCodec(), WithReplication(true))
+		require.NoError(t, db.Update(lib.TrimReplicaLog(1, dps.ReplicaState{Sequence: 41})))
+		require.NoError(t, db.View(lib.LoadReplicaLog()))
+		require.NoError(t, db.Update(lib.SaveLast(1)))
+
+		got := entries(t, db, lib)
+		assert.Len(t, got, 1)
+		assert.Contains(t, got, uint64(42))
+	})
+
+	t.Run("does not log when disabled", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		lib := New(zbor.NewCodec())
+		require.NoError(t, db.View(lib.LoadReplicaLog()))
+		require.NoError(t, db.Update(lib.SaveLast(1)))
+
+		assert.Empty(t, entries(t, db, lib))
+	})
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package storage
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// sizeRecorder keeps the running size statistics of the entries stored by the
+// library, per key prefix, starting from the statistics that were persisted.
+type sizeRecorder struct {
+	sync.Mutex
+	sizes map[uint8]*dps.SizeStats
+}
+
+func newSizeRecorder() *sizeRecorder {
+	s := sizeRecorder{
+		sizes: make(map[uint8]*dps.SizeStats),
+	}
+	return &s
+}
+
+// load adds the given persisted statistics to the running statistics.
+func (s *sizeRecorder) load(stats []dps.SizeStats) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, entry := range stats {
+		s.add(entry.Prefix, int64(entry.Keys), int64(entry.KeyBytes), int64(entry.ValueBytes))
+	}
+}
+
+// record adds the given changes in number of keys and in bytes to the running
+// statistics of the given prefix.
+func (s *sizeRecorder) record(prefix uint8, keys int64, keyBytes int64, valueBytes int64) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.add(prefix, keys, keyBytes, valueBytes)
+}
+
+// undo takes back a change that was recorded with the given deltas. Prefixes
+// that end up without any size are dropped, as they were most likely only
+// added by the change.
+func (s *sizeRecorder) undo(prefix uint8, keys int64, keyBytes int64, valueBytes int64) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.add(prefix, -keys, -keyBytes, -valueBytes)
+	if *s.sizes[prefix] == (dps.SizeStats{Prefix: prefix}) {
+		delete(s.sizes, prefix)
+	}
+}
+
+func (s *sizeRecorder) add(prefix uint8, keys int64, keyBytes int64, valueBytes int64) {
+	stats, ok := s.sizes[prefix]
+	if !ok {
+		stats = &dps.SizeStats{Prefix: prefix}
+		s.sizes[prefix] = stats
+	}
+	stats.Keys = clamp(stats.Keys, keys)
+	stats.KeyBytes = clamp(stats.KeyBytes, keyBytes)
+	stats.ValueBytes = clamp(stats.ValueBytes, valueBytes)
+}
+
+// current returns the running statistics of all prefixes, sorted by prefix.
+func (s *sizeRecorder) current() []dps.SizeStats {
+	s.Lock()
+	defer s.Unlock()
+
+	current := make([]dps.SizeStats, 0, len(s.sizes))
+	for _, stats := range s.sizes {
+		current = append(current, *stats)
+	}
+	sort.Slice(current, func(i, j int) bool {
+		return current[i].Prefix < current[j].Prefix
+	})
+
+	return current
+}
+
+// clamp applies the given change to the given counter. Entries that were
+// written before size statistics were enabled can be deleted afterwards, so
+// the counter does not go below zero.
+func clamp(counter uint64, delta int64) uint64 {
+	if delta < 0 && uint64(-delta) > counter {
+		return 0
+	}
+	return uint64(int64(counter) + delta)
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/helpers"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestLibrary_SizeStats(t *testing.T) {
+	header := mocks.GenericHeader
+
+	// valueSize returns the size of the value stored at the given key.
+	valueSize := func(t *testing.T, db *badger.DB, key []byte) uint64 {
+		t.Helper()
+
+		var size int64
+		err := db.View(func(tx *badger.Txn) error {
+			item, err := tx.Get(key)
+			if err != nil {
+				return err
+			}
+			size = item.ValueSize()
+			return nil
+		})
+		require.NoError(t, err)
+
+		return uint64(size)
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		lib := New(zbor.NewCodec(), WithSizeStats(true))
+
+		require.NoError(t, db.Update(lib.SaveLast(1)))
+		require.NoError(t, db.Update(lib.SaveLast(2)))
+		require.NoError(t, db.Update(lib.SaveHeader(header.Height, header)))
+
+		last := lib.key(PrefixLast)
+		key := lib.key(PrefixHeader, header.Height)
+		want := []dps.SizeStats{
+			{Prefix: PrefixLast, Keys: 1, KeyBytes: uint64(len(last)), ValueBytes: valueSize(t, db, last)},
+			{Prefix: PrefixHeader, Keys: 1, KeyBytes: uint64(len(key)), ValueBytes: valueSize(t, db, key)},
+		}
+		assert.Equal(t, want, lib.SizeStats())
+
+		require.NoError(t, db.Update(func(tx *badger.Txn) error {
+			return lib.delete(tx, key)
+		}))
+
+		want[1] = dps.SizeStats{Prefix: PrefixHeader}
+		assert.Equal(t, want, lib.SizeStats())
+
+		require.NoError(t, db.Update(lib.SaveSizeStats()))
+
+		var got []dps.SizeStats
+		require.NoError(t, db.View(lib.RetrieveSizeStats(context.Background(), &got)))
+		assert.Equal(t, want, got)
+
+		reopened := New(zbor.NewCodec(), WithSizeStats(true))
+		require.NoError(t, db.View(reopened.LoadSizeStats()))
+		assert.Equal(t, want, reopened.SizeStats())
+	})
+
+	t.Run("does not record when disabled", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		lib := New(zbor.NewCodec())
+
+		require.NoError(t, db.Update(lib.SaveLast(1)))
+		require.NoError(t, db.Update(lib.SaveSizeStats()))
+
+		assert.Empty(t, lib.SizeStats())
+
+		var got []dps.SizeStats
+		require.NoError(t, db.View(lib.RetrieveSizeStats(context.Background(), &got)))
+		assert.Empty(t, got)
+	})
+
+	t.Run("does not go below zero", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, uint64(0), clamp(2, -3))
+		assert.Equal(t, uint64(1), clamp(2, -1))
+		assert.Equal(t, uint64(5), clamp(2, 3))
+	})
+}
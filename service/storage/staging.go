@@ -0,0 +1,141 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package storage
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/dgraph-io/badger/v2"
+)
+
+// stagedEntry is the value that an entry of the index had before it was saved
+// at a staged height. If the entry did not exist before, rolling back the
+// height deletes it.
+type stagedEntry struct {
+	Key   []byte
+	Value []byte
+	Found bool
+}
+
+// stagedEntryFor returns the staged entry holding the current value of the
+// given key.
+func stagedEntryFor(tx *badger.Txn, key []byte) (stagedEntry, error) {
+
+	entry := stagedEntry{
+		Key: append([]byte{}, key...),
+	}
+
+	item, err := tx.Get(key)
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return entry, nil
+	}
+	if err != nil {
+		return stagedEntry{}, err
+	}
+	entry.Value, err = item.ValueCopy(nil)
+	if err != nil {
+		return stagedEntry{}, err
+	}
+	entry.Found = true
+
+	return entry, nil
+}
+
+// staged returns whether the previous values of entries with the given prefix
+// are staged. The staging keyspace itself, the size statistics and the
+// replication log keep track of the index as it is written, including when
+// heights are rolled back, so they are not rolled back themselves. Archived
+// execution records are saved as they are downloaded, rather than for the
+// height being indexed, and the progress of backfills is saved as they build
+// secondary indexes for past heights, so they are not rolled back either.
+func staged(prefix uint8) bool {
+	switch prefix {
+	case PrefixStaging, PrefixSizeStats, PrefixReplicaLog, PrefixReplicaState, PrefixExecutionRecords, PrefixBackfills:
+		return false
+	default:
+		return true
+	}
+}
+
+// stagingRecorder accumulates the previous values of the entries saved by the
+// library for the height that is being indexed, until they are persisted in
+// the staging keyspace.
+type stagingRecorder struct {
+	sync.Mutex
+	entries map[string]stagedEntry
+}
+
+func newStagingRecorder() *stagingRecorder {
+	s := stagingRecorder{
+		entries: make(map[string]stagedEntry),
+	}
+	return &s
+}
+
+func (s *stagingRecorder) has(key []byte) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	_, ok := s.entries[string(key)]
+	return ok
+}
+
+func (s *stagingRecorder) add(entry stagedEntry) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.entries[string(entry.Key)] = entry
+}
+
+// staged returns the entries recorded so far.
+func (s *stagingRecorder) staged() []stagedEntry {
+	s.Lock()
+	defer s.Unlock()
+
+	entries := make([]stagedEntry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// remove drops the entry recorded for the given key.
+func (s *stagingRecorder) remove(key []byte) {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.entries, string(key))
+}
+
+// reset starts recording the entries of the next height, and returns the
+// entries recorded so far, so that they can be restored.
+func (s *stagingRecorder) reset() map[string]stagedEntry {
+	s.Lock()
+	defer s.Unlock()
+
+	entries := s.entries
+	s.entries = make(map[string]stagedEntry)
+	return entries
+}
+
+// restore replaces the recorded entries with the given ones.
+func (s *stagingRecorder) restore(entries map[string]stagedEntry) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.entries = entries
+}
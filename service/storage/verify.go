@@ -0,0 +1,46 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package storage
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common/pathfinder"
+	"github.com/onflow/flow-go/ledger/complete"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// verifyPayload checks that the key of the given payload maps to the path it was
+// retrieved from, when verification is enabled. Payloads without key parts are
+// not verified, as they do not carry the key their path was derived from.
+func (l *Library) verifyPayload(path ledger.Path, payload *ledger.Payload) error {
+
+	if !l.verify || len(payload.Key.KeyParts) == 0 {
+		return nil
+	}
+
+	actual, err := pathfinder.KeyToPath(payload.Key, complete.DefaultPathFinderVersion)
+	if err != nil {
+		return fmt.Errorf("could not compute path of payload key (path: %x): %v: %w", path, err, dps.ErrCorrupted)
+	}
+	if !bytes.Equal(actual[:], path[:]) {
+		return fmt.Errorf("payload key does not match its path (path: %x, actual: %x): %w", path, actual, dps.ErrCorrupted)
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common/pathfinder"
+	"github.com/onflow/flow-go/ledger/complete"
+
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/helpers"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestLibrary_VerifyPayload(t *testing.T) {
+	payload := mocks.GenericLedgerPayload(0)
+	path, err := pathfinder.KeyToPath(payload.Key, complete.DefaultPathFinderVersion)
+	require.NoError(t, err)
+
+	// The generic ledger path was not derived from the generic ledger key, so
+	// storing the payload under it looks like a payload that was corrupted.
+	corrupted := mocks.GenericLedgerPath(0)
+	require.NotEqual(t, path, corrupted)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := New(zbor.NewCodec(), WithVerification(true))
+		require.NoError(t, db.Update(l.SavePayload(mocks.GenericHeight, path, payload)))
+
+		var got ledger.Payload
+		err := db.View(l.RetrievePayload(mocks.GenericHeight, path, &got))
+
+		require.NoError(t, err)
+		assert.Equal(t, payload.Value, got.Value)
+	})
+
+	t.Run("handles payload stored under another path", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := New(zbor.NewCodec(), WithVerification(true))
+		require.NoError(t, db.Update(l.SavePayload(mocks.GenericHeight, corrupted, payload)))
+
+		var got ledger.Payload
+		err := db.View(l.RetrievePayload(mocks.GenericHeight, corrupted, &got))
+
+		assert.ErrorIs(t, err, dps.ErrCorrupted)
+
+		err = db.View(l.IterateLedger(context.Background(), func(uint64) bool { return false }, func(ledger.Path, *ledger.Payload) error {
+			return nil
+		}))
+
+		assert.ErrorIs(t, err, dps.ErrCorrupted)
+	})
+
+	t.Run("does not verify payload when disabled", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := New(zbor.NewCodec())
+		require.NoError(t, db.Update(l.SavePayload(mocks.GenericHeight, corrupted, payload)))
+
+		var got ledger.Payload
+		err := db.View(l.RetrievePayload(mocks.GenericHeight, corrupted, &got))
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("does not verify payload without key", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		l := New(zbor.NewCodec(), WithVerification(true))
+		empty := ledger.NewPayload(ledger.Key{}, ledger.Value{})
+		require.NoError(t, db.Update(l.SavePayload(mocks.GenericHeight, corrupted, empty)))
+
+		var got ledger.Payload
+		err := db.View(l.RetrievePayload(mocks.GenericHeight, corrupted, &got))
+
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+// socketVariable is the environment variable through which systemd passes the
+// path of its notification socket to services of type `notify`.
+const socketVariable = "NOTIFY_SOCKET"
+
+// States that can be sent to systemd to notify it of the progress of the
+// startup and shutdown of the service.
+const (
+	Ready    = "READY=1"
+	Stopping = "STOPPING=1"
+)
+
+// Notify sends the given state to systemd, following the protocol of
+// `sd_notify`. When the process was not started by systemd with a notification
+// socket, it does nothing, so that it can be called unconditionally.
+func Notify(state string) error {
+
+	path := os.Getenv(socketVariable)
+	if path == "" {
+		return nil
+	}
+
+	// Socket paths starting with `@` are in the abstract namespace, which the
+	// standard library handles for us.
+	addr := &net.UnixAddr{
+		Name: path,
+		Net:  "unixgram",
+	}
+	conn, err := net.DialUnix(addr.Net, nil, addr)
+	if err != nil {
+		return fmt.Errorf("could not connect to notification socket: %w", err)
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	if err != nil {
+		return fmt.Errorf("could not send notification: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+//go:build !windows
+// +build !windows
+
+package systemd
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotify(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "notify.sock")
+		conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+		require.NoError(t, err)
+		defer conn.Close()
+		t.Setenv(socketVariable, path)
+
+		err = Notify(Ready)
+
+		require.NoError(t, err)
+		buf := make([]byte, 64)
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		assert.Equal(t, Ready, string(buf[:n]))
+	})
+
+	t.Run("does nothing without socket", func(t *testing.T) {
+		t.Setenv(socketVariable, "")
+
+		err := Notify(Stopping)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("handles missing socket", func(t *testing.T) {
+		t.Setenv(socketVariable, filepath.Join(t.TempDir(), "missing.sock"))
+
+		err := Notify(Ready)
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package tier
+
+import (
+	"time"
+)
+
+// DefaultConfig is the default configuration for the cold tier.
+var DefaultConfig = Config{
+	Age:         100_000,
+	Interval:    time.Hour,
+	SegmentSize: 64 * 1024 * 1024,
+	CacheSize:   100_000_000,
+	Fence:       0,
+	Namespace:   "",
+}
+
+// Config is the configuration for the mover and the reader of the cold tier.
+type Config struct {
+	Age         uint64
+	Interval    time.Duration
+	SegmentSize uint64
+	CacheSize   uint64
+	Fence       uint64
+	Namespace   string
+}
+
+// Option is a function that can be applied to a Config.
+type Option func(*Config)
+
+// WithAge sets the number of heights after which the replaced payloads of a
+// register are moved to the cold tier.
+func WithAge(age uint64) Option {
+	return func(cfg *Config) {
+		cfg.Age = age
+	}
+}
+
+// WithInterval sets the interval at which the mover checks for payloads to move
+// to the cold tier.
+func WithInterval(interval time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.Interval = interval
+	}
+}
+
+// WithSegmentSize sets the size in bytes above which the mover uploads the
+// payloads it collected as a segment.
+func WithSegmentSize(size uint64) Option {
+	return func(cfg *Config) {
+		cfg.SegmentSize = size
+	}
+}
+
+// WithCacheSize sets the size in bytes of the cache of values read from the
+// cold tier.
+func WithCacheSize(size uint64) Option {
+	return func(cfg *Config) {
+		cfg.CacheSize = size
+	}
+}
+
+// WithFence sets the fencing token that the mover checks before replacing
+// payloads in the index, so that it stops when its index writer was fenced off
+// by another instance.
+func WithFence(token uint64) Option {
+	return func(cfg *Config) {
+		cfg.Fence = token
+	}
+}
+
+// WithNamespace sets the namespace of the index whose payloads are moved to the
+// cold tier. The segments of each namespace are kept apart in the store, so that
+// the namespaces of an index can share a cold tier.
+func WithNamespace(namespace string) Option {
+	return func(cfg *Config) {
+		cfg.Namespace = namespace
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package tier
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+
+	"cloud.google.com/go/storage"
+)
+
+// GCSStore is a store that keeps the segments of the cold tier in a Google
+// Cloud Storage bucket.
+type GCSStore struct {
+	bucket *storage.BucketHandle
+	prefix string
+}
+
+// NewGCSStore returns a store that keeps objects in the given bucket, with the
+// given prefix prepended to their names.
+func NewGCSStore(bucket *storage.BucketHandle, prefix string) *GCSStore {
+
+	g := GCSStore{
+		bucket: bucket,
+		prefix: prefix,
+	}
+
+	return &g
+}
+
+// Upload writes the given data to the object with the given name.
+func (g *GCSStore) Upload(name string, data []byte) error {
+
+	object := g.bucket.Object(path.Join(g.prefix, name))
+	writer := object.NewWriter(context.Background())
+	_, err := writer.Write(data)
+	if err != nil {
+		_ = writer.Close()
+		return fmt.Errorf("could not write object: %w", err)
+	}
+
+	// The object is only created once the writer is closed, so this is where
+	// the upload fails.
+	err = writer.Close()
+	if err != nil {
+		return fmt.Errorf("could not close object writer: %w", err)
+	}
+
+	return nil
+}
+
+// Download reads the given range of bytes from the object with the given name.
+func (g *GCSStore) Download(name string, offset uint64, length uint64) ([]byte, error) {
+
+	object := g.bucket.Object(path.Join(g.prefix, name))
+	reader, err := object.NewRangeReader(context.Background(), int64(offset), int64(length))
+	if err != nil {
+		return nil, fmt.Errorf("could not create object reader: %w", err)
+	}
+	defer reader.Close()
+
+	data := make([]byte, length)
+	_, err = io.ReadFull(reader, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not read object: %w", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,278 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package tier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/rs/zerolog"
+
+	"github.com/onflow/flow-go/ledger"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/logs"
+)
+
+// batchSize is the number of payloads that the mover replaces with their cold
+// tier location in a single transaction.
+const batchSize = 1000
+
+// Mover moves the payloads of registers that were replaced more than a given
+// number of heights ago from the index to the cold tier. It collects them into
+// segments, uploads each segment to the store and then replaces the payloads in
+// the index with their location in the segment.
+type Mover struct {
+	log   zerolog.Logger
+	db    *badger.DB
+	lib   dps.Library
+	store Store
+	cfg   Config
+	stop  chan struct{}
+	wg    *sync.WaitGroup
+}
+
+// NewMover returns a new mover, which moves payloads from the index in the given
+// database to the given store.
+func NewMover(log zerolog.Logger, db *badger.DB, lib dps.Library, store Store, options ...Option) *Mover {
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	m := Mover{
+		log:   log.With().Str(logs.Component, "cold_tier_mover").Logger(),
+		db:    db,
+		lib:   lib,
+		store: store,
+		cfg:   cfg,
+		stop:  make(chan struct{}),
+		wg:    &sync.WaitGroup{},
+	}
+
+	return &m
+}
+
+// Run moves payloads to the cold tier at the configured interval, until the
+// mover is stopped.
+func (m *Mover) Run() error {
+	m.wg.Add(1)
+	defer m.wg.Done()
+
+	for {
+		err := m.move()
+		if errors.Is(err, dps.ErrFinished) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not move payloads to cold tier: %w", err)
+		}
+
+		select {
+		case <-m.stop:
+			return nil
+		case <-time.After(m.cfg.Interval):
+			// continue
+		}
+	}
+}
+
+// Stop gracefully stops the mover, which finishes writing the segment that it
+// is currently uploading.
+func (m *Mover) Stop() error {
+	close(m.stop)
+	m.wg.Wait()
+	return nil
+}
+
+// entry is a payload that was collected for the next segment.
+type entry struct {
+	path   ledger.Path
+	height uint64
+	value  []byte
+}
+
+func (m *Mover) move() error {
+
+	// Payloads are moved once they were replaced at a height that is more than
+	// the configured age below the last indexed height.
+	var last uint64
+	err := m.db.View(m.lib.RetrieveLast(&last))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not retrieve last height: %w", err)
+	}
+	if last <= m.cfg.Age {
+		return nil
+	}
+	cutoff := last - m.cfg.Age
+
+	// If the last segment was already written for the same cutoff height, all
+	// payloads that can be moved were already moved.
+	var segments []dps.Segment
+	err = m.db.View(m.lib.RetrieveSegments(context.Background(), &segments))
+	if err != nil {
+		return fmt.Errorf("could not retrieve segments: %w", err)
+	}
+	number := uint64(1)
+	if len(segments) > 0 {
+		previous := segments[len(segments)-1]
+		if previous.Cutoff >= cutoff {
+			return nil
+		}
+		number = previous.Number + 1
+	}
+
+	// We go through the replaced payloads and upload them whenever we collected
+	// enough of them for a segment. The transactions that replace the payloads
+	// in the index do not affect the iteration, which reads from a snapshot.
+	var entries []entry
+	var size uint64
+	err = m.db.View(m.lib.IterateHistory(context.Background(), cutoff, func(path ledger.Path, height uint64, value []byte) error {
+
+		entries = append(entries, entry{
+			path:   path,
+			height: height,
+			value:  append([]byte(nil), value...),
+		})
+		size += uint64(len(value))
+		if size < m.cfg.SegmentSize {
+			return nil
+		}
+
+		err := m.flush(number, cutoff, entries, size)
+		if err != nil {
+			return fmt.Errorf("could not flush segment (number: %d): %w", number, err)
+		}
+		number++
+		entries = nil
+		size = 0
+
+		select {
+		case <-m.stop:
+			return dps.ErrFinished
+		default:
+			return nil
+		}
+	}))
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	err = m.flush(number, cutoff, entries, size)
+	if err != nil {
+		return fmt.Errorf("could not flush segment (number: %d): %w", number, err)
+	}
+
+	return nil
+}
+
+// flush uploads the given entries as the segment with the given number, and
+// then replaces their payloads in the index with their location in it.
+func (m *Mover) flush(number uint64, cutoff uint64, entries []entry, size uint64) error {
+
+	data := make([]byte, 0, size)
+	colds := make([]dps.ColdValue, 0, len(entries))
+	for _, entry := range entries {
+		colds = append(colds, dps.ColdValue{
+			Segment: number,
+			Offset:  uint64(len(data)),
+			Length:  uint64(len(entry.value)),
+		})
+		data = append(data, entry.value...)
+	}
+
+	err := m.store.Upload(segmentName(m.cfg.Namespace, number), data)
+	if err != nil {
+		return fmt.Errorf("could not upload segment: %w", err)
+	}
+
+	segment := dps.Segment{
+		Number: number,
+		Cutoff: cutoff,
+		Values: uint64(len(entries)),
+		Bytes:  size,
+	}
+	err = m.update(m.lib.SaveSegment(&segment))
+	if err != nil {
+		return fmt.Errorf("could not save segment: %w", err)
+	}
+
+	for start := 0; start < len(entries); start += batchSize {
+		end := start + batchSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+
+		ops := make([]func(*badger.Txn) error, 0, end-start)
+		for i := start; i < end; i++ {
+			entry := entries[i]
+			ops = append(ops, m.lib.SaveColdPayload(entry.height, entry.path, entry.value, colds[i]))
+		}
+
+		err = m.update(ops...)
+		if err != nil {
+			return fmt.Errorf("could not replace payloads: %w", err)
+		}
+	}
+
+	m.log.Info().
+		Uint64("segment", number).
+		Uint64("cutoff", cutoff).
+		Int("values", len(entries)).
+		Uint64("bytes", size).
+		Msg("payloads moved to cold tier")
+
+	return nil
+}
+
+// update applies the given operations in a transaction, which also checks the
+// fence when fencing is enabled. When the transaction conflicts with one of the
+// index writer, it is applied again, as the operations skip payloads that were
+// written in the meantime.
+func (m *Mover) update(ops ...func(*badger.Txn) error) error {
+	for {
+		err := m.db.Update(func(tx *badger.Txn) error {
+			if m.cfg.Fence != 0 {
+				err := m.lib.CheckFence(m.cfg.Fence)(tx)
+				if err != nil {
+					return err
+				}
+			}
+			for _, op := range ops {
+				err := op(tx)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if errors.Is(err, badger.ErrConflict) {
+			continue
+		}
+		return err
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package tier
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger"
+
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/storage"
+	"github.com/optakt/flow-dps/testing/helpers"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestMover_Move(t *testing.T) {
+	paths := mocks.GenericLedgerPaths(2)
+	payloads := mocks.GenericLedgerPayloads(4)
+
+	// setup indexes three versions of each register, at heights 10, 20 and 30,
+	// with 40 as the last indexed height.
+	setup := func(t *testing.T) (*badger.DB, *storage.Library) {
+		t.Helper()
+
+		db := helpers.InMemoryDB(t)
+		lib := storage.New(zbor.NewCodec())
+
+		for i, path := range paths {
+			for j, height := range []uint64{10, 20, 30} {
+				require.NoError(t, db.Update(lib.SavePayload(height, path, payloads[i+j])))
+			}
+		}
+		require.NoError(t, db.Update(lib.SaveLast(40)))
+
+		return db, lib
+	}
+
+	// memory returns a store that keeps uploaded objects in memory.
+	memory := func(t *testing.T) *mocks.Store {
+		t.Helper()
+
+		var mutex sync.Mutex
+		objects := make(map[string][]byte)
+		store := mocks.BaselineStore(t)
+		store.UploadFunc = func(name string, data []byte) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			objects[name] = data
+			return nil
+		}
+		store.DownloadFunc = func(name string, offset uint64, length uint64) ([]byte, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return objects[name][offset : offset+length], nil
+		}
+
+		return store
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setup(t)
+		defer db.Close()
+
+		store := memory(t)
+		mover := NewMover(zerolog.Nop(), db, lib, store, WithAge(15), WithSegmentSize(1))
+
+		err := mover.move()
+		require.NoError(t, err)
+
+		// The payloads replaced at or below height 25 were moved, one per
+		// segment, as each of them is above the segment size.
+		var segments []dps.Segment
+		require.NoError(t, db.View(lib.RetrieveSegments(context.Background(), &segments)))
+		require.Len(t, segments, 2)
+		assert.Equal(t, uint64(1), segments[0].Number)
+		assert.Equal(t, uint64(2), segments[1].Number)
+		assert.Equal(t, uint64(25), segments[1].Cutoff)
+
+		// The moved payloads are read back from the store, while the others
+		// are still read from the index.
+		reader, err := NewReader(store)
+		require.NoError(t, err)
+		cold := storage.New(zbor.NewCodec(), storage.WithColdTier(reader))
+		for i, path := range paths {
+			for j, height := range []uint64{10, 20, 30} {
+				var payload ledger.Payload
+				err := db.View(cold.RetrievePayload(height, path, &payload))
+				require.NoError(t, err)
+				assert.Equal(t, *payloads[i+j], payload)
+			}
+		}
+
+		var payload ledger.Payload
+		err = db.View(lib.RetrievePayload(10, paths[0], &payload))
+		assert.ErrorIs(t, err, dps.ErrCold)
+		err = db.View(lib.RetrievePayload(20, paths[0], &payload))
+		assert.NoError(t, err)
+
+		// Nothing is moved again for the same cutoff height.
+		store.UploadFunc = func(string, []byte) error {
+			t.Fatal("unexpected upload")
+			return nil
+		}
+		err = mover.move()
+		assert.NoError(t, err)
+	})
+
+	t.Run("does nothing below age", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setup(t)
+		defer db.Close()
+
+		store := mocks.BaselineStore(t)
+		store.UploadFunc = func(string, []byte) error {
+			t.Fatal("unexpected upload")
+			return nil
+		}
+		mover := NewMover(zerolog.Nop(), db, lib, store, WithAge(40))
+
+		err := mover.move()
+		assert.NoError(t, err)
+	})
+
+	t.Run("handles store failure", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setup(t)
+		defer db.Close()
+
+		store := mocks.BaselineStore(t)
+		store.UploadFunc = func(string, []byte) error {
+			return mocks.GenericError
+		}
+		mover := NewMover(zerolog.Nop(), db, lib, store, WithAge(15))
+
+		err := mover.move()
+		assert.ErrorIs(t, err, mocks.GenericError)
+
+		// The payloads are left in the index.
+		var payload ledger.Payload
+		err = db.View(lib.RetrievePayload(10, paths[0], &payload))
+		assert.NoError(t, err)
+	})
+
+	t.Run("handles fenced index", func(t *testing.T) {
+		t.Parallel()
+
+		db, lib := setup(t)
+		defer db.Close()
+
+		var fence dps.Fence
+		require.NoError(t, db.Update(lib.AcquireFence("first", false, &fence)))
+		require.NoError(t, db.Update(lib.AcquireFence("second", true, &fence)))
+
+		mover := NewMover(zerolog.Nop(), db, lib, memory(t), WithAge(15), WithFence(fence.Token-1))
+
+		err := mover.move()
+		assert.ErrorIs(t, err, dps.ErrFenced)
+	})
+}
+
+func TestMover_RunAndStop(t *testing.T) {
+	db := helpers.InMemoryDB(t)
+	defer db.Close()
+
+	mover := NewMover(zerolog.Nop(), db, storage.New(zbor.NewCodec()), mocks.BaselineStore(t))
+
+	done := make(chan error)
+	go func() {
+		done <- mover.Run()
+	}()
+
+	require.NoError(t, mover.Stop())
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package tier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"cloud.google.com/go/storage"
+)
+
+// Supported schemes of cold tier addresses.
+const (
+	SchemeS3  = "s3"
+	SchemeGCS = "gs"
+)
+
+// Open returns the store for the given address, which is made of the scheme of
+// the object storage, the bucket and an optional prefix for the object names,
+// such as `s3://bucket/prefix` or `gs://bucket/prefix`. The endpoint, region and
+// credentials are only used for S3-compatible object storage, while Google
+// Cloud Storage uses the default credentials of the environment, with a client
+// that lives as long as the process.
+func Open(address string, endpoint string, region string, creds Credentials) (Store, error) {
+
+	parsed, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse address: %w", err)
+	}
+	bucket := parsed.Host
+	prefix := strings.TrimPrefix(parsed.Path, "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("missing bucket in address (%s)", address)
+	}
+
+	switch parsed.Scheme {
+
+	case SchemeS3:
+		return NewS3Store(http.DefaultClient, endpoint, region, bucket, prefix, creds)
+
+	case SchemeGCS:
+		client, err := storage.NewClient(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("could not create client: %w", err)
+		}
+		return NewGCSStore(client.Bucket(bucket), prefix), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported address scheme (%s)", parsed.Scheme)
+	}
+}
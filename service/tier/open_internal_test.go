@@ -0,0 +1,54 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package tier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		got, err := Open("s3://bucket/cold/segments", "https://s3.amazonaws.com", "us-east-1", Credentials{})
+
+		require.NoError(t, err)
+		require.IsType(t, &S3Store{}, got)
+		store := got.(*S3Store)
+		assert.Equal(t, "bucket", store.bucket)
+		assert.Equal(t, "cold/segments", store.prefix)
+		assert.Equal(t, "https://s3.amazonaws.com/bucket/cold/segments/object", store.address("object"))
+	})
+
+	t.Run("handles missing bucket", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Open("s3:///prefix", "https://s3.amazonaws.com", "us-east-1", Credentials{})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles unsupported scheme", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Open("ftp://bucket", "https://s3.amazonaws.com", "us-east-1", Credentials{})
+
+		assert.Error(t, err)
+	})
+}
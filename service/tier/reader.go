@@ -0,0 +1,83 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package tier
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/dgraph-io/ristretto"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Reader reads values from the segments of the cold tier, and keeps the values
+// it read in a cache, as the same historical registers tend to be read again.
+type Reader struct {
+	store Store
+	cache *ristretto.Cache
+	cfg   Config
+}
+
+// NewReader returns a new reader of the cold tier using the given store.
+func NewReader(store Store, options ...Option) (*Reader, error) {
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	// Ristretto recommends keeping ten times as many counters as items in the
+	// cache when full. Assuming an average value size of 1 kilobyte, this is
+	// what we get.
+	cache, err := ristretto.NewCache(&ristretto.Config{
+		NumCounters: int64(cfg.CacheSize) / 1000 * 10,
+		MaxCost:     int64(cfg.CacheSize),
+		BufferItems: 64,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize cache: %w", err)
+	}
+
+	r := Reader{
+		store: store,
+		cache: cache,
+		cfg:   cfg,
+	}
+
+	return &r, nil
+}
+
+// Read reads the given value from the cold tier.
+func (r *Reader) Read(value dps.ColdValue) ([]byte, error) {
+
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key[0:], value.Segment)
+	binary.BigEndian.PutUint64(key[8:], value.Offset)
+
+	cached, ok := r.cache.Get(key)
+	if ok {
+		return cached.([]byte), nil
+	}
+
+	data, err := r.store.Download(segmentName(r.cfg.Namespace, value.Segment), value.Offset, value.Length)
+	if err != nil {
+		return nil, fmt.Errorf("could not download value: %w", err)
+	}
+
+	_ = r.cache.Set(key, data, int64(len(data)))
+
+	return data, nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package tier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestReader_Read(t *testing.T) {
+
+	value := dps.ColdValue{Segment: 3, Offset: 8, Length: 4}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		downloads := 0
+		store := mocks.BaselineStore(t)
+		store.DownloadFunc = func(name string, offset uint64, length uint64) ([]byte, error) {
+			assert.Equal(t, "namespace/0000000000000003.segment", name)
+			assert.Equal(t, value.Offset, offset)
+			assert.Equal(t, value.Length, length)
+			downloads++
+			return mocks.GenericBytes, nil
+		}
+
+		reader, err := NewReader(store, WithNamespace("namespace"))
+		require.NoError(t, err)
+
+		got, err := reader.Read(value)
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericBytes, got)
+
+		// The cache is populated asynchronously, so we wait for the value to be
+		// cached before reading it again.
+		reader.cache.Wait()
+
+		got, err = reader.Read(value)
+
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericBytes, got)
+		assert.Equal(t, 1, downloads)
+	})
+
+	t.Run("handles store failure", func(t *testing.T) {
+		t.Parallel()
+
+		store := mocks.BaselineStore(t)
+		store.DownloadFunc = func(string, uint64, uint64) ([]byte, error) {
+			return nil, mocks.GenericError
+		}
+
+		reader, err := NewReader(store)
+		require.NoError(t, err)
+
+		_, err = reader.Read(value)
+
+		assert.ErrorIs(t, err, mocks.GenericError)
+	})
+}
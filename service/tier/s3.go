@@ -0,0 +1,210 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package tier
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Credentials are the credentials used to sign requests to an S3-compatible
+// object storage.
+type Credentials struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+// S3Store is a store that keeps the segments of the cold tier in a bucket of an
+// S3-compatible object storage, such as Amazon S3, MinIO or Google Cloud
+// Storage through its interoperability API. Buckets are addressed in the path
+// of the requests, which all of them support.
+type S3Store struct {
+	client   *http.Client
+	endpoint *url.URL
+	region   string
+	bucket   string
+	prefix   string
+	creds    Credentials
+	now      func() time.Time
+}
+
+// NewS3Store returns a store that keeps objects in the given bucket of the
+// S3-compatible object storage at the given endpoint, with the given prefix
+// prepended to their names.
+func NewS3Store(client *http.Client, endpoint string, region string, bucket string, prefix string, creds Credentials) (*S3Store, error) {
+
+	address, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse endpoint: %w", err)
+	}
+	if address.Scheme != "http" && address.Scheme != "https" {
+		return nil, fmt.Errorf("invalid endpoint scheme (%s)", address.Scheme)
+	}
+
+	s := S3Store{
+		client:   client,
+		endpoint: address,
+		region:   region,
+		bucket:   bucket,
+		prefix:   prefix,
+		creds:    creds,
+		now:      time.Now,
+	}
+
+	return &s, nil
+}
+
+// Upload writes the given data to the object with the given name.
+func (s *S3Store) Upload(name string, data []byte) error {
+
+	req, err := http.NewRequest(http.MethodPut, s.address(name), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+	s.sign(req, data)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not execute request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status (%s)", res.Status)
+	}
+
+	return nil
+}
+
+// Download reads the given range of bytes from the object with the given name.
+func (s *S3Store) Download(name string, offset uint64, length uint64) ([]byte, error) {
+
+	req, err := http.NewRequest(http.MethodGet, s.address(name), nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	s.sign(req, nil)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("unexpected response status (%s)", res.Status)
+	}
+
+	data := make([]byte, length)
+	_, err = io.ReadFull(res.Body, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not read response body: %w", err)
+	}
+
+	return data, nil
+}
+
+// address returns the URL of the object with the given name.
+func (s *S3Store) address(name string) string {
+	address := *s.endpoint
+	address.Path = "/" + path.Join(s.bucket, s.prefix, name)
+	return address.String()
+}
+
+// sign signs the given request with the given payload, using the AWS signature
+// version 4, see:
+// https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func (s *S3Store) sign(req *http.Request, payload []byte) {
+
+	now := s.now().UTC()
+	timestamp := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	hash := sha256.Sum256(payload)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(hash[:]))
+	req.Header.Set("X-Amz-Date", timestamp)
+	if s.creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.creds.SessionToken)
+	}
+
+	// The host and all headers specific to the request are signed, with their
+	// names in lower case and in alphabetical order.
+	headers := map[string]string{
+		"host": req.URL.Host,
+	}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "range" || name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	request := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonical.String(),
+		signed,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	digest := sha256.Sum256([]byte(request))
+	message := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		timestamp,
+		scope,
+		hex.EncodeToString(digest[:]),
+	}, "\n")
+
+	key := mac([]byte("AWS4"+s.creds.SecretKey), date)
+	key = mac(key, s.region)
+	key = mac(key, "s3")
+	key = mac(key, "aws4_request")
+	signature := hex.EncodeToString(mac(key, message))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.creds.AccessKey, scope, signed, signature,
+	))
+}
+
+func mac(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
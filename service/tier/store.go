@@ -0,0 +1,33 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package tier
+
+import (
+	"fmt"
+	"path"
+)
+
+// Store represents an object storage bucket, in which the segments of the cold
+// tier are kept.
+type Store interface {
+	Upload(name string, data []byte) error
+	Download(name string, offset uint64, length uint64) ([]byte, error)
+}
+
+// segmentName returns the name of the object that holds the segment with the
+// given number for the given namespace.
+func segmentName(namespace string, number uint64) string {
+	return path.Join(namespace, fmt.Sprintf("%016d.segment", number))
+}
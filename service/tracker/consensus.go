@@ -24,6 +24,7 @@ import (
 	"github.com/onflow/flow-go/storage/badger/operation"
 
 	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/logs"
 )
 
 // Consensus is the DPS consensus follower, which uses a local protocol state
@@ -33,10 +34,11 @@ import (
 // the cached data each time a block is finalized.
 // Consensus implements the `Chain` interface needed by the DPS indexer.
 type Consensus struct {
-	log  zerolog.Logger
-	db   *badger.DB
-	hold RecordHolder
-	last uint64
+	log   zerolog.Logger
+	db    *badger.DB
+	hold  RecordHolder
+	last  uint64          // height of the last finalized block
+	final flow.Identifier // identifier of the last finalized block
 }
 
 // NewConsensus returns a new instance of the DPS consensus follower, reading
@@ -49,31 +51,64 @@ func NewConsensus(log zerolog.Logger, db *badger.DB, hold RecordHolder) (*Consen
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve last height: %w", err)
 	}
+	var final flow.Identifier
+	err = db.View(operation.LookupBlockHeight(last, &final))
+	if err != nil {
+		return nil, fmt.Errorf("could not look up last block: %w", err)
+	}
 
 	c := Consensus{
-		log:  log.With().Str("component", "consensus_tracker").Logger(),
-		db:   db,
-		hold: hold,
-		last: last,
+		log:   log.With().Str(logs.Component, "consensus_tracker").Logger(),
+		db:    db,
+		hold:  hold,
+		last:  last,
+		final: final,
 	}
 
 	return &c, nil
 }
 
 // OnBlockFinalized is a callback that notifies the consensus tracker of a new
-// finalized block.
+// finalized block. The follower can deliver blocks of short-lived forks, for
+// example when it resumes with a stale protocol state database, so the block is
+// only accepted if it is the finalized block at its height in the protocol
+// state, and if it comes after the last finalized block we know of.
 func (c *Consensus) OnBlockFinalized(blockID flow.Identifier) {
 
 	var header flow.Header
 	err := c.db.View(operation.RetrieveHeader(blockID, &header))
 	if err != nil {
-		c.log.Error().Err(err).Hex("block", blockID[:]).Msg("could not get header")
+		c.log.Error().Err(err).Hex(logs.BlockID, blockID[:]).Msg("could not get header")
+		return
+	}
+
+	var finalID flow.Identifier
+	err = c.db.View(operation.LookupBlockHeight(header.Height, &finalID))
+	if err != nil {
+		c.log.Error().Err(err).Hex(logs.BlockID, blockID[:]).Uint64(logs.Height, header.Height).Msg("could not look up finalized block")
+		return
+	}
+	if finalID != blockID {
+		c.log.Warn().
+			Hex(logs.BlockID, blockID[:]).
+			Hex("finalized", finalID[:]).
+			Uint64(logs.Height, header.Height).
+			Msg("skipping block that is not on the finalized fork")
+		return
+	}
+	if blockID == c.final || header.Height < c.last {
+		c.log.Debug().
+			Hex(logs.BlockID, blockID[:]).
+			Uint64(logs.Height, header.Height).
+			Uint64("last", c.last).
+			Msg("skipping stale finalized block")
 		return
 	}
 
 	c.last = header.Height
+	c.final = blockID
 
-	c.log.Debug().Hex("block", blockID[:]).Uint64("height", header.Height).Msg("block finalization processed")
+	c.log.Debug().Hex(logs.BlockID, blockID[:]).Uint64(logs.Height, header.Height).Msg("block finalization processed")
 }
 
 // Root returns the root height from the underlying protocol state.
@@ -88,6 +123,37 @@ func (c *Consensus) Root() (uint64, error) {
 	return root, nil
 }
 
+// RootSeal returns the seal of the root block from the underlying protocol
+// state, which contains the state commitment at the root height.
+func (c *Consensus) RootSeal() (*flow.Seal, error) {
+
+	var root uint64
+	err := c.db.View(operation.RetrieveRootHeight(&root))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve root height: %w", err)
+	}
+
+	var blockID flow.Identifier
+	err = c.db.View(operation.LookupBlockHeight(root, &blockID))
+	if err != nil {
+		return nil, fmt.Errorf("could not look up root block: %w", err)
+	}
+
+	var sealID flow.Identifier
+	err = c.db.View(operation.LookupBlockSeal(blockID, &sealID))
+	if err != nil {
+		return nil, fmt.Errorf("could not look up root seal: %w", err)
+	}
+
+	var seal flow.Seal
+	err = c.db.View(operation.RetrieveSeal(sealID, &seal))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve root seal: %w", err)
+	}
+
+	return &seal, nil
+}
+
 // Header returns the header for the given height, if available. Once a header
 // has been successfully retrieved, all block payload data at a height lower
 // than the returned payload are purged from the cache.
@@ -304,3 +370,44 @@ func (c *Consensus) Events(height uint64) ([]flow.Event, error) {
 
 	return events, nil
 }
+
+// EpochStatus returns the epoch status of the protocol state for the
+// finalized block at the given height.
+func (c *Consensus) EpochStatus(height uint64) (*flow.EpochStatus, error) {
+
+	if height > c.last {
+		return nil, dps.ErrUnavailable
+	}
+
+	var blockID flow.Identifier
+	err := c.db.View(operation.LookupBlockHeight(height, &blockID))
+	if err != nil {
+		return nil, fmt.Errorf("could not look up block: %w", err)
+	}
+
+	var status flow.EpochStatus
+	err = c.db.View(operation.RetrieveEpochStatus(blockID, &status))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve epoch status: %w", err)
+	}
+
+	return &status, nil
+}
+
+// EpochSetup returns the setup event of the current epoch for the finalized
+// block at the given height.
+func (c *Consensus) EpochSetup(height uint64) (*flow.EpochSetup, error) {
+
+	status, err := c.EpochStatus(height)
+	if err != nil {
+		return nil, fmt.Errorf("could not get epoch status: %w", err)
+	}
+
+	var setup flow.EpochSetup
+	err = c.db.View(operation.RetrieveEpochSetup(status.CurrentEpoch.SetupID, &setup))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve epoch setup: %w", err)
+	}
+
+	return &setup, nil
+}
@@ -41,6 +41,7 @@ func TestNewConsensus(t *testing.T) {
 		defer db.Close()
 
 		require.NoError(t, db.Update(operation.InsertFinalizedHeight(header.Height)))
+		require.NoError(t, db.Update(operation.IndexBlockHeight(header.Height, header.ID())))
 
 		consensus, err := NewConsensus(log, db, hold)
 
@@ -48,6 +49,23 @@ func TestNewConsensus(t *testing.T) {
 		assert.Equal(t, hold, consensus.hold)
 		assert.Equal(t, db, consensus.db)
 		assert.Equal(t, header.Height, consensus.last)
+		assert.Equal(t, header.ID(), consensus.final)
+	})
+
+	t.Run("handles missing last block", func(t *testing.T) {
+		t.Parallel()
+
+		log := zerolog.Nop()
+		hold := mocks.BaselineRecordHolder(t)
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.InsertFinalizedHeight(header.Height)))
+
+		_, err := NewConsensus(log, db, hold)
+
+		assert.Error(t, err)
 	})
 
 	t.Run("handles missing root height", func(t *testing.T) {
@@ -75,12 +93,73 @@ func TestConsensus_OnBlockFinalized(t *testing.T) {
 		defer db.Close()
 
 		require.NoError(t, db.Update(operation.InsertHeader(header.ID(), header)))
+		require.NoError(t, db.Update(operation.IndexBlockHeight(header.Height, header.ID())))
 
 		cons := BaselineConsensus(t, WithDB(db))
 
 		cons.OnBlockFinalized(header.ID())
 
 		assert.Equal(t, cons.last, header.Height)
+		assert.Equal(t, cons.final, header.ID())
+	})
+
+	t.Run("skips block on fork", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		// The fork block has the same height as the finalized block, but a
+		// different identifier, so it is not indexed as finalized.
+		fork := *header
+		fork.View++
+		require.NoError(t, db.Update(operation.InsertHeader(header.ID(), header)))
+		require.NoError(t, db.Update(operation.InsertHeader(fork.ID(), &fork)))
+		require.NoError(t, db.Update(operation.IndexBlockHeight(header.Height, header.ID())))
+
+		cons := BaselineConsensus(t, WithDB(db), WithLast(header.Height-1))
+
+		cons.OnBlockFinalized(fork.ID())
+
+		assert.Equal(t, header.Height-1, cons.last)
+
+		cons.OnBlockFinalized(header.ID())
+
+		assert.Equal(t, header.Height, cons.last)
+		assert.Equal(t, header.ID(), cons.final)
+	})
+
+	t.Run("skips stale block", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		// A block that was finalized before the last one we know of, such as
+		// one delivered again after a restart, must not move us backwards.
+		require.NoError(t, db.Update(operation.InsertHeader(header.ID(), header)))
+		require.NoError(t, db.Update(operation.IndexBlockHeight(header.Height, header.ID())))
+
+		cons := BaselineConsensus(t, WithDB(db), WithLast(header.Height+10))
+
+		cons.OnBlockFinalized(header.ID())
+
+		assert.Equal(t, header.Height+10, cons.last)
+	})
+
+	t.Run("handles missing block height index in DB", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.InsertHeader(header.ID(), header)))
+
+		cons := BaselineConsensus(t, WithDB(db))
+
+		cons.OnBlockFinalized(header.ID())
+
+		assert.NotEqual(t, cons.last, header.Height)
 	})
 
 	t.Run("handles missing header in DB", func(t *testing.T) {
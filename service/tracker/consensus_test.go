@@ -62,6 +62,75 @@ func TestConsensus_Root(t *testing.T) {
 	})
 }
 
+func TestConsensus_RootSeal(t *testing.T) {
+	header := mocks.GenericHeader
+	seal := mocks.GenericSeal(0)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.InsertRootHeight(header.Height)))
+		require.NoError(t, db.Update(operation.IndexBlockHeight(header.Height, header.ID())))
+		require.NoError(t, db.Update(operation.IndexBlockSeal(header.ID(), seal.ID())))
+		require.NoError(t, db.Update(operation.InsertSeal(seal.ID(), seal)))
+
+		cons := tracker.BaselineConsensus(t, tracker.WithDB(db))
+
+		got, err := cons.RootSeal()
+
+		require.NoError(t, err)
+		assert.Equal(t, seal, got)
+	})
+
+	t.Run("handles missing root height", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		cons := tracker.BaselineConsensus(t, tracker.WithDB(db))
+
+		_, err := cons.RootSeal()
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles missing root block", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.InsertRootHeight(header.Height)))
+
+		cons := tracker.BaselineConsensus(t, tracker.WithDB(db))
+
+		_, err := cons.RootSeal()
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles missing root seal", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.InsertRootHeight(header.Height)))
+		require.NoError(t, db.Update(operation.IndexBlockHeight(header.Height, header.ID())))
+		require.NoError(t, db.Update(operation.IndexBlockSeal(header.ID(), seal.ID())))
+
+		cons := tracker.BaselineConsensus(t, tracker.WithDB(db))
+
+		_, err := cons.RootSeal()
+
+		assert.Error(t, err)
+	})
+}
+
 func TestConsensus_Header(t *testing.T) {
 	header := mocks.GenericHeader
 
@@ -868,3 +937,150 @@ func TestConsensus_Events(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestConsensus_EpochStatus(t *testing.T) {
+	header := mocks.GenericHeader
+	status := mocks.GenericEpochStatus
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.IndexBlockHeight(header.Height, header.ID())))
+		require.NoError(t, db.Update(operation.InsertEpochStatus(header.ID(), status)))
+
+		cons := tracker.BaselineConsensus(
+			t,
+			tracker.WithDB(db),
+			tracker.WithLast(header.Height),
+		)
+
+		got, err := cons.EpochStatus(header.Height)
+
+		require.NoError(t, err)
+		assert.Equal(t, status, got)
+	})
+
+	t.Run("handles requested height over last finalized height", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		cons := tracker.BaselineConsensus(
+			t,
+			tracker.WithDB(db),
+			tracker.WithLast(header.Height),
+		)
+
+		_, err := cons.EpochStatus(header.Height + 999)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles missing block height index in DB", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		cons := tracker.BaselineConsensus(
+			t,
+			tracker.WithDB(db),
+			tracker.WithLast(header.Height),
+		)
+
+		_, err := cons.EpochStatus(header.Height)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles missing epoch status in DB", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.IndexBlockHeight(header.Height, header.ID())))
+
+		cons := tracker.BaselineConsensus(
+			t,
+			tracker.WithDB(db),
+			tracker.WithLast(header.Height),
+		)
+
+		_, err := cons.EpochStatus(header.Height)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestConsensus_EpochSetup(t *testing.T) {
+	header := mocks.GenericHeader
+	status := mocks.GenericEpochStatus
+	setup := mocks.GenericEpochSetup(1)
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.IndexBlockHeight(header.Height, header.ID())))
+		require.NoError(t, db.Update(operation.InsertEpochStatus(header.ID(), status)))
+		require.NoError(t, db.Update(operation.InsertEpochSetup(status.CurrentEpoch.SetupID, setup)))
+
+		cons := tracker.BaselineConsensus(
+			t,
+			tracker.WithDB(db),
+			tracker.WithLast(header.Height),
+		)
+
+		got, err := cons.EpochSetup(header.Height)
+
+		require.NoError(t, err)
+		assert.Equal(t, setup.Counter, got.Counter)
+		assert.Len(t, got.Participants, len(setup.Participants))
+	})
+
+	t.Run("handles missing epoch status in DB", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.IndexBlockHeight(header.Height, header.ID())))
+
+		cons := tracker.BaselineConsensus(
+			t,
+			tracker.WithDB(db),
+			tracker.WithLast(header.Height),
+		)
+
+		_, err := cons.EpochSetup(header.Height)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles missing epoch setup in DB", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.IndexBlockHeight(header.Height, header.ID())))
+		require.NoError(t, db.Update(operation.InsertEpochStatus(header.ID(), status)))
+
+		cons := tracker.BaselineConsensus(
+			t,
+			tracker.WithDB(db),
+			tracker.WithLast(header.Height),
+		)
+
+		_, err := cons.EpochSetup(header.Height)
+
+		assert.Error(t, err)
+	})
+}
@@ -15,6 +15,7 @@
 package tracker
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/dgraph-io/badger/v2"
@@ -24,18 +25,28 @@ import (
 	"github.com/onflow/flow-go/engine/execution/computation/computer/uploader"
 	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/storage"
 	"github.com/onflow/flow-go/storage/badger/operation"
+
+	"github.com/optakt/flow-dps/service/logs"
 )
 
+// jobExecution is the name under which the execution tracker keeps track of
+// the height of the last processed execution record in the protocol state
+// database.
+const jobExecution = "dps_execution_tracker"
+
 // Execution is the DPS execution follower, which keeps track of updates to the
 // execution state. It retrieves block records (block data updates) from a
 // streamer and extracts the trie updates for consumers. It also makes the rest
 // of the block record data available for external consumers by block ID.
 type Execution struct {
-	log     zerolog.Logger
-	queue   *deque.Deque
-	stream  RecordStreamer
-	records map[flow.Identifier]*uploader.BlockData
+	log       zerolog.Logger
+	db        *badger.DB
+	queue     *deque.Deque
+	stream    RecordStreamer
+	records   map[flow.Identifier]*uploader.BlockData
+	processed uint64 // height of the last processed execution record
 }
 
 // NewExecution creates a new DPS execution follower, relying on the provided
@@ -71,11 +82,28 @@ func NewExecution(log zerolog.Logger, db *badger.DB, stream RecordStreamer) (*Ex
 		return nil, fmt.Errorf("could not retrieve root seal: %w", err)
 	}
 
+	// We keep track of the height of the last execution record we processed in
+	// the protocol state database, so that records which are streamed more than
+	// once, for example because catch-up blocks overlap with newly finalized
+	// blocks, are only processed once, even across restarts. If there is no
+	// processed height yet, we start from the root block.
+	var processed uint64
+	err = db.View(operation.RetrieveProcessedIndex(jobExecution, &processed))
+	if errors.Is(err, storage.ErrNotFound) {
+		processed = height
+		err = db.Update(operation.InsertProcessedIndex(jobExecution, processed))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize processed height: %w", err)
+	}
+
 	e := Execution{
-		log:     log.With().Str("component", "execution_tracker").Logger(),
-		stream:  stream,
-		queue:   deque.New(),
-		records: make(map[flow.Identifier]*uploader.BlockData),
+		log:       log.With().Str(logs.Component, "execution_tracker").Logger(),
+		db:        db,
+		stream:    stream,
+		queue:     deque.New(),
+		records:   make(map[flow.Identifier]*uploader.BlockData),
+		processed: processed,
 	}
 
 	payload := flow.Payload{
@@ -164,12 +192,19 @@ func (e *Execution) processNext() error {
 		return fmt.Errorf("could not read next execution record: %w", err)
 	}
 
-	// Check if we already processed a block with this ID recently. This should
-	// be idempotent, but we should be aware if something like this happens.
+	// Execution records are streamed in the order of finalization, so any
+	// record at or below the last processed height is a duplicate. Pushing its
+	// trie updates again would corrupt the execution state, so we skip it.
 	blockID := record.Block.Header.ID()
+	height := record.Block.Header.Height
 	_, ok := e.records[blockID]
-	if ok {
-		return fmt.Errorf("duplicate execution record (block: %x)", blockID)
+	if ok || height <= e.processed {
+		e.log.Warn().
+			Hex(logs.BlockID, blockID[:]).
+			Uint64(logs.Height, height).
+			Uint64("processed", e.processed).
+			Msg("skipping duplicate execution record")
+		return nil
 	}
 
 	// Dump the block execution record into our cache and push all trie updates
@@ -187,8 +222,16 @@ func (e *Execution) processNext() error {
 		e.queue.PushFront(update)
 	}
 
+	// Finally, we persist the processed height, so that we still recognize the
+	// duplicates of this record after a restart.
+	err = e.db.Update(operation.SetProcessedIndex(jobExecution, height))
+	if err != nil {
+		return fmt.Errorf("could not persist processed height: %w", err)
+	}
+	e.processed = height
+
 	e.log.Debug().
-		Hex("block", blockID[:]).
+		Hex(logs.BlockID, blockID[:]).
 		Int("updates", len(record.TrieUpdates)).
 		Msg("next execution record processed")
 
@@ -203,3 +246,30 @@ func (e *Execution) purge(threshold uint64) {
 		}
 	}
 }
+
+// Rewind resets the processed height of the execution tracker in the given
+// protocol state database to the given height, if it is higher. It should be
+// called with the last indexed height before resuming, so that the execution
+// records that were processed, but not yet indexed, are processed again.
+func Rewind(db *badger.DB, height uint64) error {
+
+	var processed uint64
+	err := db.View(operation.RetrieveProcessedIndex(jobExecution, &processed))
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not retrieve processed height: %w", err)
+	}
+
+	if processed <= height {
+		return nil
+	}
+
+	err = db.Update(operation.SetProcessedIndex(jobExecution, height))
+	if err != nil {
+		return fmt.Errorf("could not rewind processed height: %w", err)
+	}
+
+	return nil
+}
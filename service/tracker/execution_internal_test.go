@@ -52,6 +52,29 @@ func TestNewExecution(t *testing.T) {
 		assert.Equal(t, stream, exec.stream)
 		assert.NotNil(t, exec.queue)
 		assert.NotEmpty(t, exec.records)
+		assert.Equal(t, header.Height, exec.processed)
+
+		var processed uint64
+		require.NoError(t, db.View(operation.RetrieveProcessedIndex(jobExecution, &processed)))
+		assert.Equal(t, header.Height, processed)
+	})
+
+	t.Run("nominal case with processed height", func(t *testing.T) {
+		log := zerolog.Nop()
+		stream := mocks.BaselineRecordStreamer(t)
+
+		db := helpers.InMemoryDB(t)
+		require.NoError(t, db.Update(operation.InsertRootHeight(header.Height)))
+		require.NoError(t, db.Update(operation.IndexBlockHeight(header.Height, blockID)))
+		require.NoError(t, db.Update(operation.InsertHeader(blockID, header)))
+		require.NoError(t, db.Update(operation.IndexBlockSeal(blockID, seal.ID())))
+		require.NoError(t, db.Update(operation.InsertSeal(seal.ID(), seal)))
+		require.NoError(t, db.Update(operation.InsertProcessedIndex(jobExecution, header.Height+10)))
+
+		exec, err := NewExecution(log, db, stream)
+
+		require.NoError(t, err)
+		assert.Equal(t, header.Height+10, exec.processed)
 	})
 
 	t.Run("handles missing root height", func(t *testing.T) {
@@ -231,14 +254,68 @@ func TestExecution_Purge(t *testing.T) {
 
 }
 
+func TestRewind(t *testing.T) {
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		require.NoError(t, db.Update(operation.InsertProcessedIndex(jobExecution, mocks.GenericHeight+10)))
+
+		err := Rewind(db, mocks.GenericHeight)
+
+		require.NoError(t, err)
+		var processed uint64
+		require.NoError(t, db.View(operation.RetrieveProcessedIndex(jobExecution, &processed)))
+		assert.Equal(t, mocks.GenericHeight, processed)
+	})
+
+	t.Run("does not move processed height forward", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		require.NoError(t, db.Update(operation.InsertProcessedIndex(jobExecution, mocks.GenericHeight)))
+
+		err := Rewind(db, mocks.GenericHeight+10)
+
+		require.NoError(t, err)
+		var processed uint64
+		require.NoError(t, db.View(operation.RetrieveProcessedIndex(jobExecution, &processed)))
+		assert.Equal(t, mocks.GenericHeight, processed)
+	})
+
+	t.Run("does nothing without processed height", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+
+		err := Rewind(db, mocks.GenericHeight)
+
+		require.NoError(t, err)
+		var processed uint64
+		err = db.View(operation.RetrieveProcessedIndex(jobExecution, &processed))
+		assert.Error(t, err)
+	})
+}
+
+func WithProcessed(height uint64) func(*Execution) {
+	return func(execution *Execution) {
+		execution.processed = height
+	}
+}
+
 func BaselineExecution(t *testing.T, opts ...func(*Execution)) *Execution {
 	t.Helper()
 
+	db := helpers.InMemoryDB(t)
+	require.NoError(t, db.Update(operation.InsertProcessedIndex(jobExecution, 0)))
+
 	e := Execution{
-		log:     zerolog.Nop(),
-		queue:   deque.New(),
-		stream:  mocks.BaselineRecordStreamer(t),
-		records: make(map[flow.Identifier]*uploader.BlockData),
+		log:       zerolog.Nop(),
+		db:        db,
+		queue:     deque.New(),
+		stream:    mocks.BaselineRecordStreamer(t),
+		records:   make(map[flow.Identifier]*uploader.BlockData),
+		processed: 0,
 	}
 
 	for _, opt := range opts {
@@ -23,6 +23,7 @@ import (
 
 	"github.com/onflow/flow-go/engine/execution/computation/computer/uploader"
 
+	"github.com/optakt/flow-dps/models/dps"
 	"github.com/optakt/flow-dps/service/tracker"
 	"github.com/optakt/flow-dps/testing/mocks"
 )
@@ -86,29 +87,59 @@ func TestExecution_Update(t *testing.T) {
 		assert.Error(t, err)
 	})
 
-	t.Run("handles duplicate records", func(t *testing.T) {
+	t.Run("skips duplicate records", func(t *testing.T) {
 		t.Parallel()
 
 		// Only keep one trie update per block to make each update call go through one block.
 		smallBlock := mocks.GenericRecord()
 		smallBlock.TrieUpdates = smallBlock.TrieUpdates[:1]
 
+		calls := 0
 		streamer := mocks.BaselineRecordStreamer(t)
 		streamer.NextFunc = func() (*uploader.BlockData, error) {
+			calls++
+			if calls > 2 {
+				return nil, dps.ErrUnavailable
+			}
 			return smallBlock, nil
 		}
 
 		exec := tracker.BaselineExecution(t, tracker.WithStreamer(streamer))
 
 		// The first call loads our "small block" with only one trie update and consumes it.
-		_, err := exec.Update()
+		got, err := exec.Update()
 
-		assert.NoError(t, err)
+		require.NoError(t, err)
+		assert.Equal(t, smallBlock.TrieUpdates[0], got)
 
-		// The next call loads the same block, realizes something is wrong and returns an error.
+		// The next call loads the same block, skips it and runs out of records.
 		_, err = exec.Update()
 
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, dps.ErrUnavailable)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("skips records processed before restart", func(t *testing.T) {
+		t.Parallel()
+
+		calls := 0
+		streamer := mocks.BaselineRecordStreamer(t)
+		streamer.NextFunc = func() (*uploader.BlockData, error) {
+			calls++
+			if calls > 1 {
+				return nil, dps.ErrUnavailable
+			}
+			return record, nil
+		}
+
+		exec := tracker.BaselineExecution(t,
+			tracker.WithStreamer(streamer),
+			tracker.WithProcessed(record.Block.Header.Height),
+		)
+
+		_, err := exec.Update()
+
+		assert.ErrorIs(t, err, dps.ErrUnavailable)
 	})
 }
 
@@ -0,0 +1,108 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package tracker
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/onflow/flow-go/engine/execution/computation/computer/uploader"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/module/mempool/entity"
+	"github.com/onflow/flow-go/storage"
+	"github.com/onflow/flow-go/storage/badger/operation"
+)
+
+// Protocol is a record holder that builds partial block records from the
+// protocol state database alone, so that the consensus tracker can be used
+// without execution data. The records contain the block header and the
+// collections with their transaction bodies, but no state commitment, trie
+// updates, events or transaction results.
+type Protocol struct {
+	db *badger.DB
+}
+
+// NewProtocol returns a new record holder that reads from the given protocol
+// state database.
+func NewProtocol(db *badger.DB) *Protocol {
+
+	p := Protocol{
+		db: db,
+	}
+
+	return &p
+}
+
+// Record returns the partial block record for the given block identifier.
+// Consensus followers do not download collections, so collections that are
+// not available in the protocol state are left out of the record.
+func (p *Protocol) Record(blockID flow.Identifier) (*uploader.BlockData, error) {
+
+	var header flow.Header
+	err := p.db.View(operation.RetrieveHeader(blockID, &header))
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve header: %w", err)
+	}
+
+	var collIDs []flow.Identifier
+	err = p.db.View(operation.LookupPayloadGuarantees(blockID, &collIDs))
+	if err != nil {
+		return nil, fmt.Errorf("could not lookup collections: %w", err)
+	}
+
+	collections := make([]*entity.CompleteCollection, 0, len(collIDs))
+	for _, collID := range collIDs {
+
+		var guarantee flow.CollectionGuarantee
+		err := p.db.View(operation.RetrieveGuarantee(collID, &guarantee))
+		if err != nil {
+			return nil, fmt.Errorf("could not retrieve guarantee (%x): %w", collID, err)
+		}
+
+		var light flow.LightCollection
+		err = p.db.View(operation.RetrieveCollection(collID, &light))
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not retrieve collection (%x): %w", collID, err)
+		}
+
+		transactions := make([]*flow.TransactionBody, 0, len(light.Transactions))
+		for _, txID := range light.Transactions {
+			var transaction flow.TransactionBody
+			err := p.db.View(operation.RetrieveTransaction(txID, &transaction))
+			if err != nil {
+				return nil, fmt.Errorf("could not retrieve transaction (%x): %w", txID, err)
+			}
+			transactions = append(transactions, &transaction)
+		}
+
+		collection := entity.CompleteCollection{
+			Guarantee:    &guarantee,
+			Transactions: transactions,
+		}
+		collections = append(collections, &collection)
+	}
+
+	record := uploader.BlockData{
+		Block:       &flow.Block{Header: &header},
+		Collections: collections,
+	}
+
+	return &record, nil
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package tracker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/storage/badger/operation"
+
+	"github.com/optakt/flow-dps/testing/helpers"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestNewProtocol(t *testing.T) {
+	db := helpers.InMemoryDB(t)
+	defer db.Close()
+
+	protocol := NewProtocol(db)
+
+	assert.Equal(t, db, protocol.db)
+}
+
+func TestProtocol_Record(t *testing.T) {
+	header := mocks.GenericHeader
+	blockID := header.ID()
+	collections := mocks.GenericCollections(2)
+	transactions := mocks.GenericTransactions(4)
+
+	var collIDs []flow.Identifier
+	var guarantees []*flow.CollectionGuarantee
+	for _, collection := range collections {
+		collID := collection.ID()
+		collIDs = append(collIDs, collID)
+		guarantees = append(guarantees, &flow.CollectionGuarantee{CollectionID: collID})
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.InsertHeader(blockID, header)))
+		require.NoError(t, db.Update(operation.IndexPayloadGuarantees(blockID, collIDs)))
+		for i, guarantee := range guarantees {
+			require.NoError(t, db.Update(operation.InsertGuarantee(guarantee.ID(), guarantee)))
+			require.NoError(t, db.Update(operation.InsertCollection(collections[i])))
+		}
+		for _, transaction := range transactions {
+			require.NoError(t, db.Update(operation.InsertTransaction(transaction.ID(), transaction)))
+		}
+
+		protocol := NewProtocol(db)
+
+		record, err := protocol.Record(blockID)
+
+		require.NoError(t, err)
+		assert.Equal(t, header, record.Block.Header)
+		require.Len(t, record.Collections, 2)
+		assert.Equal(t, guarantees[0], record.Collections[0].Guarantee)
+		assert.Equal(t, transactions[:2], record.Collections[0].Transactions)
+		assert.Equal(t, transactions[2:], record.Collections[1].Transactions)
+		assert.Empty(t, record.Events)
+		assert.Empty(t, record.TxResults)
+		assert.Empty(t, record.TrieUpdates)
+	})
+
+	t.Run("skips missing collections", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.InsertHeader(blockID, header)))
+		require.NoError(t, db.Update(operation.IndexPayloadGuarantees(blockID, collIDs)))
+		for _, guarantee := range guarantees {
+			require.NoError(t, db.Update(operation.InsertGuarantee(guarantee.ID(), guarantee)))
+		}
+
+		protocol := NewProtocol(db)
+
+		record, err := protocol.Record(blockID)
+
+		require.NoError(t, err)
+		assert.Empty(t, record.Collections)
+	})
+
+	t.Run("handles missing header", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		protocol := NewProtocol(db)
+
+		_, err := protocol.Record(blockID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles missing guarantee", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.InsertHeader(blockID, header)))
+		require.NoError(t, db.Update(operation.IndexPayloadGuarantees(blockID, collIDs)))
+
+		protocol := NewProtocol(db)
+
+		_, err := protocol.Record(blockID)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("handles missing transaction", func(t *testing.T) {
+		t.Parallel()
+
+		db := helpers.InMemoryDB(t)
+		defer db.Close()
+
+		require.NoError(t, db.Update(operation.InsertHeader(blockID, header)))
+		require.NoError(t, db.Update(operation.IndexPayloadGuarantees(blockID, collIDs)))
+		for i, guarantee := range guarantees {
+			require.NoError(t, db.Update(operation.InsertGuarantee(guarantee.ID(), guarantee)))
+			require.NoError(t, db.Update(operation.InsertCollection(collections[i])))
+		}
+
+		protocol := NewProtocol(db)
+
+		_, err := protocol.Record(blockID)
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,120 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package tracker
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/storage/badger/operation"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Seals looks up the seals of the execution results of finalized blocks in the
+// protocol state database, so that the data indexed for a block before it was
+// sealed can be checked against the sealed execution result.
+type Seals struct {
+	db *badger.DB
+}
+
+// NewSeals returns a new seal lookup that reads from the given protocol state
+// database.
+func NewSeals(db *badger.DB) *Seals {
+
+	s := Seals{
+		db: db,
+	}
+
+	return &s
+}
+
+// Sealed returns the final state commitment of the sealed execution result for
+// the finalized block at the given height. If the block is not sealed yet, it
+// fails with `dps.ErrUnavailable`.
+func (s *Seals) Sealed(height uint64) (flow.StateCommitment, error) {
+
+	// Blocks are sealed in order, so we can check whether the block is sealed
+	// by comparing its height to the height of the block sealed by the latest
+	// seal, which is the seal as of the last finalized block.
+	var finalized uint64
+	err := s.db.View(operation.RetrieveFinalizedHeight(&finalized))
+	if err != nil {
+		return flow.DummyStateCommitment, fmt.Errorf("could not retrieve finalized height: %w", err)
+	}
+	var finalID flow.Identifier
+	err = s.db.View(operation.LookupBlockHeight(finalized, &finalID))
+	if err != nil {
+		return flow.DummyStateCommitment, fmt.Errorf("could not look up finalized block: %w", err)
+	}
+	var latestID flow.Identifier
+	err = s.db.View(operation.LookupBlockSeal(finalID, &latestID))
+	if err != nil {
+		return flow.DummyStateCommitment, fmt.Errorf("could not look up latest seal: %w", err)
+	}
+	var latest flow.Seal
+	err = s.db.View(operation.RetrieveSeal(latestID, &latest))
+	if err != nil {
+		return flow.DummyStateCommitment, fmt.Errorf("could not retrieve latest seal: %w", err)
+	}
+	var sealed flow.Header
+	err = s.db.View(operation.RetrieveHeader(latest.BlockID, &sealed))
+	if err != nil {
+		return flow.DummyStateCommitment, fmt.Errorf("could not retrieve sealed header: %w", err)
+	}
+	if height > sealed.Height {
+		return flow.DummyStateCommitment, dps.ErrUnavailable
+	}
+
+	var blockID flow.Identifier
+	err = s.db.View(operation.LookupBlockHeight(height, &blockID))
+	if err != nil {
+		return flow.DummyStateCommitment, fmt.Errorf("could not look up block: %w", err)
+	}
+	if blockID == latest.BlockID {
+		return latest.FinalState, nil
+	}
+
+	// Otherwise, the seal for the block was included in the payload of one of
+	// the finalized blocks that follow it.
+	for next := height + 1; next <= finalized; next++ {
+
+		var nextID flow.Identifier
+		err = s.db.View(operation.LookupBlockHeight(next, &nextID))
+		if err != nil {
+			return flow.DummyStateCommitment, fmt.Errorf("could not look up block (height: %d): %w", next, err)
+		}
+		var sealIDs []flow.Identifier
+		err = s.db.View(operation.LookupPayloadSeals(nextID, &sealIDs))
+		if err != nil {
+			return flow.DummyStateCommitment, fmt.Errorf("could not look up payload seals (height: %d): %w", next, err)
+		}
+
+		for _, sealID := range sealIDs {
+			var seal flow.Seal
+			err = s.db.View(operation.RetrieveSeal(sealID, &seal))
+			if err != nil {
+				return flow.DummyStateCommitment, fmt.Errorf("could not retrieve seal (%x): %w", sealID, err)
+			}
+			if seal.BlockID == blockID {
+				return seal.FinalState, nil
+			}
+		}
+	}
+
+	return flow.DummyStateCommitment, fmt.Errorf("could not find seal for sealed block (height: %d)", height)
+}
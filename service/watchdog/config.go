@@ -0,0 +1,80 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package watchdog
+
+import (
+	"time"
+)
+
+// DefaultConfig is the default configuration for the watchdog.
+var DefaultConfig = Config{
+	Interval:       10 * time.Second,
+	MemoryLimit:    0,
+	GoroutineLimit: 0,
+	Cooldown:       10 * time.Minute,
+	Retain:         10,
+}
+
+// Config is the configuration for the watchdog.
+type Config struct {
+	Interval       time.Duration
+	MemoryLimit    uint64
+	GoroutineLimit uint
+	Cooldown       time.Duration
+	Retain         uint
+}
+
+// Option is a function that can be applied to a Config.
+type Option func(*Config)
+
+// WithInterval sets the interval at which the watchdog checks the resident
+// memory and the number of goroutines of the process.
+func WithInterval(interval time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.Interval = interval
+	}
+}
+
+// WithMemoryLimit sets the resident memory in bytes above which the watchdog
+// captures profiles. A limit of zero disables the memory check.
+func WithMemoryLimit(limit uint64) Option {
+	return func(cfg *Config) {
+		cfg.MemoryLimit = limit
+	}
+}
+
+// WithGoroutineLimit sets the number of goroutines above which the watchdog
+// captures profiles. A limit of zero disables the goroutine check.
+func WithGoroutineLimit(limit uint) Option {
+	return func(cfg *Config) {
+		cfg.GoroutineLimit = limit
+	}
+}
+
+// WithCooldown sets the minimum duration between two captures, so that a
+// process that stays above a limit does not fill its disk with profiles.
+func WithCooldown(cooldown time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.Cooldown = cooldown
+	}
+}
+
+// WithRetain sets the number of captures that are kept in the profile
+// directory. Older captures are removed whenever a new one is written.
+func WithRetain(retain uint) Option {
+	return func(cfg *Config) {
+		cfg.Retain = retain
+	}
+}
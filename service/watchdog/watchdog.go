@@ -0,0 +1,254 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package watchdog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/optakt/flow-dps/service/logs"
+)
+
+// layout is the layout of the timestamps in the names of the profile files. It
+// sorts lexically in chronological order, which the rotation relies on.
+const layout = "20060102T150405.000Z"
+
+// profiles are the runtime profiles written for each capture.
+var profiles = []string{"heap", "goroutine"}
+
+// Watchdog periodically checks the resident memory and the number of goroutines
+// of the process, and captures heap and goroutine profiles into a directory
+// whenever one of them is above its limit. It allows analyzing the state of a
+// process after it was killed for running out of memory.
+type Watchdog struct {
+	log  zerolog.Logger
+	dir  string
+	cfg  Config
+	stop chan struct{}
+	wg   *sync.WaitGroup
+
+	memory     func() (uint64, error)
+	goroutines func() int
+	last       time.Time
+}
+
+// New returns a new watchdog, which writes its profiles to the given directory.
+func New(log zerolog.Logger, dir string, options ...Option) *Watchdog {
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	w := Watchdog{
+		log:  log.With().Str(logs.Component, "watchdog").Logger(),
+		dir:  dir,
+		cfg:  cfg,
+		stop: make(chan struct{}),
+		wg:   &sync.WaitGroup{},
+
+		memory:     resident,
+		goroutines: runtime.NumGoroutine,
+	}
+
+	return &w
+}
+
+// Run checks the process at the configured interval, until the watchdog is
+// stopped.
+func (w *Watchdog) Run() error {
+	w.wg.Add(1)
+	defer w.wg.Done()
+
+	for {
+		err := w.check()
+		if err != nil {
+			return fmt.Errorf("could not check process: %w", err)
+		}
+
+		select {
+		case <-w.stop:
+			return nil
+		case <-time.After(w.cfg.Interval):
+			// continue
+		}
+	}
+}
+
+// Stop gracefully stops the watchdog, which finishes writing the profiles that
+// it is currently capturing.
+func (w *Watchdog) Stop() error {
+	close(w.stop)
+	w.wg.Wait()
+	return nil
+}
+
+func (w *Watchdog) check() error {
+
+	if !w.last.IsZero() && time.Since(w.last) < w.cfg.Cooldown {
+		return nil
+	}
+
+	memory, err := w.memory()
+	if err != nil {
+		return fmt.Errorf("could not get resident memory: %w", err)
+	}
+	goroutines := w.goroutines()
+
+	exceeded := (w.cfg.MemoryLimit > 0 && memory > w.cfg.MemoryLimit) ||
+		(w.cfg.GoroutineLimit > 0 && uint(goroutines) > w.cfg.GoroutineLimit)
+	if !exceeded {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	err = w.capture(now)
+	if err != nil {
+		return fmt.Errorf("could not capture profiles: %w", err)
+	}
+	w.last = now
+
+	err = w.rotate()
+	if err != nil {
+		return fmt.Errorf("could not rotate profiles: %w", err)
+	}
+
+	w.log.Warn().
+		Uint64("memory", memory).
+		Uint64("memory_limit", w.cfg.MemoryLimit).
+		Int("goroutines", goroutines).
+		Uint("goroutine_limit", w.cfg.GoroutineLimit).
+		Str("dir", w.dir).
+		Msg("limit exceeded, profiles captured")
+
+	return nil
+}
+
+// capture writes one file per profile to the directory, named after the
+// profile and the given time.
+func (w *Watchdog) capture(now time.Time) error {
+
+	err := os.MkdirAll(w.dir, 0755)
+	if err != nil {
+		return fmt.Errorf("could not create directory: %w", err)
+	}
+
+	stamp := now.Format(layout)
+	for _, name := range profiles {
+		profile := pprof.Lookup(name)
+		if profile == nil {
+			return fmt.Errorf("unknown profile (name: %s)", name)
+		}
+
+		var buf bytes.Buffer
+		err = profile.WriteTo(&buf, 0)
+		if err != nil {
+			return fmt.Errorf("could not write profile (name: %s): %w", name, err)
+		}
+
+		path := filepath.Join(w.dir, fmt.Sprintf("%s-%s.pprof", name, stamp))
+		err = os.WriteFile(path, buf.Bytes(), 0644)
+		if err != nil {
+			return fmt.Errorf("could not write profile file (path: %s): %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// rotate removes the files of all captures but the most recent ones, as many
+// as configured to be retained. A retention of zero keeps all captures.
+func (w *Watchdog) rotate() error {
+
+	if w.cfg.Retain == 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return fmt.Errorf("could not read directory: %w", err)
+	}
+
+	captures := make(map[string][]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		for _, profile := range profiles {
+			prefix := profile + "-"
+			if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".pprof") {
+				continue
+			}
+			stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".pprof")
+			captures[stamp] = append(captures[stamp], name)
+		}
+	}
+	if uint(len(captures)) <= w.cfg.Retain {
+		return nil
+	}
+
+	stamps := make([]string, 0, len(captures))
+	for stamp := range captures {
+		stamps = append(stamps, stamp)
+	}
+	sort.Strings(stamps)
+
+	for _, stamp := range stamps[:uint(len(stamps))-w.cfg.Retain] {
+		for _, name := range captures[stamp] {
+			err = os.Remove(filepath.Join(w.dir, name))
+			if err != nil {
+				return fmt.Errorf("could not remove profile file (name: %s): %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resident returns the resident memory of the process in bytes. On Linux, it
+// reads it from the proc filesystem; elsewhere, it falls back to the memory
+// obtained from the system by the Go runtime.
+func resident() (uint64, error) {
+
+	data, err := os.ReadFile("/proc/self/statm")
+	if os.IsNotExist(err) {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		return stats.Sys, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("could not read memory statistics: %w", err)
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("invalid memory statistics (data: %s)", data)
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse resident pages: %w", err)
+	}
+
+	return pages * uint64(os.Getpagesize()), nil
+}
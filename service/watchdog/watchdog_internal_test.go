@@ -0,0 +1,124 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package watchdog
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestWatchdog_Check(t *testing.T) {
+
+	// setup creates a watchdog that measures the given memory and number of
+	// goroutines instead of those of the test process.
+	setup := func(t *testing.T, memory uint64, goroutines int, options ...Option) *Watchdog {
+		t.Helper()
+
+		w := New(zerolog.Nop(), t.TempDir(), options...)
+		w.memory = func() (uint64, error) { return memory, nil }
+		w.goroutines = func() int { return goroutines }
+
+		return w
+	}
+
+	t.Run("nominal case", func(t *testing.T) {
+		t.Parallel()
+
+		w := setup(t, 2048, 10, WithMemoryLimit(1024))
+
+		err := w.check()
+		require.NoError(t, err)
+
+		entries, err := os.ReadDir(w.dir)
+		require.NoError(t, err)
+		require.Len(t, entries, len(profiles))
+		for _, entry := range entries {
+			info, err := entry.Info()
+			require.NoError(t, err)
+			assert.NotZero(t, info.Size())
+		}
+	})
+
+	t.Run("handles goroutine limit", func(t *testing.T) {
+		t.Parallel()
+
+		w := setup(t, 2048, 10, WithGoroutineLimit(5))
+
+		err := w.check()
+		require.NoError(t, err)
+
+		entries, err := os.ReadDir(w.dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, len(profiles))
+	})
+
+	t.Run("handles limits not exceeded", func(t *testing.T) {
+		t.Parallel()
+
+		w := setup(t, 512, 10, WithMemoryLimit(1024), WithGoroutineLimit(20))
+
+		err := w.check()
+		require.NoError(t, err)
+
+		entries, err := os.ReadDir(w.dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("handles cooldown", func(t *testing.T) {
+		t.Parallel()
+
+		w := setup(t, 2048, 10, WithMemoryLimit(1024), WithCooldown(time.Hour))
+
+		require.NoError(t, w.check())
+		require.NoError(t, w.check())
+
+		entries, err := os.ReadDir(w.dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, len(profiles))
+	})
+
+	t.Run("handles rotation", func(t *testing.T) {
+		t.Parallel()
+
+		w := setup(t, 2048, 10, WithMemoryLimit(1024), WithCooldown(0), WithRetain(2))
+
+		for i := 0; i < 4; i++ {
+			require.NoError(t, w.check())
+			time.Sleep(2 * time.Millisecond)
+		}
+
+		entries, err := os.ReadDir(w.dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 2*len(profiles))
+	})
+
+	t.Run("handles memory failure", func(t *testing.T) {
+		t.Parallel()
+
+		w := setup(t, 2048, 10, WithMemoryLimit(1024))
+		w.memory = func() (uint64, error) { return 0, mocks.GenericError }
+
+		err := w.check()
+		assert.Error(t, err)
+	})
+}
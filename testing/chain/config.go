@@ -0,0 +1,101 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package chain
+
+import (
+	"github.com/onflow/flow-go/model/flow"
+)
+
+// DefaultConfig is the default configuration for the synthetic chain
+// generator.
+var DefaultConfig = Config{
+	ChainID:      flow.Emulator,
+	Seed:         1,
+	Accounts:     16,
+	Transactions: 4,
+	Registers:    2,
+	Events:       1,
+	ValueSize:    8,
+}
+
+// Config contains optional parameters for the synthetic chain generator.
+type Config struct {
+	ChainID      flow.ChainID
+	Seed         int64
+	Accounts     uint
+	Transactions uint
+	Registers    uint
+	Events       uint
+	ValueSize    uint
+}
+
+// Option is an option that can be given to the generator to configure optional
+// parameters on initialization.
+type Option func(*Config)
+
+// WithChainID sets the chain ID of the generated blocks, which also determines
+// the addresses of the generated accounts.
+func WithChainID(chainID flow.ChainID) Option {
+	return func(cfg *Config) {
+		cfg.ChainID = chainID
+	}
+}
+
+// WithSeed sets the seed of the random source used to generate the chain. Two
+// generators with the same configuration and seed generate the same chain.
+func WithSeed(seed int64) Option {
+	return func(cfg *Config) {
+		cfg.Seed = seed
+	}
+}
+
+// WithAccounts sets the number of accounts that send the generated
+// transactions and own the registers they write to.
+func WithAccounts(accounts uint) Option {
+	return func(cfg *Config) {
+		cfg.Accounts = accounts
+	}
+}
+
+// WithTransactions sets the number of transactions in each generated block.
+// Blocks without transactions have no collections and leave the execution
+// state unchanged.
+func WithTransactions(transactions uint) Option {
+	return func(cfg *Config) {
+		cfg.Transactions = transactions
+	}
+}
+
+// WithRegisters sets the number of registers that each generated transaction
+// writes to.
+func WithRegisters(registers uint) Option {
+	return func(cfg *Config) {
+		cfg.Registers = registers
+	}
+}
+
+// WithEvents sets the number of events that each generated transaction emits.
+func WithEvents(events uint) Option {
+	return func(cfg *Config) {
+		cfg.Events = events
+	}
+}
+
+// WithValueSize sets the size in bytes of the values written to registers.
+func WithValueSize(size uint) Option {
+	return func(cfg *Config) {
+		cfg.ValueSize = size
+	}
+}
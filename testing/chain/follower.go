@@ -0,0 +1,142 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package chain
+
+import (
+	"sync"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/service/tracker"
+)
+
+// Follower serializes the access to the trackers of the live indexer. In the
+// live indexer, the consensus follower notifies the consensus tracker of
+// finalized blocks from its own goroutine, so the harness needs to do the same
+// while the mapper reads chain data and trie updates from the trackers. The
+// follower implements the `dps.Chain` and `mapper.Feeder` interfaces.
+type Follower struct {
+	mutex     *sync.Mutex
+	execution *tracker.Execution
+	consensus *tracker.Consensus
+}
+
+// NewFollower creates a new follower for the given execution and consensus
+// trackers.
+func NewFollower(execution *tracker.Execution, consensus *tracker.Consensus) *Follower {
+
+	f := Follower{
+		mutex:     &sync.Mutex{},
+		execution: execution,
+		consensus: consensus,
+	}
+
+	return &f
+}
+
+// Restart replaces the consensus tracker of the follower, as happens when the
+// consensus follower of the live indexer is restarted.
+func (f *Follower) Restart(consensus *tracker.Consensus) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.consensus = consensus
+}
+
+// OnBlockFinalized notifies the consensus tracker of a new finalized block.
+func (f *Follower) OnBlockFinalized(blockID flow.Identifier) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.consensus.OnBlockFinalized(blockID)
+}
+
+// Update provides the next trie update from the execution tracker.
+func (f *Follower) Update() (*ledger.TrieUpdate, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.execution.Update()
+}
+
+func (f *Follower) Root() (uint64, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.consensus.Root()
+}
+
+func (f *Follower) RootSeal() (*flow.Seal, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.consensus.RootSeal()
+}
+
+func (f *Follower) Header(height uint64) (*flow.Header, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.consensus.Header(height)
+}
+
+func (f *Follower) Commit(height uint64) (flow.StateCommitment, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.consensus.Commit(height)
+}
+
+func (f *Follower) Events(height uint64) ([]flow.Event, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.consensus.Events(height)
+}
+
+func (f *Follower) Collections(height uint64) ([]*flow.LightCollection, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.consensus.Collections(height)
+}
+
+func (f *Follower) Guarantees(height uint64) ([]*flow.CollectionGuarantee, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.consensus.Guarantees(height)
+}
+
+func (f *Follower) Transactions(height uint64) ([]*flow.TransactionBody, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.consensus.Transactions(height)
+}
+
+func (f *Follower) Results(height uint64) ([]*flow.TransactionResult, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.consensus.Results(height)
+}
+
+func (f *Follower) Seals(height uint64) ([]*flow.Seal, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.consensus.Seals(height)
+}
+
+func (f *Follower) EpochStatus(height uint64) (*flow.EpochStatus, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.consensus.EpochStatus(height)
+}
+
+func (f *Follower) EpochSetup(height uint64) (*flow.EpochSetup, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.consensus.EpochSetup(height)
+}
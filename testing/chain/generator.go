@@ -0,0 +1,398 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package chain
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime/common"
+
+	"github.com/onflow/flow-go/engine/execution/computation/computer/uploader"
+	"github.com/onflow/flow-go/engine/execution/state"
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/ledger/common/pathfinder"
+	"github.com/onflow/flow-go/ledger/complete"
+	"github.com/onflow/flow-go/ledger/complete/mtrie/trie"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/module/mempool/entity"
+)
+
+// genesis is the timestamp of the root block of every synthetic chain. Each
+// following block is timestamped one second after its parent.
+var genesis = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// keySpace is the number of distinct registers that each account can own, so
+// that transactions regularly overwrite registers written at earlier heights.
+const keySpace = 64
+
+// Generator generates a deterministic synthetic chain. For each finalized block,
+// it produces the execution record that execution nodes would upload for it,
+// with collections, transaction results, events and the trie updates that lead
+// from the state commitment of the parent block to the one of the block. The
+// execution state starts out empty at the root block.
+type Generator struct {
+	cfg      Config
+	random   *rand.Rand
+	contract flow.Address
+	event    *cadence.EventType
+	accounts []flow.Address
+	sequence map[flow.Address]uint64
+	tree     *trie.MTrie
+	setup    *flow.EpochSetup
+	status   *flow.EpochStatus
+	seal     *flow.Seal
+	root     *uploader.BlockData
+	last     *uploader.BlockData
+}
+
+// NewGenerator creates a new generator for a synthetic chain, using the given
+// options to configure the accounts and the rate of transactions.
+func NewGenerator(options ...Option) (*Generator, error) {
+
+	cfg := DefaultConfig
+	for _, option := range options {
+		option(&cfg)
+	}
+
+	if cfg.Transactions > 0 && cfg.Accounts == 0 {
+		return nil, fmt.Errorf("generating transactions requires at least one account")
+	}
+
+	// The service account hosts the contract that emits the synthetic events,
+	// while the transactions are sent by the accounts that come after it.
+	chain := cfg.ChainID.Chain()
+	contract := chain.ServiceAddress()
+	accounts := make([]flow.Address, 0, cfg.Accounts)
+	for index := uint64(2); index < uint64(cfg.Accounts)+2; index++ {
+		address, err := chain.AddressAtIndex(index)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate account address (index: %d): %w", index, err)
+		}
+		accounts = append(accounts, address)
+	}
+
+	event := cadence.EventType{
+		Location: common.AddressLocation{
+			Address: common.Address(contract),
+			Name:    "Synthetic",
+		},
+		QualifiedIdentifier: "Synthetic.Transfer",
+		Fields: []cadence.Field{
+			{
+				Identifier: "amount",
+				Type:       cadence.UInt64Type{},
+			},
+			{
+				Identifier: "address",
+				Type:       cadence.AddressType{},
+			},
+		},
+	}
+
+	g := Generator{
+		cfg:      cfg,
+		random:   rand.New(rand.NewSource(cfg.Seed)),
+		contract: contract,
+		event:    &event,
+		accounts: accounts,
+		sequence: make(map[flow.Address]uint64),
+		tree:     trie.NewEmptyMTrie(),
+	}
+
+	// All of the generated blocks are part of a single epoch, which is in its
+	// staking phase for the whole chain. The identifiers of its service events
+	// are random, as they are only used to look the events up.
+	participants := make(flow.IdentityList, 0, len(flow.Roles()))
+	for i, role := range flow.Roles() {
+		identity := flow.Identity{
+			NodeID:  g.identifier(),
+			Address: fmt.Sprintf("%s-%d.synthetic:3569", role, i),
+			Role:    role,
+			Stake:   1000,
+		}
+		participants = append(participants, &identity)
+	}
+	g.setup = &flow.EpochSetup{
+		Counter:            1,
+		FirstView:          0,
+		DKGPhase1FinalView: 1_000_000,
+		DKGPhase2FinalView: 2_000_000,
+		DKGPhase3FinalView: 3_000_000,
+		FinalView:          4_000_000,
+		Participants:       participants,
+	}
+	g.status = &flow.EpochStatus{
+		CurrentEpoch: flow.EventIDs{
+			SetupID:  g.identifier(),
+			CommitID: g.identifier(),
+		},
+	}
+
+	// The root block has no payload, and it is sealed by the root seal, which
+	// commits to the empty execution state.
+	payload := flow.Payload{}
+	header := flow.Header{
+		ChainID:     cfg.ChainID,
+		ParentID:    flow.ZeroID,
+		Height:      0,
+		View:        0,
+		Timestamp:   genesis,
+		PayloadHash: payload.Hash(),
+	}
+	g.seal = &flow.Seal{
+		BlockID:    header.ID(),
+		ResultID:   g.identifier(),
+		FinalState: flow.StateCommitment(g.tree.RootHash()),
+	}
+	g.root = &uploader.BlockData{
+		Block: &flow.Block{
+			Header:  &header,
+			Payload: &payload,
+		},
+		Collections:          nil, // no collections
+		TxResults:            nil, // no transaction results
+		Events:               nil, // no events
+		TrieUpdates:          nil, // no trie updates
+		FinalStateCommitment: g.seal.FinalState,
+	}
+	g.last = g.root
+
+	return &g, nil
+}
+
+// Root returns the execution record of the root block.
+func (g *Generator) Root() *uploader.BlockData {
+	return g.root
+}
+
+// Last returns the execution record of the last generated block.
+func (g *Generator) Last() *uploader.BlockData {
+	return g.last
+}
+
+// Tree returns the execution state trie as it is after the last generated
+// block.
+func (g *Generator) Tree() *trie.MTrie {
+	return g.tree
+}
+
+// Accounts returns the addresses of the accounts that send the generated
+// transactions and own the registers they write to.
+func (g *Generator) Accounts() []flow.Address {
+	return g.accounts
+}
+
+// Next generates the execution record of the next block of the chain, on top
+// of the last generated block.
+func (g *Generator) Next() (*uploader.BlockData, error) {
+
+	parent := g.last.Block.Header
+	parentID := parent.ID()
+
+	// Each transaction is sent by a random account, emits the configured
+	// number of events and writes to random registers of its sender.
+	var transactions []*flow.TransactionBody
+	var results []*flow.TransactionResult
+	var events []*flow.Event
+	writes := make(map[ledger.Path]*ledger.Payload)
+	for index := uint(0); index < g.cfg.Transactions; index++ {
+
+		sender := g.accounts[g.random.Intn(len(g.accounts))]
+		transaction := flow.TransactionBody{
+			ReferenceBlockID: parentID,
+			Script:           []byte(`transaction { execute { Synthetic.transfer() } }`),
+			GasLimit:         9999,
+			ProposalKey: flow.ProposalKey{
+				Address:        sender,
+				KeyIndex:       0,
+				SequenceNumber: g.sequence[sender],
+			},
+			Payer:       sender,
+			Authorizers: []flow.Address{sender},
+		}
+		g.sequence[sender]++
+		txID := transaction.ID()
+
+		result := flow.TransactionResult{
+			TransactionID: txID,
+			ErrorMessage:  "",
+		}
+
+		transactions = append(transactions, &transaction)
+		results = append(results, &result)
+
+		for position := uint(0); position < g.cfg.Events; position++ {
+			event, err := g.transfer(txID, uint32(index), uint32(position), sender)
+			if err != nil {
+				return nil, fmt.Errorf("could not generate event: %w", err)
+			}
+			events = append(events, event)
+		}
+
+		for count := uint(0); count < g.cfg.Registers; count++ {
+			path, payload, err := g.register(sender)
+			if err != nil {
+				return nil, fmt.Errorf("could not generate register: %w", err)
+			}
+			writes[path] = payload
+		}
+	}
+
+	// All transactions of a block are part of a single collection. Blocks
+	// without transactions have no collections at all.
+	var collections []*entity.CompleteCollection
+	var guarantees []*flow.CollectionGuarantee
+	if len(transactions) > 0 {
+		collection := flow.Collection{Transactions: transactions}
+		guarantee := flow.CollectionGuarantee{
+			CollectionID:     collection.ID(),
+			ReferenceBlockID: parentID,
+		}
+		complete := entity.CompleteCollection{
+			Guarantee:    &guarantee,
+			Transactions: transactions,
+		}
+		collections = append(collections, &complete)
+		guarantees = append(guarantees, &guarantee)
+	}
+
+	// The register writes of the block are applied to the execution state as
+	// a single trie update, with the paths in the order the trie expects.
+	var updates []*ledger.TrieUpdate
+	if len(writes) > 0 {
+		paths := make([]ledger.Path, 0, len(writes))
+		for path := range writes {
+			paths = append(paths, path)
+		}
+		sort.Slice(paths, func(i int, j int) bool {
+			return bytes.Compare(paths[i][:], paths[j][:]) < 0
+		})
+		payloads := make([]*ledger.Payload, 0, len(paths))
+		values := make([]ledger.Payload, 0, len(paths))
+		for _, path := range paths {
+			payloads = append(payloads, writes[path])
+			values = append(values, *writes[path])
+		}
+
+		update := ledger.TrieUpdate{
+			RootHash: g.tree.RootHash(),
+			Paths:    paths,
+			Payloads: payloads,
+		}
+		tree, err := trie.NewTrieWithUpdatedRegisters(g.tree, paths, values)
+		if err != nil {
+			return nil, fmt.Errorf("could not update execution state: %w", err)
+		}
+
+		updates = append(updates, &update)
+		g.tree = tree
+	}
+
+	// Each block seals the execution result of its parent, except for the
+	// first block, as the root block is sealed by the root seal.
+	var seals []*flow.Seal
+	if parent.Height > g.root.Block.Header.Height {
+		seal := flow.Seal{
+			BlockID:    parentID,
+			ResultID:   g.identifier(),
+			FinalState: g.last.FinalStateCommitment,
+		}
+		seals = append(seals, &seal)
+	}
+
+	payload := flow.Payload{
+		Guarantees: guarantees,
+		Seals:      seals,
+	}
+	header := flow.Header{
+		ChainID:     g.cfg.ChainID,
+		ParentID:    parentID,
+		Height:      parent.Height + 1,
+		View:        parent.View + 1,
+		Timestamp:   parent.Timestamp.Add(time.Second),
+		PayloadHash: payload.Hash(),
+	}
+
+	record := uploader.BlockData{
+		Block: &flow.Block{
+			Header:  &header,
+			Payload: &payload,
+		},
+		Collections:          collections,
+		TxResults:            results,
+		Events:               events,
+		TrieUpdates:          updates,
+		FinalStateCommitment: flow.StateCommitment(g.tree.RootHash()),
+	}
+
+	g.last = &record
+
+	return &record, nil
+}
+
+// transfer generates a transfer event of the synthetic contract, emitted by the
+// given transaction for the given account.
+func (g *Generator) transfer(txID flow.Identifier, index uint32, position uint32, address flow.Address) (*flow.Event, error) {
+
+	value := cadence.NewEvent([]cadence.Value{
+		cadence.NewUInt64(uint64(g.random.Intn(1_000_000))),
+		cadence.NewAddress(address),
+	}).WithType(g.event)
+
+	payload, err := json.Encode(value)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode event: %w", err)
+	}
+
+	event := flow.Event{
+		Type:             flow.EventType(g.event.ID()),
+		TransactionID:    txID,
+		TransactionIndex: index,
+		EventIndex:       position,
+		Payload:          payload,
+	}
+
+	return &event, nil
+}
+
+// register generates a write of a random value to a random register of the
+// given account.
+func (g *Generator) register(owner flow.Address) (ledger.Path, *ledger.Payload, error) {
+
+	regID := flow.NewRegisterID(string(owner.Bytes()), "", fmt.Sprintf("synthetic_%d", g.random.Intn(keySpace)))
+	key := state.RegisterIDToKey(regID)
+	path, err := pathfinder.KeyToPath(key, complete.DefaultPathFinderVersion)
+	if err != nil {
+		return ledger.Path{}, nil, fmt.Errorf("could not convert key to path: %w", err)
+	}
+
+	value := make([]byte, g.cfg.ValueSize)
+	_, _ = g.random.Read(value)
+
+	return path, ledger.NewPayload(key, value), nil
+}
+
+// identifier generates a random identifier.
+func (g *Generator) identifier() flow.Identifier {
+	var id flow.Identifier
+	_, _ = g.random.Read(id[:])
+	return id
+}
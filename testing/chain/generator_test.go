@@ -0,0 +1,102 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package chain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/testing/chain"
+)
+
+func TestGenerator(t *testing.T) {
+	t.Run("deterministic chain", func(t *testing.T) {
+		t.Parallel()
+
+		first, err := chain.NewGenerator(chain.WithSeed(42))
+		require.NoError(t, err)
+		second, err := chain.NewGenerator(chain.WithSeed(42))
+		require.NoError(t, err)
+
+		for i := 0; i < 4; i++ {
+			left, err := first.Next()
+			require.NoError(t, err)
+			right, err := second.Next()
+			require.NoError(t, err)
+
+			assert.Equal(t, left.Block.Header.ID(), right.Block.Header.ID())
+			assert.Equal(t, left.FinalStateCommitment, right.FinalStateCommitment)
+		}
+	})
+
+	t.Run("consecutive blocks", func(t *testing.T) {
+		t.Parallel()
+
+		gen, err := chain.NewGenerator(
+			chain.WithTransactions(3),
+			chain.WithEvents(2),
+			chain.WithRegisters(2),
+		)
+		require.NoError(t, err)
+
+		parent := gen.Root()
+		for i := 0; i < 4; i++ {
+			record, err := gen.Next()
+			require.NoError(t, err)
+
+			header := record.Block.Header
+			assert.Equal(t, parent.Block.Header.ID(), header.ParentID)
+			assert.Equal(t, parent.Block.Header.Height+1, header.Height)
+			assert.Equal(t, record.Block.Payload.Hash(), header.PayloadHash)
+
+			require.Len(t, record.Collections, 1)
+			assert.Len(t, record.Collections[0].Transactions, 3)
+			assert.Len(t, record.TxResults, 3)
+			assert.Len(t, record.Events, 6)
+
+			require.Len(t, record.TrieUpdates, 1)
+			assert.Equal(t, parent.FinalStateCommitment, flow.StateCommitment(record.TrieUpdates[0].RootHash))
+			assert.NotEqual(t, parent.FinalStateCommitment, record.FinalStateCommitment)
+
+			parent = record
+		}
+	})
+
+	t.Run("empty blocks", func(t *testing.T) {
+		t.Parallel()
+
+		gen, err := chain.NewGenerator(chain.WithTransactions(0))
+		require.NoError(t, err)
+
+		record, err := gen.Next()
+		require.NoError(t, err)
+
+		assert.Empty(t, record.Collections)
+		assert.Empty(t, record.Events)
+		assert.Empty(t, record.TrieUpdates)
+		assert.Equal(t, gen.Root().FinalStateCommitment, record.FinalStateCommitment)
+	})
+
+	t.Run("handles missing accounts", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := chain.NewGenerator(chain.WithAccounts(0))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,216 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package chain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/rs/zerolog"
+
+	api "github.com/optakt/flow-dps/api/dps"
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/service/forest"
+	"github.com/optakt/flow-dps/service/loader"
+	"github.com/optakt/flow-dps/service/mapper"
+	"github.com/optakt/flow-dps/service/tracker"
+	"github.com/optakt/flow-dps/testing/memindex"
+)
+
+// pollInterval is the interval at which the harness checks the index while
+// waiting for the mapper to index a height.
+const pollInterval = time.Millisecond
+
+// Harness runs the full pipeline of the live indexer in-process on top of a
+// synthetic chain. Execution records go from an in-memory streamer through the
+// execution and consensus trackers to the mapper, which indexes them in an
+// in-memory index that is served by the DPS API server. It is meant to be used
+// for regression tests and benchmarks of the whole pipeline.
+type Harness struct {
+	gen    *Generator
+	db     *badger.DB
+	stream *Streamer
+	follow *Follower
+	read   *memindex.Reader
+	fsm    *mapper.FSM
+	server *api.Server
+	done   chan struct{}
+	err    error
+}
+
+// NewHarness creates a new harness for a synthetic chain generated with the
+// given options. The protocol state database is bootstrapped with the root
+// block of the chain, and the mapper bootstraps the index from the empty
+// execution state once the harness is started.
+func NewHarness(log zerolog.Logger, options ...Option) (*Harness, error) {
+
+	gen, err := NewGenerator(options...)
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize generator: %w", err)
+	}
+
+	opts := badger.DefaultOptions("").WithInMemory(true).WithLogger(nil)
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not open protocol state database: %w", err)
+	}
+
+	err = gen.Bootstrap(db)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("could not bootstrap protocol state: %w", err)
+	}
+
+	stream := NewStreamer()
+	execution, err := tracker.NewExecution(log, db, stream)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("could not initialize execution tracker: %w", err)
+	}
+	consensus, err := tracker.NewConsensus(log, db, execution)
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("could not initialize consensus tracker: %w", err)
+	}
+	follow := NewFollower(execution, consensus)
+
+	codec := zbor.NewCodec()
+	index := memindex.New(codec)
+	read := memindex.NewReader(index)
+	write := memindex.NewWriter(index)
+
+	transitions := mapper.NewTransitions(log, loader.FromScratch(), follow, follow, read, write,
+		mapper.WithBootstrapState(true),
+		mapper.WithWaitInterval(pollInterval),
+	)
+	state := mapper.EmptyState(forest.New())
+	fsm := mapper.NewFSM(state,
+		mapper.WithTransition(mapper.StatusInitialize, transitions.InitializeMapper),
+		mapper.WithTransition(mapper.StatusBootstrap, transitions.BootstrapState),
+		mapper.WithTransition(mapper.StatusResume, transitions.ResumeIndexing),
+		mapper.WithTransition(mapper.StatusIndex, transitions.IndexChain),
+		mapper.WithTransition(mapper.StatusUpdate, transitions.UpdateTree),
+		mapper.WithTransition(mapper.StatusCollect, transitions.CollectRegisters),
+		mapper.WithTransition(mapper.StatusMap, transitions.MapRegisters),
+		mapper.WithTransition(mapper.StatusForward, transitions.ForwardHeight),
+	)
+
+	h := Harness{
+		gen:    gen,
+		db:     db,
+		stream: stream,
+		follow: follow,
+		read:   read,
+		fsm:    fsm,
+		server: api.NewServer(read, codec),
+		done:   make(chan struct{}),
+	}
+
+	return &h, nil
+}
+
+// Generator returns the generator of the synthetic chain.
+func (h *Harness) Generator() *Generator {
+	return h.gen
+}
+
+// Reader returns a reader for the index built by the mapper.
+func (h *Harness) Reader() dps.Reader {
+	return h.read
+}
+
+// Server returns the API server that serves the index built by the mapper.
+func (h *Harness) Server() *api.Server {
+	return h.server
+}
+
+// Start starts the mapper in the background.
+func (h *Harness) Start() {
+	go func() {
+		h.err = h.fsm.Run()
+		close(h.done)
+	}()
+}
+
+// Extend generates the given number of blocks on top of the synthetic chain
+// and finalizes them. Their execution records are streamed to the execution
+// tracker, and the consensus tracker is notified of each finalized block. It
+// returns the height of the last finalized block.
+func (h *Harness) Extend(blocks uint) (uint64, error) {
+
+	for i := uint(0); i < blocks; i++ {
+		record, err := h.gen.Next()
+		if err != nil {
+			return 0, fmt.Errorf("could not generate block: %w", err)
+		}
+		err = h.gen.Finalize(h.db, record)
+		if err != nil {
+			return 0, fmt.Errorf("could not finalize block: %w", err)
+		}
+		h.stream.Push(record)
+		h.follow.OnBlockFinalized(record.Block.Header.ID())
+	}
+
+	return h.gen.Last().Block.Header.Height, nil
+}
+
+// Wait waits until the mapper has indexed the given height. It fails if the
+// mapper stops before reaching it, or if the given context is done first.
+func (h *Harness) Wait(ctx context.Context, height uint64) error {
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		last, err := h.read.Last(ctx)
+		if err == nil && last >= height {
+			return nil
+		}
+		if err != nil && !errors.Is(err, dps.ErrBootstrapping) {
+			return fmt.Errorf("could not get last height: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-h.done:
+			if h.err != nil {
+				return fmt.Errorf("mapper failed: %w", h.err)
+			}
+			return fmt.Errorf("mapper stopped before indexing height (%d)", height)
+		case <-ticker.C:
+			// continue
+		}
+	}
+}
+
+// Stop stops the mapper and releases the resources of the harness.
+func (h *Harness) Stop() error {
+
+	err := h.fsm.Stop()
+	if err != nil {
+		return fmt.Errorf("could not stop mapper: %w", err)
+	}
+	err = h.db.Close()
+	if err != nil {
+		return fmt.Errorf("could not close protocol state database: %w", err)
+	}
+
+	return nil
+}
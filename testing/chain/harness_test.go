@@ -0,0 +1,101 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package chain_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	api "github.com/optakt/flow-dps/api/dps"
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/testing/chain"
+)
+
+func TestHarness(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	harness, err := chain.NewHarness(zerolog.Nop(),
+		chain.WithTransactions(4),
+		chain.WithEvents(2),
+	)
+	require.NoError(t, err)
+
+	harness.Start()
+	defer func() {
+		assert.NoError(t, harness.Stop())
+	}()
+
+	height, err := harness.Extend(8)
+	require.NoError(t, err)
+	require.NoError(t, harness.Wait(ctx, height))
+
+	server := harness.Server()
+	codec := zbor.NewCodec()
+	record := harness.Generator().Last()
+
+	last, err := server.GetLast(ctx, &api.GetLastRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, height, last.Height)
+
+	res, err := server.GetHeader(ctx, &api.GetHeaderRequest{Height: height})
+	require.NoError(t, err)
+	var header flow.Header
+	require.NoError(t, codec.Unmarshal(res.Data, &header))
+	assert.Equal(t, record.Block.Header.ID(), header.ID())
+
+	events, err := server.GetEvents(ctx, &api.GetEventsRequest{Height: height})
+	require.NoError(t, err)
+	var decoded []flow.Event
+	require.NoError(t, codec.Unmarshal(events.Data, &decoded))
+	assert.Len(t, decoded, len(record.Events))
+
+	commit, err := harness.Reader().Commit(ctx, height)
+	require.NoError(t, err)
+	assert.Equal(t, record.FinalStateCommitment, commit)
+
+	require.Len(t, record.TrieUpdates, 1)
+	update := record.TrieUpdates[0]
+	values, err := harness.Reader().Values(ctx, height, update.Paths)
+	require.NoError(t, err)
+	for i, payload := range update.Payloads {
+		assert.Equal(t, payload.Value, values[i])
+	}
+}
+
+func BenchmarkHarness(b *testing.B) {
+	ctx := context.Background()
+
+	harness, err := chain.NewHarness(zerolog.Nop())
+	require.NoError(b, err)
+
+	harness.Start()
+	defer func() {
+		_ = harness.Stop()
+	}()
+
+	b.ResetTimer()
+
+	height, err := harness.Extend(uint(b.N))
+	require.NoError(b, err)
+	require.NoError(b, harness.Wait(ctx, height))
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package chain
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/onflow/flow-go/engine/execution/computation/computer/uploader"
+	"github.com/onflow/flow-go/model/flow"
+	"github.com/onflow/flow-go/storage/badger/operation"
+)
+
+// Bootstrap initializes the given protocol state database with the root block
+// of the synthetic chain, the same way that bootstrapping a Flow node with a
+// root snapshot does.
+func (g *Generator) Bootstrap(db *badger.DB) error {
+
+	header := g.root.Block.Header
+	blockID := header.ID()
+	sealID := g.seal.ID()
+
+	ops := []func(*badger.Txn) error{
+		operation.InsertHeader(blockID, header),
+		operation.IndexBlockHeight(header.Height, blockID),
+		operation.InsertRootHeight(header.Height),
+		operation.InsertFinalizedHeight(header.Height),
+		operation.IndexPayloadGuarantees(blockID, nil),
+		operation.IndexPayloadSeals(blockID, nil),
+		operation.InsertSeal(sealID, g.seal),
+		operation.IndexBlockSeal(blockID, sealID),
+		operation.InsertEpochSetup(g.status.CurrentEpoch.SetupID, g.setup),
+		operation.InsertEpochStatus(blockID, g.status),
+	}
+
+	err := db.Update(combine(ops))
+	if err != nil {
+		return fmt.Errorf("could not bootstrap protocol state: %w", err)
+	}
+
+	return nil
+}
+
+// Finalize adds the block of the given execution record to the given protocol
+// state database as the new finalized block, the same way that the consensus
+// follower does when it finalizes a block.
+func (g *Generator) Finalize(db *badger.DB, record *uploader.BlockData) error {
+
+	header := record.Block.Header
+	payload := record.Block.Payload
+	blockID := header.ID()
+
+	ops := []func(*badger.Txn) error{
+		operation.InsertHeader(blockID, header),
+		operation.IndexBlockHeight(header.Height, blockID),
+	}
+
+	collIDs := make([]flow.Identifier, 0, len(payload.Guarantees))
+	for _, guarantee := range payload.Guarantees {
+		collIDs = append(collIDs, guarantee.CollectionID)
+		ops = append(ops, operation.InsertGuarantee(guarantee.CollectionID, guarantee))
+	}
+	ops = append(ops, operation.IndexPayloadGuarantees(blockID, collIDs))
+
+	sealIDs := make([]flow.Identifier, 0, len(payload.Seals))
+	for _, seal := range payload.Seals {
+		sealID := seal.ID()
+		sealIDs = append(sealIDs, sealID)
+		ops = append(ops, operation.InsertSeal(sealID, seal))
+	}
+	ops = append(ops, operation.IndexPayloadSeals(blockID, sealIDs))
+
+	// The latest seal of a block is the last seal in its payload, or the
+	// latest seal of its parent if it does not include any seals.
+	ops = append(ops, func(tx *badger.Txn) error {
+		if len(sealIDs) > 0 {
+			return operation.IndexBlockSeal(blockID, sealIDs[len(sealIDs)-1])(tx)
+		}
+		var sealID flow.Identifier
+		err := operation.LookupBlockSeal(header.ParentID, &sealID)(tx)
+		if err != nil {
+			return fmt.Errorf("could not look up parent seal: %w", err)
+		}
+		return operation.IndexBlockSeal(blockID, sealID)(tx)
+	})
+
+	ops = append(ops,
+		operation.InsertEpochStatus(blockID, g.status),
+		operation.UpdateFinalizedHeight(header.Height),
+	)
+
+	err := db.Update(combine(ops))
+	if err != nil {
+		return fmt.Errorf("could not finalize block (height: %d): %w", header.Height, err)
+	}
+
+	return nil
+}
+
+// combine combines the given database operations into a single one, which
+// applies them in order within the same transaction.
+func combine(ops []func(*badger.Txn) error) func(*badger.Txn) error {
+	return func(tx *badger.Txn) error {
+		for _, op := range ops {
+			err := op(tx)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
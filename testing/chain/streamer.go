@@ -0,0 +1,66 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package chain
+
+import (
+	"sync"
+
+	"github.com/onflow/flow-go/engine/execution/computation/computer/uploader"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Streamer is an in-memory stream of execution records, which stands in for
+// the cloud streamer of the live indexer. It implements the
+// `tracker.RecordStreamer` interface.
+type Streamer struct {
+	mutex   *sync.Mutex
+	records []*uploader.BlockData
+}
+
+// NewStreamer creates a new empty in-memory stream of execution records.
+func NewStreamer() *Streamer {
+
+	s := Streamer{
+		mutex:   &sync.Mutex{},
+		records: nil,
+	}
+
+	return &s
+}
+
+// Push appends the given execution records to the stream.
+func (s *Streamer) Push(records ...*uploader.BlockData) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.records = append(s.records, records...)
+}
+
+// Next returns the next execution record of the stream. It returns an
+// `ErrUnavailable` if no record is available at the moment.
+func (s *Streamer) Next() (*uploader.BlockData, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.records) == 0 {
+		return nil, dps.ErrUnavailable
+	}
+
+	record := s.records[0]
+	s.records = s.records[1:]
+
+	return record, nil
+}
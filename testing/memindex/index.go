@@ -0,0 +1,130 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package memindex
+
+import (
+	"sync"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Index holds the data of a DPS index in memory, so that the API server and
+// the mapper can run against an index without a Badger database on disk. It is
+// written through a `Writer` and read through a `Reader`, which can share the
+// same index concurrently.
+type Index struct {
+	mutex *sync.RWMutex // guards all of the indexed data against concurrent access
+	codec dps.Codec     // encodes the data returned by raw reads
+
+	first *uint64
+	last  *uint64
+
+	blocks       map[flow.Identifier]uint64
+	transactions map[flow.Identifier]uint64
+	commits      map[flow.StateCommitment]uint64
+
+	created    map[flow.Address]uint64
+	keyUpdates map[flow.Address]map[uint64]uint
+	contracts  map[flow.Address]map[string][]dps.ContractVersion
+
+	commit        map[uint64]flow.StateCommitment
+	header        map[uint64]flow.Header
+	blockStats    map[uint64]dps.BlockStats
+	events        map[uint64][]flow.Event
+	eventTypes    map[flow.EventType]dps.EventTypeStats
+	serviceEvents map[uint64][]flow.Event
+	epochs        map[uint64]dps.Epoch
+	phases        map[uint64]map[flow.EpochPhase]uint64
+	identities    map[uint64]flow.IdentityList
+	payloads      map[ledger.Path][]version
+	owners        map[flow.Address]map[ledger.Path]struct{}
+	registerChurn map[uint64]dps.RegisterChurn
+	deltas        map[uint64]map[ledger.Path]dps.RegisterDelta
+
+	collection  map[flow.Identifier]flow.LightCollection
+	guarantee   map[flow.Identifier]flow.CollectionGuarantee
+	transaction map[flow.Identifier]flow.TransactionBody
+	result      map[flow.Identifier]flow.TransactionResult
+	seal        map[flow.Identifier]flow.Seal
+
+	collectionsByHeight  map[uint64][]flow.Identifier
+	transactionsByHeight map[uint64][]flow.Identifier
+	sealsByHeight        map[uint64][]flow.Identifier
+
+	transactionsForScript map[flow.Identifier]map[uint64][]flow.Identifier
+
+	corruptions map[uint64][]string
+	records     map[flow.Identifier][]byte
+	filter      dps.Filter
+}
+
+// version is the payload of a register as it was written at a given height.
+type version struct {
+	height  uint64
+	payload *ledger.Payload
+}
+
+// New creates a new empty in-memory index. The given codec is used to encode
+// the data returned by the raw reads, so it should be the same codec that the
+// API server and its clients use.
+func New(codec dps.Codec) *Index {
+
+	i := Index{
+		mutex: &sync.RWMutex{},
+		codec: codec,
+
+		blocks:       make(map[flow.Identifier]uint64),
+		transactions: make(map[flow.Identifier]uint64),
+		commits:      make(map[flow.StateCommitment]uint64),
+
+		created:    make(map[flow.Address]uint64),
+		keyUpdates: make(map[flow.Address]map[uint64]uint),
+		contracts:  make(map[flow.Address]map[string][]dps.ContractVersion),
+
+		commit:        make(map[uint64]flow.StateCommitment),
+		header:        make(map[uint64]flow.Header),
+		blockStats:    make(map[uint64]dps.BlockStats),
+		events:        make(map[uint64][]flow.Event),
+		eventTypes:    make(map[flow.EventType]dps.EventTypeStats),
+		serviceEvents: make(map[uint64][]flow.Event),
+		epochs:        make(map[uint64]dps.Epoch),
+		phases:        make(map[uint64]map[flow.EpochPhase]uint64),
+		identities:    make(map[uint64]flow.IdentityList),
+		payloads:      make(map[ledger.Path][]version),
+		owners:        make(map[flow.Address]map[ledger.Path]struct{}),
+		registerChurn: make(map[uint64]dps.RegisterChurn),
+		deltas:        make(map[uint64]map[ledger.Path]dps.RegisterDelta),
+
+		collection:  make(map[flow.Identifier]flow.LightCollection),
+		guarantee:   make(map[flow.Identifier]flow.CollectionGuarantee),
+		transaction: make(map[flow.Identifier]flow.TransactionBody),
+		result:      make(map[flow.Identifier]flow.TransactionResult),
+		seal:        make(map[flow.Identifier]flow.Seal),
+
+		collectionsByHeight:  make(map[uint64][]flow.Identifier),
+		transactionsByHeight: make(map[uint64][]flow.Identifier),
+		sealsByHeight:        make(map[uint64][]flow.Identifier),
+
+		transactionsForScript: make(map[flow.Identifier]map[uint64][]flow.Identifier),
+
+		corruptions: make(map[uint64][]string),
+		records:     make(map[flow.Identifier][]byte),
+	}
+
+	return &i
+}
@@ -0,0 +1,354 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package memindex_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/codec/zbor"
+	"github.com/optakt/flow-dps/models/dps"
+	"github.com/optakt/flow-dps/testing/memindex"
+	"github.com/optakt/flow-dps/testing/mocks"
+)
+
+func TestIndex(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("first and last", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer := setupIndex(t)
+
+		_, err := reader.First(ctx)
+		assert.ErrorIs(t, err, dps.ErrBootstrapping)
+		_, err = reader.Last(ctx)
+		assert.ErrorIs(t, err, dps.ErrBootstrapping)
+
+		require.NoError(t, writer.First(ctx, mocks.GenericHeight))
+		require.NoError(t, writer.Last(ctx, mocks.GenericHeight+1))
+
+		first, err := reader.First(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, first)
+
+		last, err := reader.Last(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight+1, last)
+	})
+
+	t.Run("headers", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer := setupIndex(t)
+
+		require.NoError(t, writer.Header(ctx, mocks.GenericHeight, mocks.GenericHeader))
+		require.NoError(t, writer.Height(ctx, mocks.GenericHeader.ID(), mocks.GenericHeight))
+
+		got, err := reader.Header(ctx, mocks.GenericHeight)
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeader, got)
+
+		height, err := reader.HeightForBlock(ctx, mocks.GenericHeader.ID())
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, height)
+
+		data, err := reader.RawHeader(ctx, mocks.GenericHeight)
+		require.NoError(t, err)
+		var decoded flow.Header
+		require.NoError(t, zbor.NewCodec().Unmarshal(data, &decoded))
+		assert.Equal(t, mocks.GenericHeader.ID(), decoded.ID())
+
+		_, err = reader.Header(ctx, mocks.GenericHeight+1)
+		assert.ErrorIs(t, err, dps.ErrNotIndexed)
+	})
+
+	t.Run("block stats", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer := setupIndex(t)
+
+		stats := mocks.GenericBlockStats(3)
+		for j := len(stats) - 1; j >= 0; j-- {
+			require.NoError(t, writer.BlockStats(ctx, stats[j]))
+		}
+
+		got, err := reader.BlockStats(ctx, stats[0].Height, stats[1].Height)
+		require.NoError(t, err)
+		assert.Equal(t, stats[:2], got)
+
+		_, err = reader.BlockStats(ctx, stats[1].Height, stats[0].Height)
+		assert.Error(t, err)
+	})
+
+	t.Run("commits", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer := setupIndex(t)
+
+		require.NoError(t, writer.Commit(ctx, mocks.GenericHeight, mocks.GenericCommit(0)))
+		require.NoError(t, writer.Commit(ctx, mocks.GenericHeight+1, mocks.GenericCommit(0)))
+
+		got, err := reader.Commit(ctx, mocks.GenericHeight+1)
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericCommit(0), got)
+
+		height, err := reader.HeightForCommit(ctx, mocks.GenericCommit(0))
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, height)
+	})
+
+	t.Run("values", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer := setupIndex(t)
+
+		paths := mocks.GenericLedgerPaths(4)
+		payloads := mocks.GenericLedgerPayloads(4)
+		values := mocks.GenericLedgerValues(4)
+
+		require.NoError(t, writer.First(ctx, mocks.GenericHeight))
+		require.NoError(t, writer.Payloads(ctx, mocks.GenericHeight, paths, payloads))
+		require.NoError(t, writer.Payloads(ctx, mocks.GenericHeight+2, paths[:1], payloads[1:2]))
+		require.NoError(t, writer.Last(ctx, mocks.GenericHeight+2))
+
+		got, err := reader.Values(ctx, mocks.GenericHeight+1, paths)
+		require.NoError(t, err)
+		assert.Equal(t, values, got)
+
+		got, err = reader.Values(ctx, mocks.GenericHeight+2, paths[:1])
+		require.NoError(t, err)
+		assert.Equal(t, values[1:2], got)
+
+		_, err = reader.Values(ctx, mocks.GenericHeight-1, paths)
+		assert.ErrorIs(t, err, dps.ErrNotIndexed)
+
+		_, err = reader.Values(ctx, mocks.GenericHeight+3, paths)
+		assert.ErrorIs(t, err, dps.ErrUnavailable)
+	})
+
+	t.Run("registers", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer := setupIndex(t)
+
+		owner := flow.BytesToAddress([]byte(`owner`))
+		paths := mocks.GenericLedgerPaths(4)
+		payloads := mocks.GenericLedgerPayloads(4)
+		deleted := ledger.NewPayload(mocks.GenericLedgerKey, nil)
+
+		require.NoError(t, writer.First(ctx, mocks.GenericHeight))
+		require.NoError(t, writer.Payloads(ctx, mocks.GenericHeight, paths, payloads))
+		require.NoError(t, writer.Payloads(ctx, mocks.GenericHeight+1, paths[:1], []*ledger.Payload{deleted}))
+		require.NoError(t, writer.Last(ctx, mocks.GenericHeight+1))
+
+		first, err := reader.Registers(ctx, mocks.GenericHeight, owner, ledger.Path{}, 2)
+		require.NoError(t, err)
+		require.Len(t, first, 2)
+
+		second, err := reader.Registers(ctx, mocks.GenericHeight, owner, first[1].Path, 2)
+		require.NoError(t, err)
+		require.Len(t, second, 2)
+
+		var got []ledger.Path
+		for _, register := range append(first, second...) {
+			got = append(got, register.Path)
+		}
+		assert.ElementsMatch(t, paths, got)
+
+		remaining, err := reader.Registers(ctx, mocks.GenericHeight+1, owner, ledger.Path{}, 10)
+		require.NoError(t, err)
+		assert.Len(t, remaining, 3)
+	})
+
+	t.Run("dump registers", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer := setupIndex(t)
+
+		paths := mocks.GenericLedgerPaths(4)
+		payloads := mocks.GenericLedgerPayloads(4)
+		deleted := ledger.NewPayload(mocks.GenericLedgerKey, nil)
+
+		require.NoError(t, writer.First(ctx, mocks.GenericHeight))
+		require.NoError(t, writer.Payloads(ctx, mocks.GenericHeight, paths, payloads))
+		require.NoError(t, writer.Payloads(ctx, mocks.GenericHeight+1, paths[:1], []*ledger.Payload{deleted}))
+		require.NoError(t, writer.Last(ctx, mocks.GenericHeight+1))
+
+		first, err := reader.DumpRegisters(ctx, mocks.GenericHeight, ledger.Path{}, 2)
+		require.NoError(t, err)
+		require.Len(t, first, 2)
+
+		second, err := reader.DumpRegisters(ctx, mocks.GenericHeight, first[1].Path, 10)
+		require.NoError(t, err)
+		require.Len(t, second, 2)
+
+		var got []ledger.Path
+		for _, register := range append(first, second...) {
+			got = append(got, register.Path)
+		}
+		assert.ElementsMatch(t, paths, got)
+		for j := 1; j < len(got); j++ {
+			assert.Equal(t, -1, bytes.Compare(got[j-1][:], got[j][:]))
+		}
+
+		remaining, err := reader.DumpRegisters(ctx, mocks.GenericHeight+1, ledger.Path{}, 10)
+		require.NoError(t, err)
+		assert.Len(t, remaining, 3)
+	})
+
+	t.Run("register churn", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer := setupIndex(t)
+
+		churns := mocks.GenericRegisterChurns(3)
+		for _, churn := range churns {
+			require.NoError(t, writer.RegisterChurn(ctx, churn))
+		}
+
+		report, err := reader.RegisterChurn(ctx, churns[1].Height, churns[2].Height, 10)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(7), report.Writes)
+		assert.Equal(t, append(churns[2].Owners, churns[1].Owners...), report.Owners)
+		assert.Len(t, report.Registers, 4)
+
+		_, err = reader.RegisterChurn(ctx, churns[1].Height, churns[0].Height, 10)
+		assert.Error(t, err)
+	})
+
+	t.Run("register deltas", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer := setupIndex(t)
+
+		_, err := reader.RegisterDeltas(ctx, mocks.GenericHeight, ledger.Path{}, 10)
+		assert.Error(t, err)
+
+		require.NoError(t, writer.First(ctx, mocks.GenericHeight))
+		require.NoError(t, writer.Last(ctx, mocks.GenericHeight))
+
+		deltas := mocks.GenericRegisterDeltas(4)
+		require.NoError(t, writer.RegisterDeltas(ctx, mocks.GenericHeight, deltas))
+
+		all, err := reader.RegisterDeltas(ctx, mocks.GenericHeight, ledger.Path{}, 10)
+		require.NoError(t, err)
+		require.Len(t, all, len(deltas))
+		for i := 1; i < len(all); i++ {
+			assert.Negative(t, bytes.Compare(all[i-1].Path[:], all[i].Path[:]))
+		}
+
+		page, err := reader.RegisterDeltas(ctx, mocks.GenericHeight, all[0].Path, 2)
+		require.NoError(t, err)
+		assert.Equal(t, all[1:3], page)
+	})
+
+	t.Run("events", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer := setupIndex(t)
+
+		types := mocks.GenericEventTypes(2)
+		events := mocks.GenericEvents(4, types...)
+
+		require.NoError(t, writer.First(ctx, mocks.GenericHeight))
+		require.NoError(t, writer.Last(ctx, mocks.GenericHeight))
+		require.NoError(t, writer.Events(ctx, mocks.GenericHeight, events))
+
+		got, err := reader.Events(ctx, mocks.GenericHeight)
+		require.NoError(t, err)
+		assert.Equal(t, events, got)
+
+		got, err = reader.Events(ctx, mocks.GenericHeight, types[0])
+		require.NoError(t, err)
+		for _, event := range got {
+			assert.Equal(t, types[0], event.Type)
+		}
+
+		data, err := reader.RawEvents(ctx, mocks.GenericHeight)
+		require.NoError(t, err)
+		var decoded []flow.Event
+		for _, batch := range data {
+			var set []flow.Event
+			require.NoError(t, zbor.NewCodec().Unmarshal(batch, &set))
+			decoded = append(decoded, set...)
+		}
+		assert.ElementsMatch(t, events, decoded)
+	})
+
+	t.Run("transactions", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer := setupIndex(t)
+
+		transactions := mocks.GenericTransactions(4)
+
+		require.NoError(t, writer.Transactions(ctx, mocks.GenericHeight, transactions))
+
+		got, err := reader.Transaction(ctx, transactions[0].ID())
+		require.NoError(t, err)
+		assert.Equal(t, transactions[0], got)
+
+		height, err := reader.HeightForTransaction(ctx, transactions[0].ID())
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericHeight, height)
+
+		txIDs, err := reader.TransactionsByHeight(ctx, mocks.GenericHeight)
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericTransactionIDs(4), txIDs)
+
+		_, err = reader.TransactionsByHeight(ctx, mocks.GenericHeight+1)
+		assert.ErrorIs(t, err, dps.ErrNotIndexed)
+
+		hash := dps.ScriptHash(transactions[0].Script)
+		txIDs, err = reader.TransactionsForScript(ctx, hash, mocks.GenericHeight, mocks.GenericHeight+1)
+		require.NoError(t, err)
+		assert.Equal(t, mocks.GenericTransactionIDs(4), txIDs)
+
+		txIDs, err = reader.TransactionsForScript(ctx, hash, mocks.GenericHeight+1, mocks.GenericHeight+2)
+		require.NoError(t, err)
+		assert.Empty(t, txIDs)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		t.Parallel()
+
+		reader, writer := setupIndex(t)
+
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		err := writer.First(canceled, mocks.GenericHeight)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		_, err = reader.First(canceled)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func setupIndex(t *testing.T) (*memindex.Reader, *memindex.Writer) {
+	t.Helper()
+
+	index := memindex.New(zbor.NewCodec())
+
+	return memindex.NewReader(index), memindex.NewWriter(index)
+}
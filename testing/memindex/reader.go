@@ -0,0 +1,860 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package memindex
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Reader implements the `dps.Reader` interface on top of an in-memory index.
+// It fails with the same errors as the reader of the on-disk index, so that
+// both can be used interchangeably.
+type Reader struct {
+	index *Index
+}
+
+// NewReader creates a new reader for the given in-memory index.
+func NewReader(index *Index) *Reader {
+
+	r := Reader{
+		index: index,
+	}
+
+	return &r
+}
+
+// First returns the height of the first finalized block that was indexed. If
+// no block was indexed yet, it fails with `dps.ErrBootstrapping`.
+func (r *Reader) First(ctx context.Context) (uint64, error) {
+	var height uint64
+	err := r.view(ctx, func(i *Index) error {
+		if i.first == nil {
+			return fmt.Errorf("index is empty: %w", dps.ErrBootstrapping)
+		}
+		height = *i.first
+		return nil
+	})
+	return height, err
+}
+
+// Last returns the height of the last finalized block that was indexed. If no
+// block was indexed yet, it fails with `dps.ErrBootstrapping`.
+func (r *Reader) Last(ctx context.Context) (uint64, error) {
+	var height uint64
+	err := r.view(ctx, func(i *Index) error {
+		if i.last == nil {
+			return fmt.Errorf("index is empty: %w", dps.ErrBootstrapping)
+		}
+		height = *i.last
+		return nil
+	})
+	return height, err
+}
+
+// HeightForBlock returns the height for the given block identifier.
+func (r *Reader) HeightForBlock(ctx context.Context, blockID flow.Identifier) (uint64, error) {
+	var height uint64
+	err := r.view(ctx, func(i *Index) error {
+		var ok bool
+		height, ok = i.blocks[blockID]
+		if !ok {
+			return fmt.Errorf("unknown block (block: %x): %w", blockID, dps.ErrNotIndexed)
+		}
+		return nil
+	})
+	return height, err
+}
+
+// HeightForTransaction returns the height of the block within which the given
+// transaction identifier is.
+func (r *Reader) HeightForTransaction(ctx context.Context, txID flow.Identifier) (uint64, error) {
+	var height uint64
+	err := r.view(ctx, func(i *Index) error {
+		var ok bool
+		height, ok = i.transactions[txID]
+		if !ok {
+			return filtered(i, fmt.Errorf("unknown transaction (transaction: %x): %w", txID, dps.ErrNotIndexed))
+		}
+		return nil
+	})
+	return height, err
+}
+
+// HeightForCommit returns the first height at which the execution state had
+// the given state commitment.
+func (r *Reader) HeightForCommit(ctx context.Context, commit flow.StateCommitment) (uint64, error) {
+	var height uint64
+	err := r.view(ctx, func(i *Index) error {
+		var ok bool
+		height, ok = i.commits[commit]
+		if !ok {
+			return fmt.Errorf("unknown commit (commit: %x): %w", commit, dps.ErrNotIndexed)
+		}
+		return nil
+	})
+	return height, err
+}
+
+// Commit returns the commitment of the execution state as it was after the
+// execution of the finalized block at the given height.
+func (r *Reader) Commit(ctx context.Context, height uint64) (flow.StateCommitment, error) {
+	var commit flow.StateCommitment
+	err := r.view(ctx, func(i *Index) error {
+		var ok bool
+		commit, ok = i.commit[height]
+		if !ok {
+			return fmt.Errorf("unknown commit (height: %d): %w", height, dps.ErrNotIndexed)
+		}
+		return nil
+	})
+	return commit, err
+}
+
+// Header returns the header for the finalized block at the given height.
+func (r *Reader) Header(ctx context.Context, height uint64) (*flow.Header, error) {
+	var header flow.Header
+	err := r.view(ctx, func(i *Index) error {
+		var ok bool
+		header, ok = i.header[height]
+		if !ok {
+			return fmt.Errorf("unknown header (height: %d): %w", height, dps.ErrNotIndexed)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &header, nil
+}
+
+// BlockStats returns the aggregate statistics of the finalized blocks between
+// the given start and end heights, inclusively, in ascending order of height.
+func (r *Reader) BlockStats(ctx context.Context, start uint64, end uint64) ([]dps.BlockStats, error) {
+
+	if start > end {
+		return nil, fmt.Errorf("invalid height range (start: %d, end: %d)", start, end)
+	}
+
+	var stats []dps.BlockStats
+	err := r.view(ctx, func(i *Index) error {
+		for _, entry := range i.blockStats {
+			if entry.Height >= start && entry.Height <= end {
+				stats = append(stats, entry)
+			}
+		}
+		sort.Slice(stats, func(j, k int) bool {
+			return stats[j].Height < stats[k].Height
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// RawHeader returns the header for the finalized block at the given height,
+// encoded with the codec of the index.
+func (r *Reader) RawHeader(ctx context.Context, height uint64) ([]byte, error) {
+	header, err := r.Header(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+	return r.index.codec.Marshal(header)
+}
+
+// Values returns the Ledger values of the execution state at the given paths
+// as they were after the execution of the finalized block at the given height.
+// For compatibility with existing Flow execution node code, a path that is not
+// found within the indexed execution state returns a nil value without error.
+func (r *Reader) Values(ctx context.Context, height uint64, paths []ledger.Path) ([]ledger.Value, error) {
+	values := make([]ledger.Value, 0, len(paths))
+	err := r.view(ctx, func(i *Index) error {
+		err := indexed(i, height)
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			payload := payloadAt(i, path, height)
+			if payload == nil {
+				values = append(values, nil)
+				continue
+			}
+			values = append(values, payload.Value)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Registers returns up to the given number of registers owned by the account
+// with the given address, as they were after the execution of the finalized
+// block at the given height. The registers are in ascending order of their
+// paths, starting after the given path, so that the last path returned can be
+// used to get the next page. The zero path starts from the first register.
+// Registers that did not exist at the given height, or that were deleted, are
+// skipped.
+func (r *Reader) Registers(ctx context.Context, height uint64, owner flow.Address, after ledger.Path, limit uint) ([]dps.Register, error) {
+	var registers []dps.Register
+	err := r.view(ctx, func(i *Index) error {
+		err := indexed(i, height)
+		if err != nil {
+			return err
+		}
+
+		paths := make([]ledger.Path, 0, len(i.owners[owner]))
+		for path := range i.owners[owner] {
+			if after != (ledger.Path{}) && bytes.Compare(path[:], after[:]) <= 0 {
+				continue
+			}
+			paths = append(paths, path)
+		}
+		sort.Slice(paths, func(j, k int) bool {
+			return bytes.Compare(paths[j][:], paths[k][:]) < 0
+		})
+
+		for _, path := range paths {
+			if uint(len(registers)) >= limit {
+				break
+			}
+			payload := payloadAt(i, path, height)
+			if payload == nil || len(payload.Value) == 0 {
+				continue
+			}
+			registers = append(registers, dps.Register{Path: path, Payload: payload.DeepCopy()})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return registers, nil
+}
+
+// DumpRegisters returns up to the given number of registers of the execution
+// state, regardless of their owner, as they were after the execution of the
+// finalized block at the given height. The registers are in ascending order of
+// their paths, starting after the given path. Registers that did not exist at
+// the given height, or that were deleted, are skipped.
+func (r *Reader) DumpRegisters(ctx context.Context, height uint64, after ledger.Path, limit uint) ([]dps.Register, error) {
+	var registers []dps.Register
+	err := r.view(ctx, func(i *Index) error {
+		err := indexed(i, height)
+		if err != nil {
+			return err
+		}
+
+		paths := make([]ledger.Path, 0, len(i.payloads))
+		for path := range i.payloads {
+			if after != (ledger.Path{}) && bytes.Compare(path[:], after[:]) <= 0 {
+				continue
+			}
+			paths = append(paths, path)
+		}
+		sort.Slice(paths, func(j, k int) bool {
+			return bytes.Compare(paths[j][:], paths[k][:]) < 0
+		})
+
+		for _, path := range paths {
+			if uint(len(registers)) >= limit {
+				break
+			}
+			payload := payloadAt(i, path, height)
+			if payload == nil || len(payload.Value) == 0 {
+				continue
+			}
+			registers = append(registers, dps.Register{Path: path, Payload: payload.DeepCopy()})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return registers, nil
+}
+
+// RegisterChurn returns the report of the register writes between the given
+// start and end heights, inclusively, with up to the given number of owners
+// and registers that were written the most.
+func (r *Reader) RegisterChurn(ctx context.Context, start uint64, end uint64, limit uint) (*dps.ChurnReport, error) {
+
+	if start > end {
+		return nil, fmt.Errorf("invalid height range (start: %d, end: %d)", start, end)
+	}
+
+	var churns []dps.RegisterChurn
+	err := r.view(ctx, func(i *Index) error {
+		for height, churn := range i.registerChurn {
+			if height >= start && height <= end {
+				churns = append(churns, churn)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := dps.MergeChurn(start, end, churns, limit)
+
+	return &report, nil
+}
+
+// RegisterDeltas returns up to the given number of changes to the registers
+// made by the finalized block at the given height, in ascending order of their
+// paths, starting after the given path. The zero path starts from the first
+// change.
+func (r *Reader) RegisterDeltas(ctx context.Context, height uint64, after ledger.Path, limit uint) ([]dps.RegisterDelta, error) {
+	var deltas []dps.RegisterDelta
+	err := r.view(ctx, func(i *Index) error {
+		err := indexed(i, height)
+		if err != nil {
+			return err
+		}
+
+		for path, delta := range i.deltas[height] {
+			if after != (ledger.Path{}) && bytes.Compare(path[:], after[:]) <= 0 {
+				continue
+			}
+			deltas = append(deltas, delta)
+		}
+		sort.Slice(deltas, func(j, k int) bool {
+			return bytes.Compare(deltas[j].Path[:], deltas[k].Path[:]) < 0
+		})
+		if uint(len(deltas)) > limit {
+			deltas = deltas[:limit]
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deltas, nil
+}
+
+// Account returns the metadata of the account with the given address. Accounts
+// that were created before the first indexed height and whose keys were not
+// updated since have no metadata besides their address.
+func (r *Reader) Account(ctx context.Context, address flow.Address) (*dps.Account, error) {
+	account := dps.Account{
+		Address: address,
+	}
+	err := r.view(ctx, func(i *Index) error {
+		if !i.filter.Address(address) {
+			return fmt.Errorf("account excluded by index filter: %w", dps.ErrNotIndexed)
+		}
+		account.Created = i.created[address]
+		for height := range i.keyUpdates[address] {
+			account.KeyUpdates = append(account.KeyUpdates, height)
+		}
+		sort.Slice(account.KeyUpdates, func(j, k int) bool {
+			return account.KeyUpdates[j] < account.KeyUpdates[k]
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// ContractHistory returns all changes to the contract with the given name on
+// the account with the given address, in the order in which they happened.
+func (r *Reader) ContractHistory(ctx context.Context, address flow.Address, name string) ([]dps.ContractVersion, error) {
+	var versions []dps.ContractVersion
+	err := r.view(ctx, func(i *Index) error {
+		if !i.filter.Address(address) {
+			return fmt.Errorf("account excluded by index filter: %w", dps.ErrNotIndexed)
+		}
+		versions = append(versions, i.contracts[address][name]...)
+		sort.SliceStable(versions, func(j, k int) bool {
+			return versions[j].Height < versions[k].Height
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// Filter returns the filter that restricted the indexed data to the data
+// involving some accounts. If all data was indexed, the filter is empty.
+func (r *Reader) Filter(ctx context.Context) (dps.Filter, error) {
+	var filter dps.Filter
+	err := r.view(ctx, func(i *Index) error {
+		filter.Allowed = append(filter.Allowed, i.filter.Allowed...)
+		filter.Denied = append(filter.Denied, i.filter.Denied...)
+		return nil
+	})
+	return filter, err
+}
+
+// Events returns the events of all transactions that were part of the
+// finalized block at the given height. It can optionally filter them by event
+// type; if no event types are given, all events are returned.
+func (r *Reader) Events(ctx context.Context, height uint64, types ...flow.EventType) ([]flow.Event, error) {
+	var events []flow.Event
+	err := r.view(ctx, func(i *Index) error {
+		err := indexed(i, height)
+		if err != nil {
+			return err
+		}
+		err = eventTypes(i, types)
+		if err != nil {
+			return err
+		}
+		events = selectEvents(i.events[height], types)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// RawEvents returns the events of all transactions that were part of the
+// finalized block at the given height, encoded with the codec of the index in
+// one batch per event type. It can optionally filter them by event type; if no
+// event types are given, all batches are returned.
+func (r *Reader) RawEvents(ctx context.Context, height uint64, types ...flow.EventType) ([][]byte, error) {
+
+	events, err := r.Events(ctx, height, types...)
+	if err != nil {
+		return nil, err
+	}
+
+	var order []flow.EventType
+	buckets := make(map[flow.EventType][]flow.Event)
+	for _, event := range events {
+		_, ok := buckets[event.Type]
+		if !ok {
+			order = append(order, event.Type)
+		}
+		buckets[event.Type] = append(buckets[event.Type], event)
+	}
+
+	data := make([][]byte, 0, len(order))
+	for _, typ := range order {
+		batch, err := r.index.codec.Marshal(buckets[typ])
+		if err != nil {
+			return nil, fmt.Errorf("could not encode events (type: %s): %w", typ, err)
+		}
+		data = append(data, batch)
+	}
+
+	return data, nil
+}
+
+// EventTypes returns the statistics of all event types that were indexed.
+func (r *Reader) EventTypes(ctx context.Context) ([]dps.EventTypeStats, error) {
+	var stats []dps.EventTypeStats
+	err := r.view(ctx, func(i *Index) error {
+		for _, entry := range i.eventTypes {
+			stats = append(stats, entry)
+		}
+		sort.Slice(stats, func(j, k int) bool {
+			return stats[j].Type < stats[k].Type
+		})
+		return nil
+	})
+	return stats, err
+}
+
+// ServiceEvents returns the service events emitted between the given start and
+// end heights, inclusively, that have one of the given types. If no types are
+// given, all service events are returned.
+func (r *Reader) ServiceEvents(ctx context.Context, start uint64, end uint64, types ...flow.EventType) ([]dps.ServiceEvent, error) {
+
+	if start > end {
+		return nil, fmt.Errorf("invalid height range (start: %d, end: %d)", start, end)
+	}
+
+	var events []dps.ServiceEvent
+	err := r.view(ctx, func(i *Index) error {
+		var heights []uint64
+		for height := range i.serviceEvents {
+			if height >= start && height <= end {
+				heights = append(heights, height)
+			}
+		}
+		sort.Slice(heights, func(j, k int) bool {
+			return heights[j] < heights[k]
+		})
+		for _, height := range heights {
+			for _, event := range selectEvents(i.serviceEvents[height], types) {
+				events = append(events, dps.ServiceEvent{Height: height, Event: event})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Epochs returns all indexed epochs, in ascending order of their counters,
+// along with the heights at which each of their phases started.
+func (r *Reader) Epochs(ctx context.Context) ([]dps.Epoch, error) {
+	var epochs []dps.Epoch
+	err := r.view(ctx, func(i *Index) error {
+		for counter, epoch := range i.epochs {
+			phases := i.phases[counter]
+			epoch.StakingHeight = phases[flow.EpochPhaseStaking]
+			epoch.SetupHeight = phases[flow.EpochPhaseSetup]
+			epoch.CommittedHeight = phases[flow.EpochPhaseCommitted]
+			epochs = append(epochs, epoch)
+		}
+		sort.Slice(epochs, func(j, k int) bool {
+			return epochs[j].Counter < epochs[k].Counter
+		})
+		return nil
+	})
+	return epochs, err
+}
+
+// Identities returns the identity table of the epoch with the given counter.
+func (r *Reader) Identities(ctx context.Context, counter uint64) (flow.IdentityList, error) {
+	var identities flow.IdentityList
+	err := r.view(ctx, func(i *Index) error {
+		list, ok := i.identities[counter]
+		if !ok {
+			return fmt.Errorf("unknown identities (counter: %d): %w", counter, dps.ErrNotIndexed)
+		}
+		identities = list.Copy()
+		return nil
+	})
+	return identities, err
+}
+
+// Collection returns the collection with the given ID.
+func (r *Reader) Collection(ctx context.Context, collID flow.Identifier) (*flow.LightCollection, error) {
+	var collection flow.LightCollection
+	err := r.view(ctx, func(i *Index) error {
+		var ok bool
+		collection, ok = i.collection[collID]
+		if !ok {
+			return fmt.Errorf("unknown collection (collection: %x): %w", collID, dps.ErrNotIndexed)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// Guarantee returns the guarantee with the given collection ID.
+func (r *Reader) Guarantee(ctx context.Context, collID flow.Identifier) (*flow.CollectionGuarantee, error) {
+	var guarantee flow.CollectionGuarantee
+	err := r.view(ctx, func(i *Index) error {
+		var ok bool
+		guarantee, ok = i.guarantee[collID]
+		if !ok {
+			return fmt.Errorf("unknown guarantee (collection: %x): %w", collID, dps.ErrNotIndexed)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &guarantee, nil
+}
+
+// Transaction returns the transaction with the given ID.
+func (r *Reader) Transaction(ctx context.Context, txID flow.Identifier) (*flow.TransactionBody, error) {
+	var transaction flow.TransactionBody
+	err := r.view(ctx, func(i *Index) error {
+		var ok bool
+		transaction, ok = i.transaction[txID]
+		if !ok {
+			return filtered(i, fmt.Errorf("unknown transaction (transaction: %x): %w", txID, dps.ErrNotIndexed))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// Seal returns the seal with the given ID.
+func (r *Reader) Seal(ctx context.Context, sealID flow.Identifier) (*flow.Seal, error) {
+	var seal flow.Seal
+	err := r.view(ctx, func(i *Index) error {
+		var ok bool
+		seal, ok = i.seal[sealID]
+		if !ok {
+			return fmt.Errorf("unknown seal (seal: %x): %w", sealID, dps.ErrNotIndexed)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &seal, nil
+}
+
+// Result returns the transaction result for the given transaction ID.
+func (r *Reader) Result(ctx context.Context, txID flow.Identifier) (*flow.TransactionResult, error) {
+	var result flow.TransactionResult
+	err := r.view(ctx, func(i *Index) error {
+		var ok bool
+		result, ok = i.result[txID]
+		if !ok {
+			return filtered(i, fmt.Errorf("unknown result (transaction: %x): %w", txID, dps.ErrNotIndexed))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RawCollection returns the collection with the given ID, encoded with the
+// codec of the index.
+func (r *Reader) RawCollection(ctx context.Context, collID flow.Identifier) ([]byte, error) {
+	collection, err := r.Collection(ctx, collID)
+	if err != nil {
+		return nil, err
+	}
+	return r.index.codec.Marshal(collection)
+}
+
+// RawGuarantee returns the guarantee with the given collection ID, encoded
+// with the codec of the index.
+func (r *Reader) RawGuarantee(ctx context.Context, collID flow.Identifier) ([]byte, error) {
+	guarantee, err := r.Guarantee(ctx, collID)
+	if err != nil {
+		return nil, err
+	}
+	return r.index.codec.Marshal(guarantee)
+}
+
+// RawTransaction returns the transaction with the given ID, encoded with the
+// codec of the index.
+func (r *Reader) RawTransaction(ctx context.Context, txID flow.Identifier) ([]byte, error) {
+	transaction, err := r.Transaction(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	return r.index.codec.Marshal(transaction)
+}
+
+// RawSeal returns the seal with the given ID, encoded with the codec of the
+// index.
+func (r *Reader) RawSeal(ctx context.Context, sealID flow.Identifier) ([]byte, error) {
+	seal, err := r.Seal(ctx, sealID)
+	if err != nil {
+		return nil, err
+	}
+	return r.index.codec.Marshal(seal)
+}
+
+// RawResult returns the transaction result for the given transaction ID,
+// encoded with the codec of the index.
+func (r *Reader) RawResult(ctx context.Context, txID flow.Identifier) ([]byte, error) {
+	result, err := r.Result(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	return r.index.codec.Marshal(result)
+}
+
+// CollectionsByHeight returns the collection IDs at the given height.
+func (r *Reader) CollectionsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error) {
+	return r.identifiers(ctx, height, func(i *Index) map[uint64][]flow.Identifier {
+		return i.collectionsByHeight
+	})
+}
+
+// TransactionsByHeight returns the transaction IDs within the block at the
+// given height.
+func (r *Reader) TransactionsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error) {
+	return r.identifiers(ctx, height, func(i *Index) map[uint64][]flow.Identifier {
+		return i.transactionsByHeight
+	})
+}
+
+// TransactionsForScript returns the identifiers of the transactions whose
+// script has the given hash, between the given start and end heights,
+// inclusively, in ascending order of height.
+func (r *Reader) TransactionsForScript(ctx context.Context, hash flow.Identifier, start uint64, end uint64) ([]flow.Identifier, error) {
+
+	if start > end {
+		return nil, fmt.Errorf("invalid height range (start: %d, end: %d)", start, end)
+	}
+
+	var txIDs []flow.Identifier
+	err := r.view(ctx, func(i *Index) error {
+		var heights []uint64
+		for height := range i.transactionsForScript[hash] {
+			if height >= start && height <= end {
+				heights = append(heights, height)
+			}
+		}
+		sort.Slice(heights, func(j, k int) bool {
+			return heights[j] < heights[k]
+		})
+		for _, height := range heights {
+			txIDs = append(txIDs, i.transactionsForScript[hash][height]...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return txIDs, nil
+}
+
+// SealsByHeight returns all of the seals that were part of the finalized block
+// at the given height.
+func (r *Reader) SealsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error) {
+	return r.identifiers(ctx, height, func(i *Index) map[uint64][]flow.Identifier {
+		return i.sealsByHeight
+	})
+}
+
+// Heights returns the heights in the given range for which the block data was
+// indexed, in ascending order.
+func (r *Reader) Heights(ctx context.Context, from uint64, to uint64) ([]uint64, error) {
+	var heights []uint64
+	err := r.view(ctx, func(i *Index) error {
+		for height := range i.header {
+			if height >= from && height <= to {
+				heights = append(heights, height)
+			}
+		}
+		sort.Slice(heights, func(j, k int) bool {
+			return heights[j] < heights[k]
+		})
+		return nil
+	})
+	return heights, err
+}
+
+// identifiers returns a copy of the identifiers indexed at the given height in
+// the lookup returned by the given function.
+func (r *Reader) identifiers(ctx context.Context, height uint64, lookup func(*Index) map[uint64][]flow.Identifier) ([]flow.Identifier, error) {
+	var ids []flow.Identifier
+	err := r.view(ctx, func(i *Index) error {
+		indexed, ok := lookup(i)[height]
+		if !ok {
+			return fmt.Errorf("unknown height (height: %d): %w", height, dps.ErrNotIndexed)
+		}
+		ids = append([]flow.Identifier{}, indexed...)
+		return nil
+	})
+	return ids, err
+}
+
+// view executes the given operation while holding the read lock of the index,
+// unless the given context is already done.
+func (r *Reader) view(ctx context.Context, op func(*Index) error) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+	r.index.mutex.RLock()
+	defer r.index.mutex.RUnlock()
+	return op(r.index)
+}
+
+// indexed checks whether the given height is within the range of indexed
+// heights. Heights above the last indexed height fail with
+// `dps.ErrUnavailable`, as they might still be indexed, while heights below
+// the first indexed height fail with `dps.ErrNotIndexed`.
+func indexed(i *Index, height uint64) error {
+	if i.first == nil || i.last == nil {
+		return fmt.Errorf("index is empty: %w", dps.ErrBootstrapping)
+	}
+	first, last := *i.first, *i.last
+	if height < first {
+		return fmt.Errorf("invalid height (given: %d, first: %d, last: %d): %w", height, first, last, dps.ErrNotIndexed)
+	}
+	if height > last {
+		return fmt.Errorf("invalid height (given: %d, first: %d, last: %d): %w", height, first, last, dps.ErrUnavailable)
+	}
+	return nil
+}
+
+// eventTypes checks whether events of the given types can have been indexed.
+// If the index is filtered, we fail on event types that can never have been
+// indexed, rather than returning no events.
+func eventTypes(i *Index, types []flow.EventType) error {
+	for _, typ := range types {
+		if !i.filter.EventType(typ) {
+			return fmt.Errorf("could not retrieve events (type: %s): %w", typ, dps.ErrNotIndexed)
+		}
+	}
+	return nil
+}
+
+// filtered checks whether data that was not found in the index might have been
+// excluded by the filter of the index, in which case it fails precisely.
+func filtered(i *Index, err error) error {
+	if !errors.Is(err, dps.ErrNotIndexed) || i.filter.Empty() {
+		return err
+	}
+	return fmt.Errorf("data might be excluded by index filter: %w", dps.ErrNotIndexed)
+}
+
+// payloadAt returns the payload of the register at the given path as it was
+// after the given height, or nil if it had not been written yet.
+func payloadAt(i *Index, path ledger.Path, height uint64) *ledger.Payload {
+	versions := i.payloads[path]
+	k := sort.Search(len(versions), func(k int) bool {
+		return versions[k].height > height
+	})
+	if k == 0 {
+		return nil
+	}
+	return versions[k-1].payload
+}
+
+// selectEvents returns the given events that have one of the given types, in
+// their original order. If no types are given, all events are returned.
+func selectEvents(events []flow.Event, types []flow.EventType) []flow.Event {
+	lookup := make(map[flow.EventType]struct{}, len(types))
+	for _, typ := range types {
+		lookup[typ] = struct{}{}
+	}
+	var selected []flow.Event
+	for _, event := range events {
+		_, ok := lookup[event.Type]
+		if len(lookup) != 0 && !ok {
+			continue
+		}
+		selected = append(selected, event)
+	}
+	return selected
+}
@@ -0,0 +1,442 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package memindex
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+// Writer implements the `dps.Writer` interface on top of an in-memory index.
+// Writes are applied right away, so they are visible to readers as soon as
+// they return.
+type Writer struct {
+	index *Index
+}
+
+// NewWriter creates a new writer for the given in-memory index.
+func NewWriter(index *Index) *Writer {
+
+	w := Writer{
+		index: index,
+	}
+
+	return &w
+}
+
+// WriteHeightBatch indexes all of the given data of a height. The data is
+// written with the individual calls, one after the other, so readers can see
+// part of it before the last height is updated.
+func (w *Writer) WriteHeightBatch(ctx context.Context, data dps.HeightData) error {
+
+	var writes []func() error
+	if data.Header != nil {
+		writes = append(writes,
+			func() error { return w.Height(ctx, data.BlockID, data.Height) },
+			func() error { return w.Header(ctx, data.Height, data.Header) },
+			func() error { return w.Guarantees(ctx, data.Height, data.Guarantees) },
+			func() error { return w.Seals(ctx, data.Height, data.Seals) },
+		)
+	}
+	if data.Commit != nil {
+		writes = append(writes, func() error { return w.Commit(ctx, data.Height, *data.Commit) })
+	}
+	if data.Header != nil {
+		writes = append(writes,
+			func() error { return w.Collections(ctx, data.Height, data.Collections) },
+			func() error { return w.Transactions(ctx, data.Height, data.Transactions) },
+		)
+	}
+	if data.Commit != nil {
+		writes = append(writes,
+			func() error { return w.Results(ctx, data.Results) },
+			func() error { return w.Events(ctx, data.Height, data.Events) },
+		)
+	}
+	if data.BlockStats != nil {
+		writes = append(writes, func() error { return w.BlockStats(ctx, *data.BlockStats) })
+	}
+	if len(data.EventTypes) > 0 {
+		writes = append(writes, func() error { return w.EventTypes(ctx, data.EventTypes) })
+	}
+	if len(data.ServiceEvents) > 0 {
+		writes = append(writes, func() error { return w.ServiceEvents(ctx, data.Height, data.ServiceEvents) })
+	}
+	writes = append(writes,
+		func() error { return w.Accounts(ctx, data.Height, data.Accounts) },
+		func() error { return w.KeyUpdates(ctx, data.Height, data.KeyUpdates) },
+		func() error { return w.Contracts(ctx, data.Height, data.Contracts) },
+	)
+	if data.RegisterChurn != nil {
+		writes = append(writes, func() error { return w.RegisterChurn(ctx, *data.RegisterChurn) })
+	}
+	if len(data.RegisterDeltas) > 0 {
+		writes = append(writes, func() error { return w.RegisterDeltas(ctx, data.Height, data.RegisterDeltas) })
+	}
+	writes = append(writes, func() error { return w.Payloads(ctx, data.Height, data.Paths, data.Payloads) })
+	if data.First {
+		writes = append(writes, func() error { return w.First(ctx, data.Height) })
+	}
+	if data.Last {
+		writes = append(writes, func() error { return w.Last(ctx, data.Height) })
+	}
+
+	for _, write := range writes {
+		err := write()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// First indexes the height of the first finalized block.
+func (w *Writer) First(ctx context.Context, height uint64) error {
+	return w.update(ctx, func(i *Index) {
+		i.first = &height
+	})
+}
+
+// Last indexes the height of the last finalized block.
+func (w *Writer) Last(ctx context.Context, height uint64) error {
+	return w.update(ctx, func(i *Index) {
+		i.last = &height
+	})
+}
+
+// Manifest does nothing, as the in-memory index does not record integrity
+// manifests.
+func (w *Writer) Manifest(ctx context.Context, _ uint64) error {
+	return ctx.Err()
+}
+
+// Stage does nothing, as the in-memory index does not stage its entries for
+// rollback.
+func (w *Writer) Stage(ctx context.Context, _ uint64) error {
+	return ctx.Err()
+}
+
+// Height indexes the height for the given block ID.
+func (w *Writer) Height(ctx context.Context, blockID flow.Identifier, height uint64) error {
+	return w.update(ctx, func(i *Index) {
+		i.blocks[blockID] = height
+	})
+}
+
+// Accounts indexes the given height as the creation height of the accounts
+// with the given addresses.
+func (w *Writer) Accounts(ctx context.Context, height uint64, addresses []flow.Address) error {
+	return w.update(ctx, func(i *Index) {
+		for _, address := range addresses {
+			i.created[address] = height
+		}
+	})
+}
+
+// KeyUpdates indexes the given height as a height at which keys were added to
+// or removed from the accounts with the given addresses. Each address is given
+// once per key that was added or removed.
+func (w *Writer) KeyUpdates(ctx context.Context, height uint64, addresses []flow.Address) error {
+	return w.update(ctx, func(i *Index) {
+		for _, address := range addresses {
+			updates, ok := i.keyUpdates[address]
+			if !ok {
+				updates = make(map[uint64]uint)
+				i.keyUpdates[address] = updates
+			}
+			updates[height]++
+		}
+	})
+}
+
+// Contracts indexes the given changes to contracts at the given height.
+func (w *Writer) Contracts(ctx context.Context, _ uint64, versions []dps.ContractVersion) error {
+	return w.update(ctx, func(i *Index) {
+		for _, version := range versions {
+			contracts, ok := i.contracts[version.Address]
+			if !ok {
+				contracts = make(map[string][]dps.ContractVersion)
+				i.contracts[version.Address] = contracts
+			}
+			contracts[version.Name] = append(contracts[version.Name], version)
+		}
+	})
+}
+
+// Commit indexes the given commitment of the execution state as it was after
+// the execution of the finalized block at the given height.
+func (w *Writer) Commit(ctx context.Context, height uint64, commit flow.StateCommitment) error {
+	return w.update(ctx, func(i *Index) {
+		i.commit[height] = commit
+		_, ok := i.commits[commit]
+		if !ok {
+			i.commits[commit] = height
+		}
+	})
+}
+
+// Header indexes the given header of a finalized block at the given height.
+func (w *Writer) Header(ctx context.Context, height uint64, header *flow.Header) error {
+	return w.update(ctx, func(i *Index) {
+		i.header[height] = *header
+	})
+}
+
+// BlockStats indexes the given aggregate statistics of a finalized block.
+func (w *Writer) BlockStats(ctx context.Context, stats dps.BlockStats) error {
+	return w.update(ctx, func(i *Index) {
+		i.blockStats[stats.Height] = stats
+	})
+}
+
+// Events indexes the events, which should represent all events of the finalized
+// block at the given height.
+func (w *Writer) Events(ctx context.Context, height uint64, events []flow.Event) error {
+	return w.update(ctx, func(i *Index) {
+		i.events[height] = append([]flow.Event{}, events...)
+	})
+}
+
+// EventTypes updates the registry of indexed event types with the given
+// statistics.
+func (w *Writer) EventTypes(ctx context.Context, stats []dps.EventTypeStats) error {
+	return w.update(ctx, func(i *Index) {
+		for _, entry := range stats {
+			i.eventTypes[entry.Type] = entry
+		}
+	})
+}
+
+// ServiceEvents indexes the given service events emitted at the given height.
+func (w *Writer) ServiceEvents(ctx context.Context, height uint64, events []flow.Event) error {
+	return w.update(ctx, func(i *Index) {
+		i.serviceEvents[height] = append([]flow.Event{}, events...)
+	})
+}
+
+// Epoch indexes the description and the identity table of the epoch that was
+// set up by the given epoch setup event.
+func (w *Writer) Epoch(ctx context.Context, setup *flow.EpochSetup) error {
+
+	epoch := dps.Epoch{
+		Counter:            setup.Counter,
+		FirstView:          setup.FirstView,
+		DKGPhase1FinalView: setup.DKGPhase1FinalView,
+		DKGPhase2FinalView: setup.DKGPhase2FinalView,
+		DKGPhase3FinalView: setup.DKGPhase3FinalView,
+		FinalView:          setup.FinalView,
+	}
+
+	return w.update(ctx, func(i *Index) {
+		i.epochs[setup.Counter] = epoch
+		i.identities[setup.Counter] = setup.Participants.Copy()
+	})
+}
+
+// Phase indexes the height at which the given phase of the epoch with the
+// given counter started.
+func (w *Writer) Phase(ctx context.Context, counter uint64, phase flow.EpochPhase, height uint64) error {
+	return w.update(ctx, func(i *Index) {
+		phases, ok := i.phases[counter]
+		if !ok {
+			phases = make(map[flow.EpochPhase]uint64)
+			i.phases[counter] = phases
+		}
+		phases[phase] = height
+	})
+}
+
+// Payloads indexes the given payloads, which should represent a trie update
+// of the execution state contained within the finalized block at the given
+// height.
+func (w *Writer) Payloads(ctx context.Context, height uint64, paths []ledger.Path, payloads []*ledger.Payload) error {
+
+	if len(paths) != len(payloads) {
+		return fmt.Errorf("mismatch between paths and payloads counts")
+	}
+
+	return w.update(ctx, func(i *Index) {
+		for j, path := range paths {
+			payload := payloads[j].DeepCopy()
+
+			// Payloads are normally indexed in ascending order of heights, but
+			// we keep the versions sorted regardless, so that reads can always
+			// search them.
+			versions := i.payloads[path]
+			k := sort.Search(len(versions), func(k int) bool {
+				return versions[k].height >= height
+			})
+			if k < len(versions) && versions[k].height == height {
+				versions[k].payload = payload
+			} else {
+				versions = append(versions, version{})
+				copy(versions[k+1:], versions[k:])
+				versions[k] = version{height: height, payload: payload}
+			}
+			i.payloads[path] = versions
+
+			owner, ok := dps.PayloadOwner(payload)
+			if !ok {
+				continue
+			}
+			owned, ok := i.owners[owner]
+			if !ok {
+				owned = make(map[ledger.Path]struct{})
+				i.owners[owner] = owned
+			}
+			owned[path] = struct{}{}
+		}
+	})
+}
+
+// RegisterChurn indexes the given register churn of a finalized block.
+func (w *Writer) RegisterChurn(ctx context.Context, churn dps.RegisterChurn) error {
+	return w.update(ctx, func(i *Index) {
+		i.registerChurn[churn.Height] = churn
+	})
+}
+
+// RegisterDeltas indexes the given changes to the registers made by the
+// finalized block at the given height.
+func (w *Writer) RegisterDeltas(ctx context.Context, height uint64, deltas []dps.RegisterDelta) error {
+	return w.update(ctx, func(i *Index) {
+		_, ok := i.deltas[height]
+		if !ok {
+			i.deltas[height] = make(map[ledger.Path]dps.RegisterDelta)
+		}
+		for _, delta := range deltas {
+			i.deltas[height][delta.Path] = delta
+		}
+	})
+}
+
+// Collections indexes the collections at the given height.
+func (w *Writer) Collections(ctx context.Context, height uint64, collections []*flow.LightCollection) error {
+	return w.update(ctx, func(i *Index) {
+		collIDs := make([]flow.Identifier, 0, len(collections))
+		for _, collection := range collections {
+			collID := collection.ID()
+			collIDs = append(collIDs, collID)
+			i.collection[collID] = *collection
+		}
+		i.collectionsByHeight[height] = collIDs
+	})
+}
+
+// Guarantees indexes the guarantees at the given height.
+func (w *Writer) Guarantees(ctx context.Context, _ uint64, guarantees []*flow.CollectionGuarantee) error {
+	return w.update(ctx, func(i *Index) {
+		for _, guarantee := range guarantees {
+			i.guarantee[guarantee.CollectionID] = *guarantee
+		}
+	})
+}
+
+// Transactions indexes the transactions at the given height.
+func (w *Writer) Transactions(ctx context.Context, height uint64, transactions []*flow.TransactionBody) error {
+	return w.update(ctx, func(i *Index) {
+		txIDs := make([]flow.Identifier, 0, len(transactions))
+		scripts := make(map[flow.Identifier][]flow.Identifier)
+		for _, transaction := range transactions {
+			txID := transaction.ID()
+			txIDs = append(txIDs, txID)
+			i.transaction[txID] = *transaction
+			i.transactions[txID] = height
+			hash := dps.ScriptHash(transaction.Script)
+			scripts[hash] = append(scripts[hash], txID)
+		}
+		i.transactionsByHeight[height] = txIDs
+		for hash, ids := range scripts {
+			heights, ok := i.transactionsForScript[hash]
+			if !ok {
+				heights = make(map[uint64][]flow.Identifier)
+				i.transactionsForScript[hash] = heights
+			}
+			heights[height] = ids
+		}
+	})
+}
+
+// Results indexes the transaction results at the given height.
+func (w *Writer) Results(ctx context.Context, results []*flow.TransactionResult) error {
+	return w.update(ctx, func(i *Index) {
+		for _, result := range results {
+			i.result[result.TransactionID] = *result
+		}
+	})
+}
+
+// Seals indexes the seals, which should represent all seals in the finalized
+// block at the given height.
+func (w *Writer) Seals(ctx context.Context, height uint64, seals []*flow.Seal) error {
+	return w.update(ctx, func(i *Index) {
+		sealIDs := make([]flow.Identifier, 0, len(seals))
+		for _, seal := range seals {
+			sealID := seal.ID()
+			sealIDs = append(sealIDs, sealID)
+			i.seal[sealID] = *seal
+		}
+		i.sealsByHeight[height] = sealIDs
+	})
+}
+
+// Corruption records that corrupted write-ahead log data was skipped while
+// indexing the given height.
+func (w *Writer) Corruption(ctx context.Context, height uint64, reason string) error {
+	return w.update(ctx, func(i *Index) {
+		i.corruptions[height] = append(i.corruptions[height], reason)
+	})
+}
+
+// ExecutionRecord archives the given execution record of the block with the
+// given ID.
+func (w *Writer) ExecutionRecord(ctx context.Context, blockID flow.Identifier, data []byte) error {
+	return w.update(ctx, func(i *Index) {
+		i.records[blockID] = append([]byte{}, data...)
+	})
+}
+
+// Filter records the filter that restricts the indexed data to the data
+// involving some accounts.
+func (w *Writer) Filter(ctx context.Context, filter dps.Filter) error {
+	return w.update(ctx, func(i *Index) {
+		if len(filter.Allowed) > 0 {
+			i.filter.Allowed = append([]flow.Address{}, filter.Allowed...)
+		}
+		if len(filter.Denied) > 0 {
+			i.filter.Denied = append([]flow.Address{}, filter.Denied...)
+		}
+	})
+}
+
+// update applies the given operation to the index while holding its write
+// lock, unless the given context is already done.
+func (w *Writer) update(ctx context.Context, op func(*Index)) error {
+	err := ctx.Err()
+	if err != nil {
+		return err
+	}
+	w.index.mutex.Lock()
+	defer w.index.mutex.Unlock()
+	op(w.index)
+	return nil
+}
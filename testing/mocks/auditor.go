@@ -0,0 +1,40 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mocks
+
+import (
+	"context"
+	"testing"
+)
+
+type Auditor struct {
+	HeightsFunc func(ctx context.Context, from uint64, to uint64) ([]uint64, error)
+}
+
+func BaselineAuditor(t *testing.T) *Auditor {
+	t.Helper()
+
+	a := Auditor{
+		HeightsFunc: func(ctx context.Context, from uint64, to uint64) ([]uint64, error) {
+			return []uint64{GenericHeight}, nil
+		},
+	}
+
+	return &a
+}
+
+func (a *Auditor) Heights(ctx context.Context, from uint64, to uint64) ([]uint64, error) {
+	return a.HeightsFunc(ctx, from, to)
+}
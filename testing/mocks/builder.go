@@ -0,0 +1,52 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mocks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgraph-io/badger/v2"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+type Builder struct {
+	NameFunc  func() string
+	BuildFunc func(ctx context.Context, read dps.Reader, write dps.WriteLibrary, height uint64) ([]func(*badger.Txn) error, error)
+}
+
+func BaselineBuilder(t *testing.T) *Builder {
+	t.Helper()
+
+	b := Builder{
+		NameFunc: func() string {
+			return "test"
+		},
+		BuildFunc: func(ctx context.Context, read dps.Reader, write dps.WriteLibrary, height uint64) ([]func(*badger.Txn) error, error) {
+			return nil, nil
+		},
+	}
+
+	return &b
+}
+
+func (b *Builder) Name() string {
+	return b.NameFunc()
+}
+
+func (b *Builder) Build(ctx context.Context, read dps.Reader, write dps.WriteLibrary, height uint64) ([]func(*badger.Txn) error, error) {
+	return b.BuildFunc(ctx, read, write, height)
+}
@@ -22,6 +22,7 @@ import (
 
 type Chain struct {
 	RootFunc         func() (uint64, error)
+	RootSealFunc     func() (*flow.Seal, error)
 	HeaderFunc       func(height uint64) (*flow.Header, error)
 	CommitFunc       func(height uint64) (flow.StateCommitment, error)
 	CollectionsFunc  func(height uint64) ([]*flow.LightCollection, error)
@@ -30,6 +31,8 @@ type Chain struct {
 	ResultsFunc      func(height uint64) ([]*flow.TransactionResult, error)
 	EventsFunc       func(height uint64) ([]flow.Event, error)
 	SealsFunc        func(height uint64) ([]*flow.Seal, error)
+	EpochStatusFunc  func(height uint64) (*flow.EpochStatus, error)
+	EpochSetupFunc   func(height uint64) (*flow.EpochSetup, error)
 }
 
 func BaselineChain(t *testing.T) *Chain {
@@ -39,6 +42,13 @@ func BaselineChain(t *testing.T) *Chain {
 		RootFunc: func() (uint64, error) {
 			return GenericHeight, nil
 		},
+		RootSealFunc: func() (*flow.Seal, error) {
+			// The root seal has to match the root hash of the generic trie,
+			// which the baseline loader uses as the root checkpoint.
+			seal := *GenericSeal(0)
+			seal.FinalState = flow.StateCommitment(GenericTrie.RootHash())
+			return &seal, nil
+		},
 		HeaderFunc: func(height uint64) (*flow.Header, error) {
 			return GenericHeader, nil
 		},
@@ -63,6 +73,12 @@ func BaselineChain(t *testing.T) *Chain {
 		SealsFunc: func(height uint64) ([]*flow.Seal, error) {
 			return GenericSeals(4), nil
 		},
+		EpochStatusFunc: func(height uint64) (*flow.EpochStatus, error) {
+			return GenericEpochStatus, nil
+		},
+		EpochSetupFunc: func(height uint64) (*flow.EpochSetup, error) {
+			return GenericEpochSetup(1), nil
+		},
 	}
 
 	return &c
@@ -72,6 +88,10 @@ func (c *Chain) Root() (uint64, error) {
 	return c.RootFunc()
 }
 
+func (c *Chain) RootSeal() (*flow.Seal, error) {
+	return c.RootSealFunc()
+}
+
 func (c *Chain) Header(height uint64) (*flow.Header, error) {
 	return c.HeaderFunc(height)
 }
@@ -103,3 +123,11 @@ func (c *Chain) Events(height uint64) ([]flow.Event, error) {
 func (c *Chain) Seals(height uint64) ([]*flow.Seal, error) {
 	return c.SealsFunc(height)
 }
+
+func (c *Chain) EpochStatus(height uint64) (*flow.EpochStatus, error) {
+	return c.EpochStatusFunc(height)
+}
+
+func (c *Chain) EpochSetup(height uint64) (*flow.EpochSetup, error) {
+	return c.EpochSetupFunc(height)
+}
@@ -0,0 +1,50 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mocks
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+type ClientConn struct {
+	InvokeFunc    func(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error
+	NewStreamFunc func(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error)
+}
+
+func BaselineClientConn(t *testing.T) *ClientConn {
+	t.Helper()
+
+	c := ClientConn{
+		InvokeFunc: func(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+			return nil
+		},
+		NewStreamFunc: func(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return nil, nil
+		},
+	}
+
+	return &c
+}
+
+func (c *ClientConn) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	return c.InvokeFunc(ctx, method, args, reply, opts...)
+}
+
+func (c *ClientConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return c.NewStreamFunc(ctx, desc, method, opts...)
+}
@@ -14,15 +14,20 @@
 
 package mocks
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
 
 type Codec struct {
-	EncodeFunc     func(value interface{}) ([]byte, error)
-	DecodeFunc     func(data []byte, value interface{}) error
-	CompressFunc   func(data []byte) ([]byte, error)
-	DecompressFunc func(compressed []byte) ([]byte, error)
-	MarshalFunc    func(value interface{}) ([]byte, error)
-	UnmarshalFunc  func(compressed []byte, value interface{}) error
+	EncodeFunc      func(value interface{}) ([]byte, error)
+	DecodeFunc      func(data []byte, value interface{}) error
+	CompressFunc    func(data []byte) ([]byte, error)
+	DecompressFunc  func(compressed []byte) ([]byte, error)
+	MarshalFunc     func(value interface{}) ([]byte, error)
+	MarshalWithFunc func(value interface{}, compression dps.Compression) ([]byte, error)
+	UnmarshalFunc   func(compressed []byte, value interface{}) error
 }
 
 func BaselineCodec(t *testing.T) *Codec {
@@ -47,6 +52,9 @@ func BaselineCodec(t *testing.T) *Codec {
 		MarshalFunc: func(interface{}) ([]byte, error) {
 			return GenericBytes, nil
 		},
+		MarshalWithFunc: func(interface{}, dps.Compression) ([]byte, error) {
+			return GenericBytes, nil
+		},
 	}
 
 	return &c
@@ -75,3 +83,7 @@ func (c *Codec) Unmarshal(b []byte, v interface{}) error {
 func (c *Codec) Marshal(v interface{}) ([]byte, error) {
 	return c.MarshalFunc(v)
 }
+
+func (c *Codec) MarshalWith(v interface{}, compression dps.Compression) ([]byte, error) {
+	return c.MarshalWithFunc(v, compression)
+}
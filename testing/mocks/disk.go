@@ -0,0 +1,44 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mocks
+
+import (
+	"testing"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+type Disk struct {
+	VolumesFunc func() []dps.VolumeInfo
+}
+
+func BaselineDisk(t *testing.T) *Disk {
+	t.Helper()
+
+	d := Disk{
+		VolumesFunc: func() []dps.VolumeInfo {
+			return []dps.VolumeInfo{
+				{Name: "index", Path: "index", Free: 2000, Threshold: 1000},
+				{Name: "data", Path: "data", Free: 2000, Threshold: 1000},
+			}
+		},
+	}
+
+	return &d
+}
+
+func (d *Disk) Volumes() []dps.VolumeInfo {
+	return d.VolumesFunc()
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mocks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+type EventReader struct {
+	EventsFunc        func(ctx context.Context, height uint64, types ...flow.EventType) ([]flow.Event, error)
+	EventTypesFunc    func(ctx context.Context) ([]dps.EventTypeStats, error)
+	ServiceEventsFunc func(ctx context.Context, start uint64, end uint64, types ...flow.EventType) ([]dps.ServiceEvent, error)
+	EpochsFunc        func(ctx context.Context) ([]dps.Epoch, error)
+	IdentitiesFunc    func(ctx context.Context, counter uint64) (flow.IdentityList, error)
+	RawEventsFunc     func(ctx context.Context, height uint64, types ...flow.EventType) ([][]byte, error)
+}
+
+func BaselineEventReader(t *testing.T) *EventReader {
+	t.Helper()
+
+	e := EventReader{
+		EventsFunc: func(ctx context.Context, height uint64, types ...flow.EventType) ([]flow.Event, error) {
+			return GenericEvents(4, GenericEventTypes(2)...), nil
+		},
+		EventTypesFunc: func(ctx context.Context) ([]dps.EventTypeStats, error) {
+			return GenericEventTypeStats(2), nil
+		},
+		ServiceEventsFunc: func(ctx context.Context, start uint64, end uint64, types ...flow.EventType) ([]dps.ServiceEvent, error) {
+			return GenericServiceEvents(2), nil
+		},
+		EpochsFunc: func(ctx context.Context) ([]dps.Epoch, error) {
+			return GenericEpochs(2), nil
+		},
+		IdentitiesFunc: func(ctx context.Context, counter uint64) (flow.IdentityList, error) {
+			return GenericIdentities(4), nil
+		},
+		RawEventsFunc: func(ctx context.Context, height uint64, types ...flow.EventType) ([][]byte, error) {
+			return [][]byte{GenericBytes, GenericBytes}, nil
+		},
+	}
+
+	return &e
+}
+
+func (e *EventReader) Events(ctx context.Context, height uint64, types ...flow.EventType) ([]flow.Event, error) {
+	return e.EventsFunc(ctx, height, types...)
+}
+
+func (e *EventReader) EventTypes(ctx context.Context) ([]dps.EventTypeStats, error) {
+	return e.EventTypesFunc(ctx)
+}
+
+func (e *EventReader) ServiceEvents(ctx context.Context, start uint64, end uint64, types ...flow.EventType) ([]dps.ServiceEvent, error) {
+	return e.ServiceEventsFunc(ctx, start, end, types...)
+}
+
+func (e *EventReader) Epochs(ctx context.Context) ([]dps.Epoch, error) {
+	return e.EpochsFunc(ctx)
+}
+
+func (e *EventReader) Identities(ctx context.Context, counter uint64) (flow.IdentityList, error) {
+	return e.IdentitiesFunc(ctx, counter)
+}
+
+func (e *EventReader) RawEvents(ctx context.Context, height uint64, types ...flow.EventType) ([][]byte, error) {
+	return e.RawEventsFunc(ctx, height, types...)
+}
@@ -47,6 +47,8 @@ const (
 	offsetBlock      = 0
 	offsetCollection = 1 * 16
 	offsetResult     = 2 * 16
+	offsetNode       = 3 * 16
+	offsetEpoch      = 4 * 16
 )
 
 // Global variables that can be used for testing. They are non-nil valid values for the types commonly needed
@@ -67,6 +69,17 @@ var (
 		Timestamp: time.Date(1972, 11, 12, 13, 14, 15, 16, time.UTC),
 	}
 
+	GenericEpochStatus = &flow.EpochStatus{
+		PreviousEpoch: flow.EventIDs{
+			SetupID:  genericIdentifier(0, offsetEpoch),
+			CommitID: genericIdentifier(1, offsetEpoch),
+		},
+		CurrentEpoch: flow.EventIDs{
+			SetupID:  genericIdentifier(2, offsetEpoch),
+			CommitID: genericIdentifier(3, offsetEpoch),
+		},
+	}
+
 	GenericLedgerKey = ledger.NewKey([]ledger.KeyPart{
 		ledger.NewKeyPart(0, []byte(`owner`)),
 		ledger.NewKeyPart(1, []byte(`controller`)),
@@ -236,6 +249,18 @@ func GenericLedgerPayload(index int) *ledger.Payload {
 	return GenericLedgerPayloads(index + 1)[index]
 }
 
+func GenericRegisters(number int) []dps.Register {
+	paths := GenericLedgerPaths(number)
+	payloads := GenericLedgerPayloads(number)
+
+	var registers []dps.Register
+	for i := 0; i < number; i++ {
+		registers = append(registers, dps.Register{Path: paths[i], Payload: payloads[i]})
+	}
+
+	return registers
+}
+
 func GenericTransactions(number int) []*flow.TransactionBody {
 	var txs []*flow.TransactionBody
 	for i := 0; i < number; i++ {
@@ -279,6 +304,156 @@ func GenericEventType(index int) flow.EventType {
 	return GenericEventTypes(index + 1)[index]
 }
 
+func GenericAccountMetadata(address flow.Address) *dps.Account {
+	return &dps.Account{
+		Address:    address,
+		Created:    GenericHeight,
+		KeyUpdates: []uint64{GenericHeight, GenericHeight + 1},
+	}
+}
+
+func GenericContractVersions(address flow.Address, name string, number int) []dps.ContractVersion {
+	var versions []dps.ContractVersion
+	for i := 0; i < number; i++ {
+		hash := GenericCommit(i)
+		versions = append(versions, dps.ContractVersion{
+			Address:       address,
+			Name:          name,
+			Height:        GenericHeight + uint64(i),
+			TransactionID: GenericTransaction(i).ID(),
+			CodeHash:      hash[:],
+		})
+	}
+
+	return versions
+}
+
+func GenericServiceEvents(number int) []dps.ServiceEvent {
+	var events []dps.ServiceEvent
+	for i, event := range GenericEvents(number) {
+		events = append(events, dps.ServiceEvent{
+			Height: GenericHeight + uint64(i),
+			Event:  event,
+		})
+	}
+
+	return events
+}
+
+func GenericBlockStats(number int) []dps.BlockStats {
+	var stats []dps.BlockStats
+	for i := 0; i < number; i++ {
+		stats = append(stats, dps.BlockStats{
+			Height:       GenericHeight + uint64(i),
+			Transactions: uint64(4 + i),
+			Events:       uint64(8 + i),
+			Fees:         uint64(1000 * (i + 1)),
+			Computation:  uint64(100 * (i + 1)),
+			Senders:      uint64(2 + i),
+		})
+	}
+
+	return stats
+}
+
+func GenericRegisterChurns(number int) []dps.RegisterChurn {
+	var churns []dps.RegisterChurn
+	for i := 0; i < number; i++ {
+		owner := GenericAddress(i)
+		churns = append(churns, dps.RegisterChurn{
+			Height: GenericHeight + uint64(i),
+			Owners: []dps.OwnerWrites{
+				{Owner: owner, Writes: uint64(2 + i)},
+			},
+			Registers: []dps.RegisterWrites{
+				{Owner: owner, Key: "storage_used", Writes: 1},
+				{Owner: owner, Key: fmt.Sprintf("public_key_%d", i), Writes: uint64(1 + i)},
+			},
+		})
+	}
+
+	return churns
+}
+
+func GenericRegisterDeltas(number int) []dps.RegisterDelta {
+	var deltas []dps.RegisterDelta
+	for i := 0; i < number; i++ {
+		deltas = append(deltas, dps.RegisterDelta{
+			Height:   GenericHeight,
+			Path:     GenericLedgerPath(i),
+			Previous: GenericBytes,
+			Current:  GenericBytes,
+		})
+	}
+
+	return deltas
+}
+
+func GenericEpochs(number int) []dps.Epoch {
+	var epochs []dps.Epoch
+	for i := 0; i < number; i++ {
+		first := uint64(i) * 1000
+		epochs = append(epochs, dps.Epoch{
+			Counter:            uint64(i),
+			FirstView:          first,
+			DKGPhase1FinalView: first + 100,
+			DKGPhase2FinalView: first + 200,
+			DKGPhase3FinalView: first + 300,
+			FinalView:          first + 999,
+			StakingHeight:      GenericHeight + first,
+			SetupHeight:        GenericHeight + first + 500,
+			CommittedHeight:    GenericHeight + first + 800,
+		})
+	}
+
+	return epochs
+}
+
+func GenericEpochSetup(counter uint64) *flow.EpochSetup {
+	first := counter * 1000
+	setup := flow.EpochSetup{
+		Counter:            counter,
+		FirstView:          first,
+		DKGPhase1FinalView: first + 100,
+		DKGPhase2FinalView: first + 200,
+		DKGPhase3FinalView: first + 300,
+		FinalView:          first + 999,
+		Participants:       GenericIdentities(4),
+	}
+
+	return &setup
+}
+
+func GenericIdentities(number int) flow.IdentityList {
+	roles := flow.Roles()
+
+	var identities flow.IdentityList
+	for i, nodeID := range genericIdentifiers(number, offsetNode) {
+		identities = append(identities, &flow.Identity{
+			NodeID:  nodeID,
+			Address: fmt.Sprintf("node%d.flow.test:3569", i),
+			Role:    roles[i%len(roles)],
+			Stake:   uint64(1000 * (i + 1)),
+		})
+	}
+
+	return identities
+}
+
+func GenericEventTypeStats(number int) []dps.EventTypeStats {
+	var stats []dps.EventTypeStats
+	for i, typ := range GenericEventTypes(number) {
+		stats = append(stats, dps.EventTypeStats{
+			Type:  typ,
+			First: GenericHeight,
+			Last:  GenericHeight + uint64(i),
+			Count: uint64(i + 1),
+		})
+	}
+
+	return stats
+}
+
 func GenericCadenceEventTypes(number int) []*cadence.EventType {
 	var types []*cadence.EventType
 	for i := 0; i < number; i++ {
@@ -0,0 +1,108 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mocks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+type HeightReader struct {
+	FirstFunc                func(ctx context.Context) (uint64, error)
+	LastFunc                 func(ctx context.Context) (uint64, error)
+	HeightForBlockFunc       func(ctx context.Context, blockID flow.Identifier) (uint64, error)
+	CommitFunc               func(ctx context.Context, height uint64) (flow.StateCommitment, error)
+	HeaderFunc               func(ctx context.Context, height uint64) (*flow.Header, error)
+	BlockStatsFunc           func(ctx context.Context, start uint64, end uint64) ([]dps.BlockStats, error)
+	HeightForTransactionFunc func(ctx context.Context, txID flow.Identifier) (uint64, error)
+	HeightForCommitFunc      func(ctx context.Context, commit flow.StateCommitment) (uint64, error)
+	RawHeaderFunc            func(ctx context.Context, height uint64) ([]byte, error)
+}
+
+func BaselineHeightReader(t *testing.T) *HeightReader {
+	t.Helper()
+
+	h := HeightReader{
+		FirstFunc: func(ctx context.Context) (uint64, error) {
+			return GenericHeight, nil
+		},
+		LastFunc: func(ctx context.Context) (uint64, error) {
+			return GenericHeight, nil
+		},
+		HeightForBlockFunc: func(ctx context.Context, blockID flow.Identifier) (uint64, error) {
+			return GenericHeight, nil
+		},
+		CommitFunc: func(ctx context.Context, height uint64) (flow.StateCommitment, error) {
+			return GenericCommit(0), nil
+		},
+		HeaderFunc: func(ctx context.Context, height uint64) (*flow.Header, error) {
+			return GenericHeader, nil
+		},
+		BlockStatsFunc: func(ctx context.Context, start uint64, end uint64) ([]dps.BlockStats, error) {
+			return GenericBlockStats(2), nil
+		},
+		HeightForTransactionFunc: func(ctx context.Context, blockID flow.Identifier) (uint64, error) {
+			return GenericHeight, nil
+		},
+		HeightForCommitFunc: func(ctx context.Context, commit flow.StateCommitment) (uint64, error) {
+			return GenericHeight, nil
+		},
+		RawHeaderFunc: func(ctx context.Context, height uint64) ([]byte, error) {
+			return GenericBytes, nil
+		},
+	}
+
+	return &h
+}
+
+func (h *HeightReader) First(ctx context.Context) (uint64, error) {
+	return h.FirstFunc(ctx)
+}
+
+func (h *HeightReader) Last(ctx context.Context) (uint64, error) {
+	return h.LastFunc(ctx)
+}
+
+func (h *HeightReader) HeightForBlock(ctx context.Context, blockID flow.Identifier) (uint64, error) {
+	return h.HeightForBlockFunc(ctx, blockID)
+}
+
+func (h *HeightReader) Commit(ctx context.Context, height uint64) (flow.StateCommitment, error) {
+	return h.CommitFunc(ctx, height)
+}
+
+func (h *HeightReader) Header(ctx context.Context, height uint64) (*flow.Header, error) {
+	return h.HeaderFunc(ctx, height)
+}
+
+func (h *HeightReader) BlockStats(ctx context.Context, start uint64, end uint64) ([]dps.BlockStats, error) {
+	return h.BlockStatsFunc(ctx, start, end)
+}
+
+func (h *HeightReader) HeightForTransaction(ctx context.Context, txID flow.Identifier) (uint64, error) {
+	return h.HeightForTransactionFunc(ctx, txID)
+}
+
+func (h *HeightReader) HeightForCommit(ctx context.Context, commit flow.StateCommitment) (uint64, error) {
+	return h.HeightForCommitFunc(ctx, commit)
+}
+
+func (h *HeightReader) RawHeader(ctx context.Context, height uint64) ([]byte, error) {
+	return h.RawHeaderFunc(ctx, height)
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mocks
+
+import (
+	"testing"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+type Recorder struct {
+	RecordFunc func(query dps.Query)
+	StatsFunc  func() dps.QueryStats
+}
+
+func BaselineRecorder(t *testing.T) *Recorder {
+	t.Helper()
+
+	r := Recorder{
+		RecordFunc: func(query dps.Query) {},
+		StatsFunc: func() dps.QueryStats {
+			return dps.QueryStats{}
+		},
+	}
+
+	return &r
+}
+
+func (r *Recorder) Record(query dps.Query) {
+	r.RecordFunc(query)
+}
+
+func (r *Recorder) Stats() dps.QueryStats {
+	return r.StatsFunc()
+}
@@ -0,0 +1,42 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mocks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+type Records struct {
+	ExecutionRecordFunc func(ctx context.Context, blockID flow.Identifier) ([]byte, error)
+}
+
+func BaselineRecords(t *testing.T) *Records {
+	t.Helper()
+
+	r := Records{
+		ExecutionRecordFunc: func(ctx context.Context, blockID flow.Identifier) ([]byte, error) {
+			return GenericBytes, nil
+		},
+	}
+
+	return &r
+}
+
+func (r *Records) ExecutionRecord(ctx context.Context, blockID flow.Identifier) ([]byte, error) {
+	return r.ExecutionRecordFunc(ctx, blockID)
+}
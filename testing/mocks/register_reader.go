@@ -0,0 +1,102 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mocks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onflow/flow-go/ledger"
+	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+type RegisterReader struct {
+	ValuesFunc          func(ctx context.Context, height uint64, paths []ledger.Path) ([]ledger.Value, error)
+	RegistersFunc       func(ctx context.Context, height uint64, owner flow.Address, after ledger.Path, limit uint) ([]dps.Register, error)
+	DumpRegistersFunc   func(ctx context.Context, height uint64, after ledger.Path, limit uint) ([]dps.Register, error)
+	RegisterChurnFunc   func(ctx context.Context, start uint64, end uint64, limit uint) (*dps.ChurnReport, error)
+	RegisterDeltasFunc  func(ctx context.Context, height uint64, after ledger.Path, limit uint) ([]dps.RegisterDelta, error)
+	AccountFunc         func(ctx context.Context, address flow.Address) (*dps.Account, error)
+	ContractHistoryFunc func(ctx context.Context, address flow.Address, name string) ([]dps.ContractVersion, error)
+	FilterFunc          func(ctx context.Context) (dps.Filter, error)
+}
+
+func BaselineRegisterReader(t *testing.T) *RegisterReader {
+	t.Helper()
+
+	r := RegisterReader{
+		ValuesFunc: func(ctx context.Context, height uint64, paths []ledger.Path) ([]ledger.Value, error) {
+			return GenericLedgerValues(6), nil
+		},
+		RegistersFunc: func(ctx context.Context, height uint64, owner flow.Address, after ledger.Path, limit uint) ([]dps.Register, error) {
+			return GenericRegisters(4), nil
+		},
+		DumpRegistersFunc: func(ctx context.Context, height uint64, after ledger.Path, limit uint) ([]dps.Register, error) {
+			return GenericRegisters(4), nil
+		},
+		RegisterChurnFunc: func(ctx context.Context, start uint64, end uint64, limit uint) (*dps.ChurnReport, error) {
+			report := dps.MergeChurn(start, end, GenericRegisterChurns(2), limit)
+			return &report, nil
+		},
+		RegisterDeltasFunc: func(ctx context.Context, height uint64, after ledger.Path, limit uint) ([]dps.RegisterDelta, error) {
+			return GenericRegisterDeltas(4), nil
+		},
+		AccountFunc: func(ctx context.Context, address flow.Address) (*dps.Account, error) {
+			return GenericAccountMetadata(address), nil
+		},
+		ContractHistoryFunc: func(ctx context.Context, address flow.Address, name string) ([]dps.ContractVersion, error) {
+			return GenericContractVersions(address, name, 2), nil
+		},
+		FilterFunc: func(ctx context.Context) (dps.Filter, error) {
+			return dps.Filter{}, nil
+		},
+	}
+
+	return &r
+}
+
+func (r *RegisterReader) Values(ctx context.Context, height uint64, paths []ledger.Path) ([]ledger.Value, error) {
+	return r.ValuesFunc(ctx, height, paths)
+}
+
+func (r *RegisterReader) Registers(ctx context.Context, height uint64, owner flow.Address, after ledger.Path, limit uint) ([]dps.Register, error) {
+	return r.RegistersFunc(ctx, height, owner, after, limit)
+}
+
+func (r *RegisterReader) DumpRegisters(ctx context.Context, height uint64, after ledger.Path, limit uint) ([]dps.Register, error) {
+	return r.DumpRegistersFunc(ctx, height, after, limit)
+}
+
+func (r *RegisterReader) RegisterChurn(ctx context.Context, start uint64, end uint64, limit uint) (*dps.ChurnReport, error) {
+	return r.RegisterChurnFunc(ctx, start, end, limit)
+}
+
+func (r *RegisterReader) RegisterDeltas(ctx context.Context, height uint64, after ledger.Path, limit uint) ([]dps.RegisterDelta, error) {
+	return r.RegisterDeltasFunc(ctx, height, after, limit)
+}
+
+func (r *RegisterReader) Account(ctx context.Context, address flow.Address) (*dps.Account, error) {
+	return r.AccountFunc(ctx, address)
+}
+
+func (r *RegisterReader) ContractHistory(ctx context.Context, address flow.Address, name string) ([]dps.ContractVersion, error) {
+	return r.ContractHistoryFunc(ctx, address, name)
+}
+
+func (r *RegisterReader) Filter(ctx context.Context) (dps.Filter, error) {
+	return r.FilterFunc(ctx)
+}
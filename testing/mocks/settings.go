@@ -0,0 +1,53 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mocks
+
+import (
+	"testing"
+
+	"github.com/optakt/flow-dps/models/dps"
+)
+
+type Settings struct {
+	SettingsFunc func() dps.Settings
+	UpdateFunc   func(settings dps.Settings) error
+}
+
+func BaselineSettings(t *testing.T) *Settings {
+	t.Helper()
+
+	s := Settings{
+		SettingsFunc: func() dps.Settings {
+			return dps.Settings{
+				Level:      "info",
+				QueryLimit: "0s",
+				QuerySlow:  "0s",
+			}
+		},
+		UpdateFunc: func(dps.Settings) error {
+			return nil
+		},
+	}
+
+	return &s
+}
+
+func (s *Settings) Settings() dps.Settings {
+	return s.SettingsFunc()
+}
+
+func (s *Settings) Update(settings dps.Settings) error {
+	return s.UpdateFunc(settings)
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mocks
+
+import (
+	"testing"
+)
+
+type Store struct {
+	UploadFunc   func(name string, data []byte) error
+	DownloadFunc func(name string, offset uint64, length uint64) ([]byte, error)
+}
+
+func BaselineStore(t *testing.T) *Store {
+	t.Helper()
+
+	s := Store{
+		UploadFunc: func(string, []byte) error {
+			return nil
+		},
+		DownloadFunc: func(string, uint64, uint64) ([]byte, error) {
+			return GenericBytes, nil
+		},
+	}
+
+	return &s
+}
+
+func (s *Store) Upload(name string, data []byte) error {
+	return s.UploadFunc(name, data)
+}
+
+func (s *Store) Download(name string, offset uint64, length uint64) ([]byte, error) {
+	return s.DownloadFunc(name, offset, length)
+}
@@ -0,0 +1,46 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mocks
+
+import (
+	"context"
+	"testing"
+
+	p2p "github.com/libp2p/go-libp2p-pubsub"
+	pb "github.com/libp2p/go-libp2p-pubsub/pb"
+)
+
+type Subscription struct {
+	NextFunc func(ctx context.Context) (*p2p.Message, error)
+}
+
+func BaselineSubscription(t *testing.T) *Subscription {
+	t.Helper()
+
+	s := Subscription{
+		NextFunc: func(ctx context.Context) (*p2p.Message, error) {
+			msg := p2p.Message{
+				Message: &pb.Message{Data: GenericBytes},
+			}
+			return &msg, nil
+		},
+	}
+
+	return &s
+}
+
+func (s *Subscription) Next(ctx context.Context) (*p2p.Message, error) {
+	return s.NextFunc(ctx)
+}
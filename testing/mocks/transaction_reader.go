@@ -0,0 +1,146 @@
+// Copyright 2021 Optakt Labs OÜ
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy of
+// the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations under
+// the License.
+
+package mocks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onflow/flow-go/model/flow"
+)
+
+type TransactionReader struct {
+	CollectionFunc            func(ctx context.Context, collID flow.Identifier) (*flow.LightCollection, error)
+	CollectionsByHeightFunc   func(ctx context.Context, height uint64) ([]flow.Identifier, error)
+	GuaranteeFunc             func(ctx context.Context, collID flow.Identifier) (*flow.CollectionGuarantee, error)
+	TransactionFunc           func(ctx context.Context, txID flow.Identifier) (*flow.TransactionBody, error)
+	TransactionsByHeightFunc  func(ctx context.Context, height uint64) ([]flow.Identifier, error)
+	TransactionsForScriptFunc func(ctx context.Context, hash flow.Identifier, start uint64, end uint64) ([]flow.Identifier, error)
+	ResultFunc                func(ctx context.Context, txID flow.Identifier) (*flow.TransactionResult, error)
+	SealFunc                  func(ctx context.Context, sealID flow.Identifier) (*flow.Seal, error)
+	SealsByHeightFunc         func(ctx context.Context, height uint64) ([]flow.Identifier, error)
+	RawCollectionFunc         func(ctx context.Context, collID flow.Identifier) ([]byte, error)
+	RawGuaranteeFunc          func(ctx context.Context, collID flow.Identifier) ([]byte, error)
+	RawTransactionFunc        func(ctx context.Context, txID flow.Identifier) ([]byte, error)
+	RawSealFunc               func(ctx context.Context, sealID flow.Identifier) ([]byte, error)
+	RawResultFunc             func(ctx context.Context, txID flow.Identifier) ([]byte, error)
+}
+
+func BaselineTransactionReader(t *testing.T) *TransactionReader {
+	t.Helper()
+
+	tr := TransactionReader{
+		CollectionFunc: func(ctx context.Context, collID flow.Identifier) (*flow.LightCollection, error) {
+			return GenericCollection(0), nil
+		},
+		CollectionsByHeightFunc: func(ctx context.Context, height uint64) ([]flow.Identifier, error) {
+			return GenericCollectionIDs(5), nil
+		},
+		GuaranteeFunc: func(ctx context.Context, collID flow.Identifier) (*flow.CollectionGuarantee, error) {
+			return GenericGuarantee(0), nil
+		},
+		TransactionFunc: func(ctx context.Context, txID flow.Identifier) (*flow.TransactionBody, error) {
+			return GenericTransaction(0), nil
+		},
+		TransactionsByHeightFunc: func(ctx context.Context, height uint64) ([]flow.Identifier, error) {
+			return GenericTransactionIDs(5), nil
+		},
+		TransactionsForScriptFunc: func(ctx context.Context, hash flow.Identifier, start uint64, end uint64) ([]flow.Identifier, error) {
+			return GenericTransactionIDs(5), nil
+		},
+		ResultFunc: func(ctx context.Context, txID flow.Identifier) (*flow.TransactionResult, error) {
+			return GenericResult(0), nil
+		},
+		SealFunc: func(ctx context.Context, sealID flow.Identifier) (*flow.Seal, error) {
+			return GenericSeal(0), nil
+		},
+		SealsByHeightFunc: func(ctx context.Context, height uint64) ([]flow.Identifier, error) {
+			return GenericSealIDs(5), nil
+		},
+		RawCollectionFunc: func(ctx context.Context, collID flow.Identifier) ([]byte, error) {
+			return GenericBytes, nil
+		},
+		RawGuaranteeFunc: func(ctx context.Context, collID flow.Identifier) ([]byte, error) {
+			return GenericBytes, nil
+		},
+		RawTransactionFunc: func(ctx context.Context, txID flow.Identifier) ([]byte, error) {
+			return GenericBytes, nil
+		},
+		RawSealFunc: func(ctx context.Context, sealID flow.Identifier) ([]byte, error) {
+			return GenericBytes, nil
+		},
+		RawResultFunc: func(ctx context.Context, txID flow.Identifier) ([]byte, error) {
+			return GenericBytes, nil
+		},
+	}
+
+	return &tr
+}
+
+func (tr *TransactionReader) Collection(ctx context.Context, collID flow.Identifier) (*flow.LightCollection, error) {
+	return tr.CollectionFunc(ctx, collID)
+}
+
+func (tr *TransactionReader) CollectionsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error) {
+	return tr.CollectionsByHeightFunc(ctx, height)
+}
+
+func (tr *TransactionReader) Guarantee(ctx context.Context, collID flow.Identifier) (*flow.CollectionGuarantee, error) {
+	return tr.GuaranteeFunc(ctx, collID)
+}
+
+func (tr *TransactionReader) Transaction(ctx context.Context, txID flow.Identifier) (*flow.TransactionBody, error) {
+	return tr.TransactionFunc(ctx, txID)
+}
+
+func (tr *TransactionReader) TransactionsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error) {
+	return tr.TransactionsByHeightFunc(ctx, height)
+}
+
+func (tr *TransactionReader) TransactionsForScript(ctx context.Context, hash flow.Identifier, start uint64, end uint64) ([]flow.Identifier, error) {
+	return tr.TransactionsForScriptFunc(ctx, hash, start, end)
+}
+
+func (tr *TransactionReader) Result(ctx context.Context, txID flow.Identifier) (*flow.TransactionResult, error) {
+	return tr.ResultFunc(ctx, txID)
+}
+
+func (tr *TransactionReader) Seal(ctx context.Context, sealID flow.Identifier) (*flow.Seal, error) {
+	return tr.SealFunc(ctx, sealID)
+}
+
+func (tr *TransactionReader) SealsByHeight(ctx context.Context, height uint64) ([]flow.Identifier, error) {
+	return tr.SealsByHeightFunc(ctx, height)
+}
+
+func (tr *TransactionReader) RawCollection(ctx context.Context, collID flow.Identifier) ([]byte, error) {
+	return tr.RawCollectionFunc(ctx, collID)
+}
+
+func (tr *TransactionReader) RawGuarantee(ctx context.Context, collID flow.Identifier) ([]byte, error) {
+	return tr.RawGuaranteeFunc(ctx, collID)
+}
+
+func (tr *TransactionReader) RawTransaction(ctx context.Context, txID flow.Identifier) ([]byte, error) {
+	return tr.RawTransactionFunc(ctx, txID)
+}
+
+func (tr *TransactionReader) RawSeal(ctx context.Context, sealID flow.Identifier) ([]byte, error) {
+	return tr.RawSealFunc(ctx, sealID)
+}
+
+func (tr *TransactionReader) RawResult(ctx context.Context, txID flow.Identifier) ([]byte, error) {
+	return tr.RawResultFunc(ctx, txID)
+}
@@ -15,66 +15,48 @@
 package mocks
 
 import (
+	"context"
 	"testing"
 
-	"github.com/onflow/flow-go/ledger"
 	"github.com/onflow/flow-go/model/flow"
+
+	"github.com/optakt/flow-dps/models/dps"
 )
 
 type Writer struct {
-	FirstFunc        func(height uint64) error
-	LastFunc         func(height uint64) error
-	HeaderFunc       func(height uint64, header *flow.Header) error
-	CommitFunc       func(height uint64, commit flow.StateCommitment) error
-	PayloadsFunc     func(height uint64, paths []ledger.Path, value []*ledger.Payload) error
-	HeightFunc       func(blockID flow.Identifier, height uint64) error
-	CollectionsFunc  func(height uint64, collections []*flow.LightCollection) error
-	GuaranteesFunc   func(height uint64, guarantees []*flow.CollectionGuarantee) error
-	TransactionsFunc func(height uint64, transactions []*flow.TransactionBody) error
-	ResultsFunc      func(results []*flow.TransactionResult) error
-	EventsFunc       func(height uint64, events []flow.Event) error
-	SealsFunc        func(height uint64, seals []*flow.Seal) error
-	CloseFunc        func() error
+	WriteHeightBatchFunc func(ctx context.Context, data dps.HeightData) error
+	FirstFunc            func(ctx context.Context, height uint64) error
+	EpochFunc            func(ctx context.Context, setup *flow.EpochSetup) error
+	PhaseFunc            func(ctx context.Context, counter uint64, phase flow.EpochPhase, height uint64) error
+	CorruptionFunc       func(ctx context.Context, height uint64, reason string) error
+	ExecutionRecordFunc  func(ctx context.Context, blockID flow.Identifier, data []byte) error
+	FilterFunc           func(ctx context.Context, filter dps.Filter) error
+	CloseFunc            func() error
 }
 
 func BaselineWriter(t *testing.T) *Writer {
 	t.Helper()
 
 	w := Writer{
-		FirstFunc: func(height uint64) error {
-			return nil
-		},
-		LastFunc: func(height uint64) error {
-			return nil
-		},
-		HeaderFunc: func(height uint64, header *flow.Header) error {
-			return nil
-		},
-		CommitFunc: func(height uint64, commit flow.StateCommitment) error {
-			return nil
-		},
-		PayloadsFunc: func(height uint64, paths []ledger.Path, value []*ledger.Payload) error {
-			return nil
-		},
-		HeightFunc: func(blockID flow.Identifier, height uint64) error {
+		WriteHeightBatchFunc: func(ctx context.Context, data dps.HeightData) error {
 			return nil
 		},
-		CollectionsFunc: func(height uint64, collections []*flow.LightCollection) error {
+		FirstFunc: func(ctx context.Context, height uint64) error {
 			return nil
 		},
-		GuaranteesFunc: func(height uint64, guarantees []*flow.CollectionGuarantee) error {
+		EpochFunc: func(ctx context.Context, setup *flow.EpochSetup) error {
 			return nil
 		},
-		TransactionsFunc: func(height uint64, transactions []*flow.TransactionBody) error {
+		PhaseFunc: func(ctx context.Context, counter uint64, phase flow.EpochPhase, height uint64) error {
 			return nil
 		},
-		ResultsFunc: func(results []*flow.TransactionResult) error {
+		CorruptionFunc: func(ctx context.Context, height uint64, reason string) error {
 			return nil
 		},
-		EventsFunc: func(height uint64, events []flow.Event) error {
+		ExecutionRecordFunc: func(ctx context.Context, blockID flow.Identifier, data []byte) error {
 			return nil
 		},
-		SealsFunc: func(height uint64, seals []*flow.Seal) error {
+		FilterFunc: func(ctx context.Context, filter dps.Filter) error {
 			return nil
 		},
 		CloseFunc: func() error {
@@ -85,52 +67,32 @@ func BaselineWriter(t *testing.T) *Writer {
 	return &w
 }
 
-func (w *Writer) First(height uint64) error {
-	return w.FirstFunc(height)
-}
-
-func (w *Writer) Last(height uint64) error {
-	return w.LastFunc(height)
-}
-
-func (w *Writer) Header(height uint64, header *flow.Header) error {
-	return w.HeaderFunc(height, header)
-}
-
-func (w *Writer) Commit(height uint64, commit flow.StateCommitment) error {
-	return w.CommitFunc(height, commit)
-}
-
-func (w *Writer) Payloads(height uint64, paths []ledger.Path, values []*ledger.Payload) error {
-	return w.PayloadsFunc(height, paths, values)
-}
-
-func (w *Writer) Height(blockID flow.Identifier, height uint64) error {
-	return w.HeightFunc(blockID, height)
+func (w *Writer) WriteHeightBatch(ctx context.Context, data dps.HeightData) error {
+	return w.WriteHeightBatchFunc(ctx, data)
 }
 
-func (w *Writer) Collections(height uint64, collections []*flow.LightCollection) error {
-	return w.CollectionsFunc(height, collections)
+func (w *Writer) First(ctx context.Context, height uint64) error {
+	return w.FirstFunc(ctx, height)
 }
 
-func (w *Writer) Guarantees(height uint64, guarantees []*flow.CollectionGuarantee) error {
-	return w.GuaranteesFunc(height, guarantees)
+func (w *Writer) Epoch(ctx context.Context, setup *flow.EpochSetup) error {
+	return w.EpochFunc(ctx, setup)
 }
 
-func (w *Writer) Transactions(height uint64, transactions []*flow.TransactionBody) error {
-	return w.TransactionsFunc(height, transactions)
+func (w *Writer) Phase(ctx context.Context, counter uint64, phase flow.EpochPhase, height uint64) error {
+	return w.PhaseFunc(ctx, counter, phase, height)
 }
 
-func (w *Writer) Results(results []*flow.TransactionResult) error {
-	return w.ResultsFunc(results)
+func (w *Writer) Corruption(ctx context.Context, height uint64, reason string) error {
+	return w.CorruptionFunc(ctx, height, reason)
 }
 
-func (w *Writer) Events(height uint64, events []flow.Event) error {
-	return w.EventsFunc(height, events)
+func (w *Writer) ExecutionRecord(ctx context.Context, blockID flow.Identifier, data []byte) error {
+	return w.ExecutionRecordFunc(ctx, blockID, data)
 }
 
-func (w *Writer) Seals(height uint64, seals []*flow.Seal) error {
-	return w.SealsFunc(height, seals)
+func (w *Writer) Filter(ctx context.Context, filter dps.Filter) error {
+	return w.FilterFunc(ctx, filter)
 }
 
 func (w *Writer) Close() error {